@@ -18,6 +18,7 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/asteris-llc/converge/backup"
 	"github.com/asteris-llc/converge/executor"
 	"github.com/asteris-llc/converge/graph"
 	"github.com/asteris-llc/converge/graph/node"
@@ -38,10 +39,11 @@ func Apply(ctx context.Context, in *graph.Graph) (*graph.Graph, error) {
 	if err != nil {
 		return nil, err
 	}
+	run := newRunState(ctx, in)
 	pipeline := func(g *graph.Graph, id string) executor.Pipeline {
-		return Pipeline(g, id, renderingPlant)
+		return streamingPipeline(g, id, renderingPlant, nil, run)
 	}
-	return execPipeline(ctx, in, pipeline, renderingPlant, nil)
+	return execPipeline(ctx, in, pipeline, renderingPlant, nil, run)
 }
 
 // PlanAndApply plans and applies each node
@@ -55,14 +57,34 @@ func WithNotify(ctx context.Context, in *graph.Graph, notify *graph.Notifier) (*
 	if err != nil {
 		return nil, err
 	}
+	renderingPlant.Record = render.SnapshotFrom(ctx)
+	run := newRunState(ctx, in)
 	pipeline := func(g *graph.Graph, id string) executor.Pipeline {
-		return plan.Pipeline(g, id, renderingPlant).Connect(Pipeline(g, id, renderingPlant))
+		meta, _ := g.Get(id)
+		output := notify.OutputFor(meta)
+		return plan.Pipeline(g, id, renderingPlant).Connect(streamingPipeline(g, id, renderingPlant, output, run))
 	}
-	return execPipeline(ctx, in, pipeline, renderingPlant, notify)
+	return execPipeline(ctx, in, pipeline, renderingPlant, notify, run)
 }
 
-// Apply the actions in a Graph of resource.Tasks
-func execPipeline(ctx context.Context, in *graph.Graph, pipelineF MkPipelineF, renderingPlant *render.Factory, notify *graph.Notifier) (*graph.Graph, error) {
+// newRunState starts the state of a run, backing up managed files into the
+// store carried by the context, if any, running the hooks in the graph and
+// applying its canaries first
+func newRunState(ctx context.Context, in *graph.Graph) *runState {
+	return &runState{
+		ctx:      ctx,
+		rollback: new(rollback),
+		backups:  backup.StoreFrom(ctx),
+		hooks:    newHookRunner(in),
+		canaries: newCanaries(in),
+	}
+}
+
+// Apply the actions in a Graph of resource.Tasks. If a node with `on_failure =
+// "rollback"` fails, the nodes applied before it are rolled back once the walk
+// is done and a *RollbackError is returned. After hooks run last, even if the
+// walk failed.
+func execPipeline(ctx context.Context, in *graph.Graph, pipelineF MkPipelineF, renderingPlant *render.Factory, notify *graph.Notifier, run *runState) (*graph.Graph, error) {
 	var hasErrors error
 
 	out, err := in.Transform(ctx,
@@ -74,6 +96,7 @@ func execPipeline(ctx context.Context, in *graph.Graph, pipelineF MkPipelineF, r
 
 			if pipelineError != nil {
 				hasErrors = ErrTreeContainsErrors
+				run.canaries.abort(meta.ID)
 				return pipelineError
 			}
 			asResult, ok := val.(*Result)
@@ -84,6 +107,8 @@ func execPipeline(ctx context.Context, in *graph.Graph, pipelineF MkPipelineF, r
 			if nil != asResult.Error() {
 				hasErrors = ErrTreeContainsErrors
 			}
+			run.rollback.record(meta.ID, asResult)
+			run.canaries.record(meta.ID, asResult.Error())
 
 			out.Add(meta.WithValue(asResult))
 			return nil
@@ -91,8 +116,19 @@ func execPipeline(ctx context.Context, in *graph.Graph, pipelineF MkPipelineF, r
 	)
 
 	if err != nil {
+		run.hooks.after()
 		return out, err
 	}
 
+	rollbackErr := run.rollback.run(ctx)
+
+	if err := run.hooks.after(); err != nil && rollbackErr == nil {
+		return out, errors.Wrap(err, "after the apply")
+	}
+
+	if rollbackErr != nil {
+		return out, rollbackErr
+	}
+
 	return out, hasErrors
 }
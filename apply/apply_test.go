@@ -16,6 +16,7 @@ package apply_test
 
 import (
 	"context"
+	"errors"
 	"testing"
 
 	"github.com/asteris-llc/converge/apply"
@@ -125,6 +126,241 @@ func TestApplyNilError(t *testing.T) {
 	assert.NotNil(t, out)
 }
 
+func TestApplyRollback(t *testing.T) {
+	defer logging.HideLogs(t)()
+
+	willChange := func(task resource.Task) *plan.Result {
+		return &plan.Result{Status: &resource.Status{Level: resource.StatusWillChange}, Task: task}
+	}
+
+	first := &rollbackTask{}
+	second := &rollbackTask{}
+
+	g := graph.New()
+	g.Add(node.New("root", willChange(faketask.NoOp())))
+	g.Add(node.New("root/first", willChange(first)))
+	g.Add(node.New("root/second", willChange(second)))
+	g.Add(node.New("root/unsupported", willChange(faketask.Swapper())))
+	g.Add(node.New("root/failing", willChange(&failingTask{onFailure: resource.OnFailureRollback})))
+
+	for _, id := range []string{"root/first", "root/second", "root/unsupported", "root/failing"} {
+		g.ConnectParent("root", id)
+	}
+	g.Connect("root/second", "root/first")
+	g.Connect("root/unsupported", "root/second")
+	g.Connect("root/failing", "root/unsupported")
+
+	require.NoError(t, g.Validate())
+
+	_, err := apply.Apply(context.Background(), g)
+	require.IsType(t, &apply.RollbackError{}, err)
+
+	rollback := err.(*apply.RollbackError)
+	assert.Equal(t, "root/failing", rollback.Failed)
+	assert.Equal(t, []string{"root/unsupported", "root/second", "root/first"}, rollback.Applied)
+	assert.Equal(t, []string{"root/second", "root/first"}, rollback.RolledBack)
+	assert.Equal(t, []string{"root/unsupported"}, rollback.Unsupported)
+	assert.True(t, rollback.Partial())
+	assert.Equal(
+		t,
+		"root/failing failed, rolled back 2 of 3 applied resources (partial rollback)\n"+
+			"rolled back: root/second\n"+
+			"rolled back: root/first\n"+
+			"can't be rolled back: root/unsupported",
+		err.Error(),
+	)
+
+	assert.Equal(t, 1, first.rollbacks)
+	assert.Equal(t, 1, second.rollbacks)
+}
+
+func TestApplyNoRollback(t *testing.T) {
+	defer logging.HideLogs(t)()
+
+	applied := &rollbackTask{}
+
+	g := graph.New()
+	g.Add(node.New("root", &plan.Result{Status: &resource.Status{Level: resource.StatusWillChange}, Task: faketask.NoOp()}))
+	g.Add(node.New("root/applied", &plan.Result{Status: &resource.Status{Level: resource.StatusWillChange}, Task: applied}))
+	g.Add(node.New("root/failing", &plan.Result{Status: &resource.Status{Level: resource.StatusWillChange}, Task: &failingTask{}}))
+
+	g.ConnectParent("root", "root/applied")
+	g.ConnectParent("root", "root/failing")
+	g.Connect("root/failing", "root/applied")
+
+	require.NoError(t, g.Validate())
+
+	// without on_failure, failures leave applied resources in place
+	_, err := apply.Apply(context.Background(), g)
+	assert.Equal(t, apply.ErrTreeContainsErrors, err)
+	assert.Equal(t, 0, applied.rollbacks)
+}
+
+func TestApplyHooks(t *testing.T) {
+	defer logging.HideLogs(t)()
+
+	t.Run("changes", func(t *testing.T) {
+		var calls []string
+		g := graph.New()
+		g.Add(node.New("root", &plan.Result{Status: &resource.Status{Level: resource.StatusWillChange}, Task: faketask.NoOp()}))
+		g.Add(node.New("root/hooks.outer", &plan.Result{Status: &resource.Status{}, Task: &hookTask{name: "outer", calls: &calls}}))
+		g.Add(node.New("root/module.inner", &plan.Result{Status: &resource.Status{Level: resource.StatusWillChange}, Task: faketask.NoOp()}))
+		g.Add(node.New("root/module.inner/hooks.inner", &plan.Result{Status: &resource.Status{}, Task: &hookTask{name: "inner", calls: &calls}}))
+		g.Add(node.New("root/module.inner/a", &plan.Result{Status: &resource.Status{Level: resource.StatusWillChange}, Task: faketask.NoOp()}))
+		g.Add(node.New("root/module.inner/b", &plan.Result{Status: &resource.Status{Level: resource.StatusWillChange}, Task: faketask.NoOp()}))
+
+		g.ConnectParent("root", "root/hooks.outer")
+		g.ConnectParent("root", "root/module.inner")
+		g.ConnectParent("root/module.inner", "root/module.inner/hooks.inner")
+		g.ConnectParent("root/module.inner", "root/module.inner/a")
+		g.ConnectParent("root/module.inner", "root/module.inner/b")
+		g.Connect("root/module.inner/b", "root/module.inner/a")
+
+		require.NoError(t, g.Validate())
+
+		_, err := apply.Apply(context.Background(), g)
+		require.NoError(t, err)
+
+		// hooks run once per module, outermost first before and innermost
+		// first after
+		assert.Equal(t, []string{"outer before", "inner before", "inner after", "outer after"}, calls)
+	})
+
+	t.Run("no changes", func(t *testing.T) {
+		var calls []string
+		g := graph.New()
+		g.Add(node.New("root", &plan.Result{Status: &resource.Status{}, Task: faketask.NoOp()}))
+		g.Add(node.New("root/hooks.outer", &plan.Result{Status: &resource.Status{}, Task: &hookTask{name: "outer", calls: &calls}}))
+		g.ConnectParent("root", "root/hooks.outer")
+
+		require.NoError(t, g.Validate())
+
+		_, err := apply.Apply(context.Background(), g)
+		require.NoError(t, err)
+		assert.Empty(t, calls)
+	})
+
+	t.Run("before fails", func(t *testing.T) {
+		var calls []string
+		g := graph.New()
+		g.Add(node.New("root", &plan.Result{Status: &resource.Status{Level: resource.StatusWillChange}, Task: faketask.NoOp()}))
+		g.Add(node.New("root/hooks.outer", &plan.Result{Status: &resource.Status{}, Task: &hookTask{name: "outer", calls: &calls, err: errors.New("maintenance mode")}}))
+		g.Add(node.New("root/a", &plan.Result{Status: &resource.Status{Level: resource.StatusWillChange}, Task: faketask.NoOp()}))
+		g.ConnectParent("root", "root/hooks.outer")
+		g.ConnectParent("root", "root/a")
+
+		require.NoError(t, g.Validate())
+
+		out, err := apply.Apply(context.Background(), g)
+		assert.Equal(t, apply.ErrTreeContainsErrors, err)
+
+		result := getResult(t, out, "root/a")
+		assert.False(t, result.Ran)
+		assert.EqualError(t, result.Error(), "not applied: maintenance mode")
+
+		// after hooks run even if before hooks fail, so they can clean up
+		assert.Equal(t, []string{"outer before", "outer after"}, calls)
+	})
+}
+
+func TestApplyCanary(t *testing.T) {
+	defer logging.HideLogs(t)()
+
+	build := func(canary resource.Task) *graph.Graph {
+		g := graph.New()
+		g.Add(node.New("root", &plan.Result{Status: &resource.Status{Level: resource.StatusWillChange}, Task: faketask.NoOp()}))
+		g.Add(node.New("root/dep", &plan.Result{Status: &resource.Status{Level: resource.StatusWillChange}, Task: faketask.Swapper()}))
+		g.Add(node.New("root/canary", &plan.Result{Status: &resource.Status{Level: resource.StatusWillChange}, Task: &resource.CanaryTask{Wrapped: resource.Wrapped{Task: canary}}}))
+		g.Add(node.New("root/rest", &plan.Result{Status: &resource.Status{Level: resource.StatusWillChange}, Task: faketask.Swapper()}))
+
+		g.ConnectParent("root", "root/dep")
+		g.ConnectParent("root", "root/canary")
+		g.ConnectParent("root", "root/rest")
+		g.Connect("root/canary", "root/dep")
+
+		return g
+	}
+
+	t.Run("passes", func(t *testing.T) {
+		g := build(faketask.Swapper())
+		require.NoError(t, g.Validate())
+
+		out, err := apply.Apply(context.Background(), g)
+		require.NoError(t, err)
+
+		for _, id := range []string{"root/dep", "root/canary", "root/rest"} {
+			assert.True(t, getResult(t, out, id).Ran, id)
+		}
+	})
+
+	t.Run("fails", func(t *testing.T) {
+		g := build(faketask.Error())
+		require.NoError(t, g.Validate())
+
+		out, err := apply.Apply(context.Background(), g)
+		assert.Equal(t, apply.ErrTreeContainsErrors, err)
+
+		// the canary's dependency is applied before it, but nothing else is
+		assert.True(t, getResult(t, out, "root/dep").Ran)
+		assert.True(t, getResult(t, out, "root/canary").Ran)
+
+		rest := getResult(t, out, "root/rest")
+		assert.False(t, rest.Ran)
+		assert.EqualError(t, rest.Error(), "not applied: canary root/canary failed")
+	})
+}
+
+type rollbackTask struct {
+	rollbacks int
+}
+
+func (rt *rollbackTask) Check(resource.Renderer) (resource.TaskStatus, error) {
+	return &resource.Status{}, nil
+}
+
+func (rt *rollbackTask) Apply() (resource.TaskStatus, error) {
+	return &resource.Status{}, nil
+}
+
+func (rt *rollbackTask) Rollback() (resource.TaskStatus, error) {
+	rt.rollbacks++
+	return &resource.Status{}, nil
+}
+
+type failingTask struct {
+	onFailure string
+}
+
+func (ft *failingTask) Check(resource.Renderer) (resource.TaskStatus, error) {
+	return &resource.Status{Level: resource.StatusWillChange}, nil
+}
+
+func (ft *failingTask) Apply() (resource.TaskStatus, error) {
+	return &resource.Status{Level: resource.StatusFatal}, errors.New("failed")
+}
+
+func (ft *failingTask) OnFailure() string {
+	return ft.onFailure
+}
+
+type hookTask struct {
+	faketask.FakeTask
+
+	name  string
+	calls *[]string
+	err   error
+}
+
+func (h *hookTask) RunBefore() error {
+	*h.calls = append(*h.calls, h.name+" before")
+	return h.err
+}
+
+func (h *hookTask) RunAfter() error {
+	*h.calls = append(*h.calls, h.name+" after")
+	return nil
+}
+
 func getResult(t *testing.T, src *graph.Graph, key string) *apply.Result {
 	meta, ok := src.Get(key)
 	require.True(t, ok, "%q was not present in the graph", key)
@@ -0,0 +1,170 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apply
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/asteris-llc/converge/graph"
+	"github.com/asteris-llc/converge/resource"
+)
+
+// canaries holds back the nodes of a run until every node marked with `canary
+// = true` has finished, and halts them if any canary failed
+type canaries struct {
+	lock sync.Mutex
+
+	// pending are the canaries that haven't finished yet
+	pending map[string]struct{}
+
+	// exempt are the canaries and every node they wait on, which are never
+	// held back
+	exempt map[string]struct{}
+
+	failed string
+	done   chan struct{}
+}
+
+// newCanaries finds the canaries in a graph. It returns nil if there are none.
+func newCanaries(g *graph.Graph) *canaries {
+	c := &canaries{pending: map[string]struct{}{}, exempt: map[string]struct{}{}, done: make(chan struct{})}
+
+	for _, id := range g.Vertices() {
+		meta, ok := g.Get(id)
+		if !ok {
+			continue
+		}
+
+		task, ok := resource.ResolveTask(meta.Value())
+		if !ok {
+			continue
+		}
+
+		if canaried, ok := task.(resource.Canaried); ok && canaried.Canary() {
+			c.pending[id] = struct{}{}
+		}
+	}
+
+	if len(c.pending) == 0 {
+		return nil
+	}
+
+	for id := range c.pending {
+		c.exemptDependencies(g, id)
+	}
+
+	return c
+}
+
+// exemptDependencies exempts the node and everything it waits on during the
+// walk: its dependencies and children, and the dependencies of the modules it
+// is in, which have to be done before the node is started
+func (c *canaries) exemptDependencies(g *graph.Graph, id string) {
+	if _, ok := c.exempt[id]; ok {
+		return
+	}
+	c.exempt[id] = struct{}{}
+
+	for _, dep := range graph.Targets(g.DownEdges(id)) {
+		c.exemptDependencies(g, dep)
+	}
+
+	for parent := graph.ParentID(id); parent != "."; parent = graph.ParentID(parent) {
+		for _, edge := range g.DownEdges(parent) {
+			if _, ok := edge.(*graph.ParentEdge); !ok {
+				c.exemptDependencies(g, edge.Target().(string))
+			}
+		}
+	}
+}
+
+// wait blocks until every canary has finished, unless the node is exempt, and
+// returns an error if one of them failed. If ctx is done first, the node stops
+// waiting and the context's error is returned, as it is if the canaries finish
+// after the run was cancelled.
+func (c *canaries) wait(ctx context.Context, id string) error {
+	if c == nil {
+		return nil
+	}
+
+	if _, ok := c.exempt[id]; ok {
+		return nil
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-c.done:
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.failed != "" {
+		return fmt.Errorf("canary %s failed", c.failed)
+	}
+	return nil
+}
+
+// record notes that a node finished, releasing the held nodes once the last
+// canary has
+func (c *canaries) record(id string, err error) {
+	if c == nil {
+		return
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if _, ok := c.pending[id]; !ok {
+		return
+	}
+	delete(c.pending, id)
+
+	if err != nil && c.failed == "" {
+		c.failed = id
+	}
+
+	if len(c.pending) == 0 {
+		close(c.done)
+	}
+}
+
+// abort releases the held nodes when the walk stops early, without waiting
+// for the rest of the canaries
+func (c *canaries) abort(id string) {
+	if c == nil {
+		return
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if len(c.pending) == 0 {
+		return
+	}
+	c.pending = map[string]struct{}{}
+
+	if c.failed == "" {
+		c.failed = id
+	}
+	close(c.done)
+}
@@ -0,0 +1,66 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apply
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/asteris-llc/converge/graph"
+	"github.com/asteris-llc/converge/graph/node"
+	"github.com/asteris-llc/converge/helpers/faketask"
+	"github.com/asteris-llc/converge/plan"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCanariesWaitCancel(t *testing.T) {
+	t.Parallel()
+
+	g := graph.New()
+	g.Add(node.New("root", &plan.Result{Status: &resource.Status{Level: resource.StatusWillChange}, Task: faketask.NoOp()}))
+	g.Add(node.New("root/canary", &plan.Result{Status: &resource.Status{Level: resource.StatusWillChange}, Task: &resource.CanaryTask{Wrapped: resource.Wrapped{Task: faketask.Swapper()}}}))
+	g.ConnectParent("root", "root/canary")
+
+	c := newCanaries(g)
+	require.NotNil(t, c)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var wg sync.WaitGroup
+	errs := make([]error, 3)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = c.wait(ctx, "root/rest")
+		}(i)
+	}
+
+	// the canary never finishes, so the nodes only stop waiting when the run
+	// is cancelled
+	cancel()
+	wg.Wait()
+
+	for _, err := range errs {
+		assert.Equal(t, context.Canceled, err)
+	}
+
+	t.Run("exempt", func(t *testing.T) {
+		assert.NoError(t, c.wait(ctx, "root/canary"))
+	})
+}
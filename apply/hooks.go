@@ -0,0 +1,134 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apply
+
+import (
+	"sync"
+
+	"github.com/asteris-llc/converge/graph"
+	"github.com/asteris-llc/converge/resource"
+)
+
+// Hooker is implemented by tasks that run commands around the apply of the
+// module they are in, like `hooks`
+type Hooker interface {
+	// RunBefore is called before the first node in the module is applied
+	RunBefore() error
+
+	// RunAfter is called once the walk is done, if RunBefore was called
+	RunAfter() error
+}
+
+// hookRunner runs the hooks of each module at most once per run. Modules are
+// identified by the ID of the node containing their hooks.
+type hookRunner struct {
+	lock sync.Mutex
+
+	byModule map[string][]Hooker
+
+	// ran are the modules whose before hooks ran, in order, so their after
+	// hooks run in reverse
+	ran []string
+
+	// errs are the errors of before hooks, returned again for every later node
+	// in the module
+	errs map[string]error
+}
+
+// newHookRunner finds the hooks in a graph
+func newHookRunner(g *graph.Graph) *hookRunner {
+	h := &hookRunner{byModule: map[string][]Hooker{}, errs: map[string]error{}}
+
+	for _, id := range g.Vertices() {
+		meta, ok := g.Get(id)
+		if !ok {
+			continue
+		}
+
+		task, ok := resource.ResolveTask(meta.Value())
+		if !ok {
+			continue
+		}
+
+		if hooker, ok := task.(Hooker); ok {
+			module := graph.ParentID(id)
+			h.byModule[module] = append(h.byModule[module], hooker)
+		}
+	}
+
+	return h
+}
+
+// before runs the before hooks of every module containing the node, outermost
+// first, that haven't run yet
+func (h *hookRunner) before(id string) error {
+	if h == nil {
+		return nil
+	}
+
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	var modules []string
+	for module := graph.ParentID(id); module != "."; module = graph.ParentID(module) {
+		if _, ok := h.byModule[module]; ok {
+			modules = append([]string{module}, modules...)
+		}
+	}
+
+	for _, module := range modules {
+		if err, ok := h.errs[module]; ok {
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		h.errs[module] = nil
+		h.ran = append(h.ran, module)
+		for _, hooker := range h.byModule[module] {
+			if err := hooker.RunBefore(); err != nil {
+				h.errs[module] = err
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// after runs the after hooks of the modules whose before hooks ran, innermost
+// first. Every module's hooks are run even if some fail, and the first error
+// is returned.
+func (h *hookRunner) after() error {
+	if h == nil {
+		return nil
+	}
+
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	var first error
+	for i := len(h.ran) - 1; i >= 0; i-- {
+		for _, hooker := range h.byModule[h.ran[i]] {
+			if err := hooker.RunAfter(); err != nil && first == nil {
+				first = err
+			}
+		}
+	}
+	h.ran = nil
+
+	return first
+}
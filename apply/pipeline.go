@@ -15,10 +15,13 @@
 package apply
 
 import (
+	"context"
 	"fmt"
 
+	"github.com/asteris-llc/converge/backup"
 	"github.com/asteris-llc/converge/executor"
 	"github.com/asteris-llc/converge/graph"
+	"github.com/asteris-llc/converge/helpers/exec"
 	"github.com/asteris-llc/converge/plan"
 	"github.com/asteris-llc/converge/render"
 	"github.com/asteris-llc/converge/resource"
@@ -29,6 +32,29 @@ type pipelineGen struct {
 	Graph          *graph.Graph
 	ID             string
 	RenderingPlant *render.Factory
+	Output         func(stream, line string)
+	run            *runState
+}
+
+// runState is shared by the pipelines of every node in a single apply
+type runState struct {
+	// ctx is the context of the run. Nodes held back by canaries stop waiting
+	// when it is done.
+	ctx context.Context
+
+	rollback *rollback
+
+	// backups receives the files of nodes that manage them before they are
+	// applied. If nil, nothing is backed up.
+	backups *backup.Store
+
+	// hooks runs the before hooks of a module ahead of the first node applied
+	// in it. If nil, there are no hooks.
+	hooks *hookRunner
+
+	// canaries holds back the nodes that aren't canaries until the canaries
+	// are done. If nil, there are no canaries.
+	canaries *canaries
 }
 
 type resultWrapper struct {
@@ -37,7 +63,19 @@ type resultWrapper struct {
 
 // Pipeline generates a pipeline to evaluate a single graph node
 func Pipeline(g *graph.Graph, id string, factory *render.Factory) executor.Pipeline {
-	gen := &pipelineGen{Graph: g, RenderingPlant: factory, ID: id}
+	return StreamingPipeline(g, id, factory, nil)
+}
+
+// StreamingPipeline generates a pipeline like Pipeline, passing output to
+// tasks that can stream it while they are applied
+func StreamingPipeline(g *graph.Graph, id string, factory *render.Factory, output func(stream, line string)) executor.Pipeline {
+	return streamingPipeline(g, id, factory, output, new(runState))
+}
+
+// streamingPipeline is StreamingPipeline as part of a run, which backs up
+// managed files and rolls back after failures
+func streamingPipeline(g *graph.Graph, id string, factory *render.Factory, output func(stream, line string), run *runState) executor.Pipeline {
+	gen := &pipelineGen{Graph: g, RenderingPlant: factory, ID: id, Output: output, run: run}
 	return executor.NewPipeline().
 		AndThen(gen.GetTask).
 		AndThen(gen.DependencyCheck).
@@ -123,6 +161,35 @@ func (g *pipelineGen) applyNode(val interface{}) (interface{}, error) {
 		return nil, fmt.Errorf("apply expected a resultWrappert but got %T", val)
 	}
 
+	if err := g.run.canaries.wait(g.run.ctx, g.ID); err != nil {
+		return notApplied(twrapper, err, "not applied"), nil
+	}
+
+	if failed, ok := g.run.rollback.triggered(); ok {
+		return notApplied(twrapper, fmt.Errorf("rolling back after %s failed", failed), "not applied"), nil
+	}
+
+	if err := g.backup(twrapper.Plan.Task); err != nil {
+		return notApplied(twrapper, err, "not applied, could not back up files"), nil
+	}
+
+	if err := g.run.hooks.before(g.ID); err != nil {
+		return notApplied(twrapper, err, "not applied"), nil
+	}
+
+	if g.Output != nil {
+		if task, ok := resource.ResolveTask(twrapper.Plan.Task); ok {
+			if streamer, ok := task.(resource.OutputStreamer); ok {
+				streamer.StreamOutput(g.Output)
+			}
+		}
+	}
+
+	planned := map[string]resource.Diff{}
+	for key, diff := range twrapper.Plan.Changes() {
+		planned[key] = diff
+	}
+
 	status, err := twrapper.Plan.Task.Apply()
 
 	if status == nil {
@@ -137,12 +204,52 @@ func (g *pipelineGen) applyNode(val interface{}) (interface{}, error) {
 	}
 
 	return &Result{
-		Ran:    true,
-		Status: status,
+		Ran:     true,
+		Status:  status,
+		Task:    twrapper.Plan.Task,
+		Plan:    twrapper.Plan,
+		Err:     status.Error(),
+		Planned: planned,
+	}, nil
+}
+
+// notApplied is the result of a node that was held back before it could be
+// applied, failing with err wrapped in msg
+func notApplied(twrapper resultWrapper, err error, msg string) *Result {
+	return &Result{
+		Ran:    false,
+		Status: twrapper.Plan.Status,
 		Task:   twrapper.Plan.Task,
 		Plan:   twrapper.Plan,
-		Err:    status.Error(),
-	}, nil
+		Err:    errors.Wrap(err, msg),
+	}
+}
+
+// backup saves the files managed by the task before it is applied. Files
+// written through a non-local executor, such as in a container, are not backed
+// up.
+func (g *pipelineGen) backup(task resource.Task) error {
+	if g.run.backups == nil {
+		return nil
+	}
+
+	resolved, ok := resource.ResolveTask(task)
+	if !ok {
+		return nil
+	}
+
+	manager, ok := resolved.(resource.FileManager)
+	if !ok {
+		return nil
+	}
+
+	paths := manager.ManagedFiles()
+	if len(paths) == 0 || !exec.Local(exec.For(resolved)) {
+		return nil
+	}
+
+	_, err := g.run.backups.Save(g.ID, paths)
+	return err
 }
 
 // maybeRunFinalCheck :: *Result -> Either error *Result; looks to see if the
@@ -28,6 +28,10 @@ type Result struct {
 	Ran       bool
 	Plan      *plan.Result
 	PostCheck resource.TaskStatus
+
+	// Planned are the changes the plan found, copied before the task was
+	// applied, since applying it may reset the status it was planned with
+	Planned map[string]resource.Diff
 }
 
 // Messages returns any result status messages supplied by the task
@@ -56,6 +60,40 @@ func (r *Result) HasChanges() bool { return r.Ran }
 // Error returns the error assigned to this Result, if any
 func (r *Result) Error() error { return r.Err }
 
+// PendingReboots returns the reboots required by the task, whether it ran or
+// was found to be waiting on a reboot during planning
+func (r *Result) PendingReboots() []string {
+	if r.Status != nil {
+		return resource.PendingReboots(r.Status)
+	} else if r.Plan != nil {
+		return r.Plan.PendingReboots()
+	}
+	return nil
+}
+
+// Warnings returns the warnings given by the task when it was planned, along
+// with any new ones given when it was applied
+func (r *Result) Warnings() []resource.Warning {
+	var out []resource.Warning
+	if r.Plan != nil {
+		out = r.Plan.Warnings()
+	}
+
+	for _, warning := range resource.Warnings(r.Status) {
+		seen := false
+		for _, existing := range out {
+			if existing == warning {
+				seen = true
+				break
+			}
+		}
+		if !seen {
+			out = append(out, warning)
+		}
+	}
+	return out
+}
+
 // GetStatus returns the current task status
 func (r *Result) GetStatus() resource.TaskStatus { return r.Status }
 
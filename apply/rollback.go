@@ -0,0 +1,196 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apply
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/asteris-llc/converge/helpers/logging"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/pkg/errors"
+)
+
+// RollbackError is returned by apply when a node with `on_failure =
+// "rollback"` failed and the nodes applied before it were rolled back. It
+// lists what happened to each of them, so a partial rollback is clear.
+type RollbackError struct {
+	// Failed is the ID of the node whose failure caused the rollback
+	Failed string
+
+	// Applied is the IDs of the nodes applied earlier in the run, in the order
+	// they were rolled back
+	Applied []string
+
+	// RolledBack is the IDs of the nodes that were rolled back
+	RolledBack []string
+
+	// Unsupported is the IDs of the nodes that can't be rolled back
+	Unsupported []string
+
+	// Errors holds the error for each node that failed to roll back
+	Errors map[string]error
+}
+
+// Partial is true if any applied node was not rolled back
+func (e *RollbackError) Partial() bool {
+	return len(e.RolledBack) < len(e.Applied)
+}
+
+func (e *RollbackError) Error() string {
+	summary := fmt.Sprintf("%s failed, rolled back %d of %d applied resources", e.Failed, len(e.RolledBack), len(e.Applied))
+	if e.Partial() {
+		summary += " (partial rollback)"
+	}
+
+	lines := []string{summary}
+	for _, id := range e.RolledBack {
+		lines = append(lines, "rolled back: "+id)
+	}
+	for _, id := range e.Unsupported {
+		lines = append(lines, "can't be rolled back: "+id)
+	}
+
+	var failed []string
+	for id := range e.Errors {
+		failed = append(failed, id)
+	}
+	sort.Strings(failed)
+	for _, id := range failed {
+		lines = append(lines, fmt.Sprintf("failed to roll back: %s: %s", id, e.Errors[id]))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// rollback records the nodes applied during a run, so they can be rolled
+// back if a node with `on_failure = "rollback"` fails
+type rollback struct {
+	lock    sync.Mutex
+	applied []*appliedNode
+	failed  string
+}
+
+type appliedNode struct {
+	id   string
+	task resource.Task
+}
+
+// record notes the result of a node. Successfully applied nodes are kept for
+// rolling back, and a failed node that asks for a rollback starts one.
+func (r *rollback) record(id string, result *Result) {
+	if r == nil || !result.Ran {
+		return
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if result.Err == nil {
+		r.applied = append(r.applied, &appliedNode{id: id, task: result.Task})
+		return
+	}
+
+	if r.failed == "" && onFailure(result.Task) == resource.OnFailureRollback {
+		r.failed = id
+	}
+}
+
+// triggered returns the ID of the node that started a rollback, if any
+func (r *rollback) triggered() (string, bool) {
+	if r == nil {
+		return "", false
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return r.failed, r.failed != ""
+}
+
+// run rolls back the applied nodes in reverse order if a rollback was
+// started, and returns a *RollbackError describing it
+func (r *rollback) run(ctx context.Context) error {
+	failed, ok := r.triggered()
+	if !ok {
+		return nil
+	}
+
+	logger := logging.GetLogger(ctx).WithField("function", "rollback.run").WithField("failed", failed)
+
+	out := &RollbackError{Failed: failed, Errors: map[string]error{}}
+	for i := len(r.applied) - 1; i >= 0; i-- {
+		applied := r.applied[i]
+		out.Applied = append(out.Applied, applied.id)
+
+		err := rollbackTask(applied.task)
+		switch {
+		case errors.Cause(err) == resource.ErrRollbackUnsupported:
+			logger.WithField("id", applied.id).Warning("can't be rolled back")
+			out.Unsupported = append(out.Unsupported, applied.id)
+
+		case err != nil:
+			logger.WithField("id", applied.id).WithError(err).Error("failed to roll back")
+			out.Errors[applied.id] = err
+
+		default:
+			logger.WithField("id", applied.id).Info("rolled back")
+			out.RolledBack = append(out.RolledBack, applied.id)
+		}
+	}
+
+	return out
+}
+
+func rollbackTask(task resource.Task) error {
+	resolved, ok := resource.ResolveTask(task)
+	if !ok {
+		return resource.ErrRollbackUnsupported
+	}
+
+	rollbacker, ok := resolved.(resource.Rollbacker)
+	if !ok {
+		return resource.ErrRollbackUnsupported
+	}
+
+	status, err := rollbacker.Rollback()
+	if err != nil {
+		return err
+	}
+
+	if status != nil && status.StatusCode() == resource.StatusFatal {
+		if statusErr := status.Error(); statusErr != nil {
+			return statusErr
+		}
+		return errors.New(strings.Join(status.Messages(), "; "))
+	}
+
+	return nil
+}
+
+func onFailure(task resource.Task) string {
+	resolved, ok := resource.ResolveTask(task)
+	if !ok {
+		return ""
+	}
+
+	if handler, ok := resolved.(resource.FailureHandler); ok {
+		return handler.OnFailure()
+	}
+	return ""
+}
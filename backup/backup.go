@@ -0,0 +1,283 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backup
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/asteris-llc/converge/helpers/atomicfile"
+	"github.com/asteris-llc/converge/helpers/statedir"
+	"github.com/pkg/errors"
+)
+
+// DefaultDir is where backups are kept if no other directory is given
+const DefaultDir = "/var/lib/converge/backups"
+
+// DefaultRetention is the number of backups kept for each node by default
+const DefaultRetention = 5
+
+// timeFormat names backups so that they sort in the order they were taken
+const timeFormat = "20060102T150405.000000000Z"
+
+const manifestName = "manifest.json"
+
+// Store keeps backups of the files managed by each node, in a directory per
+// node under Dir. Backups may hold secrets, so the directories are only
+// readable by the user running converge, and so are the copies in them.
+type Store struct {
+	Dir string
+
+	// Retention is the number of backups kept for each node. Older backups are
+	// removed when a new one is saved. 0 keeps every backup.
+	Retention int
+}
+
+// New returns a Store for the given directory
+func New(dir string, retention int) *Store {
+	return &Store{Dir: dir, Retention: retention}
+}
+
+// Module returns a Store for the backups of the module at location, kept in a
+// directory of their own, so that nodes with the same ID in other modules
+// don't share backups with it
+func (s *Store) Module(location string) *Store {
+	return &Store{Dir: filepath.Join(s.Dir, statedir.Key(location)), Retention: s.Retention}
+}
+
+// File is a single file in a backup
+type File struct {
+	Path string `json:"path"`
+
+	// Exists is false if there was no file at Path when the backup was taken,
+	// in which case restoring the backup removes it
+	Exists bool `json:"exists"`
+
+	Mode os.FileMode `json:"mode,omitempty"`
+
+	// Name is the name of the saved copy in the backup directory
+	Name string `json:"name,omitempty"`
+}
+
+// Backup is the set of files saved before a node was applied
+type Backup struct {
+	ID    string    `json:"id"`
+	Time  time.Time `json:"time"`
+	Files []*File   `json:"files"`
+
+	dir string
+}
+
+// Save backs up the files at paths for the node with the given ID, and
+// removes backups beyond the retention limit
+func (s *Store) Save(id string, paths []string) (*Backup, error) {
+	now := time.Now().UTC()
+	out := &Backup{
+		ID:   id,
+		Time: now,
+		dir:  filepath.Join(s.nodeDir(id), now.Format(timeFormat)),
+	}
+
+	if err := os.MkdirAll(out.dir, 0700); err != nil {
+		return nil, errors.Wrap(err, "could not create backup directory")
+	}
+
+	for i, path := range paths {
+		path, err := filepath.Abs(path)
+		if err != nil {
+			return nil, err
+		}
+
+		file := &File{Path: path}
+		out.Files = append(out.Files, file)
+
+		stat, err := os.Stat(path)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		if stat.IsDir() {
+			return nil, fmt.Errorf("cannot back up %s, it is a directory", path)
+		}
+
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		file.Exists = true
+		file.Mode = stat.Mode().Perm()
+		file.Name = strconv.Itoa(i)
+
+		if err := ioutil.WriteFile(filepath.Join(out.dir, file.Name), content, 0600); err != nil {
+			return nil, errors.Wrapf(err, "could not back up %s", path)
+		}
+	}
+
+	manifest, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(out.dir, manifestName), manifest, 0600); err != nil {
+		return nil, errors.Wrap(err, "could not write backup manifest")
+	}
+
+	return out, s.prune(id)
+}
+
+// List returns the backups of the node with the given ID, newest first
+func (s *Store) List(id string) ([]*Backup, error) {
+	dir := s.nodeDir(id)
+
+	entries, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(names)))
+
+	var out []*Backup
+	for _, name := range names {
+		backup, err := load(filepath.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, backup)
+	}
+
+	return out, nil
+}
+
+// Latest returns the newest backup of the node with the given ID
+func (s *Store) Latest(id string) (*Backup, error) {
+	backups, err := s.List(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(backups) == 0 {
+		return nil, fmt.Errorf("no backups of %s in %s", id, s.Dir)
+	}
+
+	return backups[0], nil
+}
+
+// Restore puts every file in the backup back as it was, removing the ones
+// that didn't exist when it was taken
+func (b *Backup) Restore() error {
+	for _, file := range b.Files {
+		if !file.Exists {
+			if err := os.Remove(file.Path); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			continue
+		}
+
+		content, err := ioutil.ReadFile(filepath.Join(b.dir, file.Name))
+		if err != nil {
+			return errors.Wrapf(err, "could not read backup of %s", file.Path)
+		}
+
+		if err := atomicfile.Write(file.Path, content, file.Mode); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Name identifies the backup among the others of the same node
+func (b *Backup) Name() string {
+	return filepath.Base(b.dir)
+}
+
+// Paths returns the paths of the files in the backup
+func (b *Backup) Paths() (out []string) {
+	for _, file := range b.Files {
+		out = append(out, file.Path)
+	}
+	return out
+}
+
+func (s *Store) nodeDir(id string) string {
+	return filepath.Join(s.Dir, url.QueryEscape(id))
+}
+
+// prune removes the oldest backups of the node beyond the retention limit
+func (s *Store) prune(id string) error {
+	if s.Retention <= 0 {
+		return nil
+	}
+
+	backups, err := s.List(id)
+	if err != nil {
+		return err
+	}
+
+	for i := s.Retention; i < len(backups); i++ {
+		if err := os.RemoveAll(backups[i].dir); err != nil {
+			return errors.Wrap(err, "could not remove old backup")
+		}
+	}
+
+	return nil
+}
+
+func load(dir string) (*Backup, error) {
+	content, err := ioutil.ReadFile(filepath.Join(dir, manifestName))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read backup manifest")
+	}
+
+	out := &Backup{dir: dir}
+	if err := json.Unmarshal(content, out); err != nil {
+		return nil, errors.Wrapf(err, "could not parse %s", filepath.Join(dir, manifestName))
+	}
+
+	return out, nil
+}
+
+type storeKey struct{}
+
+// WithStore returns a context carrying the given store. Apply backs up the
+// files of managed nodes into it.
+func WithStore(ctx context.Context, store *Store) context.Context {
+	return context.WithValue(ctx, storeKey{}, store)
+}
+
+// StoreFrom returns the store carried by the context, or nil if there is none
+func StoreFrom(ctx context.Context) *Store {
+	store, _ := ctx.Value(storeKey{}).(*Store)
+	return store
+}
@@ -0,0 +1,150 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backup_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/asteris-llc/converge/backup"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSaveRestore(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "converge-backup")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	existing := filepath.Join(dir, "existing")
+	missing := filepath.Join(dir, "missing")
+	require.NoError(t, ioutil.WriteFile(existing, []byte("before"), 0640))
+
+	store := backup.New(filepath.Join(dir, "backups"), 0)
+	saved, err := store.Save("root/file.content.x", []string{existing, missing})
+	require.NoError(t, err)
+	assert.Equal(t, []string{existing, missing}, saved.Paths())
+
+	require.NoError(t, ioutil.WriteFile(existing, []byte("after"), 0600))
+	require.NoError(t, ioutil.WriteFile(missing, []byte("created"), 0600))
+
+	latest, err := store.Latest("root/file.content.x")
+	require.NoError(t, err)
+	assert.Equal(t, saved.Name(), latest.Name())
+	require.NoError(t, latest.Restore())
+
+	t.Run("existing", func(t *testing.T) {
+		content, err := ioutil.ReadFile(existing)
+		require.NoError(t, err)
+		assert.Equal(t, "before", string(content))
+
+		stat, err := os.Stat(existing)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0640), stat.Mode().Perm())
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		_, err := os.Stat(missing)
+		assert.True(t, os.IsNotExist(err))
+	})
+}
+
+func TestRetention(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "converge-backup")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "file")
+	store := backup.New(filepath.Join(dir, "backups"), 2)
+
+	var names []string
+	for _, content := range []string{"1", "2", "3"} {
+		require.NoError(t, ioutil.WriteFile(path, []byte(content), 0600))
+		saved, err := store.Save("root/x", []string{path})
+		require.NoError(t, err)
+		names = append(names, saved.Name())
+	}
+
+	backups, err := store.List("root/x")
+	require.NoError(t, err)
+	require.Len(t, backups, 2)
+	assert.Equal(t, names[2], backups[0].Name())
+	assert.Equal(t, names[1], backups[1].Name())
+}
+
+func TestLatestNone(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "converge-backup")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	_, err = backup.New(dir, 0).Latest("root/x")
+	assert.Error(t, err)
+}
+
+func TestModule(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "converge-backup")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "file")
+	require.NoError(t, ioutil.WriteFile(path, []byte("a"), 0600))
+
+	store := backup.New(filepath.Join(dir, "backups"), 0)
+	_, err = store.Module("/etc/converge/a.hcl").Save("root/file.content.x", []string{path})
+	require.NoError(t, err)
+
+	backups, err := store.Module("/etc/converge/a.hcl").List("root/file.content.x")
+	require.NoError(t, err)
+	assert.Len(t, backups, 1)
+
+	backups, err = store.Module("/etc/converge/b.hcl").List("root/file.content.x")
+	require.NoError(t, err)
+	assert.Empty(t, backups)
+}
+
+func TestPermissions(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "converge-backup")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "file")
+	require.NoError(t, ioutil.WriteFile(path, []byte("secret"), 0644))
+
+	backups := filepath.Join(dir, "backups")
+	_, err = backup.New(backups, 0).Module("/etc/converge/a.hcl").Save("root/file.content.x", []string{path})
+	require.NoError(t, err)
+
+	require.NoError(t, filepath.Walk(backups, func(name string, info os.FileInfo, err error) error {
+		require.NoError(t, err)
+		if info.IsDir() {
+			assert.Equal(t, os.FileMode(0700), info.Mode().Perm(), name)
+		} else {
+			assert.Equal(t, os.FileMode(0600), info.Mode().Perm(), name)
+		}
+		return nil
+	}))
+}
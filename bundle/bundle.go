@@ -0,0 +1,327 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bundle
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/asteris-llc/converge/fetch"
+	"github.com/asteris-llc/converge/load"
+	"github.com/pkg/errors"
+)
+
+// Ext is the extension of bundle files
+const Ext = ".tgz"
+
+const (
+	manifestName = "manifest.json"
+	filesDir     = "files/"
+)
+
+// Manifest describes what is in a bundle
+type Manifest struct {
+	// Root is the url the root module was loaded from
+	Root string `json:"root"`
+
+	// Params are the params given when the bundle was made. Params given when
+	// it is loaded take precedence.
+	Params map[string]string `json:"params,omitempty"`
+
+	// Files has the sha256 of every file fetched while loading the module, by
+	// url. The content of each is stored in the bundle under its hash.
+	Files map[string]string `json:"files"`
+
+	// Expanded has the files that made up each directory or glob module
+	Expanded map[string][]string `json:"expanded"`
+
+	// Hash is the sha256 of everything else in the manifest, so that a bundle
+	// that was changed after it was made can be detected
+	Hash string `json:"hash"`
+}
+
+// Is tests whether a location names a bundle
+func Is(location string) bool {
+	return strings.HasSuffix(location, Ext)
+}
+
+// Bundle is the content of a bundle. It is a load.Source for the files in
+// it, so modules can be loaded from it without fetching anything.
+type Bundle struct {
+	Manifest *Manifest
+
+	content map[string][]byte
+}
+
+// Create loads the module at root, along with every module it calls, and
+// writes a bundle of the files they were loaded from to w. Signatures are
+// fetched and checked when verify is set, and added to the bundle so that
+// it can be verified when loaded.
+func Create(ctx context.Context, w io.Writer, root string, params map[string]string, verify bool) (*Manifest, error) {
+	rec := &recorder{
+		source:   load.DefaultSource,
+		content:  make(map[string][]byte),
+		expanded: make(map[string][]string),
+	}
+
+	if _, err := load.Load(load.WithSource(ctx, rec), root, verify); err != nil {
+		return nil, err
+	}
+
+	url, err := fetch.ResolveInContext(root, root)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := &Manifest{
+		Root:     url,
+		Params:   params,
+		Files:    make(map[string]string),
+		Expanded: rec.expanded,
+	}
+	for url, content := range rec.content {
+		manifest.Files[url] = hash(content)
+	}
+	manifest.Hash, err = manifest.hash()
+	if err != nil {
+		return nil, err
+	}
+
+	return manifest, write(w, manifest, rec.content)
+}
+
+// Open reads the bundle at path, checking that nothing in it has changed
+// since it was made
+func Open(path string) (*Bundle, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	b, err := read(f)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not read bundle %s", path)
+	}
+
+	return b, nil
+}
+
+// Expand gets the files that were found for a directory or glob module when
+// the bundle was made
+func (b *Bundle) Expand(url string) ([]string, error) {
+	if files, ok := b.Manifest.Expanded[url]; ok {
+		return files, nil
+	}
+	return nil, fmt.Errorf("%s is not in the bundle", url)
+}
+
+// Fetch gets the content of a file in the bundle
+func (b *Bundle) Fetch(ctx context.Context, url string) ([]byte, error) {
+	if content, ok := b.content[url]; ok {
+		return content, nil
+	}
+	return nil, fmt.Errorf("%s is not in the bundle", url)
+}
+
+// Params returns the params saved in the bundle, overridden by the ones
+// given
+func (b *Bundle) Params(given map[string]string) map[string]string {
+	out := make(map[string]string)
+	for k, v := range b.Manifest.Params {
+		out[k] = v
+	}
+	for k, v := range given {
+		out[k] = v
+	}
+	return out
+}
+
+// recorder is a load.Source that keeps every file it gets from another
+type recorder struct {
+	source load.Source
+
+	lock     sync.Mutex
+	content  map[string][]byte
+	expanded map[string][]string
+}
+
+func (r *recorder) Expand(url string) ([]string, error) {
+	files, err := r.source.Expand(url)
+	if err != nil {
+		return nil, err
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.expanded[url] = files
+
+	return files, nil
+}
+
+func (r *recorder) Fetch(ctx context.Context, url string) ([]byte, error) {
+	content, err := r.source.Fetch(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.content[url] = content
+
+	return content, nil
+}
+
+func (m *Manifest) hash() (string, error) {
+	copied := *m
+	copied.Hash = ""
+
+	// maps are marshaled with their keys sorted, so this is stable
+	content, err := json.Marshal(&copied)
+	if err != nil {
+		return "", err
+	}
+	return hash(content), nil
+}
+
+func hash(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+func write(w io.Writer, manifest *Manifest, content map[string][]byte) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	encoded, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeFile(tw, manifestName, encoded); err != nil {
+		return err
+	}
+
+	// the same content may be at more than one url, but is only stored once
+	written := make(map[string]struct{})
+	var urls []string
+	for url := range content {
+		urls = append(urls, url)
+	}
+	sort.Strings(urls)
+
+	for _, url := range urls {
+		sum := manifest.Files[url]
+		if _, ok := written[sum]; ok {
+			continue
+		}
+		written[sum] = struct{}{}
+
+		if err := writeFile(tw, filesDir+sum, content[url]); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+func writeFile(tw *tar.Writer, name string, content []byte) error {
+	err := tw.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0600,
+		Size:    int64(len(content)),
+		ModTime: time.Unix(0, 0),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = tw.Write(content)
+	return err
+}
+
+func read(r io.Reader) (*Bundle, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	tr := tar.NewReader(gz)
+
+	var manifest *Manifest
+	stored := make(map[string][]byte)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		content, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case header.Name == manifestName:
+			manifest = new(Manifest)
+			if err := json.Unmarshal(content, manifest); err != nil {
+				return nil, errors.Wrap(err, "could not parse manifest")
+			}
+		case strings.HasPrefix(header.Name, filesDir):
+			stored[strings.TrimPrefix(header.Name, filesDir)] = content
+		}
+	}
+
+	if manifest == nil {
+		return nil, errors.New("no manifest")
+	}
+
+	sum, err := manifest.hash()
+	if err != nil {
+		return nil, err
+	}
+	if sum != manifest.Hash {
+		return nil, errors.New("manifest has changed since the bundle was made")
+	}
+
+	b := &Bundle{Manifest: manifest, content: make(map[string][]byte)}
+	for url, sum := range manifest.Files {
+		content, ok := stored[sum]
+		if !ok {
+			return nil, fmt.Errorf("%s is missing", url)
+		}
+		if hash(content) != sum {
+			return nil, fmt.Errorf("%s has changed since the bundle was made", url)
+		}
+		b.content[url] = content
+	}
+
+	return b, nil
+}
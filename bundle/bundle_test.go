@@ -0,0 +1,168 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bundle_test
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/asteris-llc/converge/bundle"
+	"github.com/asteris-llc/converge/helpers/logging"
+	"github.com/asteris-llc/converge/load"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBundle(t *testing.T) {
+	defer logging.HideLogs(t)()
+
+	dir, err := ioutil.TempDir("", "converge-bundle")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	module := filepath.Join(dir, "module")
+	require.NoError(t, os.MkdirAll(filepath.Join(module, "lib"), 0700))
+	files := map[string]string{
+		"main.hcl":     "param \"name\" {}\n\nmodule \"lib\" \"lib\" {\n  params = {\n    name = \"{{param `name`}}\"\n  }\n}\n",
+		"lib/a.hcl":    "param \"name\" {}\n",
+		"lib/b.hcl":    "task \"hello\" {\n  check = \"echo {{param `name`}}\"\n  apply = \"true\"\n}\n",
+		"lib/x_x.hcl2": "not a module",
+	}
+	for name, content := range files {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(module, name), []byte(content), 0600))
+	}
+
+	path := filepath.Join(dir, "main.tgz")
+	out, err := os.Create(path)
+	require.NoError(t, err)
+
+	manifest, err := bundle.Create(context.Background(), out, filepath.Join(module, "main.hcl"), map[string]string{"name": "bundled"}, false)
+	require.NoError(t, err)
+	require.NoError(t, out.Close())
+
+	assert.Len(t, manifest.Files, 3)
+	assert.Equal(t, "file://"+filepath.Join(module, "main.hcl"), manifest.Root)
+
+	// the bundle is loaded without the files it was made from
+	require.NoError(t, os.RemoveAll(module))
+
+	t.Run("load", func(t *testing.T) {
+		b, err := bundle.Open(path)
+		require.NoError(t, err)
+
+		g, err := load.Nodes(load.WithSource(context.Background(), b), b.Manifest.Root, false)
+		require.NoError(t, err)
+
+		_, ok := g.Get("root/module.lib/task.hello")
+		assert.True(t, ok)
+	})
+
+	t.Run("params", func(t *testing.T) {
+		b, err := bundle.Open(path)
+		require.NoError(t, err)
+
+		assert.Equal(t, map[string]string{"name": "bundled"}, b.Params(nil))
+		assert.Equal(t, map[string]string{"name": "given"}, b.Params(map[string]string{"name": "given"}))
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		b, err := bundle.Open(path)
+		require.NoError(t, err)
+
+		_, err = b.Fetch(context.Background(), "file:///elsewhere.hcl")
+		assert.EqualError(t, err, "file:///elsewhere.hcl is not in the bundle")
+	})
+
+	t.Run("changed", func(t *testing.T) {
+		changed := filepath.Join(dir, "changed.tgz")
+		require.NoError(t, rewrite(path, changed, func(name string, content []byte) []byte {
+			if strings.HasPrefix(name, "files/") {
+				return append(content, '#')
+			}
+			return content
+		}))
+
+		_, err := bundle.Open(changed)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "has changed since the bundle was made")
+	})
+
+	t.Run("not a bundle", func(t *testing.T) {
+		bad := filepath.Join(dir, "bad.tgz")
+		require.NoError(t, ioutil.WriteFile(bad, []byte("nope"), 0600))
+
+		_, err := bundle.Open(bad)
+		assert.Error(t, err)
+	})
+}
+
+// rewrite copies a bundle, changing the content of each file in it
+func rewrite(from, to string, change func(string, []byte) []byte) error {
+	in, err := os.Open(from)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	gzIn, err := gzip.NewReader(in)
+	if err != nil {
+		return err
+	}
+	tr := tar.NewReader(gzIn)
+
+	out, err := os.Create(to)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	gzOut := gzip.NewWriter(out)
+	tw := tar.NewWriter(gzOut)
+
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+
+		content, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return err
+		}
+		content = change(header.Name, content)
+
+		header.Size = int64(len(content))
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if _, err := tw.Write(content); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gzOut.Close()
+}
@@ -16,13 +16,16 @@ package cmd
 
 import (
 	"context"
-	"errors"
 	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
 
 	log "github.com/Sirupsen/logrus"
 	"github.com/asteris-llc/converge/graph"
-	"github.com/asteris-llc/converge/graph/node"
 	"github.com/asteris-llc/converge/helpers/logging"
+	"github.com/asteris-llc/converge/prettyprinters/progress"
 	"github.com/asteris-llc/converge/rpc"
 	"github.com/asteris-llc/converge/rpc/pb"
 	"github.com/spf13/cobra"
@@ -34,13 +37,22 @@ var applyCmd = &cobra.Command{
 	Use:   "apply",
 	Short: "apply what needs to change in the system",
 	Long: `application is where the actual work of making your execution graph
-real happens.`,
-	PreRunE: func(cmd *cobra.Command, args []string) error {
-		if len(args) == 0 {
-			return errors.New("Need at least one module filename as argument, got 0")
-		}
-		return nil
-	},
+real happens.
+
+If a maintenance window is set, changes are only applied while it is open.
+Outside of it apply only plans, so drift is still reported, unless --force is
+given.
+
+A module argument of "-" is read from stdin, and a module can be given inline
+with --eval:
+
+    converge apply --local -e 'file.content "x" { destination = "x.txt" }'
+
+With --detailed-exitcode, apply exits 0 when nothing needed to change, 3 when
+changes were applied, 2 when changes were only planned because the
+maintenance window is closed, and 1 when any resource failed. With
+--summary-out, a JSON summary of the run is written to the given path.`,
+	PreRunE: requireModules,
 	Run: func(cmd *cobra.Command, args []string) {
 		// set up execution context
 		ctx, cancel := context.WithCancel(context.Background())
@@ -73,6 +85,20 @@ real happens.`,
 			clog.WithError(err).Fatal("could not get client")
 		}
 
+		closed, err := outsideMaintenance(time.Now())
+		if err != nil {
+			clog.WithError(err).Fatal("could not get maintenance window")
+		}
+
+		stage := pb.StatusResponse_APPLY
+		if closed != nil {
+			stage = pb.StatusResponse_PLAN
+			clog.WithFields(log.Fields{
+				"window": closed.String(),
+				"next":   closed.Next(time.Now()),
+			}).Warning("outside maintenance window, only planning")
+		}
+
 		rpcParams := getParamsRPC(cmd)
 
 		verifyModules := viper.GetBool("verify-modules")
@@ -80,25 +106,40 @@ real happens.`,
 			clog.Warn("skipping module verification")
 		}
 
+		mods, err := getModuleArgs(cmd, args, os.Stdin)
+		if err != nil {
+			clog.WithError(err).Fatal("could not read modules")
+		}
+
+		summary := newRunSummary("apply", stage == pb.StatusResponse_APPLY)
+
 		// execute files
-		for _, fname := range args {
+		for _, mod := range mods {
+			fname := mod.Location
 			flog := clog.WithField("file", fname)
 
 			flog.Debug("applying")
 
-			stream, err := client.Apply(
-				ctx,
-				&pb.LoadRequest{
-					Location:   fname,
-					Parameters: rpcParams,
-					Verify:     verifyModules,
-				},
-			)
+			req := &pb.LoadRequest{
+				Location:   fname,
+				Content:    mod.Content,
+				Parameters: rpcParams,
+				Verify:     verifyModules,
+			}
+
+			var stream statusStream
+			if stage == pb.StatusResponse_APPLY {
+				stream, err = client.Apply(ctx, req)
+			} else {
+				stream, err = client.Plan(ctx, req)
+			}
 			if err != nil {
 				flog.WithError(err).Fatal("error getting RPC stream")
 			}
 
 			g := graph.New()
+			found := warnings{}
+			reboots := map[string][]string{}
 
 			// get edges
 			edges, err := getMeta(stream)
@@ -109,29 +150,63 @@ real happens.`,
 				g.Connect(edge.Source, edge.Dest)
 			}
 
+			var bar *progress.Bar
+			if stage == pb.StatusResponse_APPLY {
+				bar = startProgress(flog, fname, edges)
+			}
+			if bar != nil {
+				bar.Redraw(progressRedrawEvery)
+			}
+
 			// get vertices
 			err = iterateOverStream(
 				stream,
 				func(resp *pb.StatusResponse) {
+					if bar != nil {
+						bar.Clear()
+						defer func() { bar.Draw(time.Now()) }()
+					}
+
+					if printOutput(resp) {
+						return
+					}
+
 					slog := flog.WithFields(log.Fields{
 						"stage": resp.Stage,
 						"run":   resp.Run,
 						"id":    resp.Meta.Id,
 					})
-					if resp.Run == pb.StatusResponse_STARTED {
+					// the bar shows which nodes are running
+					if resp.Run == pb.StatusResponse_STARTED && bar == nil {
 						slog.Info("got status")
 					} else {
 						slog.Debug("got status")
 					}
 
-					if resp.Stage == pb.StatusResponse_APPLY && resp.Run == pb.StatusResponse_FINISHED {
+					if bar != nil && resp.Stage == stage {
+						switch resp.Run {
+						case pb.StatusResponse_STARTED:
+							bar.Start(resp.Meta.Id, time.Now())
+						case pb.StatusResponse_FINISHED:
+							bar.Finish(resp.Meta.Id, time.Now())
+						}
+					}
+
+					if resp.Stage == stage && resp.Run == pb.StatusResponse_FINISHED {
+						found.add(resp)
+
 						details := resp.GetDetails()
 						if details != nil {
-							g.Add(node.New(resp.Id, details.ToPrintable()))
+							g.Add(resp.ToNode(details.ToPrintable()))
+
+							if len(details.RebootRequired) > 0 {
+								reboots[resp.Meta.Id] = details.RebootRequired
+							}
 						}
 					}
 				},
 			)
+			finishProgress(flog, fname, bar)
 			if err != nil {
 				flog.WithError(err).Fatal("could not get responses")
 			}
@@ -149,18 +224,63 @@ real happens.`,
 
 			fmt.Print("\n")
 			fmt.Print(out)
+
+			found.print()
+			warnPendingReboots(flog, reboots)
+
+			summary.add(fname, g)
 		}
+
+		finishRun(clog, summary, viper.GetBool(detailedExitCodeFlagName))
 	},
 }
 
+// statusStream is the stream of responses to either an apply or a plan
+type statusStream interface {
+	recver
+	headerer
+}
+
+// warnPendingReboots reports the resources that are waiting on a reboot. Only
+// an os.reboot resource depending on them will actually reboot the system.
+func warnPendingReboots(logger *log.Entry, reboots map[string][]string) {
+	var ids []string
+	for id := range reboots {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		logger.WithFields(log.Fields{
+			"id":     id,
+			"reason": strings.Join(reboots[id], ", "),
+		}).Warning("reboot required")
+	}
+}
+
 func init() {
 	applyCmd.Flags().Bool("show-meta", false, "show metadata (params and modules)")
 	applyCmd.Flags().Bool("only-show-changes", false, "only show changes")
+	applyCmd.Flags().Bool("quiet", false, "only show changes and errors")
+	applyCmd.Flags().Bool("verbose", false, "show the details of resources without changes")
 	applyCmd.Flags().Bool("verify-modules", false, "verify module signatures")
 	registerRPCFlags(applyCmd.Flags())
+	registerEvalFlags(applyCmd.Flags())
+	registerRefTraceFlags(applyCmd.Flags())
+	registerOverrideFlags(applyCmd.Flags())
 	registerLocalRPCFlags(applyCmd.Flags())
+	registerPlanCheckFlags(applyCmd.Flags())
+	registerProgressFlags(applyCmd.Flags())
+	registerLockFlags(applyCmd.Flags())
+	registerRateLimitFlags(applyCmd.Flags())
+	registerTraceFlags(applyCmd.Flags())
+	registerReportFlags(applyCmd.Flags())
+	registerBackupFlags(applyCmd.Flags())
+	registerMaintenanceFlags(applyCmd.Flags())
 	registerSSLFlags(applyCmd.Flags())
 	registerParamsFlags(applyCmd.Flags())
+	registerSummaryFlags(applyCmd.Flags())
+	registerExitCodeFlags(applyCmd.Flags())
 
 	RootCmd.AddCommand(applyCmd)
 }
@@ -0,0 +1,275 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/asteris-llc/converge/graph"
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/helpers/logging"
+	"github.com/asteris-llc/converge/image"
+	"github.com/asteris-llc/converge/rpc"
+	"github.com/asteris-llc/converge/rpc/pb"
+	"github.com/fgrid/uuid"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// buildImageCmd represents the build-image command
+var buildImageCmd = &cobra.Command{
+	Use:   "build-image",
+	Short: "build a container image by applying a module",
+	Long: `build-image starts a container from a base image, applies a module inside
+it, and commits the result as a new image. The module's resources run in the
+container just as if it had a target of {docker = "<build container>"}, so the
+same modules can be used to converge hosts and to build images. Resources
+that change the local system directly instead of running commands, such as
+file.content, can't be used in the module.`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 1 {
+			return fmt.Errorf("Need one module filename as argument, got %d", len(args))
+		}
+		if viper.GetString("base") == "" {
+			return errors.New("--base is required")
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		// set up execution context
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		GracefulExit(cancel)
+
+		// logging
+		clog := log.WithField("component", "client")
+		ctx = logging.WithLogger(ctx, clog)
+
+		// the build container is only reachable from this host, so the RPC
+		// server is always self-hosted
+		viper.Set(rpcEnableLocalName, true)
+		maybeSetToken()
+
+		labels, err := image.ParseLabels(imageLabels)
+		if err != nil {
+			clog.WithError(err).Fatal("invalid label")
+		}
+
+		source, err := moduleSource(args[0])
+		if err != nil {
+			clog.WithError(err).Fatal("could not find module")
+		}
+
+		ssl, err := getSSLConfig(getServerName())
+		if err != nil {
+			clog.WithError(err).Fatal("could not get SSL config")
+		}
+
+		if err = startRPC(ctx, getLocalAddr(), ssl, "", false); err != nil {
+			clog.WithError(err).Fatal("could not start RPC")
+		}
+
+		client, err := getRPCExecutorClient(
+			ctx,
+			&rpc.ClientOpts{
+				Token: getToken(),
+				SSL:   ssl,
+			},
+		)
+		if err != nil {
+			clog.WithError(err).Fatal("could not get client")
+		}
+
+		// start the build container
+		executor := exec.New()
+		base := viper.GetString("base")
+		blog := clog.WithField("base", base)
+
+		container, err := image.Start(executor, base, "converge-build-"+uuid.NewV4().String())
+		if err != nil {
+			blog.WithError(err).Fatal("could not start build container")
+		}
+		blog = blog.WithField("container", container.Name)
+		blog.Info("started build container")
+
+		id, err := buildInContainer(logging.WithLogger(ctx, blog), client, container, source, getParamsRPC(cmd), labels)
+
+		if rmErr := container.Remove(); rmErr != nil {
+			blog.WithError(rmErr).Warning("could not remove build container")
+		}
+
+		if err != nil {
+			blog.WithError(err).Fatal("could not build image")
+		}
+		blog.WithField("image", id).Info("committed image")
+
+		if output := viper.GetString("output"); output != "" {
+			if err := image.Save(executor, id, output); err != nil {
+				blog.WithError(err).Fatal("could not save image")
+			}
+			blog.WithField("output", output).Info("saved image")
+		}
+
+		fmt.Println(id)
+	},
+}
+
+// buildInContainer applies the module at source inside the container and
+// commits the container if every resource applied successfully
+func buildInContainer(ctx context.Context, client pb.ExecutorClient, container *image.Container, source string, params map[string]string, labels map[string]string) (string, error) {
+	logger := logging.GetLogger(ctx)
+
+	// write a module that applies the real one inside the container
+	dir, err := ioutil.TempDir("", "converge-build")
+	if err != nil {
+		return "", errors.Wrap(err, "could not create temporary directory")
+	}
+	defer os.RemoveAll(dir)
+
+	var names []string
+	for name := range params {
+		names = append(names, name)
+	}
+
+	wrapper := filepath.Join(dir, "build.hcl")
+	if err = ioutil.WriteFile(wrapper, container.Module(source, names), 0600); err != nil {
+		return "", errors.Wrap(err, "could not write build module")
+	}
+
+	verifyModules := viper.GetBool("verify-modules")
+	if !verifyModules {
+		logger.Warn("skipping module verification")
+	}
+
+	stream, err := client.Apply(
+		ctx,
+		&pb.LoadRequest{
+			Location:   wrapper,
+			Parameters: params,
+			Verify:     verifyModules,
+		},
+	)
+	if err != nil {
+		return "", errors.Wrap(err, "error getting RPC stream")
+	}
+
+	g := graph.New()
+
+	// get edges
+	edges, err := getMeta(stream)
+	if err != nil {
+		return "", errors.Wrap(err, "error getting RPC metadata")
+	}
+	for _, edge := range edges {
+		g.Connect(edge.Source, edge.Dest)
+	}
+
+	// get vertices
+	failed := false
+	err = iterateOverStream(
+		stream,
+		func(resp *pb.StatusResponse) {
+			if printOutput(resp) {
+				return
+			}
+
+			slog := logger.WithFields(log.Fields{
+				"stage": resp.Stage,
+				"run":   resp.Run,
+				"id":    resp.Meta.Id,
+			})
+			if resp.Run == pb.StatusResponse_STARTED {
+				slog.Info("got status")
+			} else {
+				slog.Debug("got status")
+			}
+
+			if resp.Stage == pb.StatusResponse_APPLY && resp.Run == pb.StatusResponse_FINISHED {
+				details := resp.GetDetails()
+				if details != nil {
+					g.Add(resp.ToNode(details.ToPrintable()))
+					if details.Error != "" {
+						failed = true
+					}
+				}
+			}
+		},
+	)
+	if err != nil {
+		return "", errors.Wrap(err, "could not get responses")
+	}
+
+	// print results
+	out, err := getPrinter().Show(ctx, g)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to print results")
+	}
+
+	fmt.Print("\n")
+	fmt.Print(out)
+
+	if failed {
+		return "", errors.New("not committing image because the module failed to apply")
+	}
+
+	return container.Commit(viper.GetString("tag"), labels)
+}
+
+// moduleSource makes local module paths absolute so that they can be sourced
+// from the generated build module
+func moduleSource(location string) (string, error) {
+	if strings.Contains(location, "://") {
+		return location, nil
+	}
+
+	return filepath.Abs(location)
+}
+
+// imageLabels is bound directly to the flag, since viper renders string slice
+// flags as a single bracketed string
+var imageLabels []string
+
+func init() {
+	buildImageCmd.Flags().String("base", "", "base image to build from")
+	buildImageCmd.Flags().String("tag", "", "tag for the committed image")
+	buildImageCmd.Flags().StringSliceVar(&imageLabels, "label", nil, "label to add to the image, as key=value (may be repeated)")
+	buildImageCmd.Flags().String("output", "", "also save the image to this tar archive")
+	buildImageCmd.Flags().Bool("show-meta", false, "show metadata (params and modules)")
+	buildImageCmd.Flags().Bool("only-show-changes", false, "only show changes")
+	buildImageCmd.Flags().Bool("quiet", false, "only show changes and errors")
+	buildImageCmd.Flags().Bool("verbose", false, "show the details of resources without changes")
+	buildImageCmd.Flags().Bool("verify-modules", false, "verify module signatures")
+	registerRPCFlags(buildImageCmd.Flags())
+	registerRefTraceFlags(buildImageCmd.Flags())
+	registerOverrideFlags(buildImageCmd.Flags())
+	buildImageCmd.Flags().String(rpcLocalAddrName, addrServerLocal, "address for local RPC connection")
+	registerSSLFlags(buildImageCmd.Flags())
+	registerParamsFlags(buildImageCmd.Flags())
+	registerPlanCheckFlags(buildImageCmd.Flags())
+	registerLockFlags(buildImageCmd.Flags())
+	registerRateLimitFlags(buildImageCmd.Flags())
+	registerTraceFlags(buildImageCmd.Flags())
+	registerReportFlags(buildImageCmd.Flags())
+	registerBackupFlags(buildImageCmd.Flags())
+
+	RootCmd.AddCommand(buildImageCmd)
+}
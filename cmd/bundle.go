@@ -0,0 +1,97 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/asteris-llc/converge/bundle"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const bundleOutputFlagName = "output"
+
+// bundleCmd represents the bundle command
+var bundleCmd = &cobra.Command{
+	Use:   "bundle MODULE",
+	Short: "bundle a module and every module it calls into a single file",
+	Long: `bundle loads a module along with every module it calls, wherever they
+are, and writes them to a single file that can be applied without fetching
+anything:
+
+    converge bundle main.hcl -o app.tgz -p env=prod
+    converge apply --local app.tgz
+
+Params given here are saved in the bundle, and params given when it is applied
+take precedence over them. The bundle has a manifest with the hash of every
+file in it, and is refused if anything has changed since it was made. When
+--verify-modules is given, the signatures of the modules are checked and
+saved in the bundle as well, so it can be applied with --verify-modules.`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 1 {
+			return errors.New("Need one module filename as argument")
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		GracefulExit(cancel)
+
+		fname := args[0]
+		output := viper.GetString(bundleOutputFlagName)
+		if output == "" {
+			output = strings.TrimSuffix(filepath.Base(fname), filepath.Ext(fname)) + bundle.Ext
+		}
+
+		flog := log.WithField("file", fname).WithField("bundle", output)
+
+		verifyModules := viper.GetBool("verify-modules")
+		if !verifyModules {
+			flog.Warn("skipping module verification")
+		}
+
+		out, err := os.Create(output)
+		if err != nil {
+			flog.WithError(err).Fatal("could not create bundle")
+		}
+
+		manifest, err := bundle.Create(ctx, out, fname, getParamsRPC(cmd), verifyModules)
+		if err == nil {
+			err = out.Close()
+		}
+		if err != nil {
+			out.Close()
+			os.Remove(output)
+			flog.WithError(err).Fatal("could not bundle module")
+		}
+
+		flog.WithField("files", len(manifest.Files)).WithField("hash", manifest.Hash).Info("bundled module")
+	},
+}
+
+func init() {
+	bundleCmd.Flags().StringP(bundleOutputFlagName, "o", "", "file to write the bundle to, named after the module by default")
+	bundleCmd.Flags().Bool("verify-modules", false, "verify module signatures, and save them in the bundle")
+	registerParamsFlags(bundleCmd.Flags())
+
+	RootCmd.AddCommand(bundleCmd)
+}
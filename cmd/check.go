@@ -0,0 +1,123 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/asteris-llc/converge/graph"
+	"github.com/asteris-llc/converge/helpers/logging"
+	"github.com/asteris-llc/converge/prettyprinters/human"
+	"github.com/spf13/cobra"
+)
+
+// checkCmd represents the check command
+var checkCmd = &cobra.Command{
+	Use:   "check",
+	Short: "check whether the system has drifted from your modules",
+	Long: `check runs the check stage of every resource, like plan, and never
+changes the system. It exits 0 when the system is converged, 2 when any
+resource would change, and 1 when a check fails. This makes it suitable for
+monitoring and CI gates.
+
+With --summary-out, a JSON summary of the run is written to the given path,
+with its status, exit code, and the resources that drifted or failed in each
+module.`,
+	PreRunE: requireModules,
+	Run: func(cmd *cobra.Command, args []string) {
+		// set up execution context
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		GracefulExit(cancel)
+
+		// logging
+		clog := log.WithField("component", "client")
+		ctx = logging.WithLogger(ctx, clog)
+
+		summary := newRunSummary("check", false)
+		planModules(ctx, cmd, args, summary)
+
+		fmt.Print("\n")
+		switch summary.ExitCode {
+		case exitError:
+			fmt.Printf("Drift: %d resources could not be checked\n", len(summary.failed()))
+
+		case exitDrift:
+			drifted := summary.changed()
+			fmt.Printf("Drift: %d resources have drifted\n", len(drifted))
+			for _, id := range drifted {
+				fmt.Printf(" * %s\n", id)
+			}
+
+		default:
+			fmt.Print("Drift: none, the system is converged\n")
+		}
+
+		finishRun(clog, summary, true)
+	},
+}
+
+// drift returns the IDs of the resources in a finished graph that have changes
+// and that failed. Modules, params, outputs, and the root are skipped.
+func drift(g *graph.Graph) (drifted, failed []string) {
+	isResource := human.HideByKind("module", "output", "param", "root")
+
+	for _, id := range g.Vertices() {
+		meta, ok := g.Get(id)
+		if !ok {
+			continue
+		}
+
+		printable, ok := meta.Value().(human.Printable)
+		if !ok || !isResource(id, printable) {
+			continue
+		}
+
+		if printable.Error() != nil {
+			failed = append(failed, id)
+		} else if printable.HasChanges() {
+			drifted = append(drifted, id)
+		}
+	}
+
+	sort.Strings(drifted)
+	sort.Strings(failed)
+	return drifted, failed
+}
+
+func init() {
+	checkCmd.Flags().Bool("show-meta", false, "show metadata (params and modules)")
+	checkCmd.Flags().Bool("only-show-changes", true, "only show changes")
+	checkCmd.Flags().Bool("quiet", false, "only show changes and errors")
+	checkCmd.Flags().Bool("verbose", false, "show the details of resources without changes")
+	checkCmd.Flags().Bool("verify-modules", false, "verify module signatures")
+	registerRPCFlags(checkCmd.Flags())
+	registerEvalFlags(checkCmd.Flags())
+	registerRefTraceFlags(checkCmd.Flags())
+	registerOverrideFlags(checkCmd.Flags())
+	registerLocalRPCFlags(checkCmd.Flags())
+	registerPlanCheckFlags(checkCmd.Flags())
+	registerLockFlags(checkCmd.Flags())
+	registerRateLimitFlags(checkCmd.Flags())
+	registerTraceFlags(checkCmd.Flags())
+	registerSSLFlags(checkCmd.Flags())
+	registerParamsFlags(checkCmd.Flags())
+	registerSummaryFlags(checkCmd.Flags())
+
+	RootCmd.AddCommand(checkCmd)
+}
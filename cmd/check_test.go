@@ -0,0 +1,93 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"testing"
+
+	"github.com/asteris-llc/converge/graph"
+	"github.com/asteris-llc/converge/graph/node"
+	"github.com/asteris-llc/converge/rpc/pb"
+	"github.com/stretchr/testify/assert"
+)
+
+// finished returns a graph of resources with the given details
+func finished(details map[string]*pb.StatusResponse_Details) *graph.Graph {
+	g := graph.New()
+	g.Add(node.New("root", (&pb.StatusResponse_Details{}).ToPrintable()))
+	for id, detail := range details {
+		g.Add(node.New(id, detail.ToPrintable()))
+		g.Connect("root", id)
+	}
+	return g
+}
+
+func TestDrift(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range []struct {
+		name    string
+		root    *pb.StatusResponse_Details
+		details map[string]*pb.StatusResponse_Details
+		drifted []string
+		failed  []string
+	}{
+		{
+			name: "drifted",
+			details: map[string]*pb.StatusResponse_Details{
+				"root/task.b":                {HasChanges: true},
+				"root/task.a":                {HasChanges: true},
+				"root/task.in-sync":          {},
+				"root/module.app/file.motd":  {HasChanges: true},
+				"root/module.app/task.other": {},
+			},
+			drifted: []string{"root/module.app/file.motd", "root/task.a", "root/task.b"},
+		},
+		{
+			name: "failed",
+			details: map[string]*pb.StatusResponse_Details{
+				"root/task.broken":  {Error: "exit status 1"},
+				"root/task.changed": {HasChanges: true, Error: "exit status 2"},
+				"root/task.drifted": {HasChanges: true},
+			},
+			drifted: []string{"root/task.drifted"},
+			failed:  []string{"root/task.broken", "root/task.changed"},
+		},
+		{
+			name: "skipped",
+			root: &pb.StatusResponse_Details{HasChanges: true, Error: "1 error(s) occurred"},
+			details: map[string]*pb.StatusResponse_Details{
+				"root/module.app":            {HasChanges: true},
+				"root/module.app/param.name": {HasChanges: true},
+				"root/param.name":            {Error: "no value"},
+				"root/output.address":        {HasChanges: true},
+			},
+		},
+	} {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			g := finished(test.details)
+			if test.root != nil {
+				g.Add(node.New("root", test.root.ToPrintable()))
+			}
+
+			drifted, failed := drift(g)
+			assert.Equal(t, test.drifted, drifted)
+			assert.Equal(t, test.failed, failed)
+		})
+	}
+}
@@ -0,0 +1,264 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// completionCmd represents the completion command
+var completionCmd = &cobra.Command{
+	Use:   "completion SHELL",
+	Short: "generate shell completion for bash, zsh, or fish",
+	Long: `completion prints a script that completes converge commands, flags,
+and resource names in the given shell. To load it in the current shell:
+
+    source <(converge completion bash)
+    source <(converge completion zsh)
+    converge completion fish | source
+
+Or save it where your shell loads completions from, such as
+/etc/bash_completion.d/converge, a directory in $fpath as _converge, or
+~/.config/fish/completions/converge.fish. Completion in bash needs the
+bash-completion package.`,
+	ValidArgs: []string{"bash", "fish", "zsh"},
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 1 {
+			return fmt.Errorf("Need exactly one shell as argument, got %d", len(args))
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		var err error
+		switch args[0] {
+		case "bash":
+			err = RootCmd.GenBashCompletion(os.Stdout)
+		case "zsh":
+			err = genZshCompletion(RootCmd, os.Stdout)
+		case "fish":
+			err = genFishCompletion(RootCmd, os.Stdout)
+		default:
+			log.WithField("shell", args[0]).Fatal("shell must be bash, zsh, or fish")
+		}
+
+		if err != nil {
+			log.WithError(err).Fatal("could not generate completion")
+		}
+	},
+}
+
+// genZshCompletion writes a zsh completion function for cmd and its
+// subcommands
+func genZshCompletion(cmd *cobra.Command, w io.Writer) error {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "#compdef %s\n", cmd.Name())
+	writeZshFunction(&buf, cmd)
+	fmt.Fprintf(&buf, "\ncompdef %s %s\n", zshFunctionName(cmd), cmd.Name())
+
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+func writeZshFunction(buf *bytes.Buffer, cmd *cobra.Command) {
+	subs := availableCommands(cmd)
+
+	fmt.Fprintf(buf, "\nfunction %s {\n", zshFunctionName(cmd))
+
+	if len(subs) > 0 {
+		buf.WriteString("  local -a commands\n\n")
+		buf.WriteString("  _arguments -C \\\n")
+		for _, arg := range zshFlags(cmd) {
+			fmt.Fprintf(buf, "    %s \\\n", arg)
+		}
+		buf.WriteString("    \"1: :->cmnds\" \\\n")
+		buf.WriteString("    \"*::arg:->args\"\n\n")
+
+		buf.WriteString("  case $state in\n  cmnds)\n    commands=(\n")
+		for _, sub := range subs {
+			fmt.Fprintf(buf, "      %s\n", zshQuote(zshEscape(sub.Name(), ":")+":"+sub.Short))
+		}
+		buf.WriteString("    )\n    _describe \"command\" commands\n    ;;\n  esac\n\n")
+
+		buf.WriteString("  case \"$words[1]\" in\n")
+		for _, sub := range subs {
+			fmt.Fprintf(buf, "  %s)\n    %s\n    ;;\n", sub.Name(), zshFunctionName(sub))
+		}
+		buf.WriteString("  esac\n}\n")
+
+		for _, sub := range subs {
+			writeZshFunction(buf, sub)
+		}
+		return
+	}
+
+	buf.WriteString("  _arguments")
+	for _, arg := range zshFlags(cmd) {
+		fmt.Fprintf(buf, " \\\n    %s", arg)
+	}
+	if len(cmd.ValidArgs) > 0 {
+		fmt.Fprintf(buf, " \\\n    %s", zshQuote("*: :("+strings.Join(cmd.ValidArgs, " ")+")"))
+	} else {
+		fmt.Fprintf(buf, " \\\n    %s", zshQuote("*: :_files"))
+	}
+	buf.WriteString("\n}\n")
+}
+
+func zshFunctionName(cmd *cobra.Command) string {
+	return "_" + strings.Replace(strings.Replace(cmd.CommandPath(), " ", "_", -1), "-", "_", -1)
+}
+
+// zshFlags lists the _arguments specs for the flags of cmd, including the
+// ones it inherits
+func zshFlags(cmd *cobra.Command) []string {
+	var out []string
+	visitFlags(cmd, func(flag *pflag.Flag) {
+		spec := "--" + flag.Name + "[" + zshEscape(flag.Usage, "[]") + "]"
+		if takesValue(flag) {
+			spec += ":" + flag.Name + ":"
+		}
+		out = append(out, zshQuote(spec))
+
+		if flag.Shorthand != "" {
+			spec = "-" + flag.Shorthand + "[" + zshEscape(flag.Usage, "[]") + "]"
+			if takesValue(flag) {
+				spec += ":" + flag.Name + ":"
+			}
+			out = append(out, zshQuote(spec))
+		}
+	})
+	return out
+}
+
+func zshEscape(s, chars string) string {
+	for _, c := range chars {
+		s = strings.Replace(s, string(c), `\`+string(c), -1)
+	}
+	return s
+}
+
+func zshQuote(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
+
+// genFishCompletion writes fish completions for cmd and its subcommands
+func genFishCompletion(cmd *cobra.Command, w io.Writer) error {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "complete -c %s -f\n", cmd.Name())
+	writeFishCompletions(&buf, cmd.Name(), cmd, nil)
+
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+// writeFishCompletions writes the completions for cmd, which is the command
+// reached through the subcommand names in path
+func writeFishCompletions(buf *bytes.Buffer, root string, cmd *cobra.Command, path []string) {
+	condition := fishCondition(path)
+	subs := availableCommands(cmd)
+
+	for _, sub := range subs {
+		subCondition := "__fish_use_subcommand"
+		if len(path) > 0 {
+			subCondition = condition + "; and not __fish_seen_subcommand_from " + sub.Name()
+		}
+		fmt.Fprintf(buf, "complete -c %s -n %s -a %s -d %s\n", root, fishQuote(subCondition), sub.Name(), fishQuote(sub.Short))
+	}
+
+	flags := cmd.LocalFlags()
+	if len(path) == 0 {
+		flags = cmd.PersistentFlags()
+	}
+	flags.VisitAll(func(flag *pflag.Flag) {
+		if flag.Hidden {
+			return
+		}
+
+		fmt.Fprintf(buf, "complete -c %s", root)
+		if condition != "" {
+			fmt.Fprintf(buf, " -n %s", fishQuote(condition))
+		}
+		fmt.Fprintf(buf, " -l %s", flag.Name)
+		if flag.Shorthand != "" {
+			fmt.Fprintf(buf, " -s %s", flag.Shorthand)
+		}
+		if takesValue(flag) {
+			buf.WriteString(" -r")
+		}
+		fmt.Fprintf(buf, " -d %s\n", fishQuote(flag.Usage))
+	})
+
+	if len(subs) == 0 && len(path) > 0 {
+		if len(cmd.ValidArgs) > 0 {
+			fmt.Fprintf(buf, "complete -c %s -n %s -a %s\n", root, fishQuote(condition), fishQuote(strings.Join(cmd.ValidArgs, " ")))
+		} else {
+			fmt.Fprintf(buf, "complete -c %s -n %s -F\n", root, fishQuote(condition))
+		}
+	}
+
+	for _, sub := range subs {
+		writeFishCompletions(buf, root, sub, append(append([]string{}, path...), sub.Name()))
+	}
+}
+
+func fishCondition(path []string) string {
+	var parts []string
+	for _, name := range path {
+		parts = append(parts, "__fish_seen_subcommand_from "+name)
+	}
+	return strings.Join(parts, "; and ")
+}
+
+func fishQuote(s string) string {
+	return "'" + strings.Replace(strings.Replace(s, `\`, `\\`, -1), "'", `\'`, -1) + "'"
+}
+
+// availableCommands lists the subcommands of cmd that are shown in help
+func availableCommands(cmd *cobra.Command) []*cobra.Command {
+	var out []*cobra.Command
+	for _, sub := range cmd.Commands() {
+		if sub.IsAvailableCommand() {
+			out = append(out, sub)
+		}
+	}
+	return out
+}
+
+// visitFlags calls fn for every visible flag of cmd, including inherited ones
+func visitFlags(cmd *cobra.Command, fn func(*pflag.Flag)) {
+	visit := func(flag *pflag.Flag) {
+		if !flag.Hidden {
+			fn(flag)
+		}
+	}
+	cmd.NonInheritedFlags().VisitAll(visit)
+	cmd.InheritedFlags().VisitAll(visit)
+}
+
+// takesValue is false for flags that can be given alone, like booleans
+func takesValue(flag *pflag.Flag) bool {
+	return flag.NoOptDefVal == ""
+}
+
+func init() {
+	RootCmd.AddCommand(completionCmd)
+}
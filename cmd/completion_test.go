@@ -0,0 +1,76 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// completionTree builds a small command tree like converge's
+func completionTree() *cobra.Command {
+	root := &cobra.Command{Use: "tool"}
+	root.PersistentFlags().BoolP("quiet", "q", false, "don't say [much]")
+
+	run := func(*cobra.Command, []string) {}
+
+	apply := &cobra.Command{Use: "apply", Short: "apply it", Run: run}
+	apply.Flags().String("params", "", "the params")
+
+	explain := &cobra.Command{Use: "explain", Short: "it's explained", Run: run, ValidArgs: []string{"a.b", "c"}}
+
+	key := &cobra.Command{Use: "key", Short: "keys"}
+	key.AddCommand(&cobra.Command{Use: "trust", Short: "trust a key", Run: run})
+
+	root.AddCommand(apply, explain, key)
+	return root
+}
+
+func TestGenZshCompletion(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	require.NoError(t, genZshCompletion(completionTree(), &buf))
+	out := buf.String()
+
+	assert.Contains(t, out, "#compdef tool\n")
+	assert.Contains(t, out, "compdef _tool tool\n")
+	assert.Contains(t, out, `'explain:it'\''s explained'`)
+	assert.Contains(t, out, `'--quiet[don'\''t say \[much\]]'`)
+	assert.Contains(t, out, `'--params[the params]:params:'`)
+	assert.Contains(t, out, `'*: :(a.b c)'`)
+	assert.Contains(t, out, "function _tool_key_trust {")
+	assert.Contains(t, out, "  trust)\n    _tool_key_trust\n")
+}
+
+func TestGenFishCompletion(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	require.NoError(t, genFishCompletion(completionTree(), &buf))
+	out := buf.String()
+
+	assert.Contains(t, out, "complete -c tool -f\n")
+	assert.Contains(t, out, "complete -c tool -n '__fish_use_subcommand' -a explain -d 'it\\'s explained'\n")
+	assert.Contains(t, out, "complete -c tool -l quiet -s q -d 'don\\'t say [much]'\n")
+	assert.Contains(t, out, "complete -c tool -n '__fish_seen_subcommand_from apply' -l params -r -d 'the params'\n")
+	assert.Contains(t, out, "complete -c tool -n '__fish_seen_subcommand_from apply' -F\n")
+	assert.Contains(t, out, "complete -c tool -n '__fish_seen_subcommand_from explain' -a 'a.b c'\n")
+	assert.Contains(t, out, "complete -c tool -n '__fish_seen_subcommand_from key; and not __fish_seen_subcommand_from trust' -a trust -d 'trust a key'\n")
+}
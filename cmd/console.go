@@ -0,0 +1,122 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/asteris-llc/converge/console"
+	"github.com/asteris-llc/converge/plan"
+	"github.com/asteris-llc/converge/render"
+	"github.com/asteris-llc/converge/rpc/pb"
+	"github.com/mattn/go-isatty"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const consolePlanFlagName = "plan"
+
+// consoleCmd represents the console command
+var consoleCmd = &cobra.Command{
+	Use:   "console MODULE",
+	Short: "evaluate templates and lookups against a module",
+	Long: `console loads a module and reads templates from standard input,
+rendering each one as if it were a field of a node in the module. Use it to
+find out what a lookup or param resolves to, and why one doesn't:
+
+    $ converge console main.hcl -p name=world
+    > param ` + "`name`" + `
+    world
+    > :resolve task.query.q.status.stdout
+    node: root/task.query.q
+    fields: status.stdout
+
+Some values, like the output of a task.query, are only known once their node
+has been checked. Pass --plan to check the module first so that they can be
+looked up. Nothing is changed either way.
+
+Type :help in the console for its other commands.`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 1 {
+			return fmt.Errorf("Need one module filename as argument, got %d", len(args))
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		fname := args[0]
+		flog := log.WithField("file", fname)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		GracefulExit(cancel)
+		ctx = withRefTraces(ctx)
+		ctx = withOverrides(ctx)
+
+		params := getParamsRPC(cmd)
+
+		var snapshot *render.Snapshot
+		if dir := viper.GetString(showSnapshotDirFlagName); dir != "" {
+			var err error
+			if snapshot, err = render.LoadSnapshot(render.SnapshotPath(dir, fname, params)); err != nil {
+				flog.WithError(err).Warning("skipping snapshot")
+			}
+		}
+
+		g, err := (&pb.LoadRequest{
+			Location:   fname,
+			Parameters: params,
+			Verify:     viper.GetBool("verify-modules"),
+		}).Load(ctx)
+		if err != nil {
+			flog.WithError(err).Fatal("could not load module")
+		}
+
+		if viper.GetBool(consolePlanFlagName) {
+			g, err = plan.Plan(render.WithSnapshot(ctx, snapshot), g)
+			if err != nil && err != plan.ErrTreeContainsErrors {
+				flog.WithError(err).Fatal("could not check module")
+			}
+		}
+
+		c, err := console.New(g)
+		if err != nil {
+			flog.WithError(err).Fatal("could not start console")
+		}
+		c.Previous = snapshot
+		c.Trace = getRefTraces()
+
+		if isatty.IsTerminal(os.Stdin.Fd()) {
+			c.Prompt = "> "
+		}
+
+		if err := c.Run(os.Stdin, os.Stdout); err != nil {
+			flog.WithError(err).Fatal("console failed")
+		}
+	},
+}
+
+func init() {
+	consoleCmd.Flags().Bool(consolePlanFlagName, false, "check the module first, so values only known after checking can be looked up")
+	consoleCmd.Flags().String(showSnapshotDirFlagName, render.DefaultSnapshotDir, "directory the server keeps the values from the last apply of each module in")
+	consoleCmd.Flags().Bool("verify-modules", false, "verify module signatures")
+	registerParamsFlags(consoleCmd.Flags())
+
+	registerRefTraceFlags(consoleCmd.Flags())
+	registerOverrideFlags(consoleCmd.Flags())
+	RootCmd.AddCommand(consoleCmd)
+}
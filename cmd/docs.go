@@ -0,0 +1,240 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/asteris-llc/converge/load/registry"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// docsCmd represents the docs command
+var docsCmd = &cobra.Command{
+	Use:   "docs [RESOURCE...]",
+	Short: "generate reference documentation for resources",
+	Long: `docs prints reference documentation for every resource converge knows
+about, or only the ones named, for example:
+
+    converge docs user.user user.group
+
+The fields, types, and validations are read from the resources themselves.
+Descriptions are read from the Go source of each resource when it can be found
+in GOPATH, and are left out otherwise.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		format := viper.GetString("format")
+		if format != "markdown" && format != "html" {
+			log.WithField("format", format).Fatal("format must be markdown or html")
+		}
+
+		names := args
+		if len(names) == 0 {
+			names = resourceNames()
+		}
+
+		var refs []*resource.Reference
+		for _, name := range names {
+			nlog := log.WithField("resource", name)
+
+			preparer, ok := registry.NewByName(name)
+			if !ok {
+				nlog.Fatal("no such resource")
+			}
+
+			ref, err := resource.NewReference(name, preparer)
+			if err != nil {
+				nlog.WithError(err).Fatal("could not document")
+			}
+			refs = append(refs, ref)
+		}
+
+		output := viper.GetString("output")
+		if output == "" {
+			if err := writeDocs(os.Stdout, format, refs); err != nil {
+				log.WithError(err).Fatal("could not write docs")
+			}
+			return
+		}
+
+		if err := os.MkdirAll(output, 0755); err != nil {
+			log.WithError(err).Fatal("could not create output directory")
+		}
+
+		ext := map[string]string{"markdown": ".md", "html": ".html"}[format]
+		for _, ref := range refs {
+			var buf bytes.Buffer
+			if err := writeDocs(&buf, format, []*resource.Reference{ref}); err != nil {
+				log.WithError(err).WithField("resource", ref.Name).Fatal("could not write docs")
+			}
+
+			dest := filepath.Join(output, ref.Name+ext)
+			if err := ioutil.WriteFile(dest, buf.Bytes(), 0644); err != nil {
+				log.WithError(err).WithField("file", dest).Fatal("could not write docs")
+			}
+		}
+	},
+}
+
+// resourceNames lists the resources that can be written in modules
+func resourceNames() []string {
+	var names []string
+	for _, name := range registry.Names() {
+		// generated by the switch preprocessor, not written in modules
+		if !strings.HasPrefix(name, "macro.") {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func writeDocs(w io.Writer, format string, refs []*resource.Reference) error {
+	if format == "html" {
+		return htmlDocs.Execute(w, refs)
+	}
+	return markdownDocs.Execute(w, refs)
+}
+
+// joinPlatforms lists the operating systems a resource works on
+func joinPlatforms(platforms []string) string {
+	return strings.Join(platforms, ", ")
+}
+
+// constraints describes the validations on a field in sentences, with names
+// and values quoted in backticks
+func constraints(field *resource.FieldReference) []string {
+	var out []string
+
+	if len(field.MutuallyExclusive) > 0 {
+		out = append(out, fmt.Sprintf("Only one of %s may be set.", codeJoin(field.MutuallyExclusive, "or")))
+	}
+
+	if len(field.ValidValues) > 0 {
+		out = append(out, fmt.Sprintf("Valid values: %s", codeJoin(field.ValidValues, "and")))
+	}
+
+	if field.Default != "" {
+		out = append(out, fmt.Sprintf("Defaults to `%s`.", field.Default))
+	}
+
+	switch {
+	case field.Min != "" && field.Max != "":
+		out = append(out, fmt.Sprintf("Must be between %s and %s.", field.Min, field.Max))
+	case field.Min != "":
+		out = append(out, fmt.Sprintf("Must be at least %s.", field.Min))
+	case field.Max != "":
+		out = append(out, fmt.Sprintf("Must be at most %s.", field.Max))
+	}
+
+	if field.Regex != "" {
+		out = append(out, fmt.Sprintf("Must match `%s`.", field.Regex))
+	}
+
+	return out
+}
+
+func codeJoin(items []string, terminal string) string {
+	quoted := make([]string, len(items))
+	for i, item := range items {
+		quoted[i] = "`" + item + "`"
+	}
+
+	if len(quoted) < 2 {
+		return strings.Join(quoted, "")
+	}
+	if len(quoted) == 2 {
+		return quoted[0] + " " + terminal + " " + quoted[1]
+	}
+	return strings.Join(quoted[:len(quoted)-1], ", ") + ", " + terminal + " " + quoted[len(quoted)-1]
+}
+
+var backticks = regexp.MustCompile("`([^`]*)`")
+
+var (
+	markdownDocs = template.Must(template.New("markdown").Funcs(template.FuncMap{
+		"constraints": constraints,
+		"join":        joinPlatforms,
+		"indent": func(s string) string {
+			lines := strings.Split(s, "\n")
+			for i, line := range lines {
+				if line != "" {
+					lines[i] = "  " + line
+				}
+			}
+			return strings.Join(lines, "\n")
+		},
+	}).Parse(`{{range $i, $ref := .}}{{if $i}}
+{{end}}# {{.Name}}
+{{if .Doc}}
+{{.Doc}}
+{{end}}{{if .Platforms}}
+Only works on {{join .Platforms}}.
+{{end}}
+## Parameters
+{{template "fields" .Fields}}{{end}}{{define "fields"}}{{range .}}
+- ` + "`{{.Name}}`" + ` ({{if .Required}}required {{end}}{{if .Base}}base {{.Base}} {{end}}{{.Type}})
+{{range constraints .}}
+  {{.}}
+{{end}}{{if .Doc}}
+{{indent .Doc}}
+{{end}}{{end}}{{end}}`))
+
+	markdownFields = markdownDocs.Lookup("fields")
+
+	htmlDocs = htmltemplate.Must(htmltemplate.New("html").Funcs(htmltemplate.FuncMap{
+		"constraints": constraints,
+		"join":        joinPlatforms,
+		"code": func(s string) htmltemplate.HTML {
+			escaped := htmltemplate.HTMLEscapeString(s)
+			return htmltemplate.HTML(backticks.ReplaceAllString(escaped, "<code>$1</code>"))
+		},
+	}).Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Converge Resources</title></head>
+<body>
+{{range .}}<section id="{{.Name}}">
+<h1>{{.Name}}</h1>
+{{if .Doc}}<p>{{code .Doc}}</p>
+{{end}}{{if .Platforms}}<p>Only works on {{join .Platforms}}.</p>
+{{end}}<h2>Parameters</h2>
+<dl>
+{{range .Fields}}<dt><code>{{.Name}}</code> ({{if .Required}}required {{end}}{{if .Base}}base {{.Base}} {{end}}{{.Type}})</dt>
+<dd>{{range constraints .}}<p>{{code .}}</p>{{end}}{{if .Doc}}<p>{{code .Doc}}</p>{{end}}</dd>
+{{end}}</dl>
+</section>
+{{end}}</body>
+</html>
+`))
+)
+
+func init() {
+	docsCmd.Flags().String("format", "markdown", "format to write, markdown or html")
+	docsCmd.Flags().String("output", "", "write a file for each resource to this directory instead of stdout")
+
+	docsCmd.ValidArgs = resourceNames()
+
+	RootCmd.AddCommand(docsCmd)
+}
@@ -0,0 +1,130 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"go/build"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/asteris-llc/converge/load/registry"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/spf13/cobra"
+)
+
+// explainCmd represents the explain command
+var explainCmd = &cobra.Command{
+	Use:   "explain RESOURCE",
+	Short: "describe a resource and the fields it exports",
+	Long: `explain prints what a resource does, the parameters it accepts, and the
+fields of it that can be used in a lookup, for example:
+
+    converge explain file.content
+
+An example of the resource is included when the converge source can be found
+in GOPATH.`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 1 {
+			return fmt.Errorf("Need exactly one resource as argument, got %d", len(args))
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		name := args[0]
+		rlog := log.WithField("resource", name)
+
+		preparer, ok := registry.NewByName(name)
+		if !ok {
+			rlog.Fatal("no such resource")
+		}
+
+		ref, err := resource.NewReference(name, preparer)
+		if err != nil {
+			rlog.WithError(err).Fatal("could not document")
+		}
+
+		if err := writeDocs(os.Stdout, "markdown", []*resource.Reference{ref}); err != nil {
+			rlog.WithError(err).Fatal("could not write docs")
+		}
+
+		for _, typ := range registry.TypesForName(name) {
+			exports, err := resource.Exports(reflect.Zero(typ).Interface())
+			if err != nil {
+				rlog.WithError(err).Fatal("could not document exported fields")
+			}
+			if len(exports) == 0 {
+				continue
+			}
+
+			fmt.Printf("\n## Exported Fields\n\nUse these as {{lookup `%s.NAME.FIELD`}}\n", name)
+			if err := markdownFields.Execute(os.Stdout, exports); err != nil {
+				rlog.WithError(err).Fatal("could not write docs")
+			}
+			break
+		}
+
+		example, err := exampleFor(name)
+		if err != nil {
+			rlog.WithError(err).Debug("no example")
+			return
+		}
+		fmt.Printf("\n## Example\n\n```hcl\n%s```\n", example)
+	},
+}
+
+// exampleFor finds the sample module for a resource through the list the
+// documentation is generated from, in the converge source
+func exampleFor(name string) (string, error) {
+	pkg, err := build.Import("github.com/asteris-llc/converge", "", build.FindOnly)
+	if err != nil {
+		return "", err
+	}
+
+	docs := filepath.Join(pkg.Dir, "docs")
+	sources, err := os.Open(filepath.Join(docs, "sources.csv"))
+	if err != nil {
+		return "", err
+	}
+	defer sources.Close()
+
+	scanner := bufio.NewScanner(sources)
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), ",")
+		if len(fields) < 3 || fields[0] != name {
+			continue
+		}
+
+		example, err := ioutil.ReadFile(filepath.Join(docs, fields[2]))
+		return string(example), err
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+
+	return "", errors.New("not listed in sources.csv")
+}
+
+func init() {
+	explainCmd.ValidArgs = resourceNames()
+
+	RootCmd.AddCommand(explainCmd)
+}
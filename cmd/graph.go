@@ -114,6 +114,8 @@ func init() {
 	registerParamsFlags(graphCmd.Flags())
 	registerSSLFlags(graphCmd.Flags())
 	registerRPCFlags(graphCmd.Flags())
+	registerRefTraceFlags(graphCmd.Flags())
+	registerOverrideFlags(graphCmd.Flags())
 	registerLocalRPCFlags(graphCmd.Flags())
 
 	RootCmd.AddCommand(graphCmd)
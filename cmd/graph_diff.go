@@ -0,0 +1,130 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/asteris-llc/converge/graph/diff"
+	"github.com/asteris-llc/converge/rpc"
+	"github.com/asteris-llc/converge/rpc/pb"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const graphDiffExitChanged = 2
+
+// graphDiffCmd represents the graph diff command
+var graphDiffCmd = &cobra.Command{
+	Use:   "diff OLD NEW",
+	Short: "compare the graphs of two versions of a module",
+	Long: `diff renders two versions of a module with the same params and reports
+the nodes and dependencies that were added, removed or changed between them,
+without touching the system:
+
+    converge graph diff old/ new/ -p env=prod
+
+Either argument may be a module file or a directory, in which case the module
+named by --file inside it is used. diff exits 0 when the graphs are the same,
+2 when they differ, and 1 when either module can't be rendered.`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 2 {
+			return fmt.Errorf("Need two modules to compare as arguments, got %d", len(args))
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		// set up execution context
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		GracefulExit(cancel)
+
+		// logging
+		flog := log.WithField("component", "client")
+
+		maybeSetToken()
+
+		ssl, err := getSSLConfig(getServerName())
+		if err != nil {
+			flog.WithError(err).Fatal("could not get SSL config")
+		}
+
+		if err := maybeStartSelfHostedRPC(ctx, ssl); err != nil {
+			flog.WithError(err).Fatal("could not start RPC")
+		}
+
+		client, err := getRPCGrapherClient(
+			ctx,
+			&rpc.ClientOpts{
+				Token: getToken(),
+				SSL:   ssl,
+			},
+		)
+		if err != nil {
+			flog.WithError(err).Fatal("could not get client")
+		}
+
+		params := getParamsRPC(cmd)
+		file := viper.GetString("file")
+
+		before, err := client.Graph(ctx, &pb.LoadRequest{Location: moduleIn(args[0], file), Parameters: params})
+		if err != nil {
+			flog.WithField("file", args[0]).WithError(err).Fatal("could not get graph")
+		}
+
+		after, err := client.Graph(ctx, &pb.LoadRequest{Location: moduleIn(args[1], file), Parameters: params})
+		if err != nil {
+			flog.WithField("file", args[1]).WithError(err).Fatal("could not get graph")
+		}
+
+		result, err := diff.Graphs(before, after)
+		if err != nil {
+			flog.WithError(err).Fatal("could not compare graphs")
+		}
+
+		if err := result.Write(os.Stdout); err != nil {
+			flog.WithError(err).Fatal("could not write differences")
+		}
+
+		if !result.Empty() {
+			os.Exit(graphDiffExitChanged)
+		}
+	},
+}
+
+// moduleIn returns the module file to load for a diff argument, which names
+// either the module itself or a directory holding it
+func moduleIn(location, file string) string {
+	if stat, err := os.Stat(location); err == nil && stat.IsDir() {
+		return filepath.Join(location, file)
+	}
+	return location
+}
+
+func init() {
+	graphDiffCmd.Flags().String("file", "main.hcl", "module to compare in directory arguments")
+	registerParamsFlags(graphDiffCmd.Flags())
+	registerSSLFlags(graphDiffCmd.Flags())
+	registerRPCFlags(graphDiffCmd.Flags())
+	registerRefTraceFlags(graphDiffCmd.Flags())
+	registerOverrideFlags(graphDiffCmd.Flags())
+	registerLocalRPCFlags(graphDiffCmd.Flags())
+
+	graphCmd.AddCommand(graphDiffCmd)
+}
@@ -21,7 +21,6 @@ import (
 
 	log "github.com/Sirupsen/logrus"
 	"github.com/asteris-llc/converge/graph"
-	"github.com/asteris-llc/converge/graph/node"
 	"github.com/asteris-llc/converge/helpers/logging"
 	"github.com/asteris-llc/converge/rpc"
 	"github.com/asteris-llc/converge/rpc/pb"
@@ -128,7 +127,7 @@ not display healthy checks.`,
 					if resp.Run == pb.StatusResponse_FINISHED {
 						details := resp.GetDetails()
 						if details != nil {
-							g.Add(node.New(resp.Id, details.ToPrintable()))
+							g.Add(resp.ToNode(details.ToPrintable()))
 						}
 					}
 				},
@@ -158,7 +157,11 @@ func init() {
 	healthcheckCmd.Flags().Bool("quiet", false, "show only a short summary of the status")
 	healthcheckCmd.Flags().Bool("verify-modules", false, "verify module signatures")
 	registerRPCFlags(healthcheckCmd.Flags())
+	registerRefTraceFlags(healthcheckCmd.Flags())
+	registerOverrideFlags(healthcheckCmd.Flags())
 	registerLocalRPCFlags(healthcheckCmd.Flags())
+	registerLockFlags(healthcheckCmd.Flags())
+	registerRateLimitFlags(healthcheckCmd.Flags())
 	registerSSLFlags(healthcheckCmd.Flags())
 	registerParamsFlags(healthcheckCmd.Flags())
 
@@ -0,0 +1,97 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/load/registry"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/spf13/cobra"
+)
+
+// importCmd represents the import command
+var importCmd = &cobra.Command{
+	Use:   "import RESOURCE NAME...",
+	Short: "generate HCL for objects that already exist on this system",
+	Long: `import reads existing objects from the local system and prints the
+resource blocks that would reproduce them, for example:
+
+    converge import user.user alice bob
+
+Only some resources can be imported. Review the output before using it, as
+sensitive fields such as password hashes are left out.`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 2 {
+			return errors.New("Need a resource and at least one name as arguments")
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		kind, names := args[0], args[1:]
+		klog := log.WithField("resource", kind)
+
+		preparer, ok := registry.NewByName(kind)
+		if !ok {
+			klog.Fatal("no such resource")
+		}
+
+		importer, ok := preparer.(resource.Importer)
+		if !ok {
+			klog.Fatal("resource cannot be imported")
+		}
+
+		for i, name := range names {
+			nlog := klog.WithField("name", name)
+
+			fields, err := importer.Import(exec.New(), name)
+			if err != nil {
+				nlog.WithError(err).Fatal("could not import")
+			}
+
+			out, err := resource.ImportHCL(kind, name, preparer, fields)
+			if err != nil {
+				nlog.WithError(err).Fatal("could not format")
+			}
+
+			if i > 0 {
+				fmt.Println()
+			}
+			os.Stdout.Write(out)
+		}
+	},
+}
+
+// importableNames lists the resources that implement resource.Importer
+func importableNames() []string {
+	var names []string
+	for _, name := range registry.Names() {
+		preparer, _ := registry.NewByName(name)
+		if _, ok := preparer.(resource.Importer); ok {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func init() {
+	importCmd.ValidArgs = importableNames()
+
+	RootCmd.AddCommand(importCmd)
+}
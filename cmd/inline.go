@@ -0,0 +1,99 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+const (
+	evalFlagName = "eval"
+
+	// stdinArg is the module argument read from stdin
+	stdinArg = "-"
+
+	// stdinLocation and evalLocation name modules that aren't in a file, in
+	// logs and in the positions of their nodes
+	stdinLocation = "<stdin>"
+	evalLocation  = "<eval>"
+)
+
+// moduleArg is a module to send to the server, along with its content if it
+// isn't in a file the server can load
+type moduleArg struct {
+	Location string
+	Content  string
+}
+
+func registerEvalFlags(flags *pflag.FlagSet) {
+	flags.StringP(evalFlagName, "e", "", "a module to use after the ones in the arguments, like 'file.content \"x\" { destination = \"x.txt\" }'")
+}
+
+// requireModules checks that a command was given at least one module
+func requireModules(cmd *cobra.Command, args []string) error {
+	eval, err := cmd.Flags().GetString(evalFlagName)
+	if err != nil {
+		return err
+	}
+
+	if len(args) == 0 && eval == "" {
+		return errors.New("Need at least one module filename as argument or a module given with --eval, got 0")
+	}
+	return nil
+}
+
+// getModuleArgs gets the modules named by the arguments. A "-" argument is
+// read from stdin, and the module given with --eval comes after the others.
+func getModuleArgs(cmd *cobra.Command, args []string, stdin io.Reader) ([]*moduleArg, error) {
+	var (
+		out     []*moduleArg
+		readIn  bool
+		content []byte
+		err     error
+	)
+
+	for _, arg := range args {
+		if arg != stdinArg {
+			out = append(out, &moduleArg{Location: arg})
+			continue
+		}
+
+		if readIn {
+			return nil, errors.New("a module can only be read from stdin once")
+		}
+		readIn = true
+
+		content, err = ioutil.ReadAll(stdin)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, &moduleArg{Location: stdinLocation, Content: string(content)})
+	}
+
+	eval, err := cmd.Flags().GetString(evalFlagName)
+	if err != nil {
+		return nil, err
+	}
+	if eval != "" {
+		out = append(out, &moduleArg{Location: evalLocation, Content: eval})
+	}
+
+	return out, nil
+}
@@ -0,0 +1,65 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func evalCmd(t *testing.T, flags ...string) *cobra.Command {
+	cmd := &cobra.Command{Use: "apply"}
+	registerEvalFlags(cmd.Flags())
+	require.NoError(t, cmd.Flags().Parse(flags))
+	return cmd
+}
+
+func TestGetModuleArgs(t *testing.T) {
+	t.Parallel()
+
+	t.Run("files", func(t *testing.T) {
+		mods, err := getModuleArgs(evalCmd(t), []string{"a.hcl", "b.hcl"}, strings.NewReader(""))
+		require.NoError(t, err)
+		assert.Equal(t, []*moduleArg{{Location: "a.hcl"}, {Location: "b.hcl"}}, mods)
+	})
+
+	t.Run("stdin", func(t *testing.T) {
+		mods, err := getModuleArgs(evalCmd(t), []string{"a.hcl", "-"}, strings.NewReader(`task "x" {}`))
+		require.NoError(t, err)
+		assert.Equal(t, []*moduleArg{{Location: "a.hcl"}, {Location: stdinLocation, Content: `task "x" {}`}}, mods)
+	})
+
+	t.Run("stdin twice", func(t *testing.T) {
+		_, err := getModuleArgs(evalCmd(t), []string{"-", "-"}, strings.NewReader(""))
+		assert.EqualError(t, err, "a module can only be read from stdin once")
+	})
+
+	t.Run("eval", func(t *testing.T) {
+		cmd := evalCmd(t, "-e", `task "y" {}`)
+		require.NoError(t, requireModules(cmd, nil))
+
+		mods, err := getModuleArgs(cmd, []string{"a.hcl"}, strings.NewReader(""))
+		require.NoError(t, err)
+		assert.Equal(t, []*moduleArg{{Location: "a.hcl"}, {Location: evalLocation, Content: `task "y" {}`}}, mods)
+	})
+
+	t.Run("none", func(t *testing.T) {
+		assert.Error(t, requireModules(evalCmd(t), nil))
+	})
+}
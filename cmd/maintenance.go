@@ -0,0 +1,74 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"time"
+
+	"github.com/asteris-llc/converge/helpers/schedule"
+	"github.com/pkg/errors"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+const (
+	maintenanceWindowFlagName   = "maintenance-window"
+	maintenanceDurationFlagName = "maintenance-duration"
+	maintenanceTimezoneFlagName = "maintenance-timezone"
+	maintenanceForceFlagName    = "force"
+)
+
+func registerMaintenanceFlags(flags *pflag.FlagSet) {
+	flags.String(maintenanceWindowFlagName, "", `cron expression for when changes may be applied, like "0 2 * * 6" (empty to always apply)`)
+	flags.Duration(maintenanceDurationFlagName, time.Hour, "how long the maintenance window stays open")
+	flags.String(maintenanceTimezoneFlagName, "Local", "timezone of the maintenance window, like UTC or America/Chicago")
+	flags.Bool(maintenanceForceFlagName, false, "apply changes even outside the maintenance window")
+}
+
+// getMaintenanceWindow returns the window for the maintenance flags, or nil
+// if none is set
+func getMaintenanceWindow() (*schedule.Window, error) {
+	expr := viper.GetString(maintenanceWindowFlagName)
+	if expr == "" {
+		return nil, nil
+	}
+
+	loc, err := time.LoadLocation(viper.GetString(maintenanceTimezoneFlagName))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not load maintenance timezone")
+	}
+
+	window, err := schedule.Parse(expr, viper.GetDuration(maintenanceDurationFlagName), loc)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid maintenance window")
+	}
+
+	return window, nil
+}
+
+// outsideMaintenance returns the maintenance window if it is set and closed
+// at now, in which case apply only plans, unless forced
+func outsideMaintenance(now time.Time) (*schedule.Window, error) {
+	if viper.GetBool(maintenanceForceFlagName) {
+		return nil, nil
+	}
+
+	window, err := getMaintenanceWindow()
+	if err != nil || window == nil || window.Open(now) {
+		return nil, err
+	}
+
+	return window, nil
+}
@@ -16,12 +16,11 @@ package cmd
 
 import (
 	"context"
-	"errors"
 	"fmt"
+	"os"
 
 	log "github.com/Sirupsen/logrus"
 	"github.com/asteris-llc/converge/graph"
-	"github.com/asteris-llc/converge/graph/node"
 	"github.com/asteris-llc/converge/helpers/logging"
 	"github.com/asteris-llc/converge/rpc"
 	"github.com/asteris-llc/converge/rpc/pb"
@@ -34,13 +33,12 @@ var planCmd = &cobra.Command{
 	Use:   "plan",
 	Short: "plan what needs to change in the system",
 	Long: `planning is the first stage in the execution of your changes, and it
-can be done separately to see what needs to be changed before execution.`,
-	PreRunE: func(cmd *cobra.Command, args []string) error {
-		if len(args) == 0 {
-			return errors.New("Need at least one module filename as argument, got 0")
-		}
-		return nil
-	},
+can be done separately to see what needs to be changed before execution.
+
+With --detailed-exitcode, plan exits 0 when nothing needs to change, 2 when
+something would change, and 1 when any resource failed, like check. With
+--summary-out, a JSON summary of the run is written to the given path.`,
+	PreRunE: requireModules,
 	Run: func(cmd *cobra.Command, args []string) {
 		// set up execution context
 		ctx, cancel := context.WithCancel(context.Background())
@@ -51,116 +49,153 @@ can be done separately to see what needs to be changed before execution.`,
 		clog := log.WithField("component", "client")
 		ctx = logging.WithLogger(ctx, clog)
 
-		maybeSetToken()
+		summary := newRunSummary("plan", false)
+		planModules(ctx, cmd, args, summary)
+
+		finishRun(clog, summary, viper.GetBool(detailedExitCodeFlagName))
+	},
+}
 
-		ssl, err := getSSLConfig(getServerName())
+// planModules plans the modules given as args through the RPC server, printing
+// the results and warnings of each module and adding them to summary. It is
+// shared by plan and check, which only differ in how they report the run.
+func planModules(ctx context.Context, cmd *cobra.Command, args []string, summary *runSummary) {
+	clog := logging.GetLogger(ctx)
+
+	maybeSetToken()
+
+	ssl, err := getSSLConfig(getServerName())
+	if err != nil {
+		clog.WithError(err).Fatal("could not get SSL config")
+	}
+
+	if err = maybeStartSelfHostedRPC(ctx, ssl); err != nil {
+		clog.WithError(err).Fatal("could not start RPC")
+	}
+
+	client, err := getRPCExecutorClient(
+		ctx,
+		&rpc.ClientOpts{
+			Token: getToken(),
+			SSL:   ssl,
+		},
+	)
+	if err != nil {
+		clog.WithError(err).Fatal("could not get client")
+	}
+
+	rpcParams := getParamsRPC(cmd)
+
+	verifyModules := viper.GetBool("verify-modules")
+	if !verifyModules {
+		clog.Warn("skipping module verification")
+	}
+
+	mods, err := getModuleArgs(cmd, args, os.Stdin)
+	if err != nil {
+		clog.WithError(err).Fatal("could not read modules")
+	}
+
+	// execute files
+	for _, mod := range mods {
+		fname := mod.Location
+		flog := clog.WithField("file", fname)
+
+		flog.Debug("planning")
+
+		stream, err := client.Plan(
+			ctx,
+			&pb.LoadRequest{
+				Location:   fname,
+				Content:    mod.Content,
+				Parameters: rpcParams,
+				Verify:     verifyModules,
+			},
+		)
 		if err != nil {
-			clog.WithError(err).Fatal("could not get SSL config")
+			flog.WithError(err).Fatal("error getting RPC stream")
 		}
 
-		if err = maybeStartSelfHostedRPC(ctx, ssl); err != nil {
-			clog.WithError(err).Fatal("could not start RPC")
+		g := graph.New()
+		found := warnings{}
+
+		// get edges
+		edges, err := getMeta(stream)
+		if err != nil {
+			flog.WithError(err).Fatal("error getting RPC metadata")
+		}
+		for _, edge := range edges {
+			g.Connect(edge.Source, edge.Dest)
 		}
 
-		client, err := getRPCExecutorClient(
-			ctx,
-			&rpc.ClientOpts{
-				Token: getToken(),
-				SSL:   ssl,
+		// get vertices
+		err = iterateOverStream(
+			stream,
+			func(resp *pb.StatusResponse) {
+				slog := flog.WithFields(log.Fields{
+					"stage": resp.Stage,
+					"run":   resp.Run,
+					"id":    resp.Meta.Id,
+				})
+				if resp.Run == pb.StatusResponse_STARTED {
+					slog.Info("got status")
+				} else {
+					slog.Debug("got status")
+				}
+
+				if resp.Run == pb.StatusResponse_FINISHED {
+					found.add(resp)
+
+					details := resp.GetDetails()
+					if details != nil {
+						g.Add(resp.ToNode(details.ToPrintable()))
+					}
+				}
 			},
 		)
 		if err != nil {
-			clog.WithError(err).Fatal("could not get client")
+			flog.WithError(err).Fatal("could not get responses")
 		}
 
-		rpcParams := getParamsRPC(cmd)
+		// validate resulting graph
+		if err = g.Validate(); err != nil {
+			flog.WithError(err).Warning("graph is not valid")
+		}
 
-		verifyModules := viper.GetBool("verify-modules")
-		if !verifyModules {
-			clog.Warn("skipping module verification")
+		// print results
+		out, err := getPrinter().Show(ctx, g)
+		if err != nil {
+			flog.WithError(err).Fatal("failed to print results")
 		}
 
-		// execute files
-		for _, fname := range args {
-			flog := clog.WithField("file", fname)
-
-			flog.Debug("planning")
-
-			stream, err := client.Plan(
-				ctx,
-				&pb.LoadRequest{
-					Location:   fname,
-					Parameters: rpcParams,
-					Verify:     verifyModules,
-				},
-			)
-			if err != nil {
-				flog.WithError(err).Fatal("error getting RPC stream")
-			}
-
-			g := graph.New()
-
-			// get edges
-			edges, err := getMeta(stream)
-			if err != nil {
-				flog.WithError(err).Fatal("error getting RPC metadata")
-			}
-			for _, edge := range edges {
-				g.Connect(edge.Source, edge.Dest)
-			}
-
-			// get vertices
-			err = iterateOverStream(
-				stream,
-				func(resp *pb.StatusResponse) {
-					slog := flog.WithFields(log.Fields{
-						"stage": resp.Stage,
-						"run":   resp.Run,
-						"id":    resp.Meta.Id,
-					})
-					if resp.Run == pb.StatusResponse_STARTED {
-						slog.Info("got status")
-					} else {
-						slog.Debug("got status")
-					}
+		fmt.Print("\n")
+		fmt.Print(out)
 
-					if resp.Run == pb.StatusResponse_FINISHED {
-						details := resp.GetDetails()
-						if details != nil {
-							g.Add(node.New(resp.Id, details.ToPrintable()))
-						}
-					}
-				},
-			)
-			if err != nil {
-				flog.WithError(err).Fatal("could not get responses")
-			}
-
-			// validate resulting graph
-			if err = g.Validate(); err != nil {
-				flog.WithError(err).Warning("graph is not valid")
-			}
-
-			// print results
-			out, err := getPrinter().Show(ctx, g)
-			if err != nil {
-				flog.WithError(err).Fatal("failed to print results")
-			}
-
-			fmt.Print("\n")
-			fmt.Print(out)
-		}
-	},
+		found.print()
+
+		summary.add(fname, g)
+	}
 }
 
 func init() {
 	planCmd.Flags().Bool("show-meta", false, "show metadata (params and modules)")
 	planCmd.Flags().Bool("only-show-changes", false, "only show changes")
+	planCmd.Flags().Bool("quiet", false, "only show changes and errors")
+	planCmd.Flags().Bool("verbose", false, "show the details of resources without changes")
 	planCmd.Flags().Bool("verify-modules", false, "verify module signatures")
 	registerRPCFlags(planCmd.Flags())
+	registerEvalFlags(planCmd.Flags())
+	registerRefTraceFlags(planCmd.Flags())
+	registerOverrideFlags(planCmd.Flags())
 	registerLocalRPCFlags(planCmd.Flags())
+	registerPlanCheckFlags(planCmd.Flags())
+	registerLockFlags(planCmd.Flags())
+	registerRateLimitFlags(planCmd.Flags())
+	registerTraceFlags(planCmd.Flags())
 	registerSSLFlags(planCmd.Flags())
 	registerParamsFlags(planCmd.Flags())
+	registerSummaryFlags(planCmd.Flags())
+	registerExitCodeFlags(planCmd.Flags())
 
 	RootCmd.AddCommand(planCmd)
 }
@@ -0,0 +1,99 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"os"
+	"runtime"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/asteris-llc/converge/graph"
+	"github.com/asteris-llc/converge/prettyprinters/progress"
+	"github.com/mattn/go-isatty"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+const (
+	progressFlagName    = "progress"
+	timingsDirFlagName  = "timings-dir"
+	progressRedrawEvery = time.Second
+)
+
+func registerProgressFlags(flags *pflag.FlagSet) {
+	flags.Bool(progressFlagName, true, "show progress while applying, when output is a terminal")
+	flags.String(timingsDirFlagName, progress.DefaultDir, "directory to keep how long each resource took in, for estimating the time left (empty to disable)")
+}
+
+// showProgress is true if progress was asked for and both stdout and stderr
+// are terminals, so the bar isn't written into piped output
+func showProgress() bool {
+	return viper.GetBool(progressFlagName) &&
+		runtime.GOOS != "windows" &&
+		isatty.IsTerminal(os.Stdout.Fd()) &&
+		isatty.IsTerminal(os.Stderr.Fd())
+}
+
+// startProgress returns a bar for applying the module at location, or nil if
+// progress isn't shown. The nodes are the ones in the edges of the graph.
+func startProgress(logger *log.Entry, location string, edges []*graph.Edge) *progress.Bar {
+	if !showProgress() {
+		return nil
+	}
+
+	var ids []string
+	seen := map[string]bool{}
+	for _, edge := range edges {
+		for _, id := range []string{edge.Source, edge.Dest} {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+
+	var previous progress.Timings
+	if dir := viper.GetString(timingsDirFlagName); dir != "" {
+		var err error
+		if previous, err = progress.Load(dir, location); err != nil {
+			logger.WithError(err).Debug("could not load timings")
+		}
+	}
+
+	bar := progress.New(os.Stderr, ids, previous, time.Now())
+	if width, _, err := terminal.GetSize(int(os.Stderr.Fd())); err == nil {
+		bar.Width = width
+	}
+	return bar
+}
+
+// finishProgress stops and clears the bar, and saves how long each node took. Timings
+// that can't be saved, as when a normal user applies with the default
+// directory, are only logged.
+func finishProgress(logger *log.Entry, location string, bar *progress.Bar) {
+	if bar == nil {
+		return
+	}
+
+	bar.Stop()
+
+	if dir := viper.GetString(timingsDirFlagName); dir != "" {
+		if err := bar.Timings().Save(dir, location); err != nil {
+			logger.WithError(err).Debug("could not save timings")
+		}
+	}
+}
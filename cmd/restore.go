@@ -0,0 +1,115 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/asteris-llc/converge/backup"
+	"github.com/asteris-llc/converge/graph"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// restoreCmd represents the restore command
+var restoreCmd = &cobra.Command{
+	Use:   "restore MODULE NODE",
+	Short: "put back the files a resource changed",
+	Long: `restore puts back the files managed by a resource as they were before it
+was last applied, using the backups taken by apply --backup. For example:
+
+    converge restore main.hcl root/file.content.config
+
+The module is given the same way it was given to apply, and the node is named
+by its ID, as shown by plan and apply. The "root/" prefix may be left off. Use --list to see the backups of a node, newest first, and
+--at to restore an older one. Backups are only taken of files on the local
+system, so restore always runs locally.`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 2 {
+			return fmt.Errorf("Need a module and a node ID as arguments, got %d arguments", len(args))
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		id := args[1]
+		if !graph.IsRoot(id) && !strings.HasPrefix(id, "root/") {
+			id = graph.ID("root", id)
+		}
+		rlog := log.WithField("component", "restore").WithField("module", args[0]).WithField("id", id)
+
+		store := backup.New(viper.GetString(backupDirFlagName), viper.GetInt(backupRetentionFlagName)).Module(args[0])
+
+		backups, err := store.List(id)
+		if err != nil {
+			rlog.WithError(err).Fatal("could not list backups")
+		}
+		if len(backups) == 0 {
+			rlog.WithField("dir", store.Dir).Fatal("no backups found")
+		}
+
+		if viper.GetBool("list") {
+			w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+			for _, b := range backups {
+				fmt.Fprintf(w, "%s\t%s\t%s\n", b.Name(), b.Time.Local().Format(time.RFC1123), strings.Join(b.Paths(), ", "))
+			}
+			w.Flush()
+			return
+		}
+
+		restore := backups[0]
+		if at := viper.GetString("at"); at != "" {
+			restore = nil
+			for _, b := range backups {
+				if b.Name() == at {
+					restore = b
+					break
+				}
+			}
+			if restore == nil {
+				rlog.WithField("at", at).Fatal("no backup with that name, see --list")
+			}
+		}
+
+		// save what is there now, so the restore can be undone the same way
+		if _, err := store.Save(id, restore.Paths()); err != nil {
+			rlog.WithError(err).Fatal("could not back up current files")
+		}
+
+		if err := restore.Restore(); err != nil {
+			rlog.WithError(err).Fatal("could not restore")
+		}
+
+		for _, file := range restore.Files {
+			if file.Exists {
+				fmt.Printf("restored %s\n", file.Path)
+			} else {
+				fmt.Printf("removed %s\n", file.Path)
+			}
+		}
+	},
+}
+
+func init() {
+	registerBackupDirFlags(restoreCmd.Flags())
+	restoreCmd.Flags().Bool("list", false, "list the backups of the node instead of restoring one")
+	restoreCmd.Flags().String("at", "", "restore the backup with this name, as shown by --list")
+
+	RootCmd.AddCommand(restoreCmd)
+}
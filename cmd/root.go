@@ -87,6 +87,11 @@ see "converge graph --help" for more details.`,
 
 			potentialSub, potentialSubFlags, err := sub.Find(subFlags)
 			if err != nil {
+				// commands like graph take arguments as well as having
+				// subcommands, so their arguments aren't unknown subcommands
+				if sub.Runnable() {
+					break
+				}
 				return errors.Wrapf(err, "failed to get child for %s", sub.Name())
 			}
 
@@ -18,17 +18,28 @@ import (
 	"context"
 	"crypto/tls"
 	"encoding/json"
+	"fmt"
 	"io"
 	"net"
+	"os"
 	"strings"
+	"time"
 
 	"google.golang.org/grpc/metadata"
 
 	log "github.com/Sirupsen/logrus"
+	"github.com/asteris-llc/converge/backup"
 	"github.com/asteris-llc/converge/graph"
+	"github.com/asteris-llc/converge/helpers/lock"
 	"github.com/asteris-llc/converge/helpers/logging"
+	"github.com/asteris-llc/converge/helpers/ratelimit"
+	"github.com/asteris-llc/converge/load"
+	"github.com/asteris-llc/converge/policy"
+	"github.com/asteris-llc/converge/render/preprocessor"
+	"github.com/asteris-llc/converge/report"
 	"github.com/asteris-llc/converge/rpc"
 	"github.com/asteris-llc/converge/rpc/pb"
+	"github.com/asteris-llc/converge/telemetry"
 	"github.com/fgrid/uuid"
 	"github.com/pkg/errors"
 	"github.com/spf13/pflag"
@@ -36,11 +47,32 @@ import (
 )
 
 const (
-	rpcNoTokenFlagName = "no-token"
-	rpcTokenFlagName   = "rpc-token"
-	rpcAddrFlagName    = "rpc-addr"
-	rpcLocalAddrName   = "local-addr"
-	rpcEnableLocalName = "local"
+	rpcNoTokenFlagName      = "no-token"
+	rpcTokenFlagName        = "rpc-token"
+	rpcAddrFlagName         = "rpc-addr"
+	rpcLocalAddrName        = "local-addr"
+	rpcEnableLocalName      = "local"
+	policyFlagName          = "policy"
+	maxChangesFlagName      = "max-changes"
+	strictWarningsFlagName  = "strict-warnings"
+	lockFileFlagName        = "lock-file"
+	lockTimeoutFlagName     = "lock-timeout"
+	consulLockFlagName      = "consul-lock"
+	consulLockLimitFlagName = "consul-lock-limit"
+	consulAddrFlagName      = "consul-addr"
+	consulTokenFlagName     = "consul-token"
+	otlpEndpointFlagName    = "otlp-endpoint"
+	otlpHeaderFlagName      = "otlp-header"
+	reportURLFlagName       = "report-url"
+	reportDirFlagName       = "report-dir"
+	reportRetriesFlagName   = "report-retries"
+	backupFlagName          = "backup"
+	backupDirFlagName       = "backup-dir"
+	backupRetentionFlagName = "backup-retention"
+	traceRefsFlagName       = "trace-refs"
+	showOverridesFlagName   = "show-overrides"
+	rateLimitFlagName       = "rate-limit"
+	rateLimitForFlagName    = "rate-limit-for"
 )
 
 func registerRPCFlags(flags *pflag.FlagSet) {
@@ -55,6 +87,190 @@ func registerLocalRPCFlags(flags *pflag.FlagSet) {
 	flags.Bool(rpcEnableLocalName, false, "self host RPC")
 }
 
+var policyFiles []string
+
+func registerPlanCheckFlags(flags *pflag.FlagSet) {
+	flags.StringSliceVar(&policyFiles, policyFlagName, nil, "policy files to check plans against before applying, when serving RPC (may be repeated)")
+	flags.Int(maxChangesFlagName, 0, "stop an apply whose plan would change more than this many resources, when serving RPC (0 for no limit)")
+	flags.Bool(strictWarningsFlagName, false, "stop a plan or apply if any resource has warnings, when serving RPC")
+}
+
+func registerLockFlags(flags *pflag.FlagSet) {
+	flags.String(lockFileFlagName, lock.DefaultPath, "file to lock while planning and applying, when serving RPC (empty to disable)")
+	flags.Duration(lockTimeoutFlagName, time.Minute, "how long to wait for another converge process to release the lock")
+	flags.String(consulLockFlagName, "", "Consul KV prefix of a lock shared by a cluster, held while applying, when serving RPC (empty to disable)")
+	flags.Int(consulLockLimitFlagName, 1, "how many hosts may hold the Consul lock at once")
+	flags.String(consulAddrFlagName, lock.DefaultConsulAddr, "address of the Consul HTTP API")
+	flags.String(consulTokenFlagName, "", "ACL token for Consul")
+}
+
+// rateLimitFor is bound directly to the flag, since viper renders string slice
+// flags as a single bracketed string
+var rateLimitFor []string
+
+func registerRateLimitFlags(flags *pflag.FlagSet) {
+	flags.Float64(rateLimitFlagName, 0, "requests per second resources may make to each API endpoint, like the docker daemon, when serving RPC (0 for no limit)")
+	flags.StringSliceVar(&rateLimitFor, rateLimitForFlagName, nil, "requests per second for a single provider, as provider=rate, like docker=5 (may be repeated)")
+}
+
+// getRateLimits returns the limits for the rate limit flags
+func getRateLimits() (ratelimit.Limits, error) {
+	limits, err := ratelimit.ParseLimits(viper.GetFloat64(rateLimitFlagName), rateLimitFor)
+	if err != nil {
+		return limits, errors.Wrap(err, "could not get rate limits")
+	}
+	return limits, nil
+}
+
+// getClusterLock returns the cluster lock for the Consul flags, or nil if none
+// is set
+func getClusterLock() *lock.Semaphore {
+	prefix := strings.Trim(viper.GetString(consulLockFlagName), "/")
+	if prefix == "" {
+		return nil
+	}
+
+	return &lock.Semaphore{
+		Addr:   viper.GetString(consulAddrFlagName),
+		Prefix: prefix,
+		Limit:  viper.GetInt(consulLockLimitFlagName),
+		Token:  viper.GetString(consulTokenFlagName),
+	}
+}
+
+// otlpHeaders is bound directly to the flag, since viper renders string slice
+// flags as a single bracketed string
+var otlpHeaders []string
+
+func registerTraceFlags(flags *pflag.FlagSet) {
+	flags.String(otlpEndpointFlagName, "", "OpenTelemetry collector to export a trace of each plan and apply to over OTLP/HTTP, like http://localhost:4318, when serving RPC")
+	flags.StringSliceVar(&otlpHeaders, otlpHeaderFlagName, nil, "header to send to the collector, as name=value (may be repeated)")
+}
+
+// getTraceExporter returns the exporter for the trace flags, or nil if no
+// collector is set
+func getTraceExporter() (*telemetry.Exporter, error) {
+	endpoint := viper.GetString(otlpEndpointFlagName)
+	if endpoint == "" {
+		return nil, nil
+	}
+
+	headers := map[string]string{}
+	for _, header := range otlpHeaders {
+		parts := strings.SplitN(header, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("%s %q must be in the form name=value", otlpHeaderFlagName, header)
+		}
+		headers[parts[0]] = parts[1]
+	}
+
+	return &telemetry.Exporter{Endpoint: endpoint, Headers: headers}, nil
+}
+
+func registerReportFlags(flags *pflag.FlagSet) {
+	flags.String(reportURLFlagName, "", "endpoint to POST a JSON summary of each apply to, when serving RPC (empty to disable)")
+	flags.String(reportDirFlagName, report.DefaultDir, "directory to buffer summaries in while the endpoint can't be reached (empty to disable)")
+	flags.Int(reportRetriesFlagName, report.DefaultRetries, "how many more times to try sending a summary before buffering it")
+}
+
+// getReporter returns the reporter for the report flags, or nil if no
+// endpoint is set. If the buffer directory can't be created because of
+// permissions, summaries that can't be sent are dropped with a warning.
+func getReporter(ctx context.Context) *report.Reporter {
+	endpoint := viper.GetString(reportURLFlagName)
+	if endpoint == "" {
+		return nil
+	}
+
+	dir := viper.GetString(reportDirFlagName)
+	if dir != "" {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			logging.GetLogger(ctx).WithError(err).WithField("dir", dir).Warning("could not create report directory, reports will not be buffered")
+			dir = ""
+		}
+	}
+
+	return &report.Reporter{
+		Endpoint:    endpoint,
+		Dir:         dir,
+		Retries:     viper.GetInt(reportRetriesFlagName),
+		MaxBuffered: report.DefaultMaxBuffered,
+	}
+}
+
+func registerBackupFlags(flags *pflag.FlagSet) {
+	flags.Bool(backupFlagName, false, "back up managed files before changing them, so that restore can put them back")
+	registerBackupDirFlags(flags)
+}
+
+func registerBackupDirFlags(flags *pflag.FlagSet) {
+	flags.String(backupDirFlagName, backup.DefaultDir, "directory backups of managed files are kept in")
+	flags.Int(backupRetentionFlagName, backup.DefaultRetention, "number of backups to keep for each resource (0 to keep all)")
+}
+
+// getBackupStore returns the store for the backup flags, or nil if backups
+// are not turned on
+func getBackupStore() (*backup.Store, error) {
+	if !viper.GetBool(backupFlagName) {
+		return nil, nil
+	}
+
+	dir := viper.GetString(backupDirFlagName)
+	if dir == "" {
+		return nil, errors.New("--backup-dir must be set to take backups")
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, errors.Wrap(err, "could not create backup directory")
+	}
+
+	return backup.New(dir, viper.GetInt(backupRetentionFlagName)), nil
+}
+
+func registerRefTraceFlags(flags *pflag.FlagSet) {
+	flags.Bool(traceRefsFlagName, false, "write how every lookup that doesn't resolve was searched for to stderr")
+}
+
+// getRefTraces returns where to trace lookups that don't resolve, or nil if
+// they aren't traced
+func getRefTraces() io.Writer {
+	if viper.GetBool(traceRefsFlagName) {
+		return os.Stderr
+	}
+	return nil
+}
+
+// withRefTraces returns a context that traces lookups that don't resolve, for
+// commands that load modules themselves instead of over RPC
+func withRefTraces(ctx context.Context) context.Context {
+	if w := getRefTraces(); w != nil {
+		return preprocessor.WithTraceWriter(ctx, w)
+	}
+	return ctx
+}
+
+func registerOverrideFlags(flags *pflag.FlagSet) {
+	flags.Bool(showOverridesFlagName, false, "write every field set by an override file, and where it was set before, to stderr")
+}
+
+// getOverrides returns where to report fields set by override files, or nil
+// if they aren't reported
+func getOverrides() io.Writer {
+	if viper.GetBool(showOverridesFlagName) {
+		return os.Stderr
+	}
+	return nil
+}
+
+// withOverrides returns a context that reports fields set by override files,
+// for commands that load modules themselves instead of over RPC
+func withOverrides(ctx context.Context) context.Context {
+	if w := getOverrides(); w != nil {
+		return load.WithOverrideWriter(ctx, w)
+	}
+	return ctx
+}
+
 func maybeStartSelfHostedRPC(ctx context.Context, secure *tls.Config) error {
 	if viper.GetBool(rpcEnableLocalName) {
 		return startRPC(ctx, getLocalAddr(), secure, "", false)
@@ -74,7 +290,46 @@ func startRPC(ctx context.Context, addr string, secure *tls.Config, resourceRoot
 		return errors.Wrap(err, "could not open RPC listener connection")
 	}
 
-	server, err := rpc.New(getToken(), secure, resourceRoot, enableBinaryDownload)
+	rules, err := policy.Load(policyFiles...)
+	if err != nil {
+		return errors.Wrap(err, "could not load policy")
+	}
+
+	traces, err := getTraceExporter()
+	if err != nil {
+		return err
+	}
+
+	rateLimits, err := getRateLimits()
+	if err != nil {
+		return err
+	}
+
+	backups, err := getBackupStore()
+	if err != nil {
+		return err
+	}
+
+	server, err := rpc.New(
+		getToken(),
+		secure,
+		resourceRoot,
+		enableBinaryDownload,
+		rpc.ExecutorOpts{
+			Policy:         rules,
+			MaxChanges:     viper.GetInt(maxChangesFlagName),
+			StrictWarnings: viper.GetBool(strictWarningsFlagName),
+			LockPath:       viper.GetString(lockFileFlagName),
+			LockTimeout:    viper.GetDuration(lockTimeoutFlagName),
+			ClusterLock:    getClusterLock(),
+			Traces:         traces,
+			Reports:        getReporter(ctx),
+			Backups:        backups,
+			RefTraces:      getRefTraces(),
+			Overrides:      getOverrides(),
+			RateLimits:     rateLimits,
+		},
+	)
 	if err != nil {
 		return errors.Wrap(err, "could not create RPC server")
 	}
@@ -140,6 +395,31 @@ func iterateOverStream(stream recver, cb func(*pb.StatusResponse)) error {
 	return nil
 }
 
+// printOutput writes a line of task output streamed during apply to the
+// matching stream of this process, prefixed with the ID of the node that
+// produced it. It reports whether resp was output at all.
+func printOutput(resp *pb.StatusResponse) bool {
+	if resp.Run != pb.StatusResponse_OUTPUT {
+		return false
+	}
+
+	details := resp.GetDetails()
+	if details == nil {
+		return true
+	}
+
+	var out io.Writer = os.Stdout
+	if details.Stream == "stderr" {
+		out = os.Stderr
+	}
+
+	for _, line := range details.Messages {
+		fmt.Fprintf(out, "%s | %s\n", resp.Meta.Id, line)
+	}
+
+	return true
+}
+
 type headerer interface {
 	Header() (metadata.MD, error)
 }
@@ -0,0 +1,108 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/asteris-llc/converge/helpers/logging"
+	"github.com/asteris-llc/converge/helpers/version"
+	"github.com/asteris-llc/converge/keystore"
+	"github.com/asteris-llc/converge/selfupdate"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	selfUpdateVersionFlagName = "version"
+	selfUpdateBaseURLFlagName = "base-url"
+)
+
+// selfUpdateCmd represents the self-update command
+var selfUpdateCmd = &cobra.Command{
+	Use:   "self-update",
+	Short: "replace this binary with another release of " + Name,
+	Long: `self-update downloads a release of converge for this platform and
+replaces the running binary with it:
+
+    converge self-update --version 0.4.0
+
+The release must be signed by a key trusted with "converge key trust", and is
+refused if its signature can't be verified. The binary is replaced atomically,
+so a failed update leaves the current one in place.`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if viper.GetString(selfUpdateVersionFlagName) == "" {
+			return errors.New("Need a version to update to, with --version")
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		GracefulExit(cancel)
+
+		clog := log.WithField("component", "client")
+		ctx = logging.WithLogger(ctx, clog)
+
+		target := viper.GetString(selfUpdateVersionFlagName)
+		vlog := clog.WithField("current", Version).WithField("version", target)
+
+		want, err := version.Parse(target)
+		if err != nil {
+			vlog.WithError(err).Fatal("could not update")
+		}
+
+		// development builds aren't versions, and can always be updated
+		if current, err := version.Parse(Version); err == nil && current.Compare(want) == 0 {
+			vlog.Info("already at this version")
+			return
+		}
+
+		dest, err := os.Executable()
+		if err == nil {
+			dest, err = filepath.EvalSymlinks(dest)
+		}
+		if err != nil {
+			vlog.WithError(err).Fatal("could not find the running binary")
+		}
+
+		url := selfupdate.URL(viper.GetString(selfUpdateBaseURLFlagName), target, runtime.GOOS, runtime.GOARCH)
+		ulog := vlog.WithField("url", url)
+
+		ulog.Debug("downloading")
+		binary, err := selfupdate.Download(ctx, url, keystore.Default())
+		if err != nil {
+			ulog.WithError(err).Fatal("could not download release")
+		}
+
+		if err := selfupdate.Replace(dest, binary); err != nil {
+			ulog.WithError(err).Fatal("could not replace binary")
+		}
+
+		ulog.WithField("binary", dest).Info("updated")
+	},
+}
+
+func init() {
+	selfUpdateCmd.Flags().String(selfUpdateVersionFlagName, "", "version to update to, like 0.4.0")
+	selfUpdateCmd.Flags().String(selfUpdateBaseURLFlagName, selfupdate.DefaultBaseURL, "location releases are downloaded from")
+
+	RootCmd.AddCommand(selfUpdateCmd)
+}
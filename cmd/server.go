@@ -139,6 +139,14 @@ func init() {
 	// common
 	registerSSLFlags(serverCmd.Flags())
 	registerRPCFlags(serverCmd.Flags())
+	registerRefTraceFlags(serverCmd.Flags())
+	registerOverrideFlags(serverCmd.Flags())
+	registerPlanCheckFlags(serverCmd.Flags())
+	registerLockFlags(serverCmd.Flags())
+	registerRateLimitFlags(serverCmd.Flags())
+	registerTraceFlags(serverCmd.Flags())
+	registerReportFlags(serverCmd.Flags())
+	registerBackupFlags(serverCmd.Flags())
 
 	// API
 	serverCmd.Flags().String("api-addr", addrServerHTTP, "address to serve API")
@@ -0,0 +1,252 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/asteris-llc/converge/graph"
+	"github.com/asteris-llc/converge/parse/preprocessor/switch"
+	"github.com/asteris-llc/converge/plan"
+	"github.com/asteris-llc/converge/policy"
+	"github.com/asteris-llc/converge/prettyprinters/human"
+	"github.com/asteris-llc/converge/render"
+	"github.com/asteris-llc/converge/render/preprocessor"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/rpc/pb"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	showCheckFlagName       = "check"
+	showSnapshotDirFlagName = "snapshot-dir"
+)
+
+// showCmd represents the show command
+var showCmd = &cobra.Command{
+	Use:   "show MODULE NODE",
+	Short: "show everything known about a single node",
+	Long: `show prints what converge knows about one node of a module, for
+debugging a resource that doesn't do what you expect:
+
+- the fields it was rendered with
+- the nodes it depends on
+- the values its lookups resolved to in the last apply
+- what checking it finds now
+
+For example:
+
+    converge show main.hcl file.content.config
+
+The node is named relative to the root module, like "module.app/task.start"
+for a node inside a module. Only the node and the nodes it depends on are
+checked, and nothing is changed. The module is loaded on this machine, and
+the values from the last apply are the ones the server saved when it applied
+the module under the same name.`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 2 {
+			return fmt.Errorf("Need a module and a node as arguments, got %d arguments", len(args))
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		fname := args[0]
+		id := graph.ID("root", strings.TrimPrefix(args[1], "root/"))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		GracefulExit(cancel)
+		ctx = withRefTraces(ctx)
+		ctx = withOverrides(ctx)
+
+		nlog := log.WithField("file", fname).WithField("id", id)
+
+		params := getParamsRPC(cmd)
+
+		var snapshot *render.Snapshot
+		if dir := viper.GetString(showSnapshotDirFlagName); dir != "" {
+			var err error
+			if snapshot, err = render.LoadSnapshot(render.SnapshotPath(dir, fname, params)); err != nil {
+				nlog.WithError(err).Warning("skipping snapshot")
+			}
+		}
+
+		g, err := (&pb.LoadRequest{
+			Location:   fname,
+			Parameters: params,
+			Verify:     viper.GetBool("verify-modules"),
+		}).Load(ctx)
+		if err != nil {
+			nlog.WithError(err).Fatal("could not load module")
+		}
+
+		meta, ok := g.Get(id)
+		if !ok {
+			nlog.Fatal("no such node")
+		}
+
+		value := meta.Value()
+
+		var checked *graph.Graph
+		if viper.GetBool(showCheckFlagName) {
+			checked, err = plan.Plan(render.WithSnapshot(ctx, snapshot), g.DependencyGraph(id))
+			if err != nil && err != plan.ErrTreeContainsErrors {
+				nlog.WithError(err).Fatal("could not check")
+			}
+
+			// the task is rendered again when checked, so fields that depend on
+			// the checks of other nodes are filled in
+			if result, ok := checked.Get(id); ok {
+				if planned, ok := result.Value().(*plan.Result); ok && planned.Task != nil {
+					value = planned.Task
+				}
+			}
+		}
+
+		fmt.Printf("%s\n", id)
+
+		fmt.Print("\nFields:\n")
+		if err := writeFields(os.Stdout, value); err != nil {
+			fmt.Printf("  %s\n", err)
+		}
+
+		fmt.Print("\nDepends on:\n")
+		deps := dependsOn(g, id)
+		if len(deps) == 0 {
+			fmt.Print("  nothing\n")
+		}
+		for _, dep := range deps {
+			fmt.Printf("  %s\n", dep)
+		}
+
+		fmt.Print("\nLast apply:\n")
+		if err := writeValues(os.Stdout, snapshot.Node(id)); err != nil {
+			nlog.WithError(err).Fatal("could not print values")
+		}
+
+		if checked == nil {
+			return
+		}
+
+		printer := human.New()
+		printer.Verbosity = human.Verbose
+		printer.Color = UseColor()
+		printer.InitColors()
+
+		out, err := printer.DrawNode(checked, id)
+		if err != nil {
+			nlog.WithError(err).Fatal("could not print check")
+		}
+		fmt.Printf("\nCheck:\n%s", out)
+	},
+}
+
+// writeFields writes each field of a task that can be looked up, and its
+// value
+func writeFields(out io.Writer, value interface{}) error {
+	if _, isThunk := value.(*render.PrepareThunk); isThunk {
+		return errors.New("depends on values that are only known when applying")
+	}
+
+	task, ok := resource.ResolveTask(value)
+	if !ok {
+		return errors.New("not a resource")
+	}
+	if conditional, ok := task.(*control.ConditionalTask); ok {
+		task = conditional.Task
+	}
+
+	fields, err := resource.Exports(task)
+	if err != nil {
+		return err
+	}
+
+	tabs := tabwriter.NewWriter(out, 1, 1, 1, ' ', 0)
+	for _, field := range fields {
+		value, err := preprocessor.EvalTerms(task, preprocessor.SplitTerms(field.Name)...)
+		if err != nil {
+			fmt.Fprintf(tabs, "  %s:\t%s\n", field.Name, err)
+			continue
+		}
+		fmt.Fprintf(tabs, "  %s:\t%s\n", field.Name, showValue(policy.Stringify(value)))
+	}
+	return tabs.Flush()
+}
+
+// writeValues writes the values saved from the last apply, sorted by key
+func writeValues(out io.Writer, values map[string]string) error {
+	if len(values) == 0 {
+		fmt.Fprint(out, "  no saved values\n")
+		return nil
+	}
+
+	var keys []string
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	tabs := tabwriter.NewWriter(out, 1, 1, 1, ' ', 0)
+	for _, key := range keys {
+		fmt.Fprintf(tabs, "  %s:\t%q\n", key, values[key])
+	}
+	return tabs.Flush()
+}
+
+// dependsOn returns the nodes the node directly depends on, sorted, leaving
+// out the nodes inside it if it is a module
+func dependsOn(g *graph.Graph, id string) (out []string) {
+	for _, edge := range g.DownEdges(id) {
+		dep := edge.Target().(string)
+		if !graph.IsDescendentID(id, dep) {
+			out = append(out, dep)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// showValue formats the value of a field like a lookup would
+func showValue(values []string) string {
+	if len(values) == 1 {
+		return fmt.Sprintf("%q", values[0])
+	}
+
+	quoted := make([]string, len(values))
+	for i, value := range values {
+		quoted[i] = fmt.Sprintf("%q", value)
+	}
+	return "[" + strings.Join(quoted, ", ") + "]"
+}
+
+func init() {
+	showCmd.Flags().Bool(showCheckFlagName, true, "check the node and the nodes it depends on")
+	showCmd.Flags().String(showSnapshotDirFlagName, render.DefaultSnapshotDir, "directory the server keeps the values from the last apply of each module in")
+	showCmd.Flags().Bool("verify-modules", false, "verify module signatures")
+	registerParamsFlags(showCmd.Flags())
+
+	registerRefTraceFlags(showCmd.Flags())
+	registerOverrideFlags(showCmd.Flags())
+	RootCmd.AddCommand(showCmd)
+}
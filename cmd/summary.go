@@ -0,0 +1,151 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/asteris-llc/converge/graph"
+	"github.com/pkg/errors"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+const (
+	summaryOutFlagName       = "summary-out"
+	detailedExitCodeFlagName = "detailed-exitcode"
+)
+
+// exit codes of apply, plan, and check. check always uses them, and apply
+// and plan only with --detailed-exitcode. Failing to run at all exits 1.
+const (
+	exitConverged = 0
+	exitError     = 1
+	exitDrift     = 2
+	exitChanged   = 3
+)
+
+// statuses of a run in its summary, matching the exit codes above
+const (
+	statusConverged = "converged"
+	statusError     = "error"
+	statusDrift     = "drift"
+	statusChanged   = "changed"
+)
+
+func registerSummaryFlags(flags *pflag.FlagSet) {
+	flags.String(summaryOutFlagName, "", "write a JSON summary of the run to this path")
+}
+
+func registerExitCodeFlags(flags *pflag.FlagSet) {
+	flags.Bool(detailedExitCodeFlagName, false, "exit 0 when nothing changed, 2 when something would change, 3 when changes were applied, and 1 on errors")
+}
+
+// runSummary is the machine-readable outcome of an apply, plan, or check.
+// ExitCode is the code the run exits with when detailed codes are used.
+type runSummary struct {
+	Command  string           `json:"command"`
+	Status   string           `json:"status"`
+	ExitCode int              `json:"exit_code"`
+	Modules  []*moduleSummary `json:"modules"`
+
+	// applied is whether changes were made, rather than only found
+	applied bool
+}
+
+// moduleSummary is the outcome of a single module in a run
+type moduleSummary struct {
+	Location string   `json:"location"`
+	Changed  []string `json:"changed"`
+	Failed   []string `json:"failed"`
+}
+
+func newRunSummary(command string, applied bool) *runSummary {
+	return &runSummary{
+		Command: command,
+		Status:  statusConverged,
+		Modules: []*moduleSummary{},
+		applied: applied,
+	}
+}
+
+// add records the resources in a finished graph that changed or failed
+func (s *runSummary) add(location string, g *graph.Graph) *moduleSummary {
+	changed, failed := drift(g)
+	if changed == nil {
+		changed = []string{}
+	}
+	if failed == nil {
+		failed = []string{}
+	}
+
+	mod := &moduleSummary{Location: location, Changed: changed, Failed: failed}
+	s.Modules = append(s.Modules, mod)
+
+	switch {
+	case len(failed) > 0:
+		s.Status, s.ExitCode = statusError, exitError
+	case s.ExitCode == exitError:
+		// errors in earlier modules take precedence
+	case len(changed) > 0 && s.applied:
+		s.Status, s.ExitCode = statusChanged, exitChanged
+	case len(changed) > 0:
+		s.Status, s.ExitCode = statusDrift, exitDrift
+	}
+
+	return mod
+}
+
+// changed returns the IDs of every changed resource, in module order
+func (s *runSummary) changed() (out []string) {
+	for _, mod := range s.Modules {
+		out = append(out, mod.Changed...)
+	}
+	return out
+}
+
+// failed returns the IDs of every failed resource, in module order
+func (s *runSummary) failed() (out []string) {
+	for _, mod := range s.Modules {
+		out = append(out, mod.Failed...)
+	}
+	return out
+}
+
+func (s *runSummary) write(path string) error {
+	out, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, append(out, '\n'), 0644)
+}
+
+// finishRun writes the summary if one was asked for, and exits with its code
+// when detailed is set. Otherwise it returns so the command exits 0.
+func finishRun(logger *log.Entry, s *runSummary, detailed bool) {
+	if path := viper.GetString(summaryOutFlagName); path != "" {
+		if err := s.write(path); err != nil {
+			logger.WithError(errors.Wrap(err, path)).Fatal("could not write summary")
+		}
+	}
+
+	if detailed && s.ExitCode != exitConverged {
+		os.Exit(s.ExitCode)
+	}
+}
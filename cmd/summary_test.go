@@ -0,0 +1,113 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/asteris-llc/converge/rpc/pb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunSummary(t *testing.T) {
+	t.Parallel()
+
+	converged := finished(map[string]*pb.StatusResponse_Details{
+		"root/task.a": {},
+	})
+	changed := finished(map[string]*pb.StatusResponse_Details{
+		"root/task.a": {HasChanges: true},
+		"root/task.b": {},
+	})
+	failed := finished(map[string]*pb.StatusResponse_Details{
+		"root/task.a": {Error: "failed"},
+	})
+
+	t.Run("converged", func(t *testing.T) {
+		s := newRunSummary("apply", true)
+		s.add("a.hcl", converged)
+
+		assert.Equal(t, statusConverged, s.Status)
+		assert.Equal(t, exitConverged, s.ExitCode)
+		assert.Equal(t, []string{}, s.Modules[0].Changed)
+	})
+
+	t.Run("changed", func(t *testing.T) {
+		s := newRunSummary("apply", true)
+		s.add("a.hcl", changed)
+
+		assert.Equal(t, statusChanged, s.Status)
+		assert.Equal(t, exitChanged, s.ExitCode)
+		assert.Equal(t, []string{"root/task.a"}, s.changed())
+	})
+
+	t.Run("drift", func(t *testing.T) {
+		s := newRunSummary("plan", false)
+		s.add("a.hcl", changed)
+
+		assert.Equal(t, statusDrift, s.Status)
+		assert.Equal(t, exitDrift, s.ExitCode)
+	})
+
+	t.Run("errors take precedence", func(t *testing.T) {
+		s := newRunSummary("apply", true)
+		s.add("a.hcl", failed)
+		s.add("b.hcl", changed)
+
+		assert.Equal(t, statusError, s.Status)
+		assert.Equal(t, exitError, s.ExitCode)
+		assert.Equal(t, []string{"root/task.a"}, s.failed())
+		assert.Equal(t, []string{"root/task.a"}, s.changed())
+	})
+}
+
+func TestRunSummaryWrite(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "converge-summary")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	s := newRunSummary("check", false)
+	s.add("a.hcl", finished(map[string]*pb.StatusResponse_Details{
+		"root/task.a": {HasChanges: true},
+	}))
+
+	path := filepath.Join(dir, "summary.json")
+	require.NoError(t, s.write(path))
+
+	content, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+
+	var out map[string]interface{}
+	require.NoError(t, json.Unmarshal(content, &out))
+	assert.Equal(t, "check", out["command"])
+	assert.Equal(t, "drift", out["status"])
+	assert.Equal(t, float64(exitDrift), out["exit_code"])
+	assert.Equal(
+		t,
+		[]interface{}{map[string]interface{}{
+			"location": "a.hcl",
+			"changed":  []interface{}{"root/task.a"},
+			"failed":   []interface{}{},
+		}},
+		out["modules"],
+	)
+}
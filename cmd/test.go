@@ -0,0 +1,133 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/moduletest"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// testCmd represents the test command
+var testCmd = &cobra.Command{
+	Use:   "test [PATH...]",
+	Short: "run the unit tests of modules",
+	Long: `test runs the tests in *_test.hcl files, searching the given directories
+(or the current one) for them. Each test renders a module with a set of params
+and checks the nodes in the resulting graph. Nothing is checked or applied on
+the system, so tests are fast enough to run on every change:
+
+    test "production" {
+      params {
+        env = "prod"
+      }
+
+      expect "file.content.config" {
+        fields {
+          destination = "/etc/app/prod.conf"
+        }
+      }
+    }
+
+With --integration, tests with an integration block are also applied for real,
+each inside a new container of the given image, which is removed afterwards.
+The verify commands are then run in the container and must exit 0. This needs
+docker on the host:
+
+    test "installs" {
+      integration {
+        image  = "centos:7"
+        verify = ["rpm -q httpd"]
+      }
+    }
+
+test exits 1 if any test fails.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		// set up execution context
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		GracefulExit(cancel)
+		ctx = withRefTraces(ctx)
+		ctx = withOverrides(ctx)
+
+		if len(args) == 0 {
+			args = []string{"."}
+		}
+
+		files, err := moduletest.Find(args...)
+		if err != nil {
+			log.WithError(err).Fatal("could not find tests")
+		}
+		if len(files) == 0 {
+			log.WithField("paths", args).Fatal("no tests found")
+		}
+
+		var total, failed int
+		for _, file := range files {
+			flog := log.WithField("file", file)
+
+			suite, err := moduletest.Load(file)
+			if err != nil {
+				flog.WithError(err).Fatal("could not load tests")
+			}
+
+			results := suite.Run(ctx)
+			if viper.GetBool("integration") {
+				results = append(results, suite.RunIntegration(ctx, exec.New())...)
+			}
+
+			for _, result := range results {
+				name := result.Case
+				if result.Integration {
+					name += " (integration)"
+				}
+
+				total++
+				if result.Passed() {
+					fmt.Printf("ok    %s: %s\n", result.Suite, name)
+					continue
+				}
+
+				failed++
+				fmt.Printf("FAIL  %s: %s\n", result.Suite, name)
+				if result.Err != nil {
+					fmt.Printf("      %s\n", result.Err)
+				}
+				for _, failure := range result.Failures {
+					fmt.Printf("      %s\n", failure)
+				}
+			}
+		}
+
+		if failed > 0 {
+			fmt.Printf("\n%d of %d tests failed\n", failed, total)
+			os.Exit(1)
+		}
+		fmt.Printf("\n%d tests passed\n", total)
+	},
+}
+
+func init() {
+	testCmd.Flags().Bool("integration", false, "also apply tests with an integration block in throwaway containers")
+	registerRefTraceFlags(testCmd.Flags())
+	registerOverrideFlags(testCmd.Flags())
+	RootCmd.AddCommand(testCmd)
+}
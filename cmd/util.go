@@ -28,13 +28,19 @@ import (
 
 func humanProvider(filter human.FilterFunc) *human.Printer {
 	if !viper.GetBool("show-meta") {
-		filter = human.HideByKind("module", "param", "root")
+		filter = human.HideByKind("module", "output", "param", "root")
 	}
 	if viper.GetBool("only-show-changes") {
 		filter = human.AndFilter(human.ShowOnlyChanged, filter)
 	}
 
 	printer := human.NewFiltered(filter)
+	switch {
+	case viper.GetBool("quiet"):
+		printer.Verbosity = human.Quiet
+	case viper.GetBool("verbose"):
+		printer.Verbosity = human.Verbose
+	}
 	printer.Color = UseColor()
 	printer.InitColors()
 	return printer
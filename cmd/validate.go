@@ -17,6 +17,7 @@ package cmd
 import (
 	"context"
 	"errors"
+	"os"
 
 	log "github.com/Sirupsen/logrus"
 	"github.com/asteris-llc/converge/load"
@@ -38,20 +39,34 @@ var validateCmd = &cobra.Command{
 		// set up execution context
 		ctx, cancel := context.WithCancel(context.Background())
 		GracefulExit(cancel)
+		ctx = withRefTraces(ctx)
+		ctx = withOverrides(ctx)
 
 		verifyModules := viper.GetBool("verify-modules")
 		if !verifyModules {
 			log.WithField("component", "client").Warn("skipping module verification")
 		}
 
+		if viper.GetBool("graph-debug") {
+			ctx = load.WithEdgeWriter(ctx, os.Stderr)
+		}
+
+		platform := viper.GetString("platform")
+
 		for _, fname := range args {
 			flog := log.WithField("file", fname)
 
-			_, err := load.Load(ctx, fname, verifyModules)
+			loaded, err := load.Load(ctx, fname, verifyModules)
 			if err != nil {
 				flog.WithError(err).Fatal("could not parse file")
 			}
 
+			if platform != "" {
+				if err := load.CheckPlatforms(loaded, platform); err != nil {
+					flog.WithError(err).Fatal("module uses unsupported resources")
+				}
+			}
+
 			flog.Info("module valid")
 		}
 	},
@@ -59,5 +74,9 @@ var validateCmd = &cobra.Command{
 
 func init() {
 	validateCmd.Flags().Bool("verify-modules", false, "verify module signatures")
+	validateCmd.Flags().Bool("graph-debug", false, "print the dependencies found between resources, and why")
+	validateCmd.Flags().String("platform", "", "check that every resource can be used on this operating system, named as in GOOS")
+	registerRefTraceFlags(validateCmd.Flags())
+	registerOverrideFlags(validateCmd.Flags())
 	RootCmd.AddCommand(validateCmd)
 }
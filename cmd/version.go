@@ -17,6 +17,7 @@ package cmd
 import (
 	"fmt"
 
+	"github.com/asteris-llc/converge/load"
 	"github.com/spf13/cobra"
 )
 
@@ -33,5 +34,7 @@ var versionCmd = &cobra.Command{
 }
 
 func init() {
+	load.ConvergeVersion = Version
+
 	RootCmd.AddCommand(versionCmd)
 }
@@ -0,0 +1,56 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/asteris-llc/converge/rpc/pb"
+)
+
+// warnings collects the warnings given by resources during a plan or apply,
+// keyed by node ID
+type warnings map[string][]*pb.StatusResponse_Warning
+
+// add records the warnings in a finished status response
+func (w warnings) add(resp *pb.StatusResponse) {
+	if details := resp.GetDetails(); details != nil && len(details.Warnings) > 0 {
+		w[resp.Meta.Id] = details.Warnings
+	}
+}
+
+// print summarizes the warnings after the results, so they aren't lost among
+// the output of every resource
+func (w warnings) print() {
+	if len(w) == 0 {
+		return
+	}
+
+	var ids []string
+	count := 0
+	for id, found := range w {
+		ids = append(ids, id)
+		count += len(found)
+	}
+	sort.Strings(ids)
+
+	fmt.Printf("\nWarnings: %d\n", count)
+	for _, id := range ids {
+		for _, warning := range w[id] {
+			fmt.Printf("  %s: %s\n", id, warning.ToResource())
+		}
+	}
+}
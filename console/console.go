@@ -0,0 +1,247 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package console
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/asteris-llc/converge/graph"
+	"github.com/asteris-llc/converge/graph/node"
+	"github.com/asteris-llc/converge/render"
+	"github.com/asteris-llc/converge/render/extensions"
+	"github.com/asteris-llc/converge/render/preprocessor"
+	"github.com/pkg/errors"
+)
+
+// Name is the base of the ID expressions are rendered at, inside the current
+// scope. No resource can have it, since resource IDs always contain a dot.
+const Name = "console"
+
+// Help lists the commands the console knows besides expressions
+const Help = `Type a template, like "{{param ` + "`name`" + `}}", or an expression
+to render as one, like "lookup ` + "`task.query.q.status.stdout`" + `".
+
+Commands:
+  :nodes           list the nodes in the current scope
+  :resolve NAME    show which node and fields a lookup of NAME resolves to
+  :scope [MODULE]  evaluate inside a module, like "module.app", or the root
+  :help            show this help
+  :quit            leave the console
+`
+
+// Console evaluates expressions against a rendered graph as if they were a
+// field of a node in the current scope
+type Console struct {
+	// Prompt is written before reading each line. It should be empty when the
+	// input isn't a terminal.
+	Prompt string
+
+	// Previous, if set, is used for lookups that can't be resolved yet, as in
+	// plan
+	Previous *render.Snapshot
+
+	// Trace, if set, receives how each lookup that doesn't resolve was
+	// searched for
+	Trace io.Writer
+
+	graph *graph.Graph
+	scope string
+}
+
+// New returns a Console for the graph, scoped to the root module
+func New(g *graph.Graph) (*Console, error) {
+	root, err := g.Root()
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Console{graph: g}
+	return c, c.SetScope(root)
+}
+
+// Scope returns the ID of the module expressions are evaluated in
+func (c *Console) Scope() string {
+	return c.scope
+}
+
+// SetScope moves the console into the module with the ID
+func (c *Console) SetScope(id string) error {
+	if !graph.IsRoot(id) {
+		if !c.graph.Contains(id) {
+			return fmt.Errorf("no such module: %s", id)
+		}
+		if !strings.HasPrefix(graph.BaseID(id), "module.") {
+			return fmt.Errorf("%s is not a module", id)
+		}
+	}
+
+	// lookups are resolved from the node being rendered, so the console needs
+	// a node of its own in the scope
+	scoped := c.graph.Copy()
+	if c.scope != "" {
+		scoped.Remove(graph.ID(c.scope, Name))
+	}
+	scoped.Add(node.New(graph.ID(id, Name), nil))
+	scoped.ConnectParent(id, graph.ID(id, Name))
+
+	c.graph = scoped
+	c.scope = id
+	return nil
+}
+
+// Eval renders the expression. Input without template actions is rendered as
+// a single action, so "param `name`" is the same as "{{param `name`}}".
+func (c *Console) Eval(expr string) (string, error) {
+	expr = strings.TrimSpace(expr)
+	if !strings.Contains(expr, "{{") {
+		expr = "{{" + expr + "}}"
+	}
+
+	renderer := &render.Renderer{
+		Graph:    func() *graph.Graph { return c.graph },
+		ID:       graph.ID(c.scope, Name),
+		Language: extensions.DefaultLanguage(),
+		Previous: c.Previous,
+		Trace:    c.Trace,
+	}
+
+	out, err := renderer.Render(Name, expr)
+	if _, unresolvable := err.(render.ErrUnresolvable); unresolvable {
+		return "", errors.New("the value is only known after its node has been checked or applied")
+	}
+	return out, err
+}
+
+// Nodes returns the IDs of the nodes directly in the current scope, relative
+// to it, sorted
+func (c *Console) Nodes() (out []string) {
+	for _, child := range c.graph.Children(c.scope) {
+		if base := graph.BaseID(child); base != Name {
+			out = append(out, strings.TrimPrefix(child, c.scope+"/"))
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Resolve returns the node a lookup of name resolves to from the current
+// scope, and the fields looked up inside it
+func (c *Console) Resolve(name string) (id, terms string, err error) {
+	id, terms, found := preprocessor.VertexSplitTraverse(
+		c.graph,
+		name,
+		graph.ID(c.scope, Name),
+		preprocessor.TraverseUntilModule,
+		make(map[string]struct{}),
+	)
+	if !found {
+		return "", "", fmt.Errorf("%s does not resolve to a node in %s", name, c.scope)
+	}
+
+	if output, outputTerms, ok := preprocessor.ModuleOutput(c.graph, id, terms); ok {
+		id, terms = output, outputTerms
+	}
+	return id, terms, nil
+}
+
+// Run reads lines from in until it ends or ":quit" is read, writing the
+// result of each to out. Errors in expressions are written too, and only
+// failing to read or write stops the console.
+func (c *Console) Run(in io.Reader, out io.Writer) error {
+	scanner := bufio.NewScanner(in)
+
+	for {
+		if _, err := io.WriteString(out, c.Prompt); err != nil {
+			return err
+		}
+
+		if !scanner.Scan() {
+			if c.Prompt != "" {
+				fmt.Fprintln(out)
+			}
+			return scanner.Err()
+		}
+
+		line := strings.TrimSpace(scanner.Text())
+		if line == ":quit" || line == ":q" {
+			return nil
+		}
+
+		if _, err := io.WriteString(out, c.handle(line)); err != nil {
+			return err
+		}
+	}
+}
+
+// handle runs a single line and returns what to write for it
+func (c *Console) handle(line string) string {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return ""
+	}
+
+	switch fields[0] {
+	case ":help", ":h":
+		return Help
+
+	case ":nodes":
+		nodes := c.Nodes()
+		if len(nodes) == 0 {
+			return "no nodes\n"
+		}
+		return strings.Join(nodes, "\n") + "\n"
+
+	case ":scope":
+		if len(fields) == 1 {
+			root, _ := c.graph.Root()
+			fields = append(fields, root)
+		}
+		id := fields[1]
+		if !graph.IsRoot(id) && !strings.HasPrefix(id, "root/") {
+			id = graph.ID(c.scope, id)
+		}
+		if err := c.SetScope(id); err != nil {
+			return fmt.Sprintf("error: %s\n", err)
+		}
+		return fmt.Sprintf("scope: %s\n", c.scope)
+
+	case ":resolve":
+		if len(fields) != 2 {
+			return "usage: :resolve NAME\n"
+		}
+		id, terms, err := c.Resolve(fields[1])
+		if err != nil {
+			return fmt.Sprintf("error: %s\n", err)
+		}
+		if terms == "" {
+			return fmt.Sprintf("node: %s\n", id)
+		}
+		return fmt.Sprintf("node: %s\nfields: %s\n", id, terms)
+	}
+
+	if strings.HasPrefix(fields[0], ":") {
+		return fmt.Sprintf("error: unknown command %s, see :help\n", fields[0])
+	}
+
+	out, err := c.Eval(line)
+	if err != nil {
+		return fmt.Sprintf("error: %s\n", err)
+	}
+	return out + "\n"
+}
@@ -0,0 +1,180 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package console_test
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/asteris-llc/converge/console"
+	"github.com/asteris-llc/converge/helpers/logging"
+	"github.com/asteris-llc/converge/render"
+	"github.com/asteris-llc/converge/rpc/pb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const mainModule = `
+param "name" {
+  default = "world"
+}
+
+task.query "q" {
+  query = "echo hi"
+}
+
+file.content "greeting" {
+  destination = "greeting.txt"
+  content     = "hello {{param ` + "`name`" + `}}"
+}
+
+module "inner.hcl" "inner" {
+  params = {
+    "name" = "inside"
+  }
+}
+`
+
+const innerModule = `
+param "name" {}
+
+task.query "q" {
+  query = "echo {{param ` + "`name`" + `}}"
+}
+`
+
+func TestConsole(t *testing.T) {
+	defer logging.HideLogs(t)()
+
+	dir, err := ioutil.TempDir("", "converge-console")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "main.hcl"), []byte(mainModule), 0600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "inner.hcl"), []byte(innerModule), 0600))
+
+	g, err := (&pb.LoadRequest{
+		Location:   filepath.Join(dir, "main.hcl"),
+		Parameters: map[string]string{"name": "tester"},
+	}).Load(context.Background())
+	require.NoError(t, err)
+
+	t.Run("param", func(t *testing.T) {
+		c, err := console.New(g)
+		require.NoError(t, err)
+
+		out, err := c.Eval("param `name`")
+		require.NoError(t, err)
+		assert.Equal(t, "tester", out)
+
+		out, err = c.Eval("name is {{param `name`}}")
+		require.NoError(t, err)
+		assert.Equal(t, "name is tester", out)
+	})
+
+	t.Run("lookup", func(t *testing.T) {
+		c, err := console.New(g)
+		require.NoError(t, err)
+
+		out, err := c.Eval("lookup `file.content.greeting.destination`")
+		require.NoError(t, err)
+		assert.Equal(t, "greeting.txt", out)
+
+		_, err = c.Eval("lookup `file.content.nope.destination`")
+		assert.Error(t, err)
+	})
+
+	t.Run("unresolvable", func(t *testing.T) {
+		c, err := console.New(g)
+		require.NoError(t, err)
+
+		_, err = c.Eval("lookup `task.query.q.status.stdout`")
+		assert.EqualError(t, err, "the value is only known after its node has been checked or applied")
+
+		c.Previous = render.NewSnapshot()
+		c.Previous.Set("root/task.query.q.status.stdout", "from last apply")
+
+		out, err := c.Eval("lookup `task.query.q.status.stdout`")
+		require.NoError(t, err)
+		assert.Equal(t, "from last apply", out)
+	})
+
+	t.Run("scope", func(t *testing.T) {
+		c, err := console.New(g)
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"file.content.greeting", "module.inner", "param.name", "task.query.q"}, c.Nodes())
+
+		require.NoError(t, c.SetScope("root/module.inner"))
+		assert.Equal(t, "root/module.inner", c.Scope())
+		assert.Equal(t, []string{"param.name", "task.query.q"}, c.Nodes())
+
+		out, err := c.Eval("param `name`")
+		require.NoError(t, err)
+		assert.Equal(t, "inside", out)
+
+		assert.Error(t, c.SetScope("root/task.query.q"))
+		assert.Error(t, c.SetScope("root/module.nope"))
+	})
+
+	t.Run("resolve", func(t *testing.T) {
+		c, err := console.New(g)
+		require.NoError(t, err)
+
+		id, terms, err := c.Resolve("task.query.q.status.stdout")
+		require.NoError(t, err)
+		assert.Equal(t, "root/task.query.q", id)
+		assert.Equal(t, "status.stdout", terms)
+
+		_, _, err = c.Resolve("task.query.nope")
+		assert.Error(t, err)
+	})
+
+	t.Run("run", func(t *testing.T) {
+		c, err := console.New(g)
+		require.NoError(t, err)
+
+		in := strings.NewReader(strings.Join([]string{
+			"param `name`",
+			"",
+			":scope module.inner",
+			"param `name`",
+			":scope",
+			":resolve param.name",
+			":nope",
+			":quit",
+			"param `name`",
+		}, "\n"))
+
+		var out bytes.Buffer
+		require.NoError(t, c.Run(in, &out))
+
+		assert.Equal(
+			t,
+			"tester\n"+
+				"scope: root/module.inner\n"+
+				"inside\n"+
+				"scope: root\n"+
+				"node: root/param.name\n"+
+				"error: unknown command :nope, see :help\n",
+			out.String(),
+		)
+	})
+}
@@ -74,6 +74,18 @@ var (
 {{end}}{{ if .ValidValues}}
   Valid values: {{codeCommaJoin .ValidValues "and"}}
 
+{{end}}{{ if and (ne .Min "") (ne .Max "")}}
+  Must be between {{.Min}} and {{.Max}}.
+
+{{else if ne .Min ""}}
+  Must be at least {{.Min}}.
+
+{{else if ne .Max ""}}
+  Must be at most {{.Max}}.
+
+{{end}}{{ if ne .Regex ""}}
+  Must match {{printf "%q" .Regex}}.
+
 {{end}}{{if ne .Doc ""}}  {{.Doc}}{{end}}{{end}}
 `))
 )
@@ -127,6 +139,9 @@ type Field struct {
 	Base              string
 	MutuallyExclusive []string
 	ValidValues       []string
+	Min               string
+	Max               string
+	Regex             string
 }
 
 // TypeExtractor extracts documentation information
@@ -215,6 +230,10 @@ func (te *TypeExtractor) Visit(node ast.Node) (w ast.Visitor) {
 			if validvalues, ok := tag.Lookup("valid_values"); ok {
 				field.ValidValues = strings.Split(validvalues, ",")
 			}
+
+			field.Min = tag.Get("min")
+			field.Max = tag.Get("max")
+			field.Regex = tag.Get("regex")
 		}
 
 		te.Fields = append(te.Fields, field)
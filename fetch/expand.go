@@ -0,0 +1,118 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fetch
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ModuleExt is the extension of the files loaded from a directory module
+const ModuleExt = ".hcl"
+
+// OverrideSuffix ends the names of override files, which change the nodes
+// defined in the other files of a module instead of adding their own
+const OverrideSuffix = "_override" + ModuleExt
+
+// IsOverride tests whether a location is an override file
+func IsOverride(loc string) bool {
+	return strings.HasSuffix(loc, OverrideSuffix)
+}
+
+// Expand gets the files making up the module at a location. A local directory
+// is every module file directly inside it, and a local glob is every file
+// matching it. Either way the files are sorted by name, so they are always
+// loaded in the same order, and override files come after all of the others.
+// A single local file comes with the override file next to it, if there is
+// one: "app_override.hcl" for "app.hcl". Any other location is a single file.
+func Expand(loc string) ([]string, error) {
+	parsed, err := url.Parse(loc)
+	if err != nil {
+		return nil, err
+	}
+
+	if parsed.Scheme != "file" {
+		return []string{loc}, nil
+	}
+
+	local := path.Join(parsed.Host, parsed.Path)
+
+	var matches []string
+	if stat, err := os.Stat(local); err == nil && stat.IsDir() {
+		matches, err = filepath.Glob(filepath.Join(local, "*"+ModuleExt))
+		if err != nil {
+			return nil, err
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no %s files in %s", ModuleExt, local)
+		}
+	} else if strings.ContainsAny(local, "*?[") {
+		matches, err = filepath.Glob(local)
+		if err != nil {
+			return nil, err
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no files match %s", local)
+		}
+	} else {
+		return withOverride(loc, local), nil
+	}
+
+	var out []string
+	for _, match := range matches {
+		if stat, err := os.Stat(match); err != nil || stat.IsDir() {
+			continue
+		}
+		out = append(out, "file://"+match)
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("no files match %s", local)
+	}
+	sort.Sort(byLoadOrder(out))
+
+	return out, nil
+}
+
+// withOverride returns the file, followed by the override file next to it if
+// there is one
+func withOverride(loc, local string) []string {
+	if IsOverride(local) || !strings.HasSuffix(local, ModuleExt) {
+		return []string{loc}
+	}
+
+	override := strings.TrimSuffix(local, ModuleExt) + OverrideSuffix
+	if stat, err := os.Stat(override); err != nil || stat.IsDir() {
+		return []string{loc}
+	}
+
+	return []string{loc, "file://" + override}
+}
+
+// byLoadOrder sorts files by name, after putting override files last
+type byLoadOrder []string
+
+func (b byLoadOrder) Len() int      { return len(b) }
+func (b byLoadOrder) Swap(i, j int) { b[i], b[j] = b[j], b[i] }
+func (b byLoadOrder) Less(i, j int) bool {
+	if IsOverride(b[i]) != IsOverride(b[j]) {
+		return !IsOverride(b[i])
+	}
+	return b[i] < b[j]
+}
@@ -0,0 +1,87 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fetch_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/asteris-llc/converge/fetch"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpand(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "converge-expand")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	for _, name := range []string{"b.hcl", "a_override.hcl", "a.hcl", "c.txt"} {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), nil, 0600))
+	}
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "d.hcl"), 0700))
+
+	t.Run("directory", func(t *testing.T) {
+		files, err := fetch.Expand("file://" + dir)
+		require.NoError(t, err)
+		assert.Equal(
+			t,
+			[]string{
+				"file://" + filepath.Join(dir, "a.hcl"),
+				"file://" + filepath.Join(dir, "b.hcl"),
+				"file://" + filepath.Join(dir, "a_override.hcl"),
+			},
+			files,
+		)
+	})
+
+	t.Run("glob", func(t *testing.T) {
+		files, err := fetch.Expand("file://" + filepath.Join(dir, "[bc].*"))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"file://" + filepath.Join(dir, "b.hcl"), "file://" + filepath.Join(dir, "c.txt")}, files)
+	})
+
+	t.Run("file", func(t *testing.T) {
+		files, err := fetch.Expand("file://" + filepath.Join(dir, "b.hcl"))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"file://" + filepath.Join(dir, "b.hcl")}, files)
+	})
+
+	t.Run("file with override", func(t *testing.T) {
+		files, err := fetch.Expand("file://" + filepath.Join(dir, "a.hcl"))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"file://" + filepath.Join(dir, "a.hcl"), "file://" + filepath.Join(dir, "a_override.hcl")}, files)
+	})
+
+	t.Run("http", func(t *testing.T) {
+		files, err := fetch.Expand("http://example.com/*.hcl")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"http://example.com/*.hcl"}, files)
+	})
+
+	t.Run("no matches", func(t *testing.T) {
+		_, err := fetch.Expand("file://" + filepath.Join(dir, "*.json"))
+		assert.EqualError(t, err, "no files match "+filepath.Join(dir, "*.json"))
+	})
+
+	t.Run("empty directory", func(t *testing.T) {
+		_, err := fetch.Expand("file://" + filepath.Join(dir, "d.hcl"))
+		assert.EqualError(t, err, "no .hcl files in "+filepath.Join(dir, "d.hcl"))
+	})
+}
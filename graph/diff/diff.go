@@ -0,0 +1,287 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diff
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/asteris-llc/converge/graph"
+	"github.com/asteris-llc/converge/rpc/pb"
+)
+
+// Result is the structural difference between two rendered graphs
+type Result struct {
+	Added   []*Node
+	Removed []*Node
+	Changed []*Change
+
+	// AddedEdges and RemovedEdges are dependencies between nodes. Parent edges
+	// are left out, since they follow from the node IDs.
+	AddedEdges   []graph.Edge
+	RemovedEdges []graph.Edge
+}
+
+// Node is a node that was added or removed
+type Node struct {
+	ID   string
+	Kind string
+}
+
+// Change is a node in both graphs that differs between them
+type Change struct {
+	ID      string
+	OldKind string
+	NewKind string
+	Fields  []*Field
+}
+
+// Field is a field of a changed node, with its old and new values as JSON. A
+// field that isn't set on one side is empty there.
+type Field struct {
+	Name string
+	Old  string
+	New  string
+}
+
+// Graphs compares two graphs loaded with rpc.GrapherClient, whose nodes hold
+// a *pb.GraphComponent_Vertex. Nodes are matched by ID, and compared by kind
+// and by the fields of their rendered details.
+func Graphs(from, to *graph.Graph) (*Result, error) {
+	out := new(Result)
+
+	for _, id := range to.Vertices() {
+		newVertex, err := vertexOf(to, id)
+		if err != nil {
+			return nil, err
+		}
+
+		if !from.Contains(id) {
+			out.Added = append(out.Added, &Node{ID: id, Kind: newVertex.Kind})
+			continue
+		}
+
+		oldVertex, err := vertexOf(from, id)
+		if err != nil {
+			return nil, err
+		}
+
+		change, err := compare(id, oldVertex, newVertex)
+		if err != nil {
+			return nil, err
+		}
+		if change != nil {
+			out.Changed = append(out.Changed, change)
+		}
+	}
+
+	for _, id := range from.Vertices() {
+		if to.Contains(id) {
+			continue
+		}
+
+		vertex, err := vertexOf(from, id)
+		if err != nil {
+			return nil, err
+		}
+		out.Removed = append(out.Removed, &Node{ID: id, Kind: vertex.Kind})
+	}
+
+	oldEdges, newEdges := dependencies(from), dependencies(to)
+	for key, edge := range newEdges {
+		if _, ok := oldEdges[key]; !ok {
+			out.AddedEdges = append(out.AddedEdges, edge)
+		}
+	}
+	for key, edge := range oldEdges {
+		if _, ok := newEdges[key]; !ok {
+			out.RemovedEdges = append(out.RemovedEdges, edge)
+		}
+	}
+	sortEdges(out.AddedEdges)
+	sortEdges(out.RemovedEdges)
+
+	return out, nil
+}
+
+// Empty is true if the graphs are the same
+func (r *Result) Empty() bool {
+	return len(r.Added) == 0 && len(r.Removed) == 0 && len(r.Changed) == 0 &&
+		len(r.AddedEdges) == 0 && len(r.RemovedEdges) == 0
+}
+
+// Write writes the differences to w in a format like a unified diff, with
+// "+" for additions, "-" for removals and "~" for changes, followed by a
+// summary
+func (r *Result) Write(w io.Writer) error {
+	var buf bytes.Buffer
+
+	for _, node := range r.Added {
+		fmt.Fprintf(&buf, "+ %s (%s)\n", node.ID, node.Kind)
+	}
+
+	for _, node := range r.Removed {
+		fmt.Fprintf(&buf, "- %s (%s)\n", node.ID, node.Kind)
+	}
+
+	for _, change := range r.Changed {
+		if change.OldKind != change.NewKind {
+			fmt.Fprintf(&buf, "~ %s (%s => %s)\n", change.ID, change.OldKind, change.NewKind)
+		} else {
+			fmt.Fprintf(&buf, "~ %s (%s)\n", change.ID, change.NewKind)
+		}
+
+		for _, field := range change.Fields {
+			fmt.Fprintf(&buf, "    %s: %s => %s\n", field.Name, orUnset(field.Old), orUnset(field.New))
+		}
+	}
+
+	for _, edge := range r.AddedEdges {
+		fmt.Fprintf(&buf, "+ %s -> %s\n", edge.Source, edge.Dest)
+	}
+
+	for _, edge := range r.RemovedEdges {
+		fmt.Fprintf(&buf, "- %s -> %s\n", edge.Source, edge.Dest)
+	}
+
+	if buf.Len() > 0 {
+		buf.WriteString("\n")
+	}
+	fmt.Fprintf(
+		&buf,
+		"Nodes: %d added, %d removed, %d changed. Edges: %d added, %d removed.\n",
+		len(r.Added), len(r.Removed), len(r.Changed), len(r.AddedEdges), len(r.RemovedEdges),
+	)
+
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+func vertexOf(g *graph.Graph, id string) (*pb.GraphComponent_Vertex, error) {
+	meta, ok := g.Get(id)
+	if !ok {
+		return nil, fmt.Errorf("%s is not in the graph", id)
+	}
+
+	vertex, ok := meta.Value().(*pb.GraphComponent_Vertex)
+	if !ok {
+		return nil, fmt.Errorf("expected %s to be a *pb.GraphComponent_Vertex, got %T", id, meta.Value())
+	}
+	return vertex, nil
+}
+
+// compare returns the change between two versions of a node, or nil if they
+// are the same
+func compare(id string, from, to *pb.GraphComponent_Vertex) (*Change, error) {
+	oldFields, err := fields(id, from.Details)
+	if err != nil {
+		return nil, err
+	}
+	newFields, err := fields(id, to.Details)
+	if err != nil {
+		return nil, err
+	}
+
+	change := &Change{ID: id, OldKind: from.Kind, NewKind: to.Kind}
+
+	names := map[string]struct{}{}
+	for name := range oldFields {
+		names[name] = struct{}{}
+	}
+	for name := range newFields {
+		names[name] = struct{}{}
+	}
+
+	var sorted []string
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+
+	for _, name := range sorted {
+		if oldFields[name] != newFields[name] {
+			change.Fields = append(change.Fields, &Field{Name: name, Old: oldFields[name], New: newFields[name]})
+		}
+	}
+
+	if from.Kind == to.Kind && len(change.Fields) == 0 {
+		return nil, nil
+	}
+	return change, nil
+}
+
+// fields splits the details of a node into its top-level fields, each
+// re-encoded as compact JSON so that they can be compared as strings
+func fields(id string, details []byte) (map[string]string, error) {
+	out := map[string]string{}
+	if len(details) == 0 {
+		return out, nil
+	}
+
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(details, &raw); err != nil {
+		// not an object, so compare it as a whole
+		out[""] = string(details)
+		return out, nil
+	}
+
+	for name, value := range raw {
+		var buf bytes.Buffer
+		if err := json.Compact(&buf, value); err != nil {
+			return nil, fmt.Errorf("could not read %s of %s: %s", name, id, err)
+		}
+		if buf.String() == "null" {
+			continue
+		}
+		out[name] = buf.String()
+	}
+	return out, nil
+}
+
+func dependencies(g *graph.Graph) map[string]graph.Edge {
+	out := map[string]graph.Edge{}
+	for _, edge := range g.Edges() {
+		if len(edge.Attributes) > 0 {
+			continue
+		}
+		out[edge.Source+" -> "+edge.Dest] = edge
+	}
+	return out
+}
+
+func sortEdges(edges []graph.Edge) {
+	sort.Sort(edgeList(edges))
+}
+
+type edgeList []graph.Edge
+
+func (l edgeList) Len() int      { return len(l) }
+func (l edgeList) Swap(i, j int) { l[i], l[j] = l[j], l[i] }
+func (l edgeList) Less(i, j int) bool {
+	if l[i].Source != l[j].Source {
+		return l[i].Source < l[j].Source
+	}
+	return l[i].Dest < l[j].Dest
+}
+
+func orUnset(value string) string {
+	if value == "" {
+		return "<unset>"
+	}
+	return value
+}
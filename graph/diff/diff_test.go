@@ -0,0 +1,124 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diff_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/asteris-llc/converge/graph"
+	"github.com/asteris-llc/converge/graph/diff"
+	"github.com/asteris-llc/converge/graph/node"
+	"github.com/asteris-llc/converge/rpc/pb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGraphs(t *testing.T) {
+	t.Parallel()
+
+	from := graph.New()
+	from.Add(vertex("root", "module", `{}`))
+	from.Add(vertex("root/file.content.config", "file.content", `{"Destination": "/etc/app.conf", "Content": "a"}`))
+	from.Add(vertex("root/task.restart", "task", `{"ApplyStmt": "systemctl restart app"}`))
+	from.Add(vertex("root/task.old", "task", `{}`))
+	from.ConnectParent("root", "root/file.content.config")
+	from.ConnectParent("root", "root/task.restart")
+	from.ConnectParent("root", "root/task.old")
+	from.Connect("root/task.restart", "root/task.old")
+
+	to := graph.New()
+	to.Add(vertex("root", "module", `{}`))
+	to.Add(vertex("root/file.content.config", "file.content", `{"Destination": "/etc/app.conf", "Content": "b", "Mode": 420}`))
+	to.Add(vertex("root/task.restart", "task", `{"ApplyStmt":"systemctl restart app"}`))
+	to.Add(vertex("root/task.new", "task", `{}`))
+	to.ConnectParent("root", "root/file.content.config")
+	to.ConnectParent("root", "root/task.restart")
+	to.ConnectParent("root", "root/task.new")
+	to.Connect("root/task.restart", "root/file.content.config")
+
+	result, err := diff.Graphs(from, to)
+	require.NoError(t, err)
+	assert.False(t, result.Empty())
+
+	assert.Equal(t, []*diff.Node{{ID: "root/task.new", Kind: "task"}}, result.Added)
+	assert.Equal(t, []*diff.Node{{ID: "root/task.old", Kind: "task"}}, result.Removed)
+
+	require.Len(t, result.Changed, 1)
+	assert.Equal(t, "root/file.content.config", result.Changed[0].ID)
+	assert.Equal(t, []*diff.Field{
+		{Name: "Content", Old: `"a"`, New: `"b"`},
+		{Name: "Mode", New: "420"},
+	}, result.Changed[0].Fields)
+
+	assert.Equal(t, []graph.Edge{{Source: "root/task.restart", Dest: "root/file.content.config"}}, result.AddedEdges)
+	assert.Equal(t, []graph.Edge{{Source: "root/task.restart", Dest: "root/task.old"}}, result.RemovedEdges)
+
+	var out bytes.Buffer
+	require.NoError(t, result.Write(&out))
+	assert.Equal(
+		t,
+		`+ root/task.new (task)
+- root/task.old (task)
+~ root/file.content.config (file.content)
+    Content: "a" => "b"
+    Mode: <unset> => 420
++ root/task.restart -> root/file.content.config
+- root/task.restart -> root/task.old
+
+Nodes: 1 added, 1 removed, 1 changed. Edges: 1 added, 1 removed.
+`,
+		out.String(),
+	)
+}
+
+func TestGraphsSame(t *testing.T) {
+	t.Parallel()
+
+	build := func() *graph.Graph {
+		g := graph.New()
+		g.Add(vertex("root", "module", `{}`))
+		g.Add(vertex("root/task.a", "task", `{"CheckStmt": "true"}`))
+		g.ConnectParent("root", "root/task.a")
+		return g
+	}
+
+	result, err := diff.Graphs(build(), build())
+	require.NoError(t, err)
+	assert.True(t, result.Empty())
+
+	var out bytes.Buffer
+	require.NoError(t, result.Write(&out))
+	assert.Equal(t, "Nodes: 0 added, 0 removed, 0 changed. Edges: 0 added, 0 removed.\n", out.String())
+}
+
+func TestGraphsKindChanged(t *testing.T) {
+	t.Parallel()
+
+	from := graph.New()
+	from.Add(vertex("root/x", "task", `{}`))
+	to := graph.New()
+	to.Add(vertex("root/x", "task.query", `{}`))
+
+	result, err := diff.Graphs(from, to)
+	require.NoError(t, err)
+	require.Len(t, result.Changed, 1)
+	assert.Equal(t, "task", result.Changed[0].OldKind)
+	assert.Equal(t, "task.query", result.Changed[0].NewKind)
+}
+
+func vertex(id, kind, details string) *node.Node {
+	return node.New(id, &pb.GraphComponent_Vertex{Id: id, Kind: kind, Details: []byte(details)})
+}
@@ -300,6 +300,62 @@ func (g *Graph) dependencies(id string, carry map[string]struct{}) map[string]st
 	return carry
 }
 
+// DependencyGraph returns a graph of the node with the ID, every node it
+// depends on, and the modules they are in, with the edges between them, so
+// that the node can be checked on its own
+func (g *Graph) DependencyGraph(id string) *Graph {
+	out := New()
+
+	ids := map[string]struct{}{}
+	for _, dep := range append(g.Dependencies(id), id) {
+		// params are rendered from their module, so it has to come along
+		for ; dep != "." && dep != "/"; dep = ParentID(dep) {
+			ids[dep] = struct{}{}
+		}
+	}
+
+	for dep := range ids {
+		if meta, ok := g.Get(dep); ok {
+			out.Add(meta)
+		}
+	}
+
+	for _, edge := range g.inner.Edges() {
+		_, source := ids[edge.Source().(string)]
+		_, dest := ids[edge.Target().(string)]
+		if source && dest {
+			out.inner.Connect(edge)
+		}
+	}
+
+	return out
+}
+
+// Path returns the IDs along a chain of edges from one vertex to another,
+// including both ends, or nil if there is none
+func (g *Graph) Path(from, to string) []string {
+	return g.path(from, to, make(map[string]struct{}))
+}
+
+func (g *Graph) path(from, to string, seen map[string]struct{}) []string {
+	if from == to {
+		return []string{to}
+	}
+	seen[from] = struct{}{}
+
+	for _, edge := range g.DownEdges(from) {
+		next := edge.Target().(string)
+		if _, ok := seen[next]; ok {
+			continue
+		}
+
+		if rest := g.path(next, to, seen); rest != nil {
+			return append([]string{from}, rest...)
+		}
+	}
+	return nil
+}
+
 // Walk the graph leaf-to-root
 func (g *Graph) Walk(ctx context.Context, cb WalkFunc) error {
 	return dependencyWalk(ctx, g, cb)
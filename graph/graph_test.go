@@ -451,6 +451,55 @@ func TestValidateCycle(t *testing.T) {
 	}
 }
 
+func TestPath(t *testing.T) {
+	t.Parallel()
+
+	g := graph.New()
+	g.Add(node.New("a", nil))
+	g.Add(node.New("b", nil))
+	g.Add(node.New("c", nil))
+	g.Add(node.New("d", nil))
+
+	g.Connect("a", "b")
+	g.Connect("b", "c")
+	g.Connect("a", "d")
+
+	assert.Equal(t, []string{"a", "b", "c"}, g.Path("a", "c"))
+	assert.Equal(t, []string{"a"}, g.Path("a", "a"))
+	assert.Nil(t, g.Path("c", "a"))
+}
+
+func TestDependencyGraph(t *testing.T) {
+	t.Parallel()
+
+	g := graph.New()
+	g.Add(node.New("root", nil))
+	g.Add(node.New("root/a", nil))
+	g.Add(node.New("root/b", nil))
+	g.Add(node.New("root/c", nil))
+	g.Add(node.New("root/d", nil))
+
+	g.ConnectParent("root", "root/a")
+	g.ConnectParent("root", "root/b")
+	g.ConnectParent("root", "root/c")
+	g.ConnectParent("root", "root/d")
+	g.Connect("root/a", "root/b")
+	g.Connect("root/b", "root/c")
+	g.Connect("root/d", "root/a")
+
+	deps := g.DependencyGraph("root/b")
+	require.NoError(t, deps.Validate())
+
+	vertices := deps.Vertices()
+	sort.Strings(vertices)
+	assert.Equal(t, []string{"root", "root/b", "root/c"}, vertices)
+
+	children := deps.Children("root")
+	sort.Strings(children)
+	assert.Equal(t, []string{"root/b", "root/c"}, children)
+	assert.Len(t, deps.DownEdges("root/b"), 1)
+}
+
 func TestValidateDanglingEdge(t *testing.T) {
 	t.Parallel()
 
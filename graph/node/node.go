@@ -19,11 +19,24 @@ type Groupable interface {
 	Group() string
 }
 
+// Annotated returns the metadata set on a node for tooling outside of converge
+type Annotated interface {
+	Metadata() map[string]string
+}
+
 // Node tracks the metadata associated with a node in the graph
 type Node struct {
 	ID    string `json:"id"`
 	Group string `json:"group"`
 
+	// Position is the file, line, and column of the block the node was loaded
+	// from, if known
+	Position string `json:"position,omitempty"`
+
+	// Metadata is the key/value annotations set on the node's block, like the
+	// team that owns it. Converge carries them along without using them.
+	Metadata map[string]string `json:"metadata,omitempty"`
+
 	value interface{}
 }
 
@@ -34,6 +47,7 @@ func New(id string, value interface{}) *Node {
 		value: value,
 	}
 	n.setGroup()
+	n.setMetadata()
 
 	return n
 }
@@ -49,6 +63,7 @@ func (n *Node) WithValue(value interface{}) *Node {
 	*copied = *n
 	copied.value = value
 	copied.setGroup()
+	copied.setMetadata()
 
 	return copied
 }
@@ -58,3 +73,9 @@ func (n *Node) setGroup() {
 		n.Group = groupable.Group()
 	}
 }
+
+func (n *Node) setMetadata() {
+	if annotated, ok := n.value.(Annotated); ok {
+		n.Metadata = annotated.Metadata()
+	}
+}
@@ -79,6 +79,28 @@ func TestWithGroupable(t *testing.T) {
 	})
 }
 
+// TestWithAnnotated tests that metadata is set when the value is Annotated
+func TestWithAnnotated(t *testing.T) {
+	t.Parallel()
+
+	metadata := map[string]string{"owner": "platform"}
+
+	t.Run("New", func(t *testing.T) {
+		n := node.New("test", anAnnotated(metadata))
+		assert.Equal(t, metadata, n.Metadata)
+	})
+
+	t.Run("WithValue", func(t *testing.T) {
+		fst := node.New("test", anAnnotated(metadata))
+		snd := fst.WithValue(1)
+		assert.Equal(t, metadata, snd.Metadata)
+	})
+}
+
+type anAnnotated map[string]string
+
+func (a anAnnotated) Metadata() map[string]string { return a }
+
 type aGroupable struct {
 	group string
 }
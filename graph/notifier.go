@@ -43,10 +43,26 @@ func NotifyPost(post NotifyFunc, inner TransformFunc) TransformFunc {
 	}
 }
 
+// OutputFunc will be called with each line of output from a node while it runs
+type OutputFunc func(meta *node.Node, stream, line string)
+
 // Notifier can wrap a graph transform
 type Notifier struct {
-	Pre  NotifyFunc
-	Post NotifyFunc
+	Pre    NotifyFunc
+	Post   NotifyFunc
+	Output OutputFunc
+}
+
+// OutputFor returns a function that passes the output of a node to Output, or
+// nil if there is no Output
+func (n *Notifier) OutputFor(meta *node.Node) func(stream, line string) {
+	if n == nil || n.Output == nil || meta == nil {
+		return nil
+	}
+
+	return func(stream, line string) {
+		n.Output(meta, stream, line)
+	}
 }
 
 // Transform wraps a TransformFunc with this notifier
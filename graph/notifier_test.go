@@ -120,3 +120,24 @@ func TestNotifyTransform(t *testing.T) {
 		)
 	})
 }
+
+func TestNotifierOutputFor(t *testing.T) {
+	t.Parallel()
+
+	t.Run("no output", func(t *testing.T) {
+		assert.Nil(t, (*graph.Notifier)(nil).OutputFor(node.New("root", 1)))
+		assert.Nil(t, new(graph.Notifier).OutputFor(node.New("root", 1)))
+	})
+
+	t.Run("output", func(t *testing.T) {
+		var got []string
+		notifier := &graph.Notifier{
+			Output: func(meta *node.Node, stream, line string) {
+				got = append(got, meta.ID, stream, line)
+			},
+		}
+
+		notifier.OutputFor(node.New("root", 1))("stdout", "hello")
+		assert.Equal(t, []string{"root", "stdout", "hello"}, got)
+	})
+}
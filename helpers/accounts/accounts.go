@@ -0,0 +1,291 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package accounts
+
+import (
+	"fmt"
+	"os/user"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/pkg/errors"
+)
+
+// DefaultTTL is how long a Resolver caches lookups by default
+const DefaultTTL = 30 * time.Second
+
+// Resolver looks up users and groups with getent(1). Unlike os/user, this
+// finds accounts from every source in the name service switch, such as LDAP
+// or sssd, and looks them up wherever the executor runs commands. Lookups are
+// cached for TTL, and resources that change accounts call Invalidate.
+type Resolver struct {
+	// Exec runs getent, or reads the files in /etc where it is missing. If
+	// nil, it is run on the local system.
+	Exec exec.Executor
+
+	// TTL is how long lookups are cached. 0 disables caching.
+	TTL time.Duration
+
+	lock  sync.Mutex
+	cache map[string]*entry
+}
+
+type entry struct {
+	fields  []string
+	found   bool
+	expires time.Time
+}
+
+// New returns a Resolver that runs getent with e
+func New(e exec.Executor) *Resolver {
+	return &Resolver{Exec: e, TTL: DefaultTTL}
+}
+
+var local = New(nil)
+
+// For returns the shared Resolver for the local system if e runs commands
+// there, or a new Resolver for e otherwise
+func For(e exec.Executor) *Resolver {
+	if exec.Local(e) {
+		return local
+	}
+	return New(e)
+}
+
+// Lookup looks up a user by name. It returns user.UnknownUserError if there
+// is no such user.
+func (r *Resolver) Lookup(name string) (*user.User, error) {
+	fields, found, err := r.getent("passwd", name, 7)
+	if err != nil {
+		return nil, err
+	}
+	if !found || fields[0] != name {
+		return nil, user.UnknownUserError(name)
+	}
+	return toUser(fields), nil
+}
+
+// LookupID looks up a user by uid. It returns user.UnknownUserIdError if there
+// is no such user.
+func (r *Resolver) LookupID(uid string) (*user.User, error) {
+	id, err := strconv.Atoi(uid)
+	if err != nil {
+		return nil, fmt.Errorf("invalid uid %q", uid)
+	}
+
+	fields, found, err := r.getent("passwd", uid, 7)
+	if err != nil {
+		return nil, err
+	}
+	if !found || fields[2] != uid {
+		return nil, user.UnknownUserIdError(id)
+	}
+	return toUser(fields), nil
+}
+
+// LookupGroup looks up a group by name. It returns user.UnknownGroupError if
+// there is no such group.
+func (r *Resolver) LookupGroup(name string) (*user.Group, error) {
+	fields, found, err := r.getent("group", name, 4)
+	if err != nil {
+		return nil, err
+	}
+	if !found || fields[0] != name {
+		return nil, user.UnknownGroupError(name)
+	}
+	return &user.Group{Name: fields[0], Gid: fields[2]}, nil
+}
+
+// LookupGroupID looks up a group by gid. It returns user.UnknownGroupIdError
+// if there is no such group.
+func (r *Resolver) LookupGroupID(gid string) (*user.Group, error) {
+	fields, found, err := r.getent("group", gid, 4)
+	if err != nil {
+		return nil, err
+	}
+	if !found || fields[2] != gid {
+		return nil, user.UnknownGroupIdError(gid)
+	}
+	return &user.Group{Name: fields[0], Gid: fields[2]}, nil
+}
+
+// UID resolves an owner given as a user name or a uid. A number is used as a
+// uid even if no such user exists, as chown(1) does.
+func (r *Resolver) UID(owner string) (int, error) {
+	if id, err := strconv.Atoi(owner); err == nil {
+		return id, nil
+	}
+
+	u, err := r.Lookup(owner)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(u.Uid)
+}
+
+// GID resolves a group given as a group name or a gid. A number is used as a
+// gid even if no such group exists, as chgrp(1) does.
+func (r *Resolver) GID(group string) (int, error) {
+	if id, err := strconv.Atoi(group); err == nil {
+		return id, nil
+	}
+
+	g, err := r.LookupGroup(group)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(g.Gid)
+}
+
+// Invalidate forgets every cached lookup
+func (r *Resolver) Invalidate() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.cache = nil
+}
+
+// getent reads an entry from a database, split into fields. The second value
+// is false if there is no such entry.
+func (r *Resolver) getent(database, key string, fields int) ([]string, bool, error) {
+	cacheKey := database + ":" + key
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	now := time.Now()
+	if cached, ok := r.cache[cacheKey]; ok && now.Before(cached.expires) {
+		return cached.fields, cached.found, nil
+	}
+
+	e := r.Exec
+	if e == nil {
+		e = exec.New()
+	}
+
+	result := &entry{expires: now.Add(r.TTL)}
+
+	var err error
+	result.fields, result.found, err = Getent(e, database, key)
+	if err != nil {
+		return nil, false, err
+	}
+	if result.found && len(result.fields) < fields {
+		return nil, false, fmt.Errorf("malformed %s entry for %s", database, key)
+	}
+
+	if r.TTL > 0 {
+		if r.cache == nil {
+			r.cache = make(map[string]*entry)
+		}
+		r.cache[cacheKey] = result
+	}
+
+	return result.fields, result.found, nil
+}
+
+// Getent reads the entry for key from a database such as passwd or group with
+// getent(1), split into fields. The second value is false if there is no such
+// entry. Minimal systems like busybox may not have getent, so the passwd,
+// group, and shadow files in /etc are read instead when it can't be found.
+func Getent(e exec.Executor, database, key string) ([]string, bool, error) {
+	out, err := exec.Read(e, "getent", database, key)
+	status, _ := exec.ExitStatus(err)
+	switch {
+	case err == nil:
+		return strings.Split(strings.TrimSpace(out), ":"), true, nil
+
+	case exec.NotFound(err):
+		return readEntry(e, database, key)
+
+	case status == 2:
+		// getent exits 2 if the key could not be found
+		return nil, false, nil
+
+	default:
+		return nil, false, errors.Wrapf(err, "could not read %s entry for %s", database, key)
+	}
+}
+
+// readEntry finds an entry in /etc/<database> by name, or by ID if key is a
+// number. Shadow entries have no ID, so they are only found by name.
+func readEntry(e exec.Executor, database, key string) ([]string, bool, error) {
+	content, _, err := exec.ReadFile(e, "/etc/"+database)
+	if err != nil {
+		return nil, false, errors.Wrapf(err, "could not read %s entry for %s", database, key)
+	}
+
+	fields := lookup(parseEntries(content), database, key)
+	return fields, fields != nil, nil
+}
+
+// parseEntries splits the lines of an account file into fields
+func parseEntries(content string) (entries [][]string) {
+	for _, line := range strings.Split(content, "\n") {
+		if line != "" {
+			entries = append(entries, strings.Split(line, ":"))
+		}
+	}
+	return entries
+}
+
+// lookup finds the entry for key by name or, if it is a number, by ID
+func lookup(entries [][]string, database, key string) []string {
+	_, err := strconv.Atoi(key)
+	byID := err == nil && database != "shadow" && database != "gshadow"
+
+	for _, fields := range entries {
+		if comment(fields) {
+			continue
+		}
+		if fields[0] == key || (byID && len(fields) > 2 && fields[2] == key) {
+			return fields
+		}
+	}
+	return nil
+}
+
+func toUser(fields []string) *user.User {
+	return &user.User{
+		Username: fields[0],
+		Uid:      fields[2],
+		Gid:      fields[3],
+		Name:     fields[4],
+		HomeDir:  fields[5],
+	}
+}
+
+// Owner resolves an owner and group given as names or IDs, as for chown(1).
+// Either may be empty, in which case -1 is returned for it.
+func (r *Resolver) Owner(owner, group string) (uid, gid int, err error) {
+	uid, gid = -1, -1
+
+	if owner != "" {
+		if uid, err = r.UID(owner); err != nil {
+			return -1, -1, err
+		}
+	}
+
+	if group != "" {
+		if gid, err = r.GID(group); err != nil {
+			return -1, -1, err
+		}
+	}
+
+	return uid, gid, nil
+}
@@ -0,0 +1,192 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package accounts_test
+
+import (
+	"os/user"
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/accounts"
+	"github.com/asteris-llc/converge/helpers/fakeexec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolver(t *testing.T) {
+	t.Parallel()
+
+	t.Run("users", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("getent", "passwd", "alice").Return("alice:x:1000:100:Alice:/home/alice:/bin/sh\n", 0).Once()
+		fake.Expect("getent", "passwd", "1000").Return("alice:x:1000:100:Alice:/home/alice:/bin/sh\n", 0).Once()
+		fake.Expect("getent", "passwd", "bob").Return("", 2).Once()
+
+		r := accounts.New(fake)
+
+		u, err := r.Lookup("alice")
+		require.NoError(t, err)
+		assert.Equal(t, &user.User{Username: "alice", Uid: "1000", Gid: "100", Name: "Alice", HomeDir: "/home/alice"}, u)
+
+		u, err = r.LookupID("1000")
+		require.NoError(t, err)
+		assert.Equal(t, "alice", u.Username)
+
+		_, err = r.Lookup("bob")
+		assert.Equal(t, user.UnknownUserError("bob"), err)
+
+		// cached, so getent isn't run again
+		uid, err := r.UID("alice")
+		require.NoError(t, err)
+		assert.Equal(t, 1000, uid)
+
+		_, err = r.Lookup("bob")
+		assert.Equal(t, user.UnknownUserError("bob"), err)
+
+		fake.AssertExpectations(t)
+	})
+
+	t.Run("groups", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("getent", "group", "wheel").Return("wheel:x:10:alice\n", 0).Once()
+		fake.Expect("getent", "group", "20").Return("", 2).Once()
+
+		r := accounts.New(fake)
+
+		g, err := r.LookupGroup("wheel")
+		require.NoError(t, err)
+		assert.Equal(t, &user.Group{Name: "wheel", Gid: "10"}, g)
+
+		gid, err := r.GID("wheel")
+		require.NoError(t, err)
+		assert.Equal(t, 10, gid)
+
+		_, err = r.LookupGroupID("20")
+		assert.Equal(t, user.UnknownGroupIdError("20"), err)
+
+		// numbers are used as they are, as chgrp does
+		gid, err = r.GID("20")
+		require.NoError(t, err)
+		assert.Equal(t, 20, gid)
+	})
+
+	t.Run("numeric name", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("getent", "passwd", "0").Return("root:x:0:0:root:/root:/bin/sh\n", 0)
+
+		_, err := accounts.New(fake).Lookup("0")
+		assert.Equal(t, user.UnknownUserError("0"), err)
+	})
+
+	t.Run("invalidate", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("getent", "group", "new").Return("", 2).Once()
+		fake.Expect("getent", "group", "new").Return("new:x:1001:\n", 0).Once()
+
+		r := accounts.New(fake)
+
+		_, err := r.LookupGroup("new")
+		assert.Error(t, err)
+
+		r.Invalidate()
+
+		g, err := r.LookupGroup("new")
+		require.NoError(t, err)
+		assert.Equal(t, "1001", g.Gid)
+	})
+
+	t.Run("no cache", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("getent", "group", "wheel").Return("wheel:x:10:\n", 0).Times(2)
+
+		r := accounts.New(fake)
+		r.TTL = 0
+
+		for i := 0; i < 2; i++ {
+			_, err := r.LookupGroup("wheel")
+			require.NoError(t, err)
+		}
+		assert.Len(t, fake.Calls(), 2)
+	})
+
+	t.Run("failure", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("getent", "passwd", "alice").Return("", 1).Stderr("broken")
+
+		_, err := accounts.New(fake).Lookup("alice")
+		assert.EqualError(t, err, "could not read passwd entry for alice: getent: exit status 1: broken")
+	})
+}
+
+// TestGetent tests reading entries without getent
+func TestGetent(t *testing.T) {
+	t.Parallel()
+
+	passwd := "# local users\nroot:x:0:0:root:/root:/bin/sh\nalice:x:1000:100:Alice:/home/alice:/bin/sh\n"
+
+	t.Run("getent", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("getent", "group", "wheel").Return("wheel:x:10:alice\n", 0)
+
+		fields, found, err := accounts.Getent(fake, "group", "wheel")
+		require.NoError(t, err)
+		assert.True(t, found)
+		assert.Equal(t, []string{"wheel", "x", "10", "alice"}, fields)
+	})
+
+	t.Run("fallback", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("getent", "passwd", "alice").Return("", 127)
+		fake.Expect("getent", "passwd", "0").Return("", 127)
+		fake.Expect("getent", "passwd", "bob").Return("", 127)
+		fake.Expect("test", "-e", "/etc/passwd")
+		fake.Expect("cat", "/etc/passwd").Return(passwd, 0)
+
+		fields, found, err := accounts.Getent(fake, "passwd", "alice")
+		require.NoError(t, err)
+		assert.True(t, found)
+		assert.Equal(t, "/home/alice", fields[5])
+
+		fields, found, err = accounts.Getent(fake, "passwd", "0")
+		require.NoError(t, err)
+		assert.True(t, found)
+		assert.Equal(t, "root", fields[0])
+
+		_, found, err = accounts.Getent(fake, "passwd", "bob")
+		require.NoError(t, err)
+		assert.False(t, found)
+	})
+
+	t.Run("shadow by name", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("getent", "shadow", "0").Return("", 127)
+		fake.Expect("test", "-e", "/etc/shadow")
+		fake.Expect("cat", "/etc/shadow").Return("root:!:0:0:99999:7:::\n", 0)
+
+		_, found, err := accounts.Getent(fake, "shadow", "0")
+		require.NoError(t, err)
+		assert.False(t, found)
+	})
+
+	t.Run("resolver", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("getent", "passwd", "alice").Return("", 127)
+		fake.Expect("test", "-e", "/etc/passwd")
+		fake.Expect("cat", "/etc/passwd").Return(passwd, 0)
+
+		u, err := accounts.New(fake).Lookup("alice")
+		require.NoError(t, err)
+		assert.Equal(t, "1000", u.Uid)
+	})
+}
@@ -0,0 +1,228 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package accounts
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/asteris-llc/converge/helpers/atomicfile"
+)
+
+// Files edits the passwd, shadow, group, and gshadow files directly, for
+// systems such as scratch containers that have none of the tools to manage
+// accounts with. The files are replaced atomically, but the lock files used by
+// those tools are not taken, so they shouldn't be run at the same time.
+type Files struct {
+	// Root is the directory containing etc. If empty, the files of the local
+	// system are edited.
+	Root string
+}
+
+// fieldCounts are the number of fields in the entries of each database.
+// Entries cut short are padded before being modified.
+var fieldCounts = map[string]int{
+	"passwd":  7,
+	"shadow":  9,
+	"group":   4,
+	"gshadow": 4,
+}
+
+// the files are read, changed, and written back, so edits from resources
+// running in parallel have to wait for each other
+var filesLock sync.Mutex
+
+// Path returns the path of the file holding a database
+func (f *Files) Path(database string) string {
+	return filepath.Join(f.Root, "etc", database)
+}
+
+// Exists reports whether the file holding a database exists. shadow and
+// gshadow are optional, and are only edited if they do.
+func (f *Files) Exists(database string) bool {
+	_, err := os.Stat(f.Path(database))
+	return err == nil
+}
+
+// Find returns the entry for key in a database, found by name or, if key is
+// a number, by ID. The second value is false if there is no such entry.
+func (f *Files) Find(database, key string) ([]string, bool, error) {
+	filesLock.Lock()
+	defer filesLock.Unlock()
+
+	entries, _, err := f.read(database)
+	if err != nil {
+		return nil, false, err
+	}
+
+	fields := lookup(entries, database, key)
+	return fields, fields != nil, nil
+}
+
+// Add appends an entry to a database. It is an error if there is already an
+// entry with the same name.
+func (f *Files) Add(database string, fields []string) error {
+	return f.update(database, func(entries [][]string) ([][]string, error) {
+		if find(entries, fields[0]) >= 0 {
+			return nil, fmt.Errorf("%s already has an entry for %s", database, fields[0])
+		}
+		return append(entries, fields), nil
+	})
+}
+
+// Modify changes the entry with the given name in a database. It is an error
+// if there is no such entry.
+func (f *Files) Modify(database, name string, modify func(fields []string)) error {
+	return f.update(database, func(entries [][]string) ([][]string, error) {
+		i := find(entries, name)
+		if i < 0 {
+			return nil, fmt.Errorf("%s has no entry for %s", database, name)
+		}
+		entries[i] = pad(database, entries[i])
+		modify(entries[i])
+		return entries, nil
+	})
+}
+
+// ModifyAll changes every entry in a database
+func (f *Files) ModifyAll(database string, modify func(fields []string)) error {
+	return f.update(database, func(entries [][]string) ([][]string, error) {
+		for i, fields := range entries {
+			if !comment(fields) {
+				entries[i] = pad(database, fields)
+				modify(entries[i])
+			}
+		}
+		return entries, nil
+	})
+}
+
+// Remove removes the entry with the given name from a database, if there is
+// one
+func (f *Files) Remove(database, name string) error {
+	return f.update(database, func(entries [][]string) ([][]string, error) {
+		if i := find(entries, name); i >= 0 {
+			entries = append(entries[:i], entries[i+1:]...)
+		}
+		return entries, nil
+	})
+}
+
+// NextID returns the lowest ID from min to max that isn't used in a database
+func (f *Files) NextID(database string, min, max int) (int, error) {
+	filesLock.Lock()
+	defer filesLock.Unlock()
+
+	entries, _, err := f.read(database)
+	if err != nil {
+		return 0, err
+	}
+
+	used := make(map[int]bool)
+	for _, fields := range entries {
+		if len(fields) > 2 {
+			if id, err := strconv.Atoi(fields[2]); err == nil {
+				used[id] = true
+			}
+		}
+	}
+
+	for id := min; id <= max; id++ {
+		if !used[id] {
+			return id, nil
+		}
+	}
+	return 0, fmt.Errorf("no free ID in %s from %d to %d", database, min, max)
+}
+
+// update replaces the entries of a database with the ones returned by change.
+// An optional database that doesn't exist is left alone.
+func (f *Files) update(database string, change func([][]string) ([][]string, error)) error {
+	filesLock.Lock()
+	defer filesLock.Unlock()
+
+	entries, exists, err := f.read(database)
+	if err != nil {
+		return err
+	}
+	if !exists && (database == "shadow" || database == "gshadow") {
+		return nil
+	}
+
+	entries, err = change(entries)
+	if err != nil {
+		return err
+	}
+
+	var content string
+	for _, fields := range entries {
+		content += strings.Join(fields, ":") + "\n"
+	}
+
+	perm := os.FileMode(0644)
+	if stat, err := os.Stat(f.Path(database)); err == nil {
+		perm = stat.Mode().Perm()
+	}
+
+	return atomicfile.Write(f.Path(database), []byte(content), perm)
+}
+
+func (f *Files) read(database string) ([][]string, bool, error) {
+	content, err := ioutil.ReadFile(f.Path(database))
+	if os.IsNotExist(err) {
+		return nil, false, nil
+	} else if err != nil {
+		return nil, false, err
+	}
+
+	return parseEntries(string(content)), true, nil
+}
+
+// RemoveMember removes a name from a list of members, as kept in group and
+// gshadow
+func RemoveMember(members, name string) string {
+	var out []string
+	for _, member := range strings.Split(members, ",") {
+		if member != "" && member != name {
+			out = append(out, member)
+		}
+	}
+	return strings.Join(out, ",")
+}
+
+func pad(database string, fields []string) []string {
+	for len(fields) < fieldCounts[database] {
+		fields = append(fields, "")
+	}
+	return fields
+}
+
+func find(entries [][]string, name string) int {
+	for i, fields := range entries {
+		if !comment(fields) && fields[0] == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func comment(fields []string) bool {
+	return strings.HasPrefix(fields[0], "#")
+}
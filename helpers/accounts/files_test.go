@@ -0,0 +1,120 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package accounts_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/accounts"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestFiles tests editing the account files directly
+func TestFiles(t *testing.T) {
+	t.Parallel()
+
+	t.Run("add and find", func(t *testing.T) {
+		files := setupFiles(t, map[string]string{"passwd": "# users\nroot:x:0:0:root:/root:/bin/sh\n"})
+		defer os.RemoveAll(files.Root)
+
+		require.NoError(t, files.Add("passwd", []string{"alice", "x", "1000", "1000", "", "/home/alice", "/bin/sh"}))
+		assert.EqualError(
+			t,
+			files.Add("passwd", []string{"alice", "x", "1001", "1001", "", "/home/alice", "/bin/sh"}),
+			"passwd already has an entry for alice",
+		)
+
+		fields, found, err := files.Find("passwd", "1000")
+		require.NoError(t, err)
+		assert.True(t, found)
+		assert.Equal(t, "alice", fields[0])
+
+		assert.Equal(
+			t,
+			"# users\nroot:x:0:0:root:/root:/bin/sh\nalice:x:1000:1000::/home/alice:/bin/sh\n",
+			readFile(t, files, "passwd"),
+		)
+	})
+
+	t.Run("modify", func(t *testing.T) {
+		files := setupFiles(t, map[string]string{"shadow": "alice:!:19000:0:99999:7\n"})
+		defer os.RemoveAll(files.Root)
+
+		require.NoError(t, files.Modify("shadow", "alice", func(fields []string) {
+			fields[7] = "20000"
+		}))
+		assert.Equal(t, "alice:!:19000:0:99999:7::20000:\n", readFile(t, files, "shadow"))
+
+		assert.EqualError(t, files.Modify("shadow", "bob", func([]string) {}), "shadow has no entry for bob")
+	})
+
+	t.Run("remove", func(t *testing.T) {
+		files := setupFiles(t, map[string]string{"group": "wheel:x:10:alice,bob\nalice:x:1000:\n"})
+		defer os.RemoveAll(files.Root)
+
+		require.NoError(t, files.Remove("group", "alice"))
+		require.NoError(t, files.Remove("group", "carol"))
+		require.NoError(t, files.ModifyAll("group", func(fields []string) {
+			fields[3] = accounts.RemoveMember(fields[3], "alice")
+		}))
+		assert.Equal(t, "wheel:x:10:bob\n", readFile(t, files, "group"))
+	})
+
+	t.Run("optional", func(t *testing.T) {
+		files := setupFiles(t, nil)
+		defer os.RemoveAll(files.Root)
+
+		assert.False(t, files.Exists("shadow"))
+		require.NoError(t, files.Add("shadow", []string{"alice", "!", "", "", "", "", "", "", ""}))
+		assert.False(t, files.Exists("shadow"))
+
+		require.NoError(t, files.Add("group", []string{"alice", "x", "1000", ""}))
+		assert.Equal(t, "alice:x:1000:\n", readFile(t, files, "group"))
+	})
+
+	t.Run("next id", func(t *testing.T) {
+		files := setupFiles(t, map[string]string{"passwd": "a:x:1000:1000:::\nb:x:1001:1001:::\nc:x:1003:1003:::\n"})
+		defer os.RemoveAll(files.Root)
+
+		id, err := files.NextID("passwd", 1000, 60000)
+		require.NoError(t, err)
+		assert.Equal(t, 1002, id)
+
+		_, err = files.NextID("passwd", 1000, 1001)
+		assert.EqualError(t, err, "no free ID in passwd from 1000 to 1001")
+	})
+}
+
+func setupFiles(t *testing.T, content map[string]string) *accounts.Files {
+	root, err := ioutil.TempDir("", "converge-accounts")
+	require.NoError(t, err)
+	require.NoError(t, os.Mkdir(filepath.Join(root, "etc"), 0755))
+
+	files := &accounts.Files{Root: root}
+	for database, text := range content {
+		require.NoError(t, ioutil.WriteFile(files.Path(database), []byte(text), 0644))
+	}
+	return files
+}
+
+func readFile(t *testing.T, files *accounts.Files, database string) string {
+	content, err := ioutil.ReadFile(files.Path(database))
+	require.NoError(t, err)
+	return string(content)
+}
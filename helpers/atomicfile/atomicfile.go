@@ -0,0 +1,123 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package atomicfile
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"github.com/pkg/errors"
+)
+
+// Write replaces the file at path with content. The content is written to a
+// temporary file in the same directory, synced, and given perm and the owner
+// of the file it replaces before being renamed over path. path never holds
+// partly written content, even if the process dies while writing.
+//
+// If path is a symlink, the file it points to is replaced.
+func Write(path string, content []byte, perm os.FileMode) error {
+	return WriteOwned(path, content, perm, -1, -1)
+}
+
+// WriteOwned is like Write, but gives the file the uid and gid. Either may be
+// -1 to keep the one of the file being replaced.
+func WriteOwned(path string, content []byte, perm os.FileMode, uid, gid int) error {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+
+	dir := filepath.Dir(path)
+	tmp, err := ioutil.TempFile(dir, "."+filepath.Base(path)+".")
+	if err != nil {
+		return errors.Wrapf(err, "could not create temporary file for %s", path)
+	}
+	defer os.Remove(tmp.Name()) // fails harmlessly once renamed
+
+	if err := write(tmp, path, content, perm, uid, gid); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+
+	return syncDir(dir)
+}
+
+func write(tmp *os.File, path string, content []byte, perm os.FileMode, uid, gid int) error {
+	if _, err := tmp.Write(content); err != nil {
+		return err
+	}
+
+	if err := tmp.Sync(); err != nil {
+		return err
+	}
+
+	if err := tmp.Chmod(perm); err != nil {
+		return err
+	}
+
+	if uid < 0 || gid < 0 {
+		stat, err := os.Stat(path)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+
+		if owner, ok := statOwner(stat); ok {
+			if uid < 0 {
+				uid = owner.uid
+			}
+			if gid < 0 {
+				gid = owner.gid
+			}
+		}
+	}
+
+	if uid < 0 && gid < 0 {
+		return nil
+	}
+
+	if err := tmp.Chown(uid, gid); err != nil {
+		return errors.Wrapf(err, "could not set the owner of %s", path)
+	}
+	return nil
+}
+
+type owner struct {
+	uid, gid int
+}
+
+// syncDir syncs the directory so that the rename is persisted
+func syncDir(dir string) error {
+	f, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	// some filesystems don't support syncing directories, and the rename has
+	// already happened by now
+	if err := f.Sync(); err != nil && err != syscall.EINVAL {
+		return err
+	}
+	return nil
+}
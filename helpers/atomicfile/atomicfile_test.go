@@ -0,0 +1,90 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package atomicfile_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/atomicfile"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWrite(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "converge-atomicfile")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "file")
+
+	t.Run("new", func(t *testing.T) {
+		require.NoError(t, atomicfile.Write(path, []byte("one"), 0640))
+
+		content, err := ioutil.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, "one", string(content))
+
+		stat, err := os.Stat(path)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0640), stat.Mode().Perm())
+	})
+
+	t.Run("replace", func(t *testing.T) {
+		require.NoError(t, atomicfile.Write(path, []byte("two"), 0600))
+
+		content, err := ioutil.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, "two", string(content))
+
+		stat, err := os.Stat(path)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0600), stat.Mode().Perm())
+	})
+
+	t.Run("symlink", func(t *testing.T) {
+		link := filepath.Join(dir, "link")
+		require.NoError(t, os.Symlink(path, link))
+
+		require.NoError(t, atomicfile.Write(link, []byte("three"), 0600))
+
+		stat, err := os.Lstat(link)
+		require.NoError(t, err)
+		assert.Equal(t, os.ModeSymlink, stat.Mode()&os.ModeSymlink)
+
+		content, err := ioutil.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, "three", string(content))
+	})
+
+	t.Run("no leftovers", func(t *testing.T) {
+		entries, err := ioutil.ReadDir(dir)
+		require.NoError(t, err)
+
+		var names []string
+		for _, entry := range entries {
+			names = append(names, entry.Name())
+		}
+		assert.Equal(t, []string{"file", "link"}, names)
+	})
+
+	t.Run("missing directory", func(t *testing.T) {
+		assert.Error(t, atomicfile.Write(filepath.Join(dir, "missing", "file"), []byte("x"), 0600))
+	})
+}
@@ -0,0 +1,35 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !windows
+
+package atomicfile
+
+import (
+	"os"
+	"syscall"
+)
+
+// statOwner returns the owner of a file, if it exists
+func statOwner(stat os.FileInfo) (owner, bool) {
+	if stat == nil {
+		return owner{}, false
+	}
+
+	sys, ok := stat.Sys().(*syscall.Stat_t)
+	if !ok {
+		return owner{}, false
+	}
+	return owner{uid: int(sys.Uid), gid: int(sys.Gid)}, true
+}
@@ -0,0 +1,71 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exec
+
+// Become is an Executor that runs commands as another user with sudo
+type Become struct {
+	// Executor runs the wrapped sudo command. If nil, it is run on the local
+	// system.
+	Executor Executor
+
+	// User is the user to run commands as. If empty, commands are run as root.
+	User string
+
+	// Password is given to sudo on stdin. If empty, sudo is run
+	// non-interactively and must not require a password.
+	Password string
+}
+
+// Run runs the command through sudo
+func (b *Become) Run(c *Command) (*Result, error) {
+	return orLocal(b.Executor).Run(b.Wrap(c))
+}
+
+// Wrap returns the sudo command line that runs c. The environment is passed
+// through env(1) since sudo resets it by default. The password, if any, is
+// never placed on the command line where it would be visible in logs or the
+// process table.
+func (b *Become) Wrap(c *Command) *Command {
+	var args []string
+	stdin := c.Stdin
+
+	if b.Password == "" {
+		args = append(args, "-n")
+	} else {
+		args = append(args, "-S", "-p", "")
+		stdin = b.Password + "\n" + stdin
+	}
+
+	if b.User != "" {
+		args = append(args, "-u", b.User)
+	}
+
+	args = append(args, "--")
+	if len(c.Env) > 0 {
+		args = append(args, "env")
+		args = append(args, c.Env...)
+	}
+	args = append(args, c.Argv()...)
+
+	return &Command{
+		Name:    "sudo",
+		Args:    args,
+		Dir:     c.Dir,
+		Stdin:   stdin,
+		Stdout:  c.Stdout,
+		Stderr:  c.Stderr,
+		Context: c.Context,
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exec_test
+
+import (
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/helpers/fakeexec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestBecome tests running commands through sudo
+func TestBecome(t *testing.T) {
+	t.Parallel()
+
+	t.Run("root", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("sudo", "-n", "--", "useradd", "bob")
+
+		become := &exec.Become{Executor: fake}
+		assert.NoError(t, exec.Run(become, "useradd", "bob"))
+		fake.AssertExpectations(t)
+	})
+
+	t.Run("user with env", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("sudo", "-n", "-u", "postgres", "--", "env", "PGDATA=/data", "initdb")
+
+		become := &exec.Become{Executor: fake, User: "postgres"}
+		_, err := become.Run(&exec.Command{Name: "initdb", Env: []string{"PGDATA=/data"}, Dir: "/tmp"})
+		require.NoError(t, err)
+
+		calls := fake.Calls()
+		require.Len(t, calls, 1)
+		assert.Equal(t, "/tmp", calls[0].Dir)
+		assert.Empty(t, calls[0].Env)
+	})
+
+	t.Run("password", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("sudo", "-S", "-p", "", "--", "sh")
+
+		become := &exec.Become{Executor: fake, Password: "hunter2"}
+		_, err := become.Run(&exec.Command{Name: "sh", Stdin: "echo hi"})
+		require.NoError(t, err)
+
+		calls := fake.Calls()
+		require.Len(t, calls, 1)
+		assert.Equal(t, "hunter2\necho hi", calls[0].Stdin)
+		assert.NotContains(t, calls[0].String(), "hunter2")
+	})
+}
+
+// TestFor tests getting an executor from a provider
+func TestFor(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, exec.New(), exec.For(nil))
+
+	fake := fakeexec.New()
+	assert.Equal(t, fake, exec.For(provider{fake}))
+}
+
+type provider struct {
+	executor exec.Executor
+}
+
+func (p provider) Executor() exec.Executor { return p.executor }
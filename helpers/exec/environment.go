@@ -0,0 +1,75 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exec
+
+import (
+	"path/filepath"
+	"sort"
+)
+
+// Environment is an Executor that gives every command a base environment and
+// working directory, which the command's own take precedence over
+type Environment struct {
+	// Executor runs the commands. If nil, they are run on the local system.
+	Executor Executor
+
+	// Env is a list of "KEY=value" strings set before the command's own
+	Env []string
+
+	// Dir is the working directory of commands that don't set one. A relative
+	// working directory on a command is taken relative to it.
+	Dir string
+}
+
+// NewEnvironment returns an Environment setting the variables in env, sorted
+// by name, and the working directory dir
+func NewEnvironment(inner Executor, env map[string]string, dir string) *Environment {
+	e := &Environment{Executor: inner, Dir: dir}
+
+	var keys []string
+	for key := range env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		e.Env = append(e.Env, key+"="+env[key])
+	}
+	return e
+}
+
+// Run runs the command with the environment and working directory added
+func (e *Environment) Run(c *Command) (*Result, error) {
+	return orLocal(e.Executor).Run(e.Wrap(c))
+}
+
+// Wrap returns a copy of c with the environment and working directory added
+func (e *Environment) Wrap(c *Command) *Command {
+	wrapped := *c
+
+	if len(e.Env) > 0 {
+		wrapped.Env = append(append([]string{}, e.Env...), c.Env...)
+	}
+
+	switch {
+	case e.Dir == "":
+	case c.Dir == "":
+		wrapped.Dir = e.Dir
+	case !filepath.IsAbs(c.Dir):
+		wrapped.Dir = filepath.Join(e.Dir, c.Dir)
+	}
+
+	return &wrapped
+}
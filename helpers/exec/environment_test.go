@@ -0,0 +1,78 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exec_test
+
+import (
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/helpers/fakeexec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestEnvironment tests adding a base environment and working directory to
+// commands
+func TestEnvironment(t *testing.T) {
+	t.Parallel()
+
+	env := exec.NewEnvironment(nil, map[string]string{"PATH": "/opt/bin", "LANG": "C"}, "/srv/app")
+
+	t.Run("defaults", func(t *testing.T) {
+		wrapped := env.Wrap(&exec.Command{Name: "make"})
+		assert.Equal(t, []string{"LANG=C", "PATH=/opt/bin"}, wrapped.Env)
+		assert.Equal(t, "/srv/app", wrapped.Dir)
+	})
+
+	t.Run("command takes precedence", func(t *testing.T) {
+		cmd := &exec.Command{Name: "make", Env: []string{"LANG=en_US.UTF-8"}, Dir: "/tmp"}
+		wrapped := env.Wrap(cmd)
+		assert.Equal(t, []string{"LANG=C", "PATH=/opt/bin", "LANG=en_US.UTF-8"}, wrapped.Env)
+		assert.Equal(t, "/tmp", wrapped.Dir)
+
+		// the original command is left alone
+		assert.Equal(t, []string{"LANG=en_US.UTF-8"}, cmd.Env)
+	})
+
+	t.Run("relative dir", func(t *testing.T) {
+		assert.Equal(t, "/srv/app/build", env.Wrap(&exec.Command{Name: "make", Dir: "build"}).Dir)
+	})
+
+	t.Run("nested", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("make")
+
+		outer := exec.NewEnvironment(fake, map[string]string{"PATH": "/opt/bin"}, "/srv")
+		inner := exec.NewEnvironment(outer, map[string]string{"PATH": "/opt/app/bin"}, "app")
+		require.NoError(t, exec.Run(inner, "make"))
+
+		calls := fake.Calls()
+		require.Len(t, calls, 1)
+		assert.Equal(t, []string{"PATH=/opt/bin", "PATH=/opt/app/bin"}, calls[0].Env)
+		assert.Equal(t, "/srv/app", calls[0].Dir)
+	})
+
+	t.Run("local", func(t *testing.T) {
+		assert.True(t, exec.Local(env))
+		assert.False(t, exec.Local(exec.NewEnvironment(&exec.Docker{Container: "app"}, nil, "/srv")))
+	})
+
+	t.Run("runs on the local system", func(t *testing.T) {
+		env := exec.NewEnvironment(nil, map[string]string{"GREETING": "hello"}, "/")
+		out, err := exec.Read(env, "sh", "-c", `echo "$GREETING from $(pwd)"`)
+		require.NoError(t, err)
+		assert.Equal(t, "hello from /\n", out)
+	})
+}
@@ -0,0 +1,273 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exec
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	osexec "os/exec"
+	"strings"
+	"syscall"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/pkg/errors"
+)
+
+// Command describes a single invocation of an external program
+type Command struct {
+	// Name is the program to run. It is looked up in PATH if it does not
+	// contain a path separator.
+	Name string
+
+	// Args are the arguments passed to the program, not including the name
+	Args []string
+
+	// Env is a list of "KEY=value" strings added to the current environment
+	Env []string
+
+	// Dir is the working directory of the program. If empty, the working
+	// directory of the current process is used.
+	Dir string
+
+	// Stdin is written to the standard input of the program
+	Stdin string
+
+	// Stdout and Stderr, if set, receive the output of the program as it is
+	// written, in addition to it being collected in the Result
+	Stdout io.Writer
+	Stderr io.Writer
+
+	// Context, if set, kills the program and anything it started when it is
+	// done, as when a timeout passes. Only programs started on the local
+	// system are killed, so a command run in a container with docker exec
+	// loses its client but may keep running inside the container.
+	Context context.Context
+}
+
+// NewCommand creates a Command for the given program and arguments
+func NewCommand(name string, args ...string) *Command {
+	return &Command{Name: name, Args: args}
+}
+
+// Argv returns the program name followed by its arguments
+func (c *Command) Argv() []string {
+	return append([]string{c.Name}, c.Args...)
+}
+
+// String returns the command line as it would be typed into a shell
+func (c *Command) String() string {
+	return strings.Join(c.Argv(), " ")
+}
+
+// Result holds the outcome of running a Command
+type Result struct {
+	Stdout     string
+	Stderr     string
+	ExitStatus int
+
+	// State is the state of the local process that was run, once it has
+	// exited. Executors that don't run a local process leave it nil.
+	State *os.ProcessState
+}
+
+// Success is true if the command exited with status 0
+func (r *Result) Success() bool {
+	return r.ExitStatus == 0
+}
+
+// Executor runs commands. A non-zero exit status is not treated as an error by
+// Run; errors are reserved for commands that could not be run at all.
+type Executor interface {
+	Run(*Command) (*Result, error)
+}
+
+// ExitError is returned by the helper functions in this package when a command
+// exits with a non-zero status
+type ExitError struct {
+	Command *Command
+	Result  *Result
+}
+
+func (e *ExitError) Error() string {
+	msg := fmt.Sprintf("%s: exit status %d", e.Command.Name, e.Result.ExitStatus)
+	if stderr := strings.TrimSpace(e.Result.Stderr); stderr != "" {
+		msg += ": " + stderr
+	}
+	return msg
+}
+
+// Provider is implemented by values that carry the Executor a resource should
+// use. Renderers passed to Prepare implement it when a node has an execution
+// context such as `become`.
+type Provider interface {
+	Executor() Executor
+}
+
+// For returns the Executor carried by v if it is a Provider, or an Executor
+// for the local system otherwise
+func For(v interface{}) Executor {
+	if provider, ok := v.(Provider); ok {
+		if e := provider.Executor(); e != nil {
+			return e
+		}
+	}
+	return New()
+}
+
+// OS is an Executor that runs commands on the local system
+type OS struct{}
+
+// New returns an Executor that runs commands on the local system
+func New() Executor {
+	return &OS{}
+}
+
+// Run runs the command and waits for it to complete
+func (o *OS) Run(c *Command) (*Result, error) {
+	logger := log.WithField("module", "exec").WithField("command", c.String())
+	if c.Dir != "" {
+		logger = logger.WithField("dir", c.Dir)
+	}
+	if len(c.Env) > 0 {
+		logger = logger.WithField("env", strings.Join(c.Env, " "))
+	}
+	logger.Debug("running command")
+
+	cmd := osexec.Command(c.Name, c.Args...)
+	cmd.Dir = c.Dir
+	if len(c.Env) > 0 {
+		cmd.Env = append(os.Environ(), c.Env...)
+	}
+	if c.Stdin != "" {
+		cmd.Stdin = strings.NewReader(c.Stdin)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = tee(&stdout, c.Stdout)
+	cmd.Stderr = tee(&stderr, c.Stderr)
+
+	result := &Result{}
+	err := run(c.Context, cmd)
+	result.Stdout = stdout.String()
+	result.Stderr = stderr.String()
+	result.State = cmd.ProcessState
+
+	if err != nil {
+		exitStatus, ok := ExitStatus(err)
+		if !ok {
+			logger.WithError(err).Debug("could not run command")
+			return result, errors.Wrapf(err, "could not run %s", c.Name)
+		}
+		result.ExitStatus = exitStatus
+	}
+
+	logger.WithField("status", result.ExitStatus).Debug("command finished")
+	return result, nil
+}
+
+// run runs cmd, killing it and the processes it started if ctx is done first
+func run(ctx context.Context, cmd *osexec.Cmd) error {
+	// contexts that can't be done, like context.Background, are left out
+	if ctx == nil || ctx.Done() == nil {
+		return cmd.Run()
+	}
+
+	startGroup(cmd)
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	exited := make(chan struct{})
+	defer close(exited)
+	go func() {
+		select {
+		case <-ctx.Done():
+			if err := killGroup(cmd.Process); err != nil {
+				log.WithField("module", "exec").WithField("pid", cmd.Process.Pid).WithError(err).Warn("could not kill command")
+			}
+		case <-exited:
+		}
+	}()
+
+	return cmd.Wait()
+}
+
+func tee(buf *bytes.Buffer, w io.Writer) io.Writer {
+	if w == nil {
+		return buf
+	}
+	return io.MultiWriter(buf, w)
+}
+
+// ExitStatus extracts the exit status from an error returned by os/exec or by
+// the helpers in this package. The second value is false if the error does not
+// carry an exit status.
+func ExitStatus(err error) (int, bool) {
+	switch e := errors.Cause(err).(type) {
+	case *ExitError:
+		return e.Result.ExitStatus, true
+
+	case *osexec.ExitError:
+		if status, ok := e.Sys().(syscall.WaitStatus); ok {
+			return status.ExitStatus(), true
+		}
+	}
+
+	return 0, false
+}
+
+// NotFound tells whether an error from running a command means that the
+// program isn't installed, so that a caller can fall back to another one. On
+// the local system the program can't be found to start; elsewhere the shell
+// that runs it exits 127.
+func NotFound(err error) bool {
+	switch e := errors.Cause(err).(type) {
+	case *osexec.Error:
+		return e.Err == osexec.ErrNotFound
+
+	case *os.PathError:
+		return os.IsNotExist(e)
+	}
+
+	status, ok := ExitStatus(err)
+	return ok && status == 127
+}
+
+// Run runs a program with the given arguments, returning an *ExitError if it
+// exits with a non-zero status
+func Run(e Executor, name string, args ...string) error {
+	_, err := Read(e, name, args...)
+	return err
+}
+
+// Read runs a program with the given arguments and returns its stdout. If the
+// program exits with a non-zero status an *ExitError is returned along with
+// the output.
+func Read(e Executor, name string, args ...string) (string, error) {
+	cmd := NewCommand(name, args...)
+	result, err := e.Run(cmd)
+	if err != nil {
+		return "", err
+	}
+
+	if !result.Success() {
+		return result.Stdout, &ExitError{Command: cmd, Result: result}
+	}
+
+	return result.Stdout, nil
+}
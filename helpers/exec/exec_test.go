@@ -0,0 +1,163 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exec_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestOSRun tests running commands on the local system
+func TestOSRun(t *testing.T) {
+	t.Parallel()
+
+	t.Run("output", func(t *testing.T) {
+		result, err := exec.New().Run(&exec.Command{
+			Name: "sh",
+			Args: []string{"-c", "echo -n out; echo -n err >&2; exit 3"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "out", result.Stdout)
+		assert.Equal(t, "err", result.Stderr)
+		assert.Equal(t, 3, result.ExitStatus)
+		assert.False(t, result.Success())
+		require.NotNil(t, result.State)
+		assert.True(t, result.State.Exited())
+	})
+
+	t.Run("stdin", func(t *testing.T) {
+		result, err := exec.New().Run(&exec.Command{Name: "cat", Stdin: "hello"})
+		require.NoError(t, err)
+		assert.Equal(t, "hello", result.Stdout)
+	})
+
+	t.Run("env and dir", func(t *testing.T) {
+		result, err := exec.New().Run(&exec.Command{
+			Name: "sh",
+			Args: []string{"-c", "echo -n $FOO; pwd"},
+			Env:  []string{"FOO=bar"},
+			Dir:  "/",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "bar/\n", result.Stdout)
+	})
+
+	t.Run("missing program", func(t *testing.T) {
+		_, err := exec.New().Run(exec.NewCommand("converge-no-such-program"))
+		assert.Error(t, err)
+	})
+
+	t.Run("context", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		// the sleep is started by the shell, so only killing the whole process
+		// group lets the output pipes close
+		start := time.Now()
+		result, err := exec.New().Run(&exec.Command{
+			Name:    "sh",
+			Args:    []string{"-c", "sleep 5; true"},
+			Context: ctx,
+		})
+		require.NoError(t, err)
+		assert.False(t, result.Success())
+		assert.True(t, time.Since(start) < 2*time.Second, "command was not killed")
+	})
+
+	t.Run("streams", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		result, err := exec.New().Run(&exec.Command{
+			Name:   "sh",
+			Args:   []string{"-c", "echo out; echo err >&2"},
+			Stdout: &stdout,
+			Stderr: &stderr,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "out\n", stdout.String())
+		assert.Equal(t, "err\n", stderr.String())
+		assert.Equal(t, "out\n", result.Stdout)
+		assert.Equal(t, "err\n", result.Stderr)
+	})
+}
+
+// TestLineWriter tests splitting output into lines
+func TestLineWriter(t *testing.T) {
+	t.Parallel()
+
+	var lines []string
+	w := exec.NewLineWriter(func(line string) { lines = append(lines, line) })
+
+	w.Write([]byte("one\ntw"))
+	assert.Equal(t, []string{"one"}, lines)
+
+	w.Write([]byte("o\n\nthree"))
+	assert.Equal(t, []string{"one", "two", ""}, lines)
+
+	w.Close()
+	assert.Equal(t, []string{"one", "two", "", "three"}, lines)
+}
+
+// TestRead tests the Read and Run helpers
+func TestRead(t *testing.T) {
+	t.Parallel()
+
+	t.Run("success", func(t *testing.T) {
+		out, err := exec.Read(exec.New(), "echo", "-n", "hi")
+		assert.NoError(t, err)
+		assert.Equal(t, "hi", out)
+	})
+
+	t.Run("failure", func(t *testing.T) {
+		err := exec.Run(exec.New(), "sh", "-c", "echo -n oops >&2; exit 2")
+		require.Error(t, err)
+		assert.EqualError(t, err, "sh: exit status 2: oops")
+
+		status, ok := exec.ExitStatus(err)
+		assert.True(t, ok)
+		assert.Equal(t, 2, status)
+	})
+}
+
+// TestNotFound tests telling missing programs apart from failing ones
+func TestNotFound(t *testing.T) {
+	t.Parallel()
+
+	t.Run("missing program", func(t *testing.T) {
+		err := exec.Run(exec.New(), "converge-no-such-program")
+		assert.True(t, exec.NotFound(err))
+	})
+
+	t.Run("missing path", func(t *testing.T) {
+		err := exec.Run(exec.New(), "/converge/no/such/program")
+		assert.True(t, exec.NotFound(err))
+	})
+
+	t.Run("missing in shell", func(t *testing.T) {
+		err := exec.Run(exec.New(), "sh", "-c", "converge-no-such-program 2>/dev/null")
+		assert.True(t, exec.NotFound(err))
+	})
+
+	t.Run("failure", func(t *testing.T) {
+		err := exec.Run(exec.New(), "sh", "-c", "exit 1")
+		assert.False(t, exec.NotFound(err))
+		assert.False(t, exec.NotFound(nil))
+	})
+}
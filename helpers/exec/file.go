@@ -0,0 +1,121 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exec
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/asteris-llc/converge/helpers/atomicfile"
+)
+
+// ReadFile reads a file with cat(1) so that it is read wherever the executor
+// runs commands. The second value is false if the file does not exist. If the
+// executor is Direct, the file is read without running any commands.
+func ReadFile(e Executor, path string) (string, bool, error) {
+	if Direct(e) {
+		content, err := ioutil.ReadFile(path)
+		if os.IsNotExist(err) {
+			return "", false, nil
+		}
+		return string(content), true, err
+	}
+
+	if err := Run(e, "test", "-e", path); err != nil {
+		if _, ok := ExitStatus(err); ok {
+			return "", false, nil
+		}
+		return "", false, err
+	}
+
+	content, err := Read(e, "cat", path)
+	if err != nil {
+		return "", true, err
+	}
+	return content, true, nil
+}
+
+// WriteFileScript is the shell script run by WriteFile. It is called with the
+// destination as $0 and the mode as $1, and the content on stdin.
+const WriteFileScript = `set -e
+umask 077
+dst="$0"
+if [ -L "$dst" ]; then dst=$(readlink -f "$dst"); fi
+tmp=$(mktemp "$(dirname "$dst")/.$(basename "$dst").XXXXXX")
+trap 'rm -f "$tmp"' EXIT
+cat > "$tmp"
+chmod "$1" "$tmp"
+if [ -e "$dst" ]; then
+  if stat -c %n / >/dev/null 2>&1; then owner=$(stat -c %u:%g "$dst"); else owner=$(stat -f %u:%g "$dst"); fi
+  chown "$owner" "$tmp"
+fi
+sync "$tmp" 2>/dev/null || sync
+mv -f "$tmp" "$dst"`
+
+// WriteFile writes content to a file with the given permissions, replacing it
+// if it exists. The content is passed on stdin and written to a temporary file
+// next to path, which is given the mode and the owner of the file it replaces
+// and then renamed over it, so path never holds partly written content. If the
+// executor is Direct, this is done without running any commands.
+func WriteFile(e Executor, path, content string, perm os.FileMode) error {
+	if Direct(e) {
+		return atomicfile.Write(path, []byte(content), perm)
+	}
+
+	cmd := &Command{
+		Name:  "sh",
+		Args:  []string{"-c", WriteFileScript, path, fmt.Sprintf("%04o", perm.Perm())},
+		Stdin: content,
+	}
+
+	result, err := e.Run(cmd)
+	if err != nil {
+		return err
+	}
+	if !result.Success() {
+		return &ExitError{Command: cmd, Result: result}
+	}
+	return nil
+}
+
+// StatScript is the shell script run by Stat. It is called with the path as
+// $0, the format for GNU and busybox stat(1) as $1 and the format for BSD
+// stat(1) as $2. Which stat is installed is found by trying -c on /, since the
+// BSD stat doesn't have it and the GNU stat reads -f as a different flag.
+const StatScript = `if stat -c %n / >/dev/null 2>&1; then exec stat -c "$1" "$0"; fi
+exec stat -f "$2" "$0"`
+
+// bsdFormat turns the GNU stat directives used with Stat into their BSD
+// equivalents
+var bsdFormat = strings.NewReplacer(
+	"%a", "%Lp",
+	"%u", "%u",
+	"%g", "%g",
+	"%U", "%Su",
+	"%G", "%Sg",
+	"%s", "%z",
+	"%Y", "%m",
+)
+
+// Stat prints details of a file with stat(1), so that it works wherever the
+// executor runs commands. format is given in the form taken by GNU stat -c,
+// and may use %a (the octal permissions), %u and %g (the owner and group
+// IDs), %U and %G (their names), %s (the size) and %Y (the modification time
+// in seconds since the epoch). It is translated for systems with BSD stat.
+func Stat(e Executor, path, format string) (string, error) {
+	return Read(e, "sh", "-c", StatScript, path, format, bsdFormat.Replace(format))
+}
@@ -0,0 +1,145 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exec_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/helpers/fakeexec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestFiles tests reading and writing files through an executor
+func TestFiles(t *testing.T) {
+	t.Parallel()
+
+	t.Run("local", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "converge-exec")
+		require.NoError(t, err)
+		defer os.RemoveAll(dir)
+
+		path := filepath.Join(dir, "file")
+
+		_, exists, err := exec.ReadFile(exec.New(), path)
+		require.NoError(t, err)
+		assert.False(t, exists)
+
+		require.NoError(t, exec.WriteFile(exec.New(), path, "hello\n", 0640))
+
+		content, exists, err := exec.ReadFile(exec.New(), path)
+		require.NoError(t, err)
+		assert.True(t, exists)
+		assert.Equal(t, "hello\n", content)
+
+		stat, err := os.Stat(path)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0640), stat.Mode().Perm())
+	})
+
+	t.Run("local symlink", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "converge-exec")
+		require.NoError(t, err)
+		defer os.RemoveAll(dir)
+
+		path := filepath.Join(dir, "file")
+		link := filepath.Join(dir, "link")
+		require.NoError(t, ioutil.WriteFile(path, []byte("old"), 0600))
+		require.NoError(t, os.Symlink(path, link))
+
+		require.NoError(t, exec.WriteFile(exec.New(), link, "new", 0600))
+
+		content, err := ioutil.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, "new", string(content))
+
+		entries, err := ioutil.ReadDir(dir)
+		require.NoError(t, err)
+		assert.Len(t, entries, 2, "the temporary file should be renamed into place")
+	})
+
+	t.Run("write failure", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("sh", "-c", exec.WriteFileScript, "/etc/x", "0440").Return("", 1).Stderr("read-only file system")
+
+		err := exec.WriteFile(fake, "/etc/x", "content", 0440)
+		assert.EqualError(t, err, "sh: exit status 1: read-only file system")
+		assert.Equal(t, "content", fake.Calls()[0].Stdin)
+	})
+
+	t.Run("read failure", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("test", "-e", "/etc/x")
+		fake.Expect("cat", "/etc/x").Return("", 1).Stderr("permission denied")
+
+		_, exists, err := exec.ReadFile(fake, "/etc/x")
+		assert.True(t, exists)
+		assert.EqualError(t, err, "cat: exit status 1: permission denied")
+	})
+}
+
+// bsdStat is a stand-in for the stat(1) of BSD systems, which has no -c and
+// takes its format with -f
+const bsdStat = `#!/bin/sh
+if [ "$1" != "-f" ]; then echo "stat: illegal option -- ${1#-}" >&2; exit 1; fi
+case "$2" in
+  %u:%g) echo 501:20 ;;
+  %Lp) echo 440 ;;
+  *) echo "stat: unexpected format $2" >&2; exit 1 ;;
+esac
+`
+
+// TestBSDStat tests the scripts that run stat with a BSD stat installed
+func TestBSDStat(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "converge-exec")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	bin := filepath.Join(dir, "bin")
+	chowned := filepath.Join(dir, "chowned")
+	require.NoError(t, os.Mkdir(bin, 0700))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(bin, "stat"), []byte(bsdStat), 0700))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(bin, "chown"), []byte("#!/bin/sh\necho \"$1\" > "+chowned+"\n"), 0700))
+
+	// a working directory keeps the files from being written directly
+	e := exec.NewEnvironment(nil, map[string]string{"PATH": bin + ":" + os.Getenv("PATH")}, dir)
+
+	t.Run("writing keeps the owner", func(t *testing.T) {
+		path := filepath.Join(dir, "file")
+		require.NoError(t, ioutil.WriteFile(path, []byte("old"), 0600))
+
+		require.NoError(t, exec.WriteFile(e, path, "new", 0640))
+
+		content, err := ioutil.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, "new", string(content))
+
+		owner, err := ioutil.ReadFile(chowned)
+		require.NoError(t, err)
+		assert.Equal(t, "501:20\n", string(owner))
+	})
+
+	t.Run("stat", func(t *testing.T) {
+		mode, err := exec.Stat(e, filepath.Join(dir, "file"), "%a")
+		require.NoError(t, err)
+		assert.Equal(t, "440\n", mode)
+	})
+}
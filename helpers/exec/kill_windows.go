@@ -0,0 +1,28 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exec
+
+import (
+	"os"
+	osexec "os/exec"
+)
+
+// startGroup does nothing, since Windows has no process groups to start
+func startGroup(cmd *osexec.Cmd) {}
+
+// killGroup kills process. Processes it started are left running.
+func killGroup(process *os.Process) error {
+	return process.Kill()
+}
@@ -0,0 +1,62 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exec
+
+import (
+	"bytes"
+	"sync"
+)
+
+// LineWriter calls a function with each line written to it, without the
+// trailing newline. It is meant for Command.Stdout and Command.Stderr, so
+// output can be reported as it is produced.
+type LineWriter struct {
+	lock sync.Mutex
+	buf  bytes.Buffer
+	fn   func(line string)
+}
+
+// NewLineWriter returns a LineWriter calling fn
+func NewLineWriter(fn func(line string)) *LineWriter {
+	return &LineWriter{fn: fn}
+}
+
+// Write buffers p and calls the function for every line it completes
+func (w *LineWriter) Write(p []byte) (int, error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	w.buf.Write(p)
+	for {
+		i := bytes.IndexByte(w.buf.Bytes(), '\n')
+		if i < 0 {
+			return len(p), nil
+		}
+		line := string(w.buf.Next(i + 1))
+		w.fn(line[:len(line)-1])
+	}
+}
+
+// Close calls the function with any final line that didn't end in a newline
+func (w *LineWriter) Close() error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.buf.Len() > 0 {
+		w.fn(w.buf.String())
+		w.buf.Reset()
+	}
+	return nil
+}
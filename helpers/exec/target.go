@@ -0,0 +1,221 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exec
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Chroot is an Executor that runs commands inside a chroot
+type Chroot struct {
+	// Executor runs the wrapped chroot command. If nil, it is run on the local
+	// system.
+	Executor Executor
+
+	// Root is the directory to chroot into
+	Root string
+}
+
+// Run runs the command inside the chroot
+func (c *Chroot) Run(cmd *Command) (*Result, error) {
+	return orLocal(c.Executor).Run(c.Wrap(cmd))
+}
+
+// Wrap returns the chroot command line that runs cmd. chroot(8) always starts
+// in "/", so a working directory is changed into by a shell inside the root.
+func (c *Chroot) Wrap(cmd *Command) *Command {
+	args := []string{c.Root}
+	args = append(args, withEnv(cmd.Env, withDir(cmd.Dir, cmd.Argv()))...)
+
+	return &Command{
+		Name:    "chroot",
+		Args:    args,
+		Stdin:   cmd.Stdin,
+		Stdout:  cmd.Stdout,
+		Stderr:  cmd.Stderr,
+		Context: cmd.Context,
+	}
+}
+
+// Namespace is an Executor that runs commands in the mount namespace of
+// another process
+type Namespace struct {
+	// Executor runs the wrapped nsenter command. If nil, it is run on the local
+	// system.
+	Executor Executor
+
+	// Target is either the PID of a process or the path to a mount namespace
+	// file, such as /proc/1234/ns/mnt or a bind mount of one
+	Target string
+}
+
+// Run runs the command inside the namespace
+func (n *Namespace) Run(cmd *Command) (*Result, error) {
+	return orLocal(n.Executor).Run(n.Wrap(cmd))
+}
+
+// Wrap returns the nsenter command line that runs cmd
+func (n *Namespace) Wrap(cmd *Command) *Command {
+	var args []string
+	if _, err := strconv.Atoi(n.Target); err == nil {
+		args = append(args, "--target", n.Target, "--mount")
+	} else {
+		args = append(args, "--mount="+n.Target)
+	}
+
+	if cmd.Dir != "" {
+		args = append(args, "--wd="+cmd.Dir)
+	}
+
+	args = append(args, "--")
+	args = append(args, withEnv(cmd.Env, cmd.Argv())...)
+
+	return &Command{
+		Name:    "nsenter",
+		Args:    args,
+		Stdin:   cmd.Stdin,
+		Stdout:  cmd.Stdout,
+		Stderr:  cmd.Stderr,
+		Context: cmd.Context,
+	}
+}
+
+// Docker is an Executor that runs commands inside a running container
+type Docker struct {
+	// Executor runs the wrapped docker command. If nil, it is run on the local
+	// system.
+	Executor Executor
+
+	// Container is the name or ID of the container
+	Container string
+}
+
+// Run runs the command inside the container
+func (d *Docker) Run(cmd *Command) (*Result, error) {
+	return orLocal(d.Executor).Run(d.Wrap(cmd))
+}
+
+// Wrap returns the docker exec command line that runs cmd
+func (d *Docker) Wrap(cmd *Command) *Command {
+	args := []string{"exec"}
+	if cmd.Stdin != "" {
+		args = append(args, "-i")
+	}
+
+	if cmd.Dir != "" {
+		args = append(args, "-w", cmd.Dir)
+	}
+
+	for _, env := range cmd.Env {
+		args = append(args, "-e", env)
+	}
+
+	args = append(args, d.Container)
+	args = append(args, cmd.Argv()...)
+
+	return &Command{
+		Name:    "docker",
+		Args:    args,
+		Stdin:   cmd.Stdin,
+		Stdout:  cmd.Stdout,
+		Stderr:  cmd.Stderr,
+		Context: cmd.Context,
+	}
+}
+
+// Targets lists the kinds of target accepted by NewTarget
+var Targets = []string{"chroot", "docker", "namespace"}
+
+// NewTarget returns an Executor that runs commands in the given target,
+// wrapping inner. The target must have exactly one of the keys in Targets.
+func NewTarget(inner Executor, target map[string]string) (Executor, error) {
+	if len(target) != 1 {
+		return nil, fmt.Errorf("target must have exactly one of %s", strings.Join(Targets, ", "))
+	}
+
+	for kind, value := range target {
+		if value == "" {
+			return nil, fmt.Errorf("target %s cannot be empty", kind)
+		}
+
+		switch kind {
+		case "chroot":
+			return &Chroot{Executor: inner, Root: value}, nil
+		case "docker":
+			return &Docker{Executor: inner, Container: value}, nil
+		case "namespace":
+			return &Namespace{Executor: inner, Target: value}, nil
+		}
+
+		return nil, fmt.Errorf("%q is not a valid target, expected one of %s", kind, strings.Join(Targets, ", "))
+	}
+
+	return inner, nil
+}
+
+// Local reports whether commands run by e see the local filesystem. Targets
+// such as chroots and containers have their own.
+func Local(e Executor) bool {
+	switch e := e.(type) {
+	case nil, *OS:
+		return true
+	case *Become:
+		return Local(e.Executor)
+	case *Environment:
+		return Local(e.Executor)
+	}
+	return false
+}
+
+// Direct reports whether commands run by e run as this process on the local
+// system, so that files can be read and written with syscalls instead of by
+// running commands. Unlike Local, it is false for Become, which runs commands
+// as another user, and for a working directory other than this process's.
+func Direct(e Executor) bool {
+	switch e := e.(type) {
+	case nil, *OS:
+		return true
+	case *Environment:
+		return e.Dir == "" && Direct(e.Executor)
+	}
+	return false
+}
+
+func orLocal(e Executor) Executor {
+	if e == nil {
+		return New()
+	}
+	return e
+}
+
+// withEnv prefixes argv with env(1) if there is an environment to set
+func withEnv(env []string, argv []string) []string {
+	if len(env) == 0 {
+		return argv
+	}
+
+	return append(append([]string{"env"}, env...), argv...)
+}
+
+// withDir prefixes argv with a shell that changes into dir first
+func withDir(dir string, argv []string) []string {
+	if dir == "" {
+		return argv
+	}
+
+	return append([]string{"sh", "-c", `cd "$0" && exec "$@"`, dir}, argv...)
+}
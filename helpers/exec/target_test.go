@@ -0,0 +1,136 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exec_test
+
+import (
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/helpers/fakeexec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestChroot tests running commands in a chroot
+func TestChroot(t *testing.T) {
+	t.Parallel()
+
+	t.Run("plain", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("chroot", "/mnt/image", "useradd", "bob")
+
+		assert.NoError(t, exec.Run(&exec.Chroot{Executor: fake, Root: "/mnt/image"}, "useradd", "bob"))
+		fake.AssertExpectations(t)
+	})
+
+	t.Run("dir and env", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect(
+			"chroot", "/mnt/image",
+			"env", "A=1",
+			"sh", "-c", `cd "$0" && exec "$@"`, "/srv",
+			"make",
+		)
+
+		chroot := &exec.Chroot{Executor: fake, Root: "/mnt/image"}
+		_, err := chroot.Run(&exec.Command{Name: "make", Env: []string{"A=1"}, Dir: "/srv"})
+		require.NoError(t, err)
+		fake.AssertExpectations(t)
+		assert.Empty(t, fake.Calls()[0].Dir)
+	})
+}
+
+// TestNamespace tests running commands in another mount namespace
+func TestNamespace(t *testing.T) {
+	t.Parallel()
+
+	t.Run("pid", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("nsenter", "--target", "1234", "--mount", "--wd=/srv", "--", "env", "A=1", "ls")
+
+		namespace := &exec.Namespace{Executor: fake, Target: "1234"}
+		_, err := namespace.Run(&exec.Command{Name: "ls", Env: []string{"A=1"}, Dir: "/srv"})
+		require.NoError(t, err)
+		fake.AssertExpectations(t)
+	})
+
+	t.Run("path", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("nsenter", "--mount=/run/ns/build", "--", "ls")
+
+		assert.NoError(t, exec.Run(&exec.Namespace{Executor: fake, Target: "/run/ns/build"}, "ls"))
+		fake.AssertExpectations(t)
+	})
+}
+
+// TestDocker tests running commands in a container
+func TestDocker(t *testing.T) {
+	t.Parallel()
+
+	fake := fakeexec.New()
+	fake.Expect("docker", "exec", "-i", "-w", "/srv", "-e", "A=1", "build", "sh")
+
+	docker := &exec.Docker{Executor: fake, Container: "build"}
+	_, err := docker.Run(&exec.Command{Name: "sh", Env: []string{"A=1"}, Dir: "/srv", Stdin: "echo hi"})
+	require.NoError(t, err)
+	fake.AssertExpectations(t)
+	assert.Equal(t, "echo hi", fake.Calls()[0].Stdin)
+}
+
+// TestNewTarget tests building a target from settings
+func TestNewTarget(t *testing.T) {
+	t.Parallel()
+
+	inner := exec.New()
+
+	t.Run("docker", func(t *testing.T) {
+		executor, err := exec.NewTarget(inner, map[string]string{"docker": "build"})
+		require.NoError(t, err)
+		assert.Equal(t, &exec.Docker{Executor: inner, Container: "build"}, executor)
+	})
+
+	t.Run("too many", func(t *testing.T) {
+		_, err := exec.NewTarget(inner, map[string]string{"docker": "build", "chroot": "/mnt"})
+		assert.EqualError(t, err, "target must have exactly one of chroot, docker, namespace")
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		_, err := exec.NewTarget(inner, map[string]string{"chroot": ""})
+		assert.EqualError(t, err, "target chroot cannot be empty")
+	})
+}
+
+// TestLocal tests detecting executors that use the local filesystem
+func TestLocal(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, exec.Local(nil))
+	assert.True(t, exec.Local(exec.New()))
+	assert.True(t, exec.Local(&exec.Become{}))
+	assert.False(t, exec.Local(&exec.Docker{Container: "build"}))
+	assert.False(t, exec.Local(&exec.Become{Executor: &exec.Chroot{Root: "/mnt"}}))
+}
+
+// TestDirect tests detecting executors that run commands as this process
+func TestDirect(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, exec.Direct(nil))
+	assert.True(t, exec.Direct(exec.New()))
+	assert.True(t, exec.Direct(exec.NewEnvironment(nil, map[string]string{"A": "b"}, "")))
+	assert.False(t, exec.Direct(exec.NewEnvironment(nil, nil, "/srv")))
+	assert.False(t, exec.Direct(&exec.Become{}))
+	assert.False(t, exec.Direct(&exec.Chroot{Root: "/mnt"}))
+}
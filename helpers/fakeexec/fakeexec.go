@@ -0,0 +1,160 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fakeexec
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/stretchr/testify/assert"
+)
+
+// Executor is a scripted exec.Executor for testing resources. Commands are
+// matched by their full argv against a transcript of expectations, and the
+// matching expectation's output and exit status are returned.
+type Executor struct {
+	lock         sync.Mutex
+	expectations []*Expectation
+	calls        []*exec.Command
+}
+
+// New gets an Executor with an empty transcript
+func New() *Executor {
+	return new(Executor)
+}
+
+// Expect adds an expected command line to the transcript. By default the
+// command succeeds with no output and may be called any number of times.
+func (e *Executor) Expect(argv ...string) *Expectation {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	x := &Expectation{argv: argv}
+	e.expectations = append(e.expectations, x)
+	return x
+}
+
+// Run returns the result of the first expectation matching the command. Once
+// an expectation has been used up the next matching one is used, so the same
+// command line may be scripted to return different results in sequence.
+func (e *Executor) Run(cmd *exec.Command) (*exec.Result, error) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	e.calls = append(e.calls, cmd)
+
+	argv := cmd.Argv()
+	for _, x := range e.expectations {
+		if x.exhausted() || !reflect.DeepEqual(x.argv, argv) {
+			continue
+		}
+
+		x.calls++
+		if x.err != nil {
+			return nil, x.err
+		}
+
+		if cmd.Stdout != nil {
+			io.WriteString(cmd.Stdout, x.stdout)
+		}
+		if cmd.Stderr != nil {
+			io.WriteString(cmd.Stderr, x.stderr)
+		}
+
+		return &exec.Result{
+			Stdout:     x.stdout,
+			Stderr:     x.stderr,
+			ExitStatus: x.exitStatus,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("fakeexec: unexpected command %q", strings.Join(argv, " "))
+}
+
+// Calls returns every command run so far, in order
+func (e *Executor) Calls() []*exec.Command {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	return append([]*exec.Command(nil), e.calls...)
+}
+
+// Unmet returns the command lines of expectations that were never run
+func (e *Executor) Unmet() (out []string) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	for _, x := range e.expectations {
+		if x.calls == 0 {
+			out = append(out, strings.Join(x.argv, " "))
+		}
+	}
+	return out
+}
+
+// AssertExpectations asserts that every expectation was run at least once
+func (e *Executor) AssertExpectations(t assert.TestingT) bool {
+	unmet := e.Unmet()
+	return assert.Empty(t, unmet, "expected commands were not run")
+}
+
+// Expectation is a single scripted command and its result
+type Expectation struct {
+	argv       []string
+	stdout     string
+	stderr     string
+	exitStatus int
+	err        error
+	times      int
+	calls      int
+}
+
+// Return sets the stdout and exit status returned for the command
+func (x *Expectation) Return(stdout string, exitStatus int) *Expectation {
+	x.stdout = stdout
+	x.exitStatus = exitStatus
+	return x
+}
+
+// Stderr sets the stderr returned for the command
+func (x *Expectation) Stderr(stderr string) *Expectation {
+	x.stderr = stderr
+	return x
+}
+
+// Error makes the command fail to run at all, as if the program did not exist
+func (x *Expectation) Error(err error) *Expectation {
+	x.err = err
+	return x
+}
+
+// Times limits the number of times the expectation can be matched
+func (x *Expectation) Times(n int) *Expectation {
+	x.times = n
+	return x
+}
+
+// Once limits the expectation to being matched a single time
+func (x *Expectation) Once() *Expectation {
+	return x.Times(1)
+}
+
+func (x *Expectation) exhausted() bool {
+	return x.times > 0 && x.calls >= x.times
+}
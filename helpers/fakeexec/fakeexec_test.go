@@ -0,0 +1,79 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fakeexec_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/helpers/fakeexec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecutorInterface(t *testing.T) {
+	t.Parallel()
+
+	assert.Implements(t, (*exec.Executor)(nil), fakeexec.New())
+}
+
+// TestExecutorRun tests running scripted commands
+func TestExecutorRun(t *testing.T) {
+	t.Parallel()
+
+	t.Run("matches argv", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("lvs", "--noheadings").Return("vg0", 0).Stderr("warning")
+
+		result, err := fake.Run(exec.NewCommand("lvs", "--noheadings"))
+		require.NoError(t, err)
+		assert.Equal(t, "vg0", result.Stdout)
+		assert.Equal(t, "warning", result.Stderr)
+		assert.Equal(t, 0, result.ExitStatus)
+		fake.AssertExpectations(t)
+	})
+
+	t.Run("unexpected", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("lvs")
+
+		_, err := fake.Run(exec.NewCommand("vgs"))
+		assert.EqualError(t, err, "fakeexec: unexpected command \"vgs\"")
+		assert.Equal(t, []string{"lvs"}, fake.Unmet())
+	})
+
+	t.Run("sequence", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("id", "bob").Return("", 1).Once()
+		fake.Expect("id", "bob").Return("1000", 0)
+
+		_, err := exec.Read(fake, "id", "bob")
+		assert.Error(t, err)
+
+		out, err := exec.Read(fake, "id", "bob")
+		assert.NoError(t, err)
+		assert.Equal(t, "1000", out)
+		assert.Len(t, fake.Calls(), 2)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("missing").Error(errors.New("not found"))
+
+		_, err := fake.Run(exec.NewCommand("missing"))
+		assert.EqualError(t, err, "not found")
+	})
+}
@@ -21,6 +21,7 @@ type FakeRenderer struct {
 	ID           string
 	DotValue     resource.Value
 	ValuePresent bool
+	Location     string
 }
 
 // GetID returns the ID of this renderer
@@ -33,6 +34,11 @@ func (fr *FakeRenderer) Value() (resource.Value, bool) {
 	return fr.DotValue, fr.ValuePresent
 }
 
+// ModuleLocation returns the location of the module
+func (fr *FakeRenderer) ModuleLocation() string {
+	return fr.Location
+}
+
 // Render returns whatever content is passed in
 func (fr *FakeRenderer) Render(name, content string) (string, error) {
 	return content, nil
@@ -22,19 +22,32 @@ import (
 
 // FakeTask for testing things that require real tasks
 type FakeTask struct {
-	Status string
-	Level  resource.StatusLevel
-	Error  error
+	Status   string
+	Level    resource.StatusLevel
+	Error    error
+	Reboots  []string
+	Warnings []resource.Warning
 }
 
 // Check returns values set on struct
 func (ft *FakeTask) Check(resource.Renderer) (resource.TaskStatus, error) {
-	return &resource.Status{Output: []string{ft.Status}, Level: ft.Level}, ft.Error
+	return ft.status(), ft.Error
 }
 
 // Apply returns values set on struct
 func (ft *FakeTask) Apply() (resource.TaskStatus, error) {
-	return &resource.Status{Output: []string{ft.Status}, Level: ft.Level}, ft.Error
+	return ft.status(), ft.Error
+}
+
+func (ft *FakeTask) status() *resource.Status {
+	status := &resource.Status{Output: []string{ft.Status}, Level: ft.Level}
+	for _, reason := range ft.Reboots {
+		status.RequireReboot(reason)
+	}
+	for _, warning := range ft.Warnings {
+		status.AddWarning(warning.Kind, warning.Field, warning.Message)
+	}
+	return status
 }
 
 // NoOp returns a FakeTask that doesn't have to do anything
@@ -65,6 +78,71 @@ func WillChange() *FakeTask {
 	}
 }
 
+// RebootRequired returns a FakeTask that requires a reboot for the given
+// reason
+func RebootRequired(reason string) *FakeTask {
+	return &FakeTask{
+		Status:  "reboot required",
+		Level:   resource.StatusNoChange,
+		Error:   nil,
+		Reboots: []string{reason},
+	}
+}
+
+// Warn returns a FakeTask that doesn't have to do anything but gives the
+// warning
+func Warn(warning resource.Warning) *FakeTask {
+	return &FakeTask{
+		Status:   "warning",
+		Level:    resource.StatusNoChange,
+		Error:    nil,
+		Warnings: []resource.Warning{warning},
+	}
+}
+
+// FakeRebootWatcher is a FakeTask that records the reboots passed to it
+type FakeRebootWatcher struct {
+	FakeTask
+	Reboots map[string][]string
+}
+
+// WatchReboots records the pending reboots
+func (ft *FakeRebootWatcher) WatchReboots(reboots map[string][]string) {
+	ft.Reboots = reboots
+}
+
+// RebootWatcher returns a FakeRebootWatcher that doesn't have to do anything
+func RebootWatcher() *FakeRebootWatcher {
+	return &FakeRebootWatcher{FakeTask: *NoOp()}
+}
+
+// FakeChangeIgnorer is a FakeTask that changes the differences named in
+// Changes and ignores changes to those matching Ignored
+type FakeChangeIgnorer struct {
+	FakeTask
+	Changes []string
+	Ignored []string
+}
+
+// Check returns a status with a difference for each of Changes
+func (ft *FakeChangeIgnorer) Check(resource.Renderer) (resource.TaskStatus, error) {
+	status := ft.status()
+	for _, name := range ft.Changes {
+		status.AddDifference(name, "old", "new", "")
+	}
+	return status, ft.Error
+}
+
+// IgnoredChanges returns Ignored
+func (ft *FakeChangeIgnorer) IgnoredChanges() []string {
+	return ft.Ignored
+}
+
+// ChangeIgnorer returns a FakeChangeIgnorer that will change
+func ChangeIgnorer(changes []string, ignored []string) *FakeChangeIgnorer {
+	return &FakeChangeIgnorer{FakeTask: *WillChange(), Changes: changes, Ignored: ignored}
+}
+
 // NilTask always return (nil, error) tuple on Check/Apply calls
 type NilTask struct {
 }
@@ -0,0 +1,272 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filemode
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Mask holds the bits of a file mode that a Spec can set: the permissions and
+// the setuid, setgid and sticky bits
+const Mask = os.ModePerm | os.ModeSetuid | os.ModeSetgid | os.ModeSticky
+
+// umask is read once at startup, before anything else can create files, since
+// reading it means setting it
+var umask = readUmask()
+
+// Spec is a file mode. It is either absolute, like 0644, or a list of changes
+// to make to the current mode, like u+rwx,g+rx.
+type Spec struct {
+	text     string
+	absolute *os.FileMode
+	clauses  []clause
+}
+
+type clause struct {
+	who     os.FileMode
+	actions []action
+}
+
+type action struct {
+	op    byte
+	perms string
+}
+
+// Octal returns an absolute Spec for mode
+func Octal(mode os.FileMode) *Spec {
+	mode &= Mask
+	return &Spec{text: fmt.Sprintf("%04o", toOctal(mode)), absolute: &mode}
+}
+
+// Parse parses a mode in octal, such as 0644, or in the symbolic form accepted
+// by chmod(1), such as u+rwx,g+rx or a=r. Symbolic modes only change the bits
+// they name. As with chmod, if a clause doesn't say who it applies to, it
+// applies to everyone except for the bits set in the umask.
+func Parse(text string) (*Spec, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, fmt.Errorf("mode cannot be empty")
+	}
+
+	if text[0] >= '0' && text[0] <= '9' {
+		raw, err := strconv.ParseUint(text, 8, 32)
+		if err != nil || raw > 07777 {
+			return nil, fmt.Errorf("%q is not a valid octal mode", text)
+		}
+		return Octal(fromOctal(uint32(raw))), nil
+	}
+
+	out := &Spec{text: text}
+	for _, part := range strings.Split(text, ",") {
+		c, err := parseClause(part)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a valid mode: %s", text, err)
+		}
+		out.clauses = append(out.clauses, c)
+	}
+
+	return out, nil
+}
+
+// FromValue parses a mode as it is given in a module. Strings are parsed with
+// Parse. Numbers are read as octal digits, so that `mode = 755` means 0755.
+// HCL has already read numbers with a leading zero as octal, so modes with a
+// leading zero must be quoted.
+func FromValue(value interface{}) (*Spec, error) {
+	switch mode := value.(type) {
+	case int:
+		spec, err := Parse(strconv.Itoa(mode))
+		if err != nil {
+			return nil, fmt.Errorf("%d is not a valid octal mode, numbers are read as octal digits so leave out the leading zero or quote the mode", mode)
+		}
+		return spec, nil
+	case string:
+		return Parse(mode)
+	default:
+		return nil, fmt.Errorf("mode must be a number or a string, not %T", value)
+	}
+}
+
+func parseClause(text string) (clause, error) {
+	var c clause
+
+	i := 0
+	for ; i < len(text) && strings.IndexByte("ugoa", text[i]) >= 0; i++ {
+		switch text[i] {
+		case 'u':
+			c.who |= 04700
+		case 'g':
+			c.who |= 02070
+		case 'o':
+			c.who |= 01007
+		case 'a':
+			c.who |= 07777
+		}
+	}
+
+	if i == len(text) {
+		return c, fmt.Errorf("%q has no operator, expected one of +, - or =", text)
+	}
+
+	for i < len(text) {
+		op := text[i]
+		if op != '+' && op != '-' && op != '=' {
+			return c, fmt.Errorf("unexpected %q in %q", op, text)
+		}
+		i++
+
+		start := i
+		for ; i < len(text) && strings.IndexByte("+-=", text[i]) < 0; i++ {
+		}
+		perms := text[start:i]
+
+		// a single u, g or o copies the bits of that class
+		copying := perms == "u" || perms == "g" || perms == "o"
+		if !copying && strings.Trim(perms, "rwxXst") != "" {
+			return c, fmt.Errorf("%q has unknown permissions %q", text, perms)
+		}
+
+		c.actions = append(c.actions, action{op: op, perms: perms})
+	}
+
+	return c, nil
+}
+
+// Absolute reports whether the Spec sets the whole mode, rather than changing
+// the current one
+func (s *Spec) Absolute() bool {
+	return s.absolute != nil
+}
+
+// Apply returns the mode that results from applying the Spec to current, which
+// should include os.ModeDir for directories
+func (s *Spec) Apply(current os.FileMode) os.FileMode {
+	if s.absolute != nil {
+		return *s.absolute
+	}
+
+	isDir := current.IsDir()
+	mode := toOctal(current & Mask)
+
+	for _, c := range s.clauses {
+		who, masked := uint32(c.who), false
+		if who == 0 {
+			who, masked = 07777, true
+		}
+
+		for _, a := range c.actions {
+			bits := permBits(a.perms, mode, isDir) & who
+			if masked {
+				bits &^= uint32(umask)
+			}
+
+			switch a.op {
+			case '+':
+				mode |= bits
+			case '-':
+				mode &^= bits
+			case '=':
+				clear := who
+				if masked {
+					clear = 07777
+				}
+				// directories keep their setuid and setgid bits unless they are
+				// named, as with chmod
+				if isDir {
+					clear &^= 06000
+				}
+				mode = (mode &^ clear) | bits
+			}
+		}
+	}
+
+	return fromOctal(mode)
+}
+
+// String returns the mode as it was given
+func (s *Spec) String() string {
+	return s.text
+}
+
+// permBits returns the octal bits named by perms for every class
+func permBits(perms string, mode uint32, isDir bool) uint32 {
+	switch perms {
+	case "u":
+		return spread((mode >> 6) & 7)
+	case "g":
+		return spread((mode >> 3) & 7)
+	case "o":
+		return spread(mode & 7)
+	}
+
+	var out uint32
+	for _, p := range perms {
+		switch p {
+		case 'r':
+			out |= 0444
+		case 'w':
+			out |= 0222
+		case 'x':
+			out |= 0111
+		case 'X':
+			if isDir || mode&0111 != 0 {
+				out |= 0111
+			}
+		case 's':
+			out |= 06000
+		case 't':
+			out |= 01000
+		}
+	}
+	return out
+}
+
+// spread copies a single class of rwx bits into every class
+func spread(bits uint32) uint32 {
+	return bits<<6 | bits<<3 | bits
+}
+
+// toOctal converts the bits in Mask to the octal form used by chmod
+func toOctal(mode os.FileMode) uint32 {
+	out := uint32(mode.Perm())
+	if mode&os.ModeSetuid != 0 {
+		out |= 04000
+	}
+	if mode&os.ModeSetgid != 0 {
+		out |= 02000
+	}
+	if mode&os.ModeSticky != 0 {
+		out |= 01000
+	}
+	return out
+}
+
+// fromOctal converts the octal form used by chmod to an os.FileMode
+func fromOctal(raw uint32) os.FileMode {
+	out := os.FileMode(raw) & os.ModePerm
+	if raw&04000 != 0 {
+		out |= os.ModeSetuid
+	}
+	if raw&02000 != 0 {
+		out |= os.ModeSetgid
+	}
+	if raw&01000 != 0 {
+		out |= os.ModeSticky
+	}
+	return out
+}
@@ -0,0 +1,117 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filemode_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/filemode"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	t.Run("octal", func(t *testing.T) {
+		for text, expected := range map[string]os.FileMode{
+			"644":  0644,
+			"0755": 0755,
+			"4755": 0755 | os.ModeSetuid,
+			"1777": 0777 | os.ModeSticky,
+		} {
+			spec, err := filemode.Parse(text)
+			require.NoError(t, err, text)
+			assert.True(t, spec.Absolute(), text)
+			assert.Equal(t, expected, spec.Apply(0600), text)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, text := range []string{"", "0999", "17777", "u", "u+q", "z+r", "u+rw,"} {
+			_, err := filemode.Parse(text)
+			assert.Error(t, err, text)
+		}
+	})
+}
+
+func TestApply(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range []struct {
+		spec     string
+		current  os.FileMode
+		expected os.FileMode
+	}{
+		{"u+rwx,g+rx", 0600, 0750},
+		{"g-w,o-rwx", 0666, 0640},
+		{"a=r", 0755, 0444},
+		{"u=rw,go=", 0777, 0600},
+		{"ug+x", 0644, 0754},
+		{"g=u", 0700, 0770},
+		{"a+X", 0644, 0644},
+		{"a+X", 0744, 0755},
+		{"a+X", os.ModeDir | 0700, 0711},
+		{"u+s", 0755, 0755 | os.ModeSetuid},
+		{"g+s", os.ModeDir | 0755, 0755 | os.ModeSetgid},
+		{"o+t", 0777, 0777 | os.ModeSticky},
+		{"u-s", 0755 | os.ModeSetuid, 0755},
+		{"go-rwx,u+w", 0444, 0600},
+	} {
+		spec, err := filemode.Parse(test.spec)
+		require.NoError(t, err, test.spec)
+		assert.False(t, spec.Absolute(), test.spec)
+		assert.Equal(t, test.expected, spec.Apply(test.current), "%s on %s", test.spec, test.current)
+	}
+}
+
+func TestString(t *testing.T) {
+	t.Parallel()
+
+	spec, err := filemode.Parse("u+rwx,g+rx")
+	require.NoError(t, err)
+	assert.Equal(t, "u+rwx,g+rx", spec.String())
+
+	assert.Equal(t, "4755", filemode.Octal(0755|os.ModeSetuid).String())
+}
+
+func TestFromValue(t *testing.T) {
+	t.Parallel()
+
+	t.Run("number", func(t *testing.T) {
+		spec, err := filemode.FromValue(755)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0755), spec.Apply(0))
+	})
+
+	t.Run("string", func(t *testing.T) {
+		spec, err := filemode.FromValue("0755")
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0755), spec.Apply(0))
+	})
+
+	t.Run("number that is not octal", func(t *testing.T) {
+		for _, value := range []int{493, 99999, -1} {
+			_, err := filemode.FromValue(value)
+			assert.Error(t, err, "%d", value)
+		}
+	})
+
+	t.Run("wrong type", func(t *testing.T) {
+		_, err := filemode.FromValue(true)
+		assert.EqualError(t, err, "mode must be a number or a string, not bool")
+	})
+}
@@ -0,0 +1,42 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !windows
+
+package filemode_test
+
+import (
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/filemode"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyUmask(t *testing.T) {
+	// umask is read when the package is loaded, so this depends on the umask
+	// of the test process
+	mask := os.FileMode(syscall.Umask(0))
+	syscall.Umask(int(mask))
+
+	spec, err := filemode.Parse("+rw")
+	require.NoError(t, err)
+	assert.Equal(t, 0666&^mask, spec.Apply(0))
+
+	spec, err = filemode.Parse("a+rw")
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0666), spec.Apply(0))
+}
@@ -0,0 +1,414 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lock
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/asteris-llc/converge/helpers/logging"
+	"github.com/pkg/errors"
+)
+
+// DefaultConsulAddr is where the Consul agent is reached if no other address
+// is given
+const DefaultConsulAddr = "http://127.0.0.1:8500"
+
+// DefaultSessionTTL is how long Consul keeps a slot after its holder stops
+// renewing it, such as when the holder crashes
+const DefaultSessionTTL = 15 * time.Second
+
+// semaphoreKey is the key under the prefix that records the holders
+const semaphoreKey = ".lock"
+
+// Semaphore is a lock shared by the hosts of a cluster through Consul's KV
+// store, which at most Limit of them hold at once. It follows Consul's
+// semaphore recipe: each host adds a key under Prefix tied to a session, and
+// the holders are recorded in a single key updated with check-and-set. If a
+// holder dies, its session expires after the TTL and its slot is freed.
+type Semaphore struct {
+	Addr   string
+	Prefix string
+	Limit  int
+
+	// Token is the ACL token sent to Consul, if any
+	Token string
+
+	TTL    time.Duration
+	Client *http.Client
+}
+
+// Slot is a place in a Semaphore held by this host
+type Slot struct {
+	sem     *Semaphore
+	session string
+	stop    chan struct{}
+	once    sync.Once
+}
+
+// FullError is returned by Acquire when every slot in the semaphore is still
+// held once the timeout has passed
+type FullError struct {
+	Prefix string
+	Limit  int
+
+	// Holders are the names of the hosts holding the slots
+	Holders []string
+}
+
+func (e *FullError) Error() string {
+	return fmt.Sprintf("%s is held by %d of %d hosts: %s", e.Prefix, len(e.Holders), e.Limit, strings.Join(e.Holders, ", "))
+}
+
+type semaphoreValue struct {
+	Limit   int
+	Holders map[string]bool
+}
+
+type kvEntry struct {
+	Key         string
+	Value       string
+	Session     string
+	ModifyIndex uint64
+}
+
+// Acquire takes a slot in the semaphore, waiting up to timeout for a holder to
+// release one. A timeout of 0 fails immediately if the semaphore is full.
+func (s *Semaphore) Acquire(ctx context.Context, timeout time.Duration) (*Slot, error) {
+	logger := logging.GetLogger(ctx).WithField("function", "Semaphore.Acquire").WithField("prefix", s.Prefix)
+
+	if s.Limit < 1 {
+		return nil, fmt.Errorf("semaphore limit must be at least 1, got %d", s.Limit)
+	}
+
+	session, err := s.createSession(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create Consul session")
+	}
+	slot := &Slot{sem: s, session: session, stop: make(chan struct{})}
+
+	name, _ := os.Hostname()
+	acquired, err := s.put(ctx, s.Prefix+"/"+session, url.Values{"acquire": {session}}, []byte(name))
+	if err == nil && !acquired {
+		err = errors.New("session was not accepted")
+	}
+	if err != nil {
+		slot.cleanup(ctx)
+		return nil, errors.Wrap(err, "could not add contender key")
+	}
+
+	go slot.renew(ctx)
+
+	deadline := time.Now().Add(timeout)
+	var index uint64
+	waiting := false
+	for {
+		entries, next, err := s.list(ctx, index, deadline.Sub(time.Now()))
+		if err != nil {
+			slot.cleanup(ctx)
+			return nil, err
+		}
+		index = next
+
+		lock, value, holders, err := s.holders(entries)
+		if err != nil {
+			slot.cleanup(ctx)
+			return nil, err
+		}
+
+		if len(value.Holders) < s.Limit {
+			value.Holders[session] = true
+
+			var modifyIndex uint64
+			if lock != nil {
+				modifyIndex = lock.ModifyIndex
+			}
+
+			body, err := json.Marshal(value)
+			if err != nil {
+				slot.cleanup(ctx)
+				return nil, err
+			}
+
+			ok, err := s.put(ctx, s.Prefix+"/"+semaphoreKey, url.Values{"cas": {strconv.FormatUint(modifyIndex, 10)}}, body)
+			if err != nil {
+				slot.cleanup(ctx)
+				return nil, errors.Wrap(err, "could not update semaphore")
+			}
+			if ok {
+				logger.Debug("locked")
+				return slot, nil
+			}
+
+			// another host updated the holders first
+			index = 0
+			continue
+		}
+
+		if !time.Now().Before(deadline) {
+			slot.cleanup(ctx)
+			return nil, &FullError{Prefix: s.Prefix, Limit: s.Limit, Holders: holders}
+		}
+
+		if !waiting {
+			logger.WithField("timeout", timeout).WithField("holders", strings.Join(holders, ", ")).Info("waiting for a slot in the cluster lock")
+			waiting = true
+		}
+
+		select {
+		case <-ctx.Done():
+			slot.cleanup(ctx)
+			return nil, errors.New("interrupted while waiting for cluster lock")
+		default:
+		}
+	}
+}
+
+// Release gives up the slot, so another host can take it
+func (l *Slot) Release(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+
+	s := l.sem
+	for {
+		entries, _, err := s.list(ctx, 0, 0)
+		if err != nil {
+			return err
+		}
+
+		lock, value, _, err := s.holders(entries)
+		if err != nil {
+			return err
+		}
+
+		if lock == nil || !value.Holders[l.session] {
+			break
+		}
+		delete(value.Holders, l.session)
+
+		body, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+
+		ok, err := s.put(ctx, s.Prefix+"/"+semaphoreKey, url.Values{"cas": {strconv.FormatUint(lock.ModifyIndex, 10)}}, body)
+		if err != nil {
+			return errors.Wrap(err, "could not update semaphore")
+		}
+		if ok {
+			break
+		}
+	}
+
+	return l.cleanup(ctx)
+}
+
+// cleanup stops renewing the session and removes it, which also removes the
+// contender key
+func (l *Slot) cleanup(ctx context.Context) error {
+	l.once.Do(func() { close(l.stop) })
+	_, err := l.sem.do(ctx, "PUT", "/v1/session/destroy/"+l.session, nil, nil, nil)
+	return err
+}
+
+// renew keeps the session alive until the slot is released
+func (l *Slot) renew(ctx context.Context) {
+	ticker := time.NewTicker(l.sem.ttl() / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := l.sem.do(ctx, "PUT", "/v1/session/renew/"+l.session, nil, nil, nil); err != nil {
+				logging.GetLogger(ctx).WithError(err).Warning("could not renew Consul session")
+			}
+		}
+	}
+}
+
+// holders reads the semaphore key from the entries under the prefix, and
+// drops holders whose sessions are gone. It returns the key, if it exists,
+// and the names of the live holders.
+func (s *Semaphore) holders(entries []*kvEntry) (*kvEntry, *semaphoreValue, []string, error) {
+	var lock *kvEntry
+	names := map[string]string{}
+	for _, entry := range entries {
+		if entry.Key == s.Prefix+"/"+semaphoreKey {
+			lock = entry
+		} else if entry.Session != "" {
+			content, _ := base64.StdEncoding.DecodeString(entry.Value)
+			names[entry.Session] = string(content)
+		}
+	}
+
+	value := &semaphoreValue{Limit: s.Limit, Holders: map[string]bool{}}
+	if lock != nil {
+		content, err := base64.StdEncoding.DecodeString(lock.Value)
+		if err != nil {
+			return nil, nil, nil, errors.Wrap(err, "could not decode semaphore")
+		}
+		if err := json.Unmarshal(content, value); err != nil {
+			return nil, nil, nil, errors.Wrap(err, "could not parse semaphore")
+		}
+		if value.Limit != s.Limit {
+			return nil, nil, nil, fmt.Errorf("%s has a limit of %d, but %d was given", s.Prefix, value.Limit, s.Limit)
+		}
+		if value.Holders == nil {
+			value.Holders = map[string]bool{}
+		}
+	}
+
+	var holders []string
+	for session := range value.Holders {
+		name, ok := names[session]
+		if !ok {
+			delete(value.Holders, session)
+			continue
+		}
+		holders = append(holders, name)
+	}
+	sort.Strings(holders)
+
+	return lock, value, holders, nil
+}
+
+func (s *Semaphore) createSession(ctx context.Context) (string, error) {
+	body, err := json.Marshal(map[string]string{
+		"Name":      "converge " + s.Prefix,
+		"TTL":       s.ttl().String(),
+		"Behavior":  "delete",
+		"LockDelay": "0s",
+	})
+	if err != nil {
+		return "", err
+	}
+
+	var out struct{ ID string }
+	if _, err := s.do(ctx, "PUT", "/v1/session/create", nil, body, &out); err != nil {
+		return "", err
+	}
+	return out.ID, nil
+}
+
+// list gets the entries under the prefix. If index is set, Consul waits up to
+// wait for them to change from that index.
+func (s *Semaphore) list(ctx context.Context, index uint64, wait time.Duration) ([]*kvEntry, uint64, error) {
+	query := url.Values{"recurse": {"true"}}
+	if index > 0 && wait > 0 {
+		query.Set("index", strconv.FormatUint(index, 10))
+		query.Set("wait", fmt.Sprintf("%dms", wait/time.Millisecond))
+	}
+
+	var entries []*kvEntry
+	resp, err := s.do(ctx, "GET", "/v1/kv/"+s.Prefix, query, nil, &entries)
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "could not read semaphore")
+	}
+
+	next, _ := strconv.ParseUint(resp.Header.Get("X-Consul-Index"), 10, 64)
+	return entries, next, nil
+}
+
+// put writes a key, returning whether Consul accepted it
+func (s *Semaphore) put(ctx context.Context, key string, query url.Values, body []byte) (bool, error) {
+	var ok bool
+	_, err := s.do(ctx, "PUT", "/v1/kv/"+key, query, body, &ok)
+	return ok, err
+}
+
+// do makes a request to the Consul HTTP API, decoding the response into out
+// if it is set. A missing key is not an error.
+func (s *Semaphore) do(ctx context.Context, method, path string, query url.Values, body []byte, out interface{}) (*http.Response, error) {
+	addr := s.Addr
+	if addr == "" {
+		addr = DefaultConsulAddr
+	}
+
+	target := strings.TrimRight(addr, "/") + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequest(method, target, reader)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if s.Token != "" {
+		req.Header.Set("X-Consul-Token", s.Token)
+	}
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return resp, nil
+	}
+
+	content, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s %s: %s: %s", method, path, resp.Status, strings.TrimSpace(string(content)))
+	}
+
+	if out != nil && len(content) > 0 {
+		if err := json.Unmarshal(content, out); err != nil {
+			return nil, errors.Wrapf(err, "could not parse response to %s %s", method, path)
+		}
+	}
+
+	return resp, nil
+}
+
+func (s *Semaphore) ttl() time.Duration {
+	if s.TTL <= 0 {
+		return DefaultSessionTTL
+	}
+	return s.TTL
+}
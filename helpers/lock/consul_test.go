@@ -0,0 +1,270 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lock_test
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/asteris-llc/converge/helpers/lock"
+	"github.com/asteris-llc/converge/helpers/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSemaphore(t *testing.T) {
+	defer logging.HideLogs(t)()
+
+	host, err := os.Hostname()
+	require.NoError(t, err)
+
+	t.Run("limit", func(t *testing.T) {
+		consul := newFakeConsul()
+		defer consul.Close()
+
+		sem := &lock.Semaphore{Addr: consul.URL, Prefix: "converge/db", Limit: 2}
+
+		first, err := sem.Acquire(context.Background(), 0)
+		require.NoError(t, err)
+		second, err := sem.Acquire(context.Background(), 0)
+		require.NoError(t, err)
+
+		_, err = sem.Acquire(context.Background(), 0)
+		assert.Equal(t, &lock.FullError{Prefix: "converge/db", Limit: 2, Holders: []string{host, host}}, err)
+
+		require.NoError(t, first.Release(context.Background()))
+
+		third, err := sem.Acquire(context.Background(), 0)
+		require.NoError(t, err)
+
+		assert.NoError(t, second.Release(context.Background()))
+		assert.NoError(t, third.Release(context.Background()))
+		assert.Empty(t, consul.sessions)
+	})
+
+	t.Run("waits", func(t *testing.T) {
+		consul := newFakeConsul()
+		defer consul.Close()
+
+		sem := &lock.Semaphore{Addr: consul.URL, Prefix: "converge/db", Limit: 1}
+
+		held, err := sem.Acquire(context.Background(), 0)
+		require.NoError(t, err)
+
+		go func() {
+			time.Sleep(50 * time.Millisecond)
+			held.Release(context.Background())
+		}()
+
+		again, err := sem.Acquire(context.Background(), 5*time.Second)
+		require.NoError(t, err)
+		assert.NoError(t, again.Release(context.Background()))
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		consul := newFakeConsul()
+		defer consul.Close()
+
+		sem := &lock.Semaphore{Addr: consul.URL, Prefix: "converge/db", Limit: 1}
+
+		_, err := sem.Acquire(context.Background(), 0)
+		require.NoError(t, err)
+
+		// a holder that stops renewing its session loses its slot
+		consul.expireAll()
+
+		again, err := sem.Acquire(context.Background(), 0)
+		require.NoError(t, err)
+		assert.NoError(t, again.Release(context.Background()))
+	})
+
+	t.Run("limit mismatch", func(t *testing.T) {
+		consul := newFakeConsul()
+		defer consul.Close()
+
+		held, err := (&lock.Semaphore{Addr: consul.URL, Prefix: "converge/db", Limit: 1}).Acquire(context.Background(), 0)
+		require.NoError(t, err)
+		defer held.Release(context.Background())
+
+		_, err = (&lock.Semaphore{Addr: consul.URL, Prefix: "converge/db", Limit: 3}).Acquire(context.Background(), 0)
+		assert.EqualError(t, err, "converge/db has a limit of 1, but 3 was given")
+	})
+
+	t.Run("invalid limit", func(t *testing.T) {
+		_, err := (&lock.Semaphore{Prefix: "converge/db"}).Acquire(context.Background(), 0)
+		assert.EqualError(t, err, "semaphore limit must be at least 1, got 0")
+	})
+}
+
+// fakeConsul implements the parts of Consul's HTTP API used by Semaphore
+type fakeConsul struct {
+	*httptest.Server
+
+	lock     sync.Mutex
+	index    uint64
+	sessions map[string]bool
+	kv       map[string]*fakeEntry
+}
+
+type fakeEntry struct {
+	value       []byte
+	session     string
+	modifyIndex uint64
+}
+
+func newFakeConsul() *fakeConsul {
+	f := &fakeConsul{index: 1, sessions: map[string]bool{}, kv: map[string]*fakeEntry{}}
+	f.Server = httptest.NewServer(http.HandlerFunc(f.serve))
+	return f
+}
+
+func (f *fakeConsul) expireAll() {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	for session := range f.sessions {
+		f.destroy(session)
+	}
+}
+
+// destroy removes a session and the keys it holds. The lock must be held.
+func (f *fakeConsul) destroy(session string) {
+	delete(f.sessions, session)
+	for key, entry := range f.kv {
+		if entry.session == session {
+			delete(f.kv, key)
+		}
+	}
+	f.index++
+}
+
+func (f *fakeConsul) serve(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	path := r.URL.Path
+
+	switch {
+	case path == "/v1/session/create":
+		f.lock.Lock()
+		id := fmt.Sprintf("session-%d", f.index)
+		f.sessions[id] = true
+		f.index++
+		f.lock.Unlock()
+		json.NewEncoder(w).Encode(map[string]string{"ID": id})
+
+	case strings.HasPrefix(path, "/v1/session/renew/"):
+		fmt.Fprint(w, "[]")
+
+	case strings.HasPrefix(path, "/v1/session/destroy/"):
+		f.lock.Lock()
+		f.destroy(strings.TrimPrefix(path, "/v1/session/destroy/"))
+		f.lock.Unlock()
+		fmt.Fprint(w, "true")
+
+	case strings.HasPrefix(path, "/v1/kv/") && r.Method == "GET":
+		f.list(w, strings.TrimPrefix(path, "/v1/kv/"), query)
+
+	case strings.HasPrefix(path, "/v1/kv/") && r.Method == "PUT":
+		body, _ := ioutil.ReadAll(r.Body)
+		fmt.Fprint(w, f.put(strings.TrimPrefix(path, "/v1/kv/"), query, body))
+
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (f *fakeConsul) list(w http.ResponseWriter, prefix string, query map[string][]string) {
+	if index, ok := query["index"]; ok {
+		wait, _ := time.ParseDuration(query["wait"][0])
+		since, _ := strconv.ParseUint(index[0], 10, 64)
+		deadline := time.Now().Add(wait)
+
+		for time.Now().Before(deadline) {
+			f.lock.Lock()
+			changed := f.index != since
+			f.lock.Unlock()
+			if changed {
+				break
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	var keys []string
+	for key := range f.kv {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	w.Header().Set("X-Consul-Index", strconv.FormatUint(f.index, 10))
+	if len(keys) == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	var out []map[string]interface{}
+	for _, key := range keys {
+		entry := f.kv[key]
+		out = append(out, map[string]interface{}{
+			"Key":         key,
+			"Value":       base64.StdEncoding.EncodeToString(entry.value),
+			"Session":     entry.session,
+			"ModifyIndex": entry.modifyIndex,
+		})
+	}
+	json.NewEncoder(w).Encode(out)
+}
+
+func (f *fakeConsul) put(key string, query map[string][]string, body []byte) bool {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	existing := f.kv[key]
+
+	if cas, ok := query["cas"]; ok {
+		index, _ := strconv.ParseUint(cas[0], 10, 64)
+		if index == 0 && existing != nil || index != 0 && (existing == nil || existing.modifyIndex != index) {
+			return false
+		}
+	}
+
+	entry := &fakeEntry{value: body, modifyIndex: f.index}
+	if acquire, ok := query["acquire"]; ok {
+		if !f.sessions[acquire[0]] {
+			return false
+		}
+		entry.session = acquire[0]
+	}
+
+	f.kv[key] = entry
+	f.index++
+	return true
+}
@@ -0,0 +1,177 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lock
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/asteris-llc/converge/helpers/logging"
+	"github.com/pkg/errors"
+)
+
+// DefaultPath is where the host lock is kept if no other path is given
+const DefaultPath = "/var/run/converge.lock"
+
+// retryInterval is how often a held lock is tried again while waiting
+var retryInterval = 100 * time.Millisecond
+
+// Lock is an advisory lock on a file, held with flock(2), or LockFileEx on
+// Windows. The kernel releases the lock when the holding process exits, so a
+// crashed run never leaves the host locked. The holder's PID is written to the
+// file for reporting.
+type Lock struct {
+	Path string
+	file *os.File
+}
+
+// HeldError is returned by Acquire when the lock is still held by another
+// process once the timeout has passed
+type HeldError struct {
+	Path string
+
+	// PID is the process that last took the lock, or 0 if it's unknown
+	PID int
+
+	// Stale is true if that process is no longer running, so PID was left in
+	// the file by an earlier run and doesn't name the holder. Lock files are
+	// opened close-on-exec, so the processes a run starts never hold its lock:
+	// the holder is a process that hasn't written its own PID yet, or one that
+	// isn't converge.
+	Stale bool
+}
+
+func (e *HeldError) Error() string {
+	switch {
+	case e.PID == 0:
+		return fmt.Sprintf("%s is locked by another process", e.Path)
+	case e.Stale:
+		return fmt.Sprintf("%s is locked by another process, which has not written its pid (pid %d in the file is left from an earlier run)", e.Path, e.PID)
+	default:
+		return fmt.Sprintf("%s is locked by another converge process (pid %d)", e.Path, e.PID)
+	}
+}
+
+// Acquire takes the lock at path, waiting up to timeout for another process to
+// release it. A timeout of 0 fails immediately if the lock is held.
+func Acquire(ctx context.Context, path string, timeout time.Duration) (*Lock, error) {
+	logger := logging.GetLogger(ctx).WithField("function", "lock.Acquire").WithField("path", path)
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, errors.Wrap(err, "could not create lock directory")
+	}
+
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not open lock file")
+	}
+
+	deadline := time.Now().Add(timeout)
+	waiting := false
+	for {
+		locked, err := tryLock(file)
+		if err != nil {
+			file.Close()
+			return nil, errors.Wrap(err, "could not lock")
+		}
+		if locked {
+			break
+		}
+
+		if !time.Now().Before(deadline) {
+			pid := readPID(file)
+			file.Close()
+			return nil, &HeldError{Path: path, PID: pid, Stale: pid != 0 && !running(pid)}
+		}
+
+		if !waiting {
+			logger.WithField("timeout", timeout).Info("waiting for another converge process to release the lock")
+			waiting = true
+		}
+
+		select {
+		case <-ctx.Done():
+			file.Close()
+			return nil, errors.New("interrupted while waiting for lock")
+		case <-time.After(retryInterval):
+		}
+	}
+
+	if pid := readPID(file); pid != 0 && pid != os.Getpid() {
+		logger.WithField("pid", pid).Warning("a previous run exited without releasing the lock")
+	}
+
+	if err := writePID(file); err != nil {
+		file.Close()
+		return nil, errors.Wrap(err, "could not write lock file")
+	}
+
+	logger.Debug("locked")
+	return &Lock{Path: path, file: file}, nil
+}
+
+// Release gives up the lock. The file is left in place, since removing it
+// would race with other processes that have it open.
+func (l *Lock) Release() error {
+	if l == nil || l.file == nil {
+		return nil
+	}
+
+	defer func() { l.file = nil }()
+
+	if err := l.file.Truncate(0); err != nil {
+		l.file.Close()
+		return errors.Wrap(err, "could not clear lock file")
+	}
+
+	if err := unlock(l.file); err != nil {
+		l.file.Close()
+		return errors.Wrap(err, "could not unlock")
+	}
+
+	return l.file.Close()
+}
+
+func readPID(file *os.File) int {
+	if _, err := file.Seek(0, 0); err != nil {
+		return 0
+	}
+
+	content, err := ioutil.ReadAll(file)
+	if err != nil {
+		return 0
+	}
+
+	pid, err := strconv.Atoi(strings.TrimSpace(string(content)))
+	if err != nil {
+		return 0
+	}
+	return pid
+}
+
+func writePID(file *os.File) error {
+	if err := file.Truncate(0); err != nil {
+		return err
+	}
+
+	_, err := file.WriteAt([]byte(strconv.Itoa(os.Getpid())+"\n"), 0)
+	return err
+}
@@ -0,0 +1,105 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lock_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/asteris-llc/converge/helpers/lock"
+	"github.com/asteris-llc/converge/helpers/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAcquire(t *testing.T) {
+	defer logging.HideLogs(t)()
+
+	dir, err := ioutil.TempDir("", "converge-lock")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "run", "converge.lock")
+
+	t.Run("held", func(t *testing.T) {
+		held, err := lock.Acquire(context.Background(), path, 0)
+		require.NoError(t, err)
+
+		content, err := ioutil.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, strconv.Itoa(os.Getpid())+"\n", string(content))
+
+		_, err = lock.Acquire(context.Background(), path, 0)
+		assert.Equal(t, &lock.HeldError{Path: path, PID: os.Getpid()}, err)
+
+		require.NoError(t, held.Release())
+
+		again, err := lock.Acquire(context.Background(), path, 0)
+		require.NoError(t, err)
+		assert.NoError(t, again.Release())
+	})
+
+	t.Run("waits", func(t *testing.T) {
+		held, err := lock.Acquire(context.Background(), path, 0)
+		require.NoError(t, err)
+
+		go func() {
+			time.Sleep(50 * time.Millisecond)
+			held.Release()
+		}()
+
+		waited, err := lock.Acquire(context.Background(), path, 5*time.Second)
+		require.NoError(t, err)
+		assert.NoError(t, waited.Release())
+	})
+
+	t.Run("left behind", func(t *testing.T) {
+		// a crashed run leaves its PID in the file, but not the lock
+		require.NoError(t, ioutil.WriteFile(path, []byte("1\n"), 0644))
+
+		taken, err := lock.Acquire(context.Background(), path, 0)
+		require.NoError(t, err)
+		assert.NoError(t, taken.Release())
+	})
+
+	t.Run("interrupted", func(t *testing.T) {
+		held, err := lock.Acquire(context.Background(), path, 0)
+		require.NoError(t, err)
+		defer held.Release()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err = lock.Acquire(ctx, path, 5*time.Second)
+		assert.EqualError(t, err, "interrupted while waiting for lock")
+	})
+}
+
+func TestHeldError(t *testing.T) {
+	t.Parallel()
+
+	assert.EqualError(t, &lock.HeldError{Path: "x"}, "x is locked by another process")
+	assert.EqualError(t, &lock.HeldError{Path: "x", PID: 10}, "x is locked by another converge process (pid 10)")
+	assert.EqualError(
+		t,
+		&lock.HeldError{Path: "x", PID: 10, Stale: true},
+		"x is locked by another process, which has not written its pid (pid 10 in the file is left from an earlier run)",
+	)
+}
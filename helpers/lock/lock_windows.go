@@ -0,0 +1,83 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lock
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	kernel32         = syscall.NewLazyDLL("kernel32.dll")
+	procLockFileEx   = kernel32.NewProc("LockFileEx")
+	procUnlockFileEx = kernel32.NewProc("UnlockFileEx")
+)
+
+const (
+	lockfileFailImmediately = 0x1
+	lockfileExclusiveLock   = 0x2
+
+	errorLockViolation syscall.Errno = 33
+)
+
+// lockOffsetHigh places the locked byte far past the PID written to the file.
+// Windows locks are mandatory, so locking the start of the file would stop
+// waiting processes from reading who holds it.
+const lockOffsetHigh = 0x7fffffff
+
+// tryLock takes the lock on the file without waiting, returning false if
+// another process holds it
+func tryLock(file *os.File) (bool, error) {
+	overlapped := syscall.Overlapped{OffsetHigh: lockOffsetHigh}
+	ok, _, err := procLockFileEx.Call(
+		file.Fd(),
+		lockfileExclusiveLock|lockfileFailImmediately,
+		0,
+		1, 0,
+		uintptr(unsafe.Pointer(&overlapped)),
+	)
+	if ok != 0 {
+		return true, nil
+	}
+	if err == errorLockViolation || err == syscall.ERROR_IO_PENDING {
+		return false, nil
+	}
+	return false, err
+}
+
+func unlock(file *os.File) error {
+	overlapped := syscall.Overlapped{OffsetHigh: lockOffsetHigh}
+	ok, _, err := procUnlockFileEx.Call(
+		file.Fd(),
+		0,
+		1, 0,
+		uintptr(unsafe.Pointer(&overlapped)),
+	)
+	if ok == 0 {
+		return err
+	}
+	return nil
+}
+
+// running reports whether a process with the given PID exists
+func running(pid int) bool {
+	handle, err := syscall.OpenProcess(syscall.PROCESS_QUERY_INFORMATION, false, uint32(pid))
+	if err != nil {
+		return err == syscall.ERROR_ACCESS_DENIED
+	}
+	syscall.CloseHandle(handle)
+	return true
+}
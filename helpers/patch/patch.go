@@ -0,0 +1,319 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package patch
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// Diff returns a unified diff from before to after, with context unchanged
+// lines around each change, for showing what would change. It is empty if
+// they are the same. A missing newline at the end of either is not shown.
+func Diff(before, after string, context int) string {
+	out, _ := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:       terminated(before),
+		B:       terminated(after),
+		Context: context,
+	})
+	return out
+}
+
+// Apply applies the hunks of a unified diff to original and returns the
+// result. A hunk that has already been applied is skipped, so applying the
+// same diff again returns its result unchanged. Hunks are searched for near
+// the line they start on, so they still apply if lines were added or removed
+// elsewhere in the file.
+func Apply(original, diff string) (string, error) {
+	hunks, err := parse(diff)
+	if err != nil {
+		return "", err
+	}
+
+	if len(hunks) == 0 {
+		return "", errors.New("patch has no hunks")
+	}
+
+	lines := splitLines(original)
+	from, delta := 0, 0
+
+	for i, h := range hunks {
+		expected := h.oldStart - 1 + delta
+		if h.oldStart == 0 {
+			expected = 0
+		}
+
+		// an insertion without context matches anywhere, so it only counts as
+		// applied if its lines are already there
+		if len(h.old) == 0 {
+			if at := find(lines, h.new, expected, from, h.anchor()); at >= 0 && len(h.new) > 0 {
+				from = at + len(h.new)
+				continue
+			}
+			at := clamp(expected, from, len(lines))
+			lines = splice(lines, at, 0, h.new)
+			from = at + len(h.new)
+			delta += len(h.new)
+			continue
+		}
+
+		if at := find(lines, h.old, expected, from, h.anchor()); at >= 0 {
+			lines = splice(lines, at, len(h.old), h.new)
+			from = at + len(h.new)
+			delta += len(h.new) - len(h.old)
+			continue
+		}
+
+		if at := find(lines, h.new, expected, from, h.anchor()); at >= 0 {
+			from = at + len(h.new)
+			continue
+		}
+
+		return "", fmt.Errorf("hunk %d does not apply: %s", i+1, h.header)
+	}
+
+	return strings.Join(lines, ""), nil
+}
+
+type anchor int
+
+const (
+	anchorNone anchor = iota
+	anchorStart
+	anchorEnd
+)
+
+type hunk struct {
+	header   string
+	oldStart int
+	old, new []string
+
+	// leading and trailing are the number of context lines before the first
+	// change and after the last
+	leading, trailing int
+}
+
+// anchor returns where in the file the hunk must match. A hunk with less
+// context on one side than the other is at the start or end of the file, and
+// only matches there.
+func (h *hunk) anchor() anchor {
+	switch {
+	case h.leading < h.trailing:
+		return anchorStart
+	case h.trailing < h.leading:
+		return anchorEnd
+	default:
+		return anchorNone
+	}
+}
+
+var hunkHeader = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+
+// parse reads the hunks of a unified diff. Lines outside of hunks, like file
+// headers, are ignored.
+func parse(diff string) ([]*hunk, error) {
+	var (
+		out              []*hunk
+		current          *hunk
+		oldLeft, newLeft int
+		lastOld, lastNew bool
+		changed          bool
+	)
+
+	for _, line := range splitLines(diff) {
+		if current == nil || (oldLeft == 0 && newLeft == 0) {
+			// a "no newline" marker may follow the last line of a hunk
+			if current != nil && strings.HasPrefix(line, `\`) {
+				trimLast(current, lastOld, lastNew)
+				continue
+			}
+
+			match := hunkHeader.FindStringSubmatch(line)
+			if match == nil {
+				continue
+			}
+
+			current = &hunk{header: strings.TrimSpace(line)}
+			current.oldStart, _ = strconv.Atoi(match[1])
+			oldLeft = count(match[2])
+			newLeft = count(match[4])
+			changed = false
+			out = append(out, current)
+			continue
+		}
+
+		body := line[1:]
+		switch line[0] {
+		case ' ', '\n', '\r':
+			// editors may strip the space from empty context lines
+			if line[0] != ' ' {
+				body = line
+			}
+			current.old = append(current.old, body)
+			current.new = append(current.new, body)
+			oldLeft--
+			newLeft--
+			lastOld, lastNew = true, true
+			if changed {
+				current.trailing++
+			} else {
+				current.leading++
+			}
+
+		case '-':
+			current.old = append(current.old, body)
+			oldLeft--
+			lastOld, lastNew = true, false
+			changed = true
+			current.trailing = 0
+
+		case '+':
+			current.new = append(current.new, body)
+			newLeft--
+			lastOld, lastNew = false, true
+			changed = true
+			current.trailing = 0
+
+		case '\\':
+			trimLast(current, lastOld, lastNew)
+
+		default:
+			return nil, fmt.Errorf("unexpected line in %s: %q", current.header, strings.TrimSpace(line))
+		}
+
+		if oldLeft < 0 || newLeft < 0 {
+			return nil, fmt.Errorf("%s has more lines than its header says", current.header)
+		}
+	}
+
+	if current != nil && (oldLeft > 0 || newLeft > 0) {
+		return nil, fmt.Errorf("%s has fewer lines than its header says", current.header)
+	}
+
+	return out, nil
+}
+
+// trimLast removes the newline from the last line of the sides of the hunk
+// that a "no newline at end of file" marker applies to
+func trimLast(h *hunk, old, new bool) {
+	if old && len(h.old) > 0 {
+		h.old[len(h.old)-1] = strings.TrimSuffix(h.old[len(h.old)-1], "\n")
+	}
+	if new && len(h.new) > 0 {
+		h.new[len(h.new)-1] = strings.TrimSuffix(h.new[len(h.new)-1], "\n")
+	}
+}
+
+// count parses a line count from a hunk header, which is 1 if left out
+func count(s string) int {
+	if s == "" {
+		return 1
+	}
+	n, _ := strconv.Atoi(s)
+	return n
+}
+
+// find returns the position of seq in lines that is closest to expected and
+// no earlier than from, or -1 if there is none
+func find(lines, seq []string, expected, from int, a anchor) int {
+	last := len(lines) - len(seq)
+
+	switch a {
+	case anchorStart:
+		if from == 0 && matches(lines, seq, 0) {
+			return 0
+		}
+		return -1
+
+	case anchorEnd:
+		if last >= from && matches(lines, seq, last) {
+			return last
+		}
+		return -1
+	}
+
+	if last < from {
+		return -1
+	}
+	expected = clamp(expected, from, last)
+
+	for d := 0; expected-d >= from || expected+d <= last; d++ {
+		if at := expected - d; at >= from && matches(lines, seq, at) {
+			return at
+		}
+		if at := expected + d; d > 0 && at <= last && matches(lines, seq, at) {
+			return at
+		}
+	}
+
+	return -1
+}
+
+func matches(lines, seq []string, at int) bool {
+	if at < 0 || at+len(seq) > len(lines) {
+		return false
+	}
+	for i, line := range seq {
+		if lines[at+i] != line {
+			return false
+		}
+	}
+	return true
+}
+
+// splice replaces n lines of lines at the position with replacement
+func splice(lines []string, at, n int, replacement []string) []string {
+	out := make([]string, 0, len(lines)-n+len(replacement))
+	out = append(out, lines[:at]...)
+	out = append(out, replacement...)
+	return append(out, lines[at+n:]...)
+}
+
+func clamp(n, min, max int) int {
+	if n < min {
+		return min
+	}
+	if n > max {
+		return max
+	}
+	return n
+}
+
+// terminated splits s into lines that all end with a newline
+func terminated(s string) []string {
+	lines := splitLines(s)
+	if n := len(lines); n > 0 && !strings.HasSuffix(lines[n-1], "\n") {
+		lines[n-1] += "\n"
+	}
+	return lines
+}
+
+// splitLines splits s into lines, each keeping its newline. The last line has
+// no newline if s doesn't end with one.
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
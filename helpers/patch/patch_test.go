@@ -0,0 +1,137 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package patch_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/patch"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// numbered returns n lines numbered from 1
+func numbered(n int) string {
+	var lines []string
+	for i := 1; i <= n; i++ {
+		lines = append(lines, fmt.Sprintf("line %d\n", i))
+	}
+	return strings.Join(lines, "")
+}
+
+func TestDiff(t *testing.T) {
+	t.Parallel()
+
+	t.Run("same", func(t *testing.T) {
+		assert.Equal(t, "", patch.Diff("a\nb\n", "a\nb\n", 3))
+	})
+
+	t.Run("context", func(t *testing.T) {
+		before := numbered(20)
+		after := strings.Replace(before, "line 10\n", "line ten\n", 1)
+
+		assert.Equal(
+			t,
+			"@@ -9,3 +9,3 @@\n line 9\n-line 10\n+line ten\n line 11\n",
+			patch.Diff(before, after, 1),
+		)
+	})
+
+	t.Run("no newline", func(t *testing.T) {
+		assert.Equal(t, "@@ -1 +1 @@\n-a\n+b\n", patch.Diff("a", "b", 3))
+	})
+}
+
+func TestApply(t *testing.T) {
+	t.Parallel()
+
+	original := numbered(20)
+
+	t.Run("round trip", func(t *testing.T) {
+		after := strings.Replace(original, "line 3\n", "line three\n", 1)
+		after = strings.Replace(after, "line 15\n", "line 15\nline 15.5\n", 1)
+		after = strings.Replace(after, "line 20\n", "", 1)
+
+		patched, err := patch.Apply(original, patch.Diff(original, after, 3))
+		require.NoError(t, err)
+		assert.Equal(t, after, patched)
+	})
+
+	t.Run("already applied", func(t *testing.T) {
+		after := strings.Replace(original, "line 10\n", "line ten\n", 1)
+		after += "line 21\n"
+		diff := patch.Diff(original, after, 3)
+
+		patched, err := patch.Apply(after, diff)
+		require.NoError(t, err)
+		assert.Equal(t, after, patched)
+	})
+
+	t.Run("offset", func(t *testing.T) {
+		diff := "--- a\n+++ b\n@@ -9,3 +9,3 @@\n line 9\n-line 10\n+line ten\n line 11\n"
+		shifted := "new\nlines\n" + original
+
+		patched, err := patch.Apply(shifted, diff)
+		require.NoError(t, err)
+		assert.Equal(t, strings.Replace(shifted, "line 10\n", "line ten\n", 1), patched)
+	})
+
+	t.Run("empty context line", func(t *testing.T) {
+		diff := "@@ -1,3 +1,3 @@\n a\n\n-b\n+c\n"
+
+		patched, err := patch.Apply("a\n\nb\n", diff)
+		require.NoError(t, err)
+		assert.Equal(t, "a\n\nc\n", patched)
+	})
+
+	t.Run("no newline at end of file", func(t *testing.T) {
+		diff := "@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+c\n"
+
+		patched, err := patch.Apply("a\nb", diff)
+		require.NoError(t, err)
+		assert.Equal(t, "a\nc\n", patched)
+	})
+
+	t.Run("new file", func(t *testing.T) {
+		diff := "@@ -0,0 +1,2 @@\n+a\n+b\n"
+
+		patched, err := patch.Apply("", diff)
+		require.NoError(t, err)
+		assert.Equal(t, "a\nb\n", patched)
+
+		patched, err = patch.Apply(patched, diff)
+		require.NoError(t, err)
+		assert.Equal(t, "a\nb\n", patched)
+	})
+
+	t.Run("does not apply", func(t *testing.T) {
+		diff := "@@ -1,3 +1,3 @@\n x\n-y\n+z\n w\n"
+
+		_, err := patch.Apply(original, diff)
+		assert.EqualError(t, err, "hunk 1 does not apply: @@ -1,3 +1,3 @@")
+	})
+
+	t.Run("no hunks", func(t *testing.T) {
+		_, err := patch.Apply(original, "--- a\n+++ b\n")
+		assert.EqualError(t, err, "patch has no hunks")
+	})
+
+	t.Run("short hunk", func(t *testing.T) {
+		_, err := patch.Apply(original, "@@ -1,3 +1,3 @@\n line 1\n")
+		assert.EqualError(t, err, "@@ -1,3 +1,3 @@ has fewer lines than its header says")
+	})
+}
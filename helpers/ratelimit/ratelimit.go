@@ -0,0 +1,188 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Limit is how often requests may be made to a single endpoint
+type Limit struct {
+	// Rate is the number of requests per second, or 0 for no limit
+	Rate float64
+
+	// Burst is the number of requests that may be made at once before Rate
+	// applies
+	Burst int
+}
+
+// PerSecond returns a limit of rate requests per second, allowing a second's
+// worth of them at once
+func PerSecond(rate float64) Limit {
+	return Limit{Rate: rate, Burst: int(math.Max(1, math.Ceil(rate)))}
+}
+
+// Limits has the limit for each provider, like "docker", and the one used for
+// providers without their own
+type Limits struct {
+	Default    Limit
+	ByProvider map[string]Limit
+}
+
+// For returns the limit for a provider
+func (l Limits) For(provider string) Limit {
+	if limit, ok := l.ByProvider[provider]; ok {
+		return limit
+	}
+	return l.Default
+}
+
+// ParseLimits returns limits of rate requests per second for every provider,
+// overridden by the given settings, each like "docker=5"
+func ParseLimits(rate float64, settings []string) (Limits, error) {
+	if rate < 0 {
+		return Limits{}, fmt.Errorf("rate limit must not be negative, got %v", rate)
+	}
+
+	limits := Limits{Default: PerSecond(rate), ByProvider: map[string]Limit{}}
+	for _, setting := range settings {
+		parts := strings.SplitN(setting, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return Limits{}, fmt.Errorf("%q is not a rate limit like docker=5", setting)
+		}
+
+		providerRate, err := strconv.ParseFloat(parts[1], 64)
+		if err != nil || providerRate < 0 {
+			return Limits{}, fmt.Errorf("%q is not a rate limit like docker=5", setting)
+		}
+
+		limits.ByProvider[parts[0]] = PerSecond(providerRate)
+	}
+
+	return limits, nil
+}
+
+// Limiter is a token bucket. It holds Burst tokens, and is refilled at Rate
+// tokens per second. Every request takes a token, waiting for one if the
+// bucket is empty.
+type Limiter struct {
+	limit Limit
+
+	lock   sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// NewLimiter returns a full limiter for the limit
+func NewLimiter(limit Limit) *Limiter {
+	if limit.Burst < 1 {
+		limit.Burst = 1
+	}
+	return &Limiter{limit: limit, tokens: float64(limit.Burst)}
+}
+
+// Wait blocks until a request may be made. A nil limiter never blocks.
+func (l *Limiter) Wait() {
+	if l == nil {
+		return
+	}
+
+	if delay := l.reserve(time.Now()); delay > 0 {
+		time.Sleep(delay)
+	}
+}
+
+// reserve takes a token, and returns how long to wait until it may be used.
+// Tokens may be taken before they're refilled, so that waiting requests are
+// spaced out in the order they arrived.
+func (l *Limiter) reserve(now time.Time) time.Duration {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if !l.last.IsZero() {
+		refilled := now.Sub(l.last).Seconds() * l.limit.Rate
+		l.tokens = math.Min(float64(l.limit.Burst), l.tokens+refilled)
+	}
+	l.last = now
+
+	l.tokens--
+	if l.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-l.tokens / l.limit.Rate * float64(time.Second))
+}
+
+// Registry shares a limiter for each endpoint of a provider, so that every
+// resource talking to the same endpoint is limited together
+type Registry struct {
+	lock     sync.Mutex
+	limits   Limits
+	limiters map[string]*Limiter
+}
+
+// NewRegistry returns a registry using the limits
+func NewRegistry(limits Limits) *Registry {
+	return &Registry{limits: limits, limiters: map[string]*Limiter{}}
+}
+
+// For returns the limiter for the endpoint of a provider, or nil if the
+// provider isn't limited
+func (r *Registry) For(provider, endpoint string) *Limiter {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	limit := r.limits.For(provider)
+	if limit.Rate <= 0 {
+		return nil
+	}
+
+	key := provider + " " + endpoint
+	limiter, ok := r.limiters[key]
+	if !ok {
+		limiter = NewLimiter(limit)
+		r.limiters[key] = limiter
+	}
+	return limiter
+}
+
+// Configure replaces the limits of the registry. Limiters already handed out
+// keep the limit they were made with.
+func (r *Registry) Configure(limits Limits) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.limits = limits
+	r.limiters = map[string]*Limiter{}
+}
+
+// shared is used by resources, which are prepared without a context to carry
+// their limits in
+var shared = NewRegistry(Limits{})
+
+// Configure sets the limits used by every resource in the process
+func Configure(limits Limits) {
+	shared.Configure(limits)
+}
+
+// Wait blocks until a request may be made to the endpoint of a provider,
+// according to the limits set with Configure
+func Wait(provider, endpoint string) {
+	shared.For(provider, endpoint).Wait()
+}
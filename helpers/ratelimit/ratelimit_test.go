@@ -0,0 +1,100 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimit_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/asteris-llc/converge/helpers/ratelimit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLimits(t *testing.T) {
+	t.Parallel()
+
+	t.Run("default and providers", func(t *testing.T) {
+		limits, err := ratelimit.ParseLimits(10, []string{"docker=2.5", "http=0"})
+		require.NoError(t, err)
+
+		assert.Equal(t, ratelimit.Limit{Rate: 10, Burst: 10}, limits.For("other"))
+		assert.Equal(t, ratelimit.Limit{Rate: 2.5, Burst: 3}, limits.For("docker"))
+		assert.Equal(t, ratelimit.Limit{Rate: 0, Burst: 1}, limits.For("http"))
+	})
+
+	t.Run("bad setting", func(t *testing.T) {
+		_, err := ratelimit.ParseLimits(0, []string{"docker"})
+		assert.EqualError(t, err, `"docker" is not a rate limit like docker=5`)
+	})
+
+	t.Run("bad rate", func(t *testing.T) {
+		_, err := ratelimit.ParseLimits(0, []string{"docker=fast"})
+		assert.EqualError(t, err, `"docker=fast" is not a rate limit like docker=5`)
+	})
+
+	t.Run("negative", func(t *testing.T) {
+		_, err := ratelimit.ParseLimits(-1, nil)
+		assert.EqualError(t, err, "rate limit must not be negative, got -1")
+	})
+}
+
+func TestLimiterWait(t *testing.T) {
+	t.Parallel()
+
+	t.Run("burst", func(t *testing.T) {
+		limiter := ratelimit.NewLimiter(ratelimit.Limit{Rate: 1, Burst: 3})
+
+		start := time.Now()
+		for i := 0; i < 3; i++ {
+			limiter.Wait()
+		}
+		assert.True(t, time.Since(start) < 500*time.Millisecond, "a full bucket should not wait")
+	})
+
+	t.Run("rate", func(t *testing.T) {
+		limiter := ratelimit.NewLimiter(ratelimit.Limit{Rate: 50, Burst: 1})
+
+		start := time.Now()
+		for i := 0; i < 3; i++ {
+			limiter.Wait()
+		}
+		// the first request takes the only token, and the next two wait 20ms
+		// each for theirs
+		assert.True(t, time.Since(start) >= 40*time.Millisecond, "requests over the burst should wait")
+	})
+
+	t.Run("nil", func(t *testing.T) {
+		var limiter *ratelimit.Limiter
+		assert.NotPanics(t, limiter.Wait)
+	})
+}
+
+func TestRegistryFor(t *testing.T) {
+	t.Parallel()
+
+	registry := ratelimit.NewRegistry(ratelimit.Limits{
+		ByProvider: map[string]ratelimit.Limit{"docker": ratelimit.PerSecond(5)},
+	})
+
+	t.Run("shared by endpoint", func(t *testing.T) {
+		assert.True(t, registry.For("docker", "unix:///var/run/docker.sock") == registry.For("docker", "unix:///var/run/docker.sock"))
+		assert.False(t, registry.For("docker", "unix:///var/run/docker.sock") == registry.For("docker", "tcp://remote:2375"))
+	})
+
+	t.Run("unlimited", func(t *testing.T) {
+		assert.Nil(t, registry.For("http", "example.com"))
+	})
+}
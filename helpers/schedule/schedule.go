@@ -0,0 +1,187 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxSearch bounds how far ahead Next looks for a window to open
+const maxSearch = 366 * 24 * time.Hour
+
+// Window is a period of time that starts whenever a cron expression matches
+// and lasts for Duration
+type Window struct {
+	text     string
+	fields   [5]field
+	Duration time.Duration
+	Location *time.Location
+}
+
+// field is the set of values a cron field matches. restricted is false if the
+// field was *, which matters for the day of month and day of week.
+type field struct {
+	values     map[int]bool
+	restricted bool
+}
+
+var bounds = [5]struct {
+	name     string
+	min, max int
+}{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 7},
+}
+
+// Parse parses a window starting at the times matched by a cron expression in
+// the usual five fields (minute, hour, day of month, month and day of week),
+// in the given location. Each field is *, a number, a range like 1-5, or a
+// comma-separated list of these, any of which may have a step like */15. As
+// with cron, a day of week of 7 is Sunday, and if both the day of month and
+// day of week are restricted a day matching either one matches.
+func Parse(expr string, duration time.Duration, loc *time.Location) (*Window, error) {
+	if duration <= 0 {
+		return nil, fmt.Errorf("window duration must be positive, got %s", duration)
+	}
+	if loc == nil {
+		loc = time.Local
+	}
+
+	parts := strings.Fields(expr)
+	if len(parts) != len(bounds) {
+		return nil, fmt.Errorf("%q must have %d fields, got %d", expr, len(bounds), len(parts))
+	}
+
+	w := &Window{text: strings.Join(parts, " "), Duration: duration, Location: loc}
+	for i, part := range parts {
+		f, err := parseField(part, bounds[i].min, bounds[i].max)
+		if err != nil {
+			return nil, fmt.Errorf("%q: %s %s", expr, bounds[i].name, err)
+		}
+		w.fields[i] = f
+	}
+
+	// Sunday can be written as 0 or 7
+	if w.fields[4].values[7] {
+		w.fields[4].values[0] = true
+	}
+
+	return w, nil
+}
+
+// Open returns whether the window is open at t
+func (w *Window) Open(t time.Time) bool {
+	t = t.In(w.Location).Truncate(time.Minute)
+	for start := t; t.Sub(start) < w.Duration; start = start.Add(-time.Minute) {
+		if w.matches(start) {
+			return true
+		}
+	}
+	return false
+}
+
+// Next returns the next time after t the window opens. It returns the zero
+// time if the window doesn't open within a year.
+func (w *Window) Next(t time.Time) time.Time {
+	t = t.In(w.Location).Truncate(time.Minute)
+	for next := t.Add(time.Minute); next.Sub(t) <= maxSearch; next = next.Add(time.Minute) {
+		if w.matches(next) {
+			return next
+		}
+	}
+	return time.Time{}
+}
+
+func (w *Window) String() string {
+	return fmt.Sprintf("%s for %s (%s)", w.text, w.Duration, w.Location)
+}
+
+func (w *Window) matches(t time.Time) bool {
+	if !w.fields[0].values[t.Minute()] || !w.fields[1].values[t.Hour()] || !w.fields[3].values[int(t.Month())] {
+		return false
+	}
+
+	dom, dow := w.fields[2], w.fields[4]
+	domMatch, dowMatch := dom.values[t.Day()], dow.values[int(t.Weekday())]
+	if dom.restricted && dow.restricted {
+		return domMatch || dowMatch
+	}
+	return domMatch && dowMatch
+}
+
+func parseField(text string, min, max int) (field, error) {
+	f := field{values: map[int]bool{}, restricted: !strings.HasPrefix(text, "*")}
+
+	for _, item := range strings.Split(text, ",") {
+		step := 1
+		if i := strings.Index(item, "/"); i >= 0 {
+			n, err := strconv.Atoi(item[i+1:])
+			if err != nil || n <= 0 {
+				return f, fmt.Errorf("has an invalid step in %q", item)
+			}
+			step = n
+			item = item[:i]
+		}
+
+		lo, hi := min, max
+		switch {
+		case item == "*":
+		case strings.Contains(item, "-"):
+			parts := strings.SplitN(item, "-", 2)
+			var err error
+			if lo, err = parseValue(parts[0], min, max); err != nil {
+				return f, err
+			}
+			if hi, err = parseValue(parts[1], min, max); err != nil {
+				return f, err
+			}
+			if lo > hi {
+				return f, fmt.Errorf("has a backwards range %q", item)
+			}
+		default:
+			n, err := parseValue(item, min, max)
+			if err != nil {
+				return f, err
+			}
+			lo, hi = n, n
+			if step > 1 {
+				hi = max
+			}
+		}
+
+		for n := lo; n <= hi; n += step {
+			f.values[n] = true
+		}
+	}
+
+	return f, nil
+}
+
+func parseValue(text string, min, max int) (int, error) {
+	n, err := strconv.Atoi(text)
+	if err != nil {
+		return 0, fmt.Errorf("has an invalid value %q", text)
+	}
+	if n < min || n > max {
+		return 0, fmt.Errorf("value %d is not between %d and %d", n, min, max)
+	}
+	return n, nil
+}
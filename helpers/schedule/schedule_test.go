@@ -0,0 +1,117 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedule_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/asteris-llc/converge/helpers/schedule"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	for _, expr := range []string{"* * * * *", "0 2 * * 6", "*/15 1-5 1,15 * 1-5", "30 22 * 1-12/2 7"} {
+		_, err := schedule.Parse(expr, time.Hour, time.UTC)
+		assert.NoError(t, err, expr)
+	}
+
+	for expr, msg := range map[string]string{
+		"0 2 * *":     `"0 2 * *" must have 5 fields, got 4`,
+		"60 * * * *":  `"60 * * * *": minute value 60 is not between 0 and 59`,
+		"0 5-2 * * *": `"0 5-2 * * *": hour has a backwards range "5-2"`,
+		"0 * x * *":   `"0 * x * *": day of month has an invalid value "x"`,
+		"*/0 * * * *": `"*/0 * * * *": minute has an invalid step in "*/0"`,
+	} {
+		_, err := schedule.Parse(expr, time.Hour, time.UTC)
+		assert.EqualError(t, err, msg, expr)
+	}
+
+	_, err := schedule.Parse("* * * * *", 0, time.UTC)
+	assert.EqualError(t, err, "window duration must be positive, got 0s")
+}
+
+func TestWindowOpen(t *testing.T) {
+	t.Parallel()
+
+	// Saturdays from 02:00 to 04:00
+	w, err := schedule.Parse("0 2 * * 6", 2*time.Hour, time.UTC)
+	require.NoError(t, err)
+
+	saturday := time.Date(2016, 10, 15, 0, 0, 0, 0, time.UTC)
+	assert.False(t, w.Open(saturday.Add(time.Hour+59*time.Minute)))
+	assert.True(t, w.Open(saturday.Add(2*time.Hour)))
+	assert.True(t, w.Open(saturday.Add(3*time.Hour+59*time.Minute)))
+	assert.False(t, w.Open(saturday.Add(4*time.Hour)))
+	assert.False(t, w.Open(saturday.Add(24*time.Hour+2*time.Hour)))
+
+	t.Run("timezone", func(t *testing.T) {
+		loc := time.FixedZone("CST", -6*60*60)
+		w, err := schedule.Parse("0 2 * * 6", 2*time.Hour, loc)
+		require.NoError(t, err)
+
+		assert.False(t, w.Open(saturday.Add(2*time.Hour)))
+		assert.True(t, w.Open(saturday.Add(8*time.Hour)))
+	})
+
+	t.Run("overnight", func(t *testing.T) {
+		w, err := schedule.Parse("0 22 * * *", 4*time.Hour, time.UTC)
+		require.NoError(t, err)
+
+		assert.True(t, w.Open(saturday.Add(time.Hour)))
+		assert.False(t, w.Open(saturday.Add(2*time.Hour)))
+	})
+
+	t.Run("day of month or week", func(t *testing.T) {
+		// the 1st of the month, and every Monday
+		w, err := schedule.Parse("0 0 1 * 1", time.Hour, time.UTC)
+		require.NoError(t, err)
+
+		assert.True(t, w.Open(time.Date(2016, 10, 1, 0, 30, 0, 0, time.UTC)))
+		assert.True(t, w.Open(time.Date(2016, 10, 17, 0, 30, 0, 0, time.UTC)))
+		assert.False(t, w.Open(saturday.Add(30*time.Minute)))
+	})
+}
+
+func TestWindowNext(t *testing.T) {
+	t.Parallel()
+
+	w, err := schedule.Parse("0 2 * * 0", time.Hour, time.UTC)
+	require.NoError(t, err)
+
+	assert.Equal(
+		t,
+		time.Date(2016, 10, 16, 2, 0, 0, 0, time.UTC),
+		w.Next(time.Date(2016, 10, 15, 12, 34, 56, 0, time.UTC)),
+	)
+
+	// Sunday may also be written as 7
+	w, err = schedule.Parse("0 2 * * 7", time.Hour, time.UTC)
+	require.NoError(t, err)
+	assert.Equal(
+		t,
+		time.Date(2016, 10, 16, 2, 0, 0, 0, time.UTC),
+		w.Next(time.Date(2016, 10, 15, 12, 34, 56, 0, time.UTC)),
+	)
+
+	t.Run("never", func(t *testing.T) {
+		w, err := schedule.Parse("0 0 31 2 *", time.Hour, time.UTC)
+		require.NoError(t, err)
+		assert.True(t, w.Next(time.Now()).IsZero())
+	})
+}
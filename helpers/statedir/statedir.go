@@ -0,0 +1,70 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+
+// Package statedir names the state converge keeps for each module on the
+// system it applies to, such as generated values, throttle stamps and backups.
+// State is grouped by module so that nodes with the same ID in different
+// modules, like `root/value.random.password`, don't share it.
+package statedir
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"path/filepath"
+	"strings"
+)
+
+// Locator is implemented by values that know the location of the module a
+// node was loaded from. Renderers passed to Prepare implement it.
+type Locator interface {
+	ModuleLocation() string
+}
+
+// For returns the Key of the module located by v if it is a Locator, or an
+// empty key otherwise
+func For(v interface{}) string {
+	if locator, ok := v.(Locator); ok {
+		return Key(locator.ModuleLocation())
+	}
+	return ""
+}
+
+// Key returns the name the state of the module at location is kept under. The
+// location is hashed, since it may be a URL. Local paths are made absolute
+// first, so that a module keeps its state however the path to it is written.
+// The key is empty if location is, so that joining it to a directory leaves the
+// directory as it is.
+func Key(location string) string {
+	if location == "" {
+		return ""
+	}
+
+	hash := sha256.Sum256([]byte(normalize(location)))
+	return hex.EncodeToString(hash[:])
+}
+
+// normalize makes local paths absolute
+func normalize(location string) string {
+	local := strings.TrimPrefix(location, "file://")
+	if strings.Contains(local, "://") {
+		return location
+	}
+
+	abs, err := filepath.Abs(local)
+	if err != nil {
+		return location
+	}
+	return "file://" + abs
+}
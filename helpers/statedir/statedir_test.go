@@ -0,0 +1,63 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+
+package statedir_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/statedir"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type locator string
+
+func (l locator) ModuleLocation() string { return string(l) }
+
+func TestKey(t *testing.T) {
+	t.Parallel()
+
+	t.Run("differs by module", func(t *testing.T) {
+		assert.NotEqual(t, statedir.Key("/etc/converge/a.hcl"), statedir.Key("/etc/converge/b.hcl"))
+	})
+
+	t.Run("same for relative and absolute paths", func(t *testing.T) {
+		wd, err := os.Getwd()
+		require.NoError(t, err)
+
+		abs := filepath.Join(wd, "a.hcl")
+		assert.Equal(t, statedir.Key(abs), statedir.Key("a.hcl"))
+		assert.Equal(t, statedir.Key(abs), statedir.Key("file://"+abs))
+	})
+
+	t.Run("url", func(t *testing.T) {
+		assert.NotEqual(t, statedir.Key("https://example.com/a.hcl"), statedir.Key("https://example.com/b.hcl"))
+		assert.Len(t, statedir.Key("https://example.com/a.hcl"), 64)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		assert.Equal(t, "", statedir.Key(""))
+	})
+}
+
+func TestFor(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, statedir.Key("/a.hcl"), statedir.For(locator("/a.hcl")))
+	assert.Equal(t, "", statedir.For(struct{}{}))
+}
@@ -0,0 +1,123 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package version
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// describeSuffix is added by git describe to versions built after a tag, as
+// in "0.3.0-12-gabc1234-dirty"
+var describeSuffix = regexp.MustCompile(`-\d+-g[0-9a-f]+(-dirty)?$|-dirty$`)
+
+// Version is a release version like "0.3.0" or "0.3.0-beta3"
+type Version struct {
+	Parts      []int
+	Prerelease string
+}
+
+// Parse reads a version. A leading "v" and anything added by git describe
+// are ignored.
+func Parse(raw string) (*Version, error) {
+	trimmed := describeSuffix.ReplaceAllString(strings.TrimPrefix(strings.TrimSpace(raw), "v"), "")
+
+	release := trimmed
+	out := new(Version)
+	if i := strings.Index(trimmed, "-"); i >= 0 {
+		release, out.Prerelease = trimmed[:i], trimmed[i+1:]
+	}
+
+	for _, part := range strings.Split(release, ".") {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("%q is not a version like 0.3.0", raw)
+		}
+		out.Parts = append(out.Parts, n)
+	}
+
+	return out, nil
+}
+
+// Compare returns -1 if v is older than other, 1 if it is newer, and 0 if
+// they are the same. Missing parts count as 0, and a prerelease is older than
+// its release.
+func (v *Version) Compare(other *Version) int {
+	for i := 0; i < len(v.Parts) || i < len(other.Parts); i++ {
+		a, b := part(v.Parts, i), part(other.Parts, i)
+		if a != b {
+			return sign(a - b)
+		}
+	}
+
+	switch {
+	case v.Prerelease == other.Prerelease:
+		return 0
+	case v.Prerelease == "":
+		return 1
+	case other.Prerelease == "":
+		return -1
+	default:
+		return sign(strings.Compare(v.Prerelease, other.Prerelease))
+	}
+}
+
+func (v *Version) String() string {
+	var parts []string
+	for _, part := range v.Parts {
+		parts = append(parts, strconv.Itoa(part))
+	}
+
+	out := strings.Join(parts, ".")
+	if v.Prerelease != "" {
+		out += "-" + v.Prerelease
+	}
+	return out
+}
+
+// AtLeast tests whether the version current is min or newer
+func AtLeast(current, min string) (bool, error) {
+	c, err := Parse(current)
+	if err != nil {
+		return false, err
+	}
+
+	m, err := Parse(min)
+	if err != nil {
+		return false, err
+	}
+
+	return c.Compare(m) >= 0, nil
+}
+
+func part(parts []int, i int) int {
+	if i < len(parts) {
+		return parts[i]
+	}
+	return 0
+}
+
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package version_test
+
+import (
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/version"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	for raw, expected := range map[string]string{
+		"0.3.0":                   "0.3.0",
+		"v0.3.0":                  "0.3.0",
+		"0.3.0-beta3":             "0.3.0-beta3",
+		"0.3.0-beta3-12-gabc1234": "0.3.0-beta3",
+		"0.3.0-12-gabc1234-dirty": "0.3.0",
+		"1.2":                     "1.2",
+	} {
+		v, err := version.Parse(raw)
+		require.NoError(t, err, raw)
+		assert.Equal(t, expected, v.String(), raw)
+	}
+
+	_, err := version.Parse("unset")
+	assert.EqualError(t, err, `"unset" is not a version like 0.3.0`)
+}
+
+func TestAtLeast(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range []struct {
+		current, min string
+		expected     bool
+	}{
+		{"0.3.0", "0.3.0", true},
+		{"0.3.1", "0.3.0", true},
+		{"0.10.0", "0.9.0", true},
+		{"0.3", "0.3.0", true},
+		{"0.2.9", "0.3.0", false},
+		{"0.3.0-beta3", "0.3.0", false},
+		{"0.3.0", "0.3.0-beta3", true},
+		{"0.3.0-beta3", "0.3.0-beta2", true},
+		{"0.3.0-beta2-4-gabc1234", "0.3.0-beta3", false},
+	} {
+		ok, err := version.AtLeast(test.current, test.min)
+		require.NoError(t, err)
+		assert.Equal(t, test.expected, ok, "%s >= %s", test.current, test.min)
+	}
+}
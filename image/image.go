@@ -0,0 +1,139 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package image
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/pkg/errors"
+)
+
+// idle keeps the build container running so that commands can be executed in
+// it. It only needs a POSIX shell, which task resources need anyway.
+const idle = "trap 'exit 0' TERM; while :; do sleep 1; done"
+
+// Container is a running container that modules are applied in before it is
+// committed to an image
+type Container struct {
+	Exec exec.Executor
+	Name string
+}
+
+// Start creates and starts a build container from the base image
+func Start(e exec.Executor, base, name string) (*Container, error) {
+	err := exec.Run(
+		e,
+		"docker", "run", "--detach",
+		"--name", name,
+		"--entrypoint", "/bin/sh",
+		base,
+		"-c", idle,
+	)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not start build container from %s", base)
+	}
+
+	return &Container{Exec: e, Name: name}, nil
+}
+
+// Module returns the source of a module that applies source inside the
+// container. Each of params is declared as a param in the wrapper and passed
+// through to the module. The container is set as the module's target, so
+// resources in it that can't run in a target, like file.content, fail to
+// prepare instead of changing the build host.
+func (c *Container) Module(source string, params []string) []byte {
+	var buf bytes.Buffer
+
+	sorted := append([]string(nil), params...)
+	sort.Strings(sorted)
+
+	for _, param := range sorted {
+		fmt.Fprintf(&buf, "param %s {}\n\n", strconv.Quote(param))
+	}
+
+	fmt.Fprintf(&buf, "module %s \"image\" {\n", strconv.Quote(source))
+	if len(sorted) > 0 {
+		buf.WriteString("  params = {\n")
+		for _, param := range sorted {
+			fmt.Fprintf(&buf, "    %s = \"{{param `%s`}}\"\n", strconv.Quote(param), param)
+		}
+		buf.WriteString("  }\n\n")
+	}
+	fmt.Fprintf(&buf, "  target {\n    docker = %s\n  }\n}\n", strconv.Quote(c.Name))
+
+	return buf.Bytes()
+}
+
+// Commit creates an image from the container with the given labels and
+// returns its ID. If tag is empty the image is left untagged.
+func (c *Container) Commit(tag string, labels map[string]string) (string, error) {
+	args := []string{"commit"}
+
+	var keys []string
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		args = append(args, "--change", fmt.Sprintf("LABEL %s=%s", strconv.Quote(key), strconv.Quote(labels[key])))
+	}
+
+	args = append(args, c.Name)
+	if tag != "" {
+		args = append(args, tag)
+	}
+
+	out, err := exec.Read(c.Exec, "docker", args...)
+	if err != nil {
+		return "", errors.Wrapf(err, "could not commit %s", c.Name)
+	}
+
+	return strings.TrimSpace(out), nil
+}
+
+// Save writes the image to a tar archive at path
+func Save(e exec.Executor, image, path string) error {
+	if err := exec.Run(e, "docker", "save", "--output", path, image); err != nil {
+		return errors.Wrapf(err, "could not save %s", image)
+	}
+	return nil
+}
+
+// Remove stops and removes the container
+func (c *Container) Remove() error {
+	if err := exec.Run(c.Exec, "docker", "rm", "--force", c.Name); err != nil {
+		return errors.Wrapf(err, "could not remove %s", c.Name)
+	}
+	return nil
+}
+
+// ParseLabels parses labels in the form "key=value"
+func ParseLabels(in []string) (map[string]string, error) {
+	labels := map[string]string{}
+	for _, label := range in {
+		parts := strings.SplitN(label, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("label %q must be in the form key=value", label)
+		}
+		labels[parts[0]] = parts[1]
+	}
+	return labels, nil
+}
@@ -0,0 +1,122 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package image_test
+
+import (
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/fakeexec"
+	"github.com/asteris-llc/converge/image"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestContainer tests the lifecycle of a build container
+func TestContainer(t *testing.T) {
+	t.Parallel()
+
+	fake := fakeexec.New()
+	fake.Expect(
+		"docker", "run", "--detach",
+		"--name", "build",
+		"--entrypoint", "/bin/sh",
+		"centos:7",
+		"-c", "trap 'exit 0' TERM; while :; do sleep 1; done",
+	).Return("abc123\n", 0)
+	fake.Expect(
+		"docker", "commit",
+		"--change", `LABEL "maintainer"="ops"`,
+		"--change", `LABEL "version"="1.0"`,
+		"build", "app:latest",
+	).Return("sha256:def456\n", 0)
+	fake.Expect("docker", "rm", "--force", "build")
+
+	container, err := image.Start(fake, "centos:7", "build")
+	require.NoError(t, err)
+
+	id, err := container.Commit("app:latest", map[string]string{"version": "1.0", "maintainer": "ops"})
+	require.NoError(t, err)
+	assert.Equal(t, "sha256:def456", id)
+
+	assert.NoError(t, container.Remove())
+	fake.AssertExpectations(t)
+}
+
+// TestStartFailure tests that a missing base image is reported
+func TestStartFailure(t *testing.T) {
+	t.Parallel()
+
+	fake := fakeexec.New()
+	fake.Expect(
+		"docker", "run", "--detach",
+		"--name", "build",
+		"--entrypoint", "/bin/sh",
+		"nope",
+		"-c", "trap 'exit 0' TERM; while :; do sleep 1; done",
+	).Return("", 125).Stderr("Unable to find image 'nope:latest' locally")
+
+	_, err := image.Start(fake, "nope", "build")
+	assert.EqualError(t, err, "could not start build container from nope: docker: exit status 125: Unable to find image 'nope:latest' locally")
+}
+
+// TestModule tests generating the module applied in the container
+func TestModule(t *testing.T) {
+	t.Parallel()
+
+	container := &image.Container{Name: "build"}
+
+	t.Run("no params", func(t *testing.T) {
+		assert.Equal(
+			t,
+			`module "/src/app.hcl" "image" {
+  target {
+    docker = "build"
+  }
+}
+`,
+			string(container.Module("/src/app.hcl", nil)),
+		)
+	})
+
+	t.Run("params", func(t *testing.T) {
+		assert.Equal(
+			t,
+			"param \"user\" {}\n\n"+
+				"param \"version\" {}\n\n"+
+				"module \"/src/app.hcl\" \"image\" {\n"+
+				"  params = {\n"+
+				"    \"user\" = \"{{param `user`}}\"\n"+
+				"    \"version\" = \"{{param `version`}}\"\n"+
+				"  }\n\n"+
+				"  target {\n"+
+				"    docker = \"build\"\n"+
+				"  }\n"+
+				"}\n",
+			string(container.Module("/src/app.hcl", []string{"version", "user"})),
+		)
+	})
+}
+
+// TestParseLabels tests parsing labels from the command line
+func TestParseLabels(t *testing.T) {
+	t.Parallel()
+
+	labels, err := image.ParseLabels([]string{"version=1.0", "description=a=b"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"version": "1.0", "description": "a=b"}, labels)
+
+	_, err = image.ParseLabels([]string{"version"})
+	assert.EqualError(t, err, `label "version" must be in the form key=value`)
+}
@@ -17,6 +17,7 @@ package load
 import (
 	"context"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"sort"
 	"sync"
@@ -31,7 +32,18 @@ import (
 	"github.com/pkg/errors"
 )
 
-type dependencyGenerator func(g *graph.Graph, id string, node *parse.Node) ([]string, error)
+type dependencyGenerator func(g *graph.Graph, id string, node *parse.Node) ([]dependency, error)
+
+// dependency is an edge to add from a node, and what in the node needs it
+type dependency struct {
+	ID     string
+	Reason string
+
+	// Ref is the node referred to by an implicit dependency. It differs from
+	// ID when a lookup reaches into a module, since the edge is made to the
+	// module instead.
+	Ref string
+}
 
 // ResolveDependencies examines the strings and depdendencies at each vertex of
 // the graph and creates edges to fit them
@@ -41,6 +53,12 @@ func ResolveDependencies(ctx context.Context, g *graph.Graph) (*graph.Graph, err
 
 	groupLock := new(sync.Mutex)
 	groupMap := make(map[string]struct{})
+
+	edgeLock := new(sync.Mutex)
+	edges := make(map[[2]string]string)
+
+	trace := preprocessor.TraceWriter(ctx)
+
 	g, err := g.Transform(ctx, func(meta *node.Node, out *graph.Graph) error {
 		if graph.IsRoot(meta.ID) { // skip root
 			return nil
@@ -51,7 +69,18 @@ func ResolveDependencies(ctx context.Context, g *graph.Graph) (*graph.Graph, err
 			return fmt.Errorf("ResolveDependencies can only be used on Graphs of *parse.Node. I got %T", meta.Value())
 		}
 
-		depGenerators := []dependencyGenerator{getDepends, getParams, getXrefs}
+		depGenerators := []dependencyGenerator{
+			getDepends,
+			getParams,
+			func(g *graph.Graph, id string, node *parse.Node) ([]dependency, error) {
+				return getXrefs(g, id, node, trace)
+			},
+		}
+
+		ignored, err := ignoredDepends(g, meta.ID, node, trace)
+		if err != nil {
+			return err
+		}
 
 		// we have dependencies from various sources, but they're always IDs, so we
 		// can connect them pretty easily
@@ -61,10 +90,21 @@ func ResolveDependencies(ctx context.Context, g *graph.Graph) (*graph.Graph, err
 				return err
 			}
 			for _, dep := range deps {
-				if err := out.SafeConnect(meta.ID, dep); err != nil {
+				if _, ok := ignored[dep.Ref]; ok && dep.Ref != "" {
+					continue
+				}
+
+				edgeLock.Lock()
+				if err := out.SafeConnect(meta.ID, dep.ID); err != nil {
+					if path := out.Path(dep.ID, meta.ID); path != nil {
+						err = newCycleError(out, append([]string{meta.ID}, path...), edges, dep.Reason)
+					}
+					edgeLock.Unlock()
 					logger.Error(err)
 					return err
 				}
+				edges[[2]string{meta.ID, dep.ID}] = dep.Reason
+				edgeLock.Unlock()
 			}
 		}
 
@@ -78,93 +118,272 @@ func ResolveDependencies(ctx context.Context, g *graph.Graph) (*graph.Graph, err
 		return nil
 	})
 
+	if w := edgeWriter(ctx); w != nil {
+		writeEdges(w, edges)
+	}
+
 	for group := range groupMap {
 		groupDeps(ctx, g, group)
 	}
 	return g, err
 }
 
-func getDepends(g *graph.Graph, id string, node *parse.Node) ([]string, error) {
+// CycleError is returned when the dependencies in a module form a cycle. It
+// has every edge in the cycle, in order, with what caused it.
+type CycleError struct {
+	Edges []Edge
+}
+
+// Edge is a dependency of one node on another
+type Edge struct {
+	Source string
+	Target string
+
+	// Reason is what in the source node needs the target, such as a lookup,
+	// along with where it is set in the module if that is known
+	Reason string
+}
+
+func (c *CycleError) Error() string {
+	if len(c.Edges) == 0 {
+		return "dependency cycle"
+	}
+
+	msg := "dependency cycle: " + c.Edges[0].Source
+	for _, edge := range c.Edges {
+		msg += " -> " + edge.Target
+		if edge.Reason != "" {
+			msg += " (" + edge.Reason + ")"
+		}
+	}
+	return msg
+}
+
+// newCycleError describes the cycle along path, which starts and ends at the
+// same node. The first edge is the one that could not be added.
+func newCycleError(g *graph.Graph, path []string, reasons map[[2]string]string, first string) *CycleError {
+	cycle := new(CycleError)
+	for i := 0; i+1 < len(path); i++ {
+		edge := Edge{Source: path[i], Target: path[i+1]}
+		if i == 0 {
+			edge.Reason = first
+		} else if reason, ok := reasons[[2]string{edge.Source, edge.Target}]; ok {
+			edge.Reason = reason
+		} else if graph.ParentID(edge.Target) == edge.Source {
+			edge.Reason = "contains"
+		}
+		cycle.Edges = append(cycle.Edges, edge)
+	}
+	return cycle
+}
+
+type edgeWriterKey struct{}
+
+// WithEdgeWriter returns a context that makes ResolveDependencies write every
+// edge it adds to w, along with why it was added. This is mostly useful for
+// finding out where an unexpected dependency comes from.
+func WithEdgeWriter(ctx context.Context, w io.Writer) context.Context {
+	return context.WithValue(ctx, edgeWriterKey{}, w)
+}
+
+func edgeWriter(ctx context.Context) io.Writer {
+	w, _ := ctx.Value(edgeWriterKey{}).(io.Writer)
+	return w
+}
+
+func writeEdges(w io.Writer, edges map[[2]string]string) {
+	var lines []string
+	for edge, reason := range edges {
+		lines = append(lines, fmt.Sprintf("%s -> %s: %s\n", edge[0], edge[1], reason))
+	}
+	sort.Strings(lines)
+
+	for _, line := range lines {
+		io.WriteString(w, line)
+	}
+}
+
+// describe says what in a node caused a dependency, and where
+func describe(node *parse.Node, key, what string) string {
+	if pos := node.Position(key); pos != "" {
+		return fmt.Sprintf("%s in %s at %s", what, key, pos)
+	}
+	return fmt.Sprintf("%s in %s", what, key)
+}
+
+func getDepends(g *graph.Graph, id string, node *parse.Node) ([]dependency, error) {
 	deps, err := node.GetStringSlice("depends")
 	switch err {
 	case parse.ErrNotFound:
-		return []string{}, nil
+		return []dependency{}, nil
 	case nil:
-		for idx, dep := range deps {
-			if ancestor, ok := getNearestAncestor(g, id, dep); ok {
-				deps[idx] = ancestor
-			} else {
+		var out []dependency
+		for _, dep := range deps {
+			ancestor, ok := getNearestAncestor(g, id, dep)
+			if !ok {
 				return nil, fmt.Errorf("nonexistent vertices in edges: %s", dep)
 			}
+			out = append(out, dependency{
+				ID:     ancestor,
+				Reason: describe(node, "depends", fmt.Sprintf("`%s`", dep)),
+			})
 		}
-		return deps, nil
+		return out, nil
 	default:
 		return nil, err
 	}
 }
 
-func getParams(g *graph.Graph, id string, node *parse.Node) (out []string, err error) {
-	var nodeStrings []string
-	nodeStrings, err = node.GetStrings()
+func getParams(g *graph.Graph, id string, node *parse.Node) (out []dependency, err error) {
+	keys, err := node.Fields()
 	if err != nil {
 		return nil, err
 	}
 
-	type stub struct{}
-	language := extensions.MinimalLanguage()
-	language.On("param", extensions.RememberCalls(&out, ""))
-	language.On("paramList", extensions.RememberCalls(&out, []interface{}(nil)))
-	language.On("paramMap", extensions.RememberCalls(&out, map[string]interface{}(nil)))
+	stubs := map[string]interface{}{
+		"param":     "",
+		"paramList": []interface{}(nil),
+		"paramMap":  map[string]interface{}(nil),
+	}
 
-	for _, s := range nodeStrings {
-		useless := stub{}
-		tmpl, tmplErr := template.New("DependencyTemplate").Funcs(language.Funcs).Parse(s)
-		if tmplErr != nil {
-			return out, tmplErr
+	for _, key := range keys {
+		nodeStrings, err := node.GetStringsFor(key)
+		if err != nil {
+			return nil, err
 		}
-		tmpl.Execute(ioutil.Discard, &useless)
-	}
-	for idx, val := range out {
-		ancestor, found := getNearestAncestor(g, id, "param."+val)
-		if !found {
-			return out, fmt.Errorf("unknown parameter: param.%s", val)
+
+		ran, found, err := templateCalls(nodeStrings, stubs)
+		if err != nil {
+			return out, err
+		}
+
+		seen := make(map[string]struct{})
+		for i, val := range append(ran, found...) {
+			if _, ok := seen[val]; ok {
+				continue
+			}
+
+			ancestor, ok := getNearestAncestor(g, id, "param."+val)
+			if !ok {
+				if i >= len(ran) {
+					// only found in a branch that isn't taken
+					continue
+				}
+				return out, fmt.Errorf("unknown parameter: param.%s", val)
+			}
+
+			seen[val] = struct{}{}
+			out = append(out, dependency{
+				ID:     ancestor,
+				Ref:    ancestor,
+				Reason: describe(node, key, fmt.Sprintf("param `%s`", val)),
+			})
 		}
-		out[idx] = ancestor
 	}
-	return out, err
+	return out, nil
 }
 
-func getXrefs(g *graph.Graph, id string, node *parse.Node) (out []string, err error) {
-	var nodeStrings []string
-	var calls []string
-	nodeRefs := make(map[string]struct{})
-	nodeStrings, err = node.GetStrings()
+func getXrefs(g *graph.Graph, id string, node *parse.Node, trace io.Writer) (out []dependency, err error) {
+	keys, err := node.Fields()
 	if err != nil {
 		return nil, err
 	}
+
+	nodeRefs := make(map[string]struct{})
+	for _, key := range keys {
+		nodeStrings, err := node.GetStringsFor(key)
+		if err != nil {
+			return nil, err
+		}
+
+		ran, found, err := templateCalls(nodeStrings, map[string]interface{}{extensions.RefFuncName: 0})
+		if err != nil {
+			return out, err
+		}
+
+		for i, call := range append(ran, found...) {
+			vertex, terms, ok := preprocessor.VertexSplitTraverse(g, call, id, preprocessor.TraverseUntilModule, make(map[string]struct{}))
+			switch {
+			case ok:
+				vertex, _, _ = preprocessor.ModuleOutput(g, vertex, terms)
+			case i >= len(ran):
+				// only found in a branch that isn't taken
+				continue
+			default:
+				if trace != nil {
+					preprocessor.TraceVertexSplitTraverse(trace, g, call, id, preprocessor.TraverseUntilModule)
+				}
+				return []dependency{}, fmt.Errorf("dependency generator: unresolvable call to %s", call)
+			}
+			if _, ok := nodeRefs[vertex]; !ok {
+				nodeRefs[vertex] = struct{}{}
+				reason := describe(node, key, fmt.Sprintf("lookup `%s`", call))
+				out = append(out, dependency{ID: vertex, Ref: vertex, Reason: reason})
+				if peerVertex, ok := getPeerVertex(g, id, vertex); ok {
+					out = append(out, dependency{ID: peerVertex, Ref: vertex, Reason: reason})
+				}
+			}
+		}
+	}
+	return out, nil
+}
+
+// templateCalls runs each template with the given stub functions and returns
+// the first argument of every call made to them. It also returns the calls
+// found by reading the templates, which include calls in branches that
+// weren't taken.
+func templateCalls(templates []string, stubs map[string]interface{}) (ran, found []string, err error) {
 	language := extensions.MinimalLanguage()
-	language.On(extensions.RefFuncName, extensions.RememberCalls(&calls, 0))
-	for _, s := range nodeStrings {
+
+	var names []string
+	for name, stub := range stubs {
+		language.On(name, extensions.RememberCalls(&ran, stub))
+		names = append(names, name)
+	}
+
+	for _, s := range templates {
 		tmpl, tmplErr := template.New("DependencyTemplate").Funcs(language.Funcs).Parse(s)
 		if tmplErr != nil {
-			return out, tmplErr
+			return ran, found, tmplErr
 		}
 		tmpl.Execute(ioutil.Discard, &struct{}{})
+
+		found = append(found, extensions.FindCalls(tmpl, names...)...)
 	}
-	for _, call := range calls {
-		vertex, _, found := preprocessor.VertexSplitTraverse(g, call, id, preprocessor.TraverseUntilModule, make(map[string]struct{}))
-		if !found {
-			return []string{}, fmt.Errorf("dependency generator: unresolvable call to %s", call)
+
+	return ran, found, nil
+}
+
+// ignoredDepends resolves the references in a node's "ignore_depends" to the
+// IDs of the nodes they refer to. If trace is set, the search for a reference
+// that doesn't resolve is written to it.
+func ignoredDepends(g *graph.Graph, id string, node *parse.Node, trace io.Writer) (map[string]struct{}, error) {
+	refs, err := node.GetStringSlice("ignore_depends")
+	switch err {
+	case parse.ErrNotFound:
+		return nil, nil
+	case nil:
+	default:
+		return nil, err
+	}
+
+	ignored := make(map[string]struct{})
+	for _, ref := range refs {
+		if ancestor, ok := getNearestAncestor(g, id, ref); ok {
+			ignored[ancestor] = struct{}{}
+			continue
 		}
-		if _, ok := nodeRefs[vertex]; !ok {
-			nodeRefs[vertex] = struct{}{}
-			out = append(out, vertex)
-			if peerVertex, ok := getPeerVertex(g, id, vertex); ok {
-				out = append(out, peerVertex)
+
+		vertex, _, found := preprocessor.VertexSplitTraverse(g, ref, id, preprocessor.TraverseUntilModule, make(map[string]struct{}))
+		if !found {
+			if trace != nil {
+				preprocessor.TraceVertexSplitTraverse(trace, g, ref, id, preprocessor.TraverseUntilModule)
 			}
+			return nil, fmt.Errorf("ignore_depends: %s does not refer to a resource", ref)
 		}
+		ignored[vertex] = struct{}{}
 	}
-	return out, err
+	return ignored, nil
 }
 
 func getPeerVertex(g *graph.Graph, src, dst string) (string, bool) {
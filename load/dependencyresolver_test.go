@@ -15,13 +15,16 @@
 package load_test
 
 import (
+	"bytes"
 	"context"
+	"strings"
 	"testing"
 
 	"github.com/asteris-llc/converge/graph"
 	"github.com/asteris-llc/converge/helpers/logging"
 	"github.com/asteris-llc/converge/load"
 	"github.com/asteris-llc/converge/parse"
+	"github.com/asteris-llc/converge/render/preprocessor"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -71,6 +74,83 @@ func TestDependencyResolverBadDependency(t *testing.T) {
 	}
 }
 
+func TestDependencyResolverCycle(t *testing.T) {
+	defer logging.HideLogs(t)()
+
+	nodes, err := load.Nodes(context.Background(), "../samples/errors/cycle.hcl", false)
+	require.NoError(t, err)
+
+	var edges bytes.Buffer
+	ctx := load.WithEdgeWriter(context.Background(), &edges)
+
+	_, err = load.ResolveDependencies(ctx, nodes)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "dependency cycle: ")
+		assert.Contains(t, err.Error(), "root/task.b (lookup `task.b.status` in check at ../samples/errors/cycle.hcl:2:3)")
+		assert.Contains(t, err.Error(), "root/task.a (`task.a` in depends at ../samples/errors/cycle.hcl:9:3)")
+	}
+
+	// only the edge that didn't close the cycle was added
+	assert.Equal(t, 1, strings.Count(edges.String(), "\n"))
+}
+
+func TestDependencyResolverTraceRefs(t *testing.T) {
+	defer logging.HideLogs(t)()
+
+	nodes, err := load.Nodes(context.Background(), "../samples/errors/bad_lookup.hcl", false)
+	require.NoError(t, err)
+
+	var trace bytes.Buffer
+	ctx := preprocessor.WithTraceWriter(context.Background(), &trace)
+
+	_, err = load.ResolveDependencies(ctx, nodes)
+	assert.Error(t, err)
+
+	assert.Equal(
+		t,
+		"resolving \"task.query.nam.status.stdout\" from root/task.bad_lookup:\n"+
+			"  tried root/task.query.nam.status.stdout, root/task.query.nam.status, root/task.query.nam, root/task.query, root/task: no such node\n"+
+			"  moving up to root\n"+
+			"  stopped at root: stop condition met\n"+
+			"  not found\n",
+		trace.String(),
+	)
+}
+
+func TestDependencyResolverImplicit(t *testing.T) {
+	defer logging.HideLogs(t)()
+
+	nodes, err := load.Nodes(context.Background(), "../samples/ignoreDepends.hcl", false)
+	require.NoError(t, err)
+
+	resolved, err := load.ResolveDependencies(context.Background(), nodes)
+	require.NoError(t, err)
+
+	t.Run("lookups in branches", func(t *testing.T) {
+		assert.Contains(t, graph.Targets(resolved.DownEdges("root/task.summary")), "root/task.report")
+	})
+
+	t.Run("ignore_depends", func(t *testing.T) {
+		deps := graph.Targets(resolved.DownEdges("root/task.report"))
+		assert.Contains(t, deps, "root/param.verbose")
+		assert.NotContains(t, deps, "root/task.install")
+	})
+}
+
+func TestDependencyResolverModuleOutputs(t *testing.T) {
+	defer logging.HideLogs(t)()
+
+	nodes, err := load.Nodes(context.Background(), "../samples/moduleOutputs.hcl", false)
+	require.NoError(t, err)
+
+	resolved, err := load.ResolveDependencies(context.Background(), nodes)
+	require.NoError(t, err)
+
+	deps := graph.Targets(resolved.DownEdges("root/file.content.summary"))
+	assert.Contains(t, deps, "root/module.config/output.path")
+	assert.Contains(t, deps, "root/module.config")
+}
+
 func TestDependencyResolverResolvesParam(t *testing.T) {
 	defer logging.HideLogs(t)()
 
@@ -18,11 +18,14 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"io"
+	"strings"
 
 	"github.com/asteris-llc/converge/fetch"
 	"github.com/asteris-llc/converge/graph"
 	"github.com/asteris-llc/converge/graph/node"
 	"github.com/asteris-llc/converge/helpers/logging"
+	"github.com/asteris-llc/converge/helpers/version"
 	"github.com/asteris-llc/converge/keystore"
 	"github.com/asteris-llc/converge/parse"
 	"github.com/asteris-llc/converge/parse/preprocessor/switch"
@@ -39,15 +42,20 @@ func (s *source) String() string {
 	return fmt.Sprintf("%s (%s)", s.Source, s.Parent)
 }
 
-// Nodes loads and parses all resources referred to by the provided url
+// Nodes loads and parses all resources referred to by the provided url. A url
+// naming a local directory or glob loads every file in it into the same
+// module, in the order given by Source.Expand. Override files among them change
+// the fields of nodes defined in the other files.
 func Nodes(ctx context.Context, root string, verify bool) (*graph.Graph, error) {
-	logger := logging.GetLogger(ctx).WithField("function", "Nodes")
-
 	toLoad := []*source{{"root", root, root}}
 
 	out := graph.New()
 	out.Add(node.New("root", nil))
 
+	// defined has the position of each node added so far, so that a node
+	// defined twice in a module split across files can be reported
+	defined := make(map[string]string)
+
 	for len(toLoad) > 0 {
 		select {
 		case <-ctx.Done():
@@ -63,68 +71,247 @@ func Nodes(ctx context.Context, root string, verify bool) (*graph.Graph, error)
 			return nil, err
 		}
 
-		logger.WithField("url", url).Debug("fetching")
-		content, err := fetch.Any(ctx, url)
-		if err != nil {
-			return nil, errors.Wrap(err, url)
+		files := []string{url}
+		if _, ok := moduleContent(ctx, url); !ok {
+			files, err = getSource(ctx).Expand(url)
+			if err != nil {
+				return nil, errors.Wrap(err, url)
+			}
 		}
 
-		if verify {
-			signatureURL := url + ".asc"
-
-			logger.WithField("signatureUrl", signatureURL).Debug("fetching")
-			signature, sigErr := fetch.Any(ctx, signatureURL)
-			if sigErr != nil {
-				return nil, errors.Wrap(sigErr, signatureURL)
+		for _, file := range files {
+			if fetch.IsOverride(file) {
+				if err := overrideFile(ctx, current, file, verify, out); err != nil {
+					return out, err
+				}
+				continue
 			}
 
-			err = keystore.Default().CheckSignature(bytes.NewBuffer(content), bytes.NewBuffer(signature))
+			modules, err := loadFile(ctx, current, file, verify, out, defined)
 			if err != nil {
-				return nil, errors.Wrap(err, signatureURL)
+				return out, err
+			}
+			toLoad = append(toLoad, modules...)
+		}
+	}
+	return out, out.Validate()
+}
+
+// loadFile adds the resources in a single file to the graph, returning the
+// modules it refers to
+func loadFile(ctx context.Context, current *source, url string, verify bool, out *graph.Graph, defined map[string]string) ([]*source, error) {
+	content, mod, err := parseFile(ctx, url, verify)
+	if err != nil {
+		return nil, err
+	}
+	resources := mod.Resources
+
+	var modules []*source
+	for _, resource := range resources {
+		resource.File = strings.TrimPrefix(url, "file://")
+		if control.IsSwitchNode(resource) {
+			if _, err = expandSwitchMacro(content, current, resource, out); err != nil {
+				return nil, errors.Wrap(err, "unable to load resource")
 			}
+			continue
+		}
+		newID := graph.ID(current.Parent, resource.String())
+		pos := resource.Position("")
+		if first, ok := defined[newID]; ok {
+			return nil, fmt.Errorf("%s is defined twice, at %s and %s", newID, first, pos)
+		}
+		defined[newID] = pos
+
+		out.Add(withPosition(node.New(newID, resource), pos))
+		out.ConnectParent(current.Parent, newID)
+
+		if resource.IsModule() {
+			modules = append(
+				modules,
+				&source{
+					Parent:       newID,
+					ParentSource: url,
+					Source:       resource.Source(),
+				},
+			)
+		}
+	}
+	return modules, nil
+}
+
+// overrideFile changes the nodes already in the graph with the fields set in
+// an override file
+func overrideFile(ctx context.Context, current *source, url string, verify bool, out *graph.Graph) error {
+	_, mod, err := parseFile(ctx, url, verify)
+	if err != nil {
+		return err
+	}
+
+	w := overrideWriter(ctx)
+	for _, resource := range mod.Resources {
+		resource.File = strings.TrimPrefix(url, "file://")
+		id := graph.ID(current.Parent, resource.String())
+		if control.IsSwitchNode(resource) {
+			return fmt.Errorf("%s: switch blocks cannot be overridden", resource.Position(""))
+		}
+
+		meta, ok := out.Get(id)
+		if !ok {
+			return fmt.Errorf("%s: %s overrides a node that isn't defined", resource.Position(""), id)
+		}
+		base, ok := meta.Value().(*parse.Node)
+		if !ok {
+			return fmt.Errorf("%s: %s cannot be overridden", resource.Position(""), id)
+		}
+
+		was := make(map[string]string)
+		fields, err := base.Fields()
+		if err != nil {
+			return err
+		}
+		for _, field := range fields {
+			was[field] = base.Position(field)
 		}
 
-		resources, err := parse.Parse(content)
+		keys, err := base.Override(resource)
 		if err != nil {
-			return nil, errors.Wrap(err, url)
+			return errors.Wrap(err, url)
 		}
 
-		for _, resource := range resources {
-			if control.IsSwitchNode(resource) {
-				out, err = expandSwitchMacro(content, current, resource, out)
-				if err != nil {
-					return out, errors.Wrap(err, "unable to load resource")
+		if w != nil {
+			for _, key := range keys {
+				if pos, ok := was[key]; ok {
+					fmt.Fprintf(w, "%s: %s set at %s, overriding %s\n", id, key, base.Position(key), pos)
+				} else {
+					fmt.Fprintf(w, "%s: %s set at %s\n", id, key, base.Position(key))
 				}
-				continue
-			}
-			newID := graph.ID(current.Parent, resource.String())
-			out.Add(node.New(newID, resource))
-			out.ConnectParent(current.Parent, newID)
-
-			if resource.IsModule() {
-				toLoad = append(
-					toLoad,
-					&source{
-						Parent:       newID,
-						ParentSource: url,
-						Source:       resource.Source(),
-					},
-				)
 			}
 		}
+
+		// the group may have been overridden
+		out.Add(meta.WithValue(base))
 	}
-	return out, out.Validate()
+
+	return nil
+}
+
+// parseFile fetches and parses a single file, checking its signature first if
+// asked to, and that it can be loaded by this version of converge
+func parseFile(ctx context.Context, url string, verify bool) ([]byte, *parse.Module, error) {
+	logger := logging.GetLogger(ctx).WithField("function", "parseFile")
+
+	var err error
+	content, inline := moduleContent(ctx, url)
+	if inline && verify {
+		return nil, nil, fmt.Errorf("%s cannot be verified, since it has no signature", strings.TrimPrefix(url, "file://"))
+	} else if !inline {
+		logger.WithField("url", url).Debug("fetching")
+		content, err = getSource(ctx).Fetch(ctx, url)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, url)
+		}
+	}
+
+	if verify {
+		signatureURL := url + ".asc"
+
+		logger.WithField("signatureUrl", signatureURL).Debug("fetching")
+		signature, sigErr := getSource(ctx).Fetch(ctx, signatureURL)
+		if sigErr != nil {
+			return nil, nil, errors.Wrap(sigErr, signatureURL)
+		}
+
+		err = keystore.Default().CheckSignature(bytes.NewBuffer(content), bytes.NewBuffer(signature))
+		if err != nil {
+			return nil, nil, errors.Wrap(err, signatureURL)
+		}
+	}
+
+	mod, err := parse.ParseModule(content)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, url)
+	}
+
+	if err := checkMinVersion(strings.TrimPrefix(url, "file://"), mod.MinVersion); err != nil {
+		return nil, nil, err
+	}
+
+	return content, mod, nil
+}
+
+// ConvergeVersion is the version of converge loading modules, which must be at
+// least the min_converge_version they set. It isn't checked if it isn't a
+// release version, as in development builds.
+var ConvergeVersion string
+
+func checkMinVersion(file, min string) error {
+	if min == "" {
+		return nil
+	}
+
+	if _, err := version.Parse(min); err != nil {
+		return errors.Wrapf(err, "%s: %s", file, parse.MinVersionKey)
+	}
+
+	ok, err := version.AtLeast(ConvergeVersion, min)
+	if err != nil {
+		// not a release, so there's nothing to compare against
+		return nil
+	}
+	if !ok {
+		return fmt.Errorf("%s needs converge %s or later, but this is %s", file, min, ConvergeVersion)
+	}
+	return nil
+}
+
+type moduleKey struct{}
+
+type module struct {
+	url     string
+	content []byte
+}
+
+// WithModule returns a context that makes Nodes load content as the module at
+// location instead of fetching it, for modules that aren't in a file, such as
+// ones read from stdin. Modules it refers to are found relative to location.
+func WithModule(ctx context.Context, location string, content []byte) context.Context {
+	url, _ := fetch.ResolveInContext(location, location)
+	return context.WithValue(ctx, moduleKey{}, &module{url: url, content: content})
+}
+
+// moduleContent returns the content set with WithModule, if it is for url
+func moduleContent(ctx context.Context, url string) ([]byte, bool) {
+	mod, ok := ctx.Value(moduleKey{}).(*module)
+	if !ok || mod.url != url {
+		return nil, false
+	}
+	return mod.content, true
+}
+
+type overrideWriterKey struct{}
+
+// WithOverrideWriter returns a context that makes Nodes write every field set
+// by an override file to w, along with where it was set before
+func WithOverrideWriter(ctx context.Context, w io.Writer) context.Context {
+	return context.WithValue(ctx, overrideWriterKey{}, w)
+}
+
+func overrideWriter(ctx context.Context) io.Writer {
+	w, _ := ctx.Value(overrideWriterKey{}).(io.Writer)
+	return w
 }
 
 // expandSwitchMacro is responsible for adding the generated switch nodes into
 // the graph.  Nodes inside of the switch macro are added as children to the
 // case statements, who are parents of the outer switch statement.  Actual node
 // generation happens in parse/preprocessor/switch and we add the nodes into the
-// graph here.
+// graph here. The generated nodes are parsed from fragments of the module, so
+// they all take the position of the switch block.
 func expandSwitchMacro(data []byte, current *source, n *parse.Node, g *graph.Graph) (*graph.Graph, error) {
 	if !control.IsSwitchNode(n) {
 		return g, nil
 	}
+	pos := n.Position("")
 	switchObj, err := control.NewSwitch(n, data)
 	if err != nil {
 		return g, err
@@ -134,7 +321,7 @@ func expandSwitchMacro(data []byte, current *source, n *parse.Node, g *graph.Gra
 		return g, err
 	}
 	switchID := graph.ID(current.Parent, switchNode.String())
-	g.Add(node.New(switchID, switchNode))
+	g.Add(withPosition(node.New(switchID, switchNode), pos))
 	g.ConnectParent(current.Parent, switchID)
 	for _, branch := range switchObj.Branches {
 		branchNode, err := branch.GenerateNode()
@@ -142,20 +329,26 @@ func expandSwitchMacro(data []byte, current *source, n *parse.Node, g *graph.Gra
 			return g, err
 		}
 		branchID := graph.ID(switchID, branchNode.String())
-		g.Add(node.New(branchID, branchNode))
+		g.Add(withPosition(node.New(branchID, branchNode), pos))
 		g.ConnectParent(switchID, branchID)
 		for _, innerNode := range branch.InnerNodes {
 			if err := validateInnerNode(innerNode); err != nil {
 				return g, err
 			}
 			innerID := graph.ID(branchID, innerNode.String())
-			g.Add(node.New(innerID, innerNode))
+			g.Add(withPosition(node.New(innerID, innerNode), pos))
 			g.ConnectParent(branchID, innerID)
 		}
 	}
 	return g, nil
 }
 
+// withPosition sets the position of the block a node was loaded from
+func withPosition(meta *node.Node, pos string) *node.Node {
+	meta.Position = pos
+	return meta
+}
+
 // validateInnerNode ensures that we do not nest control statements nor attempt
 // to add modules under a switch statement.
 func validateInnerNode(node *parse.Node) error {
@@ -15,13 +15,20 @@
 package load_test
 
 import (
+	"bytes"
 	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"sort"
+	"strings"
 	"testing"
 
 	"github.com/asteris-llc/converge/graph"
 	"github.com/asteris-llc/converge/helpers/logging"
 	"github.com/asteris-llc/converge/load"
+	"github.com/asteris-llc/converge/parse"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -92,3 +99,177 @@ func TestNodeWithConditionals(t *testing.T) {
 	_, found = g.Get("root/macro.switch.test-switch/macro.case.default/file.content.greeting")
 	assert.True(t, found)
 }
+
+// TestNodesPosition tests that nodes record where their blocks are
+func TestNodesPosition(t *testing.T) {
+	defer logging.HideLogs(t)()
+
+	t.Run("module", func(t *testing.T) {
+		g, err := load.Nodes(context.Background(), "../samples/sourceFile.hcl", false)
+		require.NoError(t, err)
+
+		meta, ok := g.Get("root/module.basic")
+		require.True(t, ok)
+		assert.True(t, strings.HasSuffix(meta.Position, "samples/sourceFile.hcl:5:1"), meta.Position)
+
+		meta, ok = g.Get("root/module.basic/task.render")
+		require.True(t, ok)
+		assert.True(t, strings.HasSuffix(meta.Position, "samples/basic.hcl:9:1"), meta.Position)
+	})
+
+	t.Run("switch", func(t *testing.T) {
+		g, err := load.Nodes(context.Background(), "../samples/conditionalLanguages.hcl", false)
+		require.NoError(t, err)
+
+		meta, ok := g.Get("root/macro.switch.test-switch/macro.case.french/file.content.greeting")
+		require.True(t, ok)
+		assert.True(t, strings.HasSuffix(meta.Position, "samples/conditionalLanguages.hcl:5:1"), meta.Position)
+	})
+}
+
+// TestNodesDirectory tests loading a module split across the files in a
+// directory
+func TestNodesDirectory(t *testing.T) {
+	defer logging.HideLogs(t)()
+
+	t.Run("module source", func(t *testing.T) {
+		g, err := load.Nodes(context.Background(), "../samples/splitModule.hcl", false)
+		require.NoError(t, err)
+
+		for _, id := range []string{
+			"root/module.split/param.message",
+			"root/module.split/param.filename",
+			"root/module.split/file.content.message",
+			"root/module.split/task.show",
+		} {
+			_, ok := g.Get(id)
+			assert.True(t, ok, "%q was missing from the graph", id)
+		}
+
+		meta, ok := g.Get("root/module.split/task.show")
+		require.True(t, ok)
+		assert.True(t, strings.HasSuffix(meta.Position, "samples/splitModule/tasks.hcl:6:1"), meta.Position)
+	})
+
+	t.Run("glob", func(t *testing.T) {
+		g, err := load.Nodes(context.Background(), "../samples/splitModule/p*.hcl", false)
+		require.NoError(t, err)
+
+		_, ok := g.Get("root/param.message")
+		assert.True(t, ok)
+		_, ok = g.Get("root/task.show")
+		assert.False(t, ok)
+	})
+
+	t.Run("defined twice", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "converge-nodes")
+		require.NoError(t, err)
+		defer os.RemoveAll(dir)
+
+		for _, name := range []string{"a.hcl", "b.hcl"} {
+			require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte("task \"x\" {\n  check = \"true\"\n}\n"), 0600))
+		}
+
+		_, err = load.Nodes(context.Background(), dir, false)
+		assert.EqualError(
+			t,
+			err,
+			fmt.Sprintf("root/task.x is defined twice, at %s:1:1 and %s:1:1", filepath.Join(dir, "a.hcl"), filepath.Join(dir, "b.hcl")),
+		)
+	})
+}
+
+// TestNodesOverride tests changing nodes with override files
+func TestNodesOverride(t *testing.T) {
+	defer logging.HideLogs(t)()
+
+	t.Run("sibling", func(t *testing.T) {
+		var report bytes.Buffer
+		ctx := load.WithOverrideWriter(context.Background(), &report)
+
+		g, err := load.Nodes(ctx, "../samples/siteOverride.hcl", false)
+		require.NoError(t, err)
+
+		meta, ok := g.Get("root/module.motd/file.content.motd")
+		require.True(t, ok)
+
+		node, ok := meta.Value().(*parse.Node)
+		require.True(t, ok)
+
+		content, err := node.GetString("content")
+		require.NoError(t, err)
+		assert.Equal(t, "{{param `greeting`}} from this site", content)
+
+		destination, err := node.GetString("destination")
+		require.NoError(t, err)
+		assert.Equal(t, "motd.txt", destination)
+
+		assert.Regexp(t, "^root/module.motd/file.content.motd: content set at .*samples/siteOverride/base_override.hcl:3:3, overriding .*samples/siteOverride/base.hcl:9:3\n$", report.String())
+	})
+
+	t.Run("undefined", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "converge-nodes")
+		require.NoError(t, err)
+		defer os.RemoveAll(dir)
+
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "main.hcl"), []byte("task \"x\" {\n  check = \"true\"\n}\n"), 0600))
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "main_override.hcl"), []byte("task \"y\" {\n  check = \"false\"\n}\n"), 0600))
+
+		_, err = load.Nodes(context.Background(), dir, false)
+		assert.EqualError(
+			t,
+			err,
+			fmt.Sprintf("%s:1:1: root/task.y overrides a node that isn't defined", filepath.Join(dir, "main_override.hcl")),
+		)
+	})
+}
+
+// TestNodesWithModule tests loading a module that isn't in a file
+func TestNodesWithModule(t *testing.T) {
+	defer logging.HideLogs(t)()
+
+	ctx := load.WithModule(context.Background(), "<stdin>", []byte("task \"x\" {\n  check = \"true\"\n}\n"))
+
+	t.Run("loads", func(t *testing.T) {
+		g, err := load.Nodes(ctx, "<stdin>", false)
+		require.NoError(t, err)
+
+		meta, ok := g.Get("root/task.x")
+		require.True(t, ok)
+		assert.Equal(t, "<stdin>:1:1", meta.Position)
+	})
+
+	t.Run("verify", func(t *testing.T) {
+		_, err := load.Nodes(ctx, "<stdin>", true)
+		assert.EqualError(t, err, "<stdin> cannot be verified, since it has no signature")
+	})
+}
+
+func TestNodesMinVersion(t *testing.T) {
+	defer logging.HideLogs(t)()
+
+	defer func(old string) { load.ConvergeVersion = old }(load.ConvergeVersion)
+
+	ctx := load.WithModule(context.Background(), "<stdin>", []byte("min_converge_version = \"0.4.0\"\n\ntask \"x\" {\n  check = \"true\"\n}\n"))
+
+	t.Run("new enough", func(t *testing.T) {
+		load.ConvergeVersion = "0.4.1"
+
+		_, err := load.Nodes(ctx, "<stdin>", false)
+		assert.NoError(t, err)
+	})
+
+	t.Run("too old", func(t *testing.T) {
+		load.ConvergeVersion = "0.3.0"
+
+		_, err := load.Nodes(ctx, "<stdin>", false)
+		assert.EqualError(t, err, "<stdin> needs converge 0.4.0 or later, but this is 0.3.0")
+	})
+
+	t.Run("development build", func(t *testing.T) {
+		load.ConvergeVersion = "unset"
+
+		_, err := load.Nodes(ctx, "<stdin>", false)
+		assert.NoError(t, err)
+	})
+}
@@ -0,0 +1,72 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package load
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/asteris-llc/converge/graph"
+	"github.com/asteris-llc/converge/load/registry"
+	"github.com/asteris-llc/converge/resource"
+)
+
+// CheckPlatforms returns an error naming every resource in a loaded graph that
+// can't be used on goos, the operating system it will be applied on. Resources
+// inside a case aren't checked, since switching on the platform is how a
+// module uses different resources on different systems.
+func CheckPlatforms(g *graph.Graph, goos string) error {
+	var unsupported []string
+	for _, id := range g.Vertices() {
+		name, ok := kind(g, id)
+		if !ok || registry.Supports(name, goos) || inCase(g, id) {
+			continue
+		}
+
+		unsupported = append(unsupported, fmt.Sprintf("%s (%s only works on %s)", id, name, strings.Join(registry.Platforms(name), ", ")))
+	}
+
+	if len(unsupported) == 0 {
+		return nil
+	}
+
+	sort.Strings(unsupported)
+	return fmt.Errorf("not supported on %s: %s", goos, strings.Join(unsupported, "; "))
+}
+
+// kind returns the name a node's resource was registered under
+func kind(g *graph.Graph, id string) (string, bool) {
+	meta, ok := g.Get(id)
+	if !ok {
+		return "", false
+	}
+
+	preparer, ok := meta.Value().(*resource.Preparer)
+	if !ok {
+		return "", false
+	}
+
+	return registry.NameForType(preparer.Destination)
+}
+
+func inCase(g *graph.Graph, id string) bool {
+	for parent := graph.ParentID(id); parent != "." && !graph.IsRoot(parent); parent = graph.ParentID(parent) {
+		if name, ok := kind(g, parent); ok && name == "macro.case" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,62 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package load_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/logging"
+	"github.com/asteris-llc/converge/load"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCheckPlatforms tests finding resources that can't be used on a platform
+func TestCheckPlatforms(t *testing.T) {
+	defer logging.HideLogs(t)()
+
+	t.Run("supported", func(t *testing.T) {
+		g, err := load.Load(context.Background(), "../samples/windowsService.hcl", false)
+		require.NoError(t, err)
+
+		assert.NoError(t, load.CheckPlatforms(g, "windows"))
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		g, err := load.Load(context.Background(), "../samples/windowsService.hcl", false)
+		require.NoError(t, err)
+
+		assert.EqualError(
+			t,
+			load.CheckPlatforms(g, "linux"),
+			"not supported on linux: root/windows.service.app (windows.service only works on windows)",
+		)
+	})
+
+	t.Run("anywhere", func(t *testing.T) {
+		g, err := load.Load(context.Background(), "../samples/basic.hcl", false)
+		require.NoError(t, err)
+
+		assert.NoError(t, load.CheckPlatforms(g, "windows"))
+	})
+
+	t.Run("in a case", func(t *testing.T) {
+		g, err := load.Load(context.Background(), "../samples/freebsdService.hcl", false)
+		require.NoError(t, err)
+
+		assert.NoError(t, load.CheckPlatforms(g, "linux"))
+	})
+}
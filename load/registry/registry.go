@@ -17,12 +17,14 @@ package registry
 import (
 	"fmt"
 	"reflect"
+	"sort"
 )
 
 // Registry for importable types
 type Registry struct {
-	forward map[string]reflect.Type
-	reverse map[reflect.Type]string
+	forward   map[string]reflect.Type
+	reverse   map[reflect.Type]string
+	platforms map[string][]string
 }
 
 // New creates a new Registry
@@ -30,6 +32,7 @@ func New() *Registry {
 	return &Registry{
 		map[string]reflect.Type{},
 		map[reflect.Type]string{},
+		map[string][]string{},
 	}
 }
 
@@ -93,6 +96,74 @@ func (r *Registry) NameForType(i interface{}) (string, bool) {
 	return name, present
 }
 
+// TypesForName lists the types registered in reverse for a name, other than
+// the one created by NewByName. These are usually the tasks a preparer
+// returns. They are sorted by their string form.
+func (r *Registry) TypesForName(name string) []reflect.Type {
+	forward := r.forward[name]
+
+	var types []reflect.Type
+	for typ, typName := range r.reverse {
+		if typName == name && typ != forward {
+			types = append(types, typ)
+		}
+	}
+
+	sort.Sort(byString(types))
+	return types
+}
+
+// RegisterPlatforms declares the operating systems, named as in GOOS, that the
+// type registered at name can be used on. Types without any declared can be
+// used anywhere.
+func (r *Registry) RegisterPlatforms(name string, goos ...string) error {
+	if _, present := r.forward[name]; !present {
+		return fmt.Errorf("%q is not registered", name)
+	}
+
+	r.platforms[name] = append(r.platforms[name], goos...)
+	return nil
+}
+
+// Platforms lists the operating systems the type registered at name can be
+// used on, in sorted order. It is empty if the type can be used anywhere.
+func (r *Registry) Platforms(name string) []string {
+	platforms := append([]string(nil), r.platforms[name]...)
+	sort.Strings(platforms)
+	return platforms
+}
+
+// Supports reports whether the type registered at name can be used on goos
+func (r *Registry) Supports(name, goos string) bool {
+	platforms := r.platforms[name]
+	if len(platforms) == 0 {
+		return true
+	}
+
+	for _, platform := range platforms {
+		if platform == goos {
+			return true
+		}
+	}
+	return false
+}
+
+type byString []reflect.Type
+
+func (b byString) Len() int           { return len(b) }
+func (b byString) Less(i, j int) bool { return b[i].String() < b[j].String() }
+func (b byString) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+
+// Names lists every name registered with Register, in sorted order
+func (r *Registry) Names() []string {
+	var names []string
+	for name := range r.forward {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 // package-global API
 var registry *Registry
 
@@ -122,6 +193,36 @@ func NameForType(i interface{}) (string, bool) {
 	return registry.NameForType(i)
 }
 
+// TypesForName lists the types registered in reverse for a name, other than
+// the one created by NewByName
+func TypesForName(name string) []reflect.Type {
+	return registry.TypesForName(name)
+}
+
+// RegisterPlatforms declares the operating systems a type in the global
+// registry can be used on
+func RegisterPlatforms(name string, goos ...string) {
+	if err := registry.RegisterPlatforms(name, goos...); err != nil {
+		panic(err)
+	}
+}
+
+// Platforms lists the operating systems the type registered at name can be
+// used on. It is empty if the type can be used anywhere.
+func Platforms(name string) []string {
+	return registry.Platforms(name)
+}
+
+// Supports reports whether the type registered at name can be used on goos
+func Supports(name, goos string) bool {
+	return registry.Supports(name, goos)
+}
+
+// Names lists every name registered with Register, in sorted order
+func Names() []string {
+	return registry.Names()
+}
+
 func init() {
 	registry = New()
 }
@@ -16,6 +16,7 @@ package registry_test
 
 import (
 	"encoding/json"
+	"reflect"
 	"testing"
 
 	"github.com/asteris-llc/converge/load/registry"
@@ -119,3 +120,53 @@ func TestRegistryNameForType(t *testing.T) {
 		assert.False(t, ok)
 	})
 }
+
+func TestRegistryNames(t *testing.T) {
+	t.Parallel()
+
+	r := registry.New()
+	require.NoError(t, r.Register("test.b", new(TestType)))
+	require.NoError(t, r.Register("test.a", new(TestType)))
+
+	assert.Equal(t, []string{"test.a", "test.b"}, r.Names())
+}
+
+func TestRegistryTypesForName(t *testing.T) {
+	t.Parallel()
+
+	type other struct{}
+
+	r := registry.New()
+	require.NoError(t, r.Register("test", new(TestType), new(other)))
+
+	assert.Equal(t, []reflect.Type{reflect.TypeOf(new(other))}, r.TypesForName("test"))
+	assert.Empty(t, r.TypesForName("missing"))
+}
+
+func TestRegistryPlatforms(t *testing.T) {
+	t.Parallel()
+
+	t.Run("anywhere", func(t *testing.T) {
+		r := registry.New()
+		require.NoError(t, r.Register("test", new(TestType)))
+
+		assert.Empty(t, r.Platforms("test"))
+		assert.True(t, r.Supports("test", "windows"))
+		assert.True(t, r.Supports("missing", "linux"))
+	})
+
+	t.Run("declared", func(t *testing.T) {
+		r := registry.New()
+		require.NoError(t, r.Register("test", new(TestType)))
+		require.NoError(t, r.RegisterPlatforms("test", "linux", "freebsd"))
+
+		assert.Equal(t, []string{"freebsd", "linux"}, r.Platforms("test"))
+		assert.True(t, r.Supports("test", "linux"))
+		assert.False(t, r.Supports("test", "windows"))
+	})
+
+	t.Run("unregistered", func(t *testing.T) {
+		r := registry.New()
+		assert.EqualError(t, r.RegisterPlatforms("test", "linux"), `"test" is not registered`)
+	})
+}
@@ -27,20 +27,67 @@ import (
 	"github.com/hashicorp/hcl"
 
 	// import empty to register types for SetResources
+	_ "github.com/asteris-llc/converge/resource/assert"
+	_ "github.com/asteris-llc/converge/resource/btrfs/snapshot"
+	_ "github.com/asteris-llc/converge/resource/btrfs/subvolume"
 	_ "github.com/asteris-llc/converge/resource/docker/container"
+	_ "github.com/asteris-llc/converge/resource/docker/daemon"
 	_ "github.com/asteris-llc/converge/resource/docker/image"
+	_ "github.com/asteris-llc/converge/resource/docker/registryauth"
+	_ "github.com/asteris-llc/converge/resource/file/acl"
 	_ "github.com/asteris-llc/converge/resource/file/content"
 	_ "github.com/asteris-llc/converge/resource/file/directory"
 	_ "github.com/asteris-llc/converge/resource/file/mode"
+	_ "github.com/asteris-llc/converge/resource/freebsd/service"
 	_ "github.com/asteris-llc/converge/resource/group"
+	_ "github.com/asteris-llc/converge/resource/haproxy/backend"
+	_ "github.com/asteris-llc/converge/resource/hooks"
+	_ "github.com/asteris-llc/converge/resource/log/journald"
+	_ "github.com/asteris-llc/converge/resource/log/rsyslog"
+	_ "github.com/asteris-llc/converge/resource/lvm/snapshot"
+	_ "github.com/asteris-llc/converge/resource/lvm/volumegroup"
 	_ "github.com/asteris-llc/converge/resource/module"
+	_ "github.com/asteris-llc/converge/resource/network/dns"
+	_ "github.com/asteris-llc/converge/resource/network/iface"
+	_ "github.com/asteris-llc/converge/resource/network/route"
+	_ "github.com/asteris-llc/converge/resource/network/rule"
+	_ "github.com/asteris-llc/converge/resource/network/wireguard"
+	_ "github.com/asteris-llc/converge/resource/nfs/export"
+	_ "github.com/asteris-llc/converge/resource/nfs/mount"
+	_ "github.com/asteris-llc/converge/resource/nginx/vhost"
+	_ "github.com/asteris-llc/converge/resource/os/alternatives"
+	_ "github.com/asteris-llc/converge/resource/os/gpgkey"
+	_ "github.com/asteris-llc/converge/resource/os/kernelcmdline"
+	_ "github.com/asteris-llc/converge/resource/os/logindefs"
+	_ "github.com/asteris-llc/converge/resource/os/logrotate"
+	_ "github.com/asteris-llc/converge/resource/os/pam"
+	_ "github.com/asteris-llc/converge/resource/os/proxy"
+	_ "github.com/asteris-llc/converge/resource/os/reboot"
+	_ "github.com/asteris-llc/converge/resource/os/sudoers"
+	_ "github.com/asteris-llc/converge/resource/output"
+	_ "github.com/asteris-llc/converge/resource/package/choco"
+	_ "github.com/asteris-llc/converge/resource/package/pkg"
 	_ "github.com/asteris-llc/converge/resource/package/rpm"
 	_ "github.com/asteris-llc/converge/resource/param"
 	_ "github.com/asteris-llc/converge/resource/shell"
 	_ "github.com/asteris-llc/converge/resource/shell/query"
+	_ "github.com/asteris-llc/converge/resource/ssh/sshdconfig"
+	_ "github.com/asteris-llc/converge/resource/storage/luks"
+	_ "github.com/asteris-llc/converge/resource/systemd/timer"
+	_ "github.com/asteris-llc/converge/resource/systemd/unitfile"
+	_ "github.com/asteris-llc/converge/resource/tls/catrust"
 	_ "github.com/asteris-llc/converge/resource/user"
+	_ "github.com/asteris-llc/converge/resource/user/keypair"
+	_ "github.com/asteris-llc/converge/resource/value/random"
+	_ "github.com/asteris-llc/converge/resource/value/uuid"
 	_ "github.com/asteris-llc/converge/resource/wait"
 	_ "github.com/asteris-llc/converge/resource/wait/port"
+	_ "github.com/asteris-llc/converge/resource/windows/feature"
+	_ "github.com/asteris-llc/converge/resource/windows/installer"
+	_ "github.com/asteris-llc/converge/resource/windows/scheduledtask"
+	_ "github.com/asteris-llc/converge/resource/windows/service"
+	_ "github.com/asteris-llc/converge/resource/zfs/dataset"
+	_ "github.com/asteris-llc/converge/resource/zfs/pool"
 )
 
 // SetResources loads the resources for each graph node
@@ -0,0 +1,59 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package load
+
+import (
+	"context"
+
+	"github.com/asteris-llc/converge/fetch"
+)
+
+// Source gets the files that modules are loaded from
+type Source interface {
+	// Expand gets the files making up the module at url, like fetch.Expand
+	Expand(url string) ([]string, error)
+
+	// Fetch gets the content of a file, like fetch.Any
+	Fetch(ctx context.Context, url string) ([]byte, error)
+}
+
+// fetchSource gets files with the fetch package
+type fetchSource struct{}
+
+func (fetchSource) Expand(url string) ([]string, error) {
+	return fetch.Expand(url)
+}
+
+func (fetchSource) Fetch(ctx context.Context, url string) ([]byte, error) {
+	return fetch.Any(ctx, url)
+}
+
+// DefaultSource gets files from wherever their urls point
+var DefaultSource Source = fetchSource{}
+
+type sourceKey struct{}
+
+// WithSource returns a context that makes Nodes get every file from source,
+// instead of from wherever its url points
+func WithSource(ctx context.Context, source Source) context.Context {
+	return context.WithValue(ctx, sourceKey{}, source)
+}
+
+func getSource(ctx context.Context) Source {
+	if source, ok := ctx.Value(sourceKey{}).(Source); ok {
+		return source
+	}
+	return DefaultSource
+}
@@ -0,0 +1,172 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package moduletest
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/asteris-llc/converge/apply"
+	"github.com/asteris-llc/converge/graph"
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/rpc/pb"
+	"github.com/pkg/errors"
+)
+
+// Integration applies the module inside a new container and runs commands
+// there to verify the result. The container is removed afterwards.
+type Integration struct {
+	// Image is the docker image to start the container from. It needs a
+	// shell, and whatever the module's resources run, such as a package
+	// manager.
+	Image string `hcl:"image"`
+
+	// Verify are shell commands run in the container after the module is
+	// applied. Each must exit 0 for the test to pass.
+	Verify []string `hcl:"verify"`
+}
+
+// RunIntegration applies the module of every case with an integration block
+// in a container of its own, and runs its verify commands there. Containers
+// are started and removed with docker through e.
+func (s *Suite) RunIntegration(ctx context.Context, e exec.Executor) []*Result {
+	var out []*Result
+	for _, c := range s.Cases {
+		if c.Integration == nil {
+			continue
+		}
+
+		result := &Result{Suite: s.Path, Case: c.Name, Integration: true}
+		result.Failures, result.Err = c.integrate(ctx, e, filepath.Dir(s.Path))
+		out = append(out, result)
+	}
+	return out
+}
+
+func (c *Case) integrate(ctx context.Context, e exec.Executor, dir string) ([]string, error) {
+	out, err := exec.Read(e, "docker", "run", "--detach", "--rm", "--entrypoint", "tail", c.Integration.Image, "-f", "/dev/null")
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not start a container from %s", c.Integration.Image)
+	}
+	container := strings.TrimSpace(out)
+	defer exec.Run(e, "docker", "rm", "--force", container)
+
+	failures, err := c.applyIn(ctx, container, dir)
+	if err != nil || len(failures) > 0 {
+		return failures, err
+	}
+
+	inside := &exec.Docker{Executor: e, Container: container}
+	for _, command := range c.Integration.Verify {
+		if err := exec.Run(inside, "sh", "-c", command); err != nil {
+			failures = append(failures, fmt.Sprintf("verify %q failed: %s", command, err))
+		}
+	}
+	return failures, nil
+}
+
+// applyIn applies the module under test to the container, through a module
+// that includes it with the container as its target. It returns the nodes
+// that failed.
+func (c *Case) applyIn(ctx context.Context, container, dir string) ([]string, error) {
+	location := c.Module
+	if !filepath.IsAbs(location) && !strings.Contains(location, "://") {
+		abs, err := filepath.Abs(filepath.Join(dir, location))
+		if err != nil {
+			return nil, err
+		}
+		location = abs
+	}
+
+	wrapper, err := ioutil.TempFile("", "converge-test")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(wrapper.Name())
+
+	_, err = wrapper.WriteString(wrapperModule(location, container, c.Params))
+	if closeErr := wrapper.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "could not write the module to apply")
+	}
+
+	loaded, err := (&pb.LoadRequest{Location: wrapper.Name()}).Load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	applied, err := apply.PlanAndApply(ctx, loaded)
+	if err != nil && err != apply.ErrTreeContainsErrors {
+		return nil, err
+	}
+
+	return applyFailures(applied), nil
+}
+
+// wrapperModule is the source of a module that applies the module at
+// location inside the container
+func wrapperModule(location, container string, params map[string]interface{}) string {
+	var keys []string
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	lines := []string{fmt.Sprintf("module %s \"test\" {", strconv.Quote(location)), "  params = {"}
+	for _, key := range keys {
+		lines = append(lines, fmt.Sprintf("    %s = %s", strconv.Quote(key), strconv.Quote(fmt.Sprintf("%v", params[key]))))
+	}
+	lines = append(lines,
+		"  }",
+		"",
+		"  target {",
+		fmt.Sprintf("    docker = %s", strconv.Quote(container)),
+		"  }",
+		"}",
+		"",
+	)
+	return strings.Join(lines, "\n")
+}
+
+// applyFailures lists the nodes of the module under test that failed to
+// apply, without the wrapper module's part of their IDs
+func applyFailures(g *graph.Graph) (out []string) {
+	prefix := graph.ID("root", "module.test") + "/"
+	for _, id := range g.Vertices() {
+		meta, ok := g.Get(id)
+		if !ok {
+			continue
+		}
+
+		status, ok := meta.Value().(interface {
+			Error() error
+		})
+		if !ok || status.Error() == nil {
+			continue
+		}
+
+		out = append(out, fmt.Sprintf("%s: failed to apply: %s", strings.TrimPrefix(id, prefix), status.Error()))
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,111 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package moduletest_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/fakeexec"
+	"github.com/asteris-llc/converge/moduletest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSuiteRunIntegration(t *testing.T) {
+	t.Parallel()
+
+	dir := writeModule(t, map[string]string{
+		"app.hcl": `
+param "env" {
+  default = "dev"
+}
+`,
+		"app_test.hcl": `
+test "unit only" {
+  expect "param.env" {}
+}
+
+test "installs" {
+  params {
+    env = "prod"
+  }
+
+  integration {
+    image  = "centos:7"
+    verify = ["test -f /etc/app.conf", "rpm -q app"]
+  }
+}
+`,
+	})
+	defer os.RemoveAll(dir)
+
+	suite, err := moduletest.Load(filepath.Join(dir, "app_test.hcl"))
+	require.NoError(t, err)
+
+	t.Run("passes", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("docker", "run", "--detach", "--rm", "--entrypoint", "tail", "centos:7", "-f", "/dev/null").Return("abc123\n", 0)
+		fake.Expect("docker", "exec", "abc123", "sh", "-c", "test -f /etc/app.conf")
+		fake.Expect("docker", "exec", "abc123", "sh", "-c", "rpm -q app")
+		fake.Expect("docker", "rm", "--force", "abc123")
+
+		results := suite.RunIntegration(context.Background(), fake)
+		require.Len(t, results, 1)
+		assert.Equal(t, "installs", results[0].Case)
+		assert.True(t, results[0].Integration)
+		assert.NoError(t, results[0].Err)
+		assert.Empty(t, results[0].Failures)
+		fake.AssertExpectations(t)
+	})
+
+	t.Run("verify fails", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("docker", "run", "--detach", "--rm", "--entrypoint", "tail", "centos:7", "-f", "/dev/null").Return("abc123\n", 0)
+		fake.Expect("docker", "exec", "abc123", "sh", "-c", "test -f /etc/app.conf")
+		fake.Expect("docker", "exec", "abc123", "sh", "-c", "rpm -q app").Return("package app is not installed\n", 1)
+		fake.Expect("docker", "rm", "--force", "abc123")
+
+		results := suite.RunIntegration(context.Background(), fake)
+		require.Len(t, results, 1)
+		assert.False(t, results[0].Passed())
+		assert.Equal(t, []string{`verify "rpm -q app" failed: sh: exit status 1`}, results[0].Failures)
+		fake.AssertExpectations(t)
+	})
+
+	t.Run("no container", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("docker", "run", "--detach", "--rm", "--entrypoint", "tail", "centos:7", "-f", "/dev/null").Return("", 125).Stderr("Unable to find image")
+
+		results := suite.RunIntegration(context.Background(), fake)
+		require.Len(t, results, 1)
+		assert.Error(t, results[0].Err)
+	})
+
+	t.Run("unit run skips integration only cases", func(t *testing.T) {
+		results := suite.Run(context.Background())
+		require.Len(t, results, 1)
+		assert.Equal(t, "unit only", results[0].Case)
+	})
+}
+
+func TestParseIntegration(t *testing.T) {
+	t.Parallel()
+
+	_, err := moduletest.Parse("app_test.hcl", []byte(`test "a" { integration { verify = ["true"] } }`))
+	assert.EqualError(t, err, `test "a" needs an image for its integration block`)
+}
@@ -0,0 +1,313 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package moduletest
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/asteris-llc/converge/graph"
+	"github.com/asteris-llc/converge/parse/preprocessor/switch"
+	"github.com/asteris-llc/converge/policy"
+	"github.com/asteris-llc/converge/render"
+	"github.com/asteris-llc/converge/render/preprocessor"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/rpc/pb"
+	"github.com/hashicorp/hcl"
+	"github.com/pkg/errors"
+)
+
+// Suffix is the end of the names of test files
+const Suffix = "_test.hcl"
+
+// Suite is the tests in one test file
+type Suite struct {
+	Path  string
+	Cases []*Case `hcl:"test"`
+}
+
+// Case renders a module with a set of params and checks the nodes in the
+// resulting graph. Nothing is checked or applied on the system.
+type Case struct {
+	Name string `hcl:",key"`
+
+	// Module is the module under test, relative to the test file. It defaults
+	// to the test file's name without "_test", so app_test.hcl tests app.hcl.
+	Module string `hcl:"module"`
+
+	// Params are passed to the module as if given with --params
+	Params map[string]interface{} `hcl:"params"`
+
+	Expect []*Expectation `hcl:"expect"`
+
+	// Integration, if set, also applies the module in a container when tests
+	// are run with --integration
+	Integration *Integration `hcl:"integration"`
+}
+
+// Expectation is an assertion about a single node, named as in a depends,
+// like "file.content.config" or "module.app/task.start"
+type Expectation struct {
+	Node string `hcl:",key"`
+
+	// Absent expects the node not to be in the graph, for example because a
+	// conditional removed it
+	Absent bool `hcl:"absent"`
+
+	// Fields maps fields of the node, written as in a lookup, to their
+	// expected values. A list is compared to every element of a list field.
+	Fields map[string]interface{} `hcl:"fields"`
+}
+
+// Result is the outcome of a single case
+type Result struct {
+	Suite string
+	Case  string
+
+	// Failures are the expectations that weren't met
+	Failures []string
+
+	// Err is set if the module couldn't be rendered, or the container for an
+	// integration test couldn't be started
+	Err error
+
+	// Integration is true if the case was applied in a container
+	Integration bool
+}
+
+// Passed is true if the module rendered and every expectation was met
+func (r *Result) Passed() bool {
+	return r.Err == nil && len(r.Failures) == 0
+}
+
+// Find returns the test files named by paths. Directories are searched
+// recursively for files ending in Suffix.
+func Find(paths ...string) ([]string, error) {
+	var out []string
+	for _, path := range paths {
+		stat, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+
+		if !stat.IsDir() {
+			out = append(out, path)
+			continue
+		}
+
+		err = filepath.Walk(path, func(file string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if !info.IsDir() && strings.HasSuffix(info.Name(), Suffix) {
+				out = append(out, file)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return out, nil
+}
+
+// Parse reads the tests in a test file from HCL source
+func Parse(path string, src []byte) (*Suite, error) {
+	suite := &Suite{Path: path}
+	if err := hcl.Unmarshal(src, suite); err != nil {
+		return nil, err
+	}
+
+	if len(suite.Cases) == 0 {
+		return nil, errors.New("no tests found")
+	}
+
+	for _, c := range suite.Cases {
+		if c.Module == "" {
+			c.Module = strings.TrimSuffix(filepath.Base(path), Suffix) + ".hcl"
+		}
+		if len(c.Expect) == 0 && c.Integration == nil {
+			return nil, fmt.Errorf("test %q has no expectations", c.Name)
+		}
+		if c.Integration != nil && c.Integration.Image == "" {
+			return nil, fmt.Errorf("test %q needs an image for its integration block", c.Name)
+		}
+	}
+
+	return suite, nil
+}
+
+// Load reads a test file
+func Load(path string) (*Suite, error) {
+	src, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	suite, err := Parse(path, src)
+	if err != nil {
+		return nil, errors.Wrap(err, path)
+	}
+	return suite, nil
+}
+
+// Run checks the expectations of every case in the suite against its
+// rendered graph
+func (s *Suite) Run(ctx context.Context) []*Result {
+	var out []*Result
+	for _, c := range s.Cases {
+		if len(c.Expect) == 0 {
+			continue
+		}
+
+		result := &Result{Suite: s.Path, Case: c.Name}
+
+		g, err := c.render(ctx, filepath.Dir(s.Path))
+		if err != nil {
+			result.Err = err
+		} else {
+			result.Failures = c.check(g)
+		}
+
+		out = append(out, result)
+	}
+	return out
+}
+
+// render loads and renders the module under test, resolving conditionals
+// like plan does
+func (c *Case) render(ctx context.Context, dir string) (*graph.Graph, error) {
+	location := c.Module
+	if !filepath.IsAbs(location) && !strings.Contains(location, "://") {
+		location = filepath.Join(dir, location)
+	}
+
+	params := map[string]string{}
+	for key, value := range c.Params {
+		params[key] = fmt.Sprintf("%v", value)
+	}
+
+	return (&pb.LoadRequest{Location: location, Parameters: params}).Load(ctx)
+}
+
+func (c *Case) check(g *graph.Graph) (failures []string) {
+	for _, expect := range c.Expect {
+		failures = append(failures, expect.check(g)...)
+	}
+	return failures
+}
+
+func (e *Expectation) check(g *graph.Graph) []string {
+	id := graph.ID("root", e.Node)
+	meta, ok := g.Get(id)
+
+	// nodes in cases that weren't chosen are in the graph, but never run
+	var task resource.Task
+	if ok {
+		if resolved, isTask := resource.ResolveTask(meta.Value()); isTask {
+			task = resolved
+			if conditional, isConditional := resolved.(*control.ConditionalTask); isConditional {
+				ok = conditional.ShouldEvaluate()
+				task = conditional.Task
+			}
+		}
+	}
+
+	if e.Absent {
+		if ok {
+			return []string{fmt.Sprintf("%s: expected to be absent", e.Node)}
+		}
+		return nil
+	}
+
+	if !ok {
+		return []string{fmt.Sprintf("%s: not in the graph", e.Node)}
+	}
+
+	if len(e.Fields) == 0 {
+		return nil
+	}
+
+	if _, isThunk := meta.Value().(*render.PrepareThunk); isThunk {
+		return []string{fmt.Sprintf("%s: depends on values that are only known when applying", e.Node)}
+	}
+
+	if task == nil {
+		return []string{fmt.Sprintf("%s: is not a resource", e.Node)}
+	}
+
+	var failures []string
+	for _, field := range sortedKeys(e.Fields) {
+		value, err := preprocessor.EvalTerms(task, preprocessor.SplitTerms(field)...)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %s: %s", e.Node, field, err))
+			continue
+		}
+
+		actual := policy.Stringify(value)
+		if actual == nil {
+			actual = []string{}
+		}
+		expected := expectedValues(e.Fields[field])
+		if !reflect.DeepEqual(actual, expected) {
+			failures = append(failures, fmt.Sprintf("%s: %s is %s, expected %s", e.Node, field, format(actual), format(expected)))
+		}
+	}
+	return failures
+}
+
+// expectedValues formats an expected value from HCL like Stringify formats
+// the actual value
+func expectedValues(value interface{}) []string {
+	switch value := value.(type) {
+	case []interface{}:
+		out := []string{}
+		for _, item := range value {
+			out = append(out, fmt.Sprintf("%v", item))
+		}
+		return out
+	case []string:
+		return value
+	}
+	return []string{fmt.Sprintf("%v", value)}
+}
+
+func format(values []string) string {
+	if len(values) == 1 {
+		return fmt.Sprintf("%q", values[0])
+	}
+
+	quoted := make([]string, len(values))
+	for i, value := range values {
+		quoted[i] = fmt.Sprintf("%q", value)
+	}
+	return "[" + strings.Join(quoted, ", ") + "]"
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	var out []string
+	for key := range m {
+		out = append(out, key)
+	}
+	sort.Strings(out)
+	return out
+}
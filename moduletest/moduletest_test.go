@@ -0,0 +1,196 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package moduletest_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/asteris-llc/converge/moduletest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const module = `
+param "env" {
+  default = "dev"
+}
+
+param "ports" {
+  default = "8080"
+}
+
+file.content "config" {
+  destination = "/etc/app/{{param ` + "`env`" + `}}.conf"
+  content     = "ports={{param ` + "`ports`" + `}}"
+}
+
+switch "debug" {
+  case "eq ` + "`dev`" + ` ` + "`{{param `env`}}`" + `" "dev" {
+    task "debug" {
+      check = "true"
+      apply = "true"
+    }
+  }
+}
+`
+
+func TestSuiteRun(t *testing.T) {
+	t.Parallel()
+
+	dir := writeModule(t, map[string]string{
+		"app.hcl": module,
+		"app_test.hcl": `
+test "production" {
+  params {
+    env = "prod"
+  }
+
+  expect "file.content.config" {
+    fields {
+      destination = "/etc/app/prod.conf"
+      content     = "ports=8080"
+    }
+  }
+
+  expect "macro.switch.debug/macro.case.dev/task.debug" {
+    absent = true
+  }
+}
+
+test "wrong" {
+  module = "app.hcl"
+
+  expect "file.content.config" {
+    fields {
+      destination = "/etc/app/prod.conf"
+    }
+  }
+
+  expect "task.missing" {}
+
+  expect "macro.switch.debug/macro.case.dev/task.debug" {
+    absent = true
+  }
+}
+`,
+	})
+	defer os.RemoveAll(dir)
+
+	suite, err := moduletest.Load(filepath.Join(dir, "app_test.hcl"))
+	require.NoError(t, err)
+
+	results := suite.Run(context.Background())
+	require.Len(t, results, 2)
+
+	assert.Equal(t, "production", results[0].Case)
+	assert.NoError(t, results[0].Err)
+	assert.Empty(t, results[0].Failures)
+	assert.True(t, results[0].Passed())
+
+	assert.Equal(t, "wrong", results[1].Case)
+	assert.NoError(t, results[1].Err)
+	assert.False(t, results[1].Passed())
+	assert.Equal(
+		t,
+		[]string{
+			`file.content.config: destination is "/etc/app/dev.conf", expected "/etc/app/prod.conf"`,
+			"task.missing: not in the graph",
+			"macro.switch.debug/macro.case.dev/task.debug: expected to be absent",
+		},
+		results[1].Failures,
+	)
+}
+
+func TestSuiteRunError(t *testing.T) {
+	t.Parallel()
+
+	dir := writeModule(t, map[string]string{
+		"broken_test.hcl": `
+test "missing module" {
+  expect "task.x" {}
+}
+`,
+	})
+	defer os.RemoveAll(dir)
+
+	suite, err := moduletest.Load(filepath.Join(dir, "broken_test.hcl"))
+	require.NoError(t, err)
+
+	results := suite.Run(context.Background())
+	require.Len(t, results, 1)
+	assert.Error(t, results[0].Err)
+	assert.False(t, results[0].Passed())
+}
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	t.Run("default module", func(t *testing.T) {
+		suite, err := moduletest.Parse("dir/app_test.hcl", []byte(`test "a" { expect "task.x" {} }`))
+		require.NoError(t, err)
+		assert.Equal(t, "app.hcl", suite.Cases[0].Module)
+	})
+
+	t.Run("no tests", func(t *testing.T) {
+		_, err := moduletest.Parse("app_test.hcl", []byte(``))
+		assert.EqualError(t, err, "no tests found")
+	})
+
+	t.Run("no expectations", func(t *testing.T) {
+		_, err := moduletest.Parse("app_test.hcl", []byte(`test "a" {}`))
+		assert.EqualError(t, err, `test "a" has no expectations`)
+	})
+}
+
+func TestFind(t *testing.T) {
+	t.Parallel()
+
+	dir := writeModule(t, map[string]string{
+		"app.hcl":              module,
+		"app_test.hcl":         `test "a" { expect "task.x" {} }`,
+		"nested/db_test.hcl":   `test "b" { expect "task.y" {} }`,
+		"nested/db.hcl":        ``,
+		"nested/notes_test.md": ``,
+	})
+	defer os.RemoveAll(dir)
+
+	found, err := moduletest.Find(dir, filepath.Join(dir, "app.hcl"))
+	require.NoError(t, err)
+	assert.Equal(
+		t,
+		[]string{
+			filepath.Join(dir, "app_test.hcl"),
+			filepath.Join(dir, "nested", "db_test.hcl"),
+			filepath.Join(dir, "app.hcl"),
+		},
+		found,
+	)
+}
+
+func writeModule(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "moduletest")
+	require.NoError(t, err)
+
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, ioutil.WriteFile(path, []byte(content), 0644))
+	}
+	return dir
+}
@@ -18,6 +18,7 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"sort"
 	"sync"
 
 	log "github.com/Sirupsen/logrus"
@@ -28,10 +29,20 @@ import (
 // ErrNotFound is returned from Get and friends when the key does not exist
 var ErrNotFound = errors.New("key does not exist")
 
+// MetadataKey is the block of annotations that can be set on any node
+const MetadataKey = "metadata"
+
 // Node represents a node in the parsed module
 type Node struct {
 	*ast.ObjectItem
 
+	// File is the location of the module the node was parsed from. It is
+	// empty if the node's position in the module isn't known.
+	File string
+
+	// overridden has the file each key set by Override came from
+	overridden map[string]string
+
 	values map[string]interface{}
 	once   sync.Once
 }
@@ -82,7 +93,15 @@ func (n *Node) Validate() error {
 		return fmt.Errorf("%s: too many keys", n.Pos())
 	}
 
-	return n.setValues()
+	if err := n.setValues(); err != nil {
+		return err
+	}
+
+	if _, err := n.GetStringMap(MetadataKey); err != nil && err != ErrNotFound {
+		return fmt.Errorf("%s: %s", n.Pos(), err)
+	}
+
+	return nil
 }
 
 // Kind returns the kind of resource this is
@@ -127,6 +146,15 @@ func (n *Node) Group() string {
 	return group
 }
 
+// Metadata returns the annotations set in the node's metadata block
+func (n *Node) Metadata() map[string]string {
+	metadata, err := n.GetStringMap(MetadataKey)
+	if err != nil {
+		return nil
+	}
+	return metadata
+}
+
 func (n *Node) setValues() (err error) {
 	n.once.Do(func() {
 		n.values = map[string]interface{}{}
@@ -190,6 +218,137 @@ func (n *Node) GetStringSlice(key string) (val []string, err error) {
 	return val, nil
 }
 
+// GetStringMap retrieves a block of strings from the values
+func (n *Node) GetStringMap(key string) (val map[string]string, err error) {
+	raw, err := n.Get(key)
+	if err != nil {
+		return nil, err
+	}
+
+	// a block is decoded as a list of maps, one for each time it's given
+	var blocks []map[string]interface{}
+	switch typed := raw.(type) {
+	case map[string]interface{}:
+		blocks = append(blocks, typed)
+	case []map[string]interface{}:
+		blocks = typed
+	default:
+		return nil, n.badTypeError(key, "block", raw)
+	}
+
+	val = map[string]string{}
+	for _, block := range blocks {
+		for name, iface := range block {
+			item, ok := iface.(string)
+			if !ok {
+				return nil, n.badTypeError(key+"."+name, "string", iface)
+			}
+
+			val[name] = item
+		}
+	}
+
+	return val, nil
+}
+
+// Fields returns the keys set in the node, sorted
+func (n *Node) Fields() ([]string, error) {
+	if err := n.setValues(); err != nil {
+		return nil, err
+	}
+
+	var keys []string
+	for key := range n.values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys, nil
+}
+
+// Position returns the file, line, and column where a key is set in the node,
+// or of the node itself if the key isn't found. It is empty if the file isn't
+// known.
+func (n *Node) Position(key string) string {
+	if n.File == "" {
+		return ""
+	}
+
+	pos := n.Pos()
+	file := n.File
+	if items := n.items(key); len(items) > 0 {
+		pos = items[0].Pos()
+		if from, ok := n.overridden[key]; ok {
+			file = from
+		}
+	}
+
+	pos.Filename = file
+	return pos.String()
+}
+
+// Override replaces the keys in the node with the ones set in other, which is
+// a node of the same kind and name from an override file. Keys that other
+// doesn't set are left alone. It returns the keys that were set, sorted.
+func (n *Node) Override(other *Node) ([]string, error) {
+	obj, ok := n.Val.(*ast.ObjectType)
+	if !ok {
+		return nil, fmt.Errorf("%s: cannot override a %T", n.Pos(), n.Val)
+	}
+	with, ok := other.Val.(*ast.ObjectType)
+	if !ok {
+		return nil, fmt.Errorf("%s: cannot override with a %T", other.Pos(), other.Val)
+	}
+
+	// a key can be set more than once, as with repeated blocks, so every item
+	// with the key is replaced by every item setting it in other
+	replaced := make(map[string]bool)
+	for _, item := range with.List.Items {
+		if len(item.Keys) > 0 {
+			key, _ := item.Keys[0].Token.Value().(string)
+			replaced[key] = true
+		}
+	}
+
+	var items []*ast.ObjectItem
+	for _, item := range obj.List.Items {
+		if len(item.Keys) > 0 {
+			if key, _ := item.Keys[0].Token.Value().(string); replaced[key] {
+				continue
+			}
+		}
+		items = append(items, item)
+	}
+	obj.List.Items = append(items, with.List.Items...)
+
+	if n.overridden == nil {
+		n.overridden = make(map[string]string)
+	}
+	var keys []string
+	for key := range replaced {
+		n.overridden[key] = other.File
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	n.values = nil
+	n.once = sync.Once{}
+
+	return keys, n.setValues()
+}
+
+// items returns the items setting a key in the node
+func (n *Node) items(key string) (out []*ast.ObjectItem) {
+	if obj, ok := n.Val.(*ast.ObjectType); ok {
+		for _, item := range obj.List.Items {
+			if len(item.Keys) > 0 && item.Keys[0].Token.Value() == key {
+				out = append(out, item)
+			}
+		}
+	}
+	return out
+}
+
 // GetStrings retrieves all the strings in the node
 func (n *Node) GetStrings() (vals []string, err error) {
 	if err := n.setValues(); err != nil {
@@ -201,6 +360,21 @@ func (n *Node) GetStrings() (vals []string, err error) {
 		toConsider = append(toConsider, val)
 	}
 
+	return stringsIn(toConsider), nil
+}
+
+// GetStringsFor retrieves all the strings set under a key in the node
+func (n *Node) GetStringsFor(key string) ([]string, error) {
+	val, err := n.Get(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return stringsIn([]interface{}{val}), nil
+}
+
+func stringsIn(toConsider []interface{}) (vals []string) {
+
 	for len(toConsider) > 0 {
 		val := toConsider[0]
 		toConsider = toConsider[1:]
@@ -228,7 +402,7 @@ func (n *Node) GetStrings() (vals []string, err error) {
 		}
 	}
 
-	return vals, nil
+	return vals
 }
 
 func (n *Node) badTypeError(key, typ string, val interface{}) error {
@@ -170,6 +170,32 @@ func TestNodeGroup(t *testing.T) {
 	assert.Equal(t, "somegroup", node.Group())
 }
 
+// TestNodeMetadata verifies that a metadata block can be parsed
+func TestNodeMetadata(t *testing.T) {
+	t.Parallel()
+
+	t.Run("block", func(t *testing.T) {
+		node, err := fromString(`task "x" { metadata { owner = "platform" runbook = "https://example.com" } }`)
+		require.NoError(t, err)
+		require.NoError(t, node.Validate())
+		assert.Equal(t, map[string]string{"owner": "platform", "runbook": "https://example.com"}, node.Metadata())
+	})
+
+	t.Run("unset", func(t *testing.T) {
+		node, err := fromString(`task "x" {}`)
+		require.NoError(t, err)
+		assert.Nil(t, node.Metadata())
+	})
+
+	t.Run("not a string", func(t *testing.T) {
+		validateTable(t, `task "x" { metadata { owner = 1 } }`, `1:1: "metadata.owner" is not a string, it is an int`)
+	})
+
+	t.Run("not a block", func(t *testing.T) {
+		validateTable(t, `task "x" { metadata = "owner" }`, `1:1: "metadata" is not a block, it is a string`)
+	})
+}
+
 func TestNodeGet(t *testing.T) {
 	t.Parallel()
 
@@ -305,3 +331,48 @@ module x y {
 	sort.Strings(vals)
 	assert.Equal(t, []string{"key", "nestedKey1", "nestedMap", "nestedValue1", "value"}, vals)
 }
+
+func TestNodePosition(t *testing.T) {
+	t.Parallel()
+
+	node, err := fromString("task \"x\" {\n  check = \"true\"\n\n  apply = \"true\"\n}")
+	require.NoError(t, err)
+
+	assert.Equal(t, "", node.Position("apply"), "position is empty without a file")
+
+	node.File = "main.hcl"
+	assert.Equal(t, "main.hcl:4:3", node.Position("apply"))
+	assert.Equal(t, "main.hcl:1:1", node.Position("missing"))
+
+	fields, err := node.Fields()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"apply", "check"}, fields)
+}
+
+func TestNodeOverride(t *testing.T) {
+	t.Parallel()
+
+	node, err := fromString("task \"x\" {\n  check = \"true\"\n  apply = \"true\"\n}")
+	require.NoError(t, err)
+	node.File = "main.hcl"
+
+	override, err := fromString("task \"x\" {\n  apply = \"echo site\"\n  dir   = \"/srv\"\n}")
+	require.NoError(t, err)
+	override.File = "main_override.hcl"
+
+	keys, err := node.Override(override)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"apply", "dir"}, keys)
+
+	apply, err := node.GetString("apply")
+	require.NoError(t, err)
+	assert.Equal(t, "echo site", apply)
+
+	check, err := node.GetString("check")
+	require.NoError(t, err)
+	assert.Equal(t, "true", check)
+
+	assert.Equal(t, "main.hcl:2:3", node.Position("check"))
+	assert.Equal(t, "main_override.hcl:2:3", node.Position("apply"))
+	assert.Equal(t, "main_override.hcl:3:3", node.Position("dir"))
+}
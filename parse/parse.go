@@ -15,13 +15,58 @@
 package parse
 
 import (
+	"fmt"
+
 	"github.com/hashicorp/go-multierror"
 	"github.com/hashicorp/hcl"
 	"github.com/hashicorp/hcl/hcl/ast"
 )
 
+// MinVersionKey is set at the top level of a module to the oldest version of
+// converge the module can be loaded by
+const MinVersionKey = "min_converge_version"
+
+// Module is the content of a module file
+type Module struct {
+	Resources []*Node
+
+	// MinVersion is the oldest version of converge that can load the module,
+	// if it is set
+	MinVersion string
+}
+
+// ParseModule parses the content of a module file. Besides resources, a
+// module can set the settings that apply to the whole file.
+func ParseModule(content []byte) (*Module, error) {
+	mod := new(Module)
+
+	resources, err := parse(content, func(item *ast.ObjectItem) (bool, error) {
+		if len(item.Keys) != 1 || item.Keys[0].Token.Value() != MinVersionKey {
+			return false, nil
+		}
+
+		lit, ok := item.Val.(*ast.LiteralType)
+		if !ok {
+			return true, fmt.Errorf("%s: %s must be a string", item.Pos(), MinVersionKey)
+		}
+		mod.MinVersion, ok = lit.Token.Value().(string)
+		if !ok {
+			return true, fmt.Errorf("%s: %s must be a string", item.Pos(), MinVersionKey)
+		}
+		return true, nil
+	})
+	mod.Resources = resources
+
+	return mod, err
+}
+
 // Parse content into a bunch of nodes
 func Parse(content []byte) (resources []*Node, err error) {
+	return parse(content, nil)
+}
+
+// parse content into nodes, leaving out the items that setting handles
+func parse(content []byte, setting func(*ast.ObjectItem) (bool, error)) (resources []*Node, err error) {
 	obj, err := hcl.ParseBytes(content)
 	if err != nil {
 		return resources, err
@@ -33,6 +78,16 @@ func Parse(content []byte) (resources []*Node, err error) {
 			return n, true
 		}
 
+		if setting != nil {
+			handled, settingErr := setting(baseItem)
+			if settingErr != nil {
+				err = multierror.Append(err, settingErr)
+			}
+			if handled {
+				return n, false
+			}
+		}
+
 		item := NewNode(baseItem)
 
 		if itemErr := item.Validate(); itemErr != nil {
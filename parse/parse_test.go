@@ -19,6 +19,7 @@ import (
 
 	"github.com/asteris-llc/converge/parse"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestParse(t *testing.T) {
@@ -48,3 +49,28 @@ func TestParseInvalid(t *testing.T) {
 		assert.EqualError(t, err, "1 error(s) occurred:\n\n* 1:1: missing name")
 	}
 }
+
+func TestParseModule(t *testing.T) {
+	t.Parallel()
+
+	t.Run("min version", func(t *testing.T) {
+		mod, err := parse.ParseModule([]byte("min_converge_version = \"0.4.0\"\ntask x {}"))
+
+		require.NoError(t, err)
+		assert.Equal(t, "0.4.0", mod.MinVersion)
+		assert.Equal(t, 1, len(mod.Resources))
+	})
+
+	t.Run("no min version", func(t *testing.T) {
+		mod, err := parse.ParseModule([]byte(`task x {}`))
+
+		require.NoError(t, err)
+		assert.Equal(t, "", mod.MinVersion)
+	})
+
+	t.Run("not a string", func(t *testing.T) {
+		_, err := parse.ParseModule([]byte(`min_converge_version = 4`))
+
+		assert.EqualError(t, err, "1 error(s) occurred:\n\n* 1:1: min_converge_version must be a string")
+	})
+}
@@ -38,6 +38,12 @@ func (c *ConditionalTask) SetExecutionController(ctrl EvaluationController) {
 	c.controller = ctrl
 }
 
+// ShouldEvaluate is true if the case the task is in was chosen, so that the
+// task will be checked and applied
+func (c *ConditionalTask) ShouldEvaluate() bool {
+	return c.controller == nil || c.controller.ShouldEvaluate()
+}
+
 // Apply will conditionally apply a task
 func (c *ConditionalTask) Apply() (resource.TaskStatus, error) {
 	if c.controller.ShouldEvaluate() {
@@ -31,6 +31,7 @@ var keywords = map[string]string{
 	"switch":  "switch",
 	"case":    "case",
 	"default": "default",
+	"on":      "on",
 }
 
 // Switch represents a switch element
@@ -38,6 +39,10 @@ type Switch struct {
 	Name     string
 	Branches []*Case
 	Node     *parse.Node
+
+	// On is the value cases are matched against, if the switch has one. Each
+	// case then lists the values it matches instead of giving a predicate.
+	On string
 }
 
 // IsSwitchNode returns true if the parse node represents a switch statement
@@ -95,6 +100,15 @@ func Cases(s *Switch, data []byte) ([]*Case, error) {
 		return nil, NewTypeError("*ast.ObjectType", s.Node.Val)
 	}
 	for _, item := range asObjType.List.Items {
+		if isOn(item) {
+			on, err := onValue(item)
+			if err != nil {
+				return nil, err
+			}
+			s.On = on
+			continue
+		}
+
 		caseNode := parse.NewNode(item)
 		if itemErr := caseNode.Validate(); itemErr != nil {
 			return nil, itemErr
@@ -105,9 +119,51 @@ func Cases(s *Switch, data []byte) ([]*Case, error) {
 		}
 		cases = append(cases, newCase)
 	}
+
+	if s.On != "" {
+		for _, c := range cases {
+			if c.Name != keywords["default"] {
+				c.Predicate = matchPredicate(s.On, c.Predicate)
+			}
+		}
+	}
+
 	return cases, nil
 }
 
+// isOn returns true if the item is the `on` value of a switch
+func isOn(item *ast.ObjectItem) bool {
+	return len(item.Keys) == 1 && item.Keys[0].Token.Value() == keywords["on"]
+}
+
+// onValue returns the string value of an `on` item
+func onValue(item *ast.ObjectItem) (string, error) {
+	literal, ok := item.Val.(*ast.LiteralType)
+	if !ok {
+		return "", NewTypeError("*ast.LiteralType", item.Val)
+	}
+
+	on, ok := literal.Token.Value().(string)
+	if !ok || strings.TrimSpace(on) == "" {
+		return "", fmt.Errorf("%s: switch `on` must be a non-empty string", item.Pos())
+	}
+	return on, nil
+}
+
+// matchPredicate returns a predicate that is true when on is equal to one of
+// the comma-separated values
+func matchPredicate(on, values string) string {
+	var clauses []string
+	for _, value := range strings.Split(values, ",") {
+		clauses = append(clauses, fmt.Sprintf("(eq `%s` `%s`)", on, strings.TrimSpace(value)))
+	}
+
+	if len(clauses) == 1 {
+		return strings.TrimSuffix(strings.TrimPrefix(clauses[0], "("), ")")
+	}
+	return "or " + strings.Join(clauses, " ")
+}
+
 // ParseSwitchConditional generates a case statement from an ast node at the
 // switch statement level.  The node should be an *ast.ObjectItem whose Val is
 // an *ast.ObjectType
@@ -132,6 +132,58 @@ task.query "query" {
 	})
 }
 
+// TestLoadSwitchOn tests loading a switch whose cases match values of its `on`
+// key
+func TestLoadSwitchOn(t *testing.T) {
+	var sampleStatement = `
+switch "packages" {
+	on = "{{platform.Family}}"
+
+	case "rhel" "yum" {
+		task.query "foo" {
+			query = "echo foo"
+		}
+	}
+	case "debian, alpine" "other" {
+		task.query "bar" {
+			query = "echo bar"
+		}
+	}
+	default {
+		task.query "baz" {
+			query = "echo baz"
+		}
+	}
+}
+`
+
+	nodes, err := parse.Parse([]byte(sampleStatement))
+	require.NoError(t, err)
+
+	t.Run("sets on", func(t *testing.T) {
+		switchStatement, err := control.NewSwitch(nodes[0], []byte(sampleStatement))
+		require.NoError(t, err)
+		assert.Equal(t, "{{platform.Family}}", switchStatement.On)
+	})
+
+	t.Run("generates predicates matching on", func(t *testing.T) {
+		switchStatement, err := control.NewSwitch(nodes[0], []byte(sampleStatement))
+		require.NoError(t, err)
+		require.Equal(t, 3, len(switchStatement.Branches))
+		assert.Equal(t, "eq `{{platform.Family}}` `rhel`", switchStatement.Branches[0].Predicate)
+		assert.Equal(t, "or (eq `{{platform.Family}}` `debian`) (eq `{{platform.Family}}` `alpine`)", switchStatement.Branches[1].Predicate)
+		assert.Equal(t, "true", switchStatement.Branches[2].Predicate)
+	})
+
+	t.Run("requires a string", func(t *testing.T) {
+		statement := "switch \"x\" {\n\ton = 1\n}\n"
+		nodes, err := parse.Parse([]byte(statement))
+		require.NoError(t, err)
+		_, err = control.NewSwitch(nodes[0], []byte(statement))
+		assert.Error(t, err)
+	})
+}
+
 // TestSwitchNode tests the generation of a *parse.Node with the correct
 // metadata about the switch node
 func TestSwitchNode(t *testing.T) {
@@ -0,0 +1,103 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plan
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/asteris-llc/converge/graph"
+	"github.com/asteris-llc/converge/resource"
+)
+
+// ChangeLimiter is implemented by tasks that limit how many resources beneath
+// them may change in a single run, like modules with `max_changes`
+type ChangeLimiter interface {
+	// ChangeLimit returns the maximum number of changes, or 0 for no limit
+	ChangeLimit() int
+}
+
+// LimitError is returned by CheckLimits when a plan changes too many resources
+type LimitError struct {
+	ID      string
+	Changes int
+	Max     int
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("%s: plan would change %d resources, more than the limit of %d", e.ID, e.Changes, e.Max)
+}
+
+// CheckLimits checks a planned graph against a maximum number of changed
+// resources for the whole graph, and against the limit of every node
+// implementing ChangeLimiter for the nodes beneath it. A max of 0 means there is
+// no limit for the whole graph.
+func CheckLimits(g *graph.Graph, max int) error {
+	root, err := g.Root()
+	if err != nil {
+		return err
+	}
+
+	if err := checkLimit(g, root, max); err != nil {
+		return err
+	}
+
+	ids := g.Vertices()
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		meta, ok := g.Get(id)
+		if !ok {
+			continue
+		}
+
+		task, ok := resource.ResolveTask(meta.Value())
+		if !ok {
+			continue
+		}
+
+		if limiter, ok := task.(ChangeLimiter); ok {
+			if err := checkLimit(g, id, limiter.ChangeLimit()); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func checkLimit(g *graph.Graph, id string, max int) error {
+	if max <= 0 {
+		return nil
+	}
+
+	changes := 0
+	for _, descendent := range g.Descendents(id) {
+		meta, ok := g.Get(descendent)
+		if !ok {
+			continue
+		}
+
+		if result, ok := meta.Value().(*Result); ok && result.Status != nil && result.HasChanges() {
+			changes++
+		}
+	}
+
+	if changes > max {
+		return &LimitError{ID: id, Changes: changes, Max: max}
+	}
+
+	return nil
+}
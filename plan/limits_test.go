@@ -0,0 +1,71 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plan_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/asteris-llc/converge/graph"
+	"github.com/asteris-llc/converge/graph/node"
+	"github.com/asteris-llc/converge/helpers/faketask"
+	"github.com/asteris-llc/converge/helpers/logging"
+	"github.com/asteris-llc/converge/plan"
+	"github.com/asteris-llc/converge/resource/module"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckLimits(t *testing.T) {
+	defer logging.HideLogs(t)()
+
+	g := graph.New()
+	g.Add(node.New("root", &module.Module{}))
+	g.Add(node.New("root/a", faketask.WillChange()))
+	g.Add(node.New("root/b", faketask.NoOp()))
+	g.Add(node.New("root/module.inner", &module.Module{MaxChanges: 1}))
+	g.Add(node.New("root/module.inner/c", faketask.WillChange()))
+	g.Add(node.New("root/module.inner/d", faketask.WillChange()))
+
+	g.ConnectParent("root", "root/a")
+	g.ConnectParent("root", "root/b")
+	g.ConnectParent("root", "root/module.inner")
+	g.ConnectParent("root/module.inner", "root/module.inner/c")
+	g.ConnectParent("root/module.inner", "root/module.inner/d")
+
+	require.NoError(t, g.Validate())
+
+	planned, err := plan.Plan(context.Background(), g)
+	require.NoError(t, err)
+
+	t.Run("module limit", func(t *testing.T) {
+		err := plan.CheckLimits(planned, 0)
+		assert.EqualError(t, err, "root/module.inner: plan would change 2 resources, more than the limit of 1")
+	})
+
+	t.Run("global limit", func(t *testing.T) {
+		err := plan.CheckLimits(planned, 2)
+		assert.Equal(t, &plan.LimitError{ID: "root", Changes: 3, Max: 2}, err)
+	})
+
+	t.Run("within limits", func(t *testing.T) {
+		planned.Add(node.New("root/module.inner", &plan.Result{
+			Task:   &module.Module{MaxChanges: 2},
+			Status: &module.Module{},
+		}))
+
+		assert.NoError(t, plan.CheckLimits(planned, 3))
+	})
+}
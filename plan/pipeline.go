@@ -110,6 +110,10 @@ func (g *pipelineGen) PlanNode(taski interface{}) (interface{}, error) {
 	if err != nil {
 		return nil, fmt.Errorf("unable to get renderer for %s", g.ID)
 	}
+	if watcher, ok := twrapper.Task.(resource.RebootWatcher); ok {
+		watcher.WatchReboots(g.pendingReboots())
+	}
+
 	status, err := twrapper.Task.Check(renderer)
 
 	// create empty Status structure, if it not created in .Check()
@@ -124,6 +128,12 @@ func (g *pipelineGen) PlanNode(taski interface{}) (interface{}, error) {
 		inner.SetError(err)
 	}
 
+	if task, ok := resource.ResolveTask(twrapper.Task); ok {
+		if ignorer, ok := task.(resource.ChangeIgnorer); ok {
+			status = resource.IgnoreChanges(status, ignorer.IgnoredChanges())
+		}
+	}
+
 	return &Result{
 		Status: status,
 		Task:   twrapper.Task,
@@ -131,6 +141,23 @@ func (g *pipelineGen) PlanNode(taski interface{}) (interface{}, error) {
 	}, nil
 }
 
+// pendingReboots collects the reboots required by the dependencies of the
+// current node, keyed by node ID
+func (g *pipelineGen) pendingReboots() map[string][]string {
+	reboots := map[string][]string{}
+	for _, depID := range g.Graph.Dependencies(g.ID) {
+		meta, ok := g.Graph.Get(depID)
+		if !ok {
+			continue
+		}
+
+		if reasons := resource.PendingReboots(meta.Value()); len(reasons) > 0 {
+			reboots[depID] = reasons
+		}
+	}
+	return reboots
+}
+
 func (g *pipelineGen) Renderer(id string) (*render.Renderer, error) {
 	return g.RenderingPlant.GetRenderer(id)
 }
@@ -40,6 +40,7 @@ func WithNotify(ctx context.Context, in *graph.Graph, notify *graph.Notifier) (*
 	if err != nil {
 		return nil, err
 	}
+	renderingPlant.Previous = render.SnapshotFrom(ctx)
 
 	out, err := in.Transform(ctx,
 		notify.Transform(func(meta *node.Node, out *graph.Graph) error {
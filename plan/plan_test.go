@@ -92,6 +92,58 @@ func TestPlanErrorsBelow(t *testing.T) {
 	assert.EqualError(t, rootNode.Error(), `error in dependency "root/err"`)
 }
 
+// TestPlanPendingReboots tests that reboots required by dependencies are
+// passed to tasks that watch for them
+func TestPlanPendingReboots(t *testing.T) {
+	defer logging.HideLogs(t)()
+
+	g := graph.New()
+	watcher := faketask.RebootWatcher()
+	g.Add(node.New("root", watcher))
+	g.Add(node.New("root/kernel", faketask.RebootRequired("kernel parameters changed")))
+	g.Add(node.New("root/noop", faketask.NoOp()))
+
+	g.Connect("root", "root/kernel")
+	g.Connect("root", "root/noop")
+
+	require.NoError(t, g.Validate())
+
+	out, err := plan.Plan(context.Background(), g)
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string][]string{"root/kernel": {"kernel parameters changed"}}, watcher.Reboots)
+	assert.Equal(t, []string{"kernel parameters changed"}, getResult(t, out, "root/kernel").PendingReboots())
+}
+
+// TestPlanIgnoredChanges tests that changes ignored by a task are left out of
+// its result
+func TestPlanIgnoredChanges(t *testing.T) {
+	defer logging.HideLogs(t)()
+
+	g := graph.New()
+	g.Add(node.New("root", faketask.NoOp()))
+	g.Add(node.New("root/ignored", faketask.ChangeIgnorer([]string{"mtime"}, []string{"mtime"})))
+	g.Add(node.New("root/partial", faketask.ChangeIgnorer([]string{"mtime", "content"}, []string{"mt*"})))
+
+	g.Connect("root", "root/ignored")
+	g.Connect("root", "root/partial")
+
+	require.NoError(t, g.Validate())
+
+	out, err := plan.Plan(context.Background(), g)
+	require.NoError(t, err)
+
+	ignored := getResult(t, out, "root/ignored")
+	assert.False(t, ignored.HasChanges())
+	assert.Empty(t, ignored.Status.Diffs())
+	assert.Contains(t, ignored.Status.Messages(), "ignored changes to mtime")
+
+	partial := getResult(t, out, "root/partial")
+	assert.True(t, partial.HasChanges())
+	assert.Len(t, partial.Status.Diffs(), 1)
+	assert.Contains(t, partial.Status.Diffs(), "content")
+}
+
 func getResult(t *testing.T, src *graph.Graph, key string) *plan.Result {
 	meta, ok := src.Get(key)
 	require.True(t, ok, "%q was not present in the graph", key)
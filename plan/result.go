@@ -35,6 +35,12 @@ func (r *Result) HasChanges() bool { return r.Status.HasChanges() }
 // Error returns the error assigned to this Result, if any
 func (r *Result) Error() error { return r.Err }
 
+// PendingReboots returns the reboots required by the task, if any
+func (r *Result) PendingReboots() []string { return resource.PendingReboots(r.Status) }
+
+// Warnings returns the warnings given by the task, if any
+func (r *Result) Warnings() []resource.Warning { return resource.Warnings(r.Status) }
+
 // GetStatus returns the current task status
 func (r *Result) GetStatus() resource.TaskStatus { return r.Status }
 
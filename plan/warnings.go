@@ -0,0 +1,70 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plan
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/asteris-llc/converge/graph"
+	"github.com/asteris-llc/converge/resource"
+)
+
+// WarningsError is returned by CheckWarnings when a plan has warnings
+type WarningsError struct {
+	// Warnings are the warnings of each node with any, keyed by ID
+	Warnings map[string][]resource.Warning
+}
+
+func (e *WarningsError) Error() string {
+	var ids []string
+	count := 0
+	for id, warnings := range e.Warnings {
+		ids = append(ids, id)
+		count += len(warnings)
+	}
+	sort.Strings(ids)
+
+	var lines []string
+	for _, id := range ids {
+		for _, warning := range e.Warnings[id] {
+			lines = append(lines, fmt.Sprintf("%s: %s", id, warning))
+		}
+	}
+
+	return fmt.Sprintf("plan has %d warnings, which are errors with strict warnings: %s", count, strings.Join(lines, "; "))
+}
+
+// CheckWarnings returns a WarningsError if any node in a planned graph has
+// warnings
+func CheckWarnings(g *graph.Graph) error {
+	warnings := map[string][]resource.Warning{}
+	for _, id := range g.Vertices() {
+		meta, ok := g.Get(id)
+		if !ok {
+			continue
+		}
+
+		if found := resource.Warnings(meta.Value()); len(found) > 0 {
+			warnings[id] = found
+		}
+	}
+
+	if len(warnings) > 0 {
+		return &WarningsError{Warnings: warnings}
+	}
+	return nil
+}
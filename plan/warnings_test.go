@@ -0,0 +1,64 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plan_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/asteris-llc/converge/graph"
+	"github.com/asteris-llc/converge/graph/node"
+	"github.com/asteris-llc/converge/helpers/faketask"
+	"github.com/asteris-llc/converge/helpers/logging"
+	"github.com/asteris-llc/converge/plan"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/module"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckWarnings(t *testing.T) {
+	defer logging.HideLogs(t)()
+
+	deprecated := resource.Warning{Kind: resource.WarningDeprecated, Field: "links", Message: "use a network"}
+
+	g := graph.New()
+	g.Add(node.New("root", &module.Module{}))
+	g.Add(node.New("root/a", faketask.Warn(deprecated)))
+	g.Add(node.New("root/b", faketask.NoOp()))
+
+	g.ConnectParent("root", "root/a")
+	g.ConnectParent("root", "root/b")
+
+	require.NoError(t, g.Validate())
+
+	t.Run("warnings", func(t *testing.T) {
+		planned, err := plan.Plan(context.Background(), g)
+		require.NoError(t, err)
+
+		err = plan.CheckWarnings(planned)
+		assert.Equal(t, &plan.WarningsError{Warnings: map[string][]resource.Warning{"root/a": {deprecated}}}, err)
+		assert.EqualError(t, err, `plan has 1 warnings, which are errors with strict warnings: root/a: deprecated field "links": use a network`)
+	})
+
+	t.Run("no warnings", func(t *testing.T) {
+		g.Add(node.New("root/a", faketask.NoOp()))
+
+		planned, err := plan.Plan(context.Background(), g)
+		require.NoError(t, err)
+
+		assert.NoError(t, plan.CheckWarnings(planned))
+	})
+}
@@ -0,0 +1,270 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package policy
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/asteris-llc/converge/graph"
+	"github.com/asteris-llc/converge/render/preprocessor"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/hashicorp/hcl"
+	"github.com/pkg/errors"
+)
+
+// Rule checks a single field of every resource of a kind. A value violates the
+// rule if it matches any of the Deny expressions, or if Allow is set and it
+// matches none of them.
+type Rule struct {
+	Name string `hcl:",key"`
+
+	// Kind is the resource the rule applies to, such as "file.mode"
+	Kind string `hcl:"kind"`
+
+	// Field is the field that is checked, written as in a lookup
+	Field string `hcl:"field"`
+
+	Deny  []string `hcl:"deny"`
+	Allow []string `hcl:"allow"`
+
+	// Message explains the rule to whoever broke it
+	Message string `hcl:"message"`
+
+	deny  []*regexp.Regexp
+	allow []*regexp.Regexp
+}
+
+// Policy is a set of rules that a rendered graph must follow
+type Policy struct {
+	Rules []*Rule `hcl:"rule"`
+}
+
+// Parse reads a policy from HCL source
+func Parse(src []byte) (*Policy, error) {
+	policy := new(Policy)
+	if err := hcl.Unmarshal(src, policy); err != nil {
+		return nil, err
+	}
+
+	for _, rule := range policy.Rules {
+		if err := rule.compile(); err != nil {
+			return nil, errors.Wrapf(err, "rule %q", rule.Name)
+		}
+	}
+
+	return policy, nil
+}
+
+// Load reads and combines the policies in the given files
+func Load(paths ...string) (*Policy, error) {
+	combined := new(Policy)
+
+	for _, path := range paths {
+		src, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		policy, err := Parse(src)
+		if err != nil {
+			return nil, errors.Wrap(err, path)
+		}
+
+		combined.Rules = append(combined.Rules, policy.Rules...)
+	}
+
+	return combined, nil
+}
+
+func (r *Rule) compile() error {
+	if r.Kind == "" {
+		return errors.New("kind is required")
+	}
+	if r.Field == "" {
+		return errors.New("field is required")
+	}
+	if len(r.Deny) == 0 && len(r.Allow) == 0 {
+		return errors.New("one of deny or allow is required")
+	}
+
+	var err error
+	if r.deny, err = compileAll(r.Deny); err != nil {
+		return errors.Wrap(err, "deny")
+	}
+	if r.allow, err = compileAll(r.Allow); err != nil {
+		return errors.Wrap(err, "allow")
+	}
+	return nil
+}
+
+func compileAll(patterns []string) ([]*regexp.Regexp, error) {
+	var out []*regexp.Regexp
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, re)
+	}
+	return out, nil
+}
+
+// Violation is a value that broke a rule
+type Violation struct {
+	Rule    string
+	ID      string
+	Field   string
+	Value   string
+	Message string
+}
+
+func (v *Violation) String() string {
+	out := fmt.Sprintf("%s: %s %q violates %q", v.ID, v.Field, v.Value, v.Rule)
+	if v.Message != "" {
+		out += ": " + v.Message
+	}
+	return out
+}
+
+// Error is returned when a graph violates a policy
+type Error struct {
+	Violations []*Violation
+}
+
+func (e *Error) Error() string {
+	lines := []string{fmt.Sprintf("%d policy violation(s)", len(e.Violations))}
+	for _, violation := range e.Violations {
+		lines = append(lines, violation.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Check checks every rule against the tasks in a planned graph, returning an
+// *Error listing the violations if there are any. A policy with no rules, or
+// a nil policy, always passes.
+func (p *Policy) Check(g *graph.Graph) error {
+	if p == nil || len(p.Rules) == 0 {
+		return nil
+	}
+
+	var violations []*Violation
+	for _, id := range g.Vertices() {
+		meta, ok := g.Get(id)
+		if !ok {
+			continue
+		}
+
+		task, ok := resource.ResolveTask(meta.Value())
+		if !ok {
+			continue
+		}
+
+		for _, rule := range p.Rules {
+			if !strings.HasPrefix(graph.BaseID(meta.ID), rule.Kind+".") {
+				continue
+			}
+
+			value, err := preprocessor.EvalTerms(task, preprocessor.SplitTerms(rule.Field)...)
+			if err != nil {
+				return errors.Wrapf(err, "rule %q: %s", rule.Name, meta.ID)
+			}
+
+			for _, str := range Stringify(value) {
+				if rule.violatedBy(str) {
+					violations = append(violations, &Violation{
+						Rule:    rule.Name,
+						ID:      meta.ID,
+						Field:   rule.Field,
+						Value:   str,
+						Message: rule.Message,
+					})
+				}
+			}
+		}
+	}
+
+	if len(violations) == 0 {
+		return nil
+	}
+
+	sort.Sort(byID(violations))
+	return &Error{Violations: violations}
+}
+
+func (r *Rule) violatedBy(value string) bool {
+	for _, re := range r.deny {
+		if re.MatchString(value) {
+			return true
+		}
+	}
+
+	if len(r.allow) == 0 {
+		return false
+	}
+	for _, re := range r.allow {
+		if re.MatchString(value) {
+			return false
+		}
+	}
+	return true
+}
+
+// Stringify formats a value the way a lookup would. Each element of a slice
+// is formatted on its own, and file modes are written in octal so that rules
+// can be written the way modes are in modules.
+func Stringify(value interface{}) []string {
+	val := reflect.ValueOf(value)
+	for val.Kind() == reflect.Ptr {
+		if val.IsNil() {
+			return nil
+		}
+		val = val.Elem()
+	}
+
+	if !val.IsValid() {
+		return nil
+	}
+
+	if mode, ok := val.Interface().(os.FileMode); ok {
+		return []string{fmt.Sprintf("%04o", uint32(mode))}
+	}
+
+	if val.Kind() == reflect.Slice && val.Type().Elem().Kind() != reflect.Uint8 {
+		var out []string
+		for i := 0; i < val.Len(); i++ {
+			out = append(out, Stringify(val.Index(i).Interface())...)
+		}
+		return out
+	}
+
+	return []string{fmt.Sprintf("%v", val.Interface())}
+}
+
+type byID []*Violation
+
+func (b byID) Len() int      { return len(b) }
+func (b byID) Swap(i, j int) { b[i], b[j] = b[j], b[i] }
+func (b byID) Less(i, j int) bool {
+	if b[i].ID != b[j].ID {
+		return b[i].ID < b[j].ID
+	}
+	return b[i].Rule < b[j].Rule
+}
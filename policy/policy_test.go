@@ -0,0 +1,123 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package policy_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/asteris-llc/converge/graph"
+	"github.com/asteris-llc/converge/graph/node"
+	"github.com/asteris-llc/converge/policy"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const rules = `
+rule "no-world-writable" {
+  kind    = "file.mode"
+  field   = "mode"
+  deny    = ["^0?[0-7]{2}[2367]$"]
+  message = "use 0644 or stricter"
+}
+
+rule "trusted-repos" {
+  kind  = "package.rpm"
+  field = "repos"
+  allow = ["^base$", "^updates$"]
+}
+`
+
+type fakeTask struct {
+	Mode  os.FileMode
+	Repos []string
+}
+
+func (f *fakeTask) Check(resource.Renderer) (resource.TaskStatus, error) { return nil, nil }
+func (f *fakeTask) Apply() (resource.TaskStatus, error)                  { return nil, nil }
+
+func planned(tasks map[string]*fakeTask) *graph.Graph {
+	g := graph.New()
+	g.Add(node.New("root", nil))
+	for id, task := range tasks {
+		g.Add(node.New(id, task))
+		g.ConnectParent("root", id)
+	}
+	return g
+}
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	t.Run("valid", func(t *testing.T) {
+		p, err := policy.Parse([]byte(rules))
+		require.NoError(t, err)
+
+		require.Len(t, p.Rules, 2)
+		assert.Equal(t, "no-world-writable", p.Rules[0].Name)
+		assert.Equal(t, "file.mode", p.Rules[0].Kind)
+		assert.Equal(t, []string{"^base$", "^updates$"}, p.Rules[1].Allow)
+	})
+
+	for _, test := range []struct {
+		src string
+		err string
+	}{
+		{`rule "x" { field = "mode" deny = ["."] }`, `rule "x": kind is required`},
+		{`rule "x" { kind = "file.mode" deny = ["."] }`, `rule "x": field is required`},
+		{`rule "x" { kind = "file.mode" field = "mode" }`, `rule "x": one of deny or allow is required`},
+		{`rule "x" { kind = "file.mode" field = "mode" deny = ["("] }`, "rule \"x\": deny: error parsing regexp: missing closing ): `(`"},
+	} {
+		t.Run(test.err, func(t *testing.T) {
+			_, err := policy.Parse([]byte(test.src))
+			assert.EqualError(t, err, test.err)
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	t.Parallel()
+
+	p, err := policy.Parse([]byte(rules))
+	require.NoError(t, err)
+
+	t.Run("passes", func(t *testing.T) {
+		assert.NoError(t, p.Check(planned(map[string]*fakeTask{
+			"root/file.mode.a":    {Mode: 0644},
+			"root/package.rpm.b":  {Repos: []string{"base"}},
+			"root/file.content.c": {Mode: 0777},
+		})))
+	})
+
+	t.Run("violations", func(t *testing.T) {
+		err := p.Check(planned(map[string]*fakeTask{
+			"root/file.mode.a":   {Mode: 0777},
+			"root/package.rpm.b": {Repos: []string{"base", "random"}},
+		}))
+		require.IsType(t, &policy.Error{}, err)
+
+		violations := err.(*policy.Error).Violations
+		require.Len(t, violations, 2)
+		assert.Equal(t, `root/file.mode.a: mode "0777" violates "no-world-writable": use 0644 or stricter`, violations[0].String())
+		assert.Equal(t, `root/package.rpm.b: repos "random" violates "trusted-repos"`, violations[1].String())
+		assert.Contains(t, err.Error(), "2 policy violation(s)")
+	})
+
+	t.Run("no rules", func(t *testing.T) {
+		var empty *policy.Policy
+		assert.NoError(t, empty.Check(planned(map[string]*fakeTask{"root/file.mode.a": {Mode: 0777}})))
+	})
+}
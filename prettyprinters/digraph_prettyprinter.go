@@ -73,6 +73,14 @@ type NodePrinter interface {
 	DrawNode(graph *graph.Graph, nodeID string) (Renderable, error)
 }
 
+// NodeOrderer can be implemented by printers that draw nodes in a particular
+// order. Otherwise nodes are drawn in no particular order.
+type NodeOrderer interface {
+	// OrderNodes will be given a graph and the IDs of the nodes about to be
+	// drawn, and should return them in the order they are to be drawn.
+	OrderNodes(graph *graph.Graph, nodeIDs []string) []string
+}
+
 // EdgeSectionPrinter should be implemented by printers that want to render edge sections (TODO: what are these?)
 type EdgeSectionPrinter interface {
 	// StartEdgeSection will be given a graph and should return a string used to
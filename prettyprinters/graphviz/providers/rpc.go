@@ -18,6 +18,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+	"unicode"
 
 	pp "github.com/asteris-llc/converge/prettyprinters"
 	"github.com/asteris-llc/converge/prettyprinters/graphviz"
@@ -138,6 +139,8 @@ func (p RPCProvider) VertexGetLabel(e graphviz.GraphEntity) (pp.VisibleRenderabl
 // VertexGetProperties sets graphviz attributes based on the type of the
 // resource. Specifically, we set the shape to 'component' for Shell preparers
 // and 'tab' for templates, and we set the entire root node to be invisible.
+// The node's metadata is added as attributes prefixed with "metadata_", which
+// graphviz ignores but other tools reading the DOT output can use.
 func (p RPCProvider) VertexGetProperties(e graphviz.GraphEntity) graphviz.PropertySet {
 	properties := make(map[string]string)
 
@@ -146,6 +149,10 @@ func (p RPCProvider) VertexGetProperties(e graphviz.GraphEntity) graphviz.Proper
 		return properties
 	}
 
+	for key, value := range val.Metadata {
+		properties[metadataAttribute(key)] = strings.Replace(value, `"`, `\"`, -1)
+	}
+
 	switch val.Kind {
 	case "task":
 		properties["shape"] = "component"
@@ -163,6 +170,17 @@ func (p RPCProvider) VertexGetProperties(e graphviz.GraphEntity) graphviz.Proper
 	return properties
 }
 
+// metadataAttribute names the attribute for a metadata key, replacing anything
+// that can't be in an unquoted DOT ID
+func metadataAttribute(key string) string {
+	return "metadata_" + strings.Map(func(r rune) rune {
+		if r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return '_'
+	}, key)
+}
+
 // EdgeGetProperties sets attributes for graph edges, specifically making edges
 // originating from the Root node invisible.
 func (p RPCProvider) EdgeGetProperties(src graphviz.GraphEntity, dst graphviz.GraphEntity) graphviz.PropertySet {
@@ -17,22 +17,59 @@ package human
 import (
 	"bytes"
 	"fmt"
+	"sort"
 	"strings"
 	"sync"
 	"text/tabwriter"
 	"text/template"
 
 	"github.com/asteris-llc/converge/graph"
+	"github.com/asteris-llc/converge/helpers/patch"
 	pp "github.com/asteris-llc/converge/prettyprinters"
 	"github.com/pkg/errors"
 )
 
+// Verbosity is how much of each node the printer shows
+type Verbosity int
+
+const (
+	// Normal shows the details of nodes with changes or errors, and a single
+	// line for each of the others
+	Normal Verbosity = iota
+
+	// Quiet shows only nodes with changes or errors, without their messages
+	Quiet
+
+	// Verbose shows the details of every node
+	Verbose
+)
+
 // Printer for human-readable output
 type Printer struct {
-	Color  bool // color output
-	Filter FilterFunc
+	Color     bool // color output
+	Filter    FilterFunc
+	Verbosity Verbosity
+
+	// grouped is true if the nodes being printed are in more than one module,
+	// in which case each module gets a heading. width is the length of the
+	// longest ID, for aligning single-line nodes. Both are set by StartPP.
+	grouped bool
+	width   int
+
+	// group is the module of the last node drawn, and compact is true if it
+	// was drawn on a single line
+	group   string
+	compact bool
 }
 
+// unifiedLines is the number of lines above which a change to a multi-line
+// value is shown as a unified diff instead of in full
+const unifiedLines = 10
+
+// diffContext is the number of unchanged lines shown around each change in a
+// unified diff
+const diffContext = 3
+
 var (
 	funcs   = map[string]interface{}{}
 	funcsMu sync.Mutex
@@ -63,11 +100,35 @@ func (p *Printer) InitColors() {
 	p.funcsMapWrite("white", p.styled(func(in string) string { return "\x1b[37m" + in + reset }))
 }
 
-// StartPP does nothing, but is required to satisfy the GraphPrinter interface
+// StartPP looks at the nodes that will be drawn, to find out whether they
+// need to be grouped and how wide their IDs are
 func (p *Printer) StartPP(g *graph.Graph) (pp.Renderable, error) {
+	groups := map[string]struct{}{}
+	p.width, p.group, p.compact = 0, "", false
+
+	for _, id := range g.Vertices() {
+		if _, ok := p.drawable(g, id); !ok {
+			continue
+		}
+
+		groups[graph.ParentID(id)] = struct{}{}
+		if len(id) > p.width {
+			p.width = len(id)
+		}
+	}
+
+	p.grouped = len(groups) > 1
 	return pp.HiddenString(), nil
 }
 
+// OrderNodes sorts nodes by their module and then by ID, so that the nodes of
+// each module are drawn together
+func (p *Printer) OrderNodes(g *graph.Graph, ids []string) []string {
+	out := append([]string(nil), ids...)
+	sort.Sort(byModule(out))
+	return out
+}
+
 // FinishPP provides summary statistics about the printed graph
 func (p *Printer) FinishPP(g *graph.Graph) (pp.Renderable, error) {
 	tmpl, err := p.template("{{if gt (len .Errors) 0}}{{red \"Summary\"}}{{else}}{{green \"Summary\"}}{{end}}: {{len .Errors}} errors, {{.ChangesCount}} changes{{if .Errors}}\n{{range .Errors}}\n * {{.}}{{end}}{{end}}\n")
@@ -96,14 +157,21 @@ func (p *Printer) FinishPP(g *graph.Graph) (pp.Renderable, error) {
 		}
 
 		if err = printable.Error(); err != nil {
+			name := id
+			if meta.Position != "" {
+				name = fmt.Sprintf("%s (%s)", id, meta.Position)
+			}
 			counts.Errors = append(
 				counts.Errors,
-				errors.Wrap(err, id),
+				errors.Wrap(err, name),
 			)
 		}
 	}
 
 	var buf bytes.Buffer
+	if p.compact {
+		buf.WriteString("\n")
+	}
 	err = tmpl.Execute(&buf, counts)
 
 	return &buf, err
@@ -116,23 +184,42 @@ func (p *Printer) DrawNode(g *graph.Graph, id string) (pp.Renderable, error) {
 		return pp.HiddenString(), nil
 	}
 
-	printable, ok := meta.Value().(Printable)
-	if !ok {
+	if _, ok := meta.Value().(Printable); !ok {
 		return pp.HiddenString(), errors.New("cannot print values that don't implement Printable")
 	}
 
-	if !p.Filter(id, printable) {
+	printable, ok := p.drawable(g, id)
+	if !ok {
 		return pp.HiddenString(), nil
 	}
 
-	tmpl, err := p.template(`{{if .Error}}{{red .ID}}{{else if .HasChanges}}{{yellow .ID}}{{else}}{{.ID}}{{end}}:
+	var out bytes.Buffer
+	p.drawGroup(&out, id)
+
+	if p.Verbosity != Verbose && !changedOrFailed(printable) {
+		fmt.Fprintf(&out, "%s%s OK\n", p.getFunc("green")(id+":"), pad(p.width-len(id)))
+		p.compact = true
+		return &out, nil
+	}
+
+	if p.compact {
+		out.WriteString("\n")
+		p.compact = false
+	}
+
+	tmpl, err := p.template(`{{if .Error}}{{red .ID}}{{else if .HasChanges}}{{yellow .ID}}{{else}}{{green .ID}}{{end}}:
 	{{- if .Error}}
 	{{red "Error"}}: {{.Error}}
+	{{- if .Position}}
+	At: {{.Position}}
+	{{- end}}
 	{{- end}}
+	{{- if not .Quiet}}
 	Messages:
 	{{- range $msg := .Messages}}
 	{{indent $msg}}
 	{{- end}}
+	{{- end}}
 	Has Changes: {{if .HasChanges}}{{yellow "yes"}}{{else}}no{{end}}
 	Changes:
 		{{- range $key, $values := .Changes}}
@@ -144,8 +231,8 @@ func (p *Printer) DrawNode(g *graph.Graph, id string) (pp.Renderable, error) {
 		return pp.HiddenString(), err
 	}
 
-	var intermediate, out bytes.Buffer
-	err = tmpl.Execute(&intermediate, &printerNode{ID: id, Printable: printable})
+	var intermediate bytes.Buffer
+	err = tmpl.Execute(&intermediate, &printerNode{ID: id, Position: meta.Position, Printable: printable, Quiet: p.Verbosity == Quiet})
 	if err != nil {
 		return pp.HiddenString(), err
 	}
@@ -156,6 +243,65 @@ func (p *Printer) DrawNode(g *graph.Graph, id string) (pp.Renderable, error) {
 	return &out, err
 }
 
+// drawable returns the printable value of the node if it is to be drawn
+func (p *Printer) drawable(g *graph.Graph, id string) (Printable, bool) {
+	meta, ok := g.Get(id)
+	if !ok {
+		return nil, false
+	}
+
+	printable, ok := meta.Value().(Printable)
+	if !ok || !p.Filter(id, printable) {
+		return nil, false
+	}
+
+	if p.Verbosity == Quiet && !changedOrFailed(printable) {
+		return nil, false
+	}
+
+	return printable, true
+}
+
+// drawGroup writes a heading for the module of the node if it is the first
+// node drawn in that module
+func (p *Printer) drawGroup(out *bytes.Buffer, id string) {
+	group := graph.ParentID(id)
+	if !p.grouped || group == p.group {
+		return
+	}
+
+	if p.compact {
+		out.WriteString("\n")
+		p.compact = false
+	}
+
+	p.group = group
+	fmt.Fprintf(out, "%s\n\n", p.getFunc("bold")("Module "+group))
+}
+
+func changedOrFailed(printable Printable) bool {
+	return printable.HasChanges() || printable.Error() != nil
+}
+
+func pad(n int) string {
+	if n <= 0 {
+		return ""
+	}
+	return strings.Repeat(" ", n)
+}
+
+// byModule sorts IDs by their parent and then by the IDs themselves
+type byModule []string
+
+func (b byModule) Len() int      { return len(b) }
+func (b byModule) Swap(i, j int) { b[i], b[j] = b[j], b[i] }
+func (b byModule) Less(i, j int) bool {
+	if left, right := graph.ParentID(b[i]), graph.ParentID(b[j]); left != right {
+		return left < right
+	}
+	return b[i] < b[j]
+}
+
 func (p *Printer) getFunc(key string) func(string) string {
 	funcsMu.Lock()
 	defer funcsMu.Unlock()
@@ -195,6 +341,11 @@ func (p *Printer) diff(before, after string) (string, error) {
 		), nil
 	}
 
+	if strings.Contains(before, "\n") && strings.Contains(after, "\n") &&
+		(strings.Count(before, "\n") > unifiedLines || strings.Count(after, "\n") > unifiedLines) {
+		return "\n" + p.indent(p.indent(p.unified(before, after))), nil
+	}
+
 	tmpl, err := p.template(`before:
 {{indent .Before}}
 after:
@@ -209,6 +360,23 @@ after:
 	return "\n" + p.indent(p.indent(buf.String())), err
 }
 
+// unified shows the lines that differ between before and after, with a few
+// lines of context around them
+func (p *Printer) unified(before, after string) string {
+	lines := strings.Split(strings.TrimSuffix(patch.Diff(before, after, diffContext), "\n"), "\n")
+	for i, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "@@"):
+			lines[i] = p.getFunc("cyan")(line)
+		case strings.HasPrefix(line, "-"):
+			lines[i] = p.getFunc("red")(line)
+		case strings.HasPrefix(line, "+"):
+			lines[i] = p.getFunc("green")(line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
 func (p *Printer) indent(in string) string {
 	return "\t" + strings.Replace(in, "\n", "\n\t", -1)
 }
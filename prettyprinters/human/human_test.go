@@ -15,7 +15,10 @@
 package human_test
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/asteris-llc/converge/graph"
@@ -116,10 +119,80 @@ func TestDrawNodeNoChanges(t *testing.T) {
 	testDrawNodes(
 		t,
 		Printable{},
+		"root: OK\n",
+	)
+}
+
+func TestDrawNodeNoChangesVerbose(t *testing.T) {
+	t.Parallel()
+
+	printer := human.New()
+	printer.Verbosity = human.Verbose
+	printer.InitColors()
+
+	testDrawNodesCustomPrinter(
+		t,
+		printer,
+		"root",
+		Printable{},
 		"root:\n Messages:\n Has Changes: no\n Changes: No changes\n\n",
 	)
 }
 
+func TestDrawNodeQuiet(t *testing.T) {
+	t.Parallel()
+
+	printer := human.New()
+	printer.Verbosity = human.Quiet
+	printer.InitColors()
+
+	t.Run("no changes", func(t *testing.T) {
+		testDrawNodesCustomPrinter(t, printer, "root", Printable{}, "")
+	})
+
+	t.Run("changes", func(t *testing.T) {
+		testDrawNodesCustomPrinter(
+			t,
+			printer,
+			"root",
+			Printable{"a": "b"},
+			"root:\n Has Changes: yes\n Changes:\n  a: \"\" => \"b\"\n\n",
+		)
+	})
+}
+
+func TestShowGrouped(t *testing.T) {
+	t.Parallel()
+
+	g := graph.New()
+	g.Add(node.New("root", Printable{}))
+	g.Add(node.New("root/task.b", Printable{}))
+	g.Add(node.New("root/module.m", Printable{}))
+	g.Add(node.New("root/module.m/task.c", Printable{"a": "b"}))
+	g.Add(node.New("root/task.a", Printable{}))
+	for _, id := range []string{"root/task.a", "root/task.b", "root/module.m"} {
+		g.Connect("root", id)
+	}
+	g.Connect("root/module.m", "root/module.m/task.c")
+
+	printer := human.NewFiltered(human.HideByKind("module", "root"))
+	printer.InitColors()
+
+	out, err := pp.New(printer).Show(context.Background(), g)
+	require.NoError(t, err)
+	assert.Equal(
+		t,
+		"Module root\n\n"+
+			"root/task.a:          OK\n"+
+			"root/task.b:          OK\n"+
+			"\n"+
+			"Module root/module.m\n\n"+
+			"root/module.m/task.c:\n Messages:\n Has Changes: yes\n Changes:\n  a: \"\" => \"b\"\n\n"+
+			"Summary: 0 errors, 1 changes\n",
+		out,
+	)
+}
+
 func BenchmarkDrawNodeNoChanges(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		benchmarkDrawNodes(
@@ -202,6 +275,62 @@ func TestDrawNodeError(t *testing.T) {
 	)
 }
 
+func TestDrawNodeErrorPosition(t *testing.T) {
+	t.Parallel()
+
+	g := graph.New()
+	meta := node.New("root", Printable{"error": "x"})
+	meta.Position = "main.hcl:3:1"
+	g.Add(meta)
+
+	printer := human.New()
+	printer.InitColors()
+
+	t.Run("node", func(t *testing.T) {
+		str, err := printer.DrawNode(g, "root")
+
+		require.NoError(t, err)
+		assert.Equal(t, "root:\n Error: x\n At: main.hcl:3:1\n Messages:\n Has Changes: yes\n Changes:\n  error: \"\" => \"x\"\n\n", str.String())
+	})
+
+	t.Run("summary", func(t *testing.T) {
+		str, err := printer.FinishPP(g)
+
+		require.NoError(t, err)
+		assert.Equal(t, "Summary: 1 errors, 1 changes\n\n * root (main.hcl:3:1): x\n", str.String())
+	})
+}
+
+func TestDrawNodeLongChanges(t *testing.T) {
+	t.Parallel()
+
+	var before, after []string
+	for i := 1; i <= 20; i++ {
+		before = append(before, fmt.Sprintf("line %d", i))
+		after = append(after, fmt.Sprintf("line %d", i))
+	}
+	after[9] = "line ten"
+
+	g := graph.New()
+	g.Add(node.New("root", Changed{
+		"file": resource.TextDiff{Values: [2]string{
+			strings.Join(before, "\n") + "\n",
+			strings.Join(after, "\n") + "\n",
+		}},
+	}))
+
+	printer := human.New()
+	printer.InitColors()
+	str, err := printer.DrawNode(g, "root")
+
+	require.NoError(t, err)
+	assert.Equal(
+		t,
+		"root:\n Messages:\n Has Changes: yes\n Changes:\n  file: \n  @@ -7,7 +7,7 @@\n   line 7\n   line 8\n   line 9\n  -line 10\n  +line ten\n   line 11\n   line 12\n   line 13\n\n",
+		str.String(),
+	)
+}
+
 func BenchmarkDrawNodeError(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		benchmarkDrawNodes(
@@ -232,6 +361,14 @@ func (p Printable) HasChanges() bool {
 	return len(p) > 0
 }
 
+// Changed is a printable stub with the given differences
+type Changed map[string]resource.Diff
+
+func (c Changed) Messages() []string                { return []string{} }
+func (c Changed) Changes() map[string]resource.Diff { return c }
+func (c Changed) HasChanges() bool                  { return true }
+func (c Changed) Error() error                      { return nil }
+
 func (p Printable) Error() error {
 	err, ok := p["error"]
 	if !ok {
@@ -17,7 +17,9 @@ package human
 import "github.com/asteris-llc/converge/resource"
 
 type printerNode struct {
-	ID string
+	ID       string
+	Position string
+	Quiet    bool
 
 	Printable
 }
@@ -38,6 +38,9 @@ func (p Printer) Show(ctx context.Context, g *graph.Graph) (string, error) {
 	subgraphs := makeSubgraphMap()
 	p.loadSubgraphs(ctx, g, subgraphs)
 	rootNodes := subgraphs[nil].Nodes
+	if orderer, ok := p.pp.(NodeOrderer); ok {
+		rootNodes = orderer.OrderNodes(g, rootNodes)
+	}
 
 	graphPrinter, gpOK := p.pp.(GraphPrinter)
 	if gpOK {
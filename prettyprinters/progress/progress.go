@@ -0,0 +1,349 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package progress
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// barWidth is the number of characters in the bar itself
+const barWidth = 20
+
+// Bar shows how many of the nodes in a graph have finished, which are
+// running, and how long the rest are expected to take. It is redrawn in place
+// on a terminal.
+type Bar struct {
+	Out io.Writer
+
+	// Width is the width of the terminal. Lines are cut to fit it if set.
+	Width int
+
+	ids      map[string]struct{}
+	previous Timings
+	start    time.Time
+	running  map[string]time.Time
+	taken    Timings
+	drawn    bool
+	stop     chan struct{}
+	lock     sync.Mutex
+}
+
+// New returns a Bar for a graph of the nodes with the given IDs, started at
+// the given time. previous holds how long each node took the last time the
+// graph was applied, for estimating how long is left.
+func New(out io.Writer, ids []string, previous Timings, start time.Time) *Bar {
+	if previous == nil {
+		previous = Timings{}
+	}
+
+	set := map[string]struct{}{}
+	for _, id := range ids {
+		set[id] = struct{}{}
+	}
+
+	return &Bar{
+		Out:      out,
+		ids:      set,
+		previous: previous,
+		start:    start,
+		running:  map[string]time.Time{},
+		taken:    Timings{},
+	}
+}
+
+// Start records that a node started at the given time
+func (b *Bar) Start(id string, at time.Time) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if _, ok := b.taken[id]; !ok {
+		b.running[id] = at
+	}
+}
+
+// Finish records that a node finished at the given time
+func (b *Bar) Finish(id string, at time.Time) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	started, ok := b.running[id]
+	if !ok {
+		started = at
+	}
+	delete(b.running, id)
+	b.taken[id] = at.Sub(started)
+}
+
+// Timings returns how long each node took, including the ones from previous
+// runs that didn't run this time
+func (b *Bar) Timings() Timings {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	out := Timings{}
+	for id, taken := range b.previous {
+		out[id] = taken
+	}
+	for id, taken := range b.taken {
+		out[id] = taken
+	}
+	return out
+}
+
+// Line returns the progress as of the given time, as a single line
+func (b *Bar) Line(now time.Time) string {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	total := b.total()
+	filled := barWidth
+	if total > 0 {
+		filled = barWidth * len(b.taken) / total
+	}
+	bar := strings.Repeat("=", filled)
+	if filled < barWidth {
+		bar += ">" + strings.Repeat(" ", barWidth-filled-1)
+	}
+
+	line := fmt.Sprintf("[%s] %d/%d nodes", bar, len(b.taken), total)
+
+	if len(b.running) > 0 {
+		line += fmt.Sprintf(", running %s", b.runningNames())
+	}
+
+	if eta, ok := b.eta(now); ok {
+		line += ", about " + eta.String() + " left"
+	}
+
+	if b.Width > 0 && len(line) >= b.Width {
+		line = line[:b.Width-1]
+	}
+
+	return line
+}
+
+// ETA returns how long the rest of the graph is expected to take, as of the
+// given time. It is false if there is nothing to base an estimate on yet, or
+// nothing is left.
+func (b *Bar) ETA(now time.Time) (time.Duration, bool) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	return b.eta(now)
+}
+
+// total returns the number of nodes, including any that started without
+// being among the IDs the bar was made with
+func (b *Bar) total() int {
+	total := len(b.ids)
+	for id := range b.taken {
+		if _, ok := b.ids[id]; !ok {
+			total++
+		}
+	}
+	for id := range b.running {
+		if _, ok := b.ids[id]; !ok {
+			total++
+		}
+	}
+	return total
+}
+
+// eta estimates the time left from the share of the expected work that is
+// done so far. Each node is expected to take as long as it did the last time,
+// or as long as the average node if it didn't run then. Since this is a share
+// of the time taken so far, nodes running in parallel are accounted for.
+func (b *Bar) eta(now time.Time) (time.Duration, bool) {
+	elapsed := now.Sub(b.start)
+	if elapsed <= 0 || (len(b.previous) == 0 && len(b.taken) == 0) {
+		return 0, false
+	}
+
+	average := b.average()
+	expected := func(id string) time.Duration {
+		if taken, ok := b.previous[id]; ok {
+			return taken
+		}
+		return average
+	}
+
+	var done, all time.Duration
+	for id := range b.taken {
+		weight := expected(id)
+		done += weight
+		all += weight
+	}
+	for id, started := range b.running {
+		weight := expected(id)
+		all += weight
+
+		// a running node is at most nearly done, however long it has run
+		if ran := now.Sub(started); ran < weight*9/10 {
+			done += ran
+		} else {
+			done += weight * 9 / 10
+		}
+	}
+	for id := range b.ids {
+		if _, done := b.taken[id]; done {
+			continue
+		}
+		if _, running := b.running[id]; running {
+			continue
+		}
+		all += expected(id)
+	}
+
+	if done <= 0 || all <= done {
+		return 0, false
+	}
+
+	left := time.Duration(float64(elapsed) * float64(all-done) / float64(done))
+	return left / time.Second * time.Second, true
+}
+
+// average returns the average time taken by a node, from previous runs if
+// there are any and from this one otherwise
+func (b *Bar) average() time.Duration {
+	timings := b.previous
+	if len(timings) == 0 {
+		timings = b.taken
+	}
+
+	if len(timings) == 0 {
+		return time.Second
+	}
+
+	var sum time.Duration
+	for _, taken := range timings {
+		sum += taken
+	}
+	if sum <= 0 {
+		return time.Second
+	}
+	return sum / time.Duration(len(timings))
+}
+
+// runningNames lists the first few running nodes, longest running first
+func (b *Bar) runningNames() string {
+	var ids []string
+	for id := range b.running {
+		ids = append(ids, id)
+	}
+	sort.Sort(byStart{ids, b.running})
+
+	if len(ids) > 2 {
+		return fmt.Sprintf("%s and %d more", strings.Join(ids[:2], ", "), len(ids)-2)
+	}
+	return strings.Join(ids, ", ")
+}
+
+// byStart sorts IDs by when they started, and then by ID
+type byStart struct {
+	ids     []string
+	started map[string]time.Time
+}
+
+func (b byStart) Len() int      { return len(b.ids) }
+func (b byStart) Swap(i, j int) { b.ids[i], b.ids[j] = b.ids[j], b.ids[i] }
+func (b byStart) Less(i, j int) bool {
+	left, right := b.started[b.ids[i]], b.started[b.ids[j]]
+	if left.Equal(right) {
+		return b.ids[i] < b.ids[j]
+	}
+	return left.Before(right)
+}
+
+// Draw writes the progress as of the given time over the last line drawn
+func (b *Bar) Draw(now time.Time) {
+	line := b.Line(now)
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.write(line)
+}
+
+// redraw draws the progress for a redraw loop, unless it has been stopped
+func (b *Bar) redraw(stop chan struct{}, now time.Time) {
+	line := b.Line(now)
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if b.stop == stop {
+		b.write(line)
+	}
+}
+
+func (b *Bar) write(line string) {
+	fmt.Fprint(b.Out, "\r\x1b[K"+line)
+	b.drawn = true
+}
+
+// Redraw draws the progress every interval until Stop is called, so that the
+// time left is kept up to date while nodes run
+func (b *Bar) Redraw(interval time.Duration) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if b.stop != nil {
+		return
+	}
+
+	stop := make(chan struct{})
+	b.stop = stop
+	ticker := time.NewTicker(interval)
+
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case now := <-ticker.C:
+				b.redraw(stop, now)
+			}
+		}
+	}()
+}
+
+// Stop stops redrawing the progress and clears it
+func (b *Bar) Stop() {
+	b.lock.Lock()
+	if b.stop != nil {
+		close(b.stop)
+		b.stop = nil
+	}
+	b.lock.Unlock()
+
+	b.Clear()
+}
+
+// Clear removes the last line drawn, so that other output can be written
+func (b *Bar) Clear() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if b.drawn {
+		fmt.Fprint(b.Out, "\r\x1b[K")
+		b.drawn = false
+	}
+}
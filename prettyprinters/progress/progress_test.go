@@ -0,0 +1,188 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package progress_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/asteris-llc/converge/prettyprinters/progress"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ids returns the IDs of tasks with the given names
+func ids(names ...string) (out []string) {
+	for _, name := range names {
+		out = append(out, "root/task."+name)
+	}
+	return out
+}
+
+func TestBar(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2016, 10, 1, 12, 0, 0, 0, time.UTC)
+	at := func(seconds int) time.Time { return start.Add(time.Duration(seconds) * time.Second) }
+
+	t.Run("no history", func(t *testing.T) {
+		bar := progress.New(new(bytes.Buffer), ids("a", "b", "c", "d"), nil, start)
+		assert.Equal(t, "[>                   ] 0/4 nodes", bar.Line(start))
+
+		bar.Start("root/task.a", at(0))
+		assert.Equal(t, "[>                   ] 0/4 nodes, running root/task.a", bar.Line(at(5)))
+
+		bar.Finish("root/task.a", at(10))
+		bar.Start("root/task.b", at(10))
+
+		assert.Equal(
+			t,
+			"[=====>              ] 1/4 nodes, running root/task.b, about 30s left",
+			bar.Line(at(10)),
+		)
+	})
+
+	t.Run("history", func(t *testing.T) {
+		previous := progress.Timings{
+			"root/task.a": 10 * time.Second,
+			"root/task.b": 30 * time.Second,
+		}
+		bar := progress.New(new(bytes.Buffer), ids("a", "b"), previous, start)
+
+		bar.Start("root/task.a", at(0))
+		bar.Finish("root/task.a", at(10))
+
+		eta, ok := bar.ETA(at(10))
+		require.True(t, ok)
+		assert.Equal(t, 30*time.Second, eta)
+
+		bar.Start("root/task.b", at(10))
+		bar.Finish("root/task.b", at(40))
+		_, ok = bar.ETA(at(40))
+		assert.False(t, ok)
+	})
+
+	t.Run("running", func(t *testing.T) {
+		bar := progress.New(new(bytes.Buffer), ids("a", "b", "c"), nil, start)
+		bar.Start("root/task.c", at(1))
+		bar.Start("root/task.a", at(2))
+		bar.Start("root/task.b", at(2))
+
+		assert.Contains(t, bar.Line(at(3)), "running root/task.c, root/task.a and 1 more")
+	})
+
+	t.Run("width", func(t *testing.T) {
+		bar := progress.New(new(bytes.Buffer), ids("a", "b", "c"), nil, start)
+		bar.Width = 20
+
+		assert.Equal(t, "[>                 ", bar.Line(start))
+	})
+
+	t.Run("draw and clear", func(t *testing.T) {
+		var out bytes.Buffer
+		bar := progress.New(&out, ids("a"), nil, start)
+
+		bar.Clear()
+		bar.Draw(start)
+		bar.Clear()
+
+		assert.Equal(t, "\r\x1b[K[>                   ] 0/1 nodes\r\x1b[K", out.String())
+	})
+
+	t.Run("timings", func(t *testing.T) {
+		previous := progress.Timings{"root/task.a": time.Second, "root/task.b": time.Second}
+		bar := progress.New(new(bytes.Buffer), ids("a", "b"), previous, start)
+		bar.Start("root/task.a", at(0))
+		bar.Finish("root/task.a", at(5))
+
+		assert.Equal(
+			t,
+			progress.Timings{"root/task.a": 5 * time.Second, "root/task.b": time.Second},
+			bar.Timings(),
+		)
+	})
+}
+
+func TestTimings(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "converge-timings")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	empty, err := progress.Load(dir, "main.hcl")
+	require.NoError(t, err)
+	assert.Empty(t, empty)
+
+	timings := progress.Timings{"root/task.a": 3 * time.Second}
+	require.NoError(t, timings.Save(dir, "main.hcl"))
+
+	loaded, err := progress.Load(dir, "main.hcl")
+	require.NoError(t, err)
+	assert.Equal(t, timings, loaded)
+
+	other, err := progress.Load(dir, "other.hcl")
+	require.NoError(t, err)
+	assert.Empty(t, other)
+}
+
+func TestBarRedraw(t *testing.T) {
+	t.Parallel()
+
+	var out syncBuffer
+	bar := progress.New(&out, ids("a"), nil, time.Now())
+	bar.Redraw(time.Millisecond)
+
+	for deadline := time.Now().Add(time.Second); out.Len() == 0 && time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond)
+	}
+	require.NotZero(t, out.Len(), "bar was never drawn")
+	bar.Stop()
+
+	stopped := out.String()
+	assert.True(t, strings.HasSuffix(stopped, "\r\x1b[K"))
+
+	time.Sleep(5 * time.Millisecond)
+	assert.Equal(t, stopped, out.String())
+}
+
+// syncBuffer is a buffer that can be written while it is being read
+type syncBuffer struct {
+	buf  bytes.Buffer
+	lock sync.Mutex
+}
+
+func (s *syncBuffer) Write(p []byte) (int, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.buf.Write(p)
+}
+
+func (s *syncBuffer) Len() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.buf.Len()
+}
+
+func (s *syncBuffer) String() string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.buf.String()
+}
@@ -0,0 +1,73 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package progress
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/asteris-llc/converge/helpers/atomicfile"
+	"github.com/pkg/errors"
+)
+
+// DefaultDir is where the timings of each module are kept
+const DefaultDir = "/var/lib/converge/timings"
+
+// Timings are how long each node of a graph took to apply, by ID
+type Timings map[string]time.Duration
+
+// Load reads the timings saved for the module at location. They are empty if
+// none have been saved yet.
+func Load(dir, location string) (Timings, error) {
+	content, err := ioutil.ReadFile(path(dir, location))
+	if os.IsNotExist(err) {
+		return Timings{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	out := Timings{}
+	if err := json.Unmarshal(content, &out); err != nil {
+		return nil, errors.Wrapf(err, "could not parse timings for %s", location)
+	}
+	return out, nil
+}
+
+// Save writes the timings for the module at location, replacing any saved
+// before
+func (t Timings) Save(dir, location string) error {
+	content, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return errors.Wrap(err, "could not create timings directory")
+	}
+
+	return atomicfile.Write(path(dir, location), content, 0600)
+}
+
+// path names the timings of a module by a hash of its location, which may be
+// a URL
+func path(dir, location string) string {
+	sum := sha256.Sum256([]byte(location))
+	return filepath.Join(dir, hex.EncodeToString(sum[:])+".json")
+}
@@ -0,0 +1,78 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	multierror "github.com/hashicorp/go-multierror"
+)
+
+// NodeError is a failure to render a single node
+type NodeError struct {
+	ID string
+
+	// Position is where the node is in its module, if known
+	Position string
+
+	Err error
+}
+
+func (e *NodeError) Error() string {
+	return e.name() + ": " + e.Err.Error()
+}
+
+func (e *NodeError) name() string {
+	if e.Position == "" {
+		return e.ID
+	}
+	return fmt.Sprintf("%s (%s)", e.ID, e.Position)
+}
+
+// Errors are the failures to render a graph. Every node that can be rendered
+// is, so that all of the mistakes in a module are found at once.
+type Errors struct {
+	// Nodes has an error for each node that failed, sorted by ID
+	Nodes []*NodeError
+
+	// Skipped are the nodes that weren't rendered because something they
+	// depend on failed, sorted
+	Skipped []string
+}
+
+func (e *Errors) Error() string {
+	var out bytes.Buffer
+
+	fmt.Fprintf(&out, "%d node(s) could not be rendered:\n", len(e.Nodes))
+	for _, node := range e.Nodes {
+		fmt.Fprintf(&out, "\n%s:\n", node.name())
+
+		problems := []error{node.Err}
+		if multi, ok := node.Err.(*multierror.Error); ok {
+			problems = multi.Errors
+		}
+		for _, problem := range problems {
+			fmt.Fprintf(&out, "  %s\n", problem)
+		}
+	}
+
+	if len(e.Skipped) > 0 {
+		fmt.Fprintf(&out, "\n%d node(s) that depend on them were not rendered: %s\n", len(e.Skipped), strings.Join(e.Skipped, ", "))
+	}
+
+	return strings.TrimSuffix(out.String(), "\n")
+}
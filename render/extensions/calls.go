@@ -0,0 +1,97 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package extensions
+
+import (
+	"text/template"
+	"text/template/parse"
+)
+
+// FindCalls returns the literal first argument of every call to one of the
+// named functions in a parsed template. Unlike executing the template with
+// RememberCalls, calls inside branches that wouldn't be taken are found too.
+// Calls whose argument is computed while the template runs are not found.
+func FindCalls(tmpl *template.Template, names ...string) (calls []string) {
+	wanted := make(map[string]struct{}, len(names))
+	for _, name := range names {
+		wanted[name] = struct{}{}
+	}
+
+	for _, t := range tmpl.Templates() {
+		if t.Tree != nil && t.Tree.Root != nil {
+			calls = findCalls(t.Tree.Root, wanted, calls)
+		}
+	}
+	return calls
+}
+
+func findCalls(node parse.Node, wanted map[string]struct{}, calls []string) []string {
+	switch node := node.(type) {
+	case *parse.ListNode:
+		if node == nil {
+			return calls
+		}
+		for _, child := range node.Nodes {
+			calls = findCalls(child, wanted, calls)
+		}
+
+	case *parse.ActionNode:
+		calls = findCalls(node.Pipe, wanted, calls)
+
+	case *parse.IfNode:
+		calls = findBranchCalls(&node.BranchNode, wanted, calls)
+
+	case *parse.RangeNode:
+		calls = findBranchCalls(&node.BranchNode, wanted, calls)
+
+	case *parse.WithNode:
+		calls = findBranchCalls(&node.BranchNode, wanted, calls)
+
+	case *parse.TemplateNode:
+		calls = findCalls(node.Pipe, wanted, calls)
+
+	case *parse.PipeNode:
+		if node == nil {
+			return calls
+		}
+		for _, cmd := range node.Cmds {
+			calls = findCalls(cmd, wanted, calls)
+		}
+
+	case *parse.CommandNode:
+		if len(node.Args) >= 2 {
+			if ident, ok := node.Args[0].(*parse.IdentifierNode); ok {
+				if _, ok := wanted[ident.Ident]; ok {
+					if arg, ok := node.Args[1].(*parse.StringNode); ok {
+						calls = append(calls, arg.Text)
+					}
+				}
+			}
+		}
+
+		// arguments may be calls themselves, as in (lookup `x`)
+		for _, arg := range node.Args {
+			calls = findCalls(arg, wanted, calls)
+		}
+	}
+
+	return calls
+}
+
+func findBranchCalls(node *parse.BranchNode, wanted map[string]struct{}, calls []string) []string {
+	calls = findCalls(node.Pipe, wanted, calls)
+	calls = findCalls(node.List, wanted, calls)
+	return findCalls(node.ElseList, wanted, calls)
+}
@@ -25,6 +25,7 @@ import (
 
 	"github.com/asteris-llc/converge/render/extensions"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 var keywords = map[string]struct{}{
@@ -141,3 +142,17 @@ func renderTemplate(l *extensions.LanguageExtension, s string) (string, error) {
 	err = tmpl.Execute(&buffer, &useless)
 	return buffer.String(), err
 }
+
+func Test_FindCalls_FindsCallsInEveryBranch(t *testing.T) {
+	t.Parallel()
+
+	language := extensions.MinimalLanguage()
+	tmpl, err := template.New("test").Funcs(language.Funcs).Parse(
+		"{{if eq (param `mode`) `a`}}{{lookup `task.a`}}{{else}}{{lookup `task.b` | printf `%s`}}{{end}}" +
+			"{{range paramList `items`}}{{.}}{{end}}{{lookup (printf `task.%s` `c`)}}",
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"task.a", "task.b"}, extensions.FindCalls(tmpl, "lookup"))
+	assert.Equal(t, []string{"mode", "items"}, extensions.FindCalls(tmpl, "param", "paramList"))
+}
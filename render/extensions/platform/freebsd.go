@@ -0,0 +1,52 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package platform
+
+import (
+	"os/exec"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// FreeBSDVers runs /bin/freebsd-version to get FreeBSD version information
+func (platform *Platform) FreeBSDVers() error {
+	cmd := "/bin/freebsd-version"
+
+	cmdOut, err := exec.Command(cmd, "-u").Output()
+	if err != nil {
+		return errors.Wrapf(err, "%s. Will be unable to parse release data", cmd)
+	}
+	platform.ParseFreeBSDVersion(string(cmdOut))
+	return nil
+}
+
+// ParseFreeBSDVersion takes output from /bin/freebsd-version, like
+// "13.2-RELEASE-p4", and stores it in a Platform. The release is the Version
+// and the branch and patch level the Build.
+func (platform *Platform) ParseFreeBSDVersion(versionData string) {
+	release := strings.TrimSpace(versionData)
+	parts := strings.SplitN(release, "-", 2)
+
+	platform.Name = "FreeBSD"
+	platform.PrettyName = "FreeBSD " + release
+	platform.Version = parts[0]
+	if len(parts) == 2 {
+		platform.Build = parts[1]
+	}
+
+	platform.Family = "freebsd"
+	platform.MajorVersion = majorVersion(platform.Version)
+}
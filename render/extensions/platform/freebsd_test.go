@@ -0,0 +1,36 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package platform
+
+import "testing"
+
+// test output of /bin/freebsd-version -u
+func TestParseFreeBSDVersion(t *testing.T) {
+	var platform Platform
+
+	platform.ParseFreeBSDVersion("13.2-RELEASE-p4\n")
+
+	if platform.Name != "FreeBSD" || platform.PrettyName != "FreeBSD 13.2-RELEASE-p4" {
+		t.Errorf("ParseFreeBSDVersion Name: wanted FreeBSD, got %q %q\n", platform.Name, platform.PrettyName)
+	}
+
+	if platform.Version != "13.2" || platform.Build != "RELEASE-p4" {
+		t.Errorf("ParseFreeBSDVersion Version: wanted 13.2 RELEASE-p4, got %q %q\n", platform.Version, platform.Build)
+	}
+
+	if platform.Family != "freebsd" || platform.MajorVersion != "13" {
+		t.Errorf("ParseFreeBSDVersion Family and MajorVersion: wanted freebsd 13, got %q %q\n", platform.Family, platform.MajorVersion)
+	}
+}
@@ -55,4 +55,7 @@ func (platform *Platform) ParseLSBContent(content string) {
 			}
 		}
 	}
+
+	platform.Family = family(platform.LinuxDistribution, platform.LinuxLSBLike)
+	platform.MajorVersion = majorVersion(platform.Version)
 }
@@ -34,6 +34,8 @@ BUG_REPORT_URL="http://bugs.alpinelinux.org"
 	expected.Name = "Alpine Linux"
 	expected.LinuxDistribution = "alpine"
 	expected.Version = "3.4.0"
+	expected.Family = "alpine"
+	expected.MajorVersion = "3"
 	expected.Build = ""
 
 	platform.ParseLSBContent(content)
@@ -64,6 +66,8 @@ REDHAT_SUPPORT_PRODUCT_VERSION="7"
 	expected.Name = "CentOS Linux"
 	expected.LinuxDistribution = "centos"
 	expected.Version = "7"
+	expected.Family = "rhel"
+	expected.MajorVersion = "7"
 	expected.LinuxLSBLike = []string{"rhel", "fedora"}
 
 	platform.ParseLSBContent(content)
@@ -86,6 +90,8 @@ BUG_REPORT_URL="https://github.com/coreos/bugs/issues"`
 	expected.Name = "CoreOS"
 	expected.LinuxDistribution = "coreos"
 	expected.Version = "835.9.0"
+	expected.Family = "coreos"
+	expected.MajorVersion = "835"
 
 	platform.ParseLSBContent(content)
 	ComparePlatform(t, expected, platform)
@@ -108,6 +114,8 @@ BUG_REPORT_URL="https://bugs.debian.org/"
 	expected.Name = "Debian GNU/Linux"
 	expected.LinuxDistribution = "debian"
 	expected.Version = "8"
+	expected.Family = "debian"
+	expected.MajorVersion = "8"
 
 	platform.ParseLSBContent(content)
 	ComparePlatform(t, expected, platform)
@@ -128,6 +136,8 @@ HOME_URL="http://nixos.org/"
 	expected.Name = "NixOS"
 	expected.LinuxDistribution = "nixos"
 	expected.Version = "16.09.git.bfc0c28"
+	expected.Family = "nixos"
+	expected.MajorVersion = "16"
 
 	platform.ParseLSBContent(content)
 	ComparePlatform(t, expected, platform)
@@ -153,6 +163,8 @@ UBUNTU_CODENAME=xenial
 	expected.Name = "Ubuntu"
 	expected.LinuxDistribution = "ubuntu"
 	expected.Version = "16.04"
+	expected.Family = "debian"
+	expected.MajorVersion = "16"
 	expected.LinuxLSBLike = []string{"debian"}
 
 	platform.ParseLSBContent(content)
@@ -160,6 +172,28 @@ UBUNTU_CODENAME=xenial
 
 }
 
+func TestParseLSBRHEL(t *testing.T) {
+	content := `NAME="Red Hat Enterprise Linux Server"
+VERSION="7.3 (Maipo)"
+ID="rhel"
+ID_LIKE="fedora"
+VERSION_ID="7.3"
+PRETTY_NAME="Red Hat Enterprise Linux Server 7.3 (Maipo)"
+`
+	var platform Platform
+	var expected Platform
+
+	expected.Name = "Red Hat Enterprise Linux Server"
+	expected.LinuxDistribution = "rhel"
+	expected.Version = "7.3"
+	expected.Family = "rhel"
+	expected.MajorVersion = "7"
+	expected.LinuxLSBLike = []string{"fedora"}
+
+	platform.ParseLSBContent(content)
+	ComparePlatform(t, expected, platform)
+}
+
 func ComparePlatform(t *testing.T, expected Platform, platform Platform) {
 
 	if platform.Name != expected.Name {
@@ -174,6 +208,14 @@ func ComparePlatform(t *testing.T, expected Platform, platform Platform) {
 		t.Errorf("ParseLSBContent Version: wanted %q, got %q\n", expected.Version, platform.Version)
 	}
 
+	if platform.Family != expected.Family {
+		t.Errorf("ParseLSBContent Family: wanted %q, got %q\n", expected.Family, platform.Family)
+	}
+
+	if platform.MajorVersion != expected.MajorVersion {
+		t.Errorf("ParseLSBContent MajorVersion: wanted %q, got %q\n", expected.MajorVersion, platform.MajorVersion)
+	}
+
 	if platform.Build != expected.Build {
 		t.Errorf("ParseLSBContent Build: wanted %q, got %q\n", expected.Build, platform.Build)
 	}
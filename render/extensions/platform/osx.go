@@ -52,4 +52,7 @@ func (platform *Platform) ParseOSXVersion(versionData string) {
 			}
 		}
 	}
+
+	platform.Family = "darwin"
+	platform.MajorVersion = majorVersion(platform.Version)
 }
@@ -36,4 +36,8 @@ func TestParseOSXSWVers(t *testing.T) {
 		t.Errorf("ParseOSXVersion Version: wanted 15G31, got %q\n", platform.Build)
 	}
 
+	if platform.Family != "darwin" || platform.MajorVersion != "10" {
+		t.Errorf("ParseOSXVersion Family and MajorVersion: wanted darwin 10, got %q %q\n", platform.Family, platform.MajorVersion)
+	}
+
 }
@@ -15,20 +15,49 @@
 // Package platform queries the underlying operating system
 package platform
 
-import "runtime"
+import (
+	"runtime"
+	"strings"
+)
 
 // Platform is a struct containing version information for the
 // underlying operating system
 type Platform struct {
 	Build             string
+	Family            string
 	OS                string
 	LinuxDistribution string
 	LinuxLSBLike      []string
+	MajorVersion      string
 	Name              string
 	PrettyName        string
 	Version           string
 }
 
+// families are the families a Linux distribution may belong to, in the order
+// they are preferred when a distribution is like more than one. Fedora comes
+// last so that distributions like both RHEL and Fedora are in the RHEL family.
+var families = []string{"debian", "rhel", "suse", "arch", "alpine", "gentoo", "fedora"}
+
+// family returns the family of a Linux distribution from its ID and ID_LIKE,
+// or the ID itself if it is not like any known family
+func family(id string, like []string) string {
+	candidates := append([]string{id}, like...)
+	for _, family := range families {
+		for _, candidate := range candidates {
+			if candidate == family {
+				return family
+			}
+		}
+	}
+	return id
+}
+
+// majorVersion returns the part of the version before the first dot
+func majorVersion(version string) string {
+	return strings.SplitN(version, ".", 2)[0]
+}
+
 // DefaultPlatform Queries the runtime and then attempts to
 // discover version information from the underlying operating system
 func DefaultPlatform() (*Platform, error) {
@@ -38,6 +67,8 @@ func DefaultPlatform() (*Platform, error) {
 	switch platform.OS {
 	case "darwin":
 		err = platform.OSXVers()
+	case "freebsd":
+		err = platform.FreeBSDVers()
 	case "linux":
 		err = platform.LinuxLSB()
 	}
@@ -18,10 +18,12 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"strings"
 
 	"github.com/asteris-llc/converge/graph"
 	"github.com/asteris-llc/converge/render/extensions"
+	"github.com/asteris-llc/converge/render/preprocessor"
 	"github.com/asteris-llc/converge/resource"
 	"github.com/asteris-llc/converge/resource/module"
 )
@@ -31,6 +33,20 @@ type Factory struct {
 	Graph     *graph.Graph
 	DotValues map[string]*LazyValue
 	Language  *extensions.LanguageExtension
+
+	// Previous holds the values from the last apply. Lookups that can't be
+	// resolved yet use them instead, if set.
+	Previous *Snapshot
+
+	// Record collects the value of every lookup that is resolved, if set
+	Record *Snapshot
+
+	// Trace receives how each lookup that doesn't resolve was searched for, if
+	// set
+	Trace io.Writer
+
+	// Location is where the module being rendered was loaded from, if known
+	Location string
 }
 
 // ValueThunk lazily evaluates a param
@@ -62,7 +78,15 @@ func (v *LazyValue) Value() (resource.Value, bool, error) {
 
 // GetRenderer returns a Factory for the specific graph node
 func (f *Factory) GetRenderer(id string) (*Renderer, error) {
-	r := &Renderer{Language: f.Language, Graph: func() *graph.Graph { return f.Graph }, ID: id}
+	r := &Renderer{
+		Language: f.Language,
+		Graph:    func() *graph.Graph { return f.Graph },
+		ID:       id,
+		Previous: f.Previous,
+		Record:   f.Record,
+		Trace:    f.Trace,
+		Location: f.Location,
+	}
 	if dotVal, found := f.DotValues[id]; found {
 		if valResult, valFound, err := dotVal.Value(); err != nil {
 			return nil, err
@@ -80,6 +104,8 @@ func NewFactory(ctx context.Context, g *graph.Graph) (*Factory, error) {
 		Graph:     g,
 		Language:  extensions.DefaultLanguage(),
 		DotValues: make(map[string]*LazyValue),
+		Trace:     preprocessor.TraceWriter(ctx),
+		Location:  LocationFrom(ctx),
 	}
 
 	for _, vertex := range g.Vertices() {
@@ -116,3 +142,19 @@ func getParamOverrides(gFunc func() *graph.Graph, id string) (ValueThunk, bool)
 	}
 	return f, true
 }
+
+type locationKey struct{}
+
+// WithLocation returns a context for rendering the module loaded from
+// location. Renderers made in the context report it to resources, which keep
+// their state apart from that of other modules.
+func WithLocation(ctx context.Context, location string) context.Context {
+	return context.WithValue(ctx, locationKey{}, location)
+}
+
+// LocationFrom returns the module location carried by a context, or an empty
+// string if there is none
+func LocationFrom(ctx context.Context) string {
+	location, _ := ctx.Value(locationKey{}).(string)
+	return location
+}
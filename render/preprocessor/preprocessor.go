@@ -19,6 +19,8 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 
@@ -135,21 +137,60 @@ func DesugarCall(g *graph.Graph, call string) (string, error) {
 // the longest vertex id from the graph and the remainder of the string.  If no
 // matching vertex is found 'false' is returned.
 func VertexSplit(g *graph.Graph, s string) (string, string, bool) {
-	prefix, found := Find(Prefixes(s), g.Contains)
+	return vertexSplit(g, s, nil)
+}
+
+func vertexSplit(g *graph.Graph, s string, trace *tracer) (string, string, bool) {
+	var tried []string
+	prefix, found := Find(Prefixes(s), func(prefix string) bool {
+		tried = append(tried, prefix)
+		return g.Contains(prefix)
+	})
 	if !found {
+		trace.printf("tried %s: no such node", strings.Join(tried, ", "))
 		return "", s, false
 	}
+	if len(tried) > 1 {
+		trace.printf("tried %s: no such node", strings.Join(tried[:len(tried)-1], ", "))
+	}
+	trace.printf("found %s", prefix)
 	if prefix == s {
 		return prefix, "", true
 	}
 	return prefix, s[len(prefix)+1:], true
 }
 
+// ModuleOutput resolves a lookup of a module's outputs, such as
+// "module.db.outputs.port", to the output node inside the module. The vertex
+// and terms are as returned by VertexSplit. If they aren't a lookup of an
+// output that exists, they are returned unchanged along with false.
+func ModuleOutput(g *graph.Graph, vertex, terms string) (string, string, bool) {
+	if !strings.HasPrefix(graph.BaseID(vertex), "module.") {
+		return vertex, terms, false
+	}
+
+	parts := SplitTerms(terms)
+	if len(parts) < 2 || parts[0] != "outputs" {
+		return vertex, terms, false
+	}
+
+	output := graph.ID(vertex, "output."+parts[1])
+	if !g.Contains(output) {
+		return vertex, terms, false
+	}
+
+	return output, JoinTerms(append([]string{"value"}, parts[2:]...)), true
+}
+
 // VertexSplitTraverse will act like vertex split, looking for a prefix matching
 // the current set of graph nodes, however unlike `VertexSplit`, if a node is
 // not found at the current level it will look at the parent level to the
 // provided starting node, unless stop(parent) returns true.
 func VertexSplitTraverse(g *graph.Graph, toFind string, startingNode string, stop func(*graph.Graph, string) bool, history map[string]struct{}) (string, string, bool) {
+	return vertexSplitTraverse(g, toFind, startingNode, stop, history, nil)
+}
+
+func vertexSplitTraverse(g *graph.Graph, toFind string, startingNode string, stop func(*graph.Graph, string) bool, history map[string]struct{}, trace *tracer) (string, string, bool) {
 	history[startingNode] = struct{}{}
 
 	for _, child := range g.Children(startingNode) {
@@ -157,24 +198,27 @@ func VertexSplitTraverse(g *graph.Graph, toFind string, startingNode string, sto
 			continue
 		}
 		if stop(g, child) {
+			trace.printf("not searching inside %s: stop condition met", child)
 			continue
 		}
-		vertex, middle, found := VertexSplitTraverse(g, toFind, child, stop, history)
+		vertex, middle, found := vertexSplitTraverse(g, toFind, child, stop, history, trace)
 		if found {
 			return vertex, middle, found
 		}
 	}
 	if stop(g, startingNode) {
+		trace.printf("stopped at %s: stop condition met", startingNode)
 		return "", toFind, false
 	}
 
 	fqgn := graph.SiblingID(startingNode, toFind)
-	vertex, middle, found := VertexSplit(g, fqgn)
+	vertex, middle, found := vertexSplit(g, fqgn, trace)
 	if found {
 		return vertex, middle, found
 	}
 	parentID := graph.ParentID(startingNode)
-	return VertexSplitTraverse(g, toFind, parentID, stop, history)
+	trace.printf("moving up to %s", parentID)
+	return vertexSplitTraverse(g, toFind, parentID, stop, history, trace)
 }
 
 // TraverseUntilModule is a function intended to be used with
@@ -347,6 +391,14 @@ func canBeNil(r reflect.Value) bool {
 // HasPath returns true of the set of terms can resolve to a value
 func HasPath(obj interface{}, terms ...string) error {
 	for _, term := range terms {
+		if elem, ok, err := evalIndex(obj, term); ok {
+			if err != nil {
+				return err
+			}
+			obj = elem
+			continue
+		}
+
 		term = strings.ToLower(term)
 		lookupMap, fieldMap := lookupMap(FieldMap(obj))
 		key, ok := lookupMap[term]
@@ -374,9 +426,23 @@ func lookupMap(src map[string]reflect.Value) (map[string]string, map[string]refl
 	return keys, src
 }
 
-// EvalTerms acts as a left fold over a list of term accessors
+// EvalTerms acts as a left fold over a list of term accessors. Terms select
+// fields of structs, keys of maps, and indexes of slices and arrays, so values
+// such as parsed JSON can be traversed as well as tasks.
 func EvalTerms(obj interface{}, terms ...string) (interface{}, error) {
 	for _, term := range terms {
+		if obj == nil {
+			return nil, ErrUnresolvable
+		}
+
+		if elem, ok, err := evalIndex(obj, term); ok {
+			if err != nil {
+				return nil, err
+			}
+			obj = elem
+			continue
+		}
+
 		term = strings.ToLower(term)
 		lookupMap, fieldMap := lookupMap(FieldMap(obj))
 		key, ok := lookupMap[term]
@@ -396,6 +462,49 @@ func EvalTerms(obj interface{}, terms ...string) (interface{}, error) {
 	return obj, nil
 }
 
+// evalIndex looks up term in obj if it is a map with string keys, a slice, or
+// an array. The second return value is false if obj is none of these. Map keys
+// are matched exactly, and slice terms must be a valid index.
+func evalIndex(obj interface{}, term string) (interface{}, bool, error) {
+	val := reflect.ValueOf(obj)
+	for val.Kind() == reflect.Ptr || val.Kind() == reflect.Interface {
+		if val.IsNil() {
+			return nil, false, nil
+		}
+		val = val.Elem()
+	}
+
+	switch val.Kind() {
+	case reflect.Map:
+		if val.Type().Key().Kind() != reflect.String {
+			return nil, true, fmt.Errorf("cannot look up %s in %T: keys are not strings", term, obj)
+		}
+		if val.IsNil() {
+			return nil, true, ErrUnresolvable
+		}
+
+		elem := val.MapIndex(reflect.ValueOf(term).Convert(val.Type().Key()))
+		if !elem.IsValid() {
+			var validKeys []string
+			for _, key := range val.MapKeys() {
+				validKeys = append(validKeys, key.String())
+			}
+			sort.Strings(validKeys)
+			return nil, true, fmt.Errorf("%T has no key named %s: should be one of: %v", obj, term, validKeys)
+		}
+		return elem.Interface(), true, nil
+
+	case reflect.Slice, reflect.Array:
+		idx, err := strconv.Atoi(term)
+		if err != nil || idx < 0 || idx >= val.Len() {
+			return nil, true, fmt.Errorf("%s is not a valid index into %T of length %d", term, obj, val.Len())
+		}
+		return val.Index(idx).Interface(), true, nil
+	}
+
+	return nil, false, nil
+}
+
 // For a given interface, fieldMap returns a map with keys being the lowercase
 // versions of the string, and values being the correct version.  It returns an
 // error if the interface is not a struct, or a reflect.Type or reflect.Value of
@@ -15,12 +15,14 @@
 package preprocessor_test
 
 import (
+	"bytes"
 	"reflect"
 	"testing"
 
 	"github.com/asteris-llc/converge/graph"
 	"github.com/asteris-llc/converge/graph/node"
 	"github.com/asteris-llc/converge/render/preprocessor"
+	"github.com/asteris-llc/converge/resource/module"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -112,6 +114,86 @@ func TestVertexSplit(t *testing.T) {
 
 }
 
+// TestTraceVertexSplitTraverse ensures the search is traced the same way as
+// it is done
+func TestTraceVertexSplitTraverse(t *testing.T) {
+	t.Parallel()
+
+	g := graph.New()
+	g.Add(node.New("root", nil))
+	g.Add(node.New("root/task.a", nil))
+	g.Add(node.New("root/module.m", &module.Module{}))
+	g.Add(node.New("root/module.m/task.b", nil))
+	g.ConnectParent("root", "root/task.a")
+	g.ConnectParent("root", "root/module.m")
+	g.ConnectParent("root/module.m", "root/module.m/task.b")
+
+	t.Run("outside module", func(t *testing.T) {
+		var trace bytes.Buffer
+		vertex, terms, found := preprocessor.TraceVertexSplitTraverse(&trace, g, "task.a.status", "root/module.m/task.b", preprocessor.TraverseUntilModule)
+
+		// lookups don't leave the module they start in
+		assert.False(t, found)
+		assert.Equal(t, "", vertex)
+		assert.Equal(t, "task.a.status", terms)
+		assert.Equal(
+			t,
+			"resolving \"task.a.status\" from root/module.m/task.b:\n"+
+				"  tried root/module.m/task.a.status, root/module.m/task.a, root/module.m/task, root/module: no such node\n"+
+				"  moving up to root/module.m\n"+
+				"  stopped at root/module.m: stop condition met\n"+
+				"  not found\n",
+			trace.String(),
+		)
+	})
+
+	t.Run("sibling", func(t *testing.T) {
+		var trace bytes.Buffer
+		vertex, terms, found := preprocessor.TraceVertexSplitTraverse(&trace, g, "task.b.status.stdout", "root/module.m/task.b", preprocessor.TraverseUntilModule)
+
+		assert.True(t, found)
+		assert.Equal(t, "root/module.m/task.b", vertex)
+		assert.Equal(t, "status.stdout", terms)
+		assert.Equal(
+			t,
+			"resolving \"task.b.status.stdout\" from root/module.m/task.b:\n"+
+				"  tried root/module.m/task.b.status.stdout, root/module.m/task.b.status: no such node\n"+
+				"  found root/module.m/task.b\n"+
+				"  resolved to root/module.m/task.b, fields status.stdout\n",
+			trace.String(),
+		)
+	})
+}
+
+// TestModuleOutput ensures lookups of module outputs resolve to the output node
+func TestModuleOutput(t *testing.T) {
+	t.Parallel()
+
+	g := graph.New()
+	g.Add(node.New("root/module.db", nil))
+	g.Add(node.New("root/module.db/output.port", nil))
+	g.Add(node.New("root/task.x", nil))
+
+	t.Run("output", func(t *testing.T) {
+		vertex, terms, ok := preprocessor.ModuleOutput(g, "root/module.db", "outputs.port")
+		assert.True(t, ok)
+		assert.Equal(t, "root/module.db/output.port", vertex)
+		assert.Equal(t, "value", terms)
+	})
+
+	t.Run("missing output", func(t *testing.T) {
+		vertex, terms, ok := preprocessor.ModuleOutput(g, "root/module.db", "outputs.host")
+		assert.False(t, ok)
+		assert.Equal(t, "root/module.db", vertex)
+		assert.Equal(t, "outputs.host", terms)
+	})
+
+	t.Run("not a module", func(t *testing.T) {
+		_, _, ok := preprocessor.ModuleOutput(g, "root/task.x", "outputs.port")
+		assert.False(t, ok)
+	})
+}
+
 // TestHasMethod ensures correct behavior in identifying methods on structs
 func TestHasMethod(t *testing.T) {
 	t.Parallel()
@@ -267,6 +349,61 @@ func TestLookupCanonicalFieldName(t *testing.T) {
 	})
 }
 
+// TestEvalTermsCollections tests traversing maps and slices
+func TestEvalTermsCollections(t *testing.T) {
+	t.Parallel()
+
+	type A struct {
+		Result interface{}
+		Labels map[string]string
+	}
+
+	a := &A{
+		Result: map[string]interface{}{
+			"disks": []interface{}{
+				map[string]interface{}{"name": "sda"},
+				map[string]interface{}{"name": "sdb"},
+			},
+		},
+		Labels: map[string]string{"Role": "db"},
+	}
+
+	t.Run("slice and map", func(t *testing.T) {
+		val, err := preprocessor.EvalTerms(a, "result", "disks", "1", "name")
+		assert.NoError(t, err)
+		assert.Equal(t, "sdb", val)
+	})
+
+	t.Run("typed map", func(t *testing.T) {
+		val, err := preprocessor.EvalTerms(a, "labels", "Role")
+		assert.NoError(t, err)
+		assert.Equal(t, "db", val)
+	})
+
+	t.Run("missing key", func(t *testing.T) {
+		_, err := preprocessor.EvalTerms(a, "labels", "role")
+		assert.Error(t, err)
+	})
+
+	t.Run("bad index", func(t *testing.T) {
+		_, err := preprocessor.EvalTerms(a, "result", "disks", "2")
+		assert.Error(t, err)
+
+		_, err = preprocessor.EvalTerms(a, "result", "disks", "first")
+		assert.Error(t, err)
+	})
+
+	t.Run("unresolved", func(t *testing.T) {
+		_, err := preprocessor.EvalTerms(&A{}, "result", "disks")
+		assert.Equal(t, preprocessor.ErrUnresolvable, err)
+	})
+
+	t.Run("has path", func(t *testing.T) {
+		assert.NoError(t, preprocessor.HasPath(a, "result", "disks", "0", "name"))
+		assert.Error(t, preprocessor.HasPath(a, "result", "volumes"))
+	})
+}
+
 // TestEvalTerms tests pulling field values from a struct in different scenarios
 func TestEvalTerms(t *testing.T) {
 	t.Parallel()
@@ -0,0 +1,85 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package preprocessor
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/asteris-llc/converge/graph"
+)
+
+type traceWriterKey struct{}
+
+// WithTraceWriter returns a context that makes lookups which don't resolve
+// write how they were searched for to w. This is mostly useful for finding
+// out why a lookup doesn't find the node you expect.
+func WithTraceWriter(ctx context.Context, w io.Writer) context.Context {
+	return context.WithValue(ctx, traceWriterKey{}, w)
+}
+
+// TraceWriter returns the writer set with WithTraceWriter, or nil
+func TraceWriter(ctx context.Context) io.Writer {
+	w, _ := ctx.Value(traceWriterKey{}).(io.Writer)
+	return w
+}
+
+// TraceVertexSplitTraverse searches like VertexSplitTraverse, and writes each
+// decision it makes to w: the prefixes tried, the nodes moved up to, and where
+// the stop condition ended the search. The trace is written at once, so
+// traces of searches made at the same time don't interleave.
+func TraceVertexSplitTraverse(w io.Writer, g *graph.Graph, toFind string, startingNode string, stop func(*graph.Graph, string) bool) (string, string, bool) {
+	trace := &tracer{seen: make(map[string]struct{})}
+
+	vertex, terms, found := vertexSplitTraverse(g, toFind, startingNode, stop, make(map[string]struct{}), trace)
+	switch {
+	case !found:
+		trace.printf("not found")
+	case terms == "":
+		trace.printf("resolved to %s", vertex)
+	default:
+		trace.printf("resolved to %s, fields %s", vertex, terms)
+	}
+
+	io.WriteString(
+		w,
+		fmt.Sprintf("resolving %q from %s:\n  %s\n", toFind, startingNode, strings.Join(trace.lines, "\n  ")),
+	)
+
+	return vertex, terms, found
+}
+
+// tracer collects the steps of a search. Since the search visits the same
+// nodes more than once, a step already taken is only kept once. A nil tracer
+// discards everything.
+type tracer struct {
+	lines []string
+	seen  map[string]struct{}
+}
+
+func (t *tracer) printf(format string, args ...interface{}) {
+	if t == nil {
+		return
+	}
+
+	line := fmt.Sprintf(format, args...)
+	if _, ok := t.seen[line]; ok {
+		return
+	}
+	t.seen[line] = struct{}{}
+	t.lines = append(t.lines, line)
+}
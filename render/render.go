@@ -19,6 +19,7 @@ import (
 	"crypto/rand"
 	"fmt"
 	"reflect"
+	"sort"
 
 	"github.com/asteris-llc/converge/executor"
 	"github.com/asteris-llc/converge/graph"
@@ -32,24 +33,74 @@ import (
 // Values for rendering
 type Values map[string]resource.Value
 
-// Render a graph with the provided values
+// Render a graph with the provided values. If any node fails to render, the
+// error is an *Errors with every failure.
 func Render(ctx context.Context, g *graph.Graph, top Values) (*graph.Graph, error) {
 	renderingPlant, err := NewFactory(ctx, g)
 	if err != nil {
 		return nil, err
 	}
-	return g.RootFirstTransform(ctx, func(meta *node.Node, out *graph.Graph) error {
+
+	var (
+		errs   = new(Errors)
+		failed = map[string]struct{}{}
+	)
+
+	rendered, err := g.RootFirstTransform(ctx, func(meta *node.Node, out *graph.Graph) error {
+		// a node can't be rendered without its module or the nodes it looks up,
+		// and trying would only repeat their errors
+		if dependsOnFailed(out, meta.ID, failed) {
+			failed[meta.ID] = struct{}{}
+			errs.Skipped = append(errs.Skipped, meta.ID)
+			return nil
+		}
+
 		pipeline := Pipeline(out, meta.ID, renderingPlant, top)
 		value, err := pipeline.Exec(meta.Value())
 		if err != nil {
-			return err
+			failed[meta.ID] = struct{}{}
+			errs.Nodes = append(errs.Nodes, &NodeError{ID: meta.ID, Position: meta.Position, Err: err})
+			return nil
 		}
 		out.Add(meta.WithValue(value))
 		renderingPlant.Graph = out
 		return nil
 	})
+	if err != nil {
+		return rendered, err
+	}
+
+	if len(errs.Nodes) > 0 {
+		sort.Sort(byID(errs.Nodes))
+		sort.Strings(errs.Skipped)
+		return rendered, errs
+	}
+
+	return rendered, nil
 }
 
+// dependsOnFailed tells if the node is in a module that failed to render, or
+// depends on a node that did
+func dependsOnFailed(g *graph.Graph, id string, failed map[string]struct{}) bool {
+	if _, ok := failed[graph.ParentID(id)]; ok {
+		return true
+	}
+
+	for _, edge := range g.DownEdges(id) {
+		if _, ok := failed[edge.Target().(string)]; ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+type byID []*NodeError
+
+func (b byID) Len() int           { return len(b) }
+func (b byID) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+func (b byID) Less(i, j int) bool { return b[i].ID < b[j].ID }
+
 type pipelineGen struct {
 	Graph          *graph.Graph
 	RenderingPlant *Factory
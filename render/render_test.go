@@ -17,15 +17,24 @@ package render_test
 import (
 	"context"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/asteris-llc/converge/graph"
 	"github.com/asteris-llc/converge/graph/node"
+	"github.com/asteris-llc/converge/helpers/exec"
 	"github.com/asteris-llc/converge/helpers/logging"
+	"github.com/asteris-llc/converge/helpers/statedir"
 	"github.com/asteris-llc/converge/render"
+	"github.com/asteris-llc/converge/render/extensions"
 	"github.com/asteris-llc/converge/resource"
 	"github.com/asteris-llc/converge/resource/file/content"
+	"github.com/asteris-llc/converge/resource/module"
 	"github.com/asteris-llc/converge/resource/param"
+	"github.com/asteris-llc/converge/resource/shell"
+	"github.com/asteris-llc/converge/resource/value/random"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -140,3 +149,278 @@ func TestRenderValues(t *testing.T) {
 
 	assert.Equal(t, "2", content.Destination)
 }
+
+func TestRenderCollectsErrors(t *testing.T) {
+	defer logging.HideLogs(t)()
+
+	bad := func() resource.Resource {
+		return resource.NewPreparerWithSource(
+			new(content.Preparer),
+			map[string]interface{}{"destination": "x", "content": "a", "source_file": "b"},
+		)
+	}
+
+	g := graph.New()
+	g.Add(node.New("root", nil))
+	g.Add(node.New("root/file.content.b", bad()))
+	withPosition := node.New("root/file.content.a", bad())
+	withPosition.Position = "main.hcl:3:1"
+	g.Add(withPosition)
+	g.Add(node.New(
+		"root/file.content.c",
+		resource.NewPreparerWithSource(
+			new(content.Preparer),
+			map[string]interface{}{"destination": "{{lookup `file.content.a.destination`}}"},
+		),
+	))
+	g.Add(node.New(
+		"root/file.content.ok",
+		resource.NewPreparerWithSource(
+			new(content.Preparer),
+			map[string]interface{}{"destination": "ok"},
+		),
+	))
+
+	for _, id := range []string{"root/file.content.a", "root/file.content.b", "root/file.content.c", "root/file.content.ok"} {
+		g.ConnectParent("root", id)
+	}
+	g.Connect("root/file.content.c", "root/file.content.a")
+
+	rendered, err := render.Render(context.Background(), g, render.Values{})
+	require.Error(t, err)
+
+	errs, ok := err.(*render.Errors)
+	require.True(t, ok, fmt.Sprintf("expected a %T, but got a %T", errs, err))
+
+	require.Len(t, errs.Nodes, 2)
+	assert.Equal(t, "root/file.content.a", errs.Nodes[0].ID)
+	assert.Equal(t, "main.hcl:3:1", errs.Nodes[0].Position)
+	assert.Equal(t, "root/file.content.b", errs.Nodes[1].ID)
+	assert.Equal(t, []string{"root/file.content.c"}, errs.Skipped)
+
+	assert.Equal(
+		t,
+		"2 node(s) could not be rendered:\n\n"+
+			"root/file.content.a (main.hcl:3:1):\n"+
+			"  only one of \"content\", \"source_file\", \"source_url\", \"base64\", or \"patch\" can be set\n\n"+
+			"root/file.content.b:\n"+
+			"  only one of \"content\", \"source_file\", \"source_url\", \"base64\", or \"patch\" can be set\n\n"+
+			"1 node(s) that depend on them were not rendered: root/file.content.c",
+		err.Error(),
+	)
+
+	// nodes that don't depend on the failures are still rendered
+	meta, ok := rendered.Get("root/file.content.ok")
+	require.True(t, ok)
+	_, ok = meta.Value().(*resource.TaskWrapper)
+	assert.True(t, ok, fmt.Sprintf("expected a *resource.TaskWrapper, but got a %T", meta.Value()))
+}
+
+func TestRenderInheritsModuleExecution(t *testing.T) {
+	defer logging.HideLogs(t)()
+
+	g := graph.New()
+	g.Add(node.New("root", nil))
+	g.Add(node.New(
+		"root/module.image",
+		resource.NewPreparerWithSource(
+			new(module.Preparer),
+			map[string]interface{}{
+				"target": []map[string]interface{}{{"docker": "build"}},
+			},
+		),
+	))
+	g.Add(node.New(
+		"root/module.image/task.x",
+		resource.NewPreparerWithSource(
+			new(shell.Preparer),
+			map[string]interface{}{"check": "true", "apply": "true", "become": true},
+		),
+	))
+
+	g.ConnectParent("root", "root/module.image")
+	g.ConnectParent("root/module.image", "root/module.image/task.x")
+
+	rendered, err := render.Render(context.Background(), g, render.Values{})
+	require.NoError(t, err)
+
+	meta, ok := rendered.Get("root/module.image/task.x")
+	require.True(t, ok, `"root/module.image/task.x" was missing from the graph`)
+
+	task, ok := resource.ResolveTask(meta.Value())
+	require.True(t, ok)
+
+	sh, ok := task.(*shell.Shell)
+	require.True(t, ok, fmt.Sprintf("expected task to be a %T, but it was a %T", sh, task))
+
+	generator, ok := sh.CmdGenerator.(*shell.CommandGenerator)
+	require.True(t, ok)
+
+	assert.Equal(
+		t,
+		&exec.Become{Executor: &exec.Docker{Executor: exec.New(), Container: "build"}},
+		generator.Exec,
+	)
+}
+
+func TestRenderModuleLocation(t *testing.T) {
+	defer logging.HideLogs(t)()
+
+	g := graph.New()
+	g.Add(node.New("root", nil))
+	g.Add(node.New(
+		"root/value.random.pw",
+		resource.NewPreparerWithSource(new(random.Preparer), map[string]interface{}{"become": true}),
+	))
+	g.ConnectParent("root", "root/value.random.pw")
+
+	ctx := render.WithLocation(context.Background(), "/etc/converge/app.hcl")
+	rendered, err := render.Render(ctx, g, render.Values{})
+	require.NoError(t, err)
+
+	meta, ok := rendered.Get("root/value.random.pw")
+	require.True(t, ok, `"root/value.random.pw" was missing from the graph`)
+
+	task, ok := resource.ResolveTask(meta.Value())
+	require.True(t, ok)
+
+	pw, ok := task.(*random.Random)
+	require.True(t, ok, fmt.Sprintf("expected task to be a %T, but it was a %T", pw, task))
+	assert.Equal(t, "/var/lib/converge/values/"+statedir.Key("/etc/converge/app.hcl")+"/root%2Fvalue.random.pw.json", pw.Path)
+}
+
+// laterTask has a value that is only known once it has been applied
+type laterTask struct {
+	resource.Status
+	Value *string
+}
+
+func (l *laterTask) Check(resource.Renderer) (resource.TaskStatus, error) {
+	return &l.Status, nil
+}
+
+func (l *laterTask) Apply() (resource.TaskStatus, error) {
+	return &l.Status, nil
+}
+
+func TestRenderSnapshot(t *testing.T) {
+	defer logging.HideLogs(t)()
+
+	later := &laterTask{}
+
+	g := graph.New()
+	g.Add(node.New("root", nil))
+	g.Add(node.New("root/later.x", resource.WrapTask(later)))
+	g.Add(node.New("root/file.content.y", nil))
+	g.ConnectParent("root", "root/later.x")
+	g.ConnectParent("root", "root/file.content.y")
+
+	password := "hunter2"
+	g.Add(node.New("root/value.random.pw", resource.WrapTask(&laterTask{Value: &password})))
+	g.ConnectParent("root", "root/value.random.pw")
+
+	renderer := func() *render.Renderer {
+		return &render.Renderer{
+			Graph:    func() *graph.Graph { return g },
+			ID:       "root/file.content.y",
+			Language: extensions.DefaultLanguage(),
+		}
+	}
+
+	t.Run("unresolvable", func(t *testing.T) {
+		_, err := renderer().Render("test", "{{lookup `later.x.value`}}")
+		assert.Equal(t, render.ErrUnresolvable{}, err)
+	})
+
+	t.Run("previous", func(t *testing.T) {
+		r := renderer()
+		r.Previous = render.NewSnapshot()
+		r.Previous.Set("root/later.x.value", "from last apply")
+
+		out, err := r.Render("test", "{{lookup `later.x.value`}}")
+		require.NoError(t, err)
+		assert.Equal(t, "from last apply", out)
+	})
+
+	t.Run("record", func(t *testing.T) {
+		value := "applied"
+		later.Value = &value
+		defer func() { later.Value = nil }()
+
+		r := renderer()
+		r.Record = render.NewSnapshot()
+
+		out, err := r.Render("test", "{{lookup `later.x.value`}}")
+		require.NoError(t, err)
+		assert.Equal(t, "applied", out)
+
+		recorded, ok := r.Record.Get("root/later.x.value")
+		assert.True(t, ok)
+		assert.Equal(t, "applied", recorded)
+	})
+
+	t.Run("values are not recorded", func(t *testing.T) {
+		r := renderer()
+		r.Record = render.NewSnapshot()
+
+		out, err := r.Render("test", "{{lookup `value.random.pw.value`}}")
+		require.NoError(t, err)
+		assert.Equal(t, "hunter2", out)
+		assert.Equal(t, 0, r.Record.Len())
+	})
+
+	t.Run("save and load", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "converge-snapshot")
+		require.NoError(t, err)
+		defer os.RemoveAll(dir)
+
+		path := filepath.Join(dir, "snapshots", "module.json")
+
+		missing, err := render.LoadSnapshot(path)
+		require.NoError(t, err)
+		assert.Equal(t, 0, missing.Len())
+
+		snapshot := render.NewSnapshot()
+		snapshot.Set("root/later.x.value", "saved")
+		require.NoError(t, snapshot.Save(path))
+
+		stat, err := os.Stat(path)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0600), stat.Mode().Perm())
+
+		loaded, err := render.LoadSnapshot(path)
+		require.NoError(t, err)
+
+		value, ok := loaded.Get("root/later.x.value")
+		assert.True(t, ok)
+		assert.Equal(t, "saved", value)
+	})
+
+	t.Run("node", func(t *testing.T) {
+		snapshot := render.NewSnapshot()
+		snapshot.Set("root/later.x.value", "saved")
+		snapshot.Set("root/later.x.status.stdout", "out")
+		snapshot.Set("root/later.xy.value", "other")
+
+		assert.Equal(
+			t,
+			map[string]string{"value": "saved", "status.stdout": "out"},
+			snapshot.Node("root/later.x"),
+		)
+
+		var missing *render.Snapshot
+		assert.Empty(t, missing.Node("root/later.x"))
+	})
+
+	t.Run("path", func(t *testing.T) {
+		params := map[string]string{"env": "prod", "region": "us-east-1"}
+
+		first := render.SnapshotPath("/snapshots", "main.hcl", params)
+		assert.Equal(t, "/snapshots", filepath.Dir(first))
+		assert.Equal(t, first, render.SnapshotPath("/snapshots", "main.hcl", map[string]string{"region": "us-east-1", "env": "prod"}))
+		assert.NotEqual(t, first, render.SnapshotPath("/snapshots", "./main.hcl", params))
+		assert.NotEqual(t, first, render.SnapshotPath("/snapshots", "main.hcl", map[string]string{"env": "staging", "region": "us-east-1"}))
+		assert.NotEqual(t, first, render.SnapshotPath("/snapshots", "main.hcl", nil))
+		assert.NotContains(t, first, "prod")
+	})
+}
@@ -16,14 +16,17 @@ package render
 
 import (
 	"fmt"
+	"io"
 	"reflect"
 
 	log "github.com/Sirupsen/logrus"
 	"github.com/asteris-llc/converge/graph"
+	"github.com/asteris-llc/converge/helpers/exec"
 	"github.com/asteris-llc/converge/parse"
 	"github.com/asteris-llc/converge/render/extensions"
 	"github.com/asteris-llc/converge/render/preprocessor"
 	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/module"
 	"github.com/asteris-llc/converge/resource/param"
 	"github.com/pkg/errors"
 )
@@ -34,6 +37,10 @@ type ErrUnresolvable struct{}
 
 func (ErrUnresolvable) Error() string { return "node is unresolvable" }
 
+// Unresolvable marks the error for packages that can't import render, like
+// resource
+func (ErrUnresolvable) Unresolvable() bool { return true }
+
 // Renderer to be passed to preparers, which will render strings
 type Renderer struct {
 	Graph           func() *graph.Graph
@@ -42,6 +49,10 @@ type Renderer struct {
 	DotValuePresent bool
 	resolverErr     bool
 	Language        *extensions.LanguageExtension
+	Previous        *Snapshot
+	Record          *Snapshot
+	Trace           io.Writer
+	Location        string
 }
 
 // GetID returns the ID of this renderer
@@ -54,6 +65,35 @@ func (r *Renderer) Value() (value resource.Value, present bool) {
 	return r.DotValue, r.DotValuePresent
 }
 
+// ModuleLocation returns where the module being rendered was loaded from, or
+// an empty string if it isn't known
+func (r *Renderer) ModuleLocation() string {
+	return r.Location
+}
+
+// Executor returns the executor inherited from the module containing this
+// node, so that execution settings on a module apply to everything in it
+func (r *Renderer) Executor() exec.Executor {
+	if r.Graph == nil {
+		return nil
+	}
+
+	parentMeta, ok := r.Graph().GetParent(r.ID)
+	if !ok {
+		return nil
+	}
+
+	parentTask, ok := resource.ResolveTask(parentMeta.Value())
+	if !ok {
+		return nil
+	}
+
+	if parent, ok := parentTask.(*module.Module); ok && parent != nil {
+		return parent.Exec
+	}
+	return nil
+}
+
 // Render a string with text/template
 func (r *Renderer) Render(name, src string) (string, error) {
 	r.resolverErr = false
@@ -172,8 +212,15 @@ func (r *Renderer) lookup(name string) (string, error) {
 	fqgn := graph.SiblingID(r.ID, name)
 
 	vertexName, terms, found := preprocessor.VertexSplitTraverse(g, name, r.ID, preprocessor.TraverseUntilModule, make(map[string]struct{}))
+	if !found && r.Trace != nil {
+		preprocessor.TraceVertexSplitTraverse(r.Trace, g, name, r.ID, preprocessor.TraverseUntilModule)
+	}
 
-	if !validateLookup(g, r.ID, vertexName) {
+	// the outputs of a module are the one thing inside it that can be looked
+	// up from outside
+	if output, outputTerms, ok := preprocessor.ModuleOutput(g, vertexName, terms); ok {
+		vertexName, terms = output, outputTerms
+	} else if !validateLookup(g, r.ID, vertexName) {
 		return "", fmt.Errorf("%s cannot resolve inner-branch node at %s", r.ID, vertexName)
 	}
 
@@ -186,10 +233,19 @@ func (r *Renderer) lookup(name string) (string, error) {
 		return "", fmt.Errorf("%s is empty", vertexName)
 	}
 
+	key := vertexName
+	if terms != "" {
+		key += "." + terms
+	}
+
 	if _, isThunk := meta.Value().(*PrepareThunk); isThunk {
-		log.WithField("proxy-reference", vertexName).Warn("node is unresolvable")
-		r.resolverErr = true
-		return "", ErrUnresolvable{}
+		return r.unresolvable(key)
+	}
+
+	// without an edge to the node, because of ignore_depends, it may not have
+	// been rendered yet
+	if _, isResource := meta.Value().(resource.Resource); isResource {
+		return r.unresolvable(key)
 	}
 
 	val, ok := resource.ResolveTask(meta.Value())
@@ -201,13 +257,35 @@ func (r *Renderer) lookup(name string) (string, error) {
 
 	if err != nil {
 		if err == preprocessor.ErrUnresolvable {
-			r.resolverErr = true
-			return "", ErrUnresolvable{}
+			return r.unresolvable(key)
 		}
 		return "", errors.Wrap(err, fmt.Sprintf("cannot perform a lookup of %s at %s", fqgn, r.ID))
 	}
 
-	return fmt.Sprintf("%v", result), nil
+	// values that are only known after a check, such as generated values, are
+	// pointers until then. Render what they point to instead of the address.
+	if val := reflect.ValueOf(result); val.Kind() == reflect.Ptr && !val.IsNil() && val.Elem().Kind() != reflect.Struct {
+		result = val.Elem().Interface()
+	}
+
+	out := fmt.Sprintf("%v", result)
+	if recordable(vertexName) {
+		r.Record.Set(key, out)
+	}
+	return out, nil
+}
+
+// unresolvable is the result of a lookup that can't be resolved yet. The value
+// from the last apply is used instead if there is one.
+func (r *Renderer) unresolvable(key string) (string, error) {
+	if value, ok := r.Previous.Get(key); ok {
+		log.WithField("proxy-reference", key).Debug("using value from last apply")
+		return value, nil
+	}
+
+	log.WithField("proxy-reference", key).Warn("node is unresolvable")
+	r.resolverErr = true
+	return "", ErrUnresolvable{}
 }
 
 // validateLookup ensures that the lookup is valid and resolvable over cases of
@@ -0,0 +1,191 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package render
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/asteris-llc/converge/graph"
+	"github.com/pkg/errors"
+)
+
+// DefaultSnapshotDir is where snapshots are kept by the RPC server
+const DefaultSnapshotDir = "/var/lib/converge/snapshots"
+
+// Snapshot holds the values that lookups resolved to during an apply. A plan
+// given the snapshot of the last successful apply uses it for lookups that
+// can't be resolved yet, such as values that are only known once their node
+// has been applied. Values are keyed by the ID of the node and the path of
+// the lookup inside it, for example "root/task.query.disks.result".
+type Snapshot struct {
+	lock   sync.RWMutex
+	values map[string]string
+}
+
+// NewSnapshot returns an empty Snapshot
+func NewSnapshot() *Snapshot {
+	return &Snapshot{values: make(map[string]string)}
+}
+
+// SnapshotPath returns where in dir the snapshot for the module at location,
+// applied with params, is kept. Applying a module with other params records a
+// snapshot of its own, since lookups may resolve differently. The location and
+// params are hashed, since locations may be URLs and params may be secrets.
+func SnapshotPath(dir, location string, params map[string]string) string {
+	var names []string
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	hash := sha256.New()
+	hash.Write([]byte(location))
+	for _, name := range names {
+		fmt.Fprintf(hash, "\x00%s=%s", name, params[name])
+	}
+	return filepath.Join(dir, hex.EncodeToString(hash.Sum(nil))+".json")
+}
+
+// LoadSnapshot reads a Snapshot saved with Save. If there is no file at the
+// path, the Snapshot is empty.
+func LoadSnapshot(path string) (*Snapshot, error) {
+	snapshot := NewSnapshot()
+
+	content, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return snapshot, nil
+	} else if err != nil {
+		return nil, errors.Wrap(err, "could not read snapshot")
+	}
+
+	if err := json.Unmarshal(content, &snapshot.values); err != nil {
+		return nil, errors.Wrapf(err, "could not parse snapshot %s", path)
+	}
+
+	return snapshot, nil
+}
+
+// Save writes the Snapshot to a file readable only by its owner
+func (s *Snapshot) Save(path string) error {
+	s.lock.RLock()
+	content, err := json.MarshalIndent(s.values, "", "  ")
+	s.lock.RUnlock()
+	if err != nil {
+		return errors.Wrap(err, "could not serialize snapshot")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return errors.Wrap(err, "could not create snapshot directory")
+	}
+
+	// write to a temporary file first so that a failed write doesn't leave a
+	// truncated snapshot for the next plan
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, content, 0600); err != nil {
+		return errors.Wrap(err, "could not write snapshot")
+	}
+
+	return errors.Wrap(os.Rename(tmp, path), "could not write snapshot")
+}
+
+// Get returns the value recorded for a lookup
+func (s *Snapshot) Get(key string) (string, bool) {
+	if s == nil {
+		return "", false
+	}
+
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	value, ok := s.values[key]
+	return value, ok
+}
+
+// Set records the value of a lookup
+func (s *Snapshot) Set(key, value string) {
+	if s == nil {
+		return
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.values[key] = value
+}
+
+// Node returns the values recorded for lookups of the node with the given ID,
+// keyed by their path inside it
+func (s *Snapshot) Node(id string) map[string]string {
+	out := map[string]string{}
+	if s == nil {
+		return out
+	}
+
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	for key, value := range s.values {
+		if strings.HasPrefix(key, id+".") {
+			out[strings.TrimPrefix(key, id+".")] = value
+		}
+	}
+	return out
+}
+
+// Len returns the number of values in the Snapshot
+func (s *Snapshot) Len() int {
+	if s == nil {
+		return 0
+	}
+
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return len(s.values)
+}
+
+// recordable reports whether lookups of the node with the given ID can be kept
+// in a snapshot. Params may be secrets, and generated values like passwords
+// are already persisted by their value.* resources, so neither is written to
+// a snapshot on disk.
+func recordable(id string) bool {
+	base := graph.BaseID(id)
+	return !strings.HasPrefix(base, "param.") && !strings.HasPrefix(base, "value.")
+}
+
+type snapshotKey struct{}
+
+// WithSnapshot returns a context carrying a Snapshot. Plans run with the
+// context fall back to its values, and applies record their values into it.
+func WithSnapshot(ctx context.Context, snapshot *Snapshot) context.Context {
+	return context.WithValue(ctx, snapshotKey{}, snapshot)
+}
+
+// SnapshotFrom returns the Snapshot carried by a context, or nil if there is
+// none
+func SnapshotFrom(ctx context.Context) *Snapshot {
+	snapshot, _ := ctx.Value(snapshotKey{}).(*Snapshot)
+	return snapshot
+}
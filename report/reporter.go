@@ -0,0 +1,244 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/asteris-llc/converge/helpers/logging"
+	"github.com/pkg/errors"
+)
+
+// DefaultDir is where reports are buffered while the endpoint can't be reached
+const DefaultDir = "/var/lib/converge/reports"
+
+// Defaults for sending reports
+const (
+	DefaultRetries       = 3
+	DefaultRetryInterval = 2 * time.Second
+	DefaultMaxBuffered   = 100
+	DefaultTimeout       = 10 * time.Second
+)
+
+// Reporter sends run summaries to an HTTP endpoint as JSON. A summary that
+// can't be sent after the retries is buffered on disk, and sent before the
+// next one once the endpoint can be reached again.
+type Reporter struct {
+	Endpoint string
+
+	// Dir buffers the summaries that couldn't be sent. If empty, they are
+	// dropped.
+	Dir string
+
+	// Retries is how many more times a failed send is tried, RetryInterval
+	// apart, doubling each time
+	Retries       int
+	RetryInterval time.Duration
+
+	// MaxBuffered is the most summaries kept in Dir. The oldest are removed
+	// first. 0 keeps every summary.
+	MaxBuffered int
+
+	Timeout time.Duration
+	Client  *http.Client
+}
+
+// Send sends the summaries buffered by earlier runs, oldest first, and then
+// this one. If the endpoint can't be reached the summary is buffered, and the
+// error is returned.
+func (r *Reporter) Send(ctx context.Context, summary *Summary) error {
+	logger := logging.GetLogger(ctx).WithField("function", "Reporter.Send")
+
+	body, err := json.Marshal(summary)
+	if err != nil {
+		return err
+	}
+
+	err = r.flush(ctx)
+	if err == nil {
+		err = r.post(ctx, body)
+	}
+	if err == nil {
+		return nil
+	}
+
+	if r.Dir == "" {
+		return err
+	}
+
+	if bufErr := r.buffer(body); bufErr != nil {
+		logger.WithError(bufErr).Warning("could not buffer report")
+		return err
+	}
+
+	return errors.Wrap(err, "report was buffered")
+}
+
+// Buffered returns the paths of the buffered summaries, oldest first
+func (r *Reporter) Buffered() ([]string, error) {
+	if r.Dir == "" {
+		return nil, nil
+	}
+
+	entries, err := ioutil.ReadDir(r.Dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var out []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".json") {
+			out = append(out, filepath.Join(r.Dir, entry.Name()))
+		}
+	}
+	sort.Strings(out)
+
+	return out, nil
+}
+
+// flush sends the buffered summaries, removing each once it is sent
+func (r *Reporter) flush(ctx context.Context) error {
+	paths, err := r.Buffered()
+	if err != nil {
+		return err
+	}
+
+	for _, path := range paths {
+		body, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		if err := r.post(ctx, body); err != nil {
+			return err
+		}
+
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// buffer saves a summary in the buffer directory, named so that they sort in
+// the order they were saved, and removes the oldest beyond MaxBuffered
+func (r *Reporter) buffer(body []byte) error {
+	if err := os.MkdirAll(r.Dir, 0700); err != nil {
+		return errors.Wrap(err, "could not create report directory")
+	}
+
+	name := fmt.Sprintf("%020d.json", time.Now().UnixNano())
+	if err := ioutil.WriteFile(filepath.Join(r.Dir, name), body, 0600); err != nil {
+		return err
+	}
+
+	if r.MaxBuffered <= 0 {
+		return nil
+	}
+
+	paths, err := r.Buffered()
+	if err != nil {
+		return err
+	}
+
+	for i := 0; i < len(paths)-r.MaxBuffered; i++ {
+		if err := os.Remove(paths[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// post sends a summary, retrying failures
+func (r *Reporter) post(ctx context.Context, body []byte) error {
+	interval := r.RetryInterval
+	if interval <= 0 {
+		interval = DefaultRetryInterval
+	}
+
+	var err error
+	for attempt := 0; attempt <= r.Retries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(interval):
+			}
+			interval *= 2
+		}
+
+		if err = r.postOnce(ctx, body); err == nil {
+			return nil
+		}
+	}
+
+	return errors.Wrapf(err, "could not send report after %s", plural(r.Retries+1, "attempt"))
+}
+
+func (r *Reporter) postOnce(ctx context.Context, body []byte) error {
+	timeout := r.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequest("POST", r.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+
+	client := r.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		content, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("endpoint returned %s: %s", resp.Status, strings.TrimSpace(string(content)))
+	}
+
+	return nil
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return strconv.Itoa(n) + " " + noun + "s"
+}
@@ -0,0 +1,159 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report_test
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/asteris-llc/converge/helpers/logging"
+	"github.com/asteris-llc/converge/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// endpoint is a report endpoint that fails the first few requests
+type endpoint struct {
+	lock     sync.Mutex
+	failures int
+	requests int
+	received []*report.Summary
+}
+
+func (e *endpoint) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	e.requests++
+	if e.failures > 0 {
+		e.failures--
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		return
+	}
+
+	summary := new(report.Summary)
+	if err := json.NewDecoder(r.Body).Decode(summary); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	e.received = append(e.received, summary)
+}
+
+func (e *endpoint) modules() (out []string) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	for _, summary := range e.received {
+		out = append(out, summary.Module)
+	}
+	return out
+}
+
+func TestReporterSend(t *testing.T) {
+	t.Parallel()
+
+	defer logging.HideLogs(t)()
+
+	ctx := context.Background()
+
+	t.Run("sends", func(t *testing.T) {
+		ep := new(endpoint)
+		server := httptest.NewServer(ep)
+		defer server.Close()
+
+		reporter := &report.Reporter{Endpoint: server.URL}
+		require.NoError(t, reporter.Send(ctx, &report.Summary{Module: "a.hcl", Changed: 2}))
+
+		require.Len(t, ep.received, 1)
+		assert.Equal(t, "a.hcl", ep.received[0].Module)
+		assert.Equal(t, 2, ep.received[0].Changed)
+	})
+
+	t.Run("retries", func(t *testing.T) {
+		ep := &endpoint{failures: 2}
+		server := httptest.NewServer(ep)
+		defer server.Close()
+
+		reporter := &report.Reporter{Endpoint: server.URL, Retries: 2, RetryInterval: time.Millisecond}
+		require.NoError(t, reporter.Send(ctx, &report.Summary{Module: "a.hcl"}))
+
+		assert.Equal(t, 3, ep.requests)
+		assert.Equal(t, []string{"a.hcl"}, ep.modules())
+	})
+
+	t.Run("buffers while offline", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "converge-reports")
+		require.NoError(t, err)
+		defer os.RemoveAll(dir)
+
+		ep := &endpoint{failures: 4}
+		server := httptest.NewServer(ep)
+		defer server.Close()
+
+		reporter := &report.Reporter{Endpoint: server.URL, Dir: dir, Retries: 1, RetryInterval: time.Millisecond}
+
+		err = reporter.Send(ctx, &report.Summary{Module: "a.hcl"})
+		assert.EqualError(t, err, "report was buffered: could not send report after 2 attempts: endpoint returned 503 Service Unavailable: unavailable")
+
+		// the buffered report isn't sent, so the new one is buffered after it
+		err = reporter.Send(ctx, &report.Summary{Module: "b.hcl"})
+		assert.Error(t, err)
+
+		buffered, err := reporter.Buffered()
+		require.NoError(t, err)
+		assert.Len(t, buffered, 2)
+
+		require.NoError(t, reporter.Send(ctx, &report.Summary{Module: "c.hcl"}))
+		assert.Equal(t, []string{"a.hcl", "b.hcl", "c.hcl"}, ep.modules())
+
+		buffered, err = reporter.Buffered()
+		require.NoError(t, err)
+		assert.Empty(t, buffered)
+	})
+
+	t.Run("drops oldest beyond max", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "converge-reports")
+		require.NoError(t, err)
+		defer os.RemoveAll(dir)
+
+		reporter := &report.Reporter{Endpoint: "http://127.0.0.1:1", Dir: filepath.Join(dir, "reports"), MaxBuffered: 2}
+		for _, module := range []string{"a.hcl", "b.hcl", "c.hcl"} {
+			assert.Error(t, reporter.Send(ctx, &report.Summary{Module: module}))
+		}
+
+		buffered, err := reporter.Buffered()
+		require.NoError(t, err)
+		require.Len(t, buffered, 2)
+
+		content, err := ioutil.ReadFile(buffered[0])
+		require.NoError(t, err)
+		assert.Contains(t, string(content), `"module":"b.hcl"`)
+	})
+
+	t.Run("no buffer", func(t *testing.T) {
+		reporter := &report.Reporter{Endpoint: "http://127.0.0.1:1"}
+		err := reporter.Send(ctx, &report.Summary{Module: "a.hcl"})
+		assert.Error(t, err)
+		assert.NotContains(t, err.Error(), "buffered")
+	})
+}
@@ -0,0 +1,119 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"os"
+	"runtime"
+	"time"
+
+	"github.com/asteris-llc/converge/render/extensions/platform"
+	"github.com/asteris-llc/converge/telemetry"
+)
+
+// Summary is the structured result of a run, as sent to a report endpoint
+type Summary struct {
+	Host     Host      `json:"host"`
+	Module   string    `json:"module"`
+	Stage    string    `json:"stage"`
+	Start    time.Time `json:"start"`
+	End      time.Time `json:"end"`
+	Duration float64   `json:"duration_seconds"`
+
+	// Changed and Failed count the nodes that changed and failed
+	Changed int `json:"changed"`
+	Failed  int `json:"failed"`
+
+	// Error is the error the run as a whole failed with, if any
+	Error string `json:"error,omitempty"`
+
+	Nodes []*Node `json:"nodes"`
+}
+
+// Node is the result of a single node in a run
+type Node struct {
+	ID       string  `json:"id"`
+	Type     string  `json:"type,omitempty"`
+	Status   string  `json:"status"`
+	Diffs    int     `json:"diffs"`
+	Duration float64 `json:"duration_seconds"`
+	Error    string  `json:"error,omitempty"`
+}
+
+// Host holds facts about the host a run was on
+type Host struct {
+	Name         string `json:"name"`
+	OS           string `json:"os"`
+	Arch         string `json:"arch"`
+	Family       string `json:"family,omitempty"`
+	Distribution string `json:"distribution,omitempty"`
+	Version      string `json:"version,omitempty"`
+}
+
+// CurrentHost returns the facts of the host converge is running on. Facts
+// that can't be found are left empty.
+func CurrentHost() Host {
+	name, _ := os.Hostname()
+	host := Host{Name: name, OS: runtime.GOOS, Arch: runtime.GOARCH}
+
+	if p, err := platform.DefaultPlatform(); err == nil {
+		host.Family = p.Family
+		host.Distribution = p.LinuxDistribution
+		host.Version = p.Version
+	}
+
+	return host
+}
+
+// FromTrace summarizes a run from its trace, as returned by telemetry.Trace.End.
+// The first span is the run, and the rest are its nodes.
+func FromTrace(host Host, module, stage string, spans []*telemetry.Span) *Summary {
+	out := &Summary{Host: host, Module: module, Stage: stage, Nodes: []*Node{}}
+	if len(spans) == 0 {
+		return out
+	}
+
+	run := spans[0]
+	out.Start = run.Start
+	out.End = run.End
+	out.Duration = run.End.Sub(run.Start).Seconds()
+	if run.Err != nil {
+		out.Error = run.Err.Error()
+	}
+
+	for _, span := range spans[1:] {
+		node := &Node{
+			ID:       span.Name,
+			Duration: span.End.Sub(span.Start).Seconds(),
+		}
+		node.Type, _ = span.Attributes[telemetry.AttrNodeType].(string)
+		node.Status, _ = span.Attributes[telemetry.AttrStatus].(string)
+		node.Diffs, _ = span.Attributes[telemetry.AttrDiffCount].(int)
+		if span.Err != nil {
+			node.Error = span.Err.Error()
+		}
+
+		switch node.Status {
+		case telemetry.StatusChanged:
+			out.Changed++
+		case telemetry.StatusError:
+			out.Failed++
+		}
+
+		out.Nodes = append(out.Nodes, node)
+	}
+
+	return out
+}
@@ -0,0 +1,84 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/asteris-llc/converge/report"
+	"github.com/asteris-llc/converge/telemetry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFromTrace(t *testing.T) {
+	t.Parallel()
+
+	host := report.Host{Name: "web1", OS: "linux", Arch: "amd64"}
+	start := time.Unix(1476000000, 0)
+
+	t.Run("nodes", func(t *testing.T) {
+		spans := []*telemetry.Span{
+			{Name: "converge apply", Start: start, End: start.Add(3 * time.Second)},
+			{
+				Name:       "root/task.a",
+				Start:      start,
+				End:        start.Add(time.Second),
+				Attributes: map[string]interface{}{telemetry.AttrNodeType: "task", telemetry.AttrStatus: telemetry.StatusChanged, telemetry.AttrDiffCount: 2},
+			},
+			{
+				Name:       "root/task.b",
+				Start:      start,
+				End:        start.Add(2 * time.Second),
+				Attributes: map[string]interface{}{telemetry.AttrNodeType: "task", telemetry.AttrStatus: telemetry.StatusError},
+				Err:        errors.New("failed"),
+			},
+			{
+				Name:       "root",
+				Start:      start,
+				End:        start,
+				Attributes: map[string]interface{}{telemetry.AttrStatus: telemetry.StatusUnchanged},
+			},
+		}
+
+		summary := report.FromTrace(host, "main.hcl", "apply", spans)
+
+		assert.Equal(t, host, summary.Host)
+		assert.Equal(t, "main.hcl", summary.Module)
+		assert.Equal(t, "apply", summary.Stage)
+		assert.Equal(t, float64(3), summary.Duration)
+		assert.Equal(t, 1, summary.Changed)
+		assert.Equal(t, 1, summary.Failed)
+		assert.Empty(t, summary.Error)
+
+		require.Len(t, summary.Nodes, 3)
+		assert.Equal(t, &report.Node{ID: "root/task.a", Type: "task", Status: "changed", Diffs: 2, Duration: 1}, summary.Nodes[0])
+		assert.Equal(t, &report.Node{ID: "root/task.b", Type: "task", Status: "error", Duration: 2, Error: "failed"}, summary.Nodes[1])
+		assert.Equal(t, &report.Node{ID: "root", Status: "unchanged"}, summary.Nodes[2])
+	})
+
+	t.Run("run error", func(t *testing.T) {
+		spans := []*telemetry.Span{
+			{Name: "converge apply", Start: start, End: start, Err: errors.New("policy violated")},
+		}
+
+		summary := report.FromTrace(host, "main.hcl", "apply", spans)
+
+		assert.Equal(t, "policy violated", summary.Error)
+		assert.Empty(t, summary.Nodes)
+	})
+}
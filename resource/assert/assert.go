@@ -0,0 +1,52 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package assert
+
+import (
+	"errors"
+
+	"github.com/asteris-llc/converge/resource"
+)
+
+// Assert fails when its condition is false
+type Assert struct {
+	resource.Status
+
+	// Condition is the rendered condition
+	Condition bool
+
+	// Message is reported when the condition is false
+	Message string
+}
+
+// Check fails if the condition is false. An assertion never has changes.
+func (a *Assert) Check(resource.Renderer) (resource.TaskStatus, error) {
+	a.Status = resource.Status{}
+
+	if !a.Condition {
+		a.RaiseLevel(resource.StatusFatal)
+		a.AddMessage(a.Message)
+		return a, errors.New(a.Message)
+	}
+
+	a.AddMessage("assertion passed")
+	return a, nil
+}
+
+// Apply checks the condition again, so that a failed assertion is reported by
+// apply as well as plan
+func (a *Assert) Apply() (resource.TaskStatus, error) {
+	return a.Check(nil)
+}
@@ -0,0 +1,84 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package assert_test
+
+import (
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/fakerenderer"
+	"github.com/asteris-llc/converge/resource"
+	assertion "github.com/asteris-llc/converge/resource/assert"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAssertInterface tests that Assert is properly implemented
+func TestAssertInterface(t *testing.T) {
+	t.Parallel()
+
+	assert.Implements(t, (*resource.Task)(nil), new(assertion.Assert))
+	assert.Implements(t, (*resource.Resource)(nil), new(assertion.Preparer))
+}
+
+// TestCheck tests the possible cases Check handles
+func TestCheck(t *testing.T) {
+	t.Parallel()
+
+	t.Run("passes", func(t *testing.T) {
+		task := prepare(t, &assertion.Preparer{Condition: "true"})
+
+		status, err := task.Check(fakerenderer.New())
+		require.NoError(t, err)
+		assert.False(t, status.HasChanges())
+		assert.Equal(t, resource.StatusNoChange, status.StatusCode())
+	})
+
+	t.Run("fails", func(t *testing.T) {
+		task := prepare(t, &assertion.Preparer{Condition: "false", Message: "refusing to wipe prod"})
+
+		status, err := task.Check(fakerenderer.New())
+		assert.EqualError(t, err, "refusing to wipe prod")
+		assert.Equal(t, resource.StatusFatal, status.StatusCode())
+		assert.Equal(t, []string{"refusing to wipe prod"}, status.Messages())
+	})
+}
+
+// TestApply tests that Apply fails in the same way as Check
+func TestApply(t *testing.T) {
+	t.Parallel()
+
+	_, err := prepare(t, &assertion.Preparer{Condition: "true"}).Apply()
+	assert.NoError(t, err)
+
+	_, err = prepare(t, &assertion.Preparer{Condition: "false"}).Apply()
+	assert.EqualError(t, err, "assertion root/assert.guard failed")
+}
+
+// TestPrepare tests validation of the condition
+func TestPrepare(t *testing.T) {
+	t.Parallel()
+
+	task := prepare(t, &assertion.Preparer{Condition: " TRUE\n"})
+	assert.True(t, task.Condition)
+
+	_, err := (&assertion.Preparer{Condition: "yes"}).Prepare(fakerenderer.New())
+	assert.EqualError(t, err, `assert "condition" must render to true or false, got "yes"`)
+}
+
+func prepare(t *testing.T, p *assertion.Preparer) *assertion.Assert {
+	task, err := p.Prepare(fakerenderer.NewWithID("root/assert.guard"))
+	require.NoError(t, err)
+	return task.(*assertion.Assert)
+}
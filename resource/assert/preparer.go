@@ -0,0 +1,60 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package assert
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/asteris-llc/converge/load/registry"
+	"github.com/asteris-llc/converge/resource"
+)
+
+// Preparer for Assert
+//
+// Assert stops a run when a condition about its inputs does not hold, such as
+// a combination of params that would be dangerous to apply. The condition is
+// a template like any other field, so it can use params and lookups of other
+// nodes. A false condition fails both plan and apply, and nodes depending on
+// the assertion are not applied.
+type Preparer struct {
+	// Condition must render to "true" or "false", for example
+	// `{{not (and (eq (param "env") "prod") (param "wipe"))}}`
+	Condition string `hcl:"condition" required:"true"`
+
+	// Message explains why the assertion failed. It defaults to a message
+	// naming the node.
+	Message string `hcl:"message"`
+}
+
+// Prepare a new task
+func (p *Preparer) Prepare(render resource.Renderer) (resource.Task, error) {
+	condition, err := strconv.ParseBool(strings.TrimSpace(p.Condition))
+	if err != nil {
+		return nil, fmt.Errorf("assert \"condition\" must render to true or false, got %q", p.Condition)
+	}
+
+	message := p.Message
+	if message == "" {
+		message = fmt.Sprintf("assertion %s failed", render.GetID())
+	}
+
+	return &Assert{Condition: condition, Message: message}, nil
+}
+
+func init() {
+	registry.Register("assert", (*Preparer)(nil), (*Assert)(nil))
+}
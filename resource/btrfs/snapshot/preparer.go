@@ -0,0 +1,112 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapshot
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/load/registry"
+	"github.com/asteris-llc/converge/resource"
+)
+
+// Preparer for Snapshot
+//
+// Snapshot takes a snapshot of a btrfs subvolume whenever the newest one is
+// older than the interval, and deletes the oldest ones beyond the number to
+// keep. Running converge periodically, for example from a systemd.timer,
+// schedules the snapshots. Snapshots are named NAME-TIMESTAMP in the snapshot
+// directory, and other entries in the directory are left alone.
+type Preparer struct {
+	// Source is the path of the subvolume to snapshot
+	Source string `hcl:"source" required:"true"`
+
+	// Dir is the directory snapshots are kept in. It must be on the same btrfs
+	// filesystem as the source and is created if missing.
+	Dir string `hcl:"dir" required:"true"`
+
+	// Name is the prefix of snapshot names. It defaults to the base name of
+	// the source.
+	Name string `hcl:"name"`
+
+	// Interval is the minimum time between snapshots, such as "1h" or "24h".
+	// It defaults to 24h.
+	Interval string `hcl:"interval" doc_type:"duration_string"`
+
+	// Keep is the number of snapshots to keep. It defaults to 7.
+	Keep *int `hcl:"keep"`
+
+	// ReadOnly controls whether snapshots are read-only. It defaults to true.
+	ReadOnly *bool `hcl:"readonly"`
+}
+
+// Prepare a new task
+func (p *Preparer) Prepare(render resource.Renderer) (resource.Task, error) {
+	for _, dir := range []string{p.Source, p.Dir} {
+		if !path.IsAbs(dir) {
+			return nil, fmt.Errorf("btrfs.snapshot: %q is not an absolute path", dir)
+		}
+	}
+
+	if p.Name == "" {
+		p.Name = path.Base(p.Source)
+	}
+	if p.Name == "/" || strings.ContainsAny(p.Name, "/ \t\n") {
+		return nil, fmt.Errorf("btrfs.snapshot: %q is not a valid name", p.Name)
+	}
+
+	interval := 24 * time.Hour
+	if p.Interval != "" {
+		duration, err := time.ParseDuration(p.Interval)
+		if err != nil {
+			return nil, err
+		}
+		interval = duration
+	}
+	if interval <= 0 {
+		return nil, fmt.Errorf("btrfs.snapshot \"interval\" must be positive, got %s", interval)
+	}
+
+	keep := 7
+	if p.Keep != nil {
+		keep = *p.Keep
+	}
+	if keep < 1 {
+		return nil, fmt.Errorf("btrfs.snapshot \"keep\" must be at least 1, got %d", keep)
+	}
+
+	readOnly := true
+	if p.ReadOnly != nil {
+		readOnly = *p.ReadOnly
+	}
+
+	return &Snapshot{
+		Source:   path.Clean(p.Source),
+		Dir:      path.Clean(p.Dir),
+		Name:     p.Name,
+		Interval: interval,
+		Keep:     keep,
+		ReadOnly: readOnly,
+		exec:     exec.For(render),
+	}, nil
+}
+
+func init() {
+	registry.Register("btrfs.snapshot", (*Preparer)(nil), (*Snapshot)(nil))
+	registry.RegisterPlatforms("btrfs.snapshot", "linux")
+}
@@ -0,0 +1,185 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapshot
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/pkg/errors"
+)
+
+// TimeFormat is the layout of the timestamp in snapshot names, in UTC
+const TimeFormat = "20060102T150405Z"
+
+// Snapshot takes and prunes snapshots of a btrfs subvolume
+type Snapshot struct {
+	resource.Status
+
+	Source   string
+	Dir      string
+	Name     string
+	Interval time.Duration
+	Keep     int
+	ReadOnly bool
+
+	// Snapshots are the paths of the existing snapshots, oldest first
+	Snapshots []string
+
+	// next and prune are planned by Check, so that Apply takes the snapshot
+	// named in the plan
+	next    string
+	prune   []string
+	planned bool
+
+	exec exec.Executor
+}
+
+// Check whether a snapshot is due or old snapshots need deleting
+func (s *Snapshot) Check(resource.Renderer) (resource.TaskStatus, error) {
+	s.Status = resource.Status{}
+
+	if err := s.plan(time.Now()); err != nil {
+		s.RaiseLevel(resource.StatusFatal)
+		return s, err
+	}
+
+	if s.next != "" {
+		s.RaiseLevel(resource.StatusWillChange)
+		s.AddDifference(s.next, "<absent>", "snapshot of "+s.Source, "")
+	}
+
+	for _, old := range s.prune {
+		s.RaiseLevel(resource.StatusWillChange)
+		s.AddDifference(old, "snapshot of "+s.Source, "<absent>", "")
+	}
+
+	return s, nil
+}
+
+// Apply takes a snapshot if one is due and deletes old ones
+func (s *Snapshot) Apply() (resource.TaskStatus, error) {
+	s.Status = resource.Status{}
+
+	if !s.planned {
+		if err := s.plan(time.Now()); err != nil {
+			s.RaiseLevel(resource.StatusFatal)
+			return s, err
+		}
+	}
+	next, prune := s.next, s.prune
+	s.planned = false
+
+	if next != "" {
+		if err := exec.Run(s.exec, "mkdir", "-p", s.Dir); err != nil {
+			s.RaiseLevel(resource.StatusFatal)
+			return s, errors.Wrapf(err, "cannot create %s", s.Dir)
+		}
+
+		args := []string{"subvolume", "snapshot"}
+		if s.ReadOnly {
+			args = append(args, "-r")
+		}
+		if err := exec.Run(s.exec, "btrfs", append(args, s.Source, next)...); err != nil {
+			s.RaiseLevel(resource.StatusFatal)
+			return s, errors.Wrapf(err, "cannot snapshot %s", s.Source)
+		}
+		s.AddMessage("created " + next)
+	}
+
+	for _, old := range prune {
+		if err := exec.Run(s.exec, "btrfs", "subvolume", "delete", old); err != nil {
+			s.RaiseLevel(resource.StatusFatal)
+			return s, errors.Wrapf(err, "cannot delete %s", old)
+		}
+		s.AddMessage("deleted " + old)
+	}
+
+	return s, nil
+}
+
+// plan finds the path of the snapshot to take at now, if one is due, and the
+// paths of the snapshots to delete
+func (s *Snapshot) plan(now time.Time) error {
+	s.next, s.prune, s.planned = "", nil, false
+
+	times, err := s.list()
+	if err != nil {
+		return err
+	}
+
+	if len(times) == 0 || now.Sub(times[len(times)-1]) >= s.Interval {
+		s.next = s.path(now)
+		times = append(times, now)
+	}
+
+	for len(times) > s.Keep {
+		s.prune = append(s.prune, s.path(times[0]))
+		times = times[1:]
+	}
+
+	s.planned = true
+	return nil
+}
+
+// list finds the existing snapshots, oldest first
+func (s *Snapshot) list() ([]time.Time, error) {
+	s.Snapshots = nil
+
+	err := exec.Run(s.exec, "test", "-d", s.Dir)
+	if _, ok := exec.ExitStatus(err); ok {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	out, err := exec.Read(s.exec, "ls", "-1", s.Dir)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot list %s", s.Dir)
+	}
+
+	var times []time.Time
+	for _, entry := range strings.Split(out, "\n") {
+		if !strings.HasPrefix(entry, s.Name+"-") {
+			continue
+		}
+		taken, err := time.Parse(TimeFormat, strings.TrimPrefix(entry, s.Name+"-"))
+		if err != nil {
+			continue
+		}
+		times = append(times, taken)
+	}
+	sort.Sort(byTime(times))
+
+	for _, taken := range times {
+		s.Snapshots = append(s.Snapshots, s.path(taken))
+	}
+	return times, nil
+}
+
+func (s *Snapshot) path(taken time.Time) string {
+	return path.Join(s.Dir, fmt.Sprintf("%s-%s", s.Name, taken.UTC().Format(TimeFormat)))
+}
+
+type byTime []time.Time
+
+func (t byTime) Len() int           { return len(t) }
+func (t byTime) Less(i, j int) bool { return t[i].Before(t[j]) }
+func (t byTime) Swap(i, j int)      { t[i], t[j] = t[j], t[i] }
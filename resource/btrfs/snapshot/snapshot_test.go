@@ -0,0 +1,149 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapshot_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/asteris-llc/converge/helpers/fakeexec"
+	"github.com/asteris-llc/converge/helpers/fakerenderer"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/btrfs/snapshot"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const dir = "/srv/.snapshots"
+
+// TestSnapshotInterface tests that Snapshot is properly implemented
+func TestSnapshotInterface(t *testing.T) {
+	t.Parallel()
+
+	assert.Implements(t, (*resource.Task)(nil), new(snapshot.Snapshot))
+	assert.Implements(t, (*resource.Resource)(nil), new(snapshot.Preparer))
+}
+
+// TestCheck tests the possible cases Check handles
+func TestCheck(t *testing.T) {
+	t.Parallel()
+
+	t.Run("first", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("test", "-d", dir).Return("", 1)
+
+		s := prepare(t, fake, &snapshot.Preparer{})
+		status, err := s.Check(fakerenderer.New())
+
+		require.NoError(t, err)
+		assert.True(t, status.HasChanges())
+		assert.Len(t, status.Diffs(), 1)
+	})
+
+	t.Run("recent", func(t *testing.T) {
+		s := prepare(t, listing(ago(time.Hour), "lost+found"), &snapshot.Preparer{})
+		status, err := s.Check(fakerenderer.New())
+
+		require.NoError(t, err)
+		assert.False(t, status.HasChanges())
+		assert.Len(t, s.Snapshots, 1)
+	})
+
+	t.Run("due and prune", func(t *testing.T) {
+		keep := 2
+		oldest, older := ago(72*time.Hour), ago(48*time.Hour)
+		s := prepare(t, listing(older, oldest), &snapshot.Preparer{Keep: &keep})
+		status, err := s.Check(fakerenderer.New())
+
+		require.NoError(t, err)
+		assert.True(t, status.HasChanges())
+		assert.Len(t, status.Diffs(), 2)
+		assert.Equal(t, "<absent>", status.Diffs()[dir+"/srv-"+oldest].Current())
+		assert.Equal(t, []string{dir + "/srv-" + oldest, dir + "/srv-" + older}, s.Snapshots)
+	})
+}
+
+// TestApply tests that Apply takes the planned snapshot and deletes old ones
+func TestApply(t *testing.T) {
+	t.Parallel()
+
+	keep := 1
+	old := ago(48 * time.Hour)
+	fake := listing(old)
+
+	s := prepare(t, fake, &snapshot.Preparer{Keep: &keep})
+	status, err := s.Check(fakerenderer.New())
+	require.NoError(t, err)
+
+	var next string
+	for name, diff := range status.Diffs() {
+		if diff.Original() == "<absent>" {
+			next = name
+		}
+	}
+	require.NotEmpty(t, next)
+
+	fake.Expect("mkdir", "-p", dir)
+	fake.Expect("btrfs", "subvolume", "snapshot", "-r", "/srv", next)
+	fake.Expect("btrfs", "subvolume", "delete", dir+"/srv-"+old)
+
+	_, err = s.Apply()
+	require.NoError(t, err)
+	fake.AssertExpectations(t)
+}
+
+// TestPrepare tests the invalid cases of Prepare
+func TestPrepare(t *testing.T) {
+	t.Parallel()
+
+	fr := fakerenderer.New()
+
+	_, err := (&snapshot.Preparer{Source: "srv", Dir: dir}).Prepare(fr)
+	assert.EqualError(t, err, `btrfs.snapshot: "srv" is not an absolute path`)
+
+	keep := 0
+	_, err = (&snapshot.Preparer{Source: "/srv", Dir: dir, Keep: &keep}).Prepare(fr)
+	assert.EqualError(t, err, `btrfs.snapshot "keep" must be at least 1, got 0`)
+}
+
+func ago(d time.Duration) string {
+	return time.Now().Add(-d).UTC().Format(snapshot.TimeFormat)
+}
+
+func listing(timestamps ...string) *fakeexec.Executor {
+	var entries []string
+	for _, ts := range timestamps {
+		if ts == "lost+found" {
+			entries = append(entries, ts)
+		} else {
+			entries = append(entries, "srv-"+ts)
+		}
+	}
+
+	fake := fakeexec.New()
+	fake.Expect("test", "-d", dir)
+	fake.Expect("ls", "-1", dir).Return(strings.Join(entries, "\n")+"\n", 0)
+	return fake
+}
+
+func prepare(t *testing.T, fake *fakeexec.Executor, p *snapshot.Preparer) *snapshot.Snapshot {
+	p.Source = "/srv"
+	p.Dir = dir
+
+	task, err := p.Prepare(&resource.ExecRenderer{Renderer: fakerenderer.New(), Exec: fake})
+	require.NoError(t, err)
+	return task.(*snapshot.Snapshot)
+}
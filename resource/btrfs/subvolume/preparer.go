@@ -0,0 +1,74 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package subvolume
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/load/registry"
+	"github.com/asteris-llc/converge/resource"
+)
+
+// Preparer for Subvolume
+//
+// Subvolume creates a btrfs subvolume and keeps its properties set. Mounting
+// a subvolume elsewhere is done through fstab with the subvol= mount option,
+// along with any other mount options. Deleting a subvolume fails if it
+// contains other subvolumes.
+type Preparer struct {
+	// Path of the subvolume. Its parent directory must be on a btrfs
+	// filesystem and must exist.
+	Path string `hcl:"path" required:"true"`
+
+	// Properties are btrfs properties of the subvolume, such as compression.
+	// See btrfs-property(8) for the ones available.
+	Properties map[string]string `hcl:"properties"`
+
+	// State is whether the subvolume should be present or absent. It defaults
+	// to present.
+	State State `hcl:"state" valid_values:"present,absent"`
+}
+
+// Prepare a new task
+func (p *Preparer) Prepare(render resource.Renderer) (resource.Task, error) {
+	if !path.IsAbs(p.Path) || path.Clean(p.Path) == "/" {
+		return nil, fmt.Errorf("btrfs.subvolume: %q is not a valid path", p.Path)
+	}
+
+	for key, value := range p.Properties {
+		if key == "" || strings.ContainsAny(key, "= \t\n") || strings.ContainsAny(value, "\n") {
+			return nil, fmt.Errorf("btrfs.subvolume: %q is not a valid property", key)
+		}
+	}
+
+	if p.State == "" {
+		p.State = StatePresent
+	}
+
+	return &Subvolume{
+		Path:       path.Clean(p.Path),
+		Properties: p.Properties,
+		State:      p.State,
+		exec:       exec.For(render),
+	}, nil
+}
+
+func init() {
+	registry.Register("btrfs.subvolume", (*Preparer)(nil), (*Subvolume)(nil))
+	registry.RegisterPlatforms("btrfs.subvolume", "linux")
+}
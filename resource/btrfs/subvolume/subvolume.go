@@ -0,0 +1,163 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package subvolume
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/pkg/errors"
+)
+
+// State type for Subvolume
+type State string
+
+const (
+	// StatePresent indicates the subvolume should be present
+	StatePresent State = "present"
+
+	// StateAbsent indicates the subvolume should be absent
+	StateAbsent State = "absent"
+)
+
+// Subvolume manages a btrfs subvolume
+type Subvolume struct {
+	resource.Status
+
+	Path       string
+	Properties map[string]string
+	State      State
+
+	exec exec.Executor
+}
+
+// Check whether the subvolume exists with the declared properties
+func (s *Subvolume) Check(resource.Renderer) (resource.TaskStatus, error) {
+	s.Status = resource.Status{}
+
+	exists, changed, err := s.diff()
+	if err != nil {
+		s.RaiseLevel(resource.StatusFatal)
+		return s, err
+	}
+
+	switch {
+	case s.State == StateAbsent && exists:
+		s.RaiseLevel(resource.StatusWillChange)
+		s.AddDifference(s.Path, "subvolume", "<absent>", "")
+
+	case s.State == StatePresent && !exists:
+		s.RaiseLevel(resource.StatusWillChange)
+		s.AddDifference(s.Path, "<absent>", "subvolume", "")
+
+	case s.State == StatePresent:
+		for _, key := range keys(changed) {
+			s.RaiseLevel(resource.StatusWillChange)
+			s.AddDifference(key, changed[key], s.Properties[key], "<unset>")
+		}
+	}
+
+	return s, nil
+}
+
+// Apply creates or deletes the subvolume and sets its properties
+func (s *Subvolume) Apply() (resource.TaskStatus, error) {
+	s.Status = resource.Status{}
+
+	exists, changed, err := s.diff()
+	if err != nil {
+		s.RaiseLevel(resource.StatusFatal)
+		return s, err
+	}
+
+	if s.State == StateAbsent {
+		if exists {
+			if err := exec.Run(s.exec, "btrfs", "subvolume", "delete", s.Path); err != nil {
+				s.RaiseLevel(resource.StatusFatal)
+				return s, errors.Wrapf(err, "cannot delete %s", s.Path)
+			}
+			s.AddMessage("deleted " + s.Path)
+		}
+		return s, nil
+	}
+
+	if !exists {
+		if err := exec.Run(s.exec, "btrfs", "subvolume", "create", s.Path); err != nil {
+			s.RaiseLevel(resource.StatusFatal)
+			return s, errors.Wrapf(err, "cannot create %s", s.Path)
+		}
+		s.AddMessage("created " + s.Path)
+		changed = s.Properties
+	}
+
+	for _, key := range keys(changed) {
+		if err := exec.Run(s.exec, "btrfs", "property", "set", s.Path, key, s.Properties[key]); err != nil {
+			s.RaiseLevel(resource.StatusFatal)
+			return s, errors.Wrapf(err, "cannot set %s on %s", key, s.Path)
+		}
+		s.AddMessage(fmt.Sprintf("set %s=%s", key, s.Properties[key]))
+	}
+
+	return s, nil
+}
+
+// diff returns whether the subvolume exists and the current values of the
+// properties that differ. A path that exists but isn't a subvolume is an
+// error.
+func (s *Subvolume) diff() (bool, map[string]string, error) {
+	err := exec.Run(s.exec, "test", "-e", s.Path)
+	if _, ok := exec.ExitStatus(err); ok {
+		return false, nil, nil
+	} else if err != nil {
+		return false, nil, err
+	}
+
+	err = exec.Run(s.exec, "btrfs", "subvolume", "show", s.Path)
+	if _, ok := exec.ExitStatus(err); ok {
+		return false, nil, fmt.Errorf("%s exists and is not a btrfs subvolume", s.Path)
+	} else if err != nil {
+		return false, nil, err
+	}
+
+	if s.State == StateAbsent {
+		return true, nil, nil
+	}
+
+	changed := map[string]string{}
+	for key, value := range s.Properties {
+		out, err := exec.Read(s.exec, "btrfs", "property", "get", s.Path, key)
+		if err != nil {
+			return true, nil, errors.Wrapf(err, "cannot get %s of %s", key, s.Path)
+		}
+
+		current := strings.TrimPrefix(strings.TrimSpace(out), key+"=")
+		if current != value {
+			changed[key] = current
+		}
+	}
+	return true, changed, nil
+}
+
+func keys(properties map[string]string) []string {
+	var out []string
+	for key := range properties {
+		out = append(out, key)
+	}
+	sort.Strings(out)
+	return out
+}
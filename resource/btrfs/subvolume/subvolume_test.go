@@ -0,0 +1,132 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package subvolume_test
+
+import (
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/fakeexec"
+	"github.com/asteris-llc/converge/helpers/fakerenderer"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/btrfs/subvolume"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSubvolumeInterface tests that Subvolume is properly implemented
+func TestSubvolumeInterface(t *testing.T) {
+	t.Parallel()
+
+	assert.Implements(t, (*resource.Task)(nil), new(subvolume.Subvolume))
+	assert.Implements(t, (*resource.Resource)(nil), new(subvolume.Preparer))
+}
+
+// TestCheck tests the possible cases Check handles
+func TestCheck(t *testing.T) {
+	t.Parallel()
+
+	t.Run("missing", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("test", "-e", "/srv/data").Return("", 1)
+
+		s := prepare(t, fake, &subvolume.Preparer{})
+		status, err := s.Check(fakerenderer.New())
+
+		require.NoError(t, err)
+		assert.Equal(t, "subvolume", status.Diffs()["/srv/data"].Current())
+	})
+
+	t.Run("not a subvolume", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("test", "-e", "/srv/data")
+		fake.Expect("btrfs", "subvolume", "show", "/srv/data").Return("", 1)
+
+		s := prepare(t, fake, &subvolume.Preparer{})
+		_, err := s.Check(fakerenderer.New())
+
+		assert.EqualError(t, err, "/srv/data exists and is not a btrfs subvolume")
+	})
+
+	t.Run("properties", func(t *testing.T) {
+		fake := existing()
+		fake.Expect("btrfs", "property", "get", "/srv/data", "compression").Return("\n", 0)
+
+		s := prepare(t, fake, &subvolume.Preparer{Properties: map[string]string{"compression": "zstd"}})
+		status, err := s.Check(fakerenderer.New())
+
+		require.NoError(t, err)
+		assert.True(t, status.HasChanges())
+		assert.Equal(t, "<unset>", status.Diffs()["compression"].Original())
+	})
+
+	t.Run("converged", func(t *testing.T) {
+		fake := existing()
+		fake.Expect("btrfs", "property", "get", "/srv/data", "compression").Return("compression=zstd\n", 0)
+
+		s := prepare(t, fake, &subvolume.Preparer{Properties: map[string]string{"compression": "zstd"}})
+		status, err := s.Check(fakerenderer.New())
+
+		require.NoError(t, err)
+		assert.False(t, status.HasChanges())
+	})
+}
+
+// TestApply tests creating and deleting subvolumes
+func TestApply(t *testing.T) {
+	t.Parallel()
+
+	t.Run("create", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("test", "-e", "/srv/data").Return("", 1)
+		fake.Expect("btrfs", "subvolume", "create", "/srv/data")
+		fake.Expect("btrfs", "property", "set", "/srv/data", "compression", "zstd")
+
+		_, err := prepare(t, fake, &subvolume.Preparer{Properties: map[string]string{"compression": "zstd"}}).Apply()
+		require.NoError(t, err)
+		fake.AssertExpectations(t)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		fake := existing()
+		fake.Expect("btrfs", "subvolume", "delete", "/srv/data")
+
+		_, err := prepare(t, fake, &subvolume.Preparer{State: subvolume.StateAbsent}).Apply()
+		require.NoError(t, err)
+		fake.AssertExpectations(t)
+	})
+}
+
+// TestPrepare tests the invalid cases of Prepare
+func TestPrepare(t *testing.T) {
+	t.Parallel()
+
+	_, err := (&subvolume.Preparer{Path: "/"}).Prepare(fakerenderer.New())
+	assert.EqualError(t, err, `btrfs.subvolume: "/" is not a valid path`)
+}
+
+func existing() *fakeexec.Executor {
+	fake := fakeexec.New()
+	fake.Expect("test", "-e", "/srv/data")
+	fake.Expect("btrfs", "subvolume", "show", "/srv/data")
+	return fake
+}
+
+func prepare(t *testing.T, fake *fakeexec.Executor, p *subvolume.Preparer) *subvolume.Subvolume {
+	p.Path = "/srv/data"
+
+	task, err := p.Prepare(&resource.ExecRenderer{Renderer: fakerenderer.New(), Exec: fake})
+	require.NoError(t, err)
+	return task.(*subvolume.Subvolume)
+}
@@ -0,0 +1,62 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import "reflect"
+
+// Canaried is implemented by tasks that may be marked as canaries. The apply
+// pipeline applies every canary in the run before anything else, and halts the
+// rest of the run if any of them fails.
+type Canaried interface {
+	Canary() bool
+}
+
+// canary holds the node-level setting that marks a resource as a canary. Like
+// "depends" and "group", it can be set on any resource.
+type canary struct {
+	// Canary is whether the resource is applied ahead of everything else in
+	// the run that doesn't have to come before it. If it fails to apply,
+	// including failing its "ready_when" checks, nothing after it is applied.
+	Canary bool `hcl:"canary"`
+}
+
+// CanaryTask wraps a task to mark it as a canary
+type CanaryTask struct {
+	Wrapped
+}
+
+// Canary is always true for a CanaryTask
+func (c *CanaryTask) Canary() bool {
+	return true
+}
+
+// prepareCanary wraps task in a CanaryTask if the node sets `canary = true`
+func (p *Preparer) prepareCanary(r Renderer, task Task) (Task, error) {
+	field, _ := reflect.TypeOf(canary{}).FieldByName("Canary")
+	if _, ok := p.Source[p.getFieldName(field)]; !ok {
+		return task, nil
+	}
+
+	val, err := p.getValueForField(r, field)
+	if err != nil {
+		return nil, err
+	}
+
+	if !val.Bool() {
+		return task, nil
+	}
+
+	return &CanaryTask{Wrapped: Wrapped{Task: task}}, nil
+}
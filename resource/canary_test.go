@@ -0,0 +1,52 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource_test
+
+import (
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/fakerenderer"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestPreparerCanary tests that canary marks the task
+func TestPreparerCanary(t *testing.T) {
+	t.Parallel()
+
+	t.Run("unset", func(t *testing.T) {
+		target := new(testRollbackTarget)
+		task, err := resource.NewPreparerWithSource(target, map[string]interface{}{}).Prepare(fakerenderer.New())
+		require.NoError(t, err)
+		assert.Equal(t, target, task)
+	})
+
+	t.Run("false", func(t *testing.T) {
+		target := new(testRollbackTarget)
+		task, err := resource.NewPreparerWithSource(target, map[string]interface{}{"canary": false}).Prepare(fakerenderer.New())
+		require.NoError(t, err)
+		assert.Equal(t, target, task)
+	})
+
+	t.Run("true", func(t *testing.T) {
+		task, err := resource.NewPreparerWithSource(new(testRollbackTarget), map[string]interface{}{"canary": true, "on_failure": "rollback"}).Prepare(fakerenderer.New())
+		require.NoError(t, err)
+
+		require.Implements(t, (*resource.Canaried)(nil), task)
+		assert.True(t, task.(resource.Canaried).Canary())
+		assert.Equal(t, resource.OnFailureRollback, task.(resource.FailureHandler).OnFailure())
+	})
+}
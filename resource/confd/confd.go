@@ -0,0 +1,196 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package confd manages configuration fragments dropped into a directory read
+// by a service, such as nginx's conf.d. A fragment is only left in place if
+// the service accepts the assembled configuration, and the service is only
+// reloaded once it does.
+package confd
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/pkg/errors"
+)
+
+// Mode is the mode fragments are written with
+const Mode = 0644
+
+// State type for Fragment
+type State string
+
+const (
+	// StatePresent indicates the fragment should be present
+	StatePresent State = "present"
+
+	// StateAbsent indicates the fragment should be absent
+	StateAbsent State = "absent"
+)
+
+// Service describes the program that reads the fragments
+type Service struct {
+	// Unit is the systemd unit reloaded after the configuration changes
+	Unit string
+
+	// Validate checks the assembled configuration, exiting non-zero if it is
+	// invalid
+	Validate []string
+}
+
+// Fragment manages a single file in a configuration directory
+type Fragment struct {
+	resource.Status
+
+	Path    string
+	Content string
+	State   State
+	Reload  bool
+	Service Service
+
+	exec exec.Executor
+}
+
+// New returns a fragment that runs commands with e
+func New(e exec.Executor, service Service, dir, name, ext, content string, state State, reload bool) (*Fragment, error) {
+	if name == "" || strings.ContainsAny(name, "/ \t\n") || strings.HasPrefix(name, ".") {
+		return nil, fmt.Errorf("%q is not a valid fragment name", name)
+	}
+
+	if state == "" {
+		state = StatePresent
+	}
+
+	if state == StatePresent && strings.TrimSpace(content) == "" {
+		return nil, fmt.Errorf("\"content\" is required unless the state is absent")
+	}
+
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+
+	return &Fragment{
+		Path:    path.Join(dir, name+ext),
+		Content: content,
+		State:   state,
+		Reload:  reload,
+		Service: service,
+		exec:    e,
+	}, nil
+}
+
+// Check whether the fragment has the declared content
+func (f *Fragment) Check(resource.Renderer) (resource.TaskStatus, error) {
+	f.Status = resource.Status{}
+
+	current, exists, err := exec.ReadFile(f.exec, f.Path)
+	if err != nil {
+		f.RaiseLevel(resource.StatusFatal)
+		return f, errors.Wrapf(err, "cannot read %s", f.Path)
+	}
+
+	switch {
+	case f.State == StateAbsent && exists:
+		f.RaiseLevel(resource.StatusWillChange)
+		f.AddDifference(f.Path, current, "<file-missing>", "")
+
+	case f.State == StatePresent && (!exists || current != f.Content):
+		f.RaiseLevel(resource.StatusWillChange)
+		f.AddDifference(f.Path, current, f.Content, "<file-missing>")
+	}
+
+	return f, nil
+}
+
+// Apply writes or removes the fragment and validates the result. If the
+// service rejects it, the previous fragment is put back.
+func (f *Fragment) Apply() (resource.TaskStatus, error) {
+	f.Status = resource.Status{}
+
+	previous, existed, err := exec.ReadFile(f.exec, f.Path)
+	if err != nil {
+		f.RaiseLevel(resource.StatusFatal)
+		return f, errors.Wrapf(err, "cannot read %s", f.Path)
+	}
+
+	if f.State == StateAbsent {
+		err = exec.Run(f.exec, "rm", "-f", f.Path)
+	} else {
+		err = exec.WriteFile(f.exec, f.Path, f.Content, Mode)
+	}
+	if err != nil {
+		f.RaiseLevel(resource.StatusFatal)
+		return f, errors.Wrapf(err, "cannot update %s", f.Path)
+	}
+
+	out, err := f.validate()
+	if err != nil {
+		f.RaiseLevel(resource.StatusFatal)
+		if _, ok := exec.ExitStatus(err); !ok {
+			return f, err
+		}
+
+		f.AddMessage(strings.TrimSpace(out))
+		if rerr := f.restore(previous, existed); rerr != nil {
+			return f, errors.Wrapf(rerr, "%s rejected %s and it could not be restored", f.Service.Unit, f.Path)
+		}
+		return f, fmt.Errorf("%s rejected %s, restored the previous configuration", f.Service.Unit, f.Path)
+	}
+	f.AddMessage("updated " + f.Path)
+
+	if f.Reload {
+		if err := exec.Run(f.exec, "systemctl", "reload", f.Service.Unit); err != nil {
+			f.RaiseLevel(resource.StatusFatal)
+			return f, errors.Wrapf(err, "cannot reload %s", f.Service.Unit)
+		}
+		f.AddMessage("reloaded " + f.Service.Unit)
+	}
+
+	return f, nil
+}
+
+// ManagedFiles returns the path of the fragment, so it is backed up before being
+// changed
+func (f *Fragment) ManagedFiles() []string {
+	return []string{f.Path}
+}
+
+// Executor returns the executor the file is written with
+func (f *Fragment) Executor() exec.Executor {
+	return f.exec
+}
+
+// validate runs the service's check, returning its output. Validators such as
+// nginx -t report on stderr.
+func (f *Fragment) validate() (string, error) {
+	cmd := exec.NewCommand(f.Service.Validate[0], f.Service.Validate[1:]...)
+	result, err := f.exec.Run(cmd)
+	if err != nil {
+		return "", err
+	}
+	if !result.Success() {
+		return result.Stdout + result.Stderr, &exec.ExitError{Command: cmd, Result: result}
+	}
+	return result.Stdout + result.Stderr, nil
+}
+
+func (f *Fragment) restore(previous string, existed bool) error {
+	if !existed {
+		return exec.Run(f.exec, "rm", "-f", f.Path)
+	}
+	return exec.WriteFile(f.exec, f.Path, previous, Mode)
+}
@@ -0,0 +1,111 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package confd_test
+
+import (
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/helpers/fakeexec"
+	"github.com/asteris-llc/converge/helpers/fakerenderer"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/confd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	path    = "/etc/nginx/conf.d/app.conf"
+	write   = exec.WriteFileScript
+	content = "server {\n  listen 80;\n}\n"
+)
+
+var service = confd.Service{Unit: "nginx", Validate: []string{"nginx", "-t"}}
+
+// TestFragmentInterface tests that Fragment is properly implemented
+func TestFragmentInterface(t *testing.T) {
+	t.Parallel()
+
+	assert.Implements(t, (*resource.Task)(nil), new(confd.Fragment))
+}
+
+// TestCheck tests comparing the fragment
+func TestCheck(t *testing.T) {
+	t.Parallel()
+
+	fake := fakeexec.New()
+	fake.Expect("test", "-e", path)
+	fake.Expect("cat", path).Return(content, 0)
+
+	f, err := confd.New(fake, service, "/etc/nginx/conf.d", "app", ".conf", "server {\n  listen 80;\n}", confd.StatePresent, true)
+	require.NoError(t, err)
+
+	status, err := f.Check(fakerenderer.New())
+	require.NoError(t, err)
+	assert.False(t, status.HasChanges())
+}
+
+// TestApply tests validating and reloading
+func TestApply(t *testing.T) {
+	t.Parallel()
+
+	t.Run("valid", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("test", "-e", path).Return("", 1)
+		fake.Expect("sh", "-c", write, path, "0644")
+		fake.Expect("nginx", "-t")
+		fake.Expect("systemctl", "reload", "nginx")
+
+		f, err := confd.New(fake, service, "/etc/nginx/conf.d", "app", ".conf", content, "", true)
+		require.NoError(t, err)
+
+		_, err = f.Apply()
+		require.NoError(t, err)
+		fake.AssertExpectations(t)
+	})
+
+	t.Run("rejected", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("test", "-e", path)
+		fake.Expect("cat", path).Return("server {}\n", 0)
+		fake.Expect("sh", "-c", write, path, "0644").Times(2)
+		fake.Expect("nginx", "-t").Return("", 1).Stderr("nginx: [emerg] unexpected end of file\n")
+
+		f, err := confd.New(fake, service, "/etc/nginx/conf.d", "app", ".conf", "server {", "", true)
+		require.NoError(t, err)
+
+		status, err := f.Apply()
+		assert.EqualError(t, err, "nginx rejected /etc/nginx/conf.d/app.conf, restored the previous configuration")
+		assert.Equal(t, resource.StatusFatal, status.StatusCode())
+		assert.Equal(t, []string{"nginx: [emerg] unexpected end of file"}, status.Messages())
+
+		calls := fake.Calls()
+		assert.Equal(t, "server {}\n", calls[len(calls)-1].Stdin)
+		for _, call := range calls {
+			assert.NotEqual(t, "systemctl", call.Name)
+		}
+	})
+}
+
+// TestNew tests the invalid cases of New
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	_, err := confd.New(fakeexec.New(), service, "/etc/nginx/conf.d", "../app", ".conf", content, "", true)
+	assert.EqualError(t, err, `"../app" is not a valid fragment name`)
+
+	_, err = confd.New(fakeexec.New(), service, "/etc/nginx/conf.d", "app", ".conf", "", "", true)
+	assert.EqualError(t, err, `"content" is required unless the state is absent`)
+}
@@ -20,6 +20,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/asteris-llc/converge/resource"
 	"github.com/asteris-llc/converge/resource/docker"
@@ -127,6 +128,16 @@ func (c *Container) Apply() (resource.TaskStatus, error) {
 	return c, nil
 }
 
+// Logs returns the logs the container has written since the given time, for
+// "log" checks in ready_when
+func (c *Container) Logs(since time.Time) (string, error) {
+	reader, ok := c.client.(docker.LogReader)
+	if !ok {
+		return "", fmt.Errorf("cannot read the logs of container %s", c.Name)
+	}
+	return reader.ContainerLogs(c.Name, since)
+}
+
 // SetClient injects a docker api client
 func (c *Container) SetClient(client docker.APIClient) {
 	c.client = client
@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/asteris-llc/converge/helpers/exec"
 	"github.com/asteris-llc/converge/helpers/transform"
 	"github.com/asteris-llc/converge/load/registry"
 	"github.com/asteris-llc/converge/resource"
@@ -28,7 +29,8 @@ import (
 // Preparer for docker containers
 //
 // Container is responsible for creating docker containers. It assumes that
-// there is already a Docker daemon running on the system.
+// there is already a Docker daemon running on the system, unless the podman
+// runtime is used.
 type Preparer struct {
 	// name of the container
 	Name string `hcl:"name" required:"true"`
@@ -52,8 +54,9 @@ type Preparer struct {
 	Expose []string `hcl:"expose"`
 
 	// A list of links for the container. Each link entry should be in the form of
-	// container_name:alias
-	Links []string `hcl:"links"`
+	// container_name:alias. Deprecated, since docker has deprecated links in
+	// favor of user-defined networks.
+	Links []string `hcl:"links" deprecated:"docker has deprecated links, connect containers with a user-defined network instead"`
 
 	// publish container ports to the host. Each item should be in the following
 	// format:
@@ -82,6 +85,11 @@ type Preparer struct {
 	// not what is expected. By default, the module will only check to see if the
 	// container exists. Specified as a boolean value
 	Force bool `hcl:"force"`
+
+	// the container runtime to use. Podman is run through its command line and
+	// runs rootless when the node uses `become` with an unprivileged user. It
+	// defaults to docker.
+	Runtime string `hcl:"runtime" valid_values:"docker,podman"`
 }
 
 // Prepare a docker container
@@ -93,7 +101,7 @@ func (p *Preparer) Prepare(render resource.Renderer) (resource.Task, error) {
 		},
 	)
 
-	dockerClient, err := docker.NewDockerClient()
+	client, err := docker.NewClient(p.Runtime, exec.For(render))
 	if err != nil {
 		return nil, err
 	}
@@ -115,7 +123,7 @@ func (p *Preparer) Prepare(render resource.Renderer) (resource.Task, error) {
 		Volumes:         p.Volumes,
 		VolumesFrom:     p.VolumesFrom,
 	}
-	container.SetClient(dockerClient)
+	container.SetClient(client)
 	return container, validateContainer(container)
 }
 
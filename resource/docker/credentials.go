@@ -0,0 +1,156 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package docker
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/pkg/errors"
+)
+
+// DefaultConfigDir is where the docker CLI keeps config.json for root
+const DefaultConfigDir = "/root/.docker"
+
+// DockerHub is the server address the docker CLI stores Docker Hub
+// credentials under
+const DockerHub = "https://index.docker.io/v1/"
+
+// Credentials are the username and password or token for a registry
+type Credentials struct {
+	Username string
+	Secret   string
+}
+
+// ConfigFile is the part of the docker CLI config.json that says where
+// registry credentials are kept
+type ConfigFile struct {
+	Auths map[string]struct {
+		Auth string `json:"auth"`
+	} `json:"auths"`
+
+	// CredsStore is the credential helper used for every registry without its
+	// own entry in CredHelpers
+	CredsStore  string            `json:"credsStore"`
+	CredHelpers map[string]string `json:"credHelpers"`
+}
+
+// ReadConfigFile reads config.json from dir. A missing file is treated as
+// empty.
+func ReadConfigFile(e exec.Executor, dir string) (*ConfigFile, error) {
+	file := path.Join(dir, "config.json")
+	content, _, err := exec.ReadFile(e, file)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot read %s", file)
+	}
+
+	config := new(ConfigFile)
+	if strings.TrimSpace(content) == "" {
+		return config, nil
+	}
+
+	if err := json.Unmarshal([]byte(content), config); err != nil {
+		return nil, errors.Wrapf(err, "cannot parse %s", file)
+	}
+	return config, nil
+}
+
+// Helper returns the credential helper that holds the credentials for the
+// registry, or an empty string if they are kept in config.json
+func (c *ConfigFile) Helper(registry string) string {
+	if helper, ok := c.CredHelpers[registry]; ok {
+		return helper
+	}
+	return c.CredsStore
+}
+
+// LookupCredentials returns the credentials stored for the registry in the
+// docker CLI configuration in dir, or nil if there are none
+func LookupCredentials(e exec.Executor, dir, registry string) (*Credentials, error) {
+	config, err := ReadConfigFile(e, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	if helper := config.Helper(registry); helper != "" {
+		return helperCredentials(e, helper, registry)
+	}
+
+	entry, ok := config.Auths[registry]
+	if !ok || entry.Auth == "" {
+		return nil, nil
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(entry.Auth)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot decode credentials for %s", registry)
+	}
+
+	parts := strings.SplitN(string(decoded), ":", 2)
+	if len(parts) != 2 {
+		return nil, errors.Errorf("credentials for %s are not in the form username:password", registry)
+	}
+	return &Credentials{Username: parts[0], Secret: parts[1]}, nil
+}
+
+// helperCredentials asks a credential helper for the credentials of a
+// registry. Helpers exit with a non-zero status when they have none.
+func helperCredentials(e exec.Executor, helper, registry string) (*Credentials, error) {
+	cmd := &exec.Command{
+		Name:  "docker-credential-" + helper,
+		Args:  []string{"get"},
+		Stdin: registry,
+	}
+
+	result, err := e.Run(cmd)
+	if err != nil {
+		return nil, err
+	}
+	if !result.Success() {
+		return nil, nil
+	}
+
+	var out Credentials
+	if err := json.Unmarshal([]byte(result.Stdout), &out); err != nil {
+		return nil, errors.Wrapf(err, "cannot parse the output of %s", cmd.Name)
+	}
+	return &out, nil
+}
+
+// RegistryOf returns the registry an image is pulled from, as the docker CLI
+// names it in config.json
+func RegistryOf(image string) string {
+	parts := strings.SplitN(image, "/", 2)
+	if len(parts) == 2 && (strings.ContainsAny(parts[0], ".:") || parts[0] == "localhost") {
+		return parts[0]
+	}
+	return DockerHub
+}
+
+// configDir returns the directory the docker CLI of the current user keeps
+// config.json in
+func configDir() string {
+	if dir := os.Getenv("DOCKER_CONFIG"); dir != "" {
+		return dir
+	}
+	if home := os.Getenv("HOME"); home != "" {
+		return path.Join(home, ".docker")
+	}
+	return DefaultConfigDir
+}
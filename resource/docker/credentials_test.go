@@ -0,0 +1,78 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package docker_test
+
+import (
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/fakeexec"
+	"github.com/asteris-llc/converge/resource/docker"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestLookupCredentials tests finding stored registry credentials
+func TestLookupCredentials(t *testing.T) {
+	t.Parallel()
+
+	config := `{
+  "auths": {"registry.example.com": {"auth": "ZGVwbG95Omh1bnRlcjI="}},
+  "credHelpers": {"123.dkr.ecr.us-east-1.amazonaws.com": "ecr-login"}
+}`
+
+	fake := fakeexec.New()
+	fake.Expect("test", "-e", "/root/.docker/config.json")
+	fake.Expect("cat", "/root/.docker/config.json").Return(config, 0)
+
+	t.Run("config", func(t *testing.T) {
+		creds, err := docker.LookupCredentials(fake, "/root/.docker", "registry.example.com")
+		require.NoError(t, err)
+		assert.Equal(t, &docker.Credentials{Username: "deploy", Secret: "hunter2"}, creds)
+	})
+
+	t.Run("none", func(t *testing.T) {
+		creds, err := docker.LookupCredentials(fake, "/root/.docker", "other.example.com")
+		require.NoError(t, err)
+		assert.Nil(t, creds)
+	})
+
+	t.Run("helper", func(t *testing.T) {
+		fake.Expect("docker-credential-ecr-login", "get").Return(`{"ServerURL":"123.dkr.ecr.us-east-1.amazonaws.com","Username":"AWS","Secret":"token"}`, 0)
+
+		creds, err := docker.LookupCredentials(fake, "/root/.docker", "123.dkr.ecr.us-east-1.amazonaws.com")
+		require.NoError(t, err)
+		assert.Equal(t, &docker.Credentials{Username: "AWS", Secret: "token"}, creds)
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("test", "-e", "/home/app/.docker/config.json").Return("", 1)
+
+		creds, err := docker.LookupCredentials(fake, "/home/app/.docker", docker.DockerHub)
+		require.NoError(t, err)
+		assert.Nil(t, creds)
+	})
+}
+
+// TestRegistryOf tests finding the registry of an image
+func TestRegistryOf(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, docker.DockerHub, docker.RegistryOf("nginx"))
+	assert.Equal(t, docker.DockerHub, docker.RegistryOf("library/nginx"))
+	assert.Equal(t, "registry.example.com", docker.RegistryOf("registry.example.com/team/app"))
+	assert.Equal(t, "localhost:5000", docker.RegistryOf("localhost:5000/app"))
+	assert.Equal(t, "localhost", docker.RegistryOf("localhost/app"))
+}
@@ -0,0 +1,210 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package daemon
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultPath is the location of daemon.json
+	DefaultPath = "/etc/docker/daemon.json"
+
+	// Service is the unit restarted when the file changes
+	Service = "docker"
+
+	// Mode is the mode the file is written with
+	Mode = 0644
+)
+
+// Daemon manages keys in daemon.json
+type Daemon struct {
+	resource.Status
+
+	// Settings are merged into the file as a JSON merge patch
+	Settings map[string]interface{}
+	Path     string
+	Restart  bool
+
+	exec exec.Executor
+}
+
+// Check whether every declared key has its value
+func (d *Daemon) Check(resource.Renderer) (resource.TaskStatus, error) {
+	d.Status = resource.Status{}
+
+	current, err := d.read()
+	if err != nil {
+		d.RaiseLevel(resource.StatusFatal)
+		return d, err
+	}
+
+	for _, change := range Diff(current, d.Settings) {
+		d.RaiseLevel(resource.StatusWillChange)
+		d.AddDifference(change.Key, change.Original, change.Current, "")
+	}
+
+	return d, nil
+}
+
+// Apply merges the declared keys into the file and restarts Docker
+func (d *Daemon) Apply() (resource.TaskStatus, error) {
+	d.Status = resource.Status{}
+
+	current, err := d.read()
+	if err != nil {
+		d.RaiseLevel(resource.StatusFatal)
+		return d, err
+	}
+
+	if len(Diff(current, d.Settings)) == 0 {
+		return d, nil
+	}
+
+	content, err := json.MarshalIndent(Merge(current, d.Settings), "", "  ")
+	if err != nil {
+		d.RaiseLevel(resource.StatusFatal)
+		return d, err
+	}
+
+	if err := exec.Run(d.exec, "mkdir", "-p", path.Dir(d.Path)); err != nil {
+		d.RaiseLevel(resource.StatusFatal)
+		return d, errors.Wrapf(err, "cannot create %s", path.Dir(d.Path))
+	}
+
+	if err := exec.WriteFile(d.exec, d.Path, string(content)+"\n", Mode); err != nil {
+		d.RaiseLevel(resource.StatusFatal)
+		return d, errors.Wrapf(err, "cannot write %s", d.Path)
+	}
+	d.AddMessage(fmt.Sprintf("updated %s", d.Path))
+
+	// most daemon.json keys are only read when the daemon starts
+	if d.Restart {
+		if err := exec.Run(d.exec, "systemctl", "restart", Service); err != nil {
+			d.RaiseLevel(resource.StatusFatal)
+			return d, errors.Wrapf(err, "cannot restart %s", Service)
+		}
+		d.AddMessage(fmt.Sprintf("restarted %s", Service))
+	}
+
+	return d, nil
+}
+
+// read returns the parsed content of the file. A missing or empty file is
+// treated as an empty object, since Docker uses its defaults without one.
+func (d *Daemon) read() (map[string]interface{}, error) {
+	content, _, err := exec.ReadFile(d.exec, d.Path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot read %s", d.Path)
+	}
+
+	current := map[string]interface{}{}
+	if strings.TrimSpace(content) == "" {
+		return current, nil
+	}
+
+	if err := json.Unmarshal([]byte(content), &current); err != nil {
+		return nil, errors.Wrapf(err, "cannot parse %s", d.Path)
+	}
+	return current, nil
+}
+
+// Change is a difference between the file and the declared settings
+type Change struct {
+	// Key is the path to the value, with object keys joined by "."
+	Key string
+
+	// Original and Current are the JSON encoded values, or "<unset>"
+	Original string
+	Current  string
+}
+
+// Diff returns the values in patch that differ from target, sorted by key
+func Diff(target, patch map[string]interface{}) []Change {
+	var changes []Change
+	diff("", target, patch, &changes)
+	sort.Sort(byKey(changes))
+	return changes
+}
+
+func diff(prefix string, target, patch map[string]interface{}, changes *[]Change) {
+	for key, value := range patch {
+		current, exists := target[key]
+
+		if nested, ok := value.(map[string]interface{}); ok {
+			currentObject, _ := current.(map[string]interface{})
+			if exists && currentObject == nil {
+				*changes = append(*changes, Change{prefix + key, encode(current, true), encode(value, true)})
+				continue
+			}
+			diff(prefix+key+".", currentObject, nested, changes)
+			continue
+		}
+
+		if !exists || !reflect.DeepEqual(current, value) {
+			*changes = append(*changes, Change{prefix + key, encode(current, exists), encode(value, true)})
+		}
+	}
+}
+
+// Merge applies patch to target as a JSON merge patch (RFC 7386): objects are
+// merged recursively and other values replace what was there. target is not
+// modified.
+func Merge(target, patch map[string]interface{}) map[string]interface{} {
+	out := map[string]interface{}{}
+	for key, value := range target {
+		out[key] = value
+	}
+
+	for key, value := range patch {
+		nested, ok := value.(map[string]interface{})
+		if !ok {
+			out[key] = value
+			continue
+		}
+
+		current, _ := out[key].(map[string]interface{})
+		out[key] = Merge(current, nested)
+	}
+
+	return out
+}
+
+func encode(value interface{}, exists bool) string {
+	if !exists {
+		return "<unset>"
+	}
+
+	out, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(out)
+}
+
+type byKey []Change
+
+func (c byKey) Len() int           { return len(c) }
+func (c byKey) Less(i, j int) bool { return c[i].Key < c[j].Key }
+func (c byKey) Swap(i, j int)      { c[i], c[j] = c[j], c[i] }
@@ -0,0 +1,222 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package daemon_test
+
+import (
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/helpers/fakeexec"
+	"github.com/asteris-llc/converge/helpers/fakerenderer"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/docker/daemon"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	path = "/etc/docker/daemon.json"
+	conf = `{
+  "debug": true,
+  "log-driver": "json-file",
+  "log-opts": {"max-file": "3"}
+}
+`
+)
+
+// TestDaemonInterface tests that Daemon is properly implemented
+func TestDaemonInterface(t *testing.T) {
+	t.Parallel()
+
+	assert.Implements(t, (*resource.Task)(nil), new(daemon.Daemon))
+	assert.Implements(t, (*resource.Resource)(nil), new(daemon.Preparer))
+}
+
+// TestMerge tests merging settings over unmanaged keys
+func TestMerge(t *testing.T) {
+	t.Parallel()
+
+	target := map[string]interface{}{
+		"debug":    true,
+		"log-opts": map[string]interface{}{"max-file": "3"},
+		"mirrors":  []interface{}{"a"},
+	}
+	patch := map[string]interface{}{
+		"log-opts": map[string]interface{}{"max-size": "10m"},
+		"mirrors":  []interface{}{"b"},
+		"proxies":  map[string]interface{}{"http-proxy": "http://proxy:3128"},
+	}
+
+	assert.Equal(t, map[string]interface{}{
+		"debug":    true,
+		"log-opts": map[string]interface{}{"max-file": "3", "max-size": "10m"},
+		"mirrors":  []interface{}{"b"},
+		"proxies":  map[string]interface{}{"http-proxy": "http://proxy:3128"},
+	}, daemon.Merge(target, patch))
+	assert.Equal(t, []interface{}{"a"}, target["mirrors"], "the target is not modified")
+}
+
+// TestDiff tests finding the declared values that differ
+func TestDiff(t *testing.T) {
+	t.Parallel()
+
+	target := map[string]interface{}{
+		"log-driver": "json-file",
+		"log-opts":   map[string]interface{}{"max-file": "3"},
+		"proxies":    "bad",
+	}
+	patch := map[string]interface{}{
+		"log-driver": "json-file",
+		"log-opts":   map[string]interface{}{"max-file": "5", "max-size": "10m"},
+		"proxies":    map[string]interface{}{"http-proxy": "http://proxy:3128"},
+	}
+
+	assert.Equal(t, []daemon.Change{
+		{Key: "log-opts.max-file", Original: `"3"`, Current: `"5"`},
+		{Key: "log-opts.max-size", Original: "<unset>", Current: `"10m"`},
+		{Key: "proxies", Original: `"bad"`, Current: `{"http-proxy":"http://proxy:3128"}`},
+	}, daemon.Diff(target, patch))
+}
+
+// TestPrepare tests building settings from the preparer
+func TestPrepare(t *testing.T) {
+	t.Parallel()
+
+	t.Run("settings", func(t *testing.T) {
+		p := &daemon.Preparer{
+			StorageDriver:   "overlay2",
+			RegistryMirrors: []string{"https://mirror.example.com"},
+			HTTPProxy:       "http://proxy:3128",
+			NoProxy:         "localhost,.internal",
+		}
+		task, err := p.Prepare(fakerenderer.New())
+		require.NoError(t, err)
+
+		d := task.(*daemon.Daemon)
+		assert.Equal(t, path, d.Path)
+		assert.True(t, d.Restart)
+		assert.Equal(t, map[string]interface{}{
+			"storage-driver":   "overlay2",
+			"registry-mirrors": []interface{}{"https://mirror.example.com"},
+			"proxies":          map[string]interface{}{"http-proxy": "http://proxy:3128", "no-proxy": "localhost,.internal"},
+		}, d.Settings)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		_, err := new(daemon.Preparer).Prepare(fakerenderer.New())
+		assert.EqualError(t, err, "docker.daemon requires at least one setting")
+	})
+
+	t.Run("mirror", func(t *testing.T) {
+		_, err := (&daemon.Preparer{RegistryMirrors: []string{"mirror.example.com"}}).Prepare(fakerenderer.New())
+		assert.Error(t, err)
+	})
+}
+
+// TestCheck tests comparing the file to the settings
+func TestCheck(t *testing.T) {
+	t.Parallel()
+
+	t.Run("changes", func(t *testing.T) {
+		status, err := prepare(t, existing(conf), nil).Check(fakerenderer.New())
+		require.NoError(t, err)
+		assert.True(t, status.HasChanges())
+		assert.Equal(t, `"json-file"`, status.Diffs()["log-driver"].Original())
+		assert.Equal(t, "<unset>", status.Diffs()["log-opts.max-size"].Original())
+		assert.NotContains(t, status.Diffs(), "log-opts.max-file")
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("test", "-e", path).Return("", 1)
+
+		status, err := prepare(t, fake, nil).Check(fakerenderer.New())
+		require.NoError(t, err)
+		assert.Equal(t, "<unset>", status.Diffs()["log-driver"].Original())
+	})
+
+	t.Run("converged", func(t *testing.T) {
+		status, err := prepare(t, existing(`{"log-driver": "journald", "log-opts": {"max-size": "10m", "tag": "x"}}`), nil).Check(fakerenderer.New())
+		require.NoError(t, err)
+		assert.False(t, status.HasChanges())
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := prepare(t, existing("{"), nil).Check(fakerenderer.New())
+		assert.Error(t, err)
+	})
+}
+
+// TestApply tests that Apply merges the file and restarts Docker
+func TestApply(t *testing.T) {
+	t.Parallel()
+
+	t.Run("restart", func(t *testing.T) {
+		fake := existing(conf)
+		fake.Expect("mkdir", "-p", "/etc/docker")
+		fake.Expect("sh", "-c", exec.WriteFileScript, path, "0644")
+		fake.Expect("systemctl", "restart", "docker")
+
+		status, err := prepare(t, fake, nil).Apply()
+		require.NoError(t, err)
+		fake.AssertExpectations(t)
+		assert.Contains(t, status.Messages(), "restarted docker")
+
+		var written string
+		for _, call := range fake.Calls() {
+			if call.Name == "sh" {
+				written = call.Stdin
+			}
+		}
+		assert.Equal(t, `{
+  "debug": true,
+  "log-driver": "journald",
+  "log-opts": {
+    "max-file": "3",
+    "max-size": "10m"
+  }
+}
+`, written)
+	})
+
+	t.Run("no restart", func(t *testing.T) {
+		restart := false
+		fake := existing(conf)
+		fake.Expect("mkdir", "-p", "/etc/docker")
+		fake.Expect("sh", "-c", exec.WriteFileScript, path, "0644")
+
+		status, err := prepare(t, fake, &restart).Apply()
+		require.NoError(t, err)
+		assert.NotContains(t, status.Messages(), "restarted docker")
+	})
+}
+
+func prepare(t *testing.T, fake *fakeexec.Executor, restart *bool) *daemon.Daemon {
+	p := &daemon.Preparer{
+		LogDriver: "journald",
+		LogOpts:   map[string]string{"max-size": "10m"},
+		Restart:   restart,
+	}
+	task, err := p.Prepare(&resource.ExecRenderer{Renderer: fakerenderer.New(), Exec: fake})
+	require.NoError(t, err)
+	return task.(*daemon.Daemon)
+}
+
+func existing(content string) *fakeexec.Executor {
+	fake := fakeexec.New()
+	fake.Expect("test", "-e", path)
+	fake.Expect("cat", path).Return(content, 0)
+	return fake
+}
@@ -0,0 +1,129 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package daemon
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/load/registry"
+	"github.com/asteris-llc/converge/resource"
+)
+
+// Preparer for Daemon
+//
+// Daemon sets keys in the Docker daemon configuration file, daemon.json, and
+// restarts Docker when they change. The declared keys are merged into the
+// file as a JSON merge patch: objects such as log-opts are merged key by key,
+// and keys that are not declared are left alone.
+type Preparer struct {
+	// LogDriver is the default logging driver for containers, such as
+	// json-file or journald
+	LogDriver string `hcl:"log_driver"`
+
+	// LogOpts are options for the logging driver, such as max-size
+	LogOpts map[string]string `hcl:"log_opts"`
+
+	// StorageDriver is the storage driver, such as overlay2
+	StorageDriver string `hcl:"storage_driver"`
+
+	// RegistryMirrors are the URLs of registry mirrors, tried in order
+	RegistryMirrors []string `hcl:"registry_mirrors"`
+
+	// HTTPProxy is the proxy used for HTTP requests made by the daemon
+	HTTPProxy string `hcl:"http_proxy"`
+
+	// HTTPSProxy is the proxy used for HTTPS requests made by the daemon
+	HTTPSProxy string `hcl:"https_proxy"`
+
+	// NoProxy lists the hosts the daemon connects to without a proxy
+	NoProxy string `hcl:"no_proxy"`
+
+	// Path is the file to manage. It defaults to /etc/docker/daemon.json.
+	Path string `hcl:"path"`
+
+	// Restart controls whether Docker is restarted after the file changes. It
+	// defaults to true.
+	Restart *bool `hcl:"restart"`
+}
+
+// Prepare a new task
+func (p *Preparer) Prepare(render resource.Renderer) (resource.Task, error) {
+	settings := map[string]interface{}{}
+
+	if p.LogDriver != "" {
+		settings["log-driver"] = p.LogDriver
+	}
+
+	if len(p.LogOpts) > 0 {
+		opts := map[string]interface{}{}
+		for key, value := range p.LogOpts {
+			opts[key] = value
+		}
+		settings["log-opts"] = opts
+	}
+
+	if p.StorageDriver != "" {
+		settings["storage-driver"] = p.StorageDriver
+	}
+
+	if len(p.RegistryMirrors) > 0 {
+		var mirrors []interface{}
+		for _, mirror := range p.RegistryMirrors {
+			if u, err := url.Parse(mirror); err != nil || u.Scheme == "" || u.Host == "" {
+				return nil, fmt.Errorf("docker.daemon: %q is not a valid registry mirror URL", mirror)
+			}
+			mirrors = append(mirrors, mirror)
+		}
+		settings["registry-mirrors"] = mirrors
+	}
+
+	proxies := map[string]interface{}{}
+	for key, value := range map[string]string{"http-proxy": p.HTTPProxy, "https-proxy": p.HTTPSProxy, "no-proxy": p.NoProxy} {
+		if value != "" {
+			proxies[key] = strings.TrimSpace(value)
+		}
+	}
+	if len(proxies) > 0 {
+		settings["proxies"] = proxies
+	}
+
+	if len(settings) == 0 {
+		return nil, fmt.Errorf("docker.daemon requires at least one setting")
+	}
+
+	if p.Path == "" {
+		p.Path = DefaultPath
+	}
+
+	restart := true
+	if p.Restart != nil {
+		restart = *p.Restart
+	}
+
+	return &Daemon{
+		Settings: settings,
+		Path:     p.Path,
+		Restart:  restart,
+		exec:     exec.For(render),
+	}, nil
+}
+
+func init() {
+	registry.Register("docker.daemon", (*Preparer)(nil), (*Daemon)(nil))
+	registry.RegisterPlatforms("docker.daemon", "linux")
+}
@@ -15,10 +15,13 @@
 package docker
 
 import (
+	"bytes"
 	"strings"
 	"time"
 
 	log "github.com/Sirupsen/logrus"
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/helpers/ratelimit"
 	dc "github.com/fsouza/go-dockerclient"
 	"github.com/pkg/errors"
 )
@@ -32,12 +35,20 @@ type APIClient interface {
 	StartContainer(string, string) error
 }
 
+// LogReader is implemented by clients that can read the logs of a container
+type LogReader interface {
+	ContainerLogs(name string, since time.Time) (string, error)
+}
+
 // Client provides api access to Docker
 type Client struct {
 	*dc.Client
 	PullInactivityTimeout time.Duration
 }
 
+// RateLimitProvider names the API calls to the docker daemon in rate limits
+const RateLimitProvider = "docker"
+
 // NewDockerClient returns a docker client with the default configuration
 func NewDockerClient() (*Client, error) {
 	c, err := dc.NewClientFromEnv()
@@ -47,9 +58,16 @@ func NewDockerClient() (*Client, error) {
 	return &Client{Client: c}, nil
 }
 
+// wait blocks until another call may be made to the daemon, so that resources
+// sharing it don't overwhelm it
+func (c *Client) wait() {
+	ratelimit.Wait(RateLimitProvider, c.Client.Endpoint())
+}
+
 // FindImage finds a local docker image with the specified repo tag
 func (c *Client) FindImage(repoTag string) (*dc.Image, error) {
 	// TODO: can I just call inspect with the repoTag?
+	c.wait()
 	images, err := c.Client.ListImages(dc.ListImagesOptions{All: true})
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to find image")
@@ -82,6 +100,7 @@ func (c *Client) FindImage(repoTag string) (*dc.Image, error) {
 
 	if imageID != "" {
 		log.WithField("module", "docker").WithField("tag", repoTag).Debug("found image")
+		c.wait()
 		image, err := c.Client.InspectImage(imageID)
 		if err != nil {
 			return nil, errors.Wrapf(err, "failed to inspect image %s (%s)", repoTag, imageID)
@@ -107,7 +126,8 @@ func (c *Client) PullImage(name, tag string) error {
 		InactivityTimeout: c.PullInactivityTimeout,
 	}
 
-	err := c.Client.PullImage(opts, dc.AuthConfiguration{})
+	c.wait()
+	err := c.Client.PullImage(opts, pullAuth(name))
 	if err != nil {
 		return errors.Wrap(err, "failed to pull image")
 	}
@@ -120,9 +140,30 @@ func (c *Client) PullImage(name, tag string) error {
 	return nil
 }
 
+// pullAuth returns the credentials the docker CLI has stored for the registry
+// of an image, so that images can be pulled from registries logged into with
+// docker.registry_auth. Images are pulled anonymously when there are none.
+func pullAuth(name string) dc.AuthConfiguration {
+	registry := RegistryOf(name)
+	creds, err := LookupCredentials(exec.New(), configDir(), registry)
+	if err != nil {
+		log.WithField("module", "docker").WithField("registry", registry).WithError(err).Debug("could not look up credentials")
+	}
+	if creds == nil {
+		return dc.AuthConfiguration{}
+	}
+
+	return dc.AuthConfiguration{
+		Username:      creds.Username,
+		Password:      creds.Secret,
+		ServerAddress: registry,
+	}
+}
+
 // FindContainer returns a container matching the specified name
 func (c *Client) FindContainer(name string) (*dc.Container, error) {
 	opts := dc.ListContainersOptions{All: true}
+	c.wait()
 	containers, err := c.Client.ListContainers(opts)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to list containers")
@@ -151,6 +192,7 @@ func (c *Client) FindContainer(name string) (*dc.Container, error) {
 
 	if containerID != "" {
 		log.WithField("module", "docker").WithField("name", name).Debug("found container")
+		c.wait()
 		container, err := c.Client.InspectContainer(containerID)
 		if err != nil {
 			return nil, errors.Wrapf(err, "failed to inspect container %s (%s)", name, containerID)
@@ -179,6 +221,7 @@ func (c *Client) CreateContainer(opts dc.CreateContainerOptions) (*dc.Container,
 		// stop the container if running
 		if container.State.Running {
 			log.WithField("module", "docker").WithFields(log.Fields{"name": name, "id": container.ID}).Debug("stopping container")
+			c.wait()
 			err = c.Client.StopContainer(container.ID, 60)
 			if err != nil {
 				return nil, errors.Wrapf(err, "failed to stop container %s (%s)", name, container.ID)
@@ -187,6 +230,7 @@ func (c *Client) CreateContainer(opts dc.CreateContainerOptions) (*dc.Container,
 
 		// remove the container
 		log.WithField("module", "docker").WithFields(log.Fields{"name": name, "id": container.ID}).Debug("removing container")
+		c.wait()
 		err = c.Client.RemoveContainer(dc.RemoveContainerOptions{ID: container.ID})
 		if err != nil {
 			return nil, errors.Wrapf(err, "failed to remove container %s (%s)", name, container.ID)
@@ -195,6 +239,7 @@ func (c *Client) CreateContainer(opts dc.CreateContainerOptions) (*dc.Container,
 
 	// create the container
 	log.WithField("module", "docker").WithField("name", name).Debug("creating container")
+	c.wait()
 	container, err = c.Client.CreateContainer(opts)
 
 	if err != nil {
@@ -207,9 +252,29 @@ func (c *Client) CreateContainer(opts dc.CreateContainerOptions) (*dc.Container,
 // StartContainer starts the container with the specified ID
 func (c *Client) StartContainer(name, containerID string) error {
 	log.WithField("module", "docker").WithFields(log.Fields{"name": name, "id": containerID}).Debug("starting container")
+	c.wait()
 	err := c.Client.StartContainer(containerID, nil)
 	if err != nil {
 		err = errors.Wrapf(err, "failed to start container %s (%s)", name, containerID)
 	}
 	return err
 }
+
+// ContainerLogs returns the stdout and stderr a container has written since
+// the given time
+func (c *Client) ContainerLogs(name string, since time.Time) (string, error) {
+	var out bytes.Buffer
+	c.wait()
+	err := c.Client.Logs(dc.LogsOptions{
+		Container:    name,
+		OutputStream: &out,
+		ErrorStream:  &out,
+		Stdout:       true,
+		Stderr:       true,
+		Since:        since.Unix(),
+	})
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to read logs of container %s", name)
+	}
+	return out.String(), nil
+}
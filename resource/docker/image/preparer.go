@@ -17,6 +17,7 @@ package image
 import (
 	"time"
 
+	"github.com/asteris-llc/converge/helpers/exec"
 	"github.com/asteris-llc/converge/load/registry"
 	"github.com/asteris-llc/converge/resource"
 	"github.com/asteris-llc/converge/resource/docker"
@@ -25,7 +26,9 @@ import (
 // Preparer for docker images
 //
 // Image is responsible for pulling Docker images. It assumes that there is
-// already a Docker daemon running on the system.
+// already a Docker daemon running on the system. Credentials the docker CLI
+// has stored for the registry of the image, such as with docker.registry_auth,
+// are used for the pull.
 type Preparer struct {
 	// name of the image to pull
 	Name string `hcl:"name"`
@@ -39,6 +42,12 @@ type Preparer struct {
 	// optional fraction and a unit suffix, such as "300ms", "-1.5h" or "2h45m".
 	// Valid time units are "ns", "us" (or "µs"), "ms", "s", "m", "h".
 	InactivityTimeout string `hcl:"inactivity_timeout" doc_type:"duration_string"`
+
+	// the container runtime to pull the image into. Podman is run through its
+	// command line and runs rootless when the node uses `become` with an
+	// unprivileged user. The inactivity timeout only applies to docker. It
+	// defaults to docker.
+	Runtime string `hcl:"runtime" valid_values:"docker,podman"`
 }
 
 // Prepare a new docker image
@@ -48,7 +57,7 @@ func (p *Preparer) Prepare(render resource.Renderer) (resource.Task, error) {
 		return nil, err
 	}
 
-	dockerClient, err := docker.NewDockerClient()
+	client, err := docker.NewClient(p.Runtime, exec.For(render))
 	if err != nil {
 		return nil, err
 	}
@@ -58,14 +67,16 @@ func (p *Preparer) Prepare(render resource.Renderer) (resource.Task, error) {
 		if err != nil {
 			return nil, err
 		}
-		dockerClient.PullInactivityTimeout = duration
+		if dockerClient, ok := client.(*docker.Client); ok {
+			dockerClient.PullInactivityTimeout = duration
+		}
 	}
 
 	image := &Image{
 		Name: p.Name,
 		Tag:  p.Tag,
 	}
-	image.SetClient(dockerClient)
+	image.SetClient(client)
 	return image, nil
 }
 
@@ -0,0 +1,356 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package docker
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/asteris-llc/converge/helpers/exec"
+	dc "github.com/fsouza/go-dockerclient"
+	"github.com/pkg/errors"
+)
+
+// PodmanClient is an APIClient that runs the podman command line, for hosts
+// without a Docker daemon
+type PodmanClient struct {
+	exec exec.Executor
+}
+
+// NewPodmanClient returns a PodmanClient running podman with the executor
+func NewPodmanClient(e exec.Executor) *PodmanClient {
+	return &PodmanClient{exec: e}
+}
+
+// FindImage finds a local image with the specified repo tag or ID
+func (p *PodmanClient) FindImage(repoTag string) (*dc.Image, error) {
+	var images []struct {
+		ID       string   `json:"Id"`
+		RepoTags []string `json:"RepoTags"`
+		Config   struct {
+			Cmd          stringList          `json:"Cmd"`
+			Entrypoint   stringList          `json:"Entrypoint"`
+			WorkingDir   string              `json:"WorkingDir"`
+			Env          []string            `json:"Env"`
+			ExposedPorts map[string]struct{} `json:"ExposedPorts"`
+			Volumes      map[string]struct{} `json:"Volumes"`
+		} `json:"Config"`
+	}
+
+	found, err := p.inspect("image", repoTag, &images)
+	if err != nil || !found {
+		return nil, err
+	}
+
+	image := images[0]
+	return &dc.Image{
+		ID:       image.ID,
+		RepoTags: image.RepoTags,
+		Config: &dc.Config{
+			Cmd:          image.Config.Cmd,
+			Entrypoint:   image.Config.Entrypoint,
+			WorkingDir:   image.Config.WorkingDir,
+			Env:          image.Config.Env,
+			ExposedPorts: toPorts(image.Config.ExposedPorts),
+			Volumes:      image.Config.Volumes,
+		},
+	}, nil
+}
+
+// PullImage pulls an image with the specified name and tag
+func (p *PodmanClient) PullImage(name, tag string) error {
+	log.WithFields(log.Fields{"module": "podman", "name": name, "tag": tag}).Debug("pulling")
+
+	if err := exec.Run(p.exec, "podman", "pull", name+":"+tag); err != nil {
+		return errors.Wrap(err, "failed to pull image")
+	}
+	return nil
+}
+
+// FindContainer returns a container matching the specified name or ID
+func (p *PodmanClient) FindContainer(name string) (*dc.Container, error) {
+	var containers []struct {
+		ID     string `json:"Id"`
+		Name   string `json:"Name"`
+		Image  string `json:"Image"`
+		Config struct {
+			Cmd          stringList          `json:"Cmd"`
+			Entrypoint   stringList          `json:"Entrypoint"`
+			WorkingDir   string              `json:"WorkingDir"`
+			Env          []string            `json:"Env"`
+			ExposedPorts map[string]struct{} `json:"ExposedPorts"`
+			Volumes      map[string]struct{} `json:"Volumes"`
+		} `json:"Config"`
+		State struct {
+			Status  string `json:"Status"`
+			Running bool   `json:"Running"`
+		} `json:"State"`
+		HostConfig struct {
+			Binds           []string                    `json:"Binds"`
+			Links           []string                    `json:"Links"`
+			DNS             []string                    `json:"Dns"`
+			PortBindings    map[string][]dc.PortBinding `json:"PortBindings"`
+			PublishAllPorts bool                        `json:"PublishAllPorts"`
+			VolumesFrom     []string                    `json:"VolumesFrom"`
+		} `json:"HostConfig"`
+	}
+
+	found, err := p.inspect("container", name, &containers)
+	if err != nil || !found {
+		return nil, err
+	}
+
+	container := containers[0]
+	bindings := map[dc.Port][]dc.PortBinding{}
+	for port, binding := range container.HostConfig.PortBindings {
+		bindings[dc.Port(port)] = binding
+	}
+
+	return &dc.Container{
+		ID:    container.ID,
+		Name:  container.Name,
+		Image: container.Image,
+		Config: &dc.Config{
+			Cmd:          container.Config.Cmd,
+			Entrypoint:   container.Config.Entrypoint,
+			WorkingDir:   container.Config.WorkingDir,
+			Env:          container.Config.Env,
+			ExposedPorts: toPorts(container.Config.ExposedPorts),
+			Volumes:      container.Config.Volumes,
+		},
+		State: dc.State{
+			Status:  container.State.Status,
+			Running: container.State.Running,
+		},
+		HostConfig: &dc.HostConfig{
+			Binds:           container.HostConfig.Binds,
+			Links:           container.HostConfig.Links,
+			DNS:             container.HostConfig.DNS,
+			PortBindings:    bindings,
+			PublishAllPorts: container.HostConfig.PublishAllPorts,
+			VolumesFrom:     container.HostConfig.VolumesFrom,
+		},
+	}, nil
+}
+
+// CreateContainer creates a container with the specified options, replacing
+// an existing container with the same name
+func (p *PodmanClient) CreateContainer(opts dc.CreateContainerOptions) (*dc.Container, error) {
+	args, err := createArgs(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := p.FindContainer(opts.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	if existing != nil {
+		log.WithFields(log.Fields{"module": "podman", "name": opts.Name, "id": existing.ID}).Debug("removing container")
+		if err := exec.Run(p.exec, "podman", "rm", "--force", existing.ID); err != nil {
+			return nil, errors.Wrapf(err, "failed to remove container %s (%s)", opts.Name, existing.ID)
+		}
+	}
+
+	log.WithField("module", "podman").WithField("name", opts.Name).Debug("creating container")
+	out, err := exec.Read(p.exec, "podman", args...)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create container %s", opts.Name)
+	}
+
+	return &dc.Container{ID: strings.TrimSpace(out), Name: opts.Name}, nil
+}
+
+// StartContainer starts the container with the specified ID
+func (p *PodmanClient) StartContainer(name, id string) error {
+	if err := exec.Run(p.exec, "podman", "start", id); err != nil {
+		return errors.Wrapf(err, "failed to start container %s (%s)", name, id)
+	}
+	return nil
+}
+
+// ContainerLogs returns the stdout and stderr a container has written since
+// the given time
+func (p *PodmanClient) ContainerLogs(name string, since time.Time) (string, error) {
+	cmd := exec.NewCommand("podman", "logs", "--since", since.UTC().Format(time.RFC3339), name)
+	result, err := p.exec.Run(cmd)
+	if err == nil && !result.Success() {
+		err = &exec.ExitError{Command: cmd, Result: result}
+	}
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to read logs of container %s", name)
+	}
+
+	// podman passes the container's stderr through to its own
+	return result.Stdout + result.Stderr, nil
+}
+
+// inspect decodes the output of podman inspect for an image or container
+// into out, returning false if it does not exist
+func (p *PodmanClient) inspect(kind, name string, out interface{}) (bool, error) {
+	err := exec.Run(p.exec, "podman", kind, "exists", name)
+	if status, ok := exec.ExitStatus(err); ok && status == 1 {
+		return false, nil
+	} else if err != nil {
+		return false, errors.Wrapf(err, "failed to find %s %s", kind, name)
+	}
+
+	content, err := exec.Read(p.exec, "podman", kind, "inspect", name)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to inspect %s %s", kind, name)
+	}
+
+	if err := json.Unmarshal([]byte(content), out); err != nil {
+		return false, errors.Wrapf(err, "failed to parse %s %s", kind, name)
+	}
+	return true, nil
+}
+
+// createArgs returns the podman create command line for the options
+func createArgs(opts dc.CreateContainerOptions) ([]string, error) {
+	config, host := opts.Config, opts.HostConfig
+	if config == nil {
+		return nil, errors.New("container config must be provided")
+	}
+	if host == nil {
+		host = &dc.HostConfig{}
+	}
+
+	if len(host.Links) > 0 {
+		return nil, errors.New("podman does not support links, put the containers in a pod or on a network instead")
+	}
+
+	args := []string{"create", "--name", opts.Name}
+	if config.WorkingDir != "" {
+		args = append(args, "--workdir", config.WorkingDir)
+	}
+
+	for _, env := range config.Env {
+		args = append(args, "--env", env)
+	}
+
+	for _, port := range sortedPorts(config.ExposedPorts) {
+		args = append(args, "--expose", port)
+	}
+
+	var bound []string
+	for port := range host.PortBindings {
+		bound = append(bound, string(port))
+	}
+	sort.Strings(bound)
+	for _, port := range bound {
+		for _, binding := range host.PortBindings[dc.Port(port)] {
+			args = append(args, "--publish", publishSpec(port, binding))
+		}
+	}
+
+	if host.PublishAllPorts {
+		args = append(args, "--publish-all")
+	}
+
+	for _, dns := range host.DNS {
+		args = append(args, "--dns", dns)
+	}
+
+	var volumes []string
+	for volume := range config.Volumes {
+		volumes = append(volumes, volume)
+	}
+	sort.Strings(volumes)
+	for _, volume := range volumes {
+		args = append(args, "--volume", volume)
+	}
+
+	for _, bind := range host.Binds {
+		args = append(args, "--volume", bind)
+	}
+
+	for _, from := range host.VolumesFrom {
+		args = append(args, "--volumes-from", from)
+	}
+
+	if len(config.Entrypoint) > 0 {
+		entrypoint, err := json.Marshal(config.Entrypoint)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, "--entrypoint", string(entrypoint))
+	}
+
+	args = append(args, config.Image)
+	return append(args, config.Cmd...), nil
+}
+
+// publishSpec formats a port binding as a --publish value
+func publishSpec(port string, binding dc.PortBinding) string {
+	switch {
+	case binding.HostIP != "":
+		return fmt.Sprintf("%s:%s:%s", binding.HostIP, binding.HostPort, port)
+	case binding.HostPort != "":
+		return binding.HostPort + ":" + port
+	}
+	return port
+}
+
+func toPorts(ports map[string]struct{}) map[dc.Port]struct{} {
+	if ports == nil {
+		return nil
+	}
+
+	out := make(map[dc.Port]struct{}, len(ports))
+	for port := range ports {
+		out[dc.Port(port)] = struct{}{}
+	}
+	return out
+}
+
+func sortedPorts(ports map[dc.Port]struct{}) []string {
+	var out []string
+	for port := range ports {
+		out = append(out, string(port))
+	}
+	sort.Strings(out)
+	return out
+}
+
+// stringList decodes either a JSON list of strings or a single string, since
+// podman versions disagree on how entrypoints are reported
+type stringList []string
+
+// UnmarshalJSON decodes a list or a single string
+func (s *stringList) UnmarshalJSON(data []byte) error {
+	var list []string
+	if err := json.Unmarshal(data, &list); err == nil {
+		*s = list
+		return nil
+	}
+
+	var single string
+	if err := json.Unmarshal(data, &single); err != nil {
+		return err
+	}
+
+	if single == "" {
+		*s = nil
+	} else {
+		*s = []string{single}
+	}
+	return nil
+}
@@ -0,0 +1,193 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package docker_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/asteris-llc/converge/helpers/fakeexec"
+	"github.com/asteris-llc/converge/resource/docker"
+	dc "github.com/fsouza/go-dockerclient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const nginxContainer = `[{
+  "Id": "abc123",
+  "Name": "nginx",
+  "Image": "docker.io/library/nginx:latest",
+  "Config": {
+    "Cmd": ["nginx", "-g", "daemon off;"],
+    "Entrypoint": "/docker-entrypoint.sh",
+    "Env": ["PATH=/usr/bin"],
+    "ExposedPorts": {"80/tcp": {}}
+  },
+  "State": {"Status": "running", "Running": true},
+  "HostConfig": {
+    "Binds": ["/srv:/usr/share/nginx/html"],
+    "PortBindings": {"80/tcp": [{"HostIp": "", "HostPort": "8080"}]}
+  }
+}]`
+
+func TestNewClient(t *testing.T) {
+	t.Parallel()
+
+	t.Run("podman", func(t *testing.T) {
+		client, err := docker.NewClient("podman", fakeexec.New())
+		require.NoError(t, err)
+		assert.IsType(t, &docker.PodmanClient{}, client)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := docker.NewClient("rkt", fakeexec.New())
+		assert.EqualError(t, err, `"rkt" is not a valid runtime, expected one of docker, podman`)
+	})
+}
+
+func TestPodmanFindContainer(t *testing.T) {
+	t.Parallel()
+
+	t.Run("missing", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("podman", "container", "exists", "nginx").Return("", 1)
+
+		container, err := docker.NewPodmanClient(fake).FindContainer("nginx")
+		require.NoError(t, err)
+		assert.Nil(t, container)
+	})
+
+	t.Run("exists", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("podman", "container", "exists", "nginx")
+		fake.Expect("podman", "container", "inspect", "nginx").Return(nginxContainer, 0)
+
+		container, err := docker.NewPodmanClient(fake).FindContainer("nginx")
+		require.NoError(t, err)
+		require.NotNil(t, container)
+
+		assert.Equal(t, "abc123", container.ID)
+		assert.Equal(t, "running", container.State.Status)
+		assert.Equal(t, []string{"/docker-entrypoint.sh"}, container.Config.Entrypoint)
+		assert.Equal(t, []string{"nginx", "-g", "daemon off;"}, container.Config.Cmd)
+		assert.Contains(t, container.Config.ExposedPorts, dc.Port("80/tcp"))
+		assert.Equal(t, []dc.PortBinding{{HostPort: "8080"}}, container.HostConfig.PortBindings["80/tcp"])
+		assert.Equal(t, []string{"/srv:/usr/share/nginx/html"}, container.HostConfig.Binds)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("podman", "container", "exists", "nginx").Return("", 125).Stderr("cannot connect")
+
+		_, err := docker.NewPodmanClient(fake).FindContainer("nginx")
+		assert.Error(t, err)
+	})
+}
+
+func TestPodmanFindImage(t *testing.T) {
+	t.Parallel()
+
+	fake := fakeexec.New()
+	fake.Expect("podman", "image", "exists", "nginx:latest")
+	fake.Expect("podman", "image", "inspect", "nginx:latest").Return(`[{"Id": "def456", "RepoTags": ["docker.io/library/nginx:latest"], "Config": {"Entrypoint": null}}]`, 0)
+
+	image, err := docker.NewPodmanClient(fake).FindImage("nginx:latest")
+	require.NoError(t, err)
+	require.NotNil(t, image)
+	assert.Equal(t, "def456", image.ID)
+	assert.Empty(t, image.Config.Entrypoint)
+}
+
+func TestPodmanCreateContainer(t *testing.T) {
+	t.Parallel()
+
+	opts := dc.CreateContainerOptions{
+		Name: "nginx",
+		Config: &dc.Config{
+			Image:        "nginx:latest",
+			Env:          []string{"FOO=bar"},
+			ExposedPorts: map[dc.Port]struct{}{"80/tcp": {}},
+			Entrypoint:   []string{"/bin/sh", "-c"},
+			Cmd:          []string{"nginx"},
+		},
+		HostConfig: &dc.HostConfig{
+			PortBindings: map[dc.Port][]dc.PortBinding{
+				"80/tcp": {{HostIP: "127.0.0.1", HostPort: "8080"}},
+			},
+			Binds: []string{"/srv:/srv"},
+		},
+	}
+
+	t.Run("new", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("podman", "container", "exists", "nginx").Return("", 1)
+		fake.Expect(
+			"podman", "create", "--name", "nginx",
+			"--env", "FOO=bar",
+			"--expose", "80/tcp",
+			"--publish", "127.0.0.1:8080:80/tcp",
+			"--volume", "/srv:/srv",
+			"--entrypoint", `["/bin/sh","-c"]`,
+			"nginx:latest", "nginx",
+		).Return("abc123\n", 0)
+
+		container, err := docker.NewPodmanClient(fake).CreateContainer(opts)
+		require.NoError(t, err)
+		assert.Equal(t, "abc123", container.ID)
+		fake.AssertExpectations(t)
+	})
+
+	t.Run("replaces existing", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("podman", "container", "exists", "nginx")
+		fake.Expect("podman", "container", "inspect", "nginx").Return(nginxContainer, 0)
+		fake.Expect("podman", "rm", "--force", "abc123")
+		fake.Expect(
+			"podman", "create", "--name", "nginx",
+			"--env", "FOO=bar",
+			"--expose", "80/tcp",
+			"--publish", "127.0.0.1:8080:80/tcp",
+			"--volume", "/srv:/srv",
+			"--entrypoint", `["/bin/sh","-c"]`,
+			"nginx:latest", "nginx",
+		).Return("def456\n", 0)
+
+		container, err := docker.NewPodmanClient(fake).CreateContainer(opts)
+		require.NoError(t, err)
+		assert.Equal(t, "def456", container.ID)
+		fake.AssertExpectations(t)
+	})
+
+	t.Run("links", func(t *testing.T) {
+		linked := opts
+		linked.HostConfig = &dc.HostConfig{Links: []string{"db:db"}}
+
+		_, err := docker.NewPodmanClient(fakeexec.New()).CreateContainer(linked)
+		assert.EqualError(t, err, "podman does not support links, put the containers in a pod or on a network instead")
+	})
+}
+
+func TestPodmanContainerLogs(t *testing.T) {
+	t.Parallel()
+
+	since := time.Date(2016, 10, 14, 10, 0, 0, 0, time.UTC)
+
+	fake := fakeexec.New()
+	fake.Expect("podman", "logs", "--since", "2016-10-14T10:00:00Z", "nginx").Return("started\n", 0).Stderr("warning\n")
+
+	logs, err := docker.NewPodmanClient(fake).ContainerLogs("nginx", since)
+	require.NoError(t, err)
+	assert.Equal(t, "started\nwarning\n", logs)
+}
@@ -0,0 +1,95 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registryauth
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/load/registry"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/docker"
+)
+
+// Preparer for RegistryAuth
+//
+// RegistryAuth logs the docker CLI into a registry with `docker login`. The
+// password is read from a file on the target and passed to docker on stdin,
+// so it never appears in a command line or passes through converge. If a
+// credential helper is given and installed, the registry is pointed at it in
+// config.json before logging in, so the credentials are kept by the helper
+// instead of in the file. docker.image pulls use the stored credentials, and
+// LoggedIn is available to other resources once the login is done.
+type Preparer struct {
+	// Registry is the server to log into, such as registry.example.com. It
+	// defaults to Docker Hub.
+	Registry string `hcl:"registry"`
+
+	// Username to log in as
+	Username string `hcl:"username" required:"true"`
+
+	// PasswordFile is the path of a file on the target holding the password or
+	// token
+	PasswordFile string `hcl:"password_file" required:"true"`
+
+	// CredentialHelper is the docker credential helper to store the
+	// credentials with, such as secretservice, pass or ecr-login. If it is not
+	// installed, the credentials are kept in config.json.
+	CredentialHelper string `hcl:"credential_helper"`
+
+	// ConfigDir is the docker CLI configuration directory. It defaults to
+	// /root/.docker.
+	ConfigDir string `hcl:"config_dir"`
+}
+
+// Prepare a new task
+func (p *Preparer) Prepare(render resource.Renderer) (resource.Task, error) {
+	if p.Registry == "" {
+		p.Registry = docker.DockerHub
+	}
+
+	if strings.ContainsAny(p.Username, " \t\n") {
+		return nil, fmt.Errorf("docker.registry_auth: %q is not a valid username", p.Username)
+	}
+
+	for _, dir := range []string{p.PasswordFile, p.ConfigDir} {
+		if dir != "" && !path.IsAbs(dir) {
+			return nil, fmt.Errorf("docker.registry_auth: %q is not an absolute path", dir)
+		}
+	}
+
+	if strings.ContainsAny(p.CredentialHelper, "/ \t\n") {
+		return nil, fmt.Errorf("docker.registry_auth: %q is not a valid credential helper", p.CredentialHelper)
+	}
+
+	if p.ConfigDir == "" {
+		p.ConfigDir = docker.DefaultConfigDir
+	}
+
+	return &RegistryAuth{
+		Registry:         p.Registry,
+		Username:         p.Username,
+		PasswordFile:     p.PasswordFile,
+		CredentialHelper: p.CredentialHelper,
+		ConfigDir:        path.Clean(p.ConfigDir),
+		exec:             exec.For(render),
+	}, nil
+}
+
+func init() {
+	registry.Register("docker.registry_auth", (*Preparer)(nil), (*RegistryAuth)(nil))
+}
@@ -0,0 +1,192 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registryauth
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/docker"
+	"github.com/pkg/errors"
+)
+
+// loginScript logs in with the password read from the file in $2, so that it
+// is passed to docker on stdin
+const loginScript = `exec docker login --username "$1" --password-stdin "$0" < "$2"`
+
+// RegistryAuth manages the docker CLI login to a registry
+type RegistryAuth struct {
+	resource.Status
+
+	Registry         string
+	Username         string
+	PasswordFile     string
+	CredentialHelper string
+	ConfigDir        string
+
+	// LoggedIn is true once credentials for Username are stored for the
+	// registry
+	LoggedIn bool
+
+	// Helper is the credential helper the credentials are stored with, or
+	// empty if they are kept in config.json
+	Helper string
+
+	exec exec.Executor
+}
+
+// Check whether credentials for the user are stored for the registry
+func (r *RegistryAuth) Check(resource.Renderer) (resource.TaskStatus, error) {
+	r.Status = resource.Status{}
+
+	helper, err := r.plan()
+	if err != nil {
+		r.RaiseLevel(resource.StatusFatal)
+		return r, err
+	}
+
+	if helper != r.Helper {
+		r.RaiseLevel(resource.StatusWillChange)
+		r.AddDifference("credential_helper", orNone(r.Helper), helper, "")
+	}
+
+	if !r.LoggedIn || helper != r.Helper {
+		r.RaiseLevel(resource.StatusWillChange)
+		r.AddDifference(r.Registry, "<logged out>", "logged in as "+r.Username, "")
+	}
+
+	return r, nil
+}
+
+// Apply points the registry at the credential helper and logs in
+func (r *RegistryAuth) Apply() (resource.TaskStatus, error) {
+	r.Status = resource.Status{}
+
+	helper, err := r.plan()
+	if err != nil {
+		r.RaiseLevel(resource.StatusFatal)
+		return r, err
+	}
+
+	if helper != r.Helper {
+		if err := r.setHelper(helper); err != nil {
+			r.RaiseLevel(resource.StatusFatal)
+			return r, err
+		}
+		r.Helper = helper
+		r.AddMessage(fmt.Sprintf("storing credentials for %s with docker-credential-%s", r.Registry, helper))
+	}
+
+	cmd := &exec.Command{
+		Name: "sh",
+		Args: []string{"-c", loginScript, r.Registry, r.Username, r.PasswordFile},
+		Env:  []string{"DOCKER_CONFIG=" + r.ConfigDir},
+	}
+
+	result, err := r.exec.Run(cmd)
+	if err == nil && !result.Success() {
+		err = &exec.ExitError{Command: cmd, Result: result}
+	}
+	if err != nil {
+		r.RaiseLevel(resource.StatusFatal)
+		return r, errors.Wrapf(err, "cannot log into %s", r.Registry)
+	}
+
+	r.LoggedIn = true
+	r.AddMessage(fmt.Sprintf("logged into %s as %s", r.Registry, r.Username))
+
+	return r, nil
+}
+
+// plan finds the current state of the login and returns the credential
+// helper the credentials should be stored with
+func (r *RegistryAuth) plan() (string, error) {
+	r.LoggedIn, r.Helper = false, ""
+
+	config, err := docker.ReadConfigFile(r.exec, r.ConfigDir)
+	if err != nil {
+		return "", err
+	}
+	r.Helper = config.Helper(r.Registry)
+
+	creds, err := docker.LookupCredentials(r.exec, r.ConfigDir, r.Registry)
+	if err != nil {
+		return "", err
+	}
+	r.LoggedIn = creds != nil && creds.Username == r.Username
+
+	if r.CredentialHelper == "" || r.CredentialHelper == r.Helper {
+		return r.Helper, nil
+	}
+
+	err = exec.Run(r.exec, "sh", "-c", `command -v "$0"`, "docker-credential-"+r.CredentialHelper)
+	if _, ok := exec.ExitStatus(err); ok {
+		r.AddMessage(fmt.Sprintf("docker-credential-%s is not installed, credentials are kept in config.json", r.CredentialHelper))
+		return r.Helper, nil
+	} else if err != nil {
+		return "", err
+	}
+
+	return r.CredentialHelper, nil
+}
+
+// setHelper sets the credential helper for the registry in config.json,
+// leaving the rest of the file alone
+func (r *RegistryAuth) setHelper(helper string) error {
+	file := path.Join(r.ConfigDir, "config.json")
+	content, _, err := exec.ReadFile(r.exec, file)
+	if err != nil {
+		return errors.Wrapf(err, "cannot read %s", file)
+	}
+
+	config := map[string]interface{}{}
+	if strings.TrimSpace(content) != "" {
+		if err := json.Unmarshal([]byte(content), &config); err != nil {
+			return errors.Wrapf(err, "cannot parse %s", file)
+		}
+	}
+
+	helpers, _ := config["credHelpers"].(map[string]interface{})
+	if helpers == nil {
+		helpers = map[string]interface{}{}
+	}
+	helpers[r.Registry] = helper
+	config["credHelpers"] = helpers
+
+	out, err := json.MarshalIndent(config, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	if err := exec.Run(r.exec, "mkdir", "-p", "-m", "0700", r.ConfigDir); err != nil {
+		return errors.Wrapf(err, "cannot create %s", r.ConfigDir)
+	}
+
+	if err := exec.WriteFile(r.exec, file, string(out)+"\n", 0600); err != nil {
+		return errors.Wrapf(err, "cannot write %s", file)
+	}
+	return nil
+}
+
+func orNone(helper string) string {
+	if helper == "" {
+		return "<none>"
+	}
+	return helper
+}
@@ -0,0 +1,185 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registryauth_test
+
+import (
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/helpers/fakeexec"
+	"github.com/asteris-llc/converge/helpers/fakerenderer"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/docker/registryauth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	config   = "/root/.docker/config.json"
+	registry = "registry.example.com"
+	login    = `exec docker login --username "$1" --password-stdin "$0" < "$2"`
+)
+
+// TestRegistryAuthInterface tests that RegistryAuth is properly implemented
+func TestRegistryAuthInterface(t *testing.T) {
+	t.Parallel()
+
+	assert.Implements(t, (*resource.Task)(nil), new(registryauth.RegistryAuth))
+	assert.Implements(t, (*resource.Resource)(nil), new(registryauth.Preparer))
+}
+
+// TestPrepare tests validating the preparer
+func TestPrepare(t *testing.T) {
+	t.Parallel()
+
+	task, err := (&registryauth.Preparer{Username: "deploy", PasswordFile: "/etc/registry-token"}).Prepare(fakerenderer.New())
+	require.NoError(t, err)
+	assert.Equal(t, "https://index.docker.io/v1/", task.(*registryauth.RegistryAuth).Registry)
+
+	for _, p := range []*registryauth.Preparer{
+		{Username: "de ploy", PasswordFile: "/etc/registry-token"},
+		{Username: "deploy", PasswordFile: "registry-token"},
+		{Username: "deploy", PasswordFile: "/etc/registry-token", CredentialHelper: "../pass"},
+	} {
+		_, err := p.Prepare(fakerenderer.New())
+		assert.Error(t, err, "%+v", p)
+	}
+}
+
+// TestCheck tests the possible cases Check handles
+func TestCheck(t *testing.T) {
+	t.Parallel()
+
+	t.Run("logged out", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("test", "-e", config).Return("", 1)
+
+		auth := prepare(t, fake, "")
+		status, err := auth.Check(fakerenderer.New())
+		require.NoError(t, err)
+		assert.True(t, status.HasChanges())
+		assert.False(t, auth.LoggedIn)
+		assert.Equal(t, "logged in as deploy", status.Diffs()[registry].Current())
+	})
+
+	t.Run("logged in", func(t *testing.T) {
+		auth := prepare(t, existing(`{"auths": {"registry.example.com": {"auth": "ZGVwbG95Omh1bnRlcjI="}}}`), "")
+		status, err := auth.Check(fakerenderer.New())
+		require.NoError(t, err)
+		assert.False(t, status.HasChanges())
+		assert.True(t, auth.LoggedIn)
+	})
+
+	t.Run("other user", func(t *testing.T) {
+		status, err := prepare(t, existing(`{"auths": {"registry.example.com": {"auth": "b3RoZXI6aHVudGVyMg=="}}}`), "").Check(fakerenderer.New())
+		require.NoError(t, err)
+		assert.True(t, status.HasChanges())
+	})
+
+	t.Run("helper", func(t *testing.T) {
+		fake := existing(`{"credHelpers": {"registry.example.com": "pass"}}`)
+		fake.Expect("docker-credential-pass", "get").Return(`{"Username":"deploy","Secret":"hunter2"}`, 0)
+
+		auth := prepare(t, fake, "pass")
+		status, err := auth.Check(fakerenderer.New())
+		require.NoError(t, err)
+		assert.False(t, status.HasChanges())
+		assert.Equal(t, "pass", auth.Helper)
+	})
+
+	t.Run("helper not installed", func(t *testing.T) {
+		fake := existing(`{"auths": {"registry.example.com": {"auth": "ZGVwbG95Omh1bnRlcjI="}}}`)
+		fake.Expect("sh", "-c", `command -v "$0"`, "docker-credential-pass").Return("", 1)
+
+		status, err := prepare(t, fake, "pass").Check(fakerenderer.New())
+		require.NoError(t, err)
+		assert.False(t, status.HasChanges())
+		assert.Contains(t, status.Messages(), "docker-credential-pass is not installed, credentials are kept in config.json")
+	})
+}
+
+// TestApply tests that Apply sets up the helper and logs in
+func TestApply(t *testing.T) {
+	t.Parallel()
+
+	t.Run("login", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("test", "-e", config).Return("", 1)
+		fake.Expect("sh", "-c", login, registry, "deploy", "/etc/registry-token")
+
+		auth := prepare(t, fake, "")
+		_, err := auth.Apply()
+		require.NoError(t, err)
+		fake.AssertExpectations(t)
+		assert.True(t, auth.LoggedIn)
+
+		calls := fake.Calls()
+		assert.Equal(t, []string{"DOCKER_CONFIG=/root/.docker"}, calls[len(calls)-1].Env)
+	})
+
+	t.Run("helper", func(t *testing.T) {
+		fake := existing(`{"auths": {"registry.example.com": {"auth": "ZGVwbG95Omh1bnRlcjI="}}, "detachKeys": "ctrl-e,e"}`)
+		fake.Expect("sh", "-c", `command -v "$0"`, "docker-credential-pass")
+		fake.Expect("mkdir", "-p", "-m", "0700", "/root/.docker")
+		fake.Expect("sh", "-c", exec.WriteFileScript, config, "0600")
+		fake.Expect("sh", "-c", login, registry, "deploy", "/etc/registry-token")
+
+		auth := prepare(t, fake, "pass")
+		_, err := auth.Apply()
+		require.NoError(t, err)
+		fake.AssertExpectations(t)
+		assert.Equal(t, "pass", auth.Helper)
+
+		var written string
+		for _, call := range fake.Calls() {
+			if call.Name == "sh" && call.Args[1] == exec.WriteFileScript {
+				written = call.Stdin
+			}
+		}
+		assert.Contains(t, written, `"detachKeys": "ctrl-e,e"`)
+		assert.Contains(t, written, `"registry.example.com": "pass"`)
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("test", "-e", config).Return("", 1)
+		fake.Expect("sh", "-c", login, registry, "deploy", "/etc/registry-token").Return("", 1).Stderr("Error response from daemon: unauthorized")
+
+		auth := prepare(t, fake, "")
+		_, err := auth.Apply()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unauthorized")
+		assert.False(t, auth.LoggedIn)
+	})
+}
+
+func prepare(t *testing.T, fake *fakeexec.Executor, helper string) *registryauth.RegistryAuth {
+	p := &registryauth.Preparer{
+		Registry:         registry,
+		Username:         "deploy",
+		PasswordFile:     "/etc/registry-token",
+		CredentialHelper: helper,
+	}
+	task, err := p.Prepare(&resource.ExecRenderer{Renderer: fakerenderer.New(), Exec: fake})
+	require.NoError(t, err)
+	return task.(*registryauth.RegistryAuth)
+}
+
+func existing(content string) *fakeexec.Executor {
+	fake := fakeexec.New()
+	fake.Expect("test", "-e", config)
+	fake.Expect("cat", config).Return(content, 0)
+	return fake
+}
@@ -0,0 +1,39 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package docker
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+)
+
+// Runtimes lists the container runtimes accepted by NewClient
+var Runtimes = []string{"docker", "podman"}
+
+// NewClient returns an APIClient for the named runtime. Docker is used if the
+// name is empty. Podman is driven through its command line with the given
+// executor, so it runs rootless when the executor runs commands as an
+// unprivileged user.
+func NewClient(runtime string, e exec.Executor) (APIClient, error) {
+	switch runtime {
+	case "", "docker":
+		return NewDockerClient()
+	case "podman":
+		return NewPodmanClient(e), nil
+	}
+	return nil, fmt.Errorf("%q is not a valid runtime, expected one of %s", runtime, strings.Join(Runtimes, ", "))
+}
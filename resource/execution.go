@@ -0,0 +1,142 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"reflect"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/helpers/statedir"
+	"github.com/pkg/errors"
+)
+
+// execution holds node-level settings that control how a resource runs
+// external commands. Like "depends" and "group", they can be set on any
+// resource.
+type execution struct {
+	// Become runs the resource's commands with sudo. Setting BecomeUser
+	// implies Become.
+	Become bool `hcl:"become"`
+
+	// BecomeUser is the user to run commands as. Defaults to root.
+	BecomeUser string `hcl:"become_user"`
+
+	// BecomePassword is given to sudo if it requires a password. It is usually
+	// set from a param so that it doesn't need to be written in the module.
+	BecomePassword string `hcl:"become_password"`
+
+	// Target runs the resource's commands somewhere other than the host: a
+	// chroot, the mount namespace of another process, or a running container.
+	// It is a block with a single key, like `target { docker = "name" }`.
+	Target map[string]string `hcl:"target"`
+}
+
+// Executor returns the executor for commands under these settings, wrapping
+// the given executor. Become applies inside the target, so sudo is run in the
+// container or chroot rather than on the host.
+func (e *execution) Executor(inner exec.Executor) (exec.Executor, error) {
+	if e.Target != nil {
+		var err error
+		inner, err = exec.NewTarget(inner, e.Target)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if !e.Become && e.BecomeUser == "" {
+		return inner, nil
+	}
+
+	return &exec.Become{
+		Executor: inner,
+		User:     e.BecomeUser,
+		Password: e.BecomePassword,
+	}, nil
+}
+
+// ExecRenderer is a Renderer that also provides the executor for the node
+// being prepared. Resources get it with exec.For.
+type ExecRenderer struct {
+	Renderer
+	Exec exec.Executor
+}
+
+// Executor returns the executor for this node
+func (e *ExecRenderer) Executor() exec.Executor {
+	return e.Exec
+}
+
+// ModuleLocation returns the module location known to the wrapped renderer
+func (e *ExecRenderer) ModuleLocation() string {
+	if locator, ok := e.Renderer.(statedir.Locator); ok {
+		return locator.ModuleLocation()
+	}
+	return ""
+}
+
+// HostOnly is implemented by resources that change the local system directly
+// instead of running commands, such as file.content. A target would be
+// ignored by them, so they can't be prepared with one, whether it is set on
+// the node or on a module around it.
+type HostOnly interface {
+	HostOnly()
+}
+
+// executionFieldNames lists the HCL names of the execution settings
+func (p *Preparer) executionFieldNames() (out []string) {
+	typ := reflect.TypeOf(execution{})
+	for i := 0; i < typ.NumField(); i++ {
+		out = append(out, p.getFieldName(typ.Field(i)))
+	}
+	return out
+}
+
+// prepareExecution renders the execution settings for the node and, if any
+// are set, returns a renderer that carries the resulting executor. It fails
+// if res is HostOnly and would run in a target.
+func (p *Preparer) prepareExecution(res Resource, r Renderer) (Renderer, error) {
+	settings := execution{}
+	value := reflect.ValueOf(&settings).Elem()
+	typ := value.Type()
+
+	set := false
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if _, ok := p.Source[p.getFieldName(field)]; !ok {
+			continue
+		}
+		set = true
+
+		val, err := p.getValueForField(r, field)
+		if err != nil {
+			return r, err
+		}
+		value.Field(i).Set(val)
+	}
+
+	if set {
+		executor, err := settings.Executor(exec.For(r))
+		if err != nil {
+			return r, err
+		}
+		r = &ExecRenderer{Renderer: r, Exec: executor}
+	}
+
+	if _, ok := res.(HostOnly); ok && !exec.Local(exec.For(r)) {
+		return r, errors.New(`"target" is not supported by this resource, since it changes the local system directly instead of running commands`)
+	}
+
+	return r, nil
+}
@@ -0,0 +1,137 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource_test
+
+import (
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/helpers/fakerenderer"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestPreparerExecution tests that execution settings are accepted on any
+// resource and passed to Prepare through the renderer
+func TestPreparerExecution(t *testing.T) {
+	t.Parallel()
+
+	prepare := func(t *testing.T, source map[string]interface{}) exec.Executor {
+		target := new(testExecutionTarget)
+		prep := resource.NewPreparerWithSource(target, source)
+
+		_, err := prep.Prepare(fakerenderer.New())
+		require.NoError(t, err)
+
+		return exec.For(target.renderer)
+	}
+
+	t.Run("unset", func(t *testing.T) {
+		assert.Equal(t, exec.New(), prepare(t, map[string]interface{}{}))
+	})
+
+	t.Run("become", func(t *testing.T) {
+		executor := prepare(t, map[string]interface{}{"become": true})
+		assert.Equal(t, &exec.Become{Executor: exec.New()}, executor)
+	})
+
+	t.Run("become_user", func(t *testing.T) {
+		executor := prepare(t, map[string]interface{}{
+			"become_user":     "postgres",
+			"become_password": "secret",
+		})
+		assert.Equal(t, &exec.Become{Executor: exec.New(), User: "postgres", Password: "secret"}, executor)
+	})
+
+	t.Run("target", func(t *testing.T) {
+		executor := prepare(t, map[string]interface{}{
+			"target": []map[string]interface{}{{"docker": "build"}},
+		})
+		assert.Equal(t, &exec.Docker{Executor: exec.New(), Container: "build"}, executor)
+	})
+
+	t.Run("target with become", func(t *testing.T) {
+		executor := prepare(t, map[string]interface{}{
+			"target": []map[string]interface{}{{"chroot": "/mnt/image"}},
+			"become": true,
+		})
+		assert.Equal(
+			t,
+			&exec.Become{Executor: &exec.Chroot{Executor: exec.New(), Root: "/mnt/image"}},
+			executor,
+		)
+	})
+
+	t.Run("invalid target", func(t *testing.T) {
+		target := new(testExecutionTarget)
+		prep := resource.NewPreparerWithSource(target, map[string]interface{}{
+			"target": []map[string]interface{}{{"vm": "x"}},
+		})
+
+		_, err := prep.Prepare(fakerenderer.New())
+		assert.EqualError(t, err, `"vm" is not a valid target, expected one of chroot, docker, namespace`)
+	})
+
+	t.Run("host only", func(t *testing.T) {
+		hostOnlyErr := `"target" is not supported by this resource, since it changes the local system directly instead of running commands`
+
+		t.Run("without target", func(t *testing.T) {
+			prep := resource.NewPreparerWithSource(new(testHostOnly), map[string]interface{}{"become": true})
+
+			_, err := prep.Prepare(fakerenderer.New())
+			assert.NoError(t, err)
+		})
+
+		t.Run("target", func(t *testing.T) {
+			prep := resource.NewPreparerWithSource(new(testHostOnly), map[string]interface{}{
+				"target": []map[string]interface{}{{"docker": "build"}},
+			})
+
+			_, err := prep.Prepare(fakerenderer.New())
+			assert.EqualError(t, err, hostOnlyErr)
+		})
+
+		t.Run("inherited target", func(t *testing.T) {
+			prep := resource.NewPreparer(new(testHostOnly))
+			render := &resource.ExecRenderer{
+				Renderer: fakerenderer.New(),
+				Exec:     &exec.Chroot{Executor: exec.New(), Root: "/mnt/image"},
+			}
+
+			_, err := prep.Prepare(render)
+			assert.EqualError(t, err, hostOnlyErr)
+		})
+	})
+}
+
+type testExecutionTarget struct {
+	renderer resource.Renderer
+}
+
+func (tet *testExecutionTarget) Prepare(r resource.Renderer) (resource.Task, error) {
+	tet.renderer = r
+	return tet, nil
+}
+func (tet *testExecutionTarget) Check(resource.Renderer) (resource.TaskStatus, error) {
+	return nil, nil
+}
+func (tet *testExecutionTarget) Apply() (resource.TaskStatus, error) { return nil, nil }
+
+type testHostOnly struct {
+	testExecutionTarget
+}
+
+func (tho *testHostOnly) HostOnly() {}
@@ -0,0 +1,88 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"errors"
+	"reflect"
+)
+
+// Values for the "on_failure" node setting
+const (
+	// OnFailureContinue leaves resources that were already applied in place
+	// when the resource fails. It is the default.
+	OnFailureContinue = "continue"
+
+	// OnFailureRollback rolls back the resources that were already applied in
+	// the run when the resource fails
+	OnFailureRollback = "rollback"
+)
+
+// ErrRollbackUnsupported is returned by Rollback on wrapped tasks that don't
+// implement Rollbacker
+var ErrRollbackUnsupported = errors.New("resource does not support rollback")
+
+// Rollbacker is implemented by tasks that can undo their last Apply. When a
+// node with `on_failure = "rollback"` fails, the apply pipeline calls Rollback
+// on every node applied earlier in the run, in reverse order. Tasks should
+// restore the state they found before Apply, and do nothing if Apply didn't
+// change anything.
+type Rollbacker interface {
+	Rollback() (TaskStatus, error)
+}
+
+// FailureHandler is implemented by tasks that set what happens to the rest of
+// the run when they fail, as one of the OnFailure values
+type FailureHandler interface {
+	OnFailure() string
+}
+
+// failure holds the node-level setting that controls what happens when a
+// resource fails. Like "depends" and "group", it can be set on any resource.
+type failure struct {
+	// OnFailure is what to do with the resources already applied in the run if
+	// this resource fails to apply. "rollback" rolls back the ones that support
+	// it, in the reverse order they were applied, and stops any more from being
+	// applied.
+	OnFailure string `hcl:"on_failure" valid_values:"continue,rollback"`
+}
+
+// HandlingFailure wraps a task to record what should happen when it fails
+type HandlingFailure struct {
+	Wrapped
+
+	onFailure string
+}
+
+// OnFailure returns the node's "on_failure" setting
+func (h *HandlingFailure) OnFailure() string {
+	return h.onFailure
+}
+
+// prepareFailure wraps task in a HandlingFailure if the node sets
+// "on_failure"
+func (p *Preparer) prepareFailure(r Renderer, task Task) (Task, error) {
+	field, _ := reflect.TypeOf(failure{}).FieldByName("OnFailure")
+	if _, ok := p.Source[p.getFieldName(field)]; !ok {
+		return task, nil
+	}
+
+	val, err := p.getValueForField(r, field)
+	if err != nil {
+		return nil, err
+	}
+
+	return &HandlingFailure{Wrapped: Wrapped{Task: task}, onFailure: val.String()}, nil
+}
@@ -0,0 +1,77 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource_test
+
+import (
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/fakerenderer"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestPreparerOnFailure tests that on_failure is recorded on the task and that
+// rollbacks are passed through to the wrapped task
+func TestPreparerOnFailure(t *testing.T) {
+	t.Parallel()
+
+	t.Run("unset", func(t *testing.T) {
+		target := new(testRollbackTarget)
+		task, err := resource.NewPreparerWithSource(target, map[string]interface{}{}).Prepare(fakerenderer.New())
+		require.NoError(t, err)
+		assert.Equal(t, target, task)
+	})
+
+	t.Run("rollback", func(t *testing.T) {
+		target := new(testRollbackTarget)
+		task, err := resource.NewPreparerWithSource(target, map[string]interface{}{"on_failure": "rollback"}).Prepare(fakerenderer.New())
+		require.NoError(t, err)
+
+		require.Implements(t, (*resource.FailureHandler)(nil), task)
+		assert.Equal(t, resource.OnFailureRollback, task.(resource.FailureHandler).OnFailure())
+
+		_, err = task.(resource.Rollbacker).Rollback()
+		assert.NoError(t, err)
+		assert.Equal(t, 1, target.rollbacks)
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		task, err := resource.NewPreparerWithSource(new(testThrottleTarget), map[string]interface{}{"on_failure": "rollback"}).Prepare(fakerenderer.New())
+		require.NoError(t, err)
+
+		_, err = task.(resource.Rollbacker).Rollback()
+		assert.Equal(t, resource.ErrRollbackUnsupported, err)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := resource.NewPreparerWithSource(new(testRollbackTarget), map[string]interface{}{"on_failure": "retry"}).Prepare(fakerenderer.New())
+		assert.Error(t, err)
+	})
+}
+
+type testRollbackTarget struct {
+	testThrottleTarget
+	rollbacks int
+}
+
+func (trt *testRollbackTarget) Prepare(resource.Renderer) (resource.Task, error) {
+	return trt, nil
+}
+
+func (trt *testRollbackTarget) Rollback() (resource.TaskStatus, error) {
+	trt.rollbacks++
+	return &resource.Status{}, nil
+}
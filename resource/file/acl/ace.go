@@ -0,0 +1,167 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package acl
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/asteris-llc/converge/resource/windows"
+)
+
+// synchronize is added to allow entries by Windows, and is left out when they
+// are compared
+const synchronize = 0x100000
+
+// Inheritance and propagation flags, as System.Security.AccessControl has
+// them
+const (
+	ContainerInherit = 1
+	ObjectInherit    = 2
+
+	NoPropagateInherit = 1
+	InheritOnly        = 2
+)
+
+// rights are the simple rights icacls accepts, most specific last
+var rights = []struct {
+	code string
+	mask uint32
+}{
+	{"D", 0x10000},
+	{"W", 0x116},
+	{"R", 0x20089},
+	{"RX", 0x200a9},
+	{"M", 0x301bf},
+	{"F", 0xf01ff},
+}
+
+var aceRe = regexp.MustCompile(`^(.+?):((?:\([A-Za-z0-9,]+\))+)$`)
+
+// ACE is an entry in the access control list of a file. It is written the way
+// icacls writes it, as an account followed by inheritance flags and rights:
+//
+//	BUILTIN\Users:(OI)(CI)(RX)
+//
+// The flags are (OI) object inherit, (CI) container inherit, (IO) inherit
+// only, and (NP) don't propagate. Rights are one or more of F (full), M
+// (modify), RX (read and execute), R (read), W (write), and D (delete),
+// separated by commas.
+type ACE struct {
+	Identity    string
+	Deny        bool
+	Rights      uint32
+	Inheritance int
+	Propagation int
+}
+
+// ParseACE parses an entry in the form ACE is written in
+func ParseACE(text string, deny bool) (*ACE, error) {
+	match := aceRe.FindStringSubmatch(strings.TrimSpace(text))
+	if match == nil {
+		return nil, fmt.Errorf(`access entry %q must be an account and rights, like "BUILTIN\Users:(OI)(CI)(RX)"`, text)
+	}
+
+	ace := &ACE{Identity: match[1], Deny: deny}
+	for _, group := range strings.Split(strings.Trim(match[2], "()"), ")(") {
+		switch strings.ToUpper(group) {
+		case "OI":
+			ace.Inheritance |= ObjectInherit
+		case "CI":
+			ace.Inheritance |= ContainerInherit
+		case "IO":
+			ace.Propagation |= InheritOnly
+		case "NP":
+			ace.Propagation |= NoPropagateInherit
+		default:
+			for _, code := range strings.Split(group, ",") {
+				mask, ok := rightsFor(code)
+				if !ok {
+					return nil, fmt.Errorf("access entry %q: unknown right %q, expected F, M, RX, R, W, or D", text, code)
+				}
+				ace.Rights |= mask
+			}
+		}
+	}
+
+	if ace.Rights == 0 {
+		return nil, fmt.Errorf("access entry %q has no rights", text)
+	}
+
+	return ace, nil
+}
+
+// String writes the entry in the form ParseACE accepts, with "deny " in front
+// of denied entries
+func (a *ACE) String() string {
+	out := a.Identity + ":"
+	if a.Deny {
+		out = "deny " + out
+	}
+
+	if a.Inheritance&ObjectInherit != 0 {
+		out += "(OI)"
+	}
+	if a.Inheritance&ContainerInherit != 0 {
+		out += "(CI)"
+	}
+	if a.Propagation&InheritOnly != 0 {
+		out += "(IO)"
+	}
+	if a.Propagation&NoPropagateInherit != 0 {
+		out += "(NP)"
+	}
+
+	return out + "(" + codeFor(a.Rights) + ")"
+}
+
+// matches tells whether an entry read from the file is this one
+func (a *ACE) matches(current *ACE) bool {
+	return windows.SameAccount(current.Identity, a.Identity) &&
+		current.Deny == a.Deny &&
+		current.Rights&^synchronize == a.Rights&^synchronize &&
+		current.Inheritance == a.Inheritance &&
+		current.Propagation == a.Propagation
+}
+
+func rightsFor(code string) (uint32, bool) {
+	for _, right := range rights {
+		if strings.EqualFold(code, right.code) {
+			return right.mask, true
+		}
+	}
+	return 0, false
+}
+
+// codeFor writes a mask as the simple rights it is made of, or as a number if
+// it isn't made of them
+func codeFor(mask uint32) string {
+	mask &^= synchronize
+
+	var codes []string
+	left := mask
+	for i := len(rights) - 1; i >= 0 && left != 0; i-- {
+		if left&rights[i].mask == rights[i].mask {
+			codes = append(codes, rights[i].code)
+			left &^= rights[i].mask
+		}
+	}
+
+	if left != 0 || len(codes) == 0 {
+		return fmt.Sprintf("0x%x", mask)
+	}
+	return strings.Join(codes, ",")
+}
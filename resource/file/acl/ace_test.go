@@ -0,0 +1,74 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package acl_test
+
+import (
+	"testing"
+
+	"github.com/asteris-llc/converge/resource/file/acl"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseACE(t *testing.T) {
+	t.Parallel()
+
+	for text, expected := range map[string]*acl.ACE{
+		`BUILTIN\Users:(OI)(CI)(RX)`: {Identity: `BUILTIN\Users`, Rights: 0x200a9, Inheritance: acl.ObjectInherit | acl.ContainerInherit},
+		`SYSTEM:(F)`:                 {Identity: "SYSTEM", Rights: 0xf01ff},
+		`app:(CI)(IO)(R,W)`:          {Identity: "app", Rights: 0x2019f, Inheritance: acl.ContainerInherit, Propagation: acl.InheritOnly},
+	} {
+		text, expected := text, expected
+		t.Run(text, func(t *testing.T) {
+			ace, err := acl.ParseACE(text, false)
+			require.NoError(t, err)
+			assert.Equal(t, expected, ace)
+			assert.Equal(t, text, ace.String())
+		})
+	}
+
+	for text, msg := range map[string]string{
+		"Users":          `access entry "Users" must be an account and rights, like "BUILTIN\Users:(OI)(CI)(RX)"`,
+		"Users:(RWX)":    `access entry "Users:(RWX)": unknown right "RWX", expected F, M, RX, R, W, or D`,
+		"Users:(OI)(CI)": `access entry "Users:(OI)(CI)" has no rights`,
+	} {
+		text, msg := text, msg
+		t.Run(text, func(t *testing.T) {
+			_, err := acl.ParseACE(text, false)
+			assert.EqualError(t, err, msg)
+		})
+	}
+}
+
+func TestACEString(t *testing.T) {
+	t.Parallel()
+
+	t.Run("deny", func(t *testing.T) {
+		assert.Equal(t, `deny Guest:(W)`, (&acl.ACE{Identity: "Guest", Deny: true, Rights: 0x116}).String())
+	})
+
+	t.Run("synchronize", func(t *testing.T) {
+		assert.Equal(t, `Users:(RX)`, (&acl.ACE{Identity: "Users", Rights: 0x1200a9}).String())
+	})
+
+	t.Run("other rights", func(t *testing.T) {
+		assert.Equal(t, `CREATOR OWNER:(OI)(CI)(IO)(0x10000000)`, (&acl.ACE{
+			Identity:    "CREATOR OWNER",
+			Rights:      0x10000000,
+			Inheritance: acl.ObjectInherit | acl.ContainerInherit,
+			Propagation: acl.InheritOnly,
+		}).String())
+	})
+}
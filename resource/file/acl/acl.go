@@ -0,0 +1,283 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package acl
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/windows"
+)
+
+// ACL manages the owner and access control list of a file or directory on
+// Windows
+type ACL struct {
+	resource.Status
+
+	Destination string
+
+	// Owner, if set, is the account that should own Destination
+	Owner string
+
+	// Entries, if set, are the entries the access control list should have
+	// besides the inherited ones
+	Entries []*ACE
+
+	// Inherit, if set, is whether Destination should inherit entries from its
+	// parent
+	Inherit *bool
+
+	// SDDL, if set, is the access control list in SDDL form. It is used
+	// instead of Entries and Inherit.
+	SDDL string
+
+	exec exec.Executor
+}
+
+// security is what is read from the security descriptor of Destination
+type security struct {
+	Owner     string
+	Protected bool
+	Rules     []rule
+
+	// SDDL is the access control list in SDDL form, and Wanted the declared
+	// one as Windows writes it. They are only read when SDDL is set.
+	SDDL   string `json:"Sddl"`
+	Wanted string
+}
+
+type rule struct {
+	Identity    string
+	Rights      uint32
+	Type        int
+	Inheritance int
+	Propagation int
+}
+
+// Check the owner and access control list of Destination
+func (a *ACL) Check(resource.Renderer) (resource.TaskStatus, error) {
+	a.Status = resource.Status{}
+
+	current, err := a.read()
+	if err != nil {
+		a.RaiseLevel(resource.StatusFatal)
+		return a, err
+	}
+
+	if current == nil {
+		a.RaiseLevel(resource.StatusFatal)
+		a.SetError(fmt.Errorf("cannot set access control list of %s, it does not exist", a.Destination))
+		return a, nil
+	}
+
+	if a.Owner != "" && !windows.SameAccount(current.Owner, a.Owner) {
+		a.RaiseLevel(resource.StatusWillChange)
+		a.AddDifference("owner", current.Owner, a.Owner, "")
+	}
+
+	if a.SDDL != "" {
+		if current.SDDL != current.Wanted {
+			a.RaiseLevel(resource.StatusWillChange)
+			a.AddDifference("sddl", current.SDDL, current.Wanted, "")
+		}
+		return a, nil
+	}
+
+	if a.Inherit != nil && current.Protected == *a.Inherit {
+		a.RaiseLevel(resource.StatusWillChange)
+		a.AddDifference("inherit", fmt.Sprint(!current.Protected), fmt.Sprint(*a.Inherit), "")
+	}
+
+	if len(a.Entries) > 0 && !a.sameEntries(current.entries()) {
+		a.RaiseLevel(resource.StatusWillChange)
+		a.AddDifference("access", describe(current.entries()), describe(a.Entries), "")
+	}
+
+	return a, nil
+}
+
+// Apply sets the owner and access control list. The owner is set with
+// icacls, which can give a file to an account other than the one converge
+// runs as.
+func (a *ACL) Apply() (resource.TaskStatus, error) {
+	a.Status = resource.Status{}
+
+	current, err := a.read()
+	if err == nil && current == nil {
+		err = fmt.Errorf("cannot set access control list of %s, it does not exist", a.Destination)
+	}
+	if err != nil {
+		a.RaiseLevel(resource.StatusFatal)
+		return a, err
+	}
+
+	if a.Owner != "" && !windows.SameAccount(current.Owner, a.Owner) {
+		if _, _, err := windows.Run(a.exec, []string{"icacls.exe", a.Destination, "/setowner", a.Owner}); err != nil {
+			a.RaiseLevel(resource.StatusFatal)
+			return a, fmt.Errorf("file.acl: could not set owner of %s: %s", a.Destination, err)
+		}
+		a.AddMessage(fmt.Sprintf("set owner of %s to %s", a.Destination, a.Owner))
+	}
+
+	if script := a.applyScript(current); script != "" {
+		if _, _, err := windows.Run(a.exec, windows.PowerShell(script)); err != nil {
+			a.RaiseLevel(resource.StatusFatal)
+			return a, fmt.Errorf("file.acl: could not set access control list of %s: %s", a.Destination, err)
+		}
+		a.AddMessage(fmt.Sprintf("set access control list of %s", a.Destination))
+	}
+
+	return a, nil
+}
+
+// read the security descriptor of Destination, or nil if it doesn't exist
+func (a *ACL) read() (*security, error) {
+	path := windows.Quote(a.Destination)
+
+	script := []string{
+		"if (-not (Test-Path -LiteralPath " + path + ")) { 'NotFound' } else {",
+		"$a = Get-Acl -LiteralPath " + path + ";",
+	}
+
+	fields := "Owner = $a.Owner; Protected = $a.AreAccessRulesProtected; Rules = $rules"
+	if a.SDDL != "" {
+		script = append(script, "$w = New-Object System.Security.AccessControl.FileSecurity; $w.SetSecurityDescriptorSddlForm("+windows.Quote(a.SDDL)+");")
+		fields += "; Sddl = $a.GetSecurityDescriptorSddlForm('Access'); Wanted = $w.GetSecurityDescriptorSddlForm('Access')"
+	}
+
+	// rights are read as unsigned, since the generic rights don't fit in an
+	// int, and accounts that no longer exist are left as SIDs
+	script = append(script,
+		"$rules = @($a.GetAccessRules($true, $false, [System.Security.Principal.SecurityIdentifier]) | ForEach-Object {",
+		"$id = $_.IdentityReference; try { $id = $id.Translate([System.Security.Principal.NTAccount]) } catch {};",
+		"@{ Identity = $id.Value; Rights = [int64]$_.FileSystemRights -band 4294967295; Type = [int]$_.AccessControlType; Inheritance = [int]$_.InheritanceFlags; Propagation = [int]$_.PropagationFlags } });",
+		"[pscustomobject]@{ "+fields+" } | ConvertTo-Json -Compress -Depth 3 }",
+	)
+
+	out, _, err := windows.Run(a.exec, windows.PowerShell(strings.Join(script, " ")))
+	if err != nil {
+		return nil, fmt.Errorf("file.acl: could not read access control list of %s: %s", a.Destination, err)
+	}
+
+	out = strings.TrimSpace(out)
+	if out == "NotFound" {
+		return nil, nil
+	}
+
+	current := new(security)
+	if err := json.Unmarshal([]byte(out), current); err != nil {
+		return nil, fmt.Errorf("file.acl: could not parse access control list of %s: %s", a.Destination, err)
+	}
+	return current, nil
+}
+
+// applyScript gives the script that sets the access control list, or an
+// empty string if it doesn't need to change
+func (a *ACL) applyScript(current *security) string {
+	var script []string
+
+	if a.SDDL != "" {
+		if current.SDDL != current.Wanted {
+			script = append(script, "$a.SetSecurityDescriptorSddlForm("+windows.Quote(a.SDDL)+", 'Access');")
+		}
+	} else {
+		// inherited entries aren't copied when inheritance is turned off, since
+		// the declared entries are the complete list
+		if a.Inherit != nil && current.Protected == *a.Inherit {
+			script = append(script, fmt.Sprintf("$a.SetAccessRuleProtection($%t, $false);", !*a.Inherit))
+		}
+
+		if len(a.Entries) > 0 && !a.sameEntries(current.entries()) {
+			script = append(script, "$a.GetAccessRules($true, $false, [System.Security.Principal.SecurityIdentifier]) | ForEach-Object { $a.RemoveAccessRuleSpecific($_) };")
+			for _, entry := range a.Entries {
+				kind := "Allow"
+				if entry.Deny {
+					kind = "Deny"
+				}
+				script = append(script, fmt.Sprintf(
+					"$a.AddAccessRule((New-Object System.Security.AccessControl.FileSystemAccessRule(%s, [System.Security.AccessControl.FileSystemRights]%d, [System.Security.AccessControl.InheritanceFlags]%d, [System.Security.AccessControl.PropagationFlags]%d, '%s')));",
+					windows.Quote(entry.Identity), entry.Rights, entry.Inheritance, entry.Propagation, kind,
+				))
+			}
+		}
+	}
+
+	if len(script) == 0 {
+		return ""
+	}
+
+	// only the access section is read and written, so that writing it back
+	// doesn't try to set the owner as well
+	path := windows.Quote(a.Destination)
+	return strings.Join(append(append(
+		[]string{"$i = Get-Item -LiteralPath " + path + "; $a = $i.GetAccessControl('Access');"},
+		script...),
+		"$i.SetAccessControl($a)",
+	), " ")
+}
+
+// sameEntries tells whether the explicit entries of Destination are the
+// declared ones, in any order
+func (a *ACL) sameEntries(current []*ACE) bool {
+	if len(current) != len(a.Entries) {
+		return false
+	}
+
+	used := make([]bool, len(current))
+	for _, wanted := range a.Entries {
+		found := false
+		for i, entry := range current {
+			if !used[i] && wanted.matches(entry) {
+				used[i], found = true, true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *security) entries() []*ACE {
+	out := make([]*ACE, len(s.Rules))
+	for i, rule := range s.Rules {
+		out[i] = &ACE{
+			Identity:    rule.Identity,
+			Deny:        rule.Type == 1,
+			Rights:      rule.Rights,
+			Inheritance: rule.Inheritance,
+			Propagation: rule.Propagation,
+		}
+	}
+	return out
+}
+
+func describe(entries []*ACE) string {
+	out := make([]string, len(entries))
+	for i, entry := range entries {
+		out[i] = entry.String()
+	}
+	sort.Strings(out)
+
+	if len(out) == 0 {
+		return "<none>"
+	}
+	return strings.Join(out, ", ")
+}
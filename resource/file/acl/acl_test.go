@@ -0,0 +1,215 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package acl_test
+
+import (
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/fakeexec"
+	"github.com/asteris-llc/converge/helpers/fakerenderer"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/file/acl"
+	"github.com/asteris-llc/converge/resource/windows"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	path = `C:\app\logs`
+
+	rules = "$rules = @($a.GetAccessRules($true, $false, [System.Security.Principal.SecurityIdentifier]) | ForEach-Object { " +
+		"$id = $_.IdentityReference; try { $id = $id.Translate([System.Security.Principal.NTAccount]) } catch {}; " +
+		"@{ Identity = $id.Value; Rights = [int64]$_.FileSystemRights -band 4294967295; Type = [int]$_.AccessControlType; Inheritance = [int]$_.InheritanceFlags; Propagation = [int]$_.PropagationFlags } });"
+
+	read = `if (-not (Test-Path -LiteralPath 'C:\app\logs')) { 'NotFound' } else { $a = Get-Acl -LiteralPath 'C:\app\logs'; ` + rules +
+		" [pscustomobject]@{ Owner = $a.Owner; Protected = $a.AreAccessRulesProtected; Rules = $rules } | ConvertTo-Json -Compress -Depth 3 }"
+
+	readSDDL = `if (-not (Test-Path -LiteralPath 'C:\app\logs')) { 'NotFound' } else { $a = Get-Acl -LiteralPath 'C:\app\logs'; ` +
+		"$w = New-Object System.Security.AccessControl.FileSecurity; $w.SetSecurityDescriptorSddlForm('D:PAI(A;OICI;FA;;;BA)'); " + rules +
+		" [pscustomobject]@{ Owner = $a.Owner; Protected = $a.AreAccessRulesProtected; Rules = $rules; Sddl = $a.GetSecurityDescriptorSddlForm('Access'); Wanted = $w.GetSecurityDescriptorSddlForm('Access') } | ConvertTo-Json -Compress -Depth 3 }"
+
+	// current has the explicit entries declared by logs()
+	current = `{"Owner":"BUILTIN\\Administrators","Protected":true,"Rules":[` +
+		`{"Identity":"NT AUTHORITY\\SYSTEM","Rights":2032127,"Type":0,"Inheritance":3,"Propagation":0},` +
+		`{"Identity":"BUILTIN\\Users","Rights":1179817,"Type":0,"Inheritance":3,"Propagation":0},` +
+		`{"Identity":"CORP\\contractors","Rights":1048854,"Type":1,"Inheritance":0,"Propagation":0}]}`
+
+	changed = `{"Owner":"CORP\\jdoe","Protected":false,"Rules":[` +
+		`{"Identity":"BUILTIN\\Users","Rights":1245631,"Type":0,"Inheritance":3,"Propagation":0}]}`
+)
+
+// TestACLInterface tests that ACL is properly implemented
+func TestACLInterface(t *testing.T) {
+	t.Parallel()
+
+	assert.Implements(t, (*resource.Task)(nil), new(acl.ACL))
+	assert.Implements(t, (*resource.Resource)(nil), new(acl.Preparer))
+}
+
+func TestPrepare(t *testing.T) {
+	t.Parallel()
+
+	inherit := true
+	for name, test := range map[string]struct {
+		preparer *acl.Preparer
+		err      string
+	}{
+		"nothing to manage": {
+			&acl.Preparer{Destination: path},
+			`file.acl needs at least one of "owner", "grant", "deny", "inherit", or "sddl"`,
+		},
+		"sddl and entries": {
+			&acl.Preparer{Destination: path, SDDL: "D:(A;;FA;;;BA)", Inherit: &inherit},
+			`file.acl "sddl" can't be used with "grant", "deny", or "inherit"`,
+		},
+		"sddl with owner": {
+			&acl.Preparer{Destination: path, SDDL: "O:BAD:(A;;FA;;;BA)"},
+			`file.acl "sddl" must be an access control list starting with "D:", set the owner with "owner"`,
+		},
+		"bad entry": {
+			&acl.Preparer{Destination: path, Grant: []string{"Users"}},
+			`file.acl: access entry "Users" must be an account and rights, like "BUILTIN\Users:(OI)(CI)(RX)"`,
+		},
+	} {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			_, err := test.preparer.Prepare(fakerenderer.New())
+			assert.EqualError(t, err, test.err)
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	t.Parallel()
+
+	t.Run("matching", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect(windows.PowerShell(read)...).Return(current+"\r\n", 0)
+
+		status, err := prepare(t, fake, logs()).Check(fakerenderer.New())
+
+		require.NoError(t, err)
+		assert.False(t, status.HasChanges(), "%v", status.Diffs())
+	})
+
+	t.Run("changed", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect(windows.PowerShell(read)...).Return(changed, 0)
+
+		status, err := prepare(t, fake, logs()).Check(fakerenderer.New())
+
+		require.NoError(t, err)
+		diffs := status.Diffs()
+		assert.Equal(t, `CORP\jdoe`, diffs["owner"].Original())
+		assert.Equal(t, `BUILTIN\Administrators`, diffs["owner"].Current())
+		assert.Equal(t, "true", diffs["inherit"].Original())
+		assert.Equal(t, "false", diffs["inherit"].Current())
+		assert.Equal(t, `BUILTIN\Users:(OI)(CI)(M)`, diffs["access"].Original())
+		assert.Equal(t, `SYSTEM:(OI)(CI)(F), Users:(OI)(CI)(RX), deny contractors:(W)`, diffs["access"].Current())
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect(windows.PowerShell(read)...).Return("NotFound\r\n", 0)
+
+		status, err := prepare(t, fake, logs()).Check(fakerenderer.New())
+
+		require.NoError(t, err)
+		assert.Equal(t, resource.StatusFatal, status.StatusCode())
+		assert.EqualError(t, status.Error(), `cannot set access control list of C:\app\logs, it does not exist`)
+	})
+
+	t.Run("sddl", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect(windows.PowerShell(readSDDL)...).Return(`{"Owner":"BUILTIN\\Administrators","Protected":false,"Rules":[],"Sddl":"D:AI(A;OICIID;FA;;;SY)","Wanted":"D:PAI(A;OICI;FA;;;BA)"}`, 0)
+
+		status, err := prepare(t, fake, &acl.Preparer{Destination: path, SDDL: "D:PAI(A;OICI;FA;;;BA)"}).Check(fakerenderer.New())
+
+		require.NoError(t, err)
+		assert.Equal(t, "D:AI(A;OICIID;FA;;;SY)", status.Diffs()["sddl"].Original())
+		assert.Equal(t, "D:PAI(A;OICI;FA;;;BA)", status.Diffs()["sddl"].Current())
+	})
+}
+
+func TestApply(t *testing.T) {
+	t.Parallel()
+
+	t.Run("changed", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect(windows.PowerShell(read)...).Return(changed, 0)
+		fake.Expect("icacls.exe", path, "/setowner", `BUILTIN\Administrators`)
+		fake.Expect(windows.PowerShell(`$i = Get-Item -LiteralPath 'C:\app\logs'; $a = $i.GetAccessControl('Access'); ` +
+			"$a.SetAccessRuleProtection($true, $false); " +
+			"$a.GetAccessRules($true, $false, [System.Security.Principal.SecurityIdentifier]) | ForEach-Object { $a.RemoveAccessRuleSpecific($_) }; " +
+			"$a.AddAccessRule((New-Object System.Security.AccessControl.FileSystemAccessRule('SYSTEM', [System.Security.AccessControl.FileSystemRights]983551, [System.Security.AccessControl.InheritanceFlags]3, [System.Security.AccessControl.PropagationFlags]0, 'Allow'))); " +
+			"$a.AddAccessRule((New-Object System.Security.AccessControl.FileSystemAccessRule('Users', [System.Security.AccessControl.FileSystemRights]131241, [System.Security.AccessControl.InheritanceFlags]3, [System.Security.AccessControl.PropagationFlags]0, 'Allow'))); " +
+			"$a.AddAccessRule((New-Object System.Security.AccessControl.FileSystemAccessRule('contractors', [System.Security.AccessControl.FileSystemRights]278, [System.Security.AccessControl.InheritanceFlags]0, [System.Security.AccessControl.PropagationFlags]0, 'Deny'))); " +
+			"$i.SetAccessControl($a)")...)
+
+		_, err := prepare(t, fake, logs()).Apply()
+
+		require.NoError(t, err)
+		fake.AssertExpectations(t)
+	})
+
+	t.Run("unchanged", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect(windows.PowerShell(read)...).Return(current, 0)
+
+		_, err := prepare(t, fake, logs()).Apply()
+
+		require.NoError(t, err)
+		assert.Len(t, fake.Calls(), 1)
+	})
+
+	t.Run("sddl", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect(windows.PowerShell(readSDDL)...).Return(`{"Owner":"BUILTIN\\Administrators","Sddl":"D:AI(A;OICIID;FA;;;SY)","Wanted":"D:PAI(A;OICI;FA;;;BA)"}`, 0)
+		fake.Expect(windows.PowerShell(`$i = Get-Item -LiteralPath 'C:\app\logs'; $a = $i.GetAccessControl('Access'); ` +
+			"$a.SetSecurityDescriptorSddlForm('D:PAI(A;OICI;FA;;;BA)', 'Access'); $i.SetAccessControl($a)")...)
+
+		_, err := prepare(t, fake, &acl.Preparer{Destination: path, SDDL: "D:PAI(A;OICI;FA;;;BA)"}).Apply()
+
+		require.NoError(t, err)
+		fake.AssertExpectations(t)
+	})
+
+	t.Run("owner failure", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect(windows.PowerShell(read)...).Return(changed, 0)
+		fake.Expect("icacls.exe", path, "/setowner", `BUILTIN\Administrators`).Return("Access is denied.\r\nSuccessfully processed 0 files; Failed processing 1 files", 5)
+
+		_, err := prepare(t, fake, logs()).Apply()
+
+		assert.EqualError(t, err, `file.acl: could not set owner of C:\app\logs: icacls.exe: exit status 5: Access is denied.`+"\r\n"+`Successfully processed 0 files; Failed processing 1 files`)
+	})
+}
+
+func logs() *acl.Preparer {
+	inherit := false
+	return &acl.Preparer{
+		Destination: path,
+		Owner:       `BUILTIN\Administrators`,
+		Grant:       []string{"SYSTEM:(OI)(CI)(F)", "Users:(OI)(CI)(RX)"},
+		Deny:        []string{"contractors:(W)"},
+		Inherit:     &inherit,
+	}
+}
+
+func prepare(t *testing.T, fake *fakeexec.Executor, p *acl.Preparer) *acl.ACL {
+	task, err := p.Prepare(&resource.ExecRenderer{Renderer: fakerenderer.New(), Exec: fake})
+	require.NoError(t, err)
+	return task.(*acl.ACL)
+}
@@ -0,0 +1,101 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package acl
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/load/registry"
+	"github.com/asteris-llc/converge/resource"
+)
+
+// Preparer for file ACL
+//
+// ACL manages the owner and access control list of a file or directory on
+// Windows, where `file.mode` has no meaning. Entries are written the way
+// icacls writes them, like "BUILTIN\\Users:(OI)(CI)(RX)", or the whole list
+// can be given in SDDL form.
+type Preparer struct {
+	// Destination is the file or directory to manage. It must exist.
+	Destination string `hcl:"destination" required:"true" nonempty:"true"`
+
+	// Owner is the account that should own the file, like
+	// "BUILTIN\\Administrators"
+	Owner string `hcl:"owner"`
+
+	// Grant are the accounts allowed access, each followed by inheritance
+	// flags and rights: (OI) object inherit, (CI) container inherit, (IO)
+	// inherit only, (NP) don't propagate, and rights F (full), M (modify), RX
+	// (read and execute), R (read), W (write), or D (delete). Together with
+	// Deny, they replace every entry that isn't inherited.
+	Grant []string `hcl:"grant"`
+
+	// Deny are the accounts denied access, written like Grant
+	Deny []string `hcl:"deny"`
+
+	// Inherit is whether the file inherits entries from its parent. When it is
+	// turned off, the inherited entries are removed rather than copied.
+	Inherit *bool `hcl:"inherit"`
+
+	// SDDL is the access control list in SDDL form, as shown by
+	// `(Get-Acl path).Sddl` after "D:". It includes inherited entries unless
+	// it starts with "D:P". It can't be used with grant, deny, or inherit.
+	SDDL string `hcl:"sddl"`
+}
+
+// Prepare a new ACL
+func (p *Preparer) Prepare(render resource.Renderer) (resource.Task, error) {
+	if p.SDDL != "" && (len(p.Grant) > 0 || len(p.Deny) > 0 || p.Inherit != nil) {
+		return nil, fmt.Errorf(`file.acl "sddl" can't be used with "grant", "deny", or "inherit"`)
+	}
+
+	if p.SDDL != "" && !strings.HasPrefix(p.SDDL, "D:") {
+		return nil, fmt.Errorf(`file.acl "sddl" must be an access control list starting with "D:", set the owner with "owner"`)
+	}
+
+	if p.Owner == "" && p.SDDL == "" && len(p.Grant) == 0 && len(p.Deny) == 0 && p.Inherit == nil {
+		return nil, fmt.Errorf(`file.acl needs at least one of "owner", "grant", "deny", "inherit", or "sddl"`)
+	}
+
+	acl := &ACL{
+		Destination: p.Destination,
+		Owner:       p.Owner,
+		Inherit:     p.Inherit,
+		SDDL:        p.SDDL,
+		exec:        exec.For(render),
+	}
+
+	for _, list := range []struct {
+		texts []string
+		deny  bool
+	}{{p.Grant, false}, {p.Deny, true}} {
+		for _, text := range list.texts {
+			entry, err := ParseACE(text, list.deny)
+			if err != nil {
+				return nil, fmt.Errorf("file.acl: %s", err)
+			}
+			acl.Entries = append(acl.Entries, entry)
+		}
+	}
+
+	return acl, nil
+}
+
+func init() {
+	registry.Register("file.acl", (*Preparer)(nil), (*ACL)(nil))
+	registry.RegisterPlatforms("file.acl", "windows")
+}
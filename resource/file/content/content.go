@@ -18,23 +18,64 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"strconv"
 
+	"github.com/asteris-llc/converge/helpers/accounts"
+	"github.com/asteris-llc/converge/helpers/atomicfile"
+	"github.com/asteris-llc/converge/helpers/filemode"
+	"github.com/asteris-llc/converge/helpers/patch"
 	"github.com/asteris-llc/converge/resource"
+	"github.com/pkg/errors"
 )
 
 // Content renders content to disk
 type Content struct {
 	Content     string
 	Destination string
+
+	// Patch, if set, is a unified diff that Content is made from by applying
+	// it to the existing content of Destination
+	Patch string
+
+	// Opaque is true if Content came from a source rather than a template. It
+	// may be binary, so differences are shown as checksums.
+	Opaque bool
+
+	// Owner and Group, if set, are the user and group Destination should be
+	// owned by, as names or IDs
+	Owner string
+	Group string
+
+	// UID and GID are the IDs Owner and Group resolve to, so that templates
+	// can use them. They are empty if Owner or Group are not set.
+	UID string
+	GID string
+
+	// Mode, if set, is the mode Destination should have
+	Mode *filemode.Spec
+
 	*resource.Status
+
+	// previous holds what Destination contained before Apply wrote it, or nil
+	// if Apply created it, and previousMode the mode it had. They are only set
+	// once Apply has run.
+	previous     *string
+	previousMode os.FileMode
+	applied      bool
 }
 
 // Check if the content needs to be rendered
 func (t *Content) Check(resource.Renderer) (resource.TaskStatus, error) {
 	diffs := make(map[string]resource.Diff)
-	contentDiff := resource.TextDiff{Values: [2]string{"", t.Content}}
+	contentDiff := resource.TextDiff{Values: [2]string{"", t.describe(t.Content)}}
 	stat, err := os.Stat(t.Destination)
-	if os.IsNotExist(err) {
+	if os.IsNotExist(err) && t.Patch != "" {
+		t.Status = &resource.Status{
+			Level:  resource.StatusCantChange,
+			Output: []string{t.Destination + ": File is missing"},
+		}
+		return t, fmt.Errorf("cannot patch %q, it does not exist", t.Destination)
+	} else if os.IsNotExist(err) {
 		contentDiff.Values[0] = "<file-missing>"
 		diffs[t.Destination] = contentDiff
 		t.Status = &resource.Status{
@@ -63,11 +104,31 @@ func (t *Content) Check(resource.Renderer) (resource.TaskStatus, error) {
 		return t, err
 	}
 
+	if err := t.applyPatch(string(actual)); err != nil {
+		t.Status = &resource.Status{
+			Level:  resource.StatusCantChange,
+			Output: []string{err.Error()},
+		}
+		return t, err
+	}
+
+	if err := t.ownerDiffs(stat, diffs); err != nil {
+		t.Status = &resource.Status{
+			Level:  resource.StatusFatal,
+			Output: []string{err.Error()},
+		}
+		return t, err
+	}
+
+	if perm := t.perm(stat.Mode()); perm != stat.Mode()&filemode.Mask {
+		diffs["mode"] = resource.TextDiff{Values: [2]string{describeMode(stat.Mode()), describeMode(perm)}}
+	}
+
 	statusMessage := "OK"
 
 	if string(actual) != t.Content {
 		statusMessage = "contents differ"
-		diffs[t.Destination] = resource.TextDiff{Values: [2]string{string(actual), t.Content}}
+		diffs[t.Destination] = resource.TextDiff{Values: [2]string{t.describe(string(actual)), t.describe(t.Content)}}
 	}
 
 	t.Status = &resource.Status{
@@ -85,26 +146,59 @@ func (t *Content) Apply() (resource.TaskStatus, error) {
 
 	stat, err := os.Stat(t.Destination)
 	if os.IsNotExist(err) {
-		diffs["mode"] = resource.TextDiff{Values: [2]string{"not set", "0600"}}
-		perm = 0600
+		perm = t.perm(0600)
+		diffs["mode"] = resource.TextDiff{Values: [2]string{"not set", describeMode(perm)}}
 	} else if err != nil {
 		return &resource.Status{
 			Level:  resource.StatusFatal,
 			Output: []string{err.Error()},
 		}, err
 	} else {
-		perm = stat.Mode()
+		perm = t.perm(stat.Mode())
+		t.previousMode = stat.Mode() & filemode.Mask
+		if current := stat.Mode() & filemode.Mask; perm != current {
+			diffs["mode"] = resource.TextDiff{Values: [2]string{describeMode(current), describeMode(perm)}}
+		}
 	}
 
+	var previous *string
 	if rawData, readErr := ioutil.ReadFile(t.Destination); readErr != nil {
 		preChange = "<file-missing>"
 	} else {
-		preChange = string(rawData)
+		data := string(rawData)
+		previous = &data
+		preChange = t.describe(data)
 	}
 
-	diffs[t.Destination] = resource.TextDiff{Values: [2]string{preChange, t.Content}}
+	if t.Patch != "" && previous == nil {
+		err := fmt.Errorf("cannot patch %q, it does not exist", t.Destination)
+		t.Status = &resource.Status{
+			Output: []string{err.Error()},
+			Level:  resource.StatusFatal,
+		}
+		return t, err
+	} else if previous != nil {
+		if err := t.applyPatch(*previous); err != nil {
+			t.Status = &resource.Status{
+				Output: []string{err.Error()},
+				Level:  resource.StatusFatal,
+			}
+			return t, err
+		}
+	}
+
+	diffs[t.Destination] = resource.TextDiff{Values: [2]string{preChange, t.describe(t.Content)}}
+
+	uid, gid, err := t.resolveOwner()
+	if err != nil {
+		t.Status = &resource.Status{
+			Output: []string{err.Error()},
+			Level:  resource.StatusFatal,
+		}
+		return t, err
+	}
 
-	if err = ioutil.WriteFile(t.Destination, []byte(t.Content), perm); err != nil {
+	if err = atomicfile.WriteOwned(t.Destination, []byte(t.Content), perm, uid, gid); err != nil {
 		t.Status = &resource.Status{
 			Output:      []string{err.Error()},
 			Level:       resource.StatusFatal,
@@ -113,6 +207,113 @@ func (t *Content) Apply() (resource.TaskStatus, error) {
 		return t, err
 	}
 
+	t.previous = previous
+	t.applied = true
+
 	t.Status = &resource.Status{Differences: diffs}
 	return t, nil
 }
+
+// applyPatch sets Content to the result of applying Patch to the existing
+// content, if Patch is set
+func (t *Content) applyPatch(existing string) error {
+	if t.Patch == "" {
+		return nil
+	}
+
+	patched, err := patch.Apply(existing, t.Patch)
+	if err != nil {
+		return errors.Wrapf(err, "cannot patch %s", t.Destination)
+	}
+
+	t.Content = patched
+	return nil
+}
+
+// perm returns the mode Destination should have, given its current mode
+func (t *Content) perm(current os.FileMode) os.FileMode {
+	if t.Mode == nil {
+		return current & filemode.Mask
+	}
+	return t.Mode.Apply(current) & filemode.Mask
+}
+
+// describeMode returns a mode as it is shown in diffs
+func describeMode(mode os.FileMode) string {
+	return filemode.Octal(mode).String()
+}
+
+// resolveOwner looks up the IDs of Owner and Group. Either is -1 if it is not
+// set.
+func (t *Content) resolveOwner() (uid, gid int, err error) {
+	uid, gid, err = accounts.For(nil).Owner(t.Owner, t.Group)
+	if err != nil {
+		return -1, -1, err
+	}
+
+	if uid >= 0 {
+		t.UID = strconv.Itoa(uid)
+	}
+	if gid >= 0 {
+		t.GID = strconv.Itoa(gid)
+	}
+	return uid, gid, nil
+}
+
+// ownerDiffs adds a difference for the owner and group of the existing file
+// if they aren't the ones asked for
+func (t *Content) ownerDiffs(stat os.FileInfo, diffs map[string]resource.Diff) error {
+	uid, gid, err := t.resolveOwner()
+	if err != nil {
+		return err
+	}
+
+	actualUID, actualGID, ok := statOwner(stat)
+	if !ok {
+		return nil
+	}
+
+	if actual := strconv.Itoa(actualUID); uid >= 0 && actual != t.UID {
+		diffs["owner"] = resource.TextDiff{Values: [2]string{actual, t.UID}}
+	}
+	if actual := strconv.Itoa(actualGID); gid >= 0 && actual != t.GID {
+		diffs["group"] = resource.TextDiff{Values: [2]string{actual, t.GID}}
+	}
+	return nil
+}
+
+// describe returns content as it is shown in diffs
+func (t *Content) describe(content string) string {
+	if t.Opaque {
+		return "sha256:" + checksum([]byte(content))
+	}
+	return content
+}
+
+// ManagedFiles returns the destination, so it is backed up before being
+// changed
+func (t *Content) ManagedFiles() []string {
+	return []string{t.Destination}
+}
+
+// Rollback restores the content Destination had before Apply, or removes it
+// if Apply created it
+func (t *Content) Rollback() (resource.TaskStatus, error) {
+	if !t.applied {
+		return &resource.Status{}, nil
+	}
+
+	if t.previous == nil {
+		if err := os.Remove(t.Destination); err != nil && !os.IsNotExist(err) {
+			return &resource.Status{Level: resource.StatusFatal, Output: []string{err.Error()}}, err
+		}
+		t.applied = false
+		return &resource.Status{Output: []string{"removed " + t.Destination}}, nil
+	}
+
+	if err := atomicfile.Write(t.Destination, []byte(*t.previous), t.previousMode); err != nil {
+		return &resource.Status{Level: resource.StatusFatal, Output: []string{err.Error()}}, err
+	}
+	t.applied = false
+	return &resource.Status{Output: []string{"restored " + t.Destination}}, nil
+}
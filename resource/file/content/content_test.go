@@ -18,9 +18,11 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"os/user"
 	"testing"
 
 	"github.com/asteris-llc/converge/helpers/fakerenderer"
+	"github.com/asteris-llc/converge/helpers/filemode"
 	"github.com/asteris-llc/converge/resource"
 	"github.com/asteris-llc/converge/resource/file/content"
 	"github.com/stretchr/testify/assert"
@@ -135,6 +137,59 @@ func TestContentCheckSetsDiffs(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestContentCheckOpaque(t *testing.T) {
+	tmpfile, err := ioutil.TempFile("", "test-check-content-opaque")
+	require.NoError(t, err)
+	defer func() { require.NoError(t, os.RemoveAll(tmpfile.Name())) }()
+
+	_, err = tmpfile.Write([]byte{0, 1, 2})
+	require.NoError(t, err)
+	require.NoError(t, tmpfile.Sync())
+
+	tmpl := content.Content{
+		Destination: tmpfile.Name(),
+		Content:     string([]byte{0, 1, 3}),
+		Opaque:      true,
+	}
+
+	status, err := tmpl.Check(fakerenderer.New())
+	require.NoError(t, err)
+	assert.True(t, status.HasChanges())
+
+	fileDiff := status.Diffs()[tmpfile.Name()]
+	assert.Equal(t, "sha256:ae4b3280e56e2faf83f414a6e3dabe9d5fbe18976544c05fed121accb85b53fc", fileDiff.Original())
+	assert.Equal(t, "sha256:b744d600fbe3853702978ec726c166d26274fe7b09b2c600ddf2d7d895667b24", fileDiff.Current())
+}
+
+func TestContentOwner(t *testing.T) {
+	tmpfile, err := ioutil.TempFile("", "test-content-owner")
+	require.NoError(t, err)
+	defer os.Remove(tmpfile.Name())
+
+	current, err := user.Current()
+	require.NoError(t, err)
+
+	tmpl := content.Content{
+		Destination: tmpfile.Name(),
+		Content:     "owned",
+		Owner:       current.Username,
+		Group:       current.Gid,
+	}
+
+	_, err = tmpl.Apply()
+	require.NoError(t, err)
+	assert.Equal(t, current.Uid, tmpl.UID)
+	assert.Equal(t, current.Gid, tmpl.GID)
+
+	status, err := tmpl.Check(fakerenderer.New())
+	require.NoError(t, err)
+	assert.False(t, status.HasChanges())
+
+	tmpl.Owner = "converge-no-such-user"
+	_, err = tmpl.Check(fakerenderer.New())
+	assert.Error(t, err)
+}
+
 func TestContentApply(t *testing.T) {
 	tmpfile, err := ioutil.TempFile("", "test-check-empty-file")
 	require.NoError(t, err)
@@ -197,3 +252,143 @@ func TestContentApplyKeepPermission(t *testing.T) {
 
 	assert.Equal(t, perm, stat.Mode().Perm())
 }
+
+func TestContentMode(t *testing.T) {
+	tmpfile, err := ioutil.TempFile("", "test-content-mode")
+	require.NoError(t, err)
+	defer func() { require.NoError(t, os.Remove(tmpfile.Name())) }()
+
+	spec, err := filemode.Parse("g+r")
+	require.NoError(t, err)
+
+	tmpl := content.Content{
+		Destination: tmpfile.Name(),
+		Mode:        spec,
+	}
+
+	status, err := tmpl.Check(fakerenderer.New())
+	require.NoError(t, err)
+	require.Contains(t, status.Diffs(), "mode")
+	assert.Equal(t, "0600", status.Diffs()["mode"].Original())
+	assert.Equal(t, "0640", status.Diffs()["mode"].Current())
+
+	_, err = tmpl.Apply()
+	require.NoError(t, err)
+
+	stat, err := os.Stat(tmpfile.Name())
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0640), stat.Mode().Perm())
+
+	status, err = tmpl.Check(fakerenderer.New())
+	require.NoError(t, err)
+	assert.False(t, status.HasChanges())
+
+	_, err = tmpl.Rollback()
+	require.NoError(t, err)
+
+	stat, err = os.Stat(tmpfile.Name())
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), stat.Mode().Perm())
+}
+
+func TestContentRollback(t *testing.T) {
+	t.Run("restores contents", func(t *testing.T) {
+		tmpfile, err := ioutil.TempFile("", "test-content-rollback")
+		require.NoError(t, err)
+		defer func() { require.NoError(t, os.Remove(tmpfile.Name())) }()
+
+		require.NoError(t, ioutil.WriteFile(tmpfile.Name(), []byte("old"), 0600))
+
+		tmpl := content.Content{
+			Destination: tmpfile.Name(),
+			Content:     "new",
+		}
+
+		_, err = tmpl.Apply()
+		require.NoError(t, err)
+
+		_, err = tmpl.Rollback()
+		require.NoError(t, err)
+
+		content, err := ioutil.ReadFile(tmpfile.Name())
+		assert.NoError(t, err)
+		assert.Equal(t, "old", string(content))
+	})
+
+	t.Run("removes created file", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "test-content-rollback")
+		require.NoError(t, err)
+		defer os.RemoveAll(dir)
+
+		tmpl := content.Content{
+			Destination: dir + "/created",
+			Content:     "new",
+		}
+
+		_, err = tmpl.Apply()
+		require.NoError(t, err)
+
+		_, err = tmpl.Rollback()
+		require.NoError(t, err)
+
+		_, err = os.Stat(tmpl.Destination)
+		assert.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("not applied", func(t *testing.T) {
+		tmpl := content.Content{Destination: "/nonexistent/file", Content: "new"}
+
+		_, err := tmpl.Rollback()
+		assert.NoError(t, err)
+	})
+}
+
+func TestContentPatch(t *testing.T) {
+	diff := "@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n"
+
+	t.Run("applies", func(t *testing.T) {
+		tmpfile, err := ioutil.TempFile("", "test-content-patch")
+		require.NoError(t, err)
+		defer os.Remove(tmpfile.Name())
+
+		require.NoError(t, ioutil.WriteFile(tmpfile.Name(), []byte("a\nb\nc\nd\n"), 0600))
+
+		tmpl := content.Content{Destination: tmpfile.Name(), Patch: diff}
+
+		status, err := tmpl.Check(fakerenderer.New())
+		require.NoError(t, err)
+		assert.True(t, status.HasChanges())
+		assert.Equal(t, "a\nB\nc\nd\n", status.Diffs()[tmpfile.Name()].Current())
+
+		_, err = tmpl.Apply()
+		require.NoError(t, err)
+
+		written, err := ioutil.ReadFile(tmpfile.Name())
+		require.NoError(t, err)
+		assert.Equal(t, "a\nB\nc\nd\n", string(written))
+
+		status, err = tmpl.Check(fakerenderer.New())
+		require.NoError(t, err)
+		assert.False(t, status.HasChanges())
+	})
+
+	t.Run("does not apply", func(t *testing.T) {
+		tmpfile, err := ioutil.TempFile("", "test-content-patch")
+		require.NoError(t, err)
+		defer os.Remove(tmpfile.Name())
+
+		require.NoError(t, ioutil.WriteFile(tmpfile.Name(), []byte("x\ny\nz\n"), 0600))
+
+		tmpl := content.Content{Destination: tmpfile.Name(), Patch: diff}
+
+		_, err = tmpl.Check(fakerenderer.New())
+		assert.EqualError(t, err, "cannot patch "+tmpfile.Name()+": hunk 1 does not apply: @@ -1,3 +1,3 @@")
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		tmpl := content.Content{Destination: "/nonexistent/file", Patch: diff}
+
+		_, err := tmpl.Check(fakerenderer.New())
+		assert.EqualError(t, err, `cannot patch "/nonexistent/file", it does not exist`)
+	})
+}
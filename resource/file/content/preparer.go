@@ -15,8 +15,19 @@
 package content
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/asteris-llc/converge/fetch"
+	"github.com/asteris-llc/converge/helpers/filemode"
 	"github.com/asteris-llc/converge/load/registry"
 	"github.com/asteris-llc/converge/resource"
+	"github.com/pkg/errors"
 )
 
 // Preparer for Content
@@ -24,20 +35,123 @@ import (
 // Content renders content to disk
 type Preparer struct {
 	// Content is the file content. This will be rendered as a template.
-	Content string `hcl:"content"`
+	Content string `hcl:"content" mutually_exclusive:"content,source_file,source_url,base64,patch"`
+
+	// SourceFile is a file on disk to copy the content from. The file is not
+	// rendered as a template, so it may hold binary content.
+	SourceFile string `hcl:"source_file" mutually_exclusive:"content,source_file,source_url,base64,patch"`
+
+	// SourceURL is a URL to download the content from, over http, https or
+	// file. Like SourceFile, the content is not rendered as a template.
+	SourceURL string `hcl:"source_url" mutually_exclusive:"content,source_file,source_url,base64,patch"`
+
+	// Base64 is the content encoded as base64, for small binary files that
+	// can be kept in the module.
+	Base64 string `hcl:"base64" mutually_exclusive:"content,source_file,source_url,base64,patch"`
+
+	// Patch is a unified diff to apply to the existing content of
+	// Destination, such as the output of `diff -u`, so that a few lines of a
+	// large file can be managed without keeping the whole file in the module.
+	// Hunks that have already been applied are skipped, and the file must
+	// exist. This will be rendered as a template.
+	Patch string `hcl:"patch" mutually_exclusive:"content,source_file,source_url,base64,patch"`
+
+	// Checksum is the SHA256 checksum of the content, as a hex string. If set,
+	// content from SourceFile, SourceURL or Base64 must match it.
+	Checksum string `hcl:"checksum"`
 
 	// Destination is the location on disk where the content will be rendered.
 	Destination string `hcl:"destination"`
+
+	// Owner is the user that owns the file, as a name or uid. If not set, a
+	// new file is owned by the user running converge and an existing file
+	// keeps its owner. The resolved uid is available to templates as `UID`.
+	Owner string `hcl:"owner"`
+
+	// Group is the group that owns the file, as a name or gid. The resolved
+	// gid is available to templates as `GID`.
+	Group string `hcl:"group"`
+
+	// Mode is the mode of the file, in any of the forms `file.mode` accepts.
+	// If not set, a new file is created with mode 0600 and an existing file
+	// keeps its mode. Symbolic modes are worked out from the current mode of
+	// the file, or from 0600 for a new file.
+	Mode interface{} `hcl:"mode"`
 }
 
 // Prepare a new task
 func (p *Preparer) Prepare(render resource.Renderer) (resource.Task, error) {
-	return &Content{
+	task := &Content{
 		Destination: p.Destination,
 		Content:     p.Content,
-	}, nil
+		Patch:       p.Patch,
+		Owner:       p.Owner,
+		Group:       p.Group,
+	}
+
+	if p.Mode != nil {
+		spec, err := filemode.FromValue(p.Mode)
+		if err != nil {
+			return nil, err
+		}
+		task.Mode = spec
+	}
+
+	// resolve the IDs now if possible, so that templates can use them. If the
+	// user or group is created by another resource in the same run, they are
+	// resolved when the content is checked.
+	task.resolveOwner()
+
+	if p.SourceFile == "" && p.SourceURL == "" && p.Base64 == "" {
+		if p.Checksum != "" {
+			return nil, errors.New("checksum can only be set with source_file, source_url or base64")
+		}
+		return task, nil
+	}
+
+	content, err := p.source()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.Checksum != "" {
+		if actual := checksum(content); actual != strings.ToLower(p.Checksum) {
+			return nil, fmt.Errorf("checksum of content is %s, expected %s", actual, p.Checksum)
+		}
+	}
+
+	task.Content = string(content)
+	task.Opaque = true
+
+	return task, nil
 }
 
+// source reads the content from whichever of the sources is set
+func (p *Preparer) source() ([]byte, error) {
+	switch {
+	case p.SourceFile != "":
+		content, err := ioutil.ReadFile(p.SourceFile)
+		return content, errors.Wrap(err, "could not read source_file")
+
+	case p.SourceURL != "":
+		content, err := fetch.Any(context.Background(), p.SourceURL)
+		return content, errors.Wrap(err, "could not fetch source_url")
+
+	default:
+		content, err := base64.StdEncoding.DecodeString(strings.TrimSpace(p.Base64))
+		return content, errors.Wrap(err, "could not decode base64")
+	}
+}
+
+// checksum returns the hex SHA256 checksum of content
+func checksum(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// HostOnly marks file.content as unable to run in a target, since it writes files with syscalls
+func (p *Preparer) HostOnly() {}
+
 func init() {
 	registry.Register("file.content", (*Preparer)(nil), (*Content)(nil))
 }
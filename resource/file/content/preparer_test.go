@@ -15,11 +15,16 @@
 package content_test
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/asteris-llc/converge/helpers/fakerenderer"
 	"github.com/asteris-llc/converge/resource"
 	"github.com/asteris-llc/converge/resource/file/content"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestPreparerInterface(t *testing.T) {
@@ -27,3 +32,81 @@ func TestPreparerInterface(t *testing.T) {
 
 	assert.Implements(t, (*resource.Resource)(nil), new(content.Preparer))
 }
+
+func TestPreparerSources(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "converge-content-source")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	source := filepath.Join(dir, "source")
+	require.NoError(t, ioutil.WriteFile(source, []byte("hello"), 0600))
+
+	hello := "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+
+	t.Run("content", func(t *testing.T) {
+		task, err := (&content.Preparer{Content: "{{hello}}"}).Prepare(fakerenderer.New())
+		require.NoError(t, err)
+		assert.Equal(t, "{{hello}}", task.(*content.Content).Content)
+		assert.False(t, task.(*content.Content).Opaque)
+	})
+
+	for name, prep := range map[string]*content.Preparer{
+		"source_file": {SourceFile: source},
+		"source_url":  {SourceURL: "file://" + source},
+		"base64":      {Base64: "aGVsbG8="},
+		"checksum":    {Base64: "aGVsbG8=", Checksum: hello},
+	} {
+		prep := prep
+		t.Run(name, func(t *testing.T) {
+			task, err := prep.Prepare(fakerenderer.New())
+			require.NoError(t, err)
+			assert.Equal(t, "hello", task.(*content.Content).Content)
+			assert.True(t, task.(*content.Content).Opaque)
+		})
+	}
+
+	t.Run("bad checksum", func(t *testing.T) {
+		_, err := (&content.Preparer{SourceFile: source, Checksum: "abc"}).Prepare(fakerenderer.New())
+		assert.EqualError(t, err, "checksum of content is "+hello+", expected abc")
+	})
+
+	t.Run("checksum without source", func(t *testing.T) {
+		_, err := (&content.Preparer{Content: "hello", Checksum: hello}).Prepare(fakerenderer.New())
+		assert.Error(t, err)
+	})
+
+	t.Run("missing source_file", func(t *testing.T) {
+		_, err := (&content.Preparer{SourceFile: filepath.Join(dir, "missing")}).Prepare(fakerenderer.New())
+		assert.Error(t, err)
+	})
+}
+
+func TestPreparerMode(t *testing.T) {
+	t.Parallel()
+
+	t.Run("number", func(t *testing.T) {
+		task, err := (&content.Preparer{Content: "hello", Mode: 644}).Prepare(fakerenderer.New())
+		require.NoError(t, err)
+		require.NotNil(t, task.(*content.Content).Mode)
+		assert.Equal(t, os.FileMode(0644), task.(*content.Content).Mode.Apply(0))
+	})
+
+	t.Run("symbolic", func(t *testing.T) {
+		task, err := (&content.Preparer{Content: "hello", Mode: "u+x"}).Prepare(fakerenderer.New())
+		require.NoError(t, err)
+		assert.Equal(t, "u+x", task.(*content.Content).Mode.String())
+	})
+
+	t.Run("unset", func(t *testing.T) {
+		task, err := (&content.Preparer{Content: "hello"}).Prepare(fakerenderer.New())
+		require.NoError(t, err)
+		assert.Nil(t, task.(*content.Content).Mode)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := (&content.Preparer{Content: "hello", Mode: 999}).Prepare(fakerenderer.New())
+		assert.Error(t, err)
+	})
+}
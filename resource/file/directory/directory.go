@@ -16,10 +16,12 @@ package directory
 
 import (
 	"fmt"
-	"path"
-
 	"os"
+	"path"
+	"strconv"
 
+	"github.com/asteris-llc/converge/helpers/accounts"
+	"github.com/asteris-llc/converge/helpers/filemode"
 	"github.com/asteris-llc/converge/resource"
 	"github.com/pkg/errors"
 )
@@ -30,6 +32,19 @@ type Directory struct {
 
 	Destination string
 	CreateAll   bool
+
+	// Owner and Group, if set, are the user and group Destination should be
+	// owned by, as names or IDs
+	Owner string
+	Group string
+
+	// UID and GID are the IDs Owner and Group resolve to, so that templates
+	// can use them. They are empty if Owner or Group are not set.
+	UID string
+	GID string
+
+	// Mode, if set, is the mode Destination should have
+	Mode *filemode.Spec
 }
 
 // Check if the directory exists
@@ -42,7 +57,7 @@ func (d *Directory) Check(resource.Renderer) (resource.TaskStatus, error) {
 
 		switch {
 		case err != nil && !os.IsNotExist(err):
-			return status, errors.Wrapf(err, "could not stat %q")
+			return status, errors.Wrapf(err, "could not stat %q", dest)
 
 		case os.IsNotExist(err):
 			// if we aren't told to create everything, we should fail early
@@ -64,6 +79,13 @@ func (d *Directory) Check(resource.Renderer) (resource.TaskStatus, error) {
 
 		default:
 			status.RaiseLevel(resource.StatusNoChange)
+			if dest == d.Destination {
+				if err := d.checkOwner(stat, status); err != nil {
+					status.RaiseLevel(resource.StatusFatal)
+					return status, err
+				}
+				d.checkMode(stat, status)
+			}
 			if !status.HasChanges() {
 				status.AddMessage(fmt.Sprintf("%q already exists", dest))
 			}
@@ -82,9 +104,15 @@ func (d *Directory) Check(resource.Renderer) (resource.TaskStatus, error) {
 func (d *Directory) Apply() (resource.TaskStatus, error) {
 	var err error
 
-	if d.CreateAll {
+	stat, statErr := os.Stat(d.Destination)
+	switch {
+	case statErr == nil && !stat.IsDir():
+		err = fmt.Errorf("%q already exists and is not a directory", d.Destination)
+	case statErr == nil:
+		// the directory is already there and only its owner or mode changes
+	case d.CreateAll:
 		err = os.MkdirAll(d.Destination, 0700)
-	} else {
+	default:
 		err = os.Mkdir(d.Destination, 0700)
 	}
 
@@ -92,6 +120,27 @@ func (d *Directory) Apply() (resource.TaskStatus, error) {
 		return nil, err
 	}
 
+	uid, gid, err := d.resolveOwner()
+	if err != nil {
+		return nil, err
+	}
+
+	if uid >= 0 || gid >= 0 {
+		if err := os.Chown(d.Destination, uid, gid); err != nil {
+			return nil, errors.Wrapf(err, "could not set the owner of %q", d.Destination)
+		}
+	}
+
+	if d.Mode != nil {
+		stat, err := os.Stat(d.Destination)
+		if err != nil {
+			return nil, err
+		}
+		if err := os.Chmod(d.Destination, d.Mode.Apply(stat.Mode())&filemode.Mask); err != nil {
+			return nil, errors.Wrapf(err, "could not set the mode of %q", d.Destination)
+		}
+	}
+
 	status := resource.NewStatus()
 	status.RaiseLevel(resource.StatusWillChange)
 	status.AddMessage(fmt.Sprintf("%q exists", d.Destination))
@@ -99,3 +148,58 @@ func (d *Directory) Apply() (resource.TaskStatus, error) {
 
 	return d, err
 }
+
+// resolveOwner looks up the IDs of Owner and Group. Either is -1 if it is not
+// set.
+func (d *Directory) resolveOwner() (uid, gid int, err error) {
+	uid, gid, err = accounts.For(nil).Owner(d.Owner, d.Group)
+	if err != nil {
+		return -1, -1, err
+	}
+
+	if uid >= 0 {
+		d.UID = strconv.Itoa(uid)
+	}
+	if gid >= 0 {
+		d.GID = strconv.Itoa(gid)
+	}
+	return uid, gid, nil
+}
+
+// checkOwner adds a difference for the owner and group of the existing
+// directory if they aren't the ones asked for
+func (d *Directory) checkOwner(stat os.FileInfo, status *resource.Status) error {
+	uid, gid, err := d.resolveOwner()
+	if err != nil {
+		return err
+	}
+
+	actualUID, actualGID, ok := statOwner(stat)
+	if !ok {
+		return nil
+	}
+
+	if actual := strconv.Itoa(actualUID); uid >= 0 && actual != d.UID {
+		status.RaiseLevel(resource.StatusWillChange)
+		status.AddDifference("owner", actual, d.UID, "")
+	}
+	if actual := strconv.Itoa(actualGID); gid >= 0 && actual != d.GID {
+		status.RaiseLevel(resource.StatusWillChange)
+		status.AddDifference("group", actual, d.GID, "")
+	}
+	return nil
+}
+
+// checkMode adds a difference for the mode of the existing directory if it
+// isn't the one asked for
+func (d *Directory) checkMode(stat os.FileInfo, status *resource.Status) {
+	if d.Mode == nil {
+		return
+	}
+
+	actual := stat.Mode() & filemode.Mask
+	if expected := d.Mode.Apply(stat.Mode()) & filemode.Mask; actual != expected {
+		status.RaiseLevel(resource.StatusWillChange)
+		status.AddDifference("mode", filemode.Octal(actual).String(), filemode.Octal(expected).String(), "")
+	}
+}
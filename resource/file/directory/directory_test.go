@@ -18,10 +18,12 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"os/user"
 	"path"
 	"testing"
 
 	"github.com/asteris-llc/converge/helpers/fakerenderer"
+	"github.com/asteris-llc/converge/helpers/filemode"
 	"github.com/asteris-llc/converge/resource"
 	"github.com/asteris-llc/converge/resource/file/directory"
 	"github.com/stretchr/testify/assert"
@@ -183,3 +185,80 @@ func TestDirectoryApply(t *testing.T) {
 		require.Error(t, err)
 	})
 }
+
+func TestDirectoryOwner(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "converge-directory-owner")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	current, err := user.Current()
+	require.NoError(t, err)
+
+	t.Run("by name", func(t *testing.T) {
+		dir := directory.Directory{Destination: tmpDir, Owner: current.Username}
+
+		plan, err := dir.Check(fakerenderer.New())
+		require.NoError(t, err)
+		assert.False(t, plan.HasChanges())
+		assert.Equal(t, current.Uid, dir.UID)
+	})
+
+	t.Run("apply", func(t *testing.T) {
+		dest := path.Join(tmpDir, "owned")
+		dir := directory.Directory{Destination: dest, Owner: current.Uid, Group: current.Gid}
+
+		_, err := dir.Apply()
+		require.NoError(t, err)
+
+		plan, err := dir.Check(fakerenderer.New())
+		require.NoError(t, err)
+		assert.False(t, plan.HasChanges())
+		assert.Equal(t, current.Gid, dir.GID)
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		dir := directory.Directory{Destination: tmpDir, Owner: "converge-no-such-user"}
+
+		_, err := dir.Check(fakerenderer.New())
+		assert.Error(t, err)
+	})
+}
+
+func TestDirectoryMode(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "converge-directory-mode")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	t.Run("existing", func(t *testing.T) {
+		require.NoError(t, os.Chmod(tmpDir, 0700))
+		dir := directory.Directory{Destination: tmpDir, Mode: filemode.Octal(0755)}
+
+		plan, err := dir.Check(fakerenderer.New())
+		require.NoError(t, err)
+		require.Contains(t, plan.Diffs(), "mode")
+		assert.Equal(t, "0700", plan.Diffs()["mode"].Original())
+		assert.Equal(t, "0755", plan.Diffs()["mode"].Current())
+
+		_, err = dir.Apply()
+		require.NoError(t, err)
+
+		plan, err = dir.Check(fakerenderer.New())
+		require.NoError(t, err)
+		assert.False(t, plan.HasChanges())
+	})
+
+	t.Run("new", func(t *testing.T) {
+		spec, err := filemode.Parse("go+rx")
+		require.NoError(t, err)
+
+		dest := path.Join(tmpDir, "new")
+		dir := directory.Directory{Destination: dest, Mode: spec}
+
+		_, err = dir.Apply()
+		require.NoError(t, err)
+
+		stat, err := os.Stat(dest)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0755), stat.Mode().Perm())
+	})
+}
@@ -15,6 +15,7 @@
 package directory
 
 import (
+	"github.com/asteris-llc/converge/helpers/filemode"
 	"github.com/asteris-llc/converge/load/registry"
 	"github.com/asteris-llc/converge/resource"
 )
@@ -28,16 +29,49 @@ type Preparer struct {
 
 	// whether or not to create all parent directories on the way up
 	CreateAll bool `hcl:"create_all"`
+
+	// the user that owns the directory, as a name or uid. The resolved uid is
+	// available to templates as `UID`.
+	Owner string `hcl:"owner"`
+
+	// the group that owns the directory, as a name or gid. The resolved gid is
+	// available to templates as `GID`.
+	Group string `hcl:"group"`
+
+	// the mode of the directory, in any of the forms `file.mode` accepts. If
+	// not set, a new directory is created with mode 0700 less the umask and
+	// an existing directory keeps its mode.
+	Mode interface{} `hcl:"mode"`
 }
 
 // Prepare the new directory
 func (p *Preparer) Prepare(render resource.Renderer) (resource.Task, error) {
-	return &Directory{
+	dir := &Directory{
 		Destination: p.Destination,
 		CreateAll:   p.CreateAll,
-	}, nil
+		Owner:       p.Owner,
+		Group:       p.Group,
+	}
+
+	if p.Mode != nil {
+		spec, err := filemode.FromValue(p.Mode)
+		if err != nil {
+			return nil, err
+		}
+		dir.Mode = spec
+	}
+
+	// resolve the IDs now if possible, so that templates can use them. If the
+	// user or group is created by another resource in the same run, they are
+	// resolved when the directory is checked.
+	dir.resolveOwner()
+
+	return dir, nil
 }
 
+// HostOnly marks file.directory as unable to run in a target, since it creates directories with syscalls
+func (p *Preparer) HostOnly() {}
+
 func init() {
 	registry.Register("file.directory", (*Preparer)(nil), (*Directory)(nil))
 }
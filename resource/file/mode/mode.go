@@ -18,6 +18,7 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/asteris-llc/converge/helpers/filemode"
 	"github.com/asteris-llc/converge/resource"
 )
 
@@ -27,6 +28,14 @@ type Mode struct {
 
 	Destination string
 	Mode        os.FileMode
+
+	// Spec, if set, is a symbolic mode. Mode is worked out from it and the
+	// current mode of Destination.
+	Spec *filemode.Spec
+
+	// previous is the mode Destination had before Apply changed it. It is only
+	// set once Apply has run.
+	previous *os.FileMode
 }
 
 // Check whether the Destination has the right Mode
@@ -42,11 +51,13 @@ func (t *Mode) Check(resource.Renderer) (resource.TaskStatus, error) {
 			Output:      []string{status},
 		}
 		stat.SetError(fmt.Errorf("cannot set mode for file that does not exist"))
+		t.warnInsecure(stat)
 		return stat, nil
 	} else if err != nil {
 		return nil, err
 	}
-	mode := stat.Mode().Perm()
+	t.resolve(stat.Mode())
+	mode := stat.Mode() & filemode.Mask
 	modeDiff := &FileModeDiff{Actual: mode, Expected: t.Mode}
 	diffs[t.Destination] = modeDiff
 	status := fmt.Sprintf("%q's mode is %q expected %q", t.Destination, mode, t.Mode)
@@ -63,12 +74,20 @@ func (t *Mode) Check(resource.Renderer) (resource.TaskStatus, error) {
 			status,
 		},
 	}
+	t.warnInsecure(&t.Status)
 	return t, nil
 }
 
 // Apply the changes the Mode
 func (t *Mode) Apply() (resource.TaskStatus, error) {
-	err := os.Chmod(t.Destination, t.Mode.Perm())
+	var previous *os.FileMode
+	if stat, err := os.Stat(t.Destination); err == nil {
+		t.resolve(stat.Mode())
+		perm := stat.Mode() & filemode.Mask
+		previous = &perm
+	}
+
+	err := os.Chmod(t.Destination, t.Mode&filemode.Mask)
 
 	if err != nil {
 		return &resource.Status{
@@ -77,9 +96,42 @@ func (t *Mode) Apply() (resource.TaskStatus, error) {
 		}, err
 	}
 
+	t.previous = previous
 	return t, nil
 }
 
+// Rollback sets the mode Destination had before Apply
+func (t *Mode) Rollback() (resource.TaskStatus, error) {
+	if t.previous == nil {
+		return &resource.Status{}, nil
+	}
+
+	if err := os.Chmod(t.Destination, *t.previous); err != nil {
+		return &resource.Status{
+			Level:  resource.StatusFatal,
+			Output: []string{fmt.Sprintf("failed to restore mode on %s: %s", t.Destination, err)},
+		}, err
+	}
+
+	status := &resource.Status{Output: []string{fmt.Sprintf("restored mode %s on %s", *t.previous, t.Destination)}}
+	t.previous = nil
+	return status, nil
+}
+
+// warnInsecure warns about a mode that lets anyone write to Destination
+func (t *Mode) warnInsecure(status *resource.Status) {
+	if t.Mode&0002 != 0 {
+		status.AddWarning(resource.WarningInsecure, "mode", fmt.Sprintf("%s makes %q writable by everyone", t.Mode&filemode.Mask, t.Destination))
+	}
+}
+
+// resolve works out Mode from Spec and the current mode of Destination
+func (t *Mode) resolve(current os.FileMode) {
+	if t.Spec != nil {
+		t.Mode = t.Spec.Apply(current)
+	}
+}
+
 // Validate Mode
 func (t *Mode) Validate() error {
 	if t.Destination == "" {
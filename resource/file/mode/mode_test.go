@@ -21,6 +21,7 @@ import (
 	"testing"
 
 	"github.com/asteris-llc/converge/helpers/fakerenderer"
+	"github.com/asteris-llc/converge/helpers/filemode"
 	"github.com/asteris-llc/converge/resource"
 	"github.com/asteris-llc/converge/resource/file/mode"
 	"github.com/stretchr/testify/assert"
@@ -47,6 +48,15 @@ func TestCheck(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Contains(t, status.Messages(), fmt.Sprintf("%q's mode is \"-rw-------\" expected \"-rwxrwxrwx\"", tmpfile.Name()))
 	assert.True(t, status.HasChanges())
+	assert.Equal(
+		t,
+		[]resource.Warning{{
+			Kind:    resource.WarningInsecure,
+			Field:   "mode",
+			Message: fmt.Sprintf("-rwxrwxrwx makes %q writable by everyone", tmpfile.Name()),
+		}},
+		resource.Warnings(status),
+	)
 }
 
 // TestApply tests Apply() for file mode
@@ -64,3 +74,51 @@ func TestApply(t *testing.T) {
 	assert.Contains(t, status.Messages(), fmt.Sprintf("%q's mode is \"-rwxrwxrwx\" expected \"-rwxrwxrwx\"", tmpfile.Name()))
 	assert.False(t, status.HasChanges())
 }
+
+// TestCheckSymbolic tests that Check works out a symbolic mode from the
+// current mode
+func TestCheckSymbolic(t *testing.T) {
+	tmpfile, err := ioutil.TempFile("", "mode_test")
+	require.NoError(t, err)
+	defer os.Remove(tmpfile.Name())
+	require.NoError(t, os.Chmod(tmpfile.Name(), 0600))
+
+	spec, err := filemode.Parse("g+r,o+r")
+	require.NoError(t, err)
+
+	task := mode.Mode{Destination: tmpfile.Name(), Spec: spec}
+	status, err := task.Check(fakerenderer.New())
+	require.NoError(t, err)
+	assert.True(t, status.HasChanges())
+	assert.Equal(t, os.FileMode(0644), task.Mode)
+
+	_, err = task.Apply()
+	require.NoError(t, err)
+
+	stat, err := os.Stat(tmpfile.Name())
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0644), stat.Mode().Perm())
+
+	status, err = task.Check(fakerenderer.New())
+	require.NoError(t, err)
+	assert.False(t, status.HasChanges())
+}
+
+func TestRollback(t *testing.T) {
+	tmpfile, err := ioutil.TempFile("", "mode_test")
+	assert.NoError(t, err)
+	defer os.Remove(tmpfile.Name())
+
+	require.NoError(t, os.Chmod(tmpfile.Name(), 0600))
+
+	mode := mode.Mode{Destination: tmpfile.Name(), Mode: os.FileMode(0777)}
+	_, err = mode.Apply()
+	require.NoError(t, err)
+
+	_, err = mode.Rollback()
+	require.NoError(t, err)
+
+	stat, err := os.Stat(tmpfile.Name())
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), stat.Mode().Perm())
+}
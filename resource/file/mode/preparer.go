@@ -15,34 +15,49 @@
 package mode
 
 import (
-	"os"
-
+	"github.com/asteris-llc/converge/helpers/filemode"
 	"github.com/asteris-llc/converge/load/registry"
 	"github.com/asteris-llc/converge/resource"
 )
 
 // Preparer for file Mode
 //
-// Mode monitors the mode of a file
+// Mode monitors the mode of a file. Windows has no file modes, so use
+// `file.acl` there instead.
 type Preparer struct {
 	// Destination specifies which file will be modified by this resource. The
 	// file must exist on the system (for example, having been created with
 	// `file.content`.)
 	Destination string `hcl:"destination" required:"true"`
 
-	// Mode is the mode of the file, specified in octal.
-	Mode *uint32 `hcl:"mode" base:"8" required:"true"`
+	// Mode is the mode of the file. It is either a number whose digits are
+	// read as octal, like 644, or a string holding an octal or symbolic mode
+	// as accepted by chmod(1), like "0644" or "u+rwx,g+rx". Numbers with a
+	// leading zero are read as octal before converge sees them, so quote
+	// modes that have one. Symbolic modes only change the bits they name, and
+	// are worked out from the current mode of the file during Check.
+	Mode interface{} `hcl:"mode" required:"true"`
 }
 
 // Prepare this resource for use
 func (p *Preparer) Prepare(render resource.Renderer) (resource.Task, error) {
-	modeTask := &Mode{
-		Destination: p.Destination,
-		Mode:        os.FileMode(*p.Mode),
+	spec, err := filemode.FromValue(p.Mode)
+	if err != nil {
+		return nil, err
+	}
+
+	modeTask := &Mode{Destination: p.Destination}
+	if spec.Absolute() {
+		modeTask.Mode = spec.Apply(0)
+	} else {
+		modeTask.Spec = spec
 	}
 	return modeTask, modeTask.Validate()
 }
 
+// HostOnly marks file.mode as unable to run in a target, since it changes modes with syscalls
+func (p *Preparer) HostOnly() {}
+
 func init() {
 	registry.Register("file.mode", (*Preparer)(nil), (*Mode)(nil))
 }
@@ -15,12 +15,14 @@
 package mode_test
 
 import (
+	"os"
 	"testing"
 
 	"github.com/asteris-llc/converge/helpers/fakerenderer"
 	"github.com/asteris-llc/converge/resource"
 	"github.com/asteris-llc/converge/resource/file/mode"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // TestPreparerInterface tests that file mode has been properly implemented
@@ -33,9 +35,69 @@ func TestPreparerInterface(t *testing.T) {
 // TestValidPreparer tests file mode Prepare()
 func TestValidPreparer(t *testing.T) {
 	t.Parallel()
-	var testMode uint32 = 777
 	fr := fakerenderer.FakeRenderer{}
-	prep := mode.Preparer{Destination: "path/to/file", Mode: &testMode}
-	_, err := prep.Prepare(&fr)
+	prep := mode.Preparer{Destination: "path/to/file", Mode: 777}
+	task, err := prep.Prepare(&fr)
 	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0777), task.(*mode.Mode).Mode)
+}
+
+// TestNumericPreparer tests that numbers are read as octal digits, as HCL
+// gives them to the preparer
+func TestNumericPreparer(t *testing.T) {
+	t.Parallel()
+
+	t.Run("755", func(t *testing.T) {
+		prep := mode.Preparer{Destination: "path/to/file", Mode: 755}
+		task, err := prep.Prepare(fakerenderer.New())
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0755), task.(*mode.Mode).Mode)
+	})
+
+	t.Run("setuid", func(t *testing.T) {
+		prep := mode.Preparer{Destination: "path/to/file", Mode: 4755}
+		task, err := prep.Prepare(fakerenderer.New())
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0755)|os.ModeSetuid, task.(*mode.Mode).Mode)
+	})
+
+	t.Run("not octal", func(t *testing.T) {
+		// HCL reads `mode = 0755` as 493
+		prep := mode.Preparer{Destination: "path/to/file", Mode: 0755}
+		_, err := prep.Prepare(fakerenderer.New())
+		assert.EqualError(t, err, "493 is not a valid octal mode, numbers are read as octal digits so leave out the leading zero or quote the mode")
+	})
+}
+
+// TestSymbolicPreparer tests file mode Prepare() with modes given as strings
+func TestSymbolicPreparer(t *testing.T) {
+	t.Parallel()
+
+	t.Run("octal", func(t *testing.T) {
+		prep := mode.Preparer{Destination: "path/to/file", Mode: "0640"}
+		task, err := prep.Prepare(fakerenderer.New())
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0640), task.(*mode.Mode).Mode)
+		assert.Nil(t, task.(*mode.Mode).Spec)
+	})
+
+	t.Run("symbolic", func(t *testing.T) {
+		prep := mode.Preparer{Destination: "path/to/file", Mode: "u+rwx,g+rx"}
+		task, err := prep.Prepare(fakerenderer.New())
+		require.NoError(t, err)
+		require.NotNil(t, task.(*mode.Mode).Spec)
+		assert.Equal(t, "u+rwx,g+rx", task.(*mode.Mode).Spec.String())
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		prep := mode.Preparer{Destination: "path/to/file", Mode: "u+q"}
+		_, err := prep.Prepare(fakerenderer.New())
+		assert.Error(t, err)
+	})
+
+	t.Run("wrong type", func(t *testing.T) {
+		prep := mode.Preparer{Destination: "path/to/file", Mode: true}
+		_, err := prep.Prepare(fakerenderer.New())
+		assert.EqualError(t, err, "mode must be a number or a string, not bool")
+	})
 }
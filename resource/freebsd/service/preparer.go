@@ -0,0 +1,64 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"fmt"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/load/registry"
+	"github.com/asteris-llc/converge/resource"
+)
+
+// Preparer for FreeBSD Service
+//
+// FreeBSD Service manages an rc.d service: whether it starts at boot, the
+// flags it is started with, and whether it is running. rc.conf is changed
+// with `sysrc`, so other settings in it are left alone.
+type Preparer struct {
+	// Name of the rc.d script, from /etc/rc.d or /usr/local/etc/rc.d
+	Name string `hcl:"name" required:"true" nonempty:"true"`
+
+	// Enabled sets `<name>_enable` in rc.conf, so that the service starts at
+	// boot. If not set, it is left as it is.
+	Enabled *bool `hcl:"enabled"`
+
+	// Flags sets `<name>_flags` in rc.conf. If not set, it is left as it is.
+	Flags *string `hcl:"flags"`
+
+	// State is whether the service should be running. If not set, it is left
+	// as it is.
+	State State `hcl:"state" valid_values:"running,stopped"`
+}
+
+// Prepare a new service
+func (p *Preparer) Prepare(render resource.Renderer) (resource.Task, error) {
+	if p.Enabled == nil && p.Flags == nil && p.State == "" {
+		return nil, fmt.Errorf(`freebsd.service needs at least one of "enabled", "flags", or "state"`)
+	}
+
+	return &Service{
+		Name:    p.Name,
+		Enabled: p.Enabled,
+		Flags:   p.Flags,
+		State:   p.State,
+		exec:    exec.For(render),
+	}, nil
+}
+
+func init() {
+	registry.Register("freebsd.service", (*Preparer)(nil), (*Service)(nil))
+	registry.RegisterPlatforms("freebsd.service", "freebsd")
+}
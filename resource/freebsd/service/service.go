@@ -0,0 +1,215 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/resource"
+)
+
+// State type for Service
+type State string
+
+const (
+	// StateRunning indicates the service should be running
+	StateRunning State = "running"
+
+	// StateStopped indicates the service should be stopped
+	StateStopped State = "stopped"
+)
+
+// Service manages an rc.d service with service(8), and whether it is enabled
+// in rc.conf with sysrc(8)
+type Service struct {
+	resource.Status
+
+	Name string
+
+	// Enabled, if set, is whether the service starts at boot
+	Enabled *bool
+
+	// Flags, if set, are the arguments the service is started with
+	Flags *string
+
+	// State, if set, is whether the service should be running
+	State State
+
+	exec exec.Executor
+}
+
+// Check the service against rc.conf and its status
+func (s *Service) Check(resource.Renderer) (resource.TaskStatus, error) {
+	s.Status = resource.Status{}
+
+	if err := s.exists(); err != nil {
+		s.RaiseLevel(resource.StatusFatal)
+		return s, err
+	}
+
+	if s.Enabled != nil {
+		enabled, err := s.enabled()
+		if err != nil {
+			s.RaiseLevel(resource.StatusFatal)
+			return s, err
+		}
+		if enabled != *s.Enabled {
+			s.RaiseLevel(resource.StatusWillChange)
+			s.AddDifference("enabled", fmt.Sprint(enabled), fmt.Sprint(*s.Enabled), "")
+		}
+	}
+
+	if s.Flags != nil {
+		flags, err := s.sysrc(s.rcvar("flags"))
+		if err != nil {
+			s.RaiseLevel(resource.StatusFatal)
+			return s, err
+		}
+		if flags != *s.Flags {
+			s.RaiseLevel(resource.StatusWillChange)
+			s.AddDifference("flags", flags, *s.Flags, "")
+		}
+	}
+
+	if s.State != "" {
+		state, err := s.state()
+		if err != nil {
+			s.RaiseLevel(resource.StatusFatal)
+			return s, err
+		}
+		if state != s.State {
+			s.RaiseLevel(resource.StatusWillChange)
+			s.AddDifference("state", string(state), string(s.State), "")
+		}
+	}
+
+	return s, nil
+}
+
+// Apply writes rc.conf and then starts or stops the service. The one* forms
+// of the service commands are used so that a service can be started or
+// stopped whether or not it is enabled.
+func (s *Service) Apply() (resource.TaskStatus, error) {
+	if _, err := s.Check(nil); err != nil {
+		return s, err
+	}
+	diffs := s.Diffs()
+	s.Status = resource.Status{}
+
+	if _, ok := diffs["enabled"]; ok {
+		value := "NO"
+		if *s.Enabled {
+			value = "YES"
+		}
+		if err := s.set(s.rcvar("enable"), value); err != nil {
+			return s, err
+		}
+	}
+
+	if _, ok := diffs["flags"]; ok {
+		if err := s.set(s.rcvar("flags"), *s.Flags); err != nil {
+			return s, err
+		}
+	}
+
+	if _, ok := diffs["state"]; ok {
+		verb := "onestart"
+		if s.State == StateStopped {
+			verb = "onestop"
+		}
+		if err := exec.Run(s.exec, "service", s.Name, verb); err != nil {
+			s.RaiseLevel(resource.StatusFatal)
+			return s, fmt.Errorf("freebsd.service: could not %s %s: %s", strings.TrimPrefix(verb, "one"), s.Name, err)
+		}
+		s.AddMessage(fmt.Sprintf("%s %s", strings.TrimPrefix(verb, "one"), s.Name))
+	}
+
+	return s, nil
+}
+
+// exists checks that there is an rc.d script for the service
+func (s *Service) exists() error {
+	out, err := exec.Read(s.exec, "service", "-l")
+	if err != nil {
+		return fmt.Errorf("freebsd.service: could not list services: %s", err)
+	}
+
+	for _, name := range strings.Fields(out) {
+		if name == s.Name {
+			return nil
+		}
+	}
+	return fmt.Errorf("freebsd.service: %s does not exist in /etc/rc.d or /usr/local/etc/rc.d", s.Name)
+}
+
+func (s *Service) enabled() (bool, error) {
+	value, err := s.sysrc(s.rcvar("enable"))
+	if err != nil {
+		return false, err
+	}
+
+	switch strings.ToUpper(value) {
+	case "YES", "TRUE", "ON", "1":
+		return true, nil
+	default:
+		return false, nil
+	}
+}
+
+// state asks the service whether it is running. onestatus exits 1 when it
+// isn't.
+func (s *Service) state() (State, error) {
+	err := exec.Run(s.exec, "service", s.Name, "onestatus")
+	if status, ok := exec.ExitStatus(err); ok && status == 1 {
+		return StateStopped, nil
+	} else if err != nil {
+		return "", fmt.Errorf("freebsd.service: could not get status of %s: %s", s.Name, err)
+	}
+	return StateRunning, nil
+}
+
+// sysrc reads a variable from rc.conf, or its default. A variable that is
+// set nowhere is empty.
+func (s *Service) sysrc(name string) (string, error) {
+	out, err := exec.Read(s.exec, "sysrc", "-n", name)
+	if status, ok := exec.ExitStatus(err); ok && status == 1 {
+		return "", nil
+	} else if err != nil {
+		return "", fmt.Errorf("freebsd.service: could not read %s: %s", name, err)
+	}
+	return strings.TrimSpace(out), nil
+}
+
+func (s *Service) set(name, value string) error {
+	if err := exec.Run(s.exec, "sysrc", name+"="+value); err != nil {
+		s.RaiseLevel(resource.StatusFatal)
+		return fmt.Errorf("freebsd.service: could not set %s: %s", name, err)
+	}
+	s.AddMessage(fmt.Sprintf("set %s=%q in rc.conf", name, value))
+	return nil
+}
+
+// rcvar is the rc.conf variable of the service with the given suffix. Like
+// rc.subr, it replaces characters that can't be in a variable name.
+func (s *Service) rcvar(suffix string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == '.' {
+			return '_'
+		}
+		return r
+	}, s.Name) + "_" + suffix
+}
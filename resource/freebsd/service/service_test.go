@@ -0,0 +1,148 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service_test
+
+import (
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/fakeexec"
+	"github.com/asteris-llc/converge/helpers/fakerenderer"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/freebsd/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestServiceInterface tests that Service is properly implemented
+func TestServiceInterface(t *testing.T) {
+	t.Parallel()
+
+	assert.Implements(t, (*resource.Task)(nil), new(service.Service))
+	assert.Implements(t, (*resource.Resource)(nil), new(service.Preparer))
+}
+
+func TestPrepare(t *testing.T) {
+	t.Parallel()
+
+	_, err := (&service.Preparer{Name: "nginx"}).Prepare(fakerenderer.New())
+	assert.EqualError(t, err, `freebsd.service needs at least one of "enabled", "flags", or "state"`)
+}
+
+func TestCheck(t *testing.T) {
+	t.Parallel()
+
+	t.Run("matching", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("service", "-l").Return("sshd\nnginx\n", 0)
+		fake.Expect("sysrc", "-n", "nginx_enable").Return("YES\n", 0)
+		fake.Expect("sysrc", "-n", "nginx_flags").Return("\n", 0)
+		fake.Expect("service", "nginx", "onestatus").Return("nginx is running as pid 812.\n", 0)
+
+		status, err := prepare(t, fake, nginx()).Check(fakerenderer.New())
+
+		require.NoError(t, err)
+		assert.False(t, status.HasChanges(), "%v", status.Diffs())
+		fake.AssertExpectations(t)
+	})
+
+	t.Run("changed", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("service", "-l").Return("sshd\nnginx\n", 0)
+		fake.Expect("sysrc", "-n", "nginx_enable").Return("NO\n", 0)
+		fake.Expect("sysrc", "-n", "nginx_flags").Return("", 1).Stderr("sysrc: unknown variable 'nginx_flags'")
+		fake.Expect("service", "nginx", "onestatus").Return("nginx is not running.\n", 1)
+
+		p := nginx()
+		flags := "-c /usr/local/etc/nginx/app.conf"
+		p.Flags = &flags
+
+		status, err := prepare(t, fake, p).Check(fakerenderer.New())
+
+		require.NoError(t, err)
+		diffs := status.Diffs()
+		assert.Equal(t, "false", diffs["enabled"].Original())
+		assert.Equal(t, "true", diffs["enabled"].Current())
+		assert.Equal(t, "", diffs["flags"].Original())
+		assert.Equal(t, flags, diffs["flags"].Current())
+		assert.Equal(t, "stopped", diffs["state"].Original())
+		assert.Equal(t, "running", diffs["state"].Current())
+	})
+
+	t.Run("rcvar", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("service", "-l").Return("node-exporter\n", 0)
+		fake.Expect("sysrc", "-n", "node_exporter_enable").Return("YES\n", 0)
+
+		enabled := true
+		status, err := prepare(t, fake, &service.Preparer{Name: "node-exporter", Enabled: &enabled}).Check(fakerenderer.New())
+
+		require.NoError(t, err)
+		assert.False(t, status.HasChanges())
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("service", "-l").Return("sshd\n", 0)
+
+		status, err := prepare(t, fake, nginx()).Check(fakerenderer.New())
+
+		assert.EqualError(t, err, "freebsd.service: nginx does not exist in /etc/rc.d or /usr/local/etc/rc.d")
+		assert.Equal(t, resource.StatusFatal, status.StatusCode())
+	})
+}
+
+func TestApply(t *testing.T) {
+	t.Parallel()
+
+	t.Run("enable and start", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("service", "-l").Return("nginx\n", 0)
+		fake.Expect("sysrc", "-n", "nginx_enable").Return("NO\n", 0)
+		fake.Expect("sysrc", "-n", "nginx_flags").Return("\n", 0)
+		fake.Expect("service", "nginx", "onestatus").Return("nginx is not running.\n", 1)
+		fake.Expect("sysrc", "nginx_enable=YES")
+		fake.Expect("service", "nginx", "onestart")
+
+		status, err := prepare(t, fake, nginx()).Apply()
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{`set nginx_enable="YES" in rc.conf`, "start nginx"}, status.Messages())
+		fake.AssertExpectations(t)
+	})
+
+	t.Run("stop", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("service", "-l").Return("nginx\n", 0)
+		fake.Expect("service", "nginx", "onestatus").Return("nginx is running as pid 812.\n", 0)
+		fake.Expect("service", "nginx", "onestop").Return("", 1).Stderr("nginx: no permission")
+
+		status, err := prepare(t, fake, &service.Preparer{Name: "nginx", State: "stopped"}).Apply()
+
+		assert.EqualError(t, err, "freebsd.service: could not stop nginx: service: exit status 1: nginx: no permission")
+		assert.Equal(t, resource.StatusFatal, status.StatusCode())
+	})
+}
+
+func nginx() *service.Preparer {
+	enabled := true
+	flags := ""
+	return &service.Preparer{Name: "nginx", Enabled: &enabled, Flags: &flags, State: "running"}
+}
+
+func prepare(t *testing.T, fake *fakeexec.Executor, p *service.Preparer) *service.Service {
+	task, err := p.Prepare(&resource.ExecRenderer{Renderer: fakerenderer.New(), Exec: fake})
+	require.NoError(t, err)
+	return task.(*service.Service)
+}
@@ -0,0 +1,133 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build linux
+
+package group
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/asteris-llc/converge/helpers/accounts"
+)
+
+// the ranges IDs are allocated from, as in the default login.defs
+const (
+	minID       = 1000
+	maxID       = 60000
+	minSystemID = 100
+	maxSystemID = 999
+)
+
+// addGroupFiles adds a group by editing the account files
+func addGroupFiles(files *accounts.Files, groupName string, options *AddGroupOptions) error {
+	gid := options.GID
+	if gid == "" {
+		min, max := minID, maxID
+		if options.System {
+			min, max = minSystemID, maxSystemID
+		}
+
+		id, err := files.NextID("group", min, max)
+		if err != nil {
+			return err
+		}
+		gid = strconv.Itoa(id)
+	} else if _, taken, err := files.Find("group", gid); err != nil {
+		return err
+	} else if taken {
+		return fmt.Errorf("gid %s is already in use", gid)
+	}
+
+	if err := files.Add("group", []string{groupName, "x", gid, ""}); err != nil {
+		return err
+	}
+	return files.Add("gshadow", []string{groupName, "!", "", ""})
+}
+
+// delGroupFiles removes a group by editing the account files
+func delGroupFiles(files *accounts.Files, groupName string) error {
+	if _, found, err := files.Find("group", groupName); err != nil {
+		return err
+	} else if !found {
+		return fmt.Errorf("group %s does not exist", groupName)
+	}
+
+	if err := files.Remove("group", groupName); err != nil {
+		return err
+	}
+	return files.Remove("gshadow", groupName)
+}
+
+// modGroupFiles changes the gid or name of a group by editing the account
+// files. Users whose primary group it is are moved to the new gid, as groupmod
+// does.
+func modGroupFiles(files *accounts.Files, groupName string, options *ModGroupOptions) error {
+	entry, found, err := files.Find("group", groupName)
+	if err != nil {
+		return err
+	} else if !found {
+		return fmt.Errorf("group %s does not exist", groupName)
+	}
+
+	if options.GID != "" && options.GID != entry[2] {
+		oldGID := entry[2]
+
+		err := files.Modify("group", groupName, func(fields []string) {
+			fields[2] = options.GID
+		})
+		if err != nil {
+			return err
+		}
+
+		err = files.ModifyAll("passwd", func(fields []string) {
+			if fields[3] == oldGID {
+				fields[3] = options.GID
+			}
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if options.NewName != "" {
+		return modifyGroup(files, groupName, func(fields []string) {
+			fields[0] = options.NewName
+		})
+	}
+	return nil
+}
+
+// setMembersFiles replaces the members of a group by editing the account
+// files
+func setMembersFiles(files *accounts.Files, groupName string, members []string) error {
+	return modifyGroup(files, groupName, func(fields []string) {
+		fields[3] = strings.Join(members, ",")
+	})
+}
+
+// modifyGroup changes the entry for a group in group, and in gshadow if it
+// has one there
+func modifyGroup(files *accounts.Files, groupName string, modify func([]string)) error {
+	if err := files.Modify("group", groupName, modify); err != nil {
+		return err
+	}
+
+	if _, found, err := files.Find("gshadow", groupName); err != nil || !found {
+		return err
+	}
+	return files.Modify("gshadow", groupName, modify)
+}
@@ -0,0 +1,106 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build linux
+
+package group_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/accounts"
+	"github.com/asteris-llc/converge/helpers/fakeexec"
+	"github.com/asteris-llc/converge/resource/group"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSystemFiles tests managing groups by editing the account files when no
+// tools are installed
+func TestSystemFiles(t *testing.T) {
+	t.Parallel()
+
+	t.Run("add", func(t *testing.T) {
+		files := setupFiles(t)
+		defer os.RemoveAll(files.Root)
+
+		fake := fakeexec.New()
+		fake.Expect("groupadd", "test", "-r").Return("", 127)
+		fake.Expect("groupadd", "other", "-g", "10").Return("", 127)
+
+		sys := &group.System{Exec: fake, Files: files}
+		require.NoError(t, sys.AddGroup("test", &group.AddGroupOptions{System: true}))
+		assert.EqualError(t, sys.AddGroup("other", &group.AddGroupOptions{GID: "10"}), "gid 10 is already in use")
+
+		assert.Equal(t, "root:x:0:\nwheel:x:10:alice\ntest:x:100:\n", readFile(t, files, "group"))
+		assert.Equal(t, "root:::\nwheel:::alice\ntest:!::\n", readFile(t, files, "gshadow"))
+	})
+
+	t.Run("modify", func(t *testing.T) {
+		files := setupFiles(t)
+		defer os.RemoveAll(files.Root)
+
+		fake := fakeexec.New()
+		fake.Expect("groupmod", "wheel", "-g", "20", "-n", "admin").Return("", 127)
+		fake.Expect("gpasswd", "-M", "alice,bob", "admin").Return("", 127)
+
+		sys := &group.System{Exec: fake, Files: files}
+		require.NoError(t, sys.ModGroup("wheel", &group.ModGroupOptions{GID: "20", NewName: "admin"}))
+		require.NoError(t, sys.SetMembers("admin", []string{"alice", "bob"}))
+
+		assert.Equal(t, "root:x:0:\nadmin:x:20:alice,bob\n", readFile(t, files, "group"))
+		assert.Equal(t, "root:::\nadmin:::alice,bob\n", readFile(t, files, "gshadow"))
+		assert.Equal(t, "alice:x:1000:20::/home/alice:/bin/sh\n", readFile(t, files, "passwd"))
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		files := setupFiles(t)
+		defer os.RemoveAll(files.Root)
+
+		fake := fakeexec.New()
+		fake.Expect("groupdel", "wheel").Return("", 127)
+
+		sys := &group.System{Exec: fake, Files: files}
+		require.NoError(t, sys.DelGroup("wheel"))
+		assert.EqualError(t, sys.DelGroup("wheel"), "group wheel does not exist")
+
+		assert.Equal(t, "root:x:0:\n", readFile(t, files, "group"))
+		assert.Equal(t, "root:::\n", readFile(t, files, "gshadow"))
+	})
+}
+
+func setupFiles(t *testing.T) *accounts.Files {
+	root, err := ioutil.TempDir("", "converge-group")
+	require.NoError(t, err)
+	require.NoError(t, os.Mkdir(filepath.Join(root, "etc"), 0755))
+
+	files := &accounts.Files{Root: root}
+	for database, content := range map[string]string{
+		"passwd":  "alice:x:1000:10::/home/alice:/bin/sh\n",
+		"group":   "root:x:0:\nwheel:x:10:alice\n",
+		"gshadow": "root:::\nwheel:::alice\n",
+	} {
+		require.NoError(t, ioutil.WriteFile(files.Path(database), []byte(content), 0644))
+	}
+	return files
+}
+
+func readFile(t *testing.T, files *accounts.Files, database string) string {
+	content, err := ioutil.ReadFile(files.Path(database))
+	require.NoError(t, err)
+	return string(content)
+}
@@ -17,6 +17,8 @@ package group
 import (
 	"fmt"
 	"os/user"
+	"sort"
+	"strings"
 
 	"github.com/asteris-llc/converge/resource"
 	"github.com/pkg/errors"
@@ -38,8 +40,21 @@ type Group struct {
 	GID     string
 	Name    string
 	NewName string
-	State   State
-	system  SystemUtils
+	System  bool
+
+	// Members is the complete list of users in the group. If nil, membership
+	// is not managed.
+	Members []string
+
+	State  State
+	system SystemUtils
+}
+
+// AddGroupOptions are the options specified in the configuration to be used
+// when adding a group
+type AddGroupOptions struct {
+	GID    string
+	System bool
 }
 
 // ModGroupOptions are the options specified in the configuration to be used
@@ -51,11 +66,13 @@ type ModGroupOptions struct {
 
 // SystemUtils provides system utilities for group
 type SystemUtils interface {
-	AddGroup(groupName, groupID string) error
+	AddGroup(groupName string, options *AddGroupOptions) error
 	DelGroup(groupName string) error
 	ModGroup(groupName string, options *ModGroupOptions) error
 	LookupGroup(groupName string) (*user.Group, error)
 	LookupGroupID(groupID string) (*user.Group, error)
+	LookupMembers(groupName string) ([]string, error)
+	SetMembers(groupName string, members []string) error
 }
 
 // ErrUnsupported is used when a system is not supported
@@ -154,9 +171,7 @@ func (g *Group) Check(resource.Renderer) (resource.TaskStatus, error) {
 					status.Output = append(status.Output, fmt.Sprintf("group add/modify: group %s and gid %s belong to different groups", g.Name, g.GID))
 					return status, errors.New("cannot add or modify group")
 				case groupByName != nil && groupByGid != nil && *groupByName == *groupByGid:
-					status.RaiseLevel(resource.StatusCantChange)
 					status.Output = append(status.Output, fmt.Sprintf("group add/modify: group %s with gid %s already exists", g.Name, g.GID))
-					return status, errors.New("cannot add or modify group")
 				}
 			case g.NewName != "":
 				_, newNameNotFound := newNameErr.(user.UnknownGroupError)
@@ -220,6 +235,13 @@ func (g *Group) Check(resource.Renderer) (resource.TaskStatus, error) {
 		return status, fmt.Errorf("group: unrecognized state %s", g.State)
 	}
 
+	if g.State == StatePresent {
+		if err := g.checkMembers(status, nameErr == nil && groupByName != nil); err != nil {
+			status.RaiseLevel(resource.StatusFatal)
+			return status, err
+		}
+	}
+
 	return status, nil
 }
 
@@ -260,13 +282,15 @@ func (g *Group) Apply() (resource.TaskStatus, error) {
 			case g.NewName == "":
 				switch {
 				case nameNotFound:
-					err := g.system.AddGroup(g.Name, g.GID)
+					err := g.system.AddGroup(g.Name, SetAddGroupOptions(g))
 					if err != nil {
 						status.RaiseLevel(resource.StatusFatal)
 						status.Output = append(status.Output, fmt.Sprintf("error adding group %s", g.Name))
 						return status, errors.Wrap(err, "group add")
 					}
 					status.Output = append(status.Output, fmt.Sprintf("added group %s", g.Name))
+				case groupByName != nil && g.Members != nil:
+					// only the members need to change
 				default:
 					status.RaiseLevel(resource.StatusCantChange)
 					return status, fmt.Errorf("will not attempt add: group %s", g.Name)
@@ -297,7 +321,7 @@ func (g *Group) Apply() (resource.TaskStatus, error) {
 			case g.NewName == "":
 				switch {
 				case nameNotFound && gidNotFound:
-					err := g.system.AddGroup(g.Name, g.GID)
+					err := g.system.AddGroup(g.Name, SetAddGroupOptions(g))
 					if err != nil {
 						status.RaiseLevel(resource.StatusFatal)
 						status.Output = append(status.Output, fmt.Sprintf("error adding group %s with gid %s", g.Name, g.GID))
@@ -313,6 +337,8 @@ func (g *Group) Apply() (resource.TaskStatus, error) {
 						return status, errors.Wrap(err, "group modify")
 					}
 					status.Output = append(status.Output, fmt.Sprintf("modified group %s with new gid %s", g.Name, g.GID))
+				case groupByName != nil && groupByGid != nil && *groupByName == *groupByGid && g.Members != nil:
+					// only the members need to change
 				default:
 					status.RaiseLevel(resource.StatusCantChange)
 					return status, fmt.Errorf("will not attempt add/modify: group %s with gid %s", g.Name, g.GID)
@@ -377,9 +403,25 @@ func (g *Group) Apply() (resource.TaskStatus, error) {
 		return status, fmt.Errorf("group: unrecognized state %s", g.State)
 	}
 
+	if g.State == StatePresent {
+		if err := g.applyMembers(status); err != nil {
+			status.RaiseLevel(resource.StatusFatal)
+			return status, err
+		}
+	}
+
 	return status, nil
 }
 
+// SetAddGroupOptions returns a AddGroupOptions struct with the options
+// specified in the configuration for adding a group
+func SetAddGroupOptions(g *Group) *AddGroupOptions {
+	return &AddGroupOptions{
+		GID:    g.GID,
+		System: g.System,
+	}
+}
+
 // SetModGroupOptions returns a ModGroupOptions struct with the options
 // specified in the configuration for modifying a group
 func SetModGroupOptions(g *Group) *ModGroupOptions {
@@ -395,3 +437,99 @@ func SetModGroupOptions(g *Group) *ModGroupOptions {
 
 	return options
 }
+
+// checkMembers adds a difference to the status if the members of the group
+// need to change. If the group doesn't exist yet, it has no members.
+func (g *Group) checkMembers(status *resource.Status, exists bool) error {
+	if g.Members == nil {
+		return nil
+	}
+
+	var current []string
+	if exists {
+		var err error
+		current, err = g.system.LookupMembers(g.Name)
+		if err != nil {
+			return errors.Wrapf(err, "cannot read members of group %s", g.Name)
+		}
+	}
+
+	add, remove := memberChanges(current, g.Members)
+	if len(add) == 0 && len(remove) == 0 {
+		return nil
+	}
+
+	status.RaiseLevel(resource.StatusWillChange)
+	status.AddDifference("members", joinMembers(current), joinMembers(g.Members), "")
+	if len(add) > 0 {
+		status.Output = append(status.Output, fmt.Sprintf("add members %s", strings.Join(add, ", ")))
+	}
+	if len(remove) > 0 {
+		status.Output = append(status.Output, fmt.Sprintf("remove members %s", strings.Join(remove, ", ")))
+	}
+	return nil
+}
+
+// applyMembers sets the members of the group, after it has been added or
+// renamed
+func (g *Group) applyMembers(status *resource.Status) error {
+	if g.Members == nil {
+		return nil
+	}
+
+	name := g.Name
+	if g.NewName != "" {
+		name = g.NewName
+	}
+
+	current, err := g.system.LookupMembers(name)
+	if err != nil {
+		return errors.Wrapf(err, "cannot read members of group %s", name)
+	}
+
+	add, remove := memberChanges(current, g.Members)
+	if len(add) == 0 && len(remove) == 0 {
+		return nil
+	}
+
+	if err := g.system.SetMembers(name, g.Members); err != nil {
+		status.Output = append(status.Output, fmt.Sprintf("error setting members of group %s", name))
+		return errors.Wrap(err, "group members")
+	}
+
+	status.Output = append(status.Output, fmt.Sprintf("set members of group %s to %s", name, joinMembers(g.Members)))
+	return nil
+}
+
+// memberChanges returns the users to add to and remove from current to get
+// desired, sorted by name
+func memberChanges(current, desired []string) (add, remove []string) {
+	have := make(map[string]struct{}, len(current))
+	for _, member := range current {
+		have[member] = struct{}{}
+	}
+
+	want := make(map[string]struct{}, len(desired))
+	for _, member := range desired {
+		want[member] = struct{}{}
+		if _, ok := have[member]; !ok {
+			add = append(add, member)
+		}
+	}
+
+	for _, member := range current {
+		if _, ok := want[member]; !ok {
+			remove = append(remove, member)
+		}
+	}
+
+	sort.Strings(add)
+	sort.Strings(remove)
+	return add, remove
+}
+
+func joinMembers(members []string) string {
+	sorted := append([]string(nil), members...)
+	sort.Strings(sorted)
+	return strings.Join(sorted, ", ")
+}
@@ -18,13 +18,18 @@ package group
 
 import (
 	"os/user"
+
+	"github.com/asteris-llc/converge/helpers/exec"
 )
 
 // System implements SystemUtils
-type System struct{}
+type System struct {
+	// Exec is unused on unsupported systems
+	Exec exec.Executor
+}
 
 // AddGroup implementation for systems which are not supported
-func (s *System) AddGroup(groupName, groupID string) error {
+func (s *System) AddGroup(groupName string, options *AddGroupOptions) error {
 	return ErrUnsupported
 }
 
@@ -47,3 +52,18 @@ func (s *System) LookupGroup(groupName string) (*user.Group, error) {
 func (s *System) LookupGroupID(groupID string) (*user.Group, error) {
 	return nil, ErrUnsupported
 }
+
+// LookupMembers implementation for systems which are not supported
+func (s *System) LookupMembers(groupName string) ([]string, error) {
+	return nil, ErrUnsupported
+}
+
+// SetMembers implementation for systems which are not supported
+func (s *System) SetMembers(groupName string, members []string) error {
+	return ErrUnsupported
+}
+
+// Import implementation for systems which are not supported
+func (s *System) Import(groupName string) (map[string]interface{}, error) {
+	return nil, ErrUnsupported
+}
@@ -18,39 +18,81 @@ package group
 
 import (
 	"fmt"
-	"os/exec"
 	"os/user"
+	"strconv"
+	"strings"
+
+	"github.com/asteris-llc/converge/helpers/accounts"
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/pkg/errors"
 )
 
 // System implements SystemUtils
-type System struct{}
+type System struct {
+	// Exec runs the group management commands. If nil, commands are run on the
+	// local system.
+	Exec exec.Executor
+
+	// Files edits the account files directly where neither shadow's tools nor
+	// busybox are installed. If nil, the files are edited when Exec is Direct.
+	Files *accounts.Files
+}
 
 // AddGroup adds a group
-func (s *System) AddGroup(groupName, groupID string) error {
+func (s *System) AddGroup(groupName string, options *AddGroupOptions) error {
+	defer accounts.For(s.executor()).Invalidate()
+
 	args := []string{groupName}
-	if groupID != "" {
-		args = append(args, "-g", groupID)
+	if options.GID != "" {
+		args = append(args, "-g", options.GID)
 	}
-	cmd := exec.Command("groupadd", args...)
-	err := cmd.Run()
-	if err != nil {
-		return fmt.Errorf("groupadd: %s", err)
+	if options.System {
+		args = append(args, "-r")
 	}
-	return nil
+
+	err := exec.Run(s.executor(), "groupadd", args...)
+	if exec.NotFound(err) {
+		if files := s.files(); files != nil {
+			return addGroupFiles(files, groupName, options)
+		}
+		return s.addBusyboxGroup(groupName, options)
+	}
+	return err
+}
+
+// addBusyboxGroup adds a group with addgroup(1) from busybox, for systems such
+// as Alpine that don't have groupadd
+func (s *System) addBusyboxGroup(groupName string, options *AddGroupOptions) error {
+	var args []string
+	if options.GID != "" {
+		args = append(args, "-g", options.GID)
+	}
+	if options.System {
+		args = append(args, "-S")
+	}
+	return exec.Run(s.executor(), "addgroup", append(args, groupName)...)
 }
 
 // DelGroup deletes a group
 func (s *System) DelGroup(groupName string) error {
-	cmd := exec.Command("groupdel", groupName)
-	err := cmd.Run()
-	if err != nil {
-		return fmt.Errorf("groupdel: %s", err)
+	defer accounts.For(s.executor()).Invalidate()
+
+	err := exec.Run(s.executor(), "groupdel", groupName)
+	if exec.NotFound(err) {
+		if files := s.files(); files != nil {
+			return delGroupFiles(files, groupName)
+		}
+
+		// busybox
+		return exec.Run(s.executor(), "delgroup", groupName)
 	}
-	return nil
+	return err
 }
 
 // ModGroup modifies a group
 func (s *System) ModGroup(groupName string, options *ModGroupOptions) error {
+	defer accounts.For(s.executor()).Invalidate()
+
 	args := []string{groupName}
 	if options.GID != "" {
 		args = append(args, "-g", options.GID)
@@ -58,22 +100,145 @@ func (s *System) ModGroup(groupName string, options *ModGroupOptions) error {
 	if options.NewName != "" {
 		args = append(args, "-n", options.NewName)
 	}
-	cmd := exec.Command("groupmod", args...)
-	err := cmd.Run()
-	if err != nil {
-		return fmt.Errorf("groupmod: %s", err)
+	err := exec.Run(s.executor(), "groupmod", args...)
+	if exec.NotFound(err) {
+		if files := s.files(); files != nil {
+			return modGroupFiles(files, groupName, options)
+		}
+		return errors.Wrapf(err, "cannot modify group %s without groupmod", groupName)
 	}
-	return nil
+	return err
 }
 
 // LookupGroup looks up a group by name
 // If the group cannot be found an error is returned
 func (s *System) LookupGroup(groupName string) (*user.Group, error) {
-	return user.LookupGroup(groupName)
+	return accounts.For(s.executor()).LookupGroup(groupName)
 }
 
 // LookupGroupID looks up a group by gid
 // If the group cannot be found an error is returned
 func (s *System) LookupGroupID(groupID string) (*user.Group, error) {
-	return user.LookupGroupId(groupID)
+	return accounts.For(s.executor()).LookupGroupID(groupID)
+}
+
+// LookupMembers reads the members of a group from the group database. Users
+// whose primary group this is are not included.
+func (s *System) LookupMembers(groupName string) ([]string, error) {
+	fields, err := s.entry(groupName)
+	if err != nil {
+		return nil, err
+	}
+
+	if fields[3] == "" {
+		return nil, nil
+	}
+	return strings.Split(fields[3], ","), nil
+}
+
+// Import reads an existing group as the fields of a user.group resource.
+// Members are only included if the group has any, so that importing a group
+// doesn't start managing its membership.
+func (s *System) Import(groupName string) (map[string]interface{}, error) {
+	entry, err := s.entry(groupName)
+	if err != nil {
+		return nil, err
+	}
+
+	gid, err := strconv.ParseUint(entry[2], 10, 32)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid gid for group %s", groupName)
+	}
+
+	fields := map[string]interface{}{
+		"name": entry[0],
+		"gid":  gid,
+	}
+
+	if entry[3] != "" {
+		fields["members"] = strings.Split(entry[3], ",")
+	}
+
+	return fields, nil
+}
+
+// SetMembers replaces the members of a group
+func (s *System) SetMembers(groupName string, members []string) error {
+	err := exec.Run(s.executor(), "gpasswd", "-M", strings.Join(members, ","), groupName)
+	if exec.NotFound(err) {
+		if files := s.files(); files != nil {
+			return setMembersFiles(files, groupName, members)
+		}
+		return s.setBusyboxMembers(groupName, members)
+	}
+	return err
+}
+
+// setBusyboxMembers replaces the members of a group one at a time with
+// busybox, which has no way of setting them all at once
+func (s *System) setBusyboxMembers(groupName string, members []string) error {
+	current, err := s.LookupMembers(groupName)
+	if err != nil {
+		return err
+	}
+
+	wanted := make(map[string]bool)
+	for _, member := range members {
+		wanted[member] = true
+	}
+
+	for _, member := range current {
+		if wanted[member] {
+			delete(wanted, member)
+			continue
+		}
+		if err := exec.Run(s.executor(), "delgroup", member, groupName); err != nil {
+			return err
+		}
+	}
+
+	for _, member := range members {
+		if !wanted[member] {
+			continue
+		}
+		if err := exec.Run(s.executor(), "addgroup", member, groupName); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// entry reads the group database entry for a group, split into fields
+func (s *System) entry(groupName string) ([]string, error) {
+	fields, found, err := accounts.Getent(s.executor(), "group", groupName)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("could not read group entry for %s: no such entry", groupName)
+	}
+	if len(fields) < 4 {
+		return nil, fmt.Errorf("malformed group entry for %s", groupName)
+	}
+	return fields, nil
+}
+
+// files returns the editor for the account files used when there are no tools
+// to manage groups, or nil if the files can't be edited directly
+func (s *System) files() *accounts.Files {
+	if s.Files != nil {
+		return s.Files
+	}
+	if exec.Direct(s.Exec) {
+		return &accounts.Files{}
+	}
+	return nil
+}
+
+func (s *System) executor() exec.Executor {
+	if s.Exec == nil {
+		return exec.New()
+	}
+	return s.Exec
 }
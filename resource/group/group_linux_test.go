@@ -0,0 +1,128 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build linux
+
+package group_test
+
+import (
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/fakeexec"
+	"github.com/asteris-llc/converge/resource/group"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSystemCommands tests the commands run by the linux System
+func TestSystemCommands(t *testing.T) {
+	t.Parallel()
+
+	t.Run("add", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("groupadd", "test", "-g", "1234", "-r")
+
+		sys := &group.System{Exec: fake}
+		assert.NoError(t, sys.AddGroup("test", &group.AddGroupOptions{GID: "1234", System: true}))
+		fake.AssertExpectations(t)
+	})
+
+	t.Run("lookup members", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("getent", "group", "test").Return("test:x:1234:alice,bob\n", 0)
+		fake.Expect("getent", "group", "empty").Return("empty:x:1235:\n", 0)
+
+		sys := &group.System{Exec: fake}
+		members, err := sys.LookupMembers("test")
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"alice", "bob"}, members)
+
+		members, err = sys.LookupMembers("empty")
+		assert.NoError(t, err)
+		assert.Empty(t, members)
+	})
+
+	t.Run("import", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("getent", "group", "test").Return("test:x:1234:alice,bob\n", 0)
+		fake.Expect("getent", "group", "empty").Return("empty:x:1235:\n", 0)
+
+		sys := &group.System{Exec: fake}
+		fields, err := sys.Import("test")
+		require.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{
+			"name":    "test",
+			"gid":     uint64(1234),
+			"members": []string{"alice", "bob"},
+		}, fields)
+
+		fields, err = sys.Import("empty")
+		require.NoError(t, err)
+		assert.NotContains(t, fields, "members")
+	})
+
+	t.Run("set members", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("gpasswd", "-M", "alice,carol", "test")
+
+		sys := &group.System{Exec: fake}
+		assert.NoError(t, sys.SetMembers("test", []string{"alice", "carol"}))
+		fake.AssertExpectations(t)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("groupdel", "test")
+
+		sys := &group.System{Exec: fake}
+		assert.NoError(t, sys.DelGroup("test"))
+		fake.AssertExpectations(t)
+	})
+
+	t.Run("modify", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("groupmod", "test", "-g", "1234", "-n", "other")
+
+		sys := &group.System{Exec: fake}
+		assert.NoError(t, sys.ModGroup("test", &group.ModGroupOptions{GID: "1234", NewName: "other"}))
+		fake.AssertExpectations(t)
+	})
+
+	t.Run("failure", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("groupdel", "test").Return("", 6).Stderr("group 'test' does not exist")
+
+		sys := &group.System{Exec: fake}
+		assert.EqualError(t, sys.DelGroup("test"), "groupdel: exit status 6: group 'test' does not exist")
+	})
+	t.Run("busybox", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("groupadd", "test", "-g", "1234", "-r").Return("", 127)
+		fake.Expect("addgroup", "-g", "1234", "-S", "test")
+		fake.Expect("gpasswd", "-M", "alice,carol", "test").Return("", 127)
+		fake.Expect("getent", "group", "test").Return("", 127)
+		fake.Expect("test", "-e", "/etc/group")
+		fake.Expect("cat", "/etc/group").Return("wheel:x:10:\ntest:x:1234:bob,carol\n", 0)
+		fake.Expect("delgroup", "bob", "test")
+		fake.Expect("addgroup", "alice", "test")
+		fake.Expect("groupdel", "test").Return("", 127)
+		fake.Expect("delgroup", "test")
+
+		sys := &group.System{Exec: fake}
+		require.NoError(t, sys.AddGroup("test", &group.AddGroupOptions{GID: "1234", System: true}))
+		require.NoError(t, sys.SetMembers("test", []string{"alice", "carol"}))
+		require.NoError(t, sys.DelGroup("test"))
+		fake.AssertExpectations(t)
+	})
+}
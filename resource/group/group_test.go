@@ -254,10 +254,10 @@ func TestCheck(t *testing.T) {
 					status, err := g.Check(fakerenderer.New())
 
 					if runtime.GOOS == "linux" {
-						assert.EqualError(t, err, "cannot add or modify group")
-						assert.Equal(t, resource.StatusCantChange, status.StatusCode())
+						assert.NoError(t, err)
+						assert.Equal(t, resource.StatusNoChange, status.StatusCode())
 						assert.Equal(t, fmt.Sprintf("group add/modify: group %s with gid %s already exists", g.Name, g.GID), status.Messages()[0])
-						assert.True(t, status.HasChanges())
+						assert.False(t, status.HasChanges())
 					} else {
 						assert.EqualError(t, err, "group: not supported on this system")
 					}
@@ -469,10 +469,10 @@ func TestApply(t *testing.T) {
 					g.State = group.StatePresent
 
 					m.On("LookupGroup", g.Name).Return(new(user.Group), user.UnknownGroupError(""))
-					m.On("AddGroup", g.Name, g.GID).Return(nil)
+					m.On("AddGroup", g.Name, &group.AddGroupOptions{GID: g.GID}).Return(nil)
 					status, err := g.Apply()
 
-					m.AssertCalled(t, "AddGroup", g.Name, g.GID)
+					m.AssertCalled(t, "AddGroup", g.Name, &group.AddGroupOptions{GID: g.GID})
 					assert.NoError(t, err)
 					assert.Equal(t, fmt.Sprintf("added group %s", g.Name), status.Messages()[0])
 				})
@@ -488,10 +488,10 @@ func TestApply(t *testing.T) {
 					g.State = group.StatePresent
 
 					m.On("LookupGroup", g.Name).Return(new(user.Group), user.UnknownGroupError(""))
-					m.On("AddGroup", g.Name, g.GID).Return(fmt.Errorf(""))
+					m.On("AddGroup", g.Name, &group.AddGroupOptions{GID: g.GID}).Return(fmt.Errorf(""))
 					status, err := g.Apply()
 
-					m.AssertCalled(t, "AddGroup", g.Name, g.GID)
+					m.AssertCalled(t, "AddGroup", g.Name, &group.AddGroupOptions{GID: g.GID})
 					assert.EqualError(t, err, "group add: ")
 					assert.Equal(t, resource.StatusFatal, status.StatusCode())
 					assert.Equal(t, fmt.Sprintf("error adding group %s", g.Name), status.Messages()[0])
@@ -508,10 +508,10 @@ func TestApply(t *testing.T) {
 					g.State = group.StatePresent
 
 					m.On("LookupGroup", g.Name).Return(grp, nil)
-					m.On("AddGroup", g.Name, g.GID).Return(nil)
+					m.On("AddGroup", g.Name, &group.AddGroupOptions{GID: g.GID}).Return(nil)
 					status, err := g.Apply()
 
-					m.AssertNotCalled(t, "AddGroup", g.Name, g.GID)
+					m.AssertNotCalled(t, "AddGroup", g.Name, &group.AddGroupOptions{GID: g.GID})
 					assert.EqualError(t, err, fmt.Sprintf("will not attempt add: group %s", g.Name))
 					assert.Equal(t, resource.StatusCantChange, status.StatusCode())
 				})
@@ -602,10 +602,10 @@ func TestApply(t *testing.T) {
 
 					m.On("LookupGroup", g.Name).Return(new(user.Group), user.UnknownGroupError(""))
 					m.On("LookupGroupID", g.GID).Return(new(user.Group), user.UnknownGroupIdError(""))
-					m.On("AddGroup", g.Name, g.GID).Return(nil)
+					m.On("AddGroup", g.Name, &group.AddGroupOptions{GID: g.GID}).Return(nil)
 					status, err := g.Apply()
 
-					m.AssertCalled(t, "AddGroup", g.Name, g.GID)
+					m.AssertCalled(t, "AddGroup", g.Name, &group.AddGroupOptions{GID: g.GID})
 					assert.NoError(t, err)
 					assert.Equal(t, fmt.Sprintf("added group %s with gid %s", g.Name, g.GID), status.Messages()[0])
 				})
@@ -623,10 +623,10 @@ func TestApply(t *testing.T) {
 
 					m.On("LookupGroup", g.Name).Return(new(user.Group), user.UnknownGroupError(""))
 					m.On("LookupGroupID", g.GID).Return(new(user.Group), user.UnknownGroupIdError(""))
-					m.On("AddGroup", g.Name, g.GID).Return(fmt.Errorf(""))
+					m.On("AddGroup", g.Name, &group.AddGroupOptions{GID: g.GID}).Return(fmt.Errorf(""))
 					status, err := g.Apply()
 
-					m.AssertCalled(t, "AddGroup", g.Name, g.GID)
+					m.AssertCalled(t, "AddGroup", g.Name, &group.AddGroupOptions{GID: g.GID})
 					assert.EqualError(t, err, "group add: ")
 					assert.Equal(t, resource.StatusFatal, status.StatusCode())
 					assert.Equal(t, fmt.Sprintf("error adding group %s with gid %s", g.Name, g.GID), status.Messages()[0])
@@ -645,10 +645,10 @@ func TestApply(t *testing.T) {
 
 					m.On("LookupGroup", g.Name).Return(grp, nil)
 					m.On("LookupGroupID", g.GID).Return(grp, nil)
-					m.On("AddGroup", g.Name, g.GID).Return(nil)
+					m.On("AddGroup", g.Name, &group.AddGroupOptions{GID: g.GID}).Return(nil)
 					status, err := g.Apply()
 
-					m.AssertNotCalled(t, "AddGroup", g.Name, g.GID)
+					m.AssertNotCalled(t, "AddGroup", g.Name, &group.AddGroupOptions{GID: g.GID})
 					assert.EqualError(t, err, fmt.Sprintf("will not attempt add/modify: group %s with gid %s", g.Name, g.GID))
 					assert.Equal(t, resource.StatusCantChange, status.StatusCode())
 				})
@@ -942,11 +942,11 @@ func TestApply(t *testing.T) {
 
 		m.On("LookupGroup", g.Name).Return(grp, nil)
 		m.On("LookupGroupID", g.GID).Return(grp, nil)
-		m.On("AddGroup", g.Name, g.GID)
+		m.On("AddGroup", g.Name, &group.AddGroupOptions{GID: g.GID})
 		m.On("DelGroup", g.Name)
 		_, err := g.Apply()
 
-		m.AssertNotCalled(t, "AddGroup", g.Name, g.GID)
+		m.AssertNotCalled(t, "AddGroup", g.Name, &group.AddGroupOptions{GID: g.GID})
 		m.AssertNotCalled(t, "DelGroup", g.Name)
 		assert.EqualError(t, err, fmt.Sprintf("group: unrecognized state %s", g.State))
 	})
@@ -980,6 +980,137 @@ func TestSetModGroupOptions(t *testing.T) {
 	})
 }
 
+// TestMembers tests reconciling the member list of a group
+func TestMembers(t *testing.T) {
+	t.Parallel()
+
+	grp := &user.Group{Name: fakeName, Gid: fakeGid}
+
+	newGroup := func(m *MockSystem) *group.Group {
+		g := group.NewGroup(m)
+		g.Name = grp.Name
+		g.State = group.StatePresent
+		g.Members = []string{"carol", "alice"}
+		return g
+	}
+
+	t.Run("check", func(t *testing.T) {
+		t.Run("drifted", func(t *testing.T) {
+			m := &MockSystem{}
+			g := newGroup(m)
+
+			m.On("LookupGroup", g.Name).Return(grp, nil)
+			m.On("LookupMembers", g.Name).Return([]string{"alice", "bob"}, nil)
+			status, err := g.Check(fakerenderer.New())
+
+			assert.NoError(t, err)
+			assert.Equal(t, resource.StatusWillChange, status.StatusCode())
+			assert.Equal(t, "alice, bob", status.Diffs()["members"].Original())
+			assert.Equal(t, "alice, carol", status.Diffs()["members"].Current())
+			assert.Contains(t, status.Messages(), "add members carol")
+			assert.Contains(t, status.Messages(), "remove members bob")
+		})
+
+		t.Run("in sync", func(t *testing.T) {
+			m := &MockSystem{}
+			g := newGroup(m)
+
+			m.On("LookupGroup", g.Name).Return(grp, nil)
+			m.On("LookupMembers", g.Name).Return([]string{"alice", "carol"}, nil)
+			status, err := g.Check(fakerenderer.New())
+
+			assert.NoError(t, err)
+			assert.False(t, status.HasChanges())
+		})
+
+		t.Run("new group", func(t *testing.T) {
+			m := &MockSystem{}
+			g := newGroup(m)
+
+			m.On("LookupGroup", g.Name).Return(new(user.Group), user.UnknownGroupError(""))
+			status, err := g.Check(fakerenderer.New())
+
+			assert.NoError(t, err)
+			assert.Equal(t, "", status.Diffs()["members"].Original())
+			m.AssertNotCalled(t, "LookupMembers", g.Name)
+		})
+
+		t.Run("empty list", func(t *testing.T) {
+			m := &MockSystem{}
+			g := newGroup(m)
+			g.Members = []string{}
+
+			m.On("LookupGroup", g.Name).Return(grp, nil)
+			m.On("LookupMembers", g.Name).Return([]string{"bob"}, nil)
+			status, err := g.Check(fakerenderer.New())
+
+			assert.NoError(t, err)
+			assert.Equal(t, resource.StatusWillChange, status.StatusCode())
+			assert.Equal(t, "", status.Diffs()["members"].Current())
+		})
+	})
+
+	t.Run("apply", func(t *testing.T) {
+		t.Run("existing group", func(t *testing.T) {
+			m := &MockSystem{}
+			g := newGroup(m)
+
+			m.On("LookupGroup", g.Name).Return(grp, nil)
+			m.On("LookupMembers", g.Name).Return([]string{"bob"}, nil)
+			m.On("SetMembers", g.Name, g.Members).Return(nil)
+			status, err := g.Apply()
+
+			assert.NoError(t, err)
+			m.AssertCalled(t, "SetMembers", g.Name, g.Members)
+			assert.Equal(t, fmt.Sprintf("set members of group %s to alice, carol", g.Name), status.Messages()[0])
+		})
+
+		t.Run("new system group", func(t *testing.T) {
+			m := &MockSystem{}
+			g := newGroup(m)
+			g.System = true
+
+			m.On("LookupGroup", g.Name).Return(new(user.Group), user.UnknownGroupError("")).Once()
+			m.On("AddGroup", g.Name, &group.AddGroupOptions{System: true}).Return(nil)
+			m.On("LookupMembers", g.Name).Return([]string(nil), nil)
+			m.On("SetMembers", g.Name, g.Members).Return(nil)
+			status, err := g.Apply()
+
+			assert.NoError(t, err)
+			assert.Equal(t, fmt.Sprintf("added group %s", g.Name), status.Messages()[0])
+			m.AssertCalled(t, "SetMembers", g.Name, g.Members)
+		})
+
+		t.Run("renamed group", func(t *testing.T) {
+			m := &MockSystem{}
+			g := newGroup(m)
+			g.NewName = "renamed"
+
+			m.On("LookupGroup", g.Name).Return(grp, nil)
+			m.On("LookupGroup", g.NewName).Return(new(user.Group), user.UnknownGroupError(""))
+			m.On("ModGroup", g.Name, &group.ModGroupOptions{NewName: g.NewName}).Return(nil)
+			m.On("LookupMembers", g.NewName).Return([]string{"alice", "carol"}, nil)
+			_, err := g.Apply()
+
+			assert.NoError(t, err)
+			m.AssertNotCalled(t, "SetMembers", g.NewName, g.Members)
+		})
+
+		t.Run("error setting members", func(t *testing.T) {
+			m := &MockSystem{}
+			g := newGroup(m)
+
+			m.On("LookupGroup", g.Name).Return(grp, nil)
+			m.On("LookupMembers", g.Name).Return([]string{"bob"}, nil)
+			m.On("SetMembers", g.Name, g.Members).Return(fmt.Errorf("gpasswd: exit status 3"))
+			status, err := g.Apply()
+
+			assert.EqualError(t, err, "group members: gpasswd: exit status 3")
+			assert.Equal(t, resource.StatusFatal, status.StatusCode())
+		})
+	})
+}
+
 // setGid is used to set a gid that exists but is not a match for
 // the current user group name (currName).
 func setGid() (string, error) {
@@ -1011,8 +1142,8 @@ type MockSystem struct {
 }
 
 // AddGroup for MockSystem
-func (m *MockSystem) AddGroup(name, gid string) error {
-	args := m.Called(name, gid)
+func (m *MockSystem) AddGroup(name string, options *group.AddGroupOptions) error {
+	args := m.Called(name, options)
 	return args.Error(0)
 }
 
@@ -1039,3 +1170,15 @@ func (m *MockSystem) LookupGroupID(gid string) (*user.Group, error) {
 	args := m.Called(gid)
 	return args.Get(0).(*user.Group), args.Error(1)
 }
+
+// LookupMembers looks up the members of a group
+func (m *MockSystem) LookupMembers(name string) ([]string, error) {
+	args := m.Called(name)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+// SetMembers sets the members of a group
+func (m *MockSystem) SetMembers(name string, members []string) error {
+	args := m.Called(name, members)
+	return args.Error(0)
+}
@@ -16,18 +16,20 @@ package group
 
 import (
 	"fmt"
-	"math"
 
+	"github.com/asteris-llc/converge/helpers/exec"
 	"github.com/asteris-llc/converge/load/registry"
 	"github.com/asteris-llc/converge/resource"
 )
 
 // Preparer for Group
 //
-// Group renders group data
+// Group renders group data. On Linux, groups are managed with groupadd and the
+// other shadow tools, with busybox's addgroup where those are missing, or by
+// editing /etc/group and /etc/gshadow directly where neither is installed.
 type Preparer struct {
 	// Gid is the group gid.
-	GID *uint32 `hcl:"gid"`
+	GID *uint32 `hcl:"gid" max:"4294967294"`
 
 	// Name is the group name.
 	Name string `hcl:"name" required:"true"`
@@ -36,24 +38,29 @@ type Preparer struct {
 	// The group Name will be changed to NewName.
 	NewName string `hcl:"new_name"`
 
+	// System creates the group as a system group. It only has an effect when
+	// the group is created.
+	System bool `hcl:"system"`
+
+	// Members is the complete list of users in the group. Users not in the
+	// list are removed from the group. If not set, membership is left alone.
+	Members []string `hcl:"members"`
+
 	// State is whether the group should be present.
 	State State `hcl:"state" valid_values:"present,absent"`
 }
 
 // Prepare a new task
 func (p *Preparer) Prepare(render resource.Renderer) (resource.Task, error) {
-	if p.GID != nil && *p.GID == math.MaxUint32 {
-		// the maximum gid on linux is MaxUint32 - 1
-		return nil, fmt.Errorf("group \"gid\" parameter out of range")
-	}
-
 	if p.State == "" {
 		p.State = StatePresent
 	}
 
-	grp := NewGroup(new(System))
+	grp := NewGroup(&System{Exec: exec.For(render)})
 	grp.Name = p.Name
 	grp.NewName = p.NewName
+	grp.System = p.System
+	grp.Members = p.Members
 	grp.State = p.State
 
 	if p.GID != nil {
@@ -63,6 +70,12 @@ func (p *Preparer) Prepare(render resource.Renderer) (resource.Task, error) {
 	return grp, nil
 }
 
+// Import reads an existing group from the system
+func (p *Preparer) Import(e exec.Executor, name string) (map[string]interface{}, error) {
+	return (&System{Exec: e}).Import(name)
+}
+
 func init() {
 	registry.Register("user.group", (*Preparer)(nil), (*Group)(nil))
+	registry.RegisterPlatforms("user.group", "linux")
 }
@@ -88,10 +88,10 @@ func TestPrepare(t *testing.T) {
 
 	t.Run("invalid", func(t *testing.T) {
 		t.Run("gid out of range", func(t *testing.T) {
-			p := group.Preparer{GID: &invalidGID, Name: "test"}
+			p := resource.NewPreparerWithSource(new(group.Preparer), map[string]interface{}{"name": "test", "gid": invalidGID})
 			_, err := p.Prepare(&fr)
 
-			assert.EqualError(t, err, fmt.Sprintf("group \"gid\" parameter out of range"))
+			assert.EqualError(t, err, fmt.Sprintf("\"gid\" must be at most %d, was %d", maxGID, invalidGID))
 		})
 	})
 }
@@ -0,0 +1,93 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/load/registry"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/confd"
+)
+
+// DefaultDir is the directory fragments are written to
+const DefaultDir = "/etc/haproxy/conf.d"
+
+// Preparer for Backend
+//
+// Backend manages a frontend, backend, or other section of the haproxy
+// configuration as a fragment in a directory. haproxy loads every file in a
+// directory passed with -f, in name order, so the service must be started
+// with both the main configuration and dir. The configuration is checked with
+// `haproxy -c` after the fragment is written. If the check fails the previous
+// fragment is restored, otherwise haproxy is reloaded.
+type Preparer struct {
+	// Name of the fragment, which is written to DIR/NAME.cfg
+	Name string `hcl:"name" required:"true"`
+
+	// Content of the fragment, such as a complete backend section
+	Content string `hcl:"content"`
+
+	// Dir is the directory of fragments. It defaults to /etc/haproxy/conf.d.
+	Dir string `hcl:"dir"`
+
+	// Config is the main configuration file, checked along with the
+	// fragments. It defaults to /etc/haproxy/haproxy.cfg.
+	Config string `hcl:"config"`
+
+	// Reload controls whether haproxy is reloaded after the fragment changes.
+	// It defaults to true.
+	Reload *bool `hcl:"reload"`
+
+	// State is whether the fragment should be present or absent. It defaults
+	// to present.
+	State confd.State `hcl:"state" valid_values:"present,absent"`
+}
+
+// Prepare a new task
+func (p *Preparer) Prepare(render resource.Renderer) (resource.Task, error) {
+	if p.Dir == "" {
+		p.Dir = DefaultDir
+	}
+
+	if p.Config == "" {
+		p.Config = "/etc/haproxy/haproxy.cfg"
+	}
+
+	reload := true
+	if p.Reload != nil {
+		reload = *p.Reload
+	}
+
+	service := confd.Service{
+		Unit:     "haproxy",
+		Validate: []string{"haproxy", "-c", "-f", p.Config, "-f", p.Dir},
+	}
+
+	fragment, err := confd.New(exec.For(render), service, p.Dir, p.Name, ".cfg", p.Content, p.State, reload)
+	if err != nil {
+		return nil, err
+	}
+	return &Backend{Fragment: fragment}, nil
+}
+
+// Backend is an haproxy configuration fragment
+type Backend struct {
+	*confd.Fragment
+}
+
+func init() {
+	registry.Register("haproxy.backend", (*Preparer)(nil), (*Backend)(nil))
+	registry.RegisterPlatforms("haproxy.backend", "linux")
+}
@@ -0,0 +1,45 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend_test
+
+import (
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/fakerenderer"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/haproxy/backend"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestBackendInterface tests that Backend is properly implemented
+func TestBackendInterface(t *testing.T) {
+	t.Parallel()
+
+	assert.Implements(t, (*resource.Task)(nil), new(backend.Backend))
+	assert.Implements(t, (*resource.Resource)(nil), new(backend.Preparer))
+}
+
+// TestPrepare tests the defaults of Prepare
+func TestPrepare(t *testing.T) {
+	t.Parallel()
+
+	task, err := (&backend.Preparer{Name: "app", Content: "backend app\n  server a 10.0.0.5:80"}).Prepare(fakerenderer.New())
+	require.NoError(t, err)
+
+	b := task.(*backend.Backend)
+	assert.Equal(t, "/etc/haproxy/conf.d/app.cfg", b.Path)
+	assert.Equal(t, []string{"haproxy", "-c", "-f", "/etc/haproxy/haproxy.cfg", "-f", "/etc/haproxy/conf.d"}, b.Service.Validate)
+}
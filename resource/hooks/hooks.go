@@ -0,0 +1,82 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hooks
+
+import (
+	"fmt"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/resource"
+)
+
+// Hooks are commands run around the apply of a module
+type Hooks struct {
+	resource.Status
+
+	Before []string
+	After  []string
+
+	// Exec runs the commands, so they inherit the execution settings of the
+	// module
+	Exec exec.Executor
+}
+
+// Check lists the hooks. They never have to change by themselves.
+func (h *Hooks) Check(resource.Renderer) (resource.TaskStatus, error) {
+	h.Status = resource.Status{}
+	for _, cmd := range h.Before {
+		h.AddMessage("before: " + cmd)
+	}
+	for _, cmd := range h.After {
+		h.AddMessage("after: " + cmd)
+	}
+
+	return h, nil
+}
+
+// Apply doesn't do anything, since hooks are run by the apply of the other
+// resources in the module
+func (h *Hooks) Apply() (resource.TaskStatus, error) {
+	return h, nil
+}
+
+// RunBefore runs the before commands, stopping at the first that fails
+func (h *Hooks) RunBefore() error {
+	return h.run("before", h.Before)
+}
+
+// RunAfter runs the after commands, stopping at the first that fails
+func (h *Hooks) RunAfter() error {
+	return h.run("after", h.After)
+}
+
+// Executor returns the executor the commands are run with
+func (h *Hooks) Executor() exec.Executor {
+	return h.Exec
+}
+
+func (h *Hooks) run(stage string, cmds []string) error {
+	executor := h.Exec
+	if executor == nil {
+		executor = exec.New()
+	}
+
+	for _, cmd := range cmds {
+		if err := exec.Run(executor, "sh", "-c", cmd); err != nil {
+			return fmt.Errorf("%s hook %q failed: %s", stage, cmd, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hooks_test
+
+import (
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/fakeexec"
+	"github.com/asteris-llc/converge/helpers/fakerenderer"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/hooks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHooksInterface(t *testing.T) {
+	t.Parallel()
+
+	assert.Implements(t, (*resource.Task)(nil), new(hooks.Hooks))
+}
+
+func TestHooksCheck(t *testing.T) {
+	t.Parallel()
+
+	h := &hooks.Hooks{Before: []string{"touch maint"}, After: []string{"rm maint"}}
+
+	status, err := h.Check(fakerenderer.New())
+	require.NoError(t, err)
+	assert.False(t, status.HasChanges())
+	assert.Equal(t, []string{"before: touch maint", "after: rm maint"}, status.Messages())
+}
+
+func TestHooksRun(t *testing.T) {
+	t.Parallel()
+
+	t.Run("before", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("sh", "-c", "touch maint").Once()
+		fake.Expect("sh", "-c", "systemctl stop app").Once()
+
+		h := &hooks.Hooks{
+			Before: []string{"touch maint", "systemctl stop app"},
+			After:  []string{"rm maint"},
+			Exec:   fake,
+		}
+
+		require.NoError(t, h.RunBefore())
+		fake.AssertExpectations(t)
+		assert.Len(t, fake.Calls(), 2)
+	})
+
+	t.Run("after", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("sh", "-c", "rm maint").Once()
+
+		h := &hooks.Hooks{
+			Before: []string{"touch maint"},
+			After:  []string{"rm maint"},
+			Exec:   fake,
+		}
+
+		require.NoError(t, h.RunAfter())
+		fake.AssertExpectations(t)
+		assert.Len(t, fake.Calls(), 1)
+	})
+
+	t.Run("failure", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("sh", "-c", "false").Return("", 1)
+
+		h := &hooks.Hooks{
+			Before: []string{"false", "touch maint"},
+			Exec:   fake,
+		}
+
+		assert.EqualError(t, h.RunBefore(), `before hook "false" failed: sh: exit status 1`)
+		assert.Len(t, fake.Calls(), 1)
+	})
+}
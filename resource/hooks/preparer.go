@@ -0,0 +1,49 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hooks
+
+import (
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/load/registry"
+	"github.com/asteris-llc/converge/resource"
+)
+
+// Preparer for hooks
+//
+// Hooks run commands around the apply of the module they are in, such as
+// turning on a maintenance mode before anything is changed and warming a
+// cache afterwards. The commands are not resources, so they don't need to be
+// added to the dependencies of everything in the module. They only run when
+// something in the module is applied, and at most once per apply.
+type Preparer struct {
+	// Before is a list of commands run in order just before the first resource
+	// in the module (or any module it calls) is applied. If one fails, nothing
+	// else in the module is applied.
+	Before []string `hcl:"before"`
+
+	// After is a list of commands run in order at the end of the apply, if
+	// the before commands ran. They run even if resources failed to apply, so
+	// that whatever Before did can be undone.
+	After []string `hcl:"after"`
+}
+
+// Prepare a new task
+func (p *Preparer) Prepare(render resource.Renderer) (resource.Task, error) {
+	return &Hooks{Before: p.Before, After: p.After, Exec: exec.For(render)}, nil
+}
+
+func init() {
+	registry.Register("hooks", (*Preparer)(nil), (*Hooks)(nil))
+}
@@ -0,0 +1,174 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"fmt"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// ChangeIgnorer is implemented by tasks with differences that should not be
+// reported as changes, such as values that are updated on their own or that
+// are managed out of band. The plan pipeline calls IgnoredChanges after Check
+// and drops the differences whose keys match any of the returned patterns,
+// which are matched with path.Match.
+type ChangeIgnorer interface {
+	IgnoredChanges() []string
+}
+
+// ignoring holds the node-level setting that ignores changes to some of a
+// resource's differences. Like "depends" and "group", it can be set on any
+// resource.
+type ignoring struct {
+	// IgnoreChanges lists the keys of differences to ignore, as shown by plan.
+	// Keys may contain shell patterns, like "/etc/app/*".
+	IgnoreChanges []string `hcl:"ignore_changes"`
+}
+
+// IgnoringChanges wraps a task so that changes to the differences named in
+// its ignore_changes setting are not reported
+type IgnoringChanges struct {
+	Wrapped
+
+	ignoreChanges []string
+}
+
+// IgnoredChanges returns the patterns set on the node along with any the
+// wrapped task ignores itself
+func (i *IgnoringChanges) IgnoredChanges() []string {
+	return append(append([]string{}, i.ignoreChanges...), i.Wrapped.IgnoredChanges()...)
+}
+
+// missing are the originals of differences that create something, rather
+// than change something that has drifted. They are never ignored.
+var missing = map[string]bool{"<absent>": true, "<file-missing>": true}
+
+// IgnoreChanges returns a view of status without the changes to differences
+// matching any of the patterns. The status is returned as-is if nothing is
+// ignored. Otherwise it only has changes if some of its remaining differences
+// do.
+func IgnoreChanges(status TaskStatus, patterns []string) TaskStatus {
+	if status == nil || len(patterns) == 0 {
+		return status
+	}
+
+	diffs := map[string]Diff{}
+	var ignored []string
+	for key, diff := range status.Diffs() {
+		if diff.Changes() && !missing[diff.Original()] && matchesAny(patterns, key) {
+			ignored = append(ignored, key)
+			continue
+		}
+		diffs[key] = diff
+	}
+
+	if len(ignored) == 0 {
+		return status
+	}
+
+	sort.Strings(ignored)
+	return &ignoredStatus{TaskStatus: status, diffs: diffs, ignored: ignored}
+}
+
+func matchesAny(patterns []string, key string) bool {
+	for _, pattern := range patterns {
+		if pattern == key {
+			return true
+		}
+		if ok, err := path.Match(pattern, key); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
+
+// ignoredStatus is a TaskStatus with some of its changes ignored
+type ignoredStatus struct {
+	TaskStatus
+
+	diffs   map[string]Diff
+	ignored []string
+}
+
+func (s *ignoredStatus) Diffs() map[string]Diff { return s.diffs }
+
+func (s *ignoredStatus) HasChanges() bool {
+	for _, diff := range s.diffs {
+		if diff.Changes() {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *ignoredStatus) StatusCode() StatusLevel {
+	level := s.TaskStatus.StatusCode()
+	if level == StatusWillChange && !s.HasChanges() {
+		return StatusNoChange
+	}
+	return level
+}
+
+func (s *ignoredStatus) Messages() []string {
+	return append(
+		append([]string{}, s.TaskStatus.Messages()...),
+		fmt.Sprintf("ignored changes to %s", strings.Join(s.ignored, ", ")),
+	)
+}
+
+// SetError sets the error on the underlying status
+func (s *ignoredStatus) SetError(err error) {
+	if settable, ok := s.TaskStatus.(interface {
+		SetError(error)
+	}); ok {
+		settable.SetError(err)
+	}
+}
+
+// PendingReboots returns the reboots required by the underlying status
+func (s *ignoredStatus) PendingReboots() []string {
+	return PendingReboots(s.TaskStatus)
+}
+
+// Warnings returns the warnings of the underlying status
+func (s *ignoredStatus) Warnings() []Warning {
+	return Warnings(s.TaskStatus)
+}
+
+// prepareIgnore wraps task in an IgnoringChanges if the node sets
+// "ignore_changes"
+func (p *Preparer) prepareIgnore(r Renderer, task Task) (Task, error) {
+	field, _ := reflect.TypeOf(ignoring{}).FieldByName("IgnoreChanges")
+	if _, ok := p.Source[p.getFieldName(field)]; !ok {
+		return task, nil
+	}
+
+	val, err := p.getValueForField(r, field)
+	if err != nil {
+		return nil, err
+	}
+
+	patterns := val.Interface().([]string)
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("ignore_changes: %q is not a valid pattern", pattern)
+		}
+	}
+
+	return &IgnoringChanges{Wrapped: Wrapped{Task: task}, ignoreChanges: patterns}, nil
+}
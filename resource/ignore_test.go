@@ -0,0 +1,112 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource_test
+
+import (
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/fakerenderer"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestIgnoreChanges tests leaving ignored differences out of a status
+func TestIgnoreChanges(t *testing.T) {
+	t.Parallel()
+
+	changed := func() *resource.Status {
+		status := resource.NewStatus()
+		status.RaiseLevel(resource.StatusWillChange)
+		status.AddDifference("/etc/app/a.conf", "old", "new", "")
+		status.AddDifference("/etc/app/b.conf", "old", "new", "")
+		status.AddDifference("owner", "root", "root", "")
+		return status
+	}
+
+	t.Run("nothing ignored", func(t *testing.T) {
+		status := changed()
+		assert.Equal(t, status, resource.IgnoreChanges(status, nil))
+		assert.Equal(t, status, resource.IgnoreChanges(status, []string{"mtime"}))
+	})
+
+	t.Run("unchanged differences are kept", func(t *testing.T) {
+		status := resource.NewStatus()
+		status.AddDifference("owner", "root", "root", "")
+		assert.Equal(t, status, resource.IgnoreChanges(status, []string{"owner"}))
+	})
+
+	t.Run("all changes ignored", func(t *testing.T) {
+		status := resource.IgnoreChanges(changed(), []string{"/etc/app/*"})
+		assert.False(t, status.HasChanges())
+		assert.Equal(t, resource.StatusNoChange, status.StatusCode())
+		assert.Equal(t, []string{"owner"}, keys(status.Diffs()))
+		assert.Equal(t, []string{"ignored changes to /etc/app/a.conf, /etc/app/b.conf"}, status.Messages())
+	})
+
+	t.Run("some changes ignored", func(t *testing.T) {
+		status := resource.IgnoreChanges(changed(), []string{"/etc/app/a.conf"})
+		assert.True(t, status.HasChanges())
+		assert.Equal(t, resource.StatusWillChange, status.StatusCode())
+	})
+
+	t.Run("creation is kept", func(t *testing.T) {
+		status := resource.NewStatus()
+		status.AddDifference("/etc/app/a.conf", "<file-missing>", "new", "")
+		assert.Equal(t, status, resource.IgnoreChanges(status, []string{"/etc/app/a.conf"}))
+	})
+
+	t.Run("errors are kept", func(t *testing.T) {
+		status := changed()
+		status.RaiseLevel(resource.StatusFatal)
+		ignored := resource.IgnoreChanges(status, []string{"*"})
+		assert.Equal(t, resource.StatusFatal, ignored.StatusCode())
+	})
+}
+
+// TestPreparerIgnoreChanges tests that ignore_changes is accepted on any
+// resource
+func TestPreparerIgnoreChanges(t *testing.T) {
+	t.Parallel()
+
+	t.Run("set", func(t *testing.T) {
+		prep := resource.NewPreparerWithSource(new(testExecutionTarget), map[string]interface{}{
+			"ignore_changes": []string{"mtime"},
+		})
+
+		task, err := prep.Prepare(fakerenderer.New())
+		require.NoError(t, err)
+
+		ignorer, ok := task.(resource.ChangeIgnorer)
+		require.True(t, ok)
+		assert.Equal(t, []string{"mtime"}, ignorer.IgnoredChanges())
+	})
+
+	t.Run("invalid pattern", func(t *testing.T) {
+		prep := resource.NewPreparerWithSource(new(testExecutionTarget), map[string]interface{}{
+			"ignore_changes": []string{"[mtime"},
+		})
+
+		_, err := prep.Prepare(fakerenderer.New())
+		assert.EqualError(t, err, `ignore_changes: "[mtime" is not a valid pattern`)
+	})
+}
+
+func keys(diffs map[string]resource.Diff) (out []string) {
+	for key := range diffs {
+		out = append(out, key)
+	}
+	return out
+}
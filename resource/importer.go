@@ -0,0 +1,107 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/hashicorp/hcl/hcl/printer"
+)
+
+// Importer is implemented by preparers that can read an existing object from
+// the system and describe the resource that would reproduce it. The returned
+// fields are keyed by their HCL name.
+type Importer interface {
+	Import(e exec.Executor, name string) (map[string]interface{}, error)
+}
+
+// ImportHCL formats imported fields as a resource block of the given kind. The
+// fields are written in the order they are declared on the preparer, and any
+// the preparer doesn't declare are written after them in sorted order.
+func ImportHCL(kind, name string, preparer interface{}, fields map[string]interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%s %s {\n", kind, strconv.Quote(name))
+
+	for _, key := range importOrder(preparer, fields) {
+		value, err := hclValue(fields[key])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", key, err)
+		}
+		fmt.Fprintf(&buf, "%s = %s\n", key, value)
+	}
+
+	buf.WriteString("}\n")
+
+	return printer.Format(buf.Bytes())
+}
+
+// importOrder lists the keys of fields in the order of the preparer's hcl tags
+func importOrder(preparer interface{}, fields map[string]interface{}) []string {
+	var keys []string
+	seen := make(map[string]bool)
+
+	typ := reflect.TypeOf(preparer)
+	for typ != nil && typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+
+	if typ != nil && typ.Kind() == reflect.Struct {
+		for i := 0; i < typ.NumField(); i++ {
+			key := strings.Split(typ.Field(i).Tag.Get("hcl"), ",")[0]
+			if _, ok := fields[key]; ok && !seen[key] {
+				keys = append(keys, key)
+				seen[key] = true
+			}
+		}
+	}
+
+	var rest []string
+	for key := range fields {
+		if !seen[key] {
+			rest = append(rest, key)
+		}
+	}
+	sort.Strings(rest)
+
+	return append(keys, rest...)
+}
+
+func hclValue(value interface{}) (string, error) {
+	switch value := value.(type) {
+	case string:
+		return strconv.Quote(value), nil
+
+	case bool:
+		return strconv.FormatBool(value), nil
+
+	case int, int32, int64, uint, uint32, uint64:
+		return fmt.Sprintf("%d", value), nil
+
+	case []string:
+		quoted := make([]string, len(value))
+		for i, item := range value {
+			quoted[i] = strconv.Quote(item)
+		}
+		return "[" + strings.Join(quoted, ", ") + "]", nil
+	}
+
+	return "", fmt.Errorf("cannot write %T as HCL", value)
+}
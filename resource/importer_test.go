@@ -0,0 +1,61 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource_test
+
+import (
+	"testing"
+
+	"github.com/asteris-llc/converge/resource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestImportHCL tests formatting imported fields as a resource block
+func TestImportHCL(t *testing.T) {
+	t.Parallel()
+
+	type preparer struct {
+		Name    string   `hcl:"name"`
+		Count   *int     `hcl:"count"`
+		Enabled bool     `hcl:"enabled"`
+		Tags    []string `hcl:"tags"`
+	}
+
+	t.Run("ordered by preparer", func(t *testing.T) {
+		out, err := resource.ImportHCL("test.thing", "x", &preparer{}, map[string]interface{}{
+			"tags":    []string{"a", "b"},
+			"extra":   "z",
+			"count":   uint64(3),
+			"name":    "say \"hi\"",
+			"enabled": true,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, `test.thing "x" {
+  name    = "say \"hi\""
+  count   = 3
+  enabled = true
+  tags    = ["a", "b"]
+  extra   = "z"
+}
+`, string(out))
+	})
+
+	t.Run("unsupported value", func(t *testing.T) {
+		_, err := resource.ImportHCL("test.thing", "x", &preparer{}, map[string]interface{}{
+			"name": 1.5,
+		})
+		assert.EqualError(t, err, "name: cannot write float64 as HCL")
+	})
+}
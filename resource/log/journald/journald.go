@@ -0,0 +1,136 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package journald
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/systemd"
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultPath is the location of journald.conf
+	DefaultPath = "/etc/systemd/journald.conf"
+
+	// Service is the unit restarted when the file changes
+	Service = "systemd-journald"
+
+	// Section is the section of journald.conf keys are set in
+	Section = "Journal"
+
+	// Mode is the mode the file is written with
+	Mode = 0644
+)
+
+// Journald manages keys in journald.conf
+type Journald struct {
+	resource.Status
+
+	Settings map[string]string
+	Path     string
+	Restart  bool
+
+	exec exec.Executor
+}
+
+// Check whether every key has the declared value
+func (j *Journald) Check(resource.Renderer) (resource.TaskStatus, error) {
+	j.Status = resource.Status{}
+
+	content, err := j.read()
+	if err != nil {
+		j.RaiseLevel(resource.StatusFatal)
+		return j, err
+	}
+
+	current := Parse(content)
+	for _, key := range j.keys() {
+		value, ok := current[key]
+		if !ok {
+			value = "<unset>"
+		}
+
+		if value != j.Settings[key] {
+			j.RaiseLevel(resource.StatusWillChange)
+			j.AddDifference(key, value, j.Settings[key], "")
+		}
+	}
+
+	return j, nil
+}
+
+// Apply writes the declared keys and restarts journald
+func (j *Journald) Apply() (resource.TaskStatus, error) {
+	j.Status = resource.Status{}
+
+	content, err := j.read()
+	if err != nil {
+		j.RaiseLevel(resource.StatusFatal)
+		return j, err
+	}
+
+	if err := exec.WriteFile(j.exec, j.Path, Set(content, j.Settings), Mode); err != nil {
+		j.RaiseLevel(resource.StatusFatal)
+		return j, errors.Wrapf(err, "cannot write %s", j.Path)
+	}
+	j.AddMessage(fmt.Sprintf("updated %s", j.Path))
+
+	// journald does not reread its configuration on reload
+	if j.Restart {
+		if err := exec.Run(j.exec, "systemctl", "restart", Service); err != nil {
+			j.RaiseLevel(resource.StatusFatal)
+			return j, errors.Wrapf(err, "cannot restart %s", Service)
+		}
+		j.AddMessage(fmt.Sprintf("restarted %s", Service))
+	}
+
+	return j, nil
+}
+
+// read returns the content of the file. A missing file is treated as empty,
+// since journald uses its defaults without one.
+func (j *Journald) read() (string, error) {
+	content, _, err := exec.ReadFile(j.exec, j.Path)
+	if err != nil {
+		return "", errors.Wrapf(err, "cannot read %s", j.Path)
+	}
+	return content, nil
+}
+
+func (j *Journald) keys() []string {
+	var keys []string
+	for key := range j.Settings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Parse returns the keys set in the [Journal] section of content. Commented
+// keys, which document the defaults, are not included.
+func Parse(content string) map[string]string {
+	return systemd.ParseSection(content, Section)
+}
+
+// Set returns content with the given keys set in the [Journal] section.
+// Existing keys are updated in place, and other keys are added after the last
+// line of the section. The section is added if it does not exist.
+func Set(content string, settings map[string]string) string {
+	return systemd.SetSection(content, Section, settings)
+}
@@ -0,0 +1,126 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package journald_test
+
+import (
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/helpers/fakeexec"
+	"github.com/asteris-llc/converge/helpers/fakerenderer"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/log/journald"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	path = "/etc/systemd/journald.conf"
+	conf = `# See journald.conf(5) for details.
+
+[Journal]
+#Storage=auto
+#Compress=yes
+RateLimitBurst=1000
+`
+)
+
+// TestJournaldInterface tests that Journald is properly implemented
+func TestJournaldInterface(t *testing.T) {
+	t.Parallel()
+
+	assert.Implements(t, (*resource.Task)(nil), new(journald.Journald))
+	assert.Implements(t, (*resource.Resource)(nil), new(journald.Preparer))
+}
+
+// TestParse tests reading keys from the [Journal] section
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, map[string]string{"RateLimitBurst": "1000"}, journald.Parse(conf))
+}
+
+// TestSet tests updating and adding keys
+func TestSet(t *testing.T) {
+	t.Parallel()
+
+	t.Run("existing section", func(t *testing.T) {
+		out := journald.Set(conf, map[string]string{"Storage": "persistent", "RateLimitBurst": "5000"})
+		assert.Equal(t, `# See journald.conf(5) for details.
+
+[Journal]
+#Storage=auto
+#Compress=yes
+RateLimitBurst=5000
+Storage=persistent
+`, out)
+	})
+
+	t.Run("empty file", func(t *testing.T) {
+		out := journald.Set("", map[string]string{"Storage": "persistent"})
+		assert.Equal(t, "[Journal]\nStorage=persistent\n", out)
+	})
+}
+
+// TestCheck tests that Check reports changed keys
+func TestCheck(t *testing.T) {
+	t.Parallel()
+
+	fake := fakeexec.New()
+	fake.Expect("test", "-e", path)
+	fake.Expect("cat", path).Return(conf, 0)
+
+	j := prepare(t, fake, &journald.Preparer{Settings: map[string]string{"Storage": "persistent", "RateLimitBurst": "1000"}})
+	status, err := j.Check(fakerenderer.New())
+
+	require.NoError(t, err)
+	assert.True(t, status.HasChanges())
+	assert.Equal(t, "<unset>", status.Diffs()["Storage"].Original())
+	assert.NotContains(t, status.Diffs(), "RateLimitBurst")
+}
+
+// TestApply tests that Apply writes the file and restarts journald
+func TestApply(t *testing.T) {
+	t.Parallel()
+
+	fake := fakeexec.New()
+	fake.Expect("test", "-e", path).Return("", 1)
+	fake.Expect("sh", "-c", exec.WriteFileScript, path, "0644")
+	fake.Expect("systemctl", "restart", "systemd-journald")
+
+	j := prepare(t, fake, &journald.Preparer{Settings: map[string]string{"Storage": "persistent"}})
+	status, err := j.Apply()
+
+	require.NoError(t, err)
+	assert.Contains(t, status.Messages(), "restarted systemd-journald")
+	fake.AssertExpectations(t)
+}
+
+// TestPrepare tests the valid and invalid cases of Prepare
+func TestPrepare(t *testing.T) {
+	t.Parallel()
+
+	_, err := (&journald.Preparer{Settings: map[string]string{"Storage": "disk"}}).Prepare(fakerenderer.New())
+	assert.EqualError(t, err, "journald: Storage must be one of volatile, persistent, auto, none")
+
+	_, err = (&journald.Preparer{Settings: map[string]string{"Rate Limit": "1"}}).Prepare(fakerenderer.New())
+	assert.EqualError(t, err, `journald: "Rate Limit" is not a valid key`)
+}
+
+func prepare(t *testing.T, fake *fakeexec.Executor, p *journald.Preparer) *journald.Journald {
+	task, err := p.Prepare(&resource.ExecRenderer{Renderer: fakerenderer.New(), Exec: fake})
+	require.NoError(t, err)
+	return task.(*journald.Journald)
+}
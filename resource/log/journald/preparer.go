@@ -0,0 +1,82 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package journald
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/load/registry"
+	"github.com/asteris-llc/converge/resource"
+)
+
+// Preparer for Journald
+//
+// Journald sets keys in the [Journal] section of journald.conf, such as
+// Storage or RateLimitBurst, and restarts systemd-journald when they change.
+// Keys that are not declared are left alone.
+type Preparer struct {
+	// Settings maps keys to the values they should have
+	Settings map[string]string `hcl:"settings" required:"true"`
+
+	// Path is the file to manage. It defaults to /etc/systemd/journald.conf.
+	Path string `hcl:"path"`
+
+	// Restart controls whether systemd-journald is restarted after the file
+	// changes. It defaults to true.
+	Restart *bool `hcl:"restart"`
+}
+
+// Prepare a new task
+func (p *Preparer) Prepare(render resource.Renderer) (resource.Task, error) {
+	for key, value := range p.Settings {
+		if key == "" || strings.ContainsAny(key, " \t\n=#[]") {
+			return nil, fmt.Errorf("journald: %q is not a valid key", key)
+		}
+		if strings.Contains(value, "\n") {
+			return nil, fmt.Errorf("journald: %q must have a single-line value", key)
+		}
+	}
+
+	if storage, ok := p.Settings["Storage"]; ok {
+		switch storage {
+		case "volatile", "persistent", "auto", "none":
+		default:
+			return nil, fmt.Errorf("journald: Storage must be one of volatile, persistent, auto, none")
+		}
+	}
+
+	if p.Path == "" {
+		p.Path = DefaultPath
+	}
+
+	restart := true
+	if p.Restart != nil {
+		restart = *p.Restart
+	}
+
+	return &Journald{
+		Settings: p.Settings,
+		Path:     p.Path,
+		Restart:  restart,
+		exec:     exec.For(render),
+	}, nil
+}
+
+func init() {
+	registry.Register("log.journald", (*Preparer)(nil), (*Journald)(nil))
+	registry.RegisterPlatforms("log.journald", "linux")
+}
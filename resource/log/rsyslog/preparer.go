@@ -0,0 +1,121 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rsyslog
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/load/registry"
+	"github.com/asteris-llc/converge/resource"
+)
+
+// Preparer for Forward
+//
+// Forward writes a rule to /etc/rsyslog.d that forwards messages to a remote
+// host. The rule is checked with `rsyslogd -N1` before it is installed, and
+// rsyslog is restarted afterwards.
+type Preparer struct {
+	// Name is the name of the file in /etc/rsyslog.d, without ".conf"
+	Name string `hcl:"name" required:"true"`
+
+	// Target is the host messages are forwarded to
+	Target string `hcl:"target"`
+
+	// Port is the port on the target. It defaults to 514.
+	Port *int `hcl:"port" min:"1" max:"65535"`
+
+	// Protocol is the transport used to forward messages
+	Protocol string `hcl:"protocol" valid_values:"udp,tcp"`
+
+	// Selector chooses the messages to forward, such as "auth,authpriv.*". It
+	// defaults to all messages.
+	Selector string `hcl:"selector"`
+
+	// Queue buffers messages on disk while the target cannot be reached. It is
+	// only useful with tcp.
+	Queue bool `hcl:"queue"`
+
+	// Restart controls whether rsyslog is restarted after the file changes. It
+	// defaults to true.
+	Restart *bool `hcl:"restart"`
+
+	// State is whether the rule should be present
+	State State `hcl:"state" valid_values:"present,absent"`
+}
+
+// Prepare a new task
+func (p *Preparer) Prepare(render resource.Renderer) (resource.Task, error) {
+	if strings.ContainsAny(p.Name, "/") || strings.HasPrefix(p.Name, ".") {
+		return nil, fmt.Errorf("rsyslog \"name\" must be a file name in %s", Dir)
+	}
+
+	if p.State == "" {
+		p.State = StatePresent
+	}
+
+	restart := true
+	if p.Restart != nil {
+		restart = *p.Restart
+	}
+
+	f := &Forward{
+		Path:    path.Join(Dir, strings.TrimSuffix(p.Name, ".conf")+".conf"),
+		State:   p.State,
+		Restart: restart,
+		exec:    exec.For(render),
+	}
+
+	if p.State == StateAbsent {
+		return f, nil
+	}
+
+	if p.Target == "" {
+		return nil, fmt.Errorf("rsyslog \"target\" is required when state is %q", StatePresent)
+	}
+
+	port := 514
+	if p.Port != nil {
+		port = *p.Port
+	}
+
+	if p.Protocol == "" {
+		p.Protocol = "udp"
+	}
+
+	if p.Selector == "" {
+		p.Selector = "*.*"
+	}
+
+	if p.Queue && p.Protocol != "tcp" {
+		return nil, fmt.Errorf("rsyslog \"queue\" requires the tcp protocol")
+	}
+
+	for _, field := range []string{p.Target, p.Selector} {
+		if strings.ContainsAny(field, "\"\n") {
+			return nil, fmt.Errorf("rsyslog fields cannot contain quotes or newlines")
+		}
+	}
+
+	f.Content = rule(p.Name, p.Selector, p.Target, port, p.Protocol, p.Queue)
+	return f, nil
+}
+
+func init() {
+	registry.Register("log.rsyslog_forward", (*Preparer)(nil), (*Forward)(nil))
+	registry.RegisterPlatforms("log.rsyslog_forward", "linux")
+}
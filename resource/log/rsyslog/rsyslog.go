@@ -0,0 +1,177 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rsyslog
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/pkg/errors"
+)
+
+// State type for Forward
+type State string
+
+const (
+	// StatePresent indicates the rule should be present
+	StatePresent State = "present"
+
+	// StateAbsent indicates the rule should be absent
+	StateAbsent State = "absent"
+
+	// Dir is the directory rules are written to
+	Dir = "/etc/rsyslog.d"
+
+	// Service is the unit restarted when a rule changes
+	Service = "rsyslog"
+
+	// Mode is the mode rules are written with
+	Mode = 0644
+)
+
+// Forward manages an rsyslog forwarding rule
+type Forward struct {
+	resource.Status
+
+	Path    string
+	Content string
+	State   State
+	Restart bool
+
+	exec exec.Executor
+}
+
+// Check whether the rule has the rendered content
+func (f *Forward) Check(resource.Renderer) (resource.TaskStatus, error) {
+	f.Status = resource.Status{}
+
+	current, exists, err := exec.ReadFile(f.exec, f.Path)
+	if err != nil {
+		f.RaiseLevel(resource.StatusFatal)
+		return f, errors.Wrapf(err, "cannot read %s", f.Path)
+	}
+	if !exists {
+		current = "<file-missing>"
+	}
+
+	switch f.State {
+	case StatePresent:
+		if !exists || current != f.Content {
+			f.RaiseLevel(resource.StatusWillChange)
+			f.AddDifference(f.Path, current, f.Content, "")
+		}
+
+	case StateAbsent:
+		if exists {
+			f.RaiseLevel(resource.StatusWillChange)
+			f.AddDifference(f.Path, current, "<file-missing>", "")
+		}
+
+	default:
+		f.RaiseLevel(resource.StatusFatal)
+		return f, fmt.Errorf("rsyslog: unrecognized state %s", f.State)
+	}
+
+	return f, nil
+}
+
+// Apply installs or removes the rule and restarts rsyslog
+func (f *Forward) Apply() (resource.TaskStatus, error) {
+	f.Status = resource.Status{}
+
+	var err error
+	if f.State == StateAbsent {
+		err = exec.Run(f.exec, "rm", "-f", f.Path)
+	} else {
+		err = f.install()
+	}
+	if err != nil {
+		f.RaiseLevel(resource.StatusFatal)
+		return f, err
+	}
+	f.AddMessage(fmt.Sprintf("updated %s", f.Path))
+
+	if f.Restart {
+		if err := exec.Run(f.exec, "systemctl", "restart", Service); err != nil {
+			f.RaiseLevel(resource.StatusFatal)
+			return f, errors.Wrapf(err, "cannot restart %s", Service)
+		}
+		f.AddMessage(fmt.Sprintf("restarted %s", Service))
+	}
+
+	return f, nil
+}
+
+// ManagedFiles returns the path of the forwarding config, so it is backed up before being
+// changed
+func (f *Forward) ManagedFiles() []string {
+	return []string{f.Path}
+}
+
+// Executor returns the executor the file is written with
+func (f *Forward) Executor() exec.Executor {
+	return f.exec
+}
+
+// install writes the rule to a file rsyslog does not include, checks it, and
+// moves it into place
+func (f *Forward) install() error {
+	dir, name := path.Split(f.Path)
+	staged := path.Join(dir, "."+name+".converge")
+
+	if err := exec.WriteFile(f.exec, staged, f.Content, Mode); err != nil {
+		return errors.Wrapf(err, "cannot write %s", staged)
+	}
+
+	if err := exec.Run(f.exec, "rsyslogd", "-N1", "-f", staged); err != nil {
+		if rmErr := exec.Run(f.exec, "rm", "-f", staged); rmErr != nil {
+			f.AddMessage(fmt.Sprintf("could not remove %s: %s", staged, rmErr))
+		}
+		return errors.Wrapf(err, "refusing to install invalid rule %s", f.Path)
+	}
+
+	if err := exec.Run(f.exec, "mv", "-f", staged, f.Path); err != nil {
+		return errors.Wrapf(err, "cannot install %s", f.Path)
+	}
+	return nil
+}
+
+func rule(name, selector, target string, port int, protocol string, queue bool) string {
+	params := []string{
+		`type="omfwd"`,
+		fmt.Sprintf("target=%q", target),
+		fmt.Sprintf(`port="%d"`, port),
+		fmt.Sprintf("protocol=%q", protocol),
+	}
+
+	if queue {
+		params = append(
+			params,
+			`queue.type="LinkedList"`,
+			fmt.Sprintf("queue.filename=%q", "fwd_"+name),
+			`queue.saveOnShutdown="on"`,
+			`action.resumeRetryCount="-1"`,
+		)
+	}
+
+	return fmt.Sprintf(
+		"%s action(%s)\n",
+		selector,
+		strings.Join(params, " "),
+	)
+}
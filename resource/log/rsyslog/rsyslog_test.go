@@ -0,0 +1,160 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rsyslog_test
+
+import (
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/helpers/fakeexec"
+	"github.com/asteris-llc/converge/helpers/fakerenderer"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/log/rsyslog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	path   = "/etc/rsyslog.d/central.conf"
+	staged = "/etc/rsyslog.d/.central.conf.converge"
+	write  = exec.WriteFileScript
+)
+
+// TestForwardInterface tests that Forward is properly implemented
+func TestForwardInterface(t *testing.T) {
+	t.Parallel()
+
+	assert.Implements(t, (*resource.Task)(nil), new(rsyslog.Forward))
+	assert.Implements(t, (*resource.Resource)(nil), new(rsyslog.Preparer))
+}
+
+// TestPrepare tests rendering rules and the invalid cases of Prepare
+func TestPrepare(t *testing.T) {
+	t.Parallel()
+
+	fr := fakerenderer.New()
+
+	t.Run("defaults", func(t *testing.T) {
+		f := prepare(t, fakeexec.New(), &rsyslog.Preparer{Name: "central", Target: "logs.example.com"})
+		assert.Equal(t, path, f.Path)
+		assert.Equal(t, `*.* action(type="omfwd" target="logs.example.com" port="514" protocol="udp")`+"\n", f.Content)
+	})
+
+	t.Run("queue", func(t *testing.T) {
+		port := 6514
+		f := prepare(t, fakeexec.New(), &rsyslog.Preparer{
+			Name:     "central",
+			Target:   "logs.example.com",
+			Port:     &port,
+			Protocol: "tcp",
+			Selector: "auth,authpriv.*",
+			Queue:    true,
+		})
+		assert.Equal(t, `auth,authpriv.* action(type="omfwd" target="logs.example.com" port="6514" protocol="tcp" queue.type="LinkedList" queue.filename="fwd_central" queue.saveOnShutdown="on" action.resumeRetryCount="-1")`+"\n", f.Content)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := (&rsyslog.Preparer{Name: "central"}).Prepare(fr)
+		assert.EqualError(t, err, `rsyslog "target" is required when state is "present"`)
+
+		_, err = (&rsyslog.Preparer{Name: "central", Target: "logs", Queue: true}).Prepare(fr)
+		assert.EqualError(t, err, `rsyslog "queue" requires the tcp protocol`)
+
+		_, err = (&rsyslog.Preparer{Name: "central", Target: `logs" type="omfile`}).Prepare(fr)
+		assert.EqualError(t, err, "rsyslog fields cannot contain quotes or newlines")
+	})
+}
+
+// TestCheck tests the possible cases Check handles
+func TestCheck(t *testing.T) {
+	t.Parallel()
+
+	t.Run("missing", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("test", "-e", path).Return("", 1)
+
+		f := prepare(t, fake, &rsyslog.Preparer{Name: "central", Target: "logs"})
+		status, err := f.Check(fakerenderer.New())
+
+		require.NoError(t, err)
+		assert.Equal(t, "<file-missing>", status.Diffs()[path].Original())
+	})
+
+	t.Run("converged", func(t *testing.T) {
+		fake := fakeexec.New()
+		f := prepare(t, fake, &rsyslog.Preparer{Name: "central", Target: "logs"})
+		fake.Expect("test", "-e", path)
+		fake.Expect("cat", path).Return(f.Content, 0)
+
+		status, err := f.Check(fakerenderer.New())
+
+		require.NoError(t, err)
+		assert.False(t, status.HasChanges())
+	})
+}
+
+// TestApply tests the possible cases Apply handles
+func TestApply(t *testing.T) {
+	t.Parallel()
+
+	t.Run("valid", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("sh", "-c", write, staged, "0644")
+		fake.Expect("rsyslogd", "-N1", "-f", staged)
+		fake.Expect("mv", "-f", staged, path)
+		fake.Expect("systemctl", "restart", "rsyslog")
+
+		f := prepare(t, fake, &rsyslog.Preparer{Name: "central", Target: "logs"})
+		_, err := f.Apply()
+
+		require.NoError(t, err)
+		fake.AssertExpectations(t)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("sh", "-c", write, staged, "0644")
+		fake.Expect("rsyslogd", "-N1", "-f", staged).Stderr("invalid character").Return("", 1)
+		fake.Expect("rm", "-f", staged)
+
+		f := prepare(t, fake, &rsyslog.Preparer{Name: "central", Target: "logs"})
+		status, err := f.Apply()
+
+		require.Error(t, err)
+		assert.Equal(t, resource.StatusFatal, status.StatusCode())
+		fake.AssertExpectations(t)
+		for _, call := range fake.Calls() {
+			assert.NotEqual(t, "systemctl", call.Name, "rsyslog must not be restarted")
+		}
+	})
+
+	t.Run("absent", func(t *testing.T) {
+		restart := false
+		fake := fakeexec.New()
+		fake.Expect("rm", "-f", path)
+
+		f := prepare(t, fake, &rsyslog.Preparer{Name: "central", State: rsyslog.StateAbsent, Restart: &restart})
+		_, err := f.Apply()
+
+		require.NoError(t, err)
+		assert.Len(t, fake.Calls(), 1)
+	})
+}
+
+func prepare(t *testing.T, fake *fakeexec.Executor, p *rsyslog.Preparer) *rsyslog.Forward {
+	task, err := p.Prepare(&resource.ExecRenderer{Renderer: fakerenderer.New(), Exec: fake})
+	require.NoError(t, err)
+	return task.(*rsyslog.Forward)
+}
@@ -0,0 +1,216 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lowlevel
+
+import (
+	"strings"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/pkg/errors"
+)
+
+// reportFlags make the LVM reporting commands print JSON with sizes in bytes
+var reportFlags = []string{"--reportformat", "json", "--units", "b", "--nosuffix"}
+
+var (
+	pvFields  = []string{"pv_name", "pv_uuid", "vg_name", "pv_size", "pv_free", "pv_used", "pv_attr"}
+	vgFields  = []string{"vg_name", "vg_uuid", "vg_size", "vg_free", "vg_extent_size", "vg_extent_count", "vg_free_count", "pv_count", "lv_count", "vg_attr", "vg_tags"}
+	lvFields  = []string{"lv_name", "vg_name", "lv_uuid", "lv_path", "lv_size", "lv_attr", "origin", "data_percent", "lv_health_status", "lv_time", "lv_tags"}
+	segFields = []string{"lv_name", "vg_name", "segtype", "seg_start", "seg_size", "devices"}
+)
+
+// LVM reads the state of physical volumes, volume groups and logical volumes
+// from the LVM reporting commands
+type LVM struct {
+	exec exec.Executor
+}
+
+// New returns an LVM that runs commands with the given executor
+func New(e exec.Executor) *LVM {
+	return &LVM{exec: e}
+}
+
+// PhysicalVolumes returns every physical volume
+func (l *LVM) PhysicalVolumes() ([]*PhysicalVolume, error) {
+	rows, err := l.report("pvs", pvFields)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []*PhysicalVolume
+	for _, r := range rows {
+		d := &decoder{row: r}
+		out = append(out, &PhysicalVolume{
+			Name: d.string("pv_name"),
+			UUID: d.string("pv_uuid"),
+			VG:   d.string("vg_name"),
+			Size: d.size("pv_size"),
+			Free: d.size("pv_free"),
+			Used: d.size("pv_used"),
+			Attr: d.string("pv_attr"),
+		})
+		if d.err != nil {
+			return nil, errors.Wrapf(d.err, "physical volume %s", r["pv_name"])
+		}
+	}
+	return out, nil
+}
+
+// VolumeGroups returns every volume group
+func (l *LVM) VolumeGroups() ([]*VolumeGroup, error) {
+	rows, err := l.report("vgs", vgFields)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []*VolumeGroup
+	for _, r := range rows {
+		d := &decoder{row: r}
+		out = append(out, &VolumeGroup{
+			Name:        d.string("vg_name"),
+			UUID:        d.string("vg_uuid"),
+			Size:        d.size("vg_size"),
+			Free:        d.size("vg_free"),
+			ExtentSize:  d.size("vg_extent_size"),
+			ExtentCount: d.size("vg_extent_count"),
+			FreeCount:   d.size("vg_free_count"),
+			PVCount:     d.int("pv_count"),
+			LVCount:     d.int("lv_count"),
+			Attr:        d.string("vg_attr"),
+			Tags:        d.list("vg_tags"),
+		})
+		if d.err != nil {
+			return nil, errors.Wrapf(d.err, "volume group %s", r["vg_name"])
+		}
+	}
+	return out, nil
+}
+
+// VolumeGroup returns the named volume group, or nil if it does not exist
+func (l *LVM) VolumeGroup(name string) (*VolumeGroup, error) {
+	vgs, err := l.VolumeGroups()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, vg := range vgs {
+		if vg.Name == name {
+			return vg, nil
+		}
+	}
+	return nil, nil
+}
+
+// PhysicalVolumesOf returns the physical volumes in the named volume group
+func (l *LVM) PhysicalVolumesOf(vg string) ([]*PhysicalVolume, error) {
+	pvs, err := l.PhysicalVolumes()
+	if err != nil {
+		return nil, err
+	}
+
+	var out []*PhysicalVolume
+	for _, pv := range pvs {
+		if pv.VG == vg {
+			out = append(out, pv)
+		}
+	}
+	return out, nil
+}
+
+// LogicalVolumes returns every logical volume along with its segments
+func (l *LVM) LogicalVolumes() ([]*LogicalVolume, error) {
+	rows, err := l.report("lvs", lvFields)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []*LogicalVolume
+	byName := map[string]*LogicalVolume{}
+	for _, r := range rows {
+		d := &decoder{row: r}
+		lv := &LogicalVolume{
+			Name:        d.string("lv_name"),
+			VG:          d.string("vg_name"),
+			UUID:        d.string("lv_uuid"),
+			Path:        d.string("lv_path"),
+			Size:        d.size("lv_size"),
+			Attr:        d.string("lv_attr"),
+			Origin:      d.string("origin"),
+			DataPercent: d.percent("data_percent"),
+			Health:      d.string("lv_health_status"),
+			Time:        d.time("lv_time"),
+			Tags:        d.list("lv_tags"),
+		}
+		if d.err != nil {
+			return nil, errors.Wrapf(d.err, "logical volume %s/%s", r["vg_name"], r["lv_name"])
+		}
+		out = append(out, lv)
+		byName[lv.VG+"/"+lv.Name] = lv
+	}
+
+	rows, err = l.report("lvs", segFields, "--segments")
+	if err != nil {
+		return nil, err
+	}
+
+	for _, r := range rows {
+		d := &decoder{row: r}
+		seg := &Segment{
+			Type:    d.string("segtype"),
+			Start:   d.size("seg_start"),
+			Size:    d.size("seg_size"),
+			Devices: d.list("devices"),
+		}
+		if d.err != nil {
+			return nil, errors.Wrapf(d.err, "segment of %s/%s", r["vg_name"], r["lv_name"])
+		}
+
+		if lv, ok := byName[d.string("vg_name")+"/"+d.string("lv_name")]; ok {
+			lv.Segments = append(lv.Segments, seg)
+		}
+	}
+
+	return out, nil
+}
+
+// LogicalVolume returns the named logical volume in a volume group, or nil if
+// it does not exist
+func (l *LVM) LogicalVolume(vg, name string) (*LogicalVolume, error) {
+	lvs, err := l.LogicalVolumes()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, lv := range lvs {
+		if lv.VG == vg && lv.Name == name {
+			return lv, nil
+		}
+	}
+	return nil, nil
+}
+
+// report runs an LVM reporting command for the given fields and returns its
+// rows
+func (l *LVM) report(cmd string, fields []string, args ...string) ([]row, error) {
+	argv := append([]string{}, reportFlags...)
+	argv = append(argv, "-o", strings.Join(fields, ","))
+	argv = append(argv, args...)
+
+	out, err := exec.Read(l.exec, cmd, argv...)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot run %s", cmd)
+	}
+	return parseReport(out)
+}
@@ -0,0 +1,193 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lowlevel_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/asteris-llc/converge/helpers/fakeexec"
+	"github.com/asteris-llc/converge/resource/lvm/lowlevel"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var flags = []string{"--reportformat", "json", "--units", "b", "--nosuffix", "-o"}
+
+const pvsJSON = `  {
+      "report": [
+          {
+              "pv": [
+                  {"pv_name":"/dev/sdb", "pv_uuid":"3zAbWx", "vg_name":"data", "pv_size":"10733223936", "pv_free":"6438256640", "pv_used":"4294967296", "pv_attr":"a--"},
+                  {"pv_name":"/dev/sdc", "pv_uuid":"Kq9fmZ", "vg_name":"", "pv_size":"10737418240", "pv_free":"10737418240", "pv_used":"0", "pv_attr":"---"}
+              ]
+          }
+      ]
+  }
+`
+
+const vgsJSON = `{"report": [{"vg": [{"vg_name":"data", "vg_uuid":"aB3dE", "vg_size":"10733223936", "vg_free":"6438256640", "vg_extent_size":"4194304", "vg_extent_count":"2559", "vg_free_count":"1535", "pv_count":"1", "lv_count":"2", "vg_attr":"wz--n-", "vg_tags":"converge,backup"}]}]}`
+
+const lvsJSON = `{"report": [{"lv": [
+	{"lv_name":"home", "vg_name":"data", "lv_uuid":"x1", "lv_path":"/dev/data/home", "lv_size":"4294967296", "lv_attr":"owi-aos---", "origin":"", "data_percent":"", "lv_health_status":"", "lv_time":"2016-10-03 12:00:00 +0000", "lv_tags":""},
+	{"lv_name":"home-snap", "vg_name":"data", "lv_uuid":"x2", "lv_path":"/dev/data/home-snap", "lv_size":"536870912", "lv_attr":"swi-a-s---", "origin":"home", "data_percent":"12.50", "lv_health_status":"", "lv_time":"2016-10-04 08:30:00 +0000", "lv_tags":"converge"}
+]}]}`
+
+const segsJSON = `{"report": [{"seg": [
+	{"lv_name":"home", "vg_name":"data", "segtype":"linear", "seg_start":"0", "seg_size":"2147483648", "devices":"/dev/sdb(0)"},
+	{"lv_name":"home", "vg_name":"data", "segtype":"linear", "seg_start":"2147483648", "seg_size":"2147483648", "devices":"/dev/sdb(1024)"},
+	{"lv_name":"home-snap", "vg_name":"data", "segtype":"linear", "seg_start":"0", "seg_size":"536870912", "devices":"/dev/sdb(2048)"}
+]}]}`
+
+func expect(fake *fakeexec.Executor, cmd, fields, out string, args ...string) {
+	argv := append(append([]string{cmd}, flags...), fields)
+	fake.Expect(append(argv, args...)...).Return(out, 0)
+}
+
+func withPVs(fake *fakeexec.Executor) {
+	expect(fake, "pvs", "pv_name,pv_uuid,vg_name,pv_size,pv_free,pv_used,pv_attr", pvsJSON)
+}
+
+func withLVs(fake *fakeexec.Executor) {
+	expect(fake, "lvs", "lv_name,vg_name,lv_uuid,lv_path,lv_size,lv_attr,origin,data_percent,lv_health_status,lv_time,lv_tags", lvsJSON)
+	expect(fake, "lvs", "lv_name,vg_name,segtype,seg_start,seg_size,devices", segsJSON, "--segments")
+}
+
+// TestPhysicalVolumes tests parsing the pvs report
+func TestPhysicalVolumes(t *testing.T) {
+	t.Parallel()
+
+	fake := fakeexec.New()
+	withPVs(fake)
+
+	pvs, err := lowlevel.New(fake).PhysicalVolumes()
+	require.NoError(t, err)
+	require.Len(t, pvs, 2)
+
+	assert.Equal(t, &lowlevel.PhysicalVolume{
+		Name: "/dev/sdb",
+		UUID: "3zAbWx",
+		VG:   "data",
+		Size: 10733223936,
+		Free: 6438256640,
+		Used: 4294967296,
+		Attr: "a--",
+	}, pvs[0])
+	assert.True(t, pvs[0].Allocatable())
+	assert.False(t, pvs[0].Missing())
+	assert.Equal(t, "", pvs[1].VG)
+
+	t.Run("of", func(t *testing.T) {
+		pvs, err := lowlevel.New(fake).PhysicalVolumesOf("data")
+		require.NoError(t, err)
+		require.Len(t, pvs, 1)
+		assert.Equal(t, "/dev/sdb", pvs[0].Name)
+	})
+}
+
+// TestVolumeGroup tests parsing the vgs report
+func TestVolumeGroup(t *testing.T) {
+	t.Parallel()
+
+	fake := fakeexec.New()
+	expect(fake, "vgs", "vg_name,vg_uuid,vg_size,vg_free,vg_extent_size,vg_extent_count,vg_free_count,pv_count,lv_count,vg_attr,vg_tags", vgsJSON)
+
+	vg, err := lowlevel.New(fake).VolumeGroup("data")
+	require.NoError(t, err)
+	require.NotNil(t, vg)
+
+	assert.Equal(t, uint64(4194304), vg.ExtentSize)
+	assert.Equal(t, uint64(1535), vg.FreeCount)
+	assert.Equal(t, 1, vg.PVCount)
+	assert.Equal(t, 2, vg.LVCount)
+	assert.Equal(t, []string{"converge", "backup"}, vg.Tags)
+	assert.False(t, vg.Partial())
+
+	t.Run("missing", func(t *testing.T) {
+		vg, err := lowlevel.New(fake).VolumeGroup("backup")
+		require.NoError(t, err)
+		assert.Nil(t, vg)
+	})
+}
+
+// TestLogicalVolume tests parsing the lvs report and attaching segments
+func TestLogicalVolume(t *testing.T) {
+	t.Parallel()
+
+	fake := fakeexec.New()
+	withLVs(fake)
+
+	lv, err := lowlevel.New(fake).LogicalVolume("data", "home")
+	require.NoError(t, err)
+	require.NotNil(t, lv)
+
+	assert.Equal(t, "/dev/data/home", lv.Path)
+	assert.Equal(t, uint64(4294967296), lv.Size)
+	assert.True(t, lv.Active())
+	assert.True(t, lv.Healthy())
+	assert.False(t, lv.Snapshot())
+	assert.Equal(t, time.Date(2016, 10, 3, 12, 0, 0, 0, time.UTC).Unix(), lv.Time.Unix())
+	require.Len(t, lv.Segments, 2)
+	assert.Equal(t, &lowlevel.Segment{Type: "linear", Start: 2147483648, Size: 2147483648, Devices: []string{"/dev/sdb(1024)"}}, lv.Segments[1])
+
+	t.Run("snapshot", func(t *testing.T) {
+		lv, err := lowlevel.New(fake).LogicalVolume("data", "home-snap")
+		require.NoError(t, err)
+		require.NotNil(t, lv)
+
+		assert.True(t, lv.Snapshot())
+		assert.Equal(t, "home", lv.Origin)
+		assert.Equal(t, 12.5, lv.DataPercent)
+		assert.Equal(t, []string{"converge"}, lv.Tags)
+		assert.Len(t, lv.Segments, 1)
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		lv, err := lowlevel.New(fake).LogicalVolume("data", "var")
+		require.NoError(t, err)
+		assert.Nil(t, lv)
+	})
+}
+
+// TestReportErrors tests reports that cannot be read
+func TestReportErrors(t *testing.T) {
+	t.Parallel()
+
+	t.Run("malformed", func(t *testing.T) {
+		fake := fakeexec.New()
+		expect(fake, "pvs", "pv_name,pv_uuid,vg_name,pv_size,pv_free,pv_used,pv_attr", "  No physical volumes found\n")
+
+		_, err := lowlevel.New(fake).PhysicalVolumes()
+		assert.Error(t, err)
+	})
+
+	t.Run("size", func(t *testing.T) {
+		fake := fakeexec.New()
+		expect(fake, "pvs", "pv_name,pv_uuid,vg_name,pv_size,pv_free,pv_used,pv_attr", `{"report": [{"pv": [{"pv_name":"/dev/sdb", "pv_size":"10.00g"}]}]}`)
+
+		_, err := lowlevel.New(fake).PhysicalVolumes()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "/dev/sdb")
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("pvs", "--reportformat", "json", "--units", "b", "--nosuffix", "-o", "pv_name,pv_uuid,vg_name,pv_size,pv_free,pv_used,pv_attr").Return("", 5).Stderr("  Unrecognised command line option --reportformat.")
+
+		_, err := lowlevel.New(fake).PhysicalVolumes()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "--reportformat")
+	})
+}
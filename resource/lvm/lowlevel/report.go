@@ -0,0 +1,242 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lowlevel
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// timeFormat is the layout of lv_time in reports
+const timeFormat = "2006-01-02 15:04:05 -0700"
+
+// PhysicalVolume is a physical volume as reported by pvs
+type PhysicalVolume struct {
+	Name string
+	UUID string
+
+	// VG is the volume group the physical volume belongs to, or empty if it
+	// does not belong to one
+	VG string
+
+	// Size, Free and Used are in bytes
+	Size uint64
+	Free uint64
+	Used uint64
+
+	// Attr is the pv_attr string, such as "a--"
+	Attr string
+}
+
+// Allocatable reports whether extents can be allocated on the volume
+func (p *PhysicalVolume) Allocatable() bool {
+	return attr(p.Attr, 0) == 'a'
+}
+
+// Missing reports whether the device behind the volume could not be found
+func (p *PhysicalVolume) Missing() bool {
+	return attr(p.Attr, 2) == 'm'
+}
+
+// VolumeGroup is a volume group as reported by vgs
+type VolumeGroup struct {
+	Name string
+	UUID string
+
+	// Size, Free and ExtentSize are in bytes
+	Size       uint64
+	Free       uint64
+	ExtentSize uint64
+
+	ExtentCount uint64
+	FreeCount   uint64
+	PVCount     int
+	LVCount     int
+
+	// Attr is the vg_attr string, such as "wz--n-"
+	Attr string
+	Tags []string
+}
+
+// Partial reports whether one or more physical volumes of the group are
+// missing
+func (v *VolumeGroup) Partial() bool {
+	return attr(v.Attr, 3) == 'p'
+}
+
+// LogicalVolume is a logical volume as reported by lvs
+type LogicalVolume struct {
+	Name string
+	VG   string
+	UUID string
+	Path string
+
+	// Size is in bytes
+	Size uint64
+
+	// Attr is the lv_attr string, such as "-wi-a-----"
+	Attr string
+
+	// Origin is the volume a snapshot was taken of
+	Origin string
+
+	// DataPercent is how full a snapshot or thin volume is
+	DataPercent float64
+
+	// Health is the lv_health_status, which is empty for a healthy volume
+	Health string
+
+	// Time is when the volume was created
+	Time time.Time
+	Tags []string
+
+	Segments []*Segment
+}
+
+// Snapshot reports whether the volume is a snapshot, including one that has
+// been invalidated by filling up
+func (l *LogicalVolume) Snapshot() bool {
+	return strings.ContainsRune("sS", rune(attr(l.Attr, 0)))
+}
+
+// Active reports whether the volume is active
+func (l *LogicalVolume) Active() bool {
+	return attr(l.Attr, 4) == 'a'
+}
+
+// Healthy reports whether LVM reports no problems with the volume
+func (l *LogicalVolume) Healthy() bool {
+	return l.Health == "" && attr(l.Attr, 0) != 'S' && attr(l.Attr, 8) != 'p'
+}
+
+// Segment is a segment of a logical volume as reported by lvs --segments
+type Segment struct {
+	// Type is the segment type, such as linear or striped
+	Type string
+
+	// Start and Size are in bytes
+	Start uint64
+	Size  uint64
+
+	// Devices are the physical volumes the segment is on, with the starting
+	// extent on each, such as "/dev/sdb(0)"
+	Devices []string
+}
+
+// row is a single entry of an LVM JSON report. Every field is reported as a
+// string, whatever its type.
+type row map[string]string
+
+// parseReport returns the rows of every report in the output of an LVM
+// reporting command run with --reportformat json
+func parseReport(out string) ([]row, error) {
+	var parsed struct {
+		Report []map[string][]row `json:"report"`
+	}
+	if err := json.Unmarshal([]byte(out), &parsed); err != nil {
+		return nil, errors.Wrap(err, "could not parse LVM report")
+	}
+
+	var rows []row
+	for _, report := range parsed.Report {
+		var kinds []string
+		for kind := range report {
+			kinds = append(kinds, kind)
+		}
+		sort.Strings(kinds)
+
+		for _, kind := range kinds {
+			rows = append(rows, report[kind]...)
+		}
+	}
+	return rows, nil
+}
+
+// decoder converts the fields of a row, keeping the first error
+type decoder struct {
+	row row
+	err error
+}
+
+func (d *decoder) string(key string) string {
+	return strings.TrimSpace(d.row[key])
+}
+
+func (d *decoder) size(key string) uint64 {
+	value := strings.TrimSuffix(d.string(key), "B")
+	if value == "" || d.err != nil {
+		return 0
+	}
+
+	n, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		d.err = errors.Wrapf(err, "could not parse %s", key)
+	}
+	return n
+}
+
+func (d *decoder) int(key string) int {
+	return int(d.size(key))
+}
+
+func (d *decoder) percent(key string) float64 {
+	value := d.string(key)
+	if value == "" || d.err != nil {
+		return 0
+	}
+
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		d.err = errors.Wrapf(err, "could not parse %s", key)
+	}
+	return n
+}
+
+func (d *decoder) time(key string) time.Time {
+	value := d.string(key)
+	if value == "" || d.err != nil {
+		return time.Time{}
+	}
+
+	t, err := time.Parse(timeFormat, value)
+	if err != nil {
+		d.err = errors.Wrapf(err, "could not parse %s", key)
+	}
+	return t
+}
+
+func (d *decoder) list(key string) []string {
+	var out []string
+	for _, item := range strings.Split(d.string(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+// attr returns the character at i of an attribute string, or '-' if it is too
+// short
+func attr(s string, i int) byte {
+	if i >= len(s) {
+		return '-'
+	}
+	return s[i]
+}
@@ -0,0 +1,31 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lowlevel_test
+
+import (
+	"testing"
+
+	"github.com/asteris-llc/converge/resource/lvm/lowlevel"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestFormatSize tests formatting sizes with binary units
+func TestFormatSize(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "512B", lowlevel.FormatSize(512))
+	assert.Equal(t, "4.0GiB", lowlevel.FormatSize(4294967296))
+	assert.Equal(t, "1.5MiB", lowlevel.FormatSize(1572864))
+}
@@ -0,0 +1,105 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapshot
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/load/registry"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/lvm/lowlevel"
+)
+
+var sizePattern = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?[bBsSkKmMgGtTpPeE]?$`)
+
+// Preparer for Snapshot
+//
+// Snapshot keeps a copy-on-write snapshot of an LVM logical volume. Resources
+// that change the data on the volume can depend on the snapshot, so it is
+// taken before they are applied. An existing snapshot is left alone until it
+// is older than cleanup_after days or has filled up and been invalidated, at
+// which point it is removed and a new one is taken in its place.
+type Preparer struct {
+	// Origin is the logical volume to snapshot, as VG/LV
+	Origin string `hcl:"origin" required:"true"`
+
+	// Name of the snapshot volume. It defaults to the origin name followed by
+	// "-snap".
+	Name string `hcl:"name"`
+
+	// Size of the snapshot, in a form lvcreate accepts, such as "512M" or "2G"
+	Size string `hcl:"size" mutually_exclusive:"size,percent"`
+
+	// Percent is the size of the snapshot as a percentage of the size of the
+	// origin
+	Percent int `hcl:"percent" mutually_exclusive:"size,percent"`
+
+	// CleanupAfter is the number of days after which the snapshot is replaced.
+	// If zero, the snapshot is kept until it is invalidated.
+	CleanupAfter int `hcl:"cleanup_after"`
+}
+
+// Prepare a new task
+func (p *Preparer) Prepare(render resource.Renderer) (resource.Task, error) {
+	parts := strings.Split(p.Origin, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return nil, fmt.Errorf("lvm.snapshot: origin must be VG/LV, got %q", p.Origin)
+	}
+
+	if p.Name == "" {
+		p.Name = parts[1] + "-snap"
+	}
+	if strings.ContainsAny(p.Name, "/ \t\n") {
+		return nil, fmt.Errorf("lvm.snapshot: %q is not a valid name", p.Name)
+	}
+
+	switch {
+	case p.Size != "":
+		if !sizePattern.MatchString(p.Size) {
+			return nil, fmt.Errorf("lvm.snapshot: %q is not a valid size", p.Size)
+		}
+	case p.Percent != 0:
+		if p.Percent < 1 || p.Percent > 100 {
+			return nil, fmt.Errorf("lvm.snapshot \"percent\" must be between 1 and 100, got %d", p.Percent)
+		}
+	default:
+		return nil, fmt.Errorf("lvm.snapshot requires size or percent")
+	}
+
+	if p.CleanupAfter < 0 {
+		return nil, fmt.Errorf("lvm.snapshot \"cleanup_after\" cannot be negative, got %d", p.CleanupAfter)
+	}
+
+	e := exec.For(render)
+	return &Snapshot{
+		VG:           parts[0],
+		Origin:       parts[1],
+		Name:         p.Name,
+		Size:         p.Size,
+		Percent:      p.Percent,
+		CleanupAfter: time.Duration(p.CleanupAfter) * 24 * time.Hour,
+		lvm:          lowlevel.New(e),
+		exec:         e,
+	}, nil
+}
+
+func init() {
+	registry.Register("lvm.snapshot", (*Preparer)(nil), (*Snapshot)(nil))
+	registry.RegisterPlatforms("lvm.snapshot", "linux")
+}
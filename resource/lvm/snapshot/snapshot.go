@@ -0,0 +1,150 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapshot
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/lvm/lowlevel"
+	"github.com/pkg/errors"
+)
+
+// Snapshot manages a snapshot of an LVM logical volume
+type Snapshot struct {
+	resource.Status
+
+	VG           string
+	Origin       string
+	Name         string
+	Size         string
+	Percent      int
+	CleanupAfter time.Duration
+
+	// Path is the device path of the snapshot, once it exists
+	Path string
+
+	// Created is when the current snapshot was taken
+	Created time.Time
+
+	// DataPercent is how full the current snapshot is
+	DataPercent float64
+
+	lvm  *lowlevel.LVM
+	exec exec.Executor
+}
+
+// Check whether the snapshot exists and is still usable
+func (s *Snapshot) Check(resource.Renderer) (resource.TaskStatus, error) {
+	s.Status = resource.Status{}
+
+	current, replace, err := s.plan()
+	if err != nil {
+		s.RaiseLevel(resource.StatusFatal)
+		return s, err
+	}
+
+	switch {
+	case current == nil:
+		s.RaiseLevel(resource.StatusWillChange)
+		s.AddDifference(s.id(), "<absent>", "snapshot of "+s.VG+"/"+s.Origin, "")
+	case replace != "":
+		s.RaiseLevel(resource.StatusWillChange)
+		s.AddDifference(s.id(), "taken "+current.Time.Format(time.RFC3339), "new snapshot of "+s.VG+"/"+s.Origin, "")
+		s.AddMessage(replace)
+	}
+
+	return s, nil
+}
+
+// Apply takes the snapshot, first removing an old one if it is to be
+// replaced
+func (s *Snapshot) Apply() (resource.TaskStatus, error) {
+	s.Status = resource.Status{}
+
+	current, replace, err := s.plan()
+	if err != nil {
+		s.RaiseLevel(resource.StatusFatal)
+		return s, err
+	}
+
+	if current != nil && replace == "" {
+		return s, nil
+	}
+
+	if current != nil {
+		if err := exec.Run(s.exec, "lvremove", "--force", s.id()); err != nil {
+			s.RaiseLevel(resource.StatusFatal)
+			return s, errors.Wrapf(err, "cannot remove %s", s.id())
+		}
+		s.AddMessage(fmt.Sprintf("removed %s, %s", s.id(), replace))
+	}
+
+	args := []string{"--snapshot", "--name", s.Name}
+	if s.Size != "" {
+		args = append(args, "--size", s.Size)
+	} else {
+		args = append(args, "--extents", strconv.Itoa(s.Percent)+"%ORIGIN")
+	}
+
+	if err := exec.Run(s.exec, "lvcreate", append(args, s.VG+"/"+s.Origin)...); err != nil {
+		s.RaiseLevel(resource.StatusFatal)
+		return s, errors.Wrapf(err, "cannot snapshot %s/%s", s.VG, s.Origin)
+	}
+	s.AddMessage("created " + s.id())
+
+	return s, nil
+}
+
+// plan finds the current snapshot and, if it should be replaced, the reason
+func (s *Snapshot) plan() (*lowlevel.LogicalVolume, string, error) {
+	s.Path, s.Created, s.DataPercent = "", time.Time{}, 0
+
+	origin, err := s.lvm.LogicalVolume(s.VG, s.Origin)
+	if err != nil {
+		return nil, "", err
+	}
+	if origin == nil {
+		return nil, "", fmt.Errorf("logical volume %s/%s does not exist", s.VG, s.Origin)
+	}
+
+	current, err := s.lvm.LogicalVolume(s.VG, s.Name)
+	if err != nil || current == nil {
+		return nil, "", err
+	}
+
+	if !current.Snapshot() || current.Origin != s.Origin {
+		return nil, "", fmt.Errorf("%s exists and is not a snapshot of %s/%s", s.id(), s.VG, s.Origin)
+	}
+
+	s.Path, s.Created, s.DataPercent = current.Path, current.Time, current.DataPercent
+
+	if !current.Healthy() {
+		return current, "it has been invalidated", nil
+	}
+
+	if s.CleanupAfter > 0 && !current.Time.IsZero() && time.Since(current.Time) >= s.CleanupAfter {
+		return current, fmt.Sprintf("it is older than %d days", int(s.CleanupAfter.Hours()/24)), nil
+	}
+
+	return current, "", nil
+}
+
+func (s *Snapshot) id() string {
+	return s.VG + "/" + s.Name
+}
@@ -0,0 +1,188 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapshot_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/asteris-llc/converge/helpers/fakeexec"
+	"github.com/asteris-llc/converge/helpers/fakerenderer"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/lvm/snapshot"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSnapshotInterface tests that Snapshot is properly implemented
+func TestSnapshotInterface(t *testing.T) {
+	t.Parallel()
+
+	assert.Implements(t, (*resource.Task)(nil), new(snapshot.Snapshot))
+	assert.Implements(t, (*resource.Resource)(nil), new(snapshot.Preparer))
+}
+
+// TestPrepare tests validating the preparer and its defaults
+func TestPrepare(t *testing.T) {
+	t.Parallel()
+
+	task, err := (&snapshot.Preparer{Origin: "data/home", Percent: 20}).Prepare(fakerenderer.New())
+	require.NoError(t, err)
+	assert.Equal(t, "home-snap", task.(*snapshot.Snapshot).Name)
+
+	for _, p := range []*snapshot.Preparer{
+		{Origin: "home", Size: "1G"},
+		{Origin: "data/home"},
+		{Origin: "data/home", Size: "lots"},
+		{Origin: "data/home", Percent: 101},
+		{Origin: "data/home", Size: "1G", CleanupAfter: -1},
+		{Origin: "data/home", Name: "a b", Size: "1G"},
+	} {
+		_, err := p.Prepare(fakerenderer.New())
+		assert.Error(t, err, "%+v", p)
+	}
+}
+
+// TestCheck tests the possible cases Check handles
+func TestCheck(t *testing.T) {
+	t.Parallel()
+
+	t.Run("missing", func(t *testing.T) {
+		status, err := prepare(t, expect()).Check(fakerenderer.New())
+		require.NoError(t, err)
+		assert.True(t, status.HasChanges())
+		assert.Equal(t, "<absent>", status.Diffs()["data/home-snap"].Original())
+	})
+
+	t.Run("current", func(t *testing.T) {
+		snap := prepare(t, expect(lv{"home-snap", "swi-a-s---", "home", 2}))
+		status, err := snap.Check(fakerenderer.New())
+		require.NoError(t, err)
+		assert.False(t, status.HasChanges())
+		assert.Equal(t, "/dev/data/home-snap", snap.Path)
+		assert.Equal(t, 12.5, snap.DataPercent)
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		status, err := prepare(t, expect(lv{"home-snap", "swi-a-s---", "home", 8})).Check(fakerenderer.New())
+		require.NoError(t, err)
+		assert.True(t, status.HasChanges())
+		assert.Contains(t, status.Messages(), "it is older than 7 days")
+	})
+
+	t.Run("invalidated", func(t *testing.T) {
+		status, err := prepare(t, expect(lv{"home-snap", "Swi-I-s---", "home", 1})).Check(fakerenderer.New())
+		require.NoError(t, err)
+		assert.True(t, status.HasChanges())
+		assert.Contains(t, status.Messages(), "it has been invalidated")
+	})
+
+	t.Run("not a snapshot", func(t *testing.T) {
+		_, err := prepare(t, expect(lv{"home-snap", "-wi-a-----", "", 1})).Check(fakerenderer.New())
+		assert.EqualError(t, err, "data/home-snap exists and is not a snapshot of data/home")
+	})
+
+	t.Run("no origin", func(t *testing.T) {
+		fake := fakeexec.New()
+		script(fake, nil)
+
+		_, err := prepare(t, fake).Check(fakerenderer.New())
+		assert.EqualError(t, err, "logical volume data/home does not exist")
+	})
+}
+
+// TestApply tests taking and replacing snapshots
+func TestApply(t *testing.T) {
+	t.Parallel()
+
+	t.Run("create", func(t *testing.T) {
+		fake := expect()
+		fake.Expect("lvcreate", "--snapshot", "--name", "home-snap", "--extents", "20%ORIGIN", "data/home")
+
+		_, err := prepare(t, fake).Apply()
+		require.NoError(t, err)
+		fake.AssertExpectations(t)
+	})
+
+	t.Run("size", func(t *testing.T) {
+		fake := expect()
+		fake.Expect("lvcreate", "--snapshot", "--name", "before", "--size", "2G", "data/home")
+
+		p := &snapshot.Preparer{Origin: "data/home", Name: "before", Size: "2G"}
+		task, err := p.Prepare(&resource.ExecRenderer{Renderer: fakerenderer.New(), Exec: fake})
+		require.NoError(t, err)
+
+		_, err = task.Apply()
+		require.NoError(t, err)
+		fake.AssertExpectations(t)
+	})
+
+	t.Run("replace", func(t *testing.T) {
+		fake := expect(lv{"home-snap", "swi-a-s---", "home", 8})
+		fake.Expect("lvremove", "--force", "data/home-snap")
+		fake.Expect("lvcreate", "--snapshot", "--name", "home-snap", "--extents", "20%ORIGIN", "data/home")
+
+		status, err := prepare(t, fake).Apply()
+		require.NoError(t, err)
+		fake.AssertExpectations(t)
+		assert.Contains(t, status.Messages(), "removed data/home-snap, it is older than 7 days")
+	})
+
+	t.Run("current", func(t *testing.T) {
+		fake := expect(lv{"home-snap", "swi-a-s---", "home", 2})
+
+		status, err := prepare(t, fake).Apply()
+		require.NoError(t, err)
+		assert.Empty(t, status.Messages())
+	})
+}
+
+func prepare(t *testing.T, fake *fakeexec.Executor) *snapshot.Snapshot {
+	p := &snapshot.Preparer{Origin: "data/home", Percent: 20, CleanupAfter: 7}
+	task, err := p.Prepare(&resource.ExecRenderer{Renderer: fakerenderer.New(), Exec: fake})
+	require.NoError(t, err)
+	return task.(*snapshot.Snapshot)
+}
+
+type lv struct {
+	name   string
+	attr   string
+	origin string
+	age    int
+}
+
+// expect scripts the lvs reports for the origin data/home and the given
+// volumes, each created age days ago
+func expect(lvs ...lv) *fakeexec.Executor {
+	fake := fakeexec.New()
+	script(fake, append([]lv{{"home", "owi-aos---", "", 30}}, lvs...))
+	return fake
+}
+
+func script(fake *fakeexec.Executor, lvs []lv) {
+	var rows []string
+	for _, lv := range lvs {
+		created := time.Now().Add(-time.Duration(lv.age) * 24 * time.Hour).Format("2006-01-02 15:04:05 -0700")
+		rows = append(rows, fmt.Sprintf(`{"lv_name":%q, "vg_name":"data", "lv_path":"/dev/data/%s", "lv_size":"1073741824", "lv_attr":%q, "origin":%q, "data_percent":"12.50", "lv_time":%q}`, lv.name, lv.name, lv.attr, lv.origin, created))
+	}
+
+	flags := []string{"--reportformat", "json", "--units", "b", "--nosuffix", "-o"}
+	fake.Expect(append(append([]string{"lvs"}, flags...), "lv_name,vg_name,lv_uuid,lv_path,lv_size,lv_attr,origin,data_percent,lv_health_status,lv_time,lv_tags")...).
+		Return(`{"report": [{"lv": [`+strings.Join(rows, ",")+`]}]}`, 0)
+	fake.Expect(append(append([]string{"lvs"}, flags...), "lv_name,vg_name,segtype,seg_start,seg_size,devices", "--segments")...).
+		Return(`{"report": [{"seg": []}]}`, 0)
+}
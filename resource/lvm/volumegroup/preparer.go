@@ -0,0 +1,88 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package volumegroup
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/load/registry"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/lvm/lowlevel"
+)
+
+// Preparer for VolumeGroup
+//
+// VolumeGroup creates an LVM volume group and keeps its physical volumes in
+// line with the declared devices. Devices missing from the group are added
+// with vgextend, which initializes them as physical volumes. With remove set,
+// physical volumes that are not declared are taken out of the group with
+// vgreduce once pvmove has moved their extents onto the others, and the
+// progress of the move is reported while it runs. Volume groups are never
+// removed.
+type Preparer struct {
+	// Name of the volume group
+	Name string `hcl:"name" required:"true"`
+
+	// Devices are the block devices in the group, named as pvs reports them,
+	// such as /dev/sdb
+	Devices []string `hcl:"devices" required:"true"`
+
+	// Remove controls whether physical volumes in the group that are not in
+	// devices are removed from it. When false, they are left in place.
+	Remove bool `hcl:"remove"`
+}
+
+// Prepare a new task
+func (p *Preparer) Prepare(render resource.Renderer) (resource.Task, error) {
+	if strings.ContainsAny(p.Name, "/ \t\n") {
+		return nil, fmt.Errorf("lvm.volumegroup: %q is not a valid name", p.Name)
+	}
+
+	if len(p.Devices) == 0 {
+		return nil, fmt.Errorf("lvm.volumegroup requires at least one device")
+	}
+
+	seen := map[string]bool{}
+	var devices []string
+	for _, device := range p.Devices {
+		if !path.IsAbs(device) {
+			return nil, fmt.Errorf("lvm.volumegroup: %q is not an absolute path", device)
+		}
+
+		device = path.Clean(device)
+		if seen[device] {
+			return nil, fmt.Errorf("lvm.volumegroup: %s is listed more than once", device)
+		}
+		seen[device] = true
+		devices = append(devices, device)
+	}
+
+	e := exec.For(render)
+	return &VolumeGroup{
+		Name:    p.Name,
+		Devices: devices,
+		Remove:  p.Remove,
+		lvm:     lowlevel.New(e),
+		exec:    e,
+	}, nil
+}
+
+func init() {
+	registry.Register("lvm.volumegroup", (*Preparer)(nil), (*VolumeGroup)(nil))
+	registry.RegisterPlatforms("lvm.volumegroup", "linux")
+}
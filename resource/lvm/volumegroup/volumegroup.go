@@ -0,0 +1,226 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package volumegroup
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/lvm/lowlevel"
+	"github.com/pkg/errors"
+)
+
+// moveInterval is how often pvmove reports its progress, in seconds
+const moveInterval = "10"
+
+// VolumeGroup manages an LVM volume group and its physical volumes
+type VolumeGroup struct {
+	resource.Status
+
+	Name    string
+	Devices []string
+	Remove  bool
+
+	// PhysicalVolumes are the devices currently in the group
+	PhysicalVolumes []string
+
+	// Size and Free are the current size and free space of the group, in
+	// bytes
+	Size uint64
+	Free uint64
+
+	lvm    *lowlevel.LVM
+	exec   exec.Executor
+	output func(stream, line string)
+}
+
+// plan is the set of changes that bring the group in line with its devices
+type plan struct {
+	exists bool
+	add    []string
+	remove []*lowlevel.PhysicalVolume
+	extra  []string
+}
+
+// Check whether the group exists with the declared devices
+func (v *VolumeGroup) Check(resource.Renderer) (resource.TaskStatus, error) {
+	v.Status = resource.Status{}
+
+	p, err := v.plan()
+	if err != nil {
+		v.RaiseLevel(resource.StatusFatal)
+		return v, err
+	}
+
+	if !p.exists {
+		v.RaiseLevel(resource.StatusWillChange)
+		v.AddDifference(v.Name, "<absent>", strings.Join(v.Devices, " "), "")
+		return v, nil
+	}
+
+	for _, device := range p.add {
+		v.RaiseLevel(resource.StatusWillChange)
+		v.AddDifference(device, "<absent>", "in "+v.Name, "")
+	}
+
+	for _, pv := range p.remove {
+		v.RaiseLevel(resource.StatusWillChange)
+		v.AddDifference(pv.Name, "in "+v.Name, "<absent>", "")
+		if pv.Used > 0 {
+			v.AddMessage(fmt.Sprintf("%s of data will be moved off %s", lowlevel.FormatSize(pv.Used), pv.Name))
+		}
+	}
+
+	for _, device := range p.extra {
+		v.AddMessage(fmt.Sprintf("%s is in %s but not in devices, set remove to take it out", device, v.Name))
+	}
+
+	return v, nil
+}
+
+// Apply creates the group or adds and removes its physical volumes. Devices
+// are added before any are removed, so their space is available to pvmove.
+func (v *VolumeGroup) Apply() (resource.TaskStatus, error) {
+	v.Status = resource.Status{}
+
+	p, err := v.plan()
+	if err != nil {
+		v.RaiseLevel(resource.StatusFatal)
+		return v, err
+	}
+
+	if !p.exists {
+		if err := exec.Run(v.exec, "vgcreate", append([]string{v.Name}, v.Devices...)...); err != nil {
+			v.RaiseLevel(resource.StatusFatal)
+			return v, errors.Wrapf(err, "cannot create volume group %s", v.Name)
+		}
+		v.AddMessage("created volume group " + v.Name)
+		return v, nil
+	}
+
+	if len(p.add) > 0 {
+		if err := exec.Run(v.exec, "vgextend", append([]string{v.Name}, p.add...)...); err != nil {
+			v.RaiseLevel(resource.StatusFatal)
+			return v, errors.Wrapf(err, "cannot extend volume group %s", v.Name)
+		}
+		v.AddMessage("added " + strings.Join(p.add, ", "))
+	}
+
+	for _, pv := range p.remove {
+		if pv.Used > 0 {
+			if err := v.move(pv.Name); err != nil {
+				v.RaiseLevel(resource.StatusFatal)
+				return v, errors.Wrapf(err, "cannot move data off %s", pv.Name)
+			}
+			v.AddMessage(fmt.Sprintf("moved %s of data off %s", lowlevel.FormatSize(pv.Used), pv.Name))
+		}
+
+		if err := exec.Run(v.exec, "vgreduce", v.Name, pv.Name); err != nil {
+			v.RaiseLevel(resource.StatusFatal)
+			return v, errors.Wrapf(err, "cannot remove %s from %s", pv.Name, v.Name)
+		}
+		v.AddMessage("removed " + pv.Name)
+	}
+
+	return v, nil
+}
+
+// StreamOutput reports the progress of pvmove while data is moved off
+// physical volumes that are being removed
+func (v *VolumeGroup) StreamOutput(output func(stream, line string)) {
+	v.output = output
+}
+
+// plan finds the devices to add to the group and the physical volumes to
+// remove from it
+func (v *VolumeGroup) plan() (*plan, error) {
+	v.PhysicalVolumes, v.Size, v.Free = nil, 0, 0
+
+	vg, err := v.lvm.VolumeGroup(v.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	pvs, err := v.lvm.PhysicalVolumes()
+	if err != nil {
+		return nil, err
+	}
+
+	declared := map[string]bool{}
+	for _, device := range v.Devices {
+		declared[device] = true
+	}
+
+	p := &plan{exists: vg != nil}
+	current := map[string]bool{}
+	for _, pv := range pvs {
+		switch {
+		case pv.VG == v.Name:
+			current[pv.Name] = true
+			v.PhysicalVolumes = append(v.PhysicalVolumes, pv.Name)
+
+			switch {
+			case declared[pv.Name]:
+				// already where it should be
+			case pv.Missing():
+				v.AddMessage(fmt.Sprintf("a physical volume of %s is missing, run vgreduce --removemissing once it is replaced", v.Name))
+			case v.Remove:
+				p.remove = append(p.remove, pv)
+			default:
+				p.extra = append(p.extra, pv.Name)
+			}
+
+		case pv.VG != "" && declared[pv.Name]:
+			return nil, fmt.Errorf("%s is already in volume group %s", pv.Name, pv.VG)
+		}
+	}
+
+	if vg != nil {
+		v.Size, v.Free = vg.Size, vg.Free
+	}
+
+	for _, device := range v.Devices {
+		if !current[device] {
+			p.add = append(p.add, device)
+		}
+	}
+
+	return p, nil
+}
+
+// move runs pvmove to move every extent off a physical volume, streaming its
+// progress if there is an output
+func (v *VolumeGroup) move(device string) error {
+	cmd := exec.NewCommand("pvmove", "--interval", moveInterval, device)
+	if v.output != nil {
+		stdout := exec.NewLineWriter(func(line string) { v.output("stdout", line) })
+		stderr := exec.NewLineWriter(func(line string) { v.output("stderr", line) })
+		defer stdout.Close()
+		defer stderr.Close()
+		cmd.Stdout, cmd.Stderr = stdout, stderr
+	}
+
+	result, err := v.exec.Run(cmd)
+	if err != nil {
+		return err
+	}
+
+	if !result.Success() {
+		return &exec.ExitError{Command: cmd, Result: result}
+	}
+	return nil
+}
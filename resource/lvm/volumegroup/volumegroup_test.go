@@ -0,0 +1,198 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package volumegroup_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/fakeexec"
+	"github.com/asteris-llc/converge/helpers/fakerenderer"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/lvm/volumegroup"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestVolumeGroupInterface tests that VolumeGroup is properly implemented
+func TestVolumeGroupInterface(t *testing.T) {
+	t.Parallel()
+
+	assert.Implements(t, (*resource.Task)(nil), new(volumegroup.VolumeGroup))
+	assert.Implements(t, (*resource.Resource)(nil), new(volumegroup.Preparer))
+	assert.Implements(t, (*resource.OutputStreamer)(nil), new(volumegroup.VolumeGroup))
+}
+
+// TestPrepare tests validating the preparer
+func TestPrepare(t *testing.T) {
+	t.Parallel()
+
+	for _, p := range []*volumegroup.Preparer{
+		{Name: "data/x", Devices: []string{"/dev/sdb"}},
+		{Name: "data"},
+		{Name: "data", Devices: []string{"sdb"}},
+		{Name: "data", Devices: []string{"/dev/sdb", "/dev/sdb/"}},
+	} {
+		_, err := p.Prepare(fakerenderer.New())
+		assert.Error(t, err, "%+v", p)
+	}
+}
+
+// TestCheck tests the possible cases Check handles
+func TestCheck(t *testing.T) {
+	t.Parallel()
+
+	t.Run("missing", func(t *testing.T) {
+		fake := fakeexec.New()
+		expect(fake, pv{"/dev/sdb", "", 0})
+
+		status, err := prepare(t, fake, false, "/dev/sdb", "/dev/sdc").Check(fakerenderer.New())
+		require.NoError(t, err)
+		assert.True(t, status.HasChanges())
+		assert.Equal(t, "/dev/sdb /dev/sdc", status.Diffs()["data"].Current())
+	})
+
+	t.Run("converged", func(t *testing.T) {
+		fake := fakeexec.New()
+		expect(fake, pv{"/dev/sdb", "data", 1024}, pv{"/dev/sdc", "data", 0})
+
+		vg := prepare(t, fake, false, "/dev/sdb", "/dev/sdc")
+		status, err := vg.Check(fakerenderer.New())
+		require.NoError(t, err)
+		assert.False(t, status.HasChanges())
+		assert.Equal(t, []string{"/dev/sdb", "/dev/sdc"}, vg.PhysicalVolumes)
+		assert.Equal(t, uint64(20480), vg.Size)
+	})
+
+	t.Run("add", func(t *testing.T) {
+		fake := fakeexec.New()
+		expect(fake, pv{"/dev/sdb", "data", 1024}, pv{"/dev/sdc", "", 0})
+
+		status, err := prepare(t, fake, false, "/dev/sdb", "/dev/sdc", "/dev/sdd").Check(fakerenderer.New())
+		require.NoError(t, err)
+		assert.True(t, status.HasChanges())
+		assert.Len(t, status.Diffs(), 2)
+		assert.Equal(t, "in data", status.Diffs()["/dev/sdd"].Current())
+	})
+
+	t.Run("remove", func(t *testing.T) {
+		fake := fakeexec.New()
+		expect(fake, pv{"/dev/sdb", "data", 4294967296}, pv{"/dev/sdc", "data", 0})
+
+		status, err := prepare(t, fake, true, "/dev/sdc").Check(fakerenderer.New())
+		require.NoError(t, err)
+		assert.True(t, status.HasChanges())
+		assert.Equal(t, "<absent>", status.Diffs()["/dev/sdb"].Current())
+		assert.Contains(t, status.Messages(), "4.0GiB of data will be moved off /dev/sdb")
+	})
+
+	t.Run("extra", func(t *testing.T) {
+		fake := fakeexec.New()
+		expect(fake, pv{"/dev/sdb", "data", 0}, pv{"/dev/sdc", "data", 0})
+
+		status, err := prepare(t, fake, false, "/dev/sdc").Check(fakerenderer.New())
+		require.NoError(t, err)
+		assert.False(t, status.HasChanges())
+		assert.Contains(t, status.Messages(), "/dev/sdb is in data but not in devices, set remove to take it out")
+	})
+
+	t.Run("other group", func(t *testing.T) {
+		fake := fakeexec.New()
+		expect(fake, pv{"/dev/sdb", "data", 0}, pv{"/dev/sdc", "backup", 0})
+
+		_, err := prepare(t, fake, false, "/dev/sdb", "/dev/sdc").Check(fakerenderer.New())
+		assert.EqualError(t, err, "/dev/sdc is already in volume group backup")
+	})
+}
+
+// TestApply tests creating, extending and reducing a group
+func TestApply(t *testing.T) {
+	t.Parallel()
+
+	t.Run("create", func(t *testing.T) {
+		fake := fakeexec.New()
+		expect(fake)
+		fake.Expect("vgcreate", "data", "/dev/sdb", "/dev/sdc")
+
+		_, err := prepare(t, fake, false, "/dev/sdb", "/dev/sdc").Apply()
+		require.NoError(t, err)
+		fake.AssertExpectations(t)
+	})
+
+	t.Run("extend and reduce", func(t *testing.T) {
+		fake := fakeexec.New()
+		expect(fake, pv{"/dev/sdb", "data", 4294967296}, pv{"/dev/sdc", "data", 0}, pv{"/dev/sdd", "", 0})
+		fake.Expect("vgextend", "data", "/dev/sdd")
+		fake.Expect("pvmove", "--interval", "10", "/dev/sdb").Return("  /dev/sdb: Moved: 50.00%\n  /dev/sdb: Moved: 100.00%\n", 0)
+		fake.Expect("vgreduce", "data", "/dev/sdb")
+		fake.Expect("vgreduce", "data", "/dev/sdc")
+
+		vg := prepare(t, fake, true, "/dev/sdd")
+		var lines []string
+		vg.StreamOutput(func(stream, line string) { lines = append(lines, stream+": "+line) })
+
+		status, err := vg.Apply()
+		require.NoError(t, err)
+		fake.AssertExpectations(t)
+		assert.Equal(t, []string{"stdout:   /dev/sdb: Moved: 50.00%", "stdout:   /dev/sdb: Moved: 100.00%"}, lines)
+		assert.Contains(t, status.Messages(), "moved 4.0GiB of data off /dev/sdb")
+	})
+
+	t.Run("move fails", func(t *testing.T) {
+		fake := fakeexec.New()
+		expect(fake, pv{"/dev/sdb", "data", 1024}, pv{"/dev/sdc", "data", 0})
+		fake.Expect("pvmove", "--interval", "10", "/dev/sdb").Return("", 5).Stderr("  Insufficient free space")
+
+		_, err := prepare(t, fake, true, "/dev/sdc").Apply()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Insufficient free space")
+	})
+}
+
+func prepare(t *testing.T, fake *fakeexec.Executor, remove bool, devices ...string) *volumegroup.VolumeGroup {
+	p := &volumegroup.Preparer{Name: "data", Devices: devices, Remove: remove}
+	task, err := p.Prepare(&resource.ExecRenderer{Renderer: fakerenderer.New(), Exec: fake})
+	require.NoError(t, err)
+	return task.(*volumegroup.VolumeGroup)
+}
+
+type pv struct {
+	name string
+	vg   string
+	used uint64
+}
+
+// expect scripts the pvs and vgs reports for a set of physical volumes, each
+// 10KiB in size
+func expect(fake *fakeexec.Executor, pvs ...pv) {
+	var pvRows, vgRows []string
+	var size uint64
+	for _, pv := range pvs {
+		pvRows = append(pvRows, fmt.Sprintf(`{"pv_name":%q, "vg_name":%q, "pv_size":"10240", "pv_free":"%d", "pv_used":"%d", "pv_attr":"a--"}`, pv.name, pv.vg, 10240-pv.used, pv.used))
+		if pv.vg == "data" {
+			size += 10240
+		}
+	}
+	if size > 0 {
+		vgRows = append(vgRows, fmt.Sprintf(`{"vg_name":"data", "vg_size":"%d", "vg_free":"0", "vg_attr":"wz--n-"}`, size))
+	}
+
+	flags := []string{"--reportformat", "json", "--units", "b", "--nosuffix", "-o"}
+	fake.Expect(append(append([]string{"pvs"}, flags...), "pv_name,pv_uuid,vg_name,pv_size,pv_free,pv_used,pv_attr")...).
+		Return(`{"report": [{"pv": [`+strings.Join(pvRows, ",")+`]}]}`, 0)
+	fake.Expect(append(append([]string{"vgs"}, flags...), "vg_name,vg_uuid,vg_size,vg_free,vg_extent_size,vg_extent_count,vg_free_count,pv_count,lv_count,vg_attr,vg_tags")...).
+		Return(`{"report": [{"vg": [`+strings.Join(vgRows, ",")+`]}]}`, 0)
+}
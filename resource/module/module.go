@@ -18,6 +18,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/asteris-llc/converge/helpers/exec"
 	"github.com/asteris-llc/converge/resource"
 )
 
@@ -26,6 +27,13 @@ type Module struct {
 	resource.Status
 
 	Params map[string]resource.Value
+
+	// MaxChanges limits the number of changes inside the module
+	MaxChanges int
+
+	// Exec is the executor for the module's execution context. Nodes inside
+	// the module inherit it.
+	Exec exec.Executor
 }
 
 // Check just returns the current value of the moduleeter. It should never have to change.
@@ -35,6 +43,11 @@ func (m *Module) Check(resource.Renderer) (resource.TaskStatus, error) {
 	return m, nil
 }
 
+// ChangeLimit returns the maximum number of changes inside the module
+func (m *Module) ChangeLimit() int {
+	return m.MaxChanges
+}
+
 // Apply doesn't do anything since modules are final values
 func (m *Module) Apply() (resource.TaskStatus, error) {
 	return m, nil
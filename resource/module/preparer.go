@@ -15,6 +15,7 @@
 package module
 
 import (
+	"github.com/asteris-llc/converge/helpers/exec"
 	"github.com/asteris-llc/converge/load/registry"
 	"github.com/asteris-llc/converge/resource"
 )
@@ -26,6 +27,23 @@ type Preparer struct {
 	// Params is a map of strings to anything you'd like. It will be passed to
 	// the called module as the default values for the `param`s there.
 	Params map[string]resource.Value `hcl:"params"`
+
+	// MaxChanges is the most resources inside the module (including any
+	// modules it calls) that a single apply may change. If the plan would
+	// change more, the apply is stopped before anything is changed. 0 means
+	// there is no limit.
+	MaxChanges int `hcl:"max_changes" min:"0"`
+
+	// Env is added to the environment of the commands run by every resource
+	// inside the module, including nested modules, so that settings like
+	// PATH or HTTP_PROXY don't have to be repeated on each task. A resource's
+	// own env takes precedence.
+	Env map[string]string `hcl:"env"`
+
+	// Dir is the working directory for the commands run by every resource
+	// inside the module that doesn't set its own. A relative dir on a resource
+	// is taken relative to it.
+	Dir string `hcl:"dir"`
 }
 
 // NewPreparer returns a new preparer for modules
@@ -35,7 +53,12 @@ func NewPreparer(params map[string]resource.Value) *Preparer {
 
 // Prepare a new task
 func (p *Preparer) Prepare(render resource.Renderer) (resource.Task, error) {
-	return &Module{Params: p.Params}, nil
+	executor := exec.For(render)
+	if len(p.Env) > 0 || p.Dir != "" {
+		executor = exec.NewEnvironment(executor, p.Env, p.Dir)
+	}
+
+	return &Module{Params: p.Params, MaxChanges: p.MaxChanges, Exec: executor}, nil
 }
 
 func init() {
@@ -17,9 +17,12 @@ package module_test
 import (
 	"testing"
 
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/helpers/fakerenderer"
 	"github.com/asteris-llc/converge/resource"
 	"github.com/asteris-llc/converge/resource/module"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestPreparerInterface(t *testing.T) {
@@ -27,3 +30,32 @@ func TestPreparerInterface(t *testing.T) {
 
 	assert.Implements(t, (*resource.Resource)(nil), new(module.Preparer))
 }
+
+func TestPreparerEnvironment(t *testing.T) {
+	t.Parallel()
+
+	t.Run("unset", func(t *testing.T) {
+		task, err := (&module.Preparer{}).Prepare(fakerenderer.New())
+		require.NoError(t, err)
+		assert.Equal(t, exec.New(), task.(*module.Module).Exec)
+	})
+
+	t.Run("set", func(t *testing.T) {
+		prep := &module.Preparer{
+			Env: map[string]string{"PATH": "/opt/app/bin:/usr/bin", "HTTP_PROXY": "http://proxy:3128"},
+			Dir: "/opt/app",
+		}
+
+		task, err := prep.Prepare(fakerenderer.New())
+		require.NoError(t, err)
+		assert.Equal(
+			t,
+			&exec.Environment{
+				Executor: exec.New(),
+				Env:      []string{"HTTP_PROXY=http://proxy:3128", "PATH=/opt/app/bin:/usr/bin"},
+				Dir:      "/opt/app",
+			},
+			task.(*module.Module).Exec,
+		)
+	})
+}
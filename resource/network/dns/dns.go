@@ -0,0 +1,209 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dns
+
+import (
+	"strings"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/systemd"
+	"github.com/pkg/errors"
+)
+
+const (
+	// ResolvConf is the file the resolver reads
+	ResolvConf = "/etc/resolv.conf"
+
+	// DropIn is the resolved.conf drop-in written in resolved mode
+	DropIn = "/etc/systemd/resolved.conf.d/converge.conf"
+
+	// Service is restarted when the drop-in changes
+	Service = "systemd-resolved"
+
+	// ModeAuto picks a mode based on where resolv.conf points
+	ModeAuto = "auto"
+
+	// ModeResolved configures systemd-resolved
+	ModeResolved = "resolved"
+
+	// ModeFile writes resolv.conf
+	ModeFile = "file"
+)
+
+// DNS manages the system resolver configuration
+type DNS struct {
+	resource.Status
+
+	Nameservers []string
+	Search      []string
+	Options     []string
+	Resolved    map[string]string
+
+	// Mode is resolved or file once Check has run
+	Mode string
+
+	// Link is where resolv.conf points, if it is a link
+	Link string
+
+	exec exec.Executor
+}
+
+// Check whether the resolver is configured
+func (d *DNS) Check(resource.Renderer) (resource.TaskStatus, error) {
+	d.Status = resource.Status{}
+
+	if err := d.detect(); err != nil {
+		d.RaiseLevel(resource.StatusFatal)
+		return d, err
+	}
+
+	path, current, desired, err := d.content()
+	if err != nil {
+		d.RaiseLevel(resource.StatusFatal)
+		return d, err
+	}
+
+	if d.Mode == ModeFile && d.Link != "" {
+		current = "<link to " + d.Link + ">"
+	}
+	if current != desired {
+		d.RaiseLevel(resource.StatusWillChange)
+		d.AddDifference(path, current, desired, "<file-missing>")
+	}
+
+	return d, nil
+}
+
+// Apply writes the resolver configuration
+func (d *DNS) Apply() (resource.TaskStatus, error) {
+	d.Status = resource.Status{}
+
+	if err := d.detect(); err != nil {
+		d.RaiseLevel(resource.StatusFatal)
+		return d, err
+	}
+
+	if d.Mode == ModeFile && d.Link != "" {
+		if err := exec.Run(d.exec, "rm", "-f", ResolvConf); err != nil {
+			d.RaiseLevel(resource.StatusFatal)
+			return d, errors.Wrapf(err, "cannot remove link %s", ResolvConf)
+		}
+		d.AddMessage("replaced link to " + d.Link)
+		d.Link = ""
+	}
+
+	path, current, desired, err := d.content()
+	if err != nil {
+		d.RaiseLevel(resource.StatusFatal)
+		return d, err
+	}
+	if current == desired {
+		return d, nil
+	}
+
+	if d.Mode == ModeResolved {
+		err = exec.Run(d.exec, "mkdir", "-p", "/etc/systemd/resolved.conf.d")
+	}
+	if err == nil {
+		err = exec.WriteFile(d.exec, path, desired, 0644)
+	}
+	if err != nil {
+		d.RaiseLevel(resource.StatusFatal)
+		return d, errors.Wrapf(err, "cannot write %s", path)
+	}
+	d.AddMessage("wrote " + path)
+
+	if d.Mode == ModeResolved {
+		if err := exec.Run(d.exec, "systemctl", "restart", Service); err != nil {
+			d.RaiseLevel(resource.StatusFatal)
+			return d, errors.Wrapf(err, "cannot restart %s", Service)
+		}
+		d.AddMessage("restarted " + Service)
+	}
+
+	return d, nil
+}
+
+// detect reads where resolv.conf points and resolves the auto mode
+func (d *DNS) detect() error {
+	out, err := exec.Read(d.exec, "readlink", ResolvConf)
+	if err != nil {
+		if _, ok := exec.ExitStatus(err); !ok {
+			return err
+		}
+		out = ""
+	}
+	d.Link = strings.TrimSpace(out)
+
+	if d.Mode == ModeAuto {
+		d.Mode = ModeFile
+		if strings.Contains(d.Link, "/run/systemd/resolve/") {
+			d.Mode = ModeResolved
+		}
+	}
+	return nil
+}
+
+// content returns the file managed in the current mode, with its current
+// and desired content
+func (d *DNS) content() (string, string, string, error) {
+	path, desired := ResolvConf, d.renderFile()
+	if d.Mode == ModeResolved {
+		var err error
+		path = DropIn
+		desired, err = d.renderResolved()
+		if err != nil {
+			return "", "", "", err
+		}
+	}
+
+	current := ""
+	if d.Link == "" || d.Mode == ModeResolved {
+		var err error
+		current, _, err = exec.ReadFile(d.exec, path)
+		if err != nil {
+			return "", "", "", errors.Wrapf(err, "cannot read %s", path)
+		}
+	}
+	return path, current, desired, nil
+}
+
+func (d *DNS) renderFile() string {
+	lines := []string{"# managed by converge"}
+	for _, ns := range d.Nameservers {
+		lines = append(lines, "nameserver "+ns)
+	}
+	if len(d.Search) > 0 {
+		lines = append(lines, "search "+strings.Join(d.Search, " "))
+	}
+	if len(d.Options) > 0 {
+		lines = append(lines, "options "+strings.Join(d.Options, " "))
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+func (d *DNS) renderResolved() (string, error) {
+	section := map[string]interface{}{
+		"DNS": strings.Join(d.Nameservers, " "),
+	}
+	if len(d.Search) > 0 {
+		section["Domains"] = strings.Join(d.Search, " ")
+	}
+	for key, value := range d.Resolved {
+		section[key] = value
+	}
+	return systemd.Render(map[string]map[string]interface{}{"Resolve": section})
+}
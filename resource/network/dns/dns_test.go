@@ -0,0 +1,149 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dns_test
+
+import (
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/helpers/fakeexec"
+	"github.com/asteris-llc/converge/helpers/fakerenderer"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/network/dns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const write = exec.WriteFileScript
+
+var preparer = dns.Preparer{
+	Nameservers: []string{"10.0.0.2", "10.0.0.3"},
+	Search:      []string{"example.com"},
+	Options:     []string{"ndots:2"},
+	Resolved:    map[string]string{"DNSSEC": "allow-downgrade"},
+}
+
+// TestDNSInterface tests that DNS is properly implemented
+func TestDNSInterface(t *testing.T) {
+	t.Parallel()
+
+	assert.Implements(t, (*resource.Task)(nil), new(dns.DNS))
+	assert.Implements(t, (*resource.Resource)(nil), new(dns.Preparer))
+}
+
+// TestCheck tests detecting the mode and comparing the configuration
+func TestCheck(t *testing.T) {
+	t.Parallel()
+
+	t.Run("file", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("readlink", dns.ResolvConf).Return("", 1)
+		fake.Expect("test", "-e", dns.ResolvConf)
+		fake.Expect("cat", dns.ResolvConf).Return("nameserver 8.8.8.8\n", 0)
+
+		d := prepare(t, fake, preparer)
+		status, err := d.Check(fakerenderer.New())
+
+		require.NoError(t, err)
+		assert.Equal(t, dns.ModeFile, d.Mode)
+		assert.Equal(t, `# managed by converge
+nameserver 10.0.0.2
+nameserver 10.0.0.3
+search example.com
+options ndots:2
+`, status.Diffs()[dns.ResolvConf].Current())
+	})
+
+	t.Run("resolved", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("readlink", dns.ResolvConf).Return("../run/systemd/resolve/stub-resolv.conf\n", 0)
+		fake.Expect("test", "-e", dns.DropIn).Return("", 1)
+
+		d := prepare(t, fake, preparer)
+		status, err := d.Check(fakerenderer.New())
+
+		require.NoError(t, err)
+		assert.Equal(t, dns.ModeResolved, d.Mode)
+		assert.Equal(t, "[Resolve]\nDNS=10.0.0.2 10.0.0.3\nDNSSEC=allow-downgrade\nDomains=example.com\n", status.Diffs()[dns.DropIn].Current())
+		assert.NotContains(t, status.Diffs(), dns.ResolvConf)
+	})
+
+	t.Run("foreign link", func(t *testing.T) {
+		p := preparer
+		p.Mode = dns.ModeFile
+
+		fake := fakeexec.New()
+		fake.Expect("readlink", dns.ResolvConf).Return("/run/resolvconf/resolv.conf\n", 0)
+
+		status, err := prepare(t, fake, p).Check(fakerenderer.New())
+
+		require.NoError(t, err)
+		assert.Equal(t, "<link to /run/resolvconf/resolv.conf>", status.Diffs()[dns.ResolvConf].Original())
+	})
+}
+
+// TestApply tests writing the configuration for each mode
+func TestApply(t *testing.T) {
+	t.Parallel()
+
+	t.Run("resolved", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("readlink", dns.ResolvConf).Return("/run/systemd/resolve/stub-resolv.conf\n", 0)
+		fake.Expect("test", "-e", dns.DropIn).Return("", 1)
+		fake.Expect("mkdir", "-p", "/etc/systemd/resolved.conf.d")
+		fake.Expect("sh", "-c", write, dns.DropIn, "0644")
+		fake.Expect("systemctl", "restart", "systemd-resolved")
+
+		_, err := prepare(t, fake, preparer).Apply()
+
+		require.NoError(t, err)
+		fake.AssertExpectations(t)
+	})
+
+	t.Run("replace link", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("readlink", dns.ResolvConf).Return("/run/resolvconf/resolv.conf\n", 0)
+		fake.Expect("rm", "-f", dns.ResolvConf)
+		fake.Expect("test", "-e", dns.ResolvConf).Return("", 1)
+		fake.Expect("sh", "-c", write, dns.ResolvConf, "0644")
+
+		_, err := prepare(t, fake, preparer).Apply()
+
+		require.NoError(t, err)
+		fake.AssertExpectations(t)
+	})
+}
+
+// TestPrepare tests the invalid cases of Prepare
+func TestPrepare(t *testing.T) {
+	t.Parallel()
+
+	fr := fakerenderer.New()
+
+	_, err := (&dns.Preparer{}).Prepare(fr)
+	assert.EqualError(t, err, "network.dns requires at least one nameserver")
+
+	_, err = (&dns.Preparer{Nameservers: []string{"ns1.example.com"}}).Prepare(fr)
+	assert.EqualError(t, err, `network.dns: "ns1.example.com" is not an IP address`)
+
+	_, err = (&dns.Preparer{Nameservers: []string{"10.0.0.2"}, Resolved: map[string]string{"DNS": "10.0.0.3"}}).Prepare(fr)
+	assert.EqualError(t, err, `network.dns: set "DNS" with "nameservers" and "search" rather than "resolved"`)
+}
+
+func prepare(t *testing.T, fake *fakeexec.Executor, p dns.Preparer) *dns.DNS {
+	task, err := p.Prepare(&resource.ExecRenderer{Renderer: fakerenderer.New(), Exec: fake})
+	require.NoError(t, err)
+	return task.(*dns.DNS)
+}
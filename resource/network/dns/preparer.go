@@ -0,0 +1,98 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dns
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/load/registry"
+	"github.com/asteris-llc/converge/resource"
+)
+
+// Preparer for DNS
+//
+// DNS manages the nameservers and search domains the system resolves with.
+// When /etc/resolv.conf is a link into /run/systemd/resolve, writing it would
+// be undone by systemd-resolved, so a resolved.conf drop-in is written and
+// resolved restarted instead. Otherwise /etc/resolv.conf is written directly,
+// replacing any link to a file generated by another tool.
+type Preparer struct {
+	// Nameservers are the addresses of the DNS servers
+	Nameservers []string `hcl:"nameservers" required:"true"`
+
+	// Search are the domains searched for names without dots
+	Search []string `hcl:"search"`
+
+	// Options are resolv.conf options, such as "ndots:2". They are only used
+	// when resolv.conf is written directly.
+	Options []string `hcl:"options"`
+
+	// Resolved are extra [Resolve] settings for systemd-resolved, such as
+	// DNSSEC or FallbackDNS. They are only used with systemd-resolved.
+	Resolved map[string]string `hcl:"resolved"`
+
+	// Mode is "resolved" to configure systemd-resolved, "file" to write
+	// /etc/resolv.conf, or "auto" to pick based on where /etc/resolv.conf
+	// points. It defaults to auto.
+	Mode string `hcl:"mode" valid_values:"auto,resolved,file"`
+}
+
+// Prepare a new task
+func (p *Preparer) Prepare(render resource.Renderer) (resource.Task, error) {
+	if len(p.Nameservers) == 0 {
+		return nil, fmt.Errorf("network.dns requires at least one nameserver")
+	}
+
+	for _, ns := range p.Nameservers {
+		if net.ParseIP(ns) == nil {
+			return nil, fmt.Errorf("network.dns: %q is not an IP address", ns)
+		}
+	}
+
+	for _, values := range [][]string{p.Search, p.Options} {
+		for _, value := range values {
+			if value == "" || strings.ContainsAny(value, " \t\n") {
+				return nil, fmt.Errorf("network.dns: %q is not a valid search domain or option", value)
+			}
+		}
+	}
+
+	for key := range p.Resolved {
+		if key == "DNS" || key == "Domains" {
+			return nil, fmt.Errorf("network.dns: set %q with \"nameservers\" and \"search\" rather than \"resolved\"", key)
+		}
+	}
+
+	if p.Mode == "" {
+		p.Mode = ModeAuto
+	}
+
+	return &DNS{
+		Nameservers: p.Nameservers,
+		Search:      p.Search,
+		Options:     p.Options,
+		Resolved:    p.Resolved,
+		Mode:        p.Mode,
+		exec:        exec.For(render),
+	}, nil
+}
+
+func init() {
+	registry.Register("network.dns", (*Preparer)(nil), (*DNS)(nil))
+	registry.RegisterPlatforms("network.dns", "linux")
+}
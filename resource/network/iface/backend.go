@@ -0,0 +1,327 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package iface
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+)
+
+// Config is the configuration of a single interface
+type Config struct {
+	Name      string
+	DHCP      bool
+	Addresses []string
+	Gateway   string
+	DNS       []string
+	Search    []string
+	Routes    map[string]string
+	MTU       int
+}
+
+// destinations returns the route destinations, sorted
+func (c *Config) destinations() []string {
+	var out []string
+	for dest := range c.Routes {
+		out = append(out, dest)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Backend writes interface configuration for a network management service
+type Backend interface {
+	// Path is the file the configuration is written to
+	Path(*Config) string
+
+	// Mode is the mode the file is written with
+	Mode() os.FileMode
+
+	// Render formats the configuration for the backend
+	Render(*Config) string
+
+	// Reload makes the backend apply the configuration in the file, or remove
+	// the configuration if the file no longer exists
+	Reload(*Config) error
+}
+
+// Backends are the names accepted by NewBackend
+var Backends = []string{"networkd", "netplan", "networkmanager"}
+
+// NewBackend returns the named backend, which runs commands with e
+func NewBackend(e exec.Executor, name string) (Backend, error) {
+	switch name {
+	case "networkd":
+		return &Networkd{Executor: e}, nil
+	case "netplan":
+		return &Netplan{Executor: e}, nil
+	case "networkmanager":
+		return &NetworkManager{Executor: e}, nil
+	}
+
+	return nil, fmt.Errorf("%q is not a valid backend, expected one of %s", name, strings.Join(Backends, ", "))
+}
+
+// Detect returns the name of the backend for the system e runs commands on.
+// netplan is preferred where it is installed, since it generates the
+// configuration of the other two.
+func Detect(e exec.Executor) (string, error) {
+	err := exec.Run(e, "sh", "-c", `command -v "$0"`, "netplan")
+	if err == nil {
+		return "netplan", nil
+	} else if _, ok := exec.ExitStatus(err); !ok {
+		return "", err
+	}
+
+	err = exec.Run(e, "systemctl", "is-active", "--quiet", "NetworkManager")
+	if err == nil {
+		return "networkmanager", nil
+	} else if _, ok := exec.ExitStatus(err); !ok {
+		return "", err
+	}
+
+	return "networkd", nil
+}
+
+// Networkd configures interfaces with systemd-networkd
+type Networkd struct {
+	Executor exec.Executor
+}
+
+// Path returns the .network file for the interface
+func (n *Networkd) Path(c *Config) string {
+	return path.Join("/etc/systemd/network", "10-converge-"+c.Name+".network")
+}
+
+// Mode returns the mode of .network files
+func (n *Networkd) Mode() os.FileMode { return 0644 }
+
+// Render formats the configuration as a .network file
+func (n *Networkd) Render(c *Config) string {
+	lines := []string{"[Match]", "Name=" + c.Name, "", "[Network]"}
+	if c.DHCP {
+		lines = append(lines, "DHCP=yes")
+	}
+	for _, address := range c.Addresses {
+		lines = append(lines, "Address="+address)
+	}
+	if c.Gateway != "" {
+		lines = append(lines, "Gateway="+c.Gateway)
+	}
+	for _, dns := range c.DNS {
+		lines = append(lines, "DNS="+dns)
+	}
+	if len(c.Search) > 0 {
+		lines = append(lines, "Domains="+strings.Join(c.Search, " "))
+	}
+
+	if c.MTU > 0 {
+		lines = append(lines, "", "[Link]", fmt.Sprintf("MTUBytes=%d", c.MTU))
+	}
+
+	for _, dest := range c.destinations() {
+		lines = append(lines, "", "[Route]", "Destination="+dest, "Gateway="+c.Routes[dest])
+	}
+
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// Reload makes networkd reread its configuration and reconfigure the
+// interface
+func (n *Networkd) Reload(c *Config) error {
+	if err := exec.Run(n.Executor, "networkctl", "reload"); err != nil {
+		return err
+	}
+	return exec.Run(n.Executor, "networkctl", "reconfigure", c.Name)
+}
+
+// Netplan configures interfaces with netplan
+type Netplan struct {
+	Executor exec.Executor
+}
+
+// Path returns the netplan file for the interface
+func (n *Netplan) Path(c *Config) string {
+	return path.Join("/etc/netplan", "90-converge-"+c.Name+".yaml")
+}
+
+// Mode returns the mode of netplan files, which netplan requires not be
+// readable by other users
+func (n *Netplan) Mode() os.FileMode { return 0600 }
+
+// Render formats the configuration as netplan YAML
+func (n *Netplan) Render(c *Config) string {
+	lines := []string{
+		"network:",
+		"  version: 2",
+		"  ethernets:",
+		"    " + c.Name + ":",
+		fmt.Sprintf("      dhcp4: %t", c.DHCP),
+	}
+
+	if len(c.Addresses) > 0 {
+		lines = append(lines, "      addresses:")
+		for _, address := range c.Addresses {
+			lines = append(lines, "        - "+address)
+		}
+	}
+
+	if c.Gateway != "" || len(c.Routes) > 0 {
+		lines = append(lines, "      routes:")
+		if c.Gateway != "" {
+			lines = append(lines, "        - to: default", "          via: "+c.Gateway)
+		}
+		for _, dest := range c.destinations() {
+			lines = append(lines, "        - to: "+dest, "          via: "+c.Routes[dest])
+		}
+	}
+
+	if len(c.DNS) > 0 || len(c.Search) > 0 {
+		lines = append(lines, "      nameservers:")
+		if len(c.DNS) > 0 {
+			lines = append(lines, "        addresses: ["+strings.Join(c.DNS, ", ")+"]")
+		}
+		if len(c.Search) > 0 {
+			lines = append(lines, "        search: ["+strings.Join(c.Search, ", ")+"]")
+		}
+	}
+
+	if c.MTU > 0 {
+		lines = append(lines, fmt.Sprintf("      mtu: %d", c.MTU))
+	}
+
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// Reload applies the netplan configuration
+func (n *Netplan) Reload(*Config) error {
+	return exec.Run(n.Executor, "netplan", "apply")
+}
+
+// NetworkManager configures interfaces with NetworkManager keyfiles
+type NetworkManager struct {
+	Executor exec.Executor
+}
+
+// Path returns the keyfile for the interface's connection
+func (n *NetworkManager) Path(c *Config) string {
+	return path.Join("/etc/NetworkManager/system-connections", connection(c)+".nmconnection")
+}
+
+// Mode returns the mode of keyfiles, which NetworkManager ignores if they are
+// readable by other users
+func (n *NetworkManager) Mode() os.FileMode { return 0600 }
+
+// Render formats the configuration as a keyfile. IPv4 and IPv6 addresses,
+// routes, and name servers go in their own sections.
+func (n *NetworkManager) Render(c *Config) string {
+	lines := []string{
+		"[connection]",
+		"id=" + connection(c),
+		"type=ethernet",
+		"interface-name=" + c.Name,
+	}
+
+	if c.MTU > 0 {
+		lines = append(lines, "", "[ethernet]", fmt.Sprintf("mtu=%d", c.MTU))
+	}
+
+	for _, family := range []string{"ipv4", "ipv6"} {
+		v6 := family == "ipv6"
+		lines = append(lines, "", "["+family+"]")
+
+		var addresses, dns []string
+		for _, address := range c.Addresses {
+			if isV6(address) == v6 {
+				addresses = append(addresses, address)
+			}
+		}
+		for _, server := range c.DNS {
+			if isV6(server) == v6 {
+				dns = append(dns, server)
+			}
+		}
+
+		switch {
+		case len(addresses) > 0:
+			lines = append(lines, "method=manual")
+		case c.DHCP || v6:
+			lines = append(lines, "method=auto")
+		default:
+			lines = append(lines, "method=disabled")
+		}
+
+		for i, address := range addresses {
+			lines = append(lines, fmt.Sprintf("address%d=%s", i+1, address))
+		}
+		if c.Gateway != "" && isV6(c.Gateway) == v6 {
+			lines = append(lines, "gateway="+c.Gateway)
+		}
+		if len(dns) > 0 {
+			lines = append(lines, "dns="+strings.Join(dns, ";")+";")
+		}
+		if len(c.Search) > 0 && !v6 {
+			lines = append(lines, "dns-search="+strings.Join(c.Search, ";")+";")
+		}
+
+		n := 0
+		for _, dest := range c.destinations() {
+			if isV6(dest) == v6 {
+				n++
+				lines = append(lines, fmt.Sprintf("route%d=%s,%s", n, dest, c.Routes[dest]))
+			}
+		}
+	}
+
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// Reload makes NetworkManager reread its keyfiles and activates the
+// connection if it still exists
+func (n *NetworkManager) Reload(c *Config) error {
+	if err := exec.Run(n.Executor, "nmcli", "connection", "reload"); err != nil {
+		return err
+	}
+
+	err := exec.Run(n.Executor, "nmcli", "connection", "show", connection(c))
+	if _, ok := exec.ExitStatus(err); ok {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	return exec.Run(n.Executor, "nmcli", "connection", "up", connection(c))
+}
+
+func connection(c *Config) string {
+	return "converge-" + c.Name
+}
+
+// isV6 reports whether an address, with or without a prefix length, is IPv6
+func isV6(address string) bool {
+	if ip, _, err := net.ParseCIDR(address); err == nil {
+		return ip.To4() == nil
+	}
+	if ip := net.ParseIP(address); ip != nil {
+		return ip.To4() == nil
+	}
+	return false
+}
@@ -0,0 +1,137 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package iface
+
+import (
+	"fmt"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/pkg/errors"
+)
+
+// Interface manages the configuration of a network interface
+type Interface struct {
+	resource.Status
+	Config
+
+	// Backend is the network service the configuration is written for
+	Backend string
+
+	CheckHost string
+
+	// Path is the file the configuration is written to
+	Path string
+
+	backend Backend
+	exec    exec.Executor
+}
+
+// Check whether the configuration file is up to date
+func (i *Interface) Check(resource.Renderer) (resource.TaskStatus, error) {
+	i.Status = resource.Status{}
+
+	current, err := i.read()
+	if err != nil {
+		i.RaiseLevel(resource.StatusFatal)
+		return i, err
+	}
+
+	if desired := i.backend.Render(&i.Config); current != desired {
+		i.RaiseLevel(resource.StatusWillChange)
+		i.AddDifference(i.Path, current, desired, "<file-missing>")
+	}
+
+	return i, nil
+}
+
+// Apply writes the configuration and reloads the network service, rolling
+// back if the check host can no longer be reached
+func (i *Interface) Apply() (resource.TaskStatus, error) {
+	i.Status = resource.Status{}
+
+	previous, err := i.read()
+	if err != nil {
+		i.RaiseLevel(resource.StatusFatal)
+		return i, err
+	}
+
+	if err := i.install(i.backend.Render(&i.Config)); err != nil {
+		i.RaiseLevel(resource.StatusFatal)
+		return i, err
+	}
+	i.AddMessage(fmt.Sprintf("configured %s with %s", i.Name, i.Backend))
+
+	if i.CheckHost == "" || i.reachable() {
+		return i, nil
+	}
+
+	i.RaiseLevel(resource.StatusFatal)
+	if err := i.install(previous); err != nil {
+		return i, errors.Wrapf(err, "lost connectivity to %s after configuring %s and could not roll back", i.CheckHost, i.Name)
+	}
+	i.AddMessage("rolled back " + i.Path)
+	return i, fmt.Errorf("lost connectivity to %s after configuring %s, rolled back", i.CheckHost, i.Name)
+}
+
+// install writes content to the configuration file, or removes the file if
+// content is empty, and reloads the network service
+func (i *Interface) install(content string) error {
+	if content == "" {
+		if err := exec.Run(i.exec, "rm", "-f", i.Path); err != nil {
+			return errors.Wrapf(err, "cannot remove %s", i.Path)
+		}
+	} else if err := exec.WriteFile(i.exec, i.Path, content, i.backend.Mode()); err != nil {
+		return errors.Wrapf(err, "cannot write %s", i.Path)
+	}
+
+	if err := i.backend.Reload(&i.Config); err != nil {
+		return errors.Wrapf(err, "cannot reload %s", i.Backend)
+	}
+	return nil
+}
+
+// reachable pings the check host a few times, allowing for the interface to
+// come back up
+func (i *Interface) reachable() bool {
+	return exec.Run(i.exec, "ping", "-c", "3", "-w", "30", i.CheckHost) == nil
+}
+
+// read returns the content of the configuration file, detecting the backend
+// if necessary. A missing file is empty.
+func (i *Interface) read() (string, error) {
+	if i.backend == nil {
+		if i.Backend == "" {
+			detected, err := Detect(i.exec)
+			if err != nil {
+				return "", errors.Wrap(err, "cannot detect network backend")
+			}
+			i.Backend = detected
+		}
+
+		backend, err := NewBackend(i.exec, i.Backend)
+		if err != nil {
+			return "", err
+		}
+		i.backend = backend
+		i.Path = backend.Path(&i.Config)
+	}
+
+	content, _, err := exec.ReadFile(i.exec, i.Path)
+	if err != nil {
+		return "", errors.Wrapf(err, "cannot read %s", i.Path)
+	}
+	return content, nil
+}
@@ -0,0 +1,211 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package iface_test
+
+import (
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/helpers/fakeexec"
+	"github.com/asteris-llc/converge/helpers/fakerenderer"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/network/iface"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	network = "/etc/systemd/network/10-converge-eth0.network"
+	write   = exec.WriteFileScript
+)
+
+var config = &iface.Config{
+	Name:      "eth0",
+	Addresses: []string{"10.0.0.5/24", "2001:db8::5/64"},
+	Gateway:   "10.0.0.1",
+	DNS:       []string{"10.0.0.2"},
+	Search:    []string{"example.com"},
+	Routes:    map[string]string{"10.1.0.0/16": "10.0.0.254"},
+	MTU:       9000,
+}
+
+// TestInterfaceInterface tests that Interface is properly implemented
+func TestInterfaceInterface(t *testing.T) {
+	t.Parallel()
+
+	assert.Implements(t, (*resource.Task)(nil), new(iface.Interface))
+	assert.Implements(t, (*resource.Resource)(nil), new(iface.Preparer))
+}
+
+// TestRender tests rendering configuration for each backend
+func TestRender(t *testing.T) {
+	t.Parallel()
+
+	t.Run("networkd", func(t *testing.T) {
+		assert.Equal(t, `[Match]
+Name=eth0
+
+[Network]
+Address=10.0.0.5/24
+Address=2001:db8::5/64
+Gateway=10.0.0.1
+DNS=10.0.0.2
+Domains=example.com
+
+[Link]
+MTUBytes=9000
+
+[Route]
+Destination=10.1.0.0/16
+Gateway=10.0.0.254
+`, new(iface.Networkd).Render(config))
+	})
+
+	t.Run("netplan", func(t *testing.T) {
+		assert.Equal(t, `network:
+  version: 2
+  ethernets:
+    eth0:
+      dhcp4: false
+      addresses:
+        - 10.0.0.5/24
+        - 2001:db8::5/64
+      routes:
+        - to: default
+          via: 10.0.0.1
+        - to: 10.1.0.0/16
+          via: 10.0.0.254
+      nameservers:
+        addresses: [10.0.0.2]
+        search: [example.com]
+      mtu: 9000
+`, new(iface.Netplan).Render(config))
+	})
+
+	t.Run("networkmanager", func(t *testing.T) {
+		assert.Equal(t, `[connection]
+id=converge-eth0
+type=ethernet
+interface-name=eth0
+
+[ethernet]
+mtu=9000
+
+[ipv4]
+method=manual
+address1=10.0.0.5/24
+gateway=10.0.0.1
+dns=10.0.0.2;
+dns-search=example.com;
+route1=10.1.0.0/16,10.0.0.254
+
+[ipv6]
+method=manual
+address1=2001:db8::5/64
+`, new(iface.NetworkManager).Render(config))
+	})
+}
+
+// TestCheck tests comparing the configuration file
+func TestCheck(t *testing.T) {
+	t.Parallel()
+
+	t.Run("missing", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("test", "-e", network).Return("", 1)
+
+		i := prepare(t, fake, &iface.Preparer{Backend: "networkd"})
+		status, err := i.Check(fakerenderer.New())
+
+		require.NoError(t, err)
+		assert.True(t, status.HasChanges())
+		assert.Equal(t, "<file-missing>", status.Diffs()[network].Original())
+	})
+
+	t.Run("detect", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("sh", "-c", `command -v "$0"`, "netplan").Return("", 1)
+		fake.Expect("systemctl", "is-active", "--quiet", "NetworkManager")
+		fake.Expect("test", "-e", "/etc/NetworkManager/system-connections/converge-eth0.nmconnection").Return("", 1)
+
+		i := prepare(t, fake, &iface.Preparer{})
+		_, err := i.Check(fakerenderer.New())
+
+		require.NoError(t, err)
+		assert.Equal(t, "networkmanager", i.Backend)
+	})
+}
+
+// TestApply tests writing the configuration and rolling it back
+func TestApply(t *testing.T) {
+	t.Parallel()
+
+	t.Run("reachable", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("test", "-e", network).Return("", 1)
+		fake.Expect("sh", "-c", write, network, "0644")
+		fake.Expect("networkctl", "reload")
+		fake.Expect("networkctl", "reconfigure", "eth0")
+		fake.Expect("ping", "-c", "3", "-w", "30", "10.0.0.100")
+
+		_, err := prepare(t, fake, &iface.Preparer{Backend: "networkd", CheckHost: "10.0.0.100"}).Apply()
+		require.NoError(t, err)
+		fake.AssertExpectations(t)
+	})
+
+	t.Run("rollback", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("test", "-e", network)
+		fake.Expect("cat", network).Return("[Match]\nName=eth0\n\n[Network]\nDHCP=yes\n", 0)
+		fake.Expect("sh", "-c", write, network, "0644").Times(2)
+		fake.Expect("networkctl", "reload").Times(2)
+		fake.Expect("networkctl", "reconfigure", "eth0").Times(2)
+		fake.Expect("ping", "-c", "3", "-w", "30", "10.0.0.100").Return("", 1)
+
+		i := prepare(t, fake, &iface.Preparer{Backend: "networkd", CheckHost: "10.0.0.100"})
+		status, err := i.Apply()
+
+		assert.EqualError(t, err, "lost connectivity to 10.0.0.100 after configuring eth0, rolled back")
+		assert.Equal(t, resource.StatusFatal, status.StatusCode())
+
+		calls := fake.Calls()
+		assert.Equal(t, "[Match]\nName=eth0\n\n[Network]\nDHCP=yes\n", calls[len(calls)-3].Stdin)
+	})
+}
+
+// TestPrepare tests the invalid cases of Prepare
+func TestPrepare(t *testing.T) {
+	t.Parallel()
+
+	fr := fakerenderer.New()
+
+	_, err := (&iface.Preparer{Name: "eth0"}).Prepare(fr)
+	assert.EqualError(t, err, `network.interface requires "dhcp" or "addresses"`)
+
+	_, err = (&iface.Preparer{Name: "eth0", Addresses: []string{"10.0.0.5"}}).Prepare(fr)
+	assert.EqualError(t, err, `network.interface: "10.0.0.5" is not an address with a prefix length`)
+
+	_, err = (&iface.Preparer{Name: "eth0", DHCP: true, Routes: map[string]string{"10.1.0.0/16": "router"}}).Prepare(fr)
+	assert.EqualError(t, err, `network.interface: "router" is not an IP address`)
+}
+
+func prepare(t *testing.T, fake *fakeexec.Executor, p *iface.Preparer) *iface.Interface {
+	p.Name = "eth0"
+	p.Addresses = []string{"10.0.0.5/24"}
+
+	task, err := p.Prepare(&resource.ExecRenderer{Renderer: fakerenderer.New(), Exec: fake})
+	require.NoError(t, err)
+	return task.(*iface.Interface)
+}
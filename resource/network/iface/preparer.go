@@ -0,0 +1,133 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package iface
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/load/registry"
+	"github.com/asteris-llc/converge/resource"
+)
+
+// Preparer for Interface
+//
+// Interface configures the addresses, routes, and name servers of a network
+// interface through the network service the host uses: netplan,
+// NetworkManager, or systemd-networkd. The configuration is written to a file
+// of its own, so configuration made by other means is left alone. When
+// check_host is set it is pinged after the change, and the previous
+// configuration is restored if it can no longer be reached, so a mistake
+// can't cut off the host from the machine managing it.
+type Preparer struct {
+	// Name is the name of the interface, such as eth0
+	Name string `hcl:"name" required:"true"`
+
+	// DHCP gets an IPv4 address by DHCP, in addition to any static addresses
+	DHCP bool `hcl:"dhcp"`
+
+	// Addresses are static addresses with prefix lengths, such as
+	// "10.0.0.5/24" or "2001:db8::5/64"
+	Addresses []string `hcl:"addresses"`
+
+	// Gateway is the default gateway
+	Gateway string `hcl:"gateway"`
+
+	// DNS are the name servers to use
+	DNS []string `hcl:"dns"`
+
+	// Search are the domains to search for host names
+	Search []string `hcl:"search"`
+
+	// Routes maps destination networks to the gateways to reach them through,
+	// such as "10.1.0.0/16" = "10.0.0.254"
+	Routes map[string]string `hcl:"routes"`
+
+	// MTU is the maximum transmission unit of the interface
+	MTU int `hcl:"mtu" min:"0"`
+
+	// Backend is the network service to configure. It is detected if not set.
+	Backend string `hcl:"backend" valid_values:"networkd,netplan,networkmanager"`
+
+	// CheckHost is pinged after the change. If it doesn't answer, the change
+	// is rolled back. This is usually the address of the machine running
+	// converge.
+	CheckHost string `hcl:"check_host"`
+}
+
+// Prepare a new task
+func (p *Preparer) Prepare(render resource.Renderer) (resource.Task, error) {
+	if p.Name == "" || strings.ContainsAny(p.Name, "/ \t\n:") {
+		return nil, fmt.Errorf("network.interface: %q is not a valid interface name", p.Name)
+	}
+
+	if !p.DHCP && len(p.Addresses) == 0 {
+		return nil, fmt.Errorf("network.interface requires \"dhcp\" or \"addresses\"")
+	}
+
+	for _, address := range p.Addresses {
+		if _, _, err := net.ParseCIDR(address); err != nil {
+			return nil, fmt.Errorf("network.interface: %q is not an address with a prefix length", address)
+		}
+	}
+
+	ips := append([]string{}, p.DNS...)
+	if p.Gateway != "" {
+		ips = append(ips, p.Gateway)
+	}
+	for _, via := range p.Routes {
+		ips = append(ips, via)
+	}
+	for _, ip := range ips {
+		if net.ParseIP(ip) == nil {
+			return nil, fmt.Errorf("network.interface: %q is not an IP address", ip)
+		}
+	}
+
+	for dest := range p.Routes {
+		if _, _, err := net.ParseCIDR(dest); err != nil {
+			return nil, fmt.Errorf("network.interface: route destination %q is not a network", dest)
+		}
+	}
+
+	for _, domain := range p.Search {
+		if domain == "" || strings.ContainsAny(domain, " \t\n,;[]") {
+			return nil, fmt.Errorf("network.interface: %q is not a valid search domain", domain)
+		}
+	}
+
+	return &Interface{
+		Config: Config{
+			Name:      p.Name,
+			DHCP:      p.DHCP,
+			Addresses: p.Addresses,
+			Gateway:   p.Gateway,
+			DNS:       p.DNS,
+			Search:    p.Search,
+			Routes:    p.Routes,
+			MTU:       p.MTU,
+		},
+		Backend:   p.Backend,
+		CheckHost: p.CheckHost,
+		exec:      exec.For(render),
+	}, nil
+}
+
+func init() {
+	registry.Register("network.interface", (*Preparer)(nil), (*Interface)(nil))
+	registry.RegisterPlatforms("network.interface", "linux")
+}
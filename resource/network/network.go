@@ -0,0 +1,97 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package network
+
+import (
+	"path"
+	"strings"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/resource/systemd"
+	"github.com/pkg/errors"
+)
+
+// IP is the path of ip(8) used in boot units
+const IP = "/sbin/ip"
+
+// BootUnit renders a oneshot unit that runs commands once the network is up,
+// restoring runtime state such as routes at boot. Commands in pre may fail.
+func BootUnit(description string, pre, start [][]string) (string, error) {
+	service := map[string]interface{}{
+		"Type":            "oneshot",
+		"RemainAfterExit": true,
+	}
+
+	var pres, starts []interface{}
+	for _, argv := range pre {
+		pres = append(pres, "-"+strings.Join(argv, " "))
+	}
+	for _, argv := range start {
+		starts = append(starts, strings.Join(argv, " "))
+	}
+	if len(pres) > 0 {
+		service["ExecStartPre"] = pres
+	}
+	service["ExecStart"] = starts
+
+	return systemd.Render(map[string]map[string]interface{}{
+		"Unit": {
+			"Description": description,
+			"After":       "network-online.target",
+			"Wants":       "network-online.target",
+		},
+		"Service": service,
+		"Install": {
+			"WantedBy": "multi-user.target",
+		},
+	})
+}
+
+// UnitPath returns the path of a boot unit
+func UnitPath(unit string) string {
+	return path.Join(systemd.Dir, unit)
+}
+
+// ReadUnit returns the content of a boot unit, or an empty string if it
+// doesn't exist
+func ReadUnit(e exec.Executor, unit string) (string, error) {
+	content, _, err := exec.ReadFile(e, UnitPath(unit))
+	if err != nil {
+		return "", errors.Wrapf(err, "cannot read %s", UnitPath(unit))
+	}
+	return content, nil
+}
+
+// InstallUnit writes and enables a boot unit
+func InstallUnit(e exec.Executor, unit, content string) error {
+	if err := exec.WriteFile(e, UnitPath(unit), content, systemd.Mode); err != nil {
+		return errors.Wrapf(err, "cannot write %s", UnitPath(unit))
+	}
+	if err := systemd.DaemonReload(e); err != nil {
+		return err
+	}
+	return exec.Run(e, "systemctl", "enable", unit)
+}
+
+// RemoveUnit disables and removes a boot unit
+func RemoveUnit(e exec.Executor, unit string) error {
+	if err := exec.Run(e, "systemctl", "disable", unit); err != nil {
+		return err
+	}
+	if err := exec.Run(e, "rm", "-f", UnitPath(unit)); err != nil {
+		return errors.Wrapf(err, "cannot remove %s", UnitPath(unit))
+	}
+	return systemd.DaemonReload(e)
+}
@@ -0,0 +1,39 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package network_test
+
+import (
+	"testing"
+
+	"github.com/asteris-llc/converge/resource/network"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestBootUnit tests rendering a boot unit
+func TestBootUnit(t *testing.T) {
+	t.Parallel()
+
+	unit, err := network.BootUnit(
+		"converge rule 100",
+		[][]string{{network.IP, "-4", "rule", "del", "pref", "100"}},
+		[][]string{{network.IP, "-4", "rule", "add", "pref", "100", "from", "all", "lookup", "web"}},
+	)
+	require.NoError(t, err)
+	assert.Contains(t, unit, "ExecStartPre=-/sbin/ip -4 rule del pref 100\n")
+	assert.Contains(t, unit, "ExecStart=/sbin/ip -4 rule add pref 100 from all lookup web\n")
+	assert.Contains(t, unit, "RemainAfterExit=yes\n")
+	assert.Contains(t, unit, "WantedBy=multi-user.target\n")
+}
@@ -0,0 +1,117 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package route
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/load/registry"
+	"github.com/asteris-llc/converge/resource"
+)
+
+// Preparer for Route
+//
+// Route manages a static route in the kernel routing table with ip(8), and
+// compares against the live table rather than configuration files. Unless
+// persistent is false, a oneshot systemd unit is also installed to add the
+// route again at boot.
+type Preparer struct {
+	// Destination is the network the route is for, such as "10.1.0.0/16", or
+	// "default"
+	Destination string `hcl:"destination" required:"true"`
+
+	// Gateway is the address of the next hop
+	Gateway string `hcl:"gateway"`
+
+	// Device is the interface the route goes through
+	Device string `hcl:"device"`
+
+	// Table is the routing table. It defaults to main.
+	Table string `hcl:"table"`
+
+	// Metric is the preference of the route, lower being preferred
+	Metric int `hcl:"metric" min:"0"`
+
+	// Persistent controls whether the route is added again at boot. It
+	// defaults to true.
+	Persistent *bool `hcl:"persistent"`
+
+	// State is whether the route should be present or absent. It defaults to
+	// present.
+	State State `hcl:"state" valid_values:"present,absent"`
+}
+
+// Prepare a new task
+func (p *Preparer) Prepare(render resource.Renderer) (resource.Task, error) {
+	v6 := false
+	if p.Destination != "default" {
+		ip, _, err := net.ParseCIDR(p.Destination)
+		if err != nil {
+			return nil, fmt.Errorf("network.route: %q is not a network", p.Destination)
+		}
+		v6 = ip.To4() == nil
+	}
+
+	if p.Gateway != "" {
+		ip := net.ParseIP(p.Gateway)
+		if ip == nil {
+			return nil, fmt.Errorf("network.route: %q is not an IP address", p.Gateway)
+		}
+		v6 = ip.To4() == nil
+	}
+
+	if p.State == "" {
+		p.State = StatePresent
+	}
+
+	if p.State == StatePresent && p.Gateway == "" && p.Device == "" {
+		return nil, fmt.Errorf("network.route requires \"gateway\" or \"device\"")
+	}
+
+	if p.Table == "" {
+		p.Table = "main"
+	}
+
+	for _, value := range []string{p.Device, p.Table} {
+		if strings.ContainsAny(value, " \t\n/") {
+			return nil, fmt.Errorf("network.route: %q is not a valid device or table", value)
+		}
+	}
+
+	persistent := true
+	if p.Persistent != nil {
+		persistent = *p.Persistent
+	}
+
+	return &Route{
+		Destination: p.Destination,
+		Gateway:     p.Gateway,
+		Device:      p.Device,
+		Table:       p.Table,
+		Metric:      p.Metric,
+		Persistent:  persistent,
+		State:       p.State,
+		v6:          v6,
+		exec:        exec.For(render),
+	}, nil
+}
+
+func init() {
+	registry.Register("network.route", (*Preparer)(nil), (*Route)(nil))
+	registry.RegisterPlatforms("network.route", "linux")
+}
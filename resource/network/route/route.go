@@ -0,0 +1,254 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package route
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/network"
+	"github.com/pkg/errors"
+)
+
+// State type for Route
+type State string
+
+const (
+	// StatePresent indicates the route should be present
+	StatePresent State = "present"
+
+	// StateAbsent indicates the route should be absent
+	StateAbsent State = "absent"
+)
+
+// Live is a route as reported by ip route show
+type Live struct {
+	Gateway string
+	Device  string
+	Metric  int
+}
+
+// ParseLive parses the first route in the output of ip route show, returning
+// false if there is none
+func ParseLive(out string) (Live, bool) {
+	line := strings.TrimSpace(strings.SplitN(out, "\n", 2)[0])
+	if line == "" {
+		return Live{}, false
+	}
+
+	var live Live
+	fields := strings.Fields(line)
+	for i := 1; i+1 < len(fields); i++ {
+		switch fields[i] {
+		case "via":
+			live.Gateway = fields[i+1]
+		case "dev":
+			live.Device = fields[i+1]
+		case "metric":
+			live.Metric, _ = strconv.Atoi(fields[i+1])
+		default:
+			continue
+		}
+		i++
+	}
+	return live, true
+}
+
+func (l Live) String() string {
+	var fields []string
+	if l.Gateway != "" {
+		fields = append(fields, "via", l.Gateway)
+	}
+	if l.Device != "" {
+		fields = append(fields, "dev", l.Device)
+	}
+	if l.Metric != 0 {
+		fields = append(fields, "metric", strconv.Itoa(l.Metric))
+	}
+	return strings.Join(fields, " ")
+}
+
+// Route manages a static route
+type Route struct {
+	resource.Status
+
+	Destination string
+	Gateway     string
+	Device      string
+	Table       string
+	Metric      int
+	Persistent  bool
+	State       State
+
+	v6   bool
+	exec exec.Executor
+}
+
+// Check whether the route is in the live table and the boot unit is installed
+func (r *Route) Check(resource.Renderer) (resource.TaskStatus, error) {
+	r.Status = resource.Status{}
+
+	live, exists, err := r.live()
+	if err != nil {
+		r.RaiseLevel(resource.StatusFatal)
+		return r, err
+	}
+
+	key := fmt.Sprintf("%s table %s", r.Destination, r.Table)
+	switch {
+	case r.State == StateAbsent && exists:
+		r.RaiseLevel(resource.StatusWillChange)
+		r.AddDifference(key, live.String(), "<absent>", "")
+
+	case r.State == StatePresent && !exists:
+		r.RaiseLevel(resource.StatusWillChange)
+		r.AddDifference(key, "<absent>", r.desired().String(), "")
+
+	case r.State == StatePresent && !r.matches(live):
+		r.RaiseLevel(resource.StatusWillChange)
+		r.AddDifference(key, live.String(), r.desired().String(), "")
+	}
+
+	current, desired, err := r.unit()
+	if err != nil {
+		r.RaiseLevel(resource.StatusFatal)
+		return r, err
+	}
+	if current != desired {
+		r.RaiseLevel(resource.StatusWillChange)
+		r.AddDifference(network.UnitPath(r.unitName()), current, desired, "<file-missing>")
+	}
+
+	return r, nil
+}
+
+// Apply changes the live table and installs or removes the boot unit
+func (r *Route) Apply() (resource.TaskStatus, error) {
+	r.Status = resource.Status{}
+
+	live, exists, err := r.live()
+	if err != nil {
+		r.RaiseLevel(resource.StatusFatal)
+		return r, err
+	}
+
+	if exists && (r.State == StateAbsent || live.Metric != r.Metric) {
+		args := append(r.family(), "route", "del", r.Destination, "table", r.Table, "metric", strconv.Itoa(live.Metric))
+		if err := exec.Run(r.exec, "ip", args...); err != nil {
+			r.RaiseLevel(resource.StatusFatal)
+			return r, errors.Wrapf(err, "cannot delete route to %s", r.Destination)
+		}
+		r.AddMessage("deleted route to " + r.Destination)
+	}
+
+	if r.State == StatePresent && (!exists || !r.matches(live)) {
+		argv := r.replace()
+		if err := exec.Run(r.exec, argv[0], argv[1:]...); err != nil {
+			r.RaiseLevel(resource.StatusFatal)
+			return r, errors.Wrapf(err, "cannot add route to %s", r.Destination)
+		}
+		r.AddMessage("added route to " + r.Destination)
+	}
+
+	current, desired, err := r.unit()
+	if err != nil {
+		r.RaiseLevel(resource.StatusFatal)
+		return r, err
+	}
+	if current != desired {
+		if desired == "" {
+			err = network.RemoveUnit(r.exec, r.unitName())
+		} else {
+			err = network.InstallUnit(r.exec, r.unitName(), desired)
+		}
+		if err != nil {
+			r.RaiseLevel(resource.StatusFatal)
+			return r, err
+		}
+		r.AddMessage("updated " + r.unitName())
+	}
+
+	return r, nil
+}
+
+func (r *Route) desired() Live {
+	return Live{Gateway: r.Gateway, Device: r.Device, Metric: r.Metric}
+}
+
+// matches is true if the live route has the declared gateway, device, and
+// metric. Fields that aren't declared may have any value.
+func (r *Route) matches(live Live) bool {
+	return (r.Gateway == "" || live.Gateway == r.Gateway) &&
+		(r.Device == "" || live.Device == r.Device) &&
+		live.Metric == r.Metric
+}
+
+func (r *Route) family() []string {
+	if r.v6 {
+		return []string{"-6"}
+	}
+	return []string{"-4"}
+}
+
+// replace returns the command line that adds the route
+func (r *Route) replace() []string {
+	argv := append(append([]string{"ip"}, r.family()...), "route", "replace", r.Destination)
+	if r.Gateway != "" {
+		argv = append(argv, "via", r.Gateway)
+	}
+	if r.Device != "" {
+		argv = append(argv, "dev", r.Device)
+	}
+	if r.Metric != 0 {
+		argv = append(argv, "metric", strconv.Itoa(r.Metric))
+	}
+	return append(argv, "table", r.Table)
+}
+
+func (r *Route) live() (Live, bool, error) {
+	args := append(r.family(), "route", "show", "table", r.Table, "to", "exact", r.Destination)
+	out, err := exec.Read(r.exec, "ip", args...)
+	if err != nil {
+		return Live{}, false, errors.Wrap(err, "cannot read routing table")
+	}
+
+	live, exists := ParseLive(out)
+	return live, exists, nil
+}
+
+// unit returns the current and desired content of the boot unit, which is
+// empty when the unit should not exist
+func (r *Route) unit() (string, string, error) {
+	current, err := network.ReadUnit(r.exec, r.unitName())
+	if err != nil {
+		return "", "", err
+	}
+
+	if r.State == StateAbsent || !r.Persistent {
+		return current, "", nil
+	}
+
+	argv := r.replace()
+	argv[0] = network.IP
+	desired, err := network.BootUnit("converge route to "+r.Destination, nil, [][]string{argv})
+	return current, desired, err
+}
+
+func (r *Route) unitName() string {
+	return fmt.Sprintf("converge-route-%s-%s.service", strings.Replace(r.Destination, "/", "_", -1), r.Table)
+}
@@ -0,0 +1,142 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package route_test
+
+import (
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/helpers/fakeexec"
+	"github.com/asteris-llc/converge/helpers/fakerenderer"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/network/route"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const unit = "/etc/systemd/system/converge-route-10.1.0.0_16-main.service"
+
+// TestRouteInterface tests that Route is properly implemented
+func TestRouteInterface(t *testing.T) {
+	t.Parallel()
+
+	assert.Implements(t, (*resource.Task)(nil), new(route.Route))
+	assert.Implements(t, (*resource.Resource)(nil), new(route.Preparer))
+}
+
+// TestParseLive tests parsing ip route show
+func TestParseLive(t *testing.T) {
+	t.Parallel()
+
+	live, ok := route.ParseLive("10.1.0.0/16 via 10.0.0.254 dev eth0 proto static metric 100 \n")
+	assert.True(t, ok)
+	assert.Equal(t, route.Live{Gateway: "10.0.0.254", Device: "eth0", Metric: 100}, live)
+	assert.Equal(t, "via 10.0.0.254 dev eth0 metric 100", live.String())
+
+	_, ok = route.ParseLive("")
+	assert.False(t, ok)
+}
+
+// TestCheck tests comparing against the live table
+func TestCheck(t *testing.T) {
+	t.Parallel()
+
+	t.Run("missing", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("ip", "-4", "route", "show", "table", "main", "to", "exact", "10.1.0.0/16")
+		fake.Expect("test", "-e", unit).Return("", 1)
+
+		status, err := prepare(t, fake, &route.Preparer{Destination: "10.1.0.0/16", Gateway: "10.0.0.254"}).Check(fakerenderer.New())
+
+		require.NoError(t, err)
+		assert.True(t, status.HasChanges())
+		assert.Equal(t, "<absent>", status.Diffs()["10.1.0.0/16 table main"].Original())
+		assert.Equal(t, "via 10.0.0.254", status.Diffs()["10.1.0.0/16 table main"].Current())
+		assert.Equal(t, "<file-missing>", status.Diffs()[unit].Original())
+	})
+
+	t.Run("in sync", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("ip", "-4", "route", "show", "table", "main", "to", "exact", "10.1.0.0/16").Return("10.1.0.0/16 via 10.0.0.254 dev eth0\n", 0)
+		fake.Expect("test", "-e", unit).Return("", 1)
+
+		status, err := prepare(t, fake, &route.Preparer{Destination: "10.1.0.0/16", Gateway: "10.0.0.254", Persistent: new(bool)}).Check(fakerenderer.New())
+
+		require.NoError(t, err)
+		assert.False(t, status.HasChanges())
+	})
+}
+
+// TestApply tests changing the live table
+func TestApply(t *testing.T) {
+	t.Parallel()
+
+	t.Run("metric", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("ip", "-4", "route", "show", "table", "main", "to", "exact", "10.1.0.0/16").Return("10.1.0.0/16 via 10.0.0.254 dev eth0 metric 100\n", 0)
+		fake.Expect("ip", "-4", "route", "del", "10.1.0.0/16", "table", "main", "metric", "100")
+		fake.Expect("ip", "-4", "route", "replace", "10.1.0.0/16", "via", "10.0.0.254", "metric", "10", "table", "main")
+		fake.Expect("test", "-e", unit).Return("", 1)
+		fake.Expect("sh", "-c", exec.WriteFileScript, unit, "0644")
+		fake.Expect("systemctl", "daemon-reload")
+		fake.Expect("systemctl", "enable", "converge-route-10.1.0.0_16-main.service")
+
+		status, err := prepare(t, fake, &route.Preparer{Destination: "10.1.0.0/16", Gateway: "10.0.0.254", Metric: 10}).Apply()
+
+		require.NoError(t, err)
+		assert.Equal(t, resource.StatusNoChange, status.StatusCode())
+		fake.AssertExpectations(t)
+		assert.Contains(t, fake.Calls()[4].Stdin, "ExecStart=/sbin/ip -4 route replace 10.1.0.0/16 via 10.0.0.254 metric 10 table main\n")
+	})
+
+	t.Run("absent", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("ip", "-4", "route", "show", "table", "main", "to", "exact", "10.1.0.0/16").Return("10.1.0.0/16 via 10.0.0.254 dev eth0\n", 0)
+		fake.Expect("ip", "-4", "route", "del", "10.1.0.0/16", "table", "main", "metric", "0")
+		fake.Expect("test", "-e", unit)
+		fake.Expect("cat", unit).Return("[Unit]\n", 0)
+		fake.Expect("systemctl", "disable", "converge-route-10.1.0.0_16-main.service")
+		fake.Expect("rm", "-f", unit)
+		fake.Expect("systemctl", "daemon-reload")
+
+		_, err := prepare(t, fake, &route.Preparer{Destination: "10.1.0.0/16", State: route.StateAbsent}).Apply()
+
+		require.NoError(t, err)
+		fake.AssertExpectations(t)
+	})
+}
+
+// TestPrepare tests the invalid cases of Prepare
+func TestPrepare(t *testing.T) {
+	t.Parallel()
+
+	fr := fakerenderer.New()
+
+	_, err := (&route.Preparer{Destination: "10.1.0.0"}).Prepare(fr)
+	assert.EqualError(t, err, `network.route: "10.1.0.0" is not a network`)
+
+	_, err = (&route.Preparer{Destination: "default"}).Prepare(fr)
+	assert.EqualError(t, err, `network.route requires "gateway" or "device"`)
+
+	task, err := (&route.Preparer{Destination: "default", Gateway: "2001:db8::1"}).Prepare(fr)
+	require.NoError(t, err)
+	assert.Equal(t, "main", task.(*route.Route).Table)
+}
+
+func prepare(t *testing.T, fake *fakeexec.Executor, p *route.Preparer) *route.Route {
+	task, err := p.Prepare(&resource.ExecRenderer{Renderer: fakerenderer.New(), Exec: fake})
+	require.NoError(t, err)
+	return task.(*route.Route)
+}
@@ -0,0 +1,119 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rule
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/load/registry"
+	"github.com/asteris-llc/converge/resource"
+)
+
+// Preparer for Rule
+//
+// Rule manages a policy routing rule with ip(8), identified by its priority.
+// The live rules at that priority are compared to the declared one, and any
+// others at the same priority are replaced. Unless persistent is false, a
+// oneshot systemd unit is also installed to add the rule again at boot.
+type Preparer struct {
+	// Priority identifies the rule, lower priorities being matched first
+	Priority int `hcl:"priority" required:"true" min:"1" max:"32765"`
+
+	// From matches the source network. It defaults to all.
+	From string `hcl:"from"`
+
+	// To matches the destination network
+	To string `hcl:"to"`
+
+	// FwMark matches packets marked by the firewall. It must be written in hex
+	// as ip(8) reports it, such as "0x1".
+	FwMark string `hcl:"fwmark"`
+
+	// Iif matches packets arriving on an interface
+	Iif string `hcl:"iif"`
+
+	// Table is the routing table to look up. It must be written as ip(8)
+	// reports it, so use the name of a table named in
+	// /etc/iproute2/rt_tables.
+	Table string `hcl:"table" required:"true"`
+
+	// Persistent controls whether the rule is added again at boot. It defaults
+	// to true.
+	Persistent *bool `hcl:"persistent"`
+
+	// State is whether the rule should be present or absent. It defaults to
+	// present.
+	State State `hcl:"state" valid_values:"present,absent"`
+}
+
+// Prepare a new task
+func (p *Preparer) Prepare(render resource.Renderer) (resource.Task, error) {
+	if p.From == "" {
+		p.From = "all"
+	}
+
+	v6 := false
+	for _, value := range []string{p.From, p.To} {
+		if value == "" || value == "all" {
+			continue
+		}
+		ip, _, err := net.ParseCIDR(value)
+		if err != nil {
+			ip = net.ParseIP(value)
+		}
+		if ip == nil {
+			return nil, fmt.Errorf("network.rule: %q is not a network", value)
+		}
+		v6 = ip.To4() == nil
+	}
+
+	for _, value := range []string{p.FwMark, p.Iif, p.Table} {
+		if strings.ContainsAny(value, " \t\n/") {
+			return nil, fmt.Errorf("network.rule: %q is not a valid fwmark, interface, or table", value)
+		}
+	}
+
+	if p.State == "" {
+		p.State = StatePresent
+	}
+
+	persistent := true
+	if p.Persistent != nil {
+		persistent = *p.Persistent
+	}
+
+	return &Rule{
+		Selector: Selector{
+			From:   p.From,
+			To:     p.To,
+			FwMark: p.FwMark,
+			Iif:    p.Iif,
+			Table:  p.Table,
+		},
+		Priority:   p.Priority,
+		Persistent: persistent,
+		State:      p.State,
+		v6:         v6,
+		exec:       exec.For(render),
+	}, nil
+}
+
+func init() {
+	registry.Register("network.rule", (*Preparer)(nil), (*Rule)(nil))
+	registry.RegisterPlatforms("network.rule", "linux")
+}
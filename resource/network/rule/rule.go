@@ -0,0 +1,252 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/network"
+	"github.com/pkg/errors"
+)
+
+// State type for Rule
+type State string
+
+const (
+	// StatePresent indicates the rule should be present
+	StatePresent State = "present"
+
+	// StateAbsent indicates the rule should be absent
+	StateAbsent State = "absent"
+)
+
+// Selector is what a rule matches and the table it looks up
+type Selector struct {
+	From   string
+	To     string
+	FwMark string
+	Iif    string
+	Table  string
+}
+
+// Args returns the selector as ip rule arguments
+func (s Selector) Args() []string {
+	args := []string{"from", s.From}
+	if s.To != "" {
+		args = append(args, "to", s.To)
+	}
+	if s.FwMark != "" {
+		args = append(args, "fwmark", s.FwMark)
+	}
+	if s.Iif != "" {
+		args = append(args, "iif", s.Iif)
+	}
+	return append(args, "lookup", s.Table)
+}
+
+func (s Selector) String() string {
+	return strings.Join(s.Args(), " ")
+}
+
+// ParseLive parses the output of ip rule list into a selector per rule
+func ParseLive(out string) []Selector {
+	var selectors []Selector
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		var s Selector
+		for i := 1; i+1 < len(fields); i++ {
+			switch fields[i] {
+			case "from":
+				s.From = fields[i+1]
+			case "to":
+				s.To = fields[i+1]
+			case "fwmark":
+				s.FwMark = fields[i+1]
+			case "iif":
+				s.Iif = fields[i+1]
+			case "lookup":
+				s.Table = fields[i+1]
+			default:
+				continue
+			}
+			i++
+		}
+		selectors = append(selectors, s)
+	}
+	return selectors
+}
+
+// Rule manages a policy routing rule
+type Rule struct {
+	resource.Status
+	Selector
+
+	Priority   int
+	Persistent bool
+	State      State
+
+	v6   bool
+	exec exec.Executor
+}
+
+// Check whether the rule is in the live rule list and the boot unit is
+// installed
+func (r *Rule) Check(resource.Renderer) (resource.TaskStatus, error) {
+	r.Status = resource.Status{}
+
+	live, err := r.live()
+	if err != nil {
+		r.RaiseLevel(resource.StatusFatal)
+		return r, err
+	}
+
+	desired := "<absent>"
+	if r.State == StatePresent {
+		desired = r.Selector.String()
+	}
+	if current := describe(live); current != desired {
+		r.RaiseLevel(resource.StatusWillChange)
+		r.AddDifference(fmt.Sprintf("priority %d", r.Priority), current, desired, "")
+	}
+
+	current, unit, err := r.unit()
+	if err != nil {
+		r.RaiseLevel(resource.StatusFatal)
+		return r, err
+	}
+	if current != unit {
+		r.RaiseLevel(resource.StatusWillChange)
+		r.AddDifference(network.UnitPath(r.unitName()), current, unit, "<file-missing>")
+	}
+
+	return r, nil
+}
+
+// Apply changes the live rule list and installs or removes the boot unit
+func (r *Rule) Apply() (resource.TaskStatus, error) {
+	r.Status = resource.Status{}
+
+	live, err := r.live()
+	if err != nil {
+		r.RaiseLevel(resource.StatusFatal)
+		return r, err
+	}
+
+	inSync := r.State == StatePresent && len(live) == 1 && live[0] == r.Selector
+	if !inSync {
+		for range live {
+			if err := exec.Run(r.exec, "ip", r.del()...); err != nil {
+				r.RaiseLevel(resource.StatusFatal)
+				return r, errors.Wrapf(err, "cannot delete rule %d", r.Priority)
+			}
+		}
+
+		if r.State == StatePresent {
+			if err := exec.Run(r.exec, "ip", r.add()...); err != nil {
+				r.RaiseLevel(resource.StatusFatal)
+				return r, errors.Wrapf(err, "cannot add rule %d", r.Priority)
+			}
+		}
+		r.AddMessage(fmt.Sprintf("updated rule %d", r.Priority))
+	}
+
+	current, unit, err := r.unit()
+	if err != nil {
+		r.RaiseLevel(resource.StatusFatal)
+		return r, err
+	}
+	if current != unit {
+		if unit == "" {
+			err = network.RemoveUnit(r.exec, r.unitName())
+		} else {
+			err = network.InstallUnit(r.exec, r.unitName(), unit)
+		}
+		if err != nil {
+			r.RaiseLevel(resource.StatusFatal)
+			return r, err
+		}
+		r.AddMessage("updated " + r.unitName())
+	}
+
+	return r, nil
+}
+
+func describe(live []Selector) string {
+	if len(live) == 0 {
+		return "<absent>"
+	}
+
+	var out []string
+	for _, s := range live {
+		out = append(out, s.String())
+	}
+	return strings.Join(out, ", ")
+}
+
+func (r *Rule) family() string {
+	if r.v6 {
+		return "-6"
+	}
+	return "-4"
+}
+
+func (r *Rule) add() []string {
+	args := []string{r.family(), "rule", "add", "pref", strconv.Itoa(r.Priority)}
+	return append(args, r.Selector.Args()...)
+}
+
+func (r *Rule) del() []string {
+	return []string{r.family(), "rule", "del", "pref", strconv.Itoa(r.Priority)}
+}
+
+func (r *Rule) live() ([]Selector, error) {
+	out, err := exec.Read(r.exec, "ip", r.family(), "rule", "list", "pref", strconv.Itoa(r.Priority))
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot read routing rules")
+	}
+	return ParseLive(out), nil
+}
+
+// unit returns the current and desired content of the boot unit, which is
+// empty when the unit should not exist
+func (r *Rule) unit() (string, string, error) {
+	current, err := network.ReadUnit(r.exec, r.unitName())
+	if err != nil {
+		return "", "", err
+	}
+
+	if r.State == StateAbsent || !r.Persistent {
+		return current, "", nil
+	}
+
+	desired, err := network.BootUnit(
+		fmt.Sprintf("converge rule %d", r.Priority),
+		[][]string{append([]string{network.IP}, r.del()...)},
+		[][]string{append([]string{network.IP}, r.add()...)},
+	)
+	return current, desired, err
+}
+
+func (r *Rule) unitName() string {
+	return fmt.Sprintf("converge-rule%s-%d.service", strings.TrimPrefix(r.family(), "-"), r.Priority)
+}
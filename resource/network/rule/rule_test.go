@@ -0,0 +1,124 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rule_test
+
+import (
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/helpers/fakeexec"
+	"github.com/asteris-llc/converge/helpers/fakerenderer"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/network/rule"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const unit = "/etc/systemd/system/converge-rule4-100.service"
+
+// TestRuleInterface tests that Rule is properly implemented
+func TestRuleInterface(t *testing.T) {
+	t.Parallel()
+
+	assert.Implements(t, (*resource.Task)(nil), new(rule.Rule))
+	assert.Implements(t, (*resource.Resource)(nil), new(rule.Preparer))
+}
+
+// TestParseLive tests parsing ip rule list
+func TestParseLive(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(
+		t,
+		[]rule.Selector{
+			{From: "10.0.0.0/24", Table: "web"},
+			{From: "all", FwMark: "0x1", Table: "vpn"},
+		},
+		rule.ParseLive("100:\tfrom 10.0.0.0/24 lookup web \n100:\tfrom all fwmark 0x1 lookup vpn \n"),
+	)
+}
+
+// TestCheck tests comparing against the live rules
+func TestCheck(t *testing.T) {
+	t.Parallel()
+
+	t.Run("different", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("ip", "-4", "rule", "list", "pref", "100").Return("100:\tfrom all lookup main\n", 0)
+		fake.Expect("test", "-e", unit).Return("", 1)
+
+		status, err := prepare(t, fake, &rule.Preparer{Priority: 100, From: "10.0.0.0/24", Table: "web", Persistent: new(bool)}).Check(fakerenderer.New())
+
+		require.NoError(t, err)
+		assert.True(t, status.HasChanges())
+		assert.Equal(t, "from all lookup main", status.Diffs()["priority 100"].Original())
+		assert.Equal(t, "from 10.0.0.0/24 lookup web", status.Diffs()["priority 100"].Current())
+	})
+
+	t.Run("in sync", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("ip", "-4", "rule", "list", "pref", "100").Return("100:\tfrom 10.0.0.0/24 lookup web\n", 0)
+		fake.Expect("test", "-e", unit).Return("", 1)
+
+		status, err := prepare(t, fake, &rule.Preparer{Priority: 100, From: "10.0.0.0/24", Table: "web", Persistent: new(bool)}).Check(fakerenderer.New())
+
+		require.NoError(t, err)
+		assert.False(t, status.HasChanges())
+	})
+}
+
+// TestApply tests replacing the rules at a priority
+func TestApply(t *testing.T) {
+	t.Parallel()
+
+	fake := fakeexec.New()
+	fake.Expect("ip", "-4", "rule", "list", "pref", "100").Return("100:\tfrom all lookup main\n100:\tfrom all lookup web\n", 0)
+	fake.Expect("ip", "-4", "rule", "del", "pref", "100").Times(2)
+	fake.Expect("ip", "-4", "rule", "add", "pref", "100", "from", "10.0.0.0/24", "lookup", "web")
+	fake.Expect("test", "-e", unit).Return("", 1)
+	fake.Expect("sh", "-c", exec.WriteFileScript, unit, "0644")
+	fake.Expect("systemctl", "daemon-reload")
+	fake.Expect("systemctl", "enable", "converge-rule4-100.service")
+
+	_, err := prepare(t, fake, &rule.Preparer{Priority: 100, From: "10.0.0.0/24", Table: "web"}).Apply()
+
+	require.NoError(t, err)
+	fake.AssertExpectations(t)
+	assert.Len(t, fake.Calls(), 8)
+	assert.Contains(t, fake.Calls()[5].Stdin, "ExecStartPre=-/sbin/ip -4 rule del pref 100\n")
+}
+
+// TestPrepare tests the invalid cases of Prepare
+func TestPrepare(t *testing.T) {
+	t.Parallel()
+
+	fr := fakerenderer.New()
+
+	_, err := resource.NewPreparerWithSource(new(rule.Preparer), map[string]interface{}{"priority": 40000, "table": "web"}).Prepare(fr)
+	assert.EqualError(t, err, `"priority" must be between 1 and 32765, was 40000`)
+
+	_, err = (&rule.Preparer{Priority: 100, From: "office", Table: "web"}).Prepare(fr)
+	assert.EqualError(t, err, `network.rule: "office" is not a network`)
+
+	task, err := (&rule.Preparer{Priority: 100, To: "2001:db8::/32", Table: "web"}).Prepare(fr)
+	require.NoError(t, err)
+	assert.Equal(t, "all", task.(*rule.Rule).From)
+}
+
+func prepare(t *testing.T, fake *fakeexec.Executor, p *rule.Preparer) *rule.Rule {
+	task, err := p.Prepare(&resource.ExecRenderer{Renderer: fakerenderer.New(), Exec: fake})
+	require.NoError(t, err)
+	return task.(*rule.Rule)
+}
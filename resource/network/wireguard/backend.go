@@ -0,0 +1,368 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wireguard
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/pkg/errors"
+)
+
+// Backend configures a WireGuard interface
+type Backend interface {
+	// Key names the difference reported when the configuration changes
+	Key(*WireGuard) string
+
+	// Read returns the current configuration, in the same form as Render, or
+	// an empty string if the interface isn't configured
+	Read(*WireGuard) (string, error)
+
+	// Render returns the desired configuration
+	Render(*WireGuard) string
+
+	// Install applies the desired configuration, including the private key
+	Install(*WireGuard) error
+
+	// Remove removes the interface
+	Remove(*WireGuard) error
+}
+
+// Backends are the names accepted by NewBackend
+var Backends = []string{"config", "netlink"}
+
+// NewBackend returns the named backend, which runs commands with e
+func NewBackend(e exec.Executor, name string) (Backend, error) {
+	switch name {
+	case "config":
+		return &Config{Executor: e}, nil
+	case "netlink":
+		return &Netlink{Executor: e}, nil
+	}
+
+	return nil, fmt.Errorf("%q is not a valid backend, expected one of %s", name, strings.Join(Backends, ", "))
+}
+
+// Config writes a wg-quick configuration file and manages the wg-quick
+// service for it. The private and preshared keys are loaded by PostUp
+// commands so the file only refers to them. The service is restarted when the
+// configuration changes.
+type Config struct {
+	Executor exec.Executor
+}
+
+// Path returns the configuration file for the interface
+func (c *Config) Path(w *WireGuard) string {
+	return path.Join(ConfigDir, w.Name+".conf")
+}
+
+// Key is the configuration file
+func (c *Config) Key(w *WireGuard) string {
+	return c.Path(w)
+}
+
+// Read the configuration file
+func (c *Config) Read(w *WireGuard) (string, error) {
+	content, _, err := exec.ReadFile(c.Executor, c.Path(w))
+	if err != nil {
+		return "", errors.Wrapf(err, "cannot read %s", c.Path(w))
+	}
+	return content, nil
+}
+
+// Render the configuration file
+func (c *Config) Render(w *WireGuard) string {
+	var b bytes.Buffer
+	b.WriteString("[Interface]\n")
+	for _, addr := range w.Addresses {
+		fmt.Fprintf(&b, "Address = %s\n", addr)
+	}
+	if w.ListenPort != 0 {
+		fmt.Fprintf(&b, "ListenPort = %d\n", w.ListenPort)
+	}
+	fmt.Fprintf(&b, "PostUp = wg set %%i private-key %s\n", w.PrivateKeyFile)
+	for _, peer := range w.Peers {
+		if peer.PresharedKeyFile != "" {
+			fmt.Fprintf(&b, "PostUp = wg set %%i peer %s preshared-key %s\n", peer.PublicKey, peer.PresharedKeyFile)
+		}
+	}
+
+	for _, peer := range w.Peers {
+		fmt.Fprintf(&b, "\n[Peer]\nPublicKey = %s\n", peer.PublicKey)
+		if len(peer.AllowedIPs) > 0 {
+			fmt.Fprintf(&b, "AllowedIPs = %s\n", strings.Join(peer.AllowedIPs, ", "))
+		}
+		if peer.Endpoint != "" {
+			fmt.Fprintf(&b, "Endpoint = %s\n", peer.Endpoint)
+		}
+		if peer.PersistentKeepalive != 0 {
+			fmt.Fprintf(&b, "PersistentKeepalive = %d\n", peer.PersistentKeepalive)
+		}
+	}
+	return b.String()
+}
+
+// Install writes the configuration file and restarts the service
+func (c *Config) Install(w *WireGuard) error {
+	if err := exec.WriteFile(c.Executor, c.Path(w), c.Render(w), 0600); err != nil {
+		return errors.Wrapf(err, "cannot write %s", c.Path(w))
+	}
+	if err := exec.Run(c.Executor, "systemctl", "enable", service(w)); err != nil {
+		return errors.Wrapf(err, "cannot enable %s", service(w))
+	}
+	if err := exec.Run(c.Executor, "systemctl", "restart", service(w)); err != nil {
+		return errors.Wrapf(err, "cannot restart %s", service(w))
+	}
+	return nil
+}
+
+// Remove stops the service and removes the configuration file
+func (c *Config) Remove(w *WireGuard) error {
+	if err := exec.Run(c.Executor, "systemctl", "disable", "--now", service(w)); err != nil {
+		return errors.Wrapf(err, "cannot stop %s", service(w))
+	}
+	return exec.Run(c.Executor, "rm", "-f", c.Path(w))
+}
+
+func service(w *WireGuard) string {
+	return "wg-quick@" + w.Name + ".service"
+}
+
+// Netlink configures the running interface with ip(8) and wg(8), which talk
+// to the kernel over netlink. Nothing is persisted, so it suits interfaces
+// that converge reconfigures on every boot. Endpoints are compared as the
+// kernel reports them, so give them as addresses rather than host names.
+type Netlink struct {
+	Executor exec.Executor
+}
+
+// Key is the interface
+func (n *Netlink) Key(w *WireGuard) string {
+	return "interface " + w.Name
+}
+
+// Read the live state of the interface
+func (n *Netlink) Read(w *WireGuard) (string, error) {
+	if err := exec.Run(n.Executor, "ip", "link", "show", "dev", w.Name); err != nil {
+		if _, ok := exec.ExitStatus(err); ok {
+			return "", nil
+		}
+		return "", err
+	}
+
+	live := &WireGuard{Name: w.Name}
+
+	addrs, err := n.addresses(w)
+	if err != nil {
+		return "", err
+	}
+	live.Addresses = addrs
+
+	if w.ListenPort != 0 {
+		out, err := n.show(w, "listen-port")
+		if err != nil {
+			return "", err
+		}
+		live.ListenPort, _ = strconv.Atoi(strings.TrimSpace(out))
+	}
+
+	peers := map[string]*Peer{}
+	var keys []string
+	for _, field := range []string{"allowed-ips", "endpoints", "persistent-keepalive"} {
+		out, err := n.show(w, field)
+		if err != nil {
+			return "", err
+		}
+
+		for _, line := range strings.Split(out, "\n") {
+			fields := strings.Fields(line)
+			if len(fields) < 2 {
+				continue
+			}
+
+			peer, ok := peers[fields[0]]
+			if !ok {
+				peer = &Peer{PublicKey: fields[0]}
+				peers[fields[0]] = peer
+				keys = append(keys, fields[0])
+			}
+
+			values := fields[1:]
+			if values[0] == "(none)" || values[0] == "off" {
+				continue
+			}
+			switch field {
+			case "allowed-ips":
+				peer.AllowedIPs = values
+				sort.Strings(peer.AllowedIPs)
+			case "endpoints":
+				peer.Endpoint = values[0]
+			case "persistent-keepalive":
+				peer.PersistentKeepalive, _ = strconv.Atoi(values[0])
+			}
+		}
+	}
+
+	sort.Strings(keys)
+	for _, key := range keys {
+		peer := *peers[key]
+		if want, ok := find(w.Peers, key); ok && want.Endpoint == "" {
+			// a peer that connected to us has an endpoint we didn't set
+			peer.Endpoint = ""
+		}
+		live.Peers = append(live.Peers, peer)
+	}
+
+	return n.Render(live), nil
+}
+
+// Render the state of the interface
+func (n *Netlink) Render(w *WireGuard) string {
+	var b bytes.Buffer
+	for _, addr := range w.Addresses {
+		fmt.Fprintf(&b, "address %s\n", addr)
+	}
+	if w.ListenPort != 0 {
+		fmt.Fprintf(&b, "listen-port %d\n", w.ListenPort)
+	}
+	for _, peer := range w.Peers {
+		fmt.Fprintf(&b, "peer %s\n", peer.PublicKey)
+		if len(peer.AllowedIPs) > 0 {
+			fmt.Fprintf(&b, "  allowed-ips %s\n", strings.Join(peer.AllowedIPs, ","))
+		}
+		if peer.Endpoint != "" {
+			fmt.Fprintf(&b, "  endpoint %s\n", peer.Endpoint)
+		}
+		if peer.PersistentKeepalive != 0 {
+			fmt.Fprintf(&b, "  persistent-keepalive %d\n", peer.PersistentKeepalive)
+		}
+	}
+	if b.Len() == 0 {
+		// the interface exists, so distinguish it from a missing one
+		return "<empty>\n"
+	}
+	return b.String()
+}
+
+// Install creates the interface if needed and configures it
+func (n *Netlink) Install(w *WireGuard) error {
+	if err := exec.Run(n.Executor, "ip", "link", "show", "dev", w.Name); err != nil {
+		if _, ok := exec.ExitStatus(err); !ok {
+			return err
+		}
+		if err := exec.Run(n.Executor, "ip", "link", "add", "dev", w.Name, "type", "wireguard"); err != nil {
+			return errors.Wrapf(err, "cannot create %s", w.Name)
+		}
+	}
+
+	args := []string{"set", w.Name, "private-key", w.PrivateKeyFile}
+	if w.ListenPort != 0 {
+		args = append(args, "listen-port", strconv.Itoa(w.ListenPort))
+	}
+	for _, peer := range w.Peers {
+		args = append(args, "peer", peer.PublicKey, "allowed-ips", strings.Join(peer.AllowedIPs, ","))
+		if peer.Endpoint != "" {
+			args = append(args, "endpoint", peer.Endpoint)
+		}
+		args = append(args, "persistent-keepalive", strconv.Itoa(peer.PersistentKeepalive))
+		if peer.PresharedKeyFile != "" {
+			args = append(args, "preshared-key", peer.PresharedKeyFile)
+		}
+	}
+
+	out, err := n.show(w, "peers")
+	if err != nil {
+		return err
+	}
+	for _, key := range strings.Fields(out) {
+		if _, ok := find(w.Peers, key); !ok {
+			args = append(args, "peer", key, "remove")
+		}
+	}
+
+	if err := exec.Run(n.Executor, "wg", args...); err != nil {
+		return errors.Wrapf(err, "cannot configure %s", w.Name)
+	}
+
+	addrs, err := n.addresses(w)
+	if err != nil {
+		return err
+	}
+	for _, addr := range addrs {
+		if !contains(w.Addresses, addr) {
+			if err := exec.Run(n.Executor, "ip", "address", "del", addr, "dev", w.Name); err != nil {
+				return errors.Wrapf(err, "cannot remove %s from %s", addr, w.Name)
+			}
+		}
+	}
+	for _, addr := range w.Addresses {
+		if !contains(addrs, addr) {
+			if err := exec.Run(n.Executor, "ip", "address", "add", addr, "dev", w.Name); err != nil {
+				return errors.Wrapf(err, "cannot add %s to %s", addr, w.Name)
+			}
+		}
+	}
+
+	return exec.Run(n.Executor, "ip", "link", "set", "up", "dev", w.Name)
+}
+
+// Remove deletes the interface
+func (n *Netlink) Remove(w *WireGuard) error {
+	return exec.Run(n.Executor, "ip", "link", "del", "dev", w.Name)
+}
+
+func (n *Netlink) show(w *WireGuard, field string) (string, error) {
+	out, err := exec.Read(n.Executor, "wg", "show", w.Name, field)
+	if err != nil {
+		return "", errors.Wrapf(err, "cannot read %s of %s", field, w.Name)
+	}
+	return out, nil
+}
+
+func (n *Netlink) addresses(w *WireGuard) ([]string, error) {
+	out, err := exec.Read(n.Executor, "ip", "-br", "address", "show", "dev", w.Name)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot read addresses of %s", w.Name)
+	}
+
+	fields := strings.Fields(out)
+	if len(fields) < 2 {
+		return nil, nil
+	}
+	return fields[2:], nil
+}
+
+func find(peers []Peer, key string) (Peer, bool) {
+	for _, peer := range peers {
+		if peer.PublicKey == key {
+			return peer, true
+		}
+	}
+	return Peer{}, false
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
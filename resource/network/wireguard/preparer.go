@@ -0,0 +1,175 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wireguard
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/load/registry"
+	"github.com/asteris-llc/converge/resource"
+)
+
+// Preparer for WireGuard
+//
+// WireGuard manages a WireGuard interface and its peers. The private key is
+// generated on the target into a key file and never passes through converge;
+// the configuration refers to the file instead. If rotate is set, the key is
+// replaced once it is older than the given duration. The public key of the
+// interface is available to other resources as PublicKey.
+type Preparer struct {
+	// Name is the interface, such as wg0
+	Name string `hcl:"name" required:"true"`
+
+	// PrivateKeyFile holds the private key. It is generated if it doesn't
+	// exist, and defaults to /etc/wireguard/NAME.key.
+	PrivateKeyFile string `hcl:"private_key_file"`
+
+	// Rotate is how old the private key may get before it is replaced. Peers
+	// must be given the new public key when this happens. Keys are not rotated
+	// by default.
+	Rotate string `hcl:"rotate" doc_type:"duration_string"`
+
+	// Addresses are assigned to the interface, with prefix lengths
+	Addresses []string `hcl:"addresses"`
+
+	// ListenPort is the UDP port to listen on. If unset, a random port is used.
+	ListenPort int `hcl:"listen_port" min:"1" max:"65535"`
+
+	// Peers maps the public keys of peers to their settings: "allowed_ips"
+	// (comma separated), "endpoint", "persistent_keepalive" (seconds), and
+	// "preshared_key_file".
+	Peers map[string]map[string]string `hcl:"peers"`
+
+	// Backend is how the interface is configured. "config" writes a wg-quick
+	// configuration and manages the wg-quick@NAME service, and "netlink"
+	// configures the running interface directly without persisting it. It
+	// defaults to config.
+	Backend string `hcl:"backend" valid_values:"config,netlink"`
+
+	// State is whether the interface should be present or absent. The key file
+	// is left in place when it is removed. It defaults to present.
+	State State `hcl:"state" valid_values:"present,absent"`
+}
+
+// Prepare a new task
+func (p *Preparer) Prepare(render resource.Renderer) (resource.Task, error) {
+	if p.Name == "" || len(p.Name) > 15 || strings.ContainsAny(p.Name, " \t\n/") {
+		return nil, fmt.Errorf("network.wireguard: %q is not a valid interface name", p.Name)
+	}
+
+	if p.PrivateKeyFile == "" {
+		p.PrivateKeyFile = path.Join(ConfigDir, p.Name+".key")
+	}
+
+	var rotate time.Duration
+	if p.Rotate != "" {
+		var err error
+		rotate, err = time.ParseDuration(p.Rotate)
+		if err != nil || rotate <= 0 {
+			return nil, fmt.Errorf("network.wireguard: %q is not a valid rotation period", p.Rotate)
+		}
+	}
+
+	for _, addr := range p.Addresses {
+		if _, _, err := net.ParseCIDR(addr); err != nil {
+			return nil, fmt.Errorf("network.wireguard: %q is not an address with a prefix length", addr)
+		}
+	}
+
+	var peers []Peer
+	for key, settings := range p.Peers {
+		peer, err := parsePeer(key, settings)
+		if err != nil {
+			return nil, err
+		}
+		peers = append(peers, peer)
+	}
+	sort.Sort(byPublicKey(peers))
+	sort.Strings(p.Addresses)
+
+	if p.Backend == "" {
+		p.Backend = "config"
+	}
+
+	if p.State == "" {
+		p.State = StatePresent
+	}
+
+	return &WireGuard{
+		Name:           p.Name,
+		PrivateKeyFile: p.PrivateKeyFile,
+		Rotate:         rotate,
+		Addresses:      p.Addresses,
+		ListenPort:     p.ListenPort,
+		Peers:          peers,
+		Backend:        p.Backend,
+		State:          p.State,
+		exec:           exec.For(render),
+	}, nil
+}
+
+func parsePeer(key string, settings map[string]string) (Peer, error) {
+	if raw, err := base64.StdEncoding.DecodeString(key); err != nil || len(raw) != 32 {
+		return Peer{}, fmt.Errorf("network.wireguard: %q is not a public key", key)
+	}
+
+	peer := Peer{PublicKey: key}
+	for name, value := range settings {
+		switch name {
+		case "allowed_ips":
+			for _, ip := range strings.Split(value, ",") {
+				ip = strings.TrimSpace(ip)
+				if _, _, err := net.ParseCIDR(ip); err != nil {
+					return Peer{}, fmt.Errorf("network.wireguard: %q is not a network", ip)
+				}
+				peer.AllowedIPs = append(peer.AllowedIPs, ip)
+			}
+			sort.Strings(peer.AllowedIPs)
+
+		case "endpoint":
+			if _, _, err := net.SplitHostPort(value); err != nil {
+				return Peer{}, fmt.Errorf("network.wireguard: %q is not a host and port", value)
+			}
+			peer.Endpoint = value
+
+		case "persistent_keepalive":
+			seconds, err := strconv.Atoi(value)
+			if err != nil || seconds < 0 {
+				return Peer{}, fmt.Errorf("network.wireguard: %q is not a number of seconds", value)
+			}
+			peer.PersistentKeepalive = seconds
+
+		case "preshared_key_file":
+			peer.PresharedKeyFile = value
+
+		default:
+			return Peer{}, fmt.Errorf("network.wireguard: unknown peer setting %q", name)
+		}
+	}
+	return peer, nil
+}
+
+func init() {
+	registry.Register("network.wireguard", (*Preparer)(nil), (*WireGuard)(nil))
+	registry.RegisterPlatforms("network.wireguard", "linux")
+}
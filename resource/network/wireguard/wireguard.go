@@ -0,0 +1,211 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wireguard
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/pkg/errors"
+)
+
+// ConfigDir is where wg-quick configuration and key files are kept
+const ConfigDir = "/etc/wireguard"
+
+// State type for WireGuard
+type State string
+
+const (
+	// StatePresent indicates the interface should be present
+	StatePresent State = "present"
+
+	// StateAbsent indicates the interface should be absent
+	StateAbsent State = "absent"
+)
+
+// Peer is a remote end of the tunnel
+type Peer struct {
+	PublicKey           string
+	AllowedIPs          []string
+	Endpoint            string
+	PersistentKeepalive int
+	PresharedKeyFile    string
+}
+
+type byPublicKey []Peer
+
+func (b byPublicKey) Len() int           { return len(b) }
+func (b byPublicKey) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+func (b byPublicKey) Less(i, j int) bool { return b[i].PublicKey < b[j].PublicKey }
+
+// WireGuard manages a WireGuard interface
+type WireGuard struct {
+	resource.Status
+
+	Name           string
+	PrivateKeyFile string
+	Rotate         time.Duration
+	Addresses      []string
+	ListenPort     int
+	Peers          []Peer
+	Backend        string
+	State          State
+
+	// PublicKey is derived from the private key file, once it exists
+	PublicKey string
+
+	exec exec.Executor
+}
+
+// Check the key file and the interface configuration
+func (w *WireGuard) Check(resource.Renderer) (resource.TaskStatus, error) {
+	w.Status = resource.Status{}
+
+	backend, err := NewBackend(w.exec, w.Backend)
+	if err != nil {
+		w.RaiseLevel(resource.StatusFatal)
+		return w, err
+	}
+
+	if w.State == StatePresent {
+		rekey, err := w.checkKey()
+		if err != nil {
+			w.RaiseLevel(resource.StatusFatal)
+			return w, err
+		}
+		if rekey != "" {
+			w.RaiseLevel(resource.StatusWillChange)
+			w.AddDifference(w.PrivateKeyFile, rekey, "<generated>", "")
+		}
+	}
+
+	current, err := backend.Read(w)
+	if err != nil {
+		w.RaiseLevel(resource.StatusFatal)
+		return w, err
+	}
+
+	desired := ""
+	if w.State == StatePresent {
+		desired = backend.Render(w)
+	}
+	if current != desired {
+		w.RaiseLevel(resource.StatusWillChange)
+		w.AddDifference(backend.Key(w), current, desired, "<absent>")
+	}
+
+	return w, nil
+}
+
+// Apply generates the key and configures the interface
+func (w *WireGuard) Apply() (resource.TaskStatus, error) {
+	w.Status = resource.Status{}
+
+	backend, err := NewBackend(w.exec, w.Backend)
+	if err != nil {
+		w.RaiseLevel(resource.StatusFatal)
+		return w, err
+	}
+
+	current, err := backend.Read(w)
+	if err != nil {
+		w.RaiseLevel(resource.StatusFatal)
+		return w, err
+	}
+
+	if w.State == StateAbsent {
+		if current != "" {
+			if err := backend.Remove(w); err != nil {
+				w.RaiseLevel(resource.StatusFatal)
+				return w, err
+			}
+			w.AddMessage("removed " + w.Name)
+		}
+		return w, nil
+	}
+
+	rekey, err := w.checkKey()
+	if err != nil {
+		w.RaiseLevel(resource.StatusFatal)
+		return w, err
+	}
+	if rekey != "" {
+		if err := exec.Run(w.exec, "sh", "-c", `umask 077 && wg genkey > "$0.new" && mv "$0.new" "$0"`, w.PrivateKeyFile); err != nil {
+			w.RaiseLevel(resource.StatusFatal)
+			return w, errors.Wrapf(err, "cannot generate %s", w.PrivateKeyFile)
+		}
+		if err := w.readPublicKey(); err != nil {
+			w.RaiseLevel(resource.StatusFatal)
+			return w, err
+		}
+		w.AddMessage("generated " + w.PrivateKeyFile + ", public key is " + w.PublicKey)
+	}
+
+	if rekey != "" || current != backend.Render(w) {
+		if err := backend.Install(w); err != nil {
+			w.RaiseLevel(resource.StatusFatal)
+			return w, err
+		}
+		w.AddMessage("configured " + w.Name)
+	}
+
+	return w, nil
+}
+
+// checkKey reads the public key, returning a description of the key file if
+// it needs to be generated
+func (w *WireGuard) checkKey() (string, error) {
+	if err := exec.Run(w.exec, "test", "-f", w.PrivateKeyFile); err != nil {
+		if _, ok := exec.ExitStatus(err); ok {
+			return "<absent>", nil
+		}
+		return "", err
+	}
+
+	if err := w.readPublicKey(); err != nil {
+		return "", err
+	}
+
+	if w.Rotate == 0 {
+		return "", nil
+	}
+
+	out, err := exec.Stat(w.exec, w.PrivateKeyFile, "%Y")
+	if err != nil {
+		return "", errors.Wrapf(err, "cannot stat %s", w.PrivateKeyFile)
+	}
+	mtime, err := strconv.ParseInt(strings.TrimSpace(out), 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("cannot parse modification time of %s: %q", w.PrivateKeyFile, out)
+	}
+
+	if age := time.Since(time.Unix(mtime, 0)); age > w.Rotate {
+		return fmt.Sprintf("<%s old>", age-age%time.Hour), nil
+	}
+	return "", nil
+}
+
+func (w *WireGuard) readPublicKey() error {
+	out, err := exec.Read(w.exec, "sh", "-c", `wg pubkey < "$0"`, w.PrivateKeyFile)
+	if err != nil {
+		return errors.Wrapf(err, "cannot read public key of %s", w.PrivateKeyFile)
+	}
+	w.PublicKey = strings.TrimSpace(out)
+	return nil
+}
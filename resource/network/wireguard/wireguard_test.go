@@ -0,0 +1,239 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wireguard_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/helpers/fakeexec"
+	"github.com/asteris-llc/converge/helpers/fakerenderer"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/network/wireguard"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	key     = "/etc/wireguard/wg0.key"
+	conf    = "/etc/wireguard/wg0.conf"
+	peer    = "xTIBA5rboUvnH4htodjb6e697QjLERt1NAB4mZqp8Dg="
+	pubkey  = "HIgo9xNzJMWLKASShiTqIybxZ0U3wGLiUeJ1PKf8ykw="
+	genkey  = `umask 077 && wg genkey > "$0.new" && mv "$0.new" "$0"`
+	readPub = `wg pubkey < "$0"`
+	write   = exec.WriteFileScript
+)
+
+var preparer = wireguard.Preparer{
+	Name:       "wg0",
+	Addresses:  []string{"10.9.0.1/24"},
+	ListenPort: 51820,
+	Peers: map[string]map[string]string{
+		peer: {
+			"allowed_ips":          "10.9.0.2/32, 10.8.0.0/16",
+			"endpoint":             "192.0.2.10:51820",
+			"persistent_keepalive": "25",
+		},
+	},
+}
+
+// TestWireGuardInterface tests that WireGuard is properly implemented
+func TestWireGuardInterface(t *testing.T) {
+	t.Parallel()
+
+	assert.Implements(t, (*resource.Task)(nil), new(wireguard.WireGuard))
+	assert.Implements(t, (*resource.Resource)(nil), new(wireguard.Preparer))
+}
+
+// TestRender tests rendering for each backend
+func TestRender(t *testing.T) {
+	t.Parallel()
+
+	w := prepare(t, fakeexec.New(), preparer)
+
+	t.Run("config", func(t *testing.T) {
+		assert.Equal(t, `[Interface]
+Address = 10.9.0.1/24
+ListenPort = 51820
+PostUp = wg set %i private-key /etc/wireguard/wg0.key
+
+[Peer]
+PublicKey = xTIBA5rboUvnH4htodjb6e697QjLERt1NAB4mZqp8Dg=
+AllowedIPs = 10.8.0.0/16, 10.9.0.2/32
+Endpoint = 192.0.2.10:51820
+PersistentKeepalive = 25
+`, new(wireguard.Config).Render(w))
+	})
+
+	t.Run("netlink", func(t *testing.T) {
+		assert.Equal(t, `address 10.9.0.1/24
+listen-port 51820
+peer xTIBA5rboUvnH4htodjb6e697QjLERt1NAB4mZqp8Dg=
+  allowed-ips 10.8.0.0/16,10.9.0.2/32
+  endpoint 192.0.2.10:51820
+  persistent-keepalive 25
+`, new(wireguard.Netlink).Render(w))
+	})
+}
+
+// TestCheck tests checking the key and configuration
+func TestCheck(t *testing.T) {
+	t.Parallel()
+
+	t.Run("new", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("test", "-f", key).Return("", 1)
+		fake.Expect("test", "-e", conf).Return("", 1)
+
+		status, err := prepare(t, fake, preparer).Check(fakerenderer.New())
+
+		require.NoError(t, err)
+		assert.True(t, status.HasChanges())
+		assert.Equal(t, "<absent>", status.Diffs()[key].Original())
+		assert.Equal(t, "<absent>", status.Diffs()[conf].Original())
+	})
+
+	t.Run("rotate", func(t *testing.T) {
+		p := preparer
+		p.Rotate = "720h"
+
+		fake := fakeexec.New()
+		fake.Expect("test", "-f", key)
+		fake.Expect("sh", "-c", readPub, key).Return(pubkey+"\n", 0)
+		fake.Expect("sh", "-c", exec.StatScript, key, "%Y", "%m").Return(fmt.Sprintf("%d\n", time.Now().Add(-800*time.Hour).Unix()), 0)
+		fake.Expect("test", "-e", conf).Return("", 1)
+
+		w := prepare(t, fake, p)
+		status, err := w.Check(fakerenderer.New())
+
+		require.NoError(t, err)
+		assert.Equal(t, "<800h0m0s old>", status.Diffs()[key].Original())
+		assert.Equal(t, pubkey, w.PublicKey)
+	})
+
+	t.Run("netlink", func(t *testing.T) {
+		p := preparer
+		p.Backend = "netlink"
+
+		fake := fakeexec.New()
+		fake.Expect("test", "-f", key)
+		fake.Expect("sh", "-c", readPub, key).Return(pubkey+"\n", 0)
+		fake.Expect("ip", "link", "show", "dev", "wg0")
+		fake.Expect("ip", "-br", "address", "show", "dev", "wg0").Return("wg0              UNKNOWN        10.9.0.1/24 \n", 0)
+		fake.Expect("wg", "show", "wg0", "listen-port").Return("51820\n", 0)
+		fake.Expect("wg", "show", "wg0", "allowed-ips").Return(peer+"\t10.9.0.2/32 10.8.0.0/16\n", 0)
+		fake.Expect("wg", "show", "wg0", "endpoints").Return(peer+"\t192.0.2.10:51820\n", 0)
+		fake.Expect("wg", "show", "wg0", "persistent-keepalive").Return(peer+"\t25\n", 0)
+
+		status, err := prepare(t, fake, p).Check(fakerenderer.New())
+
+		require.NoError(t, err)
+		assert.False(t, status.HasChanges())
+	})
+}
+
+// TestApply tests generating the key and installing the configuration
+func TestApply(t *testing.T) {
+	t.Parallel()
+
+	t.Run("config", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("test", "-e", conf).Return("", 1)
+		fake.Expect("test", "-f", key).Return("", 1)
+		fake.Expect("sh", "-c", genkey, key)
+		fake.Expect("sh", "-c", readPub, key).Return(pubkey+"\n", 0)
+		fake.Expect("sh", "-c", write, conf, "0600")
+		fake.Expect("systemctl", "enable", "wg-quick@wg0.service")
+		fake.Expect("systemctl", "restart", "wg-quick@wg0.service")
+
+		w := prepare(t, fake, preparer)
+		_, err := w.Apply()
+
+		require.NoError(t, err)
+		fake.AssertExpectations(t)
+		assert.Equal(t, pubkey, w.PublicKey)
+	})
+
+	t.Run("netlink", func(t *testing.T) {
+		p := preparer
+		p.Backend = "netlink"
+
+		stale := "HIgo9xNzJMWLKASShiTqIybxZ0U3wGLiUeJ1PKf8yk0="
+
+		fake := fakeexec.New()
+		fake.Expect("ip", "link", "show", "dev", "wg0").Return("", 1).Times(2)
+		fake.Expect("test", "-f", key)
+		fake.Expect("sh", "-c", readPub, key).Return(pubkey+"\n", 0)
+		fake.Expect("ip", "link", "add", "dev", "wg0", "type", "wireguard")
+		fake.Expect("wg", "show", "wg0", "peers").Return(stale+"\n", 0)
+		fake.Expect(
+			"wg", "set", "wg0", "private-key", key, "listen-port", "51820",
+			"peer", peer, "allowed-ips", "10.8.0.0/16,10.9.0.2/32", "endpoint", "192.0.2.10:51820", "persistent-keepalive", "25",
+			"peer", stale, "remove",
+		)
+		fake.Expect("ip", "-br", "address", "show", "dev", "wg0").Return("wg0 DOWN 10.7.0.1/24\n", 0)
+		fake.Expect("ip", "address", "del", "10.7.0.1/24", "dev", "wg0")
+		fake.Expect("ip", "address", "add", "10.9.0.1/24", "dev", "wg0")
+		fake.Expect("ip", "link", "set", "up", "dev", "wg0")
+
+		_, err := prepare(t, fake, p).Apply()
+
+		require.NoError(t, err)
+		fake.AssertExpectations(t)
+	})
+
+	t.Run("absent", func(t *testing.T) {
+		p := preparer
+		p.State = wireguard.StateAbsent
+
+		fake := fakeexec.New()
+		fake.Expect("test", "-e", conf)
+		fake.Expect("cat", conf).Return("[Interface]\n", 0)
+		fake.Expect("systemctl", "disable", "--now", "wg-quick@wg0.service")
+		fake.Expect("rm", "-f", conf)
+
+		_, err := prepare(t, fake, p).Apply()
+
+		require.NoError(t, err)
+		fake.AssertExpectations(t)
+	})
+}
+
+// TestPrepare tests the invalid cases of Prepare
+func TestPrepare(t *testing.T) {
+	t.Parallel()
+
+	fr := fakerenderer.New()
+
+	_, err := (&wireguard.Preparer{Name: "wireguard-tunnel0"}).Prepare(fr)
+	assert.EqualError(t, err, `network.wireguard: "wireguard-tunnel0" is not a valid interface name`)
+
+	_, err = (&wireguard.Preparer{Name: "wg0", Peers: map[string]map[string]string{"abc": {}}}).Prepare(fr)
+	assert.EqualError(t, err, `network.wireguard: "abc" is not a public key`)
+
+	_, err = (&wireguard.Preparer{Name: "wg0", Peers: map[string]map[string]string{peer: {"endpoint": "vpn.example.com"}}}).Prepare(fr)
+	assert.EqualError(t, err, `network.wireguard: "vpn.example.com" is not a host and port`)
+
+	_, err = (&wireguard.Preparer{Name: "wg0", Rotate: "monthly"}).Prepare(fr)
+	assert.EqualError(t, err, `network.wireguard: "monthly" is not a valid rotation period`)
+}
+
+func prepare(t *testing.T, fake *fakeexec.Executor, p wireguard.Preparer) *wireguard.WireGuard {
+	task, err := p.Prepare(&resource.ExecRenderer{Renderer: fakerenderer.New(), Exec: fake})
+	require.NoError(t, err)
+	return task.(*wireguard.WireGuard)
+}
@@ -0,0 +1,126 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package export
+
+import (
+	"fmt"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultFile is the location of the exports file
+	DefaultFile = "/etc/exports"
+
+	// Mode is the mode the exports file is written with
+	Mode = 0644
+)
+
+// State type for Export
+type State string
+
+const (
+	// StatePresent indicates the export should be present
+	StatePresent State = "present"
+
+	// StateAbsent indicates the export should be absent
+	StateAbsent State = "absent"
+)
+
+// Export manages an entry in the NFS exports file
+type Export struct {
+	resource.Status
+
+	Path    string
+	Clients map[string]string
+	State   State
+	File    string
+
+	exec exec.Executor
+}
+
+// Check whether the exports file has the declared entry
+func (e *Export) Check(resource.Renderer) (resource.TaskStatus, error) {
+	e.Status = resource.Status{}
+
+	content, err := e.read()
+	if err != nil {
+		e.RaiseLevel(resource.StatusFatal)
+		return e, err
+	}
+
+	current, exists := Find(content, e.Path)
+	switch {
+	case e.State == StateAbsent && exists:
+		e.RaiseLevel(resource.StatusWillChange)
+		e.AddDifference(e.Path, current.String(), "<absent>", "")
+
+	case e.State == StatePresent && !exists:
+		e.RaiseLevel(resource.StatusWillChange)
+		e.AddDifference(e.Path, "<absent>", e.entry().String(), "")
+
+	case e.State == StatePresent && !current.Equal(e.entry()):
+		e.RaiseLevel(resource.StatusWillChange)
+		e.AddDifference(e.Path, current.String(), e.entry().String(), "")
+	}
+
+	return e, nil
+}
+
+// Apply writes the entry and reloads the export table
+func (e *Export) Apply() (resource.TaskStatus, error) {
+	e.Status = resource.Status{}
+
+	content, err := e.read()
+	if err != nil {
+		e.RaiseLevel(resource.StatusFatal)
+		return e, err
+	}
+
+	var entry *Entry
+	if e.State == StatePresent {
+		desired := e.entry()
+		entry = &desired
+	}
+
+	if err := exec.WriteFile(e.exec, e.File, Set(content, e.Path, entry), Mode); err != nil {
+		e.RaiseLevel(resource.StatusFatal)
+		return e, errors.Wrapf(err, "cannot write %s", e.File)
+	}
+	e.AddMessage(fmt.Sprintf("updated %s", e.File))
+
+	if err := exec.Run(e.exec, "exportfs", "-ra"); err != nil {
+		e.RaiseLevel(resource.StatusFatal)
+		return e, errors.Wrap(err, "cannot reload exports")
+	}
+	e.AddMessage("reloaded exports")
+
+	return e, nil
+}
+
+func (e *Export) entry() Entry {
+	return Entry{Path: e.Path, Clients: e.Clients}
+}
+
+// read returns the content of the exports file, which may not exist yet
+func (e *Export) read() (string, error) {
+	content, _, err := exec.ReadFile(e.exec, e.File)
+	if err != nil {
+		return "", errors.Wrapf(err, "cannot read %s", e.File)
+	}
+	return content, nil
+}
@@ -0,0 +1,148 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package export_test
+
+import (
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/helpers/fakeexec"
+	"github.com/asteris-llc/converge/helpers/fakerenderer"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/nfs/export"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	exports = `# /etc/exports
+/srv/home 10.0.0.0/24(rw,sync) \
+	backup(ro)
+"/srv/media files" *(ro)
+`
+	write = exec.WriteFileScript
+)
+
+// TestExportInterface tests that Export is properly implemented
+func TestExportInterface(t *testing.T) {
+	t.Parallel()
+
+	assert.Implements(t, (*resource.Task)(nil), new(export.Export))
+	assert.Implements(t, (*resource.Resource)(nil), new(export.Preparer))
+}
+
+// TestFind tests parsing entries from an exports file
+func TestFind(t *testing.T) {
+	t.Parallel()
+
+	entry, ok := export.Find(exports, "/srv/home")
+	require.True(t, ok)
+	assert.Equal(t, map[string]string{"10.0.0.0/24": "rw,sync", "backup": "ro"}, entry.Clients)
+
+	entry, ok = export.Find(exports, "/srv/media files")
+	require.True(t, ok)
+	assert.Equal(t, `"/srv/media files" *(ro)`, entry.String())
+
+	_, ok = export.Find(exports, "/srv")
+	assert.False(t, ok)
+}
+
+// TestSet tests replacing, adding, and removing entries
+func TestSet(t *testing.T) {
+	t.Parallel()
+
+	entry := &export.Entry{Path: "/srv/home", Clients: map[string]string{"10.0.0.0/24": "rw"}}
+	assert.Equal(
+		t,
+		"# /etc/exports\n/srv/home 10.0.0.0/24(rw)\n\"/srv/media files\" *(ro)\n",
+		export.Set(exports, "/srv/home", entry),
+	)
+
+	assert.Equal(t, "# /etc/exports\n/srv/home 10.0.0.0/24(rw,sync) \\\n\tbackup(ro)\n", export.Set(exports, "/srv/media files", nil))
+	assert.Equal(t, "/srv/home 10.0.0.0/24(rw)\n", export.Set("", "/srv/home", entry))
+}
+
+// TestCheck tests the possible cases Check handles
+func TestCheck(t *testing.T) {
+	t.Parallel()
+
+	t.Run("changed", func(t *testing.T) {
+		e := prepare(t, reading(exports), &export.Preparer{Clients: map[string]string{"10.0.0.0/24": "rw,sync"}})
+		status, err := e.Check(fakerenderer.New())
+
+		require.NoError(t, err)
+		assert.True(t, status.HasChanges())
+		assert.Equal(t, "/srv/home 10.0.0.0/24(rw,sync)", status.Diffs()["/srv/home"].Current())
+	})
+
+	t.Run("converged", func(t *testing.T) {
+		e := prepare(t, reading(exports), &export.Preparer{Clients: map[string]string{"10.0.0.0/24": "rw,sync", "backup": "ro"}})
+		status, err := e.Check(fakerenderer.New())
+
+		require.NoError(t, err)
+		assert.False(t, status.HasChanges())
+	})
+
+	t.Run("absent", func(t *testing.T) {
+		e := prepare(t, reading(exports), &export.Preparer{State: export.StateAbsent})
+		status, err := e.Check(fakerenderer.New())
+
+		require.NoError(t, err)
+		assert.Equal(t, "<absent>", status.Diffs()["/srv/home"].Current())
+	})
+}
+
+// TestApply tests that Apply writes the file and reloads exports
+func TestApply(t *testing.T) {
+	t.Parallel()
+
+	fake := fakeexec.New()
+	fake.Expect("test", "-e", "/etc/exports").Return("", 1)
+	fake.Expect("sh", "-c", write, "/etc/exports", "0644")
+	fake.Expect("exportfs", "-ra")
+
+	_, err := prepare(t, fake, &export.Preparer{Clients: map[string]string{"*": "ro"}}).Apply()
+	require.NoError(t, err)
+	fake.AssertExpectations(t)
+	assert.Equal(t, "/srv/home *(ro)\n", fake.Calls()[1].Stdin)
+}
+
+// TestPrepare tests the invalid cases of Prepare
+func TestPrepare(t *testing.T) {
+	t.Parallel()
+
+	fr := fakerenderer.New()
+
+	_, err := (&export.Preparer{Path: "/srv/home"}).Prepare(fr)
+	assert.EqualError(t, err, "nfs.export requires at least one client")
+
+	_, err = (&export.Preparer{Path: "/srv/home", Clients: map[string]string{"a b": "rw"}}).Prepare(fr)
+	assert.EqualError(t, err, `nfs.export: "a b(rw)" is not a valid client and options`)
+}
+
+func reading(content string) *fakeexec.Executor {
+	fake := fakeexec.New()
+	fake.Expect("test", "-e", "/etc/exports")
+	fake.Expect("cat", "/etc/exports").Return(content, 0)
+	return fake
+}
+
+func prepare(t *testing.T, fake *fakeexec.Executor, p *export.Preparer) *export.Export {
+	p.Path = "/srv/home"
+
+	task, err := p.Prepare(&resource.ExecRenderer{Renderer: fakerenderer.New(), Exec: fake})
+	require.NoError(t, err)
+	return task.(*export.Export)
+}
@@ -0,0 +1,164 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package export
+
+import (
+	"sort"
+	"strings"
+)
+
+// Entry is a single line of an exports file: a path and the options each
+// client accesses it with
+type Entry struct {
+	Path    string
+	Clients map[string]string
+}
+
+// String formats the entry as a line of an exports file, with clients sorted
+func (e Entry) String() string {
+	var clients []string
+	for client := range e.Clients {
+		clients = append(clients, client)
+	}
+	sort.Strings(clients)
+
+	fields := []string{quote(e.Path)}
+	for _, client := range clients {
+		fields = append(fields, client+"("+e.Clients[client]+")")
+	}
+	return strings.Join(fields, " ")
+}
+
+// Equal is true if both entries export the same path to the same clients with
+// the same options
+func (e Entry) Equal(other Entry) bool {
+	if e.Path != other.Path || len(e.Clients) != len(other.Clients) {
+		return false
+	}
+	for client, options := range e.Clients {
+		if other.Clients[client] != options {
+			return false
+		}
+	}
+	return true
+}
+
+// Find returns the entry for path in the content of an exports file, if there
+// is one
+func Find(content, path string) (Entry, bool) {
+	for _, line := range logicalLines(content) {
+		if entry, ok := parseEntry(line.text()); ok && entry.Path == path {
+			return entry, true
+		}
+	}
+	return Entry{}, false
+}
+
+// Set returns content with the entry for path replaced by entry, or with
+// entry appended if there was none. A nil entry removes the path. Other lines
+// are kept as they are.
+func Set(content, path string, entry *Entry) string {
+	var out []string
+	replaced := false
+	for _, line := range logicalLines(content) {
+		if existing, ok := parseEntry(line.text()); ok && existing.Path == path {
+			if entry != nil && !replaced {
+				out = append(out, entry.String())
+			}
+			replaced = true
+			continue
+		}
+		out = append(out, line...)
+	}
+
+	if entry != nil && !replaced {
+		out = append(out, entry.String())
+	}
+
+	if len(out) == 0 {
+		return ""
+	}
+	return strings.Join(out, "\n") + "\n"
+}
+
+// logicalLine is a line of an exports file along with any lines it is
+// continued on with a trailing backslash
+type logicalLine []string
+
+func (l logicalLine) text() string {
+	var parts []string
+	for _, raw := range l {
+		parts = append(parts, strings.TrimSuffix(raw, "\\"))
+	}
+	return strings.Join(parts, " ")
+}
+
+// logicalLines splits content into logical lines
+func logicalLines(content string) []logicalLine {
+	if content == "" {
+		return nil
+	}
+
+	var out []logicalLine
+	var current logicalLine
+	for _, raw := range strings.Split(strings.TrimSuffix(content, "\n"), "\n") {
+		current = append(current, raw)
+		if !strings.HasSuffix(raw, "\\") {
+			out = append(out, current)
+			current = nil
+		}
+	}
+	if current != nil {
+		out = append(out, current)
+	}
+	return out
+}
+
+// parseEntry parses a non-comment line of an exports file
+func parseEntry(line string) (Entry, bool) {
+	trimmed := strings.TrimSpace(line)
+	if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+		return Entry{}, false
+	}
+
+	var path, rest string
+	if strings.HasPrefix(trimmed, `"`) {
+		end := strings.Index(trimmed[1:], `"`)
+		if end < 0 {
+			return Entry{}, false
+		}
+		path, rest = trimmed[1:end+1], trimmed[end+2:]
+	} else {
+		fields := strings.Fields(trimmed)
+		path, rest = fields[0], strings.Join(fields[1:], " ")
+	}
+
+	entry := Entry{Path: path, Clients: map[string]string{}}
+	for _, field := range strings.Fields(rest) {
+		client, options := field, ""
+		if open := strings.Index(field, "("); open >= 0 && strings.HasSuffix(field, ")") {
+			client, options = field[:open], field[open+1:len(field)-1]
+		}
+		entry.Clients[client] = options
+	}
+	return entry, true
+}
+
+func quote(path string) string {
+	if strings.ContainsAny(path, " \t") {
+		return `"` + path + `"`
+	}
+	return path
+}
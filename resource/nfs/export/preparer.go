@@ -0,0 +1,84 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package export
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/load/registry"
+	"github.com/asteris-llc/converge/resource"
+)
+
+// Preparer for Export
+//
+// Export manages the entry for a path in /etc/exports and reloads the export
+// table with exportfs -ra when it changes. Other entries in the file are left
+// alone.
+type Preparer struct {
+	// Path is the exported directory
+	Path string `hcl:"path" required:"true"`
+
+	// Clients maps hosts, networks, or wildcards to their export options, such
+	// as "10.0.0.0/24" = "rw,sync,no_subtree_check"
+	Clients map[string]string `hcl:"clients"`
+
+	// State is whether the export should be present or absent. It defaults to
+	// present.
+	State State `hcl:"state" valid_values:"present,absent"`
+
+	// File is the exports file. It defaults to /etc/exports.
+	File string `hcl:"file"`
+}
+
+// Prepare a new task
+func (p *Preparer) Prepare(render resource.Renderer) (resource.Task, error) {
+	if !path.IsAbs(p.Path) || strings.ContainsAny(p.Path, "\"\n") {
+		return nil, fmt.Errorf("nfs.export: %q is not a valid path", p.Path)
+	}
+
+	if p.State == "" {
+		p.State = StatePresent
+	}
+
+	if p.State == StatePresent && len(p.Clients) == 0 {
+		return nil, fmt.Errorf("nfs.export requires at least one client")
+	}
+
+	for client, options := range p.Clients {
+		if strings.ContainsAny(client, " \t\n()") || strings.ContainsAny(options, " \t\n()") {
+			return nil, fmt.Errorf("nfs.export: %q is not a valid client and options", client+"("+options+")")
+		}
+	}
+
+	if p.File == "" {
+		p.File = DefaultFile
+	}
+
+	return &Export{
+		Path:    path.Clean(p.Path),
+		Clients: p.Clients,
+		State:   p.State,
+		File:    p.File,
+		exec:    exec.For(render),
+	}, nil
+}
+
+func init() {
+	registry.Register("nfs.export", (*Preparer)(nil), (*Export)(nil))
+	registry.RegisterPlatforms("nfs.export", "linux")
+}
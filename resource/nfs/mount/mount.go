@@ -0,0 +1,200 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mount
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/pkg/errors"
+)
+
+// State type for Mount
+type State string
+
+const (
+	// StatePresent indicates the export should be mounted
+	StatePresent State = "present"
+
+	// StateAbsent indicates the export should not be mounted
+	StateAbsent State = "absent"
+)
+
+// Mount manages an NFS mount
+type Mount struct {
+	resource.Status
+
+	Source   string
+	Server   string
+	Path     string
+	Options  []string
+	State    State
+	SoftFail bool
+
+	// Unreachable is true if the server could not be reached during planning
+	Unreachable bool
+
+	exec exec.Executor
+}
+
+// Check whether the export is mounted
+func (m *Mount) Check(resource.Renderer) (resource.TaskStatus, error) {
+	m.Status = resource.Status{}
+	m.Unreachable = false
+
+	mounted, err := m.mounted()
+	if err != nil {
+		m.RaiseLevel(resource.StatusFatal)
+		return m, err
+	}
+
+	switch {
+	case m.State == StateAbsent && mounted:
+		m.RaiseLevel(resource.StatusWillChange)
+		m.AddDifference(m.Path, m.Source, "<unmounted>", "")
+
+	case m.State == StatePresent && !mounted:
+		reachable, err := m.reachable()
+		if err != nil {
+			m.RaiseLevel(resource.StatusFatal)
+			return m, err
+		}
+
+		if !reachable {
+			m.Unreachable = true
+			if m.SoftFail {
+				m.RaiseLevel(resource.StatusWontChange)
+				m.AddMessage(fmt.Sprintf("%s is unreachable, not mounting %s", m.Server, m.Path))
+				return m, nil
+			}
+
+			m.RaiseLevel(resource.StatusCantChange)
+			m.AddDifference(m.Path, "<unmounted>", m.Source, "")
+			return m, fmt.Errorf("NFS server %s is unreachable", m.Server)
+		}
+
+		m.RaiseLevel(resource.StatusWillChange)
+		m.AddDifference(m.Path, "<unmounted>", m.Source, "")
+	}
+
+	return m, nil
+}
+
+// Apply mounts or unmounts the export
+func (m *Mount) Apply() (resource.TaskStatus, error) {
+	m.Status = resource.Status{}
+
+	mounted, err := m.mounted()
+	if err != nil {
+		m.RaiseLevel(resource.StatusFatal)
+		return m, err
+	}
+
+	switch {
+	case m.State == StateAbsent && mounted:
+		if err := exec.Run(m.exec, "umount", m.Path); err != nil {
+			m.RaiseLevel(resource.StatusFatal)
+			return m, errors.Wrapf(err, "cannot unmount %s", m.Path)
+		}
+		m.AddMessage("unmounted " + m.Path)
+
+	case m.State == StatePresent && !mounted:
+		if err := exec.Run(m.exec, "mkdir", "-p", m.Path); err != nil {
+			m.RaiseLevel(resource.StatusFatal)
+			return m, errors.Wrapf(err, "cannot create %s", m.Path)
+		}
+
+		args := []string{"-t", "nfs"}
+		if len(m.Options) > 0 {
+			args = append(args, "-o", strings.Join(m.Options, ","))
+		}
+		if err := exec.Run(m.exec, "mount", append(args, m.Source, m.Path)...); err != nil {
+			m.RaiseLevel(resource.StatusFatal)
+			return m, errors.Wrapf(err, "cannot mount %s", m.Source)
+		}
+		m.AddMessage(fmt.Sprintf("mounted %s on %s", m.Source, m.Path))
+	}
+
+	return m, nil
+}
+
+// mounted reports whether the source is mounted on the path. Anything else
+// mounted there is an error, since it would be hidden by the mount.
+func (m *Mount) mounted() (bool, error) {
+	out, err := exec.Read(m.exec, "findmnt", "-n", "-o", "SOURCE", "--mountpoint", m.Path)
+	if exec.NotFound(err) {
+		out, err = m.mountSource()
+	}
+	if _, ok := exec.ExitStatus(err); ok {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	if source := strings.TrimSpace(out); source == "" {
+		return false, nil
+	} else if source != m.Source {
+		return false, fmt.Errorf("%s already has %s mounted", m.Path, source)
+	}
+	return true, nil
+}
+
+// mountSource finds what is mounted on the path in /proc/self/mounts, for
+// systems without findmnt such as busybox. It is empty if nothing is.
+func (m *Mount) mountSource() (string, error) {
+	content, err := exec.Read(m.exec, "cat", "/proc/self/mounts")
+	if err != nil {
+		return "", err
+	}
+
+	var source string
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && unescapeMount(fields[1]) == m.Path {
+			// later mounts hide earlier ones on the same path
+			source = unescapeMount(fields[0])
+		}
+	}
+	return source, nil
+}
+
+// unescapeMount decodes the octal escapes used for spaces and other
+// whitespace in /proc/self/mounts
+func unescapeMount(field string) string {
+	var out []byte
+	for i := 0; i < len(field); i++ {
+		if field[i] == '\\' && i+3 < len(field) {
+			if b, err := strconv.ParseUint(field[i+1:i+4], 8, 8); err == nil {
+				out = append(out, byte(b))
+				i += 3
+				continue
+			}
+		}
+		out = append(out, field[i])
+	}
+	return string(out)
+}
+
+// reachable reports whether the NFS service on the server answers
+func (m *Mount) reachable() (bool, error) {
+	err := exec.Run(m.exec, "timeout", "10", "rpcinfo", "-t", m.Server, "nfs")
+	if _, ok := exec.ExitStatus(err); ok {
+		return false, nil
+	}
+	return err == nil, err
+}
@@ -0,0 +1,183 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mount_test
+
+import (
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/fakeexec"
+	"github.com/asteris-llc/converge/helpers/fakerenderer"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/nfs/mount"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const source = "fileserver:/srv/home"
+
+// TestMountInterface tests that Mount is properly implemented
+func TestMountInterface(t *testing.T) {
+	t.Parallel()
+
+	assert.Implements(t, (*resource.Task)(nil), new(mount.Mount))
+	assert.Implements(t, (*resource.Resource)(nil), new(mount.Preparer))
+}
+
+// TestCheck tests the possible cases Check handles
+func TestCheck(t *testing.T) {
+	t.Parallel()
+
+	t.Run("unmounted", func(t *testing.T) {
+		fake := unmounted()
+		fake.Expect("timeout", "10", "rpcinfo", "-t", "fileserver", "nfs")
+
+		m := prepare(t, fake, &mount.Preparer{})
+		status, err := m.Check(fakerenderer.New())
+
+		require.NoError(t, err)
+		assert.True(t, status.HasChanges())
+		assert.Equal(t, source, status.Diffs()["/home"].Current())
+	})
+
+	t.Run("unreachable", func(t *testing.T) {
+		fake := unmounted()
+		fake.Expect("timeout", "10", "rpcinfo", "-t", "fileserver", "nfs").Return("", 1)
+
+		m := prepare(t, fake, &mount.Preparer{})
+		status, err := m.Check(fakerenderer.New())
+
+		require.NoError(t, err)
+		assert.False(t, status.HasChanges())
+		assert.True(t, m.Unreachable)
+		assert.Equal(t, []string{"fileserver is unreachable, not mounting /home"}, status.Messages())
+	})
+
+	t.Run("unreachable without soft fail", func(t *testing.T) {
+		fake := unmounted()
+		fake.Expect("timeout", "10", "rpcinfo", "-t", "fileserver", "nfs").Return("", 124)
+
+		softFail := false
+		m := prepare(t, fake, &mount.Preparer{SoftFail: &softFail})
+		status, err := m.Check(fakerenderer.New())
+
+		assert.EqualError(t, err, "NFS server fileserver is unreachable")
+		assert.Equal(t, resource.StatusCantChange, status.StatusCode())
+	})
+
+	t.Run("mounted", func(t *testing.T) {
+		m := prepare(t, mounted(source), &mount.Preparer{})
+		status, err := m.Check(fakerenderer.New())
+
+		require.NoError(t, err)
+		assert.False(t, status.HasChanges())
+	})
+
+	t.Run("something else mounted", func(t *testing.T) {
+		m := prepare(t, mounted("/dev/sdb1"), &mount.Preparer{})
+		_, err := m.Check(fakerenderer.New())
+
+		assert.EqualError(t, err, "/home already has /dev/sdb1 mounted")
+	})
+
+	t.Run("without findmnt", func(t *testing.T) {
+		mounts := "/dev/sda1 / ext4 rw 0 0\n" +
+			"/dev/sdb1 /home ext4 rw 0 0\n" +
+			source + " /home nfs rw 0 0\n" +
+			"/dev/sdc1 /home\\040other ext4 rw 0 0\n"
+
+		fake := fakeexec.New()
+		fake.Expect("findmnt", "-n", "-o", "SOURCE", "--mountpoint", "/home").Return("", 127)
+		fake.Expect("cat", "/proc/self/mounts").Return(mounts, 0)
+
+		m := prepare(t, fake, &mount.Preparer{})
+		status, err := m.Check(fakerenderer.New())
+
+		require.NoError(t, err)
+		assert.False(t, status.HasChanges())
+	})
+
+	t.Run("without findmnt unmounted", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("findmnt", "-n", "-o", "SOURCE", "--mountpoint", "/home").Return("", 127)
+		fake.Expect("cat", "/proc/self/mounts").Return("/dev/sdc1 /home\\040other ext4 rw 0 0\n", 0)
+		fake.Expect("timeout", "10", "rpcinfo", "-t", "fileserver", "nfs")
+
+		m := prepare(t, fake, &mount.Preparer{})
+		status, err := m.Check(fakerenderer.New())
+
+		require.NoError(t, err)
+		assert.True(t, status.HasChanges())
+	})
+}
+
+// TestApply tests mounting and unmounting
+func TestApply(t *testing.T) {
+	t.Parallel()
+
+	t.Run("mount", func(t *testing.T) {
+		fake := unmounted()
+		fake.Expect("mkdir", "-p", "/home")
+		fake.Expect("mount", "-t", "nfs", "-o", "vers=4.2,soft", source, "/home")
+
+		_, err := prepare(t, fake, &mount.Preparer{Options: []string{"vers=4.2", "soft"}}).Apply()
+		require.NoError(t, err)
+		fake.AssertExpectations(t)
+	})
+
+	t.Run("unmount", func(t *testing.T) {
+		fake := mounted(source)
+		fake.Expect("umount", "/home")
+
+		_, err := prepare(t, fake, &mount.Preparer{State: mount.StateAbsent}).Apply()
+		require.NoError(t, err)
+		fake.AssertExpectations(t)
+	})
+}
+
+// TestPrepare tests the invalid cases of Prepare
+func TestPrepare(t *testing.T) {
+	t.Parallel()
+
+	fr := fakerenderer.New()
+
+	_, err := (&mount.Preparer{Source: "fileserver", Path: "/home"}).Prepare(fr)
+	assert.EqualError(t, err, `nfs.mount: "fileserver" is not a valid source, expected HOST:/PATH`)
+
+	_, err = (&mount.Preparer{Source: source, Path: "/home", Options: []string{"rw,soft"}}).Prepare(fr)
+	assert.EqualError(t, err, `nfs.mount: "rw,soft" is not a valid option`)
+}
+
+func unmounted() *fakeexec.Executor {
+	fake := fakeexec.New()
+	fake.Expect("findmnt", "-n", "-o", "SOURCE", "--mountpoint", "/home").Return("", 1)
+	return fake
+}
+
+func mounted(source string) *fakeexec.Executor {
+	fake := fakeexec.New()
+	fake.Expect("findmnt", "-n", "-o", "SOURCE", "--mountpoint", "/home").Return(source+"\n", 0)
+	return fake
+}
+
+func prepare(t *testing.T, fake *fakeexec.Executor, p *mount.Preparer) *mount.Mount {
+	if p.Source == "" {
+		p.Source = source
+	}
+	p.Path = "/home"
+
+	task, err := p.Prepare(&resource.ExecRenderer{Renderer: fakerenderer.New(), Exec: fake})
+	require.NoError(t, err)
+	return task.(*mount.Mount)
+}
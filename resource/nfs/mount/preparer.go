@@ -0,0 +1,94 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mount
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/load/registry"
+	"github.com/asteris-llc/converge/resource"
+)
+
+// Preparer for Mount
+//
+// Mount mounts an NFS export. When the server can't be reached during
+// planning the mount is skipped with a warning instead of failing the run,
+// unless soft_fail is false. Mount options are only used when mounting, and a
+// mounted export is not remounted when they change. To mount the export at
+// boot, add it to fstab as well.
+type Preparer struct {
+	// Source is the export to mount, such as "fileserver:/srv/share"
+	Source string `hcl:"source" required:"true"`
+
+	// Path is the mount point. It is created if missing.
+	Path string `hcl:"path" required:"true"`
+
+	// Options are NFS mount options, such as "vers=4.2", "soft", or
+	// "timeo=100". See nfs(5) for the ones available.
+	Options []string `hcl:"options"`
+
+	// State is whether the export should be mounted (present) or not
+	// (absent). It defaults to present.
+	State State `hcl:"state" valid_values:"present,absent"`
+
+	// SoftFail skips mounting when the server is unreachable during planning,
+	// so the rest of the run can continue. It defaults to true.
+	SoftFail *bool `hcl:"soft_fail"`
+}
+
+// Prepare a new task
+func (p *Preparer) Prepare(render resource.Renderer) (resource.Task, error) {
+	sep := strings.Index(p.Source, ":")
+	if sep <= 0 || !path.IsAbs(p.Source[sep+1:]) {
+		return nil, fmt.Errorf("nfs.mount: %q is not a valid source, expected HOST:/PATH", p.Source)
+	}
+
+	if !path.IsAbs(p.Path) || path.Clean(p.Path) == "/" {
+		return nil, fmt.Errorf("nfs.mount: %q is not a valid mount point", p.Path)
+	}
+
+	for _, option := range p.Options {
+		if option == "" || strings.ContainsAny(option, ", \t\n") {
+			return nil, fmt.Errorf("nfs.mount: %q is not a valid option", option)
+		}
+	}
+
+	if p.State == "" {
+		p.State = StatePresent
+	}
+
+	softFail := true
+	if p.SoftFail != nil {
+		softFail = *p.SoftFail
+	}
+
+	return &Mount{
+		Source:   p.Source,
+		Server:   p.Source[:sep],
+		Path:     path.Clean(p.Path),
+		Options:  p.Options,
+		State:    p.State,
+		SoftFail: softFail,
+		exec:     exec.For(render),
+	}, nil
+}
+
+func init() {
+	registry.Register("nfs.mount", (*Preparer)(nil), (*Mount)(nil))
+	registry.RegisterPlatforms("nfs.mount", "linux")
+}
@@ -0,0 +1,84 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vhost
+
+import (
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/load/registry"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/confd"
+)
+
+// DefaultDir is the directory nginx includes configuration from
+const DefaultDir = "/etc/nginx/conf.d"
+
+// Service is nginx, which is checked with nginx -t
+var Service = confd.Service{
+	Unit:     "nginx",
+	Validate: []string{"nginx", "-t"},
+}
+
+// Preparer for VHost
+//
+// VHost manages a server block or other fragment in nginx's conf.d. The whole
+// configuration is checked with `nginx -t` after the fragment is written. If
+// the check fails the previous fragment is restored, otherwise nginx is
+// reloaded.
+type Preparer struct {
+	// Name of the fragment, which is written to DIR/NAME.conf
+	Name string `hcl:"name" required:"true"`
+
+	// Content of the fragment
+	Content string `hcl:"content"`
+
+	// Dir is the directory nginx includes. It defaults to /etc/nginx/conf.d.
+	Dir string `hcl:"dir"`
+
+	// Reload controls whether nginx is reloaded after the fragment changes. It
+	// defaults to true.
+	Reload *bool `hcl:"reload"`
+
+	// State is whether the fragment should be present or absent. It defaults
+	// to present.
+	State confd.State `hcl:"state" valid_values:"present,absent"`
+}
+
+// Prepare a new task
+func (p *Preparer) Prepare(render resource.Renderer) (resource.Task, error) {
+	if p.Dir == "" {
+		p.Dir = DefaultDir
+	}
+
+	reload := true
+	if p.Reload != nil {
+		reload = *p.Reload
+	}
+
+	fragment, err := confd.New(exec.For(render), Service, p.Dir, p.Name, ".conf", p.Content, p.State, reload)
+	if err != nil {
+		return nil, err
+	}
+	return &VHost{Fragment: fragment}, nil
+}
+
+// VHost is an nginx configuration fragment
+type VHost struct {
+	*confd.Fragment
+}
+
+func init() {
+	registry.Register("nginx.vhost", (*Preparer)(nil), (*VHost)(nil))
+	registry.RegisterPlatforms("nginx.vhost", "linux")
+}
@@ -0,0 +1,43 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vhost_test
+
+import (
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/fakerenderer"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/nginx/vhost"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestVHostInterface tests that VHost is properly implemented
+func TestVHostInterface(t *testing.T) {
+	t.Parallel()
+
+	assert.Implements(t, (*resource.Task)(nil), new(vhost.VHost))
+	assert.Implements(t, (*resource.Resource)(nil), new(vhost.Preparer))
+}
+
+// TestPrepare tests the defaults of Prepare
+func TestPrepare(t *testing.T) {
+	t.Parallel()
+
+	task, err := (&vhost.Preparer{Name: "app", Content: "server {}"}).Prepare(fakerenderer.New())
+	require.NoError(t, err)
+	assert.Equal(t, "/etc/nginx/conf.d/app.conf", task.(*vhost.VHost).Path)
+	assert.True(t, task.(*vhost.VHost).Reload)
+}
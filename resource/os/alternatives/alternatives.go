@@ -0,0 +1,175 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package alternatives
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/pkg/errors"
+)
+
+// Alternatives manages the selection for an alternatives group
+type Alternatives struct {
+	resource.Status
+
+	Name     string
+	Path     string
+	Link     string
+	Priority *int
+
+	exec exec.Executor
+
+	register bool
+}
+
+// Check whether the alternative is registered and selected
+func (a *Alternatives) Check(resource.Renderer) (resource.TaskStatus, error) {
+	a.Status = resource.Status{}
+	a.register = false
+
+	group, err := Query(a.exec, a.Name)
+	if err != nil {
+		a.RaiseLevel(resource.StatusFatal)
+		return a, err
+	}
+
+	if group == nil {
+		if a.Priority == nil || a.Link == "" {
+			a.RaiseLevel(resource.StatusFatal)
+			return a, fmt.Errorf("alternatives group %s does not exist, set \"link\" and \"priority\" to create it", a.Name)
+		}
+		group = &Group{Name: a.Name, Value: "<none>"}
+	}
+
+	if a.Link == "" {
+		a.Link = group.Link
+	}
+
+	priority, registered := group.Alternatives[a.Path]
+	switch {
+	case !registered && a.Priority == nil:
+		a.RaiseLevel(resource.StatusFatal)
+		return a, fmt.Errorf("%s is not an alternative for %s, set \"priority\" to register it", a.Path, a.Name)
+
+	case !registered:
+		a.register = true
+		a.RaiseLevel(resource.StatusWillChange)
+		a.AddDifference(a.Path, "<unregistered>", fmt.Sprintf("priority %d", *a.Priority), "")
+
+	case a.Priority != nil && priority != *a.Priority:
+		a.register = true
+		a.RaiseLevel(resource.StatusWillChange)
+		a.AddDifference(a.Path, fmt.Sprintf("priority %d", priority), fmt.Sprintf("priority %d", *a.Priority), "")
+	}
+
+	if group.Value != a.Path {
+		a.RaiseLevel(resource.StatusWillChange)
+		a.AddDifference(a.Name, group.Value, a.Path, "")
+	}
+
+	return a, nil
+}
+
+// Apply registers the alternative if needed and selects it
+func (a *Alternatives) Apply() (resource.TaskStatus, error) {
+	a.Status = resource.Status{}
+
+	if a.register {
+		err := exec.Run(a.exec, "update-alternatives", "--install", a.Link, a.Name, a.Path, strconv.Itoa(*a.Priority))
+		if err != nil {
+			a.RaiseLevel(resource.StatusFatal)
+			return a, errors.Wrapf(err, "cannot register %s", a.Path)
+		}
+		a.AddMessage(fmt.Sprintf("registered %s for %s", a.Path, a.Name))
+	}
+
+	if err := exec.Run(a.exec, "update-alternatives", "--set", a.Name, a.Path); err != nil {
+		a.RaiseLevel(resource.StatusFatal)
+		return a, errors.Wrapf(err, "cannot select %s", a.Path)
+	}
+	a.AddMessage(fmt.Sprintf("selected %s for %s", a.Path, a.Name))
+
+	return a, nil
+}
+
+// Group is an alternatives group as reported by update-alternatives --query
+type Group struct {
+	Name   string
+	Link   string
+	Status string
+	Value  string
+
+	// Alternatives maps registered alternatives to their priorities
+	Alternatives map[string]int
+}
+
+// Query returns the group, or nil if it does not exist
+func Query(e exec.Executor, name string) (*Group, error) {
+	out, err := exec.Read(e, "update-alternatives", "--query", name)
+	if err != nil {
+		if _, ok := exec.ExitStatus(err); ok {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "cannot query alternatives for %s", name)
+	}
+
+	return ParseQuery(out), nil
+}
+
+// ParseQuery parses the output of update-alternatives --query
+func ParseQuery(out string) *Group {
+	group := &Group{Alternatives: map[string]int{}}
+
+	current := ""
+	for _, line := range strings.Split(out, "\n") {
+		if strings.HasPrefix(line, " ") {
+			// slave links are indented
+			continue
+		}
+
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		value := strings.TrimSpace(parts[1])
+
+		switch parts[0] {
+		case "Name":
+			group.Name = value
+		case "Link":
+			group.Link = value
+		case "Status":
+			group.Status = value
+		case "Value":
+			group.Value = value
+		case "Alternative":
+			current = value
+			group.Alternatives[current] = 0
+		case "Priority":
+			if priority, err := strconv.Atoi(value); err == nil && current != "" {
+				group.Alternatives[current] = priority
+			}
+		}
+	}
+
+	if group.Value == "" {
+		group.Value = "<none>"
+	}
+	return group
+}
@@ -0,0 +1,174 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package alternatives_test
+
+import (
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/fakeexec"
+	"github.com/asteris-llc/converge/helpers/fakerenderer"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/os/alternatives"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const query = `Name: editor
+Link: /usr/bin/editor
+Slaves:
+ editor.1.gz /usr/share/man/man1/editor.1.gz
+Status: auto
+Best: /bin/nano
+Value: /bin/nano
+
+Alternative: /bin/nano
+Priority: 40
+Slaves:
+ editor.1.gz /usr/share/man/man1/nano.1.gz
+
+Alternative: /usr/bin/vim.basic
+Priority: 30
+Slaves:
+ editor.1.gz /usr/share/man/man1/vim.1.gz
+`
+
+// TestAlternativesInterface tests that Alternatives is properly implemented
+func TestAlternativesInterface(t *testing.T) {
+	t.Parallel()
+
+	assert.Implements(t, (*resource.Task)(nil), new(alternatives.Alternatives))
+	assert.Implements(t, (*resource.Resource)(nil), new(alternatives.Preparer))
+}
+
+// TestParseQuery tests parsing update-alternatives --query
+func TestParseQuery(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(
+		t,
+		&alternatives.Group{
+			Name:         "editor",
+			Link:         "/usr/bin/editor",
+			Status:       "auto",
+			Value:        "/bin/nano",
+			Alternatives: map[string]int{"/bin/nano": 40, "/usr/bin/vim.basic": 30},
+		},
+		alternatives.ParseQuery(query),
+	)
+}
+
+// TestCheck tests the possible cases Check handles
+func TestCheck(t *testing.T) {
+	t.Parallel()
+
+	t.Run("selected", func(t *testing.T) {
+		status, err := check(t, query, &alternatives.Preparer{Name: "editor", Path: "/bin/nano"})
+
+		require.NoError(t, err)
+		assert.False(t, status.HasChanges())
+	})
+
+	t.Run("select", func(t *testing.T) {
+		status, err := check(t, query, &alternatives.Preparer{Name: "editor", Path: "/usr/bin/vim.basic"})
+
+		require.NoError(t, err)
+		assert.Equal(t, "/bin/nano", status.Diffs()["editor"].Original())
+		assert.Equal(t, "/usr/bin/vim.basic", status.Diffs()["editor"].Current())
+	})
+
+	t.Run("not registered", func(t *testing.T) {
+		_, err := check(t, query, &alternatives.Preparer{Name: "editor", Path: "/usr/bin/emacs"})
+
+		assert.EqualError(t, err, `/usr/bin/emacs is not an alternative for editor, set "priority" to register it`)
+	})
+
+	t.Run("priority", func(t *testing.T) {
+		priority := 50
+		status, err := check(t, query, &alternatives.Preparer{Name: "editor", Path: "/usr/bin/vim.basic", Priority: &priority})
+
+		require.NoError(t, err)
+		assert.Equal(t, "priority 30", status.Diffs()["/usr/bin/vim.basic"].Original())
+	})
+
+	t.Run("missing group", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("update-alternatives", "--query", "java").Stderr("update-alternatives: error: no alternatives for java").Return("", 2)
+
+		_, err := prepare(t, fake, &alternatives.Preparer{Name: "java", Path: "/opt/jdk/bin/java"}).Check(fakerenderer.New())
+
+		assert.EqualError(t, err, `alternatives group java does not exist, set "link" and "priority" to create it`)
+	})
+}
+
+// TestApply tests the possible cases Apply handles
+func TestApply(t *testing.T) {
+	t.Parallel()
+
+	t.Run("register and select", func(t *testing.T) {
+		priority := 100
+		fake := fakeexec.New()
+		fake.Expect("update-alternatives", "--query", "editor").Return(query, 0)
+		fake.Expect("update-alternatives", "--install", "/usr/bin/editor", "editor", "/usr/bin/emacs", "100")
+		fake.Expect("update-alternatives", "--set", "editor", "/usr/bin/emacs")
+
+		a := prepare(t, fake, &alternatives.Preparer{Name: "editor", Path: "/usr/bin/emacs", Priority: &priority})
+		_, err := a.Check(fakerenderer.New())
+		require.NoError(t, err)
+
+		_, err = a.Apply()
+		require.NoError(t, err)
+		fake.AssertExpectations(t)
+	})
+
+	t.Run("select", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("update-alternatives", "--query", "editor").Return(query, 0)
+		fake.Expect("update-alternatives", "--set", "editor", "/usr/bin/vim.basic")
+
+		a := prepare(t, fake, &alternatives.Preparer{Name: "editor", Path: "/usr/bin/vim.basic"})
+		_, err := a.Check(fakerenderer.New())
+		require.NoError(t, err)
+
+		_, err = a.Apply()
+		require.NoError(t, err)
+		assert.Len(t, fake.Calls(), 2)
+	})
+}
+
+// TestPrepare tests the invalid cases of Prepare
+func TestPrepare(t *testing.T) {
+	t.Parallel()
+
+	fr := fakerenderer.New()
+
+	_, err := (&alternatives.Preparer{Name: "editor", Path: "vim"}).Prepare(fr)
+	assert.EqualError(t, err, `alternatives "path" must be absolute`)
+
+	_, err = (&alternatives.Preparer{Name: "editor", Path: "/usr/bin/vim", Link: "/usr/bin/editor"}).Prepare(fr)
+	assert.EqualError(t, err, `alternatives "link" is only used with "priority"`)
+}
+
+func check(t *testing.T, out string, p *alternatives.Preparer) (resource.TaskStatus, error) {
+	fake := fakeexec.New()
+	fake.Expect("update-alternatives", "--query", p.Name).Return(out, 0)
+
+	return prepare(t, fake, p).Check(fakerenderer.New())
+}
+
+func prepare(t *testing.T, fake *fakeexec.Executor, p *alternatives.Preparer) *alternatives.Alternatives {
+	task, err := p.Prepare(&resource.ExecRenderer{Renderer: fakerenderer.New(), Exec: fake})
+	require.NoError(t, err)
+	return task.(*alternatives.Alternatives)
+}
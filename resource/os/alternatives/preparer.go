@@ -0,0 +1,78 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package alternatives
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/load/registry"
+	"github.com/asteris-llc/converge/resource"
+)
+
+// Preparer for Alternatives
+//
+// Alternatives selects the alternative used for a group managed by
+// update-alternatives, such as "editor" or "java". When a priority is given,
+// the alternative is registered first if it is not already, or if it has a
+// different priority.
+type Preparer struct {
+	// Name is the name of the group
+	Name string `hcl:"name" required:"true"`
+
+	// Path is the alternative to select
+	Path string `hcl:"path" required:"true"`
+
+	// Link is the generic name of the group, such as /usr/bin/editor. It is
+	// required to register an alternative for a group that does not exist.
+	Link string `hcl:"link"`
+
+	// Priority registers the alternative with this priority
+	Priority *int `hcl:"priority"`
+}
+
+// Prepare a new task
+func (p *Preparer) Prepare(render resource.Renderer) (resource.Task, error) {
+	if strings.ContainsAny(p.Name, "/ ") {
+		return nil, fmt.Errorf("alternatives \"name\" cannot contain \"/\" or spaces")
+	}
+
+	if !path.IsAbs(p.Path) {
+		return nil, fmt.Errorf("alternatives \"path\" must be absolute")
+	}
+
+	if p.Link != "" && !path.IsAbs(p.Link) {
+		return nil, fmt.Errorf("alternatives \"link\" must be absolute")
+	}
+
+	if p.Link != "" && p.Priority == nil {
+		return nil, fmt.Errorf("alternatives \"link\" is only used with \"priority\"")
+	}
+
+	return &Alternatives{
+		Name:     p.Name,
+		Path:     p.Path,
+		Link:     p.Link,
+		Priority: p.Priority,
+		exec:     exec.For(render),
+	}, nil
+}
+
+func init() {
+	registry.Register("os.alternatives", (*Preparer)(nil), (*Alternatives)(nil))
+	registry.RegisterPlatforms("os.alternatives", "linux")
+}
@@ -0,0 +1,190 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gpgkey
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/pkg/errors"
+)
+
+// State type for GPGKey
+type State string
+
+const (
+	// StatePresent indicates the key should be in the keyring
+	StatePresent State = "present"
+
+	// StateAbsent indicates the key should not be in the keyring
+	StateAbsent State = "absent"
+)
+
+// GPGKey manages a key in a keyring
+type GPGKey struct {
+	resource.Status
+
+	Fingerprint string
+	Keyring     string
+	URL         string
+	File        string
+	Keyserver   string
+	State       State
+
+	// Path is the keyring file for apt keyrings
+	Path string
+
+	exec    exec.Executor
+	keyring Keyring
+}
+
+// Check whether the key is in the keyring. When it is not, the key is fetched
+// and its fingerprint verified, so a source serving the wrong key fails the
+// plan rather than the apply.
+func (g *GPGKey) Check(resource.Renderer) (resource.TaskStatus, error) {
+	g.Status = resource.Status{}
+
+	installed, err := g.keyring.Installed(g.Fingerprint)
+	if err != nil {
+		g.RaiseLevel(resource.StatusFatal)
+		return g, errors.Wrapf(err, "cannot check %s keyring", g.Keyring)
+	}
+
+	switch g.State {
+	case StatePresent:
+		if installed {
+			return g, nil
+		}
+
+		if _, err := g.fetch(); err != nil {
+			g.RaiseLevel(resource.StatusFatal)
+			return g, err
+		}
+
+		g.RaiseLevel(resource.StatusWillChange)
+		g.AddDifference(g.Fingerprint, "<absent>", "imported from "+g.source(), "")
+
+	case StateAbsent:
+		if installed {
+			g.RaiseLevel(resource.StatusWillChange)
+			g.AddDifference(g.Fingerprint, "<present>", "<absent>", "")
+		}
+
+	default:
+		g.RaiseLevel(resource.StatusFatal)
+		return g, fmt.Errorf("gpg_key: unrecognized state %s", g.State)
+	}
+
+	return g, nil
+}
+
+// Apply imports or removes the key
+func (g *GPGKey) Apply() (resource.TaskStatus, error) {
+	g.Status = resource.Status{}
+
+	if g.State == StateAbsent {
+		if err := g.keyring.Remove(g.Fingerprint); err != nil {
+			g.RaiseLevel(resource.StatusFatal)
+			return g, errors.Wrapf(err, "cannot remove %s", g.Fingerprint)
+		}
+		g.AddMessage(fmt.Sprintf("removed %s from %s keyring", g.Fingerprint, g.Keyring))
+		return g, nil
+	}
+
+	// the key is fetched and verified again since the source may have changed
+	// since the plan
+	data, err := g.fetch()
+	if err != nil {
+		g.RaiseLevel(resource.StatusFatal)
+		return g, err
+	}
+
+	if err := g.keyring.Import(data); err != nil {
+		g.RaiseLevel(resource.StatusFatal)
+		return g, errors.Wrapf(err, "cannot import %s", g.Fingerprint)
+	}
+
+	g.AddMessage(fmt.Sprintf("imported %s into %s keyring", g.Fingerprint, g.Keyring))
+	return g, nil
+}
+
+// fetch returns the key data from the source after checking that it holds
+// only the declared key
+func (g *GPGKey) fetch() (string, error) {
+	var data string
+	var err error
+	switch {
+	case g.File != "":
+		data, err = exec.Read(g.exec, "cat", g.File)
+	default:
+		data, err = exec.Read(g.exec, "curl", "--fail", "--silent", "--show-error", "--location", g.fetchURL())
+	}
+	if err != nil {
+		return "", errors.Wrapf(err, "cannot fetch key from %s", g.source())
+	}
+
+	out, err := read(g.exec, data, "gpg", "--batch", "--show-keys", "--with-colons")
+	if err != nil {
+		return "", errors.Wrapf(err, "cannot read key from %s", g.source())
+	}
+
+	fingerprints := Fingerprints(out)
+	if len(fingerprints) != 1 || fingerprints[0] != g.Fingerprint {
+		return "", fmt.Errorf(
+			"fingerprint mismatch: %s has %s, expected %s",
+			g.source(),
+			describe(fingerprints),
+			g.Fingerprint,
+		)
+	}
+
+	return data, nil
+}
+
+func (g *GPGKey) fetchURL() string {
+	if g.URL != "" {
+		return g.URL
+	}
+
+	// HKP keyservers also serve keys over HTTPS
+	host := g.Keyserver
+	for _, scheme := range []string{"hkps://", "hkp://", "https://"} {
+		host = strings.TrimPrefix(host, scheme)
+	}
+	host = strings.TrimSuffix(host, "/")
+
+	query := url.Values{"op": {"get"}, "options": {"mr"}, "search": {"0x" + g.Fingerprint}}
+	return "https://" + host + "/pks/lookup?" + query.Encode()
+}
+
+func (g *GPGKey) source() string {
+	switch {
+	case g.File != "":
+		return g.File
+	case g.URL != "":
+		return g.URL
+	}
+	return g.Keyserver
+}
+
+func describe(fingerprints []string) string {
+	if len(fingerprints) == 0 {
+		return "no keys"
+	}
+	return strings.Join(fingerprints, ", ")
+}
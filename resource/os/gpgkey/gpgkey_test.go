@@ -0,0 +1,208 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gpgkey_test
+
+import (
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/fakeexec"
+	"github.com/asteris-llc/converge/helpers/fakerenderer"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/os/gpgkey"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	fingerprint = "9DC858229FC7DD38854AE2D88D81803C0EBFCD88"
+	keyring     = "/etc/apt/keyrings/docker.gpg"
+	url         = "https://download.docker.com/linux/ubuntu/gpg"
+	key         = "-----BEGIN PGP PUBLIC KEY BLOCK-----\n...\n-----END PGP PUBLIC KEY BLOCK-----\n"
+
+	colons = `pub:-:4096:1:8D81803C0EBFCD88:1487788586:::-:::scESC::::::23::0:
+fpr:::::::::9DC858229FC7DD38854AE2D88D81803C0EBFCD88:
+uid:-::::1487792064::B5A08F01796E7F521861B449372D1C33E58B4529::Docker Release (CE deb) <docker@docker.com>::::::::::0:
+sub:-:4096:1:7EA0A9C3F273FCD8:1487791176::::::s::::::23:
+fpr:::::::::D3306A018370199E527AE7997EA0A9C3F273FCD8:
+`
+)
+
+// TestGPGKeyInterface tests that GPGKey is properly implemented
+func TestGPGKeyInterface(t *testing.T) {
+	t.Parallel()
+
+	assert.Implements(t, (*resource.Task)(nil), new(gpgkey.GPGKey))
+	assert.Implements(t, (*resource.Resource)(nil), new(gpgkey.Preparer))
+}
+
+// TestFingerprints tests that only primary key fingerprints are returned
+func TestFingerprints(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, []string{fingerprint}, gpgkey.Fingerprints(colons))
+}
+
+// TestCheck tests the possible cases Check handles
+func TestCheck(t *testing.T) {
+	t.Parallel()
+
+	t.Run("installed", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("test", "-e", keyring)
+		fake.Expect("gpg", "--batch", "--show-keys", "--with-colons", keyring).Return(colons, 0)
+
+		g := prepare(t, fake, docker())
+		status, err := g.Check(fakerenderer.New())
+
+		require.NoError(t, err)
+		assert.False(t, status.HasChanges())
+		assert.Equal(t, keyring, g.Path)
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		fake := fetching(colons)
+		fake.Expect("test", "-e", keyring).Return("", 1)
+
+		status, err := prepare(t, fake, docker()).Check(fakerenderer.New())
+
+		require.NoError(t, err)
+		assert.True(t, status.HasChanges())
+		assert.Equal(t, "imported from "+url, status.Diffs()[fingerprint].Current())
+		fake.AssertExpectations(t)
+	})
+
+	t.Run("fingerprint mismatch", func(t *testing.T) {
+		fake := fetching("pub:-:4096:1:AAAA:1::::::\nfpr:::::::::0000000000000000000000000000000000000000:\n")
+		fake.Expect("test", "-e", keyring).Return("", 1)
+
+		status, err := prepare(t, fake, docker()).Check(fakerenderer.New())
+
+		assert.EqualError(t, err, "fingerprint mismatch: "+url+" has 0000000000000000000000000000000000000000, expected "+fingerprint)
+		assert.Equal(t, resource.StatusFatal, status.StatusCode())
+	})
+
+	t.Run("extra keys", func(t *testing.T) {
+		fake := fetching(colons + "pub:-:4096:1:AAAA:1::::::\nfpr:::::::::0000000000000000000000000000000000000000:\n")
+		fake.Expect("test", "-e", keyring).Return("", 1)
+
+		_, err := prepare(t, fake, docker()).Check(fakerenderer.New())
+
+		assert.Error(t, err)
+	})
+
+	t.Run("keyserver", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("rpm", "-q", "gpg-pubkey-0ebfcd88").Return("", 1)
+		fake.Expect("curl", "--fail", "--silent", "--show-error", "--location", "https://keyserver.ubuntu.com/pks/lookup?op=get&options=mr&search=0x"+fingerprint).Return(key, 0)
+		fake.Expect("gpg", "--batch", "--show-keys", "--with-colons").Return(colons, 0)
+
+		status, err := prepare(t, fake, &gpgkey.Preparer{
+			Name:        "docker",
+			Fingerprint: "9DC8 5822 9FC7 DD38 854A  E2D8 8D81 803C 0EBF CD88",
+			Keyring:     "rpm",
+			Keyserver:   "hkps://keyserver.ubuntu.com",
+		}).Check(fakerenderer.New())
+
+		require.NoError(t, err)
+		assert.True(t, status.HasChanges())
+		fake.AssertExpectations(t)
+	})
+}
+
+// TestApply tests the possible cases Apply handles
+func TestApply(t *testing.T) {
+	t.Parallel()
+
+	t.Run("apt", func(t *testing.T) {
+		fake := fetching(colons)
+		fake.Expect("mkdir", "-p", "/etc/apt/keyrings")
+		fake.Expect("gpg", "--batch", "--yes", "--dearmor", "--output", keyring)
+		fake.Expect("chmod", "0644", keyring)
+
+		_, err := prepare(t, fake, docker()).Apply()
+
+		require.NoError(t, err)
+		fake.AssertExpectations(t)
+	})
+
+	t.Run("user", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("cat", "/srv/key.asc").Return(key, 0)
+		fake.Expect("gpg", "--batch", "--show-keys", "--with-colons").Return(colons, 0)
+		fake.Expect("gpg", "--batch", "--import")
+
+		_, err := prepare(t, fake, &gpgkey.Preparer{Name: "docker", Fingerprint: fingerprint, Keyring: "user", File: "/srv/key.asc"}).Apply()
+
+		require.NoError(t, err)
+		fake.AssertExpectations(t)
+		calls := fake.Calls()
+		assert.Equal(t, key, calls[len(calls)-1].Stdin)
+	})
+
+	t.Run("rpm absent", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("rpm", "-e", "--allmatches", "gpg-pubkey-0ebfcd88")
+
+		_, err := prepare(t, fake, &gpgkey.Preparer{Name: "docker", Fingerprint: fingerprint, Keyring: "rpm", State: gpgkey.StateAbsent}).Apply()
+
+		require.NoError(t, err)
+		fake.AssertExpectations(t)
+	})
+
+	t.Run("mismatch", func(t *testing.T) {
+		fake := fetching("")
+
+		status, err := prepare(t, fake, docker()).Apply()
+
+		assert.EqualError(t, err, "fingerprint mismatch: "+url+" has no keys, expected "+fingerprint)
+		assert.Equal(t, resource.StatusFatal, status.StatusCode())
+		for _, call := range fake.Calls() {
+			assert.NotEqual(t, "mkdir", call.Name, "mismatched key must not be imported")
+		}
+	})
+}
+
+// TestPrepare tests the invalid cases of Prepare
+func TestPrepare(t *testing.T) {
+	t.Parallel()
+
+	fr := fakerenderer.New()
+
+	_, err := (&gpgkey.Preparer{Name: "docker", Fingerprint: "0EBFCD88", Keyring: "apt", URL: url}).Prepare(fr)
+	assert.EqualError(t, err, `gpg_key "fingerprint" must be a full fingerprint of 40 or 64 hex digits`)
+
+	_, err = (&gpgkey.Preparer{Name: "docker", Fingerprint: fingerprint, Keyring: "apt"}).Prepare(fr)
+	assert.EqualError(t, err, `gpg_key requires one of "url", "file", or "keyserver"`)
+
+	_, err = (&gpgkey.Preparer{Name: "docker", Fingerprint: fingerprint, Keyring: "apt", State: gpgkey.StateAbsent}).Prepare(fr)
+	assert.NoError(t, err)
+}
+
+func docker() *gpgkey.Preparer {
+	return &gpgkey.Preparer{Name: "docker", Fingerprint: fingerprint, Keyring: "apt", URL: url}
+}
+
+func fetching(listing string) *fakeexec.Executor {
+	fake := fakeexec.New()
+	fake.Expect("curl", "--fail", "--silent", "--show-error", "--location", url).Return(key, 0)
+	fake.Expect("gpg", "--batch", "--show-keys", "--with-colons").Return(listing, 0)
+	return fake
+}
+
+func prepare(t *testing.T, fake *fakeexec.Executor, p *gpgkey.Preparer) *gpgkey.GPGKey {
+	task, err := p.Prepare(&resource.ExecRenderer{Renderer: fakerenderer.New(), Exec: fake})
+	require.NoError(t, err)
+	return task.(*gpgkey.GPGKey)
+}
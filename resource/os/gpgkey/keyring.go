@@ -0,0 +1,229 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gpgkey
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/pkg/errors"
+)
+
+// Keyring is a store of trusted keys
+type Keyring interface {
+	// Installed reports whether the key with the fingerprint is in the keyring
+	Installed(fingerprint string) (bool, error)
+
+	// Import adds the key data to the keyring
+	Import(data string) error
+
+	// Remove removes the key with the fingerprint from the keyring
+	Remove(fingerprint string) error
+}
+
+// Keyrings are the names accepted by NewKeyring
+var Keyrings = []string{"apt", "rpm", "user"}
+
+// AptDir is the directory apt keyrings are written to. Sources refer to them
+// with the signed-by option.
+const AptDir = "/etc/apt/keyrings"
+
+// NewKeyring returns the named keyring. name identifies the key in keyrings
+// that store each key in its own file.
+func NewKeyring(e exec.Executor, keyring, name string) (Keyring, error) {
+	switch keyring {
+	case "apt":
+		return &Apt{Executor: e, Path: path.Join(AptDir, name+".gpg")}, nil
+	case "rpm":
+		return &RPM{Executor: e, Name: name}, nil
+	case "user":
+		return &User{Executor: e}, nil
+	}
+
+	return nil, fmt.Errorf("%q is not a valid keyring, expected one of %s", keyring, strings.Join(Keyrings, ", "))
+}
+
+// Apt is a Keyring for a single key in its own file, for use with the
+// signed-by option of an apt source
+type Apt struct {
+	Executor exec.Executor
+	Path     string
+}
+
+// Installed reports whether the keyring file contains the key
+func (a *Apt) Installed(fingerprint string) (bool, error) {
+	if err := exec.Run(a.Executor, "test", "-e", a.Path); err != nil {
+		if _, ok := exec.ExitStatus(err); ok {
+			return false, nil
+		}
+		return false, err
+	}
+
+	out, err := exec.Read(a.Executor, "gpg", "--batch", "--show-keys", "--with-colons", a.Path)
+	if err != nil {
+		return false, errors.Wrapf(err, "cannot read %s", a.Path)
+	}
+	return contains(Fingerprints(out), fingerprint), nil
+}
+
+// Import writes the key to the keyring file, removing ASCII armor since apt
+// only reads armored keys from files ending in .asc
+func (a *Apt) Import(data string) error {
+	if err := exec.Run(a.Executor, "mkdir", "-p", path.Dir(a.Path)); err != nil {
+		return errors.Wrapf(err, "cannot create %s", path.Dir(a.Path))
+	}
+
+	if !armored(data) {
+		return exec.WriteFile(a.Executor, a.Path, data, 0644)
+	}
+
+	if err := run(a.Executor, data, "gpg", "--batch", "--yes", "--dearmor", "--output", a.Path); err != nil {
+		return err
+	}
+	return exec.Run(a.Executor, "chmod", "0644", a.Path)
+}
+
+// Remove removes the keyring file
+func (a *Apt) Remove(string) error {
+	return exec.Run(a.Executor, "rm", "-f", a.Path)
+}
+
+// RPM is a Keyring for the rpm database
+type RPM struct {
+	Executor exec.Executor
+	Name     string
+}
+
+// Installed reports whether the rpm database has the key. rpm names keys
+// after the last eight hex digits of their fingerprint.
+func (r *RPM) Installed(fingerprint string) (bool, error) {
+	err := exec.Run(r.Executor, "rpm", "-q", r.pkg(fingerprint))
+	if err == nil {
+		return true, nil
+	}
+	if _, ok := exec.ExitStatus(err); ok {
+		return false, nil
+	}
+	return false, err
+}
+
+// Import adds the key to the rpm database
+func (r *RPM) Import(data string) error {
+	staged := path.Join("/tmp", "converge-"+r.Name+".asc")
+	if err := exec.WriteFile(r.Executor, staged, data, 0600); err != nil {
+		return errors.Wrapf(err, "cannot write %s", staged)
+	}
+
+	err := exec.Run(r.Executor, "rpm", "--import", staged)
+	if rmErr := exec.Run(r.Executor, "rm", "-f", staged); rmErr != nil && err == nil {
+		err = rmErr
+	}
+	return err
+}
+
+// Remove removes the key from the rpm database
+func (r *RPM) Remove(fingerprint string) error {
+	return exec.Run(r.Executor, "rpm", "-e", "--allmatches", r.pkg(fingerprint))
+}
+
+func (r *RPM) pkg(fingerprint string) string {
+	return "gpg-pubkey-" + strings.ToLower(fingerprint[len(fingerprint)-8:])
+}
+
+// User is a Keyring for the default keyring of the user commands run as. Use
+// become_user to manage the keyring of another user.
+type User struct {
+	Executor exec.Executor
+}
+
+// Installed reports whether the user's keyring has the key
+func (u *User) Installed(fingerprint string) (bool, error) {
+	err := exec.Run(u.Executor, "gpg", "--batch", "--with-colons", "--list-keys", fingerprint)
+	if err == nil {
+		return true, nil
+	}
+	if _, ok := exec.ExitStatus(err); ok {
+		return false, nil
+	}
+	return false, err
+}
+
+// Import adds the key to the user's keyring
+func (u *User) Import(data string) error {
+	return run(u.Executor, data, "gpg", "--batch", "--import")
+}
+
+// Remove removes the key from the user's keyring
+func (u *User) Remove(fingerprint string) error {
+	return exec.Run(u.Executor, "gpg", "--batch", "--yes", "--delete-keys", fingerprint)
+}
+
+// Fingerprints returns the fingerprints of the primary keys in gpg's colon
+// listing format. Fingerprints of subkeys are skipped.
+func Fingerprints(colons string) []string {
+	var out []string
+	primary := false
+	for _, line := range strings.Split(colons, "\n") {
+		fields := strings.Split(line, ":")
+		switch fields[0] {
+		case "pub":
+			primary = true
+		case "sub", "ssb":
+			primary = false
+		case "fpr":
+			if primary && len(fields) > 9 {
+				out = append(out, fields[9])
+				primary = false
+			}
+		}
+	}
+	return out
+}
+
+func contains(list []string, item string) bool {
+	for _, x := range list {
+		if x == item {
+			return true
+		}
+	}
+	return false
+}
+
+func armored(data string) bool {
+	return strings.HasPrefix(strings.TrimSpace(data), "-----BEGIN PGP")
+}
+
+// run runs a command with stdin, returning an *exec.ExitError if it fails
+func run(e exec.Executor, stdin, name string, args ...string) error {
+	_, err := read(e, stdin, name, args...)
+	return err
+}
+
+// read runs a command with stdin and returns its stdout
+func read(e exec.Executor, stdin, name string, args ...string) (string, error) {
+	cmd := exec.NewCommand(name, args...)
+	cmd.Stdin = stdin
+
+	result, err := e.Run(cmd)
+	if err != nil {
+		return "", err
+	}
+	if !result.Success() {
+		return result.Stdout, &exec.ExitError{Command: cmd, Result: result}
+	}
+	return result.Stdout, nil
+}
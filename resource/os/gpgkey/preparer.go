@@ -0,0 +1,104 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gpgkey
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/load/registry"
+	"github.com/asteris-llc/converge/resource"
+)
+
+var fingerprintRe = regexp.MustCompile(`^([0-9A-F]{40}|[0-9A-F]{64})$`)
+
+// Preparer for GPGKey
+//
+// GPGKey imports a key into the apt or rpm keyring, or the keyring of the user
+// commands are run as. The fingerprint of the key is required, and the key is
+// verified against it before it is imported.
+type Preparer struct {
+	// Name identifies the key. apt keys are written to
+	// /etc/apt/keyrings/NAME.gpg.
+	Name string `hcl:"name" required:"true"`
+
+	// Fingerprint is the full fingerprint of the key. Spaces are ignored.
+	Fingerprint string `hcl:"fingerprint" required:"true"`
+
+	// Keyring is where the key is imported
+	Keyring string `hcl:"keyring" required:"true" valid_values:"apt,rpm,user"`
+
+	// URL to download the key from
+	URL string `hcl:"url" mutually_exclusive:"url,file,keyserver"`
+
+	// File to read the key from
+	File string `hcl:"file" mutually_exclusive:"url,file,keyserver"`
+
+	// Keyserver to fetch the key from by fingerprint, such as
+	// "keyserver.ubuntu.com"
+	Keyserver string `hcl:"keyserver" mutually_exclusive:"url,file,keyserver"`
+
+	// State is whether the key should be in the keyring
+	State State `hcl:"state" valid_values:"present,absent"`
+}
+
+// Prepare a new task
+func (p *Preparer) Prepare(render resource.Renderer) (resource.Task, error) {
+	if strings.ContainsAny(p.Name, "/ ") || strings.HasPrefix(p.Name, ".") {
+		return nil, fmt.Errorf("gpg_key \"name\" must be a file name without spaces")
+	}
+
+	fingerprint := strings.ToUpper(strings.Replace(p.Fingerprint, " ", "", -1))
+	if !fingerprintRe.MatchString(fingerprint) {
+		return nil, fmt.Errorf("gpg_key \"fingerprint\" must be a full fingerprint of 40 or 64 hex digits")
+	}
+
+	if p.State == "" {
+		p.State = StatePresent
+	}
+
+	if p.State == StatePresent && p.URL == "" && p.File == "" && p.Keyserver == "" {
+		return nil, fmt.Errorf("gpg_key requires one of \"url\", \"file\", or \"keyserver\"")
+	}
+
+	e := exec.For(render)
+	keyring, err := NewKeyring(e, p.Keyring, p.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	g := &GPGKey{
+		Fingerprint: fingerprint,
+		Keyring:     p.Keyring,
+		URL:         p.URL,
+		File:        p.File,
+		Keyserver:   p.Keyserver,
+		State:       p.State,
+		exec:        e,
+		keyring:     keyring,
+	}
+	if apt, ok := keyring.(*Apt); ok {
+		g.Path = apt.Path
+	}
+
+	return g, nil
+}
+
+func init() {
+	registry.Register("os.gpg_key", (*Preparer)(nil), (*GPGKey)(nil))
+	registry.RegisterPlatforms("os.gpg_key", "linux")
+}
@@ -0,0 +1,107 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kernelcmdline
+
+import (
+	"strings"
+)
+
+// Params is a list of kernel parameters, such as "quiet" or "audit=1"
+type Params []string
+
+// ParseParams splits a kernel command line into parameters
+func ParseParams(cmdline string) Params {
+	return Params(strings.Fields(cmdline))
+}
+
+// String joins the parameters into a command line
+func (p Params) String() string {
+	return strings.Join(p, " ")
+}
+
+// Satisfies reports whether every parameter in present is set and no
+// parameter has a key in absent
+func (p Params) Satisfies(present, absent []string) bool {
+	for _, want := range present {
+		if !p.has(want) {
+			return false
+		}
+	}
+	for _, key := range absent {
+		for _, param := range p {
+			if Key(param) == key {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Update returns the parameters with present set and absent removed. A
+// parameter with a value replaces the first parameter with the same key, and
+// any others with that key are removed. New parameters are appended.
+func (p Params) Update(present, absent []string) Params {
+	out := Params{}
+
+	remove := map[string]bool{}
+	for _, key := range absent {
+		remove[key] = true
+	}
+
+	replace := map[string]string{}
+	for _, want := range present {
+		if strings.Contains(want, "=") {
+			replace[Key(want)] = want
+		}
+	}
+
+	replaced := map[string]bool{}
+	for _, param := range p {
+		key := Key(param)
+		if remove[key] {
+			continue
+		}
+		if want, ok := replace[key]; ok {
+			if !replaced[key] {
+				out = append(out, want)
+				replaced[key] = true
+			}
+			continue
+		}
+		out = append(out, param)
+	}
+
+	for _, want := range present {
+		if !out.has(want) {
+			out = append(out, want)
+		}
+	}
+
+	return out
+}
+
+func (p Params) has(want string) bool {
+	for _, param := range p {
+		if param == want {
+			return true
+		}
+	}
+	return false
+}
+
+// Key returns the part of a parameter before "="
+func Key(param string) string {
+	return strings.SplitN(param, "=", 2)[0]
+}
@@ -0,0 +1,196 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kernelcmdline
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultPath is the location of the grub defaults file
+	DefaultPath = "/etc/default/grub"
+
+	// Mode is the mode the defaults file is written with
+	Mode = 0644
+)
+
+// KernelCmdline manages kernel parameters in the grub configuration
+type KernelCmdline struct {
+	resource.Status
+
+	Present  []string
+	Absent   []string
+	Variable string
+	Path     string
+
+	// RebootRequired is true when the running kernel was not booted with the
+	// declared parameters
+	RebootRequired bool
+
+	exec exec.Executor
+}
+
+// Check whether the defaults file has the declared parameters
+func (k *KernelCmdline) Check(resource.Renderer) (resource.TaskStatus, error) {
+	k.Status = resource.Status{}
+
+	content, err := k.read()
+	if err != nil {
+		k.RaiseLevel(resource.StatusFatal)
+		return k, err
+	}
+
+	current := ParseParams(Get(content, k.Variable))
+	if !current.Satisfies(k.Present, k.Absent) {
+		k.RaiseLevel(resource.StatusWillChange)
+		k.AddDifference(k.Variable, current.String(), current.Update(k.Present, k.Absent).String(), "")
+	}
+
+	if err := k.checkRunning(); err != nil {
+		k.RaiseLevel(resource.StatusFatal)
+		return k, err
+	}
+
+	return k, nil
+}
+
+// Apply writes the parameters and regenerates grub.cfg
+func (k *KernelCmdline) Apply() (resource.TaskStatus, error) {
+	k.Status = resource.Status{}
+
+	content, err := k.read()
+	if err != nil {
+		k.RaiseLevel(resource.StatusFatal)
+		return k, err
+	}
+
+	params := ParseParams(Get(content, k.Variable)).Update(k.Present, k.Absent)
+	if err := exec.WriteFile(k.exec, k.Path, Set(content, k.Variable, params.String()), Mode); err != nil {
+		k.RaiseLevel(resource.StatusFatal)
+		return k, errors.Wrapf(err, "cannot write %s", k.Path)
+	}
+	k.AddMessage(fmt.Sprintf("updated %s in %s", k.Variable, k.Path))
+
+	mkconfig, err := k.mkconfig()
+	if err != nil {
+		k.RaiseLevel(resource.StatusFatal)
+		return k, err
+	}
+	if err := exec.Run(k.exec, mkconfig[0], mkconfig[1:]...); err != nil {
+		k.RaiseLevel(resource.StatusFatal)
+		return k, errors.Wrap(err, "cannot regenerate grub configuration")
+	}
+	k.AddMessage("regenerated grub configuration")
+
+	if err := k.checkRunning(); err != nil {
+		k.RaiseLevel(resource.StatusFatal)
+		return k, err
+	}
+
+	return k, nil
+}
+
+// checkRunning sets RebootRequired from the command line of the running kernel
+func (k *KernelCmdline) checkRunning() error {
+	running, err := exec.Read(k.exec, "cat", "/proc/cmdline")
+	if err != nil {
+		return errors.Wrap(err, "cannot read kernel command line")
+	}
+
+	k.RebootRequired = !ParseParams(running).Satisfies(k.Present, k.Absent)
+	if k.RebootRequired {
+		k.AddMessage("reboot required for kernel parameters to take effect")
+		k.RequireReboot("kernel parameters changed")
+	}
+	return nil
+}
+
+// mkconfig returns the command that regenerates grub.cfg on this system
+func (k *KernelCmdline) mkconfig() ([]string, error) {
+	for _, candidate := range [][]string{
+		{"update-grub"},
+		{"grub2-mkconfig", "-o", "/boot/grub2/grub.cfg"},
+		{"grub-mkconfig", "-o", "/boot/grub/grub.cfg"},
+	} {
+		err := exec.Run(k.exec, "sh", "-c", `command -v "$0"`, candidate[0])
+		if err == nil {
+			return candidate, nil
+		}
+		if _, ok := exec.ExitStatus(err); !ok {
+			return nil, err
+		}
+	}
+	return nil, fmt.Errorf("could not find update-grub, grub2-mkconfig, or grub-mkconfig")
+}
+
+func (k *KernelCmdline) read() (string, error) {
+	content, exists, err := exec.ReadFile(k.exec, k.Path)
+	if err != nil {
+		return "", errors.Wrapf(err, "cannot read %s", k.Path)
+	}
+	if !exists {
+		return "", fmt.Errorf("%s does not exist", k.Path)
+	}
+	return content, nil
+}
+
+// Get returns the unquoted value of a shell variable assignment in content.
+// The last assignment wins, as it does when grub sources the file.
+func Get(content, variable string) string {
+	value := ""
+	for _, line := range strings.Split(content, "\n") {
+		if v, ok := assignment(line, variable); ok {
+			value = v
+		}
+	}
+	return value
+}
+
+// Set returns content with the variable assigned value. The last assignment
+// is replaced, or one is appended if there is none.
+func Set(content, variable, value string) string {
+	lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	if content == "" {
+		lines = nil
+	}
+
+	line := fmt.Sprintf("%s=\"%s\"", variable, value)
+	for i := len(lines) - 1; i >= 0; i-- {
+		if _, ok := assignment(lines[i], variable); ok {
+			lines[i] = line
+			return strings.Join(lines, "\n") + "\n"
+		}
+	}
+
+	return strings.Join(append(lines, line), "\n") + "\n"
+}
+
+func assignment(line, variable string) (string, bool) {
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(line, variable+"=") {
+		return "", false
+	}
+
+	value := strings.TrimPrefix(line, variable+"=")
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		value = value[1 : len(value)-1]
+	}
+	return value, true
+}
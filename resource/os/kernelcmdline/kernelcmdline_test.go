@@ -0,0 +1,169 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kernelcmdline_test
+
+import (
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/helpers/fakeexec"
+	"github.com/asteris-llc/converge/helpers/fakerenderer"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/os/kernelcmdline"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	path  = "/etc/default/grub"
+	grub  = "GRUB_DEFAULT=0\nGRUB_CMDLINE_LINUX_DEFAULT=\"quiet splash\"\nGRUB_CMDLINE_LINUX=\"console=tty0 rhgb\"\n"
+	write = exec.WriteFileScript
+)
+
+// TestKernelCmdlineInterface tests that KernelCmdline is properly implemented
+func TestKernelCmdlineInterface(t *testing.T) {
+	t.Parallel()
+
+	assert.Implements(t, (*resource.Task)(nil), new(kernelcmdline.KernelCmdline))
+	assert.Implements(t, (*resource.Resource)(nil), new(kernelcmdline.Preparer))
+}
+
+// TestParams tests updating and comparing parameters
+func TestParams(t *testing.T) {
+	t.Parallel()
+
+	params := kernelcmdline.ParseParams("console=tty0 quiet audit=0 console=ttyS0 rhgb")
+
+	t.Run("update", func(t *testing.T) {
+		updated := params.Update([]string{"audit=1", "console=ttyS0,115200", "nosmt"}, []string{"rhgb"})
+		assert.Equal(t, "console=ttyS0,115200 quiet audit=1 nosmt", updated.String())
+	})
+
+	t.Run("satisfies", func(t *testing.T) {
+		assert.True(t, params.Satisfies([]string{"quiet", "audit=0"}, []string{"nosmt"}))
+		assert.False(t, params.Satisfies([]string{"audit=1"}, nil))
+		assert.False(t, params.Satisfies(nil, []string{"rhgb"}))
+	})
+}
+
+// TestGetSet tests reading and writing the defaults file
+func TestGetSet(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "console=tty0 rhgb", kernelcmdline.Get(grub, "GRUB_CMDLINE_LINUX"))
+	assert.Equal(t, "quiet splash", kernelcmdline.Get(grub, "GRUB_CMDLINE_LINUX_DEFAULT"))
+	assert.Equal(t, "a", kernelcmdline.Get("GRUB_CMDLINE_LINUX='a'\n", "GRUB_CMDLINE_LINUX"))
+
+	assert.Equal(
+		t,
+		"GRUB_DEFAULT=0\nGRUB_CMDLINE_LINUX_DEFAULT=\"quiet splash\"\nGRUB_CMDLINE_LINUX=\"audit=1\"\n",
+		kernelcmdline.Set(grub, "GRUB_CMDLINE_LINUX", "audit=1"),
+	)
+	assert.Equal(t, "GRUB_DEFAULT=0\nGRUB_CMDLINE_LINUX=\"audit=1\"\n", kernelcmdline.Set("GRUB_DEFAULT=0\n", "GRUB_CMDLINE_LINUX", "audit=1"))
+}
+
+// TestCheck tests the possible cases Check handles
+func TestCheck(t *testing.T) {
+	t.Parallel()
+
+	t.Run("changes", func(t *testing.T) {
+		fake := reading("console=tty0 rhgb")
+
+		k := prepare(t, fake, &kernelcmdline.Preparer{Present: []string{"audit=1"}, Absent: []string{"rhgb"}})
+		status, err := k.Check(fakerenderer.New())
+
+		require.NoError(t, err)
+		assert.Equal(t, "console=tty0 rhgb", status.Diffs()["GRUB_CMDLINE_LINUX"].Original())
+		assert.Equal(t, "console=tty0 audit=1", status.Diffs()["GRUB_CMDLINE_LINUX"].Current())
+		assert.True(t, k.RebootRequired)
+	})
+
+	t.Run("pending reboot", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("test", "-e", path)
+		fake.Expect("cat", path).Return("GRUB_CMDLINE_LINUX=\"audit=1\"\n", 0)
+		fake.Expect("cat", "/proc/cmdline").Return("BOOT_IMAGE=/vmlinuz root=/dev/sda1\n", 0)
+
+		k := prepare(t, fake, &kernelcmdline.Preparer{Present: []string{"audit=1"}})
+		status, err := k.Check(fakerenderer.New())
+
+		require.NoError(t, err)
+		assert.False(t, status.HasChanges())
+		assert.True(t, k.RebootRequired)
+	})
+
+	t.Run("converged", func(t *testing.T) {
+		k := prepare(t, reading("audit=1"), &kernelcmdline.Preparer{Present: []string{"audit=1"}})
+		status, err := k.Check(fakerenderer.New())
+
+		require.NoError(t, err)
+		assert.False(t, status.HasChanges())
+		assert.False(t, k.RebootRequired)
+	})
+}
+
+// TestApply tests that Apply writes the file and regenerates grub.cfg
+func TestApply(t *testing.T) {
+	t.Parallel()
+
+	fake := fakeexec.New()
+	fake.Expect("test", "-e", path)
+	fake.Expect("cat", path).Return(grub, 0)
+	fake.Expect("sh", "-c", write, path, "0644")
+	fake.Expect("sh", "-c", `command -v "$0"`, "update-grub").Return("", 1)
+	fake.Expect("sh", "-c", `command -v "$0"`, "grub2-mkconfig").Return("/usr/sbin/grub2-mkconfig\n", 0)
+	fake.Expect("grub2-mkconfig", "-o", "/boot/grub2/grub.cfg")
+	fake.Expect("cat", "/proc/cmdline").Return("console=tty0 rhgb\n", 0)
+
+	k := prepare(t, fake, &kernelcmdline.Preparer{Present: []string{"audit=1"}})
+	status, err := k.Apply()
+
+	require.NoError(t, err)
+	fake.AssertExpectations(t)
+	assert.True(t, k.RebootRequired)
+	assert.Contains(t, status.Messages(), "reboot required for kernel parameters to take effect")
+	assert.Equal(t, []string{"kernel parameters changed"}, resource.PendingReboots(status))
+	assert.Contains(t, fake.Calls()[2].Stdin, `GRUB_CMDLINE_LINUX="console=tty0 rhgb audit=1"`)
+}
+
+// TestPrepare tests the invalid cases of Prepare
+func TestPrepare(t *testing.T) {
+	t.Parallel()
+
+	fr := fakerenderer.New()
+
+	_, err := (&kernelcmdline.Preparer{}).Prepare(fr)
+	assert.EqualError(t, err, `kernel_cmdline requires "present" or "absent"`)
+
+	_, err = (&kernelcmdline.Preparer{Present: []string{"audit=1"}, Absent: []string{"audit"}}).Prepare(fr)
+	assert.EqualError(t, err, "kernel_cmdline: audit is both present and absent")
+
+	_, err = (&kernelcmdline.Preparer{Present: []string{"a=$(reboot)"}}).Prepare(fr)
+	assert.EqualError(t, err, `kernel_cmdline: "a=$(reboot)" is not a valid parameter`)
+}
+
+func reading(cmdline string) *fakeexec.Executor {
+	fake := fakeexec.New()
+	fake.Expect("test", "-e", path)
+	fake.Expect("cat", path).Return("GRUB_CMDLINE_LINUX=\""+cmdline+"\"\n", 0)
+	fake.Expect("cat", "/proc/cmdline").Return(cmdline+"\n", 0)
+	return fake
+}
+
+func prepare(t *testing.T, fake *fakeexec.Executor, p *kernelcmdline.Preparer) *kernelcmdline.KernelCmdline {
+	task, err := p.Prepare(&resource.ExecRenderer{Renderer: fakerenderer.New(), Exec: fake})
+	require.NoError(t, err)
+	return task.(*kernelcmdline.KernelCmdline)
+}
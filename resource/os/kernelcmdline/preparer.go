@@ -0,0 +1,91 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kernelcmdline
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/load/registry"
+	"github.com/asteris-llc/converge/resource"
+)
+
+// Preparer for KernelCmdline
+//
+// KernelCmdline sets kernel parameters in the grub defaults file and
+// regenerates grub.cfg when they change. The parameters only take effect on
+// the next boot, so RebootRequired is set and a reboot is requested from any
+// dependent os.reboot when the running kernel was booted without them.
+type Preparer struct {
+	// Present are parameters that should be set, such as "audit=1" or "quiet".
+	// A parameter with a value replaces any other value for the same key.
+	Present []string `hcl:"present"`
+
+	// Absent are keys of parameters that should not be set, such as "rhgb"
+	Absent []string `hcl:"absent"`
+
+	// Variable is the variable in the defaults file that holds the parameters.
+	// It defaults to GRUB_CMDLINE_LINUX, which applies to all boot entries.
+	Variable string `hcl:"variable" valid_values:"GRUB_CMDLINE_LINUX,GRUB_CMDLINE_LINUX_DEFAULT"`
+
+	// Path is the grub defaults file. It defaults to /etc/default/grub.
+	Path string `hcl:"path"`
+}
+
+// Prepare a new task
+func (p *Preparer) Prepare(render resource.Renderer) (resource.Task, error) {
+	if len(p.Present) == 0 && len(p.Absent) == 0 {
+		return nil, fmt.Errorf("kernel_cmdline requires \"present\" or \"absent\"")
+	}
+
+	for _, param := range append(append([]string{}, p.Present...), p.Absent...) {
+		if param == "" || strings.ContainsAny(param, " \t\n\"'$`\\") {
+			return nil, fmt.Errorf("kernel_cmdline: %q is not a valid parameter", param)
+		}
+	}
+
+	for _, key := range p.Absent {
+		if strings.Contains(key, "=") {
+			return nil, fmt.Errorf("kernel_cmdline \"absent\" takes keys, not %q", key)
+		}
+		for _, param := range p.Present {
+			if Key(param) == key {
+				return nil, fmt.Errorf("kernel_cmdline: %s is both present and absent", key)
+			}
+		}
+	}
+
+	if p.Variable == "" {
+		p.Variable = "GRUB_CMDLINE_LINUX"
+	}
+
+	if p.Path == "" {
+		p.Path = DefaultPath
+	}
+
+	return &KernelCmdline{
+		Present:  p.Present,
+		Absent:   p.Absent,
+		Variable: p.Variable,
+		Path:     p.Path,
+		exec:     exec.For(render),
+	}, nil
+}
+
+func init() {
+	registry.Register("os.kernel_cmdline", (*Preparer)(nil), (*KernelCmdline)(nil))
+	registry.RegisterPlatforms("os.kernel_cmdline", "linux")
+}
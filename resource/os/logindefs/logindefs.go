@@ -0,0 +1,167 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logindefs
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultPath is the location of login.defs
+	DefaultPath = "/etc/login.defs"
+
+	// Mode is the mode the file is written with
+	Mode = 0644
+)
+
+// LoginDefs manages keys in login.defs
+type LoginDefs struct {
+	resource.Status
+
+	Settings map[string]string
+	Path     string
+
+	exec exec.Executor
+}
+
+// Check whether every key has the declared value
+func (l *LoginDefs) Check(resource.Renderer) (resource.TaskStatus, error) {
+	l.Status = resource.Status{}
+
+	content, err := l.read()
+	if err != nil {
+		l.RaiseLevel(resource.StatusFatal)
+		return l, err
+	}
+
+	current := Parse(content)
+	for _, key := range l.keys() {
+		value, ok := current[key]
+		if !ok {
+			value = "<unset>"
+		}
+
+		if value != l.Settings[key] {
+			l.RaiseLevel(resource.StatusWillChange)
+			l.AddDifference(key, value, l.Settings[key], "")
+		}
+	}
+
+	return l, nil
+}
+
+// Apply writes the declared keys to the file
+func (l *LoginDefs) Apply() (resource.TaskStatus, error) {
+	l.Status = resource.Status{}
+
+	content, err := l.read()
+	if err != nil {
+		l.RaiseLevel(resource.StatusFatal)
+		return l, err
+	}
+
+	updated := Set(content, l.Settings)
+	if err := exec.WriteFile(l.exec, l.Path, updated, Mode); err != nil {
+		l.RaiseLevel(resource.StatusFatal)
+		return l, errors.Wrapf(err, "cannot write %s", l.Path)
+	}
+
+	l.AddMessage(fmt.Sprintf("updated %s", l.Path))
+	return l, nil
+}
+
+func (l *LoginDefs) read() (string, error) {
+	content, exists, err := exec.ReadFile(l.exec, l.Path)
+	if err != nil {
+		return "", errors.Wrapf(err, "cannot read %s", l.Path)
+	}
+	if !exists {
+		return "", fmt.Errorf("%s does not exist", l.Path)
+	}
+	return content, nil
+}
+
+func (l *LoginDefs) keys() []string {
+	var keys []string
+	for key := range l.Settings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Parse returns the keys set in the content of a login.defs file. When a key
+// is set more than once the last value wins, as it does for the shadow tools.
+func Parse(content string) map[string]string {
+	out := map[string]string{}
+	for _, line := range strings.Split(content, "\n") {
+		if key, value, ok := parseLine(line); ok {
+			out[key] = value
+		}
+	}
+	return out
+}
+
+// Set returns content with the given keys set. Existing lines for a key are
+// updated in place, and keys that are not in the file are appended.
+func Set(content string, settings map[string]string) string {
+	lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	if content == "" {
+		lines = nil
+	}
+
+	seen := map[string]bool{}
+	for i, line := range lines {
+		key, _, ok := parseLine(line)
+		if !ok {
+			continue
+		}
+		if value, declared := settings[key]; declared {
+			lines[i] = key + "\t" + value
+			seen[key] = true
+		}
+	}
+
+	var missing []string
+	for key := range settings {
+		if !seen[key] {
+			missing = append(missing, key)
+		}
+	}
+	sort.Strings(missing)
+
+	for _, key := range missing {
+		lines = append(lines, key+"\t"+settings[key])
+	}
+
+	return strings.Join(lines, "\n") + "\n"
+}
+
+func parseLine(line string) (key, value string, ok bool) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+		return "", "", false
+	}
+	if len(fields) == 1 {
+		return fields[0], "", true
+	}
+	return fields[0], strings.Join(fields[1:], " "), true
+}
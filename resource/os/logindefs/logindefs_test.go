@@ -0,0 +1,132 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logindefs_test
+
+import (
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/helpers/fakeexec"
+	"github.com/asteris-llc/converge/helpers/fakerenderer"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/os/logindefs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const defs = `# Password aging controls:
+PASS_MAX_DAYS	99999
+PASS_MIN_DAYS	0
+#UID_MIN		1000
+`
+
+// TestLoginDefsInterface tests that LoginDefs is properly implemented
+func TestLoginDefsInterface(t *testing.T) {
+	t.Parallel()
+
+	assert.Implements(t, (*resource.Task)(nil), new(logindefs.LoginDefs))
+	assert.Implements(t, (*resource.Resource)(nil), new(logindefs.Preparer))
+}
+
+// TestParse tests reading keys from a file
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(
+		t,
+		map[string]string{"PASS_MAX_DAYS": "99999", "PASS_MIN_DAYS": "0"},
+		logindefs.Parse(defs),
+	)
+}
+
+// TestSet tests updating and appending keys
+func TestSet(t *testing.T) {
+	t.Parallel()
+
+	out := logindefs.Set(defs, map[string]string{"PASS_MAX_DAYS": "90", "UID_MIN": "2000"})
+	assert.Equal(t, `# Password aging controls:
+PASS_MAX_DAYS	90
+PASS_MIN_DAYS	0
+#UID_MIN		1000
+UID_MIN	2000
+`, out)
+}
+
+// TestCheck tests the possible cases Check handles
+func TestCheck(t *testing.T) {
+	t.Parallel()
+
+	t.Run("changes", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("test", "-e", logindefs.DefaultPath)
+		fake.Expect("cat", logindefs.DefaultPath).Return(defs, 0)
+
+		l := prepare(t, fake, map[string]string{"PASS_MAX_DAYS": "90", "PASS_MIN_DAYS": "0", "UID_MIN": "2000"})
+		status, err := l.Check(fakerenderer.New())
+
+		require.NoError(t, err)
+		assert.True(t, status.HasChanges())
+		assert.Equal(t, "99999", status.Diffs()["PASS_MAX_DAYS"].Original())
+		assert.Equal(t, "<unset>", status.Diffs()["UID_MIN"].Original())
+		assert.NotContains(t, status.Diffs(), "PASS_MIN_DAYS")
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("test", "-e", logindefs.DefaultPath).Return("", 1)
+
+		l := prepare(t, fake, map[string]string{"UID_MIN": "2000"})
+		status, err := l.Check(fakerenderer.New())
+
+		assert.EqualError(t, err, "/etc/login.defs does not exist")
+		assert.Equal(t, resource.StatusFatal, status.StatusCode())
+	})
+}
+
+// TestApply tests that Apply writes the updated file
+func TestApply(t *testing.T) {
+	t.Parallel()
+
+	fake := fakeexec.New()
+	fake.Expect("test", "-e", logindefs.DefaultPath)
+	fake.Expect("cat", logindefs.DefaultPath).Return("PASS_MAX_DAYS 99999\n", 0)
+	fake.Expect("sh", "-c", exec.WriteFileScript, logindefs.DefaultPath, "0644")
+
+	l := prepare(t, fake, map[string]string{"PASS_MAX_DAYS": "90"})
+	_, err := l.Apply()
+
+	require.NoError(t, err)
+	fake.AssertExpectations(t)
+	calls := fake.Calls()
+	assert.Equal(t, "PASS_MAX_DAYS\t90\n", calls[len(calls)-1].Stdin)
+}
+
+// TestPrepare tests the valid and invalid cases of Prepare
+func TestPrepare(t *testing.T) {
+	t.Parallel()
+
+	_, err := (&logindefs.Preparer{Settings: map[string]string{"UID MIN": "1"}}).Prepare(fakerenderer.New())
+	assert.EqualError(t, err, `logindefs: "UID MIN" is not a valid key`)
+
+	_, err = (&logindefs.Preparer{Settings: map[string]string{"UID_MIN": ""}}).Prepare(fakerenderer.New())
+	assert.EqualError(t, err, `logindefs: "UID_MIN" must have a single-line value`)
+}
+
+func prepare(t *testing.T, fake *fakeexec.Executor, settings map[string]string) *logindefs.LoginDefs {
+	p := &logindefs.Preparer{Settings: settings}
+	task, err := p.Prepare(&resource.ExecRenderer{Renderer: fakerenderer.New(), Exec: fake})
+	require.NoError(t, err)
+	return task.(*logindefs.LoginDefs)
+}
@@ -0,0 +1,63 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logindefs
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/load/registry"
+	"github.com/asteris-llc/converge/resource"
+)
+
+// Preparer for LoginDefs
+//
+// LoginDefs sets keys in /etc/login.defs, such as UID_MIN or PASS_MAX_DAYS.
+// Keys that are not declared are left alone.
+type Preparer struct {
+	// Settings maps keys to the values they should have
+	Settings map[string]string `hcl:"settings" required:"true"`
+
+	// Path is the file to manage. It defaults to /etc/login.defs.
+	Path string `hcl:"path"`
+}
+
+// Prepare a new task
+func (p *Preparer) Prepare(render resource.Renderer) (resource.Task, error) {
+	for key, value := range p.Settings {
+		if key == "" || strings.ContainsAny(key, " \t\n#") {
+			return nil, fmt.Errorf("logindefs: %q is not a valid key", key)
+		}
+		if value == "" || strings.ContainsAny(value, "\n") {
+			return nil, fmt.Errorf("logindefs: %q must have a single-line value", key)
+		}
+	}
+
+	if p.Path == "" {
+		p.Path = DefaultPath
+	}
+
+	return &LoginDefs{
+		Settings: p.Settings,
+		Path:     p.Path,
+		exec:     exec.For(render),
+	}, nil
+}
+
+func init() {
+	registry.Register("os.logindefs", (*Preparer)(nil), (*LoginDefs)(nil))
+	registry.RegisterPlatforms("os.logindefs", "linux")
+}
@@ -0,0 +1,160 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logrotate
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/pkg/errors"
+)
+
+// State type for LogRotate
+type State string
+
+const (
+	// StatePresent indicates the entry should be present
+	StatePresent State = "present"
+
+	// StateAbsent indicates the entry should be absent
+	StateAbsent State = "absent"
+
+	// Dir is the directory entries are written to
+	Dir = "/etc/logrotate.d"
+
+	// Mode is the mode entries are written with. logrotate refuses to read
+	// entries that are writable by group or others.
+	Mode = 0644
+)
+
+// LogRotate manages a logrotate.d entry
+type LogRotate struct {
+	resource.Status
+
+	Path    string
+	Content string
+	State   State
+
+	exec exec.Executor
+}
+
+// Check whether the entry has the rendered content
+func (l *LogRotate) Check(resource.Renderer) (resource.TaskStatus, error) {
+	l.Status = resource.Status{}
+
+	current, exists, err := exec.ReadFile(l.exec, l.Path)
+	if err != nil {
+		l.RaiseLevel(resource.StatusFatal)
+		return l, errors.Wrapf(err, "cannot read %s", l.Path)
+	}
+	if !exists {
+		current = "<file-missing>"
+	}
+
+	switch l.State {
+	case StatePresent:
+		if !exists || current != l.Content {
+			l.RaiseLevel(resource.StatusWillChange)
+			l.AddDifference(l.Path, current, l.Content, "")
+		}
+
+	case StateAbsent:
+		if exists {
+			l.RaiseLevel(resource.StatusWillChange)
+			l.AddDifference(l.Path, current, "<file-missing>", "")
+		}
+
+	default:
+		l.RaiseLevel(resource.StatusFatal)
+		return l, fmt.Errorf("logrotate: unrecognized state %s", l.State)
+	}
+
+	return l, nil
+}
+
+// Apply installs or removes the entry
+func (l *LogRotate) Apply() (resource.TaskStatus, error) {
+	l.Status = resource.Status{}
+
+	if l.State == StateAbsent {
+		if err := exec.Run(l.exec, "rm", "-f", l.Path); err != nil {
+			l.RaiseLevel(resource.StatusFatal)
+			return l, errors.Wrapf(err, "cannot remove %s", l.Path)
+		}
+		l.AddMessage(fmt.Sprintf("removed %s", l.Path))
+		return l, nil
+	}
+
+	if err := l.install(); err != nil {
+		l.RaiseLevel(resource.StatusFatal)
+		return l, err
+	}
+
+	l.AddMessage(fmt.Sprintf("installed %s", l.Path))
+	return l, nil
+}
+
+// ManagedFiles returns the path of the logrotate config, so it is backed up before being
+// changed
+func (l *LogRotate) ManagedFiles() []string {
+	return []string{l.Path}
+}
+
+// Executor returns the executor the file is written with
+func (l *LogRotate) Executor() exec.Executor {
+	return l.exec
+}
+
+// install checks the entry and moves it into place. The entry is staged with
+// a name ending in "~" so that a logrotate run in the meantime skips it.
+func (l *LogRotate) install() error {
+	staged := l.Path + ".converge~"
+
+	if err := exec.WriteFile(l.exec, staged, l.Content, Mode); err != nil {
+		return errors.Wrapf(err, "cannot write %s", staged)
+	}
+
+	// -d is a dry run, so nothing is rotated
+	if err := exec.Run(l.exec, "logrotate", "-d", staged); err != nil {
+		if rmErr := exec.Run(l.exec, "rm", "-f", staged); rmErr != nil {
+			l.AddMessage(fmt.Sprintf("could not remove %s: %s", staged, rmErr))
+		}
+		return errors.Wrapf(err, "refusing to install invalid entry %s", l.Path)
+	}
+
+	if err := exec.Run(l.exec, "mv", "-f", staged, l.Path); err != nil {
+		return errors.Wrapf(err, "cannot install %s", l.Path)
+	}
+	return nil
+}
+
+func entry(paths, options []string, postrotate string) string {
+	lines := []string{strings.Join(paths, " ") + " {"}
+	for _, option := range options {
+		lines = append(lines, "\t"+option)
+	}
+
+	if script := strings.TrimSpace(postrotate); script != "" {
+		lines = append(lines, "\tsharedscripts", "\tpostrotate")
+		for _, line := range strings.Split(script, "\n") {
+			lines = append(lines, "\t\t"+strings.TrimSpace(line))
+		}
+		lines = append(lines, "\tendscript")
+	}
+
+	return strings.Join(append(lines, "}"), "\n") + "\n"
+}
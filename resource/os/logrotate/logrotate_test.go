@@ -0,0 +1,165 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logrotate_test
+
+import (
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/helpers/fakeexec"
+	"github.com/asteris-llc/converge/helpers/fakerenderer"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/os/logrotate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	path   = "/etc/logrotate.d/app"
+	staged = "/etc/logrotate.d/app.converge~"
+	write  = exec.WriteFileScript
+)
+
+// TestLogRotateInterface tests that LogRotate is properly implemented
+func TestLogRotateInterface(t *testing.T) {
+	t.Parallel()
+
+	assert.Implements(t, (*resource.Task)(nil), new(logrotate.LogRotate))
+	assert.Implements(t, (*resource.Resource)(nil), new(logrotate.Preparer))
+}
+
+// TestPrepare tests rendering entries and the invalid cases of Prepare
+func TestPrepare(t *testing.T) {
+	t.Parallel()
+
+	fr := fakerenderer.New()
+
+	t.Run("defaults", func(t *testing.T) {
+		l := prepare(t, fakeexec.New(), &logrotate.Preparer{Name: "app", Paths: []string{"/var/log/app/*.log"}})
+		assert.Equal(t, "/var/log/app/*.log {\n\tweekly\n\trotate 4\n}\n", l.Content)
+	})
+
+	t.Run("all fields", func(t *testing.T) {
+		rotate := 14
+		l := prepare(t, fakeexec.New(), &logrotate.Preparer{
+			Name:          "app",
+			Paths:         []string{"/var/log/app/access.log", "/var/log/app/error.log"},
+			Frequency:     "daily",
+			Rotate:        &rotate,
+			Compress:      true,
+			DelayCompress: true,
+			MissingOK:     true,
+			NotIfEmpty:    true,
+			MaxSize:       "100M",
+			Create:        "0640 app adm",
+			PostRotate:    "systemctl reload app\n",
+		})
+		assert.Equal(t, `/var/log/app/access.log /var/log/app/error.log {
+	daily
+	rotate 14
+	compress
+	delaycompress
+	missingok
+	notifempty
+	maxsize 100M
+	create 0640 app adm
+	sharedscripts
+	postrotate
+		systemctl reload app
+	endscript
+}
+`, l.Content)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := (&logrotate.Preparer{Name: "app"}).Prepare(fr)
+		assert.EqualError(t, err, `logrotate "paths" is required when state is "present"`)
+
+		_, err = (&logrotate.Preparer{Name: "app", Paths: []string{"app.log"}}).Prepare(fr)
+		assert.EqualError(t, err, `logrotate: "app.log" must be an absolute path without spaces`)
+
+		_, err = (&logrotate.Preparer{Name: "app.conf", Paths: []string{"/var/log/app.log"}}).Prepare(fr)
+		assert.Error(t, err)
+
+		_, err = (&logrotate.Preparer{Name: "app", Paths: []string{"/var/log/app.log"}, PostRotate: "endscript\nrm -rf /"}).Prepare(fr)
+		assert.EqualError(t, err, `logrotate "postrotate" cannot contain "endscript"`)
+	})
+}
+
+// TestCheck tests the possible cases Check handles
+func TestCheck(t *testing.T) {
+	t.Parallel()
+
+	t.Run("missing", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("test", "-e", path).Return("", 1)
+
+		l := prepare(t, fake, &logrotate.Preparer{Name: "app", Paths: []string{"/var/log/app.log"}})
+		status, err := l.Check(fakerenderer.New())
+
+		require.NoError(t, err)
+		assert.Equal(t, "<file-missing>", status.Diffs()[path].Original())
+	})
+
+	t.Run("absent", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("test", "-e", path).Return("", 1)
+
+		l := prepare(t, fake, &logrotate.Preparer{Name: "app", State: logrotate.StateAbsent})
+		status, err := l.Check(fakerenderer.New())
+
+		require.NoError(t, err)
+		assert.False(t, status.HasChanges())
+	})
+}
+
+// TestApply tests the possible cases Apply handles
+func TestApply(t *testing.T) {
+	t.Parallel()
+
+	t.Run("valid", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("sh", "-c", write, staged, "0644")
+		fake.Expect("logrotate", "-d", staged)
+		fake.Expect("mv", "-f", staged, path)
+
+		l := prepare(t, fake, &logrotate.Preparer{Name: "app", Paths: []string{"/var/log/app.log"}})
+		_, err := l.Apply()
+
+		require.NoError(t, err)
+		fake.AssertExpectations(t)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("sh", "-c", write, staged, "0644")
+		fake.Expect("logrotate", "-d", staged).Stderr("error: app.converge~:3 unknown option").Return("", 1)
+		fake.Expect("rm", "-f", staged)
+
+		l := prepare(t, fake, &logrotate.Preparer{Name: "app", Paths: []string{"/var/log/app.log"}})
+		status, err := l.Apply()
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unknown option")
+		assert.Equal(t, resource.StatusFatal, status.StatusCode())
+		fake.AssertExpectations(t)
+	})
+}
+
+func prepare(t *testing.T, fake *fakeexec.Executor, p *logrotate.Preparer) *logrotate.LogRotate {
+	task, err := p.Prepare(&resource.ExecRenderer{Renderer: fakerenderer.New(), Exec: fake})
+	require.NoError(t, err)
+	return task.(*logrotate.LogRotate)
+}
@@ -0,0 +1,145 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logrotate
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/load/registry"
+	"github.com/asteris-llc/converge/resource"
+)
+
+// Preparer for LogRotate
+//
+// LogRotate writes an entry to /etc/logrotate.d for an application's logs.
+// The entry is checked with `logrotate -d` before it is installed.
+type Preparer struct {
+	// Name is the name of the file in /etc/logrotate.d
+	Name string `hcl:"name" required:"true"`
+
+	// Paths are the log files to rotate. They may contain globs.
+	Paths []string `hcl:"paths"`
+
+	// Frequency is how often logs are rotated. It defaults to weekly.
+	Frequency string `hcl:"frequency" valid_values:"daily,weekly,monthly,yearly"`
+
+	// Rotate is the number of rotated logs to keep. It defaults to 4.
+	Rotate *int `hcl:"rotate"`
+
+	// Compress compresses rotated logs
+	Compress bool `hcl:"compress"`
+
+	// DelayCompress leaves the most recently rotated log uncompressed
+	DelayCompress bool `hcl:"delaycompress"`
+
+	// MissingOK ignores logs that do not exist
+	MissingOK bool `hcl:"missingok"`
+
+	// NotIfEmpty does not rotate empty logs
+	NotIfEmpty bool `hcl:"notifempty"`
+
+	// MaxSize rotates logs that grow past this size before the next rotation,
+	// such as "100M"
+	MaxSize string `hcl:"maxsize"`
+
+	// Create is the mode, owner, and group of the new log, such as
+	// "0640 app adm"
+	Create string `hcl:"create"`
+
+	// PostRotate is a script run after logs are rotated, such as a command that
+	// makes the application reopen its logs. It is run once for all paths.
+	PostRotate string `hcl:"postrotate"`
+
+	// State is whether the entry should be present
+	State State `hcl:"state" valid_values:"present,absent"`
+}
+
+// Prepare a new task
+func (p *Preparer) Prepare(render resource.Renderer) (resource.Task, error) {
+	if strings.ContainsAny(p.Name, "/.") || strings.HasSuffix(p.Name, "~") {
+		return nil, fmt.Errorf("logrotate \"name\" cannot contain \"/\" or \".\" or end in \"~\"")
+	}
+
+	if p.State == "" {
+		p.State = StatePresent
+	}
+
+	l := &LogRotate{
+		Path:  path.Join(Dir, p.Name),
+		State: p.State,
+		exec:  exec.For(render),
+	}
+
+	if p.State == StateAbsent {
+		return l, nil
+	}
+
+	if len(p.Paths) == 0 {
+		return nil, fmt.Errorf("logrotate \"paths\" is required when state is %q", StatePresent)
+	}
+	for _, log := range p.Paths {
+		if !path.IsAbs(log) || strings.ContainsAny(log, " \n{}") {
+			return nil, fmt.Errorf("logrotate: %q must be an absolute path without spaces", log)
+		}
+	}
+
+	if p.Frequency == "" {
+		p.Frequency = "weekly"
+	}
+
+	rotate := 4
+	if p.Rotate != nil {
+		rotate = *p.Rotate
+	}
+	if rotate < 0 {
+		return nil, fmt.Errorf("logrotate \"rotate\" cannot be negative")
+	}
+
+	if strings.Contains(p.PostRotate, "endscript") {
+		return nil, fmt.Errorf("logrotate \"postrotate\" cannot contain \"endscript\"")
+	}
+
+	options := []string{p.Frequency, fmt.Sprintf("rotate %d", rotate)}
+	for _, flag := range []struct {
+		set  bool
+		name string
+	}{
+		{p.Compress, "compress"},
+		{p.DelayCompress, "delaycompress"},
+		{p.MissingOK, "missingok"},
+		{p.NotIfEmpty, "notifempty"},
+	} {
+		if flag.set {
+			options = append(options, flag.name)
+		}
+	}
+	if p.MaxSize != "" {
+		options = append(options, "maxsize "+p.MaxSize)
+	}
+	if p.Create != "" {
+		options = append(options, "create "+p.Create)
+	}
+
+	l.Content = entry(p.Paths, options, p.PostRotate)
+	return l, nil
+}
+
+func init() {
+	registry.Register("os.logrotate", (*Preparer)(nil), (*LogRotate)(nil))
+	registry.RegisterPlatforms("os.logrotate", "linux")
+}
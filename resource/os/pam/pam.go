@@ -0,0 +1,345 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pam
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/pkg/errors"
+)
+
+// State type for PAM
+type State string
+
+const (
+	// StatePresent indicates the line should be present
+	StatePresent State = "present"
+
+	// StateAbsent indicates the line should be absent
+	StateAbsent State = "absent"
+
+	// Dir is the directory service files are read from
+	Dir = "/etc/pam.d"
+
+	// Mode is the mode service files are written with
+	Mode = 0644
+)
+
+// PAM manages a module line in a PAM service file
+type PAM struct {
+	resource.Status
+
+	Path   string
+	Entry  Entry
+	Before string
+	After  string
+	State  State
+
+	exec exec.Executor
+}
+
+// Check whether the line is present and in the right place
+func (p *PAM) Check(resource.Renderer) (resource.TaskStatus, error) {
+	p.Status = resource.Status{}
+
+	lines, err := p.read()
+	if err != nil {
+		p.RaiseLevel(resource.StatusFatal)
+		return p, err
+	}
+
+	matches := p.matches(lines)
+
+	switch p.State {
+	case StatePresent:
+		if len(matches) == 0 {
+			p.RaiseLevel(resource.StatusWillChange)
+			p.AddDifference(p.Entry.ID(), "<absent>", p.Entry.String(), "")
+			return p, nil
+		}
+
+		if len(matches) > 1 {
+			p.RaiseLevel(resource.StatusWillChange)
+			p.AddDifference(p.Entry.ID(), fmt.Sprintf("%d lines", len(matches)), "1 line", "")
+		}
+
+		if current := lines[matches[0]]; !current.Entry.Equal(p.Entry) {
+			p.RaiseLevel(resource.StatusWillChange)
+			p.AddDifference(p.Entry.ID(), current.Entry.String(), p.Entry.String(), "")
+		}
+
+		if !p.inPlace(lines, matches[0]) {
+			p.RaiseLevel(resource.StatusWillChange)
+			p.AddDifference("position", "", p.position(), "")
+		}
+
+	case StateAbsent:
+		if len(matches) > 0 {
+			p.RaiseLevel(resource.StatusWillChange)
+			p.AddDifference(p.Entry.ID(), lines[matches[0]].Entry.String(), "<absent>", "")
+		}
+
+	default:
+		p.RaiseLevel(resource.StatusFatal)
+		return p, fmt.Errorf("pam: unrecognized state %s", p.State)
+	}
+
+	return p, nil
+}
+
+// Apply writes the file with the line added, updated, moved, or removed
+func (p *PAM) Apply() (resource.TaskStatus, error) {
+	p.Status = resource.Status{}
+
+	lines, err := p.read()
+	if err != nil {
+		p.RaiseLevel(resource.StatusFatal)
+		return p, err
+	}
+
+	lines = p.update(lines)
+	if err := exec.WriteFile(p.exec, p.Path, Format(lines), Mode); err != nil {
+		p.RaiseLevel(resource.StatusFatal)
+		return p, errors.Wrapf(err, "cannot write %s", p.Path)
+	}
+
+	p.AddMessage(fmt.Sprintf("updated %s %s", p.Path, p.Entry.ID()))
+	return p, nil
+}
+
+func (p *PAM) read() ([]Line, error) {
+	content, exists, err := exec.ReadFile(p.exec, p.Path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot read %s", p.Path)
+	}
+	if !exists {
+		return nil, fmt.Errorf("%s does not exist", p.Path)
+	}
+	return Parse(content), nil
+}
+
+// update returns lines with the entry reconciled. A single existing line in
+// the right place is rewritten in place; otherwise every matching line is
+// removed and the entry is inserted where it belongs.
+func (p *PAM) update(lines []Line) []Line {
+	matches := p.matches(lines)
+
+	if p.State == StateAbsent {
+		return remove(lines, matches)
+	}
+
+	if len(matches) == 1 && p.inPlace(lines, matches[0]) {
+		lines[matches[0]] = Line{Entry: p.Entry, rule: true}
+		return lines
+	}
+
+	at := -1
+	if len(matches) > 0 {
+		at = matches[0]
+	}
+	lines = remove(lines, matches)
+
+	if i := p.index(lines, p.Before, false); i >= 0 {
+		at = i
+	} else if i := p.index(lines, p.After, true); i >= 0 {
+		at = i + 1
+	} else if at < 0 {
+		at = len(lines)
+		if i := p.lastOfType(lines); i >= 0 {
+			at = i + 1
+		}
+	}
+
+	out := append([]Line{}, lines[:at]...)
+	out = append(out, Line{Entry: p.Entry, rule: true})
+	return append(out, lines[at:]...)
+}
+
+// matches returns the indexes of lines for the same type and module
+func (p *PAM) matches(lines []Line) []int {
+	var out []int
+	for i, line := range lines {
+		if line.rule && line.Entry.Type == p.Entry.Type && line.Entry.Module == p.Entry.Module {
+			out = append(out, i)
+		}
+	}
+	return out
+}
+
+// inPlace reports whether the line at i satisfies the ordering. A module
+// named in before or after that is not in the stack is not a constraint.
+func (p *PAM) inPlace(lines []Line, i int) bool {
+	if before := p.index(lines, p.Before, false); before >= 0 && i > before {
+		return false
+	}
+	if after := p.index(lines, p.After, true); after >= 0 && i < after {
+		return false
+	}
+	return true
+}
+
+// index returns the index of the first (or last) line in the same stack for
+// module, or -1
+func (p *PAM) index(lines []Line, module string, last bool) int {
+	found := -1
+	if module == "" {
+		return found
+	}
+	for i, line := range lines {
+		if line.rule && line.Entry.Type == p.Entry.Type && line.Entry.Module == module {
+			found = i
+			if !last {
+				break
+			}
+		}
+	}
+	return found
+}
+
+func (p *PAM) lastOfType(lines []Line) int {
+	found := -1
+	for i, line := range lines {
+		if line.rule && line.Entry.Type == p.Entry.Type {
+			found = i
+		}
+	}
+	return found
+}
+
+func (p *PAM) position() string {
+	switch {
+	case p.Before != "":
+		return "before " + p.Before
+	case p.After != "":
+		return "after " + p.After
+	}
+	return ""
+}
+
+func remove(lines []Line, indexes []int) []Line {
+	skip := map[int]bool{}
+	for _, i := range indexes {
+		skip[i] = true
+	}
+
+	var out []Line
+	for i, line := range lines {
+		if !skip[i] {
+			out = append(out, line)
+		}
+	}
+	return out
+}
+
+// Entry is a module line in a PAM service file
+type Entry struct {
+	Type      string
+	Control   string
+	Module    string
+	Arguments []string
+}
+
+// ID identifies the entry within a file
+func (e Entry) ID() string {
+	return e.Type + " " + e.Module
+}
+
+// Equal reports whether two entries have the same fields
+func (e Entry) Equal(other Entry) bool {
+	return e.Type == other.Type &&
+		e.Control == other.Control &&
+		e.Module == other.Module &&
+		strings.Join(e.Arguments, " ") == strings.Join(other.Arguments, " ")
+}
+
+func (e Entry) String() string {
+	line := e.Type + "\t" + e.Control + "\t" + e.Module
+	if len(e.Arguments) > 0 {
+		line += " " + strings.Join(e.Arguments, " ")
+	}
+	return line
+}
+
+// Line is a line of a PAM service file. Lines that are not module lines, such
+// as comments and @include directives, are kept as they are.
+type Line struct {
+	Entry Entry
+
+	raw  string
+	rule bool
+}
+
+// Parse splits the content of a service file into lines
+func Parse(content string) []Line {
+	if content == "" {
+		return nil
+	}
+
+	var out []Line
+	for _, raw := range strings.Split(strings.TrimSuffix(content, "\n"), "\n") {
+		out = append(out, parseLine(raw))
+	}
+	return out
+}
+
+// Format joins lines back into the content of a service file
+func Format(lines []Line) string {
+	var out []string
+	for _, line := range lines {
+		if line.rule && line.raw == "" {
+			out = append(out, line.Entry.String())
+		} else {
+			out = append(out, line.raw)
+		}
+	}
+	return strings.Join(out, "\n") + "\n"
+}
+
+func parseLine(raw string) Line {
+	fields := strings.Fields(raw)
+	if len(fields) < 3 || strings.HasPrefix(fields[0], "#") || strings.HasPrefix(fields[0], "@") {
+		return Line{raw: raw}
+	}
+
+	// a leading "-" only suppresses logging when the module is missing
+	entry := Entry{Type: strings.TrimPrefix(fields[0], "-")}
+	rest := fields[1:]
+
+	if strings.HasPrefix(rest[0], "[") {
+		// complex controls contain spaces: [success=1 default=ignore]
+		end := 0
+		for end < len(rest) && !strings.HasSuffix(rest[end], "]") {
+			end++
+		}
+		if end >= len(rest)-1 {
+			return Line{raw: raw}
+		}
+		entry.Control = strings.Join(rest[:end+1], " ")
+		rest = rest[end+1:]
+	} else {
+		entry.Control = rest[0]
+		rest = rest[1:]
+	}
+
+	entry.Module = rest[0]
+	if len(rest) > 1 {
+		entry.Arguments = rest[1:]
+	}
+
+	return Line{Entry: entry, raw: raw, rule: true}
+}
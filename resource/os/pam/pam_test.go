@@ -0,0 +1,209 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pam_test
+
+import (
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/helpers/fakeexec"
+	"github.com/asteris-llc/converge/helpers/fakerenderer"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/os/pam"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	path    = "/etc/pam.d/common-password"
+	service = `# here are the per-package modules
+password	[success=1 default=ignore]	pam_unix.so obscure sha512
+password	requisite	pam_deny.so
+password	required	pam_permit.so
+@include common-session
+`
+)
+
+// TestPAMInterface tests that PAM is properly implemented
+func TestPAMInterface(t *testing.T) {
+	t.Parallel()
+
+	assert.Implements(t, (*resource.Task)(nil), new(pam.PAM))
+	assert.Implements(t, (*resource.Resource)(nil), new(pam.Preparer))
+}
+
+// TestParse tests parsing and formatting service files
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	lines := pam.Parse(service)
+	require.Len(t, lines, 5)
+	assert.Equal(
+		t,
+		pam.Entry{Type: "password", Control: "[success=1 default=ignore]", Module: "pam_unix.so", Arguments: []string{"obscure", "sha512"}},
+		lines[1].Entry,
+	)
+	assert.Equal(t, service, pam.Format(lines))
+}
+
+// TestCheck tests the possible cases Check handles
+func TestCheck(t *testing.T) {
+	t.Parallel()
+
+	t.Run("missing", func(t *testing.T) {
+		p := prepare(t, service, &pam.Preparer{Control: "requisite", Module: "pam_pwquality.so", Before: "pam_unix.so"})
+		status, err := p.Check(fakerenderer.New())
+
+		require.NoError(t, err)
+		assert.True(t, status.HasChanges())
+		assert.Equal(t, "<absent>", status.Diffs()["password pam_pwquality.so"].Original())
+	})
+
+	t.Run("converged", func(t *testing.T) {
+		p := prepare(t, service, &pam.Preparer{Control: "requisite", Module: "pam_deny.so", After: "pam_unix.so"})
+		status, err := p.Check(fakerenderer.New())
+
+		require.NoError(t, err)
+		assert.False(t, status.HasChanges())
+	})
+
+	t.Run("different arguments", func(t *testing.T) {
+		p := prepare(t, service, &pam.Preparer{Control: "[success=1 default=ignore]", Module: "pam_unix.so", Arguments: []string{"sha512"}})
+		status, err := p.Check(fakerenderer.New())
+
+		require.NoError(t, err)
+		assert.Equal(t, "password\t[success=1 default=ignore]\tpam_unix.so sha512", status.Diffs()["password pam_unix.so"].Current())
+	})
+
+	t.Run("out of order", func(t *testing.T) {
+		p := prepare(t, service, &pam.Preparer{Control: "required", Module: "pam_permit.so", Before: "pam_deny.so"})
+		status, err := p.Check(fakerenderer.New())
+
+		require.NoError(t, err)
+		assert.Equal(t, "before pam_deny.so", status.Diffs()["position"].Current())
+	})
+
+	t.Run("absent", func(t *testing.T) {
+		p := prepare(t, service, &pam.Preparer{Module: "pam_permit.so", State: pam.StateAbsent})
+		status, err := p.Check(fakerenderer.New())
+
+		require.NoError(t, err)
+		assert.True(t, status.HasChanges())
+	})
+}
+
+// TestApply tests that Apply places lines within the stack
+func TestApply(t *testing.T) {
+	t.Parallel()
+
+	t.Run("before", func(t *testing.T) {
+		out := apply(t, service, &pam.Preparer{Control: "requisite", Module: "pam_pwquality.so", Arguments: []string{"retry=3"}, Before: "pam_unix.so"})
+		assert.Equal(t, `# here are the per-package modules
+password	requisite	pam_pwquality.so retry=3
+password	[success=1 default=ignore]	pam_unix.so obscure sha512
+password	requisite	pam_deny.so
+password	required	pam_permit.so
+@include common-session
+`, out)
+	})
+
+	t.Run("end of stack", func(t *testing.T) {
+		out := apply(t, service, &pam.Preparer{Control: "optional", Module: "pam_gnome_keyring.so"})
+		assert.Equal(t, `# here are the per-package modules
+password	[success=1 default=ignore]	pam_unix.so obscure sha512
+password	requisite	pam_deny.so
+password	required	pam_permit.so
+password	optional	pam_gnome_keyring.so
+@include common-session
+`, out)
+	})
+
+	t.Run("move", func(t *testing.T) {
+		out := apply(t, service, &pam.Preparer{Control: "required", Module: "pam_permit.so", Before: "pam_deny.so"})
+		assert.Equal(t, `# here are the per-package modules
+password	[success=1 default=ignore]	pam_unix.so obscure sha512
+password	required	pam_permit.so
+password	requisite	pam_deny.so
+@include common-session
+`, out)
+	})
+
+	t.Run("update in place", func(t *testing.T) {
+		out := apply(t, service, &pam.Preparer{Control: "requisite", Module: "pam_unix.so", Arguments: []string{"sha512"}})
+		assert.Equal(t, `# here are the per-package modules
+password	requisite	pam_unix.so sha512
+password	requisite	pam_deny.so
+password	required	pam_permit.so
+@include common-session
+`, out)
+	})
+
+	t.Run("absent", func(t *testing.T) {
+		out := apply(t, service, &pam.Preparer{Module: "pam_deny.so", State: pam.StateAbsent})
+		assert.Equal(t, `# here are the per-package modules
+password	[success=1 default=ignore]	pam_unix.so obscure sha512
+password	required	pam_permit.so
+@include common-session
+`, out)
+	})
+}
+
+// TestPrepare tests the valid and invalid cases of Prepare
+func TestPrepare(t *testing.T) {
+	t.Parallel()
+
+	fr := fakerenderer.New()
+
+	_, err := (&pam.Preparer{Service: "sshd", Type: "auth", Module: "pam_unix.so"}).Prepare(fr)
+	assert.EqualError(t, err, `pam "control" is required when state is "present"`)
+
+	_, err = (&pam.Preparer{Service: "../shadow", Type: "auth", Control: "required", Module: "pam_unix.so"}).Prepare(fr)
+	assert.EqualError(t, err, `pam "service" must be a file name in /etc/pam.d`)
+
+	_, err = (&pam.Preparer{Service: "sshd", Type: "auth", Control: "required", Module: "pam_unix.so", After: "pam_unix.so"}).Prepare(fr)
+	assert.EqualError(t, err, `pam cannot order pam_unix.so relative to itself`)
+}
+
+func prepare(t *testing.T, content string, p *pam.Preparer) *pam.PAM {
+	fake := fakeexec.New()
+	fake.Expect("test", "-e", path)
+	fake.Expect("cat", path).Return(content, 0)
+
+	return prepareWith(t, fake, p)
+}
+
+func prepareWith(t *testing.T, fake *fakeexec.Executor, p *pam.Preparer) *pam.PAM {
+	p.Service = "common-password"
+	p.Type = "password"
+
+	task, err := p.Prepare(&resource.ExecRenderer{Renderer: fakerenderer.New(), Exec: fake})
+	require.NoError(t, err)
+	return task.(*pam.PAM)
+}
+
+func apply(t *testing.T, content string, p *pam.Preparer) string {
+	fake := fakeexec.New()
+	fake.Expect("test", "-e", path)
+	fake.Expect("cat", path).Return(content, 0)
+	fake.Expect("sh", "-c", exec.WriteFileScript, path, "0644")
+
+	task := prepareWith(t, fake, p)
+	_, err := task.Apply()
+	require.NoError(t, err)
+	fake.AssertExpectations(t)
+
+	calls := fake.Calls()
+	return calls[len(calls)-1].Stdin
+}
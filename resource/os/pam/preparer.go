@@ -0,0 +1,100 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pam
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/load/registry"
+	"github.com/asteris-llc/converge/resource"
+)
+
+// Preparer for PAM
+//
+// PAM manages a single module line in a service file under /etc/pam.d. A line
+// is identified by its type and module, so a module can appear once in each
+// stack. Lines are placed relative to other modules in the same stack with
+// `before` or `after`; when neither is set, new lines are added to the end of
+// the stack.
+type Preparer struct {
+	// Service is the name of the file in /etc/pam.d, such as "sshd" or
+	// "common-password"
+	Service string `hcl:"service" required:"true"`
+
+	// Type is the management group, or stack, the line belongs to
+	Type string `hcl:"type" required:"true" valid_values:"account,auth,password,session"`
+
+	// Control is the control flag of the line, such as "required" or
+	// "[success=1 default=ignore]"
+	Control string `hcl:"control"`
+
+	// Module is the path or name of the module, such as "pam_pwquality.so"
+	Module string `hcl:"module" required:"true"`
+
+	// Arguments are passed to the module
+	Arguments []string `hcl:"arguments"`
+
+	// Before places the line before the first line for this module in the
+	// same stack
+	Before string `hcl:"before" mutually_exclusive:"before,after"`
+
+	// After places the line after the last line for this module in the same
+	// stack
+	After string `hcl:"after" mutually_exclusive:"before,after"`
+
+	// State is whether the line should be present
+	State State `hcl:"state" valid_values:"present,absent"`
+}
+
+// Prepare a new task
+func (p *Preparer) Prepare(render resource.Renderer) (resource.Task, error) {
+	if strings.ContainsAny(p.Service, "/") || strings.HasPrefix(p.Service, ".") {
+		return nil, fmt.Errorf("pam \"service\" must be a file name in %s", Dir)
+	}
+
+	if p.State == "" {
+		p.State = StatePresent
+	}
+
+	if p.State == StatePresent && p.Control == "" {
+		return nil, fmt.Errorf("pam \"control\" is required when state is %q", StatePresent)
+	}
+
+	if p.Before == p.Module || p.After == p.Module {
+		return nil, fmt.Errorf("pam cannot order %s relative to itself", p.Module)
+	}
+
+	return &PAM{
+		Path: path.Join(Dir, p.Service),
+		Entry: Entry{
+			Type:      p.Type,
+			Control:   p.Control,
+			Module:    p.Module,
+			Arguments: p.Arguments,
+		},
+		Before: p.Before,
+		After:  p.After,
+		State:  p.State,
+		exec:   exec.For(render),
+	}, nil
+}
+
+func init() {
+	registry.Register("os.pam", (*Preparer)(nil), (*PAM)(nil))
+	registry.RegisterPlatforms("os.pam", "linux")
+}
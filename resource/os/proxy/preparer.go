@@ -0,0 +1,99 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/load/registry"
+	"github.com/asteris-llc/converge/resource"
+)
+
+// Preparer for Proxy
+//
+// Proxy configures the proxy used by the whole system from one place. The
+// settings are written to environment.d and profile.d for services and login
+// shells, to an apt configuration file, to the yum or dnf configuration, and
+// to a drop-in for the docker service. yum and dnf take a single proxy and no
+// exclusions, so they are given the HTTP proxy, or the HTTPS proxy if that is
+// the only one.
+type Preparer struct {
+	// HTTP is the proxy for HTTP, such as "http://proxy.example.com:3128"
+	HTTP string `hcl:"http"`
+
+	// HTTPS is the proxy for HTTPS
+	HTTPS string `hcl:"https"`
+
+	// NoProxy are hosts and domains reached directly
+	NoProxy []string `hcl:"no_proxy"`
+
+	// Targets are configured with the proxy. By default each of environment,
+	// apt, yum, and docker is configured if it is installed.
+	Targets []string `hcl:"targets"`
+}
+
+// Prepare a new task
+func (p *Preparer) Prepare(render resource.Renderer) (resource.Task, error) {
+	if p.HTTP == "" && p.HTTPS == "" {
+		return nil, fmt.Errorf("os.proxy requires \"http\" or \"https\"")
+	}
+
+	for _, proxy := range []string{p.HTTP, p.HTTPS} {
+		if proxy == "" {
+			continue
+		}
+		u, err := url.Parse(proxy)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return nil, fmt.Errorf("os.proxy: %q is not a proxy URL", proxy)
+		}
+	}
+
+	for _, host := range p.NoProxy {
+		if host == "" || strings.ContainsAny(host, " \t\n,\"") {
+			return nil, fmt.Errorf("os.proxy: %q is not a valid no_proxy entry", host)
+		}
+	}
+
+	for _, target := range p.Targets {
+		if !valid(target) {
+			return nil, fmt.Errorf("os.proxy: %q is not a valid target, expected one of %s", target, strings.Join(Targets, ", "))
+		}
+	}
+
+	return &Proxy{
+		HTTP:    p.HTTP,
+		HTTPS:   p.HTTPS,
+		NoProxy: p.NoProxy,
+		Targets: p.Targets,
+		exec:    exec.For(render),
+	}, nil
+}
+
+func valid(target string) bool {
+	for _, t := range Targets {
+		if t == target {
+			return true
+		}
+	}
+	return false
+}
+
+func init() {
+	registry.Register("os.proxy", (*Preparer)(nil), (*Proxy)(nil))
+	registry.RegisterPlatforms("os.proxy", "linux")
+}
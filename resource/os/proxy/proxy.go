@@ -0,0 +1,291 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/systemd"
+	"github.com/pkg/errors"
+)
+
+// Targets are the kinds of configuration Proxy writes
+var Targets = []string{"environment", "apt", "yum", "docker"}
+
+const (
+	// EnvironmentFile is read by systemd for services and user sessions
+	EnvironmentFile = "/etc/environment.d/90-converge-proxy.conf"
+
+	// ProfileFile is read by login shells
+	ProfileFile = "/etc/profile.d/converge-proxy.sh"
+
+	// AptFile is read by apt
+	AptFile = "/etc/apt/apt.conf.d/90converge-proxy"
+
+	// DockerDropIn is read by the docker service
+	DockerDropIn = "/etc/systemd/system/docker.service.d/converge-proxy.conf"
+)
+
+// YumFiles are the yum and dnf configuration files, in order of preference
+var YumFiles = []string{"/etc/dnf/dnf.conf", "/etc/yum.conf"}
+
+// file is a file written by Proxy
+type file struct {
+	target  string
+	path    string
+	mode    os.FileMode
+	current string
+	desired string
+}
+
+// Proxy manages the system proxy configuration
+type Proxy struct {
+	resource.Status
+
+	HTTP    string
+	HTTPS   string
+	NoProxy []string
+
+	// Targets are the targets configured, once detected
+	Targets []string
+
+	exec exec.Executor
+}
+
+// Check whether each file has the proxy settings
+func (p *Proxy) Check(resource.Renderer) (resource.TaskStatus, error) {
+	p.Status = resource.Status{}
+
+	files, err := p.files()
+	if err != nil {
+		p.RaiseLevel(resource.StatusFatal)
+		return p, err
+	}
+
+	for _, f := range files {
+		if f.current != f.desired {
+			p.RaiseLevel(resource.StatusWillChange)
+			p.AddDifference(f.path, f.current, f.desired, "<file-missing>")
+		}
+	}
+
+	return p, nil
+}
+
+// Apply writes the files that differ and restarts docker if its drop-in
+// changed
+func (p *Proxy) Apply() (resource.TaskStatus, error) {
+	p.Status = resource.Status{}
+
+	files, err := p.files()
+	if err != nil {
+		p.RaiseLevel(resource.StatusFatal)
+		return p, err
+	}
+
+	restart := false
+	for _, f := range files {
+		if f.current == f.desired {
+			continue
+		}
+
+		if err := exec.Run(p.exec, "mkdir", "-p", path.Dir(f.path)); err != nil {
+			p.RaiseLevel(resource.StatusFatal)
+			return p, errors.Wrapf(err, "cannot create %s", path.Dir(f.path))
+		}
+		if err := exec.WriteFile(p.exec, f.path, f.desired, f.mode); err != nil {
+			p.RaiseLevel(resource.StatusFatal)
+			return p, errors.Wrapf(err, "cannot write %s", f.path)
+		}
+		p.AddMessage("wrote " + f.path)
+
+		restart = restart || f.target == "docker"
+	}
+
+	if restart {
+		if err := systemd.DaemonReload(p.exec); err != nil {
+			p.RaiseLevel(resource.StatusFatal)
+			return p, err
+		}
+		// only a running daemon needs to pick up the change
+		if err := exec.Run(p.exec, "systemctl", "try-restart", "docker"); err != nil {
+			p.RaiseLevel(resource.StatusFatal)
+			return p, errors.Wrap(err, "cannot restart docker")
+		}
+		p.AddMessage("restarted docker")
+	}
+
+	return p, nil
+}
+
+// files returns the current and desired contents of every file for the
+// targets
+func (p *Proxy) files() ([]file, error) {
+	if err := p.detect(); err != nil {
+		return nil, err
+	}
+
+	var files []file
+	for _, target := range p.Targets {
+		switch target {
+		case "environment":
+			files = append(files,
+				file{target: target, path: EnvironmentFile, mode: 0644, desired: p.environment("%s=%s")},
+				file{target: target, path: ProfileFile, mode: 0644, desired: p.environment("export %s=%q")},
+			)
+
+		case "apt":
+			files = append(files, file{target: target, path: AptFile, mode: 0644, desired: p.apt()})
+
+		case "yum":
+			yum, err := p.yumFile()
+			if err != nil {
+				return nil, err
+			}
+			if yum == "" {
+				yum = YumFiles[len(YumFiles)-1]
+			}
+			files = append(files, file{target: target, path: yum, mode: 0644})
+
+		case "docker":
+			desired, err := p.docker()
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, file{target: target, path: DockerDropIn, mode: systemd.Mode, desired: desired})
+		}
+	}
+
+	for i := range files {
+		current, _, err := exec.ReadFile(p.exec, files[i].path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot read %s", files[i].path)
+		}
+		files[i].current = current
+
+		if files[i].target == "yum" {
+			files[i].desired = systemd.SetSection(current, "main", map[string]string{"proxy": p.single()})
+		}
+	}
+	return files, nil
+}
+
+// detect picks the installed targets if none were declared
+func (p *Proxy) detect() error {
+	if len(p.Targets) > 0 {
+		return nil
+	}
+
+	p.Targets = []string{"environment"}
+
+	yum, err := p.yumFile()
+	if err != nil {
+		return err
+	}
+	if yum != "" {
+		p.Targets = append(p.Targets, "yum")
+	}
+
+	checks := []struct {
+		target string
+		argv   []string
+	}{
+		{"apt", []string{"test", "-d", "/etc/apt"}},
+		{"docker", []string{"sh", "-c", `command -v "$0"`, "dockerd"}},
+	}
+	for _, check := range checks {
+		err := exec.Run(p.exec, check.argv[0], check.argv[1:]...)
+		if err == nil {
+			p.Targets = append(p.Targets, check.target)
+		} else if _, ok := exec.ExitStatus(err); !ok {
+			return err
+		}
+	}
+	return nil
+}
+
+// yumFile returns the first of YumFiles that exists, or an empty string if
+// none do
+func (p *Proxy) yumFile() (string, error) {
+	for _, path := range YumFiles {
+		err := exec.Run(p.exec, "test", "-f", path)
+		if err == nil {
+			return path, nil
+		}
+		if _, ok := exec.ExitStatus(err); !ok {
+			return "", err
+		}
+	}
+	return "", nil
+}
+
+// variables returns the proxy environment variables, in both the lower case
+// most tools read and the upper case others do
+func (p *Proxy) variables() [][2]string {
+	var vars [][2]string
+	add := func(name, value string) {
+		if value != "" {
+			vars = append(vars, [2]string{name, value}, [2]string{strings.ToUpper(name), value})
+		}
+	}
+	add("http_proxy", p.HTTP)
+	add("https_proxy", p.HTTPS)
+	add("no_proxy", strings.Join(p.NoProxy, ","))
+	return vars
+}
+
+func (p *Proxy) environment(format string) string {
+	lines := []string{"# managed by converge"}
+	for _, v := range p.variables() {
+		lines = append(lines, fmt.Sprintf(format, v[0], v[1]))
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+func (p *Proxy) apt() string {
+	lines := []string{"// managed by converge"}
+	if p.HTTP != "" {
+		lines = append(lines, fmt.Sprintf("Acquire::http::Proxy %q;", p.HTTP))
+	}
+	if p.HTTPS != "" {
+		lines = append(lines, fmt.Sprintf("Acquire::https::Proxy %q;", p.HTTPS))
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+func (p *Proxy) docker() (string, error) {
+	var env []interface{}
+	for _, v := range p.variables() {
+		if v[0] == strings.ToUpper(v[0]) {
+			env = append(env, fmt.Sprintf("\"%s=%s\"", v[0], v[1]))
+		}
+	}
+	return systemd.Render(map[string]map[string]interface{}{
+		"Service": {"Environment": env},
+	})
+}
+
+// single returns the one proxy yum and dnf use
+func (p *Proxy) single() string {
+	if p.HTTP != "" {
+		return p.HTTP
+	}
+	return p.HTTPS
+}
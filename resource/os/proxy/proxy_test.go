@@ -0,0 +1,144 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy_test
+
+import (
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/helpers/fakeexec"
+	"github.com/asteris-llc/converge/helpers/fakerenderer"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/os/proxy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const write = exec.WriteFileScript
+
+var preparer = proxy.Preparer{
+	HTTP:    "http://proxy.example.com:3128",
+	HTTPS:   "http://proxy.example.com:3129",
+	NoProxy: []string{"localhost", ".example.com"},
+}
+
+// TestProxyInterface tests that Proxy is properly implemented
+func TestProxyInterface(t *testing.T) {
+	t.Parallel()
+
+	assert.Implements(t, (*resource.Task)(nil), new(proxy.Proxy))
+	assert.Implements(t, (*resource.Resource)(nil), new(proxy.Preparer))
+}
+
+// TestCheck tests detecting targets and rendering each file
+func TestCheck(t *testing.T) {
+	t.Parallel()
+
+	fake := fakeexec.New()
+	fake.Expect("test", "-f", "/etc/dnf/dnf.conf")
+	fake.Expect("test", "-d", "/etc/apt").Return("", 1)
+	fake.Expect("sh", "-c", `command -v "$0"`, "dockerd")
+	fake.Expect("test", "-e", proxy.EnvironmentFile).Return("", 1)
+	fake.Expect("test", "-e", proxy.ProfileFile).Return("", 1)
+	fake.Expect("test", "-e", "/etc/dnf/dnf.conf")
+	fake.Expect("cat", "/etc/dnf/dnf.conf").Return("[main]\ngpgcheck=1\n", 0)
+	fake.Expect("test", "-e", proxy.DockerDropIn).Return("", 1)
+
+	p := prepare(t, fake, preparer)
+	status, err := p.Check(fakerenderer.New())
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"environment", "yum", "docker"}, p.Targets)
+
+	diffs := status.Diffs()
+	assert.Equal(t, `# managed by converge
+http_proxy=http://proxy.example.com:3128
+HTTP_PROXY=http://proxy.example.com:3128
+https_proxy=http://proxy.example.com:3129
+HTTPS_PROXY=http://proxy.example.com:3129
+no_proxy=localhost,.example.com
+NO_PROXY=localhost,.example.com
+`, diffs[proxy.EnvironmentFile].Current())
+	assert.Contains(t, diffs[proxy.ProfileFile].Current(), "export HTTP_PROXY=\"http://proxy.example.com:3128\"\n")
+	assert.Equal(t, "[main]\ngpgcheck=1\nproxy=http://proxy.example.com:3128\n", diffs["/etc/dnf/dnf.conf"].Current())
+	assert.Equal(t, `[Service]
+Environment="HTTP_PROXY=http://proxy.example.com:3128"
+Environment="HTTPS_PROXY=http://proxy.example.com:3129"
+Environment="NO_PROXY=localhost,.example.com"
+`, diffs[proxy.DockerDropIn].Current())
+}
+
+// TestApply tests writing files and restarting docker
+func TestApply(t *testing.T) {
+	t.Parallel()
+
+	t.Run("apt", func(t *testing.T) {
+		p := preparer
+		p.Targets = []string{"apt"}
+
+		fake := fakeexec.New()
+		fake.Expect("test", "-e", proxy.AptFile).Return("", 1)
+		fake.Expect("mkdir", "-p", "/etc/apt/apt.conf.d")
+		fake.Expect("sh", "-c", write, proxy.AptFile, "0644")
+
+		_, err := prepare(t, fake, p).Apply()
+
+		require.NoError(t, err)
+		fake.AssertExpectations(t)
+		assert.Equal(t, `// managed by converge
+Acquire::http::Proxy "http://proxy.example.com:3128";
+Acquire::https::Proxy "http://proxy.example.com:3129";
+`, fake.Calls()[2].Stdin)
+	})
+
+	t.Run("docker", func(t *testing.T) {
+		p := preparer
+		p.Targets = []string{"docker"}
+
+		fake := fakeexec.New()
+		fake.Expect("test", "-e", proxy.DockerDropIn).Return("", 1)
+		fake.Expect("mkdir", "-p", "/etc/systemd/system/docker.service.d")
+		fake.Expect("sh", "-c", write, proxy.DockerDropIn, "0644")
+		fake.Expect("systemctl", "daemon-reload")
+		fake.Expect("systemctl", "try-restart", "docker")
+
+		_, err := prepare(t, fake, p).Apply()
+
+		require.NoError(t, err)
+		fake.AssertExpectations(t)
+	})
+}
+
+// TestPrepare tests the invalid cases of Prepare
+func TestPrepare(t *testing.T) {
+	t.Parallel()
+
+	fr := fakerenderer.New()
+
+	_, err := (&proxy.Preparer{}).Prepare(fr)
+	assert.EqualError(t, err, `os.proxy requires "http" or "https"`)
+
+	_, err = (&proxy.Preparer{HTTP: "proxy.example.com:3128"}).Prepare(fr)
+	assert.EqualError(t, err, `os.proxy: "proxy.example.com:3128" is not a proxy URL`)
+
+	_, err = (&proxy.Preparer{HTTP: "http://proxy:3128", Targets: []string{"pip"}}).Prepare(fr)
+	assert.EqualError(t, err, `os.proxy: "pip" is not a valid target, expected one of environment, apt, yum, docker`)
+}
+
+func prepare(t *testing.T, fake *fakeexec.Executor, p proxy.Preparer) *proxy.Proxy {
+	task, err := p.Prepare(&resource.ExecRenderer{Renderer: fakerenderer.New(), Exec: fake})
+	require.NoError(t, err)
+	return task.(*proxy.Proxy)
+}
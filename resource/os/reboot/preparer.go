@@ -0,0 +1,88 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reboot
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/load/registry"
+	"github.com/asteris-llc/converge/resource"
+)
+
+// Preparer for Reboot
+//
+// Reboot schedules a reboot of the system when the resources it depends on
+// require one, such as os.kernel_cmdline after changing kernel parameters.
+// The reboot is scheduled with shutdown(8) after a delay rather than run
+// immediately, so the rest of the run can finish first. A run that doesn't
+// reboot still reports pending reboots when it finishes.
+type Preparer struct {
+	// When is "required" to reboot only if a dependency requires it, "always"
+	// to reboot on every run, or "never" to refuse to reboot and only report
+	// the reboots that are pending. It defaults to "required".
+	When string `hcl:"when" valid_values:"required,always,never"`
+
+	// Delay is the number of minutes to wait before rebooting. It defaults to
+	// 1. A delay of 0 reboots immediately, which may interrupt the run.
+	Delay *int `hcl:"delay"`
+
+	// Message is broadcast to logged in users when the reboot is scheduled
+	Message string `hcl:"message"`
+
+	// Resume is a command that is run once after the reboot, such as
+	// "/usr/bin/converge apply --local /etc/converge/site.hcl", so the run
+	// continues where it left off. It is installed as the
+	// converge-resume.service systemd unit, which disables itself after
+	// running.
+	Resume string `hcl:"resume"`
+}
+
+// Prepare a new task
+func (p *Preparer) Prepare(render resource.Renderer) (resource.Task, error) {
+	if p.When == "" {
+		p.When = WhenRequired
+	}
+
+	delay := 1
+	if p.Delay != nil {
+		delay = *p.Delay
+	}
+	if delay < 0 {
+		return nil, fmt.Errorf("reboot \"delay\" must not be negative, got %d", delay)
+	}
+
+	if p.Message == "" {
+		p.Message = "converge: rebooting to apply changes"
+	}
+
+	if strings.ContainsAny(p.Message, "\n") || strings.ContainsAny(p.Resume, "\n") {
+		return nil, fmt.Errorf("reboot \"message\" and \"resume\" must be a single line")
+	}
+
+	return &Reboot{
+		When:    p.When,
+		Delay:   delay,
+		Message: p.Message,
+		Resume:  p.Resume,
+		exec:    exec.For(render),
+	}, nil
+}
+
+func init() {
+	registry.Register("os.reboot", (*Preparer)(nil), (*Reboot)(nil))
+	registry.RegisterPlatforms("os.reboot", "linux")
+}
@@ -0,0 +1,167 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reboot
+
+import (
+	"fmt"
+	"path"
+	"sort"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/systemd"
+	"github.com/pkg/errors"
+)
+
+const (
+	// WhenRequired reboots when a dependency requires it
+	WhenRequired = "required"
+
+	// WhenAlways reboots on every run
+	WhenAlways = "always"
+
+	// WhenNever refuses to reboot
+	WhenNever = "never"
+
+	// ResumeUnit is the unit that runs the resume command after the reboot
+	ResumeUnit = "converge-resume.service"
+
+	// ScheduledPath exists while systemd has a shutdown scheduled
+	ScheduledPath = "/run/systemd/shutdown/scheduled"
+)
+
+// Reboot schedules a reboot of the system
+type Reboot struct {
+	resource.Status
+
+	When    string
+	Delay   int
+	Message string
+	Resume  string
+
+	// Pending lists the reboots required by dependencies, as "ID: reason"
+	Pending []string
+
+	// Scheduled is true once a reboot has been scheduled
+	Scheduled bool
+
+	exec exec.Executor
+}
+
+// WatchReboots records the reboots required by dependencies
+func (r *Reboot) WatchReboots(reboots map[string][]string) {
+	r.Pending = nil
+	for id, reasons := range reboots {
+		for _, reason := range reasons {
+			r.Pending = append(r.Pending, id+": "+reason)
+		}
+	}
+	sort.Strings(r.Pending)
+}
+
+// Check whether a reboot should be scheduled
+func (r *Reboot) Check(resource.Renderer) (resource.TaskStatus, error) {
+	r.Status = resource.Status{}
+
+	for _, pending := range r.Pending {
+		r.AddMessage("reboot required by " + pending)
+	}
+
+	if !r.Scheduled {
+		err := exec.Run(r.exec, "test", "-e", ScheduledPath)
+		if _, ok := exec.ExitStatus(err); err != nil && !ok {
+			r.RaiseLevel(resource.StatusFatal)
+			return r, errors.Wrap(err, "cannot check for a scheduled reboot")
+		}
+		r.Scheduled = err == nil
+	}
+
+	switch {
+	case r.Scheduled:
+		r.AddMessage("reboot already scheduled")
+
+	case r.When == WhenAlways, r.When == WhenRequired && len(r.Pending) > 0:
+		r.RaiseLevel(resource.StatusWillChange)
+		r.AddDifference("reboot", "<none>", r.when(), "")
+
+	case r.When == WhenNever && len(r.Pending) > 0:
+		r.RaiseLevel(resource.StatusWontChange)
+		r.AddMessage("refusing to reboot, reboot manually for changes to take effect")
+	}
+
+	return r, nil
+}
+
+// Apply installs the resume unit, if any, and schedules the reboot
+func (r *Reboot) Apply() (resource.TaskStatus, error) {
+	r.Status = resource.Status{}
+
+	if r.Resume != "" {
+		if err := r.installResume(); err != nil {
+			r.RaiseLevel(resource.StatusFatal)
+			return r, errors.Wrap(err, "cannot install resume unit")
+		}
+		r.AddMessage(fmt.Sprintf("%s will run %q after the reboot", ResumeUnit, r.Resume))
+	}
+
+	if err := exec.Run(r.exec, "shutdown", "-r", fmt.Sprintf("+%d", r.Delay), r.Message); err != nil {
+		r.RaiseLevel(resource.StatusFatal)
+		return r, errors.Wrap(err, "cannot schedule reboot")
+	}
+	r.Scheduled = true
+	r.AddMessage("reboot scheduled " + r.when())
+
+	return r, nil
+}
+
+func (r *Reboot) when() string {
+	if r.Delay == 0 {
+		return "now"
+	}
+	return fmt.Sprintf("in %d minute(s)", r.Delay)
+}
+
+// installResume writes and enables a oneshot unit that runs the resume
+// command on the next boot and then disables itself
+func (r *Reboot) installResume() error {
+	unit, err := systemd.Render(map[string]map[string]interface{}{
+		"Unit": {
+			"Description": "Resume converge after reboot",
+			"After":       "network-online.target",
+			"Wants":       "network-online.target",
+		},
+		"Service": {
+			"Type":         "oneshot",
+			"ExecStart":    r.Resume,
+			"ExecStopPost": "/bin/systemctl disable " + ResumeUnit,
+		},
+		"Install": {
+			"WantedBy": "multi-user.target",
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := exec.WriteFile(r.exec, path.Join(systemd.Dir, ResumeUnit), unit, systemd.Mode); err != nil {
+		return err
+	}
+
+	if err := systemd.DaemonReload(r.exec); err != nil {
+		return err
+	}
+
+	return exec.Run(r.exec, "systemctl", "enable", ResumeUnit)
+}
@@ -0,0 +1,161 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reboot_test
+
+import (
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/helpers/fakeexec"
+	"github.com/asteris-llc/converge/helpers/fakerenderer"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/os/reboot"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const write = exec.WriteFileScript
+
+// TestRebootInterface tests that Reboot is properly implemented
+func TestRebootInterface(t *testing.T) {
+	t.Parallel()
+
+	assert.Implements(t, (*resource.Task)(nil), new(reboot.Reboot))
+	assert.Implements(t, (*resource.Resource)(nil), new(reboot.Preparer))
+	assert.Implements(t, (*resource.RebootWatcher)(nil), new(reboot.Reboot))
+}
+
+// TestCheck tests the possible cases Check handles
+func TestCheck(t *testing.T) {
+	t.Parallel()
+
+	pending := map[string][]string{"root/os.kernel_cmdline.audit": {"kernel parameters changed"}}
+
+	t.Run("required", func(t *testing.T) {
+		r := prepare(t, notScheduled(), &reboot.Preparer{})
+		r.WatchReboots(pending)
+		status, err := r.Check(fakerenderer.New())
+
+		require.NoError(t, err)
+		assert.True(t, status.HasChanges())
+		assert.Equal(t, "in 1 minute(s)", status.Diffs()["reboot"].Current())
+		assert.Equal(t, []string{"reboot required by root/os.kernel_cmdline.audit: kernel parameters changed"}, status.Messages())
+	})
+
+	t.Run("not required", func(t *testing.T) {
+		r := prepare(t, notScheduled(), &reboot.Preparer{})
+		r.WatchReboots(map[string][]string{})
+		status, err := r.Check(fakerenderer.New())
+
+		require.NoError(t, err)
+		assert.False(t, status.HasChanges())
+	})
+
+	t.Run("always", func(t *testing.T) {
+		r := prepare(t, notScheduled(), &reboot.Preparer{When: reboot.WhenAlways})
+		status, err := r.Check(fakerenderer.New())
+
+		require.NoError(t, err)
+		assert.True(t, status.HasChanges())
+	})
+
+	t.Run("never", func(t *testing.T) {
+		r := prepare(t, notScheduled(), &reboot.Preparer{When: reboot.WhenNever})
+		r.WatchReboots(pending)
+		status, err := r.Check(fakerenderer.New())
+
+		require.NoError(t, err)
+		assert.False(t, status.HasChanges())
+		assert.Equal(t, resource.StatusWontChange, status.StatusCode())
+		assert.Contains(t, status.Messages(), "refusing to reboot, reboot manually for changes to take effect")
+	})
+
+	t.Run("scheduled", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("test", "-e", reboot.ScheduledPath)
+
+		r := prepare(t, fake, &reboot.Preparer{})
+		r.WatchReboots(pending)
+		status, err := r.Check(fakerenderer.New())
+
+		require.NoError(t, err)
+		assert.False(t, status.HasChanges())
+		assert.Contains(t, status.Messages(), "reboot already scheduled")
+	})
+}
+
+// TestApply tests that Apply schedules the reboot and then has no changes
+func TestApply(t *testing.T) {
+	t.Parallel()
+
+	t.Run("reboot", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("shutdown", "-r", "+5", "converge: rebooting to apply changes").Once()
+
+		delay := 5
+		r := prepare(t, fake, &reboot.Preparer{When: reboot.WhenAlways, Delay: &delay})
+		_, err := r.Apply()
+		require.NoError(t, err)
+		fake.AssertExpectations(t)
+
+		status, err := r.Check(fakerenderer.New())
+		require.NoError(t, err)
+		assert.False(t, status.HasChanges())
+	})
+
+	t.Run("resume", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("sh", "-c", write, "/etc/systemd/system/converge-resume.service", "0644")
+		fake.Expect("systemctl", "daemon-reload")
+		fake.Expect("systemctl", "enable", "converge-resume.service")
+		fake.Expect("shutdown", "-r", "+1", "converge: rebooting to apply changes")
+
+		r := prepare(t, fake, &reboot.Preparer{Resume: "/usr/bin/converge apply --local /etc/converge/site.hcl"})
+		_, err := r.Apply()
+		require.NoError(t, err)
+		fake.AssertExpectations(t)
+
+		unit := fake.Calls()[0].Stdin
+		assert.Contains(t, unit, "ExecStart=/usr/bin/converge apply --local /etc/converge/site.hcl\n")
+		assert.Contains(t, unit, "ExecStopPost=/bin/systemctl disable converge-resume.service\n")
+		assert.Contains(t, unit, "WantedBy=multi-user.target\n")
+	})
+}
+
+// TestPrepare tests the invalid cases of Prepare
+func TestPrepare(t *testing.T) {
+	t.Parallel()
+
+	fr := fakerenderer.New()
+
+	delay := -1
+	_, err := (&reboot.Preparer{Delay: &delay}).Prepare(fr)
+	assert.EqualError(t, err, `reboot "delay" must not be negative, got -1`)
+
+	_, err = (&reboot.Preparer{Resume: "a\nb"}).Prepare(fr)
+	assert.EqualError(t, err, `reboot "message" and "resume" must be a single line`)
+}
+
+func notScheduled() *fakeexec.Executor {
+	fake := fakeexec.New()
+	fake.Expect("test", "-e", reboot.ScheduledPath).Return("", 1)
+	return fake
+}
+
+func prepare(t *testing.T, fake *fakeexec.Executor, p *reboot.Preparer) *reboot.Reboot {
+	task, err := p.Prepare(&resource.ExecRenderer{Renderer: fakerenderer.New(), Exec: fake})
+	require.NoError(t, err)
+	return task.(*reboot.Reboot)
+}
@@ -0,0 +1,75 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sudoers
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/load/registry"
+	"github.com/asteris-llc/converge/resource"
+)
+
+// Preparer for Sudoers
+//
+// Sudoers manages a drop-in file in /etc/sudoers.d. The file is checked with
+// `visudo` before it is installed, and the previous version is restored if
+// the complete sudoers configuration is invalid afterwards.
+type Preparer struct {
+	// Name is the name of the file in /etc/sudoers.d. sudo ignores files with
+	// a "." in their name or ending in "~", so these are not allowed.
+	Name string `hcl:"name" required:"true"`
+
+	// Content is the content of the file.
+	Content string `hcl:"content"`
+
+	// State is whether the file should be present.
+	State State `hcl:"state" valid_values:"present,absent"`
+}
+
+// Prepare a new task
+func (p *Preparer) Prepare(render resource.Renderer) (resource.Task, error) {
+	if strings.ContainsAny(p.Name, "./") || strings.HasSuffix(p.Name, "~") {
+		return nil, fmt.Errorf("sudoers \"name\" cannot contain \".\" or \"/\" or end in \"~\"")
+	}
+
+	if p.State == "" {
+		p.State = StatePresent
+	}
+
+	if p.State == StatePresent && p.Content == "" {
+		return nil, fmt.Errorf("sudoers \"content\" is required when state is %q", StatePresent)
+	}
+
+	content := p.Content
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		// sudo requires the last line to be terminated
+		content += "\n"
+	}
+
+	return &Sudoers{
+		Path:    path.Join(Dir, p.Name),
+		Content: content,
+		State:   p.State,
+		exec:    exec.For(render),
+	}, nil
+}
+
+func init() {
+	registry.Register("os.sudoers", (*Preparer)(nil), (*Sudoers)(nil))
+	registry.RegisterPlatforms("os.sudoers", "linux")
+}
@@ -0,0 +1,70 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sudoers_test
+
+import (
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/fakerenderer"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/os/sudoers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestPreparerInterface tests that the Preparer interface is properly implemeted
+func TestPreparerInterface(t *testing.T) {
+	t.Parallel()
+
+	assert.Implements(t, (*resource.Resource)(nil), new(sudoers.Preparer))
+}
+
+// TestPrepare tests the valid and invalid cases of Prepare
+func TestPrepare(t *testing.T) {
+	t.Parallel()
+
+	fr := fakerenderer.New()
+
+	t.Run("defaults", func(t *testing.T) {
+		p := sudoers.Preparer{Name: "deploy", Content: "deploy ALL=(ALL) NOPASSWD: ALL"}
+		task, err := p.Prepare(fr)
+		require.NoError(t, err)
+
+		s := task.(*sudoers.Sudoers)
+		assert.Equal(t, "/etc/sudoers.d/deploy", s.Path)
+		assert.Equal(t, "deploy ALL=(ALL) NOPASSWD: ALL\n", s.Content)
+		assert.Equal(t, sudoers.StatePresent, s.State)
+	})
+
+	t.Run("absent without content", func(t *testing.T) {
+		p := sudoers.Preparer{Name: "deploy", State: sudoers.StateAbsent}
+		_, err := p.Prepare(fr)
+		assert.NoError(t, err)
+	})
+
+	t.Run("present without content", func(t *testing.T) {
+		p := sudoers.Preparer{Name: "deploy"}
+		_, err := p.Prepare(fr)
+		assert.EqualError(t, err, `sudoers "content" is required when state is "present"`)
+	})
+
+	t.Run("ignored names", func(t *testing.T) {
+		for _, name := range []string{"deploy.conf", "deploy~", "../sudoers"} {
+			p := sudoers.Preparer{Name: name, Content: "x"}
+			_, err := p.Prepare(fr)
+			assert.Error(t, err, name)
+		}
+	})
+}
@@ -0,0 +1,194 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sudoers
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/pkg/errors"
+)
+
+// State type for Sudoers
+type State string
+
+const (
+	// StatePresent indicates the file should be present
+	StatePresent State = "present"
+
+	// StateAbsent indicates the file should be absent
+	StateAbsent State = "absent"
+
+	// Dir is the directory drop-in files are written to
+	Dir = "/etc/sudoers.d"
+
+	// Mode is the mode drop-in files are written with, as required by sudo
+	Mode = 0440
+)
+
+// Sudoers manages a sudoers drop-in file
+type Sudoers struct {
+	resource.Status
+
+	Path    string
+	Content string
+	State   State
+
+	exec exec.Executor
+}
+
+// Check whether the file has the right content
+func (s *Sudoers) Check(resource.Renderer) (resource.TaskStatus, error) {
+	s.Status = resource.Status{}
+
+	current, exists, err := exec.ReadFile(s.exec, s.Path)
+	if err != nil {
+		s.RaiseLevel(resource.StatusFatal)
+		return s, errors.Wrapf(err, "cannot read %s", s.Path)
+	}
+
+	switch s.State {
+	case StatePresent:
+		if !exists {
+			current = "<file-missing>"
+		}
+
+		if !exists || current != s.Content {
+			s.RaiseLevel(resource.StatusWillChange)
+			s.AddDifference(s.Path, current, s.Content, "")
+			return s, nil
+		}
+
+		mode, err := exec.Stat(s.exec, s.Path, "%a")
+		if err != nil {
+			s.RaiseLevel(resource.StatusFatal)
+			return s, errors.Wrapf(err, "cannot read mode of %s", s.Path)
+		}
+
+		if mode = strings.TrimSpace(mode); mode != fmt.Sprintf("%o", Mode) {
+			s.RaiseLevel(resource.StatusWillChange)
+			s.AddDifference("mode", mode, fmt.Sprintf("%o", Mode), "")
+		}
+
+	case StateAbsent:
+		if exists {
+			s.RaiseLevel(resource.StatusWillChange)
+			s.AddDifference(s.Path, current, "<file-missing>", "")
+		}
+
+	default:
+		s.RaiseLevel(resource.StatusFatal)
+		return s, fmt.Errorf("sudoers: unrecognized state %s", s.State)
+	}
+
+	return s, nil
+}
+
+// Apply installs or removes the file
+func (s *Sudoers) Apply() (resource.TaskStatus, error) {
+	s.Status = resource.Status{}
+
+	if s.State == StateAbsent {
+		if err := exec.Run(s.exec, "rm", "-f", s.Path); err != nil {
+			s.RaiseLevel(resource.StatusFatal)
+			return s, errors.Wrapf(err, "cannot remove %s", s.Path)
+		}
+		s.AddMessage(fmt.Sprintf("removed %s", s.Path))
+		return s, nil
+	}
+
+	if err := s.install(); err != nil {
+		s.RaiseLevel(resource.StatusFatal)
+		return s, err
+	}
+
+	s.AddMessage(fmt.Sprintf("installed %s", s.Path))
+	return s, nil
+}
+
+// ManagedFiles returns the path of the sudoers file, so it is backed up before being
+// changed
+func (s *Sudoers) ManagedFiles() []string {
+	return []string{s.Path}
+}
+
+// Executor returns the executor the file is written with
+func (s *Sudoers) Executor() exec.Executor {
+	return s.exec
+}
+
+// install validates the new content on its own, moves it into place, and
+// validates the complete configuration. The staging and backup files have a
+// "." in their names so that sudo never reads them.
+func (s *Sudoers) install() error {
+	dir, name := path.Split(s.Path)
+	staged := path.Join(dir, "."+name+".converge")
+	backup := path.Join(dir, "."+name+".converge-backup")
+
+	if err := exec.WriteFile(s.exec, staged, s.Content, Mode); err != nil {
+		return errors.Wrapf(err, "cannot write %s", staged)
+	}
+
+	if err := exec.Run(s.exec, "visudo", "-c", "-q", "-f", staged); err != nil {
+		s.remove(staged)
+		return errors.Wrapf(err, "refusing to install invalid sudoers file %s", s.Path)
+	}
+
+	_, existed, err := exec.ReadFile(s.exec, s.Path)
+	if err != nil {
+		s.remove(staged)
+		return errors.Wrapf(err, "cannot read %s", s.Path)
+	}
+
+	if existed {
+		if err := exec.Run(s.exec, "cp", "-p", s.Path, backup); err != nil {
+			s.remove(staged)
+			return errors.Wrapf(err, "cannot back up %s", s.Path)
+		}
+	}
+
+	if err := exec.Run(s.exec, "mv", "-f", staged, s.Path); err != nil {
+		s.remove(staged)
+		return errors.Wrapf(err, "cannot install %s", s.Path)
+	}
+
+	if err := exec.Run(s.exec, "visudo", "-c", "-q"); err != nil {
+		// put things back the way they were
+		if existed {
+			if rbErr := exec.Run(s.exec, "mv", "-f", backup, s.Path); rbErr != nil {
+				return errors.Wrapf(err, "sudoers configuration is invalid and %s could not be restored (%s)", s.Path, rbErr)
+			}
+		} else {
+			s.remove(s.Path)
+		}
+		s.AddMessage(fmt.Sprintf("rolled back %s", s.Path))
+		return errors.Wrapf(err, "sudoers configuration is invalid with %s", s.Path)
+	}
+
+	if existed {
+		s.remove(backup)
+	}
+	return nil
+}
+
+// remove removes a file on a best-effort basis
+func (s *Sudoers) remove(file string) {
+	if err := exec.Run(s.exec, "rm", "-f", file); err != nil {
+		s.AddMessage(fmt.Sprintf("could not remove %s: %s", file, err))
+	}
+}
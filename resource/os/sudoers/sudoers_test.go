@@ -0,0 +1,222 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sudoers_test
+
+import (
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/helpers/fakeexec"
+	"github.com/asteris-llc/converge/helpers/fakerenderer"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/os/sudoers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	path    = "/etc/sudoers.d/deploy"
+	staged  = "/etc/sudoers.d/.deploy.converge"
+	backup  = "/etc/sudoers.d/.deploy.converge-backup"
+	content = "deploy ALL=(ALL) NOPASSWD: ALL\n"
+	write   = exec.WriteFileScript
+)
+
+// TestSudoersInterface tests that Sudoers is properly implemented
+func TestSudoersInterface(t *testing.T) {
+	t.Parallel()
+
+	assert.Implements(t, (*resource.Task)(nil), new(sudoers.Sudoers))
+}
+
+// TestCheck tests the possible cases Check handles
+func TestCheck(t *testing.T) {
+	t.Parallel()
+
+	t.Run("missing", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("test", "-e", path).Return("", 1)
+
+		s := prepare(t, fake, &sudoers.Preparer{Name: "deploy", Content: content})
+		status, err := s.Check(fakerenderer.New())
+
+		require.NoError(t, err)
+		assert.Equal(t, resource.StatusWillChange, status.StatusCode())
+		assert.Equal(t, "<file-missing>", status.Diffs()[path].Original())
+		fake.AssertExpectations(t)
+	})
+
+	t.Run("different content", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("test", "-e", path)
+		fake.Expect("cat", path).Return("deploy ALL=(ALL) ALL\n", 0)
+
+		s := prepare(t, fake, &sudoers.Preparer{Name: "deploy", Content: content})
+		status, err := s.Check(fakerenderer.New())
+
+		require.NoError(t, err)
+		assert.True(t, status.HasChanges())
+		assert.Equal(t, content, status.Diffs()[path].Current())
+	})
+
+	t.Run("wrong mode", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("test", "-e", path)
+		fake.Expect("cat", path).Return(content, 0)
+		fake.Expect("sh", "-c", exec.StatScript, path, "%a", "%Lp").Return("644\n", 0)
+
+		s := prepare(t, fake, &sudoers.Preparer{Name: "deploy", Content: content})
+		status, err := s.Check(fakerenderer.New())
+
+		require.NoError(t, err)
+		assert.True(t, status.HasChanges())
+		assert.Equal(t, "644", status.Diffs()["mode"].Original())
+	})
+
+	t.Run("converged", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("test", "-e", path)
+		fake.Expect("cat", path).Return(content, 0)
+		fake.Expect("sh", "-c", exec.StatScript, path, "%a", "%Lp").Return("440\n", 0)
+
+		s := prepare(t, fake, &sudoers.Preparer{Name: "deploy", Content: content})
+		status, err := s.Check(fakerenderer.New())
+
+		require.NoError(t, err)
+		assert.False(t, status.HasChanges())
+	})
+
+	t.Run("absent", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("test", "-e", path)
+		fake.Expect("cat", path).Return(content, 0)
+
+		s := prepare(t, fake, &sudoers.Preparer{Name: "deploy", State: sudoers.StateAbsent})
+		status, err := s.Check(fakerenderer.New())
+
+		require.NoError(t, err)
+		assert.True(t, status.HasChanges())
+		assert.Equal(t, "<file-missing>", status.Diffs()[path].Current())
+	})
+}
+
+// TestApply tests the possible cases Apply handles
+func TestApply(t *testing.T) {
+	t.Parallel()
+
+	t.Run("new file", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("sh", "-c", write, staged, "0440")
+		fake.Expect("visudo", "-c", "-q", "-f", staged)
+		fake.Expect("test", "-e", path).Return("", 1)
+		fake.Expect("mv", "-f", staged, path)
+		fake.Expect("visudo", "-c", "-q")
+
+		s := prepare(t, fake, &sudoers.Preparer{Name: "deploy", Content: content})
+		_, err := s.Apply()
+
+		require.NoError(t, err)
+		fake.AssertExpectations(t)
+		assert.Equal(t, content, fake.Calls()[0].Stdin)
+	})
+
+	t.Run("replace file", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("sh", "-c", write, staged, "0440")
+		fake.Expect("visudo", "-c", "-q", "-f", staged)
+		fake.Expect("test", "-e", path)
+		fake.Expect("cat", path).Return("old\n", 0)
+		fake.Expect("cp", "-p", path, backup)
+		fake.Expect("mv", "-f", staged, path)
+		fake.Expect("visudo", "-c", "-q")
+		fake.Expect("rm", "-f", backup)
+
+		s := prepare(t, fake, &sudoers.Preparer{Name: "deploy", Content: content})
+		_, err := s.Apply()
+
+		require.NoError(t, err)
+		fake.AssertExpectations(t)
+	})
+
+	t.Run("invalid file", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("sh", "-c", write, staged, "0440")
+		fake.Expect("visudo", "-c", "-q", "-f", staged).Stderr("syntax error near line 1").Return("", 1)
+		fake.Expect("rm", "-f", staged)
+
+		s := prepare(t, fake, &sudoers.Preparer{Name: "deploy", Content: content})
+		status, err := s.Apply()
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "syntax error near line 1")
+		assert.Equal(t, resource.StatusFatal, status.StatusCode())
+		fake.AssertExpectations(t)
+		for _, call := range fake.Calls() {
+			assert.NotEqual(t, "mv", call.Name, "invalid file must not be installed")
+		}
+	})
+
+	t.Run("invalid configuration restores backup", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("sh", "-c", write, staged, "0440")
+		fake.Expect("visudo", "-c", "-q", "-f", staged)
+		fake.Expect("test", "-e", path)
+		fake.Expect("cat", path).Return("old\n", 0)
+		fake.Expect("cp", "-p", path, backup)
+		fake.Expect("mv", "-f", staged, path)
+		fake.Expect("visudo", "-c", "-q").Return("", 1)
+		fake.Expect("mv", "-f", backup, path)
+
+		s := prepare(t, fake, &sudoers.Preparer{Name: "deploy", Content: content})
+		status, err := s.Apply()
+
+		require.Error(t, err)
+		assert.Contains(t, status.Messages(), "rolled back "+path)
+		fake.AssertExpectations(t)
+	})
+
+	t.Run("invalid configuration removes new file", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("sh", "-c", write, staged, "0440")
+		fake.Expect("visudo", "-c", "-q", "-f", staged)
+		fake.Expect("test", "-e", path).Return("", 1)
+		fake.Expect("mv", "-f", staged, path)
+		fake.Expect("visudo", "-c", "-q").Return("", 1)
+		fake.Expect("rm", "-f", path)
+
+		s := prepare(t, fake, &sudoers.Preparer{Name: "deploy", Content: content})
+		_, err := s.Apply()
+
+		require.Error(t, err)
+		fake.AssertExpectations(t)
+	})
+
+	t.Run("absent", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("rm", "-f", path)
+
+		s := prepare(t, fake, &sudoers.Preparer{Name: "deploy", State: sudoers.StateAbsent})
+		_, err := s.Apply()
+
+		require.NoError(t, err)
+		fake.AssertExpectations(t)
+	})
+}
+
+func prepare(t *testing.T, fake *fakeexec.Executor, p *sudoers.Preparer) *sudoers.Sudoers {
+	task, err := p.Prepare(&resource.ExecRenderer{Renderer: fakerenderer.New(), Exec: fake})
+	require.NoError(t, err)
+	return task.(*sudoers.Sudoers)
+}
@@ -0,0 +1,36 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package output
+
+import "github.com/asteris-llc/converge/resource"
+
+// Output is a value exported by a module
+type Output struct {
+	resource.Status
+
+	Value string
+}
+
+// Check returns the value of the output. It never has to change.
+func (o *Output) Check(resource.Renderer) (resource.TaskStatus, error) {
+	o.Status = resource.Status{Output: []string{o.Value}}
+
+	return o, nil
+}
+
+// Apply doesn't do anything since outputs are final values
+func (o *Output) Apply() (resource.TaskStatus, error) {
+	return o, nil
+}
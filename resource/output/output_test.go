@@ -0,0 +1,48 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package output_test
+
+import (
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/fakerenderer"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/output"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOutputInterface(t *testing.T) {
+	t.Parallel()
+
+	assert.Implements(t, (*resource.Task)(nil), new(output.Output))
+}
+
+func TestOutputCheck(t *testing.T) {
+	t.Parallel()
+
+	out := &output.Output{Value: "5432"}
+
+	status, err := out.Check(fakerenderer.New())
+	assert.NoError(t, err)
+	assert.Contains(t, status.Messages(), "5432")
+	assert.False(t, status.HasChanges())
+}
+
+func TestOutputApply(t *testing.T) {
+	t.Parallel()
+
+	_, err := new(output.Output).Apply()
+	assert.NoError(t, err)
+}
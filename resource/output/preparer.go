@@ -0,0 +1,41 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package output
+
+import (
+	"github.com/asteris-llc/converge/load/registry"
+	"github.com/asteris-llc/converge/resource"
+)
+
+// Preparer for outputs
+//
+// Output exports a value from a module. Outside the module, the value can be
+// looked up as `{{lookup "module.name.outputs.output-name"}}`, where
+// `module.name` is the module call. This saves passing values back up through
+// params.
+type Preparer struct {
+	// Value is the value to export, usually a lookup of a resource in the
+	// module.
+	Value string `hcl:"value"`
+}
+
+// Prepare a new task
+func (p *Preparer) Prepare(render resource.Renderer) (resource.Task, error) {
+	return &Output{Value: p.Value}, nil
+}
+
+func init() {
+	registry.Register("output", (*Preparer)(nil), (*Output)(nil))
+}
@@ -0,0 +1,162 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package choco
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/windows"
+)
+
+// State type for Package
+type State string
+
+const (
+	// StatePresent indicates the package should be present
+	StatePresent State = "present"
+
+	// StateAbsent indicates the package should be absent
+	StateAbsent State = "absent"
+)
+
+// commonArgs keep choco from prompting or drawing progress bars
+var commonArgs = []string{"--yes", "--no-progress", "--limit-output"}
+
+// Package manages a Chocolatey package
+type Package struct {
+	resource.Status
+
+	Name    string
+	Version string
+	Source  string
+	State   State
+
+	exec exec.Executor
+}
+
+// Check if the package is installed at the declared version
+func (p *Package) Check(resource.Renderer) (resource.TaskStatus, error) {
+	p.Status = resource.Status{}
+
+	installed, err := p.installedVersion()
+	if err != nil {
+		p.RaiseLevel(resource.StatusFatal)
+		return p, err
+	}
+
+	switch {
+	case p.State == StateAbsent && installed != "":
+		p.RaiseLevel(resource.StatusWillChange)
+		p.AddDifference(p.Name, installed, "<absent>", "")
+
+	case p.State == StatePresent && installed == "":
+		p.RaiseLevel(resource.StatusWillChange)
+		p.AddDifference(p.Name, "<absent>", p.wanted(), "")
+
+	case p.State == StatePresent && p.Version != "" && installed != p.Version:
+		p.RaiseLevel(resource.StatusWillChange)
+		p.AddDifference(p.Name, installed, p.Version, "")
+	}
+
+	return p, nil
+}
+
+// Apply installs, upgrades, or removes the package
+func (p *Package) Apply() (resource.TaskStatus, error) {
+	p.Status = resource.Status{}
+
+	installed, err := p.installedVersion()
+	if err != nil {
+		p.RaiseLevel(resource.StatusFatal)
+		return p, err
+	}
+
+	var verb string
+	var argv []string
+
+	switch {
+	case p.State == StateAbsent:
+		verb = "removed"
+		argv = append([]string{"choco", "uninstall", p.Name}, commonArgs...)
+
+	case installed == "":
+		verb = "installed"
+		argv = append([]string{"choco", "install", p.Name}, commonArgs...)
+		argv = append(argv, p.optionArgs()...)
+
+	default:
+		// choco install won't change the version of an installed package
+		verb = "changed"
+		argv = append([]string{"choco", "upgrade", p.Name}, commonArgs...)
+		argv = append(argv, p.optionArgs()...)
+		argv = append(argv, "--allow-downgrade")
+	}
+
+	_, reboot, err := windows.Run(p.exec, argv)
+	if err != nil {
+		p.RaiseLevel(resource.StatusFatal)
+		return p, fmt.Errorf("package.choco: could not change %s: %s", p.Name, err)
+	}
+
+	if verb == "changed" {
+		p.AddMessage(fmt.Sprintf("changed %s from %s to %s", p.Name, installed, p.Version))
+	} else {
+		p.AddMessage(fmt.Sprintf("%s %s", verb, p.Name))
+	}
+
+	if reboot {
+		p.RequireReboot(fmt.Sprintf("chocolatey package %s was %s", p.Name, verb))
+	}
+
+	return p, nil
+}
+
+// installedVersion returns the installed version of the package, or an empty
+// string if it isn't installed
+func (p *Package) installedVersion() (string, error) {
+	out, err := exec.Read(p.exec, "choco", "list", "--exact", "--limit-output", p.Name)
+	if err != nil {
+		return "", fmt.Errorf("package.choco: could not list %s: %s", p.Name, err)
+	}
+
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.SplitN(strings.TrimSpace(line), "|", 2)
+		if len(fields) == 2 && strings.EqualFold(fields[0], p.Name) {
+			return fields[1], nil
+		}
+	}
+
+	return "", nil
+}
+
+func (p *Package) optionArgs() (out []string) {
+	if p.Version != "" {
+		out = append(out, "--version", p.Version)
+	}
+	if p.Source != "" {
+		out = append(out, "--source", p.Source)
+	}
+	return out
+}
+
+func (p *Package) wanted() string {
+	if p.Version != "" {
+		return p.Version
+	}
+	return "<present>"
+}
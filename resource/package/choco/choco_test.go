@@ -0,0 +1,152 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package choco_test
+
+import (
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/fakeexec"
+	"github.com/asteris-llc/converge/helpers/fakerenderer"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/package/choco"
+	"github.com/asteris-llc/converge/resource/windows"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var list = []string{"choco", "list", "--exact", "--limit-output", "git"}
+
+// TestPackageInterface tests that Package is properly implemented
+func TestPackageInterface(t *testing.T) {
+	t.Parallel()
+
+	assert.Implements(t, (*resource.Task)(nil), new(choco.Package))
+	assert.Implements(t, (*resource.Resource)(nil), new(choco.Preparer))
+}
+
+func TestCheck(t *testing.T) {
+	t.Parallel()
+
+	for name, test := range map[string]struct {
+		preparer  *choco.Preparer
+		installed string
+		original  string
+		current   string
+	}{
+		"installed":           {&choco.Preparer{Name: "git"}, "git|2.43.0\r\n", "", ""},
+		"installed version":   {&choco.Preparer{Name: "git", Version: "2.43.0"}, "git|2.43.0\r\n", "", ""},
+		"case insensitive":    {&choco.Preparer{Name: "git"}, "Git|2.43.0\r\n", "", ""},
+		"missing":             {&choco.Preparer{Name: "git"}, "", "<absent>", "<present>"},
+		"missing version":     {&choco.Preparer{Name: "git", Version: "2.43.0"}, "", "<absent>", "2.43.0"},
+		"other version":       {&choco.Preparer{Name: "git", Version: "2.44.0"}, "git|2.43.0\r\n", "2.43.0", "2.44.0"},
+		"absent":              {&choco.Preparer{Name: "git", State: "absent"}, "", "", ""},
+		"absent but present":  {&choco.Preparer{Name: "git", State: "absent"}, "git|2.43.0\r\n", "2.43.0", "<absent>"},
+		"other package named": {&choco.Preparer{Name: "git"}, "git.install|2.43.0\r\n", "<absent>", "<present>"},
+	} {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			fake := fakeexec.New()
+			fake.Expect(list...).Return(test.installed, 0)
+
+			status, err := prepare(t, fake, test.preparer).Check(fakerenderer.New())
+			require.NoError(t, err)
+
+			if test.original == "" {
+				assert.False(t, status.HasChanges())
+				return
+			}
+
+			assert.True(t, status.HasChanges())
+			assert.Equal(t, test.original, status.Diffs()["git"].Original())
+			assert.Equal(t, test.current, status.Diffs()["git"].Current())
+		})
+	}
+
+	t.Run("choco error", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect(list...).Stderr("access denied").Return("", 1)
+
+		status, err := prepare(t, fake, &choco.Preparer{Name: "git"}).Check(fakerenderer.New())
+
+		assert.EqualError(t, err, "package.choco: could not list git: choco: exit status 1: access denied")
+		assert.Equal(t, resource.StatusFatal, status.StatusCode())
+	})
+}
+
+func TestApply(t *testing.T) {
+	t.Parallel()
+
+	t.Run("install", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect(list...).Return("", 0)
+		fake.Expect("choco", "install", "git", "--yes", "--no-progress", "--limit-output", "--version", "2.43.0", "--source", "internal").Return("", 0)
+
+		status, err := prepare(t, fake, &choco.Preparer{Name: "git", Version: "2.43.0", Source: "internal"}).Apply()
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"installed git"}, status.Messages())
+		fake.AssertExpectations(t)
+	})
+
+	t.Run("change version", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect(list...).Return("git|2.44.0\r\n", 0)
+		fake.Expect("choco", "upgrade", "git", "--yes", "--no-progress", "--limit-output", "--version", "2.43.0", "--allow-downgrade").Return("", 0)
+
+		status, err := prepare(t, fake, &choco.Preparer{Name: "git", Version: "2.43.0"}).Apply()
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"changed git from 2.44.0 to 2.43.0"}, status.Messages())
+	})
+
+	t.Run("remove", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect(list...).Return("git|2.44.0\r\n", 0)
+		fake.Expect("choco", "uninstall", "git", "--yes", "--no-progress", "--limit-output").Return("", 0)
+
+		status, err := prepare(t, fake, &choco.Preparer{Name: "git", State: "absent"}).Apply()
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"removed git"}, status.Messages())
+	})
+
+	t.Run("reboot required", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect(list...).Return("", 0)
+		fake.Expect("choco", "install", "git", "--yes", "--no-progress", "--limit-output").Return("", windows.ExitRebootRequired)
+
+		status, err := prepare(t, fake, &choco.Preparer{Name: "git"}).Apply()
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"chocolatey package git was installed"}, status.(*choco.Package).PendingReboots())
+	})
+
+	t.Run("failure", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect(list...).Return("", 0)
+		fake.Expect("choco", "install", "git", "--yes", "--no-progress", "--limit-output").Return("git not installed. The package was not found with the source(s) listed.\r\n", 1)
+
+		status, err := prepare(t, fake, &choco.Preparer{Name: "git"}).Apply()
+
+		assert.EqualError(t, err, "package.choco: could not change git: choco: exit status 1: git not installed. The package was not found with the source(s) listed.")
+		assert.Equal(t, resource.StatusFatal, status.StatusCode())
+	})
+}
+
+func prepare(t *testing.T, fake *fakeexec.Executor, p *choco.Preparer) *choco.Package {
+	task, err := p.Prepare(&resource.ExecRenderer{Renderer: fakerenderer.New(), Exec: fake})
+	require.NoError(t, err)
+	return task.(*choco.Package)
+}
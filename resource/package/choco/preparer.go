@@ -0,0 +1,64 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package choco
+
+import (
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/load/registry"
+	"github.com/asteris-llc/converge/resource"
+)
+
+// Preparer for Chocolatey Package
+//
+// Chocolatey Package manages Windows packages with `choco`. It assumes that
+// Chocolatey 2.0 or later is installed, where `choco list` lists the
+// packages installed locally, and that the user may install and remove
+// packages. A package that needs a reboot to finish is reported as a pending
+// reboot.
+type Preparer struct {
+	// Name of the package.
+	Name string `hcl:"name" required:"true" nonempty:"true"`
+
+	// Version of the package. If given, a different installed version is
+	// upgraded or downgraded to it. If not, any installed version is kept.
+	Version string `hcl:"version"`
+
+	// Source is the feed to install from, instead of the configured sources.
+	Source string `hcl:"source"`
+
+	// State of the package. Present means the package will be installed if
+	// missing; Absent means the package will be uninstalled if present.
+	State State `hcl:"state" valid_values:"present,absent" default:"present"`
+}
+
+// Prepare a new package
+func (p *Preparer) Prepare(render resource.Renderer) (resource.Task, error) {
+	if p.State == "" {
+		p.State = StatePresent
+	}
+
+	return &Package{
+		Name:    p.Name,
+		Version: p.Version,
+		Source:  p.Source,
+		State:   p.State,
+		exec:    exec.For(render),
+	}, nil
+}
+
+func init() {
+	registry.Register("package.choco", (*Preparer)(nil), (*Package)(nil))
+	registry.RegisterPlatforms("package.choco", "windows")
+}
@@ -0,0 +1,107 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pkg
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/resource"
+)
+
+// State type for Package
+type State string
+
+const (
+	// StatePresent indicates the package should be present
+	StatePresent State = "present"
+
+	// StateAbsent indicates the package should be absent
+	StateAbsent State = "absent"
+)
+
+// Package manages a FreeBSD package
+type Package struct {
+	resource.Status
+
+	Name       string
+	Repository string
+	State      State
+
+	exec exec.Executor
+}
+
+// Check if the package is installed
+func (p *Package) Check(resource.Renderer) (resource.TaskStatus, error) {
+	p.Status = resource.Status{}
+
+	installed, err := p.installedVersion()
+	if err != nil {
+		p.RaiseLevel(resource.StatusFatal)
+		return p, err
+	}
+
+	switch {
+	case p.State == StateAbsent && installed != "":
+		p.RaiseLevel(resource.StatusWillChange)
+		p.AddDifference(p.Name, installed, "<absent>", "")
+
+	case p.State == StatePresent && installed == "":
+		p.RaiseLevel(resource.StatusWillChange)
+		p.AddDifference(p.Name, "<absent>", "<present>", "")
+	}
+
+	return p, nil
+}
+
+// Apply installs or removes the package
+func (p *Package) Apply() (resource.TaskStatus, error) {
+	p.Status = resource.Status{}
+
+	verb := "installed"
+	args := []string{"install", "-y"}
+	if p.Repository != "" {
+		args = append(args, "-r", p.Repository)
+	}
+
+	if p.State == StateAbsent {
+		verb = "removed"
+		args = []string{"delete", "-y"}
+	}
+
+	if err := exec.Run(p.exec, "pkg", append(args, p.Name)...); err != nil {
+		p.RaiseLevel(resource.StatusFatal)
+		return p, fmt.Errorf("package.pkg: could not change %s: %s", p.Name, err)
+	}
+
+	p.AddMessage(fmt.Sprintf("%s %s", verb, p.Name))
+	return p, nil
+}
+
+// installedVersion returns the installed version of the package, or an empty
+// string if it isn't installed
+func (p *Package) installedVersion() (string, error) {
+	out, err := exec.Read(p.exec, "pkg", "query", "%v", p.Name)
+
+	// pkg query exits 1 when no installed package matches
+	if status, ok := exec.ExitStatus(err); ok && status == 1 {
+		return "", nil
+	} else if err != nil {
+		return "", fmt.Errorf("package.pkg: could not query %s: %s", p.Name, err)
+	}
+
+	return strings.TrimSpace(out), nil
+}
@@ -0,0 +1,132 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pkg_test
+
+import (
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/fakeexec"
+	"github.com/asteris-llc/converge/helpers/fakerenderer"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/package/pkg"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var query = []string{"pkg", "query", "%v", "nginx"}
+
+// TestPackageInterface tests that Package is properly implemented
+func TestPackageInterface(t *testing.T) {
+	t.Parallel()
+
+	assert.Implements(t, (*resource.Task)(nil), new(pkg.Package))
+	assert.Implements(t, (*resource.Resource)(nil), new(pkg.Preparer))
+}
+
+func TestCheck(t *testing.T) {
+	t.Parallel()
+
+	for name, test := range map[string]struct {
+		preparer *pkg.Preparer
+		version  string
+		status   int
+		original string
+		current  string
+	}{
+		"installed":          {&pkg.Preparer{Name: "nginx"}, "1.24.0_12,3\n", 0, "", ""},
+		"missing":            {&pkg.Preparer{Name: "nginx"}, "", 1, "<absent>", "<present>"},
+		"absent":             {&pkg.Preparer{Name: "nginx", State: "absent"}, "", 1, "", ""},
+		"absent but present": {&pkg.Preparer{Name: "nginx", State: "absent"}, "1.24.0_12,3\n", 0, "1.24.0_12,3", "<absent>"},
+	} {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			fake := fakeexec.New()
+			fake.Expect(query...).Return(test.version, test.status)
+
+			status, err := prepare(t, fake, test.preparer).Check(fakerenderer.New())
+			require.NoError(t, err)
+
+			if test.original == "" {
+				assert.False(t, status.HasChanges())
+				return
+			}
+
+			assert.True(t, status.HasChanges())
+			assert.Equal(t, test.original, status.Diffs()["nginx"].Original())
+			assert.Equal(t, test.current, status.Diffs()["nginx"].Current())
+		})
+	}
+
+	t.Run("pkg error", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect(query...).Stderr("pkg: Insufficient privileges to access the package database").Return("", 3)
+
+		status, err := prepare(t, fake, &pkg.Preparer{Name: "nginx"}).Check(fakerenderer.New())
+
+		assert.Error(t, err)
+		assert.Equal(t, resource.StatusFatal, status.StatusCode())
+	})
+
+	t.Run("without prompting", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect(query...).Return("", 1)
+
+		_, err := prepare(t, fake, &pkg.Preparer{Name: "nginx"}).Check(fakerenderer.New())
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"ASSUME_ALWAYS_YES=yes"}, fake.Calls()[0].Env)
+	})
+}
+
+func TestApply(t *testing.T) {
+	t.Parallel()
+
+	t.Run("install", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("pkg", "install", "-y", "-r", "internal", "nginx")
+
+		status, err := prepare(t, fake, &pkg.Preparer{Name: "nginx", Repository: "internal"}).Apply()
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"installed nginx"}, status.Messages())
+		fake.AssertExpectations(t)
+	})
+
+	t.Run("remove", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("pkg", "delete", "-y", "nginx")
+
+		status, err := prepare(t, fake, &pkg.Preparer{Name: "nginx", State: "absent"}).Apply()
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"removed nginx"}, status.Messages())
+	})
+
+	t.Run("failure", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("pkg", "install", "-y", "nginx").Stderr("pkg: No packages available to install matching 'nginx' have been found in the repositories").Return("", 1)
+
+		status, err := prepare(t, fake, &pkg.Preparer{Name: "nginx"}).Apply()
+
+		assert.EqualError(t, err, "package.pkg: could not change nginx: pkg: exit status 1: pkg: No packages available to install matching 'nginx' have been found in the repositories")
+		assert.Equal(t, resource.StatusFatal, status.StatusCode())
+	})
+}
+
+func prepare(t *testing.T, fake *fakeexec.Executor, p *pkg.Preparer) *pkg.Package {
+	task, err := p.Prepare(&resource.ExecRenderer{Renderer: fakerenderer.New(), Exec: fake})
+	require.NoError(t, err)
+	return task.(*pkg.Package)
+}
@@ -0,0 +1,59 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pkg
+
+import (
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/load/registry"
+	"github.com/asteris-llc/converge/resource"
+)
+
+// Preparer for pkg Package
+//
+// pkg Package manages FreeBSD binary packages with `pkg`. It assumes that the
+// user may install and remove packages. If pkg itself hasn't been installed
+// yet, it is bootstrapped without prompting.
+type Preparer struct {
+	// Name of the package, or its origin, like "www/nginx".
+	Name string `hcl:"name" required:"true" nonempty:"true"`
+
+	// Repository to install from, instead of any configured repository.
+	Repository string `hcl:"repository"`
+
+	// State of the package. Present means the package will be installed if
+	// missing; Absent means the package will be uninstalled if present.
+	State State `hcl:"state" valid_values:"present,absent" default:"present"`
+}
+
+// Prepare a new package
+func (p *Preparer) Prepare(render resource.Renderer) (resource.Task, error) {
+	if p.State == "" {
+		p.State = StatePresent
+	}
+
+	return &Package{
+		Name:       p.Name,
+		Repository: p.Repository,
+		State:      p.State,
+
+		// so that pkg doesn't ask before bootstrapping itself
+		exec: exec.NewEnvironment(exec.For(render), map[string]string{"ASSUME_ALWAYS_YES": "yes"}, ""),
+	}, nil
+}
+
+func init() {
+	registry.Register("package.pkg", (*Preparer)(nil), (*Package)(nil))
+	registry.RegisterPlatforms("package.pkg", "freebsd")
+}
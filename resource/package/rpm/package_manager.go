@@ -16,9 +16,8 @@ package rpm
 
 import (
 	"fmt"
-	"os/exec"
-	"syscall"
 
+	"github.com/asteris-llc/converge/helpers/exec"
 	"github.com/pkg/errors"
 )
 
@@ -45,13 +44,21 @@ type SysCaller interface {
 	Run(string) ([]byte, error)
 }
 
-// ExecCaller is a dummy struct to handle wrapping exec.Command in the SysCaller
-// interface.
-type ExecCaller struct{}
+// ExecCaller wraps an exec.Executor in the SysCaller interface
+type ExecCaller struct {
+	// Exec runs the package manager commands. If nil, commands are run on the
+	// local system.
+	Exec exec.Executor
+}
 
 // Run executs `cmd` as a /bin/sh script and returns the output and error
 func (e ExecCaller) Run(cmd string) ([]byte, error) {
-	return exec.Command("sh", "-c", cmd).Output()
+	executor := e.Exec
+	if executor == nil {
+		executor = exec.New()
+	}
+	out, err := exec.Read(executor, "sh", "-c", cmd)
+	return []byte(out), err
 }
 
 // YumManager provides a concrete implementation of PackageManager for yum
@@ -89,13 +96,9 @@ func getExitCode(err error) (uint32, error) {
 	if err == nil {
 		return 0, nil
 	}
-	exitErr, ok := err.(*exec.ExitError)
+	status, ok := exec.ExitStatus(err)
 	if !ok {
 		return 255, errors.Wrap(err, "not a valid exitError")
 	}
-	status, ok := exitErr.Sys().(syscall.WaitStatus)
-	if !ok {
-		return 255, errors.Wrap(err, "failed to get sys waitstatus")
-	}
-	return uint32(status.ExitStatus()), nil
+	return uint32(status), nil
 }
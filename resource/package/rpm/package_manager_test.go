@@ -19,6 +19,7 @@ import (
 	"os/exec"
 	"testing"
 
+	"github.com/asteris-llc/converge/helpers/fakeexec"
 	"github.com/asteris-llc/converge/resource/package/rpm"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -79,6 +80,28 @@ func TestYumInstallPackage(t *testing.T) {
 	})
 }
 
+// TestExecCaller validates that shell commands are run through the executor
+func TestExecCaller(t *testing.T) {
+	t.Parallel()
+
+	t.Run("when installed", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("sh", "-c", queryString("foo1")).Return("foo1-0.1.2", 0)
+		y := &rpm.YumManager{Sys: rpm.ExecCaller{Exec: fake}}
+		result, found := y.InstalledVersion("foo1")
+		assert.True(t, found)
+		assert.Equal(t, "foo1-0.1.2", string(result))
+	})
+
+	t.Run("when not installed", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("sh", "-c", queryString("foo1")).Return("", 1)
+		y := &rpm.YumManager{Sys: rpm.ExecCaller{Exec: fake}}
+		_, found := y.InstalledVersion("foo1")
+		assert.False(t, found)
+	})
+}
+
 // MockRunner mocks out SysCaller
 type MockRunner struct {
 	mock.Mock
@@ -15,6 +15,7 @@
 package rpm
 
 import (
+	"github.com/asteris-llc/converge/helpers/exec"
 	"github.com/asteris-llc/converge/load/registry"
 	"github.com/asteris-llc/converge/resource"
 )
@@ -38,10 +39,11 @@ func (p *Preparer) Prepare(render resource.Renderer) (resource.Task, error) {
 	return &Package{
 		Name:   p.Name,
 		State:  p.State,
-		PkgMgr: &YumManager{Sys: ExecCaller{}},
+		PkgMgr: &YumManager{Sys: ExecCaller{Exec: exec.For(render)}},
 	}, nil
 }
 
 func init() {
 	registry.Register("package.rpm", (*Preparer)(nil), (*Package)(nil))
+	registry.RegisterPlatforms("package.rpm", "linux")
 }
@@ -17,6 +17,7 @@ package resource
 import (
 	"fmt"
 	"reflect"
+	"regexp"
 	"strconv"
 	"strings"
 
@@ -68,6 +69,9 @@ func (p *Preparer) Prepare(r Renderer) (Task, error) {
 		return nil, err
 	}
 
+	// every field is tried, so that all the mistakes in a node are reported
+	// together
+	var fieldErrs []error
 	for i := 0; i < typ.NumField(); i++ {
 		field := typ.Field(i)
 		if field.Anonymous {
@@ -76,7 +80,17 @@ func (p *Preparer) Prepare(r Renderer) (Task, error) {
 
 		val, err := p.getValueForField(r, field)
 		if err != nil {
-			return nil, err
+			// the node is rendered again once an unresolvable value is known, so
+			// there's no point in going on
+			if isUnresolvable(err) {
+				return nil, err
+			}
+			// fields checked together, like mutually exclusive ones, fail the
+			// same way more than once
+			if !containsError(fieldErrs, err) {
+				fieldErrs = append(fieldErrs, err)
+			}
+			continue
 		}
 
 		fieldValue := value.Field(i)
@@ -85,6 +99,14 @@ func (p *Preparer) Prepare(r Renderer) (Task, error) {
 		}
 	}
 
+	switch len(fieldErrs) {
+	case 0:
+	case 1:
+		return nil, fieldErrs[0]
+	default:
+		return nil, multierror.Append(nil, fieldErrs...)
+	}
+
 	if wasPtr && value.CanAddr() {
 		value = value.Addr()
 	}
@@ -95,7 +117,35 @@ func (p *Preparer) Prepare(r Renderer) (Task, error) {
 		return nil, errors.New("unwrapped was not a Resource")
 	}
 
-	return resource.Prepare(r)
+	r, err := p.prepareExecution(resource, r)
+	if err != nil {
+		return nil, err
+	}
+
+	task, err := resource.Prepare(r)
+	if err != nil {
+		return task, err
+	}
+
+	task = p.prepareDeprecations(typ, task)
+
+	if task, err = p.prepareReady(r, task); err != nil {
+		return nil, err
+	}
+
+	if task, err = p.prepareIgnore(r, task); err != nil {
+		return nil, err
+	}
+
+	if task, err = p.prepareThrottle(r, task); err != nil {
+		return nil, err
+	}
+
+	if task, err = p.prepareFailure(r, task); err != nil {
+		return nil, err
+	}
+
+	return p.prepareCanary(r, task)
 }
 
 func (p *Preparer) validateExtra(typ reflect.Type) error {
@@ -111,6 +161,16 @@ func (p *Preparer) validateExtra(typ reflect.Type) error {
 	// add special fields
 	fieldNames["depends"] = struct{}{}
 	fieldNames["group"] = struct{}{}
+	fieldNames["ignore_depends"] = struct{}{}
+	fieldNames["ignore_changes"] = struct{}{}
+	fieldNames["throttle"] = struct{}{}
+	fieldNames["on_failure"] = struct{}{}
+	fieldNames["ready_when"] = struct{}{}
+	fieldNames["canary"] = struct{}{}
+	fieldNames["metadata"] = struct{}{}
+	for _, name := range p.executionFieldNames() {
+		fieldNames[name] = struct{}{}
+	}
 
 	var err error
 	for key := range p.Source {
@@ -140,6 +200,24 @@ func (p *Preparer) validateExtra(typ reflect.Type) error {
 	return err
 }
 
+// isUnresolvable tells if the error is from rendering a value that isn't known
+// yet, like render.ErrUnresolvable
+func isUnresolvable(err error) bool {
+	marked, ok := errors.Cause(err).(interface {
+		Unresolvable() bool
+	})
+	return ok && marked.Unresolvable()
+}
+
+func containsError(errs []error, err error) bool {
+	for _, existing := range errs {
+		if existing.Error() == err.Error() {
+			return true
+		}
+	}
+	return false
+}
+
 // getValueForField retrieves and converts the value for a given field
 func (p *Preparer) getValueForField(r Renderer, field reflect.StructField) (reflect.Value, error) {
 	// get the field name for use in future lookups
@@ -181,6 +259,14 @@ func (p *Preparer) getValueForField(r Renderer, field reflect.StructField) (refl
 		return reflect.Zero(field.Type), err
 	}
 
+	if err := p.validateRange(field, value); err != nil {
+		return reflect.Zero(field.Type), err
+	}
+
+	if err := p.validateRegex(field, value); err != nil {
+		return reflect.Zero(field.Type), err
+	}
+
 	return value, nil
 }
 
@@ -277,6 +363,144 @@ func (p *Preparer) validateValidValues(field reflect.StructField, r Renderer, ba
 	return nil
 }
 
+// validateRange detects if a number is outside the bounds given in the "min"
+// and "max" tags. Both bounds are inclusive, and every element is checked for
+// slices of numbers.
+func (p *Preparer) validateRange(field reflect.StructField, value reflect.Value) error {
+	min, hasMin := field.Tag.Lookup("min")
+	max, hasMax := field.Tag.Lookup("max")
+	if !hasMin && !hasMax {
+		return nil
+	}
+
+	name := p.getFieldName(field)
+
+	for _, elem := range p.elements(value) {
+		var tooLow, tooHigh bool
+
+		if hasMin {
+			cmp, err := p.compareNumber(elem, min)
+			if err != nil {
+				return errors.Wrapf(err, "invalid min tag for %q", name)
+			}
+			tooLow = cmp < 0
+		}
+
+		if hasMax {
+			cmp, err := p.compareNumber(elem, max)
+			if err != nil {
+				return errors.Wrapf(err, "invalid max tag for %q", name)
+			}
+			tooHigh = cmp > 0
+		}
+
+		if !tooLow && !tooHigh {
+			continue
+		}
+
+		switch {
+		case hasMin && hasMax:
+			return fmt.Errorf("%q must be between %s and %s, was %v", name, min, max, elem.Interface())
+		case hasMin:
+			return fmt.Errorf("%q must be at least %s, was %v", name, min, elem.Interface())
+		default:
+			return fmt.Errorf("%q must be at most %s, was %v", name, max, elem.Interface())
+		}
+	}
+
+	return nil
+}
+
+// compareNumber compares a numeric value to a bound, returning -1, 0, or 1 if
+// the value is less than, equal to, or greater than the bound
+func (p *Preparer) compareNumber(value reflect.Value, bound string) (int, error) {
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		b, err := strconv.ParseInt(bound, 10, 64)
+		if err != nil {
+			return 0, err
+		}
+		return compare(value.Int() < b, value.Int() > b), nil
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		b, err := strconv.ParseUint(bound, 10, 64)
+		if err != nil {
+			return 0, err
+		}
+		return compare(value.Uint() < b, value.Uint() > b), nil
+
+	case reflect.Float32, reflect.Float64:
+		b, err := strconv.ParseFloat(bound, 64)
+		if err != nil {
+			return 0, err
+		}
+		return compare(value.Float() < b, value.Float() > b), nil
+	}
+
+	return 0, fmt.Errorf("%s is not a number", value.Kind())
+}
+
+func compare(less, greater bool) int {
+	switch {
+	case less:
+		return -1
+	case greater:
+		return 1
+	}
+	return 0
+}
+
+// validateRegex detects if a string doesn't match the regular expression given
+// in the "regex" tag. The expression isn't anchored, so it should start with ^
+// and end with $ to match the whole value. Every element is checked for slices
+// of strings.
+func (p *Preparer) validateRegex(field reflect.StructField, value reflect.Value) error {
+	pattern, ok := field.Tag.Lookup("regex")
+	if !ok {
+		return nil
+	}
+
+	name := p.getFieldName(field)
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return errors.Wrapf(err, "invalid regex tag for %q", name)
+	}
+
+	for _, elem := range p.elements(value) {
+		if elem.Kind() != reflect.String {
+			return fmt.Errorf("invalid regex tag for %q: %s is not a string", name, elem.Kind())
+		}
+
+		if !re.MatchString(elem.String()) {
+			return fmt.Errorf("%q must match %q, was %q", name, pattern, elem.String())
+		}
+	}
+
+	return nil
+}
+
+// elements dereferences pointers and returns the elements of slices, so that
+// validations apply to each value given
+func (p *Preparer) elements(value reflect.Value) []reflect.Value {
+	for value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return nil
+		}
+		value = value.Elem()
+	}
+
+	if value.Kind() != reflect.Slice {
+		return []reflect.Value{value}
+	}
+
+	var out []reflect.Value
+	for i := 0; i < value.Len(); i++ {
+		out = append(out, p.elements(value.Index(i))...)
+	}
+	return out
+}
+
 // convertValue converts and returns the value of an individual element
 func (p *Preparer) convertValue(typ reflect.Type, r Renderer, name string, val interface{}, base int) (out reflect.Value, err error) {
 	switch typ.Kind() {
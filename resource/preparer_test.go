@@ -210,6 +210,58 @@ func TestPreparerPrepare(t *testing.T) {
 		})
 	})
 
+	// numbers can be bounded with min and max
+	t.Run("min and max", func(t *testing.T) {
+		t.Run("valid", func(t *testing.T) {
+			target := newWithField(t, "bounded", 10)
+			assert.Equal(t, 10, target.Bounded)
+
+			target = newWithField(t, "at_least", "2")
+			if assert.NotNil(t, target.AtLeast) {
+				assert.Equal(t, uint(2), *target.AtLeast)
+			}
+		})
+
+		for _, test := range []struct {
+			key   string
+			value interface{}
+			err   string
+		}{
+			{"bounded", 0, `"bounded" must be between 1 and 10, was 0`},
+			{"bounded", 11, `"bounded" must be between 1 and 10, was 11`},
+			{"at_least", 1, `"at_least" must be at least 2, was 1`},
+			{"at_most", 1.6, `"at_most" must be at most 1.5, was 1.6`},
+		} {
+			t.Run(fmt.Sprintf("invalid-%s-%v", test.key, test.value), func(t *testing.T) {
+				prep := &resource.Preparer{
+					Source:      map[string]interface{}{test.key: test.value},
+					Destination: new(testPreparerTarget),
+				}
+
+				_, err := prep.Prepare(fakerenderer.New())
+				assert.EqualError(t, err, test.err)
+			})
+		}
+	})
+
+	// strings can be matched against a regular expression
+	t.Run("regex", func(t *testing.T) {
+		t.Run("valid", func(t *testing.T) {
+			target := newWithField(t, "pattern", []string{"a", "bc"})
+			assert.Equal(t, []string{"a", "bc"}, target.Pattern)
+		})
+
+		t.Run("invalid", func(t *testing.T) {
+			prep := &resource.Preparer{
+				Source:      map[string]interface{}{"pattern": []string{"a", "B"}},
+				Destination: new(testPreparerTarget),
+			}
+
+			_, err := prep.Prepare(fakerenderer.New())
+			assert.EqualError(t, err, `"pattern" must match "^[a-z]+$", was "B"`)
+		})
+	})
+
 	// type aliases are important for enum-like behavior
 	t.Run("alias", func(t *testing.T) {
 		target := newWithField(t, "alias", "a")
@@ -254,6 +306,22 @@ func TestPreparerPrepare(t *testing.T) {
 			assert.EqualError(t, err, `only one of "a" or "b" can be set`)
 		})
 	})
+
+	// every invalid field is reported, not just the first
+	t.Run("several invalid", func(t *testing.T) {
+		prep := &resource.Preparer{
+			Source: map[string]interface{}{
+				"valid_values": "invalid",
+				"bounded":      11,
+			},
+			Destination: new(testPreparerTarget),
+		}
+
+		_, err := prep.Prepare(fakerenderer.New())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "value did not pass validation. Must be one of \"a\", was \"invalid\"")
+		assert.Contains(t, err.Error(), `"bounded" must be between 1 and 10, was 11`)
+	})
 }
 
 // testAlias is a type alias... can we deserialize those?
@@ -289,7 +357,11 @@ type testPreparerTarget struct {
 	Float64 float64 `hcl:"float64"`
 
 	// simple validation
-	ValidValues string `hcl:"valid_values" valid_values:"a"`
+	ValidValues string   `hcl:"valid_values" valid_values:"a"`
+	Bounded     int      `hcl:"bounded" min:"1" max:"10"`
+	AtLeast     *uint    `hcl:"at_least" min:"2"`
+	AtMost      float64  `hcl:"at_most" max:"1.5"`
+	Pattern     []string `hcl:"pattern" regex:"^[a-z]+$"`
 
 	// aliasing
 	Alias testAlias `hcl:"alias"`
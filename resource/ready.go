@@ -0,0 +1,328 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/helpers/ratelimit"
+	"github.com/pkg/errors"
+)
+
+// Defaults for the "ready_when" node setting
+const (
+	// DefaultReadyTimeout is how long a resource has to become ready
+	DefaultReadyTimeout = time.Minute
+
+	// DefaultReadyInterval is the time between readiness checks
+	DefaultReadyInterval = 2 * time.Second
+)
+
+// ReadyHTTPRateLimitProvider names "http" readiness checks in rate limits. They
+// are limited per host.
+const ReadyHTTPRateLimitProvider = "http"
+
+// ReadyKeys lists the keys accepted in a "ready_when" block
+var ReadyKeys = []string{"http", "http_status", "tcp", "command", "log", "log_file", "timeout", "interval"}
+
+// LogSource is implemented by tasks that can read the logs of what they run,
+// such as containers. "log" checks in "ready_when" match against them when no
+// "log_file" is given.
+type LogSource interface {
+	Logs(since time.Time) (string, error)
+}
+
+// logSource returns the LogSource of task, looking through the tasks that
+// wrap it, such as Deprecations
+func logSource(task Task) (LogSource, bool) {
+	for {
+		if source, ok := task.(LogSource); ok {
+			return source, true
+		}
+
+		wrapper, ok := task.(interface {
+			Unwrap() Task
+		})
+		if !ok {
+			return nil, false
+		}
+		task = wrapper.Unwrap()
+	}
+}
+
+// readiness holds the node-level setting that holds back dependents until a
+// resource is ready. Like "depends" and "group", it can be set on any
+// resource.
+type readiness struct {
+	// ReadyWhen is a block of checks that must all pass after the resource is
+	// applied, like `ready_when { http = "http://localhost:8080/health" }`.
+	ReadyWhen map[string]string `hcl:"ready_when"`
+}
+
+// Ready wraps a task so that Apply only returns once the checks in its
+// "ready_when" block pass, so dependents start after the resource is actually
+// serving rather than just started. The checks are polled every interval
+// until they pass or the timeout runs out, which fails the resource.
+type Ready struct {
+	Wrapped
+
+	readyHTTP       string
+	readyHTTPStatus int
+	readyTCP        string
+	readyCommand    string
+	readyLog        *regexp.Regexp
+	readyLogFile    string
+	readyTimeout    time.Duration
+	readyInterval   time.Duration
+	readyExec       exec.Executor
+}
+
+// Apply applies the wrapped task and waits for it to become ready
+func (r *Ready) Apply() (TaskStatus, error) {
+	start := time.Now()
+
+	status, err := r.Task.Apply()
+	if err != nil || (status != nil && status.StatusCode() == StatusFatal) {
+		return status, err
+	}
+
+	deadline := start.Add(r.readyTimeout)
+	for {
+		notReady := r.check(start)
+		if notReady == nil {
+			r.addMessage(status, fmt.Sprintf("ready after %s", roundDuration(time.Since(start))))
+			return status, nil
+		}
+
+		if time.Now().Add(r.readyInterval).After(deadline) {
+			if raiser, ok := status.(interface {
+				RaiseLevel(StatusLevel)
+			}); ok {
+				raiser.RaiseLevel(StatusFatal)
+			}
+			return status, errors.Wrapf(notReady, "not ready after %s", r.readyTimeout)
+		}
+
+		time.Sleep(r.readyInterval)
+	}
+}
+
+// check runs every readiness check, returning the first that fails
+func (r *Ready) check(since time.Time) error {
+	if r.readyHTTP != "" {
+		if err := r.checkHTTP(); err != nil {
+			return err
+		}
+	}
+
+	if r.readyTCP != "" {
+		conn, err := net.DialTimeout("tcp", r.readyTCP, r.readyInterval)
+		if err != nil {
+			return errors.Wrapf(err, "could not connect to %s", r.readyTCP)
+		}
+		conn.Close()
+	}
+
+	if r.readyCommand != "" {
+		if err := exec.Run(r.readyExec, "sh", "-c", r.readyCommand); err != nil {
+			return errors.Wrap(err, "ready command failed")
+		}
+	}
+
+	if r.readyLog != nil {
+		if err := r.checkLog(since); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *Ready) checkHTTP() error {
+	endpoint := r.readyHTTP
+	if parsed, err := url.Parse(r.readyHTTP); err == nil {
+		endpoint = parsed.Host
+	}
+	ratelimit.Wait(ReadyHTTPRateLimitProvider, endpoint)
+
+	client := &http.Client{Timeout: r.readyInterval}
+	resp, err := client.Get(r.readyHTTP)
+	if err != nil {
+		return errors.Wrapf(err, "could not get %s", r.readyHTTP)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != r.readyHTTPStatus {
+		return fmt.Errorf("%s returned status %d, expected %d", r.readyHTTP, resp.StatusCode, r.readyHTTPStatus)
+	}
+	return nil
+}
+
+// checkLog looks for the log pattern in the log file if there is one, or
+// otherwise in the logs the task has written since it was applied
+func (r *Ready) checkLog(since time.Time) error {
+	var logs string
+	if r.readyLogFile != "" {
+		content, exists, err := exec.ReadFile(r.readyExec, r.readyLogFile)
+		if err != nil {
+			return errors.Wrapf(err, "could not read %s", r.readyLogFile)
+		}
+		if !exists {
+			return fmt.Errorf("%s does not exist", r.readyLogFile)
+		}
+		logs = content
+	} else {
+		source, _ := logSource(r.Task)
+		content, err := source.Logs(since)
+		if err != nil {
+			return errors.Wrap(err, "could not read logs")
+		}
+		logs = content
+	}
+
+	if !r.readyLog.MatchString(logs) {
+		return fmt.Errorf("logs do not match %q", r.readyLog)
+	}
+	return nil
+}
+
+func (r *Ready) addMessage(status TaskStatus, message string) {
+	if adder, ok := status.(interface {
+		AddMessage(...string)
+	}); ok {
+		adder.AddMessage(message)
+	}
+}
+
+func roundDuration(d time.Duration) time.Duration {
+	if d < time.Second {
+		return d - d%time.Millisecond
+	}
+	return d - d%(100*time.Millisecond)
+}
+
+// prepareReady wraps task in a Ready if the node sets "ready_when"
+func (p *Preparer) prepareReady(r Renderer, task Task) (Task, error) {
+	field, _ := reflect.TypeOf(readiness{}).FieldByName("ReadyWhen")
+	if _, ok := p.Source[p.getFieldName(field)]; !ok {
+		return task, nil
+	}
+
+	val, err := p.getValueForField(r, field)
+	if err != nil {
+		return nil, err
+	}
+	settings := val.Interface().(map[string]string)
+
+	if err := validReadyKeys(settings); err != nil {
+		return nil, err
+	}
+
+	ready := &Ready{
+		Wrapped:         Wrapped{Task: task},
+		readyHTTP:       settings["http"],
+		readyHTTPStatus: http.StatusOK,
+		readyTCP:        settings["tcp"],
+		readyCommand:    settings["command"],
+		readyLogFile:    settings["log_file"],
+		readyTimeout:    DefaultReadyTimeout,
+		readyInterval:   DefaultReadyInterval,
+		readyExec:       exec.For(r),
+	}
+
+	if ready.readyHTTP == "" && ready.readyTCP == "" && ready.readyCommand == "" && settings["log"] == "" {
+		return nil, errors.New("ready_when needs at least one of \"http\", \"tcp\", \"command\" or \"log\"")
+	}
+
+	if status, ok := settings["http_status"]; ok {
+		if ready.readyHTTP == "" {
+			return nil, errors.New("ready_when \"http_status\" needs \"http\"")
+		}
+		if ready.readyHTTPStatus, err = strconv.Atoi(status); err != nil {
+			return nil, errors.Wrap(err, "could not parse ready_when \"http_status\"")
+		}
+	}
+
+	if ready.readyTCP != "" {
+		if _, _, err := net.SplitHostPort(ready.readyTCP); err != nil {
+			return nil, errors.Wrap(err, "ready_when \"tcp\" must be host:port")
+		}
+	}
+
+	if pattern, ok := settings["log"]; ok {
+		if ready.readyLog, err = regexp.Compile(pattern); err != nil {
+			return nil, errors.Wrap(err, "could not parse ready_when \"log\"")
+		}
+		if _, ok := logSource(task); !ok && ready.readyLogFile == "" {
+			return nil, errors.New("ready_when \"log\" needs \"log_file\" for resources that don't provide logs")
+		}
+	} else if ready.readyLogFile != "" {
+		return nil, errors.New("ready_when \"log_file\" needs \"log\"")
+	}
+
+	if ready.readyTimeout, err = readyDuration(settings, "timeout", DefaultReadyTimeout); err != nil {
+		return nil, err
+	}
+	if ready.readyInterval, err = readyDuration(settings, "interval", DefaultReadyInterval); err != nil {
+		return nil, err
+	}
+
+	return ready, nil
+}
+
+func validReadyKeys(settings map[string]string) error {
+	var invalid []string
+	for key := range settings {
+		valid := false
+		for _, known := range ReadyKeys {
+			valid = valid || key == known
+		}
+		if !valid {
+			invalid = append(invalid, strconv.Quote(key))
+		}
+	}
+
+	if len(invalid) > 0 {
+		sort.Strings(invalid)
+		return fmt.Errorf("ready_when has no %s, expected %s", strings.Join(invalid, ", "), strings.Join(ReadyKeys, ", "))
+	}
+	return nil
+}
+
+func readyDuration(settings map[string]string, key string, def time.Duration) (time.Duration, error) {
+	raw, ok := settings[key]
+	if !ok {
+		return def, nil
+	}
+
+	d, err := time.ParseDuration(raw)
+	if err != nil {
+		return 0, errors.Wrapf(err, "could not parse ready_when %q", key)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("ready_when %q must be positive, got %s", key, d)
+	}
+	return d, nil
+}
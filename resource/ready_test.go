@@ -0,0 +1,223 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource_test
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/asteris-llc/converge/helpers/fakeexec"
+	"github.com/asteris-llc/converge/helpers/fakerenderer"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestPreparerReadyWhen tests that resources with ready_when only finish
+// applying once their checks pass
+func TestPreparerReadyWhen(t *testing.T) {
+	t.Parallel()
+
+	prepare := func(t *testing.T, fake *fakeexec.Executor, target resource.Resource, ready map[string]interface{}) resource.Task {
+		ready["interval"] = "10ms"
+		if _, ok := ready["timeout"]; !ok {
+			ready["timeout"] = "5s"
+		}
+
+		prep := resource.NewPreparerWithSource(target, map[string]interface{}{"ready_when": ready})
+		task, err := prep.Prepare(&resource.ExecRenderer{Renderer: fakerenderer.New(), Exec: fake})
+		require.NoError(t, err)
+		return task
+	}
+
+	t.Run("unset", func(t *testing.T) {
+		target := new(testThrottleTarget)
+		task, err := resource.NewPreparerWithSource(target, map[string]interface{}{}).Prepare(fakerenderer.New())
+		require.NoError(t, err)
+		assert.Equal(t, target, task)
+	})
+
+	t.Run("http", func(t *testing.T) {
+		var requests int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if atomic.AddInt32(&requests, 1) < 3 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}
+		}))
+		defer server.Close()
+
+		task := prepare(t, fakeexec.New(), new(testThrottleTarget), map[string]interface{}{"http": server.URL})
+		status, err := task.Apply()
+		require.NoError(t, err)
+		assert.EqualValues(t, 3, atomic.LoadInt32(&requests))
+		require.Len(t, status.Messages(), 1)
+		assert.True(t, strings.HasPrefix(status.Messages()[0], "ready after "))
+	})
+
+	t.Run("http status", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer server.Close()
+
+		task := prepare(t, fakeexec.New(), new(testThrottleTarget), map[string]interface{}{
+			"http":        server.URL,
+			"http_status": "503",
+		})
+		_, err := task.Apply()
+		assert.NoError(t, err)
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer server.Close()
+
+		task := prepare(t, fakeexec.New(), new(testThrottleTarget), map[string]interface{}{
+			"http":    server.URL,
+			"timeout": "50ms",
+		})
+		status, err := task.Apply()
+		require.Error(t, err)
+		assert.Equal(t, "not ready after 50ms: "+server.URL+" returned status 503, expected 200", err.Error())
+		assert.Equal(t, resource.StatusFatal, status.StatusCode())
+	})
+
+	t.Run("tcp", func(t *testing.T) {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer listener.Close()
+
+		task := prepare(t, fakeexec.New(), new(testThrottleTarget), map[string]interface{}{"tcp": listener.Addr().String()})
+		_, err = task.Apply()
+		assert.NoError(t, err)
+	})
+
+	t.Run("command", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("sh", "-c", "pg_isready").Return("", 1).Once()
+		fake.Expect("sh", "-c", "pg_isready")
+
+		task := prepare(t, fake, new(testThrottleTarget), map[string]interface{}{"command": "pg_isready"})
+		_, err := task.Apply()
+		require.NoError(t, err)
+		assert.Len(t, fake.Calls(), 2)
+	})
+
+	t.Run("log file", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("test", "-e", "/var/log/app.log")
+		fake.Expect("cat", "/var/log/app.log").Return("starting\nlistening on :8080\n", 0)
+
+		task := prepare(t, fake, new(testThrottleTarget), map[string]interface{}{
+			"log":      "listening on",
+			"log_file": "/var/log/app.log",
+		})
+		_, err := task.Apply()
+		assert.NoError(t, err)
+	})
+
+	t.Run("log source", func(t *testing.T) {
+		target := &testReadyLogTarget{logs: []string{"starting", "starting\nready to accept connections"}}
+
+		task := prepare(t, fakeexec.New(), target, map[string]interface{}{"log": "ready to accept"})
+		_, err := task.Apply()
+		require.NoError(t, err)
+		assert.Equal(t, 2, target.reads)
+		assert.WithinDuration(t, time.Now(), target.since, time.Minute)
+	})
+
+	t.Run("log source with deprecated fields", func(t *testing.T) {
+		target := &testDeprecatedLogTarget{
+			testReadyLogTarget: testReadyLogTarget{logs: []string{"ready to accept connections"}},
+		}
+
+		prep := resource.NewPreparerWithSource(target, map[string]interface{}{
+			"links":      []string{"db"},
+			"ready_when": map[string]interface{}{"log": "ready to accept", "interval": "10ms"},
+		})
+		task, err := prep.Prepare(fakerenderer.New())
+		require.NoError(t, err)
+
+		_, err = task.Apply()
+		require.NoError(t, err)
+		assert.Equal(t, 1, target.reads)
+	})
+
+	t.Run("failed apply is not checked", func(t *testing.T) {
+		fake := fakeexec.New()
+		target := &testThrottleTarget{fail: true}
+
+		task := prepare(t, fake, target, map[string]interface{}{"command": "true"})
+		_, err := task.Apply()
+		assert.Equal(t, assert.AnError, err)
+		assert.Empty(t, fake.Calls())
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for msg, ready := range map[string]map[string]interface{}{
+			"ready_when needs at least one of \"http\", \"tcp\", \"command\" or \"log\"":                            {"timeout": "1m"},
+			"ready_when has no \"url\", expected http, http_status, tcp, command, log, log_file, timeout, interval": {"url": "http://localhost"},
+			"ready_when \"log\" needs \"log_file\" for resources that don't provide logs":                           {"log": "ready"},
+			"ready_when \"http_status\" needs \"http\"":                                                             {"tcp": "localhost:80", "http_status": "200"},
+			"ready_when \"timeout\" must be positive, got -1s":                                                      {"tcp": "localhost:80", "timeout": "-1s"},
+		} {
+			prep := resource.NewPreparerWithSource(new(testThrottleTarget), map[string]interface{}{"ready_when": ready})
+			_, err := prep.Prepare(fakerenderer.New())
+			assert.EqualError(t, err, msg)
+		}
+
+		prep := resource.NewPreparerWithSource(new(testThrottleTarget), map[string]interface{}{
+			"ready_when": map[string]interface{}{"tcp": "localhost"},
+		})
+		_, err := prep.Prepare(fakerenderer.New())
+		assert.Error(t, err)
+	})
+}
+
+type testReadyLogTarget struct {
+	testThrottleTarget
+
+	logs  []string
+	reads int
+	since time.Time
+}
+
+func (trlt *testReadyLogTarget) Prepare(resource.Renderer) (resource.Task, error) {
+	return trlt, nil
+}
+
+func (trlt *testReadyLogTarget) Logs(since time.Time) (string, error) {
+	trlt.since = since
+	out := trlt.logs[trlt.reads]
+	trlt.reads++
+	return out, nil
+}
+
+type testDeprecatedLogTarget struct {
+	testReadyLogTarget
+
+	Links []string `hcl:"links" deprecated:"use networks instead"`
+}
+
+func (tdlt *testDeprecatedLogTarget) Prepare(resource.Renderer) (resource.Task, error) {
+	return &tdlt.testReadyLogTarget, nil
+}
@@ -0,0 +1,301 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/asteris-llc/converge/load/registry"
+)
+
+// Reference documents a resource for the people writing modules. It is built
+// from the struct tags of the resource's preparer, and from the comments in
+// the preparer's source when that can be found.
+type Reference struct {
+	Name   string
+	Doc    string
+	Fields []*FieldReference
+
+	// Platforms are the operating systems the resource works on. It is empty
+	// if the resource works anywhere.
+	Platforms []string
+}
+
+// FieldReference documents a single field of a preparer
+type FieldReference struct {
+	Name              string
+	Type              string
+	Doc               string
+	Required          bool
+	Default           string
+	Base              string
+	Min               string
+	Max               string
+	Regex             string
+	MutuallyExclusive []string
+	ValidValues       []string
+}
+
+// NewReference documents the preparer registered under name. Comments are read
+// from the preparer's package in GOPATH, and are left empty if the source
+// isn't there.
+func NewReference(name string, preparer interface{}) (*Reference, error) {
+	typ := reflect.TypeOf(preparer)
+	for typ != nil && typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ == nil || typ.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("%s: can only document structs", name)
+	}
+
+	comments, err := readComments(typ)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", name, err)
+	}
+
+	ref := &Reference{
+		Name:      name,
+		Doc:       stripPreparerHeading(comments[""]),
+		Platforms: registry.Platforms(name),
+	}
+
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if field.Anonymous || field.PkgPath != "" {
+			continue
+		}
+
+		ref.Fields = append(ref.Fields, newFieldReference(field, comments[field.Name]))
+	}
+
+	return ref, nil
+}
+
+func newFieldReference(field reflect.StructField, doc string) *FieldReference {
+	ref := &FieldReference{
+		Name:    field.Name,
+		Type:    describeType(field.Type),
+		Doc:     doc,
+		Default: field.Tag.Get("default"),
+		Base:    field.Tag.Get("base"),
+		Min:     field.Tag.Get("min"),
+		Max:     field.Tag.Get("max"),
+		Regex:   field.Tag.Get("regex"),
+	}
+
+	if hcl, ok := field.Tag.Lookup("hcl"); ok {
+		ref.Name = strings.Split(hcl, ",")[0]
+	}
+
+	if docType, ok := field.Tag.Lookup("doc_type"); ok {
+		ref.Type = docType
+	}
+
+	ref.Required = field.Tag.Get("required") == "true"
+
+	if exclusive, ok := field.Tag.Lookup("mutually_exclusive"); ok {
+		ref.MutuallyExclusive = strings.Split(exclusive, ",")
+	}
+
+	if valid, ok := field.Tag.Lookup("valid_values"); ok {
+		ref.ValidValues = strings.Split(valid, ",")
+	}
+
+	return ref
+}
+
+// Exports documents the fields of a task that can be used in a lookup, with
+// names as they are written there. Fields of embedded structs are included,
+// since lookups reach them without naming the embedded struct.
+func Exports(task interface{}) ([]*FieldReference, error) {
+	typ := reflect.TypeOf(task)
+	for typ != nil && typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ == nil || typ.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("%T: can only document structs", task)
+	}
+
+	var out []*FieldReference
+	seen := map[string]bool{}
+	if err := addExports(&out, seen, typ); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func addExports(out *[]*FieldReference, seen map[string]bool, typ reflect.Type) error {
+	comments, err := readComments(typ)
+	if err != nil {
+		return err
+	}
+
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if field.PkgPath != "" || !exportable(field.Type) {
+			continue
+		}
+
+		if field.Anonymous {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+
+			// Status only carries the messages shown in the output
+			if embedded == reflect.TypeOf(Status{}) {
+				continue
+			}
+
+			if embedded.Kind() == reflect.Struct {
+				if err := addExports(out, seen, embedded); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+
+		name := strings.ToLower(field.Name)
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		*out = append(*out, &FieldReference{
+			Name: name,
+			Type: describeType(field.Type),
+			Doc:  comments[field.Name],
+		})
+	}
+
+	return nil
+}
+
+// exportable is false for fields that only make sense to the task itself,
+// like functions and interfaces it runs commands through
+func exportable(typ reflect.Type) bool {
+	switch typ.Kind() {
+	case reflect.Func, reflect.Chan:
+		return false
+	case reflect.Interface:
+		return typ.NumMethod() == 0
+	}
+	return true
+}
+
+// describeType names a type the way it is written in HCL
+func describeType(typ reflect.Type) string {
+	switch typ.Kind() {
+	case reflect.Ptr:
+		return "optional " + describeType(typ.Elem())
+
+	case reflect.Slice:
+		return "list of " + describeType(typ.Elem()) + "s"
+
+	case reflect.Map:
+		return fmt.Sprintf("map of %s to %s", describeType(typ.Key()), describeType(typ.Elem()))
+
+	case reflect.Interface:
+		return "anything"
+	}
+
+	return typ.Kind().String()
+}
+
+// stripPreparerHeading removes the "Preparer for X" line that starts the doc
+// comment of most preparers
+func stripPreparerHeading(doc string) string {
+	if !strings.HasPrefix(doc, "Preparer for") {
+		return doc
+	}
+
+	parts := strings.SplitN(doc, "\n\n", 2)
+	if len(parts) < 2 {
+		return ""
+	}
+	return parts[1]
+}
+
+// readComments finds the doc comments of a struct and its fields in the source
+// of its package. The comment on the struct itself is keyed by "".
+func readComments(typ reflect.Type) (map[string]string, error) {
+	comments := map[string]string{}
+
+	pkg, err := build.Import(typ.PkgPath(), "", build.FindOnly)
+	if err != nil {
+		// no source to read, so go without
+		return comments, nil
+	}
+
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(
+		fset,
+		pkg.Dir,
+		func(info os.FileInfo) bool { return !strings.HasSuffix(info.Name(), "_test.go") },
+		parser.ParseComments,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, astPkg := range pkgs {
+		for _, file := range astPkg.Files {
+			for _, decl := range file.Decls {
+				gen, ok := decl.(*ast.GenDecl)
+				if !ok || gen.Tok != token.TYPE {
+					continue
+				}
+
+				for _, spec := range gen.Specs {
+					ts, ok := spec.(*ast.TypeSpec)
+					if !ok || ts.Name.Name != typ.Name() {
+						continue
+					}
+
+					st, ok := ts.Type.(*ast.StructType)
+					if !ok {
+						continue
+					}
+
+					comments[""] = commentText(gen.Doc, ts.Doc)
+					for _, field := range st.Fields.List {
+						for _, name := range field.Names {
+							comments[name.Name] = commentText(field.Doc, field.Comment)
+						}
+					}
+				}
+			}
+		}
+	}
+
+	return comments, nil
+}
+
+func commentText(groups ...*ast.CommentGroup) string {
+	var out []string
+	for _, group := range groups {
+		if group != nil {
+			out = append(out, strings.TrimSpace(group.Text()))
+		}
+	}
+	return strings.Join(out, "\n\n")
+}
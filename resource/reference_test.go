@@ -0,0 +1,106 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource_test
+
+import (
+	"testing"
+
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/group"
+	"github.com/asteris-llc/converge/resource/shell/query"
+	"github.com/asteris-llc/converge/resource/user"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewReference(t *testing.T) {
+	t.Parallel()
+
+	fields := func(ref *resource.Reference) map[string]*resource.FieldReference {
+		out := map[string]*resource.FieldReference{}
+		for _, field := range ref.Fields {
+			out[field.Name] = field
+		}
+		return out
+	}
+
+	t.Run("tags", func(t *testing.T) {
+		ref, err := resource.NewReference("user.user", new(user.Preparer))
+		require.NoError(t, err)
+
+		byName := fields(ref)
+		assert.True(t, byName["username"].Required)
+		assert.Equal(t, "optional uint32", byName["uid"].Type)
+		assert.Equal(t, "4294967294", byName["uid"].Max)
+		assert.Equal(t, "-1", byName["inactive"].Min)
+		assert.Equal(t, []string{"gid", "groupname"}, byName["gid"].MutuallyExclusive)
+		assert.Equal(t, []string{"present", "absent"}, byName["state"].ValidValues)
+		assert.Equal(t, "string", byName["state"].Type)
+	})
+
+	t.Run("comments", func(t *testing.T) {
+		ref, err := resource.NewReference("user.group", new(group.Preparer))
+		require.NoError(t, err)
+
+		assert.Equal(
+			t,
+			"Group renders group data. On Linux, groups are managed with groupadd and the\n"+
+				"other shadow tools, with busybox's addgroup where those are missing, or by\n"+
+				"editing /etc/group and /etc/gshadow directly where neither is installed.",
+			ref.Doc,
+		)
+
+		byName := fields(ref)
+		assert.Equal(t, "list of strings", byName["members"].Type)
+		assert.Contains(t, byName["members"].Doc, "complete list of users")
+	})
+
+	t.Run("not a struct", func(t *testing.T) {
+		_, err := resource.NewReference("bad", "string")
+		assert.EqualError(t, err, "bad: can only document structs")
+	})
+}
+
+func TestExports(t *testing.T) {
+	t.Parallel()
+
+	exports, err := resource.Exports(new(query.Query))
+	require.NoError(t, err)
+
+	byName := map[string]*resource.FieldReference{}
+	for _, field := range exports {
+		byName[field.Name] = field
+	}
+
+	t.Run("embedded", func(t *testing.T) {
+		if assert.Contains(t, byName, "stdout") {
+			assert.Equal(t, "string", byName["stdout"].Type)
+		}
+		assert.NotContains(t, byName, "shell")
+	})
+
+	t.Run("own fields", func(t *testing.T) {
+		if assert.Contains(t, byName, "result") {
+			assert.Equal(t, "anything", byName["result"].Type)
+			assert.Contains(t, byName["result"].Doc, "parsed output of the query")
+		}
+	})
+
+	t.Run("internals", func(t *testing.T) {
+		assert.NotContains(t, byName, "cmdgenerator")
+		assert.NotContains(t, byName, "exec")
+		assert.NotContains(t, byName, "renderer")
+	})
+}
@@ -15,31 +15,22 @@
 package shell
 
 import (
-	"io"
-	"io/ioutil"
+	"context"
 	"os"
-	"os/exec"
-	"syscall"
 	"time"
 
 	log "github.com/Sirupsen/logrus"
+	"github.com/asteris-llc/converge/helpers/exec"
 	"github.com/pkg/errors"
 )
 
 // NB: Known Bug with timed script execution:
 
 // Currently when a script executes beyond it's alloted time a timeout will
-// occur and nil is returned by timeoutExec.  The goroutine running the script
-// will continue to drain stdout and stderr from the process sockets and they
-// will be GCed when the script finally finishes.  This means that there is no
-// mechanism for getting the output of a script when it has timed out.  A
-// proposed solution to this would be to implement a ReadUntilWouldBlock-type
-// function that would allow us to read into a buffer from a ReadCloser until
-// the read operation would block, then return the contents of the buffer (along
-// with some marker if we recevied an error or EOF).  Then exec function would
-// then take in pointers to buffers for stdout and stderr and populate them
-// directly, so that if the script execution timed out we would still have a
-// reference to those buffers.
+// occur and nil is returned by timeoutExec. The script is killed, along with
+// anything it started, but whatever it wrote before then is discarded. This
+// means that there is no mechanism for getting the output of a script when it
+// has timed out.
 var (
 	ErrTimedOut = errors.New("execution timed out")
 )
@@ -57,55 +48,40 @@ type CommandGenerator struct {
 	Dir         string
 	Env         []string
 	Timeout     *time.Duration
-}
 
-// Run will generate a new command and run it with optional timeout parameters
-func (cmd *CommandGenerator) Run(script string) (*CommandResults, error) {
-	ctx, err := cmd.start()
-	if err != nil {
-		return nil, err
-	}
-	return ctx.Run(script, cmd.Timeout)
-}
+	// Stdin is written to the standard input of the command. If set, the
+	// script is passed as an argument to -c instead.
+	Stdin string
 
-func (cmd *CommandGenerator) start() (*commandIOContext, error) {
-	command := newCommand(cmd)
-	stdin, stdout, stderr, err := cmdGetPipes(command)
-	return &commandIOContext{
-		Command: command,
-		Stdin:   stdin,
-		Stdout:  stdout,
-		Stderr:  stderr,
-	}, err
-}
+	// Umask is the octal umask the command is run with
+	Umask string
+
+	// Output, if set, is called with each line of output as it is written
+	Output func(stream, line string) `hash:"ignore" json:"-"`
 
-// commandIOContext provides the context for a command that includes it's stdin,
-// stdout, and stderr pipes along with the underlying command.
-type commandIOContext struct {
-	Command *exec.Cmd
-	Stdin   io.WriteCloser
-	Stdout  io.ReadCloser
-	Stderr  io.ReadCloser
+	// Exec runs the generated command. If nil, the command is run on the local
+	// system.
+	Exec exec.Executor
 }
 
-// Run wraps exec and timeoutExec, executing the script with or without a
-// timeout depending whether or not timeout is nil.
-func (c *commandIOContext) Run(script string, timeout *time.Duration) (results *CommandResults, err error) {
-	if timeout == nil {
-		results, err = c.exec(script)
-	} else {
-		results, err = c.timeoutExec(script, *timeout)
+// Run will generate a new command and run it with optional timeout parameters
+func (cmd *CommandGenerator) Run(script string) (*CommandResults, error) {
+	if cmd.Timeout == nil {
+		return cmd.exec(context.Background(), script)
 	}
-	return
+	return cmd.timeoutExec(script, *cmd.Timeout)
 }
 
 // timeoutExec will run the given script with a timelimit specified by
-// timeout. If the script does not return with that time duration a
-// ScriptTimeoutError is returned.
-func (c *commandIOContext) timeoutExec(script string, timeout time.Duration) (*CommandResults, error) {
+// timeout. If the script does not return with that time duration it is
+// killed and ErrTimedOut is returned.
+func (cmd *CommandGenerator) timeoutExec(script string, timeout time.Duration) (*CommandResults, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
 	timeoutChannel := make(chan []interface{}, 1)
 	go func() {
-		cmdResults, err := c.exec(script)
+		cmdResults, err := cmd.exec(ctx, script)
 		timeoutChannel <- []interface{}{cmdResults, err}
 	}()
 	select {
@@ -120,16 +96,23 @@ func (c *commandIOContext) timeoutExec(script string, timeout time.Duration) (*C
 	}
 }
 
-func (c *commandIOContext) exec(script string) (results *CommandResults, err error) {
-	results = &CommandResults{
+// exec runs the script, killing it if ctx is done first
+func (cmd *CommandGenerator) exec(ctx context.Context, script string) (*CommandResults, error) {
+	results := &CommandResults{
 		Stdin: script,
 	}
 
+	executor := cmd.Exec
+	if executor == nil {
+		executor = exec.New()
+	}
+
 	// if working dir does not exist, we want the check to return a non-zero
-	// result. otherwise, Command.Start will return an error and short-circuit
-	// plan/apply
-	if c.Command.Dir != "" {
-		_, err = os.Stat(c.Command.Dir)
+	// result. otherwise, running the command will return an error and
+	// short-circuit plan/apply. Targets with their own filesystem fail the
+	// command themselves when they can't change into the directory.
+	if cmd.Dir != "" && exec.Local(executor) {
+		_, err := os.Stat(cmd.Dir)
 		if os.IsNotExist(err) {
 			results.ExitStatus = 1
 			results.Stdout = err.Error()
@@ -137,64 +120,55 @@ func (c *commandIOContext) exec(script string) (results *CommandResults, err err
 		}
 	}
 
-	if err = c.Command.Start(); err != nil {
-		return
-	}
-	if _, err = c.Stdin.Write([]byte(script)); err != nil {
-		return
-	}
-	if err = c.Stdin.Close(); err != nil {
-		return
+	command := cmd.command(script)
+	command.Context = ctx
+	if cmd.Output != nil {
+		stdout := exec.NewLineWriter(func(line string) { cmd.Output("stdout", line) })
+		stderr := exec.NewLineWriter(func(line string) { cmd.Output("stderr", line) })
+		defer stdout.Close()
+		defer stderr.Close()
+		command.Stdout, command.Stderr = stdout, stderr
 	}
 
-	if data, readErr := ioutil.ReadAll(c.Stdout); readErr == nil {
-		results.Stdout = string(data)
-	} else {
-		log.WithField("module", "shell").Warn("cannot read stdout from script")
+	result, err := executor.Run(command)
+	if err != nil {
+		return results, err
 	}
 
-	if data, readErr := ioutil.ReadAll(c.Stderr); readErr == nil {
-		results.Stderr = string(data)
-	} else {
-		log.WithField("module", "shell").Warn("cannot read stdout from script")
-	}
+	results.Stdout = result.Stdout
+	results.Stderr = result.Stderr
+	results.ExitStatus = uint32(result.ExitStatus)
+	results.State = result.State
+	return results, nil
+}
 
-	if waitErr := c.Command.Wait(); waitErr == nil {
-		results.ExitStatus = 0
-	} else {
-		exitErr, ok := waitErr.(*exec.ExitError)
-		if !ok {
-			err = errors.Wrap(waitErr, "failed to wait on process")
-			return
-		}
-		status, ok := exitErr.Sys().(syscall.WaitStatus)
-		if !ok {
-			err = errors.New("unexpected error getting exit status")
-		}
-		results.ExitStatus = uint32(status.ExitStatus())
+func (cmd *CommandGenerator) command(script string) *exec.Command {
+	command := &exec.Command{
+		Name:  cmd.Interpreter,
+		Args:  cmd.Flags,
+		Dir:   cmd.Dir,
+		Env:   cmd.Env,
+		Stdin: script,
 	}
-	results.State = c.Command.ProcessState
-	return
-}
 
-func newCommand(cmd *CommandGenerator) *exec.Cmd {
-	var command *exec.Cmd
 	if cmd.Interpreter == "" {
+		command.Name = defaultInterpreter
 		if len(cmd.Flags) > 0 {
-			log.WithField("module", "shell").WithField("interpreter", "/bin/sh").Debug("passing flags to default interpreter")
-			command = exec.Command(defaultInterpreter, cmd.Flags...)
+			log.WithField("module", "shell").WithField("interpreter", defaultInterpreter).Debug("passing flags to default interpreter")
 		} else {
-			command = exec.Command(defaultInterpreter, defaultExecFlags...)
+			command.Args = defaultExecFlags
 		}
-	} else {
-		command = exec.Command(cmd.Interpreter, cmd.Flags...)
 	}
 
-	command.Dir = cmd.Dir
-	if len(cmd.Env) > 0 {
-		env := os.Environ()
-		env = append(env, cmd.Env...)
-		command.Env = env
+	if cmd.Stdin != "" {
+		command.Args = append(append([]string{}, command.Args...), "-c", script)
+		command.Stdin = cmd.Stdin
+	}
+
+	if cmd.Umask != "" {
+		args := []string{"-c", `umask "$0" && exec "$@"`, cmd.Umask, command.Name}
+		command.Name = "sh"
+		command.Args = append(args, command.Args...)
 	}
 
 	return command
@@ -17,6 +17,7 @@ package shell_test
 import (
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -37,6 +38,25 @@ func Test_Run_WhenScriptTimesOut_ReturnsTimeoutError(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func Test_Run_WhenScriptTimesOut_KillsScript(t *testing.T) {
+	dir, err := ioutil.TempDir("", "converge-shell")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	marker := filepath.Join(dir, "finished")
+	timeout := 50 * time.Millisecond
+	generator := &shell.CommandGenerator{
+		Interpreter: "/bin/sh",
+		Timeout:     &timeout,
+	}
+	_, err = generator.Run("sleep 0.5; touch " + marker)
+	assert.Equal(t, shell.ErrTimedOut, err)
+
+	time.Sleep(time.Second)
+	_, err = os.Stat(marker)
+	assert.True(t, os.IsNotExist(err), "script kept running after it timed out")
+}
+
 func Test_Run_WhenTimeoutSetScriptDoesNotTimeout_DoesNotReturnError(t *testing.T) {
 	script := "true"
 	timeout := 5 * time.Second
@@ -65,6 +85,9 @@ exit 7`
 	assert.Equal(t, uint32(7), result.ExitStatus)
 	assert.Equal(t, "stdout", result.Stdout)
 	assert.Equal(t, "stderr", result.Stderr)
+	require.NotNil(t, result.State)
+	assert.True(t, result.State.Exited())
+	assert.False(t, result.State.Success())
 }
 
 func Test_Run_RunsWithSpecifiedInterpreter(t *testing.T) {
@@ -100,3 +123,34 @@ func Test_Run_RunsWithEnv(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, "Role: test, Version: 0.1", result.Stdout)
 }
+
+func Test_Run_WritesStdin(t *testing.T) {
+	generator := &shell.CommandGenerator{
+		Interpreter: "/bin/sh",
+		Stdin:       "from stdin\n",
+	}
+	result, err := generator.Run("read line; echo -n \"$line\"")
+	assert.NoError(t, err)
+	assert.Equal(t, "from stdin", result.Stdout)
+}
+
+func Test_Run_RunsWithUmask(t *testing.T) {
+	generator := &shell.CommandGenerator{Interpreter: "/bin/sh", Umask: "0027"}
+	result, err := generator.Run("umask")
+	assert.NoError(t, err)
+	assert.Equal(t, "0027", strings.TrimSpace(result.Stdout))
+}
+
+func Test_Run_StreamsOutput(t *testing.T) {
+	var lines []string
+	generator := &shell.CommandGenerator{
+		Interpreter: "/bin/sh",
+		Output: func(stream, line string) {
+			lines = append(lines, stream+": "+line)
+		},
+	}
+	result, err := generator.Run("echo one; echo two; echo -n three")
+	assert.NoError(t, err)
+	assert.Equal(t, "one\ntwo\nthree", result.Stdout)
+	assert.Equal(t, []string{"stdout: one", "stdout: two", "stdout: three"}, lines)
+}
@@ -27,7 +27,13 @@ type CommandResults struct {
 	Stdout     string
 	Stderr     string
 	Stdin      string
-	State      *os.ProcessState
+
+	// State is the state of the local process that ran the command. When the
+	// command runs with become or in a target, this is the sudo or docker
+	// process rather than the interpreter.
+	//
+	// Deprecated: use ExitStatus instead. State will be removed in 0.4.0.
+	State *os.ProcessState
 }
 
 // ResultsContext provides a linked list of CommandResults with a operation
@@ -17,14 +17,13 @@ package shell
 import (
 	"bytes"
 	"fmt"
-	"io"
-	"io/ioutil"
-	"os/exec"
+	"strconv"
 	"time"
 
 	"github.com/pkg/errors"
 
 	log "github.com/Sirupsen/logrus"
+	"github.com/asteris-llc/converge/helpers/exec"
 	"github.com/asteris-llc/converge/helpers/transform"
 	"github.com/asteris-llc/converge/load/registry"
 	"github.com/asteris-llc/converge/resource"
@@ -74,6 +73,28 @@ type Preparer struct {
 
 	// any environment variables that should be passed to the command
 	Env map[string]string `hcl:"env"`
+
+	// the user to run the scripts as. They are run through sudo, like
+	// `become`, so the user converge runs as must be allowed to do so.
+	User string `hcl:"user"`
+
+	// content to write to the standard input of the scripts. When set, the
+	// scripts are passed to the interpreter with `-c` instead of on standard
+	// input, so the interpreter must accept that flag.
+	Stdin string `hcl:"stdin"`
+
+	// the umask the scripts are run with, in octal, such as "0027"
+	Umask string `hcl:"umask"`
+
+	// a file the apply script creates. If it exists, the task is considered
+	// done and the check script is not run. Relative paths are relative to
+	// `dir`.
+	Creates string `hcl:"creates"`
+
+	// a file the apply script removes. If it does not exist, the task is
+	// considered done and the check script is not run. Relative paths are
+	// relative to `dir`.
+	Removes string `hcl:"removes"`
 }
 
 // Prepare a new shell task
@@ -85,11 +106,24 @@ func (p *Preparer) Prepare(render resource.Renderer) (resource.Task, error) {
 		},
 	)
 
+	if p.Umask != "" {
+		if mask, err := strconv.ParseUint(p.Umask, 8, 32); err != nil || mask > 0777 {
+			return nil, fmt.Errorf("task: %q is not an octal umask", p.Umask)
+		}
+	}
+
 	generator := &CommandGenerator{
 		Interpreter: p.Interpreter,
 		Flags:       p.ExecFlags,
 		Dir:         p.Dir,
 		Env:         env,
+		Stdin:       p.Stdin,
+		Umask:       p.Umask,
+		Exec:        exec.For(render),
+	}
+
+	if p.User != "" {
+		generator.Exec = &exec.Become{Executor: generator.Exec, User: p.User}
 	}
 
 	if duration, err := time.ParseDuration(p.Timeout); err == nil {
@@ -102,12 +136,19 @@ func (p *Preparer) Prepare(render resource.Renderer) (resource.Task, error) {
 		ApplyStmt:    p.Apply,
 		Dir:          p.Dir,
 		Env:          env,
+		User:         p.User,
+		Umask:        p.Umask,
+		Creates:      p.Creates,
+		Removes:      p.Removes,
+		Exec:         generator.Exec,
 	}
 
-	return shell, checkSyntax(p.Interpreter, p.CheckFlags, p.Check)
+	// syntax checking doesn't need the node's execution context, so it's
+	// always done locally
+	return shell, checkSyntax(exec.New(), p.Interpreter, p.CheckFlags, p.Check)
 }
 
-func checkSyntax(interpreter string, flags []string, script string) error {
+func checkSyntax(executor exec.Executor, interpreter string, flags []string, script string) error {
 	if interpreter == "" {
 		interpreter = defaultInterpreter
 		if len(flags) > 0 {
@@ -121,60 +162,31 @@ func checkSyntax(interpreter string, flags []string, script string) error {
 			return nil
 		}
 	}
-	command := exec.Command(interpreter, flags...)
-	cmdStdin, cmdStdout, cmdStderr, err := cmdGetPipes(command)
+	result, err := executor.Run(&exec.Command{
+		Name:  interpreter,
+		Args:  flags,
+		Stdin: script,
+	})
 	if err != nil {
-		return errors.Wrap(err, "unable to communicate with subprocess")
-	}
-	if err := command.Start(); err != nil {
 		return errors.Wrap(err, "unable to start subprocess")
 	}
-	if _, err := cmdStdin.Write([]byte(script)); err != nil {
-		return errors.Wrap(err, "unable to write to interpreter")
-	}
 
-	if err := cmdStdin.Close(); err != nil {
-		return errors.Wrap(err, "failed to close stdin")
-	}
-
-	var buffer bytes.Buffer
-	if data, err := ioutil.ReadAll(cmdStdout); err == nil {
-		if len(data) > 0 {
+	if !result.Success() {
+		var buffer bytes.Buffer
+		if result.Stdout != "" {
 			buffer.WriteString("Command Stdout:\n")
-			buffer.Write(data)
+			buffer.WriteString(result.Stdout)
 		}
-	}
-	if data, err := ioutil.ReadAll(cmdStderr); err == nil {
-		if len(data) > 0 {
+		if result.Stderr != "" {
 			buffer.WriteString("Command Stderr:\n")
-			buffer.Write(data)
+			buffer.WriteString(result.Stderr)
 		}
-	}
-
-	if err := command.Wait(); err != nil {
-		return errors.Wrap(err, "syntax error: "+buffer.String())
+		return fmt.Errorf("syntax error: %s: exit status %d", buffer.String(), result.ExitStatus)
 	}
 
 	return nil
 }
 
-func cmdGetPipes(command *exec.Cmd) (io.WriteCloser, io.ReadCloser, io.ReadCloser, error) {
-	var err error
-	cmdStdin, err := command.StdinPipe()
-	if err != nil {
-		return nil, nil, nil, errors.Wrap(err, "failed to get stdin pipe")
-	}
-	cmdStderr, err := command.StderrPipe()
-	if err != nil {
-		return nil, nil, nil, errors.Wrap(err, "failed to get stderr pipe")
-	}
-	cmdStdout, err := command.StdoutPipe()
-	if err != nil {
-		return nil, nil, nil, errors.Wrap(err, "failed to get stdout pipe")
-	}
-	return cmdStdin, cmdStdout, cmdStderr, nil
-}
-
 func init() {
 	registry.Register("task", (*Preparer)(nil), (*Shell)(nil))
 	registry.Register("healthcheck.task", (*Preparer)(nil), (*Shell)(nil))
@@ -17,10 +17,12 @@ package shell_test
 import (
 	"testing"
 
+	"github.com/asteris-llc/converge/helpers/exec"
 	"github.com/asteris-llc/converge/helpers/fakerenderer"
 	"github.com/asteris-llc/converge/resource"
 	"github.com/asteris-llc/converge/resource/shell"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func Test_Preparer_ImplementsResourceInterface(t *testing.T) {
@@ -56,6 +58,27 @@ func Test_Prepare_ReturnsError_WhenSyntaxError(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func Test_Prepare_ReturnsError_WhenUmaskInvalid(t *testing.T) {
+	t.Parallel()
+	p := shPreparer("true")
+	p.Umask = "0999"
+	_, err := p.Prepare(fakerenderer.New())
+	assert.EqualError(t, err, `task: "0999" is not an octal umask`)
+}
+
+func Test_Prepare_RunsAsUser(t *testing.T) {
+	t.Parallel()
+	p := shPreparer("true")
+	p.User = "deploy"
+	task, err := p.Prepare(fakerenderer.New())
+	require.NoError(t, err)
+
+	generator := task.(*shell.Shell).CmdGenerator.(*shell.CommandGenerator)
+	become, ok := generator.Exec.(*exec.Become)
+	require.True(t, ok)
+	assert.Equal(t, "deploy", become.User)
+}
+
 func shPreparer(script string) *shell.Preparer {
 	syntaxFlag := []string{"-n"}
 	return &shell.Preparer{
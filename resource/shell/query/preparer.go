@@ -31,6 +31,10 @@ type Preparer struct {
 	Timeout     string            `hcl:"timeout" doc_type:"duration string"`
 	Dir         string            `hcl:"dir"`
 	Env         map[string]string `hcl:"env"`
+
+	// Format parses the output of the query so that its fields can be looked
+	// up through `result`
+	Format string `hcl:"format" valid_values:"json"`
 }
 
 // Prepare creates a new query type
@@ -45,6 +49,12 @@ func (p *Preparer) Prepare(render resource.Renderer) (resource.Task, error) {
 		Env:         p.Env,
 	}
 
+	switch p.Format {
+	case "", "json":
+	default:
+		return &Query{}, fmt.Errorf("task.query: %q is not a valid format, expected json", p.Format)
+	}
+
 	task, err := shPrep.Prepare(render)
 
 	if err != nil {
@@ -56,7 +66,7 @@ func (p *Preparer) Prepare(render resource.Renderer) (resource.Task, error) {
 		return &Query{}, fmt.Errorf("expected *shell.Shell but got %T", task)
 	}
 
-	return &Query{Shell: shell}, nil
+	return &Query{Shell: shell, Format: p.Format}, nil
 }
 
 func init() {
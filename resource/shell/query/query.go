@@ -15,7 +15,9 @@
 package query
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
 
 	"github.com/asteris-llc/converge/resource"
 	"github.com/asteris-llc/converge/resource/shell"
@@ -24,6 +26,33 @@ import (
 // Query represents an environmental query
 type Query struct {
 	*shell.Shell
+
+	// the format the output of the query is parsed as, if any
+	Format string
+
+	// the parsed output of the query. Maps and lists in it can be traversed in
+	// lookups, for example `lookup "task.query.disks.result.0.name"`
+	Result interface{}
+}
+
+// Check runs the query and parses its output
+func (q *Query) Check(r resource.Renderer) (resource.TaskStatus, error) {
+	status, err := q.Shell.Check(r)
+	if err != nil {
+		return status, err
+	}
+
+	switch q.Format {
+	case "":
+	case "json":
+		if err := json.Unmarshal([]byte(q.Stdout), &q.Result); err != nil {
+			return status, fmt.Errorf("could not parse output of query as JSON: %s", err)
+		}
+	default:
+		return status, fmt.Errorf("%q is not a supported query format", q.Format)
+	}
+
+	return status, nil
 }
 
 // Apply is a nop for queries.  Because HasChanges always returns false this
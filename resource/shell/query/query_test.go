@@ -17,10 +17,12 @@ package query_test
 import (
 	"testing"
 
+	"github.com/asteris-llc/converge/helpers/fakerenderer"
 	"github.com/asteris-llc/converge/resource"
 	"github.com/asteris-llc/converge/resource/shell"
 	"github.com/asteris-llc/converge/resource/shell/query"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func Test_Query_ImplementsTaskInterface(t *testing.T) {
@@ -35,7 +37,47 @@ func Test_Apply_ReturnsError(t *testing.T) {
 	assert.Error(t, actual)
 }
 
+func Test_Check_ParsesJSON(t *testing.T) {
+	t.Parallel()
+
+	t.Run("json", func(t *testing.T) {
+		q := testQuery()
+		q.Format = "json"
+		q.CheckStmt = `echo '{"disks": [{"name": "sda"}]}'`
+
+		_, err := q.Check(fakerenderer.New())
+		require.NoError(t, err)
+		assert.Equal(
+			t,
+			map[string]interface{}{
+				"disks": []interface{}{map[string]interface{}{"name": "sda"}},
+			},
+			q.Result,
+		)
+	})
+
+	t.Run("invalid json", func(t *testing.T) {
+		q := testQuery()
+		q.Format = "json"
+		q.CheckStmt = "echo not json"
+
+		_, err := q.Check(fakerenderer.New())
+		assert.Error(t, err)
+	})
+
+	t.Run("no format", func(t *testing.T) {
+		q := testQuery()
+		q.CheckStmt = `echo '{}'`
+
+		_, err := q.Check(fakerenderer.New())
+		require.NoError(t, err)
+		assert.Nil(t, q.Result)
+	})
+}
+
 // Test Utils
 func testQuery() *query.Query {
-	return &query.Query{Shell: &shell.Shell{}}
+	return &query.Query{Shell: &shell.Shell{
+		CmdGenerator: &shell.CommandGenerator{Interpreter: "/bin/sh"},
+	}}
 }
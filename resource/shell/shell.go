@@ -16,8 +16,10 @@ package shell
 
 import (
 	"fmt"
+	"path"
 	"strings"
 
+	"github.com/asteris-llc/converge/helpers/exec"
 	"github.com/asteris-llc/converge/resource"
 )
 
@@ -28,25 +30,56 @@ type Shell struct {
 	ApplyStmt    string
 	Dir          string
 	Env          []string
+	User         string
+	Umask        string
+	Creates      string
+	Removes      string
 	Status       *CommandResults
 	CheckStatus  *CommandResults
 	HealthStatus *resource.HealthStatus
-	renderer     resource.Renderer
+
+	// Stdout, Stderr, and ExitCode are the results of the most recently run
+	// script
+	Stdout   string
+	Stderr   string
+	ExitCode int
+
+	// Exec checks the creates and removes guards. If nil, they are checked on
+	// the local system.
+	Exec exec.Executor
+
+	renderer resource.Renderer
 }
 
 // Check passes through to shell.Shell.Check() and then sets the health status
 func (s *Shell) Check(r resource.Renderer) (resource.TaskStatus, error) {
 	s.renderer = r
-	results, err := s.CmdGenerator.Run(s.CheckStmt)
+
+	done, reason, err := s.guard()
 	if err != nil {
 		return nil, err
 	}
+
+	var results *CommandResults
+	switch {
+	case done:
+		results = &CommandResults{Stdout: reason}
+	case s.CheckStmt == "" && reason != "":
+		// the guard alone decides whether the task runs
+		results = &CommandResults{Stdout: reason, ExitStatus: 1}
+	default:
+		results, err = s.CmdGenerator.Run(s.CheckStmt)
+		if err != nil {
+			return nil, err
+		}
+	}
 	if s.Status == nil {
 		s.Status = s.Status.Cons("check", results)
 	}
 	if s.CheckStatus == nil {
 		s.CheckStatus = results
 	}
+	s.setOutput(results)
 	return s, nil
 }
 
@@ -58,10 +91,76 @@ func (s *Shell) Apply() (resource.TaskStatus, error) {
 	results, err := s.CmdGenerator.Run(s.ApplyStmt)
 	if err == nil {
 		s.Status = s.Status.Cons("apply", results)
+		s.setOutput(results)
 	}
 	return s, err
 }
 
+// StreamOutput reports the output of the apply script as it runs
+func (s *Shell) StreamOutput(output func(stream, line string)) {
+	if cg, ok := s.CmdGenerator.(*CommandGenerator); ok {
+		cg.Output = output
+	}
+}
+
+// guard checks the creates and removes files, returning true if either shows
+// the task has already been done. The reason describes the files checked, and
+// is empty if there are no guards.
+func (s *Shell) guard() (bool, string, error) {
+	executor := s.Exec
+	if executor == nil {
+		executor = exec.New()
+	}
+
+	var reasons []string
+	for _, g := range []struct {
+		path string
+		done bool
+		name string
+	}{
+		{s.Creates, true, "creates"},
+		{s.Removes, false, "removes"},
+	} {
+		if g.path == "" {
+			continue
+		}
+
+		target := g.path
+		if s.Dir != "" && !path.IsAbs(target) {
+			target = path.Join(s.Dir, target)
+		}
+
+		exists := true
+		if err := exec.Run(executor, "test", "-e", target); err != nil {
+			if _, ok := exec.ExitStatus(err); !ok {
+				return false, "", err
+			}
+			exists = false
+		}
+
+		reason := fmt.Sprintf("%s: %s does not exist", g.name, target)
+		if exists {
+			reason = fmt.Sprintf("%s: %s exists", g.name, target)
+		}
+
+		if exists == g.done {
+			return true, reason, nil
+		}
+		reasons = append(reasons, reason)
+	}
+
+	return false, strings.Join(reasons, ", "), nil
+}
+
+func (s *Shell) setOutput(results *CommandResults) {
+	if results == nil {
+		return
+	}
+	s.Stdout = results.Stdout
+	s.Stderr = results.Stderr
+	s.ExitCode = int(results.ExitStatus)
+}
+
 // resource.TaskStatus functions
 
 // Value provides a value for the shell, which is the stdout data from the last
@@ -104,6 +203,14 @@ func (s *Shell) Messages() (messages []string) {
 		messages = append(messages, fmt.Sprintf("env (%s)", strings.Join(s.Env, " ")))
 	}
 
+	if s.User != "" {
+		messages = append(messages, fmt.Sprintf("user (%s)", s.User))
+	}
+
+	if s.Umask != "" {
+		messages = append(messages, fmt.Sprintf("umask (%s)", s.Umask))
+	}
+
 	messages = append(messages, s.Status.Reverse().UniqOp().SummarizeAll()...)
 	return
 }
@@ -19,6 +19,7 @@ import (
 	"testing"
 
 	"github.com/asteris-llc/converge/healthcheck"
+	"github.com/asteris-llc/converge/helpers/fakeexec"
 	"github.com/asteris-llc/converge/helpers/fakerenderer"
 	"github.com/asteris-llc/converge/helpers/logging"
 	"github.com/asteris-llc/converge/resource"
@@ -182,6 +183,21 @@ func Test_Messages_Includes_Env(t *testing.T) {
 	assert.Contains(t, sh.Messages(), "env (VAR=test ANOTHER_VAR=test2)")
 }
 
+func Test_Messages_Includes_User(t *testing.T) {
+	sh := defaultTestShell()
+	sh.User = "deploy"
+	sh.Check(fakerenderer.New())
+	assert.Contains(t, sh.Messages(), "user (deploy)")
+}
+
+func Test_Apply_SetsOutputFields(t *testing.T) {
+	sh := testShell(resultExecutor(&shell.CommandResults{Stdout: "out", Stderr: "err", ExitStatus: 3}))
+	sh.Apply()
+	assert.Equal(t, "out", sh.Stdout)
+	assert.Equal(t, "err", sh.Stderr)
+	assert.Equal(t, 3, sh.ExitCode)
+}
+
 // Test Utils
 
 func testShell(c shell.CommandExecutor) *shell.Shell {
@@ -212,3 +228,41 @@ func resultExecutor(r *shell.CommandResults) *MockExecutor {
 	m.On("Run", any).Return(r, nil)
 	return m
 }
+
+func Test_Check_WhenCreatesExists_SkipsCheck(t *testing.T) {
+	fake := fakeexec.New()
+	fake.Expect("test", "-e", "/srv/app/installed")
+
+	m := defaultExecutor()
+	sh := &shell.Shell{CmdGenerator: m, CheckStmt: "false", Dir: "/srv/app", Creates: "installed", Exec: fake}
+	status, err := sh.Check(fakerenderer.New())
+
+	assert.NoError(t, err)
+	assert.False(t, status.HasChanges())
+	assert.Equal(t, "creates: /srv/app/installed exists", sh.Stdout)
+	m.AssertNotCalled(t, "Run", any)
+}
+
+func Test_Check_WhenRemovesExistsWithoutCheck_NeedsChange(t *testing.T) {
+	fake := fakeexec.New()
+	fake.Expect("test", "-e", "/tmp/cache")
+
+	sh := &shell.Shell{CmdGenerator: defaultExecutor(), Removes: "/tmp/cache", Exec: fake}
+	status, err := sh.Check(fakerenderer.New())
+
+	assert.NoError(t, err)
+	assert.True(t, status.HasChanges())
+	assert.Equal(t, "removes: /tmp/cache exists", sh.Stdout)
+}
+
+func Test_Check_WhenGuardPending_RunsCheck(t *testing.T) {
+	fake := fakeexec.New()
+	fake.Expect("test", "-e", "/srv/app/installed").Return("", 1)
+
+	m := defaultExecutor()
+	sh := &shell.Shell{CmdGenerator: m, CheckStmt: "check", Creates: "/srv/app/installed", Exec: fake}
+	_, err := sh.Check(fakerenderer.New())
+
+	assert.NoError(t, err)
+	m.AssertCalled(t, "Run", "check")
+}
@@ -0,0 +1,158 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sshdconfig
+
+import (
+	"sort"
+	"strings"
+)
+
+// Config is a parsed sshd_config file. Lines are kept as they are so that
+// comments and options that are not managed are preserved.
+type Config struct {
+	lines []string
+}
+
+// Parse parses the content of an sshd_config file
+func Parse(content string) *Config {
+	c := new(Config)
+	if content != "" {
+		c.lines = strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	}
+	return c
+}
+
+// String returns the content of the file
+func (c *Config) String() string {
+	return strings.Join(c.lines, "\n") + "\n"
+}
+
+// Get returns the value of the first occurrence of keyword in the given Match
+// block, or the global section if criteria is empty. sshd uses the first value
+// it reads for most keywords, so later occurrences are ignored.
+func (c *Config) Get(criteria, keyword string) (string, bool) {
+	start, end, ok := c.section(criteria)
+	if !ok {
+		return "", false
+	}
+
+	for _, line := range c.lines[start:end] {
+		if k, v, ok := parseLine(line); ok && strings.EqualFold(k, keyword) {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// Set sets the options in the given Match block, or the global section if
+// criteria is empty. The first occurrence of each option is updated in place,
+// and options that are not set are added after the last option in the section. The Match
+// block is added to the end of the file if it does not exist.
+func (c *Config) Set(criteria string, options map[string]string) {
+	start, end, ok := c.section(criteria)
+	if !ok {
+		c.lines = append(c.lines, "Match "+criteria)
+		start, end = len(c.lines), len(c.lines)
+	}
+
+	indent := ""
+	if criteria != "" {
+		indent = "\t"
+	}
+
+	var missing []string
+	for keyword, value := range options {
+		found := false
+		for i := start; i < end; i++ {
+			if k, _, ok := parseLine(c.lines[i]); ok && strings.EqualFold(k, keyword) {
+				c.lines[i] = indent + keyword + " " + value
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, keyword)
+		}
+	}
+	sort.Strings(missing)
+
+	var added []string
+	for _, keyword := range missing {
+		added = append(added, indent+keyword+" "+options[keyword])
+	}
+
+	// add after the last option so blank lines and comments separating the
+	// section from the next one stay where they are
+	at := start
+	for i := start; i < end; i++ {
+		if _, _, ok := parseLine(c.lines[i]); ok {
+			at = i + 1
+		}
+	}
+
+	lines := append([]string{}, c.lines[:at]...)
+	lines = append(lines, added...)
+	c.lines = append(lines, c.lines[at:]...)
+}
+
+// section returns the range of lines in the given Match block, or the global
+// section if criteria is empty. The global section ends at the first Match.
+func (c *Config) section(criteria string) (start, end int, ok bool) {
+	start = -1
+	if criteria == "" {
+		start = 0
+	}
+
+	for i, line := range c.lines {
+		k, v, isOption := parseLine(line)
+		if !isOption || !strings.EqualFold(k, "match") {
+			continue
+		}
+
+		if start >= 0 {
+			return start, i, true
+		}
+		if normalize(v) == normalize(criteria) {
+			start = i + 1
+		}
+	}
+
+	if start < 0 {
+		return 0, 0, false
+	}
+	return start, len(c.lines), true
+}
+
+// parseLine splits an option line into its keyword and value. Keywords and
+// values are separated by whitespace or an optional "=".
+func parseLine(line string) (keyword, value string, ok bool) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return "", "", false
+	}
+
+	i := strings.IndexAny(line, " \t=")
+	if i < 0 {
+		return line, "", true
+	}
+
+	value = strings.TrimSpace(line[i:])
+	value = strings.TrimSpace(strings.TrimPrefix(value, "="))
+	return line[:i], value, true
+}
+
+func normalize(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,78 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sshdconfig_test
+
+import (
+	"testing"
+
+	"github.com/asteris-llc/converge/resource/ssh/sshdconfig"
+	"github.com/stretchr/testify/assert"
+)
+
+const config = `# global options
+Port 22
+permitrootlogin=yes
+X11Forwarding yes
+
+Match User backup
+	PasswordAuthentication yes
+Match Group admin
+	AllowTcpForwarding yes
+`
+
+// TestConfigGet tests reading options
+func TestConfigGet(t *testing.T) {
+	t.Parallel()
+
+	c := sshdconfig.Parse(config)
+
+	value, ok := c.Get("", "PermitRootLogin")
+	assert.True(t, ok)
+	assert.Equal(t, "yes", value)
+
+	value, ok = c.Get("User  backup", "PasswordAuthentication")
+	assert.True(t, ok)
+	assert.Equal(t, "yes", value)
+
+	_, ok = c.Get("", "PasswordAuthentication")
+	assert.False(t, ok, "options in Match blocks are not global")
+
+	_, ok = c.Get("User nobody", "PasswordAuthentication")
+	assert.False(t, ok)
+}
+
+// TestConfigSet tests updating options and Match blocks
+func TestConfigSet(t *testing.T) {
+	t.Parallel()
+
+	c := sshdconfig.Parse(config)
+	c.Set("", map[string]string{"PermitRootLogin": "no", "Ciphers": "aes256-ctr"})
+	c.Set("User backup", map[string]string{"PasswordAuthentication": "no"})
+	c.Set("User deploy", map[string]string{"X11Forwarding": "no"})
+
+	assert.Equal(t, `# global options
+Port 22
+PermitRootLogin no
+X11Forwarding yes
+Ciphers aes256-ctr
+
+Match User backup
+	PasswordAuthentication no
+Match Group admin
+	AllowTcpForwarding yes
+Match User deploy
+	X11Forwarding no
+`, c.String())
+}
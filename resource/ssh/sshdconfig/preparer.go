@@ -0,0 +1,111 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sshdconfig
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/load/registry"
+	"github.com/asteris-llc/converge/resource"
+)
+
+// Preparer for SSHDConfig
+//
+// SSHDConfig manages individual options in sshd_config. Options that are not
+// declared are left alone. The new file is checked with `sshd -t` before it
+// replaces the old one, and sshd is reloaded afterwards.
+type Preparer struct {
+	// Options are set in the global section of the file
+	Options map[string]string `hcl:"options"`
+
+	// Match maps the criteria of Match blocks, such as "User deploy", to the
+	// options set in the block. Blocks that do not exist are added to the end
+	// of the file.
+	Match map[string]map[string]string `hcl:"match"`
+
+	// Path is the file to manage. It defaults to /etc/ssh/sshd_config.
+	Path string `hcl:"path"`
+
+	// Service is the systemd unit reloaded after the file changes. It defaults
+	// to "sshd".
+	Service string `hcl:"service"`
+
+	// Reload controls whether the service is reloaded after the file changes.
+	// It defaults to true.
+	Reload *bool `hcl:"reload"`
+}
+
+// Prepare a new task
+func (p *Preparer) Prepare(render resource.Renderer) (resource.Task, error) {
+	if len(p.Options) == 0 && len(p.Match) == 0 {
+		return nil, fmt.Errorf("sshd_config requires \"options\" or \"match\"")
+	}
+
+	if err := validateOptions(p.Options); err != nil {
+		return nil, err
+	}
+	for criteria, options := range p.Match {
+		if strings.TrimSpace(criteria) == "" {
+			return nil, fmt.Errorf("sshd_config match criteria cannot be empty")
+		}
+		if err := validateOptions(options); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.Path == "" {
+		p.Path = DefaultPath
+	}
+
+	if p.Service == "" {
+		p.Service = DefaultService
+	}
+
+	reload := true
+	if p.Reload != nil {
+		reload = *p.Reload
+	}
+
+	return &SSHDConfig{
+		Options: p.Options,
+		Match:   p.Match,
+		Path:    p.Path,
+		Service: p.Service,
+		Reload:  reload,
+		exec:    exec.For(render),
+	}, nil
+}
+
+func validateOptions(options map[string]string) error {
+	for keyword, value := range options {
+		if keyword == "" || strings.ContainsAny(keyword, " \t=#\n") {
+			return fmt.Errorf("sshd_config: %q is not a valid option", keyword)
+		}
+		if strings.EqualFold(keyword, "match") {
+			return fmt.Errorf("sshd_config: use \"match\" to declare Match blocks")
+		}
+		if value == "" || strings.Contains(value, "\n") {
+			return fmt.Errorf("sshd_config: %q must have a single-line value", keyword)
+		}
+	}
+	return nil
+}
+
+func init() {
+	registry.Register("ssh.sshd_config", (*Preparer)(nil), (*SSHDConfig)(nil))
+	registry.RegisterPlatforms("ssh.sshd_config", "linux")
+}
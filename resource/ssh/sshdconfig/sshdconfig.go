@@ -0,0 +1,170 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sshdconfig
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultPath is the location of sshd_config
+	DefaultPath = "/etc/ssh/sshd_config"
+
+	// DefaultService is the unit reloaded when the file changes
+	DefaultService = "sshd"
+
+	// Mode is the mode the file is written with
+	Mode = 0644
+)
+
+// SSHDConfig manages options in sshd_config
+type SSHDConfig struct {
+	resource.Status
+
+	Options map[string]string
+	Match   map[string]map[string]string
+	Path    string
+	Service string
+	Reload  bool
+
+	exec exec.Executor
+}
+
+// Check whether every option has the declared value
+func (s *SSHDConfig) Check(resource.Renderer) (resource.TaskStatus, error) {
+	s.Status = resource.Status{}
+
+	config, err := s.read()
+	if err != nil {
+		s.RaiseLevel(resource.StatusFatal)
+		return s, err
+	}
+
+	s.diff(config, "", s.Options)
+	for _, criteria := range sortedCriteria(s.Match) {
+		s.diff(config, criteria, s.Match[criteria])
+	}
+
+	return s, nil
+}
+
+// Apply validates and installs the updated file, then reloads sshd
+func (s *SSHDConfig) Apply() (resource.TaskStatus, error) {
+	s.Status = resource.Status{}
+
+	config, err := s.read()
+	if err != nil {
+		s.RaiseLevel(resource.StatusFatal)
+		return s, err
+	}
+
+	if len(s.Options) > 0 {
+		config.Set("", s.Options)
+	}
+	for _, criteria := range sortedCriteria(s.Match) {
+		config.Set(criteria, s.Match[criteria])
+	}
+
+	if err := s.install(config.String()); err != nil {
+		s.RaiseLevel(resource.StatusFatal)
+		return s, err
+	}
+	s.AddMessage(fmt.Sprintf("updated %s", s.Path))
+
+	if s.Reload {
+		if err := exec.Run(s.exec, "systemctl", "reload", s.Service); err != nil {
+			s.RaiseLevel(resource.StatusFatal)
+			return s, errors.Wrapf(err, "cannot reload %s", s.Service)
+		}
+		s.AddMessage(fmt.Sprintf("reloaded %s", s.Service))
+	}
+
+	return s, nil
+}
+
+// install writes content next to the file, checks it with sshd, and moves it
+// into place. The existing file is not touched if the check fails.
+func (s *SSHDConfig) install(content string) error {
+	staged := s.Path + ".converge"
+
+	if err := exec.WriteFile(s.exec, staged, content, Mode); err != nil {
+		return errors.Wrapf(err, "cannot write %s", staged)
+	}
+
+	if err := exec.Run(s.exec, "sshd", "-t", "-f", staged); err != nil {
+		if rmErr := exec.Run(s.exec, "rm", "-f", staged); rmErr != nil {
+			s.AddMessage(fmt.Sprintf("could not remove %s: %s", staged, rmErr))
+		}
+		return errors.Wrapf(err, "refusing to install invalid %s", s.Path)
+	}
+
+	if err := exec.Run(s.exec, "mv", "-f", staged, s.Path); err != nil {
+		return errors.Wrapf(err, "cannot install %s", s.Path)
+	}
+	return nil
+}
+
+func (s *SSHDConfig) diff(config *Config, criteria string, options map[string]string) {
+	prefix := ""
+	if criteria != "" {
+		prefix = "Match " + criteria + "/"
+	}
+
+	for _, keyword := range sortedKeys(options) {
+		current, ok := config.Get(criteria, keyword)
+		if !ok {
+			current = "<unset>"
+		}
+
+		if current != options[keyword] {
+			s.RaiseLevel(resource.StatusWillChange)
+			s.AddDifference(prefix+keyword, current, options[keyword], "")
+		}
+	}
+}
+
+func (s *SSHDConfig) read() (*Config, error) {
+	content, exists, err := exec.ReadFile(s.exec, s.Path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot read %s", s.Path)
+	}
+	if !exists {
+		return nil, fmt.Errorf("%s does not exist", s.Path)
+	}
+	return Parse(content), nil
+}
+
+func sortedKeys(options map[string]string) []string {
+	var keys []string
+	for k := range options {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sortedCriteria(match map[string]map[string]string) []string {
+	var keys []string
+	for k := range match {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
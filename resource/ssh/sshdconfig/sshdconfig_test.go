@@ -0,0 +1,158 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sshdconfig_test
+
+import (
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/helpers/fakeexec"
+	"github.com/asteris-llc/converge/helpers/fakerenderer"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/ssh/sshdconfig"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	path   = "/etc/ssh/sshd_config"
+	staged = "/etc/ssh/sshd_config.converge"
+	write  = exec.WriteFileScript
+)
+
+// TestSSHDConfigInterface tests that SSHDConfig is properly implemented
+func TestSSHDConfigInterface(t *testing.T) {
+	t.Parallel()
+
+	assert.Implements(t, (*resource.Task)(nil), new(sshdconfig.SSHDConfig))
+}
+
+// TestCheck tests the possible cases Check handles
+func TestCheck(t *testing.T) {
+	t.Parallel()
+
+	t.Run("changes", func(t *testing.T) {
+		fake := reading(config)
+		s := prepare(t, fake, &sshdconfig.Preparer{
+			Options: map[string]string{"PermitRootLogin": "no", "Port": "22"},
+			Match:   map[string]map[string]string{"User deploy": {"X11Forwarding": "no"}},
+		})
+		status, err := s.Check(fakerenderer.New())
+
+		require.NoError(t, err)
+		assert.True(t, status.HasChanges())
+		assert.Equal(t, "yes", status.Diffs()["PermitRootLogin"].Original())
+		assert.Equal(t, "<unset>", status.Diffs()["Match User deploy/X11Forwarding"].Original())
+		assert.NotContains(t, status.Diffs(), "Port")
+	})
+
+	t.Run("converged", func(t *testing.T) {
+		fake := reading(config)
+		s := prepare(t, fake, &sshdconfig.Preparer{
+			Options: map[string]string{"PermitRootLogin": "yes"},
+			Match:   map[string]map[string]string{"User backup": {"PasswordAuthentication": "yes"}},
+		})
+		status, err := s.Check(fakerenderer.New())
+
+		require.NoError(t, err)
+		assert.False(t, status.HasChanges())
+	})
+}
+
+// TestApply tests the possible cases Apply handles
+func TestApply(t *testing.T) {
+	t.Parallel()
+
+	t.Run("valid", func(t *testing.T) {
+		fake := reading("PermitRootLogin yes\n")
+		fake.Expect("sh", "-c", write, staged, "0644")
+		fake.Expect("sshd", "-t", "-f", staged)
+		fake.Expect("mv", "-f", staged, path)
+		fake.Expect("systemctl", "reload", "sshd")
+
+		s := prepare(t, fake, &sshdconfig.Preparer{Options: map[string]string{"PermitRootLogin": "no"}})
+		status, err := s.Apply()
+
+		require.NoError(t, err)
+		assert.Contains(t, status.Messages(), "reloaded sshd")
+		fake.AssertExpectations(t)
+		assert.Equal(t, "PermitRootLogin no\n", fake.Calls()[2].Stdin)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		fake := reading("PermitRootLogin yes\n")
+		fake.Expect("sh", "-c", write, staged, "0644")
+		fake.Expect("sshd", "-t", "-f", staged).Stderr("Bad configuration option: PermitRootLogn").Return("", 255)
+		fake.Expect("rm", "-f", staged)
+
+		s := prepare(t, fake, &sshdconfig.Preparer{Options: map[string]string{"PermitRootLogn": "no"}})
+		status, err := s.Apply()
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Bad configuration option")
+		assert.Equal(t, resource.StatusFatal, status.StatusCode())
+		fake.AssertExpectations(t)
+		for _, call := range fake.Calls() {
+			assert.NotEqual(t, "mv", call.Name, "invalid file must not be installed")
+			assert.NotEqual(t, "systemctl", call.Name, "sshd must not be reloaded")
+		}
+	})
+
+	t.Run("without reload", func(t *testing.T) {
+		reload := false
+		fake := reading("PermitRootLogin yes\n")
+		fake.Expect("sh", "-c", write, staged, "0644")
+		fake.Expect("sshd", "-t", "-f", staged)
+		fake.Expect("mv", "-f", staged, path)
+
+		s := prepare(t, fake, &sshdconfig.Preparer{Options: map[string]string{"PermitRootLogin": "no"}, Reload: &reload})
+		_, err := s.Apply()
+
+		require.NoError(t, err)
+		fake.AssertExpectations(t)
+	})
+}
+
+// TestPrepare tests the valid and invalid cases of Prepare
+func TestPrepare(t *testing.T) {
+	t.Parallel()
+
+	fr := fakerenderer.New()
+
+	_, err := (&sshdconfig.Preparer{}).Prepare(fr)
+	assert.EqualError(t, err, `sshd_config requires "options" or "match"`)
+
+	_, err = (&sshdconfig.Preparer{Options: map[string]string{"Match": "User x"}}).Prepare(fr)
+	assert.EqualError(t, err, `sshd_config: use "match" to declare Match blocks`)
+
+	_, err = (&sshdconfig.Preparer{Match: map[string]map[string]string{" ": {"X11Forwarding": "no"}}}).Prepare(fr)
+	assert.EqualError(t, err, `sshd_config match criteria cannot be empty`)
+
+	_, err = (&sshdconfig.Preparer{Options: map[string]string{"Port": ""}}).Prepare(fr)
+	assert.EqualError(t, err, `sshd_config: "Port" must have a single-line value`)
+}
+
+func reading(content string) *fakeexec.Executor {
+	fake := fakeexec.New()
+	fake.Expect("test", "-e", path)
+	fake.Expect("cat", path).Return(content, 0)
+	return fake
+}
+
+func prepare(t *testing.T, fake *fakeexec.Executor, p *sshdconfig.Preparer) *sshdconfig.SSHDConfig {
+	task, err := p.Prepare(&resource.ExecRenderer{Renderer: fakerenderer.New(), Exec: fake})
+	require.NoError(t, err)
+	return task.(*sshdconfig.SSHDConfig)
+}
@@ -113,6 +113,8 @@ type Status struct {
 
 	error       error
 	failingDeps []badDep
+	reboots     []string
+	warnings    []Warning
 }
 
 // NewStatus returns a Status with all fields initialized
@@ -228,6 +230,74 @@ func (t *Status) RaiseLevel(level StatusLevel) {
 	}
 }
 
+// RequireReboot records that a change made or detected by the task only takes
+// effect once the system has been rebooted
+func (t *Status) RequireReboot(reason string) {
+	t.reboots = append(t.reboots, reason)
+}
+
+// PendingReboots returns the reasons given to RequireReboot
+func (t *Status) PendingReboots() []string {
+	return t.reboots
+}
+
+// AddWarning records a problem with the task that doesn't stop it from being
+// checked or applied, like a deprecated field being set. A warning that has
+// already been added is not added again, so tasks may warn on every Check.
+func (t *Status) AddWarning(kind WarningKind, field, message string) {
+	warning := Warning{Kind: kind, Field: field, Message: message}
+	for _, existing := range t.warnings {
+		if existing == warning {
+			return
+		}
+	}
+	t.warnings = append(t.warnings, warning)
+}
+
+// Warnings returns the warnings given to AddWarning
+func (t *Status) Warnings() []Warning {
+	return t.warnings
+}
+
+// RebootRequirer is implemented by statuses and results that can carry
+// reboots required by a task
+type RebootRequirer interface {
+	PendingReboots() []string
+}
+
+// RebootWatcher is implemented by tasks that act on the reboots required by
+// the tasks they depend on. The plan pipeline calls WatchReboots with the
+// pending reboots of every dependency, keyed by node ID, before calling Check.
+type RebootWatcher interface {
+	WatchReboots(map[string][]string)
+}
+
+// OutputStreamer is implemented by tasks that can report their output while
+// they are applied rather than only once they finish. The apply pipeline calls
+// StreamOutput before Apply with a function that takes each line of output
+// and the stream ("stdout" or "stderr") it was written to.
+type OutputStreamer interface {
+	StreamOutput(func(stream, line string))
+}
+
+// FileManager is implemented by tasks that write or remove files. Before the
+// task is applied, the apply pipeline backs up the files it returns from
+// ManagedFiles, so that they can be put back with `converge restore`. Tasks
+// that run commands through an executor should also implement exec.Provider,
+// since only files on the local system are backed up.
+type FileManager interface {
+	ManagedFiles() []string
+}
+
+// PendingReboots returns the pending reboots of a status or result, if it
+// carries any
+func PendingReboots(v interface{}) []string {
+	if requirer, ok := v.(RebootRequirer); ok {
+		return requirer.PendingReboots()
+	}
+	return nil
+}
+
 // Diff represents a difference
 type Diff interface {
 	Original() string
@@ -0,0 +1,192 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package luks
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/pkg/errors"
+)
+
+// MapperPath returns the path an open container is available at
+func MapperPath(name string) string {
+	return path.Join("/dev/mapper", name)
+}
+
+// LUKS manages an encrypted LUKS container
+type LUKS struct {
+	resource.Status
+
+	Device  string
+	Name    string
+	KeyFile string
+	Type    string
+	Open    bool
+
+	// Mapper is the path of the opened container, /dev/mapper/NAME
+	Mapper string
+
+	formatted bool
+	active    bool
+	exec      exec.Executor
+}
+
+// Check whether the container is formatted and open
+func (l *LUKS) Check(resource.Renderer) (resource.TaskStatus, error) {
+	l.Status = resource.Status{}
+
+	if err := l.inspect(); err != nil {
+		l.RaiseLevel(resource.StatusFatal)
+		return l, err
+	}
+
+	if !l.formatted {
+		l.RaiseLevel(resource.StatusWillChange)
+		l.AddDifference(l.Device, "<unformatted>", l.Type, "")
+	}
+
+	if l.active != l.Open {
+		l.RaiseLevel(resource.StatusWillChange)
+		l.AddDifference(l.Mapper, state(l.active), state(l.Open), "")
+	}
+
+	return l, nil
+}
+
+// Apply formats, opens, or closes the container
+func (l *LUKS) Apply() (resource.TaskStatus, error) {
+	l.Status = resource.Status{}
+
+	if err := l.inspect(); err != nil {
+		l.RaiseLevel(resource.StatusFatal)
+		return l, err
+	}
+
+	if !l.formatted {
+		if err := exec.Run(l.exec, "cryptsetup", "luksFormat", "--batch-mode", "--type", l.Type, "--key-file", l.KeyFile, l.Device); err != nil {
+			l.RaiseLevel(resource.StatusFatal)
+			return l, errors.Wrapf(err, "cannot format %s", l.Device)
+		}
+		l.AddMessage(fmt.Sprintf("formatted %s as %s", l.Device, l.Type))
+	}
+
+	switch {
+	case l.Open && !l.active:
+		if err := exec.Run(l.exec, "cryptsetup", "open", "--key-file", l.KeyFile, l.Device, l.Name); err != nil {
+			l.RaiseLevel(resource.StatusFatal)
+			return l, errors.Wrapf(err, "cannot open %s", l.Device)
+		}
+		l.AddMessage("opened " + l.Mapper)
+
+	case !l.Open && l.active:
+		if err := exec.Run(l.exec, "cryptsetup", "close", l.Name); err != nil {
+			l.RaiseLevel(resource.StatusFatal)
+			return l, errors.Wrapf(err, "cannot close %s", l.Mapper)
+		}
+		l.AddMessage("closed " + l.Mapper)
+	}
+
+	return l, nil
+}
+
+// inspect finds out whether the device is formatted and the container open.
+// A device with any other signature is an error, since formatting it would
+// destroy its data, as is a container the key file doesn't unlock.
+func (l *LUKS) inspect() error {
+	if err := l.checkKeyFile(); err != nil {
+		return err
+	}
+
+	formatted, err := l.succeeds("cryptsetup", "isLuks", l.Device)
+	if err != nil {
+		return err
+	}
+	l.formatted = formatted
+
+	if !l.formatted {
+		signature, err := l.signature()
+		if err != nil {
+			return err
+		}
+		if signature != "" {
+			return fmt.Errorf("refusing to format %s, it has an existing %s signature", l.Device, signature)
+		}
+	} else {
+		unlocks, err := l.succeeds("cryptsetup", "open", "--test-passphrase", "--key-file", l.KeyFile, l.Device)
+		if err != nil {
+			return err
+		}
+		if !unlocks {
+			return fmt.Errorf("%s does not unlock %s", l.KeyFile, l.Device)
+		}
+	}
+
+	active, err := l.succeeds("cryptsetup", "status", l.Name)
+	if err != nil {
+		return err
+	}
+	l.active = active
+
+	return nil
+}
+
+// checkKeyFile checks that the key file exists without reading it
+func (l *LUKS) checkKeyFile() error {
+	exists, err := l.succeeds("test", "-f", l.KeyFile)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("key file %s does not exist", l.KeyFile)
+	}
+	return nil
+}
+
+// signature returns the type of any filesystem or other signature on the
+// device. blkid exits with status 2 when it finds none.
+func (l *LUKS) signature() (string, error) {
+	out, err := exec.Read(l.exec, "blkid", "-p", "-o", "value", "-s", "TYPE", l.Device)
+	if status, ok := exec.ExitStatus(err); ok && status == 2 {
+		return "", nil
+	} else if err != nil {
+		return "", errors.Wrapf(err, "cannot probe %s", l.Device)
+	}
+
+	signature := strings.TrimSpace(out)
+	if signature == "" {
+		signature = "partition table"
+	}
+	return signature, nil
+}
+
+// succeeds runs a command, reporting whether it exited with status 0
+func (l *LUKS) succeeds(name string, args ...string) (bool, error) {
+	err := exec.Run(l.exec, name, args...)
+	if _, ok := exec.ExitStatus(err); ok {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func state(open bool) string {
+	if open {
+		return "open"
+	}
+	return "closed"
+}
@@ -0,0 +1,178 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package luks_test
+
+import (
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/fakeexec"
+	"github.com/asteris-llc/converge/helpers/fakerenderer"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/storage/luks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	device  = "/dev/vg0/secure"
+	keyFile = "/etc/keys/secure.key"
+)
+
+// TestLUKSInterface tests that LUKS is properly implemented
+func TestLUKSInterface(t *testing.T) {
+	t.Parallel()
+
+	assert.Implements(t, (*resource.Task)(nil), new(luks.LUKS))
+	assert.Implements(t, (*resource.Resource)(nil), new(luks.Preparer))
+}
+
+// TestCheck tests the possible cases Check handles
+func TestCheck(t *testing.T) {
+	t.Parallel()
+
+	t.Run("blank device", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("test", "-f", keyFile)
+		fake.Expect("cryptsetup", "isLuks", device).Return("", 1)
+		fake.Expect("blkid", "-p", "-o", "value", "-s", "TYPE", device).Return("", 2)
+		fake.Expect("cryptsetup", "status", "secure").Return("", 4)
+
+		l := prepare(t, fake, &luks.Preparer{})
+		status, err := l.Check(fakerenderer.New())
+
+		require.NoError(t, err)
+		assert.True(t, status.HasChanges())
+		assert.Equal(t, "luks2", status.Diffs()[device].Current())
+		assert.Equal(t, "open", status.Diffs()["/dev/mapper/secure"].Current())
+	})
+
+	t.Run("existing filesystem", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("test", "-f", keyFile)
+		fake.Expect("cryptsetup", "isLuks", device).Return("", 1)
+		fake.Expect("blkid", "-p", "-o", "value", "-s", "TYPE", device).Return("ext4\n", 0)
+
+		l := prepare(t, fake, &luks.Preparer{})
+		_, err := l.Check(fakerenderer.New())
+
+		assert.EqualError(t, err, "refusing to format /dev/vg0/secure, it has an existing ext4 signature")
+	})
+
+	t.Run("wrong key", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("test", "-f", keyFile)
+		fake.Expect("cryptsetup", "isLuks", device)
+		fake.Expect("cryptsetup", "open", "--test-passphrase", "--key-file", keyFile, device).Return("", 2)
+
+		l := prepare(t, fake, &luks.Preparer{})
+		_, err := l.Check(fakerenderer.New())
+
+		assert.EqualError(t, err, "/etc/keys/secure.key does not unlock /dev/vg0/secure")
+	})
+
+	t.Run("missing key file", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("test", "-f", keyFile).Return("", 1)
+
+		l := prepare(t, fake, &luks.Preparer{})
+		_, err := l.Check(fakerenderer.New())
+
+		assert.EqualError(t, err, "key file /etc/keys/secure.key does not exist")
+	})
+
+	t.Run("open", func(t *testing.T) {
+		l := prepare(t, formatted(true), &luks.Preparer{})
+		status, err := l.Check(fakerenderer.New())
+
+		require.NoError(t, err)
+		assert.False(t, status.HasChanges())
+	})
+
+	t.Run("close", func(t *testing.T) {
+		open := false
+		l := prepare(t, formatted(true), &luks.Preparer{Open: &open})
+		status, err := l.Check(fakerenderer.New())
+
+		require.NoError(t, err)
+		assert.True(t, status.HasChanges())
+		assert.Equal(t, "closed", status.Diffs()["/dev/mapper/secure"].Current())
+	})
+}
+
+// TestApply tests that Apply formats and opens the container
+func TestApply(t *testing.T) {
+	t.Parallel()
+
+	t.Run("format and open", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("test", "-f", keyFile)
+		fake.Expect("cryptsetup", "isLuks", device).Return("", 1)
+		fake.Expect("blkid", "-p", "-o", "value", "-s", "TYPE", device).Return("", 2)
+		fake.Expect("cryptsetup", "status", "secure").Return("", 4)
+		fake.Expect("cryptsetup", "luksFormat", "--batch-mode", "--type", "luks1", "--key-file", keyFile, device)
+		fake.Expect("cryptsetup", "open", "--key-file", keyFile, device, "secure")
+
+		l := prepare(t, fake, &luks.Preparer{Type: "luks1"})
+		_, err := l.Apply()
+
+		require.NoError(t, err)
+		fake.AssertExpectations(t)
+	})
+
+	t.Run("close", func(t *testing.T) {
+		fake := formatted(true)
+		fake.Expect("cryptsetup", "close", "secure")
+
+		open := false
+		l := prepare(t, fake, &luks.Preparer{Open: &open})
+		status, err := l.Apply()
+
+		require.NoError(t, err)
+		fake.AssertExpectations(t)
+		assert.Equal(t, []string{"closed /dev/mapper/secure"}, status.Messages())
+	})
+}
+
+// TestPrepare tests the invalid cases of Prepare
+func TestPrepare(t *testing.T) {
+	t.Parallel()
+
+	_, err := (&luks.Preparer{Device: device, Name: "a/b", KeyFile: keyFile}).Prepare(fakerenderer.New())
+	assert.EqualError(t, err, `luks: "a/b" is not a valid device mapper name`)
+}
+
+func formatted(active bool) *fakeexec.Executor {
+	status := 4
+	if active {
+		status = 0
+	}
+
+	fake := fakeexec.New()
+	fake.Expect("test", "-f", keyFile)
+	fake.Expect("cryptsetup", "isLuks", device)
+	fake.Expect("cryptsetup", "open", "--test-passphrase", "--key-file", keyFile, device)
+	fake.Expect("cryptsetup", "status", "secure").Return("", status)
+	return fake
+}
+
+func prepare(t *testing.T, fake *fakeexec.Executor, p *luks.Preparer) *luks.LUKS {
+	p.Device = device
+	p.Name = "secure"
+	p.KeyFile = keyFile
+
+	task, err := p.Prepare(&resource.ExecRenderer{Renderer: fakerenderer.New(), Exec: fake})
+	require.NoError(t, err)
+	return task.(*luks.LUKS)
+}
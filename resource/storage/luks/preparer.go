@@ -0,0 +1,83 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package luks
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/load/registry"
+	"github.com/asteris-llc/converge/resource"
+)
+
+// Preparer for LUKS
+//
+// LUKS formats a block device as a LUKS container and opens it as
+// /dev/mapper/NAME, where it can be used like any other block device, for
+// example as an LVM physical volume or a filesystem. The key is read by
+// cryptsetup from a key file, so it is never passed through converge and
+// never appears in plans, output, or logs. A device that already contains a
+// filesystem or other signature is never formatted.
+type Preparer struct {
+	// Device is the block device holding the container, such as /dev/sdb or an
+	// LVM logical volume
+	Device string `hcl:"device" required:"true"`
+
+	// Name is the device mapper name the container is opened as
+	Name string `hcl:"name" required:"true"`
+
+	// KeyFile is the path of the file holding the key. It must exist before
+	// the container is created or opened.
+	KeyFile string `hcl:"key_file" required:"true"`
+
+	// Type is the LUKS format version used when formatting. It defaults to
+	// luks2.
+	Type string `hcl:"type" valid_values:"luks1,luks2"`
+
+	// Open controls whether the container is opened. It defaults to true.
+	Open *bool `hcl:"open"`
+}
+
+// Prepare a new task
+func (p *Preparer) Prepare(render resource.Renderer) (resource.Task, error) {
+	if p.Name == "" || strings.ContainsAny(p.Name, "/ \t\n") {
+		return nil, fmt.Errorf("luks: %q is not a valid device mapper name", p.Name)
+	}
+
+	if p.Type == "" {
+		p.Type = "luks2"
+	}
+
+	open := true
+	if p.Open != nil {
+		open = *p.Open
+	}
+
+	return &LUKS{
+		Device:  p.Device,
+		Name:    p.Name,
+		KeyFile: p.KeyFile,
+		Type:    p.Type,
+		Open:    open,
+		Mapper:  MapperPath(p.Name),
+		exec:    exec.For(render),
+	}, nil
+}
+
+func init() {
+	registry.Register("storage.luks", (*Preparer)(nil), (*LUKS)(nil))
+	registry.RegisterPlatforms("storage.luks", "linux")
+}
@@ -0,0 +1,127 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package systemd
+
+import (
+	"sort"
+	"strings"
+)
+
+// ParseSection returns the keys set in a section of an ini-style file such as
+// a unit or journald.conf. Commented keys are not included.
+func ParseSection(content, section string) map[string]string {
+	out := map[string]string{}
+	current := ""
+	for _, line := range strings.Split(content, "\n") {
+		if name, ok := parseSection(line); ok {
+			current = name
+			continue
+		}
+		if current != section {
+			continue
+		}
+		if key, value, ok := parseLine(line); ok {
+			out[key] = value
+		}
+	}
+	return out
+}
+
+// SetSection returns content with the given keys set in a section. Existing
+// keys are updated in place, and other keys are added after the last line of
+// the section. The section is added if it does not exist.
+func SetSection(content, section string, settings map[string]string) string {
+	var lines []string
+	if content != "" {
+		lines = strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	}
+
+	start, end := -1, len(lines)
+	for i, line := range lines {
+		if name, ok := parseSection(line); ok {
+			if start >= 0 {
+				end = i
+				break
+			}
+			if name == section {
+				start = i + 1
+			}
+		}
+	}
+
+	if start < 0 {
+		lines = append(lines, "["+section+"]")
+		start, end = len(lines), len(lines)
+	}
+
+	seen := map[string]bool{}
+	at := start
+	for i := start; i < end; i++ {
+		// stock files often document every key in a comment, so new keys go
+		// after both comments and options rather than right after the header
+		if strings.HasPrefix(strings.TrimSpace(lines[i]), "#") {
+			at = i + 1
+		}
+
+		key, _, ok := parseLine(lines[i])
+		if !ok {
+			continue
+		}
+		at = i + 1
+		if value, declared := settings[key]; declared {
+			lines[i] = key + "=" + value
+			seen[key] = true
+		}
+	}
+
+	var missing []string
+	for key := range settings {
+		if !seen[key] {
+			missing = append(missing, key)
+		}
+	}
+	sort.Strings(missing)
+
+	var added []string
+	for _, key := range missing {
+		added = append(added, key+"="+settings[key])
+	}
+
+	out := append([]string{}, lines[:at]...)
+	out = append(out, added...)
+	out = append(out, lines[at:]...)
+	return strings.Join(out, "\n") + "\n"
+}
+
+func parseSection(line string) (string, bool) {
+	line = strings.TrimSpace(line)
+	if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+		return strings.TrimSpace(line[1 : len(line)-1]), true
+	}
+	return "", false
+}
+
+func parseLine(line string) (key, value string, ok bool) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+		return "", "", false
+	}
+
+	parts := strings.SplitN(line, "=", 2)
+	if len(parts) != 2 {
+		return "", "", false
+	}
+	return strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]), true
+}
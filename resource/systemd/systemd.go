@@ -0,0 +1,139 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package systemd
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+)
+
+// Dir is the directory units written by converge are installed to
+const Dir = "/etc/systemd/system"
+
+// Mode is the mode unit files are written with
+const Mode = 0644
+
+// UnitTypes are the suffixes of valid unit names
+var UnitTypes = []string{
+	"automount", "mount", "path", "scope", "service",
+	"slice", "socket", "swap", "target", "timer",
+}
+
+// ValidName returns an error if name is not the name of a unit
+func ValidName(name string) error {
+	if name == "" || strings.Contains(name, "/") {
+		return fmt.Errorf("%q is not a valid unit name", name)
+	}
+
+	ext := strings.TrimPrefix(path.Ext(name), ".")
+	for _, typ := range UnitTypes {
+		if ext == typ && name != "."+typ {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%q is not a valid unit name, expected a name ending in one of .%s", name, strings.Join(UnitTypes, ", ."))
+}
+
+// Render formats sections as a unit file. Sections are written with [Unit]
+// first and [Install] last. Keys are sorted within each section, and a key
+// with a list value is written once for each item, in order, so that an empty
+// first item resets a list such as ExecStart in a drop-in.
+func Render(sections map[string]map[string]interface{}) (string, error) {
+	var names []string
+	for name := range sections {
+		if name != "Unit" && name != "Install" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	if _, ok := sections["Unit"]; ok {
+		names = append([]string{"Unit"}, names...)
+	}
+	if _, ok := sections["Install"]; ok {
+		names = append(names, "Install")
+	}
+
+	var out []string
+	for _, name := range names {
+		if len(out) > 0 {
+			out = append(out, "")
+		}
+		out = append(out, "["+name+"]")
+
+		var keys []string
+		for key := range sections[name] {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			values, err := values(sections[name][key])
+			if err != nil {
+				return "", fmt.Errorf("[%s] %s: %s", name, key, err)
+			}
+			for _, value := range values {
+				out = append(out, key+"="+value)
+			}
+		}
+	}
+
+	return strings.Join(out, "\n") + "\n", nil
+}
+
+// DaemonReload makes systemd reread unit files
+func DaemonReload(e exec.Executor) error {
+	return exec.Run(e, "systemctl", "daemon-reload")
+}
+
+func values(val interface{}) ([]string, error) {
+	switch val := val.(type) {
+	case []interface{}:
+		var out []string
+		for _, item := range val {
+			values, err := values(item)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, values...)
+		}
+		return out, nil
+
+	case []string:
+		return val, nil
+
+	case string:
+		if strings.Contains(val, "\n") {
+			return nil, fmt.Errorf("values cannot contain newlines")
+		}
+		return []string{val}, nil
+
+	case bool:
+		if val {
+			return []string{"yes"}, nil
+		}
+		return []string{"no"}, nil
+
+	case int, int64, float64:
+		return []string{fmt.Sprint(val)}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported value %v", val)
+}
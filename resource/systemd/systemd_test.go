@@ -0,0 +1,92 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package systemd_test
+
+import (
+	"testing"
+
+	"github.com/asteris-llc/converge/resource/systemd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRender tests rendering sections as a unit file
+func TestRender(t *testing.T) {
+	t.Parallel()
+
+	t.Run("order", func(t *testing.T) {
+		out, err := systemd.Render(map[string]map[string]interface{}{
+			"Install": {"WantedBy": "multi-user.target"},
+			"Service": {
+				"ExecStart":   "/usr/bin/app",
+				"Environment": []interface{}{"A=1", "B=2"},
+				"Restart":     "always",
+			},
+			"Unit": {"Description": "App"},
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, `[Unit]
+Description=App
+
+[Service]
+Environment=A=1
+Environment=B=2
+ExecStart=/usr/bin/app
+Restart=always
+
+[Install]
+WantedBy=multi-user.target
+`, out)
+	})
+
+	t.Run("reset", func(t *testing.T) {
+		out, err := systemd.Render(map[string]map[string]interface{}{
+			"Service": {"ExecStart": []interface{}{"", "/usr/bin/app --flag"}},
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, "[Service]\nExecStart=\nExecStart=/usr/bin/app --flag\n", out)
+	})
+
+	t.Run("scalars", func(t *testing.T) {
+		out, err := systemd.Render(map[string]map[string]interface{}{
+			"Service": {"LimitNOFILE": 65536, "NoNewPrivileges": true},
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, "[Service]\nLimitNOFILE=65536\nNoNewPrivileges=yes\n", out)
+	})
+
+	t.Run("newline", func(t *testing.T) {
+		_, err := systemd.Render(map[string]map[string]interface{}{
+			"Service": {"ExecStart": "a\nb"},
+		})
+		assert.EqualError(t, err, "[Service] ExecStart: values cannot contain newlines")
+	})
+}
+
+// TestValidName tests unit name validation
+func TestValidName(t *testing.T) {
+	t.Parallel()
+
+	for _, name := range []string{"app.service", "backup.timer", "getty@tty1.service"} {
+		assert.NoError(t, systemd.ValidName(name), name)
+	}
+
+	for _, name := range []string{"", "app", ".service", "../app.service", "app.conf"} {
+		assert.Error(t, systemd.ValidName(name), name)
+	}
+}
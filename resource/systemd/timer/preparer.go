@@ -0,0 +1,140 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package timer
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/load/registry"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/systemd"
+)
+
+// Preparer for Timer
+//
+// Timer runs a command on a schedule with a systemd timer. It writes a
+// NAME.timer and a oneshot NAME.service to /etc/systemd/system, and keeps the
+// timer enabled and started. The schedule is compared in the normalized form
+// systemd uses, so equivalent calendar expressions are not reported as
+// changes.
+type Preparer struct {
+	// Name is the name of the timer and service units, without a suffix
+	Name string `hcl:"name" required:"true"`
+
+	// Schedule is the calendar expression the command runs on, such as
+	// "daily" or "Mon *-*-* 03:00:00". See systemd.time(7).
+	Schedule string `hcl:"schedule"`
+
+	// Command is the command line the service runs
+	Command string `hcl:"command"`
+
+	// User runs the command as this user instead of root
+	User string `hcl:"user"`
+
+	// Description is used for both units. It defaults to the command.
+	Description string `hcl:"description"`
+
+	// Persistent runs the command on boot if a run was missed while the system
+	// was down
+	Persistent bool `hcl:"persistent"`
+
+	// RandomizedDelay delays each run by a random time up to this value, such as
+	// "5m"
+	RandomizedDelay string `hcl:"randomized_delay"`
+
+	// State is whether the timer should be present
+	State State `hcl:"state" valid_values:"present,absent"`
+}
+
+// Prepare a new task
+func (p *Preparer) Prepare(render resource.Renderer) (resource.Task, error) {
+	if strings.ContainsAny(p.Name, "/.") {
+		return nil, fmt.Errorf("timer \"name\" cannot contain \"/\" or \".\"")
+	}
+
+	if p.State == "" {
+		p.State = StatePresent
+	}
+
+	t := &Timer{
+		Name:        p.Name,
+		Schedule:    p.Schedule,
+		Command:     p.Command,
+		TimerPath:   path.Join(systemd.Dir, p.Name+".timer"),
+		ServicePath: path.Join(systemd.Dir, p.Name+".service"),
+		State:       p.State,
+		exec:        exec.For(render),
+	}
+
+	if p.State == StateAbsent {
+		return t, nil
+	}
+
+	if p.Schedule == "" || p.Command == "" {
+		return nil, fmt.Errorf("timer \"schedule\" and \"command\" are required when state is %q", StatePresent)
+	}
+
+	description := p.Description
+	if description == "" {
+		description = p.Command
+	}
+
+	service := map[string]interface{}{
+		"Type":      "oneshot",
+		"ExecStart": p.Command,
+	}
+	if p.User != "" {
+		service["User"] = p.User
+	}
+
+	schedule := map[string]interface{}{
+		"OnCalendar": p.Schedule,
+		"Unit":       p.Name + ".service",
+	}
+	if p.Persistent {
+		schedule["Persistent"] = true
+	}
+	if p.RandomizedDelay != "" {
+		schedule["RandomizedDelaySec"] = p.RandomizedDelay
+	}
+
+	var err error
+	t.ServiceContent, err = systemd.Render(map[string]map[string]interface{}{
+		"Unit":    {"Description": description},
+		"Service": service,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	t.TimerContent, err = systemd.Render(map[string]map[string]interface{}{
+		"Unit":    {"Description": description},
+		"Timer":   schedule,
+		"Install": {"WantedBy": "timers.target"},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+func init() {
+	registry.Register("systemd.timer", (*Preparer)(nil), (*Timer)(nil))
+	registry.RegisterPlatforms("systemd.timer", "linux")
+}
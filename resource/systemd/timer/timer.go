@@ -0,0 +1,233 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package timer
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/systemd"
+	"github.com/pkg/errors"
+)
+
+// State type for Timer
+type State string
+
+const (
+	// StatePresent indicates the timer should be installed and running
+	StatePresent State = "present"
+
+	// StateAbsent indicates the timer should be stopped and removed
+	StateAbsent State = "absent"
+)
+
+var (
+	normalizedRe = regexp.MustCompile(`(?m)^\s*Normalized form:\s*(.+?)\s*$`)
+	onCalendarRe = regexp.MustCompile(`OnCalendar=(.+?)\s*;`)
+)
+
+// Timer manages a timer and the service it runs
+type Timer struct {
+	resource.Status
+
+	Name     string
+	Schedule string
+	Command  string
+
+	TimerPath      string
+	TimerContent   string
+	ServicePath    string
+	ServiceContent string
+
+	State State
+
+	exec exec.Executor
+}
+
+// Check whether the units are installed, loaded with the declared schedule,
+// enabled, and started
+func (t *Timer) Check(resource.Renderer) (resource.TaskStatus, error) {
+	t.Status = resource.Status{}
+
+	if t.State == StateAbsent {
+		for _, file := range []string{t.TimerPath, t.ServicePath} {
+			_, exists, err := exec.ReadFile(t.exec, file)
+			if err != nil {
+				t.RaiseLevel(resource.StatusFatal)
+				return t, errors.Wrapf(err, "cannot read %s", file)
+			}
+			if exists {
+				t.RaiseLevel(resource.StatusWillChange)
+				t.AddDifference(file, "<present>", "<file-missing>", "")
+			}
+		}
+		return t, nil
+	}
+
+	if t.State != StatePresent {
+		t.RaiseLevel(resource.StatusFatal)
+		return t, fmt.Errorf("timer: unrecognized state %s", t.State)
+	}
+
+	for file, content := range map[string]string{t.TimerPath: t.TimerContent, t.ServicePath: t.ServiceContent} {
+		current, exists, err := exec.ReadFile(t.exec, file)
+		if err != nil {
+			t.RaiseLevel(resource.StatusFatal)
+			return t, errors.Wrapf(err, "cannot read %s", file)
+		}
+		if !exists {
+			current = "<file-missing>"
+		}
+		if current != content {
+			t.RaiseLevel(resource.StatusWillChange)
+			t.AddDifference(file, current, content, "")
+		}
+	}
+
+	normalized, err := t.normalize()
+	if err != nil {
+		t.RaiseLevel(resource.StatusFatal)
+		return t, err
+	}
+
+	loaded, err := t.loaded()
+	if err != nil {
+		t.RaiseLevel(resource.StatusFatal)
+		return t, err
+	}
+	if loaded != normalized {
+		t.RaiseLevel(resource.StatusWillChange)
+		t.AddDifference("schedule", loaded, normalized, "")
+	}
+
+	for _, check := range []string{"enabled", "active"} {
+		current, _ := exec.Read(t.exec, "systemctl", "is-"+check, t.unit())
+		if current = strings.TrimSpace(current); current != check {
+			t.RaiseLevel(resource.StatusWillChange)
+			t.AddDifference(check, current, check, "")
+		}
+	}
+
+	return t, nil
+}
+
+// Apply installs or removes the units
+func (t *Timer) Apply() (resource.TaskStatus, error) {
+	t.Status = resource.Status{}
+
+	var err error
+	if t.State == StateAbsent {
+		err = t.remove()
+	} else {
+		err = t.install()
+	}
+
+	if err != nil {
+		t.RaiseLevel(resource.StatusFatal)
+		return t, err
+	}
+	return t, nil
+}
+
+func (t *Timer) install() error {
+	if err := exec.Run(t.exec, "mkdir", "-p", systemd.Dir); err != nil {
+		return errors.Wrapf(err, "cannot create %s", systemd.Dir)
+	}
+
+	for _, file := range []struct{ path, content string }{
+		{t.ServicePath, t.ServiceContent},
+		{t.TimerPath, t.TimerContent},
+	} {
+		if err := exec.WriteFile(t.exec, file.path, file.content, systemd.Mode); err != nil {
+			return errors.Wrapf(err, "cannot write %s", file.path)
+		}
+	}
+
+	if err := systemd.DaemonReload(t.exec); err != nil {
+		return errors.Wrap(err, "cannot reload systemd")
+	}
+
+	if err := exec.Run(t.exec, "systemctl", "enable", t.unit()); err != nil {
+		return errors.Wrapf(err, "cannot enable %s", t.unit())
+	}
+
+	// restarting a timer recalculates the next elapse from the new schedule
+	if err := exec.Run(t.exec, "systemctl", "restart", t.unit()); err != nil {
+		return errors.Wrapf(err, "cannot start %s", t.unit())
+	}
+
+	t.AddMessage(fmt.Sprintf("installed and started %s", t.unit()))
+	return nil
+}
+
+func (t *Timer) remove() error {
+	_, exists, err := exec.ReadFile(t.exec, t.TimerPath)
+	if err != nil {
+		return errors.Wrapf(err, "cannot read %s", t.TimerPath)
+	}
+
+	if exists {
+		if err := exec.Run(t.exec, "systemctl", "disable", "--now", t.unit()); err != nil {
+			return errors.Wrapf(err, "cannot stop %s", t.unit())
+		}
+	}
+
+	if err := exec.Run(t.exec, "rm", "-f", t.TimerPath, t.ServicePath); err != nil {
+		return errors.Wrapf(err, "cannot remove %s", t.unit())
+	}
+
+	if err := systemd.DaemonReload(t.exec); err != nil {
+		return errors.Wrap(err, "cannot reload systemd")
+	}
+
+	t.AddMessage(fmt.Sprintf("removed %s", t.unit()))
+	return nil
+}
+
+// normalize returns the schedule in the form systemd prints it
+func (t *Timer) normalize() (string, error) {
+	out, err := exec.Read(t.exec, "systemd-analyze", "calendar", t.Schedule)
+	if err != nil {
+		return "", errors.Wrapf(err, "%q is not a valid schedule", t.Schedule)
+	}
+
+	match := normalizedRe.FindStringSubmatch(out)
+	if match == nil {
+		return "", fmt.Errorf("could not read normalized schedule from systemd-analyze output %q", out)
+	}
+	return match[1], nil
+}
+
+// loaded returns the schedule of the timer as systemd has loaded it, or
+// "<none>" if the timer is not loaded
+func (t *Timer) loaded() (string, error) {
+	out, err := exec.Read(t.exec, "systemctl", "show", "--property=TimersCalendar", t.unit())
+	if err != nil {
+		return "", errors.Wrapf(err, "cannot read schedule of %s", t.unit())
+	}
+
+	match := onCalendarRe.FindStringSubmatch(out)
+	if match == nil {
+		return "<none>", nil
+	}
+	return match[1], nil
+}
+
+func (t *Timer) unit() string {
+	return t.Name + ".timer"
+}
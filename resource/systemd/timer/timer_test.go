@@ -0,0 +1,215 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package timer_test
+
+import (
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/helpers/fakeexec"
+	"github.com/asteris-llc/converge/helpers/fakerenderer"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/systemd/timer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	timerPath   = "/etc/systemd/system/backup.timer"
+	servicePath = "/etc/systemd/system/backup.service"
+	write       = exec.WriteFileScript
+
+	timerContent = `[Unit]
+Description=/usr/local/bin/backup
+
+[Timer]
+OnCalendar=daily
+Persistent=yes
+Unit=backup.service
+
+[Install]
+WantedBy=timers.target
+`
+
+	serviceContent = `[Unit]
+Description=/usr/local/bin/backup
+
+[Service]
+ExecStart=/usr/local/bin/backup
+Type=oneshot
+User=backup
+`
+
+	analyze = `  Original form: daily
+Normalized form: *-*-* 00:00:00
+    Next elapse: Thu 2016-10-13 00:00:00 UTC
+`
+)
+
+// TestTimerInterface tests that Timer is properly implemented
+func TestTimerInterface(t *testing.T) {
+	t.Parallel()
+
+	assert.Implements(t, (*resource.Task)(nil), new(timer.Timer))
+	assert.Implements(t, (*resource.Resource)(nil), new(timer.Preparer))
+}
+
+// TestPrepare tests rendering the units
+func TestPrepare(t *testing.T) {
+	t.Parallel()
+
+	tm := prepare(t, fakeexec.New(), backup())
+	assert.Equal(t, timerContent, tm.TimerContent)
+	assert.Equal(t, serviceContent, tm.ServiceContent)
+
+	_, err := (&timer.Preparer{Name: "backup"}).Prepare(fakerenderer.New())
+	assert.EqualError(t, err, `timer "schedule" and "command" are required when state is "present"`)
+
+	_, err = (&timer.Preparer{Name: "backup.timer", Schedule: "daily", Command: "true"}).Prepare(fakerenderer.New())
+	assert.EqualError(t, err, `timer "name" cannot contain "/" or "."`)
+}
+
+// TestCheck tests the possible cases Check handles
+func TestCheck(t *testing.T) {
+	t.Parallel()
+
+	t.Run("converged", func(t *testing.T) {
+		fake := installed("TimersCalendar={ OnCalendar=*-*-* 00:00:00 ; next_elapse=Thu 2016-10-13 00:00:00 UTC }\n")
+
+		status, err := prepare(t, fake, backup()).Check(fakerenderer.New())
+
+		require.NoError(t, err)
+		assert.False(t, status.HasChanges())
+		fake.AssertExpectations(t)
+	})
+
+	t.Run("schedule drift", func(t *testing.T) {
+		fake := installed("TimersCalendar={ OnCalendar=*-*-* 04:00:00 ; next_elapse=Thu 2016-10-13 04:00:00 UTC }\n")
+
+		status, err := prepare(t, fake, backup()).Check(fakerenderer.New())
+
+		require.NoError(t, err)
+		assert.True(t, status.HasChanges())
+		assert.Equal(t, "*-*-* 04:00:00", status.Diffs()["schedule"].Original())
+		assert.Equal(t, "*-*-* 00:00:00", status.Diffs()["schedule"].Current())
+	})
+
+	t.Run("not installed", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("test", "-e", timerPath).Return("", 1)
+		fake.Expect("test", "-e", servicePath).Return("", 1)
+		fake.Expect("systemd-analyze", "calendar", "daily").Return(analyze, 0)
+		fake.Expect("systemctl", "show", "--property=TimersCalendar", "backup.timer").Return("TimersCalendar=\n", 0)
+		fake.Expect("systemctl", "is-enabled", "backup.timer").Return("", 1)
+		fake.Expect("systemctl", "is-active", "backup.timer").Return("inactive\n", 3)
+
+		status, err := prepare(t, fake, backup()).Check(fakerenderer.New())
+
+		require.NoError(t, err)
+		assert.Equal(t, "<file-missing>", status.Diffs()[timerPath].Original())
+		assert.Equal(t, "<none>", status.Diffs()["schedule"].Original())
+		assert.Equal(t, "inactive", status.Diffs()["active"].Original())
+		assert.Contains(t, status.Diffs(), "enabled")
+	})
+
+	t.Run("invalid schedule", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("test", "-e", timerPath).Return("", 1)
+		fake.Expect("test", "-e", servicePath).Return("", 1)
+		fake.Expect("systemd-analyze", "calendar", "daily").Stderr("Failed to parse calendar specification").Return("", 1)
+
+		status, err := prepare(t, fake, backup()).Check(fakerenderer.New())
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `"daily" is not a valid schedule`)
+		assert.Equal(t, resource.StatusFatal, status.StatusCode())
+	})
+
+	t.Run("absent", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("test", "-e", timerPath)
+		fake.Expect("cat", timerPath).Return(timerContent, 0)
+		fake.Expect("test", "-e", servicePath).Return("", 1)
+
+		status, err := prepare(t, fake, &timer.Preparer{Name: "backup", State: timer.StateAbsent}).Check(fakerenderer.New())
+
+		require.NoError(t, err)
+		assert.True(t, status.HasChanges())
+		assert.NotContains(t, status.Diffs(), servicePath)
+	})
+}
+
+// TestApply tests the possible cases Apply handles
+func TestApply(t *testing.T) {
+	t.Parallel()
+
+	t.Run("present", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("mkdir", "-p", "/etc/systemd/system")
+		fake.Expect("sh", "-c", write, servicePath, "0644")
+		fake.Expect("sh", "-c", write, timerPath, "0644")
+		fake.Expect("systemctl", "daemon-reload")
+		fake.Expect("systemctl", "enable", "backup.timer")
+		fake.Expect("systemctl", "restart", "backup.timer")
+
+		_, err := prepare(t, fake, backup()).Apply()
+
+		require.NoError(t, err)
+		fake.AssertExpectations(t)
+	})
+
+	t.Run("absent", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("test", "-e", timerPath)
+		fake.Expect("cat", timerPath).Return(timerContent, 0)
+		fake.Expect("systemctl", "disable", "--now", "backup.timer")
+		fake.Expect("rm", "-f", timerPath, servicePath)
+		fake.Expect("systemctl", "daemon-reload")
+
+		_, err := prepare(t, fake, &timer.Preparer{Name: "backup", State: timer.StateAbsent}).Apply()
+
+		require.NoError(t, err)
+		fake.AssertExpectations(t)
+	})
+}
+
+func backup() *timer.Preparer {
+	return &timer.Preparer{
+		Name:       "backup",
+		Schedule:   "daily",
+		Command:    "/usr/local/bin/backup",
+		User:       "backup",
+		Persistent: true,
+	}
+}
+
+func installed(show string) *fakeexec.Executor {
+	fake := fakeexec.New()
+	fake.Expect("test", "-e", timerPath)
+	fake.Expect("cat", timerPath).Return(timerContent, 0)
+	fake.Expect("test", "-e", servicePath)
+	fake.Expect("cat", servicePath).Return(serviceContent, 0)
+	fake.Expect("systemd-analyze", "calendar", "daily").Return(analyze, 0)
+	fake.Expect("systemctl", "show", "--property=TimersCalendar", "backup.timer").Return(show, 0)
+	fake.Expect("systemctl", "is-enabled", "backup.timer").Return("enabled\n", 0)
+	fake.Expect("systemctl", "is-active", "backup.timer").Return("active\n", 0)
+	return fake
+}
+
+func prepare(t *testing.T, fake *fakeexec.Executor, p *timer.Preparer) *timer.Timer {
+	task, err := p.Prepare(&resource.ExecRenderer{Renderer: fakerenderer.New(), Exec: fake})
+	require.NoError(t, err)
+	return task.(*timer.Timer)
+}
@@ -0,0 +1,94 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package unitfile
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/load/registry"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/systemd"
+)
+
+// Preparer for UnitFile
+//
+// UnitFile writes a unit file, or a drop-in override for an existing unit, to
+// /etc/systemd/system and runs `systemctl daemon-reload` when it changes.
+// Running units are not restarted; restart them with a task that depends on
+// this resource.
+type Preparer struct {
+	// Name is the name of the unit, including its type, such as "app.service"
+	Name string `hcl:"name" required:"true"`
+
+	// DropIn is the name of a drop-in to write for the unit instead of the
+	// unit file itself. The drop-in is written to NAME.d/DROPIN.conf.
+	DropIn string `hcl:"dropin"`
+
+	// Sections maps section names, such as "Service", to their keys. A list
+	// value writes the key once for each item.
+	Sections map[string]map[string]interface{} `hcl:"sections"`
+
+	// State is whether the file should be present
+	State State `hcl:"state" valid_values:"present,absent"`
+}
+
+// Prepare a new task
+func (p *Preparer) Prepare(render resource.Renderer) (resource.Task, error) {
+	if err := systemd.ValidName(p.Name); err != nil {
+		return nil, err
+	}
+
+	if strings.ContainsAny(p.DropIn, "/") {
+		return nil, fmt.Errorf("unit_file \"dropin\" must be a file name")
+	}
+
+	if p.State == "" {
+		p.State = StatePresent
+	}
+
+	var content string
+	if p.State == StatePresent {
+		if len(p.Sections) == 0 {
+			return nil, fmt.Errorf("unit_file \"sections\" is required when state is %q", StatePresent)
+		}
+
+		var err error
+		content, err = systemd.Render(p.Sections)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	file := path.Join(systemd.Dir, p.Name)
+	if p.DropIn != "" {
+		file = path.Join(systemd.Dir, p.Name+".d", strings.TrimSuffix(p.DropIn, ".conf")+".conf")
+	}
+
+	return &UnitFile{
+		Name:    p.Name,
+		Path:    file,
+		Content: content,
+		State:   p.State,
+		exec:    exec.For(render),
+	}, nil
+}
+
+func init() {
+	registry.Register("systemd.unit_file", (*Preparer)(nil), (*UnitFile)(nil))
+	registry.RegisterPlatforms("systemd.unit_file", "linux")
+}
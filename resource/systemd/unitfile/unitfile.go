@@ -0,0 +1,124 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package unitfile
+
+import (
+	"fmt"
+	"path"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/systemd"
+	"github.com/pkg/errors"
+)
+
+// State type for UnitFile
+type State string
+
+const (
+	// StatePresent indicates the file should be present
+	StatePresent State = "present"
+
+	// StateAbsent indicates the file should be absent
+	StateAbsent State = "absent"
+)
+
+// UnitFile manages a unit file or drop-in
+type UnitFile struct {
+	resource.Status
+
+	Name    string
+	Path    string
+	Content string
+	State   State
+
+	exec exec.Executor
+}
+
+// Check whether the file has the rendered content
+func (u *UnitFile) Check(resource.Renderer) (resource.TaskStatus, error) {
+	u.Status = resource.Status{}
+
+	current, exists, err := exec.ReadFile(u.exec, u.Path)
+	if err != nil {
+		u.RaiseLevel(resource.StatusFatal)
+		return u, errors.Wrapf(err, "cannot read %s", u.Path)
+	}
+	if !exists {
+		current = "<file-missing>"
+	}
+
+	switch u.State {
+	case StatePresent:
+		if !exists || current != u.Content {
+			u.RaiseLevel(resource.StatusWillChange)
+			u.AddDifference(u.Path, current, u.Content, "")
+		}
+
+	case StateAbsent:
+		if exists {
+			u.RaiseLevel(resource.StatusWillChange)
+			u.AddDifference(u.Path, current, "<file-missing>", "")
+		}
+
+	default:
+		u.RaiseLevel(resource.StatusFatal)
+		return u, fmt.Errorf("unit_file: unrecognized state %s", u.State)
+	}
+
+	return u, nil
+}
+
+// Apply writes or removes the file and reloads systemd
+func (u *UnitFile) Apply() (resource.TaskStatus, error) {
+	u.Status = resource.Status{}
+
+	if u.State == StateAbsent {
+		if err := exec.Run(u.exec, "rm", "-f", u.Path); err != nil {
+			u.RaiseLevel(resource.StatusFatal)
+			return u, errors.Wrapf(err, "cannot remove %s", u.Path)
+		}
+		u.AddMessage(fmt.Sprintf("removed %s", u.Path))
+	} else {
+		if err := exec.Run(u.exec, "mkdir", "-p", path.Dir(u.Path)); err != nil {
+			u.RaiseLevel(resource.StatusFatal)
+			return u, errors.Wrapf(err, "cannot create %s", path.Dir(u.Path))
+		}
+		if err := exec.WriteFile(u.exec, u.Path, u.Content, systemd.Mode); err != nil {
+			u.RaiseLevel(resource.StatusFatal)
+			return u, errors.Wrapf(err, "cannot write %s", u.Path)
+		}
+		u.AddMessage(fmt.Sprintf("wrote %s", u.Path))
+	}
+
+	if err := systemd.DaemonReload(u.exec); err != nil {
+		u.RaiseLevel(resource.StatusFatal)
+		return u, errors.Wrap(err, "cannot reload systemd")
+	}
+	u.AddMessage("reloaded systemd")
+
+	return u, nil
+}
+
+// ManagedFiles returns the path of the unit file, so it is backed up before being
+// changed
+func (u *UnitFile) ManagedFiles() []string {
+	return []string{u.Path}
+}
+
+// Executor returns the executor the file is written with
+func (u *UnitFile) Executor() exec.Executor {
+	return u.exec
+}
@@ -0,0 +1,170 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package unitfile_test
+
+import (
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/helpers/fakeexec"
+	"github.com/asteris-llc/converge/helpers/fakerenderer"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/systemd/unitfile"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	path    = "/etc/systemd/system/app.service"
+	content = "[Service]\nExecStart=/usr/bin/app\n"
+)
+
+var sections = map[string]map[string]interface{}{
+	"Service": {"ExecStart": "/usr/bin/app"},
+}
+
+// TestUnitFileInterface tests that UnitFile is properly implemented
+func TestUnitFileInterface(t *testing.T) {
+	t.Parallel()
+
+	assert.Implements(t, (*resource.Task)(nil), new(unitfile.UnitFile))
+	assert.Implements(t, (*resource.Resource)(nil), new(unitfile.Preparer))
+}
+
+// TestCheck tests the possible cases Check handles
+func TestCheck(t *testing.T) {
+	t.Parallel()
+
+	t.Run("missing", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("test", "-e", path).Return("", 1)
+
+		u := prepare(t, fake, &unitfile.Preparer{Name: "app.service", Sections: sections})
+		status, err := u.Check(fakerenderer.New())
+
+		require.NoError(t, err)
+		assert.Equal(t, "<file-missing>", status.Diffs()[path].Original())
+		assert.Equal(t, content, status.Diffs()[path].Current())
+	})
+
+	t.Run("converged", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("test", "-e", path)
+		fake.Expect("cat", path).Return(content, 0)
+
+		u := prepare(t, fake, &unitfile.Preparer{Name: "app.service", Sections: sections})
+		status, err := u.Check(fakerenderer.New())
+
+		require.NoError(t, err)
+		assert.False(t, status.HasChanges())
+	})
+
+	t.Run("drifted", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("test", "-e", path)
+		fake.Expect("cat", path).Return("[Service]\nExecStart=/usr/local/bin/app\n", 0)
+
+		u := prepare(t, fake, &unitfile.Preparer{Name: "app.service", Sections: sections})
+		status, err := u.Check(fakerenderer.New())
+
+		require.NoError(t, err)
+		assert.True(t, status.HasChanges())
+	})
+
+	t.Run("absent", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("test", "-e", path)
+		fake.Expect("cat", path).Return(content, 0)
+
+		u := prepare(t, fake, &unitfile.Preparer{Name: "app.service", State: unitfile.StateAbsent})
+		status, err := u.Check(fakerenderer.New())
+
+		require.NoError(t, err)
+		assert.Equal(t, "<file-missing>", status.Diffs()[path].Current())
+	})
+}
+
+// TestApply tests the possible cases Apply handles
+func TestApply(t *testing.T) {
+	t.Parallel()
+
+	t.Run("dropin", func(t *testing.T) {
+		dropin := "/etc/systemd/system/app.service.d/limits.conf"
+
+		fake := fakeexec.New()
+		fake.Expect("mkdir", "-p", "/etc/systemd/system/app.service.d")
+		fake.Expect("sh", "-c", exec.WriteFileScript, dropin, "0644")
+		fake.Expect("systemctl", "daemon-reload")
+
+		u := prepare(t, fake, &unitfile.Preparer{
+			Name:     "app.service",
+			DropIn:   "limits",
+			Sections: map[string]map[string]interface{}{"Service": {"LimitNOFILE": "65536"}},
+		})
+		_, err := u.Apply()
+
+		require.NoError(t, err)
+		fake.AssertExpectations(t)
+		assert.Equal(t, "[Service]\nLimitNOFILE=65536\n", fake.Calls()[1].Stdin)
+	})
+
+	t.Run("absent", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("rm", "-f", path)
+		fake.Expect("systemctl", "daemon-reload")
+
+		u := prepare(t, fake, &unitfile.Preparer{Name: "app.service", State: unitfile.StateAbsent})
+		_, err := u.Apply()
+
+		require.NoError(t, err)
+		fake.AssertExpectations(t)
+	})
+
+	t.Run("reload fails", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("mkdir", "-p", "/etc/systemd/system")
+		fake.Expect("sh", "-c", exec.WriteFileScript, path, "0644")
+		fake.Expect("systemctl", "daemon-reload").Return("", 1)
+
+		u := prepare(t, fake, &unitfile.Preparer{Name: "app.service", Sections: sections})
+		status, err := u.Apply()
+
+		assert.Error(t, err)
+		assert.Equal(t, resource.StatusFatal, status.StatusCode())
+	})
+}
+
+// TestPrepare tests the valid and invalid cases of Prepare
+func TestPrepare(t *testing.T) {
+	t.Parallel()
+
+	fr := fakerenderer.New()
+
+	_, err := (&unitfile.Preparer{Name: "app.service"}).Prepare(fr)
+	assert.EqualError(t, err, `unit_file "sections" is required when state is "present"`)
+
+	_, err = (&unitfile.Preparer{Name: "app.service", DropIn: "../x", Sections: sections}).Prepare(fr)
+	assert.EqualError(t, err, `unit_file "dropin" must be a file name`)
+
+	task, err := (&unitfile.Preparer{Name: "app.service", DropIn: "10-env.conf", Sections: sections}).Prepare(fr)
+	require.NoError(t, err)
+	assert.Equal(t, "/etc/systemd/system/app.service.d/10-env.conf", task.(*unitfile.UnitFile).Path)
+}
+
+func prepare(t *testing.T, fake *fakeexec.Executor, p *unitfile.Preparer) *unitfile.UnitFile {
+	task, err := p.Prepare(&resource.ExecRenderer{Renderer: fakerenderer.New(), Exec: fake})
+	require.NoError(t, err)
+	return task.(*unitfile.UnitFile)
+}
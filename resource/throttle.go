@@ -0,0 +1,140 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/helpers/statedir"
+	"github.com/pkg/errors"
+)
+
+// ThrottleDir holds the time of the last successful apply of throttled nodes.
+// Each module that was loaded has a directory in it, holding files named after
+// the escaped IDs of its nodes, so that nodes don't share a time with nodes of
+// the same name in other modules.
+const ThrottleDir = "/var/lib/converge/throttle"
+
+// throttling holds the node-level setting that limits how often a resource is
+// applied. Like "depends" and "group", it can be set on any resource.
+type throttling struct {
+	// Throttle is the least amount of time between applies of the resource, as
+	// a duration string like "24h". Until it has passed since the last
+	// successful apply the resource is not even checked.
+	Throttle string `hcl:"throttle" doc_type:"duration string"`
+}
+
+// Throttled wraps a task so that it is only checked and applied once Interval
+// has passed since it was last applied successfully. The time of the last
+// success is stored on the target, so it survives between runs.
+type Throttled struct {
+	Wrapped
+
+	throttleInterval time.Duration
+	throttlePath     string
+	throttleExec     exec.Executor
+}
+
+// Check checks the wrapped task if it is due, and reports that it is throttled
+// otherwise
+func (t *Throttled) Check(r Renderer) (TaskStatus, error) {
+	last, err := t.last()
+	if err != nil {
+		return &Status{Level: StatusFatal}, err
+	}
+
+	if !last.IsZero() {
+		since := time.Since(last)
+		if since < t.throttleInterval {
+			status := &Status{}
+			status.AddMessage(fmt.Sprintf(
+				"throttled: last applied %s ago, next run after %s",
+				since-since%time.Second,
+				last.Add(t.throttleInterval).Format(time.RFC3339),
+			))
+			return status, nil
+		}
+	}
+
+	return t.Task.Check(r)
+}
+
+// Apply applies the wrapped task and records the time if it succeeded
+func (t *Throttled) Apply() (TaskStatus, error) {
+	status, err := t.Task.Apply()
+	if err != nil || (status != nil && status.StatusCode() == StatusFatal) {
+		return status, err
+	}
+
+	if err := exec.Run(t.throttleExec, "mkdir", "-p", path.Dir(t.throttlePath)); err != nil {
+		return status, errors.Wrapf(err, "could not create %s", path.Dir(t.throttlePath))
+	}
+
+	stamp := time.Now().UTC().Format(time.RFC3339) + "\n"
+	if err := exec.WriteFile(t.throttleExec, t.throttlePath, stamp, 0644); err != nil {
+		return status, errors.Wrapf(err, "could not record apply in %s", t.throttlePath)
+	}
+
+	return status, nil
+}
+
+// last returns the time of the last successful apply, or the zero time if
+// there hasn't been one
+func (t *Throttled) last() (time.Time, error) {
+	content, exists, err := exec.ReadFile(t.throttleExec, t.throttlePath)
+	if err != nil || !exists {
+		return time.Time{}, err
+	}
+
+	last, err := time.Parse(time.RFC3339, strings.TrimSpace(content))
+	if err != nil {
+		return time.Time{}, errors.Wrapf(err, "could not parse %s", t.throttlePath)
+	}
+	return last, nil
+}
+
+// prepareThrottle wraps task in a Throttled if the node sets "throttle"
+func (p *Preparer) prepareThrottle(r Renderer, task Task) (Task, error) {
+	field, _ := reflect.TypeOf(throttling{}).FieldByName("Throttle")
+	if _, ok := p.Source[p.getFieldName(field)]; !ok {
+		return task, nil
+	}
+
+	val, err := p.getValueForField(r, field)
+	if err != nil {
+		return nil, err
+	}
+
+	interval, err := time.ParseDuration(val.String())
+	if err != nil {
+		return nil, errors.Wrap(err, "could not parse throttle")
+	}
+	if interval <= 0 {
+		return nil, fmt.Errorf("throttle must be positive, got %s", interval)
+	}
+
+	return &Throttled{
+		Wrapped:          Wrapped{Task: task},
+		throttleInterval: interval,
+		throttlePath:     path.Join(ThrottleDir, statedir.For(r), url.QueryEscape(r.GetID())),
+		throttleExec:     exec.For(r),
+	}, nil
+}
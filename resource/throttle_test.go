@@ -0,0 +1,184 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/helpers/fakeexec"
+	"github.com/asteris-llc/converge/helpers/fakerenderer"
+	"github.com/asteris-llc/converge/helpers/statedir"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestPreparerThrottle tests that throttled resources are only checked and
+// applied once the interval has passed
+func TestPreparerThrottle(t *testing.T) {
+	t.Parallel()
+
+	var (
+		dir   = "/var/lib/converge/throttle/" + statedir.Key("/etc/converge/a.hcl")
+		stamp = dir + "/root%2Ftask.backup"
+	)
+
+	renderer := func(fake *fakeexec.Executor, location, id string) resource.Renderer {
+		render := fakerenderer.NewWithID(id)
+		render.Location = location
+		return &resource.ExecRenderer{Renderer: render, Exec: fake}
+	}
+
+	prepare := func(t *testing.T, fake *fakeexec.Executor, source map[string]interface{}) (resource.Task, *testThrottleTarget) {
+		target := new(testThrottleTarget)
+		prep := resource.NewPreparerWithSource(target, source)
+
+		task, err := prep.Prepare(renderer(fake, "/etc/converge/a.hcl", "root/task.backup"))
+		require.NoError(t, err)
+		return task, target
+	}
+
+	t.Run("unset", func(t *testing.T) {
+		task, target := prepare(t, fakeexec.New(), map[string]interface{}{})
+		assert.Equal(t, target, task)
+	})
+
+	t.Run("never applied", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("test", "-e", stamp).Return("", 1)
+
+		task, target := prepare(t, fake, map[string]interface{}{"throttle": "24h"})
+		status, err := task.Check(fakerenderer.New())
+		require.NoError(t, err)
+		assert.True(t, status.HasChanges())
+		assert.Equal(t, 1, target.checks)
+	})
+
+	t.Run("recently applied", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("test", "-e", stamp)
+		fake.Expect("cat", stamp).Return(time.Now().Add(-time.Hour).Format(time.RFC3339)+"\n", 0)
+
+		task, target := prepare(t, fake, map[string]interface{}{"throttle": "24h"})
+		status, err := task.Check(fakerenderer.New())
+		require.NoError(t, err)
+		assert.False(t, status.HasChanges())
+		assert.Equal(t, 0, target.checks)
+		require.Len(t, status.Messages(), 1)
+		assert.True(t, strings.HasPrefix(status.Messages()[0], "throttled: last applied 1h0m0s ago"))
+	})
+
+	t.Run("due", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("test", "-e", stamp)
+		fake.Expect("cat", stamp).Return(time.Now().Add(-25*time.Hour).Format(time.RFC3339), 0)
+
+		task, target := prepare(t, fake, map[string]interface{}{"throttle": "24h"})
+		status, err := task.Check(fakerenderer.New())
+		require.NoError(t, err)
+		assert.True(t, status.HasChanges())
+		assert.Equal(t, 1, target.checks)
+	})
+
+	t.Run("apply records success", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("mkdir", "-p", dir)
+		fake.Expect("sh", "-c", exec.WriteFileScript, stamp, "0644")
+
+		task, target := prepare(t, fake, map[string]interface{}{"throttle": "24h"})
+		_, err := task.Apply()
+		require.NoError(t, err)
+		assert.Equal(t, 1, target.applies)
+		fake.AssertExpectations(t)
+
+		calls := fake.Calls()
+		recorded, err := time.Parse(time.RFC3339, strings.TrimSpace(calls[len(calls)-1].Stdin))
+		require.NoError(t, err)
+		assert.WithinDuration(t, time.Now(), recorded, time.Minute)
+	})
+
+	t.Run("stamps are kept per node", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("test", "-e", dir+"/root%2Fmodule.db%2Ftask.backup").Return("", 1)
+
+		prep := resource.NewPreparerWithSource(new(testThrottleTarget), map[string]interface{}{"throttle": "24h"})
+		task, err := prep.Prepare(renderer(fake, "/etc/converge/a.hcl", "root/module.db/task.backup"))
+		require.NoError(t, err)
+
+		_, err = task.Check(fakerenderer.New())
+		require.NoError(t, err)
+		fake.AssertExpectations(t)
+	})
+
+	t.Run("stamps are kept per module", func(t *testing.T) {
+		other := "/var/lib/converge/throttle/" + statedir.Key("/etc/converge/b.hcl") + "/root%2Ftask.backup"
+		require.NotEqual(t, stamp, other)
+
+		fake := fakeexec.New()
+		fake.Expect("test", "-e", other).Return("", 1)
+
+		prep := resource.NewPreparerWithSource(new(testThrottleTarget), map[string]interface{}{"throttle": "24h"})
+		task, err := prep.Prepare(renderer(fake, "/etc/converge/b.hcl", "root/task.backup"))
+		require.NoError(t, err)
+
+		_, err = task.Check(fakerenderer.New())
+		require.NoError(t, err)
+		fake.AssertExpectations(t)
+	})
+
+	t.Run("failed apply is not recorded", func(t *testing.T) {
+		fake := fakeexec.New()
+
+		task, target := prepare(t, fake, map[string]interface{}{"throttle": "24h"})
+		target.fail = true
+		_, err := task.Apply()
+		assert.Error(t, err)
+		assert.Empty(t, fake.Calls())
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		prep := resource.NewPreparerWithSource(new(testThrottleTarget), map[string]interface{}{"throttle": "daily"})
+		_, err := prep.Prepare(fakerenderer.New())
+		assert.Error(t, err)
+
+		prep = resource.NewPreparerWithSource(new(testThrottleTarget), map[string]interface{}{"throttle": "-1h"})
+		_, err = prep.Prepare(fakerenderer.New())
+		assert.EqualError(t, err, "throttle must be positive, got -1h0m0s")
+	})
+}
+
+type testThrottleTarget struct {
+	checks  int
+	applies int
+	fail    bool
+}
+
+func (ttt *testThrottleTarget) Prepare(resource.Renderer) (resource.Task, error) {
+	return ttt, nil
+}
+func (ttt *testThrottleTarget) Check(resource.Renderer) (resource.TaskStatus, error) {
+	ttt.checks++
+	return &resource.Status{Level: resource.StatusWillChange}, nil
+}
+func (ttt *testThrottleTarget) Apply() (resource.TaskStatus, error) {
+	ttt.applies++
+	if ttt.fail {
+		return &resource.Status{Level: resource.StatusFatal}, assert.AnError
+	}
+	return &resource.Status{}, nil
+}
@@ -0,0 +1,187 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package catrust
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/pkg/errors"
+)
+
+// Backend installs certificates into a system trust store
+type Backend interface {
+	// Installed reports whether the certificate is in the store
+	Installed(*Certificate) (bool, error)
+
+	// Install adds the certificate to the store
+	Install(*Certificate) error
+
+	// Remove removes the certificate from the store
+	Remove(*Certificate) error
+}
+
+// Backends are the names accepted by NewBackend
+var Backends = []string{"debian", "redhat", "macos"}
+
+// NewBackend returns the named backend, which runs commands with e
+func NewBackend(e exec.Executor, name string) (Backend, error) {
+	switch name {
+	case "debian":
+		return &Anchors{
+			Executor: e,
+			Dir:      "/usr/local/share/ca-certificates",
+			Ext:      ".crt",
+			Update:   []string{"update-ca-certificates"},
+			Purge:    []string{"update-ca-certificates", "--fresh"},
+		}, nil
+
+	case "redhat":
+		return &Anchors{
+			Executor: e,
+			Dir:      "/etc/pki/ca-trust/source/anchors",
+			Ext:      ".pem",
+			Update:   []string{"update-ca-trust", "extract"},
+			Purge:    []string{"update-ca-trust", "extract"},
+		}, nil
+
+	case "macos":
+		return &Keychain{
+			Executor: e,
+			Keychain: "/Library/Keychains/System.keychain",
+		}, nil
+	}
+
+	return nil, fmt.Errorf("%q is not a valid backend, expected one of %s", name, strings.Join(Backends, ", "))
+}
+
+// Detect returns the name of the backend for the system e runs commands on
+func Detect(e exec.Executor) (string, error) {
+	if system, err := exec.Read(e, "uname", "-s"); err == nil && strings.TrimSpace(system) == "Darwin" {
+		return "macos", nil
+	}
+
+	for _, candidate := range []struct{ backend, command string }{
+		{"debian", "update-ca-certificates"},
+		{"redhat", "update-ca-trust"},
+	} {
+		err := exec.Run(e, "sh", "-c", `command -v "$0"`, candidate.command)
+		if err == nil {
+			return candidate.backend, nil
+		}
+		if _, ok := exec.ExitStatus(err); !ok {
+			return "", err
+		}
+	}
+
+	return "", fmt.Errorf("could not find a supported trust store, expected one of %s", strings.Join(Backends, ", "))
+}
+
+// Anchors is a Backend for trust stores built from a directory of anchor
+// certificates, as on Debian and Red Hat
+type Anchors struct {
+	Executor exec.Executor
+
+	// Dir is the directory anchors are written to
+	Dir string
+
+	// Ext is the extension the update command expects anchors to have
+	Ext string
+
+	// Update rebuilds the store after an anchor is added
+	Update []string
+
+	// Purge rebuilds the store after an anchor is removed
+	Purge []string
+}
+
+// Installed reports whether an anchor with the certificate is in the
+// directory. Without a certificate, any anchor with the name counts.
+func (a *Anchors) Installed(cert *Certificate) (bool, error) {
+	current, exists, err := exec.ReadFile(a.Executor, a.path(cert))
+	if err != nil || !exists {
+		return false, err
+	}
+	return cert.PEM == "" || current == cert.PEM, nil
+}
+
+// Install writes the anchor and rebuilds the store
+func (a *Anchors) Install(cert *Certificate) error {
+	if err := exec.Run(a.Executor, "mkdir", "-p", a.Dir); err != nil {
+		return errors.Wrapf(err, "cannot create %s", a.Dir)
+	}
+	if err := exec.WriteFile(a.Executor, a.path(cert), cert.PEM, 0644); err != nil {
+		return errors.Wrapf(err, "cannot write %s", a.path(cert))
+	}
+	return exec.Run(a.Executor, a.Update[0], a.Update[1:]...)
+}
+
+// Remove removes the anchor and rebuilds the store
+func (a *Anchors) Remove(cert *Certificate) error {
+	if err := exec.Run(a.Executor, "rm", "-f", a.path(cert)); err != nil {
+		return errors.Wrapf(err, "cannot remove %s", a.path(cert))
+	}
+	return exec.Run(a.Executor, a.Purge[0], a.Purge[1:]...)
+}
+
+func (a *Anchors) path(cert *Certificate) string {
+	return path.Join(a.Dir, cert.Name+a.Ext)
+}
+
+// Keychain is a Backend for the macOS system keychain. Certificates are
+// identified by their SHA-1 fingerprint, so removing one requires it.
+type Keychain struct {
+	Executor exec.Executor
+
+	// Keychain is the path to the keychain
+	Keychain string
+}
+
+// Installed reports whether the keychain has a certificate with the same
+// fingerprint
+func (k *Keychain) Installed(cert *Certificate) (bool, error) {
+	out, err := exec.Read(k.Executor, "security", "find-certificate", "-a", "-Z", k.Keychain)
+	if err != nil {
+		return false, errors.Wrapf(err, "cannot list certificates in %s", k.Keychain)
+	}
+
+	for _, line := range strings.Split(out, "\n") {
+		if strings.TrimSpace(line) == "SHA-1 hash: "+cert.Fingerprint {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Install adds the certificate to the keychain as a trusted root
+func (k *Keychain) Install(cert *Certificate) error {
+	staged := path.Join("/tmp", "converge-"+cert.Name+".pem")
+	if err := exec.WriteFile(k.Executor, staged, cert.PEM, 0600); err != nil {
+		return errors.Wrapf(err, "cannot write %s", staged)
+	}
+
+	err := exec.Run(k.Executor, "security", "add-trusted-cert", "-d", "-r", "trustRoot", "-k", k.Keychain, staged)
+	if rmErr := exec.Run(k.Executor, "rm", "-f", staged); rmErr != nil && err == nil {
+		err = rmErr
+	}
+	return err
+}
+
+// Remove deletes the certificate and its trust settings from the keychain
+func (k *Keychain) Remove(cert *Certificate) error {
+	return exec.Run(k.Executor, "security", "delete-certificate", "-Z", cert.Fingerprint, "-t", k.Keychain)
+}
@@ -0,0 +1,183 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package catrust
+
+import (
+	"crypto/sha1"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"strings"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/pkg/errors"
+)
+
+// State type for CATrust
+type State string
+
+const (
+	// StatePresent indicates the certificate should be trusted
+	StatePresent State = "present"
+
+	// StateAbsent indicates the certificate should not be trusted
+	StateAbsent State = "absent"
+)
+
+// Certificate is a CA certificate to install
+type Certificate struct {
+	// Name identifies the certificate in stores that use file names
+	Name string
+
+	// PEM is the encoded certificate
+	PEM string
+
+	// Fingerprint is the uppercase hex SHA-1 of the certificate
+	Fingerprint string
+
+	// Subject is the subject of the certificate
+	Subject string
+}
+
+// ParseCertificate parses a single PEM-encoded certificate. The PEM is
+// re-encoded so that whitespace differences do not count as changes.
+func ParseCertificate(name, encoded string) (*Certificate, error) {
+	block, rest := pem.Decode([]byte(strings.TrimSpace(encoded)))
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, fmt.Errorf("expected a PEM-encoded certificate")
+	}
+	if strings.TrimSpace(string(rest)) != "" {
+		return nil, fmt.Errorf("expected a single certificate")
+	}
+
+	parsed, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not parse certificate")
+	}
+	if !parsed.IsCA {
+		return nil, fmt.Errorf("%s is not a CA certificate", parsed.Subject.CommonName)
+	}
+
+	return &Certificate{
+		Name:        name,
+		PEM:         string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: block.Bytes})),
+		Fingerprint: fmt.Sprintf("%X", sha1.Sum(block.Bytes)),
+		Subject:     parsed.Subject.CommonName,
+	}, nil
+}
+
+// CATrust manages a certificate in the system trust store
+type CATrust struct {
+	resource.Status
+
+	Certificate *Certificate
+	Backend     string
+	State       State
+
+	exec    exec.Executor
+	backend Backend
+}
+
+// Check whether the certificate is trusted
+func (c *CATrust) Check(resource.Renderer) (resource.TaskStatus, error) {
+	c.Status = resource.Status{}
+
+	backend, err := c.getBackend()
+	if err != nil {
+		c.RaiseLevel(resource.StatusFatal)
+		return c, err
+	}
+
+	installed, err := backend.Installed(c.Certificate)
+	if err != nil {
+		c.RaiseLevel(resource.StatusFatal)
+		return c, errors.Wrapf(err, "cannot check %s trust store", c.Backend)
+	}
+
+	switch c.State {
+	case StatePresent:
+		if !installed {
+			c.RaiseLevel(resource.StatusWillChange)
+			c.AddDifference(c.Certificate.Name, "<untrusted>", c.describe(), "")
+		}
+
+	case StateAbsent:
+		if installed {
+			c.RaiseLevel(resource.StatusWillChange)
+			c.AddDifference(c.Certificate.Name, c.describe(), "<untrusted>", "")
+		}
+
+	default:
+		c.RaiseLevel(resource.StatusFatal)
+		return c, fmt.Errorf("ca_trust: unrecognized state %s", c.State)
+	}
+
+	return c, nil
+}
+
+// Apply adds the certificate to or removes it from the trust store
+func (c *CATrust) Apply() (resource.TaskStatus, error) {
+	c.Status = resource.Status{}
+
+	backend, err := c.getBackend()
+	if err != nil {
+		c.RaiseLevel(resource.StatusFatal)
+		return c, err
+	}
+
+	if c.State == StateAbsent {
+		err = backend.Remove(c.Certificate)
+	} else {
+		err = backend.Install(c.Certificate)
+	}
+	if err != nil {
+		c.RaiseLevel(resource.StatusFatal)
+		return c, errors.Wrapf(err, "cannot update %s trust store", c.Backend)
+	}
+
+	c.AddMessage(fmt.Sprintf("updated %s trust store", c.Backend))
+	return c, nil
+}
+
+// getBackend returns the backend, detecting it the first time if it was not
+// set
+func (c *CATrust) getBackend() (Backend, error) {
+	if c.backend != nil {
+		return c.backend, nil
+	}
+
+	if c.Backend == "" {
+		name, err := Detect(c.exec)
+		if err != nil {
+			return nil, err
+		}
+		c.Backend = name
+	}
+
+	backend, err := NewBackend(c.exec, c.Backend)
+	if err != nil {
+		return nil, err
+	}
+	c.backend = backend
+	return backend, nil
+}
+
+func (c *CATrust) describe() string {
+	if c.Certificate.Subject == "" {
+		return "<trusted>"
+	}
+	return fmt.Sprintf("%s (SHA-1 %s)", c.Certificate.Subject, c.Certificate.Fingerprint)
+}
@@ -0,0 +1,277 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package catrust_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/helpers/fakeexec"
+	"github.com/asteris-llc/converge/helpers/fakerenderer"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/tls/catrust"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const anchor = "/usr/local/share/ca-certificates/internal.crt"
+
+// TestCATrustInterface tests that CATrust is properly implemented
+func TestCATrustInterface(t *testing.T) {
+	t.Parallel()
+
+	assert.Implements(t, (*resource.Task)(nil), new(catrust.CATrust))
+	assert.Implements(t, (*resource.Resource)(nil), new(catrust.Preparer))
+}
+
+// TestParseCertificate tests parsing and normalizing certificates
+func TestParseCertificate(t *testing.T) {
+	t.Parallel()
+
+	t.Run("valid", func(t *testing.T) {
+		encoded := generate(t, true)
+		cert, err := catrust.ParseCertificate("internal", "\n  "+encoded+"\n\n")
+		require.NoError(t, err)
+
+		assert.Equal(t, encoded, cert.PEM)
+		assert.Equal(t, "Internal CA", cert.Subject)
+		assert.Len(t, cert.Fingerprint, 40)
+	})
+
+	t.Run("not a CA", func(t *testing.T) {
+		_, err := catrust.ParseCertificate("internal", generate(t, false))
+		assert.EqualError(t, err, "Internal CA is not a CA certificate")
+	})
+
+	t.Run("not PEM", func(t *testing.T) {
+		_, err := catrust.ParseCertificate("internal", "hello")
+		assert.EqualError(t, err, "expected a PEM-encoded certificate")
+	})
+
+	t.Run("bundle", func(t *testing.T) {
+		encoded := generate(t, true)
+		_, err := catrust.ParseCertificate("internal", encoded+encoded)
+		assert.EqualError(t, err, "expected a single certificate")
+	})
+}
+
+// TestDetect tests detecting the trust store
+func TestDetect(t *testing.T) {
+	t.Parallel()
+
+	t.Run("macos", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("uname", "-s").Return("Darwin\n", 0)
+
+		backend, err := catrust.Detect(fake)
+		require.NoError(t, err)
+		assert.Equal(t, "macos", backend)
+	})
+
+	t.Run("redhat", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("uname", "-s").Return("Linux\n", 0)
+		fake.Expect("sh", "-c", `command -v "$0"`, "update-ca-certificates").Return("", 1)
+		fake.Expect("sh", "-c", `command -v "$0"`, "update-ca-trust").Return("/usr/bin/update-ca-trust\n", 0)
+
+		backend, err := catrust.Detect(fake)
+		require.NoError(t, err)
+		assert.Equal(t, "redhat", backend)
+	})
+
+	t.Run("none", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("uname", "-s").Return("Linux\n", 0)
+		fake.Expect("sh", "-c", `command -v "$0"`, "update-ca-certificates").Return("", 1)
+		fake.Expect("sh", "-c", `command -v "$0"`, "update-ca-trust").Return("", 1)
+
+		_, err := catrust.Detect(fake)
+		assert.EqualError(t, err, "could not find a supported trust store, expected one of debian, redhat, macos")
+	})
+}
+
+// TestCheck tests the possible cases Check handles
+func TestCheck(t *testing.T) {
+	t.Parallel()
+
+	encoded := generate(t, true)
+
+	t.Run("detected and missing", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("uname", "-s").Return("Linux\n", 0)
+		fake.Expect("sh", "-c", `command -v "$0"`, "update-ca-certificates")
+		fake.Expect("test", "-e", anchor).Return("", 1)
+
+		c := prepare(t, fake, &catrust.Preparer{Name: "internal", Certificate: encoded})
+		status, err := c.Check(fakerenderer.New())
+
+		require.NoError(t, err)
+		assert.True(t, status.HasChanges())
+		assert.Equal(t, "debian", c.Backend)
+		assert.Equal(t, "<untrusted>", status.Diffs()["internal"].Original())
+	})
+
+	t.Run("installed", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("test", "-e", anchor)
+		fake.Expect("cat", anchor).Return(encoded, 0)
+
+		c := prepare(t, fake, &catrust.Preparer{Name: "internal", Certificate: encoded, Backend: "debian"})
+		status, err := c.Check(fakerenderer.New())
+
+		require.NoError(t, err)
+		assert.False(t, status.HasChanges())
+	})
+
+	t.Run("absent by name", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("test", "-e", anchor)
+		fake.Expect("cat", anchor).Return("old certificate", 0)
+
+		c := prepare(t, fake, &catrust.Preparer{Name: "internal", Backend: "debian", State: catrust.StateAbsent})
+		status, err := c.Check(fakerenderer.New())
+
+		require.NoError(t, err)
+		assert.True(t, status.HasChanges())
+	})
+
+	t.Run("keychain", func(t *testing.T) {
+		cert, err := catrust.ParseCertificate("internal", encoded)
+		require.NoError(t, err)
+
+		fake := fakeexec.New()
+		fake.Expect("security", "find-certificate", "-a", "-Z", "/Library/Keychains/System.keychain").
+			Return("SHA-1 hash: "+cert.Fingerprint+"\nkeychain: \"/Library/Keychains/System.keychain\"\n", 0)
+
+		c := prepare(t, fake, &catrust.Preparer{Name: "internal", Certificate: encoded, Backend: "macos"})
+		status, err := c.Check(fakerenderer.New())
+
+		require.NoError(t, err)
+		assert.False(t, status.HasChanges())
+	})
+}
+
+// TestApply tests the possible cases Apply handles
+func TestApply(t *testing.T) {
+	t.Parallel()
+
+	encoded := generate(t, true)
+
+	t.Run("debian", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("mkdir", "-p", "/usr/local/share/ca-certificates")
+		fake.Expect("sh", "-c", exec.WriteFileScript, anchor, "0644")
+		fake.Expect("update-ca-certificates")
+
+		c := prepare(t, fake, &catrust.Preparer{Name: "internal", Certificate: encoded, Backend: "debian"})
+		_, err := c.Apply()
+
+		require.NoError(t, err)
+		fake.AssertExpectations(t)
+	})
+
+	t.Run("redhat absent", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("rm", "-f", "/etc/pki/ca-trust/source/anchors/internal.pem")
+		fake.Expect("update-ca-trust", "extract")
+
+		c := prepare(t, fake, &catrust.Preparer{Name: "internal", Backend: "redhat", State: catrust.StateAbsent})
+		_, err := c.Apply()
+
+		require.NoError(t, err)
+		fake.AssertExpectations(t)
+	})
+
+	t.Run("macos", func(t *testing.T) {
+		staged := "/tmp/converge-internal.pem"
+
+		fake := fakeexec.New()
+		fake.Expect("sh", "-c", exec.WriteFileScript, staged, "0600")
+		fake.Expect("security", "add-trusted-cert", "-d", "-r", "trustRoot", "-k", "/Library/Keychains/System.keychain", staged)
+		fake.Expect("rm", "-f", staged)
+
+		c := prepare(t, fake, &catrust.Preparer{Name: "internal", Certificate: encoded, Backend: "macos"})
+		_, err := c.Apply()
+
+		require.NoError(t, err)
+		fake.AssertExpectations(t)
+	})
+
+	t.Run("update fails", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("mkdir", "-p", "/usr/local/share/ca-certificates")
+		fake.Expect("sh", "-c", exec.WriteFileScript, anchor, "0644")
+		fake.Expect("update-ca-certificates").Return("", 1)
+
+		c := prepare(t, fake, &catrust.Preparer{Name: "internal", Certificate: encoded, Backend: "debian"})
+		status, err := c.Apply()
+
+		assert.Error(t, err)
+		assert.Equal(t, resource.StatusFatal, status.StatusCode())
+	})
+}
+
+// TestPrepare tests the invalid cases of Prepare
+func TestPrepare(t *testing.T) {
+	t.Parallel()
+
+	fr := fakerenderer.New()
+
+	_, err := (&catrust.Preparer{Name: "internal"}).Prepare(fr)
+	assert.EqualError(t, err, `ca_trust "certificate" is required`)
+
+	_, err = (&catrust.Preparer{Name: "internal", Backend: "macos", State: catrust.StateAbsent}).Prepare(fr)
+	assert.EqualError(t, err, `ca_trust "certificate" is required`)
+
+	_, err = (&catrust.Preparer{Name: "../internal", Certificate: "x"}).Prepare(fr)
+	assert.EqualError(t, err, `ca_trust "name" must be a file name without spaces`)
+
+	_, err = (&catrust.Preparer{Name: "internal", Certificate: "x"}).Prepare(fr)
+	assert.EqualError(t, err, `ca_trust "certificate": expected a PEM-encoded certificate`)
+}
+
+func prepare(t *testing.T, fake *fakeexec.Executor, p *catrust.Preparer) *catrust.CATrust {
+	task, err := p.Prepare(&resource.ExecRenderer{Renderer: fakerenderer.New(), Exec: fake})
+	require.NoError(t, err)
+	return task.(*catrust.CATrust)
+}
+
+func generate(t *testing.T, ca bool) string {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Internal CA"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		BasicConstraintsValid: true,
+		IsCA:                  ca,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	return strings.TrimLeft(string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})), "\n")
+}
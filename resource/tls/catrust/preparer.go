@@ -0,0 +1,81 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package catrust
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/load/registry"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/pkg/errors"
+)
+
+// Preparer for CATrust
+//
+// CATrust adds a CA certificate to the system trust store, or removes one. The
+// store is detected from the commands available on the system unless a
+// backend is given: update-ca-certificates on Debian and Ubuntu,
+// update-ca-trust on Red Hat and CentOS, or the system keychain on macOS.
+type Preparer struct {
+	// Name identifies the certificate in the store, and is used as its file
+	// name where the store is a directory
+	Name string `hcl:"name" required:"true"`
+
+	// Certificate is the PEM-encoded CA certificate. It is required unless
+	// state is absent on a Debian or Red Hat system.
+	Certificate string `hcl:"certificate"`
+
+	// Backend overrides detection of the trust store
+	Backend string `hcl:"backend" valid_values:"debian,redhat,macos"`
+
+	// State is whether the certificate should be trusted
+	State State `hcl:"state" valid_values:"present,absent"`
+}
+
+// Prepare a new task
+func (p *Preparer) Prepare(render resource.Renderer) (resource.Task, error) {
+	if strings.ContainsAny(p.Name, "/ ") || strings.HasPrefix(p.Name, ".") {
+		return nil, fmt.Errorf("ca_trust \"name\" must be a file name without spaces")
+	}
+
+	if p.State == "" {
+		p.State = StatePresent
+	}
+
+	cert := &Certificate{Name: p.Name}
+	if p.Certificate != "" {
+		var err error
+		cert, err = ParseCertificate(p.Name, p.Certificate)
+		if err != nil {
+			return nil, errors.Wrap(err, "ca_trust \"certificate\"")
+		}
+	} else if p.State == StatePresent || p.Backend == "macos" {
+		return nil, fmt.Errorf("ca_trust \"certificate\" is required")
+	}
+
+	return &CATrust{
+		Certificate: cert,
+		Backend:     p.Backend,
+		State:       p.State,
+		exec:        exec.For(render),
+	}, nil
+}
+
+func init() {
+	registry.Register("tls.ca_trust", (*Preparer)(nil), (*CATrust)(nil))
+	registry.RegisterPlatforms("tls.ca_trust", "linux")
+}
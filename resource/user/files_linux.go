@@ -0,0 +1,234 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build linux
+
+package user
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/asteris-llc/converge/helpers/accounts"
+	"github.com/pkg/errors"
+)
+
+// the ranges IDs are allocated from, as in the default login.defs
+const (
+	minID       = 1000
+	maxID       = 60000
+	minSystemID = 100
+	maxSystemID = 999
+)
+
+// addUserFiles adds a user by editing the account files. Like useradd with
+// the usual settings, a group named after the user is its primary group when
+// no group is given.
+func addUserFiles(files *accounts.Files, userName string, options *AddUserOptions) error {
+	hasShadow := files.Exists("shadow")
+	if !hasShadow && (options.Expiry != "" || options.Inactive != "") {
+		return fmt.Errorf("cannot set expiry or inactive for user %s without %s", userName, files.Path("shadow"))
+	}
+
+	expiry, err := expiryDays(options.Expiry)
+	if err != nil {
+		return err
+	}
+
+	min, max := minID, maxID
+	if options.System {
+		min, max = minSystemID, maxSystemID
+	}
+
+	uid := options.UID
+	if uid == "" {
+		id, err := files.NextID("passwd", min, max)
+		if err != nil {
+			return err
+		}
+		uid = strconv.Itoa(id)
+	}
+
+	gid, err := primaryGroup(files, userName, uid, options.Group, min, max)
+	if err != nil {
+		return err
+	}
+
+	home := options.Directory
+	if home == "" {
+		home = path.Join("/home", userName)
+	}
+
+	shell := options.Shell
+	if shell == "" {
+		shell = "/bin/sh"
+	}
+
+	password := "!"
+	if hasShadow {
+		password = "x"
+	}
+
+	if err := files.Add("passwd", []string{userName, password, uid, gid, options.Comment, home, shell}); err != nil {
+		return err
+	}
+
+	return files.Add("shadow", []string{userName, "!", today(), "0", "99999", "7", inactiveDays(options.Inactive), expiry, ""})
+}
+
+// primaryGroup returns the gid of the group given by name or ID, or the one
+// named after the user, which is added if it doesn't exist
+func primaryGroup(files *accounts.Files, userName, uid, group string, min, max int) (string, error) {
+	if group != "" {
+		fields, found, err := files.Find("group", group)
+		if err != nil {
+			return "", err
+		}
+		if !found {
+			return "", fmt.Errorf("group %s does not exist", group)
+		}
+		return fields[2], nil
+	}
+
+	if fields, found, err := files.Find("group", userName); err != nil {
+		return "", err
+	} else if found {
+		return fields[2], nil
+	}
+
+	// use the same ID for the user and its group if it is free
+	gid := uid
+	if _, taken, err := files.Find("group", gid); err != nil {
+		return "", err
+	} else if taken {
+		id, err := files.NextID("group", min, max)
+		if err != nil {
+			return "", err
+		}
+		gid = strconv.Itoa(id)
+	}
+
+	if err := files.Add("group", []string{userName, "x", gid, ""}); err != nil {
+		return "", err
+	}
+	if err := files.Add("gshadow", []string{userName, "!", "", ""}); err != nil {
+		return "", err
+	}
+	return gid, nil
+}
+
+// modUserFiles changes the login settings of a user by editing the account
+// files
+func modUserFiles(files *accounts.Files, userName string, options *ModUserOptions) error {
+	if options.Expiry != "" || options.Inactive != "" {
+		if !files.Exists("shadow") {
+			return fmt.Errorf("cannot set expiry or inactive for user %s without %s", userName, files.Path("shadow"))
+		}
+
+		expiry, err := expiryDays(options.Expiry)
+		if err != nil {
+			return err
+		}
+
+		err = files.Modify("shadow", userName, func(fields []string) {
+			if options.Inactive != "" {
+				fields[6] = inactiveDays(options.Inactive)
+			}
+			if options.Expiry != "" {
+				fields[7] = expiry
+			}
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if options.Shell != "" {
+		return files.Modify("passwd", userName, func(fields []string) {
+			fields[6] = options.Shell
+		})
+	}
+	return nil
+}
+
+// setPasswordFiles sets the password hash in shadow, or in passwd if there is
+// no shadow file
+func setPasswordFiles(files *accounts.Files, userName, hash string) error {
+	if !files.Exists("shadow") {
+		return files.Modify("passwd", userName, func(fields []string) {
+			fields[1] = hash
+		})
+	}
+
+	return files.Modify("shadow", userName, func(fields []string) {
+		fields[1] = hash
+		fields[2] = today()
+	})
+}
+
+// delUserFiles removes a user, and its membership of any groups, by editing
+// the account files. Its primary group is left alone.
+func delUserFiles(files *accounts.Files, userName string) error {
+	if _, found, err := files.Find("passwd", userName); err != nil {
+		return err
+	} else if !found {
+		return fmt.Errorf("user %s does not exist", userName)
+	}
+
+	if err := files.Remove("passwd", userName); err != nil {
+		return err
+	}
+	if err := files.Remove("shadow", userName); err != nil {
+		return err
+	}
+
+	for _, database := range []string{"group", "gshadow"} {
+		err := files.ModifyAll(database, func(fields []string) {
+			if len(fields) > 3 {
+				fields[3] = accounts.RemoveMember(fields[3], userName)
+			}
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// expiryDays converts an expiry date to days since the epoch, as it is kept
+// in shadow
+func expiryDays(expiry string) (string, error) {
+	if expiry == "" || expiry == Disabled {
+		return "", nil
+	}
+
+	date, err := time.Parse(expiryFormat, expiry)
+	if err != nil {
+		return "", errors.Wrapf(err, "invalid expiry %q", expiry)
+	}
+	return strconv.FormatInt(date.Unix()/(24*60*60), 10), nil
+}
+
+func inactiveDays(inactive string) string {
+	if inactive == Disabled {
+		return ""
+	}
+	return inactive
+}
+
+func today() string {
+	return strconv.FormatInt(time.Now().Unix()/(24*60*60), 10)
+}
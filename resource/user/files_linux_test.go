@@ -0,0 +1,127 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build linux
+
+package user_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/accounts"
+	"github.com/asteris-llc/converge/helpers/fakeexec"
+	"github.com/asteris-llc/converge/resource/user"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSystemFiles tests managing users by editing the account files when no
+// tools are installed
+func TestSystemFiles(t *testing.T) {
+	t.Parallel()
+
+	t.Run("add", func(t *testing.T) {
+		files := setupFiles(t)
+		defer os.RemoveAll(files.Root)
+
+		fake := fakeexec.New()
+		fake.Expect("useradd", "test", "-c", "a test", "-e", "2030-01-01").Return("", 127)
+		fake.Expect("chpasswd", "-e").Return("", 127)
+
+		sys := &user.System{Exec: fake, Files: files}
+		require.NoError(t, sys.AddUser("test", &user.AddUserOptions{
+			Comment:  "a test",
+			Expiry:   "2030-01-01",
+			Password: "$1$hash",
+		}))
+
+		assert.Equal(t, "root:x:0:0:root:/root:/bin/sh\ntest:x:1000:1000:a test:/home/test:/bin/sh\n", readFile(t, files, "passwd"))
+		assert.Equal(t, "root:x:0:\nwheel:x:10:root\ntest:x:1000:\n", readFile(t, files, "group"))
+
+		shadow := strings.Split(strings.Split(readFile(t, files, "shadow"), "\n")[1], ":")
+		assert.Equal(t, []string{"test", "$1$hash"}, shadow[:2])
+		assert.Equal(t, "21915", shadow[7])
+	})
+
+	t.Run("add with group", func(t *testing.T) {
+		files := setupFiles(t)
+		defer os.RemoveAll(files.Root)
+
+		fake := fakeexec.New()
+		fake.Expect("useradd", "test", "-u", "500", "-g", "wheel", "-r").Return("", 127)
+
+		sys := &user.System{Exec: fake, Files: files}
+		require.NoError(t, sys.AddUser("test", &user.AddUserOptions{UID: "500", Group: "wheel", System: true}))
+
+		assert.Contains(t, readFile(t, files, "passwd"), "test:x:500:10::/home/test:/bin/sh\n")
+		assert.Equal(t, "root:x:0:\nwheel:x:10:root\n", readFile(t, files, "group"))
+	})
+
+	t.Run("modify", func(t *testing.T) {
+		files := setupFiles(t)
+		defer os.RemoveAll(files.Root)
+
+		fake := fakeexec.New()
+		fake.Expect("usermod", "-s", "/bin/ash", "-f", "7", "root").Return("", 127)
+
+		sys := &user.System{Exec: fake, Files: files}
+		require.NoError(t, sys.ModUser("root", &user.ModUserOptions{Shell: "/bin/ash", Inactive: "7"}))
+
+		assert.Equal(t, "root:x:0:0:root:/root:/bin/ash\n", readFile(t, files, "passwd"))
+		assert.Equal(t, "root:!:19000:0:99999:7:7::\n", readFile(t, files, "shadow"))
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		files := setupFiles(t)
+		defer os.RemoveAll(files.Root)
+
+		fake := fakeexec.New()
+		fake.Expect("userdel", "root").Return("", 127)
+
+		sys := &user.System{Exec: fake, Files: files}
+		require.NoError(t, sys.DelUser("root"))
+
+		assert.Equal(t, "", readFile(t, files, "passwd"))
+		assert.Equal(t, "", readFile(t, files, "shadow"))
+		assert.Equal(t, "root:x:0:\nwheel:x:10:\n", readFile(t, files, "group"))
+
+		assert.EqualError(t, sys.DelUser("root"), "user root does not exist")
+	})
+}
+
+func setupFiles(t *testing.T) *accounts.Files {
+	root, err := ioutil.TempDir("", "converge-user")
+	require.NoError(t, err)
+	require.NoError(t, os.Mkdir(filepath.Join(root, "etc"), 0755))
+
+	files := &accounts.Files{Root: root}
+	for database, content := range map[string]string{
+		"passwd": "root:x:0:0:root:/root:/bin/sh\n",
+		"shadow": "root:!:19000:0:99999:7:::\n",
+		"group":  "root:x:0:\nwheel:x:10:root\n",
+	} {
+		require.NoError(t, ioutil.WriteFile(files.Path(database), []byte(content), 0644))
+	}
+	return files
+}
+
+func readFile(t *testing.T, files *accounts.Files, database string) string {
+	content, err := ioutil.ReadFile(files.Path(database))
+	require.NoError(t, err)
+	return string(content)
+}
@@ -0,0 +1,224 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keypair
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/asteris-llc/converge/helpers/accounts"
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/pkg/errors"
+)
+
+const (
+	// TypeED25519 generates ed25519 keys
+	TypeED25519 = "ed25519"
+
+	// TypeRSA generates RSA keys
+	TypeRSA = "rsa"
+
+	defaultRSABits = 4096
+	minRSABits     = 2048
+)
+
+// KeyPair manages an SSH key pair for a user
+type KeyPair struct {
+	resource.Status
+
+	Username string
+	Type     string
+	Bits     int
+	Path     string
+	Comment  string
+
+	// PublicKey is the contents of the public key, once it exists
+	PublicKey string
+
+	exec exec.Executor
+}
+
+// account is the part of a passwd entry needed to place and own keys
+type account struct {
+	home string
+	gid  string
+}
+
+// Check whether the key pair exists and is owned by the user
+func (k *KeyPair) Check(resource.Renderer) (resource.TaskStatus, error) {
+	k.Status = resource.Status{}
+
+	acct, err := k.lookup()
+	if err != nil {
+		k.RaiseLevel(resource.StatusFatal)
+		return k, err
+	}
+	k.resolvePath(acct)
+
+	if !k.exists(k.Path) {
+		k.RaiseLevel(resource.StatusWillChange)
+		k.AddMessage(fmt.Sprintf("%s does not exist", k.Path))
+		k.AddDifference(k.Path, "<absent>", fmt.Sprintf("%s key for %s", k.Type, k.Username), "")
+		return k, nil
+	}
+
+	if !k.exists(k.pubPath()) {
+		k.RaiseLevel(resource.StatusWillChange)
+		k.AddMessage(fmt.Sprintf("%s does not exist", k.pubPath()))
+		k.AddDifference(k.pubPath(), "<absent>", fmt.Sprintf("public key of %s", k.Path), "")
+		return k, nil
+	}
+
+	pub, err := exec.Read(k.exec, "cat", k.pubPath())
+	if err != nil {
+		k.RaiseLevel(resource.StatusFatal)
+		return k, errors.Wrapf(err, "cannot read public key for %s", k.Path)
+	}
+	k.PublicKey = strings.TrimSpace(pub)
+
+	if actual := keyType(k.PublicKey); actual != k.Type {
+		k.RaiseLevel(resource.StatusCantChange)
+		return k, fmt.Errorf("%s is a %s key, will not replace it with a %s key", k.Path, actual, k.Type)
+	}
+
+	for _, file := range []string{k.Path, k.pubPath()} {
+		owner, err := exec.Stat(k.exec, file, "%U")
+		if err != nil {
+			k.RaiseLevel(resource.StatusFatal)
+			return k, errors.Wrapf(err, "cannot read owner of %s", file)
+		}
+
+		if owner = strings.TrimSpace(owner); owner != k.Username {
+			k.RaiseLevel(resource.StatusWillChange)
+			k.AddDifference(file+" owner", owner, k.Username, "")
+		}
+	}
+
+	k.AddMessage(fmt.Sprintf("%s exists", k.Path))
+	return k, nil
+}
+
+// Apply generates the key pair if needed and sets its ownership
+func (k *KeyPair) Apply() (resource.TaskStatus, error) {
+	k.Status = resource.Status{}
+
+	acct, err := k.lookup()
+	if err != nil {
+		k.RaiseLevel(resource.StatusFatal)
+		return k, err
+	}
+	k.resolvePath(acct)
+
+	owner := k.Username + ":" + acct.gid
+
+	if !k.exists(k.Path) {
+		dir := path.Dir(k.Path)
+		if err := exec.Run(k.exec, "install", "-d", "-m", "0700", "-o", k.Username, "-g", acct.gid, dir); err != nil {
+			k.RaiseLevel(resource.StatusFatal)
+			return k, errors.Wrapf(err, "cannot create %s", dir)
+		}
+
+		if err := exec.Run(k.exec, "ssh-keygen", k.keygenArgs()...); err != nil {
+			k.RaiseLevel(resource.StatusFatal)
+			return k, errors.Wrapf(err, "cannot generate %s", k.Path)
+		}
+		k.AddMessage(fmt.Sprintf("generated %s key %s", k.Type, k.Path))
+	} else if !k.exists(k.pubPath()) {
+		if err := k.derivePublicKey(); err != nil {
+			k.RaiseLevel(resource.StatusFatal)
+			return k, err
+		}
+		k.AddMessage(fmt.Sprintf("regenerated %s from %s", k.pubPath(), k.Path))
+	}
+
+	if err := exec.Run(k.exec, "chown", owner, k.Path, k.pubPath()); err != nil {
+		k.RaiseLevel(resource.StatusFatal)
+		return k, errors.Wrapf(err, "cannot set owner of %s", k.Path)
+	}
+
+	pub, err := exec.Read(k.exec, "cat", k.pubPath())
+	if err != nil {
+		k.RaiseLevel(resource.StatusFatal)
+		return k, errors.Wrapf(err, "cannot read public key for %s", k.Path)
+	}
+	k.PublicKey = strings.TrimSpace(pub)
+
+	return k, nil
+}
+
+// derivePublicKey writes the public key file from the private key, for when
+// only the public key has been removed
+func (k *KeyPair) derivePublicKey() error {
+	pub, err := exec.Read(k.exec, "ssh-keygen", "-y", "-f", k.Path)
+	if err != nil {
+		return errors.Wrapf(err, "cannot read public key from %s", k.Path)
+	}
+
+	pub = strings.TrimSpace(pub)
+	if k.Comment != "" && len(strings.Fields(pub)) == 2 {
+		pub += " " + k.Comment
+	}
+
+	if err := exec.WriteFile(k.exec, k.pubPath(), pub+"\n", 0644); err != nil {
+		return errors.Wrapf(err, "cannot write %s", k.pubPath())
+	}
+	return nil
+}
+
+// lookup reads the user's home directory and primary group. This goes
+// through the executor rather than os/user so that it works inside targets.
+func (k *KeyPair) lookup() (*account, error) {
+	u, err := accounts.For(k.exec).Lookup(k.Username)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot look up user %s", k.Username)
+	}
+
+	return &account{home: u.HomeDir, gid: u.Gid}, nil
+}
+
+func (k *KeyPair) resolvePath(acct *account) {
+	if k.Path == "" {
+		k.Path = path.Join(acct.home, ".ssh", "id_"+k.Type)
+	}
+}
+
+func (k *KeyPair) pubPath() string {
+	return k.Path + ".pub"
+}
+
+func (k *KeyPair) exists(file string) bool {
+	return exec.Run(k.exec, "test", "-f", file) == nil
+}
+
+func (k *KeyPair) keygenArgs() []string {
+	args := []string{"-q", "-t", k.Type}
+	if k.Type == TypeRSA {
+		args = append(args, "-b", strconv.Itoa(k.Bits))
+	}
+	if k.Comment != "" {
+		args = append(args, "-C", k.Comment)
+	}
+	return append(args, "-N", "", "-f", k.Path)
+}
+
+// keyType gets the key type from the algorithm name at the start of a public
+// key, such as "ssh-ed25519"
+func keyType(pub string) string {
+	algorithm := strings.SplitN(pub, " ", 2)[0]
+	return strings.TrimPrefix(algorithm, "ssh-")
+}
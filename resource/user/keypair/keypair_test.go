@@ -0,0 +1,223 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keypair_test
+
+import (
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/helpers/fakeexec"
+	"github.com/asteris-llc/converge/helpers/fakerenderer"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/user/keypair"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	passwd = "test:x:1000:1001::/home/test:/bin/bash\n"
+	pubKey = "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIKey test@host\n"
+)
+
+// TestKeyPairInterface tests that KeyPair is properly implemented
+func TestKeyPairInterface(t *testing.T) {
+	t.Parallel()
+
+	assert.Implements(t, (*resource.Task)(nil), new(keypair.KeyPair))
+}
+
+// TestCheck tests the possible cases Check handles
+func TestCheck(t *testing.T) {
+	t.Parallel()
+
+	t.Run("missing", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("getent", "passwd", "test").Return(passwd, 0)
+		fake.Expect("test", "-f", "/home/test/.ssh/id_ed25519").Return("", 1)
+
+		kp := prepare(t, fake, &keypair.Preparer{Username: "test"})
+		status, err := kp.Check(fakerenderer.New())
+
+		require.NoError(t, err)
+		assert.Equal(t, resource.StatusWillChange, status.StatusCode())
+		assert.Equal(t, "<absent>", status.Diffs()["/home/test/.ssh/id_ed25519"].Original())
+		assert.Empty(t, kp.PublicKey)
+		fake.AssertExpectations(t)
+	})
+
+	t.Run("exists", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("getent", "passwd", "test").Return(passwd, 0)
+		fake.Expect("test", "-f", "/home/test/.ssh/id_ed25519")
+		fake.Expect("test", "-f", "/home/test/.ssh/id_ed25519.pub")
+		fake.Expect("cat", "/home/test/.ssh/id_ed25519.pub").Return(pubKey, 0)
+		fake.Expect("sh", "-c", exec.StatScript, "/home/test/.ssh/id_ed25519", "%U", "%Su").Return("test\n", 0)
+		fake.Expect("sh", "-c", exec.StatScript, "/home/test/.ssh/id_ed25519.pub", "%U", "%Su").Return("test\n", 0)
+
+		kp := prepare(t, fake, &keypair.Preparer{Username: "test"})
+		status, err := kp.Check(fakerenderer.New())
+
+		require.NoError(t, err)
+		assert.False(t, status.HasChanges())
+		assert.Equal(t, "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIKey test@host", kp.PublicKey)
+		fake.AssertExpectations(t)
+	})
+
+	t.Run("missing public key", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("getent", "passwd", "test").Return(passwd, 0)
+		fake.Expect("test", "-f", "/home/test/.ssh/id_ed25519")
+		fake.Expect("test", "-f", "/home/test/.ssh/id_ed25519.pub").Return("", 1)
+
+		kp := prepare(t, fake, &keypair.Preparer{Username: "test"})
+		status, err := kp.Check(fakerenderer.New())
+
+		require.NoError(t, err)
+		assert.Equal(t, resource.StatusWillChange, status.StatusCode())
+		assert.Equal(t, "<absent>", status.Diffs()["/home/test/.ssh/id_ed25519.pub"].Original())
+		fake.AssertExpectations(t)
+	})
+
+	t.Run("wrong owner", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("getent", "passwd", "test").Return(passwd, 0)
+		fake.Expect("test", "-f", "/srv/key")
+		fake.Expect("test", "-f", "/srv/key.pub")
+		fake.Expect("cat", "/srv/key.pub").Return(pubKey, 0)
+		fake.Expect("sh", "-c", exec.StatScript, "/srv/key", "%U", "%Su").Return("root\n", 0)
+		fake.Expect("sh", "-c", exec.StatScript, "/srv/key.pub", "%U", "%Su").Return("test\n", 0)
+
+		kp := prepare(t, fake, &keypair.Preparer{Username: "test", Path: "/srv/key"})
+		status, err := kp.Check(fakerenderer.New())
+
+		require.NoError(t, err)
+		assert.Equal(t, resource.StatusWillChange, status.StatusCode())
+		assert.Equal(t, "root", status.Diffs()["/srv/key owner"].Original())
+		assert.Equal(t, "test", status.Diffs()["/srv/key owner"].Current())
+	})
+
+	t.Run("different type", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("getent", "passwd", "test").Return(passwd, 0)
+		fake.Expect("test", "-f", "/home/test/.ssh/id_rsa")
+		fake.Expect("test", "-f", "/home/test/.ssh/id_rsa.pub")
+		fake.Expect("cat", "/home/test/.ssh/id_rsa.pub").Return(pubKey, 0)
+
+		kp := prepare(t, fake, &keypair.Preparer{Username: "test", Type: "rsa"})
+		status, err := kp.Check(fakerenderer.New())
+
+		assert.EqualError(t, err, "/home/test/.ssh/id_rsa is a ed25519 key, will not replace it with a rsa key")
+		assert.Equal(t, resource.StatusCantChange, status.StatusCode())
+	})
+
+	t.Run("unknown user", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("getent", "passwd", "test").Return("", 2)
+
+		kp := prepare(t, fake, &keypair.Preparer{Username: "test"})
+		status, err := kp.Check(fakerenderer.New())
+
+		assert.EqualError(t, err, "cannot look up user test: user: unknown user test")
+		assert.Equal(t, resource.StatusFatal, status.StatusCode())
+	})
+}
+
+// TestApply tests generating key pairs
+func TestApply(t *testing.T) {
+	t.Parallel()
+
+	t.Run("generate", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("getent", "passwd", "test").Return(passwd, 0)
+		fake.Expect("test", "-f", "/home/test/.ssh/id_rsa").Return("", 1)
+		fake.Expect("install", "-d", "-m", "0700", "-o", "test", "-g", "1001", "/home/test/.ssh")
+		fake.Expect("ssh-keygen", "-q", "-t", "rsa", "-b", "4096", "-C", "deploy", "-N", "", "-f", "/home/test/.ssh/id_rsa")
+		fake.Expect("chown", "test:1001", "/home/test/.ssh/id_rsa", "/home/test/.ssh/id_rsa.pub")
+		fake.Expect("cat", "/home/test/.ssh/id_rsa.pub").Return("ssh-rsa AAAAB3 deploy\n", 0)
+
+		kp := prepare(t, fake, &keypair.Preparer{Username: "test", Type: "rsa", Comment: "deploy"})
+		status, err := kp.Apply()
+
+		require.NoError(t, err)
+		assert.Equal(t, "generated rsa key /home/test/.ssh/id_rsa", status.Messages()[0])
+		assert.Equal(t, "ssh-rsa AAAAB3 deploy", kp.PublicKey)
+		fake.AssertExpectations(t)
+	})
+
+	t.Run("fix ownership", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("getent", "passwd", "test").Return(passwd, 0)
+		fake.Expect("test", "-f", "/home/test/.ssh/id_ed25519")
+		fake.Expect("test", "-f", "/home/test/.ssh/id_ed25519.pub")
+		fake.Expect("chown", "test:1001", "/home/test/.ssh/id_ed25519", "/home/test/.ssh/id_ed25519.pub")
+		fake.Expect("cat", "/home/test/.ssh/id_ed25519.pub").Return(pubKey, 0)
+
+		kp := prepare(t, fake, &keypair.Preparer{Username: "test"})
+		_, err := kp.Apply()
+
+		require.NoError(t, err)
+		fake.AssertExpectations(t)
+		for _, call := range fake.Calls() {
+			assert.NotEqual(t, "ssh-keygen", call.Name)
+		}
+	})
+
+	t.Run("regenerate public key", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("getent", "passwd", "test").Return(passwd, 0)
+		fake.Expect("test", "-f", "/home/test/.ssh/id_ed25519")
+		fake.Expect("test", "-f", "/home/test/.ssh/id_ed25519.pub").Return("", 1)
+		fake.Expect("ssh-keygen", "-y", "-f", "/home/test/.ssh/id_ed25519").Return("ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIKey\n", 0)
+		fake.Expect("sh", "-c", exec.WriteFileScript, "/home/test/.ssh/id_ed25519.pub", "0644")
+		fake.Expect("chown", "test:1001", "/home/test/.ssh/id_ed25519", "/home/test/.ssh/id_ed25519.pub")
+		fake.Expect("cat", "/home/test/.ssh/id_ed25519.pub").Return(pubKey, 0)
+
+		kp := prepare(t, fake, &keypair.Preparer{Username: "test", Comment: "test@host"})
+		status, err := kp.Apply()
+
+		require.NoError(t, err)
+		assert.Equal(t, "regenerated /home/test/.ssh/id_ed25519.pub from /home/test/.ssh/id_ed25519", status.Messages()[0])
+		fake.AssertExpectations(t)
+
+		var written string
+		for _, call := range fake.Calls() {
+			if call.Name == "sh" {
+				written = call.Stdin
+			}
+		}
+		assert.Equal(t, pubKey, written)
+	})
+
+	t.Run("keygen fails", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("getent", "passwd", "test").Return(passwd, 0)
+		fake.Expect("test", "-f", "/home/test/.ssh/id_ed25519").Return("", 1)
+		fake.Expect("install", "-d", "-m", "0700", "-o", "test", "-g", "1001", "/home/test/.ssh")
+		fake.Expect("ssh-keygen", "-q", "-t", "ed25519", "-N", "", "-f", "/home/test/.ssh/id_ed25519").Return("", 1).Stderr("unknown key type")
+
+		kp := prepare(t, fake, &keypair.Preparer{Username: "test"})
+		status, err := kp.Apply()
+
+		assert.EqualError(t, err, "cannot generate /home/test/.ssh/id_ed25519: ssh-keygen: exit status 1: unknown key type")
+		assert.Equal(t, resource.StatusFatal, status.StatusCode())
+	})
+}
+
+// prepare a KeyPair that runs its commands with the fake executor
+func prepare(t *testing.T, fake *fakeexec.Executor, p *keypair.Preparer) *keypair.KeyPair {
+	task, err := p.Prepare(&resource.ExecRenderer{Renderer: fakerenderer.New(), Exec: fake})
+	require.NoError(t, err)
+	return task.(*keypair.KeyPair)
+}
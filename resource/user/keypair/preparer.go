@@ -0,0 +1,83 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keypair
+
+import (
+	"fmt"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/load/registry"
+	"github.com/asteris-llc/converge/resource"
+)
+
+// Preparer for KeyPair
+//
+// KeyPair generates an SSH key pair for a user. Once the key exists, other
+// nodes can use the public key by looking up the PublicKey field.
+type Preparer struct {
+	// Username is the user who will own the key pair. The user must already
+	// exist (for example, having been created with `user.user`.)
+	Username string `hcl:"username" required:"true"`
+
+	// Type is the type of key to generate.
+	Type string `hcl:"type" valid_values:"ed25519,rsa"`
+
+	// Bits is the size of RSA keys. Ignored for other types.
+	Bits *int `hcl:"bits"`
+
+	// Path is where the private key is written. The public key is written
+	// alongside it with a ".pub" extension. Defaults to ~/.ssh/id_<type> in
+	// the user's home directory.
+	Path string `hcl:"path"`
+
+	// Comment is added to the public key. Defaults to user@host, as chosen by
+	// ssh-keygen.
+	Comment string `hcl:"comment"`
+}
+
+// Prepare a new task
+func (p *Preparer) Prepare(render resource.Renderer) (resource.Task, error) {
+	if p.Type == "" {
+		p.Type = TypeED25519
+	}
+
+	bits := defaultRSABits
+	if p.Bits != nil {
+		bits = *p.Bits
+	}
+
+	if p.Type == TypeRSA && bits < minRSABits {
+		return nil, fmt.Errorf("keypair \"bits\" must be at least %d for rsa keys", minRSABits)
+	}
+
+	kp := &KeyPair{
+		Username: p.Username,
+		Type:     p.Type,
+		Path:     p.Path,
+		Comment:  p.Comment,
+		exec:     exec.For(render),
+	}
+
+	if p.Type == TypeRSA {
+		kp.Bits = bits
+	}
+
+	return kp, nil
+}
+
+func init() {
+	registry.Register("user.keypair", (*Preparer)(nil), (*KeyPair)(nil))
+	registry.RegisterPlatforms("user.keypair", "linux")
+}
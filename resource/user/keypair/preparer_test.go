@@ -0,0 +1,65 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keypair_test
+
+import (
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/fakerenderer"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/user/keypair"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestPreparerInterface tests that the Preparer interface is properly implemeted
+func TestPreparerInterface(t *testing.T) {
+	t.Parallel()
+
+	assert.Implements(t, (*resource.Resource)(nil), new(keypair.Preparer))
+}
+
+// TestPrepare tests the valid and invalid cases of Prepare
+func TestPrepare(t *testing.T) {
+	t.Parallel()
+
+	fr := fakerenderer.New()
+
+	t.Run("defaults", func(t *testing.T) {
+		p := keypair.Preparer{Username: "test"}
+		task, err := p.Prepare(fr)
+		require.NoError(t, err)
+
+		kp := task.(*keypair.KeyPair)
+		assert.Equal(t, keypair.TypeED25519, kp.Type)
+		assert.Equal(t, 0, kp.Bits)
+	})
+
+	t.Run("rsa", func(t *testing.T) {
+		p := keypair.Preparer{Username: "test", Type: keypair.TypeRSA}
+		task, err := p.Prepare(fr)
+		require.NoError(t, err)
+
+		assert.Equal(t, 4096, task.(*keypair.KeyPair).Bits)
+	})
+
+	t.Run("rsa too small", func(t *testing.T) {
+		bits := 1024
+		p := keypair.Preparer{Username: "test", Type: keypair.TypeRSA, Bits: &bits}
+		_, err := p.Prepare(fr)
+
+		assert.EqualError(t, err, `keypair "bits" must be at least 2048 for rsa keys`)
+	})
+}
@@ -16,21 +16,27 @@ package user
 
 import (
 	"fmt"
-	"math"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/asteris-llc/converge/helpers/exec"
 	"github.com/asteris-llc/converge/load/registry"
 	"github.com/asteris-llc/converge/resource"
 )
 
 // Preparer for User
 //
-// User renders user data
+// User renders user data. On Linux, users are managed with useradd and the
+// other shadow tools, with busybox's adduser where those are missing, or by
+// editing /etc/passwd, /etc/shadow, and /etc/group directly where neither is
+// installed.
 type Preparer struct {
 	// Username is the user login name.
 	Username string `hcl:"username" required:"true"`
 
 	// UID is the user ID.
-	UID *uint32 `hcl:"uid"`
+	UID *uint32 `hcl:"uid" max:"4294967294"`
 
 	// GroupName is the primary group for user and must already exist.
 	// Only one of GID or Groupname may be indicated.
@@ -38,7 +44,7 @@ type Preparer struct {
 
 	// Gid is the primary group ID for user and must refer to an existing group.
 	// Only one of GID or Groupname may be indicated.
-	GID *uint32 `hcl:"gid" mutually_exclusive:"gid,groupname"`
+	GID *uint32 `hcl:"gid" mutually_exclusive:"gid,groupname" max:"4294967294"`
 
 	// Name is the user description.
 	Name string `hcl:"name"`
@@ -47,31 +53,65 @@ type Preparer struct {
 	// name is appended to the home directory.
 	HomeDir string `hcl:"home_dir"`
 
+	// Password is the user's password, already hashed in the format used in
+	// /etc/shadow (for example, the output of `openssl passwd -1`). It is
+	// usually set from a param so that it doesn't need to be written in the
+	// module.
+	Password string `hcl:"password"`
+
+	// Shell is the user's login shell.
+	Shell string `hcl:"shell"`
+
+	// Expiry is the date on which the account will be disabled, in the format
+	// YYYY-MM-DD. Set it to "never" to remove an expiry the account already
+	// has.
+	Expiry string `hcl:"expiry"`
+
+	// Inactive is the number of days after the password expires until the
+	// account is disabled. A value of -1 disables this feature.
+	Inactive *int `hcl:"inactive" min:"-1"`
+
+	// System creates the user as a system account. It only has an effect when
+	// the user is created.
+	System bool `hcl:"system"`
+
 	// State is whether the user should be present.
 	State State `hcl:"state" valid_values:"present,absent"`
 }
 
+// expiryFormat is the date format used by useradd and usermod for expiry
+const expiryFormat = "2006-01-02"
+
 // Prepare a new task
 func (p *Preparer) Prepare(render resource.Renderer) (resource.Task, error) {
-	if p.UID != nil && *p.UID == math.MaxUint32 {
-		// the maximum uid on linux is MaxUint32 - 1
-		return nil, fmt.Errorf("user \"uid\" parameter out of range")
+	if strings.ContainsAny(p.Password, ":\n") {
+		return nil, fmt.Errorf("user \"password\" must be a password hash")
 	}
 
-	if p.GID != nil && *p.GID == math.MaxUint32 {
-		// the maximum gid on linux is MaxUint32 - 1
-		return nil, fmt.Errorf("user \"gid\" parameter out of range")
+	expiry := p.Expiry
+	switch expiry {
+	case "":
+	case "never":
+		expiry = Disabled
+	default:
+		if _, err := time.Parse(expiryFormat, expiry); err != nil {
+			return nil, fmt.Errorf("user \"expiry\" must be a date in the format YYYY-MM-DD, or \"never\"")
+		}
 	}
 
 	if p.State == "" {
 		p.State = StatePresent
 	}
 
-	usr := NewUser(new(System))
+	usr := NewUser(&System{Exec: exec.For(render)})
 	usr.Username = p.Username
 	usr.GroupName = p.GroupName
 	usr.Name = p.Name
 	usr.HomeDir = p.HomeDir
+	usr.Password = p.Password
+	usr.Shell = p.Shell
+	usr.Expiry = expiry
+	usr.System = p.System
 	usr.State = p.State
 
 	if p.UID != nil {
@@ -82,9 +122,19 @@ func (p *Preparer) Prepare(render resource.Renderer) (resource.Task, error) {
 		usr.GID = fmt.Sprintf("%v", *p.GID)
 	}
 
+	if p.Inactive != nil {
+		usr.Inactive = strconv.Itoa(*p.Inactive)
+	}
+
 	return usr, nil
 }
 
+// Import reads an existing user from the system
+func (p *Preparer) Import(e exec.Executor, name string) (map[string]interface{}, error) {
+	return (&System{Exec: e}).Import(name)
+}
+
 func init() {
 	registry.Register("user.user", (*Preparer)(nil), (*User)(nil))
+	registry.RegisterPlatforms("user.user", "linux", "freebsd")
 }
@@ -113,6 +113,23 @@ func TestPrepare(t *testing.T) {
 			assert.NoError(t, err)
 		})
 
+		t.Run("account settings", func(t *testing.T) {
+			inactive := 30
+			p := user.Preparer{Username: "test", Password: "$1$hash", Shell: "/bin/bash", Expiry: "2030-01-01", Inactive: &inactive, System: true}
+			task, err := p.Prepare(&fr)
+
+			assert.NoError(t, err)
+			assert.Equal(t, "30", task.(*user.User).Inactive)
+		})
+
+		t.Run("expiry never", func(t *testing.T) {
+			p := user.Preparer{Username: "test", Expiry: "never"}
+			task, err := p.Prepare(&fr)
+
+			assert.NoError(t, err)
+			assert.Equal(t, user.Disabled, task.(*user.User).Expiry)
+		})
+
 		t.Run("no state parameter", func(t *testing.T) {
 			p := user.Preparer{UID: &testID, GID: &testID, Username: "test", Name: "test", HomeDir: "tmp"}
 			_, err := p.Prepare(&fr)
@@ -123,17 +140,38 @@ func TestPrepare(t *testing.T) {
 
 	t.Run("invalid", func(t *testing.T) {
 		t.Run("uid out of range", func(t *testing.T) {
-			p := user.Preparer{UID: &invalidID, Username: "test"}
+			p := resource.NewPreparerWithSource(new(user.Preparer), map[string]interface{}{"username": "test", "uid": invalidID})
+			_, err := p.Prepare(&fr)
+
+			assert.EqualError(t, err, fmt.Sprintf("\"uid\" must be at most %d, was %d", maxID, invalidID))
+		})
+
+		t.Run("password not hashed", func(t *testing.T) {
+			p := user.Preparer{Username: "test", Password: "a:b"}
+			_, err := p.Prepare(&fr)
+
+			assert.EqualError(t, err, "user \"password\" must be a password hash")
+		})
+
+		t.Run("invalid expiry", func(t *testing.T) {
+			p := user.Preparer{Username: "test", Expiry: "01/02/2030"}
+			_, err := p.Prepare(&fr)
+
+			assert.EqualError(t, err, "user \"expiry\" must be a date in the format YYYY-MM-DD, or \"never\"")
+		})
+
+		t.Run("inactive out of range", func(t *testing.T) {
+			p := resource.NewPreparerWithSource(new(user.Preparer), map[string]interface{}{"username": "test", "inactive": -2})
 			_, err := p.Prepare(&fr)
 
-			assert.EqualError(t, err, fmt.Sprintf("user \"uid\" parameter out of range"))
+			assert.EqualError(t, err, `"inactive" must be at least -1, was -2`)
 		})
 
 		t.Run("gid out of range", func(t *testing.T) {
-			p := user.Preparer{GID: &invalidID, Username: "test"}
+			p := resource.NewPreparerWithSource(new(user.Preparer), map[string]interface{}{"username": "test", "gid": invalidID})
 			_, err := p.Prepare(&fr)
 
-			assert.EqualError(t, err, fmt.Sprintf("user \"gid\" parameter out of range"))
+			assert.EqualError(t, err, fmt.Sprintf("\"gid\" must be at most %d, was %d", maxID, invalidID))
 		})
 	})
 }
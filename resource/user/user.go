@@ -18,6 +18,7 @@ import (
 	"fmt"
 	"os/user"
 
+	"github.com/asteris-llc/converge/helpers/accounts"
 	"github.com/asteris-llc/converge/resource"
 	"github.com/pkg/errors"
 )
@@ -41,6 +42,11 @@ type User struct {
 	GID       string
 	Name      string
 	HomeDir   string
+	Password  string
+	Shell     string
+	Expiry    string
+	Inactive  string
+	System    bool
 	State     State
 	system    SystemUtils
 }
@@ -52,6 +58,39 @@ type AddUserOptions struct {
 	Group     string
 	Comment   string
 	Directory string
+	Password  string
+	Shell     string
+	Expiry    string
+	Inactive  string
+	System    bool
+}
+
+// ModUserOptions are the login settings to change on an existing user. Empty
+// fields are left as they are.
+type ModUserOptions struct {
+	Password string
+	Shell    string
+	Expiry   string
+	Inactive string
+}
+
+// Account holds the login settings of an existing user, as read from passwd
+// and shadow
+type Account struct {
+	// Password is the password hash
+	Password string
+
+	// Shell is the login shell
+	Shell string
+
+	// Expiry is the date the account expires, formatted as YYYY-MM-DD. It is
+	// empty if the account does not expire.
+	Expiry string
+
+	// Inactive is the number of days after the password expires that the
+	// account is disabled. It is empty, or Disabled, if the account is never
+	// disabled.
+	Inactive string
 }
 
 // SystemUtils provides system utilities for user
@@ -62,11 +101,18 @@ type SystemUtils interface {
 	LookupID(userID string) (*user.User, error)
 	LookupGroup(groupName string) (*user.Group, error)
 	LookupGroupID(groupID string) (*user.Group, error)
+	ModUser(userName string, options *ModUserOptions) error
+	LookupAccount(userName string) (*Account, error)
 }
 
 // ErrUnsupported is used when a system is not supported
 var ErrUnsupported = fmt.Errorf("user: not supported on this system")
 
+// Disabled is the Expiry or Inactive of a user that turns the setting off, as
+// it is given to useradd and usermod. Accounts read back without the setting
+// have it empty instead.
+const Disabled = "-1"
+
 // NewUser constructs and returns a new User
 func NewUser(system SystemUtils) *User {
 	return &User{
@@ -106,6 +152,10 @@ func (u *User) Check(resource.Renderer) (resource.TaskStatus, error) {
 			switch {
 			case userByName != nil:
 				status.AddMessage(fmt.Sprintf("user %s already exists", u.Username))
+				if _, err := u.diffAccount(status); err != nil {
+					status.RaiseLevel(resource.StatusFatal)
+					return status, err
+				}
 			case nameNotFound:
 				_, err := SetAddUserOptions(u)
 				if err != nil {
@@ -141,6 +191,10 @@ func (u *User) Check(resource.Renderer) (resource.TaskStatus, error) {
 				return status, fmt.Errorf("cannot add user %s with uid %s: user and uid belong to different users", u.Username, u.UID)
 			case userByName != nil && userByID != nil && *userByName == *userByID:
 				status.AddMessage("user %s with uid %s already exists", u.Username, u.UID)
+				if _, err := u.diffAccount(status); err != nil {
+					status.RaiseLevel(resource.StatusFatal)
+					return status, err
+				}
 			}
 		}
 	case StateAbsent:
@@ -227,6 +281,8 @@ func (u *User) Apply() (resource.TaskStatus, error) {
 					return status, errors.Wrap(err, "user add")
 				}
 				status.AddMessage(fmt.Sprintf("added user %s", u.Username))
+			case userByName != nil && u.managesAccount():
+				return u.modifyAccount(status, fmt.Sprintf("user %s", u.Username))
 			default:
 				status.RaiseLevel(resource.StatusCantChange)
 				return status, fmt.Errorf("will not attempt to add user %s", u.Username)
@@ -249,6 +305,8 @@ func (u *User) Apply() (resource.TaskStatus, error) {
 					return status, errors.Wrap(err, "user add")
 				}
 				status.AddMessage(fmt.Sprintf("added user %s with uid %s", u.Username, u.UID))
+			case !nameNotFound && !uidNotFound && userByName != nil && userByID != nil && *userByName == *userByID && u.managesAccount():
+				return u.modifyAccount(status, fmt.Sprintf("user %s with uid %s", u.Username, u.UID))
 			default:
 				status.RaiseLevel(resource.StatusCantChange)
 				return status, fmt.Errorf("will not attempt to add user %s with uid %s", u.Username, u.UID)
@@ -311,13 +369,13 @@ func SetAddUserOptions(u *User) (*AddUserOptions, error) {
 
 	switch {
 	case u.GroupName != "":
-		_, err := user.LookupGroup(u.GroupName)
+		_, err := accounts.For(nil).LookupGroup(u.GroupName)
 		if err != nil {
 			return nil, fmt.Errorf("group %s does not exist", u.GroupName)
 		}
 		options.Group = u.GroupName
 	case u.GID != "":
-		_, err := user.LookupGroupId(u.GID)
+		_, err := accounts.For(nil).LookupGroupID(u.GID)
 		if err != nil {
 			return nil, fmt.Errorf("group gid %s does not exist", u.GID)
 		}
@@ -332,5 +390,107 @@ func SetAddUserOptions(u *User) (*AddUserOptions, error) {
 		options.Directory = u.HomeDir
 	}
 
+	options.Password = u.Password
+	options.Shell = u.Shell
+	options.Expiry = u.Expiry
+	options.Inactive = u.Inactive
+	options.System = u.System
+
+	return options, nil
+}
+
+// managesAccount is true if any login settings of an existing user are
+// managed. System only applies when the user is created.
+func (u *User) managesAccount() bool {
+	return u.Password != "" || u.Shell != "" || u.Expiry != "" || u.Inactive != ""
+}
+
+// diffAccount compares the login settings of an existing user with the
+// desired ones, adding a difference to the status for each that has drifted.
+// It returns the options needed to correct them, or nil if nothing needs to
+// change.
+func (u *User) diffAccount(status *resource.Status) (*ModUserOptions, error) {
+	if !u.managesAccount() {
+		return nil, nil
+	}
+
+	account, err := u.system.LookupAccount(u.Username)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot read account for user %s", u.Username)
+	}
+
+	options := new(ModUserOptions)
+	changed := false
+
+	if u.Password != "" && u.Password != account.Password {
+		// the hashes are kept out of the plan output
+		status.AddDifference("password", "<hidden>", "<updated>", "")
+		options.Password = u.Password
+		changed = true
+	}
+
+	if u.Shell != "" && u.Shell != account.Shell {
+		status.AddDifference("shell", account.Shell, u.Shell, "")
+		options.Shell = u.Shell
+		changed = true
+	}
+
+	if u.Expiry != "" && accountSetting(u.Expiry) != accountSetting(account.Expiry) {
+		status.AddDifference("expiry", account.Expiry, describeExpiry(u.Expiry), "")
+		options.Expiry = u.Expiry
+		changed = true
+	}
+
+	if u.Inactive != "" && accountSetting(u.Inactive) != accountSetting(account.Inactive) {
+		status.AddDifference("inactive", account.Inactive, u.Inactive, "")
+		options.Inactive = u.Inactive
+		changed = true
+	}
+
+	if !changed {
+		return nil, nil
+	}
+
+	status.RaiseLevel(resource.StatusWillChange)
 	return options, nil
 }
+
+// accountSetting returns an expiry or inactive setting as it is read back from
+// an account, where a disabled setting is empty
+func accountSetting(value string) string {
+	if value == Disabled {
+		return ""
+	}
+	return value
+}
+
+// describeExpiry returns an expiry as it is shown in diffs
+func describeExpiry(expiry string) string {
+	if expiry == Disabled {
+		return "never"
+	}
+	return expiry
+}
+
+// modifyAccount corrects the login settings of an existing user
+func (u *User) modifyAccount(status *resource.Status, desc string) (resource.TaskStatus, error) {
+	options, err := u.diffAccount(status)
+	if err != nil {
+		status.RaiseLevel(resource.StatusFatal)
+		return status, err
+	}
+
+	if options == nil {
+		status.RaiseLevel(resource.StatusCantChange)
+		return status, fmt.Errorf("will not attempt to modify %s", desc)
+	}
+
+	if err := u.system.ModUser(u.Username, options); err != nil {
+		status.RaiseLevel(resource.StatusFatal)
+		status.AddMessage(fmt.Sprintf("error modifying %s", desc))
+		return status, errors.Wrap(err, "user modify")
+	}
+
+	status.AddMessage(fmt.Sprintf("modified %s", desc))
+	return status, nil
+}
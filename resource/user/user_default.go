@@ -12,22 +12,42 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
-// +build !linux
+// +build !linux,!freebsd
 
 package user
 
 import (
 	"os/user"
+
+	"github.com/asteris-llc/converge/helpers/exec"
 )
 
 // System implements SystemUtils
-type System struct{}
+type System struct {
+	// Exec is unused on unsupported systems
+	Exec exec.Executor
+}
 
 // AddUser implementation for systems which are not supported
 func (s *System) AddUser(userName string, options *AddUserOptions) error {
 	return ErrUnsupported
 }
 
+// ModUser implementation for systems which are not supported
+func (s *System) ModUser(userName string, options *ModUserOptions) error {
+	return ErrUnsupported
+}
+
+// LookupAccount implementation for systems which are not supported
+func (s *System) LookupAccount(userName string) (*Account, error) {
+	return nil, ErrUnsupported
+}
+
+// Import implementation for systems which are not supported
+func (s *System) Import(userName string) (map[string]interface{}, error) {
+	return nil, ErrUnsupported
+}
+
 // DelUser implementation for systems which are not supported
 func (s *System) DelUser(userName string) error {
 	return ErrUnsupported
@@ -0,0 +1,248 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build freebsd
+
+package user
+
+import (
+	"fmt"
+	"os/user"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/asteris-llc/converge/helpers/accounts"
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/pkg/errors"
+)
+
+// pwExpiryFormat is the date format pw(8) takes for expiry
+const pwExpiryFormat = "02-01-2006"
+
+// System implements SystemUtils with pw(8)
+type System struct {
+	// Exec runs the user management commands. If nil, commands are run on the
+	// local system.
+	Exec exec.Executor
+}
+
+// AddUser adds a user. FreeBSD has no system accounts, so a system user must
+// be given a uid, and no inactive period.
+func (s *System) AddUser(userName string, options *AddUserOptions) error {
+	defer accounts.For(s.executor()).Invalidate()
+
+	if options.System && options.UID == "" {
+		return fmt.Errorf("user: system users need a uid on FreeBSD")
+	}
+
+	args := []string{"useradd", userName}
+	if options.UID != "" {
+		args = append(args, "-u", options.UID)
+	}
+	if options.Group != "" {
+		args = append(args, "-g", options.Group)
+	}
+	if options.Comment != "" {
+		args = append(args, "-c", options.Comment)
+	}
+	if options.Directory != "" {
+		args = append(args, "-d", options.Directory)
+	}
+
+	more, err := loginArgs(options.Shell, options.Expiry, options.Inactive)
+	if err != nil {
+		return err
+	}
+
+	return s.pw(append(args, more...), options.Password)
+}
+
+// ModUser modifies the login settings of a user
+func (s *System) ModUser(userName string, options *ModUserOptions) error {
+	defer accounts.For(s.executor()).Invalidate()
+
+	args, err := loginArgs(options.Shell, options.Expiry, options.Inactive)
+	if err != nil {
+		return err
+	}
+
+	if len(args) == 0 && options.Password == "" {
+		return nil
+	}
+	return s.pw(append([]string{"usermod", userName}, args...), options.Password)
+}
+
+// loginArgs are the pw arguments for the login settings
+func loginArgs(shell, expiry, inactive string) ([]string, error) {
+	var args []string
+	if shell != "" {
+		args = append(args, "-s", shell)
+	}
+	switch expiry {
+	case "":
+	case Disabled:
+		// pw takes an expiry of 0 as none
+		args = append(args, "-e", "0")
+	default:
+		date, err := time.Parse(expiryFormat, expiry)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid expiry %s", expiry)
+		}
+		args = append(args, "-e", date.Format(pwExpiryFormat))
+	}
+	if inactive != "" && inactive != Disabled {
+		return nil, fmt.Errorf("user: inactive is not supported on FreeBSD")
+	}
+	return args, nil
+}
+
+// pw runs pw(8). A password hash is given on stdin with -H 0, so that it
+// doesn't show up in the process table.
+func (s *System) pw(args []string, hash string) error {
+	cmd := exec.NewCommand("pw", args...)
+	if hash != "" {
+		cmd.Args = append(cmd.Args, "-H", "0")
+		cmd.Stdin = hash + "\n"
+	}
+
+	result, err := s.executor().Run(cmd)
+	if err != nil {
+		return err
+	}
+	if !result.Success() {
+		return &exec.ExitError{Command: cmd, Result: result}
+	}
+	return nil
+}
+
+// LookupAccount reads the login settings of a user from master.passwd, which
+// requires root. FreeBSD has no inactive period, so it is always -1.
+func (s *System) LookupAccount(userName string) (*Account, error) {
+	entry, err := s.usershow(userName)
+	if err != nil {
+		return nil, err
+	}
+
+	account := &Account{
+		Password: entry[1],
+		Shell:    entry[9],
+		Inactive: "-1",
+	}
+
+	if entry[6] != "0" && entry[6] != "" {
+		seconds, err := strconv.ParseInt(entry[6], 10, 64)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid expiry for user %s", userName)
+		}
+		account.Expiry = time.Unix(seconds, 0).UTC().Format(expiryFormat)
+	}
+
+	return account, nil
+}
+
+// Import reads an existing user as the fields of a user.user resource. The
+// password hash is not included, so that it isn't written into a module.
+func (s *System) Import(userName string) (map[string]interface{}, error) {
+	entry, err := s.usershow(userName)
+	if err != nil {
+		return nil, err
+	}
+
+	uid, err := strconv.ParseUint(entry[2], 10, 32)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid uid for user %s", userName)
+	}
+
+	fields := map[string]interface{}{
+		"username": userName,
+		"uid":      uid,
+		"home_dir": entry[8],
+		"shell":    entry[9],
+	}
+
+	if entry[7] != "" {
+		fields["name"] = entry[7]
+	}
+
+	if group, err := s.LookupGroupID(entry[3]); err == nil {
+		fields["groupname"] = group.Name
+	} else {
+		gid, err := strconv.ParseUint(entry[3], 10, 32)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid gid for user %s", userName)
+		}
+		fields["gid"] = gid
+	}
+
+	account, err := s.LookupAccount(userName)
+	if err == nil && account.Expiry != "" {
+		fields["expiry"] = account.Expiry
+	}
+
+	return fields, nil
+}
+
+// usershow reads the master.passwd entry for the user, split into its ten
+// fields: name, password, uid, gid, class, change, expire, gecos, home, and
+// shell
+func (s *System) usershow(userName string) ([]string, error) {
+	out, err := exec.Read(s.executor(), "pw", "usershow", userName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not read master.passwd entry for %s", userName)
+	}
+
+	entry := strings.Split(strings.TrimSpace(out), ":")
+	if len(entry) < 10 {
+		return nil, fmt.Errorf("malformed master.passwd entry for %s", userName)
+	}
+	return entry, nil
+}
+
+// DelUser deletes a user
+func (s *System) DelUser(userName string) error {
+	defer accounts.For(s.executor()).Invalidate()
+	return exec.Run(s.executor(), "pw", "userdel", userName)
+}
+
+// Lookup looks up a user by name
+// If the user cannot be found an error is returned
+func (s *System) Lookup(userName string) (*user.User, error) {
+	return accounts.For(s.executor()).Lookup(userName)
+}
+
+// LookupID looks up a user by uid
+// If the user cannot be found an error is returned
+func (s *System) LookupID(userID string) (*user.User, error) {
+	return accounts.For(s.executor()).LookupID(userID)
+}
+
+// LookupGroup looks up a group by name
+// If the group cannot be found an error is returned
+func (s *System) LookupGroup(groupName string) (*user.Group, error) {
+	return accounts.For(s.executor()).LookupGroup(groupName)
+}
+
+// LookupGroupID looks up a group by gid
+// If the group cannot be found an error is returned
+func (s *System) LookupGroupID(groupID string) (*user.Group, error) {
+	return accounts.For(s.executor()).LookupGroupID(groupID)
+}
+
+func (s *System) executor() exec.Executor {
+	if s.Exec == nil {
+		return exec.New()
+	}
+	return s.Exec
+}
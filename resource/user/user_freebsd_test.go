@@ -0,0 +1,112 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build freebsd
+
+package user_test
+
+import (
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/fakeexec"
+	"github.com/asteris-llc/converge/resource/user"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSystemCommands tests the commands run by the FreeBSD System
+func TestSystemCommands(t *testing.T) {
+	t.Parallel()
+
+	t.Run("add", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("pw", "useradd", "test", "-u", "1234", "-g", "wheel", "-c", "a test", "-d", "/home/t", "-s", "/bin/sh", "-e", "01-01-2030", "-H", "0")
+
+		sys := &user.System{Exec: fake}
+		err := sys.AddUser("test", &user.AddUserOptions{
+			UID:       "1234",
+			Group:     "wheel",
+			Comment:   "a test",
+			Directory: "/home/t",
+			Shell:     "/bin/sh",
+			Expiry:    "2030-01-01",
+			Password:  "$6$hash",
+		})
+		require.NoError(t, err)
+		fake.AssertExpectations(t)
+
+		calls := fake.Calls()
+		assert.Equal(t, "$6$hash\n", calls[0].Stdin)
+		assert.NotContains(t, calls[0].String(), "$6$hash")
+	})
+
+	t.Run("add system user without uid", func(t *testing.T) {
+		sys := &user.System{Exec: fakeexec.New()}
+		err := sys.AddUser("test", &user.AddUserOptions{System: true})
+		assert.EqualError(t, err, "user: system users need a uid on FreeBSD")
+	})
+
+	t.Run("modify", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("pw", "usermod", "test", "-s", "/bin/csh")
+
+		sys := &user.System{Exec: fake}
+		err := sys.ModUser("test", &user.ModUserOptions{Shell: "/bin/csh", Inactive: "-1"})
+		assert.NoError(t, err)
+		fake.AssertExpectations(t)
+	})
+
+	t.Run("modify inactive", func(t *testing.T) {
+		sys := &user.System{Exec: fakeexec.New()}
+		err := sys.ModUser("test", &user.ModUserOptions{Inactive: "7"})
+		assert.EqualError(t, err, "user: inactive is not supported on FreeBSD")
+	})
+
+	t.Run("lookup account", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("pw", "usershow", "test").Return("test:$6$hash:1234:0::0:1893456000:a test:/home/t:/bin/sh\n", 0)
+
+		sys := &user.System{Exec: fake}
+		account, err := sys.LookupAccount("test")
+		require.NoError(t, err)
+		assert.Equal(t, &user.Account{Password: "$6$hash", Shell: "/bin/sh", Expiry: "2030-01-01", Inactive: "-1"}, account)
+	})
+
+	t.Run("import", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("pw", "usershow", "test").Return("test:*:1234:0::0:0:a test:/home/t:/bin/sh\n", 0)
+		fake.Expect("getent", "group", "0").Return("wheel:*:0:root\n", 0)
+
+		sys := &user.System{Exec: fake}
+		fields, err := sys.Import("test")
+		require.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{
+			"username":  "test",
+			"uid":       uint64(1234),
+			"home_dir":  "/home/t",
+			"shell":     "/bin/sh",
+			"name":      "a test",
+			"groupname": "wheel",
+		}, fields)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("pw", "userdel", "test")
+
+		sys := &user.System{Exec: fake}
+		assert.NoError(t, sys.DelUser("test"))
+		fake.AssertExpectations(t)
+	})
+}
@@ -18,15 +18,30 @@ package user
 
 import (
 	"fmt"
-	"os/exec"
 	"os/user"
+	"strconv"
+	"time"
+
+	"github.com/asteris-llc/converge/helpers/accounts"
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/pkg/errors"
 )
 
 // System implements SystemUtils
-type System struct{}
+type System struct {
+	// Exec runs the user management commands. If nil, commands are run on the
+	// local system.
+	Exec exec.Executor
+
+	// Files edits the account files directly where neither shadow's tools nor
+	// busybox are installed. If nil, the files are edited when Exec is Direct.
+	Files *accounts.Files
+}
 
 // AddUser adds a user
 func (s *System) AddUser(userName string, options *AddUserOptions) error {
+	defer accounts.For(s.executor()).Invalidate()
+
 	args := []string{userName}
 	if options.UID != "" {
 		args = append(args, "-u", options.UID)
@@ -40,45 +55,278 @@ func (s *System) AddUser(userName string, options *AddUserOptions) error {
 	if options.Directory != "" {
 		args = append(args, "-d", options.Directory)
 	}
+	if options.Shell != "" {
+		args = append(args, "-s", options.Shell)
+	}
+	if options.Expiry != "" {
+		args = append(args, "-e", options.Expiry)
+	}
+	if options.Inactive != "" {
+		args = append(args, "-f", options.Inactive)
+	}
+	if options.System {
+		args = append(args, "-r")
+	}
 
-	cmd := exec.Command("useradd", args...)
-	err := cmd.Run()
+	err := exec.Run(s.executor(), "useradd", args...)
+	if exec.NotFound(err) {
+		if files := s.files(); files != nil {
+			err = addUserFiles(files, userName, options)
+		} else {
+			err = s.addBusyboxUser(userName, options)
+		}
+	}
 	if err != nil {
-		return fmt.Errorf("useradd: %s", err)
+		return err
+	}
+
+	if options.Password != "" {
+		return s.setPassword(userName, options.Password)
+	}
+	return nil
+}
+
+// addBusyboxUser adds a user with adduser(1) from busybox, for systems such as
+// Alpine that don't have useradd. It has no options for the shadow settings.
+func (s *System) addBusyboxUser(userName string, options *AddUserOptions) error {
+	if options.Expiry != "" || options.Inactive != "" {
+		return fmt.Errorf("cannot set expiry or inactive for user %s without useradd", userName)
+	}
+
+	// -D leaves the password unset rather than prompting for it, and -H
+	// doesn't create the home directory, as useradd doesn't
+	args := []string{"-D", "-H"}
+	if options.UID != "" {
+		args = append(args, "-u", options.UID)
+	}
+	if options.Group != "" {
+		args = append(args, "-G", options.Group)
+	}
+	if options.Comment != "" {
+		args = append(args, "-g", options.Comment)
+	}
+	if options.Directory != "" {
+		args = append(args, "-h", options.Directory)
+	}
+	if options.Shell != "" {
+		args = append(args, "-s", options.Shell)
+	}
+	if options.System {
+		args = append(args, "-S")
+	}
+
+	return exec.Run(s.executor(), "adduser", append(args, userName)...)
+}
+
+// ModUser modifies the login settings of a user
+func (s *System) ModUser(userName string, options *ModUserOptions) error {
+	defer accounts.For(s.executor()).Invalidate()
+
+	var args []string
+	if options.Shell != "" {
+		args = append(args, "-s", options.Shell)
+	}
+	if options.Expiry != "" {
+		args = append(args, "-e", options.Expiry)
+	}
+	if options.Inactive != "" {
+		args = append(args, "-f", options.Inactive)
+	}
+
+	if len(args) > 0 {
+		err := exec.Run(s.executor(), "usermod", append(args, userName)...)
+		if exec.NotFound(err) {
+			if files := s.files(); files != nil {
+				err = modUserFiles(files, userName, options)
+			} else {
+				return errors.Wrapf(err, "cannot modify user %s without usermod", userName)
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if options.Password != "" {
+		return s.setPassword(userName, options.Password)
 	}
 	return nil
 }
 
+// setPassword sets the password hash with chpasswd, which reads it from stdin
+// so that it doesn't show up in the process table
+func (s *System) setPassword(userName, hash string) error {
+	cmd := &exec.Command{
+		Name:  "chpasswd",
+		Args:  []string{"-e"},
+		Stdin: userName + ":" + hash + "\n",
+	}
+
+	result, err := s.executor().Run(cmd)
+	if err == nil && !result.Success() {
+		err = &exec.ExitError{Command: cmd, Result: result}
+	}
+	if files := s.files(); files != nil && exec.NotFound(err) {
+		return setPasswordFiles(files, userName, hash)
+	}
+	return err
+}
+
+// LookupAccount reads the login settings of a user from the passwd and shadow
+// databases. Reading shadow requires root.
+func (s *System) LookupAccount(userName string) (*Account, error) {
+	passwd, err := s.getent("passwd", userName, 7)
+	if err != nil {
+		return nil, err
+	}
+
+	shadow, err := s.getent("shadow", userName, 9)
+	if err != nil {
+		return nil, err
+	}
+
+	account := &Account{
+		Shell:    passwd[6],
+		Password: shadow[1],
+		Inactive: shadow[6],
+	}
+
+	if shadow[7] != "" {
+		days, err := strconv.ParseInt(shadow[7], 10, 64)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid expiry for user %s", userName)
+		}
+		account.Expiry = time.Unix(days*24*60*60, 0).UTC().Format(expiryFormat)
+	}
+
+	return account, nil
+}
+
+// Import reads an existing user as the fields of a user.user resource. The
+// password hash is not included, so that it isn't written into a module. The
+// expiry and inactive settings are only included when shadow can be read.
+func (s *System) Import(userName string) (map[string]interface{}, error) {
+	passwd, err := s.getent("passwd", userName, 7)
+	if err != nil {
+		return nil, err
+	}
+
+	uid, err := strconv.ParseUint(passwd[2], 10, 32)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid uid for user %s", userName)
+	}
+
+	fields := map[string]interface{}{
+		"username": userName,
+		"uid":      uid,
+		"home_dir": passwd[5],
+		"shell":    passwd[6],
+	}
+
+	if passwd[4] != "" {
+		fields["name"] = passwd[4]
+	}
+
+	if group, err := s.getent("group", passwd[3], 3); err == nil {
+		fields["groupname"] = group[0]
+	} else {
+		gid, err := strconv.ParseUint(passwd[3], 10, 32)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid gid for user %s", userName)
+		}
+		fields["gid"] = gid
+	}
+
+	account, err := s.LookupAccount(userName)
+	if err != nil {
+		return fields, nil
+	}
+
+	if account.Expiry != "" {
+		fields["expiry"] = account.Expiry
+	}
+
+	if account.Inactive != "" {
+		inactive, err := strconv.Atoi(account.Inactive)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid inactive for user %s", userName)
+		}
+		fields["inactive"] = inactive
+	}
+
+	return fields, nil
+}
+
+// getent reads the entry for the user from a database, split into fields
+func (s *System) getent(database, userName string, fields int) ([]string, error) {
+	entry, found, err := accounts.Getent(s.executor(), database, userName)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("could not read %s entry for %s: no such entry", database, userName)
+	}
+	if len(entry) < fields {
+		return nil, fmt.Errorf("malformed %s entry for %s", database, userName)
+	}
+	return entry, nil
+}
+
 // DelUser deletes a user
 func (s *System) DelUser(userName string) error {
-	cmd := exec.Command("userdel", userName)
-	err := cmd.Run()
-	if err != nil {
-		return fmt.Errorf("userdel: %s", err)
+	defer accounts.For(s.executor()).Invalidate()
+
+	err := exec.Run(s.executor(), "userdel", userName)
+	if exec.NotFound(err) {
+		if files := s.files(); files != nil {
+			return delUserFiles(files, userName)
+		}
+
+		// busybox
+		return exec.Run(s.executor(), "deluser", userName)
 	}
-	return nil
+	return err
 }
 
 // Lookup looks up a user by name
 // If the user cannot be found an error is returned
 func (s *System) Lookup(userName string) (*user.User, error) {
-	return user.Lookup(userName)
+	return accounts.For(s.executor()).Lookup(userName)
 }
 
 // LookupID looks up a user by uid
 // If the user cannot be found an error is returned
 func (s *System) LookupID(userID string) (*user.User, error) {
-	return user.LookupId(userID)
+	return accounts.For(s.executor()).LookupID(userID)
 }
 
 // LookupGroup looks up a group by name
 // If the group cannot be found an error is returned
 func (s *System) LookupGroup(groupName string) (*user.Group, error) {
-	return user.LookupGroup(groupName)
+	return accounts.For(s.executor()).LookupGroup(groupName)
 }
 
 // LookupGroupID looks up a group by gid
 // If the group cannot be found an error is returned
 func (s *System) LookupGroupID(groupID string) (*user.Group, error) {
-	return user.LookupGroupId(groupID)
+	return accounts.For(s.executor()).LookupGroupID(groupID)
+}
+
+// files returns the editor for the account files used when there are no tools
+// to manage users, or nil if the files can't be edited directly
+func (s *System) files() *accounts.Files {
+	if s.Files != nil {
+		return s.Files
+	}
+	if exec.Direct(s.Exec) {
+		return &accounts.Files{}
+	}
+	return nil
+}
+
+func (s *System) executor() exec.Executor {
+	if s.Exec == nil {
+		return exec.New()
+	}
+	return s.Exec
 }
@@ -0,0 +1,202 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build linux
+
+package user_test
+
+import (
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/fakeexec"
+	"github.com/asteris-llc/converge/resource/user"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSystemCommands tests the commands run by the linux System
+func TestSystemCommands(t *testing.T) {
+	t.Parallel()
+
+	t.Run("add", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("useradd", "test", "-u", "1234", "-g", "wheel", "-c", "a test", "-d", "/home/t")
+
+		sys := &user.System{Exec: fake}
+		err := sys.AddUser("test", &user.AddUserOptions{
+			UID:       "1234",
+			Group:     "wheel",
+			Comment:   "a test",
+			Directory: "/home/t",
+		})
+		assert.NoError(t, err)
+		fake.AssertExpectations(t)
+	})
+
+	t.Run("add with account settings", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("useradd", "test", "-s", "/bin/bash", "-e", "2030-01-01", "-f", "7", "-r")
+		fake.Expect("chpasswd", "-e")
+
+		sys := &user.System{Exec: fake}
+		err := sys.AddUser("test", &user.AddUserOptions{
+			Password: "$1$hash",
+			Shell:    "/bin/bash",
+			Expiry:   "2030-01-01",
+			Inactive: "7",
+			System:   true,
+		})
+		require.NoError(t, err)
+		fake.AssertExpectations(t)
+
+		calls := fake.Calls()
+		assert.Equal(t, "test:$1$hash\n", calls[1].Stdin)
+		assert.NotContains(t, calls[0].String(), "$1$hash")
+	})
+
+	t.Run("modify", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("usermod", "-s", "/bin/zsh", "-f", "-1", "test")
+
+		sys := &user.System{Exec: fake}
+		err := sys.ModUser("test", &user.ModUserOptions{Shell: "/bin/zsh", Inactive: "-1"})
+		assert.NoError(t, err)
+		fake.AssertExpectations(t)
+	})
+
+	t.Run("modify password only", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("chpasswd", "-e").Return("", 1).Stderr("chpasswd: line 1: user 'test' does not exist")
+
+		sys := &user.System{Exec: fake}
+		err := sys.ModUser("test", &user.ModUserOptions{Password: "$1$hash"})
+		assert.EqualError(t, err, "chpasswd: exit status 1: chpasswd: line 1: user 'test' does not exist")
+		assert.Len(t, fake.Calls(), 1)
+	})
+
+	t.Run("lookup account", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("getent", "passwd", "test").Return("test:x:1000:1000::/home/test:/bin/bash\n", 0)
+		fake.Expect("getent", "shadow", "test").Return("test:$1$hash:17000:0:99999:7:30:21915:\n", 0)
+
+		sys := &user.System{Exec: fake}
+		account, err := sys.LookupAccount("test")
+		require.NoError(t, err)
+		assert.Equal(t, &user.Account{
+			Password: "$1$hash",
+			Shell:    "/bin/bash",
+			Expiry:   "2030-01-01",
+			Inactive: "30",
+		}, account)
+	})
+
+	t.Run("lookup account without expiry", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("getent", "passwd", "test").Return("test:x:1000:1000::/home/test:/bin/sh\n", 0)
+		fake.Expect("getent", "shadow", "test").Return("test:!:17000:0:99999:7:::\n", 0)
+
+		sys := &user.System{Exec: fake}
+		account, err := sys.LookupAccount("test")
+		require.NoError(t, err)
+		assert.Equal(t, &user.Account{Password: "!", Shell: "/bin/sh"}, account)
+	})
+
+	t.Run("lookup account missing", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("getent", "passwd", "test").Return("", 2)
+
+		sys := &user.System{Exec: fake}
+		_, err := sys.LookupAccount("test")
+		assert.EqualError(t, err, "could not read passwd entry for test: no such entry")
+	})
+
+	t.Run("import", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("getent", "passwd", "test").Return("test:x:1000:100:A Test:/home/test:/bin/bash\n", 0)
+		fake.Expect("getent", "group", "100").Return("users:x:100:\n", 0)
+		fake.Expect("getent", "shadow", "test").Return("test:$1$hash:17000:0:99999:7:30:21915:\n", 0)
+
+		sys := &user.System{Exec: fake}
+		fields, err := sys.Import("test")
+		require.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{
+			"username":  "test",
+			"uid":       uint64(1000),
+			"groupname": "users",
+			"name":      "A Test",
+			"home_dir":  "/home/test",
+			"shell":     "/bin/bash",
+			"expiry":    "2030-01-01",
+			"inactive":  30,
+		}, fields)
+	})
+
+	t.Run("import without shadow", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("getent", "passwd", "test").Return("test:x:1000:1234::/home/test:/bin/sh\n", 0)
+		fake.Expect("getent", "group", "1234").Return("", 2)
+		fake.Expect("getent", "shadow", "test").Return("", 2)
+
+		sys := &user.System{Exec: fake}
+		fields, err := sys.Import("test")
+		require.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{
+			"username": "test",
+			"uid":      uint64(1000),
+			"gid":      uint64(1234),
+			"home_dir": "/home/test",
+			"shell":    "/bin/sh",
+		}, fields)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("userdel", "test").Return("", 6).Stderr("user 'test' does not exist")
+
+		sys := &user.System{Exec: fake}
+		assert.EqualError(t, sys.DelUser("test"), "userdel: exit status 6: user 'test' does not exist")
+	})
+	t.Run("busybox", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("useradd", "test", "-u", "1234", "-g", "wheel", "-s", "/bin/ash", "-r").Return("", 127)
+		fake.Expect("adduser", "-D", "-H", "-u", "1234", "-G", "wheel", "-s", "/bin/ash", "-S", "test")
+		fake.Expect("chpasswd", "-e")
+		fake.Expect("userdel", "test").Return("", 127)
+		fake.Expect("deluser", "test")
+
+		sys := &user.System{Exec: fake}
+		require.NoError(t, sys.AddUser("test", &user.AddUserOptions{
+			UID:      "1234",
+			Group:    "wheel",
+			Shell:    "/bin/ash",
+			System:   true,
+			Password: "$1$hash",
+		}))
+		require.NoError(t, sys.DelUser("test"))
+		fake.AssertExpectations(t)
+	})
+
+	t.Run("busybox shadow settings", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("useradd", "test", "-e", "2030-01-01").Return("", 127)
+		fake.Expect("usermod", "-e", "2030-01-01", "test").Return("", 127)
+
+		sys := &user.System{Exec: fake}
+		err := sys.AddUser("test", &user.AddUserOptions{Expiry: "2030-01-01"})
+		assert.EqualError(t, err, "cannot set expiry or inactive for user test without useradd")
+
+		err = sys.ModUser("test", &user.ModUserOptions{Expiry: "2030-01-01"})
+		assert.EqualError(t, err, "cannot modify user test without usermod: usermod: exit status 127")
+	})
+}
@@ -892,6 +892,173 @@ func TestApply(t *testing.T) {
 	})
 }
 
+// TestAccount tests detecting and correcting drift in the login settings of
+// an existing user
+func TestAccount(t *testing.T) {
+	t.Parallel()
+
+	existing := &os.User{Username: fakeUsername, Uid: fakeUID}
+	account := &user.Account{Password: "$1$old", Shell: "/bin/sh"}
+
+	newUser := func(m *MockSystem) *user.User {
+		u := user.NewUser(m)
+		u.Username = existing.Username
+		u.State = user.StatePresent
+		u.Password = "$1$new"
+		u.Shell = "/bin/bash"
+		u.Expiry = "2030-01-01"
+		u.Inactive = "7"
+		return u
+	}
+
+	t.Run("check", func(t *testing.T) {
+		t.Run("drifted", func(t *testing.T) {
+			m := &MockSystem{}
+			u := newUser(m)
+
+			m.On("Lookup", u.Username).Return(existing, nil)
+			m.On("LookupAccount", u.Username).Return(account, nil)
+			status, err := u.Check(fakerenderer.New())
+
+			assert.NoError(t, err)
+			assert.Equal(t, resource.StatusWillChange, status.StatusCode())
+			assert.True(t, status.HasChanges())
+			assert.Equal(t, "<hidden>", status.Diffs()["password"].Original())
+			assert.NotContains(t, status.Diffs()["password"].Current(), "$1$new")
+			assert.Equal(t, "/bin/sh", status.Diffs()["shell"].Original())
+			assert.Equal(t, "/bin/bash", status.Diffs()["shell"].Current())
+			assert.Equal(t, "2030-01-01", status.Diffs()["expiry"].Current())
+			assert.Equal(t, "7", status.Diffs()["inactive"].Current())
+		})
+
+		t.Run("in sync", func(t *testing.T) {
+			m := &MockSystem{}
+			u := newUser(m)
+
+			m.On("Lookup", u.Username).Return(existing, nil)
+			m.On("LookupAccount", u.Username).Return(&user.Account{
+				Password: u.Password,
+				Shell:    u.Shell,
+				Expiry:   u.Expiry,
+				Inactive: u.Inactive,
+			}, nil)
+			status, err := u.Check(fakerenderer.New())
+
+			assert.NoError(t, err)
+			assert.Equal(t, resource.StatusNoChange, status.StatusCode())
+			assert.False(t, status.HasChanges())
+		})
+
+		t.Run("disabled settings in sync", func(t *testing.T) {
+			m := &MockSystem{}
+			u := newUser(m)
+			u.Expiry = user.Disabled
+			u.Inactive = user.Disabled
+
+			m.On("Lookup", u.Username).Return(existing, nil)
+			m.On("LookupAccount", u.Username).Return(&user.Account{Password: u.Password, Shell: u.Shell}, nil)
+			status, err := u.Check(fakerenderer.New())
+
+			assert.NoError(t, err)
+			assert.False(t, status.HasChanges())
+		})
+
+		t.Run("clearing expiry", func(t *testing.T) {
+			m := &MockSystem{}
+			u := newUser(m)
+			u.Expiry = user.Disabled
+			u.Inactive = user.Disabled
+
+			m.On("Lookup", u.Username).Return(existing, nil)
+			m.On("LookupAccount", u.Username).Return(&user.Account{
+				Password: u.Password,
+				Shell:    u.Shell,
+				Expiry:   "2030-01-01",
+				Inactive: "7",
+			}, nil)
+			status, err := u.Check(fakerenderer.New())
+
+			assert.NoError(t, err)
+			assert.True(t, status.HasChanges())
+			assert.Equal(t, "2030-01-01", status.Diffs()["expiry"].Original())
+			assert.Equal(t, "never", status.Diffs()["expiry"].Current())
+			assert.Equal(t, user.Disabled, status.Diffs()["inactive"].Current())
+		})
+
+		t.Run("unmanaged", func(t *testing.T) {
+			m := &MockSystem{}
+			u := user.NewUser(m)
+			u.Username = existing.Username
+			u.State = user.StatePresent
+
+			m.On("Lookup", u.Username).Return(existing, nil)
+			status, err := u.Check(fakerenderer.New())
+
+			assert.NoError(t, err)
+			assert.False(t, status.HasChanges())
+			m.AssertNotCalled(t, "LookupAccount", u.Username)
+		})
+
+		t.Run("unreadable", func(t *testing.T) {
+			m := &MockSystem{}
+			u := newUser(m)
+
+			m.On("Lookup", u.Username).Return(existing, nil)
+			m.On("LookupAccount", u.Username).Return((*user.Account)(nil), fmt.Errorf("permission denied"))
+			status, err := u.Check(fakerenderer.New())
+
+			assert.EqualError(t, err, fmt.Sprintf("cannot read account for user %s: permission denied", u.Username))
+			assert.Equal(t, resource.StatusFatal, status.StatusCode())
+		})
+	})
+
+	t.Run("apply", func(t *testing.T) {
+		t.Run("modify", func(t *testing.T) {
+			m := &MockSystem{}
+			u := newUser(m)
+			u.Shell = account.Shell
+			options := &user.ModUserOptions{Password: "$1$new", Expiry: "2030-01-01", Inactive: "7"}
+
+			m.On("Lookup", u.Username).Return(existing, nil)
+			m.On("LookupAccount", u.Username).Return(account, nil)
+			m.On("ModUser", u.Username, options).Return(nil)
+			status, err := u.Apply()
+
+			assert.NoError(t, err)
+			m.AssertCalled(t, "ModUser", u.Username, options)
+			assert.Equal(t, fmt.Sprintf("modified user %s", u.Username), status.Messages()[0])
+		})
+
+		t.Run("modify with uid", func(t *testing.T) {
+			m := &MockSystem{}
+			u := newUser(m)
+			u.UID = existing.Uid
+
+			m.On("Lookup", u.Username).Return(existing, nil)
+			m.On("LookupID", u.UID).Return(existing, nil)
+			m.On("LookupAccount", u.Username).Return(account, nil)
+			m.On("ModUser", u.Username, mock.Anything).Return(nil)
+			status, err := u.Apply()
+
+			assert.NoError(t, err)
+			assert.Equal(t, fmt.Sprintf("modified user %s with uid %s", u.Username, u.UID), status.Messages()[0])
+		})
+
+		t.Run("error modifying user", func(t *testing.T) {
+			m := &MockSystem{}
+			u := newUser(m)
+
+			m.On("Lookup", u.Username).Return(existing, nil)
+			m.On("LookupAccount", u.Username).Return(account, nil)
+			m.On("ModUser", u.Username, mock.Anything).Return(fmt.Errorf("usermod: exit status 1"))
+			status, err := u.Apply()
+
+			assert.EqualError(t, err, "user modify: usermod: exit status 1")
+			assert.Equal(t, resource.StatusFatal, status.StatusCode())
+		})
+	})
+}
+
 // TestSetAddUserOptions tests options provided for adding a user
 // are properly set
 func TestSetAddUserOptions(t *testing.T) {
@@ -912,6 +1079,11 @@ func TestSetAddUserOptions(t *testing.T) {
 		u.GID = gid
 		u.Name = "test"
 		u.HomeDir = "testDir"
+		u.Password = "$1$hash"
+		u.Shell = "/bin/bash"
+		u.Expiry = "2030-01-01"
+		u.Inactive = "7"
+		u.System = true
 
 		options, err := user.SetAddUserOptions(u)
 
@@ -920,6 +1092,11 @@ func TestSetAddUserOptions(t *testing.T) {
 		assert.Equal(t, u.GID, options.Group)
 		assert.Equal(t, u.Name, options.Comment)
 		assert.Equal(t, u.HomeDir, options.Directory)
+		assert.Equal(t, u.Password, options.Password)
+		assert.Equal(t, u.Shell, options.Shell)
+		assert.Equal(t, u.Expiry, options.Expiry)
+		assert.Equal(t, u.Inactive, options.Inactive)
+		assert.True(t, options.System)
 	})
 
 	t.Run("group options", func(t *testing.T) {
@@ -1094,3 +1271,15 @@ func (m *MockSystem) LookupGroupID(gid string) (*os.Group, error) {
 	args := m.Called(gid)
 	return args.Get(0).(*os.Group), args.Error(1)
 }
+
+// ModUser modifies a user
+func (m *MockSystem) ModUser(name string, options *user.ModUserOptions) error {
+	args := m.Called(name, options)
+	return args.Error(0)
+}
+
+// LookupAccount looks up the login settings of a user
+func (m *MockSystem) LookupAccount(name string) (*user.Account, error) {
+	args := m.Called(name)
+	return args.Get(0).(*user.Account), args.Error(1)
+}
@@ -0,0 +1,79 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package random
+
+import (
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/load/registry"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/value"
+)
+
+const (
+	// Alphanumeric is the default set of characters
+	Alphanumeric = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789"
+
+	defaultLength = 32
+)
+
+// Preparer for Random
+//
+// Random generates a random string once, such as a password, and stores it on
+// the target so the same value is used on every run. It is exported as
+// `value`, for example `{{lookup "value.random.db-password.value"}}`. A new
+// value is generated when any of the keepers change. The value is kept in a
+// file readable only by root, not in the plan or in the configuration.
+type Preparer struct {
+	// Length of the value. It defaults to 32.
+	Length int `hcl:"length" min:"0"`
+
+	// Characters the value is made of. It defaults to upper and lower case
+	// letters and digits.
+	Characters string `hcl:"characters"`
+
+	// Keepers are arbitrary values which cause a new value to be generated
+	// when they change, such as the name of the database a password is for
+	Keepers map[string]string `hcl:"keepers"`
+
+	// Path is the file the value is stored in. It defaults to a file named
+	// after the node's full ID, in a directory for the module under
+	// /var/lib/converge/values.
+	Path string `hcl:"path"`
+}
+
+// Prepare a new task
+func (p *Preparer) Prepare(render resource.Renderer) (resource.Task, error) {
+	if p.Length == 0 {
+		p.Length = defaultLength
+	}
+
+	if p.Characters == "" {
+		p.Characters = Alphanumeric
+	}
+
+	if p.Path == "" {
+		p.Path = value.DefaultPath(render)
+	}
+
+	return &Random{
+		Persisted:  value.New(exec.For(render), p.Path, p.Keepers),
+		Length:     p.Length,
+		Characters: p.Characters,
+	}, nil
+}
+
+func init() {
+	registry.Register("value.random", (*Preparer)(nil), (*Random)(nil))
+}
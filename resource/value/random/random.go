@@ -0,0 +1,58 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package random
+
+import (
+	"bytes"
+	"crypto/rand"
+	"math/big"
+
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/value"
+)
+
+// Random is a persisted random string
+type Random struct {
+	*value.Persisted
+
+	Length     int
+	Characters string
+}
+
+// Check reads the stored value, generating one if needed
+func (r *Random) Check(resource.Renderer) (resource.TaskStatus, error) {
+	return r.Persisted.Check(r)
+}
+
+// Apply stores the generated value
+func (r *Random) Apply() (resource.TaskStatus, error) {
+	return r.Persisted.Apply()
+}
+
+// Generate a random string from Characters
+func (r *Random) Generate() (string, error) {
+	chars := []rune(r.Characters)
+	max := big.NewInt(int64(len(chars)))
+
+	var out bytes.Buffer
+	for i := 0; i < r.Length; i++ {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		out.WriteRune(chars[n.Int64()])
+	}
+	return out.String(), nil
+}
@@ -0,0 +1,85 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package random_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/fakeexec"
+	"github.com/asteris-llc/converge/helpers/fakerenderer"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/value/random"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRandomInterface tests that Random is properly implemented
+func TestRandomInterface(t *testing.T) {
+	t.Parallel()
+
+	assert.Implements(t, (*resource.Task)(nil), new(random.Random))
+	assert.Implements(t, (*resource.Resource)(nil), new(random.Preparer))
+}
+
+// TestGenerate tests the length and characters of generated values
+func TestGenerate(t *testing.T) {
+	t.Parallel()
+
+	task := prepare(t, fakeexec.New(), &random.Preparer{})
+	value, err := task.Generate()
+	require.NoError(t, err)
+	assert.Len(t, value, 32)
+	assert.Empty(t, strings.Trim(value, random.Alphanumeric))
+
+	task = prepare(t, fakeexec.New(), &random.Preparer{Length: 8, Characters: "ab"})
+	value, err = task.Generate()
+	require.NoError(t, err)
+	assert.Len(t, value, 8)
+	assert.Empty(t, strings.Trim(value, "ab"))
+}
+
+// TestCheck tests that a missing value is generated
+func TestCheck(t *testing.T) {
+	t.Parallel()
+
+	path := "/var/lib/converge/values/root%2Fvalue.random.pw.json"
+	fake := fakeexec.New()
+	fake.Expect("test", "-e", path).Return("", 1)
+
+	task := prepare(t, fake, &random.Preparer{Length: 12})
+	status, err := task.Check(fakerenderer.New())
+	require.NoError(t, err)
+	assert.True(t, status.HasChanges())
+	assert.Len(t, *task.Value, 12)
+}
+
+// TestPrepare tests defaults and validation
+func TestPrepare(t *testing.T) {
+	t.Parallel()
+
+	task := prepare(t, fakeexec.New(), &random.Preparer{Path: "/etc/app/pw.json"})
+	assert.Equal(t, "/etc/app/pw.json", task.Path)
+
+	_, err := resource.NewPreparerWithSource(new(random.Preparer), map[string]interface{}{"length": -1}).Prepare(fakerenderer.New())
+	assert.EqualError(t, err, `"length" must be at least 0, was -1`)
+}
+
+func prepare(t *testing.T, fake *fakeexec.Executor, p *random.Preparer) *random.Random {
+	render := fakerenderer.NewWithID("root/value.random.pw")
+	task, err := p.Prepare(&resource.ExecRenderer{Renderer: render, Exec: fake})
+	require.NoError(t, err)
+	return task.(*random.Random)
+}
@@ -0,0 +1,53 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package uuid
+
+import (
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/load/registry"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/value"
+)
+
+// Preparer for UUID
+//
+// UUID generates a random (version 4) UUID once, such as an ID for a node in
+// a cluster, and stores it on the target so the same value is used on every
+// run. It is exported as `value`, for example
+// `{{lookup "value.uuid.node-id.value"}}`. A new UUID is generated when any of
+// the keepers change.
+type Preparer struct {
+	// Keepers are arbitrary values which cause a new UUID to be generated when
+	// they change
+	Keepers map[string]string `hcl:"keepers"`
+
+	// Path is the file the UUID is stored in. It defaults to a file named after
+	// the node's full ID, in a directory for the module under
+	// /var/lib/converge/values.
+	Path string `hcl:"path"`
+}
+
+// Prepare a new task
+func (p *Preparer) Prepare(render resource.Renderer) (resource.Task, error) {
+	if p.Path == "" {
+		p.Path = value.DefaultPath(render)
+	}
+
+	return &UUID{Persisted: value.New(exec.For(render), p.Path, p.Keepers)}, nil
+}
+
+func init() {
+	registry.Register("value.uuid", (*Preparer)(nil), (*UUID)(nil))
+}
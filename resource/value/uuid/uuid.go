@@ -0,0 +1,41 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package uuid
+
+import (
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/value"
+	"github.com/fgrid/uuid"
+)
+
+// UUID is a persisted UUID
+type UUID struct {
+	*value.Persisted
+}
+
+// Check reads the stored UUID, generating one if needed
+func (u *UUID) Check(resource.Renderer) (resource.TaskStatus, error) {
+	return u.Persisted.Check(u)
+}
+
+// Apply stores the generated UUID
+func (u *UUID) Apply() (resource.TaskStatus, error) {
+	return u.Persisted.Apply()
+}
+
+// Generate a version 4 UUID
+func (u *UUID) Generate() (string, error) {
+	return uuid.NewV4().String(), nil
+}
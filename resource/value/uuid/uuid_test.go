@@ -0,0 +1,74 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package uuid_test
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/fakeexec"
+	"github.com/asteris-llc/converge/helpers/fakerenderer"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/value/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var v4 = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+// TestUUIDInterface tests that UUID is properly implemented
+func TestUUIDInterface(t *testing.T) {
+	t.Parallel()
+
+	assert.Implements(t, (*resource.Task)(nil), new(uuid.UUID))
+	assert.Implements(t, (*resource.Resource)(nil), new(uuid.Preparer))
+}
+
+// TestCheck tests reading and generating UUIDs
+func TestCheck(t *testing.T) {
+	t.Parallel()
+
+	path := "/var/lib/converge/values/root%2Fvalue.uuid.node.json"
+
+	t.Run("absent", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("test", "-e", path).Return("", 1)
+
+		task := prepare(t, fake)
+		status, err := task.Check(fakerenderer.New())
+		require.NoError(t, err)
+		assert.True(t, status.HasChanges())
+		assert.Regexp(t, v4, *task.Value)
+	})
+
+	t.Run("stored", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("test", "-e", path)
+		fake.Expect("cat", path).Return(`{"value":"5b1d0b3e-8a8e-4c1e-9d0e-2f0c8f6b9a11"}`, 0)
+
+		task := prepare(t, fake)
+		status, err := task.Check(fakerenderer.New())
+		require.NoError(t, err)
+		assert.False(t, status.HasChanges())
+		assert.Equal(t, "5b1d0b3e-8a8e-4c1e-9d0e-2f0c8f6b9a11", *task.Value)
+	})
+}
+
+func prepare(t *testing.T, fake *fakeexec.Executor) *uuid.UUID {
+	render := fakerenderer.NewWithID("root/value.uuid.node")
+	task, err := (&uuid.Preparer{}).Prepare(&resource.ExecRenderer{Renderer: render, Exec: fake})
+	require.NoError(t, err)
+	return task.(*uuid.UUID)
+}
@@ -0,0 +1,169 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package value persists generated values, such as passwords and node IDs,
+// so that they stay the same from one run to the next
+package value
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/helpers/statedir"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/pkg/errors"
+)
+
+// DefaultDir holds values that don't set a path
+const DefaultDir = "/var/lib/converge/values"
+
+// DefaultPath is where the value of the node rendered by r is stored. Values
+// are kept in a directory for the module that was loaded and named after the
+// whole ID of the node, escaped, so that neither nodes in other modules loaded
+// on the same system nor nodes with the same name in other submodules share
+// them.
+func DefaultPath(r resource.Renderer) string {
+	return path.Join(DefaultDir, statedir.For(r), url.QueryEscape(r.GetID())+".json")
+}
+
+// Generator generates a new value
+type Generator interface {
+	Generate() (string, error)
+}
+
+// state is what is stored at Path
+type state struct {
+	Value   string            `json:"value"`
+	Keepers map[string]string `json:"keepers,omitempty"`
+}
+
+// Persisted is a generated value kept at Path. It is generated when no value
+// has been stored yet, or when the keepers stored with it differ.
+type Persisted struct {
+	resource.Status
+
+	// Path is the file the value is stored in
+	Path string
+
+	// Keepers cause the value to be generated again when they change
+	Keepers map[string]string
+
+	// Value is the stored value, or the value that will be stored. It is nil
+	// until checked, so that lookups of it wait for the check.
+	Value *string
+
+	pending bool
+	exec    exec.Executor
+}
+
+// New returns a Persisted that runs commands with e
+func New(e exec.Executor, path string, keepers map[string]string) *Persisted {
+	return &Persisted{Path: path, Keepers: keepers, exec: e}
+}
+
+// Check reads the stored value, generating a new one with gen if it needs to
+// be replaced. The generated value is only stored by Apply.
+func (p *Persisted) Check(gen Generator) (resource.TaskStatus, error) {
+	p.Status = resource.Status{}
+	p.pending = false
+
+	content, exists, err := exec.ReadFile(p.exec, p.Path)
+	if err != nil {
+		p.RaiseLevel(resource.StatusFatal)
+		return p, errors.Wrapf(err, "could not read %s", p.Path)
+	}
+
+	var stored state
+	if exists {
+		if err := json.Unmarshal([]byte(content), &stored); err != nil {
+			p.RaiseLevel(resource.StatusFatal)
+			return p, errors.Wrapf(err, "could not parse %s", p.Path)
+		}
+	}
+
+	switch {
+	case !exists:
+		p.AddDifference(p.Path, "<absent>", "<generated>", "")
+
+	case !sameKeepers(stored.Keepers, p.Keepers):
+		p.AddMessage("keepers changed, generating a new value")
+		p.AddDifference("keepers", keepersString(stored.Keepers), keepersString(p.Keepers), "")
+
+	default:
+		p.Value = &stored.Value
+		return p, nil
+	}
+
+	value, err := gen.Generate()
+	if err != nil {
+		p.RaiseLevel(resource.StatusFatal)
+		return p, errors.Wrap(err, "could not generate value")
+	}
+
+	p.Value = &value
+	p.pending = true
+	p.RaiseLevel(resource.StatusWillChange)
+	return p, nil
+}
+
+// Apply stores the value generated by Check
+func (p *Persisted) Apply() (resource.TaskStatus, error) {
+	if !p.pending {
+		return p, nil
+	}
+
+	content, err := json.Marshal(&state{Value: *p.Value, Keepers: p.Keepers})
+	if err != nil {
+		return p, err
+	}
+
+	if err := exec.Run(p.exec, "mkdir", "-p", "-m", "0700", path.Dir(p.Path)); err != nil {
+		p.RaiseLevel(resource.StatusFatal)
+		return p, errors.Wrapf(err, "could not create %s", path.Dir(p.Path))
+	}
+
+	if err := exec.WriteFile(p.exec, p.Path, string(content)+"\n", 0600); err != nil {
+		p.RaiseLevel(resource.StatusFatal)
+		return p, errors.Wrapf(err, "could not write %s", p.Path)
+	}
+
+	p.pending = false
+	return p, nil
+}
+
+func sameKeepers(a, b map[string]string) bool {
+	if len(a) == 0 && len(b) == 0 {
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func keepersString(keepers map[string]string) string {
+	if len(keepers) == 0 {
+		return "<none>"
+	}
+
+	var pairs []string
+	for k, v := range keepers {
+		pairs = append(pairs, fmt.Sprintf("%s=%s", k, v))
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, " ")
+}
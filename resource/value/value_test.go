@@ -0,0 +1,163 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package value_test
+
+import (
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/helpers/fakeexec"
+	"github.com/asteris-llc/converge/helpers/fakerenderer"
+	"github.com/asteris-llc/converge/helpers/statedir"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/value"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	path  = "/var/lib/converge/values/root%2Fvalue.random.pw.json"
+	write = exec.WriteFileScript
+)
+
+type gen string
+
+func (g gen) Generate() (string, error) { return string(g), nil }
+
+// TestDefaultPath tests naming the file after the module and the full ID of
+// the node
+func TestDefaultPath(t *testing.T) {
+	t.Parallel()
+
+	renderer := func(location, id string) *fakerenderer.FakeRenderer {
+		render := fakerenderer.NewWithID(id)
+		render.Location = location
+		return render
+	}
+
+	assert.Equal(
+		t,
+		"/var/lib/converge/values/"+statedir.Key("/etc/converge/a.hcl")+"/root%2Fvalue.random.pw.json",
+		value.DefaultPath(renderer("/etc/converge/a.hcl", "root/value.random.pw")),
+	)
+
+	t.Run("differs by node", func(t *testing.T) {
+		assert.NotEqual(
+			t,
+			value.DefaultPath(renderer("/etc/converge/a.hcl", "root/module.a/value.random.pw")),
+			value.DefaultPath(renderer("/etc/converge/a.hcl", "root/module.b/value.random.pw")),
+		)
+	})
+
+	t.Run("differs by module", func(t *testing.T) {
+		assert.NotEqual(
+			t,
+			value.DefaultPath(renderer("/etc/converge/a.hcl", "root/value.random.pw")),
+			value.DefaultPath(renderer("/etc/converge/b.hcl", "root/value.random.pw")),
+		)
+	})
+}
+
+// TestCheck tests the possible cases Check handles
+func TestCheck(t *testing.T) {
+	t.Parallel()
+
+	t.Run("absent", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("test", "-e", path).Return("", 1)
+
+		p := value.New(fake, path, nil)
+		status, err := p.Check(gen("new"))
+		require.NoError(t, err)
+		assert.True(t, status.HasChanges())
+		assert.Equal(t, "new", *p.Value)
+		assert.Equal(t, "<generated>", status.Diffs()[path].Current())
+	})
+
+	t.Run("stored", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("test", "-e", path)
+		fake.Expect("cat", path).Return(`{"value":"old","keepers":{"db":"app"}}`, 0)
+
+		p := value.New(fake, path, map[string]string{"db": "app"})
+		status, err := p.Check(gen("new"))
+		require.NoError(t, err)
+		assert.False(t, status.HasChanges())
+		assert.Equal(t, "old", *p.Value)
+	})
+
+	t.Run("keepers changed", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("test", "-e", path)
+		fake.Expect("cat", path).Return(`{"value":"old","keepers":{"db":"app"}}`, 0)
+
+		p := value.New(fake, path, map[string]string{"db": "other"})
+		status, err := p.Check(gen("new"))
+		require.NoError(t, err)
+		assert.True(t, status.HasChanges())
+		assert.Equal(t, "new", *p.Value)
+		assert.Equal(t, "db=app", status.Diffs()["keepers"].Original())
+		assert.Equal(t, "db=other", status.Diffs()["keepers"].Current())
+	})
+
+	t.Run("corrupt", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("test", "-e", path)
+		fake.Expect("cat", path).Return("garbage", 0)
+
+		p := value.New(fake, path, nil)
+		status, err := p.Check(gen("new"))
+		assert.Error(t, err)
+		assert.Equal(t, resource.StatusFatal, status.StatusCode())
+		assert.Nil(t, p.Value)
+	})
+}
+
+// TestApply tests storing the generated value
+func TestApply(t *testing.T) {
+	t.Parallel()
+
+	t.Run("generated", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("test", "-e", path).Return("", 1)
+		fake.Expect("mkdir", "-p", "-m", "0700", "/var/lib/converge/values")
+		fake.Expect("sh", "-c", write, path, "0600")
+
+		p := value.New(fake, path, map[string]string{"db": "app"})
+		_, err := p.Check(gen("new"))
+		require.NoError(t, err)
+
+		_, err = p.Apply()
+		require.NoError(t, err)
+		fake.AssertExpectations(t)
+
+		calls := fake.Calls()
+		assert.Equal(t, "{\"value\":\"new\",\"keepers\":{\"db\":\"app\"}}\n", calls[len(calls)-1].Stdin)
+	})
+
+	t.Run("stored", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("test", "-e", path)
+		fake.Expect("cat", path).Return(`{"value":"old"}`, 0)
+
+		p := value.New(fake, path, nil)
+		_, err := p.Check(gen("new"))
+		require.NoError(t, err)
+
+		_, err = p.Apply()
+		require.NoError(t, err)
+		assert.Len(t, fake.Calls(), 2)
+	})
+}
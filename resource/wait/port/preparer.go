@@ -15,8 +15,6 @@
 package port
 
 import (
-	"errors"
-
 	"github.com/asteris-llc/converge/load/registry"
 	"github.com/asteris-llc/converge/resource"
 	"github.com/asteris-llc/converge/resource/wait"
@@ -29,7 +27,7 @@ type Preparer struct {
 	Host string `hcl:"host"`
 
 	// the TCP port to attempt to connect to.
-	Port int `hcl:"port" required:"true"`
+	Port int `hcl:"port" required:"true" min:"1" max:"65535"`
 
 	// the amount of time to wait in between checks. The format is Go's duration
 	// string. A duration string is a possibly signed sequence of decimal numbers,
@@ -53,9 +51,6 @@ type Preparer struct {
 
 // Prepare creates a new wait.port type
 func (p *Preparer) Prepare(render resource.Renderer) (resource.Task, error) {
-	if p.Port <= 0 {
-		return nil, errors.New("port is required and must be greater than zero")
-	}
 	port := &Port{
 		Host:    p.Host,
 		Port:    p.Port,
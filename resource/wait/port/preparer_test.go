@@ -59,8 +59,8 @@ func TestPreparerPrepare(t *testing.T) {
 	})
 
 	t.Run("invalid port", func(t *testing.T) {
-		p := &port.Preparer{Port: 0, Host: "hostname"}
+		p := resource.NewPreparerWithSource(new(port.Preparer), map[string]interface{}{"port": 0, "host": "hostname"})
 		_, err := p.Prepare(fakerenderer.New())
-		assert.Error(t, err)
+		assert.EqualError(t, err, `"port" must be between 1 and 65535, was 0`)
 	})
 }
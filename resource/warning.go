@@ -0,0 +1,164 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// WarningKind is the kind of problem a warning is about
+type WarningKind string
+
+const (
+	// WarningDeprecated is given for a field or resource that still works but
+	// will be removed
+	WarningDeprecated WarningKind = "deprecated"
+
+	// WarningInsecure is given when a resource is set up in a way that is
+	// likely to be insecure, like a world-writable file
+	WarningInsecure WarningKind = "insecure"
+)
+
+// Warning is a problem with a resource that doesn't stop it from being checked
+// or applied. Warnings are summarized at the end of plan and apply, and are
+// turned into errors with --strict-warnings.
+type Warning struct {
+	Kind WarningKind
+
+	// Field is the field the warning is about, if any
+	Field string
+
+	Message string
+}
+
+func (w Warning) String() string {
+	if w.Field != "" {
+		return fmt.Sprintf("%s field %q: %s", w.Kind, w.Field, w.Message)
+	}
+	return fmt.Sprintf("%s: %s", w.Kind, w.Message)
+}
+
+// Warner is implemented by statuses and results that can carry warnings
+type Warner interface {
+	Warnings() []Warning
+}
+
+// Warnings returns the warnings of a status or result, if it carries any
+func Warnings(v interface{}) []Warning {
+	if warner, ok := v.(Warner); ok {
+		return warner.Warnings()
+	}
+	return nil
+}
+
+// Deprecations wraps a task so that the statuses it returns warn about the
+// deprecated fields set on its node
+type Deprecations struct {
+	Wrapped
+
+	warnings []Warning
+}
+
+// Check checks the wrapped task and adds the warnings to its status
+func (d *Deprecations) Check(r Renderer) (TaskStatus, error) {
+	status, err := d.Task.Check(r)
+	return d.warn(status), err
+}
+
+// Apply applies the wrapped task and adds the warnings to its status
+func (d *Deprecations) Apply() (TaskStatus, error) {
+	status, err := d.Task.Apply()
+	return d.warn(status), err
+}
+
+// warn adds the warnings to status. Statuses that can't take warnings
+// themselves are wrapped.
+func (d *Deprecations) warn(status TaskStatus) TaskStatus {
+	if status == nil {
+		status = NewStatus()
+	}
+
+	if warnable, ok := status.(interface {
+		AddWarning(WarningKind, string, string)
+	}); ok {
+		for _, warning := range d.warnings {
+			warnable.AddWarning(warning.Kind, warning.Field, warning.Message)
+		}
+		return status
+	}
+
+	return &warnedStatus{TaskStatus: status, warnings: d.warnings}
+}
+
+// warnedStatus is a TaskStatus with warnings from outside the task added
+type warnedStatus struct {
+	TaskStatus
+
+	warnings []Warning
+}
+
+// Warnings returns the added warnings after those of the underlying status
+func (s *warnedStatus) Warnings() []Warning {
+	return append(append([]Warning{}, Warnings(s.TaskStatus)...), s.warnings...)
+}
+
+// SetError sets the error on the underlying status
+func (s *warnedStatus) SetError(err error) {
+	if settable, ok := s.TaskStatus.(interface {
+		SetError(error)
+	}); ok {
+		settable.SetError(err)
+	}
+}
+
+// PendingReboots returns the reboots required by the underlying status
+func (s *warnedStatus) PendingReboots() []string {
+	return PendingReboots(s.TaskStatus)
+}
+
+// prepareDeprecations wraps task in Deprecations if the node sets any fields
+// tagged "deprecated". The tag holds the message shown to the user, usually
+// saying what to use instead.
+func (p *Preparer) prepareDeprecations(typ reflect.Type, task Task) Task {
+	var warnings []Warning
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+
+		msg := field.Tag.Get("deprecated")
+		if msg == "" {
+			continue
+		}
+
+		name := p.getFieldName(field)
+		if _, ok := p.Source[name]; ok {
+			warnings = append(warnings, Warning{Kind: WarningDeprecated, Field: name, Message: msg})
+		}
+	}
+
+	if len(warnings) == 0 {
+		return task
+	}
+
+	sort.Sort(warningsByField(warnings))
+	return &Deprecations{Wrapped: Wrapped{Task: task}, warnings: warnings}
+}
+
+type warningsByField []Warning
+
+func (w warningsByField) Len() int           { return len(w) }
+func (w warningsByField) Less(i, j int) bool { return w[i].Field < w[j].Field }
+func (w warningsByField) Swap(i, j int)      { w[i], w[j] = w[j], w[i] }
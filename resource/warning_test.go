@@ -0,0 +1,125 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource_test
+
+import (
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/fakerenderer"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestStatusAddWarning tests that warnings are recorded once
+func TestStatusAddWarning(t *testing.T) {
+	t.Parallel()
+
+	status := resource.NewStatus()
+	status.AddWarning(resource.WarningInsecure, "mode", "world-writable")
+	status.AddWarning(resource.WarningInsecure, "mode", "world-writable")
+	status.AddWarning(resource.WarningDeprecated, "", "use x instead")
+
+	assert.Equal(
+		t,
+		[]resource.Warning{
+			{Kind: resource.WarningInsecure, Field: "mode", Message: "world-writable"},
+			{Kind: resource.WarningDeprecated, Message: "use x instead"},
+		},
+		resource.Warnings(status),
+	)
+	assert.Equal(t, `insecure field "mode": world-writable`, status.Warnings()[0].String())
+	assert.Equal(t, "deprecated: use x instead", status.Warnings()[1].String())
+}
+
+// TestPreparerDeprecations tests that setting a field tagged "deprecated"
+// gives a warning on the task's statuses
+func TestPreparerDeprecations(t *testing.T) {
+	t.Parallel()
+
+	warning := resource.Warning{Kind: resource.WarningDeprecated, Field: "old", Message: "use new instead"}
+
+	t.Run("set", func(t *testing.T) {
+		prep := resource.NewPreparerWithSource(new(testDeprecated), map[string]interface{}{"old": "x"})
+
+		task, err := prep.Prepare(fakerenderer.New())
+		require.NoError(t, err)
+
+		status, err := task.Check(fakerenderer.New())
+		require.NoError(t, err)
+		assert.Equal(t, []resource.Warning{warning}, resource.Warnings(status))
+
+		// warnings given again on later checks are only recorded once
+		status, err = task.Check(fakerenderer.New())
+		require.NoError(t, err)
+		assert.Equal(t, []resource.Warning{warning}, resource.Warnings(status))
+
+		status, err = task.Apply()
+		require.NoError(t, err)
+		assert.Equal(t, []resource.Warning{warning}, resource.Warnings(status))
+	})
+
+	t.Run("status without warnings", func(t *testing.T) {
+		prep := resource.NewPreparerWithSource(&testDeprecated{plain: true}, map[string]interface{}{"old": "x"})
+
+		task, err := prep.Prepare(fakerenderer.New())
+		require.NoError(t, err)
+
+		status, err := task.Check(fakerenderer.New())
+		require.NoError(t, err)
+		assert.Equal(t, []resource.Warning{warning}, resource.Warnings(status))
+		assert.False(t, status.HasChanges())
+	})
+
+	t.Run("unset", func(t *testing.T) {
+		prep := resource.NewPreparerWithSource(new(testDeprecated), map[string]interface{}{"new": "x"})
+
+		task, err := prep.Prepare(fakerenderer.New())
+		require.NoError(t, err)
+
+		status, err := task.Check(fakerenderer.New())
+		require.NoError(t, err)
+		assert.Empty(t, resource.Warnings(status))
+	})
+}
+
+type testDeprecated struct {
+	resource.Status
+
+	Old string `hcl:"old" deprecated:"use new instead"`
+	New string `hcl:"new"`
+
+	// plain returns statuses that can't carry warnings
+	plain bool
+}
+
+func (td *testDeprecated) Prepare(resource.Renderer) (resource.Task, error) { return td, nil }
+
+func (td *testDeprecated) Check(resource.Renderer) (resource.TaskStatus, error) {
+	if td.plain {
+		return plainStatus{}, nil
+	}
+	return td, nil
+}
+
+func (td *testDeprecated) Apply() (resource.TaskStatus, error) { return td, nil }
+
+type plainStatus struct{}
+
+func (plainStatus) Diffs() map[string]resource.Diff  { return nil }
+func (plainStatus) StatusCode() resource.StatusLevel { return resource.StatusNoChange }
+func (plainStatus) Messages() []string               { return nil }
+func (plainStatus) HasChanges() bool                 { return false }
+func (plainStatus) Error() error                     { return nil }
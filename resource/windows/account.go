@@ -0,0 +1,53 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package windows
+
+import "strings"
+
+// BuiltinAccounts are the accounts services run as, by name and SID. They
+// have no password.
+var BuiltinAccounts = map[string]string{
+	"SYSTEM":          "S-1-5-18",
+	"LOCAL SERVICE":   "S-1-5-19",
+	"NETWORK SERVICE": "S-1-5-20",
+}
+
+// AccountName gives the name of a built in account from its SID, or the
+// account unchanged if it isn't one
+func AccountName(account string) string {
+	for name, sid := range BuiltinAccounts {
+		if strings.EqualFold(account, sid) {
+			return name
+		}
+	}
+	return account
+}
+
+// SameAccount tells whether an account read from the system is the one
+// declared. Names are compared without case, built in accounts may be given
+// by SID, and a declared account without a domain matches it in any domain.
+func SameAccount(current, wanted string) bool {
+	current = AccountName(current)
+	if strings.EqualFold(current, wanted) {
+		return true
+	}
+
+	if !strings.Contains(wanted, `\`) {
+		if i := strings.LastIndex(current, `\`); i >= 0 {
+			return strings.EqualFold(current[i+1:], wanted)
+		}
+	}
+	return false
+}
@@ -0,0 +1,40 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package windows_test
+
+import (
+	"testing"
+
+	"github.com/asteris-llc/converge/resource/windows"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSameAccount(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range []struct {
+		current, wanted string
+		same            bool
+	}{
+		{"S-1-5-18", "SYSTEM", true},
+		{"NT AUTHORITY\\SYSTEM", "system", true},
+		{"BUILTIN\\Users", "Users", true},
+		{"BUILTIN\\Users", "BUILTIN\\users", true},
+		{"CORP\\backup", "OTHER\\backup", false},
+		{"S-1-5-20", "LOCAL SERVICE", false},
+	} {
+		assert.Equal(t, test.same, windows.SameAccount(test.current, test.wanted), "%q and %q", test.current, test.wanted)
+	}
+}
@@ -0,0 +1,224 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package feature
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/windows"
+)
+
+// State type for Feature
+type State string
+
+const (
+	// StatePresent indicates the feature should be installed
+	StatePresent State = "present"
+
+	// StateAbsent indicates the feature should be removed
+	StateAbsent State = "absent"
+)
+
+// Providers install features
+const (
+	// ProviderServerManager uses Install-WindowsFeature, on Windows Server
+	ProviderServerManager = "servermanager"
+
+	// ProviderDISM uses dism.exe, for optional features on any edition
+	ProviderDISM = "dism"
+)
+
+var dismStateRe = regexp.MustCompile(`(?m)^State : (.+?)\s*$`)
+
+// Feature manages a Windows feature
+type Feature struct {
+	resource.Status
+
+	Name     string
+	State    State
+	Provider string
+
+	IncludeManagementTools bool
+	IncludeAllSubFeatures  bool
+	Source                 string
+
+	exec exec.Executor
+}
+
+// Check whether the feature is installed
+func (f *Feature) Check(resource.Renderer) (resource.TaskStatus, error) {
+	f.Status = resource.Status{}
+
+	installed, err := f.installed()
+	if err != nil {
+		f.RaiseLevel(resource.StatusFatal)
+		return f, err
+	}
+
+	if current := stateOf(installed); current != f.State {
+		f.RaiseLevel(resource.StatusWillChange)
+		f.AddDifference(f.Name, string(current), string(f.State), "")
+	}
+
+	return f, nil
+}
+
+// Apply installs or removes the feature
+func (f *Feature) Apply() (resource.TaskStatus, error) {
+	f.Status = resource.Status{}
+
+	provider, err := f.provider()
+	if err != nil {
+		f.RaiseLevel(resource.StatusFatal)
+		return f, err
+	}
+
+	verb := "installed"
+	argv := f.installCommand(provider)
+	if f.State == StateAbsent {
+		verb = "removed"
+		argv = f.removeCommand(provider)
+	}
+
+	out, reboot, err := windows.Run(f.exec, argv)
+	if err != nil {
+		f.RaiseLevel(resource.StatusFatal)
+		return f, fmt.Errorf("windows.feature: could not change %s: %s", f.Name, err)
+	}
+
+	// Install-WindowsFeature reports the restart rather than exiting with it
+	if provider == ProviderServerManager && strings.TrimSpace(out) == "Yes" {
+		reboot = true
+	}
+
+	f.AddMessage(fmt.Sprintf("%s %s", verb, f.Name))
+	if reboot {
+		f.RequireReboot(fmt.Sprintf("windows feature %s was %s", f.Name, verb))
+	}
+
+	return f, nil
+}
+
+// provider returns the configured provider, or detects one from whether the
+// Server Manager cmdlets are available
+func (f *Feature) provider() (string, error) {
+	if f.Provider != "" {
+		return f.Provider, nil
+	}
+
+	out, _, err := windows.Run(f.exec, windows.PowerShell(
+		"if (Get-Command Install-WindowsFeature -ErrorAction SilentlyContinue) { 'servermanager' } else { 'dism' }",
+	))
+	if err != nil {
+		return "", fmt.Errorf("windows.feature: could not detect a provider: %s", err)
+	}
+
+	f.Provider = strings.TrimSpace(out)
+	return f.Provider, nil
+}
+
+// installed tells whether the feature is installed. Features waiting on a
+// reboot to be installed count as installed, and the reverse.
+func (f *Feature) installed() (bool, error) {
+	provider, err := f.provider()
+	if err != nil {
+		return false, err
+	}
+
+	switch provider {
+	case ProviderServerManager:
+		out, _, err := windows.Run(f.exec, windows.PowerShell(fmt.Sprintf(
+			"$f = Get-WindowsFeature -Name %s; if ($f) { $f.InstallState } else { 'NotFound' }",
+			windows.Quote(f.Name),
+		)))
+		if err != nil {
+			return false, fmt.Errorf("windows.feature: could not query %s: %s", f.Name, err)
+		}
+
+		switch state := strings.TrimSpace(out); state {
+		case "Installed", "InstallPending":
+			return true, nil
+		case "Available", "Removed", "UninstallPending":
+			return false, nil
+		case "NotFound":
+			return false, fmt.Errorf("windows.feature: no feature named %s", f.Name)
+		default:
+			return false, fmt.Errorf("windows.feature: %s has unknown install state %q", f.Name, state)
+		}
+
+	case ProviderDISM:
+		out, _, err := windows.Run(f.exec, []string{"dism.exe", "/Online", "/English", "/Get-FeatureInfo", "/FeatureName:" + f.Name})
+		if err != nil {
+			return false, fmt.Errorf("windows.feature: could not query %s: %s", f.Name, err)
+		}
+
+		match := dismStateRe.FindStringSubmatch(out)
+		if match == nil {
+			return false, fmt.Errorf("windows.feature: no state for %s in dism output", f.Name)
+		}
+
+		state := match[1]
+		return state == "Enabled" || state == "Enable Pending", nil
+
+	default:
+		return false, fmt.Errorf("windows.feature: unknown provider %q", provider)
+	}
+}
+
+func (f *Feature) installCommand(provider string) []string {
+	if provider == ProviderDISM {
+		argv := []string{"dism.exe", "/Online", "/English", "/Enable-Feature", "/FeatureName:" + f.Name, "/Quiet", "/NoRestart"}
+		if f.IncludeAllSubFeatures {
+			argv = append(argv, "/All")
+		}
+		if f.Source != "" {
+			argv = append(argv, "/Source:"+f.Source, "/LimitAccess")
+		}
+		return argv
+	}
+
+	script := "$r = Install-WindowsFeature -Name " + windows.Quote(f.Name)
+	if f.IncludeManagementTools {
+		script += " -IncludeManagementTools"
+	}
+	if f.IncludeAllSubFeatures {
+		script += " -IncludeAllSubFeature"
+	}
+	if f.Source != "" {
+		script += " -Source " + windows.Quote(f.Source)
+	}
+	return windows.PowerShell(script + "; if (-not $r.Success) { exit 1 }; $r.RestartNeeded")
+}
+
+func (f *Feature) removeCommand(provider string) []string {
+	if provider == ProviderDISM {
+		return []string{"dism.exe", "/Online", "/English", "/Disable-Feature", "/FeatureName:" + f.Name, "/Quiet", "/NoRestart"}
+	}
+
+	return windows.PowerShell(
+		"$r = Uninstall-WindowsFeature -Name " + windows.Quote(f.Name) + "; if (-not $r.Success) { exit 1 }; $r.RestartNeeded",
+	)
+}
+
+func stateOf(installed bool) State {
+	if installed {
+		return StatePresent
+	}
+	return StateAbsent
+}
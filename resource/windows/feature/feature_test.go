@@ -0,0 +1,193 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package feature_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/fakeexec"
+	"github.com/asteris-llc/converge/helpers/fakerenderer"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/windows"
+	"github.com/asteris-llc/converge/resource/windows/feature"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	detect  = "if (Get-Command Install-WindowsFeature -ErrorAction SilentlyContinue) { 'servermanager' } else { 'dism' }"
+	query   = "$f = Get-WindowsFeature -Name 'Web-Server'; if ($f) { $f.InstallState } else { 'NotFound' }"
+	install = "$r = Install-WindowsFeature -Name 'Web-Server' -IncludeManagementTools; if (-not $r.Success) { exit 1 }; $r.RestartNeeded"
+
+	dismInfo = `Deployment Image Servicing and Management tool
+
+Feature Name : IIS-WebServerRole
+Display Name : Internet Information Services
+State : %s
+Restart Required : Possible
+`
+)
+
+// TestFeatureInterface tests that Feature is properly implemented
+func TestFeatureInterface(t *testing.T) {
+	t.Parallel()
+
+	assert.Implements(t, (*resource.Task)(nil), new(feature.Feature))
+	assert.Implements(t, (*resource.Resource)(nil), new(feature.Preparer))
+}
+
+func TestPrepare(t *testing.T) {
+	t.Parallel()
+
+	_, err := (&feature.Preparer{Name: "IIS-WebServerRole", Provider: "dism", IncludeManagementTools: true}).Prepare(fakerenderer.New())
+	assert.EqualError(t, err, `windows.feature "include_management_tools" is only supported by the servermanager provider`)
+}
+
+func TestCheck(t *testing.T) {
+	t.Parallel()
+
+	t.Run("detects provider", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect(windows.PowerShell(detect)...).Return("servermanager\r\n", 0).Once()
+		fake.Expect(windows.PowerShell(query)...).Return("Available\r\n", 0)
+
+		status, err := prepare(t, fake, &feature.Preparer{Name: "Web-Server"}).Check(fakerenderer.New())
+
+		require.NoError(t, err)
+		assert.True(t, status.HasChanges())
+		assert.Equal(t, "absent", status.Diffs()["Web-Server"].Original())
+		assert.Equal(t, "present", status.Diffs()["Web-Server"].Current())
+		fake.AssertExpectations(t)
+	})
+
+	t.Run("servermanager", func(t *testing.T) {
+		for state, changes := range map[string]bool{
+			"Installed":        false,
+			"InstallPending":   false,
+			"Available":        true,
+			"Removed":          true,
+			"UninstallPending": true,
+		} {
+			fake := fakeexec.New()
+			fake.Expect(windows.PowerShell(query)...).Return(state+"\r\n", 0)
+
+			status, err := prepare(t, fake, &feature.Preparer{Name: "Web-Server", Provider: "servermanager"}).Check(fakerenderer.New())
+
+			require.NoError(t, err)
+			assert.Equal(t, changes, status.HasChanges(), state)
+		}
+	})
+
+	t.Run("unknown feature", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect(windows.PowerShell(query)...).Return("NotFound\r\n", 0)
+
+		status, err := prepare(t, fake, &feature.Preparer{Name: "Web-Server", Provider: "servermanager"}).Check(fakerenderer.New())
+
+		assert.EqualError(t, err, "windows.feature: no feature named Web-Server")
+		assert.Equal(t, resource.StatusFatal, status.StatusCode())
+	})
+
+	t.Run("dism", func(t *testing.T) {
+		for state, installed := range map[string]bool{
+			"Enabled":                       true,
+			"Enable Pending":                true,
+			"Disabled":                      false,
+			"Disabled with Payload Removed": false,
+		} {
+			fake := fakeexec.New()
+			fake.Expect("dism.exe", "/Online", "/English", "/Get-FeatureInfo", "/FeatureName:IIS-WebServerRole").Return(fmt.Sprintf(dismInfo, state), 0)
+
+			status, err := prepare(t, fake, &feature.Preparer{Name: "IIS-WebServerRole", Provider: "dism", State: "absent"}).Check(fakerenderer.New())
+
+			require.NoError(t, err)
+			assert.Equal(t, installed, status.HasChanges(), state)
+		}
+	})
+
+	t.Run("dism error", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("dism.exe", "/Online", "/English", "/Get-FeatureInfo", "/FeatureName:Nope").Return("Feature name Nope is unknown.\n", 87)
+
+		_, err := prepare(t, fake, &feature.Preparer{Name: "Nope", Provider: "dism"}).Check(fakerenderer.New())
+
+		assert.EqualError(t, err, "windows.feature: could not query Nope: dism.exe: exit status 87: Feature name Nope is unknown.")
+	})
+}
+
+func TestApply(t *testing.T) {
+	t.Parallel()
+
+	t.Run("servermanager", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect(windows.PowerShell(install)...).Return("No\r\n", 0)
+
+		status, err := prepare(t, fake, &feature.Preparer{Name: "Web-Server", Provider: "servermanager", IncludeManagementTools: true}).Apply()
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"installed Web-Server"}, status.Messages())
+		assert.Empty(t, status.(*feature.Feature).PendingReboots())
+	})
+
+	t.Run("servermanager restart needed", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect(windows.PowerShell(install)...).Return("Yes\r\n", 0)
+
+		status, err := prepare(t, fake, &feature.Preparer{Name: "Web-Server", Provider: "servermanager", IncludeManagementTools: true}).Apply()
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"windows feature Web-Server was installed"}, status.(*feature.Feature).PendingReboots())
+	})
+
+	t.Run("servermanager remove", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect(windows.PowerShell("$r = Uninstall-WindowsFeature -Name 'Web-Server'; if (-not $r.Success) { exit 1 }; $r.RestartNeeded")...).Return("No\r\n", 0)
+
+		status, err := prepare(t, fake, &feature.Preparer{Name: "Web-Server", Provider: "servermanager", State: "absent"}).Apply()
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"removed Web-Server"}, status.Messages())
+	})
+
+	t.Run("dism", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect(
+			"dism.exe", "/Online", "/English", "/Enable-Feature", "/FeatureName:NetFx3", "/Quiet", "/NoRestart",
+			"/All", `/Source:D:\sources\sxs`, "/LimitAccess",
+		).Return("", windows.ExitRebootRequired)
+
+		status, err := prepare(t, fake, &feature.Preparer{Name: "NetFx3", Provider: "dism", IncludeAllSubFeatures: true, Source: `D:\sources\sxs`}).Apply()
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"windows feature NetFx3 was installed"}, status.(*feature.Feature).PendingReboots())
+	})
+
+	t.Run("failure", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("dism.exe", "/Online", "/English", "/Disable-Feature", "/FeatureName:NetFx3", "/Quiet", "/NoRestart").Return("Error: 5\nAccess is denied.\n", 5)
+
+		status, err := prepare(t, fake, &feature.Preparer{Name: "NetFx3", Provider: "dism", State: "absent"}).Apply()
+
+		assert.EqualError(t, err, "windows.feature: could not change NetFx3: dism.exe: exit status 5: Error: 5\nAccess is denied.")
+		assert.Equal(t, resource.StatusFatal, status.StatusCode())
+	})
+}
+
+func prepare(t *testing.T, fake *fakeexec.Executor, p *feature.Preparer) *feature.Feature {
+	task, err := p.Prepare(&resource.ExecRenderer{Renderer: fakerenderer.New(), Exec: fake})
+	require.NoError(t, err)
+	return task.(*feature.Feature)
+}
@@ -0,0 +1,84 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package feature
+
+import (
+	"fmt"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/load/registry"
+	"github.com/asteris-llc/converge/resource"
+)
+
+// Preparer for Windows features
+//
+// Feature installs or removes a Windows feature. On Windows Server it uses
+// Install-WindowsFeature, and elsewhere the optional features of dism.exe.
+// The two name features differently, such as "Web-Server" and
+// "IIS-WebServerRole" for IIS, so the name must match the provider. A feature
+// that needs a reboot to finish is reported as a pending reboot.
+type Preparer struct {
+	// Name of the feature, as given by Get-WindowsFeature or
+	// `dism /Online /Get-Features`
+	Name string `hcl:"name" required:"true" nonempty:"true"`
+
+	// State of the feature. Present means the feature will be installed if
+	// missing; Absent means the feature will be removed if installed.
+	State State `hcl:"state" valid_values:"present,absent" default:"present"`
+
+	// Provider is "servermanager" to use Install-WindowsFeature or "dism" to
+	// use dism.exe. It is detected from whether Install-WindowsFeature is
+	// available if not given.
+	Provider string `hcl:"provider" valid_values:"servermanager,dism"`
+
+	// IncludeManagementTools installs the management tools of the feature
+	// too. Only used by the servermanager provider.
+	IncludeManagementTools bool `hcl:"include_management_tools"`
+
+	// IncludeAllSubFeatures installs the features under this one with
+	// servermanager, and the features this one depends on with dism.
+	IncludeAllSubFeatures bool `hcl:"include_all_sub_features"`
+
+	// Source is where to find the feature files if they aren't on the
+	// system, such as a mounted install image. With dism, Windows Update isn't
+	// used when a source is given.
+	Source string `hcl:"source"`
+}
+
+// Prepare a new task
+func (p *Preparer) Prepare(render resource.Renderer) (resource.Task, error) {
+	if p.State == "" {
+		p.State = StatePresent
+	}
+
+	if p.IncludeManagementTools && p.Provider == ProviderDISM {
+		return nil, fmt.Errorf("windows.feature \"include_management_tools\" is only supported by the servermanager provider")
+	}
+
+	return &Feature{
+		Name:                   p.Name,
+		State:                  p.State,
+		Provider:               p.Provider,
+		IncludeManagementTools: p.IncludeManagementTools,
+		IncludeAllSubFeatures:  p.IncludeAllSubFeatures,
+		Source:                 p.Source,
+		exec:                   exec.For(render),
+	}, nil
+}
+
+func init() {
+	registry.Register("windows.feature", (*Preparer)(nil), (*Feature)(nil))
+	registry.RegisterPlatforms("windows.feature", "windows")
+}
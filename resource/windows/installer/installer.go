@@ -0,0 +1,283 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package installer
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/windows"
+)
+
+// State type for Installer
+type State string
+
+const (
+	// StatePresent indicates the product should be installed
+	StatePresent State = "present"
+
+	// StateAbsent indicates the product should be uninstalled
+	StateAbsent State = "absent"
+)
+
+// Type is the kind of installer, which sets the arguments that make it run
+// silently
+type Type string
+
+// Installer types
+const (
+	TypeMSI           Type = "msi"
+	TypeNSIS          Type = "nsis"
+	TypeInno          Type = "inno"
+	TypeInstallShield Type = "installshield"
+	TypeEXE           Type = "exe"
+)
+
+// silentArgs are the arguments that run each type of installer without
+// prompting or restarting. TypeEXE has none, so its arguments must be given.
+var silentArgs = map[Type]string{
+	TypeMSI:           "/qn /norestart",
+	TypeNSIS:          "/S",
+	TypeInno:          "/VERYSILENT /SUPPRESSMSGBOXES /NORESTART /SP-",
+	TypeInstallShield: `/s /v"/qn /norestart"`,
+	TypeEXE:           "",
+}
+
+// uninstallKeys are where installed products are registered, for 64 and 32
+// bit programs
+var uninstallKeys = []string{
+	`HKLM:\SOFTWARE\Microsoft\Windows\CurrentVersion\Uninstall\*`,
+	`HKLM:\SOFTWARE\WOW6432Node\Microsoft\Windows\CurrentVersion\Uninstall\*`,
+}
+
+// registration is an installed product, as registered under the uninstall keys
+type registration struct {
+	Key                  string `json:"PSChildName"`
+	DisplayName          string `json:"DisplayName"`
+	DisplayVersion       string `json:"DisplayVersion"`
+	QuietUninstallString string `json:"QuietUninstallString"`
+}
+
+// Installer installs a product from an MSI or EXE installer
+type Installer struct {
+	resource.Status
+
+	Source string
+	Type   Type
+
+	// ProductCode or DisplayName find the installed product in the registry
+	ProductCode string
+	DisplayName string
+
+	// Version is the display version the product should be at, if set
+	Version string
+
+	Args         string
+	SuccessCodes []int
+	State        State
+
+	exec exec.Executor
+}
+
+// Check whether the product is installed at the declared version
+func (i *Installer) Check(resource.Renderer) (resource.TaskStatus, error) {
+	i.Status = resource.Status{}
+
+	product, err := i.installed()
+	if err != nil {
+		i.RaiseLevel(resource.StatusFatal)
+		return i, err
+	}
+
+	switch {
+	case i.State == StateAbsent && product != nil:
+		i.RaiseLevel(resource.StatusWillChange)
+		i.AddDifference(i.name(), product.version(), "<absent>", "")
+
+	case i.State == StatePresent && product == nil:
+		i.RaiseLevel(resource.StatusWillChange)
+		i.AddDifference(i.name(), "<absent>", i.wanted(), "")
+
+	case i.State == StatePresent && i.Version != "" && product.DisplayVersion != i.Version:
+		i.RaiseLevel(resource.StatusWillChange)
+		i.AddDifference(i.name(), product.version(), i.Version, "")
+	}
+
+	return i, nil
+}
+
+// Apply runs the installer, or uninstalls the product
+func (i *Installer) Apply() (resource.TaskStatus, error) {
+	i.Status = resource.Status{}
+
+	if err := i.apply(); err != nil {
+		i.RaiseLevel(resource.StatusFatal)
+		return i, err
+	}
+
+	return i, nil
+}
+
+func (i *Installer) apply() error {
+	if i.State == StateAbsent {
+		return i.uninstall()
+	}
+
+	reboot, err := i.start(i.installCommand())
+	if err != nil {
+		return fmt.Errorf("windows.installer: could not install %s: %s", i.Source, err)
+	}
+	i.AddMessage(fmt.Sprintf("installed %s", i.Source))
+	if reboot {
+		i.RequireReboot(fmt.Sprintf("%s was installed", i.name()))
+	}
+
+	// an installer that succeeded without registering the product would be
+	// run again on every apply
+	product, err := i.installed()
+	if err != nil {
+		return err
+	}
+	if product == nil {
+		return fmt.Errorf("windows.installer: %s succeeded, but %s is not registered as installed", i.Source, i.name())
+	}
+	if i.Version != "" && product.DisplayVersion != i.Version {
+		return fmt.Errorf("windows.installer: %s succeeded, but %s is at version %s", i.Source, i.name(), product.DisplayVersion)
+	}
+
+	return nil
+}
+
+func (i *Installer) uninstall() error {
+	product, err := i.installed()
+	if err != nil {
+		return err
+	}
+	if product == nil {
+		return nil
+	}
+
+	var command string
+	switch {
+	case i.ProductCode != "":
+		command = "msiexec.exe /x " + i.ProductCode + " " + silentArgs[TypeMSI]
+	case product.QuietUninstallString != "":
+		command = product.QuietUninstallString
+	default:
+		return fmt.Errorf("windows.installer: %s has no quiet uninstall command", i.name())
+	}
+
+	reboot, err := i.start(uninstallScript(command))
+	if err != nil {
+		return fmt.Errorf("windows.installer: could not uninstall %s: %s", i.name(), err)
+	}
+	i.AddMessage(fmt.Sprintf("uninstalled %s", i.name()))
+	if reboot {
+		i.RequireReboot(fmt.Sprintf("%s was uninstalled", i.name()))
+	}
+
+	return nil
+}
+
+// installed returns the installed product, or nil if it isn't installed
+func (i *Installer) installed() (*registration, error) {
+	filter := "$_.PSChildName -eq " + windows.Quote(i.ProductCode)
+	if i.ProductCode == "" {
+		filter = "$_.DisplayName -like " + windows.Quote(i.DisplayName)
+	}
+
+	keys := make([]string, len(uninstallKeys))
+	for n, key := range uninstallKeys {
+		keys[n] = windows.Quote(key)
+	}
+
+	out, _, err := windows.Run(i.exec, windows.PowerShell(fmt.Sprintf(
+		"Get-ItemProperty %s -ErrorAction SilentlyContinue | Where-Object { %s } | Select-Object -First 1 PSChildName,DisplayName,DisplayVersion,QuietUninstallString | ConvertTo-Json -Compress",
+		strings.Join(keys, ","),
+		filter,
+	)))
+	if err != nil {
+		return nil, fmt.Errorf("windows.installer: could not look up %s: %s", i.name(), err)
+	}
+
+	out = strings.TrimSpace(out)
+	if out == "" {
+		return nil, nil
+	}
+
+	product := new(registration)
+	if err := json.Unmarshal([]byte(out), product); err != nil {
+		return nil, fmt.Errorf("windows.installer: could not parse installed product %q: %s", out, err)
+	}
+
+	return product, nil
+}
+
+// start runs a command line, mapping its exit code to success and reboots
+func (i *Installer) start(argv []string) (bool, error) {
+	_, reboot, err := windows.Run(i.exec, argv, i.SuccessCodes...)
+	return reboot, err
+}
+
+// installCommand starts the installer and exits with its exit code. The
+// arguments are passed to the installer as a single string, since installers
+// parse their own command lines with their own quoting rules.
+func (i *Installer) installCommand() []string {
+	file := i.Source
+	args := strings.TrimSpace(silentArgs[i.Type] + " " + i.Args)
+
+	if i.Type == TypeMSI {
+		file = "msiexec.exe"
+		args = strings.TrimSpace(fmt.Sprintf(`/i "%s" %s`, i.Source, args))
+	}
+
+	script := "$p = Start-Process -FilePath " + windows.Quote(file)
+	if args != "" {
+		script += " -ArgumentList " + windows.Quote(args)
+	}
+	return windows.PowerShell(script + " -Wait -PassThru -NoNewWindow; exit $p.ExitCode")
+}
+
+// uninstallScript runs an uninstall command line, as registered by the
+// product, and exits with its exit code
+func uninstallScript(command string) []string {
+	return windows.PowerShell(
+		"$p = Start-Process -FilePath cmd.exe -ArgumentList " + windows.Quote("/c "+command) + " -Wait -PassThru -NoNewWindow; exit $p.ExitCode",
+	)
+}
+
+func (i *Installer) name() string {
+	if i.ProductCode != "" {
+		return i.ProductCode
+	}
+	return i.DisplayName
+}
+
+func (i *Installer) wanted() string {
+	if i.Version != "" {
+		return i.Version
+	}
+	return "<present>"
+}
+
+func (p *registration) version() string {
+	if p.DisplayVersion != "" {
+		return p.DisplayVersion
+	}
+	return "<present>"
+}
@@ -0,0 +1,267 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package installer_test
+
+import (
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/fakeexec"
+	"github.com/asteris-llc/converge/helpers/fakerenderer"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/windows"
+	"github.com/asteris-llc/converge/resource/windows/installer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	productCode = "{23170F69-40C1-2702-1900-000001000000}"
+	keys        = `'HKLM:\SOFTWARE\Microsoft\Windows\CurrentVersion\Uninstall\*','HKLM:\SOFTWARE\WOW6432Node\Microsoft\Windows\CurrentVersion\Uninstall\*'`
+	selectJSON  = " | Select-Object -First 1 PSChildName,DisplayName,DisplayVersion,QuietUninstallString | ConvertTo-Json -Compress"
+
+	byCode = "Get-ItemProperty " + keys + " -ErrorAction SilentlyContinue | Where-Object { $_.PSChildName -eq '" + productCode + "' }" + selectJSON
+	byName = "Get-ItemProperty " + keys + " -ErrorAction SilentlyContinue | Where-Object { $_.DisplayName -like 'Notepad++*' }" + selectJSON
+
+	registered = `{"PSChildName":"` + productCode + `","DisplayName":"7-Zip 19.00 (x64 edition)","DisplayVersion":"19.00.00.0","QuietUninstallString":null}`
+)
+
+// TestInstallerInterface tests that Installer is properly implemented
+func TestInstallerInterface(t *testing.T) {
+	t.Parallel()
+
+	assert.Implements(t, (*resource.Task)(nil), new(installer.Installer))
+	assert.Implements(t, (*resource.Resource)(nil), new(installer.Preparer))
+}
+
+func TestPrepare(t *testing.T) {
+	t.Parallel()
+
+	t.Run("msi by extension", func(t *testing.T) {
+		task, err := (&installer.Preparer{Source: `C:\7z.MSI`, ProductCode: productCode}).Prepare(fakerenderer.New())
+		require.NoError(t, err)
+		assert.Equal(t, installer.TypeMSI, task.(*installer.Installer).Type)
+	})
+
+	t.Run("uninstall without source", func(t *testing.T) {
+		_, err := (&installer.Preparer{ProductCode: productCode, State: "absent"}).Prepare(fakerenderer.New())
+		assert.NoError(t, err)
+	})
+
+	for name, test := range map[string]struct {
+		preparer *installer.Preparer
+		err      string
+	}{
+		"no detection": {
+			&installer.Preparer{Source: `C:\7z.msi`},
+			`windows.installer needs a "product_code" or "display_name" to find the installed product`,
+		},
+		"bad product code": {
+			&installer.Preparer{Source: `C:\7z.msi`, ProductCode: "23170F69"},
+			`windows.installer "product_code" must be a GUID in braces, like "{23170F69-40C1-2702-1900-000001000000}", not "23170F69"`,
+		},
+		"no source": {
+			&installer.Preparer{ProductCode: productCode},
+			`windows.installer "source" is required when state is "present"`,
+		},
+		"no type": {
+			&installer.Preparer{Source: `C:\setup.exe`, DisplayName: "App"},
+			`windows.installer "type" is required for installers that aren't .msi files`,
+		},
+		"exe without args": {
+			&installer.Preparer{Source: `C:\setup.exe`, DisplayName: "App", Type: "exe"},
+			`windows.installer "args" is required for type "exe", so that it runs silently`,
+		},
+	} {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			_, err := test.preparer.Prepare(fakerenderer.New())
+			assert.EqualError(t, err, test.err)
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	t.Parallel()
+
+	t.Run("installed", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect(windows.PowerShell(byCode)...).Return(registered+"\r\n", 0)
+
+		status, err := prepare(t, fake, &installer.Preparer{Source: `C:\7z.msi`, ProductCode: productCode, Version: "19.00.00.0"}).Check(fakerenderer.New())
+
+		require.NoError(t, err)
+		assert.False(t, status.HasChanges())
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect(windows.PowerShell(byCode)...).Return("", 0)
+
+		status, err := prepare(t, fake, &installer.Preparer{Source: `C:\7z.msi`, ProductCode: productCode}).Check(fakerenderer.New())
+
+		require.NoError(t, err)
+		assert.Equal(t, "<absent>", status.Diffs()[productCode].Original())
+		assert.Equal(t, "<present>", status.Diffs()[productCode].Current())
+	})
+
+	t.Run("other version", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect(windows.PowerShell(byCode)...).Return(registered, 0)
+
+		status, err := prepare(t, fake, &installer.Preparer{Source: `C:\7z.msi`, ProductCode: productCode, Version: "22.01.00.0"}).Check(fakerenderer.New())
+
+		require.NoError(t, err)
+		assert.Equal(t, "19.00.00.0", status.Diffs()[productCode].Original())
+		assert.Equal(t, "22.01.00.0", status.Diffs()[productCode].Current())
+	})
+
+	t.Run("absent", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect(windows.PowerShell(byCode)...).Return(registered, 0)
+
+		status, err := prepare(t, fake, &installer.Preparer{ProductCode: productCode, State: "absent"}).Check(fakerenderer.New())
+
+		require.NoError(t, err)
+		assert.Equal(t, "<absent>", status.Diffs()[productCode].Current())
+	})
+
+	t.Run("by display name", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect(windows.PowerShell(byName)...).Return("", 0)
+
+		status, err := prepare(t, fake, &installer.Preparer{Source: `C:\npp.exe`, Type: "nsis", DisplayName: "Notepad++*"}).Check(fakerenderer.New())
+
+		require.NoError(t, err)
+		assert.True(t, status.HasChanges())
+		fake.AssertExpectations(t)
+	})
+
+	t.Run("bad output", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect(windows.PowerShell(byCode)...).Return("nope", 0)
+
+		status, err := prepare(t, fake, &installer.Preparer{Source: `C:\7z.msi`, ProductCode: productCode}).Check(fakerenderer.New())
+
+		assert.Error(t, err)
+		assert.Equal(t, resource.StatusFatal, status.StatusCode())
+	})
+}
+
+func TestApply(t *testing.T) {
+	t.Parallel()
+
+	install := windows.PowerShell(`$p = Start-Process -FilePath 'msiexec.exe' -ArgumentList '/i "C:\7z.msi" /qn /norestart INSTALLDIR=D:\7z' -Wait -PassThru -NoNewWindow; exit $p.ExitCode`)
+
+	t.Run("msi", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect(install...).Return("", 0)
+		fake.Expect(windows.PowerShell(byCode)...).Return(registered, 0)
+
+		status, err := prepare(t, fake, &installer.Preparer{Source: `C:\7z.msi`, ProductCode: productCode, Args: `INSTALLDIR=D:\7z`}).Apply()
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{`installed C:\7z.msi`}, status.Messages())
+		assert.Empty(t, status.(*installer.Installer).PendingReboots())
+	})
+
+	t.Run("reboot required", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect(install...).Return("", windows.ExitRebootRequired)
+		fake.Expect(windows.PowerShell(byCode)...).Return(registered, 0)
+
+		status, err := prepare(t, fake, &installer.Preparer{Source: `C:\7z.msi`, ProductCode: productCode, Args: `INSTALLDIR=D:\7z`}).Apply()
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{productCode + " was installed"}, status.(*installer.Installer).PendingReboots())
+	})
+
+	t.Run("exe preset", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect(windows.PowerShell(`$p = Start-Process -FilePath 'C:\inno''s setup.exe' -ArgumentList '/VERYSILENT /SUPPRESSMSGBOXES /NORESTART /SP- /DIR="D:\app"' -Wait -PassThru -NoNewWindow; exit $p.ExitCode`)...).Return("", 0)
+		fake.Expect(windows.PowerShell(byName)...).Return(`{"PSChildName":"npp","DisplayName":"Notepad++ (64-bit x64)","DisplayVersion":"8.6"}`, 0)
+
+		_, err := prepare(t, fake, &installer.Preparer{Source: `C:\inno's setup.exe`, Type: "inno", DisplayName: "Notepad++*", Args: `/DIR="D:\app"`}).Apply()
+
+		require.NoError(t, err)
+		fake.AssertExpectations(t)
+	})
+
+	t.Run("success codes", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect(install...).Return("", 1638)
+		fake.Expect(windows.PowerShell(byCode)...).Return(registered, 0)
+
+		_, err := prepare(t, fake, &installer.Preparer{Source: `C:\7z.msi`, ProductCode: productCode, Args: `INSTALLDIR=D:\7z`, SuccessCodes: []int{1638}}).Apply()
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("failure", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect(install...).Return("", 1603)
+
+		status, err := prepare(t, fake, &installer.Preparer{Source: `C:\7z.msi`, ProductCode: productCode, Args: `INSTALLDIR=D:\7z`}).Apply()
+
+		assert.EqualError(t, err, `windows.installer: could not install C:\7z.msi: powershell.exe: exit status 1603`)
+		assert.Equal(t, resource.StatusFatal, status.StatusCode())
+	})
+
+	t.Run("not registered after install", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect(install...).Return("", 0)
+		fake.Expect(windows.PowerShell(byCode)...).Return("", 0)
+
+		_, err := prepare(t, fake, &installer.Preparer{Source: `C:\7z.msi`, ProductCode: productCode, Args: `INSTALLDIR=D:\7z`}).Apply()
+
+		assert.EqualError(t, err, `windows.installer: C:\7z.msi succeeded, but `+productCode+` is not registered as installed`)
+	})
+
+	t.Run("uninstall by product code", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect(windows.PowerShell(byCode)...).Return(registered, 0)
+		fake.Expect(windows.PowerShell(`$p = Start-Process -FilePath cmd.exe -ArgumentList '/c msiexec.exe /x `+productCode+` /qn /norestart' -Wait -PassThru -NoNewWindow; exit $p.ExitCode`)...).Return("", 0)
+
+		status, err := prepare(t, fake, &installer.Preparer{ProductCode: productCode, State: "absent"}).Apply()
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"uninstalled " + productCode}, status.Messages())
+	})
+
+	t.Run("uninstall by quiet uninstall string", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect(windows.PowerShell(byName)...).Return(`{"PSChildName":"npp","DisplayName":"Notepad++","QuietUninstallString":"\"C:\\npp\\uninstall.exe\" /S"}`, 0)
+		fake.Expect(windows.PowerShell(`$p = Start-Process -FilePath cmd.exe -ArgumentList '/c "C:\npp\uninstall.exe" /S' -Wait -PassThru -NoNewWindow; exit $p.ExitCode`)...).Return("", 0)
+
+		_, err := prepare(t, fake, &installer.Preparer{DisplayName: "Notepad++*", State: "absent"}).Apply()
+
+		require.NoError(t, err)
+		fake.AssertExpectations(t)
+	})
+
+	t.Run("no quiet uninstall string", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect(windows.PowerShell(byName)...).Return(`{"PSChildName":"npp","DisplayName":"Notepad++"}`, 0)
+
+		_, err := prepare(t, fake, &installer.Preparer{DisplayName: "Notepad++*", State: "absent"}).Apply()
+
+		assert.EqualError(t, err, "windows.installer: Notepad++* has no quiet uninstall command")
+	})
+}
+
+func prepare(t *testing.T, fake *fakeexec.Executor, p *installer.Preparer) *installer.Installer {
+	task, err := p.Prepare(&resource.ExecRenderer{Renderer: fakerenderer.New(), Exec: fake})
+	require.NoError(t, err)
+	return task.(*installer.Installer)
+}
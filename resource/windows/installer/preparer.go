@@ -0,0 +1,122 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package installer
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/load/registry"
+	"github.com/asteris-llc/converge/resource"
+)
+
+var productCodeRe = regexp.MustCompile(`^\{[0-9A-Fa-f]{8}-[0-9A-Fa-f]{4}-[0-9A-Fa-f]{4}-[0-9A-Fa-f]{4}-[0-9A-Fa-f]{12}\}$`)
+
+// Preparer for Windows Installer
+//
+// Installer installs a product from an MSI or EXE installer, unless it is
+// already registered as installed under the Uninstall registry keys. The
+// product is found by its MSI product code or by its display name. Exit codes
+// 3010 and 1641 count as success and are reported as a pending reboot.
+type Preparer struct {
+	// Source is the path to the installer, such as
+	// `C:\installers\app-1.2.msi` or a UNC path.
+	Source string `hcl:"source"`
+
+	// Type of the installer. It sets the arguments that run the installer
+	// silently: "/qn /norestart" for msi, "/S" for nsis, "/VERYSILENT
+	// /SUPPRESSMSGBOXES /NORESTART /SP-" for inno, and `/s /v"/qn
+	// /norestart"` for installshield. Use exe to give all the arguments in
+	// args. Defaults to msi for .msi files.
+	Type Type `hcl:"type" valid_values:"msi,nsis,inno,installshield,exe"`
+
+	// ProductCode is the MSI product code of the product, like
+	// "{23170F69-40C1-2702-1900-000001000000}". It is also used to uninstall
+	// the product.
+	ProductCode string `hcl:"product_code" mutually_exclusive:"product_code,display_name"`
+
+	// DisplayName is the name of the product as shown in Programs and
+	// Features, and may contain * wildcards. The product's quiet uninstall
+	// command is used to uninstall it.
+	DisplayName string `hcl:"display_name" mutually_exclusive:"product_code,display_name"`
+
+	// Version is the display version the product should be at. If given, the
+	// installer is run when a different version is installed.
+	Version string `hcl:"version"`
+
+	// Args are passed to the installer after the silent arguments for its
+	// type, such as "INSTALLDIR=D:\app" for an MSI.
+	Args string `hcl:"args"`
+
+	// SuccessCodes are exit codes of the installer to treat as success, in
+	// addition to 0, 3010, and 1641.
+	SuccessCodes []int `hcl:"success_codes"`
+
+	// State of the product. Present means the installer will be run if the
+	// product is missing; Absent means the product will be uninstalled if
+	// present.
+	State State `hcl:"state" valid_values:"present,absent" default:"present"`
+}
+
+// Prepare a new task
+func (p *Preparer) Prepare(render resource.Renderer) (resource.Task, error) {
+	if p.State == "" {
+		p.State = StatePresent
+	}
+
+	if p.ProductCode == "" && p.DisplayName == "" {
+		return nil, fmt.Errorf("windows.installer needs a \"product_code\" or \"display_name\" to find the installed product")
+	}
+
+	if p.ProductCode != "" && !productCodeRe.MatchString(p.ProductCode) {
+		return nil, fmt.Errorf("windows.installer \"product_code\" must be a GUID in braces, like \"{23170F69-40C1-2702-1900-000001000000}\", not %q", p.ProductCode)
+	}
+
+	if p.State == StatePresent {
+		if p.Source == "" {
+			return nil, fmt.Errorf("windows.installer \"source\" is required when state is \"present\"")
+		}
+
+		if p.Type == "" {
+			if !strings.HasSuffix(strings.ToLower(p.Source), ".msi") {
+				return nil, fmt.Errorf("windows.installer \"type\" is required for installers that aren't .msi files")
+			}
+			p.Type = TypeMSI
+		}
+
+		if p.Type == TypeEXE && p.Args == "" {
+			return nil, fmt.Errorf("windows.installer \"args\" is required for type \"exe\", so that it runs silently")
+		}
+	}
+
+	return &Installer{
+		Source:       p.Source,
+		Type:         p.Type,
+		ProductCode:  p.ProductCode,
+		DisplayName:  p.DisplayName,
+		Version:      p.Version,
+		Args:         p.Args,
+		SuccessCodes: p.SuccessCodes,
+		State:        p.State,
+		exec:         exec.For(render),
+	}, nil
+}
+
+func init() {
+	registry.Register("windows.installer", (*Preparer)(nil), (*Installer)(nil))
+	registry.RegisterPlatforms("windows.installer", "windows")
+}
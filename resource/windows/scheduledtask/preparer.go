@@ -0,0 +1,132 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduledtask
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/load/registry"
+	"github.com/asteris-llc/converge/resource"
+)
+
+// Preparer for Scheduled Task
+//
+// Scheduled Task runs a command on a schedule with the Windows Task
+// Scheduler, like systemd.timer does on Linux. The registered task is
+// exported as XML and compared with the declared triggers, action, principal,
+// and settings, and is registered again when any of them differ.
+type Preparer struct {
+	// Name of the task, optionally in a folder, such as `\Converge\backup`
+	Name string `hcl:"name" required:"true" nonempty:"true"`
+
+	// Triggers are when the task runs. Each is one of "boot", "logon",
+	// "daily at 03:00", "weekly on Mon,Thu at 03:00", or an interval like
+	// "every 15m".
+	Triggers []string `hcl:"triggers"`
+
+	// Command is the program the task runs
+	Command string `hcl:"command"`
+
+	// Arguments are passed to the command
+	Arguments string `hcl:"arguments"`
+
+	// WorkingDirectory is where the command runs
+	WorkingDirectory string `hcl:"working_directory"`
+
+	// Description of the task
+	Description string `hcl:"description"`
+
+	// User runs the task as this account instead of SYSTEM. Without a
+	// password, the task runs whether or not the user is logged on, but can't
+	// reach network resources.
+	User string `hcl:"user"`
+
+	// Password of the user. It can't be read back from the system, so it is
+	// only set when the task is registered because something else changed.
+	// It is usually set from a param so that it doesn't need to be written in
+	// the module.
+	Password string `hcl:"password"`
+
+	// Highest runs the task with the highest privileges of the user
+	Highest bool `hcl:"highest"`
+
+	// Enabled is whether the task runs on its triggers. It defaults to true.
+	Enabled *bool `hcl:"enabled"`
+
+	// Persistent runs the task as soon as possible after a run was missed,
+	// such as while the system was down
+	Persistent bool `hcl:"persistent"`
+
+	// State is whether the task should be present
+	State State `hcl:"state" valid_values:"present,absent"`
+}
+
+// Prepare a new task
+func (p *Preparer) Prepare(render resource.Renderer) (resource.Task, error) {
+	if p.State == "" {
+		p.State = StatePresent
+	}
+
+	if p.State == StatePresent && (len(p.Triggers) == 0 || p.Command == "") {
+		return nil, fmt.Errorf("windows.scheduled_task \"triggers\" and \"command\" are required when state is \"present\"")
+	}
+
+	if p.Password != "" && p.User == "" {
+		return nil, fmt.Errorf("windows.scheduled_task \"password\" needs a \"user\"")
+	}
+
+	if strings.HasSuffix(p.Name, `\`) {
+		return nil, fmt.Errorf("windows.scheduled_task \"name\" cannot end in \"\\\"")
+	}
+
+	task := &Task{
+		Command:          p.Command,
+		Arguments:        p.Arguments,
+		WorkingDirectory: p.WorkingDirectory,
+		Description:      p.Description,
+		User:             p.User,
+		Password:         p.Password,
+		Highest:          p.Highest,
+		Enabled:          p.Enabled == nil || *p.Enabled,
+		Persistent:       p.Persistent,
+		State:            p.State,
+		exec:             exec.For(render),
+	}
+
+	if task.User == "" {
+		task.User = "SYSTEM"
+	}
+
+	name := `\` + strings.TrimPrefix(p.Name, `\`)
+	split := strings.LastIndex(name, `\`) + 1
+	task.Path, task.Name = name[:split], name[split:]
+
+	for _, text := range p.Triggers {
+		trigger, err := ParseTrigger(text)
+		if err != nil {
+			return nil, fmt.Errorf("windows.scheduled_task: %s", err)
+		}
+		task.Triggers = append(task.Triggers, trigger)
+	}
+
+	return task, nil
+}
+
+func init() {
+	registry.Register("windows.scheduled_task", (*Preparer)(nil), (*Task)(nil))
+	registry.RegisterPlatforms("windows.scheduled_task", "windows")
+}
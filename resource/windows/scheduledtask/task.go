@@ -0,0 +1,274 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduledtask
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/windows"
+)
+
+// State type for Task
+type State string
+
+const (
+	// StatePresent indicates the task should be registered
+	StatePresent State = "present"
+
+	// StateAbsent indicates the task should be removed
+	StateAbsent State = "absent"
+)
+
+// Task manages a task in the Windows Task Scheduler
+type Task struct {
+	resource.Status
+
+	// Path is the folder of the task, ending in a backslash, and Name its name
+	// in the folder
+	Path string
+	Name string
+
+	Triggers         []*Trigger
+	Command          string
+	Arguments        string
+	WorkingDirectory string
+	Description      string
+
+	User     string
+	Password string
+	Highest  bool
+
+	Enabled    bool
+	Persistent bool
+
+	State State
+
+	exec exec.Executor
+}
+
+// Check the registered task against the declared one
+func (t *Task) Check(resource.Renderer) (resource.TaskStatus, error) {
+	t.Status = resource.Status{}
+
+	live, err := t.export()
+	if err != nil {
+		t.RaiseLevel(resource.StatusFatal)
+		return t, err
+	}
+
+	switch {
+	case t.State == StateAbsent:
+		if live != nil {
+			t.RaiseLevel(resource.StatusWillChange)
+			t.AddDifference(t.fullName(), "<present>", "<absent>", "")
+		}
+		return t, nil
+
+	case live == nil:
+		t.RaiseLevel(resource.StatusWillChange)
+		t.AddDifference(t.fullName(), "<absent>", "<present>", "")
+		return t, nil
+	}
+
+	wanted := t.xml()
+	current := live.Principals.Principal
+
+	t.diff("triggers", strings.Join(live.Triggers.describe(), ", "), strings.Join(t.describeTriggers(), ", "))
+	t.diff("command", describeActions(live.Actions), t.Command)
+	if len(live.Actions.Exec) == 1 {
+		t.diff("arguments", live.Actions.Exec[0].Arguments, t.Arguments)
+		t.diff("working_directory", live.Actions.Exec[0].WorkingDirectory, t.WorkingDirectory)
+	}
+	t.diff("description", live.Description, t.Description)
+
+	if !windows.SameAccount(current.UserID, t.User) {
+		t.diff("user", windows.AccountName(current.UserID), t.User)
+	}
+	t.diff("logon_type", current.LogonType, wanted.Principals.Principal.LogonType)
+	t.diff("run_level", orDefault(current.RunLevel, "LeastPrivilege"), wanted.Principals.Principal.RunLevel)
+
+	t.diff("enabled", fmt.Sprint(boolOr(live.Settings.Enabled, true)), fmt.Sprint(t.Enabled))
+	t.diff("persistent", fmt.Sprint(boolOr(live.Settings.StartWhenAvailable, false)), fmt.Sprint(t.Persistent))
+
+	return t, nil
+}
+
+// Apply registers or removes the task. Registering replaces the whole task,
+// so it also sets the password.
+func (t *Task) Apply() (resource.TaskStatus, error) {
+	t.Status = resource.Status{}
+
+	var err error
+	if t.State == StateAbsent {
+		err = t.unregister()
+	} else {
+		err = t.register()
+	}
+
+	if err != nil {
+		t.RaiseLevel(resource.StatusFatal)
+		return t, err
+	}
+	return t, nil
+}
+
+func (t *Task) diff(field, current, wanted string) {
+	if current != wanted {
+		t.RaiseLevel(resource.StatusWillChange)
+		t.AddDifference(field, current, wanted, "")
+	}
+}
+
+// export reads the registered task, or returns nil if there is none
+func (t *Task) export() (*taskXML, error) {
+	selector := fmt.Sprintf("-TaskPath %s -TaskName %s", windows.Quote(t.Path), windows.Quote(t.Name))
+
+	out, _, err := windows.Run(t.exec, windows.PowerShell(fmt.Sprintf(
+		"$t = Get-ScheduledTask %s -ErrorAction SilentlyContinue; if ($t) { Export-ScheduledTask %s } else { 'NotFound' }",
+		selector, selector,
+	)))
+	if err != nil {
+		return nil, fmt.Errorf("windows.scheduled_task: could not export %s: %s", t.fullName(), err)
+	}
+
+	out = strings.TrimSpace(out)
+	if out == "NotFound" {
+		return nil, nil
+	}
+
+	live, err := parseTaskXML(out)
+	if err != nil {
+		return nil, fmt.Errorf("windows.scheduled_task: could not parse %s: %s", t.fullName(), err)
+	}
+	return live, nil
+}
+
+func (t *Task) register() error {
+	content, err := t.xml().render()
+	if err != nil {
+		return err
+	}
+
+	script := fmt.Sprintf("Register-ScheduledTask -TaskPath %s -TaskName %s -Xml %s", windows.Quote(t.Path), windows.Quote(t.Name), windows.Quote(content))
+
+	// the password is read from stdin so that it isn't on the command line
+	if t.Password != "" {
+		script = "$password = [Console]::In.ReadLine(); " + script + " -User " + windows.Quote(t.User) + " -Password $password"
+	}
+
+	argv := windows.PowerShell(script + " -Force | Out-Null")
+	cmd := exec.NewCommand(argv[0], argv[1:]...)
+	if t.Password != "" {
+		cmd.Stdin = t.Password + "\n"
+	}
+
+	if _, _, err := windows.RunCommand(t.exec, cmd); err != nil {
+		return fmt.Errorf("windows.scheduled_task: could not register %s: %s", t.fullName(), err)
+	}
+
+	t.AddMessage(fmt.Sprintf("registered %s", t.fullName()))
+	return nil
+}
+
+func (t *Task) unregister() error {
+	_, _, err := windows.Run(t.exec, windows.PowerShell(fmt.Sprintf(
+		"Unregister-ScheduledTask -TaskPath %s -TaskName %s -Confirm:$false",
+		windows.Quote(t.Path), windows.Quote(t.Name),
+	)))
+	if err != nil {
+		return fmt.Errorf("windows.scheduled_task: could not remove %s: %s", t.fullName(), err)
+	}
+
+	t.AddMessage(fmt.Sprintf("removed %s", t.fullName()))
+	return nil
+}
+
+// xml is the declared task in the Task Scheduler schema
+func (t *Task) xml() *taskXML {
+	principal := principalXML{ID: "Author", UserID: t.User, LogonType: "S4U", RunLevel: "LeastPrivilege"}
+	if sid, ok := windows.BuiltinAccounts[strings.ToUpper(t.User)]; ok {
+		principal.UserID = sid
+		principal.LogonType = "ServiceAccount"
+	} else if t.Password != "" {
+		principal.LogonType = "Password"
+	}
+	if t.Highest {
+		principal.RunLevel = "HighestAvailable"
+	}
+
+	no := false
+	return &taskXML{
+		Version:     "1.2",
+		Namespace:   taskNamespace,
+		Description: t.Description,
+		Triggers:    triggersFor(t.Triggers),
+		Principals:  principalsXML{Principal: principal},
+		Settings: settingsXML{
+			DisallowStartIfOnBatteries: &no,
+			StopIfGoingOnBatteries:     &no,
+			StartWhenAvailable:         &t.Persistent,
+			Enabled:                    &t.Enabled,
+			MultipleInstancesPolicy:    "IgnoreNew",
+		},
+		Actions: actionsXML{
+			Context: "Author",
+			Exec: []execXML{{
+				Command:          t.Command,
+				Arguments:        t.Arguments,
+				WorkingDirectory: t.WorkingDirectory,
+			}},
+		},
+	}
+}
+
+func (t *Task) describeTriggers() []string {
+	out := make([]string, len(t.Triggers))
+	for i, trigger := range t.Triggers {
+		out[i] = trigger.String()
+	}
+	sort.Strings(out)
+	return out
+}
+
+func (t *Task) fullName() string {
+	return t.Path + t.Name
+}
+
+// describeActions gives the command of a task with a single program to run,
+// or a summary of its actions otherwise
+func describeActions(actions actionsXML) string {
+	if len(actions.Exec) == 1 && len(actions.Other) == 0 {
+		return actions.Exec[0].Command
+	}
+	return fmt.Sprintf("<%d actions>", len(actions.Exec)+len(actions.Other))
+}
+
+func boolOr(value *bool, def bool) bool {
+	if value == nil {
+		return def
+	}
+	return *value
+}
+
+func orDefault(value, def string) string {
+	if value == "" {
+		return def
+	}
+	return value
+}
@@ -0,0 +1,339 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduledtask_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/fakeexec"
+	"github.com/asteris-llc/converge/helpers/fakerenderer"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/windows"
+	"github.com/asteris-llc/converge/resource/windows/scheduledtask"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	selector = `-TaskPath '\Converge\' -TaskName 'backup'`
+	export   = "$t = Get-ScheduledTask " + selector + " -ErrorAction SilentlyContinue; if ($t) { Export-ScheduledTask " + selector + " } else { 'NotFound' }"
+
+	// exported is what Export-ScheduledTask gives for the task declared by
+	// backup()
+	exported = `<?xml version="1.0" encoding="UTF-16"?>
+<Task version="1.2" xmlns="http://schemas.microsoft.com/windows/2004/02/mit/task">
+  <RegistrationInfo>
+    <URI>\Converge\backup</URI>
+    <Description>nightly backup</Description>
+  </RegistrationInfo>
+  <Triggers>
+    <CalendarTrigger>
+      <StartBoundary>2000-01-01T03:00:00</StartBoundary>
+      <Enabled>true</Enabled>
+      <ScheduleByDay>
+        <DaysInterval>1</DaysInterval>
+      </ScheduleByDay>
+    </CalendarTrigger>
+    <BootTrigger>
+      <Enabled>true</Enabled>
+    </BootTrigger>
+  </Triggers>
+  <Principals>
+    <Principal id="Author">
+      <UserId>S-1-5-18</UserId>
+      <LogonType>ServiceAccount</LogonType>
+      <RunLevel>HighestAvailable</RunLevel>
+    </Principal>
+  </Principals>
+  <Settings>
+    <MultipleInstancesPolicy>IgnoreNew</MultipleInstancesPolicy>
+    <DisallowStartIfOnBatteries>false</DisallowStartIfOnBatteries>
+    <StopIfGoingOnBatteries>false</StopIfGoingOnBatteries>
+    <AllowHardTerminate>true</AllowHardTerminate>
+    <StartWhenAvailable>true</StartWhenAvailable>
+    <IdleSettings>
+      <StopOnIdleEnd>true</StopOnIdleEnd>
+      <RestartOnIdle>false</RestartOnIdle>
+    </IdleSettings>
+    <Enabled>true</Enabled>
+    <ExecutionTimeLimit>PT72H</ExecutionTimeLimit>
+    <Priority>7</Priority>
+  </Settings>
+  <Actions Context="Author">
+    <Exec>
+      <Command>C:\backup\run.exe</Command>
+      <Arguments>--full</Arguments>
+    </Exec>
+  </Actions>
+</Task>`
+
+	// registered is the task XML rendered for backup()
+	registered = `<?xml version="1.0" encoding="UTF-16"?>
+<Task version="1.2" xmlns="http://schemas.microsoft.com/windows/2004/02/mit/task">
+  <RegistrationInfo>
+    <Description>nightly backup</Description>
+  </RegistrationInfo>
+  <Triggers>
+    <BootTrigger>
+      <Enabled>true</Enabled>
+    </BootTrigger>
+    <CalendarTrigger>
+      <StartBoundary>2000-01-01T03:00:00</StartBoundary>
+      <Enabled>true</Enabled>
+      <ScheduleByDay>
+        <DaysInterval>1</DaysInterval>
+      </ScheduleByDay>
+    </CalendarTrigger>
+  </Triggers>
+  <Principals>
+    <Principal id="Author">
+      <UserId>S-1-5-18</UserId>
+      <LogonType>ServiceAccount</LogonType>
+      <RunLevel>HighestAvailable</RunLevel>
+    </Principal>
+  </Principals>
+  <Settings>
+    <DisallowStartIfOnBatteries>false</DisallowStartIfOnBatteries>
+    <StopIfGoingOnBatteries>false</StopIfGoingOnBatteries>
+    <StartWhenAvailable>true</StartWhenAvailable>
+    <Enabled>true</Enabled>
+    <MultipleInstancesPolicy>IgnoreNew</MultipleInstancesPolicy>
+  </Settings>
+  <Actions Context="Author">
+    <Exec>
+      <Command>C:\backup\run.exe</Command>
+      <Arguments>--full</Arguments>
+    </Exec>
+  </Actions>
+</Task>`
+
+	register = "Register-ScheduledTask " + selector + " -Xml " + "'" + registered + "'" + " -Force | Out-Null"
+)
+
+// TestTaskInterface tests that Task is properly implemented
+func TestTaskInterface(t *testing.T) {
+	t.Parallel()
+
+	assert.Implements(t, (*resource.Task)(nil), new(scheduledtask.Task))
+	assert.Implements(t, (*resource.Resource)(nil), new(scheduledtask.Preparer))
+}
+
+func TestPrepare(t *testing.T) {
+	t.Parallel()
+
+	t.Run("folder and name", func(t *testing.T) {
+		task, err := backup().Prepare(fakerenderer.New())
+		require.NoError(t, err)
+		assert.Equal(t, `\Converge\`, task.(*scheduledtask.Task).Path)
+		assert.Equal(t, "backup", task.(*scheduledtask.Task).Name)
+		assert.Equal(t, "SYSTEM", task.(*scheduledtask.Task).User)
+		assert.True(t, task.(*scheduledtask.Task).Enabled)
+	})
+
+	t.Run("root folder", func(t *testing.T) {
+		task, err := (&scheduledtask.Preparer{Name: "backup", State: "absent"}).Prepare(fakerenderer.New())
+		require.NoError(t, err)
+		assert.Equal(t, `\`, task.(*scheduledtask.Task).Path)
+		assert.Equal(t, "backup", task.(*scheduledtask.Task).Name)
+	})
+
+	for name, test := range map[string]struct {
+		preparer *scheduledtask.Preparer
+		err      string
+	}{
+		"no triggers": {
+			&scheduledtask.Preparer{Name: "backup", Command: "run.exe"},
+			`windows.scheduled_task "triggers" and "command" are required when state is "present"`,
+		},
+		"bad trigger": {
+			&scheduledtask.Preparer{Name: "backup", Command: "run.exe", Triggers: []string{"hourly"}},
+			`windows.scheduled_task: trigger "hourly" must be "boot", "logon", "daily at HH:MM", "weekly on Mon,Thu at HH:MM", or "every DURATION"`,
+		},
+		"password without user": {
+			&scheduledtask.Preparer{Name: "backup", Command: "run.exe", Triggers: []string{"boot"}, Password: "secret"},
+			`windows.scheduled_task "password" needs a "user"`,
+		},
+		"folder without name": {
+			&scheduledtask.Preparer{Name: `\Converge\`, State: "absent"},
+			`windows.scheduled_task "name" cannot end in "\"`,
+		},
+	} {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			_, err := test.preparer.Prepare(fakerenderer.New())
+			assert.EqualError(t, err, test.err)
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	t.Parallel()
+
+	t.Run("registered", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect(windows.PowerShell(export)...).Return(exported+"\r\n", 0)
+
+		status, err := prepare(t, fake, backup()).Check(fakerenderer.New())
+
+		require.NoError(t, err)
+		assert.False(t, status.HasChanges(), "%v", status.Diffs())
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect(windows.PowerShell(export)...).Return("NotFound\r\n", 0)
+
+		status, err := prepare(t, fake, backup()).Check(fakerenderer.New())
+
+		require.NoError(t, err)
+		assert.Equal(t, "<absent>", status.Diffs()[`\Converge\backup`].Original())
+		assert.Equal(t, "<present>", status.Diffs()[`\Converge\backup`].Current())
+	})
+
+	t.Run("changed", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect(windows.PowerShell(export)...).Return(exported, 0)
+
+		p := backup()
+		p.Triggers = []string{"weekly on Sun at 03:00"}
+		p.User = `CORP\backup`
+		p.Password = "secret"
+		p.Highest = false
+		p.Enabled = new(bool)
+
+		status, err := prepare(t, fake, p).Check(fakerenderer.New())
+
+		require.NoError(t, err)
+		diffs := status.Diffs()
+		assert.Equal(t, "boot, daily at 03:00", diffs["triggers"].Original())
+		assert.Equal(t, "weekly on Sun at 03:00", diffs["triggers"].Current())
+		assert.Equal(t, "SYSTEM", diffs["user"].Original())
+		assert.Equal(t, `CORP\backup`, diffs["user"].Current())
+		assert.Equal(t, "Password", diffs["logon_type"].Current())
+		assert.Equal(t, "LeastPrivilege", diffs["run_level"].Current())
+		assert.Equal(t, "false", diffs["enabled"].Current())
+		assert.NotContains(t, diffs, "command")
+	})
+
+	t.Run("user without domain", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect(windows.PowerShell(export)...).Return(strings.Replace(exported, "S-1-5-18", `CORP\backup`, 1), 0)
+
+		p := backup()
+		p.User = "backup"
+
+		status, err := prepare(t, fake, p).Check(fakerenderer.New())
+
+		require.NoError(t, err)
+		assert.NotContains(t, status.Diffs(), "user")
+	})
+
+	t.Run("absent", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect(windows.PowerShell(export)...).Return(exported, 0)
+
+		status, err := prepare(t, fake, &scheduledtask.Preparer{Name: `\Converge\backup`, State: "absent"}).Check(fakerenderer.New())
+
+		require.NoError(t, err)
+		assert.Equal(t, "<absent>", status.Diffs()[`\Converge\backup`].Current())
+	})
+}
+
+func TestApply(t *testing.T) {
+	t.Parallel()
+
+	t.Run("register", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect(windows.PowerShell(register)...)
+
+		_, err := prepare(t, fake, backup()).Apply()
+
+		require.NoError(t, err)
+		fake.AssertExpectations(t)
+		assert.Empty(t, fake.Calls()[0].Stdin)
+	})
+
+	t.Run("with password", func(t *testing.T) {
+		fake := fakeexec.New()
+
+		p := backup()
+		p.User = `CORP\backup`
+		p.Password = "secret"
+
+		xml := strings.NewReplacer(
+			"<UserId>S-1-5-18</UserId>", `<UserId>CORP\backup</UserId>`,
+			"<LogonType>ServiceAccount</LogonType>", "<LogonType>Password</LogonType>",
+		).Replace(registered)
+
+		// the password is read from stdin, so that it isn't on the command line
+		fake.Expect(windows.PowerShell("$password = [Console]::In.ReadLine(); Register-ScheduledTask " + selector + " -Xml " + windows.Quote(xml) + ` -User 'CORP\backup' -Password $password -Force | Out-Null`)...)
+
+		_, err := prepare(t, fake, p).Apply()
+
+		require.NoError(t, err)
+		fake.AssertExpectations(t)
+		assert.Equal(t, "secret\n", fake.Calls()[0].Stdin)
+	})
+
+	t.Run("remove", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect(windows.PowerShell("Unregister-ScheduledTask " + selector + " -Confirm:$false")...)
+
+		_, err := prepare(t, fake, &scheduledtask.Preparer{Name: `\Converge\backup`, State: "absent"}).Apply()
+
+		require.NoError(t, err)
+		fake.AssertExpectations(t)
+	})
+
+	t.Run("failure", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect(windows.PowerShell(register)...).Return("", 1).Stderr("Access is denied.")
+
+		_, err := prepare(t, fake, backup()).Apply()
+
+		assert.EqualError(t, err, `windows.scheduled_task: could not register \Converge\backup: powershell.exe: exit status 1: Access is denied.`)
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect(windows.PowerShell(export)...).Return(registered, 0)
+
+		status, err := prepare(t, fake, backup()).Check(fakerenderer.New())
+
+		require.NoError(t, err)
+		assert.False(t, status.HasChanges(), "%v", status.Diffs())
+	})
+}
+
+func backup() *scheduledtask.Preparer {
+	enabled := true
+	return &scheduledtask.Preparer{
+		Name:        `\Converge\backup`,
+		Triggers:    []string{"daily at 03:00", "boot"},
+		Command:     `C:\backup\run.exe`,
+		Arguments:   "--full",
+		Description: "nightly backup",
+		Highest:     true,
+		Enabled:     &enabled,
+		Persistent:  true,
+	}
+}
+
+func prepare(t *testing.T, fake *fakeexec.Executor, p *scheduledtask.Preparer) *scheduledtask.Task {
+	task, err := p.Prepare(&resource.ExecRenderer{Renderer: fakerenderer.New(), Exec: fake})
+	require.NoError(t, err)
+	return task.(*scheduledtask.Task)
+}
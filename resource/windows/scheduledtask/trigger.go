@@ -0,0 +1,215 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduledtask
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Trigger kinds
+const (
+	TriggerBoot   = "boot"
+	TriggerLogon  = "logon"
+	TriggerDaily  = "daily"
+	TriggerWeekly = "weekly"
+	TriggerEvery  = "every"
+)
+
+// weekdays are the days of a weekly trigger, in the order they are written,
+// with the element names the task XML uses for them
+var weekdays = []struct{ short, long string }{
+	{"Mon", "Monday"},
+	{"Tue", "Tuesday"},
+	{"Wed", "Wednesday"},
+	{"Thu", "Thursday"},
+	{"Fri", "Friday"},
+	{"Sat", "Saturday"},
+	{"Sun", "Sunday"},
+}
+
+var (
+	dailyRe    = regexp.MustCompile(`^daily at (\d{1,2}):(\d{2})$`)
+	weeklyRe   = regexp.MustCompile(`^weekly on ([A-Za-z,]+) at (\d{1,2}):(\d{2})$`)
+	everyRe    = regexp.MustCompile(`^every (\S+)$`)
+	iso8601Re  = regexp.MustCompile(`^P(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)S)?)?$`)
+	boundaryRe = regexp.MustCompile(`T(\d{2}):(\d{2})`)
+)
+
+// Trigger is when a task runs. It is written as one of:
+//
+//	boot
+//	logon
+//	daily at 03:00
+//	weekly on Mon,Thu at 03:00
+//	every 15m
+type Trigger struct {
+	Kind string
+
+	// Hour and Minute are the time of day of daily and weekly triggers
+	Hour, Minute int
+
+	// Days are the days of a weekly trigger, as three letter abbreviations
+	Days []string
+
+	// Interval is how often an "every" trigger repeats
+	Interval time.Duration
+}
+
+// ParseTrigger parses a trigger written in one of the forms Trigger accepts
+func ParseTrigger(text string) (*Trigger, error) {
+	text = strings.TrimSpace(text)
+
+	switch {
+	case text == TriggerBoot, text == TriggerLogon:
+		return &Trigger{Kind: text}, nil
+
+	case dailyRe.MatchString(text):
+		match := dailyRe.FindStringSubmatch(text)
+		t := &Trigger{Kind: TriggerDaily}
+		return t, t.setTime(text, match[1], match[2])
+
+	case weeklyRe.MatchString(text):
+		match := weeklyRe.FindStringSubmatch(text)
+		t := &Trigger{Kind: TriggerWeekly}
+
+		for _, day := range strings.Split(match[1], ",") {
+			if dayIndex(day) < 0 {
+				return nil, fmt.Errorf("trigger %q: unknown day %q, expected one of Mon, Tue, Wed, Thu, Fri, Sat or Sun", text, day)
+			}
+			t.Days = append(t.Days, day)
+		}
+		t.Days = sortDays(t.Days)
+
+		return t, t.setTime(text, match[2], match[3])
+
+	case everyRe.MatchString(text):
+		interval, err := time.ParseDuration(everyRe.FindStringSubmatch(text)[1])
+		if err != nil {
+			return nil, fmt.Errorf("trigger %q: %s", text, err)
+		}
+		if interval < time.Minute || interval > 31*24*time.Hour || interval%time.Minute != 0 {
+			return nil, fmt.Errorf("trigger %q: interval must be whole minutes between 1m and 744h", text)
+		}
+		return &Trigger{Kind: TriggerEvery, Interval: interval}, nil
+
+	default:
+		return nil, fmt.Errorf(`trigger %q must be "boot", "logon", "daily at HH:MM", "weekly on Mon,Thu at HH:MM", or "every DURATION"`, text)
+	}
+}
+
+// String writes the trigger in the form ParseTrigger accepts
+func (t *Trigger) String() string {
+	switch t.Kind {
+	case TriggerDaily:
+		return fmt.Sprintf("daily at %02d:%02d", t.Hour, t.Minute)
+	case TriggerWeekly:
+		return fmt.Sprintf("weekly on %s at %02d:%02d", strings.Join(t.Days, ","), t.Hour, t.Minute)
+	case TriggerEvery:
+		return "every " + shortDuration(t.Interval)
+	default:
+		return t.Kind
+	}
+}
+
+func (t *Trigger) setTime(text, hour, minute string) error {
+	t.Hour, _ = strconv.Atoi(hour)
+	t.Minute, _ = strconv.Atoi(minute)
+	if t.Hour > 23 || t.Minute > 59 {
+		return fmt.Errorf("trigger %q: %s:%s is not a time of day", text, hour, minute)
+	}
+	return nil
+}
+
+// startBoundary is when a trigger first fires. Task Scheduler needs a date,
+// but only the time of day matters here.
+func (t *Trigger) startBoundary() string {
+	return fmt.Sprintf("2000-01-01T%02d:%02d:00", t.Hour, t.Minute)
+}
+
+// setBoundary reads the time of day from a start boundary
+func (t *Trigger) setBoundary(boundary string) {
+	if match := boundaryRe.FindStringSubmatch(boundary); match != nil {
+		t.Hour, _ = strconv.Atoi(match[1])
+		t.Minute, _ = strconv.Atoi(match[2])
+	}
+}
+
+func dayIndex(day string) int {
+	for i, d := range weekdays {
+		if strings.EqualFold(day, d.short) || strings.EqualFold(day, d.long) {
+			return i
+		}
+	}
+	return -1
+}
+
+// sortDays puts days in week order, as abbreviations, without duplicates
+func sortDays(days []string) (out []string) {
+	seen := map[int]bool{}
+	for _, day := range days {
+		seen[dayIndex(day)] = true
+	}
+	for i, d := range weekdays {
+		if seen[i] {
+			out = append(out, d.short)
+		}
+	}
+	return out
+}
+
+// isoDuration writes a duration in the ISO 8601 form Task Scheduler uses
+func isoDuration(d time.Duration) string {
+	out := "PT"
+	if hours := d / time.Hour; hours > 0 {
+		out += fmt.Sprintf("%dH", hours)
+	}
+	if minutes := d % time.Hour / time.Minute; minutes > 0 || out == "PT" {
+		out += fmt.Sprintf("%dM", minutes)
+	}
+	return out
+}
+
+// parseISODuration reads a duration like "PT1H30M" or "P1D"
+func parseISODuration(text string) (time.Duration, bool) {
+	match := iso8601Re.FindStringSubmatch(text)
+	if match == nil || text == "P" || text == "PT" {
+		return 0, false
+	}
+
+	var out time.Duration
+	for i, unit := range []time.Duration{24 * time.Hour, time.Hour, time.Minute, time.Second} {
+		if match[i+1] != "" {
+			n, _ := strconv.Atoi(match[i+1])
+			out += time.Duration(n) * unit
+		}
+	}
+	return out, true
+}
+
+// shortDuration writes a duration without zero units, like "1h30m" or "15m"
+func shortDuration(d time.Duration) string {
+	out := d.String()
+	if strings.HasSuffix(out, "m0s") {
+		out = strings.TrimSuffix(out, "0s")
+	}
+	if strings.HasSuffix(out, "h0m") {
+		out = strings.TrimSuffix(out, "0m")
+	}
+	return out
+}
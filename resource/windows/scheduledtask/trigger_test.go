@@ -0,0 +1,66 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduledtask_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/asteris-llc/converge/resource/windows/scheduledtask"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTrigger(t *testing.T) {
+	t.Parallel()
+
+	for text, expected := range map[string]*scheduledtask.Trigger{
+		"boot":                       {Kind: scheduledtask.TriggerBoot},
+		"logon":                      {Kind: scheduledtask.TriggerLogon},
+		"daily at 3:05":              {Kind: scheduledtask.TriggerDaily, Hour: 3, Minute: 5},
+		"weekly on Thu,Mon at 23:00": {Kind: scheduledtask.TriggerWeekly, Hour: 23, Days: []string{"Mon", "Thu"}},
+		"every 90m":                  {Kind: scheduledtask.TriggerEvery, Interval: 90 * time.Minute},
+	} {
+		text, expected := text, expected
+		t.Run(text, func(t *testing.T) {
+			trigger, err := scheduledtask.ParseTrigger(text)
+			require.NoError(t, err)
+			assert.Equal(t, expected, trigger)
+		})
+	}
+
+	for text, msg := range map[string]string{
+		"hourly":                    `trigger "hourly" must be "boot", "logon", "daily at HH:MM", "weekly on Mon,Thu at HH:MM", or "every DURATION"`,
+		"daily at 24:00":            `trigger "daily at 24:00": 24:00 is not a time of day`,
+		"weekly on Mon,Fun at 1:00": `trigger "weekly on Mon,Fun at 1:00": unknown day "Fun", expected one of Mon, Tue, Wed, Thu, Fri, Sat or Sun`,
+		"every 30s":                 `trigger "every 30s": interval must be whole minutes between 1m and 744h`,
+	} {
+		text, msg := text, msg
+		t.Run(text, func(t *testing.T) {
+			_, err := scheduledtask.ParseTrigger(text)
+			assert.EqualError(t, err, msg)
+		})
+	}
+}
+
+func TestTriggerString(t *testing.T) {
+	t.Parallel()
+
+	for _, text := range []string{"boot", "daily at 03:05", "weekly on Mon,Thu at 23:00", "every 1h30m", "every 15m"} {
+		trigger, err := scheduledtask.ParseTrigger(text)
+		require.NoError(t, err)
+		assert.Equal(t, text, trigger.String())
+	}
+}
@@ -0,0 +1,263 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduledtask
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+const taskNamespace = "http://schemas.microsoft.com/windows/2004/02/mit/task"
+
+// taskXML is the part of the Task Scheduler schema that is managed. Exported
+// tasks have more elements, which are ignored.
+type taskXML struct {
+	XMLName     xml.Name      `xml:"Task"`
+	Version     string        `xml:"version,attr,omitempty"`
+	Namespace   string        `xml:"xmlns,attr,omitempty"`
+	Description string        `xml:"RegistrationInfo>Description,omitempty"`
+	Triggers    triggersXML   `xml:"Triggers"`
+	Principals  principalsXML `xml:"Principals"`
+	Settings    settingsXML   `xml:"Settings"`
+	Actions     actionsXML    `xml:"Actions"`
+}
+
+type triggersXML struct {
+	Boot     []simpleTriggerXML   `xml:"BootTrigger"`
+	Logon    []simpleTriggerXML   `xml:"LogonTrigger"`
+	Calendar []calendarTriggerXML `xml:"CalendarTrigger"`
+	Time     []timeTriggerXML     `xml:"TimeTrigger"`
+	Other    []otherXML           `xml:",any"`
+}
+
+type simpleTriggerXML struct {
+	Enabled *bool `xml:"Enabled"`
+}
+
+type calendarTriggerXML struct {
+	StartBoundary string     `xml:"StartBoundary"`
+	Enabled       *bool      `xml:"Enabled"`
+	ByDay         *byDayXML  `xml:"ScheduleByDay"`
+	ByWeek        *byWeekXML `xml:"ScheduleByWeek"`
+	ByMonth       *otherXML  `xml:"ScheduleByMonth"`
+	ByMonthDay    *otherXML  `xml:"ScheduleByMonthDayOfWeek"`
+}
+
+type byDayXML struct {
+	DaysInterval int `xml:"DaysInterval"`
+}
+
+type byWeekXML struct {
+	DaysOfWeek    daysXML `xml:"DaysOfWeek"`
+	WeeksInterval int     `xml:"WeeksInterval"`
+}
+
+type daysXML struct {
+	Days []otherXML `xml:",any"`
+}
+
+type timeTriggerXML struct {
+	StartBoundary string         `xml:"StartBoundary"`
+	Enabled       *bool          `xml:"Enabled"`
+	Repetition    *repetitionXML `xml:"Repetition"`
+}
+
+type repetitionXML struct {
+	Interval string `xml:"Interval"`
+}
+
+// otherXML is any element, kept by name
+type otherXML struct {
+	XMLName xml.Name
+}
+
+type principalsXML struct {
+	Principal principalXML `xml:"Principal"`
+}
+
+type principalXML struct {
+	ID        string `xml:"id,attr,omitempty"`
+	UserID    string `xml:"UserId,omitempty"`
+	LogonType string `xml:"LogonType,omitempty"`
+	RunLevel  string `xml:"RunLevel,omitempty"`
+}
+
+type settingsXML struct {
+	DisallowStartIfOnBatteries *bool  `xml:"DisallowStartIfOnBatteries"`
+	StopIfGoingOnBatteries     *bool  `xml:"StopIfGoingOnBatteries"`
+	StartWhenAvailable         *bool  `xml:"StartWhenAvailable"`
+	Enabled                    *bool  `xml:"Enabled"`
+	MultipleInstancesPolicy    string `xml:"MultipleInstancesPolicy,omitempty"`
+}
+
+type actionsXML struct {
+	Context string     `xml:"Context,attr,omitempty"`
+	Exec    []execXML  `xml:"Exec"`
+	Other   []otherXML `xml:",any"`
+}
+
+type execXML struct {
+	Command          string `xml:"Command"`
+	Arguments        string `xml:"Arguments,omitempty"`
+	WorkingDirectory string `xml:"WorkingDirectory,omitempty"`
+}
+
+// parseTaskXML reads an exported task
+func parseTaskXML(content string) (*taskXML, error) {
+	decoder := xml.NewDecoder(strings.NewReader(content))
+
+	// exported tasks declare UTF-16, but reach us already decoded
+	decoder.CharsetReader = func(charset string, input io.Reader) (io.Reader, error) {
+		return input, nil
+	}
+
+	out := new(taskXML)
+	if err := decoder.Decode(out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// render writes the task as XML
+func (t *taskXML) render() (string, error) {
+	var buf bytes.Buffer
+	buf.WriteString(`<?xml version="1.0" encoding="UTF-16"?>` + "\n")
+
+	encoder := xml.NewEncoder(&buf)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(t); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+// describe gives every trigger of the task, sorted, in the form
+// ParseTrigger accepts. Triggers that can't be written that way are described
+// by their element name.
+func (t *triggersXML) describe() []string {
+	var out []string
+
+	add := func(desc string, enabled *bool) {
+		if enabled != nil && !*enabled {
+			desc += " (disabled)"
+		}
+		out = append(out, desc)
+	}
+
+	for _, trigger := range t.Boot {
+		add(TriggerBoot, trigger.Enabled)
+	}
+
+	for _, trigger := range t.Logon {
+		add(TriggerLogon, trigger.Enabled)
+	}
+
+	for _, trigger := range t.Calendar {
+		parsed := &Trigger{}
+		parsed.setBoundary(trigger.StartBoundary)
+
+		switch {
+		case trigger.ByMonth != nil || trigger.ByMonthDay != nil:
+			add("<monthly CalendarTrigger>", trigger.Enabled)
+
+		case trigger.ByDay != nil && trigger.ByDay.DaysInterval <= 1:
+			parsed.Kind = TriggerDaily
+			add(parsed.String(), trigger.Enabled)
+
+		case trigger.ByWeek != nil && trigger.ByWeek.WeeksInterval <= 1:
+			parsed.Kind = TriggerWeekly
+			for _, day := range trigger.ByWeek.DaysOfWeek.Days {
+				parsed.Days = append(parsed.Days, day.XMLName.Local)
+			}
+			parsed.Days = sortDays(parsed.Days)
+			add(parsed.String(), trigger.Enabled)
+
+		case trigger.ByDay != nil:
+			add(fmt.Sprintf("every %d days at %02d:%02d", trigger.ByDay.DaysInterval, parsed.Hour, parsed.Minute), trigger.Enabled)
+
+		default:
+			add("<CalendarTrigger>", trigger.Enabled)
+		}
+	}
+
+	for _, trigger := range t.Time {
+		if trigger.Repetition == nil {
+			add("once at "+trigger.StartBoundary, trigger.Enabled)
+			continue
+		}
+
+		interval, ok := parseISODuration(trigger.Repetition.Interval)
+		if !ok {
+			add("<TimeTrigger "+trigger.Repetition.Interval+">", trigger.Enabled)
+			continue
+		}
+		add((&Trigger{Kind: TriggerEvery, Interval: interval}).String(), trigger.Enabled)
+	}
+
+	for _, other := range t.Other {
+		out = append(out, "<"+other.XMLName.Local+">")
+	}
+
+	sort.Strings(out)
+	return out
+}
+
+// triggersFor writes triggers as task XML
+func triggersFor(triggers []*Trigger) triggersXML {
+	var out triggersXML
+	enabled := true
+
+	for _, trigger := range triggers {
+		switch trigger.Kind {
+		case TriggerBoot:
+			out.Boot = append(out.Boot, simpleTriggerXML{Enabled: &enabled})
+
+		case TriggerLogon:
+			out.Logon = append(out.Logon, simpleTriggerXML{Enabled: &enabled})
+
+		case TriggerDaily:
+			out.Calendar = append(out.Calendar, calendarTriggerXML{
+				StartBoundary: trigger.startBoundary(),
+				Enabled:       &enabled,
+				ByDay:         &byDayXML{DaysInterval: 1},
+			})
+
+		case TriggerWeekly:
+			week := &byWeekXML{WeeksInterval: 1}
+			for _, day := range trigger.Days {
+				week.DaysOfWeek.Days = append(week.DaysOfWeek.Days, otherXML{XMLName: xml.Name{Local: weekdays[dayIndex(day)].long}})
+			}
+			out.Calendar = append(out.Calendar, calendarTriggerXML{
+				StartBoundary: trigger.startBoundary(),
+				Enabled:       &enabled,
+				ByWeek:        week,
+			})
+
+		case TriggerEvery:
+			out.Time = append(out.Time, timeTriggerXML{
+				StartBoundary: (&Trigger{}).startBoundary(),
+				Enabled:       &enabled,
+				Repetition:    &repetitionXML{Interval: isoDuration(trigger.Interval)},
+			})
+		}
+	}
+
+	return out
+}
@@ -0,0 +1,142 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/asteris-llc/converge/load/registry"
+	"github.com/asteris-llc/converge/resource"
+)
+
+// Preparer for Windows services
+//
+// Service manages an installed Windows service through the service control
+// manager: when it starts, whether it is running, the account it runs as, and
+// what happens when it fails. Settings that aren't given are left as they are.
+// The service must already be installed.
+type Preparer struct {
+	// Name is the name of the service, as opposed to its display name
+	Name string `hcl:"name" required:"true" nonempty:"true"`
+
+	// StartMode is when the service is started
+	StartMode StartMode `hcl:"start_mode" valid_values:"auto,manual,disabled"`
+
+	// State is whether the service should be running. A disabled service
+	// can't be running.
+	State State `hcl:"state" valid_values:"running,stopped"`
+
+	// Username is the account the service runs as, such as
+	// `NT AUTHORITY\LocalService` or `.\svc-app`. If the service is running
+	// when the account changes, it is restarted.
+	Username string `hcl:"username"`
+
+	// Password is the password of the account. It can't be read back from the
+	// system, so it is only set when the account changes. It is usually set
+	// from a param so that it doesn't need to be written in the module.
+	Password string `hcl:"password"`
+
+	// RecoveryActions are taken on the first, second, and later failures of
+	// the service, in turn. The last is repeated for every failure after it.
+	RecoveryActions []string `hcl:"recovery_actions"`
+
+	// RecoveryDelay is how long to wait after a failure before taking the
+	// action. Defaults to "1m".
+	RecoveryDelay string `hcl:"recovery_delay" doc_type:"duration string"`
+
+	// RecoveryReset is how long the service must run without failing before
+	// the failure count goes back to zero. Defaults to "24h".
+	RecoveryReset string `hcl:"recovery_reset" doc_type:"duration string"`
+}
+
+// Prepare a new task
+func (p *Preparer) Prepare(render resource.Renderer) (resource.Task, error) {
+	if p.State == StateRunning && p.StartMode == StartDisabled {
+		return nil, fmt.Errorf("windows.service %q cannot be running when disabled", p.Name)
+	}
+
+	if p.Password != "" && p.Username == "" {
+		return nil, fmt.Errorf("windows.service \"password\" needs a \"username\"")
+	}
+
+	recovery, err := p.recovery()
+	if err != nil {
+		return nil, err
+	}
+
+	svc := New(&SCM{})
+	svc.Name = p.Name
+	svc.StartMode = p.StartMode
+	svc.State = p.State
+	svc.Username = p.Username
+	svc.Password = p.Password
+	svc.Recovery = recovery
+
+	return svc, nil
+}
+
+// recovery gets the recovery settings, or nil if none are given
+func (p *Preparer) recovery() (*Recovery, error) {
+	if len(p.RecoveryActions) == 0 {
+		if p.RecoveryDelay != "" || p.RecoveryReset != "" {
+			return nil, fmt.Errorf("windows.service \"recovery_delay\" and \"recovery_reset\" need \"recovery_actions\"")
+		}
+		return nil, nil
+	}
+
+	var err error
+	out := &Recovery{Delay: time.Minute, Reset: 24 * time.Hour}
+
+	for _, action := range p.RecoveryActions {
+		switch Action(action) {
+		case ActionNone, ActionRestart, ActionReboot:
+			out.Actions = append(out.Actions, Action(action))
+		default:
+			return nil, fmt.Errorf("windows.service \"recovery_actions\" must be one of none, restart or reboot, not %q", action)
+		}
+	}
+
+	if out.Delay, err = parseDuration("recovery_delay", p.RecoveryDelay, out.Delay); err != nil {
+		return nil, err
+	}
+	if out.Reset, err = parseDuration("recovery_reset", p.RecoveryReset, out.Reset); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// parseDuration parses the value of a duration field, or returns the default
+// if it is empty
+func parseDuration(field, value string, def time.Duration) (time.Duration, error) {
+	if value == "" {
+		return def, nil
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration < 0 {
+		return 0, fmt.Errorf("windows.service %q must be a duration like \"1m\", not %q", field, value)
+	}
+	return duration, nil
+}
+
+// HostOnly marks windows.service as unable to run in a target, since it talks to the local service control manager
+func (p *Preparer) HostOnly() {}
+
+func init() {
+	registry.Register("windows.service", (*Preparer)(nil), (*Service)(nil))
+	registry.RegisterPlatforms("windows.service", "windows")
+}
@@ -0,0 +1,105 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/asteris-llc/converge/helpers/fakerenderer"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/windows/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestPreparerInterface tests that the Preparer interface is properly implemented
+func TestPreparerInterface(t *testing.T) {
+	t.Parallel()
+
+	assert.Implements(t, (*resource.Resource)(nil), new(service.Preparer))
+}
+
+func TestPreparerPrepare(t *testing.T) {
+	t.Parallel()
+
+	t.Run("valid", func(t *testing.T) {
+		p := &service.Preparer{
+			Name:            "app",
+			StartMode:       service.StartAuto,
+			State:           service.StateRunning,
+			Username:        `.\svc-app`,
+			Password:        "secret",
+			RecoveryActions: []string{"restart", "reboot"},
+			RecoveryDelay:   "30s",
+		}
+
+		task, err := p.Prepare(fakerenderer.New())
+		require.NoError(t, err)
+
+		svc := task.(*service.Service)
+		assert.Equal(t, "app", svc.Name)
+		assert.Equal(t, `.\svc-app`, svc.Username)
+		assert.Equal(
+			t,
+			&service.Recovery{
+				Actions: []service.Action{service.ActionRestart, service.ActionReboot},
+				Delay:   30 * time.Second,
+				Reset:   24 * time.Hour,
+			},
+			svc.Recovery,
+		)
+	})
+
+	t.Run("no recovery", func(t *testing.T) {
+		task, err := (&service.Preparer{Name: "app"}).Prepare(fakerenderer.New())
+		require.NoError(t, err)
+		assert.Nil(t, task.(*service.Service).Recovery)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for name, test := range map[string]struct {
+			preparer *service.Preparer
+			err      string
+		}{
+			"running when disabled": {
+				&service.Preparer{Name: "app", StartMode: service.StartDisabled, State: service.StateRunning},
+				`windows.service "app" cannot be running when disabled`,
+			},
+			"password without username": {
+				&service.Preparer{Name: "app", Password: "secret"},
+				`windows.service "password" needs a "username"`,
+			},
+			"unknown action": {
+				&service.Preparer{Name: "app", RecoveryActions: []string{"restart", "explode"}},
+				`windows.service "recovery_actions" must be one of none, restart or reboot, not "explode"`,
+			},
+			"bad delay": {
+				&service.Preparer{Name: "app", RecoveryActions: []string{"restart"}, RecoveryDelay: "soon"},
+				`windows.service "recovery_delay" must be a duration like "1m", not "soon"`,
+			},
+			"delay without actions": {
+				&service.Preparer{Name: "app", RecoveryReset: "1h"},
+				`windows.service "recovery_delay" and "recovery_reset" need "recovery_actions"`,
+			},
+		} {
+			test := test
+			t.Run(name, func(t *testing.T) {
+				_, err := test.preparer.Prepare(fakerenderer.New())
+				assert.EqualError(t, err, test.err)
+			})
+		}
+	})
+}
@@ -0,0 +1,50 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !windows
+
+package service
+
+// SCM implements Manager on systems without a service control manager
+type SCM struct{}
+
+// Query implementation for systems which are not supported
+func (m *SCM) Query(name string) (*Config, error) {
+	return nil, ErrUnsupported
+}
+
+// SetStartMode implementation for systems which are not supported
+func (m *SCM) SetStartMode(name string, mode StartMode) error {
+	return ErrUnsupported
+}
+
+// SetCredentials implementation for systems which are not supported
+func (m *SCM) SetCredentials(name, username, password string) error {
+	return ErrUnsupported
+}
+
+// SetRecovery implementation for systems which are not supported
+func (m *SCM) SetRecovery(name string, recovery *Recovery) error {
+	return ErrUnsupported
+}
+
+// Start implementation for systems which are not supported
+func (m *SCM) Start(name string) error {
+	return ErrUnsupported
+}
+
+// Stop implementation for systems which are not supported
+func (m *SCM) Stop(name string) error {
+	return ErrUnsupported
+}
@@ -0,0 +1,271 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build windows
+
+package service
+
+import (
+	"fmt"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/mgr"
+)
+
+const errorServiceDoesNotExist = syscall.Errno(1060)
+
+// waitTimeout is how long Start and Stop wait for the service to change state
+const waitTimeout = time.Minute
+
+// actions for SC_ACTION, which aren't defined in x/sys/windows
+const (
+	scActionNone    = 0
+	scActionRestart = 1
+	scActionReboot  = 2
+)
+
+// serviceFailureActions is SERVICE_FAILURE_ACTIONS
+type serviceFailureActions struct {
+	ResetPeriod  uint32
+	RebootMsg    *uint16
+	Command      *uint16
+	ActionsCount uint32
+	Actions      *scAction
+}
+
+// scAction is SC_ACTION
+type scAction struct {
+	Type  uint32
+	Delay uint32
+}
+
+// SCM implements Manager with the Windows service control manager
+type SCM struct{}
+
+// Query returns the settings of a service
+func (m *SCM) Query(name string) (*Config, error) {
+	out := new(Config)
+
+	err := m.with(name, func(s *mgr.Service) error {
+		config, err := s.Config()
+		if err != nil {
+			return err
+		}
+
+		switch config.StartType {
+		case mgr.StartAutomatic:
+			out.StartMode = StartAuto
+		case mgr.StartManual:
+			out.StartMode = StartManual
+		case mgr.StartDisabled:
+			out.StartMode = StartDisabled
+		case windows.SERVICE_BOOT_START:
+			out.StartMode = "boot"
+		case windows.SERVICE_SYSTEM_START:
+			out.StartMode = "system"
+		}
+		out.Username = config.ServiceStartName
+
+		status, err := s.Query()
+		if err != nil {
+			return err
+		}
+
+		switch status.State {
+		case svc.Running, svc.StartPending, svc.ContinuePending:
+			out.State = StateRunning
+		case svc.Stopped, svc.StopPending:
+			out.State = StateStopped
+		default:
+			out.State = "paused"
+		}
+
+		out.Recovery, err = queryRecovery(s)
+		return err
+	})
+
+	return out, err
+}
+
+// SetStartMode changes when a service is started
+func (m *SCM) SetStartMode(name string, mode StartMode) error {
+	startTypes := map[StartMode]uint32{
+		StartAuto:     mgr.StartAutomatic,
+		StartManual:   mgr.StartManual,
+		StartDisabled: mgr.StartDisabled,
+	}
+
+	startType, ok := startTypes[mode]
+	if !ok {
+		return fmt.Errorf("unknown start mode %q", mode)
+	}
+
+	return m.with(name, func(s *mgr.Service) error {
+		config, err := s.Config()
+		if err != nil {
+			return err
+		}
+
+		config.StartType = startType
+		return s.UpdateConfig(config)
+	})
+}
+
+// SetCredentials changes the account a service runs as
+func (m *SCM) SetCredentials(name, username, password string) error {
+	return m.with(name, func(s *mgr.Service) error {
+		config, err := s.Config()
+		if err != nil {
+			return err
+		}
+
+		config.ServiceStartName = username
+		config.Password = password
+		return s.UpdateConfig(config)
+	})
+}
+
+// SetRecovery changes what happens when a service fails
+func (m *SCM) SetRecovery(name string, recovery *Recovery) error {
+	types := map[Action]uint32{
+		ActionNone:    scActionNone,
+		ActionRestart: scActionRestart,
+		ActionReboot:  scActionReboot,
+	}
+
+	actions := make([]scAction, len(recovery.Actions))
+	for i, action := range recovery.Actions {
+		actions[i] = scAction{
+			Type:  types[action],
+			Delay: uint32(recovery.Delay / time.Millisecond),
+		}
+	}
+
+	info := serviceFailureActions{
+		ResetPeriod:  uint32(recovery.Reset / time.Second),
+		ActionsCount: uint32(len(actions)),
+	}
+	if len(actions) > 0 {
+		info.Actions = &actions[0]
+	}
+
+	return m.with(name, func(s *mgr.Service) error {
+		return windows.ChangeServiceConfig2(s.Handle, windows.SERVICE_CONFIG_FAILURE_ACTIONS, (*byte)(unsafe.Pointer(&info)))
+	})
+}
+
+// Start starts a service and waits for it to be running
+func (m *SCM) Start(name string) error {
+	return m.with(name, func(s *mgr.Service) error {
+		if err := s.Start(); err != nil {
+			return err
+		}
+		return wait(s, svc.Running)
+	})
+}
+
+// Stop stops a service and waits for it to be stopped
+func (m *SCM) Stop(name string) error {
+	return m.with(name, func(s *mgr.Service) error {
+		if _, err := s.Control(svc.Stop); err != nil {
+			return err
+		}
+		return wait(s, svc.Stopped)
+	})
+}
+
+// with connects to the service control manager and opens a service for the
+// duration of fn
+func (m *SCM) with(name string, fn func(*mgr.Service) error) error {
+	manager, err := mgr.Connect()
+	if err != nil {
+		return err
+	}
+	defer manager.Disconnect()
+
+	s, err := manager.OpenService(name)
+	if err == errorServiceDoesNotExist {
+		return ErrNotInstalled
+	} else if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	return fn(s)
+}
+
+func queryRecovery(s *mgr.Service) (*Recovery, error) {
+	var needed uint32
+	err := windows.QueryServiceConfig2(s.Handle, windows.SERVICE_CONFIG_FAILURE_ACTIONS, nil, 0, &needed)
+	if err != windows.ERROR_INSUFFICIENT_BUFFER {
+		return nil, err
+	}
+
+	buf := make([]byte, needed)
+	if err := windows.QueryServiceConfig2(s.Handle, windows.SERVICE_CONFIG_FAILURE_ACTIONS, &buf[0], needed, &needed); err != nil {
+		return nil, err
+	}
+
+	info := (*serviceFailureActions)(unsafe.Pointer(&buf[0]))
+	if info.ActionsCount == 0 {
+		return nil, nil
+	}
+
+	actions := (*[1 << 16]scAction)(unsafe.Pointer(info.Actions))[:info.ActionsCount:info.ActionsCount]
+	out := &Recovery{
+		Reset: time.Duration(info.ResetPeriod) * time.Second,
+		Delay: time.Duration(actions[0].Delay) * time.Millisecond,
+	}
+
+	for _, action := range actions {
+		switch action.Type {
+		case scActionNone:
+			out.Actions = append(out.Actions, ActionNone)
+		case scActionRestart:
+			out.Actions = append(out.Actions, ActionRestart)
+		case scActionReboot:
+			out.Actions = append(out.Actions, ActionReboot)
+		default:
+			out.Actions = append(out.Actions, Action(fmt.Sprintf("action %d", action.Type)))
+		}
+	}
+
+	return out, nil
+}
+
+// wait polls a service until it is in the given state
+func wait(s *mgr.Service, state svc.State) error {
+	deadline := time.Now().Add(waitTimeout)
+
+	for {
+		status, err := s.Query()
+		if err != nil {
+			return err
+		}
+
+		if status.State == state {
+			return nil
+		}
+
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timed out after %s waiting for the service", waitTimeout)
+		}
+
+		time.Sleep(250 * time.Millisecond)
+	}
+}
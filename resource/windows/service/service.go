@@ -0,0 +1,287 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/asteris-llc/converge/resource"
+)
+
+// StartMode is when a service is started
+type StartMode string
+
+const (
+	// StartAuto starts the service when the system boots
+	StartAuto StartMode = "auto"
+
+	// StartManual starts the service only when asked to
+	StartManual StartMode = "manual"
+
+	// StartDisabled keeps the service from being started
+	StartDisabled StartMode = "disabled"
+)
+
+// State is whether a service is running
+type State string
+
+const (
+	// StateRunning indicates the service should be running
+	StateRunning State = "running"
+
+	// StateStopped indicates the service should be stopped
+	StateStopped State = "stopped"
+)
+
+// Action is what the service control manager does when a service fails
+type Action string
+
+const (
+	// ActionNone leaves the service stopped
+	ActionNone Action = "none"
+
+	// ActionRestart starts the service again
+	ActionRestart Action = "restart"
+
+	// ActionReboot restarts the computer
+	ActionReboot Action = "reboot"
+)
+
+// Recovery is how the service control manager responds to a service failing
+type Recovery struct {
+	// Actions are taken on the first, second, and later failures in turn. The
+	// last is repeated for every failure after it.
+	Actions []Action
+
+	// Delay is how long to wait before taking an action
+	Delay time.Duration
+
+	// Reset is how long the service must run without failing before the
+	// count of failures goes back to zero
+	Reset time.Duration
+}
+
+// String describes the recovery settings
+func (r *Recovery) String() string {
+	if r == nil || len(r.Actions) == 0 {
+		return "<none>"
+	}
+
+	actions := make([]string, len(r.Actions))
+	for i, action := range r.Actions {
+		actions[i] = string(action)
+	}
+
+	return fmt.Sprintf("%s after %s, reset after %s", strings.Join(actions, ", "), r.Delay, r.Reset)
+}
+
+// Equal tells whether two sets of recovery settings are the same
+func (r *Recovery) Equal(other *Recovery) bool {
+	return r.String() == other.String()
+}
+
+// Config is the current settings of an installed service
+type Config struct {
+	// StartMode is when the service is started. Drivers may also be started
+	// at "boot" or by the "system".
+	StartMode StartMode
+
+	// State is whether the service is running. Services that are changing
+	// state are reported in the state they are moving to.
+	State State
+
+	// Username is the account the service runs as
+	Username string
+
+	Recovery *Recovery
+}
+
+// Manager reads and changes services through the service control manager
+type Manager interface {
+	// Query returns the settings of the named service, or ErrNotInstalled if
+	// there is no such service
+	Query(name string) (*Config, error)
+
+	SetStartMode(name string, mode StartMode) error
+	SetCredentials(name, username, password string) error
+	SetRecovery(name string, recovery *Recovery) error
+
+	// Start and Stop wait for the service to be running or stopped
+	Start(name string) error
+	Stop(name string) error
+}
+
+// ErrUnsupported is used when a system is not supported
+var ErrUnsupported = errors.New("windows.service: not supported on this system")
+
+// ErrNotInstalled is returned by Manager.Query when the service doesn't exist
+var ErrNotInstalled = errors.New("service is not installed")
+
+// Service manages the settings and state of an installed Windows service.
+// Fields left empty are not changed.
+type Service struct {
+	resource.Status
+
+	Name      string
+	StartMode StartMode
+	State     State
+	Username  string
+	Password  string
+	Recovery  *Recovery
+
+	manager Manager
+}
+
+// New returns a Service managed through the given Manager
+func New(manager Manager) *Service {
+	return &Service{manager: manager}
+}
+
+// Check the service settings against the declared ones
+func (s *Service) Check(resource.Renderer) (resource.TaskStatus, error) {
+	s.Status = resource.Status{}
+
+	current, err := s.query()
+	if err != nil {
+		s.RaiseLevel(resource.StatusFatal)
+		return s, err
+	}
+
+	if s.credentialsChanged(current) {
+		s.RaiseLevel(resource.StatusWillChange)
+		s.AddDifference("username", current.Username, s.Username, "")
+	}
+
+	if s.StartMode != "" && current.StartMode != s.StartMode {
+		s.RaiseLevel(resource.StatusWillChange)
+		s.AddDifference("start_mode", string(current.StartMode), string(s.StartMode), "")
+	}
+
+	if s.Recovery != nil && !current.Recovery.Equal(s.Recovery) {
+		s.RaiseLevel(resource.StatusWillChange)
+		s.AddDifference("recovery", current.Recovery.String(), s.Recovery.String(), "")
+	}
+
+	if s.State != "" && current.State != s.State {
+		s.RaiseLevel(resource.StatusWillChange)
+		s.AddDifference("state", string(current.State), string(s.State), "")
+	}
+
+	return s, nil
+}
+
+// Apply changes the settings that differ from the declared ones. A running
+// service whose account changed is restarted so that it runs as the new one.
+func (s *Service) Apply() (resource.TaskStatus, error) {
+	s.Status = resource.Status{}
+
+	if err := s.apply(); err != nil {
+		s.RaiseLevel(resource.StatusFatal)
+		return s, err
+	}
+
+	return s, nil
+}
+
+func (s *Service) apply() error {
+	current, err := s.query()
+	if err != nil {
+		return err
+	}
+
+	restart := false
+
+	if s.credentialsChanged(current) {
+		if err := s.manager.SetCredentials(s.Name, s.Username, s.Password); err != nil {
+			return fmt.Errorf("could not set the account of %s: %s", s.Name, err)
+		}
+		s.AddMessage(fmt.Sprintf("set %s to run as %s", s.Name, s.Username))
+		restart = current.State == StateRunning
+	}
+
+	if s.StartMode != "" && current.StartMode != s.StartMode {
+		if err := s.manager.SetStartMode(s.Name, s.StartMode); err != nil {
+			return fmt.Errorf("could not set the start mode of %s: %s", s.Name, err)
+		}
+		s.AddMessage(fmt.Sprintf("set start mode of %s to %s", s.Name, s.StartMode))
+	}
+
+	if s.Recovery != nil && !current.Recovery.Equal(s.Recovery) {
+		if err := s.manager.SetRecovery(s.Name, s.Recovery); err != nil {
+			return fmt.Errorf("could not set the recovery actions of %s: %s", s.Name, err)
+		}
+		s.AddMessage(fmt.Sprintf("set recovery of %s to %s", s.Name, s.Recovery))
+	}
+
+	state := s.State
+	if state == "" {
+		state = current.State
+	}
+
+	switch {
+	case state == StateStopped && current.State != StateStopped:
+		if err := s.manager.Stop(s.Name); err != nil {
+			return fmt.Errorf("could not stop %s: %s", s.Name, err)
+		}
+		s.AddMessage(fmt.Sprintf("stopped %s", s.Name))
+
+	case state == StateRunning && (current.State != StateRunning || restart):
+		if restart {
+			if err := s.manager.Stop(s.Name); err != nil {
+				return fmt.Errorf("could not stop %s: %s", s.Name, err)
+			}
+		}
+		if err := s.manager.Start(s.Name); err != nil {
+			return fmt.Errorf("could not start %s: %s", s.Name, err)
+		}
+		if restart {
+			s.AddMessage(fmt.Sprintf("restarted %s", s.Name))
+		} else {
+			s.AddMessage(fmt.Sprintf("started %s", s.Name))
+		}
+	}
+
+	return nil
+}
+
+func (s *Service) query() (*Config, error) {
+	current, err := s.manager.Query(s.Name)
+	if err == ErrUnsupported {
+		return nil, err
+	} else if err == ErrNotInstalled {
+		return nil, fmt.Errorf("windows.service: %s is not installed", s.Name)
+	} else if err != nil {
+		return nil, fmt.Errorf("windows.service: could not query %s: %s", s.Name, err)
+	}
+	return current, nil
+}
+
+// credentialsChanged tells whether the service should run as a different
+// account. Account names aren't case sensitive, and accounts on the local
+// computer may be given with or without the ".\" prefix. Passwords can't be
+// read back, so they are only set along with the account.
+func (s *Service) credentialsChanged(current *Config) bool {
+	if s.Username == "" {
+		return false
+	}
+
+	return !strings.EqualFold(
+		strings.TrimPrefix(current.Username, `.\`),
+		strings.TrimPrefix(s.Username, `.\`),
+	)
+}
@@ -0,0 +1,266 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/asteris-llc/converge/helpers/fakerenderer"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/windows/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeManager is a service control manager with a single service
+type fakeManager struct {
+	config *service.Config
+	err    error
+	calls  []string
+}
+
+func (m *fakeManager) Query(name string) (*service.Config, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	copied := *m.config
+	return &copied, nil
+}
+
+func (m *fakeManager) SetStartMode(name string, mode service.StartMode) error {
+	m.calls = append(m.calls, "start mode "+string(mode))
+	m.config.StartMode = mode
+	return nil
+}
+
+func (m *fakeManager) SetCredentials(name, username, password string) error {
+	m.calls = append(m.calls, "credentials "+username+" "+password)
+	m.config.Username = username
+	return nil
+}
+
+func (m *fakeManager) SetRecovery(name string, recovery *service.Recovery) error {
+	m.calls = append(m.calls, "recovery "+recovery.String())
+	m.config.Recovery = recovery
+	return nil
+}
+
+func (m *fakeManager) Start(name string) error {
+	m.calls = append(m.calls, "start")
+	m.config.State = service.StateRunning
+	return nil
+}
+
+func (m *fakeManager) Stop(name string) error {
+	m.calls = append(m.calls, "stop")
+	m.config.State = service.StateStopped
+	return nil
+}
+
+func newManager() *fakeManager {
+	return &fakeManager{config: &service.Config{
+		StartMode: service.StartManual,
+		State:     service.StateStopped,
+		Username:  "LocalSystem",
+	}}
+}
+
+// TestServiceInterface tests that Service is properly implemented
+func TestServiceInterface(t *testing.T) {
+	t.Parallel()
+
+	assert.Implements(t, (*resource.Task)(nil), new(service.Service))
+}
+
+func TestServiceCheck(t *testing.T) {
+	t.Parallel()
+
+	t.Run("no changes", func(t *testing.T) {
+		svc := service.New(newManager())
+		svc.Name = "app"
+		svc.StartMode = service.StartManual
+		svc.State = service.StateStopped
+		svc.Username = "localsystem"
+
+		status, err := svc.Check(fakerenderer.New())
+		require.NoError(t, err)
+		assert.False(t, status.HasChanges())
+	})
+
+	t.Run("unset fields are not managed", func(t *testing.T) {
+		svc := service.New(newManager())
+		svc.Name = "app"
+
+		status, err := svc.Check(fakerenderer.New())
+		require.NoError(t, err)
+		assert.False(t, status.HasChanges())
+	})
+
+	t.Run("changes", func(t *testing.T) {
+		svc := service.New(newManager())
+		svc.Name = "app"
+		svc.StartMode = service.StartAuto
+		svc.State = service.StateRunning
+		svc.Username = `.\svc-app`
+		svc.Recovery = &service.Recovery{Actions: []service.Action{service.ActionRestart}, Delay: time.Minute, Reset: time.Hour}
+
+		status, err := svc.Check(fakerenderer.New())
+		require.NoError(t, err)
+		assert.True(t, status.HasChanges())
+
+		diffs := status.Diffs()
+		assert.Equal(t, "manual", diffs["start_mode"].Original())
+		assert.Equal(t, "auto", diffs["start_mode"].Current())
+		assert.Equal(t, "stopped", diffs["state"].Original())
+		assert.Equal(t, "running", diffs["state"].Current())
+		assert.Equal(t, `.\svc-app`, diffs["username"].Current())
+		assert.Equal(t, "<none>", diffs["recovery"].Original())
+		assert.Equal(t, "restart after 1m0s, reset after 1h0m0s", diffs["recovery"].Current())
+	})
+
+	t.Run("local account prefix", func(t *testing.T) {
+		m := newManager()
+		m.config.Username = `.\svc-app`
+
+		svc := service.New(m)
+		svc.Name = "app"
+		svc.Username = "SVC-APP"
+
+		status, err := svc.Check(fakerenderer.New())
+		require.NoError(t, err)
+		assert.False(t, status.HasChanges())
+	})
+
+	t.Run("not installed", func(t *testing.T) {
+		m := newManager()
+		m.err = service.ErrNotInstalled
+
+		svc := service.New(m)
+		svc.Name = "app"
+
+		_, err := svc.Check(fakerenderer.New())
+		assert.EqualError(t, err, "windows.service: app is not installed")
+	})
+
+	t.Run("query error", func(t *testing.T) {
+		m := newManager()
+		m.err = errors.New("access denied")
+
+		svc := service.New(m)
+		svc.Name = "app"
+
+		status, err := svc.Check(fakerenderer.New())
+		assert.EqualError(t, err, "windows.service: could not query app: access denied")
+		assert.Equal(t, resource.StatusFatal, status.StatusCode())
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		m := newManager()
+		m.err = service.ErrUnsupported
+
+		svc := service.New(m)
+		svc.Name = "app"
+
+		_, err := svc.Check(fakerenderer.New())
+		assert.Equal(t, service.ErrUnsupported, err)
+	})
+}
+
+func TestServiceApply(t *testing.T) {
+	t.Parallel()
+
+	t.Run("changes", func(t *testing.T) {
+		m := newManager()
+
+		svc := service.New(m)
+		svc.Name = "app"
+		svc.StartMode = service.StartAuto
+		svc.State = service.StateRunning
+		svc.Username = `.\svc-app`
+		svc.Password = "secret"
+		svc.Recovery = &service.Recovery{Actions: []service.Action{service.ActionRestart, service.ActionNone}, Delay: time.Minute, Reset: time.Hour}
+
+		_, err := svc.Apply()
+		require.NoError(t, err)
+
+		assert.Equal(
+			t,
+			[]string{
+				`credentials .\svc-app secret`,
+				"start mode auto",
+				"recovery restart, none after 1m0s, reset after 1h0m0s",
+				"start",
+			},
+			m.calls,
+		)
+
+		status, err := svc.Check(fakerenderer.New())
+		require.NoError(t, err)
+		assert.False(t, status.HasChanges())
+	})
+
+	t.Run("stop", func(t *testing.T) {
+		m := newManager()
+		m.config.State = service.StateRunning
+
+		svc := service.New(m)
+		svc.Name = "app"
+		svc.State = service.StateStopped
+
+		_, err := svc.Apply()
+		require.NoError(t, err)
+		assert.Equal(t, []string{"stop"}, m.calls)
+	})
+
+	t.Run("restarts for new account", func(t *testing.T) {
+		m := newManager()
+		m.config.State = service.StateRunning
+
+		svc := service.New(m)
+		svc.Name = "app"
+		svc.Username = `.\svc-app`
+
+		status, err := svc.Apply()
+		require.NoError(t, err)
+		assert.Equal(t, []string{`credentials .\svc-app `, "stop", "start"}, m.calls)
+		assert.Contains(t, status.Messages(), "restarted app")
+	})
+
+	t.Run("stopped service is not started for new account", func(t *testing.T) {
+		m := newManager()
+
+		svc := service.New(m)
+		svc.Name = "app"
+		svc.Username = `.\svc-app`
+
+		_, err := svc.Apply()
+		require.NoError(t, err)
+		assert.Equal(t, []string{`credentials .\svc-app `}, m.calls)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		m := newManager()
+		m.err = errors.New("access denied")
+
+		svc := service.New(m)
+		svc.Name = "app"
+
+		status, err := svc.Apply()
+		assert.Error(t, err)
+		assert.Equal(t, resource.StatusFatal, status.StatusCode())
+	})
+}
@@ -0,0 +1,83 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package windows holds what the Windows resources share: running commands
+// whose exit codes report a pending reboot, quoting for PowerShell, and
+// matching account names.
+package windows
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+)
+
+// Exit codes that Windows installers use for success
+const (
+	// ExitRebootRequired is ERROR_SUCCESS_REBOOT_REQUIRED: the change was made
+	// but only takes effect after a reboot
+	ExitRebootRequired = 3010
+
+	// ExitRebootInitiated is ERROR_SUCCESS_REBOOT_INITIATED: the change was
+	// made and the installer started a reboot
+	ExitRebootInitiated = 1641
+)
+
+// Run runs a command, treating the exit codes in success as successful along
+// with 0, ExitRebootRequired, and ExitRebootInitiated. reboot is true when the
+// command exited with one of the reboot codes. Other exit codes are an error
+// with the command's output, since many Windows tools write their errors to
+// stdout.
+func Run(e exec.Executor, argv []string, success ...int) (out string, reboot bool, err error) {
+	return RunCommand(e, exec.NewCommand(argv[0], argv[1:]...), success...)
+}
+
+// RunCommand is Run for a command with input or an environment
+func RunCommand(e exec.Executor, cmd *exec.Command, success ...int) (out string, reboot bool, err error) {
+	result, err := e.Run(cmd)
+	if err != nil {
+		return "", false, err
+	}
+
+	switch result.ExitStatus {
+	case 0:
+		return result.Stdout, false, nil
+	case ExitRebootRequired, ExitRebootInitiated:
+		return result.Stdout, true, nil
+	}
+
+	for _, status := range success {
+		if result.ExitStatus == status {
+			return result.Stdout, false, nil
+		}
+	}
+
+	msg := fmt.Sprintf("%s: exit status %d", cmd.Name, result.ExitStatus)
+	if output := strings.TrimSpace(result.Stderr + "\n" + result.Stdout); output != "" {
+		msg += ": " + output
+	}
+	return result.Stdout, false, fmt.Errorf("%s", msg)
+}
+
+// PowerShell returns the command line that runs script with PowerShell,
+// without loading profiles or prompting
+func PowerShell(script string) []string {
+	return []string{"powershell.exe", "-NoProfile", "-NonInteractive", "-Command", script}
+}
+
+// Quote quotes a string for use as a literal in a PowerShell script
+func Quote(s string) string {
+	return "'" + strings.Replace(s, "'", "''", -1) + "'"
+}
@@ -0,0 +1,73 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package windows_test
+
+import (
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/fakeexec"
+	"github.com/asteris-llc/converge/resource/windows"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+
+	t.Run("success", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("setup.exe", "/quiet").Return("done\n", 0)
+
+		out, reboot, err := windows.Run(fake, []string{"setup.exe", "/quiet"})
+		require.NoError(t, err)
+		assert.Equal(t, "done\n", out)
+		assert.False(t, reboot)
+	})
+
+	t.Run("reboot", func(t *testing.T) {
+		for _, status := range []int{windows.ExitRebootRequired, windows.ExitRebootInitiated} {
+			fake := fakeexec.New()
+			fake.Expect("setup.exe").Return("", status)
+
+			_, reboot, err := windows.Run(fake, []string{"setup.exe"})
+			require.NoError(t, err)
+			assert.True(t, reboot, "exit status %d", status)
+		}
+	})
+
+	t.Run("extra success code", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("setup.exe").Return("", 1638)
+
+		_, reboot, err := windows.Run(fake, []string{"setup.exe"}, 1638)
+		require.NoError(t, err)
+		assert.False(t, reboot)
+	})
+
+	t.Run("failure", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("dism.exe").Return("Error: 0x800f080c\nFeature name Foo is unknown.\n", 87)
+
+		_, _, err := windows.Run(fake, []string{"dism.exe"})
+		assert.EqualError(t, err, "dism.exe: exit status 87: Error: 0x800f080c\nFeature name Foo is unknown.")
+	})
+}
+
+func TestQuote(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "'Web-Server'", windows.Quote("Web-Server"))
+	assert.Equal(t, "'it''s'", windows.Quote("it's"))
+}
@@ -0,0 +1,96 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import "github.com/asteris-llc/converge/helpers/exec"
+
+// Wrapped is embedded by tasks that wrap another task to change how it is
+// checked or applied. It passes the optional task interfaces through, so
+// wrapping a task doesn't change how the pipelines treat it. Lookups see the
+// fields of the wrapped task, since it is embedded.
+type Wrapped struct {
+	Task
+}
+
+// WatchReboots passes reboots on to the wrapped task if it watches them
+func (w Wrapped) WatchReboots(reboots map[string][]string) {
+	if watcher, ok := w.Task.(RebootWatcher); ok {
+		watcher.WatchReboots(reboots)
+	}
+}
+
+// StreamOutput passes the output function on to the wrapped task if it
+// streams output
+func (w Wrapped) StreamOutput(output func(stream, line string)) {
+	if streamer, ok := w.Task.(OutputStreamer); ok {
+		streamer.StreamOutput(output)
+	}
+}
+
+// IgnoredChanges returns the changes ignored by the wrapped task
+func (w Wrapped) IgnoredChanges() []string {
+	if ignorer, ok := w.Task.(ChangeIgnorer); ok {
+		return ignorer.IgnoredChanges()
+	}
+	return nil
+}
+
+// ManagedFiles returns the files managed by the wrapped task, if it manages
+// any
+func (w Wrapped) ManagedFiles() []string {
+	if manager, ok := w.Task.(FileManager); ok {
+		return manager.ManagedFiles()
+	}
+	return nil
+}
+
+// Executor returns the executor of the wrapped task, if it has one
+func (w Wrapped) Executor() exec.Executor {
+	if provider, ok := w.Task.(exec.Provider); ok {
+		return provider.Executor()
+	}
+	return nil
+}
+
+// Rollback rolls back the wrapped task if it supports it, and returns
+// ErrRollbackUnsupported otherwise
+func (w Wrapped) Rollback() (TaskStatus, error) {
+	if rollbacker, ok := w.Task.(Rollbacker); ok {
+		return rollbacker.Rollback()
+	}
+	return nil, ErrRollbackUnsupported
+}
+
+// OnFailure returns the "on_failure" setting of the wrapped task, if it has
+// one
+func (w Wrapped) OnFailure() string {
+	if handler, ok := w.Task.(FailureHandler); ok {
+		return handler.OnFailure()
+	}
+	return ""
+}
+
+// Canary returns whether the wrapped task is a canary
+func (w Wrapped) Canary() bool {
+	if canaried, ok := w.Task.(Canaried); ok {
+		return canaried.Canary()
+	}
+	return false
+}
+
+// Unwrap returns the wrapped task
+func (w Wrapped) Unwrap() Task {
+	return w.Task
+}
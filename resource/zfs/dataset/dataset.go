@@ -0,0 +1,155 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dataset
+
+import (
+	"fmt"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/zfs"
+	"github.com/pkg/errors"
+)
+
+// State type for Dataset
+type State string
+
+const (
+	// StatePresent indicates the dataset should be present
+	StatePresent State = "present"
+
+	// StateAbsent indicates the dataset should be absent
+	StateAbsent State = "absent"
+)
+
+// Dataset manages a ZFS filesystem or volume
+type Dataset struct {
+	resource.Status
+
+	Name       string
+	Type       string
+	Properties map[string]string
+	State      State
+
+	exec exec.Executor
+}
+
+// Check whether the dataset exists with the declared properties
+func (d *Dataset) Check(resource.Renderer) (resource.TaskStatus, error) {
+	d.Status = resource.Status{}
+
+	exists, changed, err := d.diff()
+	if err != nil {
+		d.RaiseLevel(resource.StatusFatal)
+		return d, err
+	}
+
+	switch {
+	case d.State == StateAbsent && exists:
+		d.RaiseLevel(resource.StatusWillChange)
+		d.AddDifference(d.Name, d.Type, "<absent>", "")
+
+	case d.State == StatePresent && !exists:
+		d.RaiseLevel(resource.StatusWillChange)
+		d.AddDifference(d.Name, "<absent>", d.Type, "")
+
+	case d.State == StatePresent:
+		for _, key := range zfs.Keys(changed) {
+			d.RaiseLevel(resource.StatusWillChange)
+			d.AddDifference(key, changed[key], d.Properties[key], "")
+		}
+	}
+
+	return d, nil
+}
+
+// Apply creates, updates, or destroys the dataset
+func (d *Dataset) Apply() (resource.TaskStatus, error) {
+	d.Status = resource.Status{}
+
+	exists, changed, err := d.diff()
+	if err != nil {
+		d.RaiseLevel(resource.StatusFatal)
+		return d, err
+	}
+
+	switch {
+	case d.State == StateAbsent:
+		if exists {
+			if err := exec.Run(d.exec, "zfs", "destroy", d.Name); err != nil {
+				d.RaiseLevel(resource.StatusFatal)
+				return d, errors.Wrapf(err, "cannot destroy %s", d.Name)
+			}
+			d.AddMessage("destroyed " + d.Name)
+		}
+
+	case !exists:
+		if err := exec.Run(d.exec, "zfs", d.createArgs()...); err != nil {
+			d.RaiseLevel(resource.StatusFatal)
+			return d, errors.Wrapf(err, "cannot create %s", d.Name)
+		}
+		d.AddMessage(fmt.Sprintf("created %s %s", d.Type, d.Name))
+
+	default:
+		for _, key := range zfs.Keys(changed) {
+			if err := exec.Run(d.exec, "zfs", "set", key+"="+d.Properties[key], d.Name); err != nil {
+				d.RaiseLevel(resource.StatusFatal)
+				return d, errors.Wrapf(err, "cannot set %s on %s", key, d.Name)
+			}
+			d.AddMessage(fmt.Sprintf("set %s=%s", key, d.Properties[key]))
+		}
+	}
+
+	return d, nil
+}
+
+// createArgs returns the arguments to zfs create. The size of a volume is
+// given with -V rather than as a property.
+func (d *Dataset) createArgs() []string {
+	properties := map[string]string{}
+	for key, value := range d.Properties {
+		if key != "volsize" {
+			properties[key] = value
+		}
+	}
+
+	args := append([]string{"create", "-p"}, zfs.Options(properties)...)
+	if d.Type == "volume" {
+		args = append(args, "-V", d.Properties["volsize"])
+	}
+	return append(args, d.Name)
+}
+
+// diff returns whether the dataset exists and the current values of the
+// properties that differ
+func (d *Dataset) diff() (bool, map[string]string, error) {
+	exists, err := zfs.Exists(d.exec, "zfs", d.Name)
+	if err != nil || !exists || d.State == StateAbsent {
+		return exists, nil, err
+	}
+
+	current, err := zfs.Get(d.exec, "zfs", d.Name, zfs.Keys(d.Properties))
+	if err != nil {
+		return true, nil, err
+	}
+
+	changed := map[string]string{}
+	for key, value := range d.Properties {
+		if current[key] != value {
+			changed[key] = current[key]
+		}
+	}
+	return true, changed, nil
+}
@@ -0,0 +1,157 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dataset_test
+
+import (
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/fakeexec"
+	"github.com/asteris-llc/converge/helpers/fakerenderer"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/zfs/dataset"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestDatasetInterface tests that Dataset is properly implemented
+func TestDatasetInterface(t *testing.T) {
+	t.Parallel()
+
+	assert.Implements(t, (*resource.Task)(nil), new(dataset.Dataset))
+	assert.Implements(t, (*resource.Resource)(nil), new(dataset.Preparer))
+}
+
+// TestCheck tests the possible cases Check handles
+func TestCheck(t *testing.T) {
+	t.Parallel()
+
+	t.Run("missing", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("zfs", "list", "-H", "-o", "name", "tank/home").Return("", 1)
+
+		d := prepare(t, fake, &dataset.Preparer{Mountpoint: "/home"})
+		status, err := d.Check(fakerenderer.New())
+
+		require.NoError(t, err)
+		assert.True(t, status.HasChanges())
+		assert.Equal(t, "filesystem", status.Diffs()["tank/home"].Current())
+	})
+
+	t.Run("quota", func(t *testing.T) {
+		d := prepare(t, existing("compression\tlz4\nquota\tnone\n"), &dataset.Preparer{
+			Quota:      "50G",
+			Properties: map[string]string{"compression": "lz4"},
+		})
+		status, err := d.Check(fakerenderer.New())
+
+		require.NoError(t, err)
+		assert.True(t, status.HasChanges())
+		assert.Len(t, status.Diffs(), 1)
+		assert.Equal(t, "50G", status.Diffs()["quota"].Current())
+	})
+
+	t.Run("absent", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("zfs", "list", "-H", "-o", "name", "tank/home").Return("tank/home\n", 0)
+
+		d := prepare(t, fake, &dataset.Preparer{State: dataset.StateAbsent})
+		status, err := d.Check(fakerenderer.New())
+
+		require.NoError(t, err)
+		assert.Equal(t, "<absent>", status.Diffs()["tank/home"].Current())
+	})
+}
+
+// TestApply tests creating, updating, and destroying datasets
+func TestApply(t *testing.T) {
+	t.Parallel()
+
+	t.Run("create filesystem", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("zfs", "list", "-H", "-o", "name", "tank/home").Return("", 1)
+		fake.Expect("zfs", "create", "-p", "-o", "mountpoint=/home", "-o", "quota=50G", "tank/home")
+
+		_, err := prepare(t, fake, &dataset.Preparer{Mountpoint: "/home", Quota: "50G"}).Apply()
+		require.NoError(t, err)
+		fake.AssertExpectations(t)
+	})
+
+	t.Run("create volume", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("zfs", "list", "-H", "-o", "name", "tank/home").Return("", 1)
+		fake.Expect("zfs", "create", "-p", "-o", "volblocksize=16K", "-V", "10G", "tank/home")
+
+		_, err := prepare(t, fake, &dataset.Preparer{
+			Type:       "volume",
+			Size:       "10G",
+			Properties: map[string]string{"volblocksize": "16K"},
+		}).Apply()
+		require.NoError(t, err)
+		fake.AssertExpectations(t)
+	})
+
+	t.Run("set", func(t *testing.T) {
+		fake := existing("compression\toff\nquota\t50G\n")
+		fake.Expect("zfs", "set", "compression=lz4", "tank/home")
+
+		_, err := prepare(t, fake, &dataset.Preparer{
+			Quota:      "50G",
+			Properties: map[string]string{"compression": "lz4"},
+		}).Apply()
+		require.NoError(t, err)
+		fake.AssertExpectations(t)
+	})
+
+	t.Run("destroy", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("zfs", "list", "-H", "-o", "name", "tank/home").Return("tank/home\n", 0)
+		fake.Expect("zfs", "destroy", "tank/home")
+
+		_, err := prepare(t, fake, &dataset.Preparer{State: dataset.StateAbsent}).Apply()
+		require.NoError(t, err)
+		fake.AssertExpectations(t)
+	})
+}
+
+// TestPrepare tests the invalid cases of Prepare
+func TestPrepare(t *testing.T) {
+	t.Parallel()
+
+	fr := fakerenderer.New()
+
+	_, err := (&dataset.Preparer{Name: "tank"}).Prepare(fr)
+	assert.EqualError(t, err, `zfs.dataset: "tank" is not a valid dataset name, expected POOL/NAME`)
+
+	_, err = (&dataset.Preparer{Name: "tank/vol", Type: "volume"}).Prepare(fr)
+	assert.EqualError(t, err, `zfs.dataset: volumes require "size"`)
+
+	_, err = (&dataset.Preparer{Name: "tank/home", Quota: "1G", Properties: map[string]string{"quota": "2G"}}).Prepare(fr)
+	assert.EqualError(t, err, `zfs.dataset: quota is set both directly and in "properties"`)
+}
+
+func existing(properties string) *fakeexec.Executor {
+	fake := fakeexec.New()
+	fake.Expect("zfs", "list", "-H", "-o", "name", "tank/home").Return("tank/home\n", 0)
+	fake.Expect("zfs", "get", "-H", "-o", "property,value", "compression,quota", "tank/home").Return(properties, 0)
+	return fake
+}
+
+func prepare(t *testing.T, fake *fakeexec.Executor, p *dataset.Preparer) *dataset.Dataset {
+	p.Name = "tank/home"
+
+	task, err := p.Prepare(&resource.ExecRenderer{Renderer: fakerenderer.New(), Exec: fake})
+	require.NoError(t, err)
+	return task.(*dataset.Dataset)
+}
@@ -0,0 +1,122 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dataset
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/load/registry"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/zfs"
+)
+
+// Preparer for Dataset
+//
+// Dataset creates a ZFS filesystem or volume and keeps its properties set.
+// Missing parent datasets are created with default properties. Removing a
+// dataset fails if it has children or snapshots, so they are never destroyed
+// implicitly.
+type Preparer struct {
+	// Name of the dataset, including the pool, such as "tank/home"
+	Name string `hcl:"name" required:"true"`
+
+	// Type is "filesystem" or "volume". It defaults to "filesystem".
+	Type string `hcl:"type" valid_values:"filesystem,volume"`
+
+	// Size is the size of a volume, such as "10G". It is required for volumes
+	// and not allowed for filesystems.
+	Size string `hcl:"size"`
+
+	// Mountpoint is where a filesystem is mounted. It is a shorthand for the
+	// mountpoint property.
+	Mountpoint string `hcl:"mountpoint"`
+
+	// Quota limits the space a filesystem and its children can use, such as
+	// "50G". It is a shorthand for the quota property.
+	Quota string `hcl:"quota"`
+
+	// Properties are other dataset properties, such as compression or
+	// recordsize
+	Properties map[string]string `hcl:"properties"`
+
+	// State is whether the dataset should be present or absent. It defaults to
+	// present.
+	State State `hcl:"state" valid_values:"present,absent"`
+}
+
+// Prepare a new task
+func (p *Preparer) Prepare(render resource.Renderer) (resource.Task, error) {
+	if !strings.Contains(p.Name, "/") || strings.HasSuffix(p.Name, "/") || strings.ContainsAny(p.Name, "@# \t\n") {
+		return nil, fmt.Errorf("zfs.dataset: %q is not a valid dataset name, expected POOL/NAME", p.Name)
+	}
+
+	if p.Type == "" {
+		p.Type = "filesystem"
+	}
+
+	if p.State == "" {
+		p.State = StatePresent
+	}
+
+	properties := map[string]string{}
+	for key, value := range p.Properties {
+		properties[key] = value
+	}
+
+	for key, value := range map[string]string{"mountpoint": p.Mountpoint, "quota": p.Quota} {
+		if value == "" {
+			continue
+		}
+		if _, ok := properties[key]; ok {
+			return nil, fmt.Errorf("zfs.dataset: %s is set both directly and in \"properties\"", key)
+		}
+		properties[key] = value
+	}
+
+	if err := zfs.Validate(properties); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case p.Type == "volume" && p.Size == "" && p.State == StatePresent:
+		return nil, fmt.Errorf("zfs.dataset: volumes require \"size\"")
+	case p.Type == "filesystem" && p.Size != "":
+		return nil, fmt.Errorf("zfs.dataset: \"size\" is only valid for volumes")
+	case p.Type == "volume" && (p.Mountpoint != "" || p.Quota != ""):
+		return nil, fmt.Errorf("zfs.dataset: \"mountpoint\" and \"quota\" are only valid for filesystems")
+	}
+
+	if p.Size != "" {
+		if _, ok := properties["volsize"]; ok {
+			return nil, fmt.Errorf("zfs.dataset: volsize is set both directly and in \"properties\"")
+		}
+		properties["volsize"] = p.Size
+	}
+
+	return &Dataset{
+		Name:       p.Name,
+		Type:       p.Type,
+		Properties: properties,
+		State:      p.State,
+		exec:       exec.For(render),
+	}, nil
+}
+
+func init() {
+	registry.Register("zfs.dataset", (*Preparer)(nil), (*Dataset)(nil))
+	registry.RegisterPlatforms("zfs.dataset", "linux")
+}
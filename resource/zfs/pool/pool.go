@@ -0,0 +1,114 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pool
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/zfs"
+	"github.com/pkg/errors"
+)
+
+// Pool manages a ZFS storage pool
+type Pool struct {
+	resource.Status
+
+	Name       string
+	VDevs      []string
+	Properties map[string]string
+
+	exec exec.Executor
+}
+
+// Check whether the pool exists with the declared properties
+func (p *Pool) Check(resource.Renderer) (resource.TaskStatus, error) {
+	p.Status = resource.Status{}
+
+	exists, changed, err := p.diff()
+	if err != nil {
+		p.RaiseLevel(resource.StatusFatal)
+		return p, err
+	}
+
+	if !exists {
+		p.RaiseLevel(resource.StatusWillChange)
+		p.AddDifference(p.Name, "<absent>", strings.Join(p.VDevs, " "), "")
+		return p, nil
+	}
+
+	for _, key := range zfs.Keys(changed) {
+		p.RaiseLevel(resource.StatusWillChange)
+		p.AddDifference(key, changed[key], p.Properties[key], "")
+	}
+
+	return p, nil
+}
+
+// Apply creates the pool or sets its properties
+func (p *Pool) Apply() (resource.TaskStatus, error) {
+	p.Status = resource.Status{}
+
+	exists, changed, err := p.diff()
+	if err != nil {
+		p.RaiseLevel(resource.StatusFatal)
+		return p, err
+	}
+
+	if !exists {
+		args := append([]string{"create"}, zfs.Options(p.Properties)...)
+		args = append(append(args, p.Name), p.VDevs...)
+		if err := exec.Run(p.exec, "zpool", args...); err != nil {
+			p.RaiseLevel(resource.StatusFatal)
+			return p, errors.Wrapf(err, "cannot create pool %s", p.Name)
+		}
+		p.AddMessage("created pool " + p.Name)
+		return p, nil
+	}
+
+	for _, key := range zfs.Keys(changed) {
+		if err := exec.Run(p.exec, "zpool", "set", key+"="+p.Properties[key], p.Name); err != nil {
+			p.RaiseLevel(resource.StatusFatal)
+			return p, errors.Wrapf(err, "cannot set %s on %s", key, p.Name)
+		}
+		p.AddMessage(fmt.Sprintf("set %s=%s", key, p.Properties[key]))
+	}
+
+	return p, nil
+}
+
+// diff returns whether the pool exists and the current values of the
+// properties that differ
+func (p *Pool) diff() (bool, map[string]string, error) {
+	exists, err := zfs.Exists(p.exec, "zpool", p.Name)
+	if err != nil || !exists {
+		return exists, nil, err
+	}
+
+	current, err := zfs.Get(p.exec, "zpool", p.Name, zfs.Keys(p.Properties))
+	if err != nil {
+		return true, nil, err
+	}
+
+	changed := map[string]string{}
+	for key, value := range p.Properties {
+		if current[key] != value {
+			changed[key] = current[key]
+		}
+	}
+	return true, changed, nil
+}
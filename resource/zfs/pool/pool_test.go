@@ -0,0 +1,120 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pool_test
+
+import (
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/fakeexec"
+	"github.com/asteris-llc/converge/helpers/fakerenderer"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/zfs/pool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestPoolInterface tests that Pool is properly implemented
+func TestPoolInterface(t *testing.T) {
+	t.Parallel()
+
+	assert.Implements(t, (*resource.Task)(nil), new(pool.Pool))
+	assert.Implements(t, (*resource.Resource)(nil), new(pool.Preparer))
+}
+
+// TestCheck tests the possible cases Check handles
+func TestCheck(t *testing.T) {
+	t.Parallel()
+
+	t.Run("missing", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("zpool", "list", "-H", "-o", "name", "tank").Return("", 1)
+
+		p := prepare(t, fake)
+		status, err := p.Check(fakerenderer.New())
+
+		require.NoError(t, err)
+		assert.True(t, status.HasChanges())
+		assert.Equal(t, "mirror /dev/sdb /dev/sdc", status.Diffs()["tank"].Current())
+	})
+
+	t.Run("properties", func(t *testing.T) {
+		p := prepare(t, existing("ashift\t12\nautoexpand\toff\n"))
+		status, err := p.Check(fakerenderer.New())
+
+		require.NoError(t, err)
+		assert.True(t, status.HasChanges())
+		assert.Len(t, status.Diffs(), 1)
+		assert.Equal(t, "off", status.Diffs()["autoexpand"].Original())
+	})
+
+	t.Run("converged", func(t *testing.T) {
+		p := prepare(t, existing("ashift\t12\nautoexpand\ton\n"))
+		status, err := p.Check(fakerenderer.New())
+
+		require.NoError(t, err)
+		assert.False(t, status.HasChanges())
+	})
+}
+
+// TestApply tests creating a pool and setting its properties
+func TestApply(t *testing.T) {
+	t.Parallel()
+
+	t.Run("create", func(t *testing.T) {
+		fake := fakeexec.New()
+		fake.Expect("zpool", "list", "-H", "-o", "name", "tank").Return("", 1)
+		fake.Expect("zpool", "create", "-o", "ashift=12", "-o", "autoexpand=on", "tank", "mirror", "/dev/sdb", "/dev/sdc")
+
+		_, err := prepare(t, fake).Apply()
+		require.NoError(t, err)
+		fake.AssertExpectations(t)
+	})
+
+	t.Run("set", func(t *testing.T) {
+		fake := existing("ashift\t12\nautoexpand\toff\n")
+		fake.Expect("zpool", "set", "autoexpand=on", "tank")
+
+		_, err := prepare(t, fake).Apply()
+		require.NoError(t, err)
+		fake.AssertExpectations(t)
+	})
+}
+
+// TestPrepare tests the invalid cases of Prepare
+func TestPrepare(t *testing.T) {
+	t.Parallel()
+
+	_, err := (&pool.Preparer{Name: "tank"}).Prepare(fakerenderer.New())
+	assert.EqualError(t, err, "zfs.pool requires at least one vdev")
+}
+
+func existing(properties string) *fakeexec.Executor {
+	fake := fakeexec.New()
+	fake.Expect("zpool", "list", "-H", "-o", "name", "tank").Return("tank\n", 0)
+	fake.Expect("zpool", "get", "-H", "-o", "property,value", "ashift,autoexpand", "tank").Return(properties, 0)
+	return fake
+}
+
+func prepare(t *testing.T, fake *fakeexec.Executor) *pool.Pool {
+	p := &pool.Preparer{
+		Name:       "tank",
+		VDevs:      []string{"mirror", "/dev/sdb", "/dev/sdc"},
+		Properties: map[string]string{"ashift": "12", "autoexpand": "on"},
+	}
+
+	task, err := p.Prepare(&resource.ExecRenderer{Renderer: fakerenderer.New(), Exec: fake})
+	require.NoError(t, err)
+	return task.(*pool.Pool)
+}
@@ -0,0 +1,66 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pool
+
+import (
+	"fmt"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/asteris-llc/converge/load/registry"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/zfs"
+)
+
+// Preparer for Pool
+//
+// Pool creates a ZFS storage pool and keeps its properties set. Pools are
+// never destroyed, and zpool refuses to create a pool on devices that are in
+// use or hold another filesystem. The layout of an existing pool is not
+// changed.
+type Preparer struct {
+	// Name of the pool
+	Name string `hcl:"name" required:"true"`
+
+	// VDevs is the layout of the pool as passed to zpool create, such as
+	// ["mirror", "/dev/sdb", "/dev/sdc"]
+	VDevs []string `hcl:"vdevs" required:"true"`
+
+	// Properties are pool properties, such as ashift or autoexpand. Some can
+	// only be set when the pool is created.
+	Properties map[string]string `hcl:"properties"`
+}
+
+// Prepare a new task
+func (p *Preparer) Prepare(render resource.Renderer) (resource.Task, error) {
+	if len(p.VDevs) == 0 {
+		return nil, fmt.Errorf("zfs.pool requires at least one vdev")
+	}
+
+	if err := zfs.Validate(p.Properties); err != nil {
+		return nil, err
+	}
+
+	return &Pool{
+		Name:       p.Name,
+		VDevs:      p.VDevs,
+		Properties: p.Properties,
+		exec:       exec.For(render),
+	}, nil
+}
+
+func init() {
+	registry.Register("zfs.pool", (*Preparer)(nil), (*Pool)(nil))
+	registry.RegisterPlatforms("zfs.pool", "linux")
+}
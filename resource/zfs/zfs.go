@@ -0,0 +1,89 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zfs
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/asteris-llc/converge/helpers/exec"
+	"github.com/pkg/errors"
+)
+
+// Exists reports whether the pool or dataset name exists. cmd is "zpool" for
+// pools and "zfs" for datasets.
+func Exists(e exec.Executor, cmd, name string) (bool, error) {
+	err := exec.Run(e, cmd, "list", "-H", "-o", "name", name)
+	if _, ok := exec.ExitStatus(err); ok {
+		return false, nil
+	} else if err != nil {
+		return false, errors.Wrapf(err, "cannot list %s", name)
+	}
+	return true, nil
+}
+
+// Get returns the current values of the given properties of a pool or dataset
+func Get(e exec.Executor, cmd, name string, properties []string) (map[string]string, error) {
+	values := map[string]string{}
+	if len(properties) == 0 {
+		return values, nil
+	}
+
+	out, err := exec.Read(e, cmd, "get", "-H", "-o", "property,value", strings.Join(properties, ","), name)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot get properties of %s", name)
+	}
+
+	for _, line := range strings.Split(out, "\n") {
+		if fields := strings.SplitN(line, "\t", 2); len(fields) == 2 {
+			values[fields[0]] = fields[1]
+		}
+	}
+	return values, nil
+}
+
+// Options formats properties as -o flags, sorted by name
+func Options(properties map[string]string) []string {
+	var out []string
+	for _, key := range Keys(properties) {
+		out = append(out, "-o", key+"="+properties[key])
+	}
+	return out
+}
+
+// Keys returns the sorted names of properties
+func Keys(properties map[string]string) []string {
+	var keys []string
+	for key := range properties {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Validate returns an error if a property name or value is empty or
+// malformed
+func Validate(properties map[string]string) error {
+	for _, key := range Keys(properties) {
+		if key == "" || strings.ContainsAny(key, "= \t\n,") {
+			return fmt.Errorf("%q is not a valid property name", key)
+		}
+		if properties[key] == "" || strings.ContainsAny(properties[key], "\t\n") {
+			return fmt.Errorf("%q is not a valid value for %s", properties[key], key)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zfs_test
+
+import (
+	"testing"
+
+	"github.com/asteris-llc/converge/helpers/fakeexec"
+	"github.com/asteris-llc/converge/resource/zfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestGet tests parsing property values
+func TestGet(t *testing.T) {
+	t.Parallel()
+
+	fake := fakeexec.New()
+	fake.Expect("zfs", "get", "-H", "-o", "property,value", "compression,quota", "tank/home").Return("compression\tlz4\nquota\tnone\n", 0)
+
+	values, err := zfs.Get(fake, "zfs", "tank/home", []string{"compression", "quota"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"compression": "lz4", "quota": "none"}, values)
+}
+
+// TestExists tests checking for pools and datasets
+func TestExists(t *testing.T) {
+	t.Parallel()
+
+	fake := fakeexec.New()
+	fake.Expect("zpool", "list", "-H", "-o", "name", "tank").Return("", 1).Stderr("cannot open 'tank': no such pool")
+
+	exists, err := zfs.Exists(fake, "zpool", "tank")
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
+
+// TestOptions tests formatting properties as flags
+func TestOptions(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, []string{"-o", "ashift=12", "-o", "autoexpand=on"}, zfs.Options(map[string]string{"autoexpand": "on", "ashift": "12"}))
+	assert.EqualError(t, zfs.Validate(map[string]string{"a,b": "on"}), `"a,b" is not a valid property name`)
+	assert.EqualError(t, zfs.Validate(map[string]string{"quota": ""}), `"" is not a valid value for quota`)
+}
@@ -17,16 +17,29 @@ package rpc
 import (
 	"context"
 	"encoding/json"
+	"io"
+	"os"
+	"runtime"
+	"sync"
+	"time"
 
 	"google.golang.org/grpc/metadata"
 
+	log "github.com/Sirupsen/logrus"
 	"github.com/asteris-llc/converge/apply"
+	"github.com/asteris-llc/converge/backup"
 	"github.com/asteris-llc/converge/graph"
 	"github.com/asteris-llc/converge/graph/node"
 	"github.com/asteris-llc/converge/healthcheck"
+	"github.com/asteris-llc/converge/helpers/lock"
 	"github.com/asteris-llc/converge/plan"
+	"github.com/asteris-llc/converge/policy"
 	"github.com/asteris-llc/converge/prettyprinters/human"
+	"github.com/asteris-llc/converge/render"
+	"github.com/asteris-llc/converge/report"
+	"github.com/asteris-llc/converge/resource"
 	"github.com/asteris-llc/converge/rpc/pb"
+	"github.com/asteris-llc/converge/telemetry"
 	"github.com/pkg/errors"
 )
 
@@ -36,6 +49,42 @@ var (
 
 type executor struct {
 	auth *authorizer
+
+	// snapshots is the directory holding the snapshot of each module's last
+	// successful apply
+	snapshots string
+
+	// policy is checked against the plan of every module before it is applied
+	policy *policy.Policy
+
+	// maxChanges is the most resources an apply may change, or 0 for no limit
+	maxChanges int
+
+	// strictWarnings rejects plans with warnings
+	strictWarnings bool
+
+	// lockPath is held while planning and applying, if set
+	lockPath    string
+	lockTimeout time.Duration
+
+	// clusterLock is held while applying, if set
+	clusterLock *lock.Semaphore
+
+	// backups receives managed files before they are changed, if set
+	backups *backup.Store
+
+	// traces receives a trace of every plan and apply, if set
+	traces *telemetry.Exporter
+
+	// reports receives a summary of every apply, if set
+	reports *report.Reporter
+
+	// refTraces receives how each lookup that doesn't resolve was searched
+	// for, if set
+	refTraces io.Writer
+
+	// overrides receives every field set by an override file, if set
+	overrides io.Writer
 }
 
 type statusResponseStream interface {
@@ -43,6 +92,53 @@ type statusResponseStream interface {
 	SendHeader(metadata.MD) error
 }
 
+// lock takes the host lock, if one is configured. The returned function
+// releases it. A lock that can't be taken fails the run, since running without
+// it could change the host at the same time as another run; locking is only
+// skipped when the lock path is empty.
+func (e *executor) lock(ctx context.Context) (func(), error) {
+	if e.lockPath == "" {
+		return func() {}, nil
+	}
+
+	logger := getLogger(ctx).WithField("function", "executor.lock")
+
+	held, err := lock.Acquire(ctx, e.lockPath, e.lockTimeout)
+	if err != nil {
+		if os.IsPermission(errors.Cause(err)) {
+			return nil, errors.Wrap(err, "could not take lock, set an empty lock file to run without one")
+		}
+		return nil, err
+	}
+
+	return func() {
+		if err := held.Release(); err != nil {
+			logger.WithError(err).Warning("could not release lock")
+		}
+	}, nil
+}
+
+// lockCluster takes a slot in the cluster lock, if one is configured. The
+// returned function releases it.
+func (e *executor) lockCluster(ctx context.Context) (func(), error) {
+	if e.clusterLock == nil {
+		return func() {}, nil
+	}
+
+	logger := getLogger(ctx).WithField("function", "executor.lockCluster")
+
+	slot, err := e.clusterLock.Acquire(ctx, e.lockTimeout)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not take cluster lock")
+	}
+
+	return func() {
+		if err := slot.Release(context.Background()); err != nil {
+			logger.WithError(err).Warning("could not release cluster lock, it will expire with its session")
+		}
+	}, nil
+}
+
 func (e *executor) edgeMeta(ctx context.Context, g *graph.Graph) (metadata.MD, error) {
 	logger := getLogger(ctx).WithField("function", "executor.edgeMeta")
 
@@ -74,8 +170,16 @@ func (e *executor) sendMeta(ctx context.Context, g *graph.Graph, stream statusRe
 }
 
 func (e *executor) stageNotifier(stage pb.StatusResponse_Stage, stream statusResponseStream) *graph.Notifier {
+	// nodes are walked in parallel and output arrives from the stdout and
+	// stderr of running tasks at once, but a gRPC stream can't be sent to
+	// concurrently
+	var sendLock sync.Mutex
+
 	return &graph.Notifier{
 		Pre: func(meta *node.Node) error {
+			sendLock.Lock()
+			defer sendLock.Unlock()
+
 			return stream.Send(&pb.StatusResponse{
 				Id:    meta.ID, // TODO: deprecated, remove in 0.4.0
 				Stage: stage,
@@ -91,13 +195,99 @@ func (e *executor) stageNotifier(stage pb.StatusResponse_Stage, stream statusRes
 				pb.StatusResponse_FINISHED,
 			)
 
+			sendLock.Lock()
+			defer sendLock.Unlock()
+
 			return stream.Send(response)
 		},
+		Output: func(meta *node.Node, name, line string) {
+			sendLock.Lock()
+			defer sendLock.Unlock()
+
+			err := stream.Send(&pb.StatusResponse{
+				Id:    meta.ID, // TODO: deprecated, remove in 0.4.0
+				Stage: stage,
+				Run:   pb.StatusResponse_OUTPUT,
+				Meta:  pb.MetaFromNode(meta),
+				Details: &pb.StatusResponse_Details{
+					Messages: []string{line},
+					Stream:   name,
+				},
+			})
+			if err != nil {
+				log.WithField("id", meta.ID).WithError(err).Debug("could not send output")
+			}
+		},
+	}
+}
+
+// trace records a trace of a run with a span for each node, if traces or
+// reports are configured. The returned function ends the trace, exports it,
+// and sends the summary of an apply. Neither failing fails the run.
+func (e *executor) trace(ctx context.Context, stage, location string, notifier *graph.Notifier) (*graph.Notifier, func(error)) {
+	reports := e.reports
+	if stage != "apply" {
+		reports = nil
+	}
+
+	if e.traces == nil && reports == nil {
+		return notifier, func(error) {}
+	}
+
+	trace := telemetry.Start("converge "+stage, map[string]interface{}{
+		"converge.stage":  stage,
+		"converge.module": location,
+	})
+
+	return trace.Notifier(notifier), func(err error) {
+		logger := getLogger(ctx).WithField("function", "executor.trace").WithField("trace", trace.ID())
+		spans := trace.End(err)
+
+		if e.traces != nil {
+			if err := e.traces.Export(ctx, spans); err != nil {
+				logger.WithError(err).Warning("could not export trace")
+			} else {
+				logger.Debug("exported trace")
+			}
+		}
+
+		if reports != nil {
+			summary := report.FromTrace(report.CurrentHost(), location, stage, spans)
+			if err := reports.Send(ctx, summary); err != nil {
+				logger.WithError(err).Warning("could not send report")
+			} else {
+				logger.Debug("sent report")
+			}
+		}
+	}
+}
+
+// snapshotPath is where the snapshot for a module and its params is kept
+func (e *executor) snapshotPath(req *pb.LoadRequest) string {
+	return render.SnapshotPath(e.snapshots, req.Location, req.Parameters)
+}
+
+// withSnapshot gives a plan the snapshot of the last successful apply of a
+// module. A snapshot that can't be read is skipped, since the plan can still
+// run without it.
+func (e *executor) withSnapshot(ctx context.Context, req *pb.LoadRequest) context.Context {
+	if e.snapshots == "" {
+		return ctx
+	}
+
+	snapshot, err := render.LoadSnapshot(e.snapshotPath(req))
+	if err != nil {
+		getLogger(ctx).WithError(err).WithField("location", req.Location).Warning("skipping snapshot")
+		return ctx
 	}
+
+	return render.WithSnapshot(ctx, snapshot)
 }
 
-func (e *executor) sendPlan(ctx context.Context, stream statusResponseStream, in *graph.Graph) (*graph.Graph, error) {
-	out, err := plan.WithNotify(ctx, in, e.stageNotifier(pb.StatusResponse_PLAN, stream))
+func (e *executor) sendPlan(ctx context.Context, stream statusResponseStream, in *graph.Graph, location string) (*graph.Graph, error) {
+	notifier, finish := e.trace(ctx, "plan", location, e.stageNotifier(pb.StatusResponse_PLAN, stream))
+	out, err := plan.WithNotify(ctx, in, notifier)
+	finish(err)
 	if err != nil && err != plan.ErrTreeContainsErrors {
 		return nil, err
 	}
@@ -106,6 +296,9 @@ func (e *executor) sendPlan(ctx context.Context, stream statusResponseStream, in
 
 func (e *executor) Plan(in *pb.LoadRequest, stream pb.Executor_PlanServer) error {
 	logger, ctx := setIDLogger(stream.Context())
+	ctx = withRefTraces(ctx, e.refTraces)
+	ctx = withOverrides(ctx, e.overrides)
+	ctx = render.WithLocation(ctx, in.Location)
 	logger = logger.WithField("function", "executor.Plan")
 
 	if err := e.auth.authorize(ctx); err != nil {
@@ -113,7 +306,14 @@ func (e *executor) Plan(in *pb.LoadRequest, stream pb.Executor_PlanServer) error
 		return errors.Wrap(err, "authorization failed")
 	}
 
-	loaded, err := in.Load(ctx)
+	unlock, err := e.lock(ctx)
+	if err != nil {
+		logger.WithError(err).Warning("could not lock")
+		return err
+	}
+	defer unlock()
+
+	loaded, err := in.LoadFor(ctx, runtime.GOOS)
 	if err != nil {
 		return err
 	}
@@ -123,12 +323,29 @@ func (e *executor) Plan(in *pb.LoadRequest, stream pb.Executor_PlanServer) error
 	}
 
 	// send the plan
-	_, err = e.sendPlan(ctx, stream, loaded)
+	planned, err := e.sendPlan(e.withSnapshot(ctx, in), stream, loaded, in.Location)
 	if err != nil {
 		logger.WithError(err).WithField("location", in.Location).Error("planning failed")
 		return errors.Wrapf(err, "planning %s", in.Location)
 	}
 
+	if err := e.policy.Check(planned); err != nil {
+		logger.WithError(err).WithField("location", in.Location).Warning("plan violates policy")
+		return errors.Wrapf(err, "planning %s", in.Location)
+	}
+
+	if err := plan.CheckLimits(planned, e.maxChanges); err != nil {
+		logger.WithError(err).WithField("location", in.Location).Warning("plan exceeds change limit")
+		return errors.Wrapf(err, "planning %s", in.Location)
+	}
+
+	if e.strictWarnings {
+		if err := plan.CheckWarnings(planned); err != nil {
+			logger.WithError(err).WithField("location", in.Location).Warning("plan has warnings")
+			return errors.Wrapf(err, "planning %s", in.Location)
+		}
+	}
+
 	return nil
 }
 
@@ -142,6 +359,9 @@ func (e *executor) sendHealthCheck(ctx context.Context, stream statusResponseStr
 
 func (e *executor) HealthCheck(in *pb.LoadRequest, stream pb.Executor_HealthCheckServer) error {
 	logger, ctx := setIDLogger(stream.Context())
+	ctx = withRefTraces(ctx, e.refTraces)
+	ctx = withOverrides(ctx, e.overrides)
+	ctx = render.WithLocation(ctx, in.Location)
 	logger = logger.WithField("function", "executor.Plan")
 
 	if err := e.auth.authorize(ctx); err != nil {
@@ -149,7 +369,14 @@ func (e *executor) HealthCheck(in *pb.LoadRequest, stream pb.Executor_HealthChec
 		return errors.Wrap(err, "authorization failed")
 	}
 
-	loaded, err := in.Load(ctx)
+	unlock, err := e.lock(ctx)
+	if err != nil {
+		logger.WithError(err).Warning("could not lock")
+		return err
+	}
+	defer unlock()
+
+	loaded, err := in.LoadFor(ctx, runtime.GOOS)
 	if err != nil {
 		return err
 	}
@@ -159,7 +386,7 @@ func (e *executor) HealthCheck(in *pb.LoadRequest, stream pb.Executor_HealthChec
 	}
 
 	// send the plan
-	planned, err := e.sendPlan(ctx, stream, loaded)
+	planned, err := e.sendPlan(e.withSnapshot(ctx, in), stream, loaded, in.Location)
 	if err != nil {
 		logger.WithError(err).WithField("location", in.Location).Error("planning failed")
 		return errors.Wrapf(err, "planning %s", in.Location)
@@ -174,16 +401,88 @@ func (e *executor) HealthCheck(in *pb.LoadRequest, stream pb.Executor_HealthChec
 	return nil
 }
 
-func (e *executor) sendApply(ctx context.Context, stream statusResponseStream, in *graph.Graph) (*graph.Graph, error) {
-	out, err := apply.WithNotify(ctx, in, e.stageNotifier(pb.StatusResponse_APPLY, stream))
+func (e *executor) sendApply(ctx context.Context, stream statusResponseStream, in *graph.Graph, req *pb.LoadRequest) (*graph.Graph, error) {
+	snapshot := render.NewSnapshot()
+
+	ctx = render.WithSnapshot(ctx, snapshot)
+	if e.backups != nil {
+		ctx = backup.WithStore(ctx, e.backups.Module(req.Location))
+	}
+
+	notifier, finish := e.trace(ctx, "apply", req.Location, e.stageNotifier(pb.StatusResponse_APPLY, stream))
+	out, err := apply.WithNotify(ctx, in, notifier)
+	finish(err)
+
+	// only a clean apply is kept, so that a plan never falls back to values
+	// from a half-finished run
+	if err == nil && e.snapshots != "" {
+		path := e.snapshotPath(req)
+		if snapshot.Len() == 0 {
+			os.Remove(path)
+		} else if saveErr := snapshot.Save(path); saveErr != nil {
+			getLogger(ctx).WithError(saveErr).WithField("location", req.Location).Warning("could not save snapshot")
+		}
+	}
+
 	if err != nil && err != apply.ErrTreeContainsErrors {
 		return nil, err
 	}
 	return out, nil
 }
 
+// checkPlan plans the graph without sending the results and checks the policy,
+// change limits and, with strict warnings, the warnings against it, so nothing
+// is changed if the apply would break a rule or change too much
+func (e *executor) checkPlan(ctx context.Context, in *graph.Graph, req *pb.LoadRequest) error {
+	if (e.policy == nil || len(e.policy.Rules) == 0) && e.maxChanges == 0 && !hasChangeLimits(in) && !e.strictWarnings {
+		return nil
+	}
+
+	planned, err := plan.Plan(e.withSnapshot(ctx, req), in)
+	if err != nil && err != plan.ErrTreeContainsErrors {
+		return err
+	}
+
+	if err := e.policy.Check(planned); err != nil {
+		return err
+	}
+
+	if err := plan.CheckLimits(planned, e.maxChanges); err != nil {
+		return err
+	}
+
+	if e.strictWarnings {
+		return plan.CheckWarnings(planned)
+	}
+	return nil
+}
+
+// hasChangeLimits reports whether any task in the rendered graph limits the
+// number of changes beneath it, like a module with `max_changes`
+func hasChangeLimits(in *graph.Graph) bool {
+	for _, id := range in.Vertices() {
+		meta, ok := in.Get(id)
+		if !ok {
+			continue
+		}
+
+		task, ok := resource.ResolveTask(meta.Value())
+		if !ok {
+			continue
+		}
+
+		if limiter, ok := task.(plan.ChangeLimiter); ok && limiter.ChangeLimit() > 0 {
+			return true
+		}
+	}
+	return false
+}
+
 func (e *executor) Apply(in *pb.LoadRequest, stream pb.Executor_ApplyServer) error {
 	logger, ctx := setIDLogger(stream.Context())
+	ctx = withRefTraces(ctx, e.refTraces)
+	ctx = withOverrides(ctx, e.overrides)
+	ctx = render.WithLocation(ctx, in.Location)
 	logger = logger.WithField("function", "executor.Apply")
 
 	if err := e.auth.authorize(ctx); err != nil {
@@ -191,7 +490,21 @@ func (e *executor) Apply(in *pb.LoadRequest, stream pb.Executor_ApplyServer) err
 		return errors.Wrap(err, "authorization failed")
 	}
 
-	loaded, err := in.Load(ctx)
+	unlock, err := e.lock(ctx)
+	if err != nil {
+		logger.WithError(err).Warning("could not lock")
+		return err
+	}
+	defer unlock()
+
+	unlockCluster, err := e.lockCluster(ctx)
+	if err != nil {
+		logger.WithError(err).Warning("could not lock cluster")
+		return err
+	}
+	defer unlockCluster()
+
+	loaded, err := in.LoadFor(ctx, runtime.GOOS)
 	if err != nil {
 		return err
 	}
@@ -200,7 +513,12 @@ func (e *executor) Apply(in *pb.LoadRequest, stream pb.Executor_ApplyServer) err
 		return err
 	}
 
-	_, err = e.sendApply(ctx, stream, loaded)
+	if err = e.checkPlan(ctx, loaded, in); err != nil {
+		logger.WithError(err).WithField("location", in.Location).Warning("plan was rejected")
+		return errors.Wrapf(err, "applying %s", in.Location)
+	}
+
+	_, err = e.sendApply(ctx, stream, loaded, in)
 	if err != nil {
 		return errors.Wrapf(err, "applying %s", in.Location)
 	}
@@ -0,0 +1,115 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/asteris-llc/converge/graph/node"
+	"github.com/asteris-llc/converge/rpc/pb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestStageNotifierSerializesSends(t *testing.T) {
+	t.Parallel()
+
+	stream := new(concurrencyCheckingStream)
+	notifier := new(executor).stageNotifier(pb.StatusResponse_APPLY, stream)
+	meta := node.New("root/task.x", (&pb.StatusResponse_Details{}).ToPrintable())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(3)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, notifier.Pre(meta))
+		}()
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, notifier.Post(meta))
+		}()
+		go func() {
+			defer wg.Done()
+			notifier.Output(meta, "stdout", "line")
+		}()
+	}
+	wg.Wait()
+
+	assert.EqualValues(t, 30, stream.sent)
+	assert.False(t, stream.overlapped, "Send was called concurrently")
+}
+
+// concurrencyCheckingStream records whether Send is ever called while another
+// Send is still running
+type concurrencyCheckingStream struct {
+	inFlight   int32
+	sent       int32
+	overlapped bool
+}
+
+func (s *concurrencyCheckingStream) Send(*pb.StatusResponse) error {
+	if atomic.AddInt32(&s.inFlight, 1) > 1 {
+		s.overlapped = true
+	}
+	time.Sleep(time.Millisecond)
+	atomic.AddInt32(&s.sent, 1)
+	atomic.AddInt32(&s.inFlight, -1)
+	return nil
+}
+
+func (s *concurrencyCheckingStream) SendHeader(metadata.MD) error { return nil }
+
+func TestLock(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "converge-rpc")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	t.Run("disabled", func(t *testing.T) {
+		unlock, err := new(executor).lock(context.Background())
+		require.NoError(t, err)
+		unlock()
+	})
+
+	t.Run("held", func(t *testing.T) {
+		e := &executor{lockPath: filepath.Join(dir, "converge.lock")}
+		unlock, err := e.lock(context.Background())
+		require.NoError(t, err)
+		defer unlock()
+
+		_, err = e.lock(context.Background())
+		assert.Error(t, err)
+	})
+
+	t.Run("can't be taken", func(t *testing.T) {
+		// a lock file under a regular file can never be created
+		file := filepath.Join(dir, "file")
+		require.NoError(t, ioutil.WriteFile(file, nil, 0600))
+
+		e := &executor{lockPath: filepath.Join(file, "converge.lock")}
+		_, err := e.lock(context.Background())
+		assert.Error(t, err)
+	})
+}
@@ -17,6 +17,7 @@ package rpc
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 
 	"github.com/asteris-llc/converge/load/registry"
 	"github.com/asteris-llc/converge/render"
@@ -27,11 +28,20 @@ import (
 
 type grapher struct {
 	auth *authorizer
+
+	// refTraces receives how each lookup that doesn't resolve was searched
+	// for, if set
+	refTraces io.Writer
+
+	// overrides receives every field set by an override file, if set
+	overrides io.Writer
 }
 
 // Graph returns the information about a graph
 func (g *grapher) Graph(in *pb.LoadRequest, stream pb.Grapher_GraphServer) error {
 	logger, ctx := setIDLogger(stream.Context())
+	ctx = withRefTraces(ctx, g.refTraces)
+	ctx = withOverrides(ctx, g.overrides)
 	logger = logger.WithField("function", "grapher.Graph")
 
 	if err := g.auth.authorize(ctx); err != nil {
@@ -47,8 +57,10 @@ func (g *grapher) Graph(in *pb.LoadRequest, stream pb.Grapher_GraphServer) error
 
 	for _, vertex := range loaded.Vertices() {
 		var val interface{}
+		var metadata map[string]string
 		if meta, ok := loaded.Get(vertex); ok {
 			val = meta.Value()
+			metadata = meta.Metadata
 		}
 
 		node, err := resolveVertex(vertex, val)
@@ -68,9 +80,10 @@ func (g *grapher) Graph(in *pb.LoadRequest, stream pb.Grapher_GraphServer) error
 
 		err = stream.Send(
 			pb.NewGraphComponent(&pb.GraphComponent_Vertex{
-				Id:      vertex,
-				Kind:    kind,
-				Details: vbytes,
+				Id:       vertex,
+				Kind:     kind,
+				Details:  vbytes,
+				Metadata: metadata,
 			}),
 		)
 		if err != nil {
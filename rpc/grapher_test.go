@@ -66,6 +66,28 @@ func TestGrapherGraph(t *testing.T) {
 		)
 	})
 
+	t.Run("metadata", func(t *testing.T) {
+		stream := new(mocks.GrapherGraphServer)
+		stream.On("Context").Return(ctx)
+
+		var metadata map[string]string
+		stream.On("Send", mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+			vertex := args.Get(0).(*pb.GraphComponent).GetVertex()
+			if vertex != nil && vertex.Id == "root/file.content.motd" {
+				metadata = vertex.Metadata
+			}
+		})
+
+		err := g.Graph(&pb.LoadRequest{Location: "../samples/metadata.hcl"}, stream)
+		assert.NoError(t, err)
+
+		assert.Equal(
+			t,
+			map[string]string{"owner": "platform", "runbook": "https://example.com/runbooks/motd"},
+			metadata,
+		)
+	})
+
 	t.Run("stream error", func(t *testing.T) {
 		stream := new(mocks.GrapherGraphServer)
 		stream.On("Context").Return(ctx)
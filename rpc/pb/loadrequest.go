@@ -16,7 +16,9 @@ package pb
 
 import (
 	"context"
+	"strings"
 
+	"github.com/asteris-llc/converge/bundle"
 	"github.com/asteris-llc/converge/graph"
 	"github.com/asteris-llc/converge/helpers/logging"
 	"github.com/asteris-llc/converge/load"
@@ -27,16 +29,47 @@ import (
 
 // Load gets a graph from a LocationRequest
 func (lr *LoadRequest) Load(ctx context.Context) (*graph.Graph, error) {
+	return lr.load(ctx, "")
+}
+
+// LoadFor gets a graph from a LocationRequest to be applied on goos. It fails
+// before rendering if the graph uses resources that can't be used there.
+func (lr *LoadRequest) LoadFor(ctx context.Context, goos string) (*graph.Graph, error) {
+	return lr.load(ctx, goos)
+}
+
+func (lr *LoadRequest) load(ctx context.Context, goos string) (*graph.Graph, error) {
 	logger := logging.GetLogger(ctx).WithField("location", lr.Location)
+	ctx = render.WithLocation(ctx, lr.Location)
 
-	loaded, err := load.Load(ctx, lr.Location, lr.Verify)
+	location, params := lr.Location, lr.Parameters
+	if lr.Content != "" {
+		ctx = load.WithModule(ctx, lr.Location, []byte(lr.Content))
+	} else if bundle.Is(lr.Location) {
+		b, err := bundle.Open(strings.TrimPrefix(lr.Location, "file://"))
+		if err != nil {
+			logger.WithError(err).Error("could not open bundle")
+			return nil, err
+		}
+		ctx = load.WithSource(ctx, b)
+		location, params = b.Manifest.Root, b.Params(lr.Parameters)
+	}
+
+	loaded, err := load.Load(ctx, location, lr.Verify)
 	if err != nil {
 		logger.WithError(err).Error("could not load")
 		return nil, errors.Wrapf(err, "loading %s", lr.Location)
 	}
 
+	if goos != "" {
+		if err := load.CheckPlatforms(loaded, goos); err != nil {
+			logger.WithError(err).Error("unsupported resources")
+			return nil, errors.Wrapf(err, "checking %s", lr.Location)
+		}
+	}
+
 	values := render.Values{}
-	for k, v := range lr.Parameters {
+	for k, v := range params {
 		values[k] = v
 	}
 	rendered, err := render.Render(ctx, loaded, values)
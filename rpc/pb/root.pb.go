@@ -6,9 +6,11 @@
 Package pb is a generated protocol buffer package.
 
 It is generated from these files:
+
 	root.proto
 
 It has these top-level messages:
+
 	LoadRequest
 	ContentResponse
 	StatusResponse
@@ -71,17 +73,20 @@ const (
 	StatusResponse_UNSPECIFIED_RUN StatusResponse_Run = 0
 	StatusResponse_STARTED         StatusResponse_Run = 1
 	StatusResponse_FINISHED        StatusResponse_Run = 2
+	StatusResponse_OUTPUT          StatusResponse_Run = 3
 )
 
 var StatusResponse_Run_name = map[int32]string{
 	0: "UNSPECIFIED_RUN",
 	1: "STARTED",
 	2: "FINISHED",
+	3: "OUTPUT",
 }
 var StatusResponse_Run_value = map[string]int32{
 	"UNSPECIFIED_RUN": 0,
 	"STARTED":         1,
 	"FINISHED":        2,
+	"OUTPUT":          3,
 }
 
 func (x StatusResponse_Run) String() string {
@@ -93,6 +98,8 @@ type LoadRequest struct {
 	Location   string            `protobuf:"bytes,1,opt,name=location" json:"location,omitempty"`
 	Parameters map[string]string `protobuf:"bytes,2,rep,name=parameters" json:"parameters,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
 	Verify     bool              `protobuf:"varint,3,opt,name=verify" json:"verify,omitempty"`
+	// the module itself, loaded instead of fetching location when set
+	Content string `protobuf:"bytes,4,opt,name=content" json:"content,omitempty"`
 }
 
 func (m *LoadRequest) Reset()                    { *m = LoadRequest{} }
@@ -147,10 +154,13 @@ func (m *StatusResponse) GetMeta() *StatusResponse_Meta {
 
 // the informational message, if present
 type StatusResponse_Details struct {
-	Messages   []string                 `protobuf:"bytes,1,rep,name=messages" json:"messages,omitempty"`
-	Changes    map[string]*DiffResponse `protobuf:"bytes,2,rep,name=changes" json:"changes,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
-	HasChanges bool                     `protobuf:"varint,3,opt,name=hasChanges" json:"hasChanges,omitempty"`
-	Error      string                   `protobuf:"bytes,4,opt,name=error" json:"error,omitempty"`
+	Messages       []string                  `protobuf:"bytes,1,rep,name=messages" json:"messages,omitempty"`
+	Changes        map[string]*DiffResponse  `protobuf:"bytes,2,rep,name=changes" json:"changes,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	HasChanges     bool                      `protobuf:"varint,3,opt,name=hasChanges" json:"hasChanges,omitempty"`
+	Error          string                    `protobuf:"bytes,4,opt,name=error" json:"error,omitempty"`
+	RebootRequired []string                  `protobuf:"bytes,5,rep,name=rebootRequired" json:"rebootRequired,omitempty"`
+	Stream         string                    `protobuf:"bytes,6,opt,name=stream" json:"stream,omitempty"`
+	Warnings       []*StatusResponse_Warning `protobuf:"bytes,7,rep,name=warnings" json:"warnings,omitempty"`
 }
 
 func (m *StatusResponse_Details) Reset()                    { *m = StatusResponse_Details{} }
@@ -165,8 +175,19 @@ func (m *StatusResponse_Details) GetChanges() map[string]*DiffResponse {
 	return nil
 }
 
+func (m *StatusResponse_Details) GetWarnings() []*StatusResponse_Warning {
+	if m != nil {
+		return m.Warnings
+	}
+	return nil
+}
+
 type StatusResponse_Meta struct {
 	Id string `protobuf:"bytes,1,opt,name=id" json:"id,omitempty"`
+	// where the node's block is in its module, like "main.hcl:3:1"
+	Position string `protobuf:"bytes,2,opt,name=position" json:"position,omitempty"`
+	// annotations set in the node's metadata block
+	Metadata map[string]string `protobuf:"bytes,3,rep,name=metadata" json:"metadata,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
 }
 
 func (m *StatusResponse_Meta) Reset()                    { *m = StatusResponse_Meta{} }
@@ -174,6 +195,24 @@ func (m *StatusResponse_Meta) String() string            { return proto.CompactT
 func (*StatusResponse_Meta) ProtoMessage()               {}
 func (*StatusResponse_Meta) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{2, 1} }
 
+func (m *StatusResponse_Meta) GetMetadata() map[string]string {
+	if m != nil {
+		return m.Metadata
+	}
+	return nil
+}
+
+type StatusResponse_Warning struct {
+	Kind    string `protobuf:"bytes,1,opt,name=kind" json:"kind,omitempty"`
+	Field   string `protobuf:"bytes,2,opt,name=field" json:"field,omitempty"`
+	Message string `protobuf:"bytes,3,opt,name=message" json:"message,omitempty"`
+}
+
+func (m *StatusResponse_Warning) Reset()                    { *m = StatusResponse_Warning{} }
+func (m *StatusResponse_Warning) String() string            { return proto.CompactTextString(m) }
+func (*StatusResponse_Warning) ProtoMessage()               {}
+func (*StatusResponse_Warning) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{2, 2} }
+
 type DiffResponse struct {
 	Original string `protobuf:"bytes,1,opt,name=original" json:"original,omitempty"`
 	Current  string `protobuf:"bytes,2,opt,name=current" json:"current,omitempty"`
@@ -313,6 +352,8 @@ type GraphComponent_Vertex struct {
 	Kind string `protobuf:"bytes,2,opt,name=kind" json:"kind,omitempty"`
 	// detailed fields of this node, serialized as JSON
 	Details []byte `protobuf:"bytes,3,opt,name=details,proto3" json:"details,omitempty"`
+	// annotations set in the node's metadata block
+	Metadata map[string]string `protobuf:"bytes,4,rep,name=metadata" json:"metadata,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
 }
 
 func (m *GraphComponent_Vertex) Reset()                    { *m = GraphComponent_Vertex{} }
@@ -320,6 +361,13 @@ func (m *GraphComponent_Vertex) String() string            { return proto.Compac
 func (*GraphComponent_Vertex) ProtoMessage()               {}
 func (*GraphComponent_Vertex) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{4, 0} }
 
+func (m *GraphComponent_Vertex) GetMetadata() map[string]string {
+	if m != nil {
+		return m.Metadata
+	}
+	return nil
+}
+
 type GraphComponent_Edge struct {
 	Source     string   `protobuf:"bytes,1,opt,name=source" json:"source,omitempty"`
 	Dest       string   `protobuf:"bytes,2,opt,name=dest" json:"dest,omitempty"`
@@ -337,6 +385,7 @@ func init() {
 	proto.RegisterType((*StatusResponse)(nil), "pb.StatusResponse")
 	proto.RegisterType((*StatusResponse_Details)(nil), "pb.StatusResponse.Details")
 	proto.RegisterType((*StatusResponse_Meta)(nil), "pb.StatusResponse.Meta")
+	proto.RegisterType((*StatusResponse_Warning)(nil), "pb.StatusResponse.Warning")
 	proto.RegisterType((*DiffResponse)(nil), "pb.DiffResponse")
 	proto.RegisterType((*GraphComponent)(nil), "pb.GraphComponent")
 	proto.RegisterType((*GraphComponent_Vertex)(nil), "pb.GraphComponent.Vertex")
@@ -765,63 +814,68 @@ var _Grapher_serviceDesc = grpc.ServiceDesc{
 func init() { proto.RegisterFile("root.proto", fileDescriptor0) }
 
 var fileDescriptor0 = []byte{
-	// 916 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0x8c, 0x95, 0x4d, 0x6f, 0x1a, 0x47,
-	0x18, 0xc7, 0xbd, 0x0b, 0x18, 0x78, 0x40, 0x98, 0x4e, 0x12, 0x67, 0xb3, 0xa9, 0x1a, 0xb4, 0x87,
-	0x84, 0x3a, 0xea, 0xd2, 0xe2, 0x56, 0xaa, 0x22, 0x45, 0x15, 0x06, 0x6c, 0x2c, 0x39, 0x08, 0x0d,
-	0x4e, 0xa5, 0xbe, 0xa8, 0xd5, 0x00, 0x63, 0x58, 0x79, 0x99, 0xd9, 0xce, 0xce, 0x5a, 0x41, 0x55,
-	0x2f, 0x3d, 0xf6, 0xda, 0x73, 0x3f, 0x4c, 0xbf, 0x40, 0x2f, 0xed, 0x47, 0xe8, 0xb1, 0x1f, 0xa2,
-	0x9a, 0x99, 0x5d, 0x07, 0x63, 0x2c, 0xf9, 0xb6, 0xcf, 0xcc, 0xff, 0xf9, 0xcd, 0xf3, 0xb6, 0x33,
-	0x00, 0x82, 0x73, 0xe9, 0x47, 0x82, 0x4b, 0x8e, 0xec, 0x68, 0xe2, 0x7e, 0x38, 0xe7, 0x7c, 0x1e,
-	0xd2, 0x16, 0x89, 0x82, 0x16, 0x61, 0x8c, 0x4b, 0x22, 0x03, 0xce, 0x62, 0xa3, 0x70, 0x9f, 0xa6,
-	0xbb, 0xda, 0x9a, 0x24, 0x17, 0x2d, 0xba, 0x8c, 0xe4, 0xca, 0x6c, 0x7a, 0x7f, 0x5a, 0x50, 0x39,
-	0xe3, 0x64, 0x86, 0xe9, 0x4f, 0x09, 0x8d, 0x25, 0x72, 0xa1, 0x14, 0xf2, 0xa9, 0xf6, 0x77, 0xac,
-	0x86, 0xd5, 0x2c, 0xe3, 0x6b, 0x1b, 0x7d, 0x05, 0x10, 0x11, 0x41, 0x96, 0x54, 0x52, 0x11, 0x3b,
-	0x76, 0x23, 0xd7, 0xac, 0xb4, 0x9f, 0xf9, 0xd1, 0xc4, 0x5f, 0x03, 0xf8, 0xa3, 0x6b, 0x45, 0x9f,
-	0x49, 0xb1, 0xc2, 0x6b, 0x2e, 0x68, 0x1f, 0x76, 0xaf, 0xa8, 0x08, 0x2e, 0x56, 0x4e, 0xae, 0x61,
-	0x35, 0x4b, 0x38, 0xb5, 0xdc, 0xd7, 0xb0, 0xb7, 0xe1, 0x86, 0xea, 0x90, 0xbb, 0xa4, 0xab, 0x34,
-	0x04, 0xf5, 0x89, 0x1e, 0x42, 0xe1, 0x8a, 0x84, 0x09, 0x75, 0x6c, 0xbd, 0x66, 0x8c, 0x57, 0xf6,
-	0x97, 0x96, 0xf7, 0x12, 0xf6, 0xba, 0x9c, 0x49, 0xca, 0x24, 0xa6, 0x71, 0xc4, 0x59, 0x4c, 0x91,
-	0x03, 0xc5, 0xa9, 0x59, 0x4a, 0x11, 0x99, 0xe9, 0xfd, 0x93, 0x87, 0xda, 0x58, 0x12, 0x99, 0xc4,
-	0xd7, 0x62, 0x04, 0x76, 0x30, 0x33, 0xba, 0x23, 0xdb, 0xb1, 0xb0, 0x1d, 0xcc, 0x90, 0x0f, 0x85,
-	0x58, 0x92, 0xb9, 0x39, 0xad, 0xd6, 0x76, 0x54, 0x9a, 0x37, 0xdd, 0x94, 0x39, 0xa7, 0xd8, 0xc8,
-	0x50, 0x13, 0x72, 0x22, 0x61, 0x3a, 0xaf, 0x5a, 0x7b, 0x7f, 0x8b, 0x1a, 0x27, 0x0c, 0x2b, 0x09,
-	0xfa, 0x1c, 0x8a, 0x33, 0x2a, 0x49, 0x10, 0xc6, 0x4e, 0xbe, 0x61, 0x35, 0x2b, 0x6d, 0x77, 0x8b,
-	0xba, 0x67, 0x14, 0x38, 0x93, 0xa2, 0x97, 0x90, 0x5f, 0x52, 0x49, 0x9c, 0x82, 0x76, 0x79, 0xbc,
-	0xc5, 0xe5, 0x0d, 0x95, 0x04, 0x6b, 0x91, 0xfb, 0x9f, 0x05, 0xc5, 0x94, 0xa0, 0x1a, 0xba, 0xa4,
-	0x71, 0x4c, 0xe6, 0x34, 0x76, 0xac, 0x46, 0x4e, 0x35, 0x34, 0xb3, 0x51, 0x07, 0x8a, 0xd3, 0x05,
-	0x61, 0x6a, 0xcb, 0x74, 0xf3, 0xc5, 0xdd, 0xa1, 0xf8, 0x5d, 0xa3, 0x34, 0x5d, 0xcd, 0xfc, 0xd0,
-	0x47, 0x00, 0x0b, 0x12, 0xa7, 0x7b, 0x69, 0x5b, 0xd7, 0x56, 0x54, 0xd7, 0xa8, 0x10, 0x5c, 0xe8,
-	0x5c, 0xcb, 0xd8, 0x18, 0xee, 0x19, 0x54, 0xd7, 0x71, 0x5b, 0xba, 0xfd, 0x7c, 0xbd, 0xdb, 0x95,
-	0x76, 0x5d, 0x05, 0xd6, 0x0b, 0x2e, 0x2e, 0xb2, 0xb0, 0xd6, 0xfa, 0xef, 0xee, 0x43, 0x5e, 0x25,
-	0x8f, 0x6a, 0xef, 0xfb, 0xa8, 0x7a, 0xe8, 0x1d, 0x42, 0x41, 0xf7, 0x08, 0x3d, 0x82, 0x0f, 0xde,
-	0x0e, 0xc7, 0xa3, 0x7e, 0xf7, 0xf4, 0xf8, 0xb4, 0xdf, 0xfb, 0x71, 0x7c, 0xde, 0x39, 0xe9, 0xd7,
-	0x77, 0x50, 0x09, 0xf2, 0xa3, 0xb3, 0xce, 0xb0, 0x6e, 0xa1, 0x32, 0x14, 0x3a, 0xa3, 0xd1, 0xd9,
-	0x37, 0x75, 0xdb, 0xfb, 0x02, 0x72, 0x38, 0x61, 0xe8, 0x01, 0xec, 0xad, 0xbb, 0xe0, 0xb7, 0xc3,
-	0xfa, 0x0e, 0xaa, 0x40, 0x71, 0x7c, 0xde, 0xc1, 0xe7, 0xfd, 0x5e, 0xdd, 0x42, 0x55, 0x28, 0x1d,
-	0x9f, 0x0e, 0x4f, 0xc7, 0x83, 0x7e, 0xaf, 0x6e, 0x7b, 0x3f, 0x40, 0x75, 0x3d, 0x3c, 0x55, 0x76,
-	0x2e, 0x82, 0x79, 0xc0, 0x48, 0x98, 0xfd, 0x47, 0x99, 0xad, 0x87, 0x33, 0x11, 0x42, 0x0d, 0xa7,
-	0x9d, 0x0e, 0xa7, 0x31, 0xf5, 0xce, 0x8d, 0x52, 0x66, 0xa6, 0xf7, 0x87, 0x0d, 0xb5, 0x13, 0x41,
-	0xa2, 0x45, 0x97, 0x2f, 0x23, 0xce, 0x94, 0xf8, 0x50, 0xff, 0x4d, 0x92, 0xbe, 0xd3, 0x07, 0x54,
-	0xda, 0x4f, 0x54, 0x8d, 0x6e, 0x6a, 0xfc, 0xaf, 0xb5, 0x60, 0xb0, 0x83, 0x53, 0x29, 0xfa, 0x04,
-	0xf2, 0x74, 0x36, 0xcf, 0xca, 0xfa, 0x78, 0x8b, 0x4b, 0x7f, 0x36, 0xa7, 0x83, 0x1d, 0xac, 0x65,
-	0xee, 0x31, 0xec, 0x1a, 0xc4, 0x66, 0x71, 0x11, 0x82, 0xfc, 0x65, 0xc0, 0x66, 0x69, 0x06, 0xfa,
-	0x5b, 0x85, 0x9f, 0x8d, 0xb6, 0x0a, 0xbf, 0x7a, 0x3d, 0xbe, 0x2e, 0x86, 0xbc, 0xe2, 0xaa, 0x1b,
-	0x20, 0xe6, 0x89, 0x98, 0xd2, 0x94, 0x94, 0x5a, 0x8a, 0x36, 0xa3, 0x71, 0x56, 0x0f, 0xfd, 0xad,
-	0x46, 0x8b, 0x48, 0x29, 0x82, 0x49, 0x22, 0x75, 0x3d, 0xd4, 0xec, 0xae, 0xad, 0x1c, 0x55, 0xa0,
-	0x3c, 0xcd, 0xa2, 0x6e, 0xff, 0x66, 0x43, 0xa9, 0xff, 0x8e, 0x4e, 0x13, 0xc9, 0x05, 0xfa, 0x1e,
-	0x2a, 0x03, 0x4a, 0x42, 0xb9, 0xe8, 0x2e, 0xe8, 0xf4, 0x12, 0xed, 0x6d, 0xdc, 0x51, 0x2e, 0xba,
-	0x3d, 0xe6, 0xde, 0xf3, 0x5f, 0xff, 0xfe, 0xf7, 0x77, 0xbb, 0xe1, 0x3d, 0xd5, 0xb7, 0xe8, 0xd5,
-	0x67, 0xad, 0x25, 0x99, 0x2e, 0x02, 0x46, 0x5b, 0x0b, 0x4d, 0x9a, 0x2a, 0xd2, 0x2b, 0xeb, 0xe0,
-	0x53, 0x0b, 0x0d, 0x21, 0x3f, 0x0a, 0x09, 0xbb, 0x1f, 0xf6, 0x99, 0xc6, 0x3e, 0xf1, 0x1e, 0x6e,
-	0x62, 0xa3, 0x90, 0x30, 0xc3, 0x1b, 0x41, 0xa1, 0x13, 0x45, 0xe1, 0xea, 0x7e, 0xc0, 0x86, 0x06,
-	0xba, 0xde, 0xa3, 0x4d, 0x20, 0x51, 0x0c, 0x4d, 0x6c, 0xff, 0x65, 0x41, 0x15, 0x53, 0x53, 0xda,
-	0x01, 0x8f, 0x25, 0xfa, 0x16, 0xca, 0x27, 0x54, 0x1e, 0x05, 0x8c, 0x88, 0x15, 0xda, 0xf7, 0xcd,
-	0x83, 0xe0, 0x67, 0x0f, 0x82, 0xdf, 0x57, 0x0f, 0x82, 0xfb, 0x40, 0x9d, 0xb6, 0x71, 0x91, 0x66,
-	0xc7, 0x21, 0x27, 0x3b, 0x4e, 0xa4, 0xdc, 0xb8, 0x35, 0x31, 0xb8, 0x89, 0x66, 0xbf, 0xe1, 0xb3,
-	0x24, 0xa4, 0xb7, 0x53, 0xd8, 0x0a, 0x6d, 0x69, 0xe8, 0xc7, 0xe8, 0xc5, 0x6d, 0xe8, 0x52, 0x73,
-	0xe2, 0xd6, 0xcf, 0xd9, 0xab, 0xf3, 0xfa, 0xe0, 0xe0, 0x97, 0xf6, 0x77, 0x50, 0xd4, 0x53, 0x4a,
-	0x85, 0xaa, 0x96, 0xfe, 0xbc, 0xa3, 0x5a, 0x37, 0x87, 0xf9, 0xee, 0x6a, 0xcd, 0x95, 0x4e, 0x57,
-	0x6b, 0xb2, 0xab, 0xeb, 0x70, 0xf8, 0x7f, 0x00, 0x00, 0x00, 0xff, 0xff, 0xfb, 0x97, 0x78, 0x6d,
-	0x56, 0x07, 0x00, 0x00,
+	// 998 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x55, 0xdd, 0x6e, 0xdb, 0x36,
+	0x18, 0x8d, 0xe4, 0xff, 0xcf, 0x81, 0xe3, 0xb1, 0x6d, 0xaa, 0xaa, 0xc3, 0x6a, 0xe8, 0x22, 0xcd,
+	0x52, 0x4c, 0xde, 0x9c, 0x61, 0x18, 0x0a, 0x14, 0x83, 0x93, 0x38, 0x3f, 0x40, 0x92, 0x19, 0x4c,
+	0xb2, 0x61, 0x3f, 0xd8, 0x40, 0x5b, 0xb4, 0x2c, 0x44, 0x26, 0x35, 0x8a, 0xca, 0x6a, 0x0c, 0xbb,
+	0xd9, 0xe5, 0x6e, 0x77, 0xbd, 0xeb, 0x3d, 0xc7, 0x5e, 0x60, 0x37, 0x7b, 0x85, 0x3e, 0x48, 0x41,
+	0x4a, 0x72, 0x15, 0xc7, 0x01, 0x7a, 0xa7, 0x43, 0x9e, 0xef, 0x90, 0x3c, 0xdf, 0xa1, 0x08, 0x20,
+	0x38, 0x97, 0x6e, 0x24, 0xb8, 0xe4, 0xc8, 0x8c, 0x46, 0xf6, 0x87, 0x3e, 0xe7, 0x7e, 0x48, 0xbb,
+	0x24, 0x0a, 0xba, 0x84, 0x31, 0x2e, 0x89, 0x0c, 0x38, 0x8b, 0x53, 0x86, 0xfd, 0x34, 0x9b, 0xd5,
+	0x68, 0x94, 0x4c, 0xba, 0x74, 0x16, 0xc9, 0x79, 0x3a, 0xe9, 0xfc, 0x6b, 0x40, 0xf3, 0x94, 0x13,
+	0x0f, 0xd3, 0x5f, 0x12, 0x1a, 0x4b, 0x64, 0x43, 0x3d, 0xe4, 0x63, 0x5d, 0x6f, 0x19, 0x1d, 0x63,
+	0xbb, 0x81, 0x17, 0x18, 0x7d, 0x05, 0x10, 0x11, 0x41, 0x66, 0x54, 0x52, 0x11, 0x5b, 0x66, 0xa7,
+	0xb4, 0xdd, 0xec, 0x3d, 0x73, 0xa3, 0x91, 0x5b, 0x10, 0x70, 0x87, 0x0b, 0xc6, 0x80, 0x49, 0x31,
+	0xc7, 0x85, 0x12, 0xb4, 0x09, 0xd5, 0x1b, 0x2a, 0x82, 0xc9, 0xdc, 0x2a, 0x75, 0x8c, 0xed, 0x3a,
+	0xce, 0x90, 0xfd, 0x0a, 0x36, 0x96, 0xca, 0x50, 0x1b, 0x4a, 0xd7, 0x74, 0x9e, 0x6d, 0x41, 0x7d,
+	0xa2, 0x87, 0x50, 0xb9, 0x21, 0x61, 0x42, 0x2d, 0x53, 0x8f, 0xa5, 0xe0, 0xa5, 0xf9, 0xa5, 0xe1,
+	0xbc, 0x80, 0x8d, 0x7d, 0xce, 0x24, 0x65, 0x12, 0xd3, 0x38, 0xe2, 0x2c, 0xa6, 0xc8, 0x82, 0xda,
+	0x38, 0x1d, 0xca, 0x24, 0x72, 0xe8, 0xfc, 0x53, 0x85, 0xd6, 0x85, 0x24, 0x32, 0x89, 0x17, 0x64,
+	0x04, 0x66, 0xe0, 0xa5, 0xbc, 0x3d, 0xd3, 0x32, 0xb0, 0x19, 0x78, 0xc8, 0x85, 0x4a, 0x2c, 0x89,
+	0x9f, 0xae, 0xd6, 0xea, 0x59, 0xea, 0x98, 0xb7, 0xcb, 0x14, 0xf4, 0x29, 0x4e, 0x69, 0x68, 0x1b,
+	0x4a, 0x22, 0x61, 0xfa, 0x5c, 0xad, 0xde, 0xe6, 0x0a, 0x36, 0x4e, 0x18, 0x56, 0x14, 0xf4, 0x39,
+	0xd4, 0x3c, 0x2a, 0x49, 0x10, 0xc6, 0x56, 0xb9, 0x63, 0x6c, 0x37, 0x7b, 0xf6, 0x0a, 0xf6, 0x41,
+	0xca, 0xc0, 0x39, 0x15, 0xbd, 0x80, 0xf2, 0x8c, 0x4a, 0x62, 0x55, 0x74, 0xc9, 0xe3, 0x15, 0x25,
+	0x67, 0x54, 0x12, 0xac, 0x49, 0xf6, 0x1b, 0x13, 0x6a, 0x99, 0x82, 0x6a, 0xe8, 0x8c, 0xc6, 0x31,
+	0xf1, 0x69, 0x6c, 0x19, 0x9d, 0x92, 0x6a, 0x68, 0x8e, 0x51, 0x1f, 0x6a, 0xe3, 0x29, 0x61, 0x3e,
+	0xcd, 0xbb, 0xf9, 0xfc, 0xfe, 0xad, 0xb8, 0xfb, 0x29, 0x33, 0xed, 0x6a, 0x5e, 0x87, 0x3e, 0x02,
+	0x98, 0x92, 0x38, 0x9b, 0xcb, 0xda, 0x5a, 0x18, 0x51, 0x5d, 0xa3, 0x42, 0x70, 0xa1, 0xcf, 0xda,
+	0xc0, 0x29, 0x40, 0x5b, 0xd0, 0x12, 0x74, 0xc4, 0xb9, 0x54, 0xa9, 0x09, 0x04, 0xf5, 0xac, 0x8a,
+	0xde, 0xda, 0xd2, 0xa8, 0x0a, 0x4c, 0x2c, 0x05, 0x25, 0x33, 0xab, 0xaa, 0xcb, 0x33, 0x84, 0xbe,
+	0x80, 0xfa, 0xaf, 0x44, 0xb0, 0x80, 0xf9, 0xb1, 0x55, 0xeb, 0x94, 0xee, 0x31, 0xf1, 0xdb, 0x94,
+	0x82, 0x17, 0x5c, 0xfb, 0x14, 0xd6, 0x8b, 0xc7, 0x58, 0x91, 0xb2, 0xad, 0x62, 0xca, 0x9a, 0xbd,
+	0xb6, 0x92, 0x3d, 0x08, 0x26, 0x93, 0x5c, 0xb4, 0x90, 0x3b, 0x7b, 0x13, 0xca, 0xca, 0x74, 0xd4,
+	0x7a, 0x97, 0x1f, 0x95, 0x1d, 0xfb, 0x0c, 0x6a, 0xd9, 0xd2, 0x08, 0x41, 0xf9, 0x3a, 0x60, 0xf9,
+	0xa4, 0xfe, 0x56, 0x96, 0x4c, 0x02, 0x1a, 0x7a, 0x79, 0x90, 0x35, 0x50, 0x89, 0xcd, 0xfa, 0xa2,
+	0x5d, 0x6c, 0xe0, 0x1c, 0x3a, 0xbb, 0x50, 0xd1, 0x51, 0x43, 0x8f, 0xe0, 0x83, 0xab, 0xf3, 0x8b,
+	0xe1, 0x60, 0xff, 0xe4, 0xf0, 0x64, 0x70, 0xf0, 0xf3, 0xc5, 0x65, 0xff, 0x68, 0xd0, 0x5e, 0x43,
+	0x75, 0x28, 0x0f, 0x4f, 0xfb, 0xe7, 0x6d, 0x03, 0x35, 0xa0, 0xd2, 0x1f, 0x0e, 0x4f, 0xbf, 0x6b,
+	0x9b, 0x4e, 0x1f, 0x4a, 0x38, 0x61, 0xe8, 0x01, 0x6c, 0x14, 0x4b, 0xf0, 0xd5, 0x79, 0x7b, 0x0d,
+	0x35, 0xa1, 0x76, 0x71, 0xd9, 0xc7, 0x97, 0x83, 0x83, 0xb6, 0x81, 0xd6, 0xa1, 0x7e, 0x78, 0x72,
+	0x7e, 0x72, 0x71, 0x3c, 0x38, 0x68, 0x9b, 0x08, 0xa0, 0xfa, 0xf5, 0xd5, 0xe5, 0xf0, 0xea, 0xb2,
+	0x5d, 0x72, 0x7e, 0x82, 0xf5, 0xe2, 0xc9, 0x55, 0x92, 0xb8, 0x08, 0xfc, 0x80, 0x91, 0x30, 0xff,
+	0x35, 0xe4, 0x58, 0xdf, 0xb7, 0x44, 0x08, 0x75, 0xdf, 0xcc, 0xec, 0xbe, 0xa5, 0x50, 0xcf, 0xdc,
+	0x4a, 0x47, 0x0e, 0x9d, 0xbf, 0x4d, 0x68, 0x1d, 0x09, 0x12, 0x4d, 0xf7, 0xf9, 0x2c, 0xe2, 0x4c,
+	0x91, 0x77, 0xf5, 0x0f, 0x42, 0xd2, 0xd7, 0x7a, 0x81, 0x66, 0xef, 0x89, 0xb2, 0xff, 0x36, 0xc7,
+	0xfd, 0x46, 0x13, 0x8e, 0xd7, 0x70, 0x46, 0x45, 0x9f, 0x40, 0x99, 0x7a, 0x7e, 0xde, 0xb1, 0xc7,
+	0x2b, 0x4a, 0x06, 0x9e, 0x4f, 0x8f, 0xd7, 0xb0, 0xa6, 0xd9, 0x87, 0x50, 0x4d, 0x25, 0x96, 0xfb,
+	0xb6, 0x68, 0x96, 0x59, 0x68, 0x96, 0xf5, 0xee, 0xb6, 0xaa, 0xed, 0xaf, 0x2f, 0x6e, 0xa4, 0x8d,
+	0xa1, 0xac, 0x74, 0x75, 0x46, 0x79, 0x22, 0xc6, 0x34, 0x53, 0xca, 0x90, 0x52, 0xf3, 0x68, 0x9c,
+	0xfb, 0xa1, 0xbf, 0xd5, 0x6d, 0x21, 0x52, 0x8a, 0x60, 0x94, 0x48, 0xed, 0x87, 0xca, 0x7c, 0x61,
+	0x64, 0xaf, 0x09, 0x8d, 0x71, 0xbe, 0xeb, 0xde, 0x9f, 0x26, 0xd4, 0x07, 0xaf, 0xe9, 0x38, 0x91,
+	0x5c, 0xa0, 0x1f, 0xa1, 0x79, 0x4c, 0x49, 0x28, 0xa7, 0xfb, 0x53, 0x3a, 0xbe, 0x46, 0x1b, 0x4b,
+	0xbf, 0x5d, 0x1b, 0xdd, 0xcd, 0xbf, 0xb3, 0xf5, 0xc7, 0xff, 0x6f, 0xfe, 0x32, 0x3b, 0xce, 0x53,
+	0xfd, 0x30, 0xdc, 0x7c, 0xd6, 0x9d, 0x91, 0xf1, 0x34, 0x60, 0xb4, 0x3b, 0xd5, 0x4a, 0x63, 0xa5,
+	0xf4, 0xd2, 0xd8, 0xf9, 0xd4, 0x40, 0xe7, 0x50, 0x1e, 0x86, 0x84, 0xbd, 0x9f, 0xec, 0x33, 0x2d,
+	0xfb, 0xc4, 0x79, 0xb8, 0x2c, 0x1b, 0x85, 0x84, 0xa5, 0x7a, 0x43, 0xa8, 0xf4, 0xa3, 0x28, 0x9c,
+	0xbf, 0x9f, 0x60, 0x47, 0x0b, 0xda, 0xce, 0xa3, 0x65, 0x41, 0xa2, 0x34, 0xb4, 0x62, 0xef, 0x3f,
+	0x03, 0xd6, 0x31, 0x4d, 0xad, 0x3d, 0xe6, 0xb1, 0x44, 0xdf, 0x43, 0xe3, 0x88, 0xca, 0xbd, 0x80,
+	0x11, 0x31, 0x47, 0x9b, 0x6e, 0xfa, 0xc6, 0xb9, 0xf9, 0x1b, 0xe7, 0x0e, 0xd4, 0x1b, 0x67, 0x3f,
+	0x50, 0xab, 0x2d, 0xbd, 0x0d, 0xf9, 0x72, 0xc8, 0xca, 0x97, 0x13, 0x99, 0x6e, 0xdc, 0x1d, 0xa5,
+	0x72, 0x23, 0xad, 0x7d, 0xc6, 0xbd, 0x24, 0xa4, 0x77, 0x8f, 0xb0, 0x52, 0xb4, 0xab, 0x45, 0x3f,
+	0x46, 0xcf, 0xef, 0x8a, 0xce, 0xb4, 0x4e, 0xdc, 0xfd, 0x2d, 0x7f, 0x48, 0x5f, 0xed, 0xec, 0xfc,
+	0xde, 0xfb, 0x01, 0x6a, 0x3a, 0xa5, 0x54, 0x28, 0xb7, 0xf4, 0xe7, 0x3d, 0x6e, 0xdd, 0x0e, 0xf3,
+	0xfd, 0x6e, 0xf9, 0x8a, 0xa7, 0xdd, 0x1a, 0x55, 0xb5, 0x0f, 0xbb, 0x6f, 0x07, 0x00, 0x53, 0xe3,
+	0xe1, 0x59, 0x29, 0x08, 0x00, 0x00,
 }
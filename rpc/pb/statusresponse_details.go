@@ -14,11 +14,45 @@
 
 package pb
 
-import "github.com/asteris-llc/converge/graph/node"
+import (
+	"github.com/asteris-llc/converge/graph/node"
+	"github.com/asteris-llc/converge/resource"
+)
 
 // MetaFromNode transfers metadata from a node to a Meta object
 func MetaFromNode(meta *node.Node) *StatusResponse_Meta {
 	return &StatusResponse_Meta{
-		Id: meta.ID,
+		Id:       meta.ID,
+		Position: meta.Position,
+		Metadata: meta.Metadata,
+	}
+}
+
+// ToNode makes a node for the response holding the value, with the metadata
+// transferred by MetaFromNode
+func (m *StatusResponse) ToNode(value interface{}) *node.Node {
+	out := node.New(m.Id, value)
+	if m.Meta != nil {
+		out.Position = m.Meta.Position
+		out.Metadata = m.Meta.Metadata
+	}
+	return out
+}
+
+// WarningFromResource transfers a resource warning to a Warning object
+func WarningFromResource(warning resource.Warning) *StatusResponse_Warning {
+	return &StatusResponse_Warning{
+		Kind:    string(warning.Kind),
+		Field:   warning.Field,
+		Message: warning.Message,
+	}
+}
+
+// ToResource returns the resource warning the Warning was made from
+func (w *StatusResponse_Warning) ToResource() resource.Warning {
+	return resource.Warning{
+		Kind:    resource.WarningKind(w.Kind),
+		Field:   w.Field,
+		Message: w.Message,
 	}
 }
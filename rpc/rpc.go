@@ -15,15 +15,80 @@
 package rpc
 
 import (
+	"context"
 	"crypto/tls"
+	"io"
+	"time"
 
+	"github.com/asteris-llc/converge/backup"
+	"github.com/asteris-llc/converge/helpers/lock"
+	"github.com/asteris-llc/converge/helpers/ratelimit"
+	"github.com/asteris-llc/converge/load"
+	"github.com/asteris-llc/converge/policy"
+	"github.com/asteris-llc/converge/render"
+	"github.com/asteris-llc/converge/render/preprocessor"
+	"github.com/asteris-llc/converge/report"
 	"github.com/asteris-llc/converge/rpc/pb"
+	"github.com/asteris-llc/converge/telemetry"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
 )
 
+// ExecutorOpts configures the checks and locking around plans and applies
+type ExecutorOpts struct {
+	// Policy is checked against the plan of every module before it is
+	// applied. It may be nil.
+	Policy *policy.Policy
+
+	// MaxChanges stops an apply that would change more than this many
+	// resources, or 0 for no limit
+	MaxChanges int
+
+	// StrictWarnings stops a plan or apply if any resource has warnings, such
+	// as a deprecated field being set
+	StrictWarnings bool
+
+	// LockPath is locked for the duration of every plan and apply, so that
+	// concurrent runs don't change the host at the same time. If empty, no
+	// lock is taken.
+	LockPath string
+
+	// LockTimeout is how long to wait for another run to release the lock
+	LockTimeout time.Duration
+
+	// ClusterLock is held for the duration of every apply, so that only a
+	// limited number of hosts in a cluster apply at the same time. If nil, no
+	// cluster lock is taken. It is waited on for LockTimeout.
+	ClusterLock *lock.Semaphore
+
+	// Backups receives the files managed by resources before they are
+	// applied. If nil, nothing is backed up.
+	Backups *backup.Store
+
+	// Traces receives a trace of every plan and apply, with a span for each
+	// node. If nil, no traces are exported.
+	Traces *telemetry.Exporter
+
+	// Reports receives a summary of every apply, with the result of each
+	// node and facts about the host. If nil, no reports are sent.
+	Reports *report.Reporter
+
+	// RefTraces receives how each lookup that doesn't resolve was searched
+	// for, when loading, checking or applying a module. If nil, lookups aren't
+	// traced.
+	RefTraces io.Writer
+
+	// Overrides receives every field set by an override file when loading a
+	// module. If nil, overrides aren't reported.
+	Overrides io.Writer
+
+	// RateLimits limit how often resources call the APIs they're backed by,
+	// like the docker daemon. They are shared by every server in the process.
+	RateLimits ratelimit.Limits
+}
+
 // New registers all servers and handlers for the RPC server
-func New(token string, secure *tls.Config, resourceRoot string, enableBinaryDownload bool) (*grpc.Server, error) {
+func New(token string, secure *tls.Config, resourceRoot string, enableBinaryDownload bool, executorOpts ExecutorOpts) (*grpc.Server, error) {
 	var opts []grpc.ServerOption
 	if secure != nil {
 		opts = append(opts, grpc.Creds(credentials.NewTLS(secure)))
@@ -37,8 +102,29 @@ func New(token string, secure *tls.Config, resourceRoot string, enableBinaryDown
 	}
 	auth := &authorizer{JWTToken: jwt}
 
-	pb.RegisterExecutorServer(server, &executor{auth: auth})
-	pb.RegisterGrapherServer(server, &grapher{auth: auth})
+	// resources are prepared without a context, so they use the limits set for
+	// the whole process
+	ratelimit.Configure(executorOpts.RateLimits)
+
+	pb.RegisterExecutorServer(
+		server,
+		&executor{
+			auth:           auth,
+			snapshots:      render.DefaultSnapshotDir,
+			policy:         executorOpts.Policy,
+			maxChanges:     executorOpts.MaxChanges,
+			strictWarnings: executorOpts.StrictWarnings,
+			lockPath:       executorOpts.LockPath,
+			lockTimeout:    executorOpts.LockTimeout,
+			clusterLock:    executorOpts.ClusterLock,
+			backups:        executorOpts.Backups,
+			traces:         executorOpts.Traces,
+			reports:        executorOpts.Reports,
+			refTraces:      executorOpts.RefTraces,
+			overrides:      executorOpts.Overrides,
+		},
+	)
+	pb.RegisterGrapherServer(server, &grapher{auth: auth, refTraces: executorOpts.RefTraces, overrides: executorOpts.Overrides})
 	pb.RegisterResourceHostServer(
 		server,
 		&resourceHost{
@@ -50,3 +136,21 @@ func New(token string, secure *tls.Config, resourceRoot string, enableBinaryDown
 
 	return server, nil
 }
+
+// withRefTraces returns a context that traces lookups which don't resolve to
+// w, or the context unchanged if w is nil
+func withRefTraces(ctx context.Context, w io.Writer) context.Context {
+	if w == nil {
+		return ctx
+	}
+	return preprocessor.WithTraceWriter(ctx, w)
+}
+
+// withOverrides returns a context that reports the fields set by override
+// files to w, or the context unchanged if w is nil
+func withOverrides(ctx context.Context, w io.Writer) context.Context {
+	if w == nil {
+		return ctx
+	}
+	return load.WithOverrideWriter(ctx, w)
+}
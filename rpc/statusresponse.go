@@ -17,6 +17,7 @@ package rpc
 import (
 	"github.com/asteris-llc/converge/graph/node"
 	"github.com/asteris-llc/converge/prettyprinters/human"
+	"github.com/asteris-llc/converge/resource"
 	"github.com/asteris-llc/converge/rpc/pb"
 )
 
@@ -31,9 +32,15 @@ func statusResponseFromPrintable(meta *node.Node, p human.Printable, stage pb.St
 			Messages:   p.Messages(),
 			Changes:    map[string]*pb.DiffResponse{},
 			HasChanges: p.HasChanges(),
+
+			RebootRequired: resource.PendingReboots(p),
 		},
 	}
 
+	for _, warning := range resource.Warnings(p) {
+		resp.Details.Warnings = append(resp.Details.Warnings, pb.WarningFromResource(warning))
+	}
+
 	if err := p.Error(); err != nil {
 		resp.Details.Error = err.Error()
 	}
@@ -0,0 +1,7 @@
+# make vim the default editor, only works with update-alternatives
+os.alternatives "editor" {
+  name     = "editor"
+  path     = "/usr/bin/vim.basic"
+  link     = "/usr/bin/editor"
+  priority = 50
+}
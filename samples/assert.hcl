@@ -0,0 +1,20 @@
+# refuse to wipe data in production
+param "environment" {
+  default = "staging"
+}
+
+param "wipe" {
+  default = false
+}
+
+assert "safe wipe" {
+  condition = "{{not (and (eq (param `environment`) `prod`) (param `wipe`))}}"
+  message   = "refusing to wipe data in prod"
+}
+
+task "wipe" {
+  check = "test ! -d /tmp/converge-scratch"
+  apply = "rm -rf /tmp/converge-scratch"
+
+  depends = ["assert.safe wipe"]
+}
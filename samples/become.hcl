@@ -0,0 +1,10 @@
+# run commands through sudo
+param "user" {
+  default = "nobody"
+}
+
+task "whoami" {
+  check       = "test \"$(whoami)\" = \"{{param `user`}}\""
+  apply       = "whoami"
+  become_user = "{{param `user`}}"
+}
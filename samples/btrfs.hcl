@@ -0,0 +1,16 @@
+# a compressed subvolume with a week of daily snapshots, only works on linux
+btrfs.subvolume "data" {
+  path = "/srv/data"
+
+  properties {
+    compression = "zstd"
+  }
+}
+
+btrfs.snapshot "data" {
+  source   = "/srv/data"
+  dir      = "/srv/.snapshots"
+  interval = "24h"
+  keep     = 7
+  depends  = ["btrfs.subvolume.data"]
+}
@@ -0,0 +1,18 @@
+# trust an internal certificate authority
+tls.ca_trust "internal" {
+  name = "example-internal-ca"
+
+  certificate = <<EOF
+-----BEGIN CERTIFICATE-----
+MIIBkTCCATegAwIBAgIUJ8GZGPEHQ13LkZQFcxrxrYdZUqQwCgYIKoZIzj0EAwIw
+HjEcMBoGA1UEAwwTRXhhbXBsZSBJbnRlcm5hbCBDQTAeFw0yNjEwMTQwODM2NDVa
+Fw0zNjEwMTEwODM2NDVaMB4xHDAaBgNVBAMME0V4YW1wbGUgSW50ZXJuYWwgQ0Ew
+WTATBgcqhkjOPQIBBggqhkjOPQMBBwNCAATRez5JYKq8xDNMsNm96Pin0HCzF16b
++OwgPcP/+ke9rnJMFklEZoOpwn0yqfmQJM9pX+GdaWBXapWF7lnte0aFo1MwUTAd
+BgNVHQ4EFgQUPlgEJnrB+TeEsYisDH2aueiIS2cwHwYDVR0jBBgwFoAUPlgEJnrB
++TeEsYisDH2aueiIS2cwDwYDVR0TAQH/BAUwAwEB/zAKBggqhkjOPQQDAgNIADBF
+AiEA6KtXkuD/bW7MKV63hjCiadxUYvFEq7PdzJR/GIeyzMMCIGyiFNJP5Wr8g/Jd
+hk8+/8EzdhyK7a1AvqKbPlyJg8qP
+-----END CERTIFICATE-----
+EOF
+}
@@ -0,0 +1,5 @@
+# install a pinned version of git with chocolatey, only works on windows
+package.choco "git" {
+  name    = "git"
+  version = "2.43.0"
+}
@@ -0,0 +1,10 @@
+# nameservers for the office, through systemd-resolved where it is in use, only works on linux
+network.dns "office" {
+  nameservers = ["10.0.0.2", "10.0.0.3"]
+  search      = ["example.com"]
+  options     = ["ndots:2"]
+
+  resolved {
+    DNSSEC = "allow-downgrade"
+  }
+}
@@ -0,0 +1,12 @@
+# send container logs to journald and pull through a local mirror, only works on linux
+docker.daemon "config" {
+  log_driver       = "journald"
+  storage_driver   = "overlay2"
+  registry_mirrors = ["https://mirror.example.com"]
+  https_proxy      = "http://proxy.example.com:3128"
+  no_proxy         = "localhost,127.0.0.1"
+
+  log_opts {
+    tag = "{{`{{.Name}}`}}"
+  }
+}
@@ -0,0 +1,13 @@
+# log into a private registry and pull an image from it
+docker.registry_auth "internal" {
+  registry          = "registry.example.com"
+  username          = "deploy"
+  password_file     = "/etc/converge/registry-token"
+  credential_helper = "secretservice"
+}
+
+docker.image "app" {
+  name    = "registry.example.com/team/app"
+  tag     = "1.4.2"
+  depends = ["docker.registry_auth.internal"]
+}
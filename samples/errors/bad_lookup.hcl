@@ -0,0 +1,8 @@
+task.query "name" {
+  query = "echo hello"
+}
+
+task "bad_lookup" {
+  check = "echo {{lookup `task.query.nam.status.stdout`}}"
+  apply = "true"
+}
@@ -0,0 +1,10 @@
+task "a" {
+  check = "echo {{lookup `task.b.status`}}"
+  apply = "true"
+}
+
+task "b" {
+  check   = "true"
+  apply   = "true"
+  depends = ["task.a"]
+}
@@ -0,0 +1,12 @@
+# give a directory to administrators and let users read it, only works on windows
+file.acl "logs" {
+  destination = "C:\\app\\logs"
+  owner       = "BUILTIN\\Administrators"
+  inherit     = false
+
+  grant = [
+    "SYSTEM:(OI)(CI)(F)",
+    "BUILTIN\\Administrators:(OI)(CI)(F)",
+    "BUILTIN\\Users:(OI)(CI)(RX)",
+  ]
+}
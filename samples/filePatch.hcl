@@ -0,0 +1,19 @@
+# change a few lines of an existing file by applying a unified diff to it
+task "config" {
+  check = "test -f patched.conf"
+  apply = "printf 'listen = 80\\nworkers = 4\\nlog = info\\n' > patched.conf"
+}
+
+file.content "config" {
+  destination = "patched.conf"
+
+  patch = <<EOF
+@@ -1,3 +1,3 @@
+ listen = 80
+-workers = 4
++workers = 16
+ log = info
+EOF
+
+  depends = ["task.config"]
+}
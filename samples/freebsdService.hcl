@@ -0,0 +1,23 @@
+# install nginx with the package manager for the platform, and on freebsd run
+# it at boot
+switch "nginx" {
+  case "eq `freebsd` `{{platform.Family}}`" "freebsd" {
+    package.pkg "nginx" {
+      name = "www/nginx"
+    }
+
+    freebsd.service "nginx" {
+      name    = "nginx"
+      enabled = true
+      flags   = ""
+      state   = "running"
+      depends = ["package.pkg.nginx"]
+    }
+  }
+
+  case "eq `rhel` `{{platform.Family}}`" "rhel" {
+    package.rpm "nginx" {
+      name = "nginx"
+    }
+  }
+}
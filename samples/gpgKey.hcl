@@ -0,0 +1,12 @@
+# trust the docker apt repository key, only works on debian and ubuntu
+os.gpg_key "docker" {
+  name        = "docker"
+  keyring     = "apt"
+  url         = "https://download.docker.com/linux/ubuntu/gpg"
+  fingerprint = "9DC8 5822 9FC7 DD38 854A  E2D8 8D81 803C 0EBF CD88"
+}
+
+task "docker-source" {
+  check = "test -f /etc/apt/sources.list.d/docker.list"
+  apply = "echo 'deb [signed-by={{lookup `os.gpg_key.docker.Path`}}] https://download.docker.com/linux/ubuntu xenial stable' > /etc/apt/sources.list.d/docker.list"
+}
@@ -1,4 +1,5 @@
 # create a group, only works on linux
 user.group "group" {
-  name = "test"
+  name    = "test"
+  members = ["root"]
 }
@@ -0,0 +1,11 @@
+# a backend section, checked with haproxy -c before haproxy is reloaded, only works on linux
+haproxy.backend "app" {
+  name = "app"
+
+  content = <<EOF
+backend app
+  balance roundrobin
+  server app1 10.0.0.5:8080 check
+  server app2 10.0.0.6:8080 check
+EOF
+}
@@ -0,0 +1,10 @@
+# run commands before and after the resources in a module are changed
+hooks "maintenance" {
+  before = ["touch maintenance"]
+  after  = ["rm -f maintenance", "echo warmed > cache.txt"]
+}
+
+task "deploy" {
+  check = "test -f deployed"
+  apply = "test -f maintenance && touch deployed"
+}
@@ -0,0 +1,6 @@
+# accept drift in a file that is rewritten by another tool
+file.content "seed" {
+  destination    = "ignored.txt"
+  content        = "initial contents\n"
+  ignore_changes = ["ignored.txt"]
+}
@@ -0,0 +1,22 @@
+# every lookup in a template adds a dependency, even inside a branch that isn't
+# taken. ignore_depends leaves some of them out, here to avoid a cycle.
+param "verbose" {
+  default = "false"
+}
+
+task "install" {
+  check   = "test -f install.txt"
+  apply   = "echo installed > install.txt"
+  depends = ["task.report"]
+}
+
+task "report" {
+  check          = "echo {{if eq (param `verbose`) `true`}}{{lookup `task.install.checkstmt`}}{{end}}"
+  apply          = "true"
+  ignore_depends = ["task.install"]
+}
+
+task "summary" {
+  check = "echo {{if eq (param `verbose`) `true`}}{{lookup `task.report.checkstmt`}}{{end}}"
+  apply = "true"
+}
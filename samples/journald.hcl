@@ -0,0 +1,8 @@
+# keep the journal across reboots and raise the rate limit, only works on linux
+log.journald "persistent" {
+  settings {
+    "Storage"              = "persistent"
+    "RateLimitIntervalSec" = "30s"
+    "RateLimitBurst"       = "10000"
+  }
+}
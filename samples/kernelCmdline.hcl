@@ -0,0 +1,5 @@
+# enable auditing from boot and drop the graphical boot, only works with grub
+os.kernel_cmdline "audit" {
+  present = ["audit=1", "audit_backlog_limit=8192"]
+  absent  = ["rhgb"]
+}
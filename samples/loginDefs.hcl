@@ -0,0 +1,8 @@
+# set password aging defaults for new accounts, only works on linux
+os.logindefs "aging" {
+  settings {
+    "PASS_MAX_DAYS" = "90"
+    "PASS_MIN_DAYS" = "7"
+    "UID_MIN"       = "1000"
+  }
+}
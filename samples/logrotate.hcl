@@ -0,0 +1,11 @@
+# rotate application logs daily and keep two weeks, only works on linux
+os.logrotate "app" {
+  name       = "app"
+  paths      = ["/var/log/app/*.log"]
+  frequency  = "daily"
+  rotate     = 14
+  compress   = true
+  missingok  = true
+  notifempty = true
+  postrotate = "systemctl reload app"
+}
@@ -0,0 +1,6 @@
+# encrypt a logical volume with a key file, only works on linux
+storage.luks "secure" {
+  device   = "/dev/vg0/secure"
+  name     = "secure"
+  key_file = "/etc/keys/secure.key"
+}
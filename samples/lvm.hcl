@@ -0,0 +1,20 @@
+# a volume group on two disks, moving data off any others, only works on linux
+lvm.volumegroup "data" {
+  name    = "data"
+  devices = ["/dev/sdb", "/dev/sdc"]
+  remove  = true
+}
+
+# snapshot the home volume before migrating it, keeping the snapshot for a week
+lvm.snapshot "before-migration" {
+  origin        = "data/home"
+  percent       = 20
+  cleanup_after = 7
+  depends       = ["lvm.volumegroup.data"]
+}
+
+task "migrate" {
+  check   = "test -f /home/.migrated"
+  apply   = "/usr/local/bin/migrate-home && touch /home/.migrated"
+  depends = ["lvm.snapshot.before-migration"]
+}
@@ -0,0 +1,10 @@
+# annotate nodes with metadata for tools that read the graph
+file.content "motd" {
+  destination = "motd.txt"
+  content     = "welcome"
+
+  metadata {
+    owner   = "platform"
+    runbook = "https://example.com/runbooks/motd"
+  }
+}
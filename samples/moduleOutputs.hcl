@@ -0,0 +1,7 @@
+# look up the outputs of a module from outside it
+module "outputsModule.hcl" "config" {}
+
+file.content "summary" {
+  destination = "outputs-summary.txt"
+  content     = "config is in {{lookup `module.config.outputs.path`}}"
+}
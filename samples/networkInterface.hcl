@@ -0,0 +1,13 @@
+# a static address, rolled back if the controller can't be reached, only works on linux
+network.interface "eth0" {
+  name       = "eth0"
+  addresses  = ["10.0.0.5/24"]
+  gateway    = "10.0.0.1"
+  dns        = ["10.0.0.2"]
+  search     = ["example.com"]
+  check_host = "10.0.0.100"
+
+  routes {
+    "10.1.0.0/16" = "10.0.0.254"
+  }
+}
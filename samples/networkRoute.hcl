@@ -0,0 +1,7 @@
+# a static route for the office network, only works on linux
+network.route "office" {
+  destination = "10.1.0.0/16"
+  gateway     = "10.0.0.254"
+  device      = "eth0"
+  metric      = 100
+}
@@ -0,0 +1,6 @@
+# look up traffic from the web network in its own table, only works on linux
+network.rule "web" {
+  priority = 100
+  from     = "10.0.1.0/24"
+  table    = "web"
+}
@@ -0,0 +1,14 @@
+# export home directories and mount them on a client, only works on linux
+nfs.export "home" {
+  path = "/srv/home"
+
+  clients {
+    "10.0.0.0/24" = "rw,sync,no_subtree_check"
+  }
+}
+
+nfs.mount "home" {
+  source  = "fileserver:/srv/home"
+  path    = "/mnt/home"
+  options = ["vers=4.2", "soft", "timeo=100"]
+}
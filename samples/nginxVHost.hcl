@@ -0,0 +1,15 @@
+# a server block, checked with nginx -t before nginx is reloaded, only works on linux
+nginx.vhost "app" {
+  name = "app"
+
+  content = <<EOF
+server {
+  listen 80;
+  server_name app.example.com;
+
+  location / {
+    proxy_pass http://127.0.0.1:8080;
+  }
+}
+EOF
+}
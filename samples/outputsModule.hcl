@@ -0,0 +1,9 @@
+# a module that exports where it wrote its file, used by moduleOutputs.hcl
+file.content "config" {
+  destination = "outputs-config.txt"
+  content     = "port = 5432"
+}
+
+output "path" {
+  value = "{{lookup `file.content.config.destination`}}"
+}
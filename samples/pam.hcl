@@ -0,0 +1,9 @@
+# require strong passwords before pam_unix sets them, only works on linux
+os.pam "pwquality" {
+  service   = "common-password"
+  type      = "password"
+  control   = "requisite"
+  module    = "pam_pwquality.so"
+  arguments = ["retry=3", "minlen=14"]
+  before    = "pam_unix.so"
+}
@@ -0,0 +1,4 @@
+# install nginx with pkg, only works on freebsd
+package.pkg "nginx" {
+  name = "www/nginx"
+}
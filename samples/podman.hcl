@@ -0,0 +1,15 @@
+# run a container with podman instead of the docker daemon
+docker.image "nginx" {
+  name    = "docker.io/library/nginx"
+  tag     = "latest"
+  runtime = "podman"
+}
+
+docker.container "nginx" {
+  name    = "nginx"
+  image   = "docker.io/library/nginx:latest"
+  runtime = "podman"
+  ports   = ["8080:80"]
+
+  depends = ["docker.image.nginx"]
+}
@@ -0,0 +1,6 @@
+# send traffic through the office proxy, only works on linux
+os.proxy "office" {
+  http     = "http://proxy.example.com:3128"
+  https    = "http://proxy.example.com:3128"
+  no_proxy = ["localhost", "127.0.0.1", ".example.com"]
+}
@@ -0,0 +1,10 @@
+# parse the output of a query as JSON and look up fields inside it, only works on linux
+task.query "disks" {
+  query  = "lsblk --json --nodeps --output name,size"
+  format = "json"
+}
+
+file.content "first disk" {
+  destination = "first-disk.txt"
+  content     = "{{lookup `task.query.disks.result.blockdevices.0.name`}}"
+}
@@ -0,0 +1,16 @@
+# hold back dependents until a started service answers
+task "serve" {
+  check = "test -f /tmp/converge-ready/index.html"
+  apply = "mkdir -p /tmp/converge-ready && echo ok > /tmp/converge-ready/index.html && (cd /tmp/converge-ready && nohup python3 -m http.server 8931 >/tmp/converge-ready.log 2>&1 &)"
+
+  ready_when {
+    http    = "http://127.0.0.1:8931/index.html"
+    timeout = "30s"
+  }
+}
+
+task "fetch" {
+  check   = "exit 1"
+  apply   = "curl -fsS http://127.0.0.1:8931/index.html"
+  depends = ["task.serve"]
+}
@@ -0,0 +1,10 @@
+# reboot when the kernel parameters change, only works on linux
+os.kernel_cmdline "audit" {
+  present = ["audit=1"]
+}
+
+os.reboot "kernel" {
+  delay   = 2
+  resume  = "/usr/bin/converge apply --local /etc/converge/site.hcl"
+  depends = ["os.kernel_cmdline.audit"]
+}
@@ -0,0 +1,8 @@
+# forward authentication logs to a central server, only works on linux
+log.rsyslog_forward "central" {
+  name     = "central"
+  target   = "logs.example.com"
+  protocol = "tcp"
+  selector = "auth,authpriv.*"
+  queue    = true
+}
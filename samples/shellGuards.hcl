@@ -0,0 +1,15 @@
+/* These tasks use creates and removes instead of check scripts. Each only
+runs while its file is in the wrong state. */
+
+task "unpack" {
+  dir     = "/tmp"
+  creates = "converge-unpacked"
+  apply   = "mkdir converge-unpacked"
+}
+
+task "clean" {
+  removes = "/tmp/converge-unpacked/.cache"
+  apply   = "rm -rf /tmp/converge-unpacked/.cache"
+
+  depends = ["task.unpack"]
+}
@@ -0,0 +1,15 @@
+/* This task demonstrates passing content on stdin and running with a umask.
+The exit code and output of the task are available to later resources. */
+
+task "private-notes" {
+  umask = "0077"
+  stdin = "written from stdin\n"
+
+  check = "test -f /tmp/converge-notes"
+  apply = "cat > /tmp/converge-notes"
+}
+
+task "notes-status" {
+  check = "echo {{lookup `task.private-notes.exitcode`}}"
+  apply = "true"
+}
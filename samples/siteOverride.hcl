@@ -0,0 +1,2 @@
+# load a shared module along with the override file next to it
+module "siteOverride/base.hcl" "motd" {}
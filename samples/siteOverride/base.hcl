@@ -0,0 +1,10 @@
+# a shared module. base_override.hcl next to it is loaded after it, and
+# changes the fields it sets.
+param "greeting" {
+  default = "hello"
+}
+
+file.content "motd" {
+  destination = "motd.txt"
+  content     = "{{param `greeting`}} from the shared module"
+}
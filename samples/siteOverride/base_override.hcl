@@ -0,0 +1,4 @@
+# site-specific changes to base.hcl. Only the fields set here are changed.
+file.content "motd" {
+  content = "{{param `greeting`}} from this site"
+}
@@ -0,0 +1,6 @@
+# load every file in the splitModule directory as a single module
+module "splitModule" "split" {
+  params = {
+    filename = "split-module.txt"
+  }
+}
@@ -0,0 +1,9 @@
+# params are split into their own file. Every .hcl file in the directory is
+# loaded into the same module, in name order.
+param "message" {
+  default = "Hello from a split module!"
+}
+
+param "filename" {
+  default = "split.txt"
+}
@@ -0,0 +1,9 @@
+file.content "message" {
+  destination = "{{param `filename`}}"
+  content     = "{{param `message`}}"
+}
+
+task "show" {
+  check = "cat {{lookup `file.content.message.destination`}}"
+  apply = "true"
+}
@@ -0,0 +1,14 @@
+# harden sshd and allow password logins for one user, only works on linux
+ssh.sshd_config "hardening" {
+  options {
+    "PermitRootLogin"        = "no"
+    "PasswordAuthentication" = "no"
+    "Ciphers"                = "chacha20-poly1305@openssh.com,aes256-ctr"
+  }
+
+  match {
+    "User backup" {
+      "PasswordAuthentication" = "yes"
+    }
+  }
+}
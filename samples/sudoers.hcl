@@ -0,0 +1,5 @@
+# allow a group to restart a service without a password, only works on linux
+os.sudoers "deploy" {
+  name    = "deploy"
+  content = "%deploy ALL=(root) NOPASSWD: /bin/systemctl restart app"
+}
@@ -0,0 +1,31 @@
+# install a service and raise its file limit with a drop-in, only works on linux
+systemd.unit_file "app" {
+  name = "app.service"
+
+  sections {
+    "Unit" {
+      "Description" = "example application"
+    }
+
+    "Service" {
+      "ExecStart"   = "/usr/local/bin/app"
+      "Environment" = ["PORT=8080", "LOG_LEVEL=info"]
+      "Restart"     = "always"
+    }
+
+    "Install" {
+      "WantedBy" = "multi-user.target"
+    }
+  }
+}
+
+systemd.unit_file "app-limits" {
+  name   = "{{lookup `systemd.unit_file.app.Name`}}"
+  dropin = "limits"
+
+  sections {
+    "Service" {
+      "LimitNOFILE" = "65536"
+    }
+  }
+}
@@ -0,0 +1,10 @@
+# run the commands of a whole module inside a container
+param "container" {
+  default = "build"
+}
+
+module "basic.hcl" "in-container" {
+  target {
+    docker = "{{param `container`}}"
+  }
+}
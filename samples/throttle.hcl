@@ -0,0 +1,6 @@
+# run an expensive task at most once a day
+task "backup" {
+  check    = "exit 1"
+  apply    = "tar -czf /tmp/converge-backup.tar.gz -C /etc hostname"
+  throttle = "24h"
+}
@@ -1,4 +1,6 @@
 # create a user, only works on linux
 user.user "user" {
   username = "test"
+  shell    = "/bin/bash"
+  expiry   = "2030-01-01"
 }
@@ -0,0 +1,13 @@
+# generate an SSH key pair for a user, only works on linux
+user.user "deploy" {
+  username = "deploy"
+}
+
+user.keypair "deploy" {
+  username = "{{lookup `user.user.deploy.Username`}}"
+}
+
+file.content "deploy-pub" {
+  destination = "/tmp/deploy.pub"
+  content     = "{{lookup `user.keypair.deploy.PublicKey`}}"
+}
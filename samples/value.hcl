@@ -0,0 +1,15 @@
+# generate a password and a node ID once and keep them across runs, only works on linux
+value.random "db-password" {
+  length = 24
+
+  keepers {
+    database = "app"
+  }
+}
+
+value.uuid "node-id" {}
+
+file.content "db credentials" {
+  destination = "/etc/app/db.env"
+  content     = "DB_PASSWORD={{lookup `value.random.db-password.value`}}\nNODE_ID={{lookup `value.uuid.node-id.value`}}\n"
+}
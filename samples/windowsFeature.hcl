@@ -0,0 +1,5 @@
+# install IIS with its management tools, only works on windows server
+windows.feature "iis" {
+  name                     = "Web-Server"
+  include_management_tools = true
+}
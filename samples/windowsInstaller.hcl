@@ -0,0 +1,6 @@
+# install 7-Zip from its MSI unless that version is already installed, only works on windows
+windows.installer "7zip" {
+  source       = "C:\\installers\\7z1900-x64.msi"
+  product_code = "{23170F69-40C1-2702-1900-000001000000}"
+  version      = "19.00.00.0"
+}
@@ -0,0 +1,10 @@
+# run a backup every night and after every boot, only works on windows
+windows.scheduled_task "backup" {
+  name        = "\\Converge\\backup"
+  triggers    = ["daily at 03:00", "boot"]
+  command     = "C:\\backup\\run.exe"
+  arguments   = "--full"
+  description = "nightly backup"
+  highest     = true
+  persistent  = true
+}
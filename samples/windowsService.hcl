@@ -0,0 +1,13 @@
+# keep a service running as its own account and restart it when it fails, only works on windows
+param "password" {}
+
+windows.service "app" {
+  name             = "AppService"
+  start_mode       = "auto"
+  state            = "running"
+  username         = ".\\svc-app"
+  password         = "{{param `password`}}"
+  recovery_actions = ["restart", "restart", "none"]
+  recovery_delay   = "30s"
+  recovery_reset   = "24h"
+}
@@ -0,0 +1,15 @@
+# a tunnel to a site router, with the key replaced every 90 days, only works on linux
+network.wireguard "wg0" {
+  name        = "wg0"
+  addresses   = ["10.9.0.1/24"]
+  listen_port = 51820
+  rotate      = "2160h"
+
+  peers {
+    "xTIBA5rboUvnH4htodjb6e697QjLERt1NAB4mZqp8Dg=" {
+      allowed_ips          = "10.9.0.2/32, 10.8.0.0/16"
+      endpoint             = "192.0.2.10:51820"
+      persistent_keepalive = "25"
+    }
+  }
+}
@@ -0,0 +1,20 @@
+# a mirrored pool with compressed home directories, only works on linux
+zfs.pool "tank" {
+  name  = "tank"
+  vdevs = ["mirror", "/dev/sdb", "/dev/sdc"]
+
+  properties {
+    ashift = "12"
+  }
+}
+
+zfs.dataset "home" {
+  name       = "tank/home"
+  mountpoint = "/home"
+  quota      = "500G"
+  depends    = ["zfs.pool.tank"]
+
+  properties {
+    compression = "lz4"
+  }
+}
@@ -0,0 +1,109 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package selfupdate
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+
+	"github.com/asteris-llc/converge/fetch"
+	"github.com/asteris-llc/converge/helpers/atomicfile"
+	"github.com/asteris-llc/converge/keystore"
+	"github.com/pkg/errors"
+)
+
+// DefaultBaseURL is where releases are downloaded from if no other location is
+// given
+const DefaultBaseURL = "https://github.com/asteris-llc/converge/releases/download"
+
+// SignatureExt is added to the url of a release to get its detached signature
+const SignatureExt = ".asc"
+
+// binaryName is the name of the binary inside a release archive
+const binaryName = "converge"
+
+// URL returns the location of the release archive for the given version and
+// platform, named the same way as install-converge.sh expects
+func URL(base, version, goos, goarch string) string {
+	return fmt.Sprintf("%s/%s/converge_%s_%s_%s.tar.gz", base, version, version, goos, goarch)
+}
+
+// Download fetches the release archive at url and its signature, checks that
+// it was signed by a key in the keystore, and returns the converge binary
+// inside it
+func Download(ctx context.Context, url string, ks *keystore.Keystore) ([]byte, error) {
+	archive, err := fetch.Any(ctx, url)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not fetch %s", url)
+	}
+
+	signature, err := fetch.Any(ctx, url+SignatureExt)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not fetch signature of %s", url)
+	}
+
+	if err := ks.CheckSignature(bytes.NewReader(archive), bytes.NewReader(signature)); err != nil {
+		return nil, errors.Wrapf(err, "could not verify %s", url)
+	}
+
+	binary, err := extract(archive)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not extract %s", url)
+	}
+
+	return binary, nil
+}
+
+// Replace swaps the binary at dest for the given one, keeping its mode. The
+// binary is replaced atomically, so a failed update leaves the old one in
+// place.
+func Replace(dest string, binary []byte) error {
+	stat, err := os.Stat(dest)
+	if err != nil {
+		return err
+	}
+
+	return atomicfile.Write(dest, binary, stat.Mode().Perm())
+}
+
+func extract(archive []byte) ([]byte, error) {
+	gz, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		return nil, err
+	}
+	tr := tar.NewReader(gz)
+
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		if header.Typeflag == tar.TypeReg && path.Base(header.Name) == binaryName {
+			return ioutil.ReadAll(tr)
+		}
+	}
+
+	return nil, fmt.Errorf("no %s binary in archive", binaryName)
+}
@@ -0,0 +1,155 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package selfupdate_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/asteris-llc/converge/keystore"
+	"github.com/asteris-llc/converge/selfupdate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/armor"
+)
+
+func TestURL(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(
+		t,
+		"https://example.com/releases/0.4.0/converge_0.4.0_linux_amd64.tar.gz",
+		selfupdate.URL("https://example.com/releases", "0.4.0", "linux", "amd64"),
+	)
+}
+
+func TestDownload(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "converge-selfupdate")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	signer, err := openpgp.NewEntity("converge", "", "converge@example.com", nil)
+	require.NoError(t, err)
+
+	ks := keystore.New(filepath.Join(dir, "trustedkeys"), "", "")
+	require.NoError(t, os.MkdirAll(ks.LocalPath, 0755))
+	trust(t, ks, signer)
+
+	archive := filepath.Join(dir, "converge.tar.gz")
+	url := "file://" + archive
+
+	t.Run("signed", func(t *testing.T) {
+		release(t, archive, signer, "new binary")
+
+		binary, err := selfupdate.Download(context.Background(), url, ks)
+		require.NoError(t, err)
+		assert.Equal(t, "new binary", string(binary))
+	})
+
+	t.Run("untrusted", func(t *testing.T) {
+		other, err := openpgp.NewEntity("other", "", "other@example.com", nil)
+		require.NoError(t, err)
+
+		release(t, archive, other, "new binary")
+
+		_, err = selfupdate.Download(context.Background(), url, ks)
+		assert.Error(t, err)
+	})
+
+	t.Run("changed", func(t *testing.T) {
+		release(t, archive, signer, "new binary")
+		require.NoError(t, ioutil.WriteFile(archive, tarball(t, "changed binary"), 0644))
+
+		_, err := selfupdate.Download(context.Background(), url, ks)
+		assert.Error(t, err)
+	})
+}
+
+func TestReplace(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "converge-selfupdate")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	dest := filepath.Join(dir, "converge")
+	require.NoError(t, ioutil.WriteFile(dest, []byte("old binary"), 0755))
+
+	require.NoError(t, selfupdate.Replace(dest, []byte("new binary")))
+
+	content, err := ioutil.ReadFile(dest)
+	require.NoError(t, err)
+	assert.Equal(t, "new binary", string(content))
+
+	stat, err := os.Stat(dest)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0755), stat.Mode().Perm())
+}
+
+// trust stores the public key of the entity in the keystore
+func trust(t *testing.T, ks *keystore.Keystore, entity *openpgp.Entity) {
+	// the identities of a new entity are only signed when its private key is
+	// serialized, and the public key can't be serialized without them
+	require.NoError(t, entity.SerializePrivate(ioutil.Discard, nil))
+
+	var buf bytes.Buffer
+	w, err := armor.Encode(&buf, openpgp.PublicKeyType, nil)
+	require.NoError(t, err)
+	require.NoError(t, entity.Serialize(w))
+	require.NoError(t, w.Close())
+
+	name := filepath.Join(ks.LocalPath, fmt.Sprintf("%x", entity.PrimaryKey.Fingerprint))
+	require.NoError(t, ioutil.WriteFile(name, buf.Bytes(), 0644))
+}
+
+// release writes an archive with the given binary to path, signed by the
+// entity
+func release(t *testing.T, path string, signer *openpgp.Entity, binary string) {
+	archive := tarball(t, binary)
+	require.NoError(t, ioutil.WriteFile(path, archive, 0644))
+
+	var sig bytes.Buffer
+	require.NoError(t, openpgp.ArmoredDetachSign(&sig, signer, bytes.NewReader(archive), nil))
+	require.NoError(t, ioutil.WriteFile(path+selfupdate.SignatureExt, sig.Bytes(), 0644))
+}
+
+func tarball(t *testing.T, binary string) []byte {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+
+	require.NoError(t, tw.WriteHeader(&tar.Header{
+		Name:     "converge",
+		Mode:     0755,
+		Size:     int64(len(binary)),
+		Typeflag: tar.TypeReg,
+	}))
+	_, err := tw.Write([]byte(binary))
+	require.NoError(t, err)
+
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+	return buf.Bytes()
+}
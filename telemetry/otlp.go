@@ -0,0 +1,217 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package telemetry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ServiceName is the service.name that traces are reported under
+const ServiceName = "converge"
+
+// DefaultTimeout is how long an export may take before it is given up
+const DefaultTimeout = 10 * time.Second
+
+// Exporter sends traces to an OpenTelemetry collector with OTLP over HTTP,
+// encoded as JSON
+type Exporter struct {
+	// Endpoint is the base URL of the collector, like http://localhost:4318.
+	// Traces are sent to /v1/traces under it.
+	Endpoint string
+
+	// Headers are added to every request, such as for authentication
+	Headers map[string]string
+
+	Timeout time.Duration
+	Client  *http.Client
+}
+
+// Export sends the spans of a trace to the collector
+func (e *Exporter) Export(ctx context.Context, spans []*Span) error {
+	body, err := json.Marshal(encode(spans))
+	if err != nil {
+		return err
+	}
+
+	timeout := e.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequest("POST", strings.TrimRight(e.Endpoint, "/")+"/v1/traces", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range e.Headers {
+		req.Header.Set(key, value)
+	}
+
+	client := e.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "could not send trace")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		content, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("collector returned %s: %s", resp.Status, strings.TrimSpace(string(content)))
+	}
+
+	return nil
+}
+
+// the OTLP JSON encoding of a trace request
+type (
+	otlpRequest struct {
+		ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+	}
+
+	otlpResourceSpans struct {
+		Resource   otlpResource     `json:"resource"`
+		ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+	}
+
+	otlpResource struct {
+		Attributes []otlpAttribute `json:"attributes"`
+	}
+
+	otlpScopeSpans struct {
+		Scope otlpScope  `json:"scope"`
+		Spans []otlpSpan `json:"spans"`
+	}
+
+	otlpScope struct {
+		Name string `json:"name"`
+	}
+
+	otlpSpan struct {
+		TraceID           string          `json:"traceId"`
+		SpanID            string          `json:"spanId"`
+		ParentSpanID      string          `json:"parentSpanId,omitempty"`
+		Name              string          `json:"name"`
+		Kind              int             `json:"kind"`
+		StartTimeUnixNano string          `json:"startTimeUnixNano"`
+		EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+		Attributes        []otlpAttribute `json:"attributes,omitempty"`
+		Status            otlpStatus      `json:"status"`
+	}
+
+	otlpStatus struct {
+		Code    int    `json:"code"`
+		Message string `json:"message,omitempty"`
+	}
+
+	otlpAttribute struct {
+		Key   string    `json:"key"`
+		Value otlpValue `json:"value"`
+	}
+
+	otlpValue struct {
+		StringValue *string `json:"stringValue,omitempty"`
+		IntValue    *string `json:"intValue,omitempty"`
+		BoolValue   *bool   `json:"boolValue,omitempty"`
+	}
+)
+
+const (
+	otlpSpanKindInternal = 1
+	otlpStatusOK         = 1
+	otlpStatusError      = 2
+)
+
+func encode(spans []*Span) *otlpRequest {
+	host, _ := os.Hostname()
+
+	scope := otlpScopeSpans{Scope: otlpScope{Name: ServiceName}}
+	for _, span := range spans {
+		out := otlpSpan{
+			TraceID:           span.TraceID,
+			SpanID:            span.SpanID,
+			ParentSpanID:      span.ParentID,
+			Name:              span.Name,
+			Kind:              otlpSpanKindInternal,
+			StartTimeUnixNano: strconv.FormatInt(span.Start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(span.End.UnixNano(), 10),
+			Attributes:        encodeAttributes(span.Attributes),
+			Status:            otlpStatus{Code: otlpStatusOK},
+		}
+		if span.Err != nil {
+			out.Status = otlpStatus{Code: otlpStatusError, Message: span.Err.Error()}
+		}
+		scope.Spans = append(scope.Spans, out)
+	}
+
+	return &otlpRequest{
+		ResourceSpans: []otlpResourceSpans{{
+			Resource: otlpResource{
+				Attributes: encodeAttributes(map[string]interface{}{
+					"service.name": ServiceName,
+					"host.name":    host,
+				}),
+			},
+			ScopeSpans: []otlpScopeSpans{scope},
+		}},
+	}
+}
+
+// encodeAttributes encodes attributes sorted by key. Values other than
+// strings, ints and bools are formatted as strings.
+func encodeAttributes(attributes map[string]interface{}) []otlpAttribute {
+	var keys []string
+	for key := range attributes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var out []otlpAttribute
+	for _, key := range keys {
+		var value otlpValue
+		switch v := attributes[key].(type) {
+		case int:
+			s := strconv.Itoa(v)
+			value.IntValue = &s
+		case bool:
+			value.BoolValue = &v
+		case string:
+			value.StringValue = &v
+		default:
+			s := fmt.Sprint(v)
+			value.StringValue = &s
+		}
+		out = append(out, otlpAttribute{Key: key, Value: value})
+	}
+	return out
+}
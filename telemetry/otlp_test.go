@@ -0,0 +1,113 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package telemetry_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/asteris-llc/converge/telemetry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExporterExport(t *testing.T) {
+	t.Parallel()
+
+	start := time.Unix(1476000000, 0)
+	spans := []*telemetry.Span{
+		{
+			TraceID: "0af7651916cd43dd8448eb211c80319c",
+			SpanID:  "b7ad6b7169203331",
+			Name:    "converge apply",
+			Start:   start,
+			End:     start.Add(time.Second),
+		},
+		{
+			TraceID:    "0af7651916cd43dd8448eb211c80319c",
+			SpanID:     "00f067aa0ba902b7",
+			ParentID:   "b7ad6b7169203331",
+			Name:       "root/task.a",
+			Start:      start,
+			End:        start.Add(time.Millisecond),
+			Attributes: map[string]interface{}{telemetry.AttrNodeType: "task", telemetry.AttrDiffCount: 2},
+			Err:        errors.New("failed"),
+		},
+	}
+
+	t.Run("sends", func(t *testing.T) {
+		var body map[string]interface{}
+		var path, auth, contentType string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			path, auth, contentType = r.URL.Path, r.Header.Get("Authorization"), r.Header.Get("Content-Type")
+			raw, _ := ioutil.ReadAll(r.Body)
+			json.Unmarshal(raw, &body)
+		}))
+		defer server.Close()
+
+		exporter := &telemetry.Exporter{Endpoint: server.URL + "/", Headers: map[string]string{"Authorization": "Bearer x"}}
+		require.NoError(t, exporter.Export(context.Background(), spans))
+
+		assert.Equal(t, "/v1/traces", path)
+		assert.Equal(t, "Bearer x", auth)
+		assert.Equal(t, "application/json", contentType)
+
+		resourceSpans := body["resourceSpans"].([]interface{})[0].(map[string]interface{})
+		assert.Contains(
+			t,
+			resourceSpans["resource"].(map[string]interface{})["attributes"],
+			map[string]interface{}{"key": "service.name", "value": map[string]interface{}{"stringValue": "converge"}},
+		)
+
+		encoded := resourceSpans["scopeSpans"].([]interface{})[0].(map[string]interface{})["spans"].([]interface{})
+		require.Len(t, encoded, 2)
+
+		assert.Equal(
+			t,
+			map[string]interface{}{
+				"traceId":           "0af7651916cd43dd8448eb211c80319c",
+				"spanId":            "00f067aa0ba902b7",
+				"parentSpanId":      "b7ad6b7169203331",
+				"name":              "root/task.a",
+				"kind":              float64(1),
+				"startTimeUnixNano": "1476000000000000000",
+				"endTimeUnixNano":   "1476000000001000000",
+				"attributes": []interface{}{
+					map[string]interface{}{"key": "converge.diff.count", "value": map[string]interface{}{"intValue": "2"}},
+					map[string]interface{}{"key": "converge.node.type", "value": map[string]interface{}{"stringValue": "task"}},
+				},
+				"status": map[string]interface{}{"code": float64(2), "message": "failed"},
+			},
+			encoded[1],
+		)
+		assert.Equal(t, map[string]interface{}{"code": float64(1)}, encoded[0].(map[string]interface{})["status"])
+	})
+
+	t.Run("rejected", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "bad request", http.StatusBadRequest)
+		}))
+		defer server.Close()
+
+		err := (&telemetry.Exporter{Endpoint: server.URL}).Export(context.Background(), spans)
+		assert.EqualError(t, err, "collector returned 400 Bad Request: bad request")
+	})
+}
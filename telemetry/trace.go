@@ -0,0 +1,223 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package telemetry
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/asteris-llc/converge/apply"
+	"github.com/asteris-llc/converge/graph"
+	"github.com/asteris-llc/converge/graph/node"
+	"github.com/asteris-llc/converge/load/registry"
+	"github.com/asteris-llc/converge/resource"
+)
+
+// Attributes of node spans
+const (
+	AttrNodeID    = "converge.node.id"
+	AttrNodeType  = "converge.node.type"
+	AttrStatus    = "converge.status"
+	AttrDiffCount = "converge.diff.count"
+)
+
+// Values of AttrStatus
+const (
+	StatusChanged   = "changed"
+	StatusUnchanged = "unchanged"
+	StatusError     = "error"
+)
+
+// Span is a single timed operation in a trace
+type Span struct {
+	TraceID  string
+	SpanID   string
+	ParentID string
+
+	Name       string
+	Start      time.Time
+	End        time.Time
+	Attributes map[string]interface{}
+
+	// Err is the error the operation failed with, if any
+	Err error
+}
+
+// Trace records a span for a plan or apply and a child span for each node in
+// it
+type Trace struct {
+	lock  sync.Mutex
+	root  *Span
+	nodes map[string]*Span
+	spans []*Span
+}
+
+// Start begins a trace with a root span of the given name
+func Start(name string, attributes map[string]interface{}) *Trace {
+	if attributes == nil {
+		attributes = map[string]interface{}{}
+	}
+
+	return &Trace{
+		root: &Span{
+			TraceID:    newID(16),
+			SpanID:     newID(8),
+			Name:       name,
+			Start:      time.Now(),
+			Attributes: attributes,
+		},
+		nodes: map[string]*Span{},
+	}
+}
+
+// ID is the ID of the trace, in hex
+func (t *Trace) ID() string {
+	return t.root.TraceID
+}
+
+// Notifier wraps a notifier so that a span is recorded around each node it is
+// notified about, and the inner notifier is called as before. The inner
+// notifier may be nil.
+func (t *Trace) Notifier(inner *graph.Notifier) *graph.Notifier {
+	if inner == nil {
+		inner = &graph.Notifier{}
+	}
+
+	return &graph.Notifier{
+		Pre: func(meta *node.Node) error {
+			t.startNode(meta.ID)
+			if inner.Pre != nil {
+				return inner.Pre(meta)
+			}
+			return nil
+		},
+		Post: func(meta *node.Node) error {
+			t.endNode(meta)
+			if inner.Post != nil {
+				return inner.Post(meta)
+			}
+			return nil
+		},
+		Output: inner.Output,
+	}
+}
+
+// End finishes the trace and returns its spans, with the root span first
+func (t *Trace) End(err error) []*Span {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.root.End = time.Now()
+	t.root.Err = err
+
+	return append([]*Span{t.root}, t.spans...)
+}
+
+func (t *Trace) startNode(id string) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.nodes[id] = &Span{
+		TraceID:    t.root.TraceID,
+		SpanID:     newID(8),
+		ParentID:   t.root.SpanID,
+		Name:       id,
+		Start:      time.Now(),
+		Attributes: map[string]interface{}{AttrNodeID: id},
+	}
+}
+
+func (t *Trace) endNode(meta *node.Node) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	span, ok := t.nodes[meta.ID]
+	if !ok {
+		return
+	}
+	delete(t.nodes, meta.ID)
+
+	span.End = time.Now()
+	if kind := nodeType(meta.Value()); kind != "" {
+		span.Attributes[AttrNodeType] = kind
+	}
+
+	if result, ok := meta.Value().(result); ok {
+		changes := result.Changes()
+
+		// the changes an apply made are the ones its plan found
+		if applied, ok := meta.Value().(*apply.Result); ok && applied.Ran {
+			changes = applied.Planned
+		}
+
+		diffs := 0
+		for _, diff := range changes {
+			if diff.Changes() {
+				diffs++
+			}
+		}
+		span.Attributes[AttrDiffCount] = diffs
+
+		switch {
+		case result.Error() != nil:
+			span.Attributes[AttrStatus] = StatusError
+			span.Err = result.Error()
+		case result.HasChanges():
+			span.Attributes[AttrStatus] = StatusChanged
+		default:
+			span.Attributes[AttrStatus] = StatusUnchanged
+		}
+	}
+
+	t.spans = append(t.spans, span)
+}
+
+// result is the part of plan and apply results recorded on spans
+type result interface {
+	Changes() map[string]resource.Diff
+	HasChanges() bool
+	Error() error
+}
+
+// nodeType returns the registered name of the resource in a node, looking
+// through the wrappers of node-level settings
+func nodeType(value interface{}) string {
+	task, ok := resource.ResolveTask(value)
+	if !ok {
+		return ""
+	}
+
+	for {
+		if name, ok := registry.NameForType(task); ok {
+			return name
+		}
+
+		unwrapper, ok := task.(interface {
+			Unwrap() resource.Task
+		})
+		if !ok {
+			return ""
+		}
+		task = unwrapper.Unwrap()
+	}
+}
+
+func newID(size int) string {
+	id := make([]byte, size)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
@@ -0,0 +1,110 @@
+// Copyright © 2016 Asteris, LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package telemetry_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/asteris-llc/converge/apply"
+	"github.com/asteris-llc/converge/graph"
+	"github.com/asteris-llc/converge/graph/node"
+	"github.com/asteris-llc/converge/helpers/faketask"
+	"github.com/asteris-llc/converge/plan"
+	"github.com/asteris-llc/converge/resource"
+	"github.com/asteris-llc/converge/resource/file/content"
+	"github.com/asteris-llc/converge/telemetry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrace(t *testing.T) {
+	t.Parallel()
+
+	trace := telemetry.Start("converge apply", map[string]interface{}{"converge.module": "main.hcl"})
+
+	var notified []string
+	notifier := trace.Notifier(&graph.Notifier{
+		Pre: func(meta *node.Node) error {
+			notified = append(notified, "pre "+meta.ID)
+			return nil
+		},
+		Post: func(meta *node.Node) error {
+			notified = append(notified, "post "+meta.ID)
+			return nil
+		},
+	})
+
+	changed := node.New("root/file.content.a", &plan.Result{Status: &resource.Status{}, Task: &content.Content{}})
+	planned := &resource.Status{Level: resource.StatusWillChange}
+	planned.AddDifference("a.txt", "<file-missing>", "hello", "")
+	require.NoError(t, notifier.Pre(changed))
+	require.NoError(t, notifier.Post(changed.WithValue(&apply.Result{
+		Ran:     true,
+		Status:  &resource.Status{},
+		Task:    &resource.CanaryTask{Wrapped: resource.Wrapped{Task: &content.Content{}}},
+		Plan:    &plan.Result{Status: planned, Task: &content.Content{}},
+		Planned: planned.Diffs(),
+	})))
+
+	failed := node.New("root/task.b", &plan.Result{Status: &resource.Status{}, Task: faketask.Error()})
+	require.NoError(t, notifier.Pre(failed))
+	require.NoError(t, notifier.Post(failed.WithValue(&apply.Result{
+		Status: &resource.Status{Level: resource.StatusFatal},
+		Task:   faketask.Error(),
+		Err:    errors.New("error"),
+	})))
+
+	spans := trace.End(nil)
+	require.Len(t, spans, 3)
+	assert.Equal(t, []string{"pre root/file.content.a", "post root/file.content.a", "pre root/task.b", "post root/task.b"}, notified)
+
+	root := spans[0]
+	assert.Equal(t, "converge apply", root.Name)
+	assert.Equal(t, trace.ID(), root.TraceID)
+	assert.Len(t, root.TraceID, 32)
+	assert.Len(t, root.SpanID, 16)
+	assert.Empty(t, root.ParentID)
+	assert.Equal(t, "main.hcl", root.Attributes["converge.module"])
+
+	for _, span := range spans[1:] {
+		assert.Equal(t, root.TraceID, span.TraceID)
+		assert.Equal(t, root.SpanID, span.ParentID)
+		assert.False(t, span.End.Before(span.Start))
+	}
+
+	assert.Equal(
+		t,
+		map[string]interface{}{
+			telemetry.AttrNodeID:    "root/file.content.a",
+			telemetry.AttrNodeType:  "file.content",
+			telemetry.AttrStatus:    telemetry.StatusChanged,
+			telemetry.AttrDiffCount: 1,
+		},
+		spans[1].Attributes,
+	)
+	assert.NoError(t, spans[1].Err)
+
+	assert.Equal(
+		t,
+		map[string]interface{}{
+			telemetry.AttrNodeID:    "root/task.b",
+			telemetry.AttrStatus:    telemetry.StatusError,
+			telemetry.AttrDiffCount: 0,
+		},
+		spans[2].Attributes,
+	)
+	assert.EqualError(t, spans[2].Err, "error")
+}